@@ -0,0 +1,99 @@
+// Command admission is the KubeAtlas admission webhook. It runs in a
+// workload cluster and rejects, or warns about, new namespaces that lack the
+// labels and annotations KubeAtlas maps to the metadata the organization
+// requires.
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/kubeatlas/kubeatlas/internal/admission"
+	"github.com/kubeatlas/kubeatlas/internal/apiclient"
+	"github.com/kubeatlas/kubeatlas/internal/operator"
+	"go.uber.org/zap"
+)
+
+var (
+	Version   = "dev"
+	GitCommit = "unknown"
+)
+
+func main() {
+	logger, _ := zap.NewProduction()
+	defer logger.Sync()
+	sugar := logger.Sugar()
+
+	sugar.Infow("Starting KubeAtlas admission webhook", "version", Version, "git_commit", GitCommit)
+
+	server := os.Getenv("KUBEATLAS_SERVER")
+	if server == "" {
+		sugar.Fatal("KUBEATLAS_SERVER is required")
+	}
+	email := os.Getenv("KUBEATLAS_EMAIL")
+	if email == "" && os.Getenv("KUBEATLAS_TOKEN") == "" {
+		sugar.Fatal("Set KUBEATLAS_EMAIL and KUBEATLAS_PASSWORD, or KUBEATLAS_TOKEN")
+	}
+	refresh, err := time.ParseDuration(getEnv("ADMISSION_POLICY_REFRESH", "1m"))
+	if err != nil || refresh <= 0 {
+		sugar.Fatalw("Invalid ADMISSION_POLICY_REFRESH", "error", err)
+	}
+	exempt := strings.Split(getEnv("ADMISSION_EXEMPT_NAMESPACES", "default,kube-system,kube-public,kube-node-lease"), ",")
+
+	api := operator.NewSession(apiclient.New(server, os.Getenv("KUBEATLAS_TOKEN")), email, os.Getenv("KUBEATLAS_PASSWORD"))
+	webhook, err := admission.NewWebhook(api, getEnv("ADMISSION_MODE", admission.ModeWarn), exempt, sugar)
+	if err != nil {
+		sugar.Fatalw("Invalid ADMISSION_MODE", "error", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go webhook.Run(ctx, refresh)
+
+	mux := http.NewServeMux()
+	mux.Handle("/validate", webhook)
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	// Ready once the policy has been loaded
+	mux.HandleFunc("/readyz", func(w http.ResponseWriter, r *http.Request) {
+		if !webhook.Ready() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+
+	srv := &http.Server{
+		Addr:              getEnv("ADMISSION_ADDR", ":8443"),
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		srv.Shutdown(shutdownCtx)
+	}()
+
+	// The API server only calls webhooks over TLS
+	cert := getEnv("ADMISSION_TLS_CERT", "/tls/tls.crt")
+	key := getEnv("ADMISSION_TLS_KEY", "/tls/tls.key")
+	if err := srv.ListenAndServeTLS(cert, key); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		sugar.Fatalw("Admission webhook failed", "error", err)
+	}
+	sugar.Info("Admission webhook stopped")
+}
+
+func getEnv(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
@@ -0,0 +1,104 @@
+// Command anonymize replaces the personal data of an organization (e-mail
+// addresses, names, phone numbers and document contents) with fakes in
+// place, so a production snapshot can be loaded into staging for testing.
+// Run it against the copy, never against production:
+//
+//	kubeatlas-anonymize -org acme -confirm acme [-keep-email admin@acme.com]
+//
+// The same person gets the same fake everywhere, so ownership and contacts
+// still line up. The user with -keep-email keeps their identity and password
+// so someone can sign in to the copy.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"sort"
+	"syscall"
+	"text/tabwriter"
+
+	"github.com/kubeatlas/kubeatlas/internal/config"
+	"github.com/kubeatlas/kubeatlas/internal/database"
+	"github.com/kubeatlas/kubeatlas/internal/database/repositories"
+	"github.com/kubeatlas/kubeatlas/internal/services"
+	"go.uber.org/zap"
+)
+
+var (
+	Version   = "dev"
+	GitCommit = "unknown"
+)
+
+func main() {
+	orgSlug := flag.String("org", "", "slug of the organization to anonymize")
+	confirm := flag.String("confirm", "", "the organization's slug again, to confirm")
+	keepEmail := flag.String("keep-email", "", "e-mail address of a user whose identity is kept")
+	flag.Parse()
+
+	logger, _ := zap.NewProduction()
+	defer logger.Sync()
+	sugar := logger.Sugar()
+
+	if *orgSlug == "" {
+		fmt.Fprintln(os.Stderr, "usage: kubeatlas-anonymize -org <slug> -confirm <slug> [-keep-email <email>]")
+		os.Exit(2)
+	}
+	if *confirm != *orgSlug {
+		fmt.Fprintln(os.Stderr, "anonymizing rewrites the organization's data in place: pass -confirm with its slug to proceed")
+		os.Exit(2)
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		sugar.Fatalw("Failed to load configuration", "error", err)
+	}
+	db, err := database.New(cfg.Database)
+	if err != nil {
+		sugar.Fatalw("Failed to connect to database", "error", err)
+	}
+	defer db.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	org, err := repositories.NewOrgSettingsRepository(db.Pool).GetOrganizationBySlug(ctx, *orgSlug)
+	if err != nil {
+		sugar.Fatalw("Failed to look up organization", "error", err)
+	}
+	if org == nil {
+		sugar.Fatalw("Organization not found", "slug", *orgSlug)
+	}
+
+	sugar.Infow("Anonymizing organization", "organization_id", org.ID, "slug", org.Slug, "version", Version, "git_commit", GitCommit)
+	anonymizer := services.NewAnonymizeService(
+		repositories.NewAnonymizeRepository(db.Pool),
+		repositories.NewOrgDeletionRepository(db.Pool),
+		sugar,
+	)
+	result, err := anonymizer.Anonymize(ctx, org.ID, *keepEmail)
+	if err != nil {
+		sugar.Fatalw("Failed to anonymize organization", "error", err)
+	}
+
+	tables := make([]string, 0, len(result.Rows))
+	for table := range result.Rows {
+		tables = append(tables, table)
+	}
+	sort.Strings(tables)
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "TABLE\tROWS")
+	for _, table := range tables {
+		fmt.Fprintf(w, "%s\t%d\n", table, result.Rows[table])
+	}
+	fmt.Fprintf(w, "escalation paths\t%d\n", result.EscalationPaths)
+	fmt.Fprintf(w, "document files\t%d\n", result.Documents)
+	w.Flush()
+
+	if result.LedgerEntries > 0 {
+		sugar.Warnw("The append-only audit log ledger cannot be rewritten and still holds the original data",
+			"entries", result.LedgerEntries)
+	}
+}
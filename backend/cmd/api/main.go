@@ -3,21 +3,32 @@ package main
 import (
 	"context"
 	"fmt"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/kubeatlas/kubeatlas/internal/accesslog"
 	"github.com/kubeatlas/kubeatlas/internal/api/handlers"
 	"github.com/kubeatlas/kubeatlas/internal/api/middleware"
 	"github.com/kubeatlas/kubeatlas/internal/config"
 	"github.com/kubeatlas/kubeatlas/internal/crypto"
 	"github.com/kubeatlas/kubeatlas/internal/database"
+	"github.com/kubeatlas/kubeatlas/internal/health"
+	"github.com/kubeatlas/kubeatlas/internal/jobs"
 	"github.com/kubeatlas/kubeatlas/internal/k8s"
+	"github.com/kubeatlas/kubeatlas/internal/objectstore"
+	"github.com/kubeatlas/kubeatlas/internal/searchindex"
 	"github.com/kubeatlas/kubeatlas/internal/services"
+	"github.com/kubeatlas/kubeatlas/internal/siem"
+	"github.com/kubeatlas/kubeatlas/internal/telemetry"
 	"go.uber.org/zap"
 )
 
@@ -25,12 +36,6 @@ var (
 	Version   = "dev"
 	BuildTime = "unknown"
 	GitCommit = "unknown"
-	startTime = time.Now()
-)
-
-// API configuration constants
-const (
-	rateLimitRequestsPerMinute = 100
 )
 
 func main() {
@@ -51,6 +56,26 @@ func main() {
 		sugar.Fatalw("Failed to load configuration", "error", err)
 	}
 
+	// Initialize tracing
+	shutdownTracing, err := telemetry.Setup(context.Background(), cfg.Tracing, Version)
+	if err != nil {
+		sugar.Fatalw("Failed to initialize tracing", "error", err)
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(ctx); err != nil {
+			sugar.Warnw("Failed to flush traces", "error", err)
+		}
+	}()
+
+	// Initialize error reporting
+	flushErrors, err := telemetry.SetupErrorReporting(cfg.Sentry, Version)
+	if err != nil {
+		sugar.Fatalw("Failed to initialize error reporting", "error", err)
+	}
+	defer flushErrors()
+
 	// Initialize encryptor for sensitive data
 	encryptor, err := crypto.NewEncryptor(cfg.Encryption.Key)
 	if err != nil {
@@ -63,34 +88,143 @@ func main() {
 		sugar.Fatalw("Failed to connect to database", "error", err)
 	}
 	defer db.Close()
+	if db.HasReadReplica() {
+		sugar.Info("Read replica configured for list and report queries")
+	}
 
 	// Run migrations
 	if err := db.Migrate(); err != nil {
 		sugar.Fatalw("Failed to run migrations", "error", err)
 	}
 
+	// Redis backs shared rate limits; without it limits are per instance
+	rdb, err := database.NewRedis(cfg.Redis)
+	if err != nil {
+		sugar.Fatalw("Failed to connect to Redis", "error", err)
+	}
+	if rdb != nil {
+		defer rdb.Close()
+	}
+
 	// Initialize Kubernetes client manager with encryptor
-	k8sManager := k8s.NewManager(sugar, k8s.WithEncryptor(encryptor))
+	k8sManager := k8s.NewManager(sugar,
+		k8s.WithEncryptor(encryptor),
+		k8s.WithClientTTL(time.Duration(cfg.Sync.ClientCacheMinutes)*time.Minute),
+	)
 
 	// Initialize services with encryptor
-	svc := services.New(db.Pool, k8sManager, encryptor, sugar, cfg.JWT.Secret, cfg.JWT.ExpirationHours)
+	svc := services.New(db.Pool, db.ReadPool, k8sManager, encryptor, sugar, cfg.JWT.Secret, cfg.JWT.ExpirationHours)
+
+	// Optional forwarding of audit events to a SIEM
+	siemForwarder, err := siem.New(cfg.SIEM, sugar)
+	if err != nil {
+		sugar.Fatalw("Failed to initialize SIEM forwarding", "error", err)
+	}
+	if siemForwarder != nil {
+		svc.Audit.SetForwarder(siemForwarder)
+	}
+
+	// Optional archival of expired audit logs to object storage
+	if cfg.Audit.Archive {
+		archiveStore, err := objectstore.New(cfg.Storage)
+		if err != nil {
+			sugar.Fatalw("Failed to initialize audit archive storage", "error", err)
+		}
+		svc.Audit.SetArchiveStore(archiveStore)
+	}
+
+	// Archives of full organization exports and metadata backups
+	exportStore, err := objectstore.New(cfg.Storage)
+	if err != nil {
+		sugar.Fatalw("Failed to initialize export storage", "error", err)
+	}
+	svc.Export.SetStore(exportStore)
+	svc.OrgDeletion.SetStore(exportStore)
+	svc.Backup.SetStore(exportStore)
+
+	// Append-only audit storage for organizations that select object lock
+	lockStorage := cfg.Storage
+	if cfg.Audit.ObjectLockBucket != "" {
+		lockStorage.S3Bucket = cfg.Audit.ObjectLockBucket
+	}
+	lockStore, err := objectstore.New(lockStorage)
+	if err != nil {
+		sugar.Fatalw("Failed to initialize audit object lock storage", "error", err)
+	}
+	if locking, ok := lockStore.(objectstore.LockingStore); ok {
+		svc.Audit.SetLockingStore(locking)
+	}
+
+	// Optional OpenSearch or Elasticsearch search backend
+	searchIndex, err := searchindex.New(cfg.Search)
+	if err != nil {
+		sugar.Fatalw("Failed to initialize search index", "error", err)
+	}
+	if searchIndex != nil {
+		svc.SearchIndex.SetIndex(searchIndex)
+	}
+
+	// Background jobs
+	jobCtx, stopJobs := context.WithCancel(context.Background())
+	scheduler := jobs.NewScheduler(sugar)
+	scheduler.Every("audit-retention", 24*time.Hour, func(ctx context.Context) error {
+		return svc.Audit.MaintainPartitions(ctx, cfg.Audit.RetentionMonths)
+	})
+	scheduler.Every("notification-delivery", 30*time.Second, svc.Notification.ProcessDeliveries)
+	scheduler.Every("notification-digest", 24*time.Hour, svc.Notification.SendDigests)
+	scheduler.Every("webhook-delivery", 15*time.Second, svc.Webhook.ProcessDeliveries)
+	scheduler.Every("escalations", time.Minute, svc.Escalation.ProcessDue)
+	scheduler.Every("data-retention", 24*time.Hour, svc.Retention.Enforce)
+	scheduler.Every("org-exports", 15*time.Second, svc.Export.ProcessPending)
+	scheduler.Every("org-deletions", 30*time.Second, svc.OrgDeletion.ProcessPending)
+	scheduler.Every("metadata-backups", 15*time.Minute, svc.Backup.ProcessScheduled)
+	scheduler.Every("confluence-pages", time.Hour, svc.Confluence.RefreshPages)
+	scheduler.Every("git-repositories", 15*time.Minute, svc.Git.RefreshRepositories)
+	scheduler.Every("monitoring-links", 15*time.Minute, svc.Monitoring.RefreshLinks)
+	scheduler.Every("document-text", 5*time.Minute, svc.Document.ExtractPendingText)
+	scheduler.Every("team-sync", time.Hour, svc.TeamSync.SyncAll)
+	scheduler.Every("team-on-call", 5*time.Minute, svc.TeamOnCall.Refresh)
+	scheduler.Every("contact-validation", 24*time.Hour, svc.Contacts.ValidateAll)
+	scheduler.Every("cluster-version-support", 24*time.Hour, svc.Cluster.CheckVersionSupport)
+	scheduler.Every("namespace-bulk-assign", 15*time.Second, svc.BulkAssign.ProcessPending)
+	if svc.SearchIndex.Enabled() {
+		scheduler.Every("search-index", time.Duration(cfg.Search.IndexIntervalMinutes)*time.Minute, svc.SearchIndex.Reindex)
+	}
+	scheduler.Every("k8s-client-cache", 5*time.Minute, func(ctx context.Context) error {
+		if n := k8sManager.EvictExpired(); n > 0 {
+			sugar.Debugw("Evicted cached Kubernetes clients", "count", n)
+		}
+		return nil
+	})
+	scheduler.Start(jobCtx)
 
 	// Initialize Gin router
 	if cfg.Server.Mode == "release" {
 		gin.SetMode(gin.ReleaseMode)
 	}
 
+	// Optional access log sink for security tooling
+	accessLog, closeAccessLog, err := accesslog.New(cfg.Log.Access)
+	if err != nil {
+		sugar.Fatalw("Failed to initialize access log", "error", err)
+	}
+	defer closeAccessLog()
+
 	router := gin.New()
-	router.Use(gin.Recovery())
-	router.Use(middleware.Logger(sugar))
+	router.Use(middleware.Recovery(sugar))
+	router.Use(middleware.Logger(sugar, middleware.WithAccessLog(accessLog)))
 	router.Use(middleware.RequestID())
+	router.Use(middleware.ErrorReporting())
+	router.Use(middleware.Tracing())
+	router.Use(middleware.Prometheus())
 	router.Use(middleware.SecurityHeaders())
 
 	// Add HSTS header for HTTPS connections (1 year max-age)
 	router.Use(middleware.StrictTransportSecurity(31536000))
 
-	// Limit request body size to 10MB
-	router.Use(middleware.MaxBodySize(10 << 20))
+	// Limit request bodies to the JSON limit. Imports and uploads raise it
+	// per route.
+	router.Use(middleware.BodyLimit(cfg.BodyLimit.JSON))
 
 	// CORS configuration
 	router.Use(cors.New(cors.Config{
@@ -110,45 +244,34 @@ func main() {
 		})
 	})
 
-	router.GET("/ready", func(c *gin.Context) {
-		if err := db.Ping(); err != nil {
-			c.JSON(http.StatusServiceUnavailable, gin.H{
-				"status": "not ready",
-				"error":  "database connection failed",
-			})
-			return
-		}
-		c.JSON(http.StatusOK, gin.H{"status": "ready"})
-	})
+	router.GET("/ready", handlers.Readiness(newReadinessChecker(cfg, db, svc, sugar)))
 
 	// Metrics endpoint for Prometheus
-	router.GET("/metrics", func(c *gin.Context) {
-		// Basic metrics - for production, use prometheus/client_golang
-		c.JSON(http.StatusOK, gin.H{
-			"uptime":     time.Since(startTime).Seconds(),
-			"version":    Version,
-			"go_version": "1.21",
-		})
-	})
+	router.GET("/metrics", middleware.MetricsHandler())
 
 	// API routes
 	api := router.Group("/api/v1")
 
-	// Apply rate limiting to API routes
-	api.Use(middleware.RateLimiterMiddleware(rateLimitRequestsPerMinute, time.Minute))
+	// Rate limiting: routes before login count per client IP, protected
+	// routes per user once Auth has identified them
+	publicLimit := middleware.RateLimit(middleware.NewLimiter(rdb, "public", cfg.RateLimit.API), middleware.KeyByIP, "rate_limit_exceeded")
+	userLimit := middleware.RateLimit(middleware.NewLimiter(rdb, "api", cfg.RateLimit.API), middleware.KeyByClient, "rate_limit_exceeded")
 	{
 		// Authentication
-		auth := api.Group("/auth")
+		auth := api.Group("/auth", publicLimit)
 		{
 			// Apply strict rate limiting to login endpoint (brute force protection)
-			auth.POST("/login", middleware.LoginRateLimiter(), handlers.Login(svc))
+			auth.POST("/login", handlers.AuditLoginLockouts(svc), middleware.RateLimit(middleware.NewLimiter(rdb, "login", cfg.RateLimit.Login), middleware.KeyByIP, "too_many_login_attempts"), handlers.Login(svc))
 			auth.POST("/logout", handlers.Logout(svc))
 			auth.POST("/refresh", handlers.RefreshToken(svc))
 		}
 
+		// Branding is public so the login page can be branded
+		api.GET("/settings/branding", publicLimit, handlers.GetBranding(svc))
+
 		// Protected routes
 		protected := api.Group("")
-		protected.Use(middleware.Auth(cfg.JWT.Secret))
+		protected.Use(middleware.Auth(cfg.JWT.Secret), middleware.RequireActiveSession(svc.Auth.SessionActive), userLimit)
 		{
 			// Users
 			users := protected.Group("/users")
@@ -165,23 +288,140 @@ func main() {
 			teams := protected.Group("/teams")
 			{
 				teams.GET("", handlers.ListTeams(svc))
+				teams.GET("/tree", handlers.GetTeamTree(svc))
 				teams.GET("/:id", handlers.GetTeam(svc))
+				teams.GET("/:id/descendants", handlers.ListTeamDescendants(svc))
 				teams.POST("", handlers.CreateTeam(svc))
+				teams.POST("/upsert", middleware.RequireEditor(), handlers.UpsertTeam(svc))
 				teams.PUT("/:id", handlers.UpdateTeam(svc))
 				teams.DELETE("/:id", handlers.DeleteTeam(svc))
+				teams.POST("/:id/restore", middleware.RequireAdmin(), handlers.RestoreTeam(svc))
+				teams.GET("/name/:name", handlers.GetTeamByName(svc))
+				teams.PUT("/name/:name", middleware.RequireEditor(), handlers.UpsertTeamByName(svc))
 				teams.GET("/:id/members", handlers.ListTeamMembers(svc))
 				teams.POST("/:id/members", handlers.AddTeamMember(svc))
 				teams.DELETE("/:id/members/:userId", handlers.RemoveTeamMember(svc))
+				teams.GET("/:id/directory-groups", handlers.ListTeamDirectoryGroups(svc))
+				teams.PUT("/:id/directory-groups", middleware.RequireAdmin(), handlers.SetTeamDirectoryGroups(svc))
+				teams.GET("/:id/membership-changes", handlers.ListTeamMembershipChanges(svc))
+				teams.GET("/:id/on-call", handlers.GetTeamOnCall(svc))
+				teams.PUT("/:id/on-call", middleware.RequireEditor(), handlers.UpdateTeamOnCall(svc))
+				teams.GET("/:id/notification-bindings", handlers.GetTeamNotificationBindings(svc))
+				teams.PUT("/:id/notification-bindings", middleware.RequireEditor(), handlers.UpdateTeamNotificationBindings(svc))
+				teams.POST("/directory-sync", middleware.RequireAdmin(), handlers.SyncTeamDirectoryGroups(svc))
+				teams.PUT("/directory-sync/groups/:group", middleware.RequireAdmin(), handlers.PushDirectoryGroup(svc))
 			}
 
 			// Business Units
 			businessUnits := protected.Group("/business-units")
 			{
 				businessUnits.GET("", handlers.ListBusinessUnits(svc))
+				businessUnits.GET("/tree", handlers.GetBusinessUnitTree(svc))
 				businessUnits.GET("/:id", handlers.GetBusinessUnit(svc))
 				businessUnits.POST("", handlers.CreateBusinessUnit(svc))
 				businessUnits.PUT("/:id", handlers.UpdateBusinessUnit(svc))
 				businessUnits.DELETE("/:id", handlers.DeleteBusinessUnit(svc))
+				businessUnits.GET("/name/:name", handlers.GetBusinessUnitByName(svc))
+				businessUnits.PUT("/name/:name", middleware.RequireAdmin(), handlers.UpsertBusinessUnitByName(svc))
+			}
+
+			// Applications grouping namespaces across clusters
+			applications := protected.Group("/applications")
+			{
+				applications.GET("", handlers.ListApplications(svc))
+				applications.POST("", middleware.RequireEditor(), handlers.CreateApplication(svc))
+				applications.GET("/:id", handlers.GetApplication(svc))
+				applications.PUT("/:id", middleware.RequireEditor(), handlers.UpdateApplication(svc))
+				applications.DELETE("/:id", middleware.RequireAdmin(), handlers.DeleteApplication(svc))
+				applications.GET("/:id/namespaces", handlers.ListApplicationNamespaces(svc))
+				applications.POST("/:id/namespaces", middleware.RequireEditor(), handlers.AddApplicationNamespaces(svc))
+				applications.DELETE("/:id/namespaces/:namespaceId", middleware.RequireEditor(), handlers.RemoveApplicationNamespace(svc))
+				applications.GET("/:id/dependencies", handlers.GetApplicationDependencies(svc))
+				applications.GET("/:id/dashboard", handlers.GetApplicationDashboard(svc))
+			}
+
+			// Cluster fleets reported on and synced together
+			fleets := protected.Group("/fleets")
+			{
+				fleets.GET("", handlers.ListFleets(svc))
+				fleets.POST("", middleware.RequireEditor(), handlers.CreateFleet(svc))
+				fleets.GET("/:id", handlers.GetFleet(svc))
+				fleets.PUT("/:id", middleware.RequireEditor(), handlers.UpdateFleet(svc))
+				fleets.DELETE("/:id", middleware.RequireAdmin(), handlers.DeleteFleet(svc))
+				fleets.POST("/:id/clusters", middleware.RequireEditor(), handlers.AddFleetClusters(svc))
+				fleets.DELETE("/:id/clusters/:clusterId", middleware.RequireEditor(), handlers.RemoveFleetCluster(svc))
+				fleets.GET("/:id/report", handlers.GetFleetReport(svc))
+				fleets.POST("/:id/sync", middleware.RequireEditor(), handlers.SyncFleet(svc))
+			}
+
+			// Imports
+			handlers.RegisterImportRoutes(protected.Group("/import", middleware.BodyLimit(cfg.BodyLimit.Import)), svc)
+
+			// Saved searches of the caller and their teams
+			savedSearches := protected.Group("/saved-searches")
+			{
+				savedSearches.GET("", handlers.ListSavedSearches(svc))
+				savedSearches.POST("", handlers.CreateSavedSearch(svc))
+				savedSearches.GET("/:id", handlers.GetSavedSearch(svc))
+				savedSearches.PUT("/:id", handlers.UpdateSavedSearch(svc))
+				savedSearches.DELETE("/:id", handlers.DeleteSavedSearch(svc))
+				savedSearches.GET("/:id/results", handlers.ApplySavedSearch(svc))
+			}
+
+			// Comments on namespaces and clusters, listed and created under them;
+			// any member may comment
+			comments := protected.Group("/comments")
+			{
+				comments.PUT("/:id", handlers.UpdateComment(svc))
+				comments.DELETE("/:id", handlers.DeleteComment(svc))
+			}
+
+			// Tags of namespaces, clusters and documents
+			tags := protected.Group("/tags")
+			{
+				tags.GET("", handlers.ListTags(svc))
+				tags.POST("/rename", middleware.RequireAdmin(), handlers.RenameTag(svc))
+				tags.POST("/merge", middleware.RequireAdmin(), handlers.MergeTags(svc))
+			}
+
+			// Global search across namespaces and documents, and autocomplete
+			protected.GET("/search", handlers.Search(svc))
+			protected.GET("/search/suggest", handlers.Suggest(svc))
+
+			// Change feed
+			protected.GET("/feed/changes", handlers.GetChangeFeed(svc))
+
+			// Trash of deleted records, restored through POST /<resources>/:id/restore
+			protected.GET("/trash", middleware.RequireAdmin(), handlers.ListTrash(svc))
+
+			// Schema migrations applied to the database
+			protected.GET("/admin/migrations", middleware.RequireAdmin(), handlers.ListMigrations(svc))
+
+			// Backups of catalog metadata, restored selectively by section
+			backups := protected.Group("/admin/backups", middleware.RequireAdmin())
+			{
+				backups.POST("", handlers.CreateMetadataBackup(svc))
+				backups.GET("", handlers.ListMetadataBackups(svc))
+				backups.POST("/import", middleware.BodyLimit(cfg.BodyLimit.Import), handlers.ImportMetadataBackup(svc))
+				backups.GET("/:id/download", handlers.DownloadMetadataBackup(svc))
+				backups.POST("/:id/restore", handlers.RestoreMetadataBackup(svc))
+			}
+
+			// Full organization exports, generated in the background
+			orgExports := protected.Group("/export/org", middleware.RequireAdmin())
+			{
+				orgExports.POST("", handlers.RequestOrgExport(svc))
+				orgExports.GET("", handlers.ListOrgExports(svc))
+				orgExports.GET("/:id", handlers.GetOrgExport(svc))
+				orgExports.GET("/:id/download", handlers.DownloadOrgExport(svc))
+			}
+
+			// Deletion of the whole organization, confirming a preview
+			organization := protected.Group("/organization", middleware.RequireAdmin())
+			{
+				organization.DELETE("", handlers.DeleteOrganization(svc))
+				organization.POST("/deletion/preview", handlers.PreviewOrgDeletion(svc))
+				organization.GET("/deletion/:id", handlers.GetOrgDeletion(svc))
 			}
 
 			// Clusters
@@ -190,22 +430,68 @@ func main() {
 				clusters.GET("", handlers.ListClusters(svc))
 				clusters.GET("/:id", handlers.GetCluster(svc))
 				clusters.POST("", handlers.CreateCluster(svc))
+				clusters.POST("/preview", middleware.RequireEditor(), handlers.PreviewCluster(svc))
 				clusters.PUT("/:id", handlers.UpdateCluster(svc))
 				clusters.DELETE("/:id", handlers.DeleteCluster(svc))
+				clusters.GET("/:id/deletion-preview", middleware.RequireAdmin(), handlers.GetClusterDeletionPreview(svc))
+				clusters.POST("/:id/restore", middleware.RequireAdmin(), handlers.RestoreCluster(svc))
+				clusters.GET("/name/:name", handlers.GetClusterByName(svc))
+				clusters.PUT("/name/:name", middleware.RequireAdmin(), handlers.UpsertClusterByName(svc))
 				clusters.POST("/:id/sync", handlers.SyncCluster(svc))
+				clusters.GET("/:id/sync-errors", handlers.ListClusterSyncErrors(svc))
+				clusters.GET("/:id/sync-runs", handlers.ListClusterSyncRuns(svc))
+				clusters.GET("/:id/maintenance-windows", handlers.ListClusterMaintenanceWindows(svc))
+				clusters.POST("/:id/maintenance-windows", middleware.RequireEditor(), handlers.CreateClusterMaintenanceWindow(svc))
+				clusters.PUT("/:id/maintenance-windows/:windowId", middleware.RequireEditor(), handlers.UpdateClusterMaintenanceWindow(svc))
+				clusters.DELETE("/:id/maintenance-windows/:windowId", middleware.RequireEditor(), handlers.DeleteClusterMaintenanceWindow(svc))
+				clusters.GET("/:id/nodes", handlers.ListClusterNodes(svc))
+				clusters.GET("/:id/capacity", handlers.GetClusterCapacity(svc))
+				clusters.GET("/:id/capacity/trend", handlers.GetClusterCapacityTrend(svc))
+				clusters.GET("/:id/comments", handlers.ListClusterComments(svc))
+				clusters.POST("/:id/comments", handlers.CreateClusterComment(svc))
 				clusters.GET("/:id/namespaces", handlers.ListClusterNamespaces(svc))
 				clusters.GET("/:id/stats", handlers.GetClusterStats(svc))
 			}
 
+			// Nodes, as found by cluster syncs
+			protected.GET("/nodes/:id", handlers.GetNode(svc))
+
 			// Namespaces
 			namespaces := protected.Group("/namespaces")
 			{
 				namespaces.GET("", handlers.ListNamespaces(svc))
+				namespaces.GET("/search", handlers.SearchNamespaces(svc))
+				namespaces.GET("/compare", handlers.CompareNamespaces(svc))
+				namespaces.GET("/check", handlers.CheckNamespace(svc))
+				namespaces.GET("/admission-policy", handlers.GetAdmissionPolicy(svc))
+				namespaces.GET("/duplicates", middleware.RequireAdmin(), handlers.ListNamespaceDuplicates(svc))
+				namespaces.GET("/contact-issues", handlers.ListContactIssues(svc))
+				namespaces.POST("/contact-issues/validate", middleware.RequireAdmin(), handlers.ValidateContacts(svc))
+				namespaces.POST("/bulk-assign", middleware.RequireEditor(), handlers.BulkAssignNamespaces(svc))
+				namespaces.GET("/bulk-assign", handlers.ListNamespaceBulkAssignments(svc))
+				namespaces.GET("/bulk-assign/:id", handlers.GetNamespaceBulkAssignment(svc))
 				namespaces.GET("/:id", handlers.GetNamespace(svc))
 				namespaces.PUT("/:id", handlers.UpdateNamespace(svc))
+				namespaces.POST("/:id/restore", middleware.RequireAdmin(), handlers.RestoreNamespace(svc))
+				namespaces.POST("/:id/merge", middleware.RequireAdmin(), handlers.MergeNamespace(svc))
+				namespaces.POST("/:id/archive", middleware.RequireEditor(), handlers.ArchiveNamespace(svc))
+				namespaces.POST("/:id/unarchive", middleware.RequireEditor(), handlers.UnarchiveNamespace(svc))
+				namespaces.PUT("/:id/lifecycle", middleware.RequireEditor(), handlers.SetNamespaceLifecycle(svc))
+				namespaces.POST("/ownership", middleware.RequireEditor(), middleware.BodyLimit(cfg.BodyLimit.Import), handlers.ImportNamespaceOwnership(svc))
+				namespaces.POST("/upsert", middleware.RequireEditor(), handlers.UpsertNamespace(svc))
 				namespaces.GET("/:id/dependencies", handlers.ListNamespaceDependencies(svc))
 				namespaces.GET("/:id/documents", handlers.ListNamespaceDocuments(svc))
 				namespaces.GET("/:id/history", handlers.ListNamespaceHistory(svc))
+				namespaces.GET("/:id/ownership-history", handlers.GetNamespaceOwnershipHistory(svc))
+				namespaces.GET("/:id/escalation-path", handlers.GetNamespaceEscalationPath(svc))
+				namespaces.GET("/:id/access", handlers.GetNamespaceAccess(svc))
+				namespaces.GET("/:id/flux", handlers.GetNamespaceFlux(svc))
+				namespaces.GET("/:id/usage", handlers.GetNamespaceUsage(svc))
+				namespaces.GET("/:id/comments", handlers.ListNamespaceComments(svc))
+				namespaces.POST("/:id/comments", handlers.CreateNamespaceComment(svc))
+				namespaces.GET("/:id/impact", handlers.GetNamespaceImpact(svc))
+				namespaces.POST("/:id/ticket", middleware.RequireEditor(), handlers.CreateNamespaceTicket(svc))
+				namespaces.POST("/:id/confluence", middleware.RequireEditor(), handlers.ExportNamespaceToConfluence(svc))
 			}
 
 			// Dependencies
@@ -221,20 +507,30 @@ func main() {
 				dependencies.GET("/external", handlers.ListExternalDependencies(svc))
 				dependencies.POST("/external", handlers.CreateExternalDependency(svc))
 				dependencies.PUT("/external/:id", handlers.UpdateExternalDependency(svc))
+				dependencies.PUT("/external/:id/status", middleware.RequireEditor(), handlers.SetExternalDependencyStatus(svc))
 				dependencies.DELETE("/external/:id", handlers.DeleteExternalDependency(svc))
 
 				// Dependency graph
 				dependencies.GET("/graph/:namespaceId", handlers.GetDependencyGraph(svc))
 			}
 
+			// Escalations
+			escalations := protected.Group("/escalations")
+			{
+				escalations.GET("", handlers.ListEscalations(svc))
+				escalations.GET("/:id", handlers.GetEscalation(svc))
+				escalations.POST("/:id/acknowledge", handlers.AcknowledgeEscalation(svc))
+			}
+
 			// Documents
 			documents := protected.Group("/documents")
 			{
 				documents.GET("", handlers.ListDocuments(svc))
 				documents.GET("/:id", handlers.GetDocument(svc))
-				documents.POST("", handlers.UploadDocument(svc))
+				documents.POST("", middleware.RateLimit(middleware.NewLimiter(rdb, "upload", cfg.RateLimit.Upload), middleware.KeyByClient, "rate_limit_exceeded"), middleware.BodyLimit(cfg.BodyLimit.Upload), handlers.UploadDocument(svc))
 				documents.PUT("/:id", handlers.UpdateDocument(svc))
 				documents.DELETE("/:id", handlers.DeleteDocument(svc))
+				documents.POST("/:id/restore", middleware.RequireAdmin(), handlers.RestoreDocument(svc))
 				documents.GET("/:id/download", handlers.DownloadDocument(svc))
 				documents.GET("/categories", handlers.ListDocumentCategories(svc))
 			}
@@ -244,8 +540,15 @@ func main() {
 			{
 				reports.GET("/ownership-coverage", handlers.OwnershipCoverageReport(svc))
 				reports.GET("/orphaned-resources", handlers.OrphanedResourcesReport(svc))
+				reports.GET("/pod-security", handlers.PodSecurityReport(svc))
+				reports.GET("/monitoring-coverage", handlers.MonitoringCoverageReport(svc))
+				reports.GET("/capacity", handlers.CapacityReport(svc))
+				reports.GET("/metadata-policy", handlers.MetadataPolicyReport(svc))
+				reports.GET("/kubernetes-versions", handlers.KubernetesVersionReport(svc))
 				reports.GET("/dependency-matrix", handlers.DependencyMatrixReport(svc))
 				reports.GET("/export", handlers.ExportReport(svc))
+				reports.POST("/email", middleware.RequireAdmin(), handlers.EmailReport(svc))
+				reports.POST("/tickets", middleware.RequireEditor(), handlers.CreateReportTickets(svc))
 			}
 
 			// Dashboard
@@ -260,18 +563,88 @@ func main() {
 			audit := protected.Group("/audit")
 			{
 				audit.GET("", handlers.ListAuditLogs(svc))
-				audit.GET("/:resourceType/:resourceId", handlers.GetResourceAuditLogs(svc))
+				audit.GET("/export", middleware.RequireAdmin(), handlers.ExportAuditLogs(svc))
+				audit.GET("/auth-anomalies", middleware.RequireAdmin(), handlers.GetAuthAnomalies(svc))
+				audit.GET("/archives", middleware.RequireAdmin(), handlers.ListAuditArchives(svc))
+				audit.POST("/archives/restore", middleware.RequireAdmin(), handlers.RestoreAuditArchives(svc))
+				// Sibling wildcards must share a name: :id is the audit log ID for
+				// /diff and the resource type for the per-resource routes
+				audit.GET("/:id/diff", handlers.GetAuditLogDiff(svc))
+				audit.GET("/:id/:resourceId", handlers.GetResourceAuditLogs(svc))
+				audit.GET("/:id/:resourceId/stream", handlers.StreamResourceAuditLogs(svc))
 			}
 
+			// Environments and criticality tiers
+			protected.GET("/environments", handlers.ListEnvironments(svc))
+			protected.GET("/criticality-tiers", handlers.ListCriticalityTiers(svc))
+
 			// Settings
 			settings := protected.Group("/settings")
 			{
 				settings.GET("", handlers.GetSettings(svc))
-				settings.PUT("", handlers.UpdateSettings(svc))
+				settings.PUT("", middleware.RequireAdmin(), handlers.UpdateSettings(svc))
+				settings.GET("/smtp", middleware.RequireAdmin(), handlers.GetSMTPConfig(svc))
+				settings.PUT("/smtp", middleware.RequireAdmin(), handlers.UpdateSMTPConfig(svc))
+				settings.POST("/smtp/test", middleware.RequireAdmin(), handlers.TestSMTPConnection(svc))
+				settings.GET("/slack", middleware.RequireAdmin(), handlers.GetSlackConfig(svc))
+				settings.PUT("/slack", middleware.RequireAdmin(), handlers.UpdateSlackConfig(svc))
+				settings.POST("/slack/test", middleware.RequireAdmin(), handlers.TestSlackConnection(svc))
+				settings.GET("/teams", middleware.RequireAdmin(), handlers.GetTeamsConfig(svc))
+				settings.PUT("/teams", middleware.RequireAdmin(), handlers.UpdateTeamsConfig(svc))
+				settings.POST("/teams/test", middleware.RequireAdmin(), handlers.TestTeamsConnection(svc))
+				settings.GET("/jira", middleware.RequireAdmin(), handlers.GetJiraConfig(svc))
+				settings.PUT("/jira", middleware.RequireAdmin(), handlers.UpdateJiraConfig(svc))
+				settings.POST("/jira/test", middleware.RequireAdmin(), handlers.TestJiraConnection(svc))
+				settings.GET("/pagerduty", middleware.RequireAdmin(), handlers.GetPagerDutyConfig(svc))
+				settings.PUT("/pagerduty", middleware.RequireAdmin(), handlers.UpdatePagerDutyConfig(svc))
+				settings.POST("/pagerduty/test", middleware.RequireAdmin(), handlers.TestPagerDutyConnection(svc))
+				settings.GET("/opsgenie", middleware.RequireAdmin(), handlers.GetOpsgenieConfig(svc))
+				settings.PUT("/opsgenie", middleware.RequireAdmin(), handlers.UpdateOpsgenieConfig(svc))
+				settings.POST("/opsgenie/test", middleware.RequireAdmin(), handlers.TestOpsgenieConnection(svc))
+				settings.GET("/grafana", middleware.RequireAdmin(), handlers.GetGrafanaConfig(svc))
+				settings.PUT("/grafana", middleware.RequireAdmin(), handlers.UpdateGrafanaConfig(svc))
+				settings.POST("/grafana/test", middleware.RequireAdmin(), handlers.TestGrafanaConnection(svc))
+				settings.GET("/confluence", middleware.RequireAdmin(), handlers.GetConfluenceConfig(svc))
+				settings.PUT("/confluence", middleware.RequireAdmin(), handlers.UpdateConfluenceConfig(svc))
+				settings.POST("/confluence/test", middleware.RequireAdmin(), handlers.TestConfluenceConnection(svc))
+				settings.GET("/git", middleware.RequireAdmin(), handlers.GetGitConfig(svc))
+				settings.PUT("/git", middleware.RequireAdmin(), handlers.UpdateGitConfig(svc))
+				settings.GET("/datadog", middleware.RequireAdmin(), handlers.GetDatadogConfig(svc))
+				settings.PUT("/datadog", middleware.RequireAdmin(), handlers.UpdateDatadogConfig(svc))
+				settings.POST("/datadog/test", middleware.RequireAdmin(), handlers.TestDatadogConnection(svc))
+				settings.GET("/sync-alerts", middleware.RequireAdmin(), handlers.GetSyncAlertConfig(svc))
+				settings.PUT("/sync-alerts", middleware.RequireAdmin(), handlers.UpdateSyncAlertConfig(svc))
+				settings.GET("/digest", middleware.RequireAdmin(), handlers.GetDigestConfig(svc))
+				settings.PUT("/digest", middleware.RequireAdmin(), handlers.UpdateDigestConfig(svc))
+				settings.GET("/retention/preview", middleware.RequireAdmin(), handlers.PreviewRetention(svc))
+			}
+
+			// Notifications
+			notifications := protected.Group("/notifications", middleware.RequireAdmin())
+			{
+				notifications.GET("/deliveries", handlers.ListNotificationDeliveries(svc))
+				notifications.GET("/templates", handlers.ListNotificationTemplates(svc))
+				notifications.PUT("/templates/:eventType", handlers.UpsertNotificationTemplate(svc))
+			}
+
+			// Outgoing webhooks (admin only)
+			webhooks := protected.Group("/webhooks", middleware.RequireAdmin())
+			{
+				webhooks.GET("", handlers.ListWebhooks(svc))
+				webhooks.POST("", handlers.CreateWebhook(svc))
+				webhooks.GET("/:id", handlers.GetWebhook(svc))
+				webhooks.PUT("/:id", handlers.UpdateWebhook(svc))
+				webhooks.DELETE("/:id", handlers.DeleteWebhook(svc))
+				webhooks.GET("/:id/deliveries", handlers.ListWebhookDeliveries(svc))
+				webhooks.POST("/:id/ping", handlers.PingWebhook(svc))
 			}
 		}
 	}
 
+	// Runtime diagnostics (pprof, expvar, goroutine summary), at /debug like
+	// the internal listener so the links of the pprof index resolve
+	handlers.RegisterDebugRoutes(router.Group("/debug", middleware.Auth(cfg.JWT.Secret), middleware.RequireActiveSession(svc.Auth.SessionActive), middleware.RequireAdmin()))
+
 	// Create HTTP server with appropriate timeouts
 	// WriteTimeout is longer to accommodate cluster sync operations
 	server := &http.Server{
@@ -284,6 +657,9 @@ func main() {
 		MaxHeaderBytes:    1 << 20, // 1 MB
 	}
 
+	// End audit streams when shutdown begins, so they do not hold it up
+	server.RegisterOnShutdown(svc.Audit.CloseWatchers)
+
 	// Start server in goroutine
 	go func() {
 		sugar.Infow("Starting HTTP server", "port", cfg.Server.Port)
@@ -292,6 +668,23 @@ func main() {
 		}
 	}()
 
+	// Internal diagnostics listener without authentication, bound to
+	// loopback unless DEBUG_HOST names another interface
+	var debugServer *http.Server
+	if cfg.Server.DebugPort > 0 {
+		debugServer = &http.Server{
+			Addr:              net.JoinHostPort(cfg.Server.DebugHost, strconv.Itoa(cfg.Server.DebugPort)),
+			Handler:           handlers.DebugMux(),
+			ReadHeaderTimeout: 10 * time.Second,
+		}
+		go func() {
+			sugar.Infow("Starting debug server", "addr", debugServer.Addr)
+			if err := debugServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				sugar.Errorw("Debug server failed", "error", err)
+			}
+		}()
+	}
+
 	// Wait for interrupt signal
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
@@ -299,13 +692,52 @@ func main() {
 
 	sugar.Info("Shutting down server...")
 
+	stopJobs()
+	scheduler.Wait()
+
 	// Graceful shutdown with timeout
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
+	if debugServer != nil {
+		debugServer.Shutdown(ctx)
+	}
 	if err := server.Shutdown(ctx); err != nil {
 		sugar.Fatalw("Server forced to shutdown", "error", err)
 	}
+	if siemForwarder != nil {
+		if err := siemForwarder.Close(ctx); err != nil {
+			sugar.Warnw("Failed to close SIEM forwarder", "error", err)
+		}
+	}
 
 	sugar.Info("Server exited gracefully")
 }
+
+// newReadinessChecker registers the dependency checks served by /ready
+func newReadinessChecker(cfg *config.Config, db *database.DB, svc *services.Services, logger *zap.SugaredLogger) *health.Checker {
+	checker := health.NewChecker(time.Duration(cfg.Health.TimeoutSeconds) * time.Second)
+
+	checker.Register(health.Check{Name: "database", Critical: true, Run: health.DatabaseCheck(db.Pool)})
+	if db.HasReadReplica() {
+		checker.Register(health.Check{Name: "database_replica", Run: health.DatabaseCheck(db.ReadPool)})
+	}
+	checker.Register(health.Check{Name: "storage", Run: health.StorageCheck(cfg.Storage)})
+	checker.Register(health.Check{Name: "redis", Run: health.RedisCheck(cfg.Redis)})
+
+	for _, rawID := range cfg.Health.ClusterIDs {
+		clusterID, err := uuid.Parse(strings.TrimSpace(rawID))
+		if err != nil {
+			logger.Warnw("Ignoring invalid cluster ID in READY_CHECK_CLUSTERS", "value", rawID)
+			continue
+		}
+		checker.Register(health.Check{
+			Name: "cluster:" + clusterID.String(),
+			Run: func(ctx context.Context) error {
+				return svc.Cluster.CheckConnectivity(ctx, clusterID)
+			},
+		})
+	}
+
+	return checker
+}
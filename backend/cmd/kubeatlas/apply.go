@@ -0,0 +1,140 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/kubeatlas/kubeatlas/internal/apiclient"
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/yaml"
+)
+
+// applyBatch is the number of namespaces sent per import request, below the
+// server's limit
+const applyBatch = 500
+
+// ownershipFile is the format of the files read by apply
+type ownershipFile struct {
+	Namespaces []apiclient.NamespaceOwnership `json:"namespaces"`
+}
+
+func newApplyCmd(opts *options) *cobra.Command {
+	var file string
+	var dryRun bool
+
+	cmd := &cobra.Command{
+		Use:   "apply -f <file>",
+		Short: "Set the ownership of namespaces from a YAML file",
+		Long: "Set the owner team, business unit, contacts and SLA of namespaces, keyed by cluster\n" +
+			"and namespace name. Teams are matched by slug or name and business units by code or\n" +
+			"name. Fields left out of the file are not changed. With --dry-run the changes are\n" +
+			"shown but not made.",
+		Example: `  kubeatlas apply -f ownership.yaml --dry-run
+
+  # ownership.yaml
+  namespaces:
+    - cluster: prod-eu
+      namespace: payments
+      owner_team: payments
+      business_unit: RETAIL
+      technical_lead_name: Jane Doe
+      technical_lead_email: jane.doe@example.com
+      sla_availability: "99.9%"`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if file == "" {
+				return errors.New("set the file to apply with -f")
+			}
+			namespaces, err := readOwnershipFile(cmd, file)
+			if err != nil {
+				return err
+			}
+			if len(namespaces) == 0 {
+				return fmt.Errorf("%s lists no namespaces", file)
+			}
+
+			c, err := opts.client(cmd.Context())
+			if err != nil {
+				return err
+			}
+
+			total := &apiclient.OwnershipImport{DryRun: dryRun}
+			for start := 0; start < len(namespaces); start += applyBatch {
+				end := start + applyBatch
+				if end > len(namespaces) {
+					end = len(namespaces)
+				}
+				result, err := c.ImportOwnership(cmd.Context(), namespaces[start:end], dryRun)
+				if err != nil {
+					return err
+				}
+				total.Updated += result.Updated
+				total.Unchanged += result.Unchanged
+				total.Failed += result.Failed
+				total.Results = append(total.Results, result.Results...)
+			}
+
+			if opts.output == outputTable {
+				printOwnershipImport(cmd.OutOrStdout(), total)
+			} else if err := printValue(cmd.OutOrStdout(), opts.output, total); err != nil {
+				return err
+			}
+			if total.Failed > 0 {
+				return fmt.Errorf("%d namespaces failed", total.Failed)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&file, "filename", "f", "", "YAML or JSON file to apply, - for stdin")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "show the changes without making them")
+	return cmd
+}
+
+func readOwnershipFile(cmd *cobra.Command, file string) ([]apiclient.NamespaceOwnership, error) {
+	var b []byte
+	var err error
+	if file == "-" {
+		b, err = io.ReadAll(cmd.InOrStdin())
+	} else {
+		b, err = os.ReadFile(file)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Unknown fields are rejected so that typos do not go unnoticed
+	var f ownershipFile
+	if err := yaml.UnmarshalStrict(b, &f); err != nil {
+		return nil, fmt.Errorf("reading %s: %w", file, err)
+	}
+	return f.Namespaces, nil
+}
+
+// printOwnershipImport shows the changes of an import as a diff
+func printOwnershipImport(w io.Writer, result *apiclient.OwnershipImport) {
+	for _, r := range result.Results {
+		name := r.Cluster + "/" + r.Namespace
+		switch r.Status {
+		case "failed":
+			fmt.Fprintf(w, "! %s: %s\n", name, r.Error)
+		case "updated":
+			fmt.Fprintf(w, "~ %s\n", name)
+			for _, ch := range r.Changes {
+				if ch.Before == nil {
+					fmt.Fprintf(w, "    + %s: %v\n", ch.Field, ch.After)
+				} else {
+					fmt.Fprintf(w, "    ~ %s: %v -> %v\n", ch.Field, ch.Before, ch.After)
+				}
+			}
+		}
+	}
+
+	verb := "updated"
+	if result.DryRun {
+		verb = "to update"
+	}
+	fmt.Fprintf(w, "%d %s, %d unchanged, %d failed\n", result.Updated, verb, result.Unchanged, result.Failed)
+}
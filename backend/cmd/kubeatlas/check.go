@@ -0,0 +1,59 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+func newCheckCmd(opts *options) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "check",
+		Short: "Check inventory metadata, for use in pipelines",
+	}
+	cmd.AddCommand(newCheckNamespaceCmd(opts))
+	return cmd
+}
+
+func newCheckNamespaceCmd(opts *options) *cobra.Command {
+	return &cobra.Command{
+		Use:     "namespace <cluster>/<namespace>",
+		Aliases: []string{"ns"},
+		Short:   "Fail unless a namespace has the metadata its organization requires",
+		Long: "Check a namespace against the organization's metadata requirements, by default an\n" +
+			"owner team, a criticality tier and documentation, and against the metadata policies\n" +
+			"of its environment and criticality. Exits non-zero when metadata is missing, so\n" +
+			"pipelines can stop deploys to namespaces missing from the catalog.",
+		Example: "  kubeatlas check namespace prod-eu/payments",
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cluster, namespace, ok := strings.Cut(args[0], "/")
+			if !ok || cluster == "" || namespace == "" {
+				return fmt.Errorf("invalid namespace %q, want <cluster>/<namespace>", args[0])
+			}
+
+			c, err := opts.client(cmd.Context())
+			if err != nil {
+				return err
+			}
+			check, err := c.CheckNamespace(cmd.Context(), cluster, namespace)
+			if err != nil {
+				return err
+			}
+
+			if opts.output != outputTable {
+				if err := printValue(cmd.OutOrStdout(), opts.output, check); err != nil {
+					return err
+				}
+			} else if check.Complete {
+				fmt.Fprintf(cmd.OutOrStdout(), "%s/%s has all required metadata (%s)\n",
+					check.Cluster, check.Namespace, strings.Join(check.Required, ", "))
+			}
+			if !check.Complete {
+				return fmt.Errorf("%s/%s is missing %s", check.Cluster, check.Namespace, strings.Join(check.Missing, ", "))
+			}
+			return nil
+		},
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/spf13/cobra"
+)
+
+var clusterColumns = []column{
+	{"ID", "id"},
+	{"NAME", "name"},
+	{"TYPE", "cluster_type"},
+	{"ENVIRONMENT", "environment"},
+	{"VERSION", "version"},
+	{"NAMESPACES", "namespace_count"},
+	{"STATUS", "status"},
+	{"LAST SYNC", "last_sync_at"},
+}
+
+func newClustersCmd(opts *options) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "clusters",
+		Aliases: []string{"cluster"},
+		Short:   "List and sync clusters",
+	}
+	cmd.AddCommand(newClustersListCmd(opts), newClustersSyncCmd(opts))
+	return cmd
+}
+
+func newClustersListCmd(opts *options) *cobra.Command {
+	var status, environment, clusterType, syncError string
+	var page, pageSize int
+
+	cmd := &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "List clusters",
+		Example: "  kubeatlas clusters list --status error -o json",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := opts.client(cmd.Context())
+			if err != nil {
+				return err
+			}
+
+			q := url.Values{}
+			setQuery(q, "status", status)
+			setQuery(q, "environment", environment)
+			setQuery(q, "type", clusterType)
+			setQuery(q, "sync_error_category", syncError)
+			setPage(q, page, pageSize)
+
+			result, err := c.ListClusters(cmd.Context(), q)
+			if err != nil {
+				return err
+			}
+			return printItems(cmd.OutOrStdout(), opts.output, clusterColumns, result.Items)
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVar(&status, "status", "", "only clusters with this status")
+	flags.StringVar(&environment, "environment", "", "only clusters in this environment")
+	flags.StringVar(&clusterType, "type", "", "only clusters of this type, e.g. openshift or eks")
+	flags.StringVar(&syncError, "sync-error", "", "only clusters whose last sync failed with this error category")
+	flags.IntVar(&page, "page", 0, "page to show")
+	flags.IntVar(&pageSize, "page-size", 0, "clusters per page")
+	return cmd
+}
+
+func newClustersSyncCmd(opts *options) *cobra.Command {
+	return &cobra.Command{
+		Use:   "sync <cluster-id>...",
+		Short: "Start a sync of clusters",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := opts.client(cmd.Context())
+			if err != nil {
+				return err
+			}
+			for _, id := range args {
+				if err := c.SyncCluster(cmd.Context(), id); err != nil {
+					return fmt.Errorf("syncing cluster %s: %w", id, err)
+				}
+				fmt.Fprintf(cmd.OutOrStdout(), "Sync of cluster %s started\n", id)
+			}
+			return nil
+		},
+	}
+}
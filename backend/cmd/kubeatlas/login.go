@@ -0,0 +1,116 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/kubeatlas/kubeatlas/internal/apiclient"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+func newLoginCmd(opts *options) *cobra.Command {
+	var email string
+	var passwordStdin bool
+
+	cmd := &cobra.Command{
+		Use:   "login [server]",
+		Short: "Log in to a KubeAtlas server and save the session",
+		Long: "Log in with email and password. The server and tokens are saved to the config file\n" +
+			"and used by later commands until they expire or you log out.",
+		Example: "  kubeatlas login https://kubeatlas.example.com --email ops@example.com\n" +
+			"  echo \"$PASSWORD\" | kubeatlas login https://kubeatlas.example.com --email ops@example.com --password-stdin",
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := opts.loadConfig()
+			if err != nil {
+				return err
+			}
+
+			server := opts.server
+			if len(args) == 1 {
+				server = args[0]
+			}
+			if server == "" {
+				server = cfg.Server
+			}
+			if server == "" {
+				return errors.New("no server given")
+			}
+
+			in := bufio.NewReader(cmd.InOrStdin())
+			if email == "" {
+				fmt.Fprint(cmd.ErrOrStderr(), "Email: ")
+				if email, err = readLine(in); err != nil {
+					return err
+				}
+			}
+			var password string
+			if passwordStdin || !term.IsTerminal(int(os.Stdin.Fd())) {
+				password, err = readLine(in)
+			} else {
+				fmt.Fprint(cmd.ErrOrStderr(), "Password: ")
+				var b []byte
+				b, err = term.ReadPassword(int(os.Stdin.Fd()))
+				fmt.Fprintln(cmd.ErrOrStderr())
+				password = string(b)
+			}
+			if err != nil {
+				return err
+			}
+
+			c := apiclient.New(server, "")
+			resp, err := c.Login(cmd.Context(), email, password)
+			if err != nil {
+				return err
+			}
+
+			cfg.Server = c.Server
+			cfg.AccessToken = resp.Tokens.AccessToken
+			cfg.RefreshToken = resp.Tokens.RefreshToken
+			cfg.ExpiresAt = resp.Tokens.ExpiresAt
+			if err := opts.saveConfig(cfg); err != nil {
+				return err
+			}
+
+			fmt.Fprintf(cmd.OutOrStdout(), "Logged in to %s as %s (%s)\n", c.Server, resp.User.Email, resp.User.Role)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&email, "email", "", "email to log in with, prompted for if not set")
+	cmd.Flags().BoolVar(&passwordStdin, "password-stdin", false, "read the password from stdin")
+	return cmd
+}
+
+func newLogoutCmd(opts *options) *cobra.Command {
+	return &cobra.Command{
+		Use:   "logout",
+		Short: "Remove the saved session",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := opts.loadConfig()
+			if err != nil {
+				return err
+			}
+			cfg.AccessToken = ""
+			cfg.RefreshToken = ""
+			cfg.ExpiresAt = time.Time{}
+			return opts.saveConfig(cfg)
+		},
+	}
+}
+
+// readLine reads a line without its line ending
+func readLine(r *bufio.Reader) (string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil && (err != io.EOF || line == "") {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
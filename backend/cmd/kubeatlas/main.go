@@ -0,0 +1,174 @@
+// Command kubeatlas is the KubeAtlas command line client. It logs in to a
+// KubeAtlas server and lists, updates and syncs its inventory through the
+// REST API.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/kubeatlas/kubeatlas/internal/apiclient"
+	"github.com/spf13/cobra"
+)
+
+var (
+	Version   = "dev"
+	GitCommit = "unknown"
+)
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := newRootCmd().ExecuteContext(ctx); err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		os.Exit(1)
+	}
+}
+
+// options are the global flags
+type options struct {
+	server     string
+	token      string
+	output     string
+	configPath string
+}
+
+func newRootCmd() *cobra.Command {
+	opts := &options{}
+
+	root := &cobra.Command{
+		Use:           "kubeatlas",
+		Short:         "Command line client for KubeAtlas",
+		Version:       fmt.Sprintf("%s (%s)", Version, GitCommit),
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			switch opts.output {
+			case outputTable, outputJSON, outputYAML:
+				return nil
+			default:
+				return fmt.Errorf("unknown output format %q, want table, json or yaml", opts.output)
+			}
+		},
+	}
+
+	flags := root.PersistentFlags()
+	flags.StringVar(&opts.server, "server", os.Getenv("KUBEATLAS_SERVER"), "KubeAtlas server URL (env KUBEATLAS_SERVER, defaults to the server logged in to)")
+	flags.StringVar(&opts.token, "token", os.Getenv("KUBEATLAS_TOKEN"), "access token (env KUBEATLAS_TOKEN, defaults to the token saved by login)")
+	flags.StringVarP(&opts.output, "output", "o", outputTable, "output format: table, json or yaml")
+	flags.StringVar(&opts.configPath, "config", os.Getenv("KUBEATLAS_CONFIG"), "config file (env KUBEATLAS_CONFIG, defaults to kubeatlas/config.json in the user config directory)")
+
+	root.AddCommand(
+		newLoginCmd(opts),
+		newLogoutCmd(opts),
+		newApplyCmd(opts),
+		newCheckCmd(opts),
+		newNamespacesCmd(opts),
+		newClustersCmd(opts),
+	)
+	return root
+}
+
+// cliConfig is the config file written by login
+type cliConfig struct {
+	Server       string    `json:"server"`
+	AccessToken  string    `json:"access_token"`
+	RefreshToken string    `json:"refresh_token"`
+	ExpiresAt    time.Time `json:"expires_at"`
+}
+
+func (o *options) configFile() (string, error) {
+	if o.configPath != "" {
+		return o.configPath, nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "kubeatlas", "config.json"), nil
+}
+
+// loadConfig reads the config file, returning an empty config if there is none
+func (o *options) loadConfig() (*cliConfig, error) {
+	path, err := o.configFile()
+	if err != nil {
+		return nil, err
+	}
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &cliConfig{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var cfg cliConfig
+	if err := json.Unmarshal(b, &cfg); err != nil {
+		return nil, fmt.Errorf("reading %s: %w", path, err)
+	}
+	return &cfg, nil
+}
+
+// saveConfig writes the config file, readable only by the user since it
+// holds tokens
+func (o *options) saveConfig(cfg *cliConfig) error {
+	path, err := o.configFile()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	b, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, b, 0o600)
+}
+
+// client returns an API client for the selected server. The saved access
+// token is refreshed when it has expired.
+func (o *options) client(ctx context.Context) (*apiclient.Client, error) {
+	cfg, err := o.loadConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	server := strings.TrimRight(o.server, "/")
+	if server == "" {
+		server = cfg.Server
+	}
+	if server == "" {
+		return nil, errors.New("no server selected, run kubeatlas login or set --server")
+	}
+	if o.token != "" {
+		return apiclient.New(server, o.token), nil
+	}
+	if cfg.AccessToken == "" || server != cfg.Server {
+		return nil, fmt.Errorf("not logged in to %s, run kubeatlas login or set --token", server)
+	}
+
+	c := apiclient.New(server, cfg.AccessToken)
+	if !cfg.ExpiresAt.IsZero() && time.Now().After(cfg.ExpiresAt.Add(-time.Minute)) && cfg.RefreshToken != "" {
+		tokens, err := c.Refresh(ctx, cfg.RefreshToken)
+		if err != nil {
+			return nil, fmt.Errorf("session expired, run kubeatlas login: %w", err)
+		}
+		cfg.AccessToken = tokens.AccessToken
+		cfg.RefreshToken = tokens.RefreshToken
+		cfg.ExpiresAt = tokens.ExpiresAt
+		if err := o.saveConfig(cfg); err != nil {
+			return nil, err
+		}
+		c.Token = tokens.AccessToken
+	}
+	return c, nil
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"errors"
+	"net/url"
+	"strconv"
+
+	"github.com/spf13/cobra"
+)
+
+var namespaceColumns = []column{
+	{"ID", "id"},
+	{"NAME", "name"},
+	{"CLUSTER", "cluster.name"},
+	{"ENVIRONMENT", "environment"},
+	{"CRITICALITY", "criticality"},
+	{"OWNER TEAM", "infrastructure_owner_team.name"},
+	{"BUSINESS UNIT", "business_unit.name"},
+	{"STATUS", "status"},
+}
+
+func newNamespacesCmd(opts *options) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "namespaces",
+		Aliases: []string{"namespace", "ns"},
+		Short:   "List and update namespaces",
+	}
+	cmd.AddCommand(newNamespacesListCmd(opts), newNamespacesSetOwnerCmd(opts))
+	return cmd
+}
+
+func newNamespacesListCmd(opts *options) *cobra.Command {
+	var f struct {
+		cluster, environment, criticality, status string
+		businessUnit, team, search                string
+		orphaned                                  bool
+		page, pageSize                            int
+	}
+
+	cmd := &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "List namespaces",
+		Example: "  kubeatlas namespaces list --environment production --orphaned\n" +
+			"  kubeatlas namespaces list --search payments -o yaml",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := opts.client(cmd.Context())
+			if err != nil {
+				return err
+			}
+
+			q := url.Values{}
+			setQuery(q, "cluster_id", f.cluster)
+			setQuery(q, "environment", f.environment)
+			setQuery(q, "criticality", f.criticality)
+			setQuery(q, "status", f.status)
+			setQuery(q, "business_unit_id", f.businessUnit)
+			setQuery(q, "team_id", f.team)
+			setQuery(q, "search", f.search)
+			if f.orphaned {
+				q.Set("orphaned", "true")
+			}
+			setPage(q, f.page, f.pageSize)
+
+			page, err := c.ListNamespaces(cmd.Context(), q)
+			if err != nil {
+				return err
+			}
+			return printItems(cmd.OutOrStdout(), opts.output, namespaceColumns, page.Items)
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVar(&f.cluster, "cluster", "", "only namespaces of this cluster ID")
+	flags.StringVar(&f.environment, "environment", "", "only namespaces in this environment")
+	flags.StringVar(&f.criticality, "criticality", "", "only namespaces with this criticality tier")
+	flags.StringVar(&f.status, "status", "", "only namespaces with this status")
+	flags.StringVar(&f.businessUnit, "business-unit", "", "only namespaces of this business unit ID")
+	flags.StringVar(&f.team, "team", "", "only namespaces owned by this team ID")
+	flags.StringVar(&f.search, "search", "", "only namespaces whose name or description matches")
+	flags.BoolVar(&f.orphaned, "orphaned", false, "only namespaces without an owner")
+	flags.IntVar(&f.page, "page", 0, "page to show")
+	flags.IntVar(&f.pageSize, "page-size", 0, "namespaces per page")
+	return cmd
+}
+
+func newNamespacesSetOwnerCmd(opts *options) *cobra.Command {
+	var team, user, businessUnit string
+
+	cmd := &cobra.Command{
+		Use:     "set-owner <namespace-id>",
+		Short:   "Set the owning team, user or business unit of a namespace",
+		Example: "  kubeatlas namespaces set-owner 6f1c... --team 2b9e... --business-unit 8a04...",
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			update := map[string]interface{}{}
+			if team != "" {
+				update["infrastructure_owner_team_id"] = team
+			}
+			if user != "" {
+				update["infrastructure_owner_user_id"] = user
+			}
+			if businessUnit != "" {
+				update["business_unit_id"] = businessUnit
+			}
+			if len(update) == 0 {
+				return errors.New("set at least one of --team, --user and --business-unit")
+			}
+
+			c, err := opts.client(cmd.Context())
+			if err != nil {
+				return err
+			}
+			ns, err := c.UpdateNamespace(cmd.Context(), args[0], update)
+			if err != nil {
+				return err
+			}
+			return printItems(cmd.OutOrStdout(), opts.output, namespaceColumns, []map[string]interface{}{ns})
+		},
+	}
+
+	cmd.Flags().StringVar(&team, "team", "", "ID of the owning team")
+	cmd.Flags().StringVar(&user, "user", "", "ID of the owning user")
+	cmd.Flags().StringVar(&businessUnit, "business-unit", "", "ID of the business unit")
+	return cmd
+}
+
+func setQuery(q url.Values, key, value string) {
+	if value != "" {
+		q.Set(key, value)
+	}
+}
+
+func setPage(q url.Values, page, pageSize int) {
+	if page > 0 {
+		q.Set("page", strconv.Itoa(page))
+	}
+	if pageSize > 0 {
+		q.Set("page_size", strconv.Itoa(pageSize))
+	}
+}
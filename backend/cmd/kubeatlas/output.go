@@ -0,0 +1,109 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"sigs.k8s.io/yaml"
+)
+
+// Output formats
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+	outputYAML  = "yaml"
+)
+
+// column is a table column showing the field at a dotted path of each item
+type column struct {
+	header string
+	path   string
+}
+
+// printItems writes items in the selected output format. Tables show the
+// given columns; JSON and YAML show the items as returned by the API.
+func printItems(w io.Writer, format string, columns []column, items []map[string]interface{}) error {
+	switch format {
+	case outputJSON, outputYAML:
+		if items == nil {
+			items = []map[string]interface{}{}
+		}
+		return printValue(w, format, items)
+	case outputTable:
+		tw := tabwriter.NewWriter(w, 0, 0, 3, ' ', 0)
+		headers := make([]string, len(columns))
+		for i, col := range columns {
+			headers[i] = col.header
+		}
+		fmt.Fprintln(tw, strings.Join(headers, "\t"))
+		for _, item := range items {
+			cells := make([]string, len(columns))
+			for i, col := range columns {
+				cells[i] = cell(lookup(item, col.path))
+			}
+			fmt.Fprintln(tw, strings.Join(cells, "\t"))
+		}
+		return tw.Flush()
+	default:
+		return fmt.Errorf("unknown output format %q, want table, json or yaml", format)
+	}
+}
+
+// printValue writes a single value as JSON or YAML
+func printValue(w io.Writer, format string, v interface{}) error {
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	if format == outputYAML {
+		if b, err = yaml.JSONToYAML(b); err != nil {
+			return err
+		}
+		_, err = w.Write(b)
+		return err
+	}
+	_, err = fmt.Fprintln(w, string(b))
+	return err
+}
+
+// lookup returns the field at a dotted path, or nil if it is not set
+func lookup(item map[string]interface{}, path string) interface{} {
+	var v interface{} = item
+	for _, key := range strings.Split(path, ".") {
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		v = m[key]
+	}
+	return v
+}
+
+// cell formats a field for a table
+func cell(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return "-"
+	case string:
+		if v == "" {
+			return "-"
+		}
+		return v
+	case float64:
+		return fmt.Sprintf("%g", v)
+	case []interface{}:
+		parts := make([]string, len(v))
+		for i, e := range v {
+			parts[i] = cell(e)
+		}
+		if len(parts) == 0 {
+			return "-"
+		}
+		return strings.Join(parts, ",")
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,109 @@
+// Command migrate applies, reverts and lists the KubeAtlas database
+// migrations. The API applies pending migrations on start; this command runs
+// them ahead of a rollout and reverts them when a release is rolled back.
+//
+//	kubeatlas-migrate [up]            apply pending migrations
+//	kubeatlas-migrate down [-steps N] revert the last N migrations (default 1)
+//	kubeatlas-migrate status          list migrations and whether they are applied
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"text/tabwriter"
+
+	"github.com/kubeatlas/kubeatlas/internal/config"
+	"github.com/kubeatlas/kubeatlas/internal/database"
+	"go.uber.org/zap"
+)
+
+var (
+	Version   = "dev"
+	GitCommit = "unknown"
+)
+
+func main() {
+	logger, _ := zap.NewProduction()
+	defer logger.Sync()
+	sugar := logger.Sugar()
+
+	command := "up"
+	args := os.Args[1:]
+	if len(args) > 0 {
+		command, args = args[0], args[1:]
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		sugar.Fatalw("Failed to load configuration", "error", err)
+	}
+	db, err := database.New(cfg.Database)
+	if err != nil {
+		sugar.Fatalw("Failed to connect to database", "error", err)
+	}
+	defer db.Close()
+
+	migrator, err := database.NewMigrator(db.Pool)
+	if err != nil {
+		sugar.Fatalw("Failed to load migrations", "error", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	switch command {
+	case "up":
+		sugar.Infow("Applying migrations", "version", Version, "git_commit", GitCommit)
+		applied, err := migrator.Up(ctx)
+		for _, m := range applied {
+			sugar.Infow("Applied migration", "version", m.Version, "name", m.Name)
+		}
+		if err != nil {
+			sugar.Fatalw("Failed to apply migrations", "error", err)
+		}
+		sugar.Infow("Migrations up to date", "applied", len(applied))
+
+	case "down":
+		flags := flag.NewFlagSet("down", flag.ExitOnError)
+		steps := flags.Int("steps", 1, "number of migrations to revert")
+		flags.Parse(args)
+		if *steps < 1 {
+			sugar.Fatal("-steps must be at least 1")
+		}
+		reverted, err := migrator.Down(ctx, *steps)
+		for _, m := range reverted {
+			sugar.Infow("Reverted migration", "version", m.Version, "name", m.Name)
+		}
+		if err != nil {
+			sugar.Fatalw("Failed to revert migrations", "error", err)
+		}
+		sugar.Infow("Migrations reverted", "reverted", len(reverted))
+
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			sugar.Fatalw("Failed to read migration status", "error", err)
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED\tREVERSIBLE")
+		for _, s := range statuses {
+			applied := "no"
+			if s.AppliedAt != nil {
+				applied = s.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			if s.Missing {
+				applied += " (unknown to this release)"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%t\n", s.Version, s.Name, applied, s.Reversible)
+		}
+		w.Flush()
+
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q: use up, down or status\n", command)
+		os.Exit(2)
+	}
+}
@@ -0,0 +1,100 @@
+// Command operator is the KubeAtlas namespace operator. It runs in a workload
+// cluster and pushes the ownership declared in NamespaceOwnership resources
+// to KubeAtlas, reporting the outcome on each resource's status.
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/kubeatlas/kubeatlas/internal/apiclient"
+	"github.com/kubeatlas/kubeatlas/internal/operator"
+	"go.uber.org/zap"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+var (
+	Version   = "dev"
+	GitCommit = "unknown"
+)
+
+func main() {
+	logger, _ := zap.NewProduction()
+	defer logger.Sync()
+	sugar := logger.Sugar()
+
+	sugar.Infow("Starting KubeAtlas namespace operator", "version", Version, "git_commit", GitCommit)
+
+	server := os.Getenv("KUBEATLAS_SERVER")
+	cluster := os.Getenv("KUBEATLAS_CLUSTER")
+	if server == "" || cluster == "" {
+		sugar.Fatal("KUBEATLAS_SERVER and KUBEATLAS_CLUSTER are required")
+	}
+	email := os.Getenv("KUBEATLAS_EMAIL")
+	if email == "" && os.Getenv("KUBEATLAS_TOKEN") == "" {
+		sugar.Fatal("Set KUBEATLAS_EMAIL and KUBEATLAS_PASSWORD, or KUBEATLAS_TOKEN")
+	}
+	resync, err := time.ParseDuration(getEnv("OPERATOR_RESYNC_INTERVAL", "10m"))
+	if err != nil || resync <= 0 {
+		sugar.Fatalw("Invalid OPERATOR_RESYNC_INTERVAL", "error", err)
+	}
+	workers, err := strconv.Atoi(getEnv("OPERATOR_WORKERS", "2"))
+	if err != nil || workers < 1 {
+		sugar.Fatalw("Invalid OPERATOR_WORKERS", "error", err)
+	}
+
+	config, err := rest.InClusterConfig()
+	if errors.Is(err, rest.ErrNotInCluster) {
+		// Outside a cluster, such as during development, use the kubeconfig
+		config, err = clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
+			clientcmd.NewDefaultClientConfigLoadingRules(), &clientcmd.ConfigOverrides{}).ClientConfig()
+	}
+	if err != nil {
+		sugar.Fatalw("Failed to load Kubernetes config", "error", err)
+	}
+	client, err := dynamic.NewForConfig(config)
+	if err != nil {
+		sugar.Fatalw("Failed to create Kubernetes client", "error", err)
+	}
+
+	api := operator.NewSession(apiclient.New(server, os.Getenv("KUBEATLAS_TOKEN")), email, os.Getenv("KUBEATLAS_PASSWORD"))
+	controller := operator.NewController(client, api, cluster, resync, sugar)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Liveness probe
+	health := &http.Server{
+		Addr: getEnv("OPERATOR_HEALTH_ADDR", ":8081"),
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		}),
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	go func() {
+		if err := health.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			sugar.Errorw("Health server failed", "error", err)
+		}
+	}()
+	defer health.Close()
+
+	if err := controller.Run(ctx, workers); err != nil {
+		sugar.Fatalw("Namespace operator failed", "error", err)
+	}
+	sugar.Info("Namespace operator stopped")
+}
+
+func getEnv(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
@@ -0,0 +1,64 @@
+// Package accesslog builds the optional JSON access log sink that runs
+// alongside the application logger.
+package accesslog
+
+import (
+	"fmt"
+	"io"
+	"log/syslog"
+	"os"
+	"path/filepath"
+
+	"github.com/kubeatlas/kubeatlas/internal/config"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+// New returns a logger writing one JSON line per request to the configured
+// sink and a function that closes the sink. It returns a nil logger when no
+// sink is configured.
+func New(cfg config.AccessLogConfig) (*zap.Logger, func() error, error) {
+	var out io.WriteCloser
+
+	switch cfg.Sink {
+	case "":
+		return nil, func() error { return nil }, nil
+	case "file":
+		if err := os.MkdirAll(filepath.Dir(cfg.Path), 0o750); err != nil {
+			return nil, nil, fmt.Errorf("failed to create access log directory: %w", err)
+		}
+		out = &lumberjack.Logger{
+			Filename:   cfg.Path,
+			MaxSize:    cfg.MaxSizeMB,
+			MaxBackups: cfg.MaxBackups,
+			MaxAge:     cfg.MaxAgeDays,
+			Compress:   cfg.Compress,
+		}
+	case "syslog":
+		if cfg.Address == "" {
+			return nil, nil, fmt.Errorf("ACCESS_LOG_SYSLOG_ADDRESS is required for the syslog sink")
+		}
+		w, err := syslog.Dial(cfg.Network, cfg.Address, syslog.LOG_INFO|syslog.LOG_LOCAL0, cfg.Tag)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to connect to syslog: %w", err)
+		}
+		out = w
+	default:
+		return nil, nil, fmt.Errorf("unknown access log sink %q", cfg.Sink)
+	}
+
+	encoderCfg := zap.NewProductionEncoderConfig()
+	encoderCfg.TimeKey = "time"
+	encoderCfg.EncodeTime = zapcore.ISO8601TimeEncoder
+	encoderCfg.CallerKey = zapcore.OmitKey
+	encoderCfg.StacktraceKey = zapcore.OmitKey
+
+	core := zapcore.NewCore(zapcore.NewJSONEncoder(encoderCfg), zapcore.AddSync(out), zap.InfoLevel)
+	logger := zap.New(core)
+
+	return logger, func() error {
+		logger.Sync()
+		return out.Close()
+	}, nil
+}
@@ -0,0 +1,199 @@
+// Package admission is a validating admission webhook that checks new
+// namespaces for the labels and annotations KubeAtlas maps to the metadata
+// their organization requires
+package admission
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kubeatlas/kubeatlas/internal/apiclient"
+	"go.uber.org/zap"
+	admissionv1 "k8s.io/api/admission/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// What the webhook does with namespaces that lack required metadata
+const (
+	ModeDeny = "deny" // reject them
+	ModeWarn = "warn" // admit them with a warning to the client
+)
+
+// maxReviewSize caps the AdmissionReview bodies read
+const maxReviewSize = 1 << 20
+
+// PolicySource gets the organization's admission policy from KubeAtlas
+type PolicySource interface {
+	AdmissionPolicy(ctx context.Context) (*apiclient.AdmissionPolicy, error)
+}
+
+// Webhook reviews namespace creations against the admission policy, which
+// it refreshes from KubeAtlas in the background. Until a policy has been
+// loaded every namespace is admitted.
+type Webhook struct {
+	source PolicySource
+	mode   string
+	exempt map[string]bool
+	logger *zap.SugaredLogger
+
+	mu     sync.RWMutex
+	policy *apiclient.AdmissionPolicy
+}
+
+// NewWebhook returns a webhook that handles namespaces lacking metadata as
+// mode says. Namespaces named in exempt are always admitted.
+func NewWebhook(source PolicySource, mode string, exempt []string, logger *zap.SugaredLogger) (*Webhook, error) {
+	if mode != ModeDeny && mode != ModeWarn {
+		return nil, fmt.Errorf("unknown mode %q, want deny or warn", mode)
+	}
+	w := &Webhook{source: source, mode: mode, exempt: make(map[string]bool), logger: logger}
+	for _, name := range exempt {
+		if name = strings.TrimSpace(name); name != "" {
+			w.exempt[name] = true
+		}
+	}
+	return w, nil
+}
+
+// Run refreshes the policy every interval until ctx is done. A failed
+// refresh keeps the previous policy.
+func (w *Webhook) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		w.refresh(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (w *Webhook) refresh(ctx context.Context) {
+	policy, err := w.source.AdmissionPolicy(ctx)
+	if err != nil {
+		w.logger.Warnw("Failed to refresh admission policy", "error", err)
+		return
+	}
+	w.mu.Lock()
+	w.policy = policy
+	w.mu.Unlock()
+}
+
+// Ready reports whether a policy has been loaded
+func (w *Webhook) Ready() bool {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.policy != nil
+}
+
+// ServeHTTP answers an AdmissionReview from the API server
+func (w *Webhook) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(rw, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var review admissionv1.AdmissionReview
+	if err := json.NewDecoder(http.MaxBytesReader(rw, r.Body, maxReviewSize)).Decode(&review); err != nil || review.Request == nil {
+		http.Error(rw, "invalid AdmissionReview", http.StatusBadRequest)
+		return
+	}
+
+	review.Response = w.review(review.Request)
+	review.Response.UID = review.Request.UID
+	review.Request = nil
+
+	rw.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(rw).Encode(&review); err != nil {
+		w.logger.Errorw("Failed to write AdmissionReview", "error", err)
+	}
+}
+
+func (w *Webhook) review(req *admissionv1.AdmissionRequest) *admissionv1.AdmissionResponse {
+	allowed := &admissionv1.AdmissionResponse{Allowed: true}
+	if req.Operation != admissionv1.Create || req.Kind.Kind != "Namespace" {
+		return allowed
+	}
+
+	var ns metav1.PartialObjectMetadata
+	if err := json.Unmarshal(req.Object.Raw, &ns); err != nil {
+		return &admissionv1.AdmissionResponse{Result: &metav1.Status{
+			Status:  metav1.StatusFailure,
+			Code:    http.StatusBadRequest,
+			Message: "invalid namespace: " + err.Error(),
+		}}
+	}
+	if w.exempt[ns.Name] {
+		return allowed
+	}
+
+	w.mu.RLock()
+	policy := w.policy
+	w.mu.RUnlock()
+	if policy == nil {
+		return allowed
+	}
+
+	missing := Missing(policy, ns.Labels, ns.Annotations)
+	if len(missing) == 0 {
+		return allowed
+	}
+	message := fmt.Sprintf("namespace %q is missing metadata required by KubeAtlas: %s", ns.Name, strings.Join(missing, "; "))
+	if w.mode == ModeWarn {
+		allowed.Warnings = []string{message}
+		return allowed
+	}
+	w.logger.Infow("Rejected namespace without required metadata", "namespace", ns.Name, "user", req.UserInfo.Username)
+	return &admissionv1.AdmissionResponse{Result: &metav1.Status{
+		Status:  metav1.StatusFailure,
+		Code:    http.StatusForbidden,
+		Reason:  metav1.StatusReasonForbidden,
+		Message: message,
+	}}
+}
+
+// Missing describes each requirement of policy that no rule matches in
+// labels and annotations, naming the labels and annotations that would
+// meet it
+func Missing(policy *apiclient.AdmissionPolicy, labels, annotations map[string]string) []string {
+	var missing []string
+	for _, req := range policy.Requirements {
+		met := false
+		keys := make([]string, 0, len(req.Rules))
+		for _, rule := range req.Rules {
+			if matches(rule, labels, annotations) {
+				met = true
+				break
+			}
+			key := rule.Source + " " + rule.Key
+			if rule.Value != "" {
+				key += "=" + rule.Value
+			}
+			keys = append(keys, key)
+		}
+		if !met {
+			missing = append(missing, fmt.Sprintf("%s (set %s)", req.Requirement, strings.Join(keys, " or ")))
+		}
+	}
+	return missing
+}
+
+// matches reports whether rule finds a value in labels or annotations, as a
+// cluster sync would map it
+func matches(rule apiclient.MappingRule, labels, annotations map[string]string) bool {
+	source := labels
+	if rule.Source == "annotation" {
+		source = annotations
+	}
+	value, ok := source[rule.Key]
+	if !ok || (rule.Value != "" && value != rule.Value) {
+		return false
+	}
+	return strings.TrimSpace(value) != ""
+}
@@ -0,0 +1,141 @@
+package admission
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/kubeatlas/kubeatlas/internal/apiclient"
+	"go.uber.org/zap"
+	admissionv1 "k8s.io/api/admission/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+type fakeSource struct {
+	policy *apiclient.AdmissionPolicy
+}
+
+func (f *fakeSource) AdmissionPolicy(ctx context.Context) (*apiclient.AdmissionPolicy, error) {
+	return f.policy, nil
+}
+
+var testPolicy = &apiclient.AdmissionPolicy{
+	Requirements: []apiclient.AdmissionRequirement{
+		{Requirement: "owner", Rules: []apiclient.MappingRule{
+			{Source: "label", Key: "team", Field: "owner_team"},
+			{Source: "annotation", Key: "kubeatlas.io/owner", Field: "owner_team"},
+		}},
+		{Requirement: "criticality", Rules: []apiclient.MappingRule{
+			{Source: "label", Key: "tier", Value: "gold", Field: "criticality"},
+		}},
+	},
+}
+
+func TestMissing(t *testing.T) {
+	tests := []struct {
+		name        string
+		labels      map[string]string
+		annotations map[string]string
+		want        int
+	}{
+		{"all met", map[string]string{"team": "payments", "tier": "gold"}, nil, 0},
+		{"met by annotation", map[string]string{"tier": "gold"}, map[string]string{"kubeatlas.io/owner": "payments"}, 0},
+		{"value does not match", map[string]string{"team": "payments", "tier": "silver"}, nil, 1},
+		{"empty value", map[string]string{"team": " ", "tier": "gold"}, nil, 1},
+		{"nothing", nil, nil, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Missing(testPolicy, tt.labels, tt.annotations); len(got) != tt.want {
+				t.Errorf("Missing() = %v, want %d missing", got, tt.want)
+			}
+		})
+	}
+
+	got := Missing(testPolicy, nil, nil)
+	if got[0] != "owner (set label team or annotation kubeatlas.io/owner)" || got[1] != "criticality (set label tier=gold)" {
+		t.Errorf("Missing() = %q", got)
+	}
+}
+
+func review(t *testing.T, w *Webhook, op admissionv1.Operation, name string, labels map[string]string) *admissionv1.AdmissionResponse {
+	t.Helper()
+	ns, _ := json.Marshal(metav1.PartialObjectMetadata{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Namespace"},
+		ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels},
+	})
+	body, _ := json.Marshal(admissionv1.AdmissionReview{
+		TypeMeta: metav1.TypeMeta{APIVersion: "admission.k8s.io/v1", Kind: "AdmissionReview"},
+		Request: &admissionv1.AdmissionRequest{
+			UID:       "uid-1",
+			Kind:      metav1.GroupVersionKind{Version: "v1", Kind: "Namespace"},
+			Operation: op,
+			Name:      name,
+			Object:    runtime.RawExtension{Raw: ns},
+		},
+	})
+
+	rec := httptest.NewRecorder()
+	w.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/validate", bytes.NewReader(body)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", rec.Code, rec.Body.String())
+	}
+	var resp admissionv1.AdmissionReview
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil || resp.Response == nil {
+		t.Fatalf("invalid response %s: %v", rec.Body.String(), err)
+	}
+	if resp.Response.UID != "uid-1" {
+		t.Errorf("response UID = %q, want the request's", resp.Response.UID)
+	}
+	return resp.Response
+}
+
+func TestWebhookReview(t *testing.T) {
+	source := &fakeSource{policy: testPolicy}
+	deny, err := NewWebhook(source, ModeDeny, []string{"kube-system", " "}, zap.NewNop().Sugar())
+	if err != nil {
+		t.Fatalf("NewWebhook failed: %v", err)
+	}
+	warn, _ := NewWebhook(source, ModeWarn, nil, zap.NewNop().Sugar())
+
+	// No policy has been loaded yet
+	if resp := review(t, deny, admissionv1.Create, "payments", nil); !resp.Allowed {
+		t.Error("namespace denied before the policy was loaded")
+	}
+	deny.refresh(context.Background())
+	warn.refresh(context.Background())
+	if !deny.Ready() {
+		t.Fatal("webhook not ready after refresh")
+	}
+
+	resp := review(t, deny, admissionv1.Create, "payments", map[string]string{"team": "payments"})
+	if resp.Allowed || resp.Result == nil || resp.Result.Code != http.StatusForbidden {
+		t.Errorf("deny mode response = %+v, want forbidden", resp)
+	} else if !strings.Contains(resp.Result.Message, "criticality") || strings.Contains(resp.Result.Message, "owner") {
+		t.Errorf("message = %q, want only criticality missing", resp.Result.Message)
+	}
+
+	resp = review(t, warn, admissionv1.Create, "payments", nil)
+	if !resp.Allowed || len(resp.Warnings) != 1 {
+		t.Errorf("warn mode response = %+v, want allowed with a warning", resp)
+	}
+
+	if resp := review(t, deny, admissionv1.Create, "payments", map[string]string{"team": "payments", "tier": "gold"}); !resp.Allowed {
+		t.Errorf("complete namespace denied: %+v", resp.Result)
+	}
+	if resp := review(t, deny, admissionv1.Create, "kube-system", nil); !resp.Allowed {
+		t.Error("exempt namespace denied")
+	}
+	if resp := review(t, deny, admissionv1.Update, "payments", nil); !resp.Allowed {
+		t.Error("namespace update denied")
+	}
+
+	if _, err := NewWebhook(source, "audit", nil, zap.NewNop().Sugar()); err == nil {
+		t.Error("NewWebhook accepted an unknown mode")
+	}
+}
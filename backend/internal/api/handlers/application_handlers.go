@@ -0,0 +1,249 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/kubeatlas/kubeatlas/internal/services"
+)
+
+// ============================================
+// Application Handlers
+// ============================================
+
+// ListApplications lists the applications of the organization, by name
+func ListApplications(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		filters := make(map[string]interface{})
+		if search := c.Query("search"); search != "" {
+			filters["search"] = search
+		}
+		if teamID := c.Query("owner_team_id"); teamID != "" {
+			if id, err := uuid.Parse(teamID); err == nil {
+				filters["owner_team_id"] = id
+			}
+		}
+
+		result, err := svc.Application.List(c.Request.Context(), getAuditContext(c).OrgID, getPagination(c), filters)
+		if err != nil {
+			respondApplicationError(c, "ListApplications", err, "Failed to list applications")
+			return
+		}
+
+		respondPaginated(c, result.Items, result.Total, result.Page, result.PageSize, result.TotalPages)
+	}
+}
+
+// GetApplication returns an application
+func GetApplication(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := parseUUID(c, "id")
+		if !ok {
+			return
+		}
+
+		app, err := svc.Application.GetByID(c.Request.Context(), getAuditContext(c).OrgID, id)
+		if err != nil {
+			respondApplicationError(c, "GetApplication", err, "Failed to get application")
+			return
+		}
+
+		respondSuccess(c, app)
+	}
+}
+
+// CreateApplication creates an application
+func CreateApplication(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req services.ApplicationRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			respondError(c, http.StatusBadRequest, err)
+			return
+		}
+
+		app, err := svc.Application.Create(c.Request.Context(), getAuditContext(c), req)
+		if err != nil {
+			respondApplicationError(c, "CreateApplication", err, "Failed to create application")
+			return
+		}
+
+		c.JSON(http.StatusCreated, SuccessResponse{Data: app})
+	}
+}
+
+// UpdateApplication replaces the details and owner of an application
+func UpdateApplication(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := parseUUID(c, "id")
+		if !ok {
+			return
+		}
+		var req services.ApplicationRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			respondError(c, http.StatusBadRequest, err)
+			return
+		}
+
+		app, err := svc.Application.Update(c.Request.Context(), getAuditContext(c), id, req)
+		if err != nil {
+			respondApplicationError(c, "UpdateApplication", err, "Failed to update application")
+			return
+		}
+
+		respondSuccess(c, app)
+	}
+}
+
+// DeleteApplication deletes an application and its documents
+func DeleteApplication(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := parseUUID(c, "id")
+		if !ok {
+			return
+		}
+
+		if err := svc.Application.Delete(c.Request.Context(), getAuditContext(c), id); err != nil {
+			respondApplicationError(c, "DeleteApplication", err, "Failed to delete application")
+			return
+		}
+
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// ListApplicationNamespaces lists the namespaces of an application with
+// the namespace list filters
+func ListApplicationNamespaces(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := parseUUID(c, "id")
+		if !ok {
+			return
+		}
+		orgID := getAuditContext(c).OrgID
+		if _, err := svc.Application.GetByID(c.Request.Context(), orgID, id); err != nil {
+			respondApplicationError(c, "ListApplicationNamespaces", err, "Failed to list application namespaces")
+			return
+		}
+
+		filters, err := namespaceListFilters(c.Request.URL.Query())
+		if err != nil {
+			respondErrorStr(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		filters["application_id"] = id
+		filters["include_relations"] = true
+
+		result, err := svc.Namespace.List(c.Request.Context(), orgID, getPagination(c), filters)
+		if err != nil {
+			respondApplicationError(c, "ListApplicationNamespaces", err, "Failed to list application namespaces")
+			return
+		}
+
+		respondPaginated(c, result.Items, result.Total, result.Page, result.PageSize, result.TotalPages)
+	}
+}
+
+// AddApplicationNamespaces adds namespaces to an application, moving them
+// from the application they belonged to
+func AddApplicationNamespaces(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := parseUUID(c, "id")
+		if !ok {
+			return
+		}
+		var req services.ApplicationNamespacesRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			respondError(c, http.StatusBadRequest, err)
+			return
+		}
+
+		app, err := svc.Application.AddNamespaces(c.Request.Context(), getAuditContext(c), id, req)
+		if err != nil {
+			respondApplicationError(c, "AddApplicationNamespaces", err, "Failed to add namespaces")
+			return
+		}
+
+		respondSuccess(c, app)
+	}
+}
+
+// RemoveApplicationNamespace removes a namespace from an application
+func RemoveApplicationNamespace(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := parseUUID(c, "id")
+		if !ok {
+			return
+		}
+		namespaceID, ok := parseUUID(c, "namespaceId")
+		if !ok {
+			return
+		}
+
+		if err := svc.Application.RemoveNamespace(c.Request.Context(), getAuditContext(c), id, namespaceID); err != nil {
+			respondApplicationError(c, "RemoveApplicationNamespace", err, "Failed to remove namespace")
+			return
+		}
+
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// GetApplicationDependencies rolls up the dependencies of the namespaces
+// of an application
+func GetApplicationDependencies(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := parseUUID(c, "id")
+		if !ok {
+			return
+		}
+
+		deps, err := svc.Application.Dependencies(c.Request.Context(), getAuditContext(c).OrgID, id)
+		if err != nil {
+			respondApplicationError(c, "GetApplicationDependencies", err, "Failed to get application dependencies")
+			return
+		}
+
+		respondSuccess(c, deps)
+	}
+}
+
+// GetApplicationDashboard summarizes an application across its namespaces
+func GetApplicationDashboard(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := parseUUID(c, "id")
+		if !ok {
+			return
+		}
+
+		dashboard, err := svc.Application.Dashboard(c.Request.Context(), getAuditContext(c).OrgID, id)
+		if err != nil {
+			respondApplicationError(c, "GetApplicationDashboard", err, "Failed to get application dashboard")
+			return
+		}
+
+		respondSuccess(c, dashboard)
+	}
+}
+
+func respondApplicationError(c *gin.Context, op string, err error, message string) {
+	switch {
+	case errors.Is(err, services.ErrApplicationNotFound):
+		respondErrorStr(c, http.StatusNotFound, "Application not found")
+	case errors.Is(err, services.ErrNamespaceNotFound):
+		respondErrorStr(c, http.StatusNotFound, "Namespace not found")
+	case errors.Is(err, services.ErrTeamNotFound):
+		respondErrorStr(c, http.StatusBadRequest, "Owner team not found")
+	case errors.Is(err, services.ErrUserNotFound):
+		respondErrorStr(c, http.StatusBadRequest, "Owner user not found")
+	case errors.Is(err, services.ErrApplicationExists):
+		respondErrorStr(c, http.StatusConflict, err.Error())
+	case errors.Is(err, services.ErrInvalidApplication):
+		respondErrorStr(c, http.StatusBadRequest, err.Error())
+	default:
+		log.Printf("ERROR %s: %v", op, err)
+		respondErrorStr(c, http.StatusInternalServerError, message)
+	}
+}
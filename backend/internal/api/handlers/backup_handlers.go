@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kubeatlas/kubeatlas/internal/api/middleware"
+	"github.com/kubeatlas/kubeatlas/internal/services"
+)
+
+// ============================================
+// Metadata Backup Handlers
+// ============================================
+
+// CreateMetadataBackup snapshots the organization's teams, business units
+// and namespace ownership to object storage
+func CreateMetadataBackup(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		backup, err := svc.Backup.Backup(c.Request.Context(), getAuditContext(c))
+		if err != nil {
+			respondBackupError(c, "CreateMetadataBackup", err, "Failed to create backup")
+			return
+		}
+
+		c.JSON(http.StatusCreated, SuccessResponse{Data: backup})
+	}
+}
+
+// ImportMetadataBackup records a backup downloaded from another environment,
+// given as the request body, so it can be restored here
+func ImportMetadataBackup(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		backup, err := svc.Backup.Import(c.Request.Context(), getAuditContext(c), c.Request.Body)
+		if err != nil {
+			respondBackupError(c, "ImportMetadataBackup", err, "Failed to import backup")
+			return
+		}
+
+		c.JSON(http.StatusCreated, SuccessResponse{Data: backup})
+	}
+}
+
+// ListMetadataBackups lists the organization's backups, newest first
+func ListMetadataBackups(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		orgID, ok := middleware.GetOrganizationID(c)
+		if !ok {
+			respondErrorStr(c, http.StatusUnauthorized, "Organization ID not found")
+			return
+		}
+
+		backups, err := svc.Backup.List(c.Request.Context(), orgID)
+		if err != nil {
+			respondBackupError(c, "ListMetadataBackups", err, "Failed to list backups")
+			return
+		}
+
+		respondSuccess(c, backups)
+	}
+}
+
+// DownloadMetadataBackup streams the JSON document of a backup
+func DownloadMetadataBackup(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := parseUUID(c, "id")
+		if !ok {
+			return
+		}
+
+		rc, backup, err := svc.Backup.Open(c.Request.Context(), getAuditContext(c), id)
+		if err != nil {
+			respondBackupError(c, "DownloadMetadataBackup", err, "Failed to download backup")
+			return
+		}
+		defer rc.Close()
+
+		filename := "kubeatlas_metadata_" + backup.CreatedAt.UTC().Format("20060102T150405Z") + ".json"
+		c.Header("Content-Type", "application/json")
+		c.Header("Content-Disposition", "attachment; filename=\""+filename+"\"")
+		c.Header("Content-Length", strconv.FormatInt(backup.SizeBytes, 10))
+		c.Status(http.StatusOK)
+
+		if _, err := io.Copy(c.Writer, rc); err != nil {
+			log.Printf("ERROR DownloadMetadataBackup: %v", err)
+			c.Abort()
+		}
+	}
+}
+
+// RestoreMetadataBackup restores the sections of a backup given in the
+// body, or all of them without one
+func RestoreMetadataBackup(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := parseUUID(c, "id")
+		if !ok {
+			return
+		}
+		var req services.RestoreMetadataRequest
+		if err := json.NewDecoder(c.Request.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+			respondErrorStr(c, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+
+		restore, err := svc.Backup.Restore(c.Request.Context(), getAuditContext(c), id, req)
+		if err != nil {
+			respondBackupError(c, "RestoreMetadataBackup", err, "Failed to restore backup")
+			return
+		}
+
+		respondSuccess(c, restore)
+	}
+}
+
+func respondBackupError(c *gin.Context, op string, err error, message string) {
+	switch {
+	case errors.Is(err, services.ErrBackupNotFound):
+		respondErrorStr(c, http.StatusNotFound, "Backup not found")
+	case errors.Is(err, services.ErrInvalidBackup), errors.Is(err, services.ErrInvalidOwnershipImport):
+		respondErrorStr(c, http.StatusBadRequest, err.Error())
+	case errors.Is(err, services.ErrBackupUnavailable):
+		respondErrorStr(c, http.StatusServiceUnavailable, err.Error())
+	default:
+		log.Printf("ERROR %s: %v", op, err)
+		respondErrorStr(c, http.StatusInternalServerError, message)
+	}
+}
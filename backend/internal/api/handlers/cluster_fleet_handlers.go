@@ -0,0 +1,235 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/kubeatlas/kubeatlas/internal/services"
+)
+
+// ============================================
+// Cluster Fleet Handlers
+// ============================================
+
+// ListFleets lists the cluster fleets of the organization, by name
+func ListFleets(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		filters := make(map[string]interface{})
+		if search := c.Query("search"); search != "" {
+			filters["search"] = search
+		}
+		if clusterID := c.Query("cluster_id"); clusterID != "" {
+			if id, err := uuid.Parse(clusterID); err == nil {
+				filters["cluster_id"] = id
+			}
+		}
+
+		result, err := svc.Fleet.List(c.Request.Context(), getAuditContext(c).OrgID, getPagination(c), filters)
+		if err != nil {
+			respondFleetError(c, "ListFleets", err, "Failed to list fleets")
+			return
+		}
+
+		respondPaginated(c, result.Items, result.Total, result.Page, result.PageSize, result.TotalPages)
+	}
+}
+
+// GetFleet returns a cluster fleet
+func GetFleet(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := parseUUID(c, "id")
+		if !ok {
+			return
+		}
+
+		fleet, err := svc.Fleet.GetByID(c.Request.Context(), getAuditContext(c).OrgID, id)
+		if err != nil {
+			respondFleetError(c, "GetFleet", err, "Failed to get fleet")
+			return
+		}
+
+		respondSuccess(c, fleet)
+	}
+}
+
+// CreateFleet creates a cluster fleet
+func CreateFleet(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req services.FleetRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			respondError(c, http.StatusBadRequest, err)
+			return
+		}
+
+		fleet, err := svc.Fleet.Create(c.Request.Context(), getAuditContext(c), req)
+		if err != nil {
+			respondFleetError(c, "CreateFleet", err, "Failed to create fleet")
+			return
+		}
+
+		c.JSON(http.StatusCreated, SuccessResponse{Data: fleet})
+	}
+}
+
+// UpdateFleet replaces the details and owner of a cluster fleet
+func UpdateFleet(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := parseUUID(c, "id")
+		if !ok {
+			return
+		}
+		var req services.FleetRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			respondError(c, http.StatusBadRequest, err)
+			return
+		}
+
+		fleet, err := svc.Fleet.Update(c.Request.Context(), getAuditContext(c), id, req)
+		if err != nil {
+			respondFleetError(c, "UpdateFleet", err, "Failed to update fleet")
+			return
+		}
+
+		respondSuccess(c, fleet)
+	}
+}
+
+// DeleteFleet deletes a cluster fleet, keeping its clusters
+func DeleteFleet(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := parseUUID(c, "id")
+		if !ok {
+			return
+		}
+
+		if err := svc.Fleet.Delete(c.Request.Context(), getAuditContext(c), id); err != nil {
+			respondFleetError(c, "DeleteFleet", err, "Failed to delete fleet")
+			return
+		}
+
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// AddFleetClusters adds clusters to a cluster fleet
+func AddFleetClusters(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := parseUUID(c, "id")
+		if !ok {
+			return
+		}
+		var req services.FleetClustersRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			respondError(c, http.StatusBadRequest, err)
+			return
+		}
+
+		fleet, err := svc.Fleet.AddClusters(c.Request.Context(), getAuditContext(c), id, req)
+		if err != nil {
+			respondFleetError(c, "AddFleetClusters", err, "Failed to add clusters")
+			return
+		}
+
+		respondSuccess(c, fleet)
+	}
+}
+
+// RemoveFleetCluster removes a cluster from a cluster fleet
+func RemoveFleetCluster(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := parseUUID(c, "id")
+		if !ok {
+			return
+		}
+		clusterID, ok := parseUUID(c, "clusterId")
+		if !ok {
+			return
+		}
+
+		if err := svc.Fleet.RemoveCluster(c.Request.Context(), getAuditContext(c), id, clusterID); err != nil {
+			respondFleetError(c, "RemoveFleetCluster", err, "Failed to remove cluster")
+			return
+		}
+
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// GetFleetReport summarizes the clusters of a cluster fleet, as JSON or
+// with ?format=csv as a CSV download
+func GetFleetReport(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := parseUUID(c, "id")
+		if !ok {
+			return
+		}
+		format := c.DefaultQuery("format", "json")
+		if format != "json" && format != "csv" {
+			respondErrorStr(c, http.StatusBadRequest, "format must be json or csv")
+			return
+		}
+
+		ac := getAuditContext(c)
+		report, err := svc.Fleet.Report(c.Request.Context(), ac.OrgID, id)
+		if err != nil {
+			respondFleetError(c, "GetFleetReport", err, "Failed to get fleet report")
+			return
+		}
+		if format == "json" {
+			respondSuccess(c, report)
+			return
+		}
+
+		data, err := report.CSV()
+		if err != nil {
+			respondFleetError(c, "GetFleetReport", err, "Failed to export fleet report")
+			return
+		}
+		svc.Audit.LogAccess(c.Request.Context(), ac, services.AuditActionExport, "cluster_fleet", id, report.Fleet.Name,
+			fmt.Sprintf("Exported the report of fleet %s as csv", report.Fleet.Name))
+
+		c.Header("Content-Disposition", "attachment; filename=\"fleet-"+report.Fleet.Slug+".csv\"")
+		c.Data(http.StatusOK, "text/csv", data)
+	}
+}
+
+// SyncFleet starts the sync of every cluster of a cluster fleet. The syncs
+// run in the background; each is recorded in its cluster's sync runs.
+func SyncFleet(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := parseUUID(c, "id")
+		if !ok {
+			return
+		}
+
+		result, err := svc.Fleet.Sync(c.Request.Context(), getAuditContext(c), id)
+		if err != nil {
+			respondFleetError(c, "SyncFleet", err, "Failed to sync fleet")
+			return
+		}
+
+		c.JSON(http.StatusAccepted, SuccessResponse{Data: result})
+	}
+}
+
+func respondFleetError(c *gin.Context, op string, err error, message string) {
+	switch {
+	case errors.Is(err, services.ErrFleetNotFound):
+		respondErrorStr(c, http.StatusNotFound, "Fleet not found")
+	case errors.Is(err, services.ErrClusterNotFound):
+		respondErrorStr(c, http.StatusNotFound, "Cluster not found")
+	case errors.Is(err, services.ErrTeamNotFound):
+		respondErrorStr(c, http.StatusBadRequest, "Owner team not found")
+	case errors.Is(err, services.ErrFleetExists):
+		respondErrorStr(c, http.StatusConflict, err.Error())
+	case errors.Is(err, services.ErrInvalidFleet):
+		respondErrorStr(c, http.StatusBadRequest, err.Error())
+	default:
+		log.Printf("ERROR %s: %v", op, err)
+		respondErrorStr(c, http.StatusInternalServerError, message)
+	}
+}
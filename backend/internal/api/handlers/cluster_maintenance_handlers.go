@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kubeatlas/kubeatlas/internal/services"
+)
+
+// ============================================
+// Cluster Maintenance Window Handlers
+// ============================================
+
+// ListClusterMaintenanceWindows lists the maintenance windows of a cluster
+func ListClusterMaintenanceWindows(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := parseUUID(c, "id")
+		if !ok {
+			return
+		}
+
+		windows, err := svc.Cluster.ListMaintenanceWindows(c.Request.Context(), getAuditContext(c).OrgID, id)
+		if err != nil {
+			respondMaintenanceWindowError(c, "ListClusterMaintenanceWindows", err, "Failed to list maintenance windows")
+			return
+		}
+
+		respondSuccess(c, windows)
+	}
+}
+
+// CreateClusterMaintenanceWindow adds a maintenance window to a cluster
+func CreateClusterMaintenanceWindow(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := parseUUID(c, "id")
+		if !ok {
+			return
+		}
+
+		var req services.MaintenanceWindowRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			respondError(c, http.StatusBadRequest, err)
+			return
+		}
+
+		window, err := svc.Cluster.CreateMaintenanceWindow(c.Request.Context(), getAuditContext(c), id, req)
+		if err != nil {
+			respondMaintenanceWindowError(c, "CreateClusterMaintenanceWindow", err, "Failed to add maintenance window")
+			return
+		}
+
+		c.JSON(http.StatusCreated, SuccessResponse{Data: window})
+	}
+}
+
+// UpdateClusterMaintenanceWindow replaces a maintenance window of a cluster
+func UpdateClusterMaintenanceWindow(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := parseUUID(c, "id")
+		if !ok {
+			return
+		}
+		windowID, ok := parseUUID(c, "windowId")
+		if !ok {
+			return
+		}
+
+		var req services.MaintenanceWindowRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			respondError(c, http.StatusBadRequest, err)
+			return
+		}
+
+		window, err := svc.Cluster.UpdateMaintenanceWindow(c.Request.Context(), getAuditContext(c), id, windowID, req)
+		if err != nil {
+			respondMaintenanceWindowError(c, "UpdateClusterMaintenanceWindow", err, "Failed to update maintenance window")
+			return
+		}
+
+		respondSuccess(c, window)
+	}
+}
+
+// DeleteClusterMaintenanceWindow deletes a maintenance window of a cluster
+func DeleteClusterMaintenanceWindow(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := parseUUID(c, "id")
+		if !ok {
+			return
+		}
+		windowID, ok := parseUUID(c, "windowId")
+		if !ok {
+			return
+		}
+
+		if err := svc.Cluster.DeleteMaintenanceWindow(c.Request.Context(), getAuditContext(c), id, windowID); err != nil {
+			respondMaintenanceWindowError(c, "DeleteClusterMaintenanceWindow", err, "Failed to delete maintenance window")
+			return
+		}
+
+		c.Status(http.StatusNoContent)
+	}
+}
+
+func respondMaintenanceWindowError(c *gin.Context, op string, err error, message string) {
+	switch {
+	case errors.Is(err, services.ErrClusterNotFound):
+		respondErrorStr(c, http.StatusNotFound, "Cluster not found")
+	case errors.Is(err, services.ErrMaintenanceWindowNotFound):
+		respondErrorStr(c, http.StatusNotFound, "Maintenance window not found")
+	case errors.Is(err, services.ErrInvalidMaintenanceWindow):
+		respondErrorStr(c, http.StatusBadRequest, err.Error())
+	default:
+		log.Printf("ERROR %s: %v", op, err)
+		respondErrorStr(c, http.StatusInternalServerError, message)
+	}
+}
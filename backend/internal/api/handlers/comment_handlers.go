@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/kubeatlas/kubeatlas/internal/database/repositories"
+	"github.com/kubeatlas/kubeatlas/internal/models"
+	"github.com/kubeatlas/kubeatlas/internal/services"
+)
+
+// ============================================
+// Comment Handlers
+// ============================================
+
+// ListNamespaceComments lists the comments on a namespace, oldest first
+func ListNamespaceComments(svc *services.Services) gin.HandlerFunc {
+	return listComments("ListNamespaceComments", svc.Comment.ListForNamespace)
+}
+
+// ListClusterComments lists the comments on a cluster, oldest first
+func ListClusterComments(svc *services.Services) gin.HandlerFunc {
+	return listComments("ListClusterComments", svc.Comment.ListForCluster)
+}
+
+// CreateNamespaceComment leaves a comment on a namespace
+func CreateNamespaceComment(svc *services.Services) gin.HandlerFunc {
+	return createComment("CreateNamespaceComment", svc.Comment.CreateForNamespace)
+}
+
+// CreateClusterComment leaves a comment on a cluster
+func CreateClusterComment(svc *services.Services) gin.HandlerFunc {
+	return createComment("CreateClusterComment", svc.Comment.CreateForCluster)
+}
+
+func listComments(op string, list func(context.Context, uuid.UUID, uuid.UUID, repositories.Pagination) (*repositories.PaginatedResult[models.Comment], error)) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := parseUUID(c, "id")
+		if !ok {
+			return
+		}
+
+		result, err := list(c.Request.Context(), getAuditContext(c).OrgID, id, getPagination(c))
+		if err != nil {
+			respondCommentError(c, op, err, "Failed to list comments")
+			return
+		}
+
+		respondPaginated(c, result.Items, result.Total, result.Page, result.PageSize, result.TotalPages)
+	}
+}
+
+func createComment(op string, create func(context.Context, services.AuditContext, uuid.UUID, services.CommentRequest) (*models.Comment, error)) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := parseUUID(c, "id")
+		if !ok {
+			return
+		}
+		var req services.CommentRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			respondError(c, http.StatusBadRequest, err)
+			return
+		}
+
+		comment, err := create(c.Request.Context(), getAuditContext(c), id, req)
+		if err != nil {
+			respondCommentError(c, op, err, "Failed to create comment")
+			return
+		}
+
+		c.JSON(http.StatusCreated, SuccessResponse{Data: comment})
+	}
+}
+
+// UpdateComment replaces the body of a comment of the caller
+func UpdateComment(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := parseUUID(c, "id")
+		if !ok {
+			return
+		}
+		var req services.CommentRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			respondError(c, http.StatusBadRequest, err)
+			return
+		}
+
+		comment, err := svc.Comment.Update(c.Request.Context(), getAuditContext(c), id, req)
+		if err != nil {
+			respondCommentError(c, "UpdateComment", err, "Failed to update comment")
+			return
+		}
+
+		respondSuccess(c, comment)
+	}
+}
+
+// DeleteComment deletes a comment of the caller, or any comment for admins
+func DeleteComment(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := parseUUID(c, "id")
+		if !ok {
+			return
+		}
+
+		if err := svc.Comment.Delete(c.Request.Context(), getAuditContext(c), id); err != nil {
+			respondCommentError(c, "DeleteComment", err, "Failed to delete comment")
+			return
+		}
+
+		c.Status(http.StatusNoContent)
+	}
+}
+
+func respondCommentError(c *gin.Context, op string, err error, message string) {
+	switch {
+	case errors.Is(err, services.ErrCommentNotFound):
+		respondErrorStr(c, http.StatusNotFound, "Comment not found")
+	case errors.Is(err, services.ErrNamespaceNotFound):
+		respondErrorStr(c, http.StatusNotFound, "Namespace not found")
+	case errors.Is(err, services.ErrClusterNotFound):
+		respondErrorStr(c, http.StatusNotFound, "Cluster not found")
+	case errors.Is(err, services.ErrCommentNotAuthor):
+		respondErrorStr(c, http.StatusForbidden, err.Error())
+	case errors.Is(err, services.ErrInvalidComment):
+		respondErrorStr(c, http.StatusBadRequest, err.Error())
+	default:
+		log.Printf("ERROR %s: %v", op, err)
+		respondErrorStr(c, http.StatusInternalServerError, message)
+	}
+}
@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kubeatlas/kubeatlas/internal/api/middleware"
+	"github.com/kubeatlas/kubeatlas/internal/services"
+)
+
+// ============================================
+// Confluence Configuration Handlers
+// ============================================
+
+// GetConfluenceConfig returns the organization's Confluence settings without
+// the API token
+func GetConfluenceConfig(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		orgID, ok := middleware.GetOrganizationID(c)
+		if !ok {
+			respondErrorStr(c, http.StatusUnauthorized, "Organization ID not found")
+			return
+		}
+
+		settings, err := svc.Confluence.GetSettings(c.Request.Context(), orgID)
+		if err != nil {
+			respondErrorStr(c, http.StatusInternalServerError, "Failed to get settings")
+			return
+		}
+
+		// Never return credentials
+		settings.APIToken = ""
+
+		respondSuccess(c, settings)
+	}
+}
+
+// UpdateConfluenceConfig updates the organization's Confluence settings
+func UpdateConfluenceConfig(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req services.ConfluenceSettings
+		if err := c.ShouldBindJSON(&req); err != nil {
+			respondErrorStr(c, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+
+		settings, err := svc.Confluence.UpdateSettings(c.Request.Context(), getAuditContext(c), req)
+		if err != nil {
+			if errors.Is(err, services.ErrInvalidConfluenceSettings) {
+				respondErrorStr(c, http.StatusBadRequest, err.Error())
+				return
+			}
+			log.Printf("ERROR UpdateConfluenceConfig: %v", err)
+			respondErrorStr(c, http.StatusInternalServerError, "Failed to update Confluence configuration")
+			return
+		}
+
+		respondSuccess(c, settings)
+	}
+}
+
+// TestConfluenceConnection checks that the given settings can reach the space
+func TestConfluenceConnection(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		orgID, ok := middleware.GetOrganizationID(c)
+		if !ok {
+			respondErrorStr(c, http.StatusUnauthorized, "Organization ID not found")
+			return
+		}
+
+		var req services.ConfluenceSettings
+		if err := c.ShouldBindJSON(&req); err != nil {
+			respondErrorStr(c, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+
+		if err := svc.Confluence.TestSettings(c.Request.Context(), orgID, req); err != nil {
+			log.Printf("Confluence test failed: %v", err)
+			respondSuccess(c, map[string]interface{}{
+				"success": false,
+				"message": err.Error(),
+			})
+			return
+		}
+
+		respondSuccess(c, map[string]interface{}{
+			"success": true,
+			"message": "Confluence space found",
+		})
+	}
+}
+
+// ============================================
+// Namespace Page Handlers
+// ============================================
+
+// ExportNamespaceToConfluence creates or updates the Confluence page of a
+// namespace's documentation
+func ExportNamespaceToConfluence(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := parseUUID(c, "id")
+		if !ok {
+			return
+		}
+
+		page, err := svc.Confluence.ExportNamespace(c.Request.Context(), getAuditContext(c), id)
+		if err != nil {
+			switch {
+			case errors.Is(err, services.ErrNamespaceNotFound):
+				respondErrorStr(c, http.StatusNotFound, "Namespace not found")
+			case errors.Is(err, services.ErrConfluenceNotEnabled):
+				respondErrorStr(c, http.StatusBadRequest, err.Error())
+			case errors.Is(err, services.ErrConfluenceRequestFailed):
+				log.Printf("ERROR ExportNamespaceToConfluence: %v", err)
+				respondErrorStr(c, http.StatusBadGateway, err.Error())
+			default:
+				log.Printf("ERROR ExportNamespaceToConfluence: %v", err)
+				respondErrorStr(c, http.StatusInternalServerError, "Failed to export namespace to Confluence")
+			}
+			return
+		}
+
+		respondSuccess(c, page)
+	}
+}
@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kubeatlas/kubeatlas/internal/services"
+)
+
+// ============================================
+// Contact Validation Handlers
+// ============================================
+
+// ListContactIssues returns the namespace contacts whose email addresses
+// failed the last validation
+func ListContactIssues(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		limit := 0
+		if l := c.Query("limit"); l != "" {
+			parsed, err := strconv.Atoi(l)
+			if err != nil {
+				respondErrorStr(c, http.StatusBadRequest, "limit must be a number")
+				return
+			}
+			limit = parsed
+		}
+
+		issues, err := svc.Contacts.Issues(c.Request.Context(), getAuditContext(c).OrgID, limit)
+		if err != nil {
+			log.Printf("ERROR ListContactIssues: %v", err)
+			respondErrorStr(c, http.StatusInternalServerError, "Failed to list contact issues")
+			return
+		}
+
+		respondSuccess(c, issues)
+	}
+}
+
+// ValidateContacts validates the organization's namespace contacts now
+func ValidateContacts(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		result, err := svc.Contacts.Validate(c.Request.Context(), getAuditContext(c).OrgID)
+		if err != nil {
+			log.Printf("ERROR ValidateContacts: %v", err)
+			respondErrorStr(c, http.StatusInternalServerError, "Failed to validate contacts")
+			return
+		}
+
+		respondSuccess(c, result)
+	}
+}
@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kubeatlas/kubeatlas/internal/api/middleware"
+	"github.com/kubeatlas/kubeatlas/internal/services"
+)
+
+// ============================================
+// Datadog Configuration Handlers
+// ============================================
+
+// GetDatadogConfig returns the organization's Datadog site and whether its
+// keys are set, without the keys
+func GetDatadogConfig(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		orgID, ok := middleware.GetOrganizationID(c)
+		if !ok {
+			respondErrorStr(c, http.StatusUnauthorized, "Organization ID not found")
+			return
+		}
+
+		settings, err := svc.Monitoring.GetDatadogSettings(c.Request.Context(), orgID)
+		if err != nil {
+			respondErrorStr(c, http.StatusInternalServerError, "Failed to get settings")
+			return
+		}
+
+		// Never return credentials
+		respondSuccess(c, map[string]interface{}{
+			"site":        settings.Site,
+			"api_key_set": settings.APIKey != "",
+			"app_key_set": settings.AppKey != "",
+		})
+	}
+}
+
+// UpdateDatadogConfig updates the keys Datadog dashboards are checked with
+func UpdateDatadogConfig(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req services.DatadogSettings
+		if err := c.ShouldBindJSON(&req); err != nil {
+			respondErrorStr(c, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+
+		settings, err := svc.Monitoring.UpdateDatadogSettings(c.Request.Context(), getAuditContext(c), req)
+		if err != nil {
+			if errors.Is(err, services.ErrInvalidDatadogSettings) {
+				respondErrorStr(c, http.StatusBadRequest, err.Error())
+				return
+			}
+			log.Printf("ERROR UpdateDatadogConfig: %v", err)
+			respondErrorStr(c, http.StatusInternalServerError, "Failed to update Datadog configuration")
+			return
+		}
+
+		respondSuccess(c, settings)
+	}
+}
+
+// TestDatadogConnection checks that Datadog accepts the given API key
+func TestDatadogConnection(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		orgID, ok := middleware.GetOrganizationID(c)
+		if !ok {
+			respondErrorStr(c, http.StatusUnauthorized, "Organization ID not found")
+			return
+		}
+
+		var req services.DatadogSettings
+		if err := c.ShouldBindJSON(&req); err != nil {
+			respondErrorStr(c, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+
+		if err := svc.Monitoring.TestDatadogSettings(c.Request.Context(), orgID, req); err != nil {
+			log.Printf("Datadog test failed: %v", err)
+			respondSuccess(c, map[string]interface{}{
+				"success": false,
+				"message": err.Error(),
+			})
+			return
+		}
+
+		respondSuccess(c, map[string]interface{}{
+			"success": true,
+			"message": "Datadog API key accepted",
+		})
+	}
+}
@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"expvar"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	rpprof "runtime/pprof"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ============================================
+// Debug Handlers
+// ============================================
+
+// RegisterDebugRoutes mounts pprof, expvar and the goroutine summary on group.
+// Callers are responsible for gating the group (admin-only or internal port).
+func RegisterDebugRoutes(group gin.IRoutes) {
+	group.GET("/pprof/", gin.WrapF(pprof.Index))
+	group.GET("/pprof/cmdline", gin.WrapF(pprof.Cmdline))
+	group.GET("/pprof/profile", gin.WrapF(pprof.Profile))
+	group.POST("/pprof/symbol", gin.WrapF(pprof.Symbol))
+	group.GET("/pprof/symbol", gin.WrapF(pprof.Symbol))
+	group.GET("/pprof/trace", gin.WrapF(pprof.Trace))
+	for _, name := range []string{"allocs", "block", "goroutine", "heap", "mutex", "threadcreate"} {
+		group.GET("/pprof/"+name, gin.WrapH(pprof.Handler(name)))
+	}
+
+	group.GET("/vars", gin.WrapH(expvar.Handler()))
+	group.GET("/goroutines", GoroutineSummary())
+}
+
+// DebugMux returns a plain http.ServeMux serving the same debug endpoints
+// under /debug, for use on an internal-only listener.
+func DebugMux() *http.ServeMux {
+	engine := gin.New()
+	RegisterDebugRoutes(engine.Group("/debug"))
+
+	mux := http.NewServeMux()
+	mux.Handle("/debug/", engine)
+	return mux
+}
+
+// goroutineGroup is a set of goroutines sharing the same top stack frame
+type goroutineGroup struct {
+	Function string `json:"function"`
+	Count    int    `json:"count"`
+}
+
+// GoroutineSummary reports goroutine counts grouped by their top user frame,
+// which is usually enough to spot a leak without downloading a full profile.
+func GoroutineSummary() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		limit := 50
+		if l := c.Query("limit"); l != "" {
+			if n, err := strconv.Atoi(l); err == nil && n > 0 {
+				limit = n
+			}
+		}
+
+		var buf strings.Builder
+		if err := rpprof.Lookup("goroutine").WriteTo(&buf, 1); err != nil {
+			respondError(c, http.StatusInternalServerError, err)
+			return
+		}
+		groups := summarizeGoroutines(buf.String())
+		if len(groups) > limit {
+			groups = groups[:limit]
+		}
+
+		var mem runtime.MemStats
+		runtime.ReadMemStats(&mem)
+
+		respondSuccess(c, gin.H{
+			"total":         runtime.NumGoroutine(),
+			"groups":        groups,
+			"heap_alloc":    mem.HeapAlloc,
+			"heap_objects":  mem.HeapObjects,
+			"num_gc":        mem.NumGC,
+			"last_gc_pause": time.Duration(mem.PauseNs[(mem.NumGC+255)%256]).String(),
+			"gomaxprocs":    runtime.GOMAXPROCS(0),
+			"go_version":    runtime.Version(),
+			"collected_at":  time.Now().UTC(),
+		})
+	}
+}
+
+// summarizeGoroutines parses a debug=1 goroutine profile. Each record starts
+// with "<count> @ <pcs>" followed by "#\t<pc>\t<function>+<off>\t<file>" lines.
+func summarizeGoroutines(profile string) []goroutineGroup {
+	counts := make(map[string]int)
+
+	var count int
+	var seenFrame bool
+	for _, line := range strings.Split(profile, "\n") {
+		switch {
+		case strings.Contains(line, " @ "):
+			count, seenFrame = 0, false
+			if n, err := strconv.Atoi(strings.Fields(line)[0]); err == nil {
+				count = n
+			}
+		case strings.HasPrefix(line, "#\t") && !seenFrame && count > 0:
+			fields := strings.Split(line, "\t")
+			if len(fields) < 3 {
+				continue
+			}
+			fn := fields[2]
+			if i := strings.LastIndex(fn, "+"); i > 0 {
+				fn = fn[:i]
+			}
+			// Skip runtime internals so goroutines group by what they wait on
+			if strings.HasPrefix(fn, "runtime.") || strings.HasPrefix(fn, "internal/") {
+				continue
+			}
+			counts[fn] += count
+			seenFrame = true
+		}
+	}
+
+	groups := make([]goroutineGroup, 0, len(counts))
+	for fn, n := range counts {
+		groups = append(groups, goroutineGroup{Function: fn, Count: n})
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		if groups[i].Count != groups[j].Count {
+			return groups[i].Count > groups[j].Count
+		}
+		return groups[i].Function < groups[j].Function
+	})
+	return groups
+}
@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/kubeatlas/kubeatlas/internal/api/middleware"
+	"github.com/kubeatlas/kubeatlas/internal/services"
+)
+
+// ============================================
+// Escalation Handlers
+// ============================================
+
+// ListEscalations returns the organization's escalations, newest first
+func ListEscalations(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		orgID, ok := middleware.GetOrganizationID(c)
+		if !ok {
+			respondErrorStr(c, http.StatusUnauthorized, "Organization ID not found")
+			return
+		}
+
+		p := getPagination(c)
+		filters := make(map[string]interface{})
+		if status := c.Query("status"); status != "" {
+			filters["status"] = status
+		}
+		if namespaceID := c.Query("namespace_id"); namespaceID != "" {
+			id, err := uuid.Parse(namespaceID)
+			if err != nil {
+				respondErrorStr(c, http.StatusBadRequest, "Invalid namespace_id")
+				return
+			}
+			filters["namespace_id"] = id
+		}
+
+		result, err := svc.Escalation.List(c.Request.Context(), orgID, p, filters)
+		if err != nil {
+			log.Printf("ERROR ListEscalations: %v", err)
+			respondErrorStr(c, http.StatusInternalServerError, "Failed to list escalations")
+			return
+		}
+
+		respondPaginated(c, result.Items, result.Total, result.Page, result.PageSize, result.TotalPages)
+	}
+}
+
+// GetEscalation returns a single escalation
+func GetEscalation(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := parseUUID(c, "id")
+		if !ok {
+			return
+		}
+		orgID, ok := middleware.GetOrganizationID(c)
+		if !ok {
+			respondErrorStr(c, http.StatusUnauthorized, "Organization ID not found")
+			return
+		}
+
+		escalation, err := svc.Escalation.GetByID(c.Request.Context(), orgID, id)
+		if err != nil {
+			respondEscalationError(c, "GetEscalation", err, "Failed to get escalation")
+			return
+		}
+
+		respondSuccess(c, escalation)
+	}
+}
+
+// AcknowledgeEscalation stops an escalation from reaching further levels
+func AcknowledgeEscalation(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := parseUUID(c, "id")
+		if !ok {
+			return
+		}
+
+		escalation, err := svc.Escalation.Acknowledge(c.Request.Context(), getAuditContext(c), id)
+		if err != nil {
+			respondEscalationError(c, "AcknowledgeEscalation", err, "Failed to acknowledge escalation")
+			return
+		}
+
+		respondSuccess(c, escalation)
+	}
+}
+
+// respondEscalationError maps escalation service errors to responses
+func respondEscalationError(c *gin.Context, op string, err error, message string) {
+	switch {
+	case errors.Is(err, services.ErrEscalationNotFound):
+		respondErrorStr(c, http.StatusNotFound, "Escalation not found")
+	case errors.Is(err, services.ErrEscalationNotOpen):
+		respondErrorStr(c, http.StatusConflict, err.Error())
+	default:
+		log.Printf("ERROR %s: %v", op, err)
+		respondErrorStr(c, http.StatusInternalServerError, message)
+	}
+}
@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kubeatlas/kubeatlas/internal/api/middleware"
+	"github.com/kubeatlas/kubeatlas/internal/services"
+)
+
+// ============================================
+// Organization Export Handlers
+// ============================================
+
+// RequestOrgExport queues an export of all of the organization's data. The
+// export runs in the background; poll GetOrgExport for its progress.
+func RequestOrgExport(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		export, err := svc.Export.Request(c.Request.Context(), getAuditContext(c))
+		if err != nil {
+			respondExportError(c, "RequestOrgExport", err, "Failed to request export")
+			return
+		}
+
+		c.JSON(http.StatusAccepted, SuccessResponse{Data: export})
+	}
+}
+
+// ListOrgExports lists the organization's most recent exports
+func ListOrgExports(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		orgID, ok := middleware.GetOrganizationID(c)
+		if !ok {
+			respondErrorStr(c, http.StatusUnauthorized, "Organization ID not found")
+			return
+		}
+
+		exports, err := svc.Export.List(c.Request.Context(), orgID)
+		if err != nil {
+			respondExportError(c, "ListOrgExports", err, "Failed to list exports")
+			return
+		}
+
+		respondSuccess(c, exports)
+	}
+}
+
+// GetOrgExport returns an export with its status and progress
+func GetOrgExport(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		orgID, ok := middleware.GetOrganizationID(c)
+		if !ok {
+			respondErrorStr(c, http.StatusUnauthorized, "Organization ID not found")
+			return
+		}
+		id, ok := parseUUID(c, "id")
+		if !ok {
+			return
+		}
+
+		export, err := svc.Export.Get(c.Request.Context(), orgID, id)
+		if err != nil {
+			respondExportError(c, "GetOrgExport", err, "Failed to get export")
+			return
+		}
+
+		respondSuccess(c, export)
+	}
+}
+
+// DownloadOrgExport streams the zip archive of a completed export
+func DownloadOrgExport(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := parseUUID(c, "id")
+		if !ok {
+			return
+		}
+
+		rc, export, err := svc.Export.Open(c.Request.Context(), getAuditContext(c), id)
+		if err != nil {
+			respondExportError(c, "DownloadOrgExport", err, "Failed to download export")
+			return
+		}
+		defer rc.Close()
+
+		filename := "kubeatlas_export_" + export.CreatedAt.UTC().Format("20060102T150405Z") + ".zip"
+		c.Header("Content-Type", "application/zip")
+		c.Header("Content-Disposition", "attachment; filename=\""+filename+"\"")
+		c.Header("Content-Length", strconv.FormatInt(export.SizeBytes, 10))
+		c.Status(http.StatusOK)
+
+		if _, err := io.Copy(c.Writer, rc); err != nil {
+			log.Printf("ERROR DownloadOrgExport: %v", err)
+			c.Abort()
+		}
+	}
+}
+
+func respondExportError(c *gin.Context, op string, err error, message string) {
+	switch {
+	case errors.Is(err, services.ErrExportNotFound):
+		respondErrorStr(c, http.StatusNotFound, "Export not found")
+	case errors.Is(err, services.ErrExportInProgress), errors.Is(err, services.ErrExportNotReady):
+		respondErrorStr(c, http.StatusConflict, err.Error())
+	case errors.Is(err, services.ErrExportUnavailable):
+		respondErrorStr(c, http.StatusServiceUnavailable, err.Error())
+	default:
+		log.Printf("ERROR %s: %v", op, err)
+		respondErrorStr(c, http.StatusInternalServerError, message)
+	}
+}
@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kubeatlas/kubeatlas/internal/api/middleware"
+	"github.com/kubeatlas/kubeatlas/internal/services"
+)
+
+// GetChangeFeed returns the catalog changes made after the since cursor,
+// oldest first, so other systems can follow the catalog by polling. since is
+// the cursor returned by the previous request, or an RFC 3339 time to start
+// from, and limit caps the number of changes (default 100, at most 1000).
+// The feed is newline-delimited JSON unless format=atom is given or the
+// Accept header asks for Atom. The cursor to pass next is returned in the
+// X-Next-Cursor header, and in the next link of Atom feeds.
+func GetChangeFeed(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		orgID, _ := middleware.GetOrganizationID(c)
+
+		format := c.Query("format")
+		if format == "" {
+			format = services.ChangeFeedNDJSON
+			if strings.Contains(c.GetHeader("Accept"), "application/atom+xml") {
+				format = services.ChangeFeedAtom
+			}
+		}
+		contentType, err := services.ChangeFeedContentType(format)
+		if err != nil {
+			respondErrorStr(c, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		limit := services.DefaultChangeFeedLimit
+		if value := c.Query("limit"); value != "" {
+			limit, err = strconv.Atoi(value)
+			if err != nil || limit < 1 || limit > services.MaxChangeFeedLimit {
+				respondErrorStr(c, http.StatusBadRequest, "limit must be between 1 and "+strconv.Itoa(services.MaxChangeFeedLimit))
+				return
+			}
+		}
+
+		cursor, err := services.ParseFeedSince(c.Query("since"))
+		if err != nil {
+			respondErrorStr(c, http.StatusBadRequest, "since must be a cursor from the feed or an RFC 3339 time")
+			return
+		}
+
+		feed, err := svc.Audit.ChangeFeed(c.Request.Context(), orgID, cursor, limit)
+		if err != nil {
+			if errors.Is(err, services.ErrInvalidFeedCursor) {
+				respondErrorStr(c, http.StatusBadRequest, err.Error())
+				return
+			}
+			log.Printf("ERROR GetChangeFeed: %v", err)
+			respondErrorStr(c, http.StatusInternalServerError, "Failed to get change feed")
+			return
+		}
+
+		// The feed is rendered before the status is sent, so a failure is
+		// still reported as one
+		var body bytes.Buffer
+		if format == services.ChangeFeedAtom {
+			err = feed.WriteAtom(&body, orgID, changeFeedURL(c, c.Query("since")), changeFeedURL(c, feed.NextCursor))
+		} else {
+			err = feed.WriteNDJSON(&body)
+		}
+		if err != nil {
+			log.Printf("ERROR GetChangeFeed: format=%s, err=%v", format, err)
+			respondErrorStr(c, http.StatusInternalServerError, "Failed to get change feed")
+			return
+		}
+
+		c.Header("X-Next-Cursor", feed.NextCursor)
+		c.Header("Cache-Control", "no-store")
+		c.Data(http.StatusOK, contentType, body.Bytes())
+	}
+}
+
+// changeFeedURL returns the absolute URL of the request with since replaced
+func changeFeedURL(c *gin.Context, since string) string {
+	scheme := "http"
+	if c.Request.TLS != nil || c.GetHeader("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+	query := c.Request.URL.Query()
+	query.Del("since")
+	if since != "" {
+		query.Set("since", since)
+	}
+	u := url.URL{Scheme: scheme, Host: c.Request.Host, Path: c.Request.URL.Path, RawQuery: query.Encode()}
+	return u.String()
+}
@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kubeatlas/kubeatlas/internal/api/middleware"
+	"github.com/kubeatlas/kubeatlas/internal/services"
+)
+
+// ============================================
+// Git Configuration Handlers
+// ============================================
+
+// GetGitConfig returns whether the organization has GitHub and GitLab tokens,
+// without the tokens
+func GetGitConfig(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		orgID, ok := middleware.GetOrganizationID(c)
+		if !ok {
+			respondErrorStr(c, http.StatusUnauthorized, "Organization ID not found")
+			return
+		}
+
+		settings, err := svc.Git.GetSettings(c.Request.Context(), orgID)
+		if err != nil {
+			respondErrorStr(c, http.StatusInternalServerError, "Failed to get settings")
+			return
+		}
+
+		// Never return credentials
+		respondSuccess(c, map[string]interface{}{
+			"github_token_set": settings.GitHubToken != "",
+			"gitlab_token_set": settings.GitLabToken != "",
+		})
+	}
+}
+
+// UpdateGitConfig updates the tokens the organization reads private
+// repositories with
+func UpdateGitConfig(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req services.GitSettings
+		if err := c.ShouldBindJSON(&req); err != nil {
+			respondErrorStr(c, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+
+		settings, err := svc.Git.UpdateSettings(c.Request.Context(), getAuditContext(c), req)
+		if err != nil {
+			log.Printf("ERROR UpdateGitConfig: %v", err)
+			respondErrorStr(c, http.StatusInternalServerError, "Failed to update Git configuration")
+			return
+		}
+
+		respondSuccess(c, settings)
+	}
+}
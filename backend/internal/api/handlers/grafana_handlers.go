@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kubeatlas/kubeatlas/internal/api/middleware"
+	"github.com/kubeatlas/kubeatlas/internal/services"
+)
+
+// ============================================
+// Grafana Configuration Handlers
+// ============================================
+
+// GetGrafanaConfig returns the organization's Grafana settings without the
+// service account token
+func GetGrafanaConfig(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		orgID, ok := middleware.GetOrganizationID(c)
+		if !ok {
+			respondErrorStr(c, http.StatusUnauthorized, "Organization ID not found")
+			return
+		}
+
+		settings, err := svc.Grafana.GetSettings(c.Request.Context(), orgID)
+		if err != nil {
+			respondErrorStr(c, http.StatusInternalServerError, "Failed to get settings")
+			return
+		}
+
+		// Never return credentials
+		settings.APIToken = ""
+
+		respondSuccess(c, settings)
+	}
+}
+
+// UpdateGrafanaConfig updates the organization's Grafana settings
+func UpdateGrafanaConfig(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req services.GrafanaSettings
+		if err := c.ShouldBindJSON(&req); err != nil {
+			respondErrorStr(c, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+
+		settings, err := svc.Grafana.UpdateSettings(c.Request.Context(), getAuditContext(c), req)
+		if err != nil {
+			if errors.Is(err, services.ErrInvalidGrafanaSettings) {
+				respondErrorStr(c, http.StatusBadRequest, err.Error())
+				return
+			}
+			log.Printf("ERROR UpdateGrafanaConfig: %v", err)
+			respondErrorStr(c, http.StatusInternalServerError, "Failed to update Grafana configuration")
+			return
+		}
+
+		respondSuccess(c, settings)
+	}
+}
+
+// TestGrafanaConnection checks that Grafana accepts the given token
+func TestGrafanaConnection(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		orgID, ok := middleware.GetOrganizationID(c)
+		if !ok {
+			respondErrorStr(c, http.StatusUnauthorized, "Organization ID not found")
+			return
+		}
+
+		var req services.GrafanaSettings
+		if err := c.ShouldBindJSON(&req); err != nil {
+			respondErrorStr(c, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+
+		if err := svc.Grafana.TestSettings(c.Request.Context(), orgID, req); err != nil {
+			log.Printf("Grafana test failed: %v", err)
+			respondSuccess(c, map[string]interface{}{
+				"success": false,
+				"message": err.Error(),
+			})
+			return
+		}
+
+		respondSuccess(c, map[string]interface{}{
+			"success": true,
+			"message": "Grafana token accepted",
+		})
+	}
+}
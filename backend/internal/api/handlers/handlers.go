@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"log"
 	"net/http"
 	"strconv"
@@ -9,6 +10,7 @@ import (
 	"github.com/google/uuid"
 	"github.com/kubeatlas/kubeatlas/internal/api/middleware"
 	"github.com/kubeatlas/kubeatlas/internal/database/repositories"
+	"github.com/kubeatlas/kubeatlas/internal/listquery"
 	"github.com/kubeatlas/kubeatlas/internal/models"
 	"github.com/kubeatlas/kubeatlas/internal/services"
 )
@@ -45,6 +47,8 @@ type TeamResponse struct {
 	TeamType       string     `json:"team_type"`
 	ContactEmail   string     `json:"contact_email,omitempty"`
 	ContactSlack   string     `json:"contact_slack,omitempty"`
+	PagerDuty      string     `json:"pagerduty_service_id,omitempty"`
+	Opsgenie       string     `json:"opsgenie_schedule_id,omitempty"`
 	MemberCount    int        `json:"member_count"`
 }
 
@@ -60,6 +64,8 @@ func toTeamResponse(t models.Team) TeamResponse {
 		TeamType:       t.TeamType,
 		ContactEmail:   t.ContactEmail.ValueOrEmpty(),
 		ContactSlack:   t.ContactSlack.ValueOrEmpty(),
+		PagerDuty:      t.PagerDutyServiceID.ValueOrEmpty(),
+		Opsgenie:       t.OpsgenieScheduleID.ValueOrEmpty(),
 		MemberCount:    t.MemberCount,
 	}
 }
@@ -90,14 +96,15 @@ func getAuditContext(c *gin.Context) services.AuditContext {
 
 // respondError sends an error response with error type
 func respondError(c *gin.Context, status int, err error) {
-	c.JSON(status, ErrorResponse{
-		Error:   http.StatusText(status),
-		Message: err.Error(),
-	})
+	respondErrorStr(c, status, err.Error())
 }
 
-// respondErrorStr sends an error response with string message
+// respondErrorStr sends an error response with string message. A request
+// rejected for a body cut off at its size limit gets 413.
 func respondErrorStr(c *gin.Context, status int, message string) {
+	if status == http.StatusBadRequest && middleware.BodyTooLarge(c) {
+		status, message = http.StatusRequestEntityTooLarge, "Request body too large"
+	}
 	c.JSON(status, ErrorResponse{
 		Error:   http.StatusText(status),
 		Message: message,
@@ -155,10 +162,53 @@ func getPagination(c *gin.Context) repositories.Pagination {
 	}
 }
 
+// expandListQuery replaces the q parameter of a list request with the list
+// parameters its query sets, answering 400 when the query is invalid. As gin
+// caches the query on its first read, it must run before anything reads it.
+func expandListQuery(c *gin.Context, fields listquery.Fields) bool {
+	params := c.Request.URL.Query()
+	q := params.Get("q")
+	if q == "" {
+		return true
+	}
+	params.Del("q")
+	if err := listquery.Expand(q, fields, params); err != nil {
+		respondErrorStr(c, http.StatusBadRequest, err.Error())
+		return false
+	}
+	c.Request.URL.RawQuery = params.Encode()
+	return true
+}
+
 // ============================================
 // Auth Handlers
 // ============================================
 
+// defaultOrganizationID is the organization of unauthenticated requests such
+// as logins. For now there is a single organization; in production this
+// would come from the request or domain.
+var defaultOrganizationID = uuid.MustParse("00000000-0000-0000-0000-000000000001")
+
+// authAuditContext is the audit context of an unauthenticated request
+func authAuditContext(c *gin.Context) services.AuditContext {
+	return services.AuditContext{
+		OrgID:     defaultOrganizationID,
+		UserIP:    c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+	}
+}
+
+// AuditLoginLockouts audits logins rejected by the rate limiter that follows
+// it in the handler chain
+func AuditLoginLockouts(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+		if c.Writer.Status() == http.StatusTooManyRequests {
+			svc.Auth.RecordLockout(c.Request.Context(), authAuditContext(c))
+		}
+	}
+}
+
 func Login(svc *services.Services) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var req services.LoginRequest
@@ -167,10 +217,7 @@ func Login(svc *services.Services) gin.HandlerFunc {
 			return
 		}
 
-		// For now, use a default org ID (in production, this would come from the request or domain)
-		orgID := uuid.MustParse("00000000-0000-0000-0000-000000000001")
-
-		tokens, user, err := svc.Auth.Login(c.Request.Context(), orgID, req)
+		tokens, user, err := svc.Auth.Login(c.Request.Context(), authAuditContext(c), req)
 		if err != nil {
 			respondError(c, http.StatusUnauthorized, err)
 			return
@@ -207,7 +254,7 @@ func RefreshToken(svc *services.Services) gin.HandlerFunc {
 			return
 		}
 
-		tokens, err := svc.Auth.RefreshToken(c.Request.Context(), req.RefreshToken)
+		tokens, err := svc.Auth.RefreshToken(c.Request.Context(), authAuditContext(c), req.RefreshToken)
 		if err != nil {
 			respondError(c, http.StatusUnauthorized, err)
 			return
@@ -375,7 +422,13 @@ func ListTeams(svc *services.Services) gin.HandlerFunc {
 			respondErrorStr(c, http.StatusUnauthorized, "Organization ID not found in context")
 			return
 		}
-		teams, err := svc.Team.List(c.Request.Context(), orgID)
+		var teams []models.Team
+		var err error
+		if search := c.Query("search"); search != "" {
+			teams, err = svc.Team.Search(c.Request.Context(), orgID, search)
+		} else {
+			teams, err = svc.Team.List(c.Request.Context(), orgID)
+		}
 		if err != nil {
 			log.Printf("ERROR ListTeams: orgID=%s, err=%v", orgID, err)
 			respondError(c, http.StatusInternalServerError, err)
@@ -418,7 +471,7 @@ func CreateTeam(svc *services.Services) gin.HandlerFunc {
 		}
 		team, err := svc.Team.Create(c.Request.Context(), getAuditContext(c), req)
 		if err != nil {
-			respondError(c, http.StatusInternalServerError, err)
+			respondTeamError(c, "CreateTeam", err, "Failed to create team")
 			return
 		}
 		c.JSON(http.StatusCreated, gin.H{"data": team})
@@ -441,14 +494,93 @@ func UpdateTeam(svc *services.Services) gin.HandlerFunc {
 		}
 		team, err := svc.Team.Update(c.Request.Context(), getAuditContext(c), id, req)
 		if err != nil {
-			log.Printf("ERROR UpdateTeam: update failed for id=%s, err=%v", id, err)
-			respondError(c, http.StatusInternalServerError, err)
+			respondTeamError(c, "UpdateTeam", err, "Failed to update team")
 			return
 		}
 		respondSuccess(c, toTeamResponse(*team))
 	}
 }
 
+// TeamTreeResponse is a team in the team tree, with the distinct members of
+// its subtree
+type TeamTreeResponse struct {
+	TeamResponse
+	SubtreeMemberCount int                 `json:"subtree_member_count"`
+	Children           []*TeamTreeResponse `json:"children"`
+}
+
+func toTeamTreeResponse(node *models.TeamNode) *TeamTreeResponse {
+	resp := &TeamTreeResponse{
+		TeamResponse:       toTeamResponse(node.Team),
+		SubtreeMemberCount: node.SubtreeMemberCount,
+		Children:           make([]*TeamTreeResponse, len(node.Children)),
+	}
+	for i, child := range node.Children {
+		resp.Children[i] = toTeamTreeResponse(child)
+	}
+	return resp
+}
+
+// GetTeamTree returns the organization's teams as a tree for org charts
+func GetTeamTree(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		orgID, ok := middleware.GetOrganizationID(c)
+		if !ok {
+			respondErrorStr(c, http.StatusUnauthorized, "Organization ID not found in context")
+			return
+		}
+		roots, err := svc.Team.Tree(c.Request.Context(), orgID)
+		if err != nil {
+			respondTeamError(c, "GetTeamTree", err, "Failed to load team tree")
+			return
+		}
+		responses := make([]*TeamTreeResponse, len(roots))
+		for i, root := range roots {
+			responses[i] = toTeamTreeResponse(root)
+		}
+		respondSuccess(c, responses)
+	}
+}
+
+// ListTeamDescendants returns every team below a team, level by level
+func ListTeamDescendants(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		orgID, ok := middleware.GetOrganizationID(c)
+		if !ok {
+			respondErrorStr(c, http.StatusUnauthorized, "Organization ID not found in context")
+			return
+		}
+		id, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			respondError(c, http.StatusBadRequest, err)
+			return
+		}
+		teams, err := svc.Team.Descendants(c.Request.Context(), orgID, id)
+		if err != nil {
+			respondTeamError(c, "ListTeamDescendants", err, "Failed to list team descendants")
+			return
+		}
+		responses := make([]TeamResponse, len(teams))
+		for i, t := range teams {
+			responses[i] = toTeamResponse(t)
+		}
+		respondSuccess(c, responses)
+	}
+}
+
+// respondTeamError maps team service errors to HTTP responses
+func respondTeamError(c *gin.Context, op string, err error, message string) {
+	switch {
+	case errors.Is(err, services.ErrTeamNotFound):
+		respondErrorStr(c, http.StatusNotFound, err.Error())
+	case errors.Is(err, services.ErrTeamParentNotFound), errors.Is(err, services.ErrTeamCycle):
+		respondErrorStr(c, http.StatusBadRequest, err.Error())
+	default:
+		log.Printf("ERROR %s: %v", op, err)
+		respondErrorStr(c, http.StatusInternalServerError, message)
+	}
+}
+
 func DeleteTeam(svc *services.Services) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		id, err := uuid.Parse(c.Param("id"))
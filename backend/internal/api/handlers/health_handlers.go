@@ -0,0 +1,26 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kubeatlas/kubeatlas/internal/health"
+)
+
+// ============================================
+// Health Handlers
+// ============================================
+
+// Readiness runs all readiness checks. A degraded service still reports 200
+// so it stays in rotation; only failing critical checks return 503.
+func Readiness(checker *health.Checker) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		report := checker.Run(c.Request.Context())
+
+		status := http.StatusOK
+		if report.Status == health.StatusNotReady {
+			status = http.StatusServiceUnavailable
+		}
+		c.JSON(status, report)
+	}
+}
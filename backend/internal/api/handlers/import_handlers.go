@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kubeatlas/kubeatlas/internal/api/middleware"
+	"github.com/kubeatlas/kubeatlas/internal/services"
+)
+
+// RegisterImportRoutes mounts the catalog and CSV imports on group. Imports
+// of users, which carry their role, and of business units are admin-only;
+// editors may import teams.
+func RegisterImportRoutes(group gin.IRoutes, svc *services.Services) {
+	group.POST("/backstage", middleware.RequireAdmin(), ImportBackstage(svc))
+	group.POST("/users", middleware.RequireAdmin(), ImportCSV(svc, services.CSVImportUsers))
+	group.POST("/teams", middleware.RequireEditor(), ImportCSV(svc, services.CSVImportTeams))
+	group.POST("/business-units", middleware.RequireAdmin(), ImportCSV(svc, services.CSVImportBusinessUnits))
+}
+
+// ImportBackstage imports a Backstage catalog posted as catalog-info YAML
+// documents or JSON. The conflict query parameter chooses whether entities
+// that disagree with existing data are skipped, overwrite it, or fail the
+// whole import, and dry_run=true reports the changes without making them.
+// The catalog is limited by the import body limit.
+func ImportBackstage(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		opts := services.BackstageImportOptions{
+			DryRun:   c.Query("dry_run") == "true",
+			Conflict: c.DefaultQuery("conflict", services.ConflictSkip),
+		}
+		result, err := svc.Backstage.Import(c.Request.Context(), getAuditContext(c), c.Request.Body, opts)
+		if err != nil {
+			var tooLarge *http.MaxBytesError
+			switch {
+			case errors.As(err, &tooLarge):
+				respondErrorStr(c, http.StatusRequestEntityTooLarge, "Catalog is too large")
+			case errors.Is(err, services.ErrInvalidBackstageImport):
+				respondErrorStr(c, http.StatusBadRequest, err.Error())
+			case errors.Is(err, services.ErrBackstageConflict):
+				// Nothing was imported; the result shows what conflicts
+				c.JSON(http.StatusConflict, SuccessResponse{Data: result})
+			default:
+				log.Printf("ERROR ImportBackstage: %v", err)
+				respondErrorStr(c, http.StatusInternalServerError, "Failed to import Backstage catalog")
+			}
+			return
+		}
+
+		respondSuccess(c, result)
+	}
+}
+
+// ImportCSV imports users, teams or business units from a CSV file, posted
+// as the file field of a multipart form or as the request body. The mapping
+// field or query parameter is a JSON object naming the column of each field,
+// delimiter sets the field delimiter, and dry_run=true validates the file
+// and reports the changes without making them. The file is limited by the
+// import body limit.
+func ImportCSV(svc *services.Services, resource string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// Options come from the form of multipart requests, whose FormValue
+		// includes the query, and from the query otherwise, so a CSV body
+		// sent as a urlencoded form is not parsed as one
+		var file io.Reader = c.Request.Body
+		option := c.Query
+		if strings.HasPrefix(c.ContentType(), "multipart/") {
+			option = c.Request.FormValue
+			f, _, err := c.Request.FormFile("file")
+			if err != nil {
+				var tooLarge *http.MaxBytesError
+				if errors.As(err, &tooLarge) {
+					respondErrorStr(c, http.StatusRequestEntityTooLarge, "File is too large")
+					return
+				}
+				respondErrorStr(c, http.StatusBadRequest, "Failed to get file")
+				return
+			}
+			defer f.Close()
+			file = f
+		}
+
+		opts := services.CSVImportOptions{DryRun: option("dry_run") == "true"}
+		if mapping := option("mapping"); mapping != "" {
+			if err := json.Unmarshal([]byte(mapping), &opts.Mapping); err != nil {
+				respondErrorStr(c, http.StatusBadRequest, "mapping must be a JSON object of field names to column headers")
+				return
+			}
+		}
+		if delimiter := option("delimiter"); delimiter != "" {
+			r, size := utf8.DecodeRuneInString(delimiter)
+			if size != len(delimiter) || r == '"' || r == '\r' || r == '\n' {
+				respondErrorStr(c, http.StatusBadRequest, "delimiter must be a single character")
+				return
+			}
+			opts.Delimiter = r
+		}
+
+		result, err := svc.CSVImport.Import(c.Request.Context(), getAuditContext(c), resource, file, opts)
+		if err != nil {
+			var tooLarge *http.MaxBytesError
+			switch {
+			case errors.As(err, &tooLarge):
+				respondErrorStr(c, http.StatusRequestEntityTooLarge, "File is too large")
+			case errors.Is(err, services.ErrInvalidCSVImport):
+				respondErrorStr(c, http.StatusBadRequest, err.Error())
+			case errors.Is(err, services.ErrCSVRowsInvalid):
+				// Nothing was imported; the result shows the invalid rows
+				c.JSON(http.StatusUnprocessableEntity, SuccessResponse{Data: result})
+			default:
+				log.Printf("ERROR ImportCSV: resource=%s, err=%v", resource, err)
+				respondErrorStr(c, http.StatusInternalServerError, "Failed to import CSV")
+			}
+			return
+		}
+
+		respondSuccess(c, result)
+	}
+}
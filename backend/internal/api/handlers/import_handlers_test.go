@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kubeatlas/kubeatlas/internal/api/middleware"
+)
+
+func TestImportRoutesRequireRole(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	// Stands in for Auth, taking the caller's role from a header
+	RegisterImportRoutes(r.Group("/import", func(c *gin.Context) {
+		c.Set(middleware.ContextUserRole, c.GetHeader("X-Role"))
+	}), nil)
+
+	tests := []struct {
+		role string
+		path string
+	}{
+		{"viewer", "/import/users"},
+		{"viewer", "/import/teams"},
+		{"viewer", "/import/business-units"},
+		{"viewer", "/import/backstage"},
+		{"editor", "/import/users"},
+		{"editor", "/import/business-units"},
+		{"editor", "/import/backstage"},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodPost, tt.path, strings.NewReader("email,name,role\nmallory@example.com,Mallory,admin\n"))
+		req.Header.Set("Content-Type", "text/csv")
+		req.Header.Set("X-Role", tt.role)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		if w.Code != http.StatusForbidden {
+			t.Errorf("%s POST %s: status = %d, want %d", tt.role, tt.path, w.Code, http.StatusForbidden)
+		}
+	}
+}
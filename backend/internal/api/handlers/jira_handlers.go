@@ -0,0 +1,165 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kubeatlas/kubeatlas/internal/api/middleware"
+	"github.com/kubeatlas/kubeatlas/internal/services"
+)
+
+// ============================================
+// Jira Configuration Handlers
+// ============================================
+
+// GetJiraConfig returns the organization's Jira settings without the API token
+func GetJiraConfig(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		orgID, ok := middleware.GetOrganizationID(c)
+		if !ok {
+			respondErrorStr(c, http.StatusUnauthorized, "Organization ID not found")
+			return
+		}
+
+		settings, err := svc.Jira.GetSettings(c.Request.Context(), orgID)
+		if err != nil {
+			respondErrorStr(c, http.StatusInternalServerError, "Failed to get settings")
+			return
+		}
+
+		// Never return credentials
+		settings.APIToken = ""
+
+		respondSuccess(c, settings)
+	}
+}
+
+// UpdateJiraConfig updates the organization's Jira settings
+func UpdateJiraConfig(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req services.JiraSettings
+		if err := c.ShouldBindJSON(&req); err != nil {
+			respondErrorStr(c, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+
+		settings, err := svc.Jira.UpdateSettings(c.Request.Context(), getAuditContext(c), req)
+		if err != nil {
+			if errors.Is(err, services.ErrInvalidJiraSettings) {
+				respondErrorStr(c, http.StatusBadRequest, err.Error())
+				return
+			}
+			log.Printf("ERROR UpdateJiraConfig: %v", err)
+			respondErrorStr(c, http.StatusInternalServerError, "Failed to update Jira configuration")
+			return
+		}
+
+		respondSuccess(c, settings)
+	}
+}
+
+// TestJiraConnection checks that the given settings can reach the project
+func TestJiraConnection(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		orgID, ok := middleware.GetOrganizationID(c)
+		if !ok {
+			respondErrorStr(c, http.StatusUnauthorized, "Organization ID not found")
+			return
+		}
+
+		var req services.JiraSettings
+		if err := c.ShouldBindJSON(&req); err != nil {
+			respondErrorStr(c, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+
+		if err := svc.Jira.TestSettings(c.Request.Context(), orgID, req); err != nil {
+			log.Printf("Jira test failed: %v", err)
+			respondSuccess(c, map[string]interface{}{
+				"success": false,
+				"message": err.Error(),
+			})
+			return
+		}
+
+		respondSuccess(c, map[string]interface{}{
+			"success": true,
+			"message": "Jira project found",
+		})
+	}
+}
+
+// ============================================
+// Remediation Ticket Handlers
+// ============================================
+
+// CreateNamespaceTicket opens a Jira issue to fix a namespace's missing
+// owner or documents
+func CreateNamespaceTicket(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := parseUUID(c, "id")
+		if !ok {
+			return
+		}
+
+		// The body is optional
+		var req services.CreateTicketRequest
+		if c.Request.ContentLength > 0 {
+			if err := c.ShouldBindJSON(&req); err != nil {
+				respondErrorStr(c, http.StatusBadRequest, "Invalid request body")
+				return
+			}
+		}
+
+		ticket, err := svc.Jira.CreateTicket(c.Request.Context(), getAuditContext(c), id, req)
+		if err != nil {
+			respondTicketError(c, "CreateNamespaceTicket", err)
+			return
+		}
+
+		c.JSON(http.StatusCreated, SuccessResponse{Data: ticket})
+	}
+}
+
+// CreateReportTickets opens Jira issues for the orphaned or undocumented
+// namespaces of a report
+func CreateReportTickets(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req services.BulkTicketRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			respondErrorStr(c, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+
+		result, err := svc.Jira.CreateTickets(c.Request.Context(), getAuditContext(c), req)
+		if err != nil {
+			respondTicketError(c, "CreateReportTickets", err)
+			return
+		}
+
+		respondSuccess(c, result)
+	}
+}
+
+// respondTicketError maps remediation ticket errors to responses
+func respondTicketError(c *gin.Context, op string, err error) {
+	switch {
+	case errors.Is(err, services.ErrNamespaceNotFound):
+		respondErrorStr(c, http.StatusNotFound, "Namespace not found")
+	case errors.Is(err, services.ErrJiraNotEnabled),
+		errors.Is(err, services.ErrInvalidTicketReason),
+		errors.Is(err, services.ErrTooManyTickets):
+		respondErrorStr(c, http.StatusBadRequest, err.Error())
+	case errors.Is(err, services.ErrTicketOpen),
+		errors.Is(err, services.ErrNothingToRemediate):
+		respondErrorStr(c, http.StatusConflict, err.Error())
+	case errors.Is(err, services.ErrJiraRequestFailed):
+		log.Printf("ERROR %s: %v", op, err)
+		respondErrorStr(c, http.StatusBadGateway, err.Error())
+	default:
+		log.Printf("ERROR %s: %v", op, err)
+		respondErrorStr(c, http.StatusInternalServerError, "Failed to create Jira issue")
+	}
+}
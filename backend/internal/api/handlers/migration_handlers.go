@@ -0,0 +1,27 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kubeatlas/kubeatlas/internal/services"
+)
+
+// ============================================
+// Migration Handlers
+// ============================================
+
+// ListMigrations lists the schema migrations and whether each is applied
+func ListMigrations(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		migrations, err := svc.Migration.Status(c.Request.Context())
+		if err != nil {
+			log.Printf("ERROR ListMigrations: %v", err)
+			respondErrorStr(c, http.StatusInternalServerError, "Failed to list migrations")
+			return
+		}
+
+		respondSuccess(c, migrations)
+	}
+}
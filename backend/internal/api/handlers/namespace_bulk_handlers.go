@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kubeatlas/kubeatlas/internal/api/middleware"
+	"github.com/kubeatlas/kubeatlas/internal/listquery"
+	"github.com/kubeatlas/kubeatlas/internal/models"
+	"github.com/kubeatlas/kubeatlas/internal/services"
+)
+
+// ============================================
+// Namespace Bulk Assignment Handlers
+// ============================================
+
+// BulkAssignNamespaces sets tags, an owner team or an environment on the
+// namespaces selected by ID or by the namespace list filters. Small
+// selections are assigned before it responds; larger ones respond 202 and
+// run in the background; poll GetNamespaceBulkAssignment for the summary.
+func BulkAssignNamespaces(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req services.BulkAssignRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			respondErrorStr(c, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		// The filter takes the namespace list's query parameters, q included
+		params := make(url.Values, len(req.Filter))
+		for key, value := range req.Filter {
+			params.Set(key, value)
+		}
+		if q := params.Get("q"); q != "" {
+			params.Del("q")
+			if err := listquery.Expand(q, namespaceQueryFields, params); err != nil {
+				respondErrorStr(c, http.StatusBadRequest, err.Error())
+				return
+			}
+		}
+		filters, err := namespaceListFilters(params)
+		if err != nil {
+			respondErrorStr(c, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		assignment, err := svc.BulkAssign.Request(c.Request.Context(), getAuditContext(c), req, filters)
+		if err != nil {
+			respondBulkAssignError(c, "BulkAssignNamespaces", err, "Failed to assign namespaces")
+			return
+		}
+
+		if assignment.Status == models.BulkAssignmentStatusPending {
+			c.JSON(http.StatusAccepted, SuccessResponse{Data: assignment})
+			return
+		}
+		respondSuccess(c, assignment)
+	}
+}
+
+// ListNamespaceBulkAssignments lists the organization's most recent bulk
+// assignments
+func ListNamespaceBulkAssignments(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		orgID, ok := middleware.GetOrganizationID(c)
+		if !ok {
+			respondErrorStr(c, http.StatusUnauthorized, "Organization ID not found")
+			return
+		}
+
+		assignments, err := svc.BulkAssign.List(c.Request.Context(), orgID)
+		if err != nil {
+			respondBulkAssignError(c, "ListNamespaceBulkAssignments", err, "Failed to list bulk assignments")
+			return
+		}
+
+		respondSuccess(c, assignments)
+	}
+}
+
+// GetNamespaceBulkAssignment returns a bulk assignment with its progress or
+// result summary
+func GetNamespaceBulkAssignment(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		orgID, ok := middleware.GetOrganizationID(c)
+		if !ok {
+			respondErrorStr(c, http.StatusUnauthorized, "Organization ID not found")
+			return
+		}
+		id, ok := parseUUID(c, "id")
+		if !ok {
+			return
+		}
+
+		assignment, err := svc.BulkAssign.Get(c.Request.Context(), orgID, id)
+		if err != nil {
+			respondBulkAssignError(c, "GetNamespaceBulkAssignment", err, "Failed to get bulk assignment")
+			return
+		}
+
+		respondSuccess(c, assignment)
+	}
+}
+
+func respondBulkAssignError(c *gin.Context, op string, err error, message string) {
+	switch {
+	case errors.Is(err, services.ErrBulkAssignmentNotFound):
+		respondErrorStr(c, http.StatusNotFound, "Bulk assignment not found")
+	case errors.Is(err, services.ErrInvalidBulkAssignment), errors.Is(err, services.ErrInvalidEnvironment):
+		respondErrorStr(c, http.StatusBadRequest, err.Error())
+	default:
+		log.Printf("ERROR %s: %v", op, err)
+		respondErrorStr(c, http.StatusInternalServerError, message)
+	}
+}
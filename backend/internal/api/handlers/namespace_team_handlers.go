@@ -2,23 +2,72 @@ package handlers
 
 import (
 	"errors"
+	"fmt"
 	"log"
 	"net/http"
+	"net/url"
+	"sort"
 	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/kubeatlas/kubeatlas/internal/api/middleware"
+	"github.com/kubeatlas/kubeatlas/internal/listquery"
 	"github.com/kubeatlas/kubeatlas/internal/services"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
 )
 
 // ============================================
 // Namespace Handlers
 // ============================================
 
+// namespaceQueryFields are the fields of the namespace list's query
+// language, such as environment:production AND owner:none
+var namespaceQueryFields = listquery.Fields{
+	"":             listquery.Param("search"),
+	"environment":  listquery.Param("environment"),
+	"criticality":  listquery.Param("criticality"),
+	"status":       listquery.Param("status"),
+	"lifecycle":    listquery.Param("lifecycle"),
+	"pod_security": listquery.Param("pod_security"),
+	"cluster":      listquery.Param("cluster"),
+	"tag":          listquery.Param("tag"),
+	"archived":     listquery.Bool("archived"),
+	// owner:none matches namespaces without an owner team, other values the
+	// slug or name of the team
+	"owner": func(value string, params url.Values) error {
+		if strings.EqualFold(value, "none") {
+			params.Set("orphaned", "true")
+		} else {
+			params.Set("team", value)
+		}
+		return nil
+	},
+	"documented": func(value string, params url.Values) error {
+		if !strings.EqualFold(value, "false") {
+			return fmt.Errorf("%w: only documented:false is supported", listquery.ErrInvalidValue)
+		}
+		params.Set("undocumented", "true")
+		return nil
+	},
+	// label:team=payments adds a requirement to the label selector
+	"label": func(value string, params url.Values) error {
+		if selector := params.Get("labelSelector"); selector != "" {
+			value = selector + "," + value
+		}
+		params.Set("labelSelector", value)
+		return nil
+	},
+}
+
 // ListNamespaces returns all namespaces
 func ListNamespaces(svc *services.Services) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		if !expandListQuery(c, namespaceQueryFields) {
+			return
+		}
 		orgID, ok := middleware.GetOrganizationID(c)
 		if !ok {
 			log.Printf("ERROR ListNamespaces: Organization ID not found in context")
@@ -27,54 +76,235 @@ func ListNamespaces(svc *services.Services) gin.HandlerFunc {
 		}
 		p := getPagination(c)
 
-		filters := make(map[string]interface{})
-		if clusterID := c.Query("cluster_id"); clusterID != "" {
-			if id, err := uuid.Parse(clusterID); err == nil {
-				filters["cluster_id"] = id
-			}
+		filters, err := namespaceListFilters(c.Request.URL.Query())
+		if err != nil {
+			respondErrorStr(c, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		result, err := svc.Namespace.List(c.Request.Context(), orgID, p, filters)
+		if err != nil {
+			log.Printf("ERROR ListNamespaces: orgID=%s, err=%v", orgID, err)
+			respondError(c, http.StatusInternalServerError, err)
+			return
+		}
+
+		if result == nil {
+			respondPaginated(c, []interface{}{}, 0, p.Page, p.PageSize, 0)
+			return
 		}
-		if environment := c.Query("environment"); environment != "" {
-			filters["environment"] = environment
+
+		respondPaginated(c, result.Items, result.Total, result.Page, result.PageSize, result.TotalPages)
+	}
+}
+
+// namespaceListFilters parses the filters of the namespace list from its
+// query parameters
+func namespaceListFilters(params url.Values) (map[string]interface{}, error) {
+	filters := make(map[string]interface{})
+	if clusterID := params.Get("cluster_id"); clusterID != "" {
+		if id, err := uuid.Parse(clusterID); err == nil {
+			filters["cluster_id"] = id
 		}
-		if criticality := c.Query("criticality"); criticality != "" {
-			filters["criticality"] = criticality
+	}
+	if environment := params.Get("environment"); environment != "" {
+		filters["environment"] = environment
+	}
+	if criticality := params.Get("criticality"); criticality != "" {
+		filters["criticality"] = criticality
+	}
+	if podSecurity := params.Get("pod_security"); podSecurity != "" {
+		filters["pod_security"] = podSecurity
+	}
+	selector, err := labelSelectorParams(params)
+	if err != nil {
+		return nil, err
+	}
+	if selector != nil {
+		filters["label_selector"] = selector
+	}
+	if status := params.Get("status"); status != "" {
+		filters["status"] = status
+	}
+	if archived := params.Get("archived"); archived != "" {
+		filters["archived"] = archived == "true"
+	}
+	if lifecycle := params.Get("lifecycle"); lifecycle != "" {
+		filters["lifecycle"] = lifecycle
+	}
+	if overdue := params.Get("decommission_overdue"); overdue == "true" {
+		filters["decommission_overdue"] = true
+	}
+	if businessUnitID := params.Get("business_unit_id"); businessUnitID != "" {
+		if id, err := uuid.Parse(businessUnitID); err == nil {
+			filters["business_unit_id"] = id
 		}
-		if status := c.Query("status"); status != "" {
-			filters["status"] = status
+	}
+	if teamID := params.Get("team_id"); teamID != "" {
+		if id, err := uuid.Parse(teamID); err == nil {
+			filters["team_id"] = id
+		}
+	}
+	if applicationID := params.Get("application_id"); applicationID != "" {
+		if id, err := uuid.Parse(applicationID); err == nil {
+			filters["application_id"] = id
 		}
-		if businessUnitID := c.Query("business_unit_id"); businessUnitID != "" {
-			if id, err := uuid.Parse(businessUnitID); err == nil {
-				filters["business_unit_id"] = id
+	}
+	if cluster := params.Get("cluster"); cluster != "" {
+		filters["cluster"] = cluster
+	}
+	if team := params.Get("team"); team != "" {
+		filters["team"] = team
+	}
+	if tag := params.Get("tag"); tag != "" {
+		filters["tag"] = tag
+	}
+	if search := params.Get("search"); search != "" {
+		filters["search"] = search
+	}
+	if orphaned := params.Get("orphaned"); orphaned == "true" {
+		filters["orphaned"] = true
+	}
+	if undocumented := params.Get("undocumented"); undocumented == "true" {
+		filters["undocumented"] = true
+	}
+	if invalidContacts := params.Get("invalid_contacts"); invalidContacts == "true" {
+		filters["invalid_contacts"] = true
+	}
+	for _, param := range []string{"min_score", "max_score"} {
+		if v := params.Get(param); v != "" {
+			score, err := strconv.Atoi(v)
+			if err != nil || score < 0 || score > 100 {
+				return nil, fmt.Errorf("%s must be a number from 0 to 100", param)
 			}
+			filters[param] = score
 		}
-		if teamID := c.Query("team_id"); teamID != "" {
-			if id, err := uuid.Parse(teamID); err == nil {
-				filters["team_id"] = id
+	}
+	for _, param := range []string{"max_cpu_millicores", "max_memory_bytes"} {
+		if v := params.Get(param); v != "" {
+			limit, err := strconv.ParseInt(v, 10, 64)
+			if err != nil || limit < 0 {
+				return nil, fmt.Errorf("%s must be a non-negative number", param)
 			}
+			filters[param] = limit
 		}
-		if search := c.Query("search"); search != "" {
-			filters["search"] = search
+	}
+	if includeRelations := params.Get("include_relations"); includeRelations == "false" {
+		filters["include_relations"] = false
+	}
+	return filters, nil
+}
+
+// labelFilterPrefix prefixes the list parameters requiring a label value,
+// such as labels.region=eu-west-1
+const labelFilterPrefix = "labels."
+
+// labelSelectorParams parses the label filters of a list: its labelSelector
+// and its labels.<key>=<value> parameters, all of which must match. Several
+// values of one key match any of them. Returns nil when there are none.
+func labelSelectorParams(params url.Values) (labels.Selector, error) {
+	selector := labels.Everything()
+	if labelSelector := params.Get("labelSelector"); labelSelector != "" {
+		parsed, err := labels.Parse(labelSelector)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid label selector: %w", err)
 		}
-		if orphaned := c.Query("orphaned"); orphaned == "true" {
-			filters["orphaned"] = true
+		selector = parsed
+	}
+
+	keys := make([]string, 0)
+	for param := range params {
+		if strings.HasPrefix(param, labelFilterPrefix) {
+			keys = append(keys, param)
+		}
+	}
+	sort.Strings(keys)
+	for _, param := range keys {
+		values := params[param]
+		op := selection.Equals
+		if len(values) > 1 {
+			op = selection.In
 		}
-		if undocumented := c.Query("undocumented"); undocumented == "true" {
-			filters["undocumented"] = true
+		req, err := labels.NewRequirement(strings.TrimPrefix(param, labelFilterPrefix), op, values)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid label filter %s: %w", param, err)
 		}
+		selector = selector.Add(*req)
+	}
 
-		result, err := svc.Namespace.List(c.Request.Context(), orgID, p, filters)
+	if selector.Empty() {
+		return nil, nil
+	}
+	return selector, nil
+}
+
+// SearchNamespaces performs a ranked free-text search over namespaces
+func SearchNamespaces(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		orgID, ok := middleware.GetOrganizationID(c)
+		if !ok {
+			respondErrorStr(c, http.StatusUnauthorized, "Organization ID not found in context")
+			return
+		}
+
+		term := strings.TrimSpace(c.Query("q"))
+		if term == "" {
+			respondErrorStr(c, http.StatusBadRequest, "Query parameter 'q' is required")
+			return
+		}
+
+		limit := 20
+		if l := c.Query("limit"); l != "" {
+			if val, err := strconv.Atoi(l); err == nil && val > 0 {
+				limit = val
+			}
+		}
+
+		namespaces, err := svc.Namespace.Search(c.Request.Context(), orgID, term, limit)
 		if err != nil {
-			log.Printf("ERROR ListNamespaces: orgID=%s, err=%v", orgID, err)
-			respondError(c, http.StatusInternalServerError, err)
+			log.Printf("ERROR SearchNamespaces: orgID=%s, err=%v", orgID, err)
+			respondErrorStr(c, http.StatusInternalServerError, "Failed to search namespaces")
 			return
 		}
 
-		if result == nil {
-			respondPaginated(c, []interface{}{}, 0, p.Page, p.PageSize, 0)
+		respondSuccess(c, namespaces)
+	}
+}
+
+// CompareNamespaces returns the differences between the namespaces given by
+// the a and b query parameters, such as the staging and production
+// namespaces of an application
+func CompareNamespaces(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		orgID, ok := middleware.GetOrganizationID(c)
+		if !ok {
+			respondErrorStr(c, http.StatusUnauthorized, "Organization ID not found in context")
 			return
 		}
 
-		respondPaginated(c, result.Items, result.Total, result.Page, result.PageSize, result.TotalPages)
+		a, errA := uuid.Parse(c.Query("a"))
+		b, errB := uuid.Parse(c.Query("b"))
+		if errA != nil || errB != nil {
+			respondErrorStr(c, http.StatusBadRequest, "Query parameters 'a' and 'b' must be namespace IDs")
+			return
+		}
+		if a == b {
+			respondErrorStr(c, http.StatusBadRequest, "Query parameters 'a' and 'b' must be different namespaces")
+			return
+		}
+
+		comparison, err := svc.Compare.Compare(c.Request.Context(), orgID, a, b)
+		if err != nil {
+			if errors.Is(err, services.ErrNamespaceNotFound) {
+				respondErrorStr(c, http.StatusNotFound, "Namespace not found")
+				return
+			}
+			log.Printf("ERROR CompareNamespaces: %v", err)
+			respondErrorStr(c, http.StatusInternalServerError, "Failed to compare namespaces")
+			return
+		}
+
+		respondSuccess(c, comparison)
 	}
 }
 
@@ -96,6 +326,8 @@ func GetNamespace(svc *services.Services) gin.HandlerFunc {
 			return
 		}
 
+		svc.Audit.LogAccess(c.Request.Context(), getAuditContext(c), services.AuditActionView, "namespace", ns.ID, ns.Name, "Viewed namespace")
+
 		respondSuccess(c, ns)
 	}
 }
@@ -126,6 +358,10 @@ func UpdateNamespace(svc *services.Services) gin.HandlerFunc {
 				respondErrorStr(c, http.StatusNotFound, "Namespace not found")
 				return
 			}
+			if errors.Is(err, services.ErrInvalidEscalationPath) || errors.Is(err, services.ErrInvalidEnvironment) || errors.Is(err, services.ErrInvalidNamespace) {
+				respondErrorStr(c, http.StatusBadRequest, err.Error())
+				return
+			}
 			respondErrorStr(c, http.StatusInternalServerError, "Failed to update namespace")
 			return
 		}
@@ -134,6 +370,93 @@ func UpdateNamespace(svc *services.Services) gin.HandlerFunc {
 	}
 }
 
+// ImportNamespaceOwnership sets the ownership of many namespaces keyed by
+// cluster and namespace name, or reports the changes with dry_run
+func ImportNamespaceOwnership(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		orgID, ok := middleware.GetOrganizationID(c)
+		if !ok {
+			respondErrorStr(c, http.StatusUnauthorized, "Organization ID not found in context")
+			return
+		}
+
+		var req services.ImportOwnershipRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			respondErrorStr(c, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+
+		result, err := svc.Namespace.ImportOwnership(c.Request.Context(), getAuditContext(c), orgID, req)
+		if err != nil {
+			if errors.Is(err, services.ErrInvalidOwnershipImport) {
+				respondErrorStr(c, http.StatusBadRequest, err.Error())
+				return
+			}
+			log.Printf("ERROR ImportNamespaceOwnership: %v", err)
+			respondErrorStr(c, http.StatusInternalServerError, "Failed to import namespace ownership")
+			return
+		}
+
+		respondSuccess(c, result)
+	}
+}
+
+// CheckNamespace reports whether the namespace named by the cluster and
+// namespace query parameters has the metadata the organization requires
+func CheckNamespace(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		orgID, ok := middleware.GetOrganizationID(c)
+		if !ok {
+			respondErrorStr(c, http.StatusUnauthorized, "Organization ID not found in context")
+			return
+		}
+
+		cluster, namespace := c.Query("cluster"), c.Query("namespace")
+		if cluster == "" || namespace == "" {
+			respondErrorStr(c, http.StatusBadRequest, "cluster and namespace are required")
+			return
+		}
+
+		check, err := svc.Namespace.Check(c.Request.Context(), orgID, cluster, namespace)
+		if err != nil {
+			if errors.Is(err, services.ErrClusterNotFound) {
+				respondErrorStr(c, http.StatusNotFound, "Cluster not found")
+				return
+			}
+			if errors.Is(err, services.ErrNamespaceNotFound) {
+				respondErrorStr(c, http.StatusNotFound, "Namespace not found")
+				return
+			}
+			log.Printf("ERROR CheckNamespace: %v", err)
+			respondErrorStr(c, http.StatusInternalServerError, "Failed to check namespace")
+			return
+		}
+
+		respondSuccess(c, check)
+	}
+}
+
+// GetAdmissionPolicy returns the labels and annotations new namespaces need
+// to meet the organization's metadata requirements, for admission webhooks
+func GetAdmissionPolicy(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		orgID, ok := middleware.GetOrganizationID(c)
+		if !ok {
+			respondErrorStr(c, http.StatusUnauthorized, "Organization ID not found in context")
+			return
+		}
+
+		policy, err := svc.Namespace.AdmissionPolicy(c.Request.Context(), orgID)
+		if err != nil {
+			log.Printf("ERROR GetAdmissionPolicy: %v", err)
+			respondErrorStr(c, http.StatusInternalServerError, "Failed to get admission policy")
+			return
+		}
+
+		respondSuccess(c, policy)
+	}
+}
+
 // ListNamespaceDependencies returns dependencies for a namespace
 func ListNamespaceDependencies(svc *services.Services) gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -199,6 +522,263 @@ func ListNamespaceHistory(svc *services.Services) gin.HandlerFunc {
 	}
 }
 
+// GetNamespaceOwnershipHistory returns who owned a namespace and when,
+// derived from its audit logs
+func GetNamespaceOwnershipHistory(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := parseUUID(c, "id")
+		if !ok {
+			return
+		}
+
+		history, err := svc.Ownership.NamespaceHistory(c.Request.Context(), getAuditContext(c).OrgID, id)
+		if err != nil {
+			if errors.Is(err, services.ErrNamespaceNotFound) {
+				respondErrorStr(c, http.StatusNotFound, "Namespace not found")
+				return
+			}
+			log.Printf("ERROR GetNamespaceOwnershipHistory: %v", err)
+			respondErrorStr(c, http.StatusInternalServerError, "Failed to get namespace ownership history")
+			return
+		}
+
+		respondSuccess(c, history)
+	}
+}
+
+// GetNamespaceEscalationPath returns the namespace's escalation path parsed
+// into levels
+func GetNamespaceEscalationPath(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := parseUUID(c, "id")
+		if !ok {
+			return
+		}
+
+		levels, err := svc.Namespace.EscalationLevels(c.Request.Context(), id)
+		if err != nil {
+			switch {
+			case errors.Is(err, services.ErrNamespaceNotFound):
+				respondErrorStr(c, http.StatusNotFound, "Namespace not found")
+			case errors.Is(err, services.ErrInvalidEscalationPath):
+				respondErrorStr(c, http.StatusUnprocessableEntity, err.Error())
+			default:
+				log.Printf("ERROR GetNamespaceEscalationPath: %v", err)
+				respondErrorStr(c, http.StatusInternalServerError, "Failed to get escalation path")
+			}
+			return
+		}
+
+		respondSuccess(c, levels)
+	}
+}
+
+// GetNamespaceAccess returns which subjects have which roles in a namespace,
+// as found by the last cluster sync
+func GetNamespaceAccess(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := parseUUID(c, "id")
+		if !ok {
+			return
+		}
+
+		access, err := svc.Namespace.GetAccess(c.Request.Context(), id)
+		if err != nil {
+			if errors.Is(err, services.ErrNamespaceNotFound) {
+				respondErrorStr(c, http.StatusNotFound, "Namespace not found")
+				return
+			}
+			log.Printf("ERROR GetNamespaceAccess: %v", err)
+			respondErrorStr(c, http.StatusInternalServerError, "Failed to get namespace access")
+			return
+		}
+
+		svc.Audit.LogAccess(c.Request.Context(), getAuditContext(c), services.AuditActionView, "namespace", access.NamespaceID, access.Namespace, "Viewed namespace access")
+
+		respondSuccess(c, access)
+	}
+}
+
+// GetNamespaceFlux returns the Flux Kustomizations and HelmReleases of a
+// namespace, as found by the last cluster sync
+func GetNamespaceFlux(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := parseUUID(c, "id")
+		if !ok {
+			return
+		}
+
+		flux, err := svc.Namespace.GetFlux(c.Request.Context(), id)
+		if err != nil {
+			if errors.Is(err, services.ErrNamespaceNotFound) {
+				respondErrorStr(c, http.StatusNotFound, "Namespace not found")
+				return
+			}
+			log.Printf("ERROR GetNamespaceFlux: %v", err)
+			respondErrorStr(c, http.StatusInternalServerError, "Failed to get namespace Flux resources")
+			return
+		}
+
+		respondSuccess(c, flux)
+	}
+}
+
+// GetNamespaceUsage returns the CPU and memory usage of a namespace, as
+// sampled from metrics-server by cluster syncs
+func GetNamespaceUsage(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := parseUUID(c, "id")
+		if !ok {
+			return
+		}
+
+		usage, err := svc.Namespace.GetUsage(c.Request.Context(), id)
+		if err != nil {
+			if errors.Is(err, services.ErrNamespaceNotFound) {
+				respondErrorStr(c, http.StatusNotFound, "Namespace not found")
+				return
+			}
+			log.Printf("ERROR GetNamespaceUsage: %v", err)
+			respondErrorStr(c, http.StatusInternalServerError, "Failed to get namespace usage")
+			return
+		}
+
+		respondSuccess(c, usage)
+	}
+}
+
+// ArchiveNamespace archives a namespace, leaving it out of coverage
+// statistics
+func ArchiveNamespace(svc *services.Services) gin.HandlerFunc {
+	return setNamespaceArchived(svc, true)
+}
+
+// UnarchiveNamespace makes an archived namespace active again
+func UnarchiveNamespace(svc *services.Services) gin.HandlerFunc {
+	return setNamespaceArchived(svc, false)
+}
+
+func setNamespaceArchived(svc *services.Services, archived bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := parseUUID(c, "id")
+		if !ok {
+			return
+		}
+
+		archive := svc.Namespace.Unarchive
+		if archived {
+			archive = svc.Namespace.Archive
+		}
+		ns, err := archive(c.Request.Context(), getAuditContext(c), id)
+		if err != nil {
+			if errors.Is(err, services.ErrNamespaceNotFound) {
+				respondErrorStr(c, http.StatusNotFound, "Namespace not found")
+				return
+			}
+			log.Printf("ERROR setNamespaceArchived: %v", err)
+			respondErrorStr(c, http.StatusInternalServerError, "Failed to update namespace")
+			return
+		}
+
+		respondSuccess(c, ns)
+	}
+}
+
+// SetNamespaceLifecycle moves a namespace to another lifecycle stage
+func SetNamespaceLifecycle(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := parseUUID(c, "id")
+		if !ok {
+			return
+		}
+
+		var req services.SetLifecycleRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			respondErrorStr(c, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+
+		ns, err := svc.Namespace.SetLifecycle(c.Request.Context(), getAuditContext(c), id, req)
+		if err != nil {
+			switch {
+			case errors.Is(err, services.ErrNamespaceNotFound):
+				respondErrorStr(c, http.StatusNotFound, "Namespace not found")
+			case errors.Is(err, services.ErrInvalidLifecycleTransition), errors.Is(err, services.ErrInvalidNamespace):
+				respondErrorStr(c, http.StatusBadRequest, err.Error())
+			case errors.Is(err, services.ErrLifecycleFieldsMissing):
+				respondErrorStr(c, http.StatusUnprocessableEntity, err.Error())
+			default:
+				log.Printf("ERROR SetNamespaceLifecycle: %v", err)
+				respondErrorStr(c, http.StatusInternalServerError, "Failed to update namespace lifecycle")
+			}
+			return
+		}
+
+		respondSuccess(c, ns)
+	}
+}
+
+// ListNamespaceDuplicates lists deleted namespaces that were replaced by a
+// current namespace and can be merged into it
+func ListNamespaceDuplicates(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		orgID, ok := middleware.GetOrganizationID(c)
+		if !ok {
+			respondErrorStr(c, http.StatusUnauthorized, "Organization ID not found in context")
+			return
+		}
+
+		duplicates, err := svc.Namespace.ListDuplicates(c.Request.Context(), orgID)
+		if err != nil {
+			log.Printf("ERROR ListNamespaceDuplicates: %v", err)
+			respondErrorStr(c, http.StatusInternalServerError, "Failed to list duplicate namespaces")
+			return
+		}
+
+		respondSuccess(c, duplicates)
+	}
+}
+
+// MergeNamespaceRequest names the deleted namespace to merge
+type MergeNamespaceRequest struct {
+	DeletedID uuid.UUID `json:"deleted_id" binding:"required"`
+}
+
+// MergeNamespace moves the ownership, documents and dependencies of a deleted
+// namespace to the namespace that replaced it
+func MergeNamespace(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := parseUUID(c, "id")
+		if !ok {
+			return
+		}
+
+		var req MergeNamespaceRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			respondErrorStr(c, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+
+		merge, err := svc.Namespace.Merge(c.Request.Context(), getAuditContext(c), req.DeletedID, id)
+		if err != nil {
+			switch {
+			case errors.Is(err, services.ErrNamespaceNotFound):
+				respondErrorStr(c, http.StatusNotFound, "Namespace not found")
+			case errors.Is(err, services.ErrDuplicateNotFound):
+				respondErrorStr(c, http.StatusNotFound, err.Error())
+			case errors.Is(err, services.ErrInvalidNamespace):
+				respondErrorStr(c, http.StatusBadRequest, err.Error())
+			default:
+				log.Printf("ERROR MergeNamespace: %v", err)
+				respondErrorStr(c, http.StatusInternalServerError, "Failed to merge namespace")
+			}
+			return
+		}
+
+		respondSuccess(c, merge)
+	}
+}
+
 // ============================================
 // Team Handlers (Additional)
 // ============================================
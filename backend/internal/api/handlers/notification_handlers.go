@@ -0,0 +1,542 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kubeatlas/kubeatlas/internal/api/middleware"
+	"github.com/kubeatlas/kubeatlas/internal/services"
+)
+
+// ============================================
+// SMTP Configuration Handlers
+// ============================================
+
+// GetSMTPConfig returns the organization's SMTP settings without the password
+func GetSMTPConfig(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		orgID, ok := middleware.GetOrganizationID(c)
+		if !ok {
+			respondErrorStr(c, http.StatusUnauthorized, "Organization ID not found")
+			return
+		}
+
+		settings, err := svc.Notification.GetSMTPSettings(c.Request.Context(), orgID)
+		if err != nil {
+			respondErrorStr(c, http.StatusInternalServerError, "Failed to get settings")
+			return
+		}
+
+		// Never return the password
+		settings.Password = ""
+
+		respondSuccess(c, settings)
+	}
+}
+
+// UpdateSMTPConfig updates the organization's SMTP settings
+func UpdateSMTPConfig(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req services.SMTPSettings
+		if err := c.ShouldBindJSON(&req); err != nil {
+			respondErrorStr(c, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+
+		settings, err := svc.Notification.UpdateSMTPSettings(c.Request.Context(), getAuditContext(c), req)
+		if err != nil {
+			if errors.Is(err, services.ErrInvalidSMTPSettings) {
+				respondErrorStr(c, http.StatusBadRequest, err.Error())
+				return
+			}
+			log.Printf("ERROR UpdateSMTPConfig: %v", err)
+			respondErrorStr(c, http.StatusInternalServerError, "Failed to update SMTP configuration")
+			return
+		}
+
+		respondSuccess(c, settings)
+	}
+}
+
+// TestSMTPRequest is the payload for sending a test email
+type TestSMTPRequest struct {
+	services.SMTPSettings
+	To string `json:"to" binding:"required,email"`
+}
+
+// TestSMTPConnection sends a test email with the given settings
+func TestSMTPConnection(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		orgID, ok := middleware.GetOrganizationID(c)
+		if !ok {
+			respondErrorStr(c, http.StatusUnauthorized, "Organization ID not found")
+			return
+		}
+
+		var req TestSMTPRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			respondErrorStr(c, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+
+		err := svc.Notification.TestSMTPSettings(c.Request.Context(), orgID, req.SMTPSettings, req.To)
+		if err != nil {
+			log.Printf("SMTP test failed: %v", err)
+			respondSuccess(c, map[string]interface{}{
+				"success": false,
+				"message": err.Error(),
+			})
+			return
+		}
+
+		respondSuccess(c, map[string]interface{}{
+			"success": true,
+			"message": "Test email sent to " + req.To,
+		})
+	}
+}
+
+// ============================================
+// Slack Configuration Handlers
+// ============================================
+
+// GetSlackConfig returns the organization's Slack settings without credentials
+func GetSlackConfig(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		orgID, ok := middleware.GetOrganizationID(c)
+		if !ok {
+			respondErrorStr(c, http.StatusUnauthorized, "Organization ID not found")
+			return
+		}
+
+		settings, err := svc.Notification.GetSlackSettings(c.Request.Context(), orgID)
+		if err != nil {
+			respondErrorStr(c, http.StatusInternalServerError, "Failed to get settings")
+			return
+		}
+
+		// Never return credentials
+		settings.WebhookURL = ""
+		settings.BotToken = ""
+
+		respondSuccess(c, settings)
+	}
+}
+
+// UpdateSlackConfig updates the organization's Slack settings
+func UpdateSlackConfig(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req services.SlackSettings
+		if err := c.ShouldBindJSON(&req); err != nil {
+			respondErrorStr(c, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+
+		settings, err := svc.Notification.UpdateSlackSettings(c.Request.Context(), getAuditContext(c), req)
+		if err != nil {
+			if errors.Is(err, services.ErrInvalidSlackSettings) {
+				respondErrorStr(c, http.StatusBadRequest, err.Error())
+				return
+			}
+			log.Printf("ERROR UpdateSlackConfig: %v", err)
+			respondErrorStr(c, http.StatusInternalServerError, "Failed to update Slack configuration")
+			return
+		}
+
+		respondSuccess(c, settings)
+	}
+}
+
+// TestSlackRequest is the payload for posting a test message
+type TestSlackRequest struct {
+	services.SlackSettings
+	Channel string `json:"channel"`
+}
+
+// TestSlackConnection posts a test message with the given settings
+func TestSlackConnection(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		orgID, ok := middleware.GetOrganizationID(c)
+		if !ok {
+			respondErrorStr(c, http.StatusUnauthorized, "Organization ID not found")
+			return
+		}
+
+		var req TestSlackRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			respondErrorStr(c, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+
+		err := svc.Notification.TestSlackSettings(c.Request.Context(), orgID, req.SlackSettings, req.Channel)
+		if err != nil {
+			log.Printf("Slack test failed: %v", err)
+			respondSuccess(c, map[string]interface{}{
+				"success": false,
+				"message": err.Error(),
+			})
+			return
+		}
+
+		respondSuccess(c, map[string]interface{}{
+			"success": true,
+			"message": "Slack test message sent",
+		})
+	}
+}
+
+// ============================================
+// Microsoft Teams Configuration Handlers
+// ============================================
+
+// GetTeamsConfig returns the organization's Microsoft Teams settings without webhook URLs
+func GetTeamsConfig(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		orgID, ok := middleware.GetOrganizationID(c)
+		if !ok {
+			respondErrorStr(c, http.StatusUnauthorized, "Organization ID not found")
+			return
+		}
+
+		settings, err := svc.Notification.GetTeamsSettings(c.Request.Context(), orgID)
+		if err != nil {
+			respondErrorStr(c, http.StatusInternalServerError, "Failed to get settings")
+			return
+		}
+
+		// Webhook URLs are credentials
+		settings.WebhookURL = ""
+		settings.EventWebhooks = nil
+
+		respondSuccess(c, settings)
+	}
+}
+
+// UpdateTeamsConfig updates the organization's Microsoft Teams settings
+func UpdateTeamsConfig(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req services.TeamsSettings
+		if err := c.ShouldBindJSON(&req); err != nil {
+			respondErrorStr(c, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+
+		settings, err := svc.Notification.UpdateTeamsSettings(c.Request.Context(), getAuditContext(c), req)
+		if err != nil {
+			if errors.Is(err, services.ErrInvalidTeamsSettings) {
+				respondErrorStr(c, http.StatusBadRequest, err.Error())
+				return
+			}
+			log.Printf("ERROR UpdateTeamsConfig: %v", err)
+			respondErrorStr(c, http.StatusInternalServerError, "Failed to update Microsoft Teams configuration")
+			return
+		}
+
+		respondSuccess(c, settings)
+	}
+}
+
+// TestTeamsRequest is the payload for posting a test card
+type TestTeamsRequest struct {
+	WebhookURL string `json:"webhook_url"`
+}
+
+// TestTeamsConnection posts a test card to a webhook
+func TestTeamsConnection(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		orgID, ok := middleware.GetOrganizationID(c)
+		if !ok {
+			respondErrorStr(c, http.StatusUnauthorized, "Organization ID not found")
+			return
+		}
+
+		var req TestTeamsRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			respondErrorStr(c, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+
+		err := svc.Notification.TestTeamsSettings(c.Request.Context(), orgID, req.WebhookURL)
+		if err != nil {
+			log.Printf("Microsoft Teams test failed: %v", err)
+			respondSuccess(c, map[string]interface{}{
+				"success": false,
+				"message": err.Error(),
+			})
+			return
+		}
+
+		respondSuccess(c, map[string]interface{}{
+			"success": true,
+			"message": "Microsoft Teams test message sent",
+		})
+	}
+}
+
+// ============================================
+// Sync Alert Configuration Handlers
+// ============================================
+
+// GetSyncAlertConfig returns when cluster owners are alerted about failing syncs
+func GetSyncAlertConfig(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		orgID, ok := middleware.GetOrganizationID(c)
+		if !ok {
+			respondErrorStr(c, http.StatusUnauthorized, "Organization ID not found")
+			return
+		}
+
+		settings, err := svc.Notification.GetSyncAlertSettings(c.Request.Context(), orgID)
+		if err != nil {
+			respondErrorStr(c, http.StatusInternalServerError, "Failed to get settings")
+			return
+		}
+
+		respondSuccess(c, settings)
+	}
+}
+
+// UpdateSyncAlertConfig updates the failure threshold and repeat suppression of sync alerts
+func UpdateSyncAlertConfig(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req services.SyncAlertSettings
+		if err := c.ShouldBindJSON(&req); err != nil {
+			respondErrorStr(c, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+
+		settings, err := svc.Notification.UpdateSyncAlertSettings(c.Request.Context(), getAuditContext(c), req)
+		if err != nil {
+			if errors.Is(err, services.ErrInvalidSyncAlertSettings) {
+				respondErrorStr(c, http.StatusBadRequest, err.Error())
+				return
+			}
+			log.Printf("ERROR UpdateSyncAlertConfig: %v", err)
+			respondErrorStr(c, http.StatusInternalServerError, "Failed to update sync alert configuration")
+			return
+		}
+
+		respondSuccess(c, settings)
+	}
+}
+
+// ============================================
+// Digest Configuration Handlers
+// ============================================
+
+// GetDigestConfig returns the weekly namespace digest settings
+func GetDigestConfig(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		orgID, ok := middleware.GetOrganizationID(c)
+		if !ok {
+			respondErrorStr(c, http.StatusUnauthorized, "Organization ID not found")
+			return
+		}
+
+		settings, err := svc.Notification.GetDigestSettings(c.Request.Context(), orgID)
+		if err != nil {
+			respondErrorStr(c, http.StatusInternalServerError, "Failed to get settings")
+			return
+		}
+
+		respondSuccess(c, settings)
+	}
+}
+
+// UpdateDigestConfig updates the weekly namespace digest settings
+func UpdateDigestConfig(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req services.DigestSettings
+		if err := c.ShouldBindJSON(&req); err != nil {
+			respondErrorStr(c, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+
+		settings, err := svc.Notification.UpdateDigestSettings(c.Request.Context(), getAuditContext(c), req)
+		if err != nil {
+			log.Printf("ERROR UpdateDigestConfig: %v", err)
+			respondErrorStr(c, http.StatusInternalServerError, "Failed to update digest configuration")
+			return
+		}
+
+		respondSuccess(c, settings)
+	}
+}
+
+// ============================================
+// Notification Handlers
+// ============================================
+
+// ListNotificationDeliveries returns the notification delivery log
+func ListNotificationDeliveries(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		orgID, ok := middleware.GetOrganizationID(c)
+		if !ok {
+			respondErrorStr(c, http.StatusUnauthorized, "Organization ID not found")
+			return
+		}
+
+		p := getPagination(c)
+		filters := make(map[string]interface{})
+		if status := c.Query("status"); status != "" {
+			filters["status"] = status
+		}
+		if eventType := c.Query("event_type"); eventType != "" {
+			filters["event_type"] = eventType
+		}
+
+		result, err := svc.Notification.ListDeliveries(c.Request.Context(), orgID, p, filters)
+		if err != nil {
+			log.Printf("ERROR ListNotificationDeliveries: %v", err)
+			respondErrorStr(c, http.StatusInternalServerError, "Failed to list notification deliveries")
+			return
+		}
+
+		respondPaginated(c, result.Items, result.Total, result.Page, result.PageSize, result.TotalPages)
+	}
+}
+
+// ListNotificationTemplates returns the organization's template overrides
+func ListNotificationTemplates(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		orgID, ok := middleware.GetOrganizationID(c)
+		if !ok {
+			respondErrorStr(c, http.StatusUnauthorized, "Organization ID not found")
+			return
+		}
+
+		templates, err := svc.Notification.ListTemplates(c.Request.Context(), orgID)
+		if err != nil {
+			respondErrorStr(c, http.StatusInternalServerError, "Failed to list notification templates")
+			return
+		}
+
+		respondSuccess(c, templates)
+	}
+}
+
+// UpsertNotificationTemplate overrides the email, Slack or Teams template of an event
+func UpsertNotificationTemplate(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req services.UpsertTemplateRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			respondErrorStr(c, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+
+		tmpl, err := svc.Notification.UpsertTemplate(c.Request.Context(), getAuditContext(c), c.Param("eventType"), req)
+		if err != nil {
+			if errors.Is(err, services.ErrUnknownNotificationEvent) {
+				respondErrorStr(c, http.StatusNotFound, "Unknown notification event")
+				return
+			}
+			if errors.Is(err, services.ErrInvalidTemplate) || errors.Is(err, services.ErrUnknownChannel) {
+				respondErrorStr(c, http.StatusBadRequest, err.Error())
+				return
+			}
+			log.Printf("ERROR UpsertNotificationTemplate: %v", err)
+			respondErrorStr(c, http.StatusInternalServerError, "Failed to save notification template")
+			return
+		}
+
+		respondSuccess(c, tmpl)
+	}
+}
+
+// EmailReportRequest is the payload for emailing a report
+type EmailReportRequest struct {
+	Type       string   `json:"type" binding:"required"`
+	Recipients []string `json:"recipients" binding:"required,min=1,dive,email"`
+}
+
+// EmailReport renders a report as CSV and emails it to the recipients
+func EmailReport(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req EmailReportRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			respondErrorStr(c, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+
+		actx := getAuditContext(c)
+		data, _, filename, err := svc.Dashboard.ExportReport(c.Request.Context(), actx.OrgID, req.Type, "csv")
+		if err != nil {
+			respondErrorStr(c, http.StatusInternalServerError, "Failed to export report")
+			return
+		}
+
+		if err := svc.Notification.SendReport(c.Request.Context(), actx, req.Type, filename, data, req.Recipients); err != nil {
+			if errors.Is(err, services.ErrEmailDisabled) {
+				respondErrorStr(c, http.StatusConflict, "Email delivery is not enabled")
+				return
+			}
+			log.Printf("ERROR EmailReport: %v", err)
+			respondErrorStr(c, http.StatusInternalServerError, "Failed to queue report email")
+			return
+		}
+
+		respondSuccess(c, map[string]interface{}{
+			"queued":     true,
+			"recipients": req.Recipients,
+		})
+	}
+}
+
+// GetTeamNotificationBindings returns where notifications about a team's
+// resources go
+func GetTeamNotificationBindings(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := parseUUID(c, "id")
+		if !ok {
+			return
+		}
+
+		bindings, err := svc.Notification.GetTeamBindings(c.Request.Context(), getAuditContext(c).OrgID, id)
+		if err != nil {
+			respondTeamBindingsError(c, "GetTeamNotificationBindings", err, "Failed to get notification bindings")
+			return
+		}
+
+		respondSuccess(c, bindings)
+	}
+}
+
+// UpdateTeamNotificationBindings sets where notifications about a team's
+// resources go
+func UpdateTeamNotificationBindings(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := parseUUID(c, "id")
+		if !ok {
+			return
+		}
+		var req services.UpdateTeamBindingsRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			respondErrorStr(c, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+
+		bindings, err := svc.Notification.UpdateTeamBindings(c.Request.Context(), getAuditContext(c), id, req)
+		if err != nil {
+			respondTeamBindingsError(c, "UpdateTeamNotificationBindings", err, "Failed to update notification bindings")
+			return
+		}
+
+		respondSuccess(c, bindings)
+	}
+}
+
+// respondTeamBindingsError maps team notification binding errors to HTTP
+// responses
+func respondTeamBindingsError(c *gin.Context, op string, err error, message string) {
+	switch {
+	case errors.Is(err, services.ErrTeamNotFound):
+		respondErrorStr(c, http.StatusNotFound, "Team not found")
+	case errors.Is(err, services.ErrInvalidTeamBindings):
+		respondErrorStr(c, http.StatusBadRequest, err.Error())
+	default:
+		log.Printf("ERROR %s: %v", op, err)
+		respondErrorStr(c, http.StatusInternalServerError, message)
+	}
+}
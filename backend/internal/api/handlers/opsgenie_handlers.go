@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kubeatlas/kubeatlas/internal/api/middleware"
+	"github.com/kubeatlas/kubeatlas/internal/services"
+)
+
+// ============================================
+// Opsgenie Configuration Handlers
+// ============================================
+
+// GetOpsgenieConfig returns the organization's Opsgenie settings without the
+// API key
+func GetOpsgenieConfig(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		orgID, ok := middleware.GetOrganizationID(c)
+		if !ok {
+			respondErrorStr(c, http.StatusUnauthorized, "Organization ID not found")
+			return
+		}
+
+		settings, err := svc.Opsgenie.GetSettings(c.Request.Context(), orgID)
+		if err != nil {
+			respondErrorStr(c, http.StatusInternalServerError, "Failed to get settings")
+			return
+		}
+
+		// Never return credentials
+		settings.APIKey = ""
+
+		respondSuccess(c, settings)
+	}
+}
+
+// UpdateOpsgenieConfig updates the organization's Opsgenie settings
+func UpdateOpsgenieConfig(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req services.OpsgenieSettings
+		if err := c.ShouldBindJSON(&req); err != nil {
+			respondErrorStr(c, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+
+		settings, err := svc.Opsgenie.UpdateSettings(c.Request.Context(), getAuditContext(c), req)
+		if err != nil {
+			if errors.Is(err, services.ErrInvalidOpsgenieSettings) {
+				respondErrorStr(c, http.StatusBadRequest, err.Error())
+				return
+			}
+			log.Printf("ERROR UpdateOpsgenieConfig: %v", err)
+			respondErrorStr(c, http.StatusInternalServerError, "Failed to update Opsgenie configuration")
+			return
+		}
+
+		respondSuccess(c, settings)
+	}
+}
+
+// TestOpsgenieConnection checks that Opsgenie accepts the given API key
+func TestOpsgenieConnection(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		orgID, ok := middleware.GetOrganizationID(c)
+		if !ok {
+			respondErrorStr(c, http.StatusUnauthorized, "Organization ID not found")
+			return
+		}
+
+		var req services.OpsgenieSettings
+		if err := c.ShouldBindJSON(&req); err != nil {
+			respondErrorStr(c, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+
+		if err := svc.Opsgenie.TestSettings(c.Request.Context(), orgID, req); err != nil {
+			log.Printf("Opsgenie test failed: %v", err)
+			respondSuccess(c, map[string]interface{}{
+				"success": false,
+				"message": err.Error(),
+			})
+			return
+		}
+
+		respondSuccess(c, map[string]interface{}{
+			"success": true,
+			"message": "Opsgenie API key accepted",
+		})
+	}
+}
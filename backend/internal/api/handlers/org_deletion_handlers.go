@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kubeatlas/kubeatlas/internal/api/middleware"
+	"github.com/kubeatlas/kubeatlas/internal/services"
+)
+
+// ============================================
+// Organization Deletion Handlers
+// ============================================
+
+// PreviewOrgDeletion counts the rows that deleting the organization would
+// remove. The returned preview is what DeleteOrganization confirms.
+func PreviewOrgDeletion(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		deletion, err := svc.OrgDeletion.Preview(c.Request.Context(), getAuditContext(c))
+		if err != nil {
+			respondOrgDeletionError(c, "PreviewOrgDeletion", err, "Failed to preview organization deletion")
+			return
+		}
+
+		respondSuccess(c, deletion)
+	}
+}
+
+// DeleteOrganization confirms a deletion preview and deletes the
+// organization with all of its data in the background
+func DeleteOrganization(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req services.ConfirmOrgDeletionRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			respondErrorStr(c, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		deletion, err := svc.OrgDeletion.Confirm(c.Request.Context(), getAuditContext(c), req)
+		if err != nil {
+			respondOrgDeletionError(c, "DeleteOrganization", err, "Failed to delete organization")
+			return
+		}
+
+		c.JSON(http.StatusAccepted, SuccessResponse{Data: deletion})
+	}
+}
+
+// GetOrgDeletion returns a deletion with its status
+func GetOrgDeletion(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		orgID, ok := middleware.GetOrganizationID(c)
+		if !ok {
+			respondErrorStr(c, http.StatusUnauthorized, "Organization ID not found")
+			return
+		}
+		id, ok := parseUUID(c, "id")
+		if !ok {
+			return
+		}
+
+		deletion, err := svc.OrgDeletion.Get(c.Request.Context(), orgID, id)
+		if err != nil {
+			respondOrgDeletionError(c, "GetOrgDeletion", err, "Failed to get organization deletion")
+			return
+		}
+
+		respondSuccess(c, deletion)
+	}
+}
+
+func respondOrgDeletionError(c *gin.Context, op string, err error, message string) {
+	switch {
+	case errors.Is(err, services.ErrOrganizationNotFound):
+		respondErrorStr(c, http.StatusNotFound, "Organization not found")
+	case errors.Is(err, services.ErrOrgDeletionNotFound):
+		respondErrorStr(c, http.StatusNotFound, "Deletion preview not found")
+	case errors.Is(err, services.ErrOrgDeletionConfirmation):
+		respondErrorStr(c, http.StatusBadRequest, err.Error())
+	case errors.Is(err, services.ErrOrgDeletionBlocked), errors.Is(err, services.ErrOrgDeletionExpired):
+		respondErrorStr(c, http.StatusConflict, err.Error())
+	default:
+		log.Printf("ERROR %s: %v", op, err)
+		respondErrorStr(c, http.StatusInternalServerError, message)
+	}
+}
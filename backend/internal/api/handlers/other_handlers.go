@@ -2,13 +2,17 @@ package handlers
 
 import (
 	"errors"
+	"fmt"
+	"io"
 	"log"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/kubeatlas/kubeatlas/internal/api/middleware"
+	"github.com/kubeatlas/kubeatlas/internal/listquery"
 	"github.com/kubeatlas/kubeatlas/internal/models"
 	"github.com/kubeatlas/kubeatlas/internal/services"
 )
@@ -45,9 +49,35 @@ func ListClusters(svc *services.Services) gin.HandlerFunc {
 		if clusterType := c.Query("type"); clusterType != "" {
 			filters["cluster_type"] = clusterType
 		}
+		if category := c.Query("sync_error_category"); category != "" {
+			filters["sync_error_category"] = category
+		}
+		if support := c.Query("version_support"); support != "" {
+			filters["version_support"] = support
+		}
+		if fleet := c.Query("fleet_id"); fleet != "" {
+			fleetID, err := uuid.Parse(fleet)
+			if err != nil {
+				respondErrorStr(c, http.StatusBadRequest, "Invalid fleet_id")
+				return
+			}
+			filters["fleet_id"] = fleetID
+		}
+		selector, err := labelSelectorParams(c.Request.URL.Query())
+		if err != nil {
+			respondErrorStr(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		if selector != nil {
+			filters["label_selector"] = selector
+		}
 
 		result, err := svc.Cluster.List(c.Request.Context(), orgID, p, filters)
 		if err != nil {
+			if errors.Is(err, services.ErrInvalidVersionSupport) {
+				respondErrorStr(c, http.StatusBadRequest, err.Error())
+				return
+			}
 			log.Printf("ERROR ListClusters: %v", err)
 			respondError(c, http.StatusInternalServerError, err)
 			return
@@ -92,15 +122,53 @@ func CreateCluster(svc *services.Services) gin.HandlerFunc {
 
 		cluster, err := svc.Cluster.Create(c.Request.Context(), actx, req)
 		if err != nil {
-			if errors.Is(err, services.ErrClusterNameExists) {
-				respondErrorStr(c, http.StatusConflict, "Cluster with this name already exists")
+			respondCreateClusterError(c, err, "Failed to create cluster")
+			return
+		}
+
+		c.JSON(http.StatusCreated, SuccessResponse{Data: cluster})
+	}
+}
+
+// PreviewCluster connects to a cluster with the credentials of a create
+// request and returns what it finds, without saving the cluster
+func PreviewCluster(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req services.CreateClusterRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			respondErrorStr(c, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+
+		preview, err := svc.Cluster.Preview(c.Request.Context(), getAuditContext(c).OrgID, req)
+		if err != nil {
+			if errors.Is(err, services.ErrClusterConnectionFailed) {
+				respondErrorStr(c, http.StatusUnprocessableEntity, err.Error())
 				return
 			}
-			respondErrorStr(c, http.StatusInternalServerError, "Failed to create cluster")
+			respondCreateClusterError(c, err, "Failed to preview cluster")
 			return
 		}
 
-		c.JSON(http.StatusCreated, SuccessResponse{Data: cluster})
+		respondSuccess(c, preview)
+	}
+}
+
+// respondCreateClusterError responds with the error of a create cluster
+// request
+func respondCreateClusterError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, services.ErrClusterNameExists):
+		respondErrorStr(c, http.StatusConflict, "Cluster with this name already exists")
+	case errors.Is(err, services.ErrInvalidEnvironment),
+		errors.Is(err, services.ErrInvalidClusterName),
+		errors.Is(err, services.ErrInvalidAPIServerURL),
+		errors.Is(err, services.ErrInvalidFallbackURLs),
+		errors.Is(err, services.ErrInvalidClusterType):
+		respondErrorStr(c, http.StatusBadRequest, err.Error())
+	default:
+		log.Printf("ERROR %s: %v", message, err)
+		respondErrorStr(c, http.StatusInternalServerError, message)
 	}
 }
 
@@ -126,6 +194,10 @@ func UpdateCluster(svc *services.Services) gin.HandlerFunc {
 				respondErrorStr(c, http.StatusNotFound, "Cluster not found")
 				return
 			}
+			if errors.Is(err, services.ErrInvalidEnvironment) || errors.Is(err, services.ErrInvalidFallbackURLs) {
+				respondErrorStr(c, http.StatusBadRequest, err.Error())
+				return
+			}
 			respondErrorStr(c, http.StatusInternalServerError, "Failed to update cluster")
 			return
 		}
@@ -157,6 +229,30 @@ func DeleteCluster(svc *services.Services) gin.HandlerFunc {
 	}
 }
 
+// GetClusterDeletionPreview counts what deleting a cluster would delete
+// with it
+func GetClusterDeletionPreview(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := parseUUID(c, "id")
+		if !ok {
+			return
+		}
+
+		preview, err := svc.Cluster.DeletionPreview(c.Request.Context(), getAuditContext(c).OrgID, id)
+		if err != nil {
+			if errors.Is(err, services.ErrClusterNotFound) {
+				respondErrorStr(c, http.StatusNotFound, "Cluster not found")
+				return
+			}
+			log.Printf("ERROR GetClusterDeletionPreview: %v", err)
+			respondErrorStr(c, http.StatusInternalServerError, "Failed to preview cluster deletion")
+			return
+		}
+
+		respondSuccess(c, preview)
+	}
+}
+
 // SyncCluster triggers cluster sync
 func SyncCluster(svc *services.Services) gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -176,6 +272,185 @@ func SyncCluster(svc *services.Services) gin.HandlerFunc {
 	}
 }
 
+// ListClusterSyncErrors returns the categorized sync error history of a cluster
+func ListClusterSyncErrors(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := parseUUID(c, "id")
+		if !ok {
+			return
+		}
+
+		orgID, ok := middleware.GetOrganizationID(c)
+		if !ok {
+			respondErrorStr(c, http.StatusUnauthorized, "Organization ID not found")
+			return
+		}
+
+		p := getPagination(c)
+		filters := make(map[string]interface{})
+		if category := c.Query("category"); category != "" {
+			filters["category"] = category
+		}
+
+		result, err := svc.Cluster.ListSyncErrors(c.Request.Context(), orgID, id, p, filters)
+		if err != nil {
+			if errors.Is(err, services.ErrClusterNotFound) {
+				respondErrorStr(c, http.StatusNotFound, "Cluster not found")
+				return
+			}
+			log.Printf("ERROR ListClusterSyncErrors: %v", err)
+			respondErrorStr(c, http.StatusInternalServerError, "Failed to list cluster sync errors")
+			return
+		}
+
+		respondPaginated(c, result.Items, result.Total, result.Page, result.PageSize, result.TotalPages)
+	}
+}
+
+// ListClusterSyncRuns returns the sync runs of a cluster, newest first
+func ListClusterSyncRuns(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := parseUUID(c, "id")
+		if !ok {
+			return
+		}
+
+		orgID, ok := middleware.GetOrganizationID(c)
+		if !ok {
+			respondErrorStr(c, http.StatusUnauthorized, "Organization ID not found")
+			return
+		}
+
+		p := getPagination(c)
+		filters := make(map[string]interface{})
+		for _, name := range []string{"status", "trigger", "archived_namespace"} {
+			if v := c.Query(name); v != "" {
+				filters[name] = v
+			}
+		}
+
+		result, err := svc.Cluster.ListSyncRuns(c.Request.Context(), orgID, id, p, filters)
+		if err != nil {
+			if errors.Is(err, services.ErrClusterNotFound) {
+				respondErrorStr(c, http.StatusNotFound, "Cluster not found")
+				return
+			}
+			log.Printf("ERROR ListClusterSyncRuns: %v", err)
+			respondErrorStr(c, http.StatusInternalServerError, "Failed to list cluster sync runs")
+			return
+		}
+
+		respondPaginated(c, result.Items, result.Total, result.Page, result.PageSize, result.TotalPages)
+	}
+}
+
+// nodeListFilters parses the node list filters: role, status and version,
+// the kubelet version
+func nodeListFilters(c *gin.Context) map[string]interface{} {
+	filters := make(map[string]interface{})
+	for _, name := range []string{"role", "status", "version"} {
+		if v := c.Query(name); v != "" {
+			filters[name] = v
+		}
+	}
+	return filters
+}
+
+// ListClusterNodes returns the nodes of a cluster as found by its last sync
+func ListClusterNodes(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := parseUUID(c, "id")
+		if !ok {
+			return
+		}
+
+		result, err := svc.Cluster.ListNodes(c.Request.Context(), getAuditContext(c).OrgID, id, getPagination(c), nodeListFilters(c))
+		if err != nil {
+			respondNodeError(c, "ListClusterNodes", err, "Failed to list cluster nodes")
+			return
+		}
+
+		respondPaginated(c, result.Items, result.Total, result.Page, result.PageSize, result.TotalPages)
+	}
+}
+
+// GetClusterCapacity sums the capacity of the nodes of a cluster, with the
+// node list filters
+func GetClusterCapacity(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := parseUUID(c, "id")
+		if !ok {
+			return
+		}
+
+		capacity, err := svc.Cluster.GetCapacity(c.Request.Context(), getAuditContext(c).OrgID, id, nodeListFilters(c))
+		if err != nil {
+			respondNodeError(c, "GetClusterCapacity", err, "Failed to get cluster capacity")
+			return
+		}
+
+		respondSuccess(c, capacity)
+	}
+}
+
+// GetClusterCapacityTrend returns the capacity of a cluster over the last
+// days (default 90), one point per interval: day (default) or week
+func GetClusterCapacityTrend(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := parseUUID(c, "id")
+		if !ok {
+			return
+		}
+		days, err := strconv.Atoi(c.DefaultQuery("days", "90"))
+		if err != nil {
+			respondErrorStr(c, http.StatusBadRequest, "days must be a number")
+			return
+		}
+
+		trend, err := svc.Cluster.GetCapacityTrend(c.Request.Context(), getAuditContext(c).OrgID, id, c.Query("interval"), days)
+		if err != nil {
+			if errors.Is(err, services.ErrInvalidCapacityTrend) {
+				respondErrorStr(c, http.StatusBadRequest, err.Error())
+				return
+			}
+			respondNodeError(c, "GetClusterCapacityTrend", err, "Failed to get cluster capacity trend")
+			return
+		}
+
+		respondSuccess(c, trend)
+	}
+}
+
+// GetNode returns a node of a cluster
+func GetNode(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := parseUUID(c, "id")
+		if !ok {
+			return
+		}
+
+		node, err := svc.Cluster.GetNode(c.Request.Context(), getAuditContext(c).OrgID, id)
+		if err != nil {
+			respondNodeError(c, "GetNode", err, "Failed to get node")
+			return
+		}
+
+		respondSuccess(c, node)
+	}
+}
+
+func respondNodeError(c *gin.Context, op string, err error, message string) {
+	switch {
+	case errors.Is(err, services.ErrClusterNotFound):
+		respondErrorStr(c, http.StatusNotFound, "Cluster not found")
+	case errors.Is(err, services.ErrNodeNotFound):
+		respondErrorStr(c, http.StatusNotFound, "Node not found")
+	default:
+		log.Printf("ERROR %s: %v", op, err)
+		respondErrorStr(c, http.StatusInternalServerError, message)
+	}
+}
+
 // GetClusterStats returns cluster statistics
 func GetClusterStats(svc *services.Services) gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -261,6 +536,27 @@ func ListBusinessUnits(svc *services.Services) gin.HandlerFunc {
 	}
 }
 
+// GetBusinessUnitTree returns the business units as a tree with per-unit and
+// rolled-up namespace statistics
+func GetBusinessUnitTree(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		orgID, ok := middleware.GetOrganizationID(c)
+		if !ok {
+			respondErrorStr(c, http.StatusUnauthorized, "Organization ID not found in context")
+			return
+		}
+
+		roots, err := svc.BusinessUnit.Tree(c.Request.Context(), orgID)
+		if err != nil {
+			log.Printf("ERROR GetBusinessUnitTree: %v", err)
+			respondErrorStr(c, http.StatusInternalServerError, "Failed to load business unit tree")
+			return
+		}
+
+		respondSuccess(c, roots)
+	}
+}
+
 // GetBusinessUnit returns a single business unit
 func GetBusinessUnit(svc *services.Services) gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -361,12 +657,13 @@ func DeleteBusinessUnit(svc *services.Services) gin.HandlerFunc {
 // Dashboard Handlers
 // ============================================
 
-// GetDashboardStats returns dashboard statistics
+// GetDashboardStats returns dashboard statistics. Archived namespaces are
+// counted with include_archived=true.
 func GetDashboardStats(svc *services.Services) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		orgID, _ := middleware.GetOrganizationID(c)
 
-		stats, err := svc.Dashboard.GetStats(c.Request.Context(), orgID)
+		stats, err := svc.Dashboard.GetStats(c.Request.Context(), orgID, c.Query("include_archived") == "true")
 		if err != nil {
 			respondErrorStr(c, http.StatusInternalServerError, "Failed to get dashboard stats")
 			return
@@ -420,9 +717,23 @@ func GetMissingInfo(svc *services.Services) gin.HandlerFunc {
 // Internal Dependency Handlers
 // ============================================
 
+// internalDependencyQueryFields are the fields of the internal dependency
+// list's query language, such as type:database AND critical:true
+var internalDependencyQueryFields = listquery.Fields{
+	"":           listquery.Param("search"),
+	"type":       listquery.Param("dependency_type"),
+	"status":     listquery.Param("status"),
+	"critical":   listquery.Bool("critical"),
+	"discovered": listquery.Bool("auto_discovered"),
+	"namespace":  listquery.Param("namespace"),
+}
+
 // ListInternalDependencies returns all internal dependencies
 func ListInternalDependencies(svc *services.Services) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		if !expandListQuery(c, internalDependencyQueryFields) {
+			return
+		}
 		orgID, ok := middleware.GetOrganizationID(c)
 		if !ok {
 			respondErrorStr(c, http.StatusUnauthorized, "Organization ID not found in context")
@@ -430,7 +741,19 @@ func ListInternalDependencies(svc *services.Services) gin.HandlerFunc {
 		}
 		p := getPagination(c)
 
-		result, err := svc.Dependency.ListInternal(c.Request.Context(), orgID, p)
+		filters := make(map[string]interface{})
+		for _, name := range []string{"dependency_type", "status", "namespace", "search"} {
+			if value := c.Query(name); value != "" {
+				filters[name] = value
+			}
+		}
+		for _, name := range []string{"critical", "auto_discovered"} {
+			if value := c.Query(name); value != "" {
+				filters[name] = value == "true"
+			}
+		}
+
+		result, err := svc.Dependency.ListInternal(c.Request.Context(), orgID, p, filters)
 		if err != nil {
 			log.Printf("ERROR ListInternalDependencies: orgID=%s, err=%v", orgID, err)
 			respondError(c, http.StatusInternalServerError, err)
@@ -524,13 +847,37 @@ func DeleteInternalDependency(svc *services.Services) gin.HandlerFunc {
 // External Dependency Handlers
 // ============================================
 
+// externalDependencyQueryFields are the fields of the external dependency
+// list's query language, such as provider:aws AND critical:true
+var externalDependencyQueryFields = listquery.Fields{
+	"":          listquery.Param("search"),
+	"type":      listquery.Param("system_type"),
+	"provider":  listquery.Param("provider"),
+	"status":    listquery.Param("status"),
+	"critical":  listquery.Bool("critical"),
+	"namespace": listquery.Param("namespace"),
+}
+
 // ListExternalDependencies returns all external dependencies
 func ListExternalDependencies(svc *services.Services) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		if !expandListQuery(c, externalDependencyQueryFields) {
+			return
+		}
 		orgID, _ := middleware.GetOrganizationID(c)
 		p := getPagination(c)
 
-		result, err := svc.Dependency.ListExternal(c.Request.Context(), orgID, p)
+		filters := make(map[string]interface{})
+		for _, name := range []string{"system_type", "provider", "status", "namespace", "search"} {
+			if value := c.Query(name); value != "" {
+				filters[name] = value
+			}
+		}
+		if critical := c.Query("critical"); critical != "" {
+			filters["critical"] = critical == "true"
+		}
+
+		result, err := svc.Dependency.ListExternal(c.Request.Context(), orgID, p, filters)
 		if err != nil {
 			respondErrorStr(c, http.StatusInternalServerError, "Failed to list external dependencies")
 			return
@@ -591,6 +938,41 @@ func UpdateExternalDependency(svc *services.Services) gin.HandlerFunc {
 	}
 }
 
+// SetExternalDependencyStatus records whether an external dependency is
+// active, degraded or down
+func SetExternalDependencyStatus(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := parseUUID(c, "id")
+		if !ok {
+			return
+		}
+
+		var req struct {
+			Status string `json:"status" binding:"required"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			respondErrorStr(c, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+
+		dep, err := svc.Dependency.SetExternalStatus(c.Request.Context(), getAuditContext(c), id, req.Status)
+		if err != nil {
+			switch {
+			case errors.Is(err, services.ErrDependencyNotFound):
+				respondErrorStr(c, http.StatusNotFound, "Dependency not found")
+			case errors.Is(err, services.ErrInvalidDependencyStatus):
+				respondErrorStr(c, http.StatusBadRequest, err.Error())
+			default:
+				log.Printf("ERROR SetExternalDependencyStatus: %v", err)
+				respondErrorStr(c, http.StatusInternalServerError, "Failed to update dependency status")
+			}
+			return
+		}
+
+		respondSuccess(c, dep)
+	}
+}
+
 // DeleteExternalDependency deletes an external dependency
 func DeleteExternalDependency(svc *services.Services) gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -630,6 +1012,11 @@ func ListDocuments(svc *services.Services) gin.HandlerFunc {
 				filters["namespace_id"] = id
 			}
 		}
+		if applicationID := c.Query("application_id"); applicationID != "" {
+			if id, err := uuid.Parse(applicationID); err == nil {
+				filters["application_id"] = id
+			}
+		}
 		if categoryID := c.Query("category_id"); categoryID != "" {
 			if id, err := uuid.Parse(categoryID); err == nil {
 				filters["category_id"] = id
@@ -744,17 +1131,9 @@ func ListAuditLogs(svc *services.Services) gin.HandlerFunc {
 		orgID, _ := middleware.GetOrganizationID(c)
 		p := getPagination(c)
 
-		filters := make(map[string]interface{})
-		if userID := c.Query("user_id"); userID != "" {
-			if id, err := uuid.Parse(userID); err == nil {
-				filters["user_id"] = id
-			}
-		}
-		if action := c.Query("action"); action != "" {
-			filters["action"] = action
-		}
-		if resourceType := c.Query("resource_type"); resourceType != "" {
-			filters["resource_type"] = resourceType
+		filters, ok := auditLogFilters(c)
+		if !ok {
+			return
 		}
 
 		result, err := svc.Audit.List(c.Request.Context(), orgID, p, filters)
@@ -767,10 +1146,81 @@ func ListAuditLogs(svc *services.Services) gin.HandlerFunc {
 	}
 }
 
+// ExportAuditLogs streams the audit logs matching the ListAuditLogs filters
+// as CSV or, with ?format=ndjson, newline-delimited JSON
+func ExportAuditLogs(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		format := c.DefaultQuery("format", services.AuditExportCSV)
+		contentType, err := services.AuditExportContentType(format)
+		if err != nil {
+			respondErrorStr(c, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		filters, ok := auditLogFilters(c)
+		if !ok {
+			return
+		}
+
+		filename := fmt.Sprintf("audit_logs_%s.%s", time.Now().UTC().Format("20060102T150405Z"), format)
+		c.Header("Content-Type", contentType)
+		c.Header("Content-Disposition", "attachment; filename=\""+filename+"\"")
+		c.Status(http.StatusOK)
+
+		// The status is sent with the first row, so a failure part way can
+		// only be logged
+		if err := svc.Audit.Export(c.Request.Context(), getAuditContext(c), filters, format, c.Writer); err != nil {
+			log.Printf("ERROR ExportAuditLogs: %v", err)
+			c.Abort()
+		}
+	}
+}
+
+// auditLogFilters parses the audit log filters of the query string. from and
+// to accept RFC 3339 timestamps or dates; a date as to includes the whole
+// day. It responds with 400 and returns false for invalid filters.
+func auditLogFilters(c *gin.Context) (map[string]interface{}, bool) {
+	filters := make(map[string]interface{})
+	if userID := c.Query("user_id"); userID != "" {
+		id, err := uuid.Parse(userID)
+		if err != nil {
+			respondErrorStr(c, http.StatusBadRequest, "Invalid user_id")
+			return nil, false
+		}
+		filters["user_id"] = id
+	}
+	if action := c.Query("action"); action != "" {
+		filters["action"] = action
+	}
+	if resourceType := c.Query("resource_type"); resourceType != "" {
+		filters["resource_type"] = resourceType
+	}
+	for _, key := range []string{"from", "to"} {
+		value := c.Query(key)
+		if value == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			day, dayErr := time.Parse("2006-01-02", value)
+			if dayErr != nil {
+				respondErrorStr(c, http.StatusBadRequest, "Invalid "+key+": use RFC 3339 or YYYY-MM-DD")
+				return nil, false
+			}
+			t = day
+			if key == "to" {
+				t = day.AddDate(0, 0, 1).Add(-time.Nanosecond)
+			}
+		}
+		filters[key] = t
+	}
+	return filters, true
+}
+
 // GetResourceAuditLogs returns audit logs for a specific resource
 func GetResourceAuditLogs(svc *services.Services) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		resourceType := c.Param("resourceType")
+		resourceType := c.Param("id")
 		resourceID, ok := parseUUID(c, "resourceId")
 		if !ok {
 			return
@@ -788,6 +1238,156 @@ func GetResourceAuditLogs(svc *services.Services) gin.HandlerFunc {
 	}
 }
 
+// GetAuthAnomalies reports IP addresses and accounts with suspiciously many
+// authentication failures. hours sets the window (default 24) and threshold
+// the number of failures that is suspicious (default 10).
+func GetAuthAnomalies(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		hours, err := strconv.Atoi(c.DefaultQuery("hours", "24"))
+		if err != nil || hours < 1 || hours > 720 {
+			respondErrorStr(c, http.StatusBadRequest, "hours must be between 1 and 720")
+			return
+		}
+		threshold, err := strconv.Atoi(c.DefaultQuery("threshold", "10"))
+		if err != nil || threshold < 1 {
+			respondErrorStr(c, http.StatusBadRequest, "threshold must be a positive number")
+			return
+		}
+
+		anomalies, err := svc.Audit.AuthAnomalies(c.Request.Context(), getAuditContext(c).OrgID, time.Duration(hours)*time.Hour, threshold)
+		if err != nil {
+			log.Printf("ERROR GetAuthAnomalies: %v", err)
+			respondErrorStr(c, http.StatusInternalServerError, "Failed to get authentication anomalies")
+			return
+		}
+
+		respondSuccess(c, anomalies)
+	}
+}
+
+// auditStreamHeartbeat is how often an idle audit stream sends a comment, so
+// proxies do not close it
+const auditStreamHeartbeat = 25 * time.Second
+
+// StreamResourceAuditLogs streams the audit logs recorded for a resource as
+// server-sent "audit_log" events until the client disconnects
+func StreamResourceAuditLogs(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		resourceType := c.Param("id")
+		resourceID, ok := parseUUID(c, "resourceId")
+		if !ok {
+			return
+		}
+
+		logs, stop := svc.Audit.Watch(getAuditContext(c).OrgID, resourceType, resourceID)
+		defer stop()
+
+		// The stream outlives the server's write timeout
+		if err := http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{}); err != nil {
+			log.Printf("WARN: Failed to lift write deadline for audit stream: %v", err)
+		}
+
+		c.Header("Content-Type", "text/event-stream")
+		c.Header("Cache-Control", "no-cache")
+		c.Header("X-Accel-Buffering", "no")
+		c.Status(http.StatusOK)
+		c.Writer.Flush()
+
+		heartbeat := time.NewTicker(auditStreamHeartbeat)
+		defer heartbeat.Stop()
+
+		c.Stream(func(w io.Writer) bool {
+			select {
+			case l, ok := <-logs:
+				if !ok {
+					return false
+				}
+				c.SSEvent("audit_log", l)
+				return true
+			case <-heartbeat.C:
+				_, err := io.WriteString(w, ": keepalive\n\n")
+				return err == nil
+			case <-c.Request.Context().Done():
+				return false
+			}
+		})
+	}
+}
+
+// GetAuditLogDiff returns the field-level changes of an audit log
+func GetAuditLogDiff(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := parseUUID(c, "id")
+		if !ok {
+			return
+		}
+
+		diff, err := svc.Audit.Diff(c.Request.Context(), getAuditContext(c).OrgID, id)
+		if err != nil {
+			if errors.Is(err, services.ErrAuditLogNotFound) {
+				respondErrorStr(c, http.StatusNotFound, "Audit log not found")
+				return
+			}
+			log.Printf("ERROR GetAuditLogDiff: %v", err)
+			respondErrorStr(c, http.StatusInternalServerError, "Failed to get audit log diff")
+			return
+		}
+
+		respondSuccess(c, diff)
+	}
+}
+
+// ListAuditArchives returns the organization's archived audit log months,
+// optionally limited to from and to (YYYY-MM)
+func ListAuditArchives(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		actx := getAuditContext(c)
+
+		archives, err := svc.Audit.ListArchives(c.Request.Context(), actx.OrgID, c.Query("from"), c.Query("to"))
+		if err != nil {
+			respondAuditArchiveError(c, "ListAuditArchives", err, "Failed to list audit log archives")
+			return
+		}
+
+		respondSuccess(c, archives)
+	}
+}
+
+// RestoreAuditArchives loads archived audit log months back into the audit
+// log for investigation
+func RestoreAuditArchives(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req services.RestoreAuditArchivesRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			respondErrorStr(c, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+
+		result, err := svc.Audit.RestoreArchives(c.Request.Context(), getAuditContext(c), req)
+		if err != nil {
+			respondAuditArchiveError(c, "RestoreAuditArchives", err, "Failed to restore audit log archives")
+			return
+		}
+
+		respondSuccess(c, result)
+	}
+}
+
+// respondAuditArchiveError maps audit archive service errors to responses
+func respondAuditArchiveError(c *gin.Context, op string, err error, message string) {
+	switch {
+	case errors.Is(err, services.ErrInvalidArchiveRange):
+		respondErrorStr(c, http.StatusBadRequest, err.Error())
+	case errors.Is(err, services.ErrAuditArchiveNotFound):
+		respondErrorStr(c, http.StatusNotFound, err.Error())
+	case errors.Is(err, services.ErrAuditArchiveDisabled):
+		respondErrorStr(c, http.StatusConflict, err.Error())
+	default:
+		log.Printf("ERROR %s: %v", op, err)
+		respondErrorStr(c, http.StatusInternalServerError, message)
+	}
+}
+
 // ============================================
 // Dependency Graph Handler
 // ============================================
@@ -847,6 +1447,8 @@ func DownloadDocument(svc *services.Services) gin.HandlerFunc {
 			return
 		}
 
+		svc.Audit.LogAccess(c.Request.Context(), getAuditContext(c), services.AuditActionExport, "document", id, fileName, "Downloaded document")
+
 		c.Header("Content-Disposition", "attachment; filename=\""+fileName+"\"")
 		c.File(filePath)
 	}
@@ -855,8 +1457,27 @@ func DownloadDocument(svc *services.Services) gin.HandlerFunc {
 // UploadDocument handles document upload
 func UploadDocument(svc *services.Services) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// Parse multipart form
-		if err := c.Request.ParseMultipartForm(32 << 20); err != nil { // 32 MB max
+		// Cap the body at the organization's upload limit so oversized
+		// requests are rejected before they are read
+		actx := getAuditContext(c)
+		maxSize, err := svc.Document.MaxRequestSize(c.Request.Context(), actx.OrgID)
+		if err != nil {
+			respondErrorStr(c, http.StatusInternalServerError, "Failed to load upload settings")
+			return
+		}
+		if c.Request.ContentLength > maxSize {
+			respondErrorStr(c, http.StatusRequestEntityTooLarge, "Request body too large")
+			return
+		}
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxSize)
+
+		// Parse multipart form, keeping up to 32 MB in memory
+		if err := c.Request.ParseMultipartForm(32 << 20); err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				respondErrorStr(c, http.StatusRequestEntityTooLarge, "Request body too large")
+				return
+			}
 			respondErrorStr(c, http.StatusBadRequest, "Failed to parse form")
 			return
 		}
@@ -885,11 +1506,16 @@ func UploadDocument(svc *services.Services) gin.HandlerFunc {
 			CategoryID:  uuidToPtr(categoryID),
 		}
 
-		actx := getAuditContext(c)
-
 		doc, err := svc.Document.Upload(c.Request.Context(), actx, req, header)
 		if err != nil {
-			respondErrorStr(c, http.StatusInternalServerError, "Failed to upload document")
+			switch {
+			case errors.Is(err, services.ErrFileTooLarge):
+				respondErrorStr(c, http.StatusRequestEntityTooLarge, err.Error())
+			case errors.Is(err, services.ErrFileTypeNotAllowed):
+				respondErrorStr(c, http.StatusUnsupportedMediaType, err.Error())
+			default:
+				respondErrorStr(c, http.StatusInternalServerError, "Failed to upload document")
+			}
 			return
 		}
 
@@ -901,12 +1527,13 @@ func UploadDocument(svc *services.Services) gin.HandlerFunc {
 // Report Handlers
 // ============================================
 
-// OwnershipCoverageReport returns ownership coverage report
+// OwnershipCoverageReport returns ownership coverage report. Archived
+// namespaces are counted with include_archived=true.
 func OwnershipCoverageReport(svc *services.Services) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		orgID, _ := middleware.GetOrganizationID(c)
 
-		report, err := svc.Dashboard.GetOwnershipCoverage(c.Request.Context(), orgID)
+		report, err := svc.Dashboard.GetOwnershipCoverage(c.Request.Context(), orgID, c.Query("include_archived") == "true")
 		if err != nil {
 			respondErrorStr(c, http.StatusInternalServerError, "Failed to generate ownership coverage report")
 			return
@@ -916,12 +1543,13 @@ func OwnershipCoverageReport(svc *services.Services) gin.HandlerFunc {
 	}
 }
 
-// OrphanedResourcesReport returns orphaned resources report
+// OrphanedResourcesReport returns orphaned resources report. Archived
+// namespaces are counted with include_archived=true.
 func OrphanedResourcesReport(svc *services.Services) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		orgID, _ := middleware.GetOrganizationID(c)
 
-		report, err := svc.Dashboard.GetOrphanedResources(c.Request.Context(), orgID)
+		report, err := svc.Dashboard.GetOrphanedResources(c.Request.Context(), orgID, c.Query("include_archived") == "true")
 		if err != nil {
 			respondErrorStr(c, http.StatusInternalServerError, "Failed to generate orphaned resources report")
 			return
@@ -931,6 +1559,95 @@ func OrphanedResourcesReport(svc *services.Services) gin.HandlerFunc {
 	}
 }
 
+// PodSecurityReport groups namespaces by the Pod Security Admission level
+// they enforce
+func PodSecurityReport(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		orgID, _ := middleware.GetOrganizationID(c)
+
+		report, err := svc.Dashboard.GetPodSecurityReport(c.Request.Context(), orgID)
+		if err != nil {
+			log.Printf("ERROR PodSecurityReport: %v", err)
+			respondErrorStr(c, http.StatusInternalServerError, "Failed to generate pod security report")
+			return
+		}
+
+		respondSuccess(c, report)
+	}
+}
+
+// MonitoringCoverageReport lists the namespaces of the most critical tier,
+// or of the criticality given, that have no monitoring linked
+func MonitoringCoverageReport(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		orgID, _ := middleware.GetOrganizationID(c)
+
+		report, err := svc.Monitoring.GetCoverageReport(c.Request.Context(), orgID, c.Query("criticality"))
+		if err != nil {
+			log.Printf("ERROR MonitoringCoverageReport: %v", err)
+			respondErrorStr(c, http.StatusInternalServerError, "Failed to generate monitoring coverage report")
+			return
+		}
+
+		respondSuccess(c, report)
+	}
+}
+
+// CapacityReport sums the CPU and memory usage of namespaces by team,
+// environment, criticality or cluster, as given by group_by
+func CapacityReport(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		orgID, _ := middleware.GetOrganizationID(c)
+
+		report, err := svc.Namespace.GetCapacityReport(c.Request.Context(), orgID, c.Query("group_by"))
+		if err != nil {
+			if errors.Is(err, services.ErrInvalidUsageGrouping) {
+				respondErrorStr(c, http.StatusBadRequest, err.Error())
+				return
+			}
+			log.Printf("ERROR CapacityReport: %v", err)
+			respondErrorStr(c, http.StatusInternalServerError, "Failed to generate capacity report")
+			return
+		}
+
+		respondSuccess(c, report)
+	}
+}
+
+// MetadataPolicyReport evaluates namespaces against the organization's
+// metadata requirements and policies
+func MetadataPolicyReport(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		orgID, _ := middleware.GetOrganizationID(c)
+
+		report, err := svc.Dashboard.GetMetadataPolicyReport(c.Request.Context(), orgID)
+		if err != nil {
+			log.Printf("ERROR MetadataPolicyReport: %v", err)
+			respondErrorStr(c, http.StatusInternalServerError, "Failed to generate metadata policy report")
+			return
+		}
+
+		respondSuccess(c, report)
+	}
+}
+
+// KubernetesVersionReport places the organization's clusters on the
+// Kubernetes support calendar
+func KubernetesVersionReport(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		orgID, _ := middleware.GetOrganizationID(c)
+
+		report, err := svc.Dashboard.GetKubernetesVersionReport(c.Request.Context(), orgID)
+		if err != nil {
+			log.Printf("ERROR KubernetesVersionReport: %v", err)
+			respondErrorStr(c, http.StatusInternalServerError, "Failed to generate Kubernetes version report")
+			return
+		}
+
+		respondSuccess(c, report)
+	}
+}
+
 // DependencyMatrixReport returns dependency matrix report
 func DependencyMatrixReport(svc *services.Services) gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -959,6 +1676,9 @@ func ExportReport(svc *services.Services) gin.HandlerFunc {
 			return
 		}
 
+		svc.Audit.LogAccess(c.Request.Context(), getAuditContext(c), services.AuditActionExport, "report", orgID, reportType,
+			fmt.Sprintf("Exported %s report as %s", reportType, format))
+
 		c.Header("Content-Disposition", "attachment; filename=\""+filename+"\"")
 		c.Data(http.StatusOK, contentType, data)
 	}
@@ -971,11 +1691,15 @@ func ExportReport(svc *services.Services) gin.HandlerFunc {
 // GetSettings returns organization settings
 func GetSettings(svc *services.Services) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		orgID, _ := middleware.GetOrganizationID(c)
+		orgID, ok := middleware.GetOrganizationID(c)
+		if !ok {
+			respondErrorStr(c, http.StatusUnauthorized, "Organization ID not found")
+			return
+		}
 
-		settings, err := svc.User.GetOrganizationSettings(c.Request.Context(), orgID)
+		settings, err := svc.OrgSettings.Get(c.Request.Context(), orgID)
 		if err != nil {
-			respondErrorStr(c, http.StatusInternalServerError, "Failed to get settings")
+			respondSettingsError(c, "GetSettings", err, "Failed to get settings")
 			return
 		}
 
@@ -986,18 +1710,15 @@ func GetSettings(svc *services.Services) gin.HandlerFunc {
 // UpdateSettings updates organization settings
 func UpdateSettings(svc *services.Services) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		var req services.UpdateSettingsRequest
+		var req services.UpdateOrganizationSettingsRequest
 		if err := c.ShouldBindJSON(&req); err != nil {
 			respondErrorStr(c, http.StatusBadRequest, "Invalid request body")
 			return
 		}
 
-		orgID, _ := middleware.GetOrganizationID(c)
-		actx := getAuditContext(c)
-
-		settings, err := svc.User.UpdateOrganizationSettings(c.Request.Context(), orgID, req, actx)
+		settings, err := svc.OrgSettings.Update(c.Request.Context(), getAuditContext(c), req)
 		if err != nil {
-			respondErrorStr(c, http.StatusInternalServerError, "Failed to update settings")
+			respondSettingsError(c, "UpdateSettings", err, "Failed to update settings")
 			return
 		}
 
@@ -1005,6 +1726,97 @@ func UpdateSettings(svc *services.Services) gin.HandlerFunc {
 	}
 }
 
+// GetBranding returns an organization's branding. It is public so the login
+// page can be branded; ?org=<slug> selects the organization.
+func GetBranding(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		branding, err := svc.OrgSettings.Branding(c.Request.Context(), defaultOrganizationID, c.Query("org"))
+		if err != nil {
+			respondSettingsError(c, "GetBranding", err, "Failed to get branding")
+			return
+		}
+
+		c.Header("Cache-Control", "public, max-age=300")
+		respondSuccess(c, branding)
+	}
+}
+
+// PreviewRetention returns the number of records the next retention run
+// would purge
+func PreviewRetention(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		orgID, ok := middleware.GetOrganizationID(c)
+		if !ok {
+			respondErrorStr(c, http.StatusUnauthorized, "Organization ID not found")
+			return
+		}
+
+		preview, err := svc.Retention.Preview(c.Request.Context(), orgID)
+		if err != nil {
+			log.Printf("ERROR PreviewRetention: %v", err)
+			respondErrorStr(c, http.StatusInternalServerError, "Failed to preview retention")
+			return
+		}
+
+		respondSuccess(c, preview)
+	}
+}
+
+// ListEnvironments returns the organization's environments, in the order
+// they are configured
+func ListEnvironments(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		orgID, ok := middleware.GetOrganizationID(c)
+		if !ok {
+			respondErrorStr(c, http.StatusUnauthorized, "Organization ID not found")
+			return
+		}
+
+		environments, err := svc.OrgSettings.Environments(c.Request.Context(), orgID)
+		if err != nil {
+			log.Printf("ERROR ListEnvironments: %v", err)
+			respondErrorStr(c, http.StatusInternalServerError, "Failed to get environments")
+			return
+		}
+
+		respondSuccess(c, environments)
+	}
+}
+
+// ListCriticalityTiers returns the organization's criticality tiers, most
+// critical first
+func ListCriticalityTiers(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		orgID, ok := middleware.GetOrganizationID(c)
+		if !ok {
+			respondErrorStr(c, http.StatusUnauthorized, "Organization ID not found")
+			return
+		}
+
+		tiers, err := svc.OrgSettings.CriticalityTiers(c.Request.Context(), orgID)
+		if err != nil {
+			log.Printf("ERROR ListCriticalityTiers: %v", err)
+			respondErrorStr(c, http.StatusInternalServerError, "Failed to get criticality tiers")
+			return
+		}
+
+		respondSuccess(c, tiers)
+	}
+}
+
+// respondSettingsError maps organization settings errors to responses
+func respondSettingsError(c *gin.Context, op string, err error, message string) {
+	switch {
+	case errors.Is(err, services.ErrOrganizationNotFound):
+		respondErrorStr(c, http.StatusNotFound, "Organization not found")
+	case errors.Is(err, services.ErrUnknownSetting), errors.Is(err, services.ErrInvalidSetting):
+		respondErrorStr(c, http.StatusBadRequest, err.Error())
+	default:
+		log.Printf("ERROR %s: %v", op, err)
+		respondErrorStr(c, http.StatusInternalServerError, message)
+	}
+}
+
 // ============================================
 // User Preferences Handlers
 // ============================================
@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kubeatlas/kubeatlas/internal/api/middleware"
+	"github.com/kubeatlas/kubeatlas/internal/services"
+)
+
+// ============================================
+// PagerDuty Configuration Handlers
+// ============================================
+
+// GetPagerDutyConfig returns the organization's PagerDuty settings without
+// the API key
+func GetPagerDutyConfig(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		orgID, ok := middleware.GetOrganizationID(c)
+		if !ok {
+			respondErrorStr(c, http.StatusUnauthorized, "Organization ID not found")
+			return
+		}
+
+		settings, err := svc.PagerDuty.GetSettings(c.Request.Context(), orgID)
+		if err != nil {
+			respondErrorStr(c, http.StatusInternalServerError, "Failed to get settings")
+			return
+		}
+
+		// Never return credentials
+		settings.APIToken = ""
+
+		respondSuccess(c, settings)
+	}
+}
+
+// UpdatePagerDutyConfig updates the organization's PagerDuty settings
+func UpdatePagerDutyConfig(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req services.PagerDutySettings
+		if err := c.ShouldBindJSON(&req); err != nil {
+			respondErrorStr(c, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+
+		settings, err := svc.PagerDuty.UpdateSettings(c.Request.Context(), getAuditContext(c), req)
+		if err != nil {
+			if errors.Is(err, services.ErrInvalidPagerDutySettings) {
+				respondErrorStr(c, http.StatusBadRequest, err.Error())
+				return
+			}
+			log.Printf("ERROR UpdatePagerDutyConfig: %v", err)
+			respondErrorStr(c, http.StatusInternalServerError, "Failed to update PagerDuty configuration")
+			return
+		}
+
+		respondSuccess(c, settings)
+	}
+}
+
+// TestPagerDutyConnection checks that PagerDuty accepts the given API key
+func TestPagerDutyConnection(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		orgID, ok := middleware.GetOrganizationID(c)
+		if !ok {
+			respondErrorStr(c, http.StatusUnauthorized, "Organization ID not found")
+			return
+		}
+
+		var req services.PagerDutySettings
+		if err := c.ShouldBindJSON(&req); err != nil {
+			respondErrorStr(c, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+
+		if err := svc.PagerDuty.TestSettings(c.Request.Context(), orgID, req); err != nil {
+			log.Printf("PagerDuty test failed: %v", err)
+			respondSuccess(c, map[string]interface{}{
+				"success": false,
+				"message": err.Error(),
+			})
+			return
+		}
+
+		respondSuccess(c, map[string]interface{}{
+			"success": true,
+			"message": "PagerDuty API key accepted",
+		})
+	}
+}
+
+// ============================================
+// Impact Analysis Handlers
+// ============================================
+
+// GetNamespaceImpact lists the namespaces that depend on a namespace, with
+// the on-call responders to contact for each
+func GetNamespaceImpact(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		orgID, ok := middleware.GetOrganizationID(c)
+		if !ok {
+			respondErrorStr(c, http.StatusUnauthorized, "Organization ID not found")
+			return
+		}
+
+		id, ok := parseUUID(c, "id")
+		if !ok {
+			return
+		}
+
+		analysis, err := svc.Impact.Analyze(c.Request.Context(), orgID, id)
+		if err != nil {
+			if errors.Is(err, services.ErrNamespaceNotFound) {
+				respondErrorStr(c, http.StatusNotFound, "Namespace not found")
+				return
+			}
+			log.Printf("ERROR GetNamespaceImpact: %v", err)
+			respondErrorStr(c, http.StatusInternalServerError, "Failed to analyze namespace impact")
+			return
+		}
+
+		respondSuccess(c, analysis)
+	}
+}
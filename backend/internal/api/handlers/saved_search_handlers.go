@@ -0,0 +1,178 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kubeatlas/kubeatlas/internal/services"
+)
+
+// ============================================
+// Saved Search Handlers
+// ============================================
+
+// savedSearchLists are the list handlers a saved search of each entity
+// applies its filters to
+var savedSearchLists = map[string]func(*services.Services) gin.HandlerFunc{
+	"namespaces": ListNamespaces,
+	"clusters":   ListClusters,
+	"teams":      ListTeams,
+	"documents":  ListDocuments,
+	"audit_logs": ListAuditLogs,
+
+	"internal_dependencies": ListInternalDependencies,
+	"external_dependencies": ListExternalDependencies,
+}
+
+// ListSavedSearches lists the caller's saved searches and those shared with
+// their teams, optionally of one entity
+func ListSavedSearches(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		searches, err := svc.SavedSearch.List(c.Request.Context(), getAuditContext(c), c.Query("entity"))
+		if err != nil {
+			respondSavedSearchError(c, "ListSavedSearches", err, "Failed to list saved searches")
+			return
+		}
+
+		respondSuccess(c, searches)
+	}
+}
+
+// CreateSavedSearch saves a named filter set for the caller
+func CreateSavedSearch(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req services.SavedSearchRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			respondError(c, http.StatusBadRequest, err)
+			return
+		}
+
+		search, err := svc.SavedSearch.Create(c.Request.Context(), getAuditContext(c), req)
+		if err != nil {
+			respondSavedSearchError(c, "CreateSavedSearch", err, "Failed to save search")
+			return
+		}
+
+		c.JSON(http.StatusCreated, SuccessResponse{Data: search})
+	}
+}
+
+// GetSavedSearch returns a saved search visible to the caller
+func GetSavedSearch(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := parseUUID(c, "id")
+		if !ok {
+			return
+		}
+
+		search, err := svc.SavedSearch.Get(c.Request.Context(), getAuditContext(c), id)
+		if err != nil {
+			respondSavedSearchError(c, "GetSavedSearch", err, "Failed to get saved search")
+			return
+		}
+
+		respondSuccess(c, search)
+	}
+}
+
+// UpdateSavedSearch replaces a saved search of the caller
+func UpdateSavedSearch(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := parseUUID(c, "id")
+		if !ok {
+			return
+		}
+		var req services.SavedSearchRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			respondError(c, http.StatusBadRequest, err)
+			return
+		}
+
+		search, err := svc.SavedSearch.Update(c.Request.Context(), getAuditContext(c), id, req)
+		if err != nil {
+			respondSavedSearchError(c, "UpdateSavedSearch", err, "Failed to update saved search")
+			return
+		}
+
+		respondSuccess(c, search)
+	}
+}
+
+// DeleteSavedSearch deletes a saved search of the caller
+func DeleteSavedSearch(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := parseUUID(c, "id")
+		if !ok {
+			return
+		}
+
+		if err := svc.SavedSearch.Delete(c.Request.Context(), getAuditContext(c), id); err != nil {
+			respondSavedSearchError(c, "DeleteSavedSearch", err, "Failed to delete saved search")
+			return
+		}
+
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// ApplySavedSearch lists the entities a saved search selects, as its
+// entity's list endpoint would with the search's filters and sort. Paging
+// is taken from the request.
+func ApplySavedSearch(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := parseUUID(c, "id")
+		if !ok {
+			return
+		}
+
+		search, err := svc.SavedSearch.Get(c.Request.Context(), getAuditContext(c), id)
+		if err != nil {
+			respondSavedSearchError(c, "ApplySavedSearch", err, "Failed to apply saved search")
+			return
+		}
+		list, ok := savedSearchLists[search.Entity]
+		if !ok {
+			respondErrorStr(c, http.StatusBadRequest, "Saved searches of "+search.Entity+" cannot be applied")
+			return
+		}
+
+		// gin caches the query on its first read, so nothing may read it
+		// before it is rewritten here
+		query := c.Request.URL.Query()
+		for key := range query {
+			if key != "page" && key != "page_size" {
+				query.Del(key)
+			}
+		}
+		for key, value := range search.Filters {
+			query.Set(key, value)
+		}
+		if search.Sort.Valid {
+			query.Set("sort", search.Sort.String)
+		}
+		if search.Order.Valid {
+			query.Set("order", search.Order.String)
+		}
+		c.Request.URL.RawQuery = query.Encode()
+
+		list(svc)(c)
+	}
+}
+
+func respondSavedSearchError(c *gin.Context, op string, err error, message string) {
+	switch {
+	case errors.Is(err, services.ErrSavedSearchNotFound):
+		respondErrorStr(c, http.StatusNotFound, "Saved search not found")
+	case errors.Is(err, services.ErrSavedSearchNotOwner):
+		respondErrorStr(c, http.StatusForbidden, err.Error())
+	case errors.Is(err, services.ErrSavedSearchExists):
+		respondErrorStr(c, http.StatusConflict, err.Error())
+	case errors.Is(err, services.ErrInvalidSavedSearch):
+		respondErrorStr(c, http.StatusBadRequest, err.Error())
+	default:
+		log.Printf("ERROR %s: %v", op, err)
+		respondErrorStr(c, http.StatusInternalServerError, message)
+	}
+}
@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kubeatlas/kubeatlas/internal/api/middleware"
+	"github.com/kubeatlas/kubeatlas/internal/services"
+)
+
+// ============================================
+// Search Handlers
+// ============================================
+
+// Search searches namespaces and documents, including document contents, at
+// once; type narrows the search to some types of records
+func Search(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		orgID, ok := middleware.GetOrganizationID(c)
+		if !ok {
+			respondErrorStr(c, http.StatusUnauthorized, "Organization ID not found in context")
+			return
+		}
+
+		term := strings.TrimSpace(c.Query("q"))
+		if term == "" {
+			respondErrorStr(c, http.StatusBadRequest, "Query parameter 'q' is required")
+			return
+		}
+
+		var types []string
+		for _, t := range c.QueryArray("type") {
+			types = append(types, strings.Split(t, ",")...)
+		}
+
+		limit := 20
+		if l := c.Query("limit"); l != "" {
+			if val, err := strconv.Atoi(l); err == nil && val > 0 {
+				limit = val
+			}
+		}
+
+		results, err := svc.Search.Search(c.Request.Context(), orgID, term, types, limit)
+		if err != nil {
+			respondSearchError(c, "Search", err, "Failed to search")
+			return
+		}
+
+		respondSuccess(c, results)
+	}
+}
+
+// Suggest returns names of namespaces, teams, clusters and tags starting
+// with q, for autocomplete. Browsers may reuse them briefly too.
+func Suggest(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		orgID, ok := middleware.GetOrganizationID(c)
+		if !ok {
+			respondErrorStr(c, http.StatusUnauthorized, "Organization ID not found in context")
+			return
+		}
+
+		limit := 0
+		if l := c.Query("limit"); l != "" {
+			if val, err := strconv.Atoi(l); err == nil && val > 0 {
+				limit = val
+			}
+		}
+
+		suggestions, err := svc.Search.Suggest(c.Request.Context(), orgID, c.Query("q"), limit)
+		if err != nil {
+			respondSearchError(c, "Suggest", err, "Failed to suggest names")
+			return
+		}
+
+		c.Header("Cache-Control", "private, max-age=30")
+		respondSuccess(c, suggestions)
+	}
+}
+
+func respondSearchError(c *gin.Context, op string, err error, message string) {
+	switch {
+	case errors.Is(err, services.ErrInvalidSearchType):
+		respondErrorStr(c, http.StatusBadRequest, err.Error())
+	default:
+		log.Printf("ERROR %s: %v", op, err)
+		respondErrorStr(c, http.StatusInternalServerError, message)
+	}
+}
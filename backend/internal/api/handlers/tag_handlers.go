@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kubeatlas/kubeatlas/internal/api/middleware"
+	"github.com/kubeatlas/kubeatlas/internal/services"
+)
+
+// ============================================
+// Tag Handlers
+// ============================================
+
+// ListTags returns the tags in use with their usage counts; q narrows them
+// for autocomplete
+func ListTags(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		orgID, ok := middleware.GetOrganizationID(c)
+		if !ok {
+			respondErrorStr(c, http.StatusUnauthorized, "Organization ID not found in context")
+			return
+		}
+
+		limit := 0
+		if l := c.Query("limit"); l != "" {
+			if val, err := strconv.Atoi(l); err == nil && val > 0 {
+				limit = val
+			}
+		}
+
+		tags, err := svc.Tag.List(c.Request.Context(), orgID, c.Query("q"), limit)
+		if err != nil {
+			respondTagError(c, "ListTags", err, "Failed to list tags")
+			return
+		}
+
+		respondSuccess(c, tags)
+	}
+}
+
+// RenameTagRequest renames a tag
+type RenameTagRequest struct {
+	From string `json:"from" binding:"required"`
+	To   string `json:"to" binding:"required"`
+}
+
+// RenameTag renames a tag on every namespace, cluster and document
+func RenameTag(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req RenameTagRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			respondError(c, http.StatusBadRequest, err)
+			return
+		}
+
+		merge, err := svc.Tag.Rename(c.Request.Context(), getAuditContext(c), req.From, req.To)
+		if err != nil {
+			respondTagError(c, "RenameTag", err, "Failed to rename tag")
+			return
+		}
+
+		respondSuccess(c, merge)
+	}
+}
+
+// MergeTagsRequest merges tags into one
+type MergeTagsRequest struct {
+	Tags []string `json:"tags" binding:"required"`
+	Into string   `json:"into" binding:"required"`
+}
+
+// MergeTags replaces several tags with one on every namespace, cluster and
+// document
+func MergeTags(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req MergeTagsRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			respondError(c, http.StatusBadRequest, err)
+			return
+		}
+
+		merge, err := svc.Tag.Merge(c.Request.Context(), getAuditContext(c), req.Tags, req.Into)
+		if err != nil {
+			respondTagError(c, "MergeTags", err, "Failed to merge tags")
+			return
+		}
+
+		respondSuccess(c, merge)
+	}
+}
+
+func respondTagError(c *gin.Context, op string, err error, message string) {
+	switch {
+	case errors.Is(err, services.ErrTagNotFound):
+		respondErrorStr(c, http.StatusNotFound, err.Error())
+	case errors.Is(err, services.ErrInvalidTag):
+		respondErrorStr(c, http.StatusBadRequest, err.Error())
+	default:
+		log.Printf("ERROR %s: %v", op, err)
+		respondErrorStr(c, http.StatusInternalServerError, message)
+	}
+}
@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kubeatlas/kubeatlas/internal/services"
+)
+
+// ============================================
+// Team On-Call Handlers
+// ============================================
+
+// GetTeamOnCall returns the on-call rotation of a team
+func GetTeamOnCall(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := parseUUID(c, "id")
+		if !ok {
+			return
+		}
+
+		rotation, err := svc.TeamOnCall.Get(c.Request.Context(), getAuditContext(c).OrgID, id)
+		if err != nil {
+			respondTeamOnCallError(c, "GetTeamOnCall", err, "Failed to get on-call rotation")
+			return
+		}
+
+		respondSuccess(c, rotation)
+	}
+}
+
+// UpdateTeamOnCall sets the rotation URL and escalation contacts of a team
+func UpdateTeamOnCall(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := parseUUID(c, "id")
+		if !ok {
+			return
+		}
+		var req services.UpdateTeamOnCallRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			respondError(c, http.StatusBadRequest, err)
+			return
+		}
+
+		rotation, err := svc.TeamOnCall.Update(c.Request.Context(), getAuditContext(c), id, req)
+		if err != nil {
+			respondTeamOnCallError(c, "UpdateTeamOnCall", err, "Failed to update on-call rotation")
+			return
+		}
+
+		respondSuccess(c, rotation)
+	}
+}
+
+// respondTeamOnCallError maps team on-call errors to HTTP responses
+func respondTeamOnCallError(c *gin.Context, op string, err error, message string) {
+	switch {
+	case errors.Is(err, services.ErrTeamNotFound):
+		respondErrorStr(c, http.StatusNotFound, "Team not found")
+	case errors.Is(err, services.ErrInvalidOnCallRotation):
+		respondErrorStr(c, http.StatusBadRequest, err.Error())
+	default:
+		log.Printf("ERROR %s: %v", op, err)
+		respondErrorStr(c, http.StatusInternalServerError, message)
+	}
+}
@@ -0,0 +1,142 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kubeatlas/kubeatlas/internal/services"
+)
+
+// ============================================
+// Team Sync Handlers
+// ============================================
+
+// TeamDirectoryGroupsRequest lists the directory groups making up a team
+type TeamDirectoryGroupsRequest struct {
+	Groups []string `json:"groups"`
+}
+
+// DirectoryGroupPushRequest carries the members of a directory group pushed
+// by the identity provider
+type DirectoryGroupPushRequest struct {
+	Members []string `json:"members"`
+}
+
+// ListTeamDirectoryGroups returns the directory groups making up a team
+func ListTeamDirectoryGroups(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := parseUUID(c, "id")
+		if !ok {
+			return
+		}
+
+		groups, err := svc.TeamSync.ListGroups(c.Request.Context(), getAuditContext(c).OrgID, id)
+		if err != nil {
+			respondTeamSyncError(c, "ListTeamDirectoryGroups", err, "Failed to list directory groups")
+			return
+		}
+
+		respondSuccess(c, groups)
+	}
+}
+
+// SetTeamDirectoryGroups replaces the directory groups making up a team
+func SetTeamDirectoryGroups(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := parseUUID(c, "id")
+		if !ok {
+			return
+		}
+		var req TeamDirectoryGroupsRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			respondError(c, http.StatusBadRequest, err)
+			return
+		}
+
+		groups, err := svc.TeamSync.SetGroups(c.Request.Context(), getAuditContext(c), id, req.Groups)
+		if err != nil {
+			respondTeamSyncError(c, "SetTeamDirectoryGroups", err, "Failed to update directory groups")
+			return
+		}
+
+		respondSuccess(c, groups)
+	}
+}
+
+// ListTeamMembershipChanges returns the changelog of users joining and
+// leaving a team through directory synchronization
+func ListTeamMembershipChanges(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := parseUUID(c, "id")
+		if !ok {
+			return
+		}
+		limit := 0
+		if l := c.Query("limit"); l != "" {
+			parsed, err := strconv.Atoi(l)
+			if err != nil {
+				respondErrorStr(c, http.StatusBadRequest, "limit must be a number")
+				return
+			}
+			limit = parsed
+		}
+
+		changes, err := svc.TeamSync.Changes(c.Request.Context(), getAuditContext(c).OrgID, id, limit)
+		if err != nil {
+			respondTeamSyncError(c, "ListTeamMembershipChanges", err, "Failed to list membership changes")
+			return
+		}
+
+		respondSuccess(c, changes)
+	}
+}
+
+// SyncTeamDirectoryGroups reads the organization's mapped groups from LDAP
+// now and reconciles the teams made up of them
+func SyncTeamDirectoryGroups(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		result, err := svc.TeamSync.SyncLDAP(c.Request.Context(), getAuditContext(c))
+		if err != nil {
+			respondTeamSyncError(c, "SyncTeamDirectoryGroups", err, "Failed to synchronize teams")
+			return
+		}
+
+		respondSuccess(c, result)
+	}
+}
+
+// PushDirectoryGroup stores the members of a directory group pushed by the
+// identity provider and reconciles the teams made up of it
+func PushDirectoryGroup(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req DirectoryGroupPushRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			respondError(c, http.StatusBadRequest, err)
+			return
+		}
+
+		result, err := svc.TeamSync.PushGroup(c.Request.Context(), getAuditContext(c), c.Param("group"), req.Members)
+		if err != nil {
+			respondTeamSyncError(c, "PushDirectoryGroup", err, "Failed to synchronize teams")
+			return
+		}
+
+		respondSuccess(c, result)
+	}
+}
+
+// respondTeamSyncError maps team sync errors to HTTP responses
+func respondTeamSyncError(c *gin.Context, op string, err error, message string) {
+	switch {
+	case errors.Is(err, services.ErrTeamNotFound):
+		respondErrorStr(c, http.StatusNotFound, "Team not found")
+	case errors.Is(err, services.ErrInvalidDirectoryGroup), errors.Is(err, services.ErrLDAPNotConfigured):
+		respondErrorStr(c, http.StatusBadRequest, err.Error())
+	default:
+		log.Printf("ERROR %s: %v", op, err)
+		respondErrorStr(c, http.StatusInternalServerError, message)
+	}
+}
@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kubeatlas/kubeatlas/internal/api/middleware"
+	"github.com/kubeatlas/kubeatlas/internal/database/repositories"
+	"github.com/kubeatlas/kubeatlas/internal/services"
+)
+
+// ============================================
+// Trash Handlers
+// ============================================
+
+// ListTrash lists the organization's deleted clusters, namespaces, teams and
+// documents, the most recently deleted first. ?type= selects one of them.
+func ListTrash(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		orgID, ok := middleware.GetOrganizationID(c)
+		if !ok {
+			respondErrorStr(c, http.StatusUnauthorized, "Organization ID not found")
+			return
+		}
+
+		result, err := svc.Trash.List(c.Request.Context(), orgID, c.Query("type"), getPagination(c))
+		if err != nil {
+			if errors.Is(err, services.ErrInvalidTrashType) {
+				respondErrorStr(c, http.StatusBadRequest, err.Error())
+				return
+			}
+			log.Printf("ERROR ListTrash: %v", err)
+			respondErrorStr(c, http.StatusInternalServerError, "Failed to list trash")
+			return
+		}
+
+		respondPaginated(c, result.Items, result.Total, result.Page, result.PageSize, result.TotalPages)
+	}
+}
+
+// RestoreCluster restores a deleted cluster with the namespaces deleted with it
+func RestoreCluster(svc *services.Services) gin.HandlerFunc {
+	return restoreFromTrash(svc, repositories.TrashCluster)
+}
+
+// RestoreNamespace restores a deleted namespace of a cluster that is not
+// deleted
+func RestoreNamespace(svc *services.Services) gin.HandlerFunc {
+	return restoreFromTrash(svc, repositories.TrashNamespace)
+}
+
+// RestoreTeam restores a deleted team
+func RestoreTeam(svc *services.Services) gin.HandlerFunc {
+	return restoreFromTrash(svc, repositories.TrashTeam)
+}
+
+// RestoreDocument restores a deleted document of a namespace or cluster that
+// is not deleted
+func RestoreDocument(svc *services.Services) gin.HandlerFunc {
+	return restoreFromTrash(svc, repositories.TrashDocument)
+}
+
+func restoreFromTrash(svc *services.Services, typ string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := parseUUID(c, "id")
+		if !ok {
+			return
+		}
+
+		item, err := svc.Trash.Restore(c.Request.Context(), getAuditContext(c), typ, id)
+		if err != nil {
+			switch {
+			case errors.Is(err, services.ErrTrashItemNotFound):
+				respondErrorStr(c, http.StatusNotFound, err.Error())
+			case errors.Is(err, services.ErrRestoreBlocked):
+				respondErrorStr(c, http.StatusConflict, err.Error())
+			default:
+				log.Printf("ERROR Restore %s: %v", typ, err)
+				respondErrorStr(c, http.StatusInternalServerError, "Failed to restore "+typ)
+			}
+			return
+		}
+
+		respondSuccess(c, item)
+	}
+}
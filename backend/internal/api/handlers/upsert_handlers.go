@@ -0,0 +1,244 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/kubeatlas/kubeatlas/internal/api/middleware"
+	"github.com/kubeatlas/kubeatlas/internal/models"
+	"github.com/kubeatlas/kubeatlas/internal/services"
+)
+
+// ============================================
+// By-name Handlers
+// ============================================
+
+// Clusters, teams and business units can be read and upserted by name, so
+// declarative tools such as Terraform can manage them without keeping track
+// of their IDs. The name also serves as their import ID.
+
+// bindNamed binds the JSON body of a request for the resource named in the
+// path. The body may leave out the name, but must not name another resource.
+func bindNamed(c *gin.Context, req interface{}, name *string) bool {
+	if err := json.NewDecoder(c.Request.Body).Decode(req); err != nil && !errors.Is(err, io.EOF) {
+		respondErrorStr(c, http.StatusBadRequest, "Invalid request body")
+		return false
+	}
+	if *name != "" && *name != c.Param("name") {
+		respondErrorStr(c, http.StatusBadRequest, "Name in the body does not match the path")
+		return false
+	}
+	*name = c.Param("name")
+	if err := binding.Validator.ValidateStruct(req); err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return false
+	}
+	return true
+}
+
+// respondUpserted responds 201 for created resources and 200 for updated ones
+func respondUpserted(c *gin.Context, created bool, data interface{}) {
+	if created {
+		c.JSON(http.StatusCreated, SuccessResponse{Data: data})
+		return
+	}
+	respondSuccess(c, data)
+}
+
+// GetClusterByName returns the cluster with the name in the path
+func GetClusterByName(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		orgID, ok := middleware.GetOrganizationID(c)
+		if !ok {
+			respondErrorStr(c, http.StatusUnauthorized, "Organization ID not found")
+			return
+		}
+
+		cluster, err := svc.Cluster.GetByName(c.Request.Context(), orgID, c.Param("name"))
+		if err != nil {
+			if errors.Is(err, services.ErrClusterNotFound) {
+				respondErrorStr(c, http.StatusNotFound, "Cluster not found")
+				return
+			}
+			respondErrorStr(c, http.StatusInternalServerError, "Failed to get cluster")
+			return
+		}
+
+		respondSuccess(c, cluster)
+	}
+}
+
+// UpsertClusterByName creates or updates the cluster with the name in the path
+func UpsertClusterByName(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req services.CreateClusterRequest
+		if !bindNamed(c, &req, &req.Name) {
+			return
+		}
+
+		cluster, created, err := svc.Cluster.UpsertByName(c.Request.Context(), getAuditContext(c), req.Name, req)
+		if err != nil {
+			switch {
+			case errors.Is(err, services.ErrInvalidClusterName), errors.Is(err, services.ErrInvalidAPIServerURL),
+				errors.Is(err, services.ErrInvalidFallbackURLs), errors.Is(err, services.ErrInvalidClusterType),
+				errors.Is(err, services.ErrInvalidEnvironment):
+				respondErrorStr(c, http.StatusBadRequest, err.Error())
+			case errors.Is(err, services.ErrClusterNameExists):
+				respondErrorStr(c, http.StatusConflict, "Cluster with this name already exists")
+			default:
+				log.Printf("ERROR UpsertClusterByName: name=%s, err=%v", req.Name, err)
+				respondErrorStr(c, http.StatusInternalServerError, "Failed to upsert cluster")
+			}
+			return
+		}
+
+		respondUpserted(c, created, cluster)
+	}
+}
+
+// GetTeamByName returns the team with the name in the path
+func GetTeamByName(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		orgID, ok := middleware.GetOrganizationID(c)
+		if !ok {
+			respondErrorStr(c, http.StatusUnauthorized, "Organization ID not found")
+			return
+		}
+
+		team, err := svc.Team.GetByName(c.Request.Context(), orgID, c.Param("name"))
+		if err != nil {
+			if errors.Is(err, services.ErrTeamNotFound) {
+				respondErrorStr(c, http.StatusNotFound, "Team not found")
+				return
+			}
+			respondErrorStr(c, http.StatusInternalServerError, "Failed to get team")
+			return
+		}
+
+		respondSuccess(c, toTeamResponse(*team))
+	}
+}
+
+// UpsertTeamByName creates or updates the team with the name in the path
+func UpsertTeamByName(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req services.CreateTeamRequest
+		if !bindNamed(c, &req, &req.Name) {
+			return
+		}
+
+		team, created, err := svc.Team.UpsertByName(c.Request.Context(), getAuditContext(c), req.Name, req)
+		if err != nil {
+			log.Printf("ERROR UpsertTeamByName: name=%s, err=%v", req.Name, err)
+			respondErrorStr(c, http.StatusInternalServerError, "Failed to upsert team")
+			return
+		}
+
+		respondUpserted(c, created, toTeamResponse(*team))
+	}
+}
+
+// GetBusinessUnitByName returns the business unit with the name in the path
+func GetBusinessUnitByName(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		orgID, ok := middleware.GetOrganizationID(c)
+		if !ok {
+			respondErrorStr(c, http.StatusUnauthorized, "Organization ID not found")
+			return
+		}
+
+		bu, err := svc.BusinessUnit.GetByName(c.Request.Context(), orgID, c.Param("name"))
+		if err != nil {
+			if errors.Is(err, services.ErrBusinessUnitNotFound) {
+				respondErrorStr(c, http.StatusNotFound, "Business unit not found")
+				return
+			}
+			respondErrorStr(c, http.StatusInternalServerError, "Failed to get business unit")
+			return
+		}
+
+		respondSuccess(c, bu)
+	}
+}
+
+// UpsertBusinessUnitByName creates or updates the business unit with the name
+// in the path
+func UpsertBusinessUnitByName(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req services.CreateBusinessUnitRequest
+		if !bindNamed(c, &req, &req.Name) {
+			return
+		}
+
+		bu, created, err := svc.BusinessUnit.UpsertByName(c.Request.Context(), getAuditContext(c), req.Name, req)
+		if err != nil {
+			log.Printf("ERROR UpsertBusinessUnitByName: name=%s, err=%v", req.Name, err)
+			respondErrorStr(c, http.StatusInternalServerError, "Failed to upsert business unit")
+			return
+		}
+
+		respondUpserted(c, created, bu)
+	}
+}
+
+// upsertResponse is the resource an idempotent upsert left and what it did
+type upsertResponse struct {
+	services.UpsertResult
+	Namespace *models.Namespace `json:"namespace,omitempty"`
+	Team      *TeamResponse     `json:"team,omitempty"`
+}
+
+// UpsertNamespace creates or updates the namespace keyed by cluster and
+// namespace name, at POST /namespaces/upsert
+func UpsertNamespace(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req services.UpsertNamespaceRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			respondError(c, http.StatusBadRequest, err)
+			return
+		}
+
+		ns, result, err := svc.Namespace.Upsert(c.Request.Context(), getAuditContext(c), req)
+		if err != nil {
+			switch {
+			case errors.Is(err, services.ErrClusterNotFound):
+				respondErrorStr(c, http.StatusNotFound, "Cluster not found")
+			case errors.Is(err, services.ErrInvalidEscalationPath), errors.Is(err, services.ErrInvalidEnvironment),
+				errors.Is(err, services.ErrInvalidNamespace):
+				respondErrorStr(c, http.StatusBadRequest, err.Error())
+			default:
+				log.Printf("ERROR UpsertNamespace: cluster=%s, name=%s, err=%v", req.Cluster, req.Name, err)
+				respondErrorStr(c, http.StatusInternalServerError, "Failed to upsert namespace")
+			}
+			return
+		}
+
+		respondUpserted(c, result.Action == services.UpsertCreated, upsertResponse{UpsertResult: *result, Namespace: ns})
+	}
+}
+
+// UpsertTeam creates or updates the team keyed by slug, at POST /teams/upsert
+func UpsertTeam(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req services.CreateTeamRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			respondError(c, http.StatusBadRequest, err)
+			return
+		}
+
+		team, result, err := svc.Team.UpsertBySlug(c.Request.Context(), getAuditContext(c), req)
+		if err != nil {
+			log.Printf("ERROR UpsertTeam: slug=%s, err=%v", req.Slug, err)
+			respondErrorStr(c, http.StatusInternalServerError, "Failed to upsert team")
+			return
+		}
+
+		resp := toTeamResponse(*team)
+		respondUpserted(c, result.Action == services.UpsertCreated, upsertResponse{UpsertResult: *result, Team: &resp})
+	}
+}
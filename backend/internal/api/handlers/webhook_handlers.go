@@ -0,0 +1,184 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kubeatlas/kubeatlas/internal/api/middleware"
+	"github.com/kubeatlas/kubeatlas/internal/services"
+)
+
+// ============================================
+// Webhook Subscription Handlers
+// ============================================
+
+// ListWebhooks returns the organization's webhook subscriptions
+func ListWebhooks(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		orgID, ok := middleware.GetOrganizationID(c)
+		if !ok {
+			respondErrorStr(c, http.StatusUnauthorized, "Organization ID not found")
+			return
+		}
+
+		webhooks, err := svc.Webhook.List(c.Request.Context(), orgID)
+		if err != nil {
+			log.Printf("ERROR ListWebhooks: %v", err)
+			respondErrorStr(c, http.StatusInternalServerError, "Failed to list webhooks")
+			return
+		}
+
+		respondSuccess(c, webhooks)
+	}
+}
+
+// GetWebhook returns a single webhook subscription
+func GetWebhook(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := parseUUID(c, "id")
+		if !ok {
+			return
+		}
+		orgID, ok := middleware.GetOrganizationID(c)
+		if !ok {
+			respondErrorStr(c, http.StatusUnauthorized, "Organization ID not found")
+			return
+		}
+
+		webhook, err := svc.Webhook.GetByID(c.Request.Context(), orgID, id)
+		if err != nil {
+			respondWebhookError(c, "GetWebhook", err, "Failed to get webhook")
+			return
+		}
+
+		respondSuccess(c, webhook)
+	}
+}
+
+// CreateWebhook creates a webhook subscription. The signing secret is only
+// returned in this response.
+func CreateWebhook(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req services.CreateWebhookRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			respondErrorStr(c, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+
+		webhook, err := svc.Webhook.Create(c.Request.Context(), getAuditContext(c), req)
+		if err != nil {
+			respondWebhookError(c, "CreateWebhook", err, "Failed to create webhook")
+			return
+		}
+
+		c.JSON(http.StatusCreated, SuccessResponse{Data: webhook})
+	}
+}
+
+// UpdateWebhook updates a webhook subscription
+func UpdateWebhook(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := parseUUID(c, "id")
+		if !ok {
+			return
+		}
+
+		var req services.UpdateWebhookRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			respondErrorStr(c, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+
+		webhook, err := svc.Webhook.Update(c.Request.Context(), getAuditContext(c), id, req)
+		if err != nil {
+			respondWebhookError(c, "UpdateWebhook", err, "Failed to update webhook")
+			return
+		}
+
+		respondSuccess(c, webhook)
+	}
+}
+
+// DeleteWebhook deletes a webhook subscription
+func DeleteWebhook(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := parseUUID(c, "id")
+		if !ok {
+			return
+		}
+
+		if err := svc.Webhook.Delete(c.Request.Context(), getAuditContext(c), id); err != nil {
+			respondWebhookError(c, "DeleteWebhook", err, "Failed to delete webhook")
+			return
+		}
+
+		c.JSON(http.StatusNoContent, nil)
+	}
+}
+
+// ListWebhookDeliveries returns the delivery history of a webhook subscription
+func ListWebhookDeliveries(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := parseUUID(c, "id")
+		if !ok {
+			return
+		}
+		orgID, ok := middleware.GetOrganizationID(c)
+		if !ok {
+			respondErrorStr(c, http.StatusUnauthorized, "Organization ID not found")
+			return
+		}
+
+		p := getPagination(c)
+		filters := make(map[string]interface{})
+		if status := c.Query("status"); status != "" {
+			filters["status"] = status
+		}
+		if eventType := c.Query("event_type"); eventType != "" {
+			filters["event_type"] = eventType
+		}
+
+		result, err := svc.Webhook.ListDeliveries(c.Request.Context(), orgID, id, p, filters)
+		if err != nil {
+			respondWebhookError(c, "ListWebhookDeliveries", err, "Failed to list webhook deliveries")
+			return
+		}
+
+		respondPaginated(c, result.Items, result.Total, result.Page, result.PageSize, result.TotalPages)
+	}
+}
+
+// PingWebhook sends a ping event and returns the recorded delivery
+func PingWebhook(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := parseUUID(c, "id")
+		if !ok {
+			return
+		}
+
+		delivery, err := svc.Webhook.Ping(c.Request.Context(), getAuditContext(c), id)
+		if err != nil {
+			respondWebhookError(c, "PingWebhook", err, "Failed to ping webhook")
+			return
+		}
+
+		respondSuccess(c, delivery)
+	}
+}
+
+// respondWebhookError maps webhook service errors to responses
+func respondWebhookError(c *gin.Context, op string, err error, message string) {
+	switch {
+	case errors.Is(err, services.ErrWebhookNotFound):
+		respondErrorStr(c, http.StatusNotFound, "Webhook not found")
+	case errors.Is(err, services.ErrInvalidWebhookURL),
+		errors.Is(err, services.ErrInvalidWebhookEvent),
+		errors.Is(err, services.ErrNoWebhookEvents):
+		respondErrorStr(c, http.StatusBadRequest, err.Error())
+	default:
+		log.Printf("ERROR %s: %v", op, err)
+		respondErrorStr(c, http.StatusInternalServerError, message)
+	}
+}
@@ -1,12 +1,14 @@
 package middleware
 
 import (
+	"context"
 	"net/http"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
+	"github.com/kubeatlas/kubeatlas/internal/telemetry"
 )
 
 // Claims represents JWT claims
@@ -92,6 +94,39 @@ func Auth(jwtSecret string) gin.HandlerFunc {
 		c.Set(ContextUserEmail, claims.Email)
 		c.Set(ContextUserRole, claims.Role)
 		c.Set(ContextClaims, claims)
+		telemetry.SetErrorUser(c.Request.Context(), claims.UserID.String(), claims.Email, claims.OrganizationID.String())
+
+		c.Next()
+	}
+}
+
+// SessionChecker reports whether the user of a valid token may still use
+// the API
+type SessionChecker func(ctx context.Context, userID, orgID uuid.UUID) (bool, error)
+
+// RequireActiveSession rejects tokens of users that were deactivated or whose
+// organization was deleted since the token was issued. It runs after Auth.
+func RequireActiveSession(check SessionChecker) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, _ := GetUserID(c)
+		orgID, _ := GetOrganizationID(c)
+
+		active, err := check(c.Request.Context(), userID, orgID)
+		if err != nil {
+			c.Error(err)
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{
+				"error":   "unavailable",
+				"message": "Failed to verify session",
+			})
+			return
+		}
+		if !active {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"error":   "unauthorized",
+				"message": "Account is inactive or its organization was deleted",
+			})
+			return
+		}
 
 		c.Next()
 	}
@@ -0,0 +1,130 @@
+package middleware
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// BodyLimit caps the request body at maxBytes. Used again on a route group
+// or route, it replaces the limit of the middleware before it, so the
+// router can set a small limit for JSON and groups raise it for uploads.
+//
+// Reading a body past the limit fails with an *http.MaxBytesError, before
+// anything is read when its Content-Length is already larger, and
+// BodyTooLarge then tells handlers to answer 413. Gzip-encoded bodies are
+// decompressed for the handler and limited once decompressed, so a small
+// compressed body cannot expand without bound; other encodings get 415.
+func BodyLimit(maxBytes int64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if body, ok := c.Request.Body.(*limitedBody); ok {
+			if body.r == nil {
+				body.limit = maxBytes
+			}
+			c.Next()
+			return
+		}
+
+		compressed := false
+		switch encoding := strings.ToLower(strings.TrimSpace(c.GetHeader("Content-Encoding"))); encoding {
+		case "", "identity":
+		case "gzip":
+			compressed = true
+		default:
+			c.AbortWithStatusJSON(http.StatusUnsupportedMediaType, gin.H{
+				"error":   "unsupported_media_type",
+				"message": "Content-Encoding must be gzip or identity",
+			})
+			return
+		}
+		if c.Request.Body == nil || c.Request.Body == http.NoBody {
+			c.Next()
+			return
+		}
+
+		c.Request.Body = &limitedBody{
+			raw:      c.Request.Body,
+			gzip:     compressed,
+			declared: c.Request.ContentLength,
+			limit:    maxBytes,
+			header:   c.Writer.Header(),
+		}
+		if compressed {
+			c.Request.Header.Del("Content-Encoding")
+			c.Request.ContentLength = -1
+		}
+		c.Next()
+	}
+}
+
+// BodyTooLarge reports whether the request body was larger than the limit
+// set by BodyLimit, once the handler tried to read it
+func BodyTooLarge(c *gin.Context) bool {
+	body, ok := c.Request.Body.(*limitedBody)
+	return ok && body.exceeded
+}
+
+// limitedBody reads a request body, decompressing it if needed, up to a
+// limit
+type limitedBody struct {
+	raw      io.ReadCloser
+	gzip     bool
+	declared int64 // Content-Length as sent, -1 if unknown
+	limit    int64
+	header   http.Header // of the response, to close the connection when exceeded
+	r        io.Reader   // raw or decompressing it, once reading started
+	read     int64
+	exceeded bool
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if b.exceeded {
+		return 0, &http.MaxBytesError{Limit: b.limit}
+	}
+	if b.r == nil {
+		// Compressed bodies are checked as sent, then as decompressed
+		if b.declared > b.limit {
+			return 0, b.exceed()
+		}
+		b.r = b.raw
+		if b.gzip {
+			zr, err := gzip.NewReader(b.raw)
+			if err != nil {
+				return 0, err
+			}
+			b.r = zr
+		}
+	}
+
+	// Read one byte past the limit to tell a body of exactly limit bytes
+	// from a larger one
+	if left := b.limit - b.read + 1; int64(len(p)) > left {
+		p = p[:left]
+	}
+	n, err := b.r.Read(p)
+	if b.read+int64(n) > b.limit {
+		n = int(b.limit - b.read)
+		b.read = b.limit
+		return n, b.exceed()
+	}
+	b.read += int64(n)
+	return n, err
+}
+
+// exceed marks the body too large. The rest of it is left unread, so the
+// connection cannot be reused.
+func (b *limitedBody) exceed() error {
+	b.exceeded = true
+	b.header.Set("Connection", "close")
+	return &http.MaxBytesError{Limit: b.limit}
+}
+
+func (b *limitedBody) Close() error {
+	if zr, ok := b.r.(*gzip.Reader); ok {
+		zr.Close()
+	}
+	return b.raw.Close()
+}
@@ -0,0 +1,80 @@
+package middleware
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestBodyLimit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(BodyLimit(16))
+	echo := func(c *gin.Context) {
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			if BodyTooLarge(c) {
+				c.String(http.StatusRequestEntityTooLarge, "too large")
+				return
+			}
+			c.String(http.StatusBadRequest, err.Error())
+			return
+		}
+		c.String(http.StatusOK, "%s %s", c.GetHeader("Content-Encoding"), body)
+	}
+	r.POST("/json", echo)
+	r.POST("/upload", BodyLimit(64), echo)
+
+	gzipped := func(s string) []byte {
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		zw.Write([]byte(s))
+		zw.Close()
+		return buf.Bytes()
+	}
+
+	tests := []struct {
+		name     string
+		path     string
+		body     io.Reader
+		length   int64 // -1 for chunked
+		encoding string
+		status   int
+		want     string
+	}{
+		{"within the limit", "/json", strings.NewReader(`{"name":"prod"}`), 15, "", http.StatusOK, ` {"name":"prod"}`},
+		{"declared too large", "/json", strings.NewReader(strings.Repeat("x", 17)), 17, "", http.StatusRequestEntityTooLarge, ""},
+		{"chunked too large", "/json", strings.NewReader(strings.Repeat("x", 17)), -1, "", http.StatusRequestEntityTooLarge, ""},
+		{"exactly the limit", "/json", strings.NewReader(strings.Repeat("x", 16)), -1, "", http.StatusOK, " " + strings.Repeat("x", 16)},
+		{"raised by the route", "/upload", strings.NewReader(strings.Repeat("x", 40)), 40, "", http.StatusOK, " " + strings.Repeat("x", 40)},
+		{"gzip decompressed", "/json", bytes.NewReader(gzipped("compressed")), -1, "gzip", http.StatusOK, " compressed"},
+		{"gzip expanding past the limit", "/json", bytes.NewReader(gzipped(strings.Repeat("0", 1<<20))), -1, "gzip", http.StatusRequestEntityTooLarge, ""},
+		{"unsupported encoding", "/json", strings.NewReader("x"), 1, "br", http.StatusUnsupportedMediaType, ""},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodPost, tt.path, tt.body)
+		req.ContentLength = tt.length
+		if tt.encoding != "" {
+			req.Header.Set("Content-Encoding", tt.encoding)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		if w.Code != tt.status {
+			t.Errorf("%s: status = %d, want %d (%s)", tt.name, w.Code, tt.status, w.Body.String())
+			continue
+		}
+		if tt.status == http.StatusOK && w.Body.String() != tt.want {
+			t.Errorf("%s: body = %q, want %q", tt.name, w.Body.String(), tt.want)
+		}
+		if tt.status == http.StatusRequestEntityTooLarge && w.Header().Get("Connection") != "close" {
+			t.Errorf("%s: connection not closed", tt.name)
+		}
+	}
+}
@@ -0,0 +1,28 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/kubeatlas/kubeatlas/internal/telemetry"
+)
+
+// ErrorReporting attaches a per-request error reporting hub tagged with the
+// request ID and reports 5xx responses. Register it after RequestID; panics are
+// reported by Recovery, which must run before it.
+func ErrorReporting() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := telemetry.WithErrorHub(c.Request.Context(), c.Request, GetRequestID(c))
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+
+		status := c.Writer.Status()
+		if status < 500 {
+			return
+		}
+		if len(c.Errors) > 0 {
+			telemetry.CaptureError(ctx, c.Errors.Last().Err)
+			return
+		}
+		telemetry.CaptureMessage(ctx, "HTTP %d %s %s", status, c.Request.Method, c.FullPath())
+	}
+}
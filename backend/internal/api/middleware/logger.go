@@ -5,6 +5,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/kubeatlas/kubeatlas/internal/telemetry"
 	"go.uber.org/zap"
 )
 
@@ -39,8 +40,29 @@ func GetRequestID(c *gin.Context) string {
 	return ""
 }
 
+// LoggerOption configures the Logger middleware
+type LoggerOption func(*loggerOptions)
+
+type loggerOptions struct {
+	access *zap.Logger
+}
+
+// WithAccessLog additionally writes a structured access log entry for every
+// request to access, e.g. a file or syslog sink collected by security teams.
+// A nil logger is ignored.
+func WithAccessLog(access *zap.Logger) LoggerOption {
+	return func(o *loggerOptions) {
+		o.access = access
+	}
+}
+
 // Logger returns a middleware that logs requests using zap
-func Logger(logger *zap.SugaredLogger) gin.HandlerFunc {
+func Logger(logger *zap.SugaredLogger, opts ...LoggerOption) gin.HandlerFunc {
+	var options loggerOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+
 	return func(c *gin.Context) {
 		// Start timer
 		start := time.Now()
@@ -64,6 +86,26 @@ func Logger(logger *zap.SugaredLogger) gin.HandlerFunc {
 		userID, _ := GetUserID(c)
 		userEmail, _ := GetUserEmail(c)
 
+		if options.access != nil {
+			orgID, _ := GetOrganizationID(c)
+			options.access.Info("access",
+				zap.String("request_id", requestID),
+				zap.String("method", method),
+				zap.String("path", path),
+				zap.String("route", c.FullPath()),
+				zap.String("query", query),
+				zap.Int("status", statusCode),
+				zap.Int64("latency_ms", latency.Milliseconds()),
+				zap.String("client_ip", clientIP),
+				zap.String("user_agent", c.Request.UserAgent()),
+				zap.Int64("bytes_in", c.Request.ContentLength),
+				zap.Int("bytes_out", bodySize),
+				zap.String("user_id", userID.String()),
+				zap.String("user_email", userEmail),
+				zap.String("organization_id", orgID.String()),
+			)
+		}
+
 		// Log based on status code
 		if statusCode >= 500 {
 			logger.Errorw("Server error",
@@ -122,6 +164,7 @@ func Recovery(logger *zap.SugaredLogger) gin.HandlerFunc {
 					"error", err,
 					"path", c.Request.URL.Path,
 				)
+				telemetry.CapturePanic(c.Request.Context(), err)
 				c.AbortWithStatusJSON(500, gin.H{
 					"error":   "Internal Server Error",
 					"message": "An unexpected error occurred",
@@ -163,17 +206,6 @@ func CORS(origins []string) gin.HandlerFunc {
 	}
 }
 
-// RateLimit returns a simple rate limiting middleware
-// In production, use a proper rate limiter with Redis
-func RateLimit(requestsPerSecond int) gin.HandlerFunc {
-	// This is a simplified implementation
-	// For production, use github.com/ulule/limiter or similar
-	return func(c *gin.Context) {
-		// TODO: Implement proper rate limiting
-		c.Next()
-	}
-}
-
 // Timeout returns a middleware that sets request timeout
 func Timeout(timeout time.Duration) gin.HandlerFunc {
 	return func(c *gin.Context) {
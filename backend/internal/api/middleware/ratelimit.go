@@ -1,13 +1,41 @@
 package middleware
 
 import (
+	"context"
+	"math"
 	"net/http"
+	"strconv"
 	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/kubeatlas/kubeatlas/internal/config"
+	"github.com/redis/go-redis/v9"
 )
 
+// LimitResult is the outcome of a rate limit check
+type LimitResult struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	RetryAfter time.Duration // time until the next request is allowed when denied
+}
+
+// Limiter decides whether a client identified by key may make another request
+type Limiter interface {
+	Take(ctx context.Context, key string) (LimitResult, error)
+}
+
+// NewLimiter returns a Redis-backed sliding window limiter shared by all API
+// replicas, or an in-memory token bucket when rdb is nil. name namespaces the
+// keys so route groups keep independent counters.
+func NewLimiter(rdb *redis.Client, name string, rule config.RateLimitRule) Limiter {
+	if rdb != nil {
+		return NewRedisLimiter(rdb, name, rule.Requests, rule.Window)
+	}
+	return NewRateLimiter(rule.Requests, rule.Window)
+}
+
 // RateLimiter implements token bucket algorithm
 type RateLimiter struct {
 	requests map[string]*clientLimit
@@ -37,10 +65,17 @@ func NewRateLimiter(rate int, window time.Duration) *RateLimiter {
 
 // Allow checks if request is allowed
 func (rl *RateLimiter) Allow(clientID string) bool {
+	result, _ := rl.Take(context.Background(), clientID)
+	return result.Allowed
+}
+
+// Take implements Limiter
+func (rl *RateLimiter) Take(_ context.Context, clientID string) (LimitResult, error) {
 	rl.mu.Lock()
 	defer rl.mu.Unlock()
 
 	now := time.Now()
+	result := LimitResult{Limit: rl.rate}
 	client, exists := rl.requests[clientID]
 
 	if !exists {
@@ -48,24 +83,31 @@ func (rl *RateLimiter) Allow(clientID string) bool {
 			tokens:    rl.rate - 1,
 			lastCheck: now,
 		}
-		return true
+		result.Allowed = true
+		result.Remaining = rl.rate - 1
+		return result, nil
 	}
 
-	// Calculate tokens to add based on time passed
-	elapsed := now.Sub(client.lastCheck)
-	tokensToAdd := int(elapsed / rl.window * time.Duration(rl.rate))
-
-	if tokensToAdd > 0 {
+	// Refill one token every window/rate
+	interval := rl.window / time.Duration(rl.rate)
+	if elapsed := now.Sub(client.lastCheck); elapsed >= interval {
+		tokensToAdd := int(elapsed / interval)
 		client.tokens = min(rl.rate, client.tokens+tokensToAdd)
-		client.lastCheck = now
+		client.lastCheck = client.lastCheck.Add(time.Duration(tokensToAdd) * interval)
+		if client.tokens == rl.rate {
+			client.lastCheck = now
+		}
 	}
 
 	if client.tokens > 0 {
 		client.tokens--
-		return true
+		result.Allowed = true
+		result.Remaining = client.tokens
+		return result, nil
 	}
 
-	return false
+	result.RetryAfter = interval - now.Sub(client.lastCheck)
+	return result, nil
 }
 
 // cleanup removes old entries periodically
@@ -85,26 +127,49 @@ func (rl *RateLimiter) cleanup() {
 	}
 }
 
-// RateLimit returns a middleware that limits requests
-func RateLimiterMiddleware(rate int, window time.Duration) gin.HandlerFunc {
-	limiter := NewRateLimiter(rate, window)
+// KeyFunc identifies the client a request is counted against
+type KeyFunc func(c *gin.Context) string
 
-	return func(c *gin.Context) {
-		// Use client IP as identifier (or API key if available)
-		clientID := c.ClientIP()
+// KeyByClient keys by authenticated user, then client IP. Before Auth has
+// run every request is keyed by IP.
+func KeyByClient(c *gin.Context) string {
+	if userID, ok := GetUserID(c); ok {
+		return "user:" + userID.String()
+	}
+	return "ip:" + c.ClientIP()
+}
+
+// KeyByIP keys by client IP only, e.g. for unauthenticated endpoints
+func KeyByIP(c *gin.Context) string {
+	return "ip:" + c.ClientIP()
+}
 
-		// Check for API key header
-		if apiKey := c.GetHeader("X-API-Key"); apiKey != "" {
-			clientID = apiKey
+// RateLimit returns a middleware that enforces limiter per client. Responses
+// carry X-RateLimit-* headers; denied requests get 429 with Retry-After. If
+// the limiter backend fails the request is let through.
+func RateLimit(limiter Limiter, key KeyFunc, errorCode string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		result, err := limiter.Take(c.Request.Context(), key(c))
+		if err != nil {
+			c.Error(err)
+			c.Next()
+			return
 		}
 
-		if !limiter.Allow(clientID) {
-			c.JSON(http.StatusTooManyRequests, gin.H{
-				"error":       "rate_limit_exceeded",
+		c.Header("X-RateLimit-Limit", strconv.Itoa(result.Limit))
+		c.Header("X-RateLimit-Remaining", strconv.Itoa(result.Remaining))
+
+		if !result.Allowed {
+			retryAfter := int(math.Ceil(result.RetryAfter.Seconds()))
+			if retryAfter < 1 {
+				retryAfter = 1
+			}
+			c.Header("Retry-After", strconv.Itoa(retryAfter))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
+				"error":       errorCode,
 				"message":     "Too many requests. Please try again later.",
-				"retry_after": window.Seconds(),
+				"retry_after": retryAfter,
 			})
-			c.Abort()
 			return
 		}
 
@@ -112,72 +177,34 @@ func RateLimiterMiddleware(rate int, window time.Duration) gin.HandlerFunc {
 	}
 }
 
-// RateLimitByUser returns a middleware that limits requests per user
+// RateLimiterMiddleware limits requests per client using an in-memory limiter
+func RateLimiterMiddleware(rate int, window time.Duration) gin.HandlerFunc {
+	return RateLimit(NewRateLimiter(rate, window), KeyByClient, "rate_limit_exceeded")
+}
+
+// RateLimiterByUser returns a middleware that limits requests per user
 func RateLimiterByUser(rate int, window time.Duration) gin.HandlerFunc {
-	limiter := NewRateLimiter(rate, window)
+	limiter := RateLimit(NewRateLimiter(rate, window), KeyByClient, "rate_limit_exceeded")
 
 	return func(c *gin.Context) {
-		userID, exists := GetUserID(c)
-		if !exists {
+		if _, exists := GetUserID(c); !exists {
 			c.Next()
 			return
 		}
-
-		if !limiter.Allow(userID.String()) {
-			c.JSON(http.StatusTooManyRequests, gin.H{
-				"error":   "rate_limit_exceeded",
-				"message": "Too many requests. Please try again later.",
-			})
-			c.Abort()
-			return
-		}
-
-		c.Next()
+		limiter(c)
 	}
 }
 
 // LoginRateLimiter returns a strict rate limiter for login attempts (brute force protection)
 // Default: 5 attempts per 15 minutes per IP
 func LoginRateLimiter() gin.HandlerFunc {
-	limiter := NewRateLimiter(5, 15*time.Minute)
-
-	return func(c *gin.Context) {
-		clientIP := c.ClientIP()
-
-		if !limiter.Allow(clientIP) {
-			c.JSON(http.StatusTooManyRequests, gin.H{
-				"error":       "too_many_login_attempts",
-				"message":     "Too many login attempts. Please try again later.",
-				"retry_after": 900, // 15 minutes in seconds
-			})
-			c.Abort()
-			return
-		}
-
-		c.Next()
-	}
+	return RateLimit(NewRateLimiter(5, 15*time.Minute), KeyByIP, "too_many_login_attempts")
 }
 
 // PasswordResetRateLimiter returns a rate limiter for password reset requests
 // Default: 3 attempts per hour per IP
 func PasswordResetRateLimiter() gin.HandlerFunc {
-	limiter := NewRateLimiter(3, time.Hour)
-
-	return func(c *gin.Context) {
-		clientIP := c.ClientIP()
-
-		if !limiter.Allow(clientIP) {
-			c.JSON(http.StatusTooManyRequests, gin.H{
-				"error":       "too_many_reset_attempts",
-				"message":     "Too many password reset attempts. Please try again later.",
-				"retry_after": 3600, // 1 hour in seconds
-			})
-			c.Abort()
-			return
-		}
-
-		c.Next()
-	}
+	return RateLimit(NewRateLimiter(3, time.Hour), KeyByIP, "too_many_reset_attempts")
 }
 
 func min(a, b int) int {
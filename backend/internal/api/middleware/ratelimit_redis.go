@@ -0,0 +1,66 @@
+package middleware
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// slidingWindowScript keeps one sorted set entry per request inside the
+// window. Returns {allowed, remaining, retry_after_ms}.
+var slidingWindowScript = redis.NewScript(`
+local key = KEYS[1]
+local now = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local limit = tonumber(ARGV[3])
+
+redis.call('ZREMRANGEBYSCORE', key, 0, now - window)
+local count = redis.call('ZCARD', key)
+if count < limit then
+	redis.call('ZADD', key, now, ARGV[4])
+	redis.call('PEXPIRE', key, window)
+	return {1, limit - count - 1, 0}
+end
+
+local oldest = redis.call('ZRANGE', key, 0, 0, 'WITHSCORES')
+return {0, 0, window - (now - tonumber(oldest[2]))}
+`)
+
+// RedisLimiter is a sliding window log limiter stored in Redis, so limits
+// hold across all API replicas.
+type RedisLimiter struct {
+	client *redis.Client
+	prefix string
+	rate   int
+	window time.Duration
+}
+
+// NewRedisLimiter creates a Redis-backed limiter allowing rate requests per window
+func NewRedisLimiter(client *redis.Client, name string, rate int, window time.Duration) *RedisLimiter {
+	return &RedisLimiter{
+		client: client,
+		prefix: "kubeatlas:ratelimit:" + name + ":",
+		rate:   rate,
+		window: window,
+	}
+}
+
+// Take implements Limiter
+func (rl *RedisLimiter) Take(ctx context.Context, key string) (LimitResult, error) {
+	now := time.Now().UnixMilli()
+	res, err := slidingWindowScript.Run(ctx, rl.client, []string{rl.prefix + key},
+		now, rl.window.Milliseconds(), rl.rate, uuid.NewString(),
+	).Int64Slice()
+	if err != nil {
+		return LimitResult{}, err
+	}
+
+	return LimitResult{
+		Allowed:    res[0] == 1,
+		Limit:      rl.rate,
+		Remaining:  int(res[1]),
+		RetryAfter: time.Duration(res[2]) * time.Millisecond,
+	}, nil
+}
@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestRateLimiterTake(t *testing.T) {
+	rl := &RateLimiter{requests: make(map[string]*clientLimit), rate: 3, window: 3 * time.Second}
+	ctx := context.Background()
+
+	for i := 2; i >= 0; i-- {
+		res, err := rl.Take(ctx, "client")
+		if err != nil {
+			t.Fatalf("Take() error = %v", err)
+		}
+		if !res.Allowed || res.Remaining != i {
+			t.Fatalf("request %d: got allowed=%v remaining=%d, want allowed with %d remaining", 3-i, res.Allowed, res.Remaining, i)
+		}
+	}
+
+	res, _ := rl.Take(ctx, "client")
+	if res.Allowed {
+		t.Fatal("fourth request should be denied")
+	}
+	if res.RetryAfter <= 0 || res.RetryAfter > time.Second {
+		t.Errorf("RetryAfter = %v, want within one refill interval", res.RetryAfter)
+	}
+
+	// Other clients have their own bucket
+	if res, _ := rl.Take(ctx, "other"); !res.Allowed {
+		t.Error("other client should be allowed")
+	}
+
+	// One token comes back after window/rate
+	rl.requests["client"].lastCheck = time.Now().Add(-time.Second)
+	if res, _ := rl.Take(ctx, "client"); !res.Allowed {
+		t.Error("request after refill interval should be allowed")
+	}
+}
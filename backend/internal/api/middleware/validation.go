@@ -192,14 +192,6 @@ func InputSanitizer() gin.HandlerFunc {
 	}
 }
 
-// MaxBodySize limits the request body size
-func MaxBodySize(maxBytes int64) gin.HandlerFunc {
-	return func(c *gin.Context) {
-		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxBytes)
-		c.Next()
-	}
-}
-
 // ContentTypeJSON ensures request content type is JSON for POST/PUT/PATCH
 func ContentTypeJSON() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -0,0 +1,318 @@
+// Package apiclient is a client for the KubeAtlas REST API, used by the
+// kubeatlas CLI
+package apiclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// apiPrefix is the path of the versioned API below the server URL
+const apiPrefix = "/api/v1"
+
+// Error is an error response of the API
+type Error struct {
+	StatusCode int
+	Code       string `json:"error"`
+	Message    string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	if e.Message != "" {
+		return fmt.Sprintf("%s (HTTP %d)", e.Message, e.StatusCode)
+	}
+	if e.Code != "" {
+		return fmt.Sprintf("%s (HTTP %d)", e.Code, e.StatusCode)
+	}
+	return fmt.Sprintf("HTTP %d", e.StatusCode)
+}
+
+// Client calls the API of one KubeAtlas server
+type Client struct {
+	// Server is the server URL, e.g. https://kubeatlas.example.com
+	Server string
+	// Token is the access token sent with requests
+	Token string
+	HTTP  *http.Client
+}
+
+// New returns a client for server
+func New(server, token string) *Client {
+	return &Client{
+		Server: strings.TrimRight(server, "/"),
+		Token:  token,
+		HTTP:   &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// Tokens are the tokens issued by login and refresh
+type Tokens struct {
+	AccessToken  string    `json:"access_token"`
+	RefreshToken string    `json:"refresh_token"`
+	ExpiresAt    time.Time `json:"expires_at"`
+	TokenType    string    `json:"token_type"`
+}
+
+// User is the user that logged in
+type User struct {
+	ID       string `json:"id"`
+	Email    string `json:"email"`
+	FullName string `json:"full_name"`
+	Role     string `json:"role"`
+}
+
+// LoginResponse is the response to a login
+type LoginResponse struct {
+	Tokens Tokens `json:"tokens"`
+	User   User   `json:"user"`
+}
+
+// Page is one page of a list
+type Page struct {
+	Items      []map[string]interface{} `json:"items"`
+	Total      int64                    `json:"total"`
+	Page       int                      `json:"page"`
+	PageSize   int                      `json:"page_size"`
+	TotalPages int                      `json:"total_pages"`
+}
+
+// Login authenticates with email and password
+func (c *Client) Login(ctx context.Context, email, password string) (*LoginResponse, error) {
+	var resp LoginResponse
+	body := map[string]string{"email": email, "password": password}
+	if err := c.do(ctx, http.MethodPost, "/auth/login", nil, body, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Refresh issues new tokens for a refresh token
+func (c *Client) Refresh(ctx context.Context, refreshToken string) (*Tokens, error) {
+	var tokens Tokens
+	body := map[string]string{"refresh_token": refreshToken}
+	if err := c.do(ctx, http.MethodPost, "/auth/refresh", nil, body, &tokens); err != nil {
+		return nil, err
+	}
+	return &tokens, nil
+}
+
+// ListNamespaces lists namespaces matching the API's list filters
+func (c *Client) ListNamespaces(ctx context.Context, query url.Values) (*Page, error) {
+	var page Page
+	if err := c.do(ctx, http.MethodGet, "/namespaces", query, nil, &page); err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+// UpdateNamespace updates the fields of a namespace that are set in update.
+// Fields left out are not changed.
+func (c *Client) UpdateNamespace(ctx context.Context, id string, update map[string]interface{}) (map[string]interface{}, error) {
+	var resp struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	if err := c.do(ctx, http.MethodPut, "/namespaces/"+url.PathEscape(id), nil, update, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Data, nil
+}
+
+// NamespaceOwnership is the ownership of one namespace, keyed by cluster and
+// namespace name. Fields left empty are not changed.
+type NamespaceOwnership struct {
+	Cluster   string `json:"cluster"`
+	Namespace string `json:"namespace"`
+
+	OwnerTeam    string `json:"owner_team,omitempty"`
+	BusinessUnit string `json:"business_unit,omitempty"`
+	Criticality  string `json:"criticality,omitempty"`
+
+	ApplicationManagerName  string `json:"application_manager_name,omitempty"`
+	ApplicationManagerEmail string `json:"application_manager_email,omitempty"`
+	ApplicationManagerPhone string `json:"application_manager_phone,omitempty"`
+	TechnicalLeadName       string `json:"technical_lead_name,omitempty"`
+	TechnicalLeadEmail      string `json:"technical_lead_email,omitempty"`
+	ProjectManagerName      string `json:"project_manager_name,omitempty"`
+	ProjectManagerEmail     string `json:"project_manager_email,omitempty"`
+
+	SLAAvailability string `json:"sla_availability,omitempty"`
+	SLARTO          string `json:"sla_rto,omitempty"`
+	SLARPO          string `json:"sla_rpo,omitempty"`
+	SupportHours    string `json:"support_hours,omitempty"`
+	EscalationPath  string `json:"escalation_path,omitempty"`
+}
+
+// FieldChange is the before and after value of a field
+type FieldChange struct {
+	Field  string      `json:"field"`
+	Change string      `json:"change"`
+	Before interface{} `json:"before,omitempty"`
+	After  interface{} `json:"after,omitempty"`
+}
+
+// OwnershipImportResult is the outcome of an import for one namespace
+type OwnershipImportResult struct {
+	Cluster     string        `json:"cluster"`
+	Namespace   string        `json:"namespace"`
+	NamespaceID string        `json:"namespace_id,omitempty"`
+	Status      string        `json:"status"`
+	Changes     []FieldChange `json:"changes,omitempty"`
+	Error       string        `json:"error,omitempty"`
+}
+
+// OwnershipImport is the outcome of an ownership import
+type OwnershipImport struct {
+	DryRun    bool                    `json:"dry_run"`
+	Updated   int                     `json:"updated"`
+	Unchanged int                     `json:"unchanged"`
+	Failed    int                     `json:"failed"`
+	Results   []OwnershipImportResult `json:"results"`
+}
+
+// ImportOwnership sets the ownership of namespaces. With dryRun the changes
+// are reported but not made.
+func (c *Client) ImportOwnership(ctx context.Context, namespaces []NamespaceOwnership, dryRun bool) (*OwnershipImport, error) {
+	var resp struct {
+		Data OwnershipImport `json:"data"`
+	}
+	body := map[string]interface{}{"dry_run": dryRun, "namespaces": namespaces}
+	if err := c.do(ctx, http.MethodPost, "/namespaces/ownership", nil, body, &resp); err != nil {
+		return nil, err
+	}
+	return &resp.Data, nil
+}
+
+// NamespaceCheck reports whether a namespace has the metadata its
+// organization requires
+type NamespaceCheck struct {
+	NamespaceID string   `json:"namespace_id"`
+	Cluster     string   `json:"cluster"`
+	Namespace   string   `json:"namespace"`
+	Complete    bool     `json:"complete"`
+	Required    []string `json:"required"`
+	Missing     []string `json:"missing"`
+	Policies    []string `json:"policies"`
+}
+
+// CheckNamespace checks a namespace against its organization's metadata
+// requirements
+func (c *Client) CheckNamespace(ctx context.Context, cluster, namespace string) (*NamespaceCheck, error) {
+	var resp struct {
+		Data NamespaceCheck `json:"data"`
+	}
+	q := url.Values{"cluster": {cluster}, "namespace": {namespace}}
+	if err := c.do(ctx, http.MethodGet, "/namespaces/check", q, nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp.Data, nil
+}
+
+// MappingRule sets a namespace field from a label or annotation of the
+// namespace
+type MappingRule struct {
+	Source string `json:"source"`
+	Key    string `json:"key"`
+	Value  string `json:"value,omitempty"`
+	Field  string `json:"field"`
+}
+
+// AdmissionRequirement is a required piece of metadata, with the rules
+// that set it from labels and annotations
+type AdmissionRequirement struct {
+	Requirement string        `json:"requirement"`
+	Rules       []MappingRule `json:"rules"`
+}
+
+// AdmissionPolicy is what admission webhooks require of new namespaces
+type AdmissionPolicy struct {
+	Requirements []AdmissionRequirement `json:"requirements"`
+	Unenforced   []string               `json:"unenforced"`
+}
+
+// AdmissionPolicy gets the labels and annotations new namespaces need to
+// meet the organization's metadata requirements
+func (c *Client) AdmissionPolicy(ctx context.Context) (*AdmissionPolicy, error) {
+	var resp struct {
+		Data AdmissionPolicy `json:"data"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/namespaces/admission-policy", nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp.Data, nil
+}
+
+// ListClusters lists clusters matching the API's list filters
+func (c *Client) ListClusters(ctx context.Context, query url.Values) (*Page, error) {
+	var page Page
+	if err := c.do(ctx, http.MethodGet, "/clusters", query, nil, &page); err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+// SyncCluster starts a sync of a cluster
+func (c *Client) SyncCluster(ctx context.Context, id string) error {
+	return c.do(ctx, http.MethodPost, "/clusters/"+url.PathEscape(id)+"/sync", nil, nil, nil)
+}
+
+// do sends a request and decodes the response into out. Most endpoints wrap
+// their result in a "data" field, while auth and list endpoints do not.
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, body, out interface{}) error {
+	u := c.Server + apiPrefix + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+
+	var reqBody io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reqBody = bytes.NewReader(b)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u, reqBody)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(io.LimitReader(resp.Body, 32<<20))
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode >= 300 {
+		apiErr := &Error{StatusCode: resp.StatusCode}
+		_ = json.Unmarshal(respBody, apiErr)
+		return apiErr
+	}
+
+	if out == nil {
+		return nil
+	}
+	if err := json.Unmarshal(respBody, out); err != nil {
+		return fmt.Errorf("decoding response: %w", err)
+	}
+	return nil
+}
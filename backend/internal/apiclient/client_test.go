@@ -0,0 +1,58 @@
+package apiclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestClient(t *testing.T) {
+	var gotAuth, gotQuery string
+	var gotUpdate map[string]interface{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAuth = r.Header.Get("Authorization")
+		switch r.Method + " " + r.URL.Path {
+		case "GET /api/v1/namespaces":
+			gotQuery = r.URL.RawQuery
+			w.Write([]byte(`{"items":[{"id":"1","name":"payments"}],"total":1,"page":1,"page_size":20,"total_pages":1}`))
+		case "PUT /api/v1/namespaces/1":
+			json.NewDecoder(r.Body).Decode(&gotUpdate)
+			w.Write([]byte(`{"data":{"id":"1","name":"payments"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error":"Not Found","message":"Cluster not found"}`))
+		}
+	}))
+	defer srv.Close()
+
+	ctx := context.Background()
+	c := New(srv.URL+"/", "token")
+
+	page, err := c.ListNamespaces(ctx, url.Values{"environment": {"production"}})
+	if err != nil {
+		t.Fatalf("ListNamespaces failed: %v", err)
+	}
+	if len(page.Items) != 1 || page.Items[0]["name"] != "payments" || page.Total != 1 {
+		t.Errorf("ListNamespaces = %+v", page)
+	}
+	if gotQuery != "environment=production" || gotAuth != "Bearer token" {
+		t.Errorf("request query %q, authorization %q", gotQuery, gotAuth)
+	}
+
+	ns, err := c.UpdateNamespace(ctx, "1", map[string]interface{}{"business_unit_id": "bu"})
+	if err != nil {
+		t.Fatalf("UpdateNamespace failed: %v", err)
+	}
+	if ns["id"] != "1" || gotUpdate["business_unit_id"] != "bu" || len(gotUpdate) != 1 {
+		t.Errorf("UpdateNamespace = %v, sent %v", ns, gotUpdate)
+	}
+
+	var apiErr *Error
+	if err := c.SyncCluster(ctx, "2"); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound || apiErr.Message != "Cluster not found" {
+		t.Errorf("SyncCluster = %v, want the API error", err)
+	}
+}
@@ -0,0 +1,135 @@
+// Package backstage reads Backstage software catalog entities, as found in
+// catalog-info.yaml files
+package backstage
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/yaml"
+)
+
+// Entity kinds KubeAtlas imports
+const (
+	KindComponent = "component"
+	KindResource  = "resource"
+	KindSystem    = "system"
+	KindGroup     = "group"
+)
+
+// Annotations naming the Kubernetes namespace of a component or resource.
+// AnnotationNamespace is the one read by the Backstage Kubernetes plugin.
+const (
+	AnnotationNamespace = "backstage.io/kubernetes-namespace"
+	AnnotationCluster   = "kubeatlas.io/cluster"
+)
+
+// Entity is a catalog entity. Only the fields KubeAtlas imports are read.
+type Entity struct {
+	APIVersion string   `json:"apiVersion"`
+	Kind       string   `json:"kind"`
+	Metadata   Metadata `json:"metadata"`
+	Spec       Spec     `json:"spec"`
+}
+
+// Metadata is the metadata of an entity
+type Metadata struct {
+	Name        string            `json:"name"`
+	Namespace   string            `json:"namespace"`
+	Title       string            `json:"title"`
+	Description string            `json:"description"`
+	Annotations map[string]string `json:"annotations"`
+}
+
+// Spec holds the spec fields of the kinds KubeAtlas imports
+type Spec struct {
+	Type      string   `json:"type"`
+	Owner     string   `json:"owner"`
+	System    string   `json:"system"`
+	DependsOn []string `json:"dependsOn"`
+	Profile   Profile  `json:"profile"`
+}
+
+// Profile is the profile of a group
+type Profile struct {
+	DisplayName string `json:"displayName"`
+	Email       string `json:"email"`
+}
+
+// Ref is a reference to an entity, kind:namespace/name
+type Ref struct {
+	Kind      string
+	Namespace string
+	Name      string
+}
+
+func (r Ref) String() string {
+	return r.Kind + ":" + r.Namespace + "/" + r.Name
+}
+
+// Ref returns the reference to e
+func (e *Entity) Ref() Ref {
+	ns := e.Metadata.Namespace
+	if ns == "" {
+		ns = "default"
+	}
+	return Ref{Kind: strings.ToLower(e.Kind), Namespace: strings.ToLower(ns), Name: strings.ToLower(e.Metadata.Name)}
+}
+
+// DisplayName returns the title of e, or its name if it has none
+func (e *Entity) DisplayName() string {
+	if e.Kind != "" && strings.EqualFold(e.Kind, KindGroup) && e.Spec.Profile.DisplayName != "" {
+		return e.Spec.Profile.DisplayName
+	}
+	if e.Metadata.Title != "" {
+		return e.Metadata.Title
+	}
+	return e.Metadata.Name
+}
+
+// ParseRef parses an entity reference such as component:default/payments,
+// group:platform or payments. Parts left out default to defaultKind and the
+// default namespace. References compare case-insensitively, so they are
+// lowercased.
+func ParseRef(ref, defaultKind string) (Ref, error) {
+	r := Ref{Kind: defaultKind, Namespace: "default"}
+	rest := strings.TrimSpace(ref)
+	if kind, name, ok := strings.Cut(rest, ":"); ok {
+		r.Kind, rest = kind, name
+	}
+	if ns, name, ok := strings.Cut(rest, "/"); ok {
+		r.Namespace, rest = ns, name
+	}
+	r.Name = rest
+	if r.Kind == "" || r.Namespace == "" || r.Name == "" {
+		return Ref{}, fmt.Errorf("invalid entity reference %q", ref)
+	}
+	r.Kind, r.Namespace, r.Name = strings.ToLower(r.Kind), strings.ToLower(r.Namespace), strings.ToLower(r.Name)
+	return r, nil
+}
+
+// Parse reads the entities of a YAML stream of one or more documents, or of
+// JSON. Empty documents are skipped.
+func Parse(r io.Reader) ([]Entity, error) {
+	dec := yaml.NewYAMLOrJSONDecoder(r, 4096)
+	entities := make([]Entity, 0)
+	for {
+		var e Entity
+		err := dec.Decode(&e)
+		if errors.Is(err, io.EOF) {
+			return entities, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if e.Kind == "" && e.Metadata.Name == "" {
+			continue
+		}
+		if e.Kind == "" || e.Metadata.Name == "" {
+			return nil, fmt.Errorf("entity %d has no kind or metadata.name", len(entities)+1)
+		}
+		entities = append(entities, e)
+	}
+}
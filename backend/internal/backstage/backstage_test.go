@@ -0,0 +1,75 @@
+package backstage
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	catalog := `
+apiVersion: backstage.io/v1alpha1
+kind: Group
+metadata:
+  name: Payments
+spec:
+  type: team
+  profile:
+    displayName: Payments Team
+    email: payments@example.com
+---
+---
+apiVersion: backstage.io/v1alpha1
+kind: Component
+metadata:
+  name: checkout
+  annotations:
+    backstage.io/kubernetes-namespace: checkout-prod
+spec:
+  owner: group:payments
+  system: retail
+  dependsOn:
+    - resource:orders-db
+`
+	entities, err := Parse(strings.NewReader(catalog))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if len(entities) != 2 {
+		t.Fatalf("Parse returned %d entities, want 2", len(entities))
+	}
+
+	group := entities[0]
+	if group.Ref().String() != "group:default/payments" || group.DisplayName() != "Payments Team" || group.Spec.Profile.Email != "payments@example.com" {
+		t.Errorf("group = %+v", group)
+	}
+	component := entities[1]
+	if component.Metadata.Annotations[AnnotationNamespace] != "checkout-prod" || len(component.Spec.DependsOn) != 1 || component.DisplayName() != "checkout" {
+		t.Errorf("component = %+v", component)
+	}
+
+	if _, err := Parse(strings.NewReader("kind: Component\nmetadata: {}\n")); err == nil {
+		t.Error("Parse of an entity without a name succeeded")
+	}
+}
+
+func TestParseRef(t *testing.T) {
+	tests := []struct {
+		ref, defaultKind, want string
+	}{
+		{"component:default/payments", "group", "component:default/payments"},
+		{"Group:Platform", "component", "group:default/platform"},
+		{"team-a/payments", "group", "group:team-a/payments"},
+		{"payments", "component", "component:default/payments"},
+	}
+	for _, tt := range tests {
+		got, err := ParseRef(tt.ref, tt.defaultKind)
+		if err != nil || got.String() != tt.want {
+			t.Errorf("ParseRef(%q) = %v, %v, want %s", tt.ref, got, err, tt.want)
+		}
+	}
+	for _, ref := range []string{"", "component:", "group:default/"} {
+		if _, err := ParseRef(ref, "group"); err == nil {
+			t.Errorf("ParseRef(%q) succeeded", ref)
+		}
+	}
+}
@@ -5,12 +5,15 @@ import (
 	"os"
 	"strconv"
 	"strings"
+	"time"
 )
 
 var (
 	ErrMissingJWTSecret     = errors.New("JWT_SECRET environment variable is required and must be at least 32 characters")
 	ErrMissingEncryptionKey = errors.New("ENCRYPTION_KEY environment variable is required for production mode")
 	ErrMissingDBPassword    = errors.New("DB_PASSWORD environment variable is required for production mode")
+	ErrInvalidRateLimit     = errors.New("rate limits need at least one request per window and a window of at least one second")
+	ErrInvalidBodyLimit     = errors.New("request body limits must be at least 1 MB")
 )
 
 // Config holds all configuration for the application
@@ -21,9 +24,17 @@ type Config struct {
 	Storage    StorageConfig
 	LDAP       LDAPConfig
 	Redis      RedisConfig
+	RateLimit  RateLimitConfig
+	BodyLimit  BodyLimitConfig
 	Encryption EncryptionConfig
 	Sync       SyncConfig
+	Audit      AuditConfig
+	Tracing    TracingConfig
+	Sentry     SentryConfig
+	Health     HealthConfig
 	Log        LogConfig
+	SIEM       SIEMConfig
+	Search     SearchConfig
 }
 
 // ServerConfig holds HTTP server configuration
@@ -31,6 +42,11 @@ type ServerConfig struct {
 	Port        int
 	Mode        string // "debug" or "release"
 	CORSOrigins []string
+
+	// DebugPort serves pprof and expvar on a separate listener; 0 disables it
+	DebugPort int
+	// DebugHost is the interface the debug listener binds, loopback by default
+	DebugHost string
 }
 
 // DatabaseConfig holds database configuration
@@ -42,6 +58,9 @@ type DatabaseConfig struct {
 	Database string
 	SSLMode  string
 	MaxConns int
+
+	// ReadReplicaDSN is an optional connection string for a read-only replica
+	ReadReplicaDSN string
 }
 
 // JWTConfig holds JWT configuration
@@ -53,11 +72,13 @@ type JWTConfig struct {
 
 // StorageConfig holds file storage configuration
 type StorageConfig struct {
-	Type       string // "local", "s3", "minio"
-	LocalPath  string
-	S3Bucket   string
-	S3Region   string
-	S3Endpoint string
+	Type        string // "local", "s3", "minio"
+	LocalPath   string
+	S3Bucket    string
+	S3Region    string
+	S3Endpoint  string
+	S3AccessKey string
+	S3SecretKey string
 }
 
 // LDAPConfig holds LDAP/AD configuration
@@ -79,6 +100,28 @@ type RedisConfig struct {
 	Enabled  bool
 }
 
+// RateLimitRule allows Requests per Window for each client
+type RateLimitRule struct {
+	Requests int
+	Window   time.Duration
+}
+
+// RateLimitConfig holds per-route-group rate limits. Limits are shared across
+// replicas through Redis when it is enabled, otherwise kept in memory.
+type RateLimitConfig struct {
+	API    RateLimitRule
+	Login  RateLimitRule
+	Upload RateLimitRule
+}
+
+// BodyLimitConfig holds per-route-group request body limits, in bytes.
+// Compressed bodies are limited once decompressed.
+type BodyLimitConfig struct {
+	JSON   int64 // every route without a limit of its own
+	Import int64 // catalog, CSV, ownership and backup imports
+	Upload int64 // document uploads, also limited by the organization's upload settings
+}
+
 // EncryptionConfig holds encryption settings
 type EncryptionConfig struct {
 	Key string
@@ -86,14 +129,93 @@ type EncryptionConfig struct {
 
 // SyncConfig holds sync settings
 type SyncConfig struct {
-	IntervalMinutes int
-	TimeoutSeconds  int
+	IntervalMinutes    int
+	TimeoutSeconds     int
+	ClientCacheMinutes int // cached Kubernetes clients are rebuilt after this long
+}
+
+// AuditConfig holds audit log settings
+type AuditConfig struct {
+	RetentionMonths int // 0 keeps audit logs forever
+
+	// Archive writes expired months to object storage as compressed NDJSON
+	// before their partitions are dropped
+	Archive bool
+
+	// ObjectLockBucket is the S3 bucket, with Object Lock enabled, holding
+	// the append-only audit logs of organizations that select object lock
+	// storage; empty uses the storage bucket
+	ObjectLockBucket string
+}
+
+// SIEMConfig configures forwarding of audit events to a SIEM
+type SIEMConfig struct {
+	Sink                 string // "", "syslog", "splunk" or "https"
+	Network              string // syslog sink: "udp" or "tcp"
+	Address              string // syslog sink host:port
+	Tag                  string
+	URL                  string // splunk sink: HEC base URL; https sink: endpoint
+	Token                string // splunk sink: HEC token; https sink: optional bearer token
+	TimeoutSeconds       int
+	QueueSize            int // events held while the SIEM catches up; more are dropped
+	BatchSize            int
+	FlushIntervalSeconds int
+	MaxRetries           int
+}
+
+// SearchConfig selects the search backend. Postgres answers searches unless
+// an OpenSearch or Elasticsearch cluster is configured, which a background
+// job keeps indexed.
+type SearchConfig struct {
+	Backend              string // "postgres" or "opensearch", which also speaks to Elasticsearch
+	URL                  string
+	Index                string
+	Username             string
+	Password             string
+	TimeoutSeconds       int
+	IndexIntervalMinutes int
+}
+
+// TracingConfig holds OpenTelemetry tracing configuration
+type TracingConfig struct {
+	Enabled     bool
+	Endpoint    string // OTLP/HTTP collector host:port
+	Insecure    bool
+	ServiceName string
+	SampleRatio float64
+}
+
+// SentryConfig holds error reporting configuration; an empty DSN disables it
+type SentryConfig struct {
+	DSN         string
+	Environment string
+	SampleRate  float64
+}
+
+// HealthConfig holds readiness check configuration
+type HealthConfig struct {
+	TimeoutSeconds int
+	ClusterIDs     []string // clusters probed by /ready; failures degrade rather than fail readiness
 }
 
 // LogConfig holds logging configuration
 type LogConfig struct {
 	Level  string
 	Format string
+	Access AccessLogConfig
+}
+
+// AccessLogConfig configures an additional JSON access log sink
+type AccessLogConfig struct {
+	Sink       string // "", "file" or "syslog"
+	Path       string // file sink
+	MaxSizeMB  int    // rotate after this size
+	MaxBackups int
+	MaxAgeDays int
+	Compress   bool
+	Network    string // syslog sink: "udp" or "tcp"
+	Address    string // syslog sink host:port
+	Tag        string
 }
 
 // Load loads configuration from environment variables
@@ -104,6 +226,8 @@ func Load() (*Config, error) {
 			Port:        getEnvInt("SERVER_PORT", 8080),
 			Mode:        getEnvDefault([]string{"SERVER_MODE", "GIN_MODE"}, "debug"),
 			CORSOrigins: getEnvSlice("CORS_ORIGINS", []string{"http://localhost:3000"}),
+			DebugPort:   getEnvInt("DEBUG_PORT", 0),
+			DebugHost:   getEnv("DEBUG_HOST", "127.0.0.1"),
 		},
 		Database: DatabaseConfig{
 			Host:     getEnvDefault([]string{"DB_HOST", "DATABASE_HOST"}, "localhost"),
@@ -113,6 +237,8 @@ func Load() (*Config, error) {
 			Database: getEnvDefault([]string{"DB_NAME", "DATABASE_NAME"}, "kubeatlas"),
 			SSLMode:  getEnvDefault([]string{"DB_SSLMODE", "DATABASE_SSL_MODE"}, "disable"),
 			MaxConns: getEnvIntDefault([]string{"DB_MAX_CONNS", "DATABASE_MAX_CONNECTIONS"}, 25),
+
+			ReadReplicaDSN: getEnvDefault([]string{"DB_READ_REPLICA_DSN", "DATABASE_READ_REPLICA_URL"}, ""),
 		},
 		JWT: JWTConfig{
 			Secret:          getEnv("JWT_SECRET", ""),
@@ -120,11 +246,13 @@ func Load() (*Config, error) {
 			RefreshHours:    getEnvIntDefault([]string{"JWT_REFRESH_HOURS", "JWT_REFRESH_TOKEN_HOURS"}, 168),
 		},
 		Storage: StorageConfig{
-			Type:       getEnv("STORAGE_TYPE", "local"),
-			LocalPath:  getEnv("STORAGE_LOCAL_PATH", "./data/uploads"),
-			S3Bucket:   getEnv("STORAGE_S3_BUCKET", ""),
-			S3Region:   getEnv("STORAGE_S3_REGION", ""),
-			S3Endpoint: getEnv("STORAGE_S3_ENDPOINT", ""),
+			Type:        getEnv("STORAGE_TYPE", "local"),
+			LocalPath:   getEnv("STORAGE_LOCAL_PATH", "./data/uploads"),
+			S3Bucket:    getEnv("STORAGE_S3_BUCKET", ""),
+			S3Region:    getEnv("STORAGE_S3_REGION", ""),
+			S3Endpoint:  getEnv("STORAGE_S3_ENDPOINT", ""),
+			S3AccessKey: getEnvDefault([]string{"STORAGE_S3_ACCESS_KEY_ID", "AWS_ACCESS_KEY_ID"}, ""),
+			S3SecretKey: getEnvDefault([]string{"STORAGE_S3_SECRET_ACCESS_KEY", "AWS_SECRET_ACCESS_KEY"}, ""),
 		},
 		LDAP: LDAPConfig{
 			Enabled:      getEnvBool("LDAP_ENABLED", false),
@@ -141,19 +269,96 @@ func Load() (*Config, error) {
 			Password: getEnv("REDIS_PASSWORD", ""),
 			Enabled:  getEnv("REDIS_HOST", "") != "",
 		},
+		RateLimit: RateLimitConfig{
+			API:    getEnvRateLimit("RATE_LIMIT_API", 100, time.Minute),
+			Login:  getEnvRateLimit("RATE_LIMIT_LOGIN", 5, 15*time.Minute),
+			Upload: getEnvRateLimit("RATE_LIMIT_UPLOAD", 20, time.Minute),
+		},
+		BodyLimit: BodyLimitConfig{
+			JSON:   int64(getEnvInt("BODY_LIMIT_JSON_MB", 1)) << 20,
+			Import: int64(getEnvInt("BODY_LIMIT_IMPORT_MB", 10)) << 20,
+			// The largest upload limit an organization can set, and the form
+			Upload: int64(getEnvInt("BODY_LIMIT_UPLOAD_MB", 1025)) << 20,
+		},
 		Encryption: EncryptionConfig{
 			Key: getEnv("ENCRYPTION_KEY", ""),
 		},
 		Sync: SyncConfig{
-			IntervalMinutes: getEnvInt("SYNC_INTERVAL_MINUTES", 30),
-			TimeoutSeconds:  getEnvInt("SYNC_TIMEOUT_SECONDS", 300),
+			IntervalMinutes:    getEnvInt("SYNC_INTERVAL_MINUTES", 30),
+			TimeoutSeconds:     getEnvInt("SYNC_TIMEOUT_SECONDS", 300),
+			ClientCacheMinutes: getEnvInt("K8S_CLIENT_CACHE_MINUTES", 30),
+		},
+		Audit: AuditConfig{
+			RetentionMonths:  getEnvInt("AUDIT_RETENTION_MONTHS", 0),
+			Archive:          getEnvBool("AUDIT_ARCHIVE_ENABLED", false),
+			ObjectLockBucket: getEnv("AUDIT_OBJECT_LOCK_BUCKET", ""),
+		},
+		SIEM: SIEMConfig{
+			Sink:                 getEnv("SIEM_SINK", ""),
+			Network:              getEnv("SIEM_SYSLOG_NETWORK", "tcp"),
+			Address:              getEnv("SIEM_SYSLOG_ADDRESS", ""),
+			Tag:                  getEnv("SIEM_SYSLOG_TAG", "kubeatlas-audit"),
+			URL:                  getEnv("SIEM_URL", ""),
+			Token:                getEnv("SIEM_TOKEN", ""),
+			TimeoutSeconds:       getEnvInt("SIEM_TIMEOUT_SECONDS", 10),
+			QueueSize:            getEnvInt("SIEM_QUEUE_SIZE", 10000),
+			BatchSize:            getEnvInt("SIEM_BATCH_SIZE", 100),
+			FlushIntervalSeconds: getEnvInt("SIEM_FLUSH_INTERVAL_SECONDS", 2),
+			MaxRetries:           getEnvInt("SIEM_MAX_RETRIES", 3),
+		},
+		Search: SearchConfig{
+			Backend:              getEnv("SEARCH_BACKEND", "postgres"),
+			URL:                  getEnv("SEARCH_URL", ""),
+			Index:                getEnv("SEARCH_INDEX", "kubeatlas"),
+			Username:             getEnv("SEARCH_USERNAME", ""),
+			Password:             getEnv("SEARCH_PASSWORD", ""),
+			TimeoutSeconds:       getEnvInt("SEARCH_TIMEOUT_SECONDS", 10),
+			IndexIntervalMinutes: getEnvInt("SEARCH_INDEX_INTERVAL_MINUTES", 10),
+		},
+		Tracing: TracingConfig{
+			Enabled:     getEnvBool("OTEL_ENABLED", false),
+			Endpoint:    getEnv("OTEL_ENDPOINT", ""),
+			Insecure:    getEnvBool("OTEL_INSECURE", false),
+			ServiceName: getEnv("OTEL_SERVICE_NAME", "kubeatlas-api"),
+			SampleRatio: getEnvFloat("OTEL_SAMPLE_RATIO", 1.0),
+		},
+		Sentry: SentryConfig{
+			DSN:         getEnv("SENTRY_DSN", ""),
+			Environment: getEnv("SENTRY_ENVIRONMENT", "production"),
+			SampleRate:  getEnvFloat("SENTRY_SAMPLE_RATE", 1.0),
+		},
+		Health: HealthConfig{
+			TimeoutSeconds: getEnvInt("READY_CHECK_TIMEOUT_SECONDS", 5),
+			ClusterIDs:     getEnvSlice("READY_CHECK_CLUSTERS", nil),
 		},
 		Log: LogConfig{
 			Level:  getEnv("LOG_LEVEL", "info"),
 			Format: getEnv("LOG_FORMAT", "json"),
+			Access: AccessLogConfig{
+				Sink:       getEnv("ACCESS_LOG_SINK", ""),
+				Path:       getEnv("ACCESS_LOG_PATH", "./logs/access.log"),
+				MaxSizeMB:  getEnvInt("ACCESS_LOG_MAX_SIZE_MB", 100),
+				MaxBackups: getEnvInt("ACCESS_LOG_MAX_BACKUPS", 10),
+				MaxAgeDays: getEnvInt("ACCESS_LOG_MAX_AGE_DAYS", 30),
+				Compress:   getEnvBool("ACCESS_LOG_COMPRESS", true),
+				Network:    getEnv("ACCESS_LOG_SYSLOG_NETWORK", "udp"),
+				Address:    getEnv("ACCESS_LOG_SYSLOG_ADDRESS", ""),
+				Tag:        getEnv("ACCESS_LOG_SYSLOG_TAG", "kubeatlas-access"),
+			},
 		},
 	}
 
+	for _, rule := range []RateLimitRule{cfg.RateLimit.API, cfg.RateLimit.Login, cfg.RateLimit.Upload} {
+		if rule.Requests <= 0 || rule.Window <= 0 {
+			return nil, ErrInvalidRateLimit
+		}
+	}
+	for _, limit := range []int64{cfg.BodyLimit.JSON, cfg.BodyLimit.Import, cfg.BodyLimit.Upload} {
+		if limit < 1<<20 {
+			return nil, ErrInvalidBodyLimit
+		}
+	}
+
 	// Security validations for production mode
 	if cfg.Server.Mode == "release" {
 		// JWT Secret is required and must be at least 32 characters
@@ -229,6 +434,23 @@ func getEnvBool(key string, defaultValue bool) bool {
 	return defaultValue
 }
 
+func getEnvFloat(key string, defaultValue float64) float64 {
+	if value := os.Getenv(key); value != "" {
+		if floatVal, err := strconv.ParseFloat(value, 64); err == nil {
+			return floatVal
+		}
+	}
+	return defaultValue
+}
+
+// getEnvRateLimit reads <prefix>_REQUESTS and <prefix>_WINDOW_SECONDS
+func getEnvRateLimit(prefix string, requests int, window time.Duration) RateLimitRule {
+	return RateLimitRule{
+		Requests: getEnvInt(prefix+"_REQUESTS", requests),
+		Window:   time.Duration(getEnvInt(prefix+"_WINDOW_SECONDS", int(window.Seconds()))) * time.Second,
+	}
+}
+
 func getEnvSlice(key string, defaultValue []string) []string {
 	if value := os.Getenv(key); value != "" {
 		return strings.Split(value, ",")
@@ -0,0 +1,257 @@
+// Package confluence creates and updates pages through the Confluence REST
+// API, version 1, which Confluence Cloud, Server and Data Center all serve.
+package confluence
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+var (
+	ErrNotConfigured = errors.New("confluence is not configured")
+	ErrNotFound      = errors.New("confluence returned 404")
+)
+
+// Config holds the credentials of an organization's Confluence integration.
+// BaseURL is the address the REST API is served under, including /wiki on
+// Confluence Cloud. With an email, the API token is sent with basic
+// authentication as Confluence Cloud expects; without one it is sent as a
+// personal access token, as Confluence Server and Data Center expect.
+type Config struct {
+	BaseURL  string
+	Email    string
+	APIToken string
+}
+
+// Page is a Confluence page and its current version
+type Page struct {
+	ID      string
+	Version int
+	URL     string
+}
+
+// Publisher creates and updates pages. Bodies are in the Confluence storage
+// format.
+type Publisher interface {
+	GetPage(ctx context.Context, cfg Config, id string) (*Page, error)
+	FindPage(ctx context.Context, cfg Config, spaceKey, title string) (*Page, error)
+	CreatePage(ctx context.Context, cfg Config, spaceKey, parentID, title, body string) (*Page, error)
+	UpdatePage(ctx context.Context, cfg Config, page *Page, title, body string) (*Page, error)
+	CheckSpace(ctx context.Context, cfg Config, spaceKey string) error
+}
+
+// Client talks to Confluence over HTTPS
+type Client struct {
+	httpClient *http.Client
+}
+
+// NewClient creates a client whose requests give up after timeout
+func NewClient(timeout time.Duration) *Client {
+	return &Client{httpClient: &http.Client{Timeout: timeout}}
+}
+
+type contentRequest struct {
+	ID        string      `json:"id,omitempty"`
+	Type      string      `json:"type"`
+	Title     string      `json:"title"`
+	Space     *keyRef     `json:"space,omitempty"`
+	Ancestors []idRef     `json:"ancestors,omitempty"`
+	Version   *versionRef `json:"version,omitempty"`
+	Body      contentBody `json:"body"`
+}
+
+type keyRef struct {
+	Key string `json:"key"`
+}
+
+type idRef struct {
+	ID string `json:"id"`
+}
+
+type versionRef struct {
+	Number int `json:"number"`
+}
+
+type contentBody struct {
+	Storage storageBody `json:"storage"`
+}
+
+type storageBody struct {
+	Value          string `json:"value"`
+	Representation string `json:"representation"`
+}
+
+type contentResponse struct {
+	ID      string     `json:"id"`
+	Version versionRef `json:"version"`
+	Links   struct {
+		Base  string `json:"base"`
+		WebUI string `json:"webui"`
+	} `json:"_links"`
+}
+
+func (r *contentResponse) page(baseURL string) *Page {
+	base := r.Links.Base
+	if base == "" {
+		base = strings.TrimRight(baseURL, "/")
+	}
+	return &Page{ID: r.ID, Version: r.Version.Number, URL: base + r.Links.WebUI}
+}
+
+// GetPage implements Publisher. It returns nil when the page does not exist,
+// for instance because it was deleted in Confluence.
+func (c *Client) GetPage(ctx context.Context, cfg Config, id string) (*Page, error) {
+	var found contentResponse
+	err := c.do(ctx, cfg, http.MethodGet, "/rest/api/content/"+url.PathEscape(id)+"?expand=version", nil, &found)
+	if errors.Is(err, ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return found.page(cfg.BaseURL), nil
+}
+
+// FindPage implements Publisher. It returns nil when the space has no page
+// with the title.
+func (c *Client) FindPage(ctx context.Context, cfg Config, spaceKey, title string) (*Page, error) {
+	query := url.Values{}
+	query.Set("spaceKey", spaceKey)
+	query.Set("title", title)
+	query.Set("expand", "version")
+
+	var result struct {
+		Results []contentResponse `json:"results"`
+		Links   struct {
+			Base string `json:"base"`
+		} `json:"_links"`
+	}
+	if err := c.do(ctx, cfg, http.MethodGet, "/rest/api/content?"+query.Encode(), nil, &result); err != nil {
+		return nil, err
+	}
+	if len(result.Results) == 0 {
+		return nil, nil
+	}
+	found := result.Results[0]
+	if found.Links.Base == "" {
+		found.Links.Base = result.Links.Base
+	}
+	return found.page(cfg.BaseURL), nil
+}
+
+// CreatePage implements Publisher. Without a parent, the page is created at
+// the top of the space.
+func (c *Client) CreatePage(ctx context.Context, cfg Config, spaceKey, parentID, title, body string) (*Page, error) {
+	req := contentRequest{
+		Type:  "page",
+		Title: title,
+		Space: &keyRef{Key: spaceKey},
+		Body:  contentBody{Storage: storageBody{Value: body, Representation: "storage"}},
+	}
+	if parentID != "" {
+		req.Ancestors = []idRef{{ID: parentID}}
+	}
+
+	var created contentResponse
+	if err := c.do(ctx, cfg, http.MethodPost, "/rest/api/content", req, &created); err != nil {
+		return nil, err
+	}
+	return created.page(cfg.BaseURL), nil
+}
+
+// UpdatePage implements Publisher, replacing the body of page with a new
+// version. page.Version must be the page's current version.
+func (c *Client) UpdatePage(ctx context.Context, cfg Config, page *Page, title, body string) (*Page, error) {
+	req := contentRequest{
+		ID:      page.ID,
+		Type:    "page",
+		Title:   title,
+		Version: &versionRef{Number: page.Version + 1},
+		Body:    contentBody{Storage: storageBody{Value: body, Representation: "storage"}},
+	}
+
+	var updated contentResponse
+	if err := c.do(ctx, cfg, http.MethodPut, "/rest/api/content/"+url.PathEscape(page.ID), req, &updated); err != nil {
+		return nil, err
+	}
+	return updated.page(cfg.BaseURL), nil
+}
+
+// CheckSpace implements Publisher, failing unless the credentials can see
+// the space
+func (c *Client) CheckSpace(ctx context.Context, cfg Config, spaceKey string) error {
+	return c.do(ctx, cfg, http.MethodGet, "/rest/api/space/"+url.PathEscape(spaceKey), nil, nil)
+}
+
+func (c *Client) do(ctx context.Context, cfg Config, method, path string, in, out interface{}) error {
+	if cfg.BaseURL == "" || cfg.APIToken == "" {
+		return ErrNotConfigured
+	}
+
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, strings.TrimRight(cfg.BaseURL, "/")+path, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if cfg.Email != "" {
+		req.SetBasicAuth(cfg.Email, cfg.APIToken)
+	} else {
+		req.Header.Set("Authorization", "Bearer "+cfg.APIToken)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach confluence: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("%w: %s", ErrNotFound, errorMessage(resp.Body))
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("confluence returned %d: %s", resp.StatusCode, errorMessage(resp.Body))
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode confluence response: %w", err)
+	}
+	return nil
+}
+
+// errorMessage reads the message of a Confluence error response, falling
+// back to the start of the body
+func errorMessage(r io.Reader) string {
+	data, _ := io.ReadAll(io.LimitReader(r, 4096))
+	var result struct {
+		Message string `json:"message"`
+	}
+	if err := json.Unmarshal(data, &result); err == nil && result.Message != "" {
+		return result.Message
+	}
+	if len(data) > 512 {
+		data = data[:512]
+	}
+	return strings.TrimSpace(string(data))
+}
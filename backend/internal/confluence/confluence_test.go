@@ -0,0 +1,102 @@
+package confluence
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestClient(t *testing.T) {
+	var gotUser, gotPass, gotBearer string
+	var got contentRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotUser, gotPass, _ = r.BasicAuth()
+		gotBearer = r.Header.Get("Authorization")
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/wiki/rest/api/content":
+			if r.URL.Query().Get("title") != "payments (prod)" {
+				w.Write([]byte(`{"results":[],"_links":{"base":"https://acme.atlassian.net/wiki"}}`))
+				return
+			}
+			w.Write([]byte(`{"results":[{"id":"42","version":{"number":3},"_links":{"webui":"/spaces/OPS/pages/42"}}],"_links":{"base":"https://acme.atlassian.net/wiki"}}`))
+		case r.Method == http.MethodPost && r.URL.Path == "/wiki/rest/api/content":
+			got = contentRequest{}
+			json.NewDecoder(r.Body).Decode(&got)
+			w.Write([]byte(`{"id":"43","version":{"number":1},"_links":{"base":"https://acme.atlassian.net/wiki","webui":"/spaces/OPS/pages/43"}}`))
+		case r.Method == http.MethodGet && r.URL.Path == "/wiki/rest/api/content/42":
+			w.Write([]byte(`{"id":"42","version":{"number":3},"_links":{"base":"https://acme.atlassian.net/wiki","webui":"/spaces/OPS/pages/42"}}`))
+		case r.Method == http.MethodPut && r.URL.Path == "/wiki/rest/api/content/42":
+			got = contentRequest{}
+			json.NewDecoder(r.Body).Decode(&got)
+			w.Write([]byte(`{"id":"42","version":{"number":4},"_links":{"base":"https://acme.atlassian.net/wiki","webui":"/spaces/OPS/pages/42"}}`))
+		case r.URL.Path == "/wiki/rest/api/space/OPS":
+			w.Write([]byte(`{"key":"OPS"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"statusCode":404,"message":"No space with key : NOPE"}`))
+		}
+	}))
+	defer srv.Close()
+
+	c := NewClient(time.Second)
+	ctx := context.Background()
+	cfg := Config{BaseURL: srv.URL + "/wiki/", Email: "bot@example.com", APIToken: "secret"}
+
+	page, err := c.FindPage(ctx, cfg, "OPS", "payments (prod)")
+	if err != nil {
+		t.Fatalf("FindPage() error = %v", err)
+	}
+	if page == nil || page.ID != "42" || page.Version != 3 || page.URL != "https://acme.atlassian.net/wiki/spaces/OPS/pages/42" {
+		t.Errorf("FindPage() = %+v", page)
+	}
+	if gotUser != "bot@example.com" || gotPass != "secret" {
+		t.Errorf("basic auth = %q:%q", gotUser, gotPass)
+	}
+	if missing, err := c.FindPage(ctx, cfg, "OPS", "orders (prod)"); err != nil || missing != nil {
+		t.Errorf("FindPage() of a missing page = %+v, %v", missing, err)
+	}
+
+	if byID, err := c.GetPage(ctx, cfg, "42"); err != nil || byID == nil || byID.Version != 3 {
+		t.Errorf("GetPage() = %+v, %v", byID, err)
+	}
+	if deleted, err := c.GetPage(ctx, cfg, "41"); err != nil || deleted != nil {
+		t.Errorf("GetPage() of a deleted page = %+v, %v", deleted, err)
+	}
+
+	updated, err := c.UpdatePage(ctx, cfg, page, "payments (prod)", "<p>v4</p>")
+	if err != nil {
+		t.Fatalf("UpdatePage() error = %v", err)
+	}
+	if updated.Version != 4 || got.Version == nil || got.Version.Number != 4 || got.Body.Storage.Value != "<p>v4</p>" {
+		t.Errorf("UpdatePage() = %+v, request = %+v", updated, got)
+	}
+
+	// Without an email the token is a personal access token
+	created, err := c.CreatePage(ctx, Config{BaseURL: srv.URL + "/wiki", APIToken: "pat"}, "OPS", "7", "orders (prod)", "<p>new</p>")
+	if err != nil {
+		t.Fatalf("CreatePage() error = %v", err)
+	}
+	if created.ID != "43" || got.Space == nil || got.Space.Key != "OPS" || len(got.Ancestors) != 1 || got.Ancestors[0].ID != "7" {
+		t.Errorf("CreatePage() = %+v, request = %+v", created, got)
+	}
+	if got.Body.Storage.Representation != "storage" {
+		t.Errorf("representation = %q", got.Body.Storage.Representation)
+	}
+	if gotBearer != "Bearer pat" {
+		t.Errorf("Authorization = %q", gotBearer)
+	}
+
+	if err := c.CheckSpace(ctx, cfg, "OPS"); err != nil {
+		t.Errorf("CheckSpace() error = %v", err)
+	}
+	if err := c.CheckSpace(ctx, cfg, "NOPE"); err == nil || !strings.Contains(err.Error(), "No space with key") {
+		t.Errorf("CheckSpace() of a missing space error = %v", err)
+	}
+	if err := c.CheckSpace(ctx, Config{BaseURL: srv.URL}, "OPS"); err != ErrNotConfigured {
+		t.Errorf("CheckSpace() without token error = %v, want ErrNotConfigured", err)
+	}
+}
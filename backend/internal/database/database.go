@@ -9,6 +9,7 @@ import (
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/kubeatlas/kubeatlas/internal/config"
+	"github.com/kubeatlas/kubeatlas/internal/telemetry"
 )
 
 //go:embed migrations/*.sql
@@ -17,7 +18,10 @@ var migrationsFS embed.FS
 // DB wraps the database connection pool
 type DB struct {
 	Pool *pgxpool.Pool
-	cfg  config.DatabaseConfig
+	// ReadPool points at the read replica when one is configured,
+	// otherwise it is the same pool as Pool
+	ReadPool *pgxpool.Pool
+	cfg      config.DatabaseConfig
 }
 
 // New creates a new database connection
@@ -33,18 +37,43 @@ func New(cfg config.DatabaseConfig) (*DB, error) {
 		cfg.MaxConns,
 	)
 
+	pool, err := newPool(connString, cfg.MaxConns)
+	if err != nil {
+		return nil, err
+	}
+
+	readPool := pool
+	if cfg.ReadReplicaDSN != "" {
+		readPool, err = newPool(cfg.ReadReplicaDSN, cfg.MaxConns)
+		if err != nil {
+			pool.Close()
+			return nil, fmt.Errorf("read replica: %w", err)
+		}
+	}
+
+	return &DB{
+		Pool:     pool,
+		ReadPool: readPool,
+		cfg:      cfg,
+	}, nil
+}
+
+func newPool(connString string, maxConns int) (*pgxpool.Pool, error) {
 	poolConfig, err := pgxpool.ParseConfig(connString)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse database config: %w", err)
 	}
 
 	// Connection pool settings
-	poolConfig.MaxConns = int32(cfg.MaxConns)
+	poolConfig.MaxConns = int32(maxConns)
 	poolConfig.MinConns = 2
 	poolConfig.MaxConnLifetime = time.Hour
 	poolConfig.MaxConnIdleTime = 30 * time.Minute
 	poolConfig.HealthCheckPeriod = time.Minute
 
+	// Emit a span per query
+	poolConfig.ConnConfig.Tracer = telemetry.PgxTracer{}
+
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
@@ -55,17 +84,23 @@ func New(cfg config.DatabaseConfig) (*DB, error) {
 
 	// Test connection
 	if err := pool.Ping(ctx); err != nil {
+		pool.Close()
 		return nil, fmt.Errorf("failed to ping database: %w", err)
 	}
 
-	return &DB{
-		Pool: pool,
-		cfg:  cfg,
-	}, nil
+	return pool, nil
+}
+
+// HasReadReplica reports whether reads are served by a separate replica
+func (db *DB) HasReadReplica() bool {
+	return db.ReadPool != nil && db.ReadPool != db.Pool
 }
 
-// Close closes the database connection pool
+// Close closes the database connection pools
 func (db *DB) Close() {
+	if db.HasReadReplica() {
+		db.ReadPool.Close()
+	}
 	if db.Pool != nil {
 		db.Pool.Close()
 	}
@@ -78,81 +113,17 @@ func (db *DB) Ping() error {
 	return db.Pool.Ping(ctx)
 }
 
-// Migrate runs database migrations
+// Migrate applies the migrations that are not applied yet
 func (db *DB) Migrate() error {
 	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
 	defer cancel()
 
-	// Create migrations table if not exists
-	_, err := db.Pool.Exec(ctx, `
-		CREATE TABLE IF NOT EXISTS schema_migrations (
-			version VARCHAR(255) PRIMARY KEY,
-			applied_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
-		)
-	`)
+	m, err := NewMigrator(db.Pool)
 	if err != nil {
-		return fmt.Errorf("failed to create migrations table: %w", err)
-	}
-
-	// Read and apply migrations
-	entries, err := migrationsFS.ReadDir("migrations")
-	if err != nil {
-		return fmt.Errorf("failed to read migrations directory: %w", err)
-	}
-
-	for _, entry := range entries {
-		if entry.IsDir() {
-			continue
-		}
-
-		version := entry.Name()
-
-		// Check if already applied
-		var exists bool
-		err := db.Pool.QueryRow(ctx,
-			"SELECT EXISTS(SELECT 1 FROM schema_migrations WHERE version = $1)",
-			version,
-		).Scan(&exists)
-		if err != nil {
-			return fmt.Errorf("failed to check migration status: %w", err)
-		}
-
-		if exists {
-			continue
-		}
-
-		// Read and execute migration
-		content, err := migrationsFS.ReadFile("migrations/" + version)
-		if err != nil {
-			return fmt.Errorf("failed to read migration %s: %w", version, err)
-		}
-
-		tx, err := db.Pool.Begin(ctx)
-		if err != nil {
-			return fmt.Errorf("failed to begin transaction: %w", err)
-		}
-
-		_, err = tx.Exec(ctx, string(content))
-		if err != nil {
-			tx.Rollback(ctx)
-			return fmt.Errorf("failed to execute migration %s: %w", version, err)
-		}
-
-		_, err = tx.Exec(ctx,
-			"INSERT INTO schema_migrations (version) VALUES ($1)",
-			version,
-		)
-		if err != nil {
-			tx.Rollback(ctx)
-			return fmt.Errorf("failed to record migration %s: %w", version, err)
-		}
-
-		if err := tx.Commit(ctx); err != nil {
-			return fmt.Errorf("failed to commit migration %s: %w", version, err)
-		}
+		return err
 	}
-
-	return nil
+	_, err = m.Up(ctx)
+	return err
 }
 
 // Transaction executes a function within a database transaction
@@ -0,0 +1,282 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Migrations are the SQL files in migrations/, applied in name order. A file
+// NNN_name.sql migrates up; the optional NNN_name.down.sql reverts it.
+// Migrations without a down file, such as the initial schema and data
+// conversions, cannot be reverted. Applied migrations are recorded in
+// schema_migrations by their up file name.
+
+var (
+	ErrIrreversibleMigration = errors.New("migration has no down migration")
+	ErrUnknownMigration      = errors.New("applied migration is not known to this release")
+)
+
+// migrationLockID is the advisory lock held while migrating, so replicas
+// starting together do not apply the same migration twice
+const migrationLockID = 7315402938
+
+const downSuffix = ".down.sql"
+
+// Migration is one schema version
+type Migration struct {
+	Version string // NNN
+	Name    string
+	File    string // the up file, recorded in schema_migrations
+	Up      string
+	Down    string // empty when the migration cannot be reverted
+}
+
+// MigrationStatus is a migration and whether it is applied. Migrations
+// applied by a newer release are listed with Missing set.
+type MigrationStatus struct {
+	Version    string     `json:"version"`
+	Name       string     `json:"name"`
+	Applied    bool       `json:"applied"`
+	AppliedAt  *time.Time `json:"applied_at,omitempty"`
+	Reversible bool       `json:"reversible"`
+	Missing    bool       `json:"missing,omitempty"`
+}
+
+// loadMigrations reads the migrations of fsys, in the order they apply
+func loadMigrations(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations directory: %w", err)
+	}
+
+	byFile := make(map[string]*Migration)
+	downs := make(map[string]string)
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".sql") {
+			continue
+		}
+		content, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %s: %w", name, err)
+		}
+		if base, ok := strings.CutSuffix(name, downSuffix); ok {
+			downs[base+".sql"] = string(content)
+			continue
+		}
+		version, title, ok := strings.Cut(strings.TrimSuffix(name, ".sql"), "_")
+		if !ok || version == "" {
+			return nil, fmt.Errorf("migration %s is not named NNN_name.sql", name)
+		}
+		byFile[name] = &Migration{Version: version, Name: title, File: name, Up: string(content)}
+	}
+
+	for file, down := range downs {
+		m, ok := byFile[file]
+		if !ok {
+			return nil, fmt.Errorf("down migration for %s has no up migration", file)
+		}
+		m.Down = down
+	}
+
+	migrations := make([]Migration, 0, len(byFile))
+	for _, m := range byFile {
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].File < migrations[j].File })
+	for i := 1; i < len(migrations); i++ {
+		if migrations[i].Version == migrations[i-1].Version {
+			return nil, fmt.Errorf("migrations %s and %s share version %s", migrations[i-1].File, migrations[i].File, migrations[i].Version)
+		}
+	}
+	return migrations, nil
+}
+
+// migrationConn is a pool, or the connection holding the migration lock
+type migrationConn interface {
+	Exec(ctx context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error)
+	Begin(ctx context.Context) (pgx.Tx, error)
+}
+
+// Migrator applies and reverts the embedded migrations
+type Migrator struct {
+	pool       *pgxpool.Pool
+	migrations []Migration
+}
+
+// NewMigrator creates a migrator for the embedded migrations
+func NewMigrator(pool *pgxpool.Pool) (*Migrator, error) {
+	sub, err := fs.Sub(migrationsFS, "migrations")
+	if err != nil {
+		return nil, err
+	}
+	migrations, err := loadMigrations(sub)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{pool: pool, migrations: migrations}, nil
+}
+
+// ensureMigrationsTable creates schema_migrations if it does not exist
+func ensureMigrationsTable(ctx context.Context, db migrationConn) error {
+	_, err := db.Exec(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version VARCHAR(255) PRIMARY KEY,
+			applied_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to create migrations table: %w", err)
+	}
+	return nil
+}
+
+// appliedMigrations returns when each applied migration was applied, by file name
+func appliedMigrations(ctx context.Context, db migrationConn) (map[string]time.Time, error) {
+	rows, err := db.Query(ctx, `SELECT version, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read applied migrations: %w", err)
+	}
+	defer rows.Close()
+
+	applied := make(map[string]time.Time)
+	for rows.Next() {
+		var file string
+		var at time.Time
+		if err := rows.Scan(&file, &at); err != nil {
+			return nil, err
+		}
+		applied[file] = at
+	}
+	return applied, rows.Err()
+}
+
+// locked runs fn on a connection holding the migration lock
+func (m *Migrator) locked(ctx context.Context, fn func(conn migrationConn, applied map[string]time.Time) error) error {
+	conn, err := m.pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	if _, err := conn.Exec(ctx, "SELECT pg_advisory_lock($1)", migrationLockID); err != nil {
+		return fmt.Errorf("failed to acquire migration lock: %w", err)
+	}
+	defer conn.Exec(context.Background(), "SELECT pg_advisory_unlock($1)", migrationLockID)
+
+	if err := ensureMigrationsTable(ctx, conn); err != nil {
+		return err
+	}
+	applied, err := appliedMigrations(ctx, conn)
+	if err != nil {
+		return err
+	}
+	return fn(conn, applied)
+}
+
+// runMigration executes sql and records the change of version in one
+// transaction
+func runMigration(ctx context.Context, conn migrationConn, sql, record, file string) error {
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, sql); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx, record, file); err != nil {
+		return fmt.Errorf("failed to record migration: %w", err)
+	}
+	return tx.Commit(ctx)
+}
+
+// Up applies the migrations that are not applied yet and returns them
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	var done []Migration
+	err := m.locked(ctx, func(conn migrationConn, applied map[string]time.Time) error {
+		for _, mig := range m.migrations {
+			if _, ok := applied[mig.File]; ok {
+				continue
+			}
+			if err := runMigration(ctx, conn, mig.Up, "INSERT INTO schema_migrations (version) VALUES ($1)", mig.File); err != nil {
+				return fmt.Errorf("failed to apply migration %s: %w", mig.File, err)
+			}
+			done = append(done, mig)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Down reverts the last steps applied migrations, newest first, and returns
+// them. It stops at the first migration that cannot be reverted.
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	var done []Migration
+	err := m.locked(ctx, func(conn migrationConn, applied map[string]time.Time) error {
+		known := make(map[string]bool, len(m.migrations))
+		for _, mig := range m.migrations {
+			known[mig.File] = true
+		}
+		for file := range applied {
+			if !known[file] {
+				return fmt.Errorf("%w: %s", ErrUnknownMigration, file)
+			}
+		}
+
+		for i := len(m.migrations) - 1; i >= 0 && len(done) < steps; i-- {
+			mig := m.migrations[i]
+			if _, ok := applied[mig.File]; !ok {
+				continue
+			}
+			if mig.Down == "" {
+				return fmt.Errorf("%w: %s", ErrIrreversibleMigration, mig.File)
+			}
+			if err := runMigration(ctx, conn, mig.Down, "DELETE FROM schema_migrations WHERE version = $1", mig.File); err != nil {
+				return fmt.Errorf("failed to revert migration %s: %w", mig.File, err)
+			}
+			done = append(done, mig)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Status lists every migration and whether it is applied, oldest first
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	if err := ensureMigrationsTable(ctx, m.pool); err != nil {
+		return nil, err
+	}
+	applied, err := appliedMigrations(ctx, m.pool)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]MigrationStatus, 0, len(m.migrations))
+	for _, mig := range m.migrations {
+		s := MigrationStatus{Version: mig.Version, Name: mig.Name, Reversible: mig.Down != ""}
+		if at, ok := applied[mig.File]; ok {
+			s.Applied, s.AppliedAt = true, &at
+			delete(applied, mig.File)
+		}
+		statuses = append(statuses, s)
+	}
+	for file, at := range applied {
+		version, name, _ := strings.Cut(strings.TrimSuffix(file, ".sql"), "_")
+		at := at
+		statuses = append(statuses, MigrationStatus{Version: version, Name: name, Applied: true, AppliedAt: &at, Missing: true})
+	}
+	sort.SliceStable(statuses, func(i, j int) bool { return statuses[i].Version < statuses[j].Version })
+	return statuses, nil
+}
@@ -0,0 +1,59 @@
+package database
+
+import (
+	"io/fs"
+	"testing"
+	"testing/fstest"
+)
+
+func TestLoadMigrations(t *testing.T) {
+	fsys := fstest.MapFS{
+		"002_teams.sql":      {Data: []byte("CREATE TABLE teams ()")},
+		"002_teams.down.sql": {Data: []byte("DROP TABLE teams")},
+		"001_initial.sql":    {Data: []byte("CREATE TABLE users ()")},
+		"README.md":          {Data: []byte("not a migration")},
+	}
+	migrations, err := loadMigrations(fsys)
+	if err != nil {
+		t.Fatalf("loadMigrations() error = %v", err)
+	}
+	if len(migrations) != 2 {
+		t.Fatalf("loadMigrations() = %d migrations, want 2", len(migrations))
+	}
+	if m := migrations[0]; m.Version != "001" || m.Name != "initial" || m.File != "001_initial.sql" || m.Down != "" {
+		t.Errorf("migrations[0] = %+v, want irreversible 001_initial", m)
+	}
+	if m := migrations[1]; m.Version != "002" || m.Name != "teams" || m.Down != "DROP TABLE teams" {
+		t.Errorf("migrations[1] = %+v, want 002_teams with its down migration", m)
+	}
+}
+
+func TestLoadMigrationsRejectsInvalidSets(t *testing.T) {
+	tests := map[string]fstest.MapFS{
+		"orphan down":       {"001_a.sql": {}, "002_b.down.sql": {}},
+		"duplicate version": {"001_a.sql": {}, "001_b.sql": {}},
+		"unversioned":       {"initial.sql": {}},
+	}
+	for name, fsys := range tests {
+		if _, err := loadMigrations(fsys); err == nil {
+			t.Errorf("%s: loadMigrations() error = nil, want error", name)
+		}
+	}
+}
+
+func TestEmbeddedMigrations(t *testing.T) {
+	sub, err := fs.Sub(migrationsFS, "migrations")
+	if err != nil {
+		t.Fatal(err)
+	}
+	migrations, err := loadMigrations(sub)
+	if err != nil {
+		t.Fatalf("embedded migrations: %v", err)
+	}
+	if len(migrations) == 0 || migrations[0].File != "001_initial_schema.sql" {
+		t.Fatalf("embedded migrations start with %v, want 001_initial_schema.sql", migrations)
+	}
+	if migrations[0].Down != "" {
+		t.Error("the initial schema must not be reversible")
+	}
+}
@@ -0,0 +1,103 @@
+-- ============================================
+-- Partition audit_logs by month
+-- ============================================
+
+-- Creates the monthly partition of audit_logs that contains the given date.
+-- Partitions are named audit_logs_yYYYYmMM.
+CREATE OR REPLACE FUNCTION create_audit_log_partition(p_month DATE)
+RETURNS TEXT AS $$
+DECLARE
+    start_date DATE := date_trunc('month', p_month)::DATE;
+    end_date DATE := (date_trunc('month', p_month) + INTERVAL '1 month')::DATE;
+    partition_name TEXT := 'audit_logs_' || to_char(start_date, '"y"YYYY"m"MM');
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_class WHERE relname = partition_name) THEN
+        EXECUTE format(
+            'CREATE TABLE %I PARTITION OF audit_logs FOR VALUES FROM (%L) TO (%L)',
+            partition_name, start_date, end_date
+        );
+    END IF;
+    RETURN partition_name;
+END;
+$$ LANGUAGE plpgsql;
+
+ALTER TABLE audit_logs RENAME TO audit_logs_legacy;
+ALTER INDEX audit_logs_pkey RENAME TO audit_logs_legacy_pkey;
+DROP INDEX IF EXISTS idx_audit_logs_organization;
+DROP INDEX IF EXISTS idx_audit_logs_resource;
+DROP INDEX IF EXISTS idx_audit_logs_user;
+DROP INDEX IF EXISTS idx_audit_logs_created;
+
+-- The partition key must be part of the primary key
+CREATE TABLE audit_logs (
+    id UUID NOT NULL DEFAULT uuid_generate_v4(),
+    organization_id UUID REFERENCES organizations(id) NOT NULL,
+
+    -- Who
+    user_id UUID REFERENCES users(id),
+    user_email VARCHAR(255),
+    user_ip VARCHAR(45),
+    user_agent TEXT,
+
+    -- What
+    action VARCHAR(100) NOT NULL,
+    resource_type VARCHAR(100) NOT NULL,
+    resource_id UUID NOT NULL,
+    resource_name VARCHAR(255),
+
+    -- Changes
+    old_values JSONB,
+    new_values JSONB,
+    changed_fields TEXT[],
+
+    -- Context
+    description TEXT,
+    metadata JSONB DEFAULT '{}',
+
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (id, created_at)
+) PARTITION BY RANGE (created_at);
+
+-- Catches rows outside the pre-created range so inserts never fail
+CREATE TABLE audit_logs_default PARTITION OF audit_logs DEFAULT;
+
+-- Partitions for existing data and the next few months
+DO $$
+DECLARE
+    first_month DATE;
+    m DATE;
+BEGIN
+    SELECT COALESCE(date_trunc('month', MIN(created_at))::DATE, date_trunc('month', NOW())::DATE)
+    INTO first_month FROM audit_logs_legacy;
+
+    m := first_month;
+    WHILE m <= (date_trunc('month', NOW()) + INTERVAL '3 months')::DATE LOOP
+        PERFORM create_audit_log_partition(m);
+        m := (m + INTERVAL '1 month')::DATE;
+    END LOOP;
+END $$;
+
+INSERT INTO audit_logs (
+    id, organization_id,
+    user_id, user_email, user_ip, user_agent,
+    action, resource_type, resource_id, resource_name,
+    old_values, new_values, changed_fields,
+    description, metadata,
+    created_at
+)
+SELECT
+    id, organization_id,
+    user_id, user_email, user_ip, user_agent,
+    action, resource_type, resource_id, resource_name,
+    old_values, new_values, changed_fields,
+    description, metadata,
+    COALESCE(created_at, NOW())
+FROM audit_logs_legacy;
+
+DROP TABLE audit_logs_legacy;
+
+-- Indexes are created on the parent and propagated to every partition
+CREATE INDEX idx_audit_logs_organization ON audit_logs(organization_id);
+CREATE INDEX idx_audit_logs_resource ON audit_logs(resource_type, resource_id);
+CREATE INDEX idx_audit_logs_user ON audit_logs(user_id);
+CREATE INDEX idx_audit_logs_created ON audit_logs(created_at DESC);
@@ -0,0 +1,4 @@
+-- The pg_trgm extension is left installed; other objects may use it
+DROP INDEX IF EXISTS idx_namespaces_description_trgm;
+DROP INDEX IF EXISTS idx_namespaces_display_name_trgm;
+DROP INDEX IF EXISTS idx_namespaces_name_trgm;
@@ -0,0 +1,13 @@
+-- ============================================
+-- Trigram indexes for namespace search
+-- ============================================
+
+-- pg_trgm lets GIN indexes serve ILIKE '%term%' lookups
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE INDEX IF NOT EXISTS idx_namespaces_name_trgm
+    ON namespaces USING GIN (name gin_trgm_ops) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_namespaces_display_name_trgm
+    ON namespaces USING GIN (display_name gin_trgm_ops) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_namespaces_description_trgm
+    ON namespaces USING GIN (description gin_trgm_ops) WHERE deleted_at IS NULL;
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS cluster_sync_errors;
+DROP INDEX IF EXISTS idx_clusters_sync_error_category;
+ALTER TABLE clusters DROP COLUMN IF EXISTS sync_error_category;
@@ -0,0 +1,19 @@
+-- ============================================
+-- Structured cluster sync outcomes
+-- ============================================
+
+ALTER TABLE clusters ADD COLUMN IF NOT EXISTS sync_error_category VARCHAR(50);
+
+CREATE INDEX IF NOT EXISTS idx_clusters_sync_error_category
+    ON clusters(organization_id, sync_error_category) WHERE deleted_at IS NULL AND sync_error_category IS NOT NULL;
+
+CREATE TABLE IF NOT EXISTS cluster_sync_errors (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    cluster_id UUID NOT NULL REFERENCES clusters(id) ON DELETE CASCADE,
+    category VARCHAR(50) NOT NULL,
+    message TEXT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_cluster_sync_errors_cluster
+    ON cluster_sync_errors(cluster_id, created_at DESC);
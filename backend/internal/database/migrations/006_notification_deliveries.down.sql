@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_notification_templates_event;
+DROP TABLE IF EXISTS notification_deliveries;
@@ -0,0 +1,29 @@
+-- ============================================
+-- Notification delivery log
+-- ============================================
+
+CREATE TABLE IF NOT EXISTS notification_deliveries (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    organization_id UUID REFERENCES organizations(id) NOT NULL,
+    event_type VARCHAR(100) NOT NULL,
+    channel VARCHAR(50) NOT NULL DEFAULT 'email',
+    recipients TEXT[] NOT NULL,
+    subject TEXT NOT NULL,
+    body TEXT NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending', -- pending, sending, sent, failed
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT,
+    next_attempt_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    sent_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_notification_deliveries_due
+    ON notification_deliveries(next_attempt_at) WHERE status IN ('pending', 'sending');
+CREATE INDEX IF NOT EXISTS idx_notification_deliveries_org
+    ON notification_deliveries(organization_id, created_at DESC);
+
+-- One active template per organization, event and channel
+CREATE UNIQUE INDEX IF NOT EXISTS idx_notification_templates_event
+    ON notification_templates(COALESCE(organization_id, '00000000-0000-0000-0000-000000000000'::uuid), event_type, channel);
@@ -0,0 +1,2 @@
+ALTER TABLE clusters DROP COLUMN IF EXISTS last_alerted_at;
+ALTER TABLE clusters DROP COLUMN IF EXISTS consecutive_failures;
@@ -0,0 +1,8 @@
+-- ============================================
+-- Sync failure alerting
+-- ============================================
+
+-- Failed syncs and connectivity probes since the last success, and when the
+-- owners were last alerted about the current failure streak
+ALTER TABLE clusters ADD COLUMN IF NOT EXISTS consecutive_failures INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE clusters ADD COLUMN IF NOT EXISTS last_alerted_at TIMESTAMP WITH TIME ZONE;
@@ -0,0 +1,32 @@
+-- ============================================
+-- Alert escalation
+-- ============================================
+
+-- Alerts that walk a namespace's escalation path until acknowledged
+CREATE TABLE IF NOT EXISTS escalations (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    organization_id UUID REFERENCES organizations(id) NOT NULL,
+    namespace_id UUID REFERENCES namespaces(id) NOT NULL,
+    reason VARCHAR(100) NOT NULL, -- dependency_down
+    resource_type VARCHAR(100) NOT NULL,
+    resource_id UUID NOT NULL,
+    summary TEXT NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'open', -- open, acknowledged, resolved
+    level INTEGER NOT NULL DEFAULT 0, -- escalation levels notified so far
+    next_escalation_at TIMESTAMP WITH TIME ZONE,
+    acknowledged_by UUID REFERENCES users(id),
+    acknowledged_at TIMESTAMP WITH TIME ZONE,
+    resolved_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- At most one unresolved alert per resource and reason
+CREATE UNIQUE INDEX IF NOT EXISTS idx_escalations_unresolved
+    ON escalations(resource_type, resource_id, reason) WHERE status <> 'resolved';
+CREATE INDEX IF NOT EXISTS idx_escalations_due
+    ON escalations(next_escalation_at) WHERE status = 'open';
+CREATE INDEX IF NOT EXISTS idx_escalations_org
+    ON escalations(organization_id, created_at DESC);
+
+CREATE TRIGGER update_escalations_updated_at BEFORE UPDATE ON escalations FOR EACH ROW EXECUTE FUNCTION update_updated_at();
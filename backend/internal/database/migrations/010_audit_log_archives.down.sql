@@ -0,0 +1,3 @@
+-- Only the index of the archives is dropped; the archived objects stay in
+-- object storage
+DROP TABLE IF EXISTS audit_log_archives;
@@ -0,0 +1,16 @@
+-- ============================================
+-- Audit log archives
+-- ============================================
+
+-- Months of audit logs archived to object storage before their partition was
+-- dropped, one compressed NDJSON object per organization and month
+CREATE TABLE IF NOT EXISTS audit_log_archives (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    organization_id UUID REFERENCES organizations(id) NOT NULL,
+    month DATE NOT NULL, -- first day of the archived month
+    object_key TEXT NOT NULL,
+    entry_count BIGINT NOT NULL,
+    size_bytes BIGINT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    UNIQUE (organization_id, month)
+);
@@ -0,0 +1,28 @@
+-- ============================================
+-- Append-only audit log ledger
+-- ============================================
+
+-- Copy of the audit log of organizations that require immutable audit
+-- storage. Unlike audit_logs, which retention prunes, rows can only be
+-- inserted: updates, deletes and truncation are rejected by triggers.
+CREATE TABLE IF NOT EXISTS audit_log_ledger (
+    seq BIGSERIAL PRIMARY KEY,
+    organization_id UUID REFERENCES organizations(id) NOT NULL,
+    audit_log_id UUID NOT NULL,
+    entry JSONB NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_log_ledger_org ON audit_log_ledger(organization_id, seq);
+
+CREATE OR REPLACE FUNCTION reject_audit_log_ledger_change()
+RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_log_ledger is append-only: % is not allowed', TG_OP;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS audit_log_ledger_no_update ON audit_log_ledger;
+CREATE TRIGGER audit_log_ledger_no_update BEFORE UPDATE OR DELETE ON audit_log_ledger FOR EACH ROW EXECUTE FUNCTION reject_audit_log_ledger_change();
+DROP TRIGGER IF EXISTS audit_log_ledger_no_truncate ON audit_log_ledger;
+CREATE TRIGGER audit_log_ledger_no_truncate BEFORE TRUNCATE ON audit_log_ledger FOR EACH STATEMENT EXECUTE FUNCTION reject_audit_log_ledger_change();
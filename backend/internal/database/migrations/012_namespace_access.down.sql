@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS namespace_service_accounts;
+DROP TABLE IF EXISTS namespace_role_bindings;
@@ -0,0 +1,31 @@
+-- ============================================
+-- Namespace RBAC inventory
+-- ============================================
+
+-- Replaced by every cluster sync. One row per subject of a RoleBinding in
+-- the namespace, or of a ClusterRoleBinding that binds a service account of
+-- the namespace.
+CREATE TABLE IF NOT EXISTS namespace_role_bindings (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    namespace_id UUID NOT NULL REFERENCES namespaces(id) ON DELETE CASCADE,
+    binding_kind VARCHAR(50) NOT NULL,
+    binding_name VARCHAR(255) NOT NULL,
+    role_kind VARCHAR(50) NOT NULL,
+    role_name VARCHAR(255) NOT NULL,
+    subject_kind VARCHAR(50) NOT NULL,
+    subject_name VARCHAR(255) NOT NULL,
+    subject_namespace VARCHAR(255),
+    synced_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_namespace_role_bindings_namespace ON namespace_role_bindings(namespace_id);
+
+CREATE TABLE IF NOT EXISTS namespace_service_accounts (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    namespace_id UUID NOT NULL REFERENCES namespaces(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    k8s_uid VARCHAR(255),
+    k8s_created_at TIMESTAMP WITH TIME ZONE,
+    synced_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    UNIQUE(namespace_id, name)
+);
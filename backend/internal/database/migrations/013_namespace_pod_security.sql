@@ -0,0 +1,9 @@
+-- ============================================
+-- Pod Security Admission levels
+-- ============================================
+
+-- Syncs store the pod-security.kubernetes.io labels with the others in
+-- k8s_labels; the enforce level is indexed for the pod security report
+-- and the pod_security namespace filter.
+CREATE INDEX IF NOT EXISTS idx_namespaces_pod_security
+    ON namespaces(organization_id, (k8s_labels->>'pod-security.kubernetes.io/enforce')) WHERE deleted_at IS NULL;
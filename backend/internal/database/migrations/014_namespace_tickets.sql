@@ -0,0 +1,20 @@
+-- ============================================
+-- Namespace remediation tickets
+-- ============================================
+
+-- The Jira issue last opened to fix a namespace's missing owner or
+-- documents. The status is copied from Jira when the namespace is viewed.
+CREATE TABLE IF NOT EXISTS namespace_tickets (
+    namespace_id UUID PRIMARY KEY REFERENCES namespaces(id) ON DELETE CASCADE,
+    organization_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    issue_key VARCHAR(100) NOT NULL,
+    issue_url TEXT NOT NULL,
+    reason VARCHAR(50) NOT NULL,
+    status VARCHAR(100),
+    status_category VARCHAR(50),
+    status_checked_at TIMESTAMP WITH TIME ZONE,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_namespace_tickets_organization ON namespace_tickets(organization_id);
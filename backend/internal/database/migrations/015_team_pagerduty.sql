@@ -0,0 +1,7 @@
+-- ============================================
+-- Team PagerDuty services
+-- ============================================
+
+-- The PagerDuty service whose escalation policy is on call for the team's
+-- namespaces
+ALTER TABLE teams ADD COLUMN IF NOT EXISTS pagerduty_service_id VARCHAR(50);
@@ -0,0 +1 @@
+ALTER TABLE teams DROP COLUMN IF EXISTS opsgenie_schedule_id;
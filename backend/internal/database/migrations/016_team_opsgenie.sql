@@ -0,0 +1,7 @@
+-- ============================================
+-- Team Opsgenie schedules
+-- ============================================
+
+-- The Opsgenie schedule whose on-call users are contacted about the team's
+-- namespaces
+ALTER TABLE teams ADD COLUMN IF NOT EXISTS opsgenie_schedule_id VARCHAR(64);
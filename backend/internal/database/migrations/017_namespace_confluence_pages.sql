@@ -0,0 +1,17 @@
+-- ============================================
+-- Namespace Confluence pages
+-- ============================================
+
+-- The Confluence page a namespace's documentation is exported to. The hash
+-- of the last exported body lets the refresh job skip unchanged pages.
+CREATE TABLE IF NOT EXISTS namespace_confluence_pages (
+    namespace_id UUID PRIMARY KEY REFERENCES namespaces(id) ON DELETE CASCADE,
+    organization_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    page_id VARCHAR(64) NOT NULL,
+    page_url TEXT NOT NULL,
+    content_hash VARCHAR(64) NOT NULL,
+    exported_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    exported_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_namespace_confluence_pages_organization ON namespace_confluence_pages(organization_id);
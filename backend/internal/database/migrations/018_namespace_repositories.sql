@@ -0,0 +1,21 @@
+-- ============================================
+-- Namespace source repositories
+-- ============================================
+
+-- Git repositories holding the source of a namespace's workloads. The
+-- default branch and last commit are read from GitHub or GitLab.
+CREATE TABLE IF NOT EXISTS namespace_repositories (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    namespace_id UUID NOT NULL REFERENCES namespaces(id) ON DELETE CASCADE,
+    organization_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    url TEXT NOT NULL,
+    provider VARCHAR(20) NOT NULL,
+    default_branch VARCHAR(255),
+    last_commit_at TIMESTAMP WITH TIME ZONE,
+    checked_at TIMESTAMP WITH TIME ZONE,
+    check_error TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    UNIQUE(namespace_id, url)
+);
+
+CREATE INDEX IF NOT EXISTS idx_namespace_repositories_checked ON namespace_repositories(checked_at NULLS FIRST);
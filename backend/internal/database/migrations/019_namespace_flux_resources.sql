@@ -0,0 +1,25 @@
+-- ============================================
+-- Namespace Flux resources
+-- ============================================
+
+-- Flux Kustomizations and HelmReleases of a namespace, replaced by every
+-- cluster sync. path is the Kustomization path or the HelmRelease chart;
+-- ready is the status of the Ready condition.
+CREATE TABLE IF NOT EXISTS namespace_flux_resources (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    namespace_id UUID NOT NULL REFERENCES namespaces(id) ON DELETE CASCADE,
+    kind VARCHAR(50) NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    source_kind VARCHAR(50),
+    source_name VARCHAR(255),
+    source_namespace VARCHAR(255),
+    path TEXT,
+    revision VARCHAR(255),
+    ready VARCHAR(20) NOT NULL,
+    ready_reason VARCHAR(255),
+    ready_message TEXT,
+    ready_changed_at TIMESTAMP WITH TIME ZONE,
+    suspended BOOLEAN NOT NULL DEFAULT false,
+    synced_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    UNIQUE(namespace_id, kind, name)
+);
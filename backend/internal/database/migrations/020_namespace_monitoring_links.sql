@@ -0,0 +1,21 @@
+-- ============================================
+-- Namespace monitoring links
+-- ============================================
+
+-- Dashboards and other monitoring pages of a namespace. Grafana and Datadog
+-- dashboards are checked through their APIs; missing is set when the last
+-- check found the dashboard deleted.
+CREATE TABLE IF NOT EXISTS namespace_monitoring_links (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    namespace_id UUID NOT NULL REFERENCES namespaces(id) ON DELETE CASCADE,
+    organization_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    type VARCHAR(20) NOT NULL,
+    url TEXT NOT NULL,
+    missing BOOLEAN NOT NULL DEFAULT false,
+    checked_at TIMESTAMP WITH TIME ZONE,
+    check_error TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    UNIQUE(namespace_id, url)
+);
+
+CREATE INDEX IF NOT EXISTS idx_namespace_monitoring_links_checked ON namespace_monitoring_links(checked_at NULLS FIRST);
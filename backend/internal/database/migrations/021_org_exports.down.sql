@@ -0,0 +1,3 @@
+-- Archives of completed exports stay in object storage until they are
+-- removed there
+DROP TABLE IF EXISTS org_exports;
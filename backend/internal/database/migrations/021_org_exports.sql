@@ -0,0 +1,29 @@
+-- ============================================
+-- Organization exports
+-- ============================================
+
+-- Archives of all of an organization's data, generated in the background.
+-- Pending exports are claimed by one instance at a time; running exports
+-- whose instance stopped are reclaimed once updated_at is stale. The archive
+-- is kept in object storage under object_key until expires_at.
+CREATE TABLE IF NOT EXISTS org_exports (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    organization_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    requested_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    requested_by_email VARCHAR(255) NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending', -- pending, running, completed, failed, expired
+    progress INTEGER NOT NULL DEFAULT 0, -- percent
+    step VARCHAR(100),
+    object_key TEXT,
+    size_bytes BIGINT NOT NULL DEFAULT 0,
+    error TEXT,
+    started_at TIMESTAMP WITH TIME ZONE,
+    completed_at TIMESTAMP WITH TIME ZONE,
+    expires_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_org_exports_org ON org_exports(organization_id, created_at DESC);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_org_exports_active ON org_exports(organization_id) WHERE status IN ('pending', 'running');
+CREATE INDEX IF NOT EXISTS idx_org_exports_expiry ON org_exports(expires_at) WHERE status = 'completed';
@@ -0,0 +1,28 @@
+-- ============================================
+-- Organization deletions
+-- ============================================
+
+-- Requests to delete an organization with all of its data. A deletion starts
+-- as a preview holding the number of rows that would be removed; confirming
+-- it queues the cascading delete, which runs in the background. Rows have no
+-- foreign keys so they remain as the record of deleted organizations.
+CREATE TABLE IF NOT EXISTS org_deletions (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    organization_id UUID NOT NULL,
+    organization_name VARCHAR(255) NOT NULL,
+    organization_slug VARCHAR(100) NOT NULL,
+    requested_by_email VARCHAR(255) NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'previewed', -- previewed, pending, running, completed, failed
+    counts JSONB NOT NULL DEFAULT '{}', -- rows to remove per table
+    step VARCHAR(100),
+    error TEXT,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL, -- until when a preview can be confirmed
+    confirmed_at TIMESTAMP WITH TIME ZONE,
+    started_at TIMESTAMP WITH TIME ZONE,
+    completed_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_org_deletions_org ON org_deletions(organization_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_org_deletions_queued ON org_deletions(updated_at) WHERE status IN ('pending', 'running', 'failed');
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_namespaces_k8s_uid;
+ALTER TABLE namespaces DROP COLUMN IF EXISTS merged_at;
+ALTER TABLE namespaces DROP COLUMN IF EXISTS merged_into_id;
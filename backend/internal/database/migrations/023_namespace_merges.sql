@@ -0,0 +1,13 @@
+-- ============================================
+-- Merged duplicate namespaces
+-- ============================================
+
+-- When a cluster is removed and added again its namespaces are discovered as
+-- new records. Merging a deleted namespace into the record that replaced it
+-- moves its metadata, documents and dependencies, and records the merge here
+-- so the deleted namespace is no longer offered as a duplicate.
+ALTER TABLE namespaces ADD COLUMN IF NOT EXISTS merged_into_id UUID REFERENCES namespaces(id) ON DELETE SET NULL;
+ALTER TABLE namespaces ADD COLUMN IF NOT EXISTS merged_at TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX IF NOT EXISTS idx_namespaces_k8s_uid
+    ON namespaces(organization_id, k8s_uid) WHERE k8s_uid IS NOT NULL AND k8s_uid <> '';
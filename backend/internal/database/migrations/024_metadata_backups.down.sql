@@ -0,0 +1,2 @@
+-- Backups in object storage stay there until they are removed there
+DROP TABLE IF EXISTS metadata_backups;
@@ -0,0 +1,20 @@
+-- ============================================
+-- Metadata backups
+-- ============================================
+
+-- Snapshots of an organization's catalog metadata (teams, business units and
+-- namespace ownership, by natural keys) kept in object storage under
+-- object_key. Credentials are never included. Counts has the number of
+-- records per section.
+CREATE TABLE IF NOT EXISTS metadata_backups (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    organization_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    trigger VARCHAR(20) NOT NULL, -- manual, scheduled, imported
+    created_by_email VARCHAR(255) NOT NULL,
+    object_key TEXT NOT NULL,
+    size_bytes BIGINT NOT NULL DEFAULT 0,
+    counts JSONB NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_metadata_backups_org ON metadata_backups(organization_id, created_at DESC);
@@ -0,0 +1,23 @@
+-- ============================================
+-- Saved searches
+-- ============================================
+
+-- Named filter sets of a list endpoint, saved by a user and optionally
+-- shared with one of their teams. Filters are the endpoint's query
+-- parameters; sort and sort_order its sort and order.
+CREATE TABLE IF NOT EXISTS saved_searches (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    organization_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    team_id UUID REFERENCES teams(id) ON DELETE SET NULL,
+    name VARCHAR(255) NOT NULL,
+    entity VARCHAR(50) NOT NULL, -- namespaces, clusters, teams, documents, audit_logs
+    filters JSONB NOT NULL DEFAULT '{}',
+    sort VARCHAR(100),
+    sort_order VARCHAR(4),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    UNIQUE(user_id, name)
+);
+
+CREATE INDEX IF NOT EXISTS idx_saved_searches_team ON saved_searches(team_id) WHERE team_id IS NOT NULL;
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS idx_namespaces_k8s_labels;
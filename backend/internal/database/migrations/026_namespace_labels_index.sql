@@ -0,0 +1,8 @@
+-- ============================================
+-- Namespace label selectors
+-- ============================================
+
+-- Equality requirements of the namespace list's labelSelector are matched
+-- with k8s_labels @> '{"key": "value"}', which this index serves.
+CREATE INDEX IF NOT EXISTS idx_namespaces_k8s_labels
+    ON namespaces USING GIN (k8s_labels jsonb_path_ops) WHERE deleted_at IS NULL;
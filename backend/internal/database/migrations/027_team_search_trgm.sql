@@ -0,0 +1,8 @@
+-- ============================================
+-- Trigram index for team search
+-- ============================================
+
+-- Serves ILIKE '%term%' lookups of the team list's search, like the
+-- namespace indexes of 004_namespace_search_trgm
+CREATE INDEX IF NOT EXISTS idx_teams_name_trgm
+    ON teams USING GIN (name gin_trgm_ops) WHERE deleted_at IS NULL;
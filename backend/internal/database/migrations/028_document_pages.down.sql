@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS document_pages;
+DROP INDEX IF EXISTS idx_documents_text_pending;
+ALTER TABLE documents DROP COLUMN IF EXISTS text_extracted_at;
@@ -0,0 +1,22 @@
+-- ============================================
+-- Document contents
+-- ============================================
+
+-- Text extracted from uploaded documents, one row per page, so global
+-- search finds documents by their contents. text_extracted_at is set once
+-- extraction was attempted, also for file types without extractable text.
+ALTER TABLE documents ADD COLUMN IF NOT EXISTS text_extracted_at TIMESTAMP WITH TIME ZONE;
+
+CREATE TABLE IF NOT EXISTS document_pages (
+    document_id UUID NOT NULL REFERENCES documents(id) ON DELETE CASCADE,
+    organization_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    page INTEGER NOT NULL, -- from 1
+    content TEXT NOT NULL,
+    search TSVECTOR GENERATED ALWAYS AS (to_tsvector('simple', content)) STORED,
+    PRIMARY KEY (document_id, page)
+);
+
+CREATE INDEX IF NOT EXISTS idx_document_pages_search ON document_pages USING GIN (search);
+CREATE INDEX IF NOT EXISTS idx_document_pages_org ON document_pages(organization_id);
+CREATE INDEX IF NOT EXISTS idx_documents_text_pending
+    ON documents(uploaded_at) WHERE text_extracted_at IS NULL AND deleted_at IS NULL;
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS team_membership_changes;
+DROP TABLE IF EXISTS directory_groups;
+DROP TABLE IF EXISTS team_directory_groups;
@@ -0,0 +1,41 @@
+-- ============================================
+-- Team synchronization from directory groups
+-- ============================================
+
+-- Directory (LDAP or IdP) groups whose members make up a team's roster
+CREATE TABLE IF NOT EXISTS team_directory_groups (
+    team_id UUID NOT NULL REFERENCES teams(id) ON DELETE CASCADE,
+    organization_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    group_name VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (team_id, group_name)
+);
+
+CREATE INDEX IF NOT EXISTS idx_team_directory_groups_org
+    ON team_directory_groups(organization_id, lower(group_name));
+
+-- The members of each mapped group last read from LDAP or pushed over SCIM.
+-- A team is reconciled only once all of its groups have been seen.
+CREATE TABLE IF NOT EXISTS directory_groups (
+    organization_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    group_name VARCHAR(255) NOT NULL, -- lowercase
+    members TEXT[] NOT NULL DEFAULT '{}', -- lowercase email addresses
+    source VARCHAR(20) NOT NULL, -- ldap, scim
+    synced_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (organization_id, group_name)
+);
+
+-- Users joining and leaving teams through synchronization
+CREATE TABLE IF NOT EXISTS team_membership_changes (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    organization_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    team_id UUID NOT NULL REFERENCES teams(id) ON DELETE CASCADE,
+    user_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    email VARCHAR(255) NOT NULL,
+    change VARCHAR(20) NOT NULL, -- joined, removed
+    source VARCHAR(20) NOT NULL, -- ldap, scim
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_team_membership_changes_team
+    ON team_membership_changes(team_id, created_at DESC);
@@ -0,0 +1,19 @@
+-- ============================================
+-- Team on-call rotations
+-- ============================================
+
+-- Where a team's on-call schedule lives, who to escalate to beyond it, and
+-- who was on call when PagerDuty or Opsgenie were last asked. current_on_call
+-- keeps the last answer when a refresh fails; refresh_error says why.
+CREATE TABLE IF NOT EXISTS team_on_call (
+    team_id UUID PRIMARY KEY REFERENCES teams(id) ON DELETE CASCADE,
+    organization_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    rotation_url TEXT NOT NULL DEFAULT '',
+    escalation_contacts JSONB NOT NULL DEFAULT '[]',
+    current_on_call JSONB NOT NULL DEFAULT '[]',
+    refreshed_at TIMESTAMP WITH TIME ZONE,
+    refresh_error TEXT NOT NULL DEFAULT '',
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_team_on_call_org ON team_on_call(organization_id);
@@ -0,0 +1,19 @@
+-- ============================================
+-- Namespace contact validation
+-- ============================================
+
+-- Namespace contacts whose email address failed the last validation. A
+-- contact that passes again is removed.
+CREATE TABLE IF NOT EXISTS namespace_contact_issues (
+    namespace_id UUID NOT NULL REFERENCES namespaces(id) ON DELETE CASCADE,
+    organization_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    role VARCHAR(40) NOT NULL, -- application_manager, technical_lead, project_manager
+    email VARCHAR(255) NOT NULL,
+    reason VARCHAR(40) NOT NULL, -- invalid_format, no_mail_server, departed
+    detected_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    checked_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (namespace_id, role)
+);
+
+CREATE INDEX IF NOT EXISTS idx_namespace_contact_issues_org
+    ON namespace_contact_issues(organization_id);
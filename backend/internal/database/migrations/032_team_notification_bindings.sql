@@ -0,0 +1,18 @@
+-- ============================================
+-- Team notification bindings
+-- ============================================
+
+-- Where notifications about a team's resources go. Each binding overrides
+-- the team's contact_slack or contact_email when set. The Microsoft Teams
+-- webhook is encrypted like the organization's own.
+CREATE TABLE IF NOT EXISTS team_notification_bindings (
+    team_id UUID PRIMARY KEY REFERENCES teams(id) ON DELETE CASCADE,
+    organization_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    slack_channel_id VARCHAR(64) NOT NULL DEFAULT '',
+    email_alias VARCHAR(255) NOT NULL DEFAULT '',
+    teams_webhook_url TEXT NOT NULL DEFAULT '',
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_team_notification_bindings_org
+    ON team_notification_bindings(organization_id);
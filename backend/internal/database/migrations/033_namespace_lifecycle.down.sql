@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_namespaces_lifecycle;
+ALTER TABLE namespaces DROP COLUMN IF EXISTS decommission_date;
+ALTER TABLE namespaces DROP COLUMN IF EXISTS lifecycle_changed_at;
+ALTER TABLE namespaces DROP COLUMN IF EXISTS lifecycle;
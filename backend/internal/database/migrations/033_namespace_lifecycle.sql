@@ -0,0 +1,15 @@
+-- ============================================
+-- Namespace lifecycle
+-- ============================================
+
+-- Where an application stands: onboarding, active, decommissioning or
+-- decommissioned. Namespaces already in the catalog are active; namespaces
+-- discovered from now on start onboarding.
+ALTER TABLE namespaces ADD COLUMN IF NOT EXISTS lifecycle VARCHAR(20) NOT NULL DEFAULT 'active';
+ALTER TABLE namespaces ALTER COLUMN lifecycle SET DEFAULT 'onboarding';
+ALTER TABLE namespaces ADD COLUMN IF NOT EXISTS lifecycle_changed_at TIMESTAMP WITH TIME ZONE;
+-- When the application is, or was, to be retired
+ALTER TABLE namespaces ADD COLUMN IF NOT EXISTS decommission_date DATE;
+
+CREATE INDEX IF NOT EXISTS idx_namespaces_lifecycle
+    ON namespaces(organization_id, lifecycle) WHERE deleted_at IS NULL;
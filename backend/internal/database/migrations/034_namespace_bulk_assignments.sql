@@ -0,0 +1,33 @@
+-- ============================================
+-- Bulk namespace assignments
+-- ============================================
+
+-- Tags, owner teams and environments set on many namespaces at once. The
+-- selection is resolved to namespace_ids when requested. Small selections
+-- run right away; larger ones stay pending until claimed by one instance,
+-- and running assignments whose instance stopped are reclaimed once
+-- updated_at is stale.
+CREATE TABLE IF NOT EXISTS namespace_bulk_assignments (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    organization_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    requested_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    requested_by_email VARCHAR(255) NOT NULL,
+    changes JSONB NOT NULL, -- add_tags, remove_tags, owner_team_id, environment
+    namespace_ids UUID[] NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending', -- pending, running, completed, failed
+    processed INTEGER NOT NULL DEFAULT 0,
+    updated INTEGER NOT NULL DEFAULT 0,
+    unchanged INTEGER NOT NULL DEFAULT 0,
+    failed INTEGER NOT NULL DEFAULT 0,
+    failures JSONB NOT NULL DEFAULT '[]', -- namespace_id, namespace, error
+    error TEXT,
+    started_at TIMESTAMP WITH TIME ZONE,
+    completed_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_namespace_bulk_assignments_org
+    ON namespace_bulk_assignments(organization_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_namespace_bulk_assignments_queue
+    ON namespace_bulk_assignments(created_at) WHERE status IN ('pending', 'running');
@@ -0,0 +1,6 @@
+ALTER TABLE namespaces DROP COLUMN IF EXISTS usage_observed_at;
+ALTER TABLE namespaces DROP COLUMN IF EXISTS memory_usage_peak_bytes;
+ALTER TABLE namespaces DROP COLUMN IF EXISTS memory_usage_bytes;
+ALTER TABLE namespaces DROP COLUMN IF EXISTS cpu_usage_peak_millicores;
+ALTER TABLE namespaces DROP COLUMN IF EXISTS cpu_usage_millicores;
+DROP TABLE IF EXISTS namespace_usage_samples;
@@ -0,0 +1,25 @@
+-- ============================================
+-- Namespace resource usage
+-- ============================================
+
+-- CPU and memory used by the pods of a namespace, as reported by
+-- metrics-server at each cluster sync. Samples older than seven days are
+-- pruned by the sync.
+CREATE TABLE IF NOT EXISTS namespace_usage_samples (
+    namespace_id UUID NOT NULL REFERENCES namespaces(id) ON DELETE CASCADE,
+    cpu_millicores BIGINT NOT NULL,
+    memory_bytes BIGINT NOT NULL,
+    pods INTEGER NOT NULL,
+    observed_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_namespace_usage_samples_namespace
+    ON namespace_usage_samples(namespace_id, observed_at DESC);
+
+-- Average and peak usage over the samples kept, recomputed by each sync so
+-- that namespaces can be filtered and sorted on them. NULL until measured.
+ALTER TABLE namespaces ADD COLUMN IF NOT EXISTS cpu_usage_millicores BIGINT;
+ALTER TABLE namespaces ADD COLUMN IF NOT EXISTS cpu_usage_peak_millicores BIGINT;
+ALTER TABLE namespaces ADD COLUMN IF NOT EXISTS memory_usage_bytes BIGINT;
+ALTER TABLE namespaces ADD COLUMN IF NOT EXISTS memory_usage_peak_bytes BIGINT;
+ALTER TABLE namespaces ADD COLUMN IF NOT EXISTS usage_observed_at TIMESTAMP WITH TIME ZONE;
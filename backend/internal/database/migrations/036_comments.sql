@@ -0,0 +1,23 @@
+-- ============================================
+-- Comments
+-- ============================================
+
+-- Markdown notes left on a namespace or a cluster, e.g. what was learned
+-- during an incident. mentioned_user_ids are the users the body mentions,
+-- who are emailed about it. author_email is kept when the author is deleted.
+CREATE TABLE IF NOT EXISTS comments (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    organization_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    namespace_id UUID REFERENCES namespaces(id) ON DELETE CASCADE,
+    cluster_id UUID REFERENCES clusters(id) ON DELETE CASCADE,
+    author_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    author_email VARCHAR(255) NOT NULL,
+    body TEXT NOT NULL,
+    mentioned_user_ids UUID[] NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    CHECK ((namespace_id IS NULL) <> (cluster_id IS NULL))
+);
+
+CREATE INDEX IF NOT EXISTS idx_comments_namespace ON comments(namespace_id, created_at) WHERE namespace_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_comments_cluster ON comments(cluster_id, created_at) WHERE cluster_id IS NOT NULL;
@@ -0,0 +1,3 @@
+ALTER TABLE documents DROP COLUMN IF EXISTS application_id;
+DROP TABLE IF EXISTS application_namespaces;
+DROP TABLE IF EXISTS applications;
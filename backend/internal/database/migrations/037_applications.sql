@@ -0,0 +1,39 @@
+-- ============================================
+-- Applications
+-- ============================================
+
+-- A logical application, such as "payments", grouping the namespaces it
+-- runs in across clusters and environments, with its own owner and
+-- documents. Slugs are unique among the current applications of an
+-- organization.
+CREATE TABLE IF NOT EXISTS applications (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    organization_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    slug VARCHAR(100) NOT NULL,
+    description TEXT,
+    owner_team_id UUID REFERENCES teams(id) ON DELETE SET NULL,
+    owner_user_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    metadata JSONB NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    deleted_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_applications_slug
+    ON applications(organization_id, slug) WHERE deleted_at IS NULL;
+
+-- A namespace belongs to one application at most
+CREATE TABLE IF NOT EXISTS application_namespaces (
+    namespace_id UUID PRIMARY KEY REFERENCES namespaces(id) ON DELETE CASCADE,
+    application_id UUID NOT NULL REFERENCES applications(id) ON DELETE CASCADE,
+    organization_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_application_namespaces_application ON application_namespaces(application_id);
+
+-- Documents of an application rather than of one of its namespaces
+ALTER TABLE documents ADD COLUMN IF NOT EXISTS application_id UUID REFERENCES applications(id) ON DELETE CASCADE;
+
+CREATE INDEX IF NOT EXISTS idx_documents_application ON documents(application_id) WHERE application_id IS NOT NULL;
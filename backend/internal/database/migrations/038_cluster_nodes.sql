@@ -0,0 +1,36 @@
+-- ============================================
+-- Cluster nodes
+-- ============================================
+
+-- The nodes of a cluster as found by its last sync. Nodes keep their ID
+-- across syncs and are removed once they are gone from the cluster.
+-- Capacity and allocatable hold every resource the node reports; CPU,
+-- memory and pods are also kept as numbers to sum them per cluster.
+CREATE TABLE IF NOT EXISTS cluster_nodes (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    organization_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    cluster_id UUID NOT NULL REFERENCES clusters(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    k8s_uid VARCHAR(255),
+    status VARCHAR(20) NOT NULL,
+    roles TEXT[] NOT NULL DEFAULT '{}',
+    kubelet_version VARCHAR(50) NOT NULL DEFAULT '',
+    os_image VARCHAR(255) NOT NULL DEFAULT '',
+    architecture VARCHAR(50) NOT NULL DEFAULT '',
+    cpu_capacity_millicores BIGINT NOT NULL DEFAULT 0,
+    cpu_allocatable_millicores BIGINT NOT NULL DEFAULT 0,
+    memory_capacity_bytes BIGINT NOT NULL DEFAULT 0,
+    memory_allocatable_bytes BIGINT NOT NULL DEFAULT 0,
+    pods_capacity INTEGER NOT NULL DEFAULT 0,
+    pods_allocatable INTEGER NOT NULL DEFAULT 0,
+    capacity JSONB NOT NULL DEFAULT '{}',
+    allocatable JSONB NOT NULL DEFAULT '{}',
+    labels JSONB NOT NULL DEFAULT '{}',
+    annotations JSONB NOT NULL DEFAULT '{}',
+    k8s_created_at TIMESTAMP WITH TIME ZONE,
+    first_seen_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    last_seen_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    UNIQUE(cluster_id, name)
+);
+
+CREATE INDEX IF NOT EXISTS idx_cluster_nodes_organization ON cluster_nodes(organization_id);
@@ -0,0 +1,14 @@
+-- ============================================
+-- Cluster version end-of-life alerts
+-- ============================================
+
+-- The end-of-life thresholds a cluster's owners were alerted about for a
+-- Kubernetes minor version, so each threshold is only alerted once.
+-- Upgrading the cluster to another minor version starts over.
+CREATE TABLE IF NOT EXISTS cluster_version_alerts (
+    cluster_id UUID NOT NULL REFERENCES clusters(id) ON DELETE CASCADE,
+    minor_version VARCHAR(20) NOT NULL,
+    threshold_days INTEGER NOT NULL,
+    alerted_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (cluster_id, minor_version, threshold_days)
+);
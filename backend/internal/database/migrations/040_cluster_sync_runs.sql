@@ -0,0 +1,28 @@
+-- ============================================
+-- Cluster sync run history
+-- ============================================
+
+-- One row per cluster sync, successful or not, with what it changed in the
+-- inventory. archived_namespaces keeps the names of the namespaces the sync
+-- archived because they were gone from the cluster.
+CREATE TABLE IF NOT EXISTS cluster_sync_runs (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    cluster_id UUID NOT NULL REFERENCES clusters(id) ON DELETE CASCADE,
+    trigger VARCHAR(20) NOT NULL,
+    triggered_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    status VARCHAR(20) NOT NULL,
+    started_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    finished_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    namespace_count INTEGER NOT NULL DEFAULT 0,
+    node_count INTEGER NOT NULL DEFAULT 0,
+    namespaces_created INTEGER NOT NULL DEFAULT 0,
+    namespaces_updated INTEGER NOT NULL DEFAULT 0,
+    namespaces_archived INTEGER NOT NULL DEFAULT 0,
+    namespaces_reactivated INTEGER NOT NULL DEFAULT 0,
+    archived_namespaces TEXT[] NOT NULL DEFAULT '{}',
+    error_category VARCHAR(50),
+    error TEXT
+);
+
+CREATE INDEX IF NOT EXISTS idx_cluster_sync_runs_cluster
+    ON cluster_sync_runs(cluster_id, started_at DESC);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS cluster_fleet_members;
+DROP TABLE IF EXISTS cluster_fleets;
@@ -0,0 +1,32 @@
+-- ============================================
+-- Cluster fleets
+-- ============================================
+
+-- A group of clusters, such as "EU production fleet", with its own owner.
+-- Slugs are unique among the current fleets of an organization.
+CREATE TABLE IF NOT EXISTS cluster_fleets (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    organization_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    slug VARCHAR(100) NOT NULL,
+    description TEXT,
+    owner_team_id UUID REFERENCES teams(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    deleted_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_cluster_fleets_slug
+    ON cluster_fleets(organization_id, slug) WHERE deleted_at IS NULL;
+
+-- A cluster can belong to several fleets, such as a region and an
+-- environment fleet
+CREATE TABLE IF NOT EXISTS cluster_fleet_members (
+    fleet_id UUID NOT NULL REFERENCES cluster_fleets(id) ON DELETE CASCADE,
+    cluster_id UUID NOT NULL REFERENCES clusters(id) ON DELETE CASCADE,
+    organization_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    PRIMARY KEY (fleet_id, cluster_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_cluster_fleet_members_cluster ON cluster_fleet_members(cluster_id);
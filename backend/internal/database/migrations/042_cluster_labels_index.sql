@@ -0,0 +1,9 @@
+-- ============================================
+-- Cluster label filters
+-- ============================================
+
+-- Equality filters on cluster labels, such as labels.region=eu-west-1 with
+-- the region a sync reads from the cluster's nodes, are matched with
+-- labels @> '{"region": "eu-west-1"}', which this index serves.
+CREATE INDEX IF NOT EXISTS idx_clusters_labels
+    ON clusters USING GIN (labels jsonb_path_ops) WHERE deleted_at IS NULL;
@@ -0,0 +1,26 @@
+-- ============================================
+-- Cluster maintenance windows
+-- ============================================
+
+-- Planned maintenance of a cluster, once or recurring, from start_time for
+-- duration_minutes in the window's own time zone. start_date is the day of
+-- a once window, weekdays (mon to sun) those of a weekly one and
+-- day_of_month that of a monthly one.
+CREATE TABLE IF NOT EXISTS cluster_maintenance_windows (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    cluster_id UUID NOT NULL REFERENCES clusters(id) ON DELETE CASCADE,
+    organization_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    recurrence VARCHAR(20) NOT NULL,
+    start_date DATE,
+    weekdays TEXT[] NOT NULL DEFAULT '{}',
+    day_of_month INTEGER,
+    start_time VARCHAR(5) NOT NULL,
+    duration_minutes INTEGER NOT NULL,
+    timezone VARCHAR(64) NOT NULL DEFAULT 'UTC',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_cluster_maintenance_windows_cluster
+    ON cluster_maintenance_windows(cluster_id);
@@ -0,0 +1,2 @@
+ALTER TABLE clusters DROP COLUMN IF EXISTS active_api_server_url;
+ALTER TABLE clusters DROP COLUMN IF EXISTS fallback_api_server_urls;
@@ -0,0 +1,9 @@
+-- ============================================
+-- Cluster API endpoint failover
+-- ============================================
+
+-- API server URLs tried in order when the primary api_server_url cannot be
+-- reached, such as an external load balancer behind an internal one, and
+-- the URL the last successful sync or connectivity probe used
+ALTER TABLE clusters ADD COLUMN IF NOT EXISTS fallback_api_server_urls TEXT[] NOT NULL DEFAULT '{}';
+ALTER TABLE clusters ADD COLUMN IF NOT EXISTS active_api_server_url VARCHAR(500);
@@ -0,0 +1,22 @@
+-- ============================================
+-- Cluster capacity history
+-- ============================================
+
+-- The node count and summed node capacity of a cluster, snapshotted by each
+-- sync that lists its nodes, to follow and forecast its growth. Snapshots
+-- older than a year are pruned by the sync.
+CREATE TABLE IF NOT EXISTS cluster_capacity_snapshots (
+    cluster_id UUID NOT NULL REFERENCES clusters(id) ON DELETE CASCADE,
+    nodes INTEGER NOT NULL,
+    ready_nodes INTEGER NOT NULL,
+    cpu_capacity_millicores BIGINT NOT NULL,
+    cpu_allocatable_millicores BIGINT NOT NULL,
+    memory_capacity_bytes BIGINT NOT NULL,
+    memory_allocatable_bytes BIGINT NOT NULL,
+    pods_capacity BIGINT NOT NULL,
+    pods_allocatable BIGINT NOT NULL,
+    observed_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_cluster_capacity_snapshots_cluster
+    ON cluster_capacity_snapshots(cluster_id, observed_at DESC);
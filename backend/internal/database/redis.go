@@ -0,0 +1,35 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/kubeatlas/kubeatlas/internal/config"
+	"github.com/redis/go-redis/v9"
+)
+
+// NewRedis connects to Redis. It returns a nil client when Redis is not
+// configured, in which case callers fall back to in-process state.
+func NewRedis(cfg config.RedisConfig) (*redis.Client, error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+
+	client := redis.NewClient(&redis.Options{
+		Addr:     net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.Port)),
+		Password: cfg.Password,
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("failed to ping redis: %w", err)
+	}
+
+	return client, nil
+}
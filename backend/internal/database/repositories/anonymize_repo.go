@@ -0,0 +1,252 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// Fakes replacing personal data. Each is derived from the MD5 of the value
+// it replaces, so the same person gets the same fake in every table and a
+// namespace contact still matches the user it was. The service computes the
+// same fakes for the values it rewrites in Go; see services.fakeEmail.
+
+// fakeEmail replaces the e-mail address in col, except the one kept, given
+// as $2
+func fakeEmail(col string) string {
+	return fmt.Sprintf(`CASE WHEN COALESCE(%[1]s, '') = '' OR lower(%[1]s) = lower($2) THEN %[1]s
+		ELSE 'person-' || left(md5(lower(trim(%[1]s))), 10) || '@example.com' END`, col)
+}
+
+// fakeName replaces the name of a person in col
+func fakeName(col string) string {
+	return fmt.Sprintf(`CASE WHEN COALESCE(%[1]s, '') = '' THEN %[1]s
+		ELSE 'Person ' || upper(left(md5(lower(trim(%[1]s))), 6)) END`, col)
+}
+
+// fakePhone replaces the phone number in col with one in the 555 range
+func fakePhone(col string) string {
+	return fmt.Sprintf(`CASE WHEN COALESCE(%[1]s, '') = '' THEN %[1]s
+		ELSE '+1 555 ' || left(translate(md5(trim(%[1]s)), 'abcdef', '012345'), 7) END`, col)
+}
+
+// fakeRecipients replaces the e-mail addresses in the array col, leaving
+// other recipients such as Slack channels
+func fakeRecipients(col string) string {
+	return fmt.Sprintf(`ARRAY(SELECT CASE WHEN position('@' IN r) > 0 THEN %s ELSE r END FROM unnest(%s) r)`, fakeEmail("r"), col)
+}
+
+// redactEmails replaces every e-mail address in the free text col
+func redactEmails(col string) string {
+	return fmt.Sprintf(`regexp_replace(%s, '[^[:space:],;:<>()"]+@[^[:space:],;:<>()"]+', 'redacted@example.com', 'g')`, col)
+}
+
+// anonymizeStep rewrites the personal data of an organization's rows of
+// table, or with remove deletes them. In where and set, t is the table, $1
+// the organization and $2 the e-mail address kept.
+type anonymizeStep struct {
+	table  string
+	where  string
+	set    []string
+	remove bool
+}
+
+// anonymizeSteps lists the rewrites of an anonymization. Escalation paths
+// and document files are rewritten by the service, which parses them.
+// Records of exports and backups are removed: their objects hold the
+// original data and stay behind in the source environment's storage.
+var anonymizeSteps = []anonymizeStep{
+	{table: "users", where: whereOrganization + ` AND lower(t.email) <> lower($2)`, set: []string{
+		"email = " + fakeEmail("t.email"),
+		"full_name = " + fakeName("t.full_name"),
+		"username = CASE WHEN t.username IS NULL THEN NULL ELSE 'user-' || left(md5(t.id::text), 8) END",
+		"phone = " + fakePhone("t.phone"),
+		"avatar_url = NULL",
+	}},
+	{table: "teams", where: whereOrganization, set: []string{
+		"contact_email = " + fakeEmail("t.contact_email"),
+		"contact_slack = CASE WHEN COALESCE(t.contact_slack, '') = '' THEN t.contact_slack ELSE '#team-' || left(md5(t.contact_slack), 8) END",
+	}},
+	{table: "team_membership_changes", where: whereOrganization, set: []string{
+		"email = " + fakeEmail("t.email"),
+	}},
+	{table: "directory_groups", where: whereOrganization, set: []string{
+		"members = " + fakeRecipients("t.members"),
+	}},
+	{table: "team_notification_bindings", where: whereOrganization, set: []string{
+		"email_alias = " + fakeEmail("t.email_alias"),
+	}},
+	{table: "team_on_call", where: whereOrganization, set: []string{
+		"escalation_contacts = COALESCE((SELECT jsonb_agg(jsonb_build_object('level', c->'level', 'name', " + fakeName("c->>'name'") +
+			", 'email', " + fakeEmail("c->>'email'") + ", 'phone', " + fakePhone("c->>'phone'") + ") ORDER BY n)" +
+			" FROM jsonb_array_elements(t.escalation_contacts) WITH ORDINALITY AS e(c, n)), '[]')",
+		"current_on_call = '[]'",
+	}},
+	{table: "business_units", where: whereOrganization, set: []string{
+		"director_name = " + fakeName("t.director_name"),
+		"director_email = " + fakeEmail("t.director_email"),
+	}},
+	{table: "namespaces", where: whereOrganization, set: []string{
+		"application_manager_name = " + fakeName("t.application_manager_name"),
+		"application_manager_email = " + fakeEmail("t.application_manager_email"),
+		"application_manager_phone = " + fakePhone("t.application_manager_phone"),
+		"technical_lead_name = " + fakeName("t.technical_lead_name"),
+		"technical_lead_email = " + fakeEmail("t.technical_lead_email"),
+		"project_manager_name = " + fakeName("t.project_manager_name"),
+		"project_manager_email = " + fakeEmail("t.project_manager_email"),
+	}},
+	{table: "namespace_contact_issues", where: whereOrganization, set: []string{
+		"email = " + fakeEmail("t.email"),
+	}},
+	{table: "external_dependencies", where: whereOrganization, set: []string{
+		"contact_name = " + fakeName("t.contact_name"),
+		"contact_email = " + fakeEmail("t.contact_email"),
+	}},
+	{table: "namespace_role_bindings", where: whereOrgNamespace + ` AND t.subject_kind = 'User'`, set: []string{
+		"subject_name = CASE WHEN position('@' IN t.subject_name) > 0 THEN " + fakeEmail("t.subject_name") +
+			" ELSE 'user-' || left(md5(lower(t.subject_name)), 10) END",
+	}},
+	{table: "comments", where: whereOrganization, set: []string{
+		"author_email = " + fakeEmail("t.author_email"),
+		"body = " + redactEmails("t.body"),
+	}},
+	{table: "documents", where: whereOrganization + ` AND t.description IS NOT NULL`, set: []string{
+		"description = 'Anonymized description'",
+	}},
+	{table: "notification_deliveries", where: whereOrganization, set: []string{
+		"recipients = " + fakeRecipients("t.recipients"),
+		"subject = " + redactEmails("t.subject"),
+		"body = " + redactEmails("t.body"),
+	}},
+	{table: "scheduled_reports", where: whereOrganization, set: []string{
+		"recipients = " + fakeRecipients("t.recipients"),
+	}},
+	{table: "audit_logs", where: whereOrganization, set: []string{
+		"user_email = " + fakeEmail("t.user_email"),
+		"user_ip = NULL",
+		"user_agent = NULL",
+		"old_values = NULL",
+		"new_values = NULL",
+		"description = " + redactEmails("t.description"),
+		"resource_name = CASE WHEN t.resource_type = 'user' THEN " + fakeEmail("t.resource_name") + " ELSE t.resource_name END",
+	}},
+	{table: "org_exports", where: whereOrganization, remove: true},
+	{table: "metadata_backups", where: whereOrganization, remove: true},
+	{table: "namespace_bulk_assignments", where: whereOrganization, remove: true},
+	{table: "audit_log_archives", where: whereOrganization, remove: true},
+}
+
+// AnonymizedDocument is a document whose file the service replaces
+type AnonymizedDocument struct {
+	ID       uuid.UUID
+	FilePath string
+}
+
+// AnonymizeRepository rewrites the personal data of an organization in place
+type AnonymizeRepository struct {
+	*BaseRepository
+	pool DBTX
+}
+
+// NewAnonymizeRepository creates a new anonymize repository
+func NewAnonymizeRepository(pool DBTX) *AnonymizeRepository {
+	return &AnonymizeRepository{
+		BaseRepository: NewBaseRepository(pool),
+		pool:           pool,
+	}
+}
+
+// Anonymize runs every anonymization step in one transaction, keeping the
+// identity of the user with keepEmail, if any. It returns the number of rows
+// rewritten or removed per table, leaving out tables without any.
+func (r *AnonymizeRepository) Anonymize(ctx context.Context, orgID uuid.UUID, keepEmail string) (map[string]int64, error) {
+	counts := make(map[string]int64)
+	err := runInTx(ctx, r.pool, func(tx pgx.Tx) error {
+		for _, step := range anonymizeSteps {
+			table := pgx.Identifier{step.table}.Sanitize()
+			query := `UPDATE ` + table + ` t SET ` + strings.Join(step.set, ", ") + ` WHERE ` + step.where
+			if step.remove {
+				query = `DELETE FROM ` + table + ` t WHERE ` + step.where
+			}
+			args := []interface{}{orgID}
+			if strings.Contains(query, "$2") {
+				args = append(args, keepEmail)
+			}
+
+			result, err := tx.Exec(ctx, query, args...)
+			if err != nil {
+				return fmt.Errorf("failed to anonymize %s: %w", step.table, err)
+			}
+			if n := result.RowsAffected(); n > 0 {
+				counts[step.table] += n
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return counts, nil
+}
+
+// ListEscalationPaths returns the escalation paths of the organization's
+// namespaces that have one, by namespace
+func (r *AnonymizeRepository) ListEscalationPaths(ctx context.Context, orgID uuid.UUID) (map[uuid.UUID]string, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT id, escalation_path FROM namespaces
+		WHERE organization_id = $1 AND COALESCE(escalation_path, '') <> ''
+	`, orgID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	paths := make(map[uuid.UUID]string)
+	for rows.Next() {
+		var id uuid.UUID
+		var path string
+		if err := rows.Scan(&id, &path); err != nil {
+			return nil, err
+		}
+		paths[id] = path
+	}
+	return paths, rows.Err()
+}
+
+// SetEscalationPath replaces the escalation path of a namespace; an empty
+// path clears it
+func (r *AnonymizeRepository) SetEscalationPath(ctx context.Context, id uuid.UUID, path string) error {
+	_, err := r.pool.Exec(ctx, `UPDATE namespaces SET escalation_path = NULLIF($2, '') WHERE id = $1`, id, path)
+	return err
+}
+
+// ListDocuments returns the organization's documents with their files
+func (r *AnonymizeRepository) ListDocuments(ctx context.Context, orgID uuid.UUID) ([]AnonymizedDocument, error) {
+	rows, err := r.pool.Query(ctx, `SELECT id, file_path FROM documents WHERE organization_id = $1 ORDER BY created_at`, orgID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	docs := make([]AnonymizedDocument, 0)
+	for rows.Next() {
+		var d AnonymizedDocument
+		if err := rows.Scan(&d.ID, &d.FilePath); err != nil {
+			return nil, err
+		}
+		docs = append(docs, d)
+	}
+	return docs, rows.Err()
+}
+
+// SetDocumentFile records the file that replaced a document's
+func (r *AnonymizeRepository) SetDocumentFile(ctx context.Context, id uuid.UUID, fileName string, size int64, mimeType string) error {
+	_, err := r.pool.Exec(ctx, `
+		UPDATE documents SET file_name = $2, file_size = $3, mime_type = $4, checksum = NULL
+		WHERE id = $1
+	`, id, fileName, size, mimeType)
+	return err
+}
@@ -0,0 +1,422 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/kubeatlas/kubeatlas/internal/models"
+)
+
+// ApplicationRepository stores applications and the namespaces they group
+type ApplicationRepository struct {
+	*BaseRepository
+	pool DBTX
+}
+
+// NewApplicationRepository creates a new application repository
+func NewApplicationRepository(pool DBTX) *ApplicationRepository {
+	return &ApplicationRepository{
+		BaseRepository: NewBaseRepository(pool),
+		pool:           pool,
+	}
+}
+
+// applicationColumns selects an application as a with its owner team as t
+// and its namespace count as nc
+const applicationColumns = `
+	a.id, a.organization_id, a.name, a.slug, a.description,
+	a.owner_team_id, a.owner_user_id, a.metadata, a.created_at, a.updated_at,
+	t.name, t.slug, nc.namespace_count
+`
+
+const applicationFrom = `
+	FROM applications a
+	LEFT JOIN teams t ON t.id = a.owner_team_id AND t.deleted_at IS NULL
+	CROSS JOIN LATERAL (
+		SELECT COUNT(*)::int AS namespace_count
+		FROM application_namespaces an
+		JOIN namespaces n ON n.id = an.namespace_id AND n.deleted_at IS NULL
+		WHERE an.application_id = a.id
+	) nc`
+
+func scanApplication(row pgx.Row, a *models.Application) error {
+	var teamName, teamSlug *string
+	if err := row.Scan(
+		&a.ID, &a.OrganizationID, &a.Name, &a.Slug, &a.Description,
+		&a.OwnerTeamID, &a.OwnerUserID, &a.Metadata, &a.CreatedAt, &a.UpdatedAt,
+		&teamName, &teamSlug, &a.NamespaceCount,
+	); err != nil {
+		return err
+	}
+	if teamName != nil && a.OwnerTeamID != nil {
+		a.OwnerTeam = &models.Team{BaseModel: models.BaseModel{ID: *a.OwnerTeamID}, OrganizationID: a.OrganizationID, Name: *teamName}
+		if teamSlug != nil {
+			a.OwnerTeam.Slug = *teamSlug
+		}
+	}
+	return nil
+}
+
+// Create creates an application
+func (r *ApplicationRepository) Create(ctx context.Context, a *models.Application) error {
+	a.ID = uuid.New()
+	a.CreatedAt = time.Now()
+	a.UpdatedAt = a.CreatedAt
+
+	query := `
+		INSERT INTO applications (
+			id, organization_id, name, slug, description, owner_team_id, owner_user_id, metadata, created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	`
+
+	_, err := r.pool.Exec(ctx, query,
+		a.ID, a.OrganizationID, a.Name, a.Slug, a.Description, a.OwnerTeamID, a.OwnerUserID, a.Metadata, a.CreatedAt, a.UpdatedAt,
+	)
+	return err
+}
+
+// GetByID retrieves a current application of the organization. Returns nil
+// when there is none.
+func (r *ApplicationRepository) GetByID(ctx context.Context, orgID, id uuid.UUID) (*models.Application, error) {
+	query := `SELECT ` + applicationColumns + applicationFrom + `
+		WHERE a.id = $1 AND a.organization_id = $2 AND a.deleted_at IS NULL`
+
+	a := &models.Application{}
+	err := scanApplication(r.pool.QueryRow(ctx, query, id, orgID), a)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// ExistsBySlug reports whether another current application of the
+// organization than exceptID has the slug
+func (r *ApplicationRepository) ExistsBySlug(ctx context.Context, orgID uuid.UUID, slug string, exceptID uuid.UUID) (bool, error) {
+	var exists bool
+	err := r.pool.QueryRow(ctx, `
+		SELECT EXISTS (
+			SELECT 1 FROM applications
+			WHERE organization_id = $1 AND slug = $2 AND id <> $3 AND deleted_at IS NULL
+		)`, orgID, slug, exceptID).Scan(&exists)
+	return exists, err
+}
+
+// List returns the current applications of the organization, by name.
+// filters["search"] matches names and slugs, filters["owner_team_id"] the
+// owner team.
+func (r *ApplicationRepository) List(ctx context.Context, orgID uuid.UUID, p Pagination, filters map[string]interface{}) (*PaginatedResult[models.Application], error) {
+	qb := NewQueryBuilder(`SELECT ` + applicationColumns + applicationFrom)
+	qb.SortAlias("a").SortColumn("namespace_count", "nc.namespace_count")
+
+	qb.Where("a.organization_id = ?", orgID)
+	qb.Where("a.deleted_at IS NULL")
+	if search, ok := filters["search"].(string); ok && search != "" {
+		pattern := searchPattern(search)
+		qb.Where("(a.name ILIKE ? OR a.slug ILIKE ?)", pattern, pattern)
+	}
+	if teamID, ok := filters["owner_team_id"].(uuid.UUID); ok {
+		qb.Where("a.owner_team_id = ?", teamID)
+	}
+
+	if p.Sort == "" {
+		p.Sort = "name"
+		p.Order = "asc"
+	}
+	qb.Paginate(p)
+	qb.ThenBy("id", "asc")
+
+	countQuery, countArgs := qb.BuildCount()
+	var total int64
+	if err := r.reader().QueryRow(ctx, countQuery, countArgs...).Scan(&total); err != nil {
+		return nil, fmt.Errorf("failed to count applications: %w", err)
+	}
+
+	query, args := qb.Build()
+	rows, err := r.reader().Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query applications: %w", err)
+	}
+	defer rows.Close()
+
+	applications := make([]models.Application, 0)
+	for rows.Next() {
+		var a models.Application
+		if err := scanApplication(rows, &a); err != nil {
+			return nil, fmt.Errorf("failed to scan application: %w", err)
+		}
+		applications = append(applications, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	totalPages := int(total) / p.PageSize
+	if int(total)%p.PageSize > 0 {
+		totalPages++
+	}
+
+	return &PaginatedResult[models.Application]{
+		Items:      applications,
+		Total:      total,
+		Page:       p.Page,
+		PageSize:   p.PageSize,
+		TotalPages: totalPages,
+	}, nil
+}
+
+// Update updates the details and owner of an application
+func (r *ApplicationRepository) Update(ctx context.Context, a *models.Application) error {
+	a.UpdatedAt = time.Now()
+
+	result, err := r.pool.Exec(ctx, `
+		UPDATE applications SET
+			name = $2, slug = $3, description = $4, owner_team_id = $5, owner_user_id = $6, metadata = $7, updated_at = $8
+		WHERE id = $1 AND deleted_at IS NULL`,
+		a.ID, a.Name, a.Slug, a.Description, a.OwnerTeamID, a.OwnerUserID, a.Metadata, a.UpdatedAt,
+	)
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+// Delete soft deletes an application with its documents and releases its
+// namespaces, which can then join another application
+func (r *ApplicationRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	return r.RunInTx(ctx, func(tx pgx.Tx) error {
+		result, err := tx.Exec(ctx, `UPDATE applications SET deleted_at = NOW() WHERE id = $1 AND deleted_at IS NULL`, id)
+		if err != nil {
+			return err
+		}
+		if result.RowsAffected() == 0 {
+			return pgx.ErrNoRows
+		}
+		if _, err := tx.Exec(ctx, `DELETE FROM application_namespaces WHERE application_id = $1`, id); err != nil {
+			return err
+		}
+		_, err = tx.Exec(ctx, `UPDATE documents SET deleted_at = NOW() WHERE application_id = $1 AND deleted_at IS NULL`, id)
+		return err
+	})
+}
+
+// AddNamespaces adds namespaces of the organization to an application,
+// moving those that belonged to another one. Returns the applications the
+// namespaces were moved from, by namespace.
+func (r *ApplicationRepository) AddNamespaces(ctx context.Context, orgID, id uuid.UUID, namespaceIDs []uuid.UUID) (map[uuid.UUID]uuid.UUID, error) {
+	moved := make(map[uuid.UUID]uuid.UUID)
+	err := r.RunInTx(ctx, func(tx pgx.Tx) error {
+		rows, err := tx.Query(ctx, `
+			SELECT namespace_id, application_id FROM application_namespaces
+			WHERE namespace_id = ANY($1) AND application_id <> $2
+			FOR UPDATE`, namespaceIDs, id)
+		if err != nil {
+			return err
+		}
+		for rows.Next() {
+			var namespaceID, from uuid.UUID
+			if err := rows.Scan(&namespaceID, &from); err != nil {
+				rows.Close()
+				return err
+			}
+			moved[namespaceID] = from
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(ctx, `
+			INSERT INTO application_namespaces (namespace_id, application_id, organization_id)
+			SELECT unnest($1::uuid[]), $2, $3
+			ON CONFLICT (namespace_id) DO UPDATE SET application_id = EXCLUDED.application_id, created_at = NOW()
+			WHERE application_namespaces.application_id <> EXCLUDED.application_id`,
+			namespaceIDs, id, orgID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return moved, nil
+}
+
+// RemoveNamespace removes a namespace from an application. Returns false
+// when it did not belong to the application.
+func (r *ApplicationRepository) RemoveNamespace(ctx context.Context, id, namespaceID uuid.UUID) (bool, error) {
+	result, err := r.pool.Exec(ctx,
+		`DELETE FROM application_namespaces WHERE application_id = $1 AND namespace_id = $2`, id, namespaceID)
+	if err != nil {
+		return false, err
+	}
+	return result.RowsAffected() > 0, nil
+}
+
+// ListNamespaces returns up to limit current namespaces of an application,
+// by cluster and name, with their cluster, owner team and completeness score
+func (r *ApplicationRepository) ListNamespaces(ctx context.Context, id uuid.UUID, limit int) ([]models.Namespace, error) {
+	query := `
+		SELECT
+			n.id, n.organization_id, n.cluster_id,
+			n.name, n.display_name, n.environment, n.criticality,
+			n.infrastructure_owner_team_id, n.status, n.lifecycle,
+			n.cpu_usage_millicores, n.memory_usage_bytes, n.usage_observed_at,
+			cs.completeness_score,
+			c.name, c.environment,
+			t.name, t.slug
+		FROM application_namespaces an
+		JOIN namespaces n ON n.id = an.namespace_id AND n.deleted_at IS NULL` + namespaceCompletenessJoin + `
+		JOIN clusters c ON c.id = n.cluster_id
+		LEFT JOIN teams t ON t.id = n.infrastructure_owner_team_id AND t.deleted_at IS NULL
+		WHERE an.application_id = $1
+		ORDER BY c.name, n.name
+		LIMIT $2
+	`
+
+	rows, err := r.reader().Query(ctx, query, id, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list application namespaces: %w", err)
+	}
+	defer rows.Close()
+
+	namespaces := make([]models.Namespace, 0)
+	for rows.Next() {
+		var ns models.Namespace
+		var cluster models.Cluster
+		var teamName, teamSlug *string
+		if err := rows.Scan(
+			&ns.ID, &ns.OrganizationID, &ns.ClusterID,
+			&ns.Name, &ns.DisplayName, &ns.Environment, &ns.Criticality,
+			&ns.InfrastructureOwnerTeamID, &ns.Status, &ns.Lifecycle,
+			&ns.CPUUsageMillicores, &ns.MemoryUsageBytes, &ns.UsageObservedAt,
+			&ns.CompletenessScore,
+			&cluster.Name, &cluster.Environment,
+			&teamName, &teamSlug,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan application namespace: %w", err)
+		}
+		cluster.ID, cluster.OrganizationID = ns.ClusterID, ns.OrganizationID
+		ns.Cluster = &cluster
+		if teamName != nil && ns.InfrastructureOwnerTeamID != nil {
+			ns.InfrastructureOwnerTeam = &models.Team{BaseModel: models.BaseModel{ID: *ns.InfrastructureOwnerTeamID}, OrganizationID: ns.OrganizationID, Name: *teamName}
+			if teamSlug != nil {
+				ns.InfrastructureOwnerTeam.Slug = *teamSlug
+			}
+		}
+		namespaces = append(namespaces, ns)
+	}
+	return namespaces, rows.Err()
+}
+
+// ListInternalDependencies returns the current dependencies from or to the
+// namespaces of an application, with the names of both ends
+func (r *ApplicationRepository) ListInternalDependencies(ctx context.Context, id uuid.UUID) ([]models.InternalDependency, error) {
+	query := `
+		SELECT
+			d.id, d.organization_id,
+			d.source_namespace_id, d.source_resource_type, d.source_resource_name,
+			d.target_namespace_id, d.target_resource_type, d.target_resource_name,
+			d.dependency_type, d.description, d.is_critical,
+			d.is_auto_discovered, d.discovery_method,
+			d.status, d.verified_at, d.verified_by, d.metadata,
+			d.created_at, d.updated_at,
+			sn.name, tn.name
+		FROM internal_dependencies d
+		JOIN namespaces sn ON sn.id = d.source_namespace_id AND sn.deleted_at IS NULL
+		JOIN namespaces tn ON tn.id = d.target_namespace_id AND tn.deleted_at IS NULL
+		WHERE d.deleted_at IS NULL AND (
+			d.source_namespace_id IN (SELECT namespace_id FROM application_namespaces WHERE application_id = $1) OR
+			d.target_namespace_id IN (SELECT namespace_id FROM application_namespaces WHERE application_id = $1)
+		)
+		ORDER BY d.is_critical DESC, sn.name, tn.name
+	`
+
+	rows, err := r.reader().Query(ctx, query, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list application dependencies: %w", err)
+	}
+	defer rows.Close()
+
+	deps := make([]models.InternalDependency, 0)
+	for rows.Next() {
+		var d models.InternalDependency
+		var sourceName, targetName string
+		if err := rows.Scan(
+			&d.ID, &d.OrganizationID,
+			&d.SourceNamespaceID, &d.SourceResourceType, &d.SourceResourceName,
+			&d.TargetNamespaceID, &d.TargetResourceType, &d.TargetResourceName,
+			&d.DependencyType, &d.Description, &d.IsCritical,
+			&d.IsAutoDiscovered, &d.DiscoveryMethod,
+			&d.Status, &d.VerifiedAt, &d.VerifiedBy, &d.Metadata,
+			&d.CreatedAt, &d.UpdatedAt,
+			&sourceName, &targetName,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan dependency: %w", err)
+		}
+		d.SourceNamespace = &models.Namespace{BaseModel: models.BaseModel{ID: d.SourceNamespaceID}, Name: sourceName}
+		d.TargetNamespace = &models.Namespace{BaseModel: models.BaseModel{ID: d.TargetNamespaceID}, Name: targetName}
+		deps = append(deps, d)
+	}
+	return deps, rows.Err()
+}
+
+// ListExternalDependencies returns the current external dependencies of the
+// namespaces of an application, with the name of the namespace
+func (r *ApplicationRepository) ListExternalDependencies(ctx context.Context, id uuid.UUID) ([]models.ExternalDependency, error) {
+	query := `
+		SELECT
+			d.id, d.organization_id, d.namespace_id,
+			d.name, d.system_type, d.provider, d.endpoint, d.description,
+			d.is_critical, d.expected_availability,
+			d.contact_name, d.contact_email, d.documentation_url,
+			d.status, d.metadata,
+			d.created_at, d.updated_at,
+			n.name
+		FROM external_dependencies d
+		JOIN application_namespaces an ON an.namespace_id = d.namespace_id
+		JOIN namespaces n ON n.id = d.namespace_id AND n.deleted_at IS NULL
+		WHERE an.application_id = $1 AND d.deleted_at IS NULL
+		ORDER BY d.is_critical DESC, d.name, n.name
+	`
+
+	rows, err := r.reader().Query(ctx, query, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list application external dependencies: %w", err)
+	}
+	defer rows.Close()
+
+	deps := make([]models.ExternalDependency, 0)
+	for rows.Next() {
+		var d models.ExternalDependency
+		var namespaceName string
+		if err := rows.Scan(
+			&d.ID, &d.OrganizationID, &d.NamespaceID,
+			&d.Name, &d.SystemType, &d.Provider, &d.Endpoint, &d.Description,
+			&d.IsCritical, &d.ExpectedAvailability,
+			&d.ContactName, &d.ContactEmail, &d.DocumentationURL,
+			&d.Status, &d.Metadata,
+			&d.CreatedAt, &d.UpdatedAt,
+			&namespaceName,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan external dependency: %w", err)
+		}
+		d.Namespace = &models.Namespace{BaseModel: models.BaseModel{ID: d.NamespaceID}, Name: namespaceName}
+		deps = append(deps, d)
+	}
+	return deps, rows.Err()
+}
+
+// CountDocuments counts the current documents of an application
+func (r *ApplicationRepository) CountDocuments(ctx context.Context, id uuid.UUID) (int, error) {
+	var n int
+	err := r.reader().QueryRow(ctx,
+		`SELECT COUNT(*) FROM documents WHERE application_id = $1 AND deleted_at IS NULL`, id).Scan(&n)
+	return n, err
+}
@@ -2,23 +2,38 @@ package repositories
 
 import (
 	"context"
+	"fmt"
 	"time"
 
 	"github.com/google/uuid"
-	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jackc/pgx/v5"
 	"github.com/kubeatlas/kubeatlas/internal/models"
 )
 
 // AuditRepository handles audit log database operations
 type AuditRepository struct {
-	pool *pgxpool.Pool
+	pool DBTX
+	read DBTX
 }
 
 // NewAuditRepository creates a new audit repository
-func NewAuditRepository(pool *pgxpool.Pool) *AuditRepository {
+func NewAuditRepository(pool DBTX) *AuditRepository {
 	return &AuditRepository{pool: pool}
 }
 
+// SetReadReplica routes audit queries to the given read-only connection
+func (r *AuditRepository) SetReadReplica(read DBTX) {
+	r.read = read
+}
+
+// reader returns the connection used for read-heavy queries
+func (r *AuditRepository) reader() DBTX {
+	if r.read != nil {
+		return r.read
+	}
+	return r.pool
+}
+
 // Create creates a new audit log entry
 func (r *AuditRepository) Create(ctx context.Context, log *models.AuditLog) error {
 	log.ID = uuid.New()
@@ -47,20 +62,21 @@ func (r *AuditRepository) Create(ctx context.Context, log *models.AuditLog) erro
 	return err
 }
 
-// List retrieves audit logs with pagination
-func (r *AuditRepository) List(ctx context.Context, orgID uuid.UUID, p Pagination, filters map[string]interface{}) (*PaginatedResult[models.AuditLog], error) {
-	qb := NewQueryBuilder(`
-		SELECT 
-			a.id, a.organization_id,
-			a.user_id, a.user_email, a.user_ip, a.user_agent,
-			a.action, a.resource_type, a.resource_id, a.resource_name,
-			a.old_values, a.new_values, a.changed_fields,
-			a.description, a.metadata,
-			a.created_at,
-			u.full_name as user_name
-		FROM audit_logs a
-		LEFT JOIN users u ON a.user_id = u.id
-	`)
+// auditLogColumns are the audit log columns read by List and Each
+const auditLogColumns = `
+	a.id, a.organization_id,
+	a.user_id, a.user_email, a.user_ip, a.user_agent,
+	a.action, a.resource_type, a.resource_id, a.resource_name,
+	a.old_values, a.new_values, a.changed_fields,
+	a.description, a.metadata,
+	a.created_at,
+	u.full_name as user_name
+`
+
+// auditLogQuery selects the organization's audit logs matching filters
+func auditLogQuery(orgID uuid.UUID, filters map[string]interface{}) *QueryBuilder {
+	qb := NewQueryBuilder(`SELECT ` + auditLogColumns + ` FROM audit_logs a LEFT JOIN users u ON a.user_id = u.id`)
+	qb.SortAlias("a")
 
 	qb.Where("a.organization_id = ?", orgID)
 
@@ -83,10 +99,48 @@ func (r *AuditRepository) List(ctx context.Context, orgID uuid.UUID, p Paginatio
 	if to, ok := filters["to"].(time.Time); ok {
 		qb.Where("a.created_at <= ?", to)
 	}
+	if actions, ok := filters["exclude_actions"].([]string); ok && len(actions) > 0 {
+		qb.Where("a.action <> ALL(?)", actions)
+	}
+	if resourceTypes, ok := filters["exclude_resource_types"].([]string); ok && len(resourceTypes) > 0 {
+		qb.Where("a.resource_type <> ALL(?)", resourceTypes)
+	}
+	return qb
+}
+
+// scanAuditLog scans a row of auditLogColumns
+func scanAuditLog(rows pgx.Rows) (models.AuditLog, error) {
+	var l models.AuditLog
+	var userName *string
+
+	err := rows.Scan(
+		&l.ID, &l.OrganizationID,
+		&l.UserID, &l.UserEmail, &l.UserIP, &l.UserAgent,
+		&l.Action, &l.ResourceType, &l.ResourceID, &l.ResourceName,
+		&l.OldValues, &l.NewValues, &l.ChangedFields,
+		&l.Description, &l.Metadata,
+		&l.CreatedAt,
+		&userName,
+	)
+	if err != nil {
+		return l, err
+	}
+
+	if userName != nil && l.UserID != nil {
+		l.User = &models.User{BaseModel: models.BaseModel{ID: *l.UserID}}
+		l.User.FullName.String = *userName
+		l.User.FullName.Valid = true
+	}
+	return l, nil
+}
+
+// List retrieves audit logs with pagination
+func (r *AuditRepository) List(ctx context.Context, orgID uuid.UUID, p Pagination, filters map[string]interface{}) (*PaginatedResult[models.AuditLog], error) {
+	qb := auditLogQuery(orgID, filters)
 
 	// Default sort: newest first
 	if p.Sort == "" {
-		p.Sort = "a.created_at"
+		p.Sort = "created_at"
 		p.Order = "desc"
 	}
 	qb.Paginate(p)
@@ -94,13 +148,13 @@ func (r *AuditRepository) List(ctx context.Context, orgID uuid.UUID, p Paginatio
 	// Count
 	countQuery, countArgs := qb.BuildCount()
 	var total int64
-	if err := r.pool.QueryRow(ctx, countQuery, countArgs...).Scan(&total); err != nil {
+	if err := r.reader().QueryRow(ctx, countQuery, countArgs...).Scan(&total); err != nil {
 		return nil, err
 	}
 
 	// Data
 	query, args := qb.Build()
-	rows, err := r.pool.Query(ctx, query, args...)
+	rows, err := r.reader().Query(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -108,28 +162,10 @@ func (r *AuditRepository) List(ctx context.Context, orgID uuid.UUID, p Paginatio
 
 	var logs []models.AuditLog
 	for rows.Next() {
-		var l models.AuditLog
-		var userName *string
-
-		err := rows.Scan(
-			&l.ID, &l.OrganizationID,
-			&l.UserID, &l.UserEmail, &l.UserIP, &l.UserAgent,
-			&l.Action, &l.ResourceType, &l.ResourceID, &l.ResourceName,
-			&l.OldValues, &l.NewValues, &l.ChangedFields,
-			&l.Description, &l.Metadata,
-			&l.CreatedAt,
-			&userName,
-		)
+		l, err := scanAuditLog(rows)
 		if err != nil {
 			return nil, err
 		}
-
-		if userName != nil && l.UserID != nil {
-			l.User = &models.User{BaseModel: models.BaseModel{ID: *l.UserID}}
-			l.User.FullName.String = *userName
-			l.User.FullName.Valid = true
-		}
-
 		logs = append(logs, l)
 	}
 
@@ -147,6 +183,80 @@ func (r *AuditRepository) List(ctx context.Context, orgID uuid.UUID, p Paginatio
 	}, nil
 }
 
+// GetByID retrieves one of the organization's audit logs
+func (r *AuditRepository) GetByID(ctx context.Context, orgID, id uuid.UUID) (*models.AuditLog, error) {
+	qb := auditLogQuery(orgID, nil)
+	qb.Where("a.id = ?", id)
+
+	query, args := qb.Build()
+	rows, err := r.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		return nil, rows.Err()
+	}
+	l, err := scanAuditLog(rows)
+	if err != nil {
+		return nil, err
+	}
+	return &l, nil
+}
+
+// Each calls fn for every audit log matching filters, oldest first, without
+// loading them all into memory. It stops at the first error fn returns.
+func (r *AuditRepository) Each(ctx context.Context, orgID uuid.UUID, filters map[string]interface{}, fn func(*models.AuditLog) error) error {
+	qb := auditLogQuery(orgID, filters)
+	qb.OrderBy("created_at", "asc")
+
+	query, args := qb.Build()
+	rows, err := r.reader().Query(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		l, err := scanAuditLog(rows)
+		if err != nil {
+			return err
+		}
+		if err := fn(&l); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// ListAfter retrieves up to limit audit logs matching filters that were
+// recorded after the log with afterTime and afterID, oldest first. Logs are
+// ordered by time and then ID, so consumers can page through them by the
+// last log they read even when logs share a timestamp.
+func (r *AuditRepository) ListAfter(ctx context.Context, orgID uuid.UUID, filters map[string]interface{}, afterTime time.Time, afterID uuid.UUID, limit int) ([]models.AuditLog, error) {
+	qb := auditLogQuery(orgID, filters)
+	qb.Where("(a.created_at, a.id) > (?, ?)", afterTime, afterID)
+	qb.OrderBy("created_at", "asc").ThenBy("id", "asc").Limit(limit)
+
+	query, args := qb.Build()
+	rows, err := r.reader().Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	logs := make([]models.AuditLog, 0, limit)
+	for rows.Next() {
+		l, err := scanAuditLog(rows)
+		if err != nil {
+			return nil, err
+		}
+		logs = append(logs, l)
+	}
+	return logs, rows.Err()
+}
+
 // ListByResource retrieves audit logs for a specific resource
 func (r *AuditRepository) ListByResource(ctx context.Context, resourceType string, resourceID uuid.UUID, limit int) ([]models.AuditLog, error) {
 	query := `
@@ -165,7 +275,7 @@ func (r *AuditRepository) ListByResource(ctx context.Context, resourceType strin
 		LIMIT $3
 	`
 
-	rows, err := r.pool.Query(ctx, query, resourceType, resourceID, limit)
+	rows, err := r.reader().Query(ctx, query, resourceType, resourceID, limit)
 	if err != nil {
 		return nil, err
 	}
@@ -218,7 +328,7 @@ func (r *AuditRepository) GetRecentActivities(ctx context.Context, orgID uuid.UU
 		LIMIT $2
 	`
 
-	rows, err := r.pool.Query(ctx, query, orgID, limit)
+	rows, err := r.reader().Query(ctx, query, orgID, limit)
 	if err != nil {
 		return nil, err
 	}
@@ -252,3 +362,255 @@ func (r *AuditRepository) GetRecentActivities(ctx context.Context, orgID uuid.UU
 
 	return logs, nil
 }
+
+// auditPartitionLayout is the time layout of monthly audit_logs partition names
+const auditPartitionLayout = "audit_logs_y2006m01"
+
+// EnsurePartitions creates monthly audit_logs partitions from the month of
+// from up to monthsAhead months later. Existing partitions are left untouched.
+func (r *AuditRepository) EnsurePartitions(ctx context.Context, from time.Time, monthsAhead int) error {
+	start := time.Date(from.Year(), from.Month(), 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i <= monthsAhead; i++ {
+		month := start.AddDate(0, i, 0)
+		if _, err := r.pool.Exec(ctx, "SELECT create_audit_log_partition($1)", month); err != nil {
+			return fmt.Errorf("failed to create audit partition for %s: %w", month.Format("2006-01"), err)
+		}
+	}
+	return nil
+}
+
+// ListPartitions returns the months covered by existing audit_logs partitions
+func (r *AuditRepository) ListPartitions(ctx context.Context) (map[string]time.Time, error) {
+	query := `
+		SELECT c.relname
+		FROM pg_inherits i
+		JOIN pg_class c ON c.oid = i.inhrelid
+		JOIN pg_class p ON p.oid = i.inhparent
+		WHERE p.relname = 'audit_logs'
+	`
+
+	rows, err := r.pool.Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	partitions := make(map[string]time.Time)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		// Skips the default partition and anything not created by us
+		month, err := time.Parse(auditPartitionLayout, name)
+		if err != nil {
+			continue
+		}
+		partitions[name] = month
+	}
+	return partitions, rows.Err()
+}
+
+// DropPartition drops a monthly partition
+func (r *AuditRepository) DropPartition(ctx context.Context, name string) error {
+	if _, err := r.pool.Exec(ctx, "DROP TABLE IF EXISTS "+pgx.Identifier{name}.Sanitize()); err != nil {
+		return fmt.Errorf("failed to drop audit partition %s: %w", name, err)
+	}
+	return nil
+}
+
+// PartitionOrganizations returns the organizations with audit logs in a
+// partition
+func (r *AuditRepository) PartitionOrganizations(ctx context.Context, partition string) ([]uuid.UUID, error) {
+	query := `SELECT DISTINCT organization_id FROM ` + pgx.Identifier{partition}.Sanitize()
+
+	rows, err := r.pool.Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, pgx.RowTo[uuid.UUID])
+}
+
+// EachInPartition calls fn for every audit log of the organization in a
+// partition, oldest first. It stops at the first error fn returns.
+func (r *AuditRepository) EachInPartition(ctx context.Context, partition string, orgID uuid.UUID, fn func(*models.AuditLog) error) error {
+	query := `SELECT ` + auditLogColumns + ` FROM ` + pgx.Identifier{partition}.Sanitize() + ` a
+		LEFT JOIN users u ON a.user_id = u.id
+		WHERE a.organization_id = $1
+		ORDER BY a.created_at`
+
+	rows, err := r.pool.Query(ctx, query, orgID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		l, err := scanAuditLog(rows)
+		if err != nil {
+			return err
+		}
+		if err := fn(&l); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// auditRestoredKey is the metadata key marking audit logs restored from an
+// archive, holding when they were restored
+const auditRestoredKey = "restored_at"
+
+// Restore inserts audit logs read back from an archive. Logs that are
+// already present are skipped. It returns the number of inserted logs.
+func (r *AuditRepository) Restore(ctx context.Context, logs []models.AuditLog, restoredAt time.Time) (int64, error) {
+	query := `
+		INSERT INTO audit_logs (
+			id, organization_id,
+			user_id, user_email, user_ip, user_agent,
+			action, resource_type, resource_id, resource_name,
+			old_values, new_values, changed_fields,
+			description, metadata,
+			created_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14,
+			COALESCE($15::jsonb, '{}'::jsonb) || jsonb_build_object('` + auditRestoredKey + `', $16::timestamptz), $17)
+		ON CONFLICT DO NOTHING
+	`
+
+	batch := &pgx.Batch{}
+	for _, l := range logs {
+		batch.Queue(query,
+			l.ID, l.OrganizationID,
+			l.UserID, l.UserEmail, l.UserIP, l.UserAgent,
+			l.Action, l.ResourceType, l.ResourceID, l.ResourceName,
+			l.OldValues, l.NewValues, l.ChangedFields,
+			l.Description, l.Metadata, restoredAt,
+			l.CreatedAt,
+		)
+	}
+
+	var inserted int64
+	err := runInTx(ctx, r.pool, func(tx pgx.Tx) error {
+		results := tx.SendBatch(ctx, batch)
+		defer results.Close()
+
+		for range logs {
+			tag, err := results.Exec()
+			if err != nil {
+				return fmt.Errorf("failed to restore audit log: %w", err)
+			}
+			inserted += tag.RowsAffected()
+		}
+		return results.Close()
+	})
+	if err != nil {
+		return 0, err
+	}
+	return inserted, nil
+}
+
+// DeleteRestoredBefore deletes audit logs restored from an archive before
+// cutoff and returns the number of deleted logs
+func (r *AuditRepository) DeleteRestoredBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+	query := `DELETE FROM audit_logs WHERE (metadata->>'` + auditRestoredKey + `')::timestamptz < $1`
+
+	result, err := r.pool.Exec(ctx, query, cutoff)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+// CreateArchive records an archived month, replacing an earlier record of
+// the same organization and month
+func (r *AuditRepository) CreateArchive(ctx context.Context, a *models.AuditLogArchive) error {
+	query := `
+		INSERT INTO audit_log_archives (id, organization_id, month, object_key, entry_count, size_bytes, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, NOW())
+		ON CONFLICT (organization_id, month) DO UPDATE SET
+			object_key = EXCLUDED.object_key,
+			entry_count = EXCLUDED.entry_count,
+			size_bytes = EXCLUDED.size_bytes,
+			created_at = EXCLUDED.created_at
+		RETURNING id, created_at
+	`
+	return r.pool.QueryRow(ctx, query, uuid.New(), a.OrganizationID, a.Month, a.ObjectKey, a.EntryCount, a.SizeBytes).Scan(&a.ID, &a.CreatedAt)
+}
+
+// ListArchives returns the organization's archived months from from to to,
+// inclusive, oldest first. Zero times leave the range open.
+func (r *AuditRepository) ListArchives(ctx context.Context, orgID uuid.UUID, from, to time.Time) ([]models.AuditLogArchive, error) {
+	qb := NewQueryBuilder(`SELECT id, organization_id, month, object_key, entry_count, size_bytes, created_at FROM audit_log_archives`)
+	qb.Where("organization_id = ?", orgID)
+	qb.WhereIf(!from.IsZero(), "month >= ?", from)
+	qb.WhereIf(!to.IsZero(), "month <= ?", to)
+
+	query, args := qb.Build()
+	rows, err := r.reader().Query(ctx, query+" ORDER BY month", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	archives := make([]models.AuditLogArchive, 0)
+	for rows.Next() {
+		var a models.AuditLogArchive
+		if err := rows.Scan(&a.ID, &a.OrganizationID, &a.Month, &a.ObjectKey, &a.EntryCount, &a.SizeBytes, &a.CreatedAt); err != nil {
+			return nil, err
+		}
+		archives = append(archives, a)
+	}
+	return archives, rows.Err()
+}
+
+// AppendLedger appends an audit log, encoded as JSON, to the append-only
+// audit log ledger
+func (r *AuditRepository) AppendLedger(ctx context.Context, l *models.AuditLog, entry []byte) error {
+	query := `
+		INSERT INTO audit_log_ledger (organization_id, audit_log_id, entry, created_at)
+		VALUES ($1, $2, $3, $4)
+	`
+	_, err := r.pool.Exec(ctx, query, l.OrganizationID, l.ID, entry, l.CreatedAt)
+	return err
+}
+
+// AuthAnomalies groups the organization's authentication failures since
+// since by IP address or by account, as kind selects, and returns the groups
+// with at least threshold failures, most failures first
+func (r *AuditRepository) AuthAnomalies(ctx context.Context, orgID uuid.UUID, kind string, since time.Time, threshold int) ([]models.AuthAnomaly, error) {
+	column, other := "user_ip", "user_email"
+	if kind == models.AuthAnomalyAccount {
+		column, other = "user_email", "user_ip"
+	}
+
+	query := fmt.Sprintf(`
+		SELECT %[1]s,
+			COUNT(*) FILTER (WHERE action <> $4),
+			COUNT(*) FILTER (WHERE action = $4),
+			COUNT(DISTINCT %[2]s),
+			MIN(created_at), MAX(created_at)
+		FROM audit_logs
+		WHERE organization_id = $1 AND resource_type = $2 AND created_at >= $3
+			AND %[1]s IS NOT NULL AND %[1]s <> ''
+		GROUP BY %[1]s
+		HAVING COUNT(*) FILTER (WHERE action <> $4) >= $5
+		ORDER BY 2 DESC
+		LIMIT 100
+	`, column, other)
+
+	rows, err := r.reader().Query(ctx, query, orgID, models.AuthAuditResource, since, models.AuthActionLockout, threshold)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	anomalies := []models.AuthAnomaly{}
+	for rows.Next() {
+		a := models.AuthAnomaly{Kind: kind}
+		if err := rows.Scan(&a.Value, &a.Failures, &a.Lockouts, &a.Distinct, &a.FirstSeen, &a.LastSeen); err != nil {
+			return nil, err
+		}
+		anomalies = append(anomalies, a)
+	}
+	return anomalies, rows.Err()
+}
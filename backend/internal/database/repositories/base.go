@@ -7,9 +7,19 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
-	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jackc/pgx/v5/pgconn"
 )
 
+// DBTX is the subset of pgx behaviour shared by *pgxpool.Pool and pgx.Tx.
+// Repositories run their queries against a DBTX so the same repository code
+// can execute either directly on the pool or inside a transaction.
+type DBTX interface {
+	Exec(ctx context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row
+	Begin(ctx context.Context) (pgx.Tx, error)
+}
+
 // Pagination holds pagination parameters
 type Pagination struct {
 	Page     int    `json:"page"`
@@ -36,13 +46,15 @@ type Filter struct {
 
 // QueryBuilder helps build SQL queries
 type QueryBuilder struct {
-	baseQuery  string
-	conditions []string
-	args       []interface{}
-	argCounter int
-	orderBy    string
-	limit      int
-	offset     int
+	baseQuery   string
+	conditions  []string
+	args        []interface{}
+	argCounter  int
+	orderBy     string
+	sortAlias   string
+	sortColumns map[string]string
+	limit       int
+	offset      int
 }
 
 // NewQueryBuilder creates a new query builder
@@ -97,6 +109,7 @@ var allowedSortFields = map[string]bool{
 	"action":          true,
 	"resource_type":   true,
 	"timestamp":       true,
+	"is_critical":     true,
 }
 
 // allowedTableNames defines valid table names to prevent SQL injection
@@ -121,19 +134,59 @@ func validateTableName(table string) bool {
 	return allowedTableNames[table]
 }
 
+// SortAlias qualifies sort fields with a table alias, which is required
+// once the base query joins other tables with overlapping column names
+func (qb *QueryBuilder) SortAlias(alias string) *QueryBuilder {
+	qb.sortAlias = alias
+	return qb
+}
+
+// SortColumn lets lists sort by field on a column outside the sort alias,
+// such as one computed by a lateral join
+func (qb *QueryBuilder) SortColumn(field, column string) *QueryBuilder {
+	if qb.sortColumns == nil {
+		qb.sortColumns = make(map[string]string)
+	}
+	qb.sortColumns[field] = column
+	return qb
+}
+
 // OrderBy sets the ORDER BY clause with SQL injection protection
 func (qb *QueryBuilder) OrderBy(field, order string) *QueryBuilder {
+	qb.orderBy = qb.sortClause(field, order)
+	return qb
+}
+
+// ThenBy sorts rows that tie on the fields already ordered by field
+func (qb *QueryBuilder) ThenBy(field, order string) *QueryBuilder {
+	if qb.orderBy == "" {
+		return qb.OrderBy(field, order)
+	}
+	qb.orderBy += ", " + qb.sortClause(field, order)
+	return qb
+}
+
+// sortClause validates a sort field and direction against the whitelist
+func (qb *QueryBuilder) sortClause(field, order string) string {
 	// Validate order direction
 	if order != "asc" && order != "desc" {
 		order = "asc"
 	}
+	if column, ok := qb.sortColumns[field]; ok {
+		return fmt.Sprintf("%s %s", column, order)
+	}
+	if qb.sortAlias != "" {
+		field = strings.TrimPrefix(field, qb.sortAlias+".")
+	}
 	// Validate field against whitelist to prevent SQL injection
 	if !allowedSortFields[field] {
 		// Default to safe field if invalid field provided
 		field = "created_at"
 	}
-	qb.orderBy = fmt.Sprintf("%s %s", field, order)
-	return qb
+	if qb.sortAlias != "" {
+		field = qb.sortAlias + "." + field
+	}
+	return fmt.Sprintf("%s %s", field, order)
 }
 
 // Limit sets the LIMIT clause
@@ -228,14 +281,53 @@ func countOffsetArgs(qb *QueryBuilder) int {
 
 // BaseRepository provides common database operations
 type BaseRepository struct {
-	pool *pgxpool.Pool
+	pool DBTX
+	read DBTX
 }
 
 // NewBaseRepository creates a new base repository
-func NewBaseRepository(pool *pgxpool.Pool) *BaseRepository {
+func NewBaseRepository(pool DBTX) *BaseRepository {
 	return &BaseRepository{pool: pool}
 }
 
+// SetReadReplica routes read-heavy queries (lists, reports, dashboard) to the
+// given read-only connection. Writes and single-row lookups stay on the primary.
+func (r *BaseRepository) SetReadReplica(read DBTX) {
+	r.read = read
+}
+
+// reader returns the connection used for read-heavy queries
+func (r *BaseRepository) reader() DBTX {
+	if r.read != nil {
+		return r.read
+	}
+	return r.pool
+}
+
+// RunInTx executes fn inside a transaction. The transaction is committed when
+// fn returns nil and rolled back otherwise. When the repository is already
+// bound to a transaction, a savepoint is used instead.
+func (r *BaseRepository) RunInTx(ctx context.Context, fn func(tx pgx.Tx) error) error {
+	return runInTx(ctx, r.pool, fn)
+}
+
+func runInTx(ctx context.Context, db DBTX, fn func(tx pgx.Tx) error) error {
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
 // GetByID retrieves a record by ID
 func (r *BaseRepository) GetByID(ctx context.Context, table string, id uuid.UUID, dest interface{}) error {
 	query := fmt.Sprintf("SELECT * FROM %s WHERE id = $1 AND deleted_at IS NULL", table)
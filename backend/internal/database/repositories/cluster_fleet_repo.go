@@ -0,0 +1,278 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/kubeatlas/kubeatlas/internal/models"
+)
+
+// ClusterFleetRepository stores cluster fleets and their clusters
+type ClusterFleetRepository struct {
+	*BaseRepository
+	pool DBTX
+}
+
+// NewClusterFleetRepository creates a new cluster fleet repository
+func NewClusterFleetRepository(pool DBTX) *ClusterFleetRepository {
+	return &ClusterFleetRepository{
+		BaseRepository: NewBaseRepository(pool),
+		pool:           pool,
+	}
+}
+
+// fleetColumns selects a fleet as f with its owner team as t and its
+// cluster count as cc
+const fleetColumns = `
+	f.id, f.organization_id, f.name, f.slug, f.description,
+	f.owner_team_id, f.created_at, f.updated_at,
+	t.name, t.slug, cc.cluster_count
+`
+
+const fleetFrom = `
+	FROM cluster_fleets f
+	LEFT JOIN teams t ON t.id = f.owner_team_id AND t.deleted_at IS NULL
+	CROSS JOIN LATERAL (
+		SELECT COUNT(*)::int AS cluster_count
+		FROM cluster_fleet_members m
+		JOIN clusters c ON c.id = m.cluster_id AND c.deleted_at IS NULL
+		WHERE m.fleet_id = f.id
+	) cc`
+
+func scanFleet(row pgx.Row, f *models.ClusterFleet) error {
+	var teamName, teamSlug *string
+	if err := row.Scan(
+		&f.ID, &f.OrganizationID, &f.Name, &f.Slug, &f.Description,
+		&f.OwnerTeamID, &f.CreatedAt, &f.UpdatedAt,
+		&teamName, &teamSlug, &f.ClusterCount,
+	); err != nil {
+		return err
+	}
+	if teamName != nil && f.OwnerTeamID != nil {
+		f.OwnerTeam = &models.Team{BaseModel: models.BaseModel{ID: *f.OwnerTeamID}, OrganizationID: f.OrganizationID, Name: *teamName}
+		if teamSlug != nil {
+			f.OwnerTeam.Slug = *teamSlug
+		}
+	}
+	return nil
+}
+
+// Create creates a fleet
+func (r *ClusterFleetRepository) Create(ctx context.Context, f *models.ClusterFleet) error {
+	f.ID = uuid.New()
+	f.CreatedAt = time.Now()
+	f.UpdatedAt = f.CreatedAt
+
+	_, err := r.pool.Exec(ctx, `
+		INSERT INTO cluster_fleets (id, organization_id, name, slug, description, owner_team_id, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+		f.ID, f.OrganizationID, f.Name, f.Slug, f.Description, f.OwnerTeamID, f.CreatedAt, f.UpdatedAt,
+	)
+	return err
+}
+
+// GetByID retrieves a current fleet of the organization. Returns nil when
+// there is none.
+func (r *ClusterFleetRepository) GetByID(ctx context.Context, orgID, id uuid.UUID) (*models.ClusterFleet, error) {
+	query := `SELECT ` + fleetColumns + fleetFrom + `
+		WHERE f.id = $1 AND f.organization_id = $2 AND f.deleted_at IS NULL`
+
+	f := &models.ClusterFleet{}
+	err := scanFleet(r.pool.QueryRow(ctx, query, id, orgID), f)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// ExistsBySlug reports whether another current fleet of the organization
+// than exceptID has the slug
+func (r *ClusterFleetRepository) ExistsBySlug(ctx context.Context, orgID uuid.UUID, slug string, exceptID uuid.UUID) (bool, error) {
+	var exists bool
+	err := r.pool.QueryRow(ctx, `
+		SELECT EXISTS (
+			SELECT 1 FROM cluster_fleets
+			WHERE organization_id = $1 AND slug = $2 AND id <> $3 AND deleted_at IS NULL
+		)`, orgID, slug, exceptID).Scan(&exists)
+	return exists, err
+}
+
+// List returns the current fleets of the organization, by name.
+// filters["search"] matches names and slugs, filters["cluster_id"] the
+// fleets of a cluster.
+func (r *ClusterFleetRepository) List(ctx context.Context, orgID uuid.UUID, p Pagination, filters map[string]interface{}) (*PaginatedResult[models.ClusterFleet], error) {
+	qb := NewQueryBuilder(`SELECT ` + fleetColumns + fleetFrom)
+	qb.SortAlias("f").SortColumn("cluster_count", "cc.cluster_count")
+
+	qb.Where("f.organization_id = ?", orgID)
+	qb.Where("f.deleted_at IS NULL")
+	if search, ok := filters["search"].(string); ok && search != "" {
+		pattern := searchPattern(search)
+		qb.Where("(f.name ILIKE ? OR f.slug ILIKE ?)", pattern, pattern)
+	}
+	if clusterID, ok := filters["cluster_id"].(uuid.UUID); ok {
+		qb.Where("f.id IN (SELECT fleet_id FROM cluster_fleet_members WHERE cluster_id = ?)", clusterID)
+	}
+
+	if p.Sort == "" {
+		p.Sort = "name"
+		p.Order = "asc"
+	}
+	qb.Paginate(p)
+	qb.ThenBy("id", "asc")
+
+	countQuery, countArgs := qb.BuildCount()
+	var total int64
+	if err := r.reader().QueryRow(ctx, countQuery, countArgs...).Scan(&total); err != nil {
+		return nil, fmt.Errorf("failed to count cluster fleets: %w", err)
+	}
+
+	query, args := qb.Build()
+	rows, err := r.reader().Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query cluster fleets: %w", err)
+	}
+	defer rows.Close()
+
+	fleets := make([]models.ClusterFleet, 0)
+	for rows.Next() {
+		var f models.ClusterFleet
+		if err := scanFleet(rows, &f); err != nil {
+			return nil, fmt.Errorf("failed to scan cluster fleet: %w", err)
+		}
+		fleets = append(fleets, f)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	totalPages := int(total) / p.PageSize
+	if int(total)%p.PageSize > 0 {
+		totalPages++
+	}
+
+	return &PaginatedResult[models.ClusterFleet]{
+		Items:      fleets,
+		Total:      total,
+		Page:       p.Page,
+		PageSize:   p.PageSize,
+		TotalPages: totalPages,
+	}, nil
+}
+
+// Update updates the details and owner of a fleet
+func (r *ClusterFleetRepository) Update(ctx context.Context, f *models.ClusterFleet) error {
+	f.UpdatedAt = time.Now()
+
+	result, err := r.pool.Exec(ctx, `
+		UPDATE cluster_fleets SET
+			name = $2, slug = $3, description = $4, owner_team_id = $5, updated_at = $6
+		WHERE id = $1 AND deleted_at IS NULL`,
+		f.ID, f.Name, f.Slug, f.Description, f.OwnerTeamID, f.UpdatedAt,
+	)
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+// Delete soft deletes a fleet and releases its clusters
+func (r *ClusterFleetRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	return r.RunInTx(ctx, func(tx pgx.Tx) error {
+		result, err := tx.Exec(ctx, `UPDATE cluster_fleets SET deleted_at = NOW() WHERE id = $1 AND deleted_at IS NULL`, id)
+		if err != nil {
+			return err
+		}
+		if result.RowsAffected() == 0 {
+			return pgx.ErrNoRows
+		}
+		_, err = tx.Exec(ctx, `DELETE FROM cluster_fleet_members WHERE fleet_id = $1`, id)
+		return err
+	})
+}
+
+// AddClusters adds clusters of the organization to a fleet. Clusters
+// already in the fleet are left as they are.
+func (r *ClusterFleetRepository) AddClusters(ctx context.Context, orgID, id uuid.UUID, clusterIDs []uuid.UUID) error {
+	_, err := r.pool.Exec(ctx, `
+		INSERT INTO cluster_fleet_members (fleet_id, cluster_id, organization_id)
+		SELECT $1, unnest($2::uuid[]), $3
+		ON CONFLICT (fleet_id, cluster_id) DO NOTHING`,
+		id, clusterIDs, orgID)
+	return err
+}
+
+// RemoveCluster removes a cluster from a fleet. Returns false when it did
+// not belong to the fleet.
+func (r *ClusterFleetRepository) RemoveCluster(ctx context.Context, id, clusterID uuid.UUID) (bool, error) {
+	result, err := r.pool.Exec(ctx,
+		`DELETE FROM cluster_fleet_members WHERE fleet_id = $1 AND cluster_id = $2`, id, clusterID)
+	if err != nil {
+		return false, err
+	}
+	return result.RowsAffected() > 0, nil
+}
+
+// ListClusters returns the current clusters of a fleet by name, with their
+// identity, environment, version, status and counts loaded
+func (r *ClusterFleetRepository) ListClusters(ctx context.Context, id uuid.UUID) ([]models.Cluster, error) {
+	rows, err := r.reader().Query(ctx, `
+		SELECT
+			c.id, c.organization_id, c.name, c.display_name, c.cluster_type, c.environment, c.version,
+			c.status, c.last_sync_at, c.sync_error_category, c.consecutive_failures,
+			c.node_count, c.namespace_count
+		FROM cluster_fleet_members m
+		JOIN clusters c ON c.id = m.cluster_id AND c.deleted_at IS NULL
+		WHERE m.fleet_id = $1
+		ORDER BY c.name`, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list fleet clusters: %w", err)
+	}
+	defer rows.Close()
+
+	clusters := make([]models.Cluster, 0)
+	for rows.Next() {
+		var c models.Cluster
+		if err := rows.Scan(
+			&c.ID, &c.OrganizationID, &c.Name, &c.DisplayName, &c.ClusterType, &c.Environment, &c.Version,
+			&c.Status, &c.LastSyncAt, &c.SyncErrorCategory, &c.ConsecutiveFailures,
+			&c.NodeCount, &c.NamespaceCount,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan fleet cluster: %w", err)
+		}
+		clusters = append(clusters, c)
+	}
+	return clusters, rows.Err()
+}
+
+// ClusterNames returns the names of the current clusters of the
+// organization among ids, by ID
+func (r *ClusterFleetRepository) ClusterNames(ctx context.Context, orgID uuid.UUID, ids []uuid.UUID) (map[uuid.UUID]string, error) {
+	rows, err := r.reader().Query(ctx, `
+		SELECT id, name FROM clusters
+		WHERE organization_id = $1 AND id = ANY($2) AND deleted_at IS NULL`, orgID, ids)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	names := make(map[uuid.UUID]string, len(ids))
+	for rows.Next() {
+		var id uuid.UUID
+		var name string
+		if err := rows.Scan(&id, &name); err != nil {
+			return nil, err
+		}
+		names[id] = name
+	}
+	return names, rows.Err()
+}
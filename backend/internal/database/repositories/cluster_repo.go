@@ -7,18 +7,18 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
-	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/kubeatlas/kubeatlas/internal/models"
+	"k8s.io/apimachinery/pkg/labels"
 )
 
 // ClusterRepository handles cluster database operations
 type ClusterRepository struct {
 	*BaseRepository
-	pool *pgxpool.Pool
+	pool DBTX
 }
 
 // NewClusterRepository creates a new cluster repository
-func NewClusterRepository(pool *pgxpool.Pool) *ClusterRepository {
+func NewClusterRepository(pool DBTX) *ClusterRepository {
 	return &ClusterRepository{
 		BaseRepository: NewBaseRepository(pool),
 		pool:           pool,
@@ -35,7 +35,7 @@ func (r *ClusterRepository) Create(ctx context.Context, cluster *models.Cluster)
 		INSERT INTO clusters (
 			id, organization_id, name, display_name, description,
 			api_server_url, cluster_type, version, platform, region, environment,
-			auth_method, kubeconfig_encrypted, service_account_token_encrypted, ca_certificate_encrypted, skip_tls_verify,
+			auth_method, kubeconfig_encrypted, service_account_token_encrypted, ca_certificate_encrypted, skip_tls_verify, fallback_api_server_urls,
 			owner_team_id, responsible_user_id,
 			status, node_count, namespace_count,
 			tags, labels, annotations, metadata,
@@ -43,18 +43,18 @@ func (r *ClusterRepository) Create(ctx context.Context, cluster *models.Cluster)
 		) VALUES (
 			$1, $2, $3, $4, $5,
 			$6, $7, $8, $9, $10, $11,
-			$12, $13, $14, $15, $16,
-			$17, $18,
-			$19, $20, $21,
-			$22, $23, $24, $25,
-			$26, $27
+			$12, $13, $14, $15, $16, $17,
+			$18, $19,
+			$20, $21, $22,
+			$23, $24, $25, $26,
+			$27, $28
 		)
 	`
 
 	_, err := r.pool.Exec(ctx, query,
 		cluster.ID, cluster.OrganizationID, cluster.Name, cluster.DisplayName, cluster.Description,
 		cluster.APIServerURL, cluster.ClusterType, cluster.Version, cluster.Platform, cluster.Region, cluster.Environment,
-		cluster.AuthMethod, cluster.KubeconfigEncrypted, cluster.ServiceAccountTokenEncrypted, cluster.CACertificateEncrypted, cluster.SkipTLSVerify,
+		cluster.AuthMethod, cluster.KubeconfigEncrypted, cluster.ServiceAccountTokenEncrypted, cluster.CACertificateEncrypted, cluster.SkipTLSVerify, cluster.FallbackAPIServerURLs,
 		cluster.OwnerTeamID, cluster.ResponsibleUserID,
 		cluster.Status, cluster.NodeCount, cluster.NamespaceCount,
 		cluster.Tags, cluster.Labels, cluster.Annotations, cluster.Metadata,
@@ -70,9 +70,9 @@ func (r *ClusterRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.
 		SELECT 
 			id, organization_id, name, display_name, description,
 			api_server_url, cluster_type, version, platform, region, environment,
-			auth_method, kubeconfig_encrypted, service_account_token_encrypted, ca_certificate_encrypted, skip_tls_verify,
+			auth_method, kubeconfig_encrypted, service_account_token_encrypted, ca_certificate_encrypted, skip_tls_verify, fallback_api_server_urls, active_api_server_url,
 			owner_team_id, responsible_user_id,
-			status, last_sync_at, sync_error,
+			status, last_sync_at, sync_error, sync_error_category, consecutive_failures, last_alerted_at,
 			node_count, namespace_count,
 			tags, labels, annotations, metadata,
 			created_at, updated_at, deleted_at
@@ -84,9 +84,9 @@ func (r *ClusterRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.
 	err := r.pool.QueryRow(ctx, query, id).Scan(
 		&cluster.ID, &cluster.OrganizationID, &cluster.Name, &cluster.DisplayName, &cluster.Description,
 		&cluster.APIServerURL, &cluster.ClusterType, &cluster.Version, &cluster.Platform, &cluster.Region, &cluster.Environment,
-		&cluster.AuthMethod, &cluster.KubeconfigEncrypted, &cluster.ServiceAccountTokenEncrypted, &cluster.CACertificateEncrypted, &cluster.SkipTLSVerify,
+		&cluster.AuthMethod, &cluster.KubeconfigEncrypted, &cluster.ServiceAccountTokenEncrypted, &cluster.CACertificateEncrypted, &cluster.SkipTLSVerify, &cluster.FallbackAPIServerURLs, &cluster.ActiveAPIServerURL,
 		&cluster.OwnerTeamID, &cluster.ResponsibleUserID,
-		&cluster.Status, &cluster.LastSyncAt, &cluster.SyncError,
+		&cluster.Status, &cluster.LastSyncAt, &cluster.SyncError, &cluster.SyncErrorCategory, &cluster.ConsecutiveFailures, &cluster.LastAlertedAt,
 		&cluster.NodeCount, &cluster.NamespaceCount,
 		&cluster.Tags, &cluster.Labels, &cluster.Annotations, &cluster.Metadata,
 		&cluster.CreatedAt, &cluster.UpdatedAt, &cluster.DeletedAt,
@@ -108,9 +108,9 @@ func (r *ClusterRepository) GetByName(ctx context.Context, orgID uuid.UUID, name
 		SELECT 
 			id, organization_id, name, display_name, description,
 			api_server_url, cluster_type, version, platform, region, environment,
-			auth_method, kubeconfig_encrypted, service_account_token_encrypted, ca_certificate_encrypted, skip_tls_verify,
+			auth_method, kubeconfig_encrypted, service_account_token_encrypted, ca_certificate_encrypted, skip_tls_verify, fallback_api_server_urls, active_api_server_url,
 			owner_team_id, responsible_user_id,
-			status, last_sync_at, sync_error,
+			status, last_sync_at, sync_error, sync_error_category, consecutive_failures, last_alerted_at,
 			node_count, namespace_count,
 			tags, labels, annotations, metadata,
 			created_at, updated_at, deleted_at
@@ -122,9 +122,9 @@ func (r *ClusterRepository) GetByName(ctx context.Context, orgID uuid.UUID, name
 	err := r.pool.QueryRow(ctx, query, orgID, name).Scan(
 		&cluster.ID, &cluster.OrganizationID, &cluster.Name, &cluster.DisplayName, &cluster.Description,
 		&cluster.APIServerURL, &cluster.ClusterType, &cluster.Version, &cluster.Platform, &cluster.Region, &cluster.Environment,
-		&cluster.AuthMethod, &cluster.KubeconfigEncrypted, &cluster.ServiceAccountTokenEncrypted, &cluster.CACertificateEncrypted, &cluster.SkipTLSVerify,
+		&cluster.AuthMethod, &cluster.KubeconfigEncrypted, &cluster.ServiceAccountTokenEncrypted, &cluster.CACertificateEncrypted, &cluster.SkipTLSVerify, &cluster.FallbackAPIServerURLs, &cluster.ActiveAPIServerURL,
 		&cluster.OwnerTeamID, &cluster.ResponsibleUserID,
-		&cluster.Status, &cluster.LastSyncAt, &cluster.SyncError,
+		&cluster.Status, &cluster.LastSyncAt, &cluster.SyncError, &cluster.SyncErrorCategory, &cluster.ConsecutiveFailures, &cluster.LastAlertedAt,
 		&cluster.NodeCount, &cluster.NamespaceCount,
 		&cluster.Tags, &cluster.Labels, &cluster.Annotations, &cluster.Metadata,
 		&cluster.CreatedAt, &cluster.UpdatedAt, &cluster.DeletedAt,
@@ -140,15 +140,39 @@ func (r *ClusterRepository) GetByName(ctx context.Context, orgID uuid.UUID, name
 	return cluster, nil
 }
 
+// ListNamesByAPIServerURL returns the names of the clusters of an
+// organization registered with an API server URL, ignoring a trailing slash
+func (r *ClusterRepository) ListNamesByAPIServerURL(ctx context.Context, orgID uuid.UUID, apiServerURL string) ([]string, error) {
+	rows, err := r.reader().Query(ctx, `
+		SELECT name
+		FROM clusters
+		WHERE organization_id = $1 AND RTRIM(api_server_url, '/') = RTRIM($2, '/') AND deleted_at IS NULL
+		ORDER BY name`, orgID, apiServerURL)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	names := make([]string, 0)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+	}
+	return names, rows.Err()
+}
+
 // List retrieves clusters with pagination and filters
 func (r *ClusterRepository) List(ctx context.Context, orgID uuid.UUID, p Pagination, filters map[string]interface{}) (*PaginatedResult[models.Cluster], error) {
 	qb := NewQueryBuilder(`
 		SELECT 
 			id, organization_id, name, display_name, description,
 			api_server_url, cluster_type, version, platform, region, environment,
-			auth_method, skip_tls_verify,
+			auth_method, skip_tls_verify, fallback_api_server_urls, active_api_server_url,
 			owner_team_id, responsible_user_id,
-			status, last_sync_at, sync_error,
+			status, last_sync_at, sync_error, sync_error_category, consecutive_failures, last_alerted_at,
 			node_count, namespace_count,
 			tags, labels, annotations, metadata,
 			created_at, updated_at
@@ -168,9 +192,25 @@ func (r *ClusterRepository) List(ctx context.Context, orgID uuid.UUID, p Paginat
 	if environment, ok := filters["environment"].(string); ok && environment != "" {
 		qb.Where("environment = ?", environment)
 	}
+	if category, ok := filters["sync_error_category"].(string); ok && category != "" {
+		qb.Where("sync_error_category = ?", category)
+	}
 	if search, ok := filters["search"].(string); ok && search != "" {
 		qb.Where("(name ILIKE ? OR display_name ILIKE ?)", "%"+search+"%", "%"+search+"%")
 	}
+	// Kubernetes minor versions, such as 1.30, to include or leave out
+	if minors, ok := filters["minor_versions"].([]string); ok {
+		qb.Where(minorVersionExpr+" = ANY(?)", minors)
+	}
+	if minors, ok := filters["exclude_minor_versions"].([]string); ok {
+		qb.Where("("+minorVersionExpr+" IS NULL OR NOT "+minorVersionExpr+" = ANY(?))", minors)
+	}
+	if fleetID, ok := filters["fleet_id"].(uuid.UUID); ok {
+		qb.Where("id IN (SELECT cluster_id FROM cluster_fleet_members WHERE fleet_id = ?)", fleetID)
+	}
+	if selector, ok := filters["label_selector"].(labels.Selector); ok {
+		whereLabelSelector(qb, "labels", selector)
+	}
 
 	// Default sort
 	if p.Sort == "" {
@@ -183,13 +223,13 @@ func (r *ClusterRepository) List(ctx context.Context, orgID uuid.UUID, p Paginat
 	// Get total count
 	countQuery, countArgs := qb.BuildCount()
 	var total int64
-	if err := r.pool.QueryRow(ctx, countQuery, countArgs...).Scan(&total); err != nil {
+	if err := r.reader().QueryRow(ctx, countQuery, countArgs...).Scan(&total); err != nil {
 		return nil, fmt.Errorf("failed to count clusters: %w", err)
 	}
 
 	// Get data
 	query, args := qb.Build()
-	rows, err := r.pool.Query(ctx, query, args...)
+	rows, err := r.reader().Query(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query clusters: %w", err)
 	}
@@ -201,9 +241,9 @@ func (r *ClusterRepository) List(ctx context.Context, orgID uuid.UUID, p Paginat
 		err := rows.Scan(
 			&c.ID, &c.OrganizationID, &c.Name, &c.DisplayName, &c.Description,
 			&c.APIServerURL, &c.ClusterType, &c.Version, &c.Platform, &c.Region, &c.Environment,
-			&c.AuthMethod, &c.SkipTLSVerify,
+			&c.AuthMethod, &c.SkipTLSVerify, &c.FallbackAPIServerURLs, &c.ActiveAPIServerURL,
 			&c.OwnerTeamID, &c.ResponsibleUserID,
-			&c.Status, &c.LastSyncAt, &c.SyncError,
+			&c.Status, &c.LastSyncAt, &c.SyncError, &c.SyncErrorCategory, &c.ConsecutiveFailures, &c.LastAlertedAt,
 			&c.NodeCount, &c.NamespaceCount,
 			&c.Tags, &c.Labels, &c.Annotations, &c.Metadata,
 			&c.CreatedAt, &c.UpdatedAt,
@@ -245,14 +285,15 @@ func (r *ClusterRepository) Update(ctx context.Context, cluster *models.Cluster)
 			environment = $10,
 			auth_method = $11,
 			skip_tls_verify = $12,
-			owner_team_id = $13,
-			responsible_user_id = $14,
-			status = $15,
-			tags = $16,
-			labels = $17,
-			annotations = $18,
-			metadata = $19,
-			updated_at = $20
+			fallback_api_server_urls = $13,
+			owner_team_id = $14,
+			responsible_user_id = $15,
+			status = $16,
+			tags = $17,
+			labels = $18,
+			annotations = $19,
+			metadata = $20,
+			updated_at = $21
 		WHERE id = $1 AND deleted_at IS NULL
 	`
 
@@ -269,6 +310,7 @@ func (r *ClusterRepository) Update(ctx context.Context, cluster *models.Cluster)
 		cluster.Environment,
 		cluster.AuthMethod,
 		cluster.SkipTLSVerify,
+		cluster.FallbackAPIServerURLs,
 		cluster.OwnerTeamID,
 		cluster.ResponsibleUserID,
 		cluster.Status,
@@ -290,16 +332,21 @@ func (r *ClusterRepository) Update(ctx context.Context, cluster *models.Cluster)
 	return nil
 }
 
-// UpdateSyncStatus updates cluster sync status
+// UpdateSyncStatus updates cluster sync status and clears the error category;
+// call RecordSyncError afterwards to categorize a failure. Becoming active
+// resets the failure count and alert state. It leaves updated_at alone, which
+// tracks configuration changes and keys the k8s client cache.
 func (r *ClusterRepository) UpdateSyncStatus(ctx context.Context, id uuid.UUID, status string, syncError string, nodeCount, namespaceCount int) error {
 	query := `
 		UPDATE clusters SET
 			status = $2,
 			sync_error = $3,
+			sync_error_category = NULL,
 			last_sync_at = NOW(),
 			node_count = $4,
 			namespace_count = $5,
-			updated_at = NOW()
+			consecutive_failures = CASE WHEN $2 = 'active' THEN 0 ELSE consecutive_failures END,
+			last_alerted_at = CASE WHEN $2 = 'active' THEN NULL ELSE last_alerted_at END
 		WHERE id = $1 AND deleted_at IS NULL
 	`
 
@@ -307,11 +354,318 @@ func (r *ClusterRepository) UpdateSyncStatus(ctx context.Context, id uuid.UUID,
 	return err
 }
 
+// IncrementFailures records a failed sync or probe and returns the number of
+// consecutive failures
+func (r *ClusterRepository) IncrementFailures(ctx context.Context, id uuid.UUID) (int, error) {
+	query := `
+		UPDATE clusters SET consecutive_failures = consecutive_failures + 1
+		WHERE id = $1 AND deleted_at IS NULL
+		RETURNING consecutive_failures
+	`
+
+	var failures int
+	err := r.pool.QueryRow(ctx, query, id).Scan(&failures)
+	if err == pgx.ErrNoRows {
+		return 0, nil
+	}
+	return failures, err
+}
+
+// ResetProbeFailures clears failures counted by connectivity probes. Clusters
+// whose last sync failed keep theirs until a sync succeeds.
+func (r *ClusterRepository) ResetProbeFailures(ctx context.Context, id uuid.UUID) error {
+	query := `
+		UPDATE clusters SET consecutive_failures = 0, last_alerted_at = NULL
+		WHERE id = $1 AND deleted_at IS NULL AND status <> 'error' AND consecutive_failures > 0
+	`
+	_, err := r.pool.Exec(ctx, query, id)
+	return err
+}
+
+// ClaimSyncAlert records that a failure alert is sent and reports whether the
+// caller should send it. With suppressRepeats, only the first alert of a
+// failure streak is claimed, plus one per repeatAfter when it is positive.
+func (r *ClusterRepository) ClaimSyncAlert(ctx context.Context, id uuid.UUID, suppressRepeats bool, repeatAfter time.Duration) (bool, error) {
+	query := `
+		UPDATE clusters SET last_alerted_at = NOW()
+		WHERE id = $1 AND deleted_at IS NULL
+		  AND (
+			NOT $2::boolean
+			OR last_alerted_at IS NULL
+			OR ($3::float8 > 0 AND last_alerted_at <= NOW() - make_interval(secs => $3::float8))
+		  )
+	`
+
+	result, err := r.pool.Exec(ctx, query, id, suppressRepeats, repeatAfter.Seconds())
+	if err != nil {
+		return false, err
+	}
+	return result.RowsAffected() == 1, nil
+}
+
+// RecordSyncError stores a categorized sync failure and marks the cluster with its category
+func (r *ClusterRepository) RecordSyncError(ctx context.Context, syncErr *models.ClusterSyncError) error {
+	syncErr.ID = uuid.New()
+	syncErr.CreatedAt = time.Now()
+
+	query := `
+		WITH updated AS (
+			UPDATE clusters SET sync_error_category = $3
+			WHERE id = $2 AND deleted_at IS NULL
+		)
+		INSERT INTO cluster_sync_errors (id, cluster_id, category, message, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+	`
+
+	_, err := r.pool.Exec(ctx, query, syncErr.ID, syncErr.ClusterID, syncErr.Category, syncErr.Message, syncErr.CreatedAt)
+	return err
+}
+
+// ListSyncErrors retrieves the sync error history of a cluster, newest first
+func (r *ClusterRepository) ListSyncErrors(ctx context.Context, clusterID uuid.UUID, p Pagination, filters map[string]interface{}) (*PaginatedResult[models.ClusterSyncError], error) {
+	qb := NewQueryBuilder(`
+		SELECT id, cluster_id, category, message, created_at
+		FROM cluster_sync_errors
+	`)
+
+	qb.Where("cluster_id = ?", clusterID)
+	if category, ok := filters["category"].(string); ok && category != "" {
+		qb.Where("category = ?", category)
+	}
+
+	p.Sort = "created_at"
+	p.Order = "desc"
+	qb.Paginate(p)
+
+	countQuery, countArgs := qb.BuildCount()
+	var total int64
+	if err := r.reader().QueryRow(ctx, countQuery, countArgs...).Scan(&total); err != nil {
+		return nil, fmt.Errorf("failed to count cluster sync errors: %w", err)
+	}
+
+	query, args := qb.Build()
+	rows, err := r.reader().Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query cluster sync errors: %w", err)
+	}
+	defer rows.Close()
+
+	syncErrors := make([]models.ClusterSyncError, 0)
+	for rows.Next() {
+		var e models.ClusterSyncError
+		if err := rows.Scan(&e.ID, &e.ClusterID, &e.Category, &e.Message, &e.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan cluster sync error: %w", err)
+		}
+		syncErrors = append(syncErrors, e)
+	}
+
+	totalPages := int(total) / p.PageSize
+	if int(total)%p.PageSize > 0 {
+		totalPages++
+	}
+
+	return &PaginatedResult[models.ClusterSyncError]{
+		Items:      syncErrors,
+		Total:      total,
+		Page:       p.Page,
+		PageSize:   p.PageSize,
+		TotalPages: totalPages,
+	}, nil
+}
+
+// RecordSyncRun stores a cluster sync run, dropping the cluster's runs that
+// started more than keep ago
+func (r *ClusterRepository) RecordSyncRun(ctx context.Context, run *models.ClusterSyncRun, keep time.Duration) error {
+	run.ID = uuid.New()
+	if run.ArchivedNamespaces == nil {
+		run.ArchivedNamespaces = []string{}
+	}
+
+	query := `
+		WITH pruned AS (
+			DELETE FROM cluster_sync_runs
+			WHERE cluster_id = $2 AND started_at < NOW() - $17::interval
+		)
+		INSERT INTO cluster_sync_runs (
+			id, cluster_id, trigger, triggered_by, status, started_at, finished_at,
+			namespace_count, node_count, namespaces_created, namespaces_updated, namespaces_archived, namespaces_reactivated,
+			archived_namespaces, error_category, error
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)
+	`
+
+	_, err := r.pool.Exec(ctx, query,
+		run.ID, run.ClusterID, run.Trigger, run.TriggeredBy, run.Status, run.StartedAt, run.FinishedAt,
+		run.NamespaceCount, run.NodeCount, run.NamespacesCreated, run.NamespacesUpdated, run.NamespacesArchived, run.NamespacesReactivated,
+		run.ArchivedNamespaces, run.ErrorCategory, run.Error,
+		fmt.Sprintf("%d seconds", int(keep.Seconds())),
+	)
+	return err
+}
+
+// ListSyncRuns retrieves the sync runs of a cluster, newest first
+func (r *ClusterRepository) ListSyncRuns(ctx context.Context, clusterID uuid.UUID, p Pagination, filters map[string]interface{}) (*PaginatedResult[models.ClusterSyncRun], error) {
+	qb := NewQueryBuilder(`
+		SELECT id, cluster_id, trigger, triggered_by, status, started_at, finished_at,
+			namespace_count, node_count, namespaces_created, namespaces_updated, namespaces_archived, namespaces_reactivated,
+			archived_namespaces, error_category, error
+		FROM cluster_sync_runs
+	`)
+
+	qb.Where("cluster_id = ?", clusterID)
+	if status, ok := filters["status"].(string); ok && status != "" {
+		qb.Where("status = ?", status)
+	}
+	if trigger, ok := filters["trigger"].(string); ok && trigger != "" {
+		qb.Where("trigger = ?", trigger)
+	}
+	// Runs that archived a namespace, to find when it disappeared
+	if namespace, ok := filters["archived_namespace"].(string); ok && namespace != "" {
+		qb.Where("? = ANY(archived_namespaces)", namespace)
+	}
+
+	qb.SortColumn("started_at", "started_at")
+	p.Sort = "started_at"
+	p.Order = "desc"
+	qb.Paginate(p)
+
+	countQuery, countArgs := qb.BuildCount()
+	var total int64
+	if err := r.reader().QueryRow(ctx, countQuery, countArgs...).Scan(&total); err != nil {
+		return nil, fmt.Errorf("failed to count cluster sync runs: %w", err)
+	}
+
+	query, args := qb.Build()
+	rows, err := r.reader().Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query cluster sync runs: %w", err)
+	}
+	defer rows.Close()
+
+	runs := make([]models.ClusterSyncRun, 0)
+	for rows.Next() {
+		var run models.ClusterSyncRun
+		if err := rows.Scan(
+			&run.ID, &run.ClusterID, &run.Trigger, &run.TriggeredBy, &run.Status, &run.StartedAt, &run.FinishedAt,
+			&run.NamespaceCount, &run.NodeCount, &run.NamespacesCreated, &run.NamespacesUpdated, &run.NamespacesArchived, &run.NamespacesReactivated,
+			&run.ArchivedNamespaces, &run.ErrorCategory, &run.Error,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan cluster sync run: %w", err)
+		}
+		run.DurationMs = run.FinishedAt.Sub(run.StartedAt).Milliseconds()
+		runs = append(runs, run)
+	}
+
+	totalPages := int(total) / p.PageSize
+	if int(total)%p.PageSize > 0 {
+		totalPages++
+	}
+
+	return &PaginatedResult[models.ClusterSyncRun]{
+		Items:      runs,
+		Total:      total,
+		Page:       p.Page,
+		PageSize:   p.PageSize,
+		TotalPages: totalPages,
+	}, nil
+}
+
+// CountSyncErrorsByCategory returns the number of clusters currently in each sync error category
+func (r *ClusterRepository) CountSyncErrorsByCategory(ctx context.Context, orgID uuid.UUID) (map[string]int64, error) {
+	query := `
+		SELECT sync_error_category, COUNT(*)
+		FROM clusters
+		WHERE organization_id = $1 AND deleted_at IS NULL AND sync_error_category IS NOT NULL
+		GROUP BY sync_error_category
+	`
+
+	rows, err := r.reader().Query(ctx, query, orgID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int64)
+	for rows.Next() {
+		var category string
+		var count int64
+		if err := rows.Scan(&category, &count); err != nil {
+			return nil, err
+		}
+		counts[category] = count
+	}
+
+	return counts, rows.Err()
+}
+
 // Delete soft deletes a cluster
 func (r *ClusterRepository) Delete(ctx context.Context, id uuid.UUID) error {
 	return r.SoftDelete(ctx, "clusters", id)
 }
 
+// liveClusterNamespaces selects the IDs of the namespaces of cluster $1 that
+// are not deleted
+const liveClusterNamespaces = `SELECT id FROM namespaces WHERE cluster_id = $1 AND deleted_at IS NULL`
+
+// DeletionPreview counts what deleting a cluster soft-deletes with it
+func (r *ClusterRepository) DeletionPreview(ctx context.Context, id uuid.UUID) (*models.ClusterDeletion, error) {
+	query := `
+		WITH ns AS (` + liveClusterNamespaces + `)
+		SELECT
+			(SELECT COUNT(*) FROM ns),
+			(SELECT COUNT(*) FROM internal_dependencies
+				WHERE deleted_at IS NULL
+				  AND (source_namespace_id IN (SELECT id FROM ns) OR target_namespace_id IN (SELECT id FROM ns))),
+			(SELECT COUNT(*) FROM external_dependencies
+				WHERE deleted_at IS NULL AND namespace_id IN (SELECT id FROM ns)),
+			(SELECT COUNT(*) FROM documents
+				WHERE deleted_at IS NULL AND (cluster_id = $1 OR namespace_id IN (SELECT id FROM ns))),
+			(SELECT COUNT(DISTINCT d.source_namespace_id) FROM internal_dependencies d
+				JOIN namespaces src ON src.id = d.source_namespace_id AND src.deleted_at IS NULL AND src.cluster_id <> $1
+				WHERE d.deleted_at IS NULL AND d.target_namespace_id IN (SELECT id FROM ns))
+	`
+
+	var d models.ClusterDeletion
+	err := r.reader().QueryRow(ctx, query, id).Scan(
+		&d.Namespaces, &d.InternalDependencies, &d.ExternalDependencies, &d.Documents, &d.DependentNamespaces,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+// DeleteAttached soft-deletes the dependencies and documents that deleting a
+// cluster deletes with its namespaces, and counts them. It runs in the
+// transaction deleting the cluster, before its namespaces are deleted, so
+// that all of them share a deletion time and are restored together.
+func (r *ClusterRepository) DeleteAttached(ctx context.Context, id uuid.UUID) (*models.ClusterDeletion, error) {
+	var d models.ClusterDeletion
+	deletes := []struct {
+		query string
+		count *int64
+	}{
+		{`UPDATE internal_dependencies SET deleted_at = NOW()
+			WHERE deleted_at IS NULL
+			  AND (source_namespace_id IN (` + liveClusterNamespaces + `) OR target_namespace_id IN (` + liveClusterNamespaces + `))`,
+			&d.InternalDependencies},
+		{`UPDATE external_dependencies SET deleted_at = NOW()
+			WHERE deleted_at IS NULL AND namespace_id IN (` + liveClusterNamespaces + `)`,
+			&d.ExternalDependencies},
+		{`UPDATE documents SET deleted_at = NOW()
+			WHERE deleted_at IS NULL AND (cluster_id = $1 OR namespace_id IN (` + liveClusterNamespaces + `))`,
+			&d.Documents},
+	}
+	for _, del := range deletes {
+		result, err := r.pool.Exec(ctx, del.query, id)
+		if err != nil {
+			return nil, err
+		}
+		*del.count = result.RowsAffected()
+	}
+	return &d, nil
+}
+
 // GetStats returns cluster statistics
 func (r *ClusterRepository) GetStats(ctx context.Context, orgID uuid.UUID) (map[string]interface{}, error) {
 	query := `
@@ -326,7 +680,7 @@ func (r *ClusterRepository) GetStats(ctx context.Context, orgID uuid.UUID) (map[
 	`
 
 	var total, active, errorCount, totalNodes, totalNamespaces int64
-	err := r.pool.QueryRow(ctx, query, orgID).Scan(&total, &active, &errorCount, &totalNodes, &totalNamespaces)
+	err := r.reader().QueryRow(ctx, query, orgID).Scan(&total, &active, &errorCount, &totalNodes, &totalNamespaces)
 	if err != nil {
 		return nil, err
 	}
@@ -339,3 +693,508 @@ func (r *ClusterRepository) GetStats(ctx context.Context, orgID uuid.UUID) (map[
 		"total_namespaces": totalNamespaces,
 	}, nil
 }
+
+// ============================================
+// Versions
+// ============================================
+
+// minorVersionExpr extracts the Kubernetes minor version, such as 1.30,
+// from a cluster's version, such as v1.30.2-eks-1a2b3c
+const minorVersionExpr = `substring(version from '^v*([0-9]+\.[0-9]+)')`
+
+// SetVersion records the Kubernetes version reported by a cluster's API
+// server. It leaves updated_at alone, like UpdateSyncStatus.
+func (r *ClusterRepository) SetVersion(ctx context.Context, id uuid.UUID, version string) error {
+	query := `UPDATE clusters SET version = $2 WHERE id = $1 AND deleted_at IS NULL`
+	_, err := r.pool.Exec(ctx, query, id, version)
+	return err
+}
+
+// SetActiveAPIServerURL records the API server URL a cluster was last
+// reached at. It leaves updated_at alone, so the cached client that reached
+// it stays in use.
+func (r *ClusterRepository) SetActiveAPIServerURL(ctx context.Context, id uuid.UUID, url string) error {
+	query := `
+		UPDATE clusters SET active_api_server_url = $2
+		WHERE id = $1 AND deleted_at IS NULL AND active_api_server_url IS DISTINCT FROM $2`
+	_, err := r.pool.Exec(ctx, query, id, url)
+	return err
+}
+
+// SetSyncLabels replaces the labels under keys, which the sync sets, with
+// those of syncLabels. Other labels are kept.
+func (r *ClusterRepository) SetSyncLabels(ctx context.Context, id uuid.UUID, keys []string, syncLabels map[string]string) error {
+	query := `
+		UPDATE clusters SET labels = (COALESCE(labels, '{}'::jsonb) - $2::text[]) || $3::jsonb
+		WHERE id = $1 AND deleted_at IS NULL`
+	_, err := r.pool.Exec(ctx, query, id, keys, syncLabels)
+	return err
+}
+
+// ListOrganizationIDs returns the organizations with clusters of a known version
+func (r *ClusterRepository) ListOrganizationIDs(ctx context.Context) ([]uuid.UUID, error) {
+	rows, err := r.reader().Query(ctx, `
+		SELECT DISTINCT organization_id
+		FROM clusters
+		WHERE deleted_at IS NULL AND COALESCE(version, '') <> ''`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := make([]uuid.UUID, 0)
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// ListVersions returns every cluster of an organization by name, with only
+// its identity, environment, version and owners loaded
+func (r *ClusterRepository) ListVersions(ctx context.Context, orgID uuid.UUID) ([]models.Cluster, error) {
+	query := `
+		SELECT id, organization_id, name, display_name, environment, version, owner_team_id, responsible_user_id
+		FROM clusters
+		WHERE organization_id = $1 AND deleted_at IS NULL
+		ORDER BY name
+	`
+
+	rows, err := r.reader().Query(ctx, query, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query cluster versions: %w", err)
+	}
+	defer rows.Close()
+
+	clusters := make([]models.Cluster, 0)
+	for rows.Next() {
+		var c models.Cluster
+		if err := rows.Scan(&c.ID, &c.OrganizationID, &c.Name, &c.DisplayName, &c.Environment, &c.Version, &c.OwnerTeamID, &c.ResponsibleUserID); err != nil {
+			return nil, fmt.Errorf("failed to scan cluster version: %w", err)
+		}
+		clusters = append(clusters, c)
+	}
+	return clusters, rows.Err()
+}
+
+// ClaimVersionAlert records that a cluster's owners are alerted about a
+// minor version reaching an end-of-life threshold and reports whether the
+// caller should alert them, which it should only once per threshold
+func (r *ClusterRepository) ClaimVersionAlert(ctx context.Context, clusterID uuid.UUID, minorVersion string, thresholdDays int) (bool, error) {
+	query := `
+		INSERT INTO cluster_version_alerts (cluster_id, minor_version, threshold_days)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (cluster_id, minor_version, threshold_days) DO NOTHING
+	`
+
+	result, err := r.pool.Exec(ctx, query, clusterID, minorVersion, thresholdDays)
+	if err != nil {
+		return false, err
+	}
+	return result.RowsAffected() == 1, nil
+}
+
+// ============================================
+// Nodes
+// ============================================
+
+const clusterNodeColumns = `
+	cn.id, cn.organization_id, cn.cluster_id, cn.name, cn.k8s_uid, cn.status, cn.roles,
+	cn.kubelet_version, cn.os_image, cn.architecture,
+	cn.cpu_capacity_millicores, cn.cpu_allocatable_millicores,
+	cn.memory_capacity_bytes, cn.memory_allocatable_bytes,
+	cn.pods_capacity, cn.pods_allocatable, cn.capacity, cn.allocatable,
+	cn.labels, cn.annotations, cn.k8s_created_at, cn.first_seen_at, cn.last_seen_at,
+	c.name`
+
+func scanClusterNode(row pgx.Row) (*models.ClusterNode, error) {
+	n := &models.ClusterNode{}
+	err := row.Scan(
+		&n.ID, &n.OrganizationID, &n.ClusterID, &n.Name, &n.K8sUID, &n.Status, &n.Roles,
+		&n.KubeletVersion, &n.OSImage, &n.Architecture,
+		&n.CPUCapacityMillicores, &n.CPUAllocatableMillicores,
+		&n.MemoryCapacityBytes, &n.MemoryAllocatableBytes,
+		&n.PodsCapacity, &n.PodsAllocatable, &n.Capacity, &n.Allocatable,
+		&n.Labels, &n.Annotations, &n.K8sCreatedAt, &n.FirstSeenAt, &n.LastSeenAt,
+		&n.ClusterName,
+	)
+	return n, err
+}
+
+// ReplaceNodes stores the nodes found by a sync of a cluster. Known nodes
+// keep their ID and first sighting; nodes no longer found are removed.
+func (r *ClusterRepository) ReplaceNodes(ctx context.Context, orgID, clusterID uuid.UUID, nodes []models.ClusterNode) error {
+	names := make([]string, 0, len(nodes))
+	batch := &pgx.Batch{}
+	for _, n := range nodes {
+		names = append(names, n.Name)
+		batch.Queue(`
+			INSERT INTO cluster_nodes (
+				organization_id, cluster_id, name, k8s_uid, status, roles,
+				kubelet_version, os_image, architecture,
+				cpu_capacity_millicores, cpu_allocatable_millicores,
+				memory_capacity_bytes, memory_allocatable_bytes,
+				pods_capacity, pods_allocatable, capacity, allocatable,
+				labels, annotations, k8s_created_at
+			) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20)
+			ON CONFLICT (cluster_id, name) DO UPDATE SET
+				k8s_uid = EXCLUDED.k8s_uid, status = EXCLUDED.status, roles = EXCLUDED.roles,
+				kubelet_version = EXCLUDED.kubelet_version, os_image = EXCLUDED.os_image,
+				architecture = EXCLUDED.architecture,
+				cpu_capacity_millicores = EXCLUDED.cpu_capacity_millicores,
+				cpu_allocatable_millicores = EXCLUDED.cpu_allocatable_millicores,
+				memory_capacity_bytes = EXCLUDED.memory_capacity_bytes,
+				memory_allocatable_bytes = EXCLUDED.memory_allocatable_bytes,
+				pods_capacity = EXCLUDED.pods_capacity, pods_allocatable = EXCLUDED.pods_allocatable,
+				capacity = EXCLUDED.capacity, allocatable = EXCLUDED.allocatable,
+				labels = EXCLUDED.labels, annotations = EXCLUDED.annotations,
+				k8s_created_at = EXCLUDED.k8s_created_at, last_seen_at = NOW()`,
+			orgID, clusterID, n.Name, n.K8sUID, n.Status, n.Roles,
+			n.KubeletVersion, n.OSImage, n.Architecture,
+			n.CPUCapacityMillicores, n.CPUAllocatableMillicores,
+			n.MemoryCapacityBytes, n.MemoryAllocatableBytes,
+			n.PodsCapacity, n.PodsAllocatable, n.Capacity, n.Allocatable,
+			n.Labels, n.Annotations, n.K8sCreatedAt,
+		)
+	}
+	batch.Queue(`DELETE FROM cluster_nodes WHERE cluster_id = $1 AND NOT (name = ANY($2))`, clusterID, names)
+
+	return runInTx(ctx, r.pool, func(tx pgx.Tx) error {
+		results := tx.SendBatch(ctx, batch)
+		defer results.Close()
+
+		for i := 0; i < batch.Len(); i++ {
+			if _, err := results.Exec(); err != nil {
+				return fmt.Errorf("failed to replace cluster nodes: %w", err)
+			}
+		}
+		return results.Close()
+	})
+}
+
+// whereNodeFilters applies the node list filters: role, status and
+// kubelet version
+func whereNodeFilters(qb *QueryBuilder, filters map[string]interface{}) {
+	if role, ok := filters["role"].(string); ok && role != "" {
+		qb.Where("? = ANY(cn.roles)", role)
+	}
+	if status, ok := filters["status"].(string); ok && status != "" {
+		qb.Where("cn.status = ?", status)
+	}
+	if version, ok := filters["version"].(string); ok && version != "" {
+		qb.Where("cn.kubelet_version = ?", version)
+	}
+}
+
+// ListNodes retrieves the nodes of a cluster, by name unless sorted
+// otherwise
+func (r *ClusterRepository) ListNodes(ctx context.Context, clusterID uuid.UUID, p Pagination, filters map[string]interface{}) (*PaginatedResult[models.ClusterNode], error) {
+	qb := NewQueryBuilder(`
+		SELECT ` + clusterNodeColumns + `
+		FROM cluster_nodes cn
+		JOIN clusters c ON c.id = cn.cluster_id
+	`)
+	qb.SortAlias("cn").
+		SortColumn("kubelet_version", "cn.kubelet_version").
+		SortColumn("cpu_capacity", "cn.cpu_capacity_millicores").
+		SortColumn("memory_capacity", "cn.memory_capacity_bytes")
+
+	qb.Where("cn.cluster_id = ?", clusterID)
+	whereNodeFilters(qb, filters)
+
+	if p.Sort == "" {
+		p.Sort = "name"
+	}
+	qb.Paginate(p)
+	qb.ThenBy("id", "asc")
+
+	countQuery, countArgs := qb.BuildCount()
+	var total int64
+	if err := r.reader().QueryRow(ctx, countQuery, countArgs...).Scan(&total); err != nil {
+		return nil, fmt.Errorf("failed to count cluster nodes: %w", err)
+	}
+
+	query, args := qb.Build()
+	rows, err := r.reader().Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query cluster nodes: %w", err)
+	}
+	defer rows.Close()
+
+	nodes := make([]models.ClusterNode, 0)
+	for rows.Next() {
+		n, err := scanClusterNode(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan cluster node: %w", err)
+		}
+		nodes = append(nodes, *n)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	totalPages := int(total) / p.PageSize
+	if int(total)%p.PageSize > 0 {
+		totalPages++
+	}
+
+	return &PaginatedResult[models.ClusterNode]{
+		Items:      nodes,
+		Total:      total,
+		Page:       p.Page,
+		PageSize:   p.PageSize,
+		TotalPages: totalPages,
+	}, nil
+}
+
+// GetNode returns a node of a cluster of the organization, or nil if none
+func (r *ClusterRepository) GetNode(ctx context.Context, orgID, id uuid.UUID) (*models.ClusterNode, error) {
+	n, err := scanClusterNode(r.pool.QueryRow(ctx, `
+		SELECT `+clusterNodeColumns+`
+		FROM cluster_nodes cn
+		JOIN clusters c ON c.id = cn.cluster_id AND c.deleted_at IS NULL
+		WHERE cn.id = $1 AND cn.organization_id = $2`,
+		id, orgID,
+	))
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	return n, err
+}
+
+// GetCapacity sums the capacity of the nodes of a cluster matching the
+// node list filters, and counts them by role and kubelet version
+func (r *ClusterRepository) GetCapacity(ctx context.Context, clusterID uuid.UUID, filters map[string]interface{}) (*models.ClusterCapacity, error) {
+	qb := NewQueryBuilder(`
+		SELECT COUNT(*), COUNT(*) FILTER (WHERE cn.status = '` + models.NodeStatusReady + `'),
+			COALESCE(SUM(cn.cpu_capacity_millicores), 0), COALESCE(SUM(cn.cpu_allocatable_millicores), 0),
+			COALESCE(SUM(cn.memory_capacity_bytes), 0), COALESCE(SUM(cn.memory_allocatable_bytes), 0),
+			COALESCE(SUM(cn.pods_capacity), 0), COALESCE(SUM(cn.pods_allocatable), 0)
+		FROM cluster_nodes cn
+	`)
+	qb.Where("cn.cluster_id = ?", clusterID)
+	whereNodeFilters(qb, filters)
+
+	capacity := &models.ClusterCapacity{ClusterID: clusterID}
+	query, args := qb.Build()
+	if err := r.reader().QueryRow(ctx, query, args...).Scan(
+		&capacity.Nodes, &capacity.ReadyNodes,
+		&capacity.CPUCapacityMillicores, &capacity.CPUAllocatableMillicores,
+		&capacity.MemoryCapacityBytes, &capacity.MemoryAllocatableBytes,
+		&capacity.PodsCapacity, &capacity.PodsAllocatable,
+	); err != nil {
+		return nil, fmt.Errorf("failed to sum cluster capacity: %w", err)
+	}
+
+	var err error
+	if capacity.Roles, err = r.countNodes(ctx, "unnest(cn.roles)", clusterID, filters); err != nil {
+		return nil, err
+	}
+	if capacity.KubeletVersions, err = r.countNodes(ctx, "cn.kubelet_version", clusterID, filters); err != nil {
+		return nil, err
+	}
+	return capacity, nil
+}
+
+// countNodes counts the nodes of a cluster matching the filters by the
+// values of expr, most nodes first
+func (r *ClusterRepository) countNodes(ctx context.Context, expr string, clusterID uuid.UUID, filters map[string]interface{}) ([]models.NodeGroup, error) {
+	qb := NewQueryBuilder(`
+		SELECT g.value, COUNT(*)
+		FROM cluster_nodes cn
+		CROSS JOIN LATERAL (SELECT ` + expr + ` AS value) g
+	`)
+	qb.Where("cn.cluster_id = ?", clusterID)
+	whereNodeFilters(qb, filters)
+
+	query, args := qb.Build()
+	rows, err := r.reader().Query(ctx, query+" GROUP BY g.value ORDER BY COUNT(*) DESC, g.value", args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count cluster nodes: %w", err)
+	}
+	defer rows.Close()
+
+	groups := make([]models.NodeGroup, 0)
+	for rows.Next() {
+		var g models.NodeGroup
+		if err := rows.Scan(&g.Value, &g.Nodes); err != nil {
+			return nil, err
+		}
+		groups = append(groups, g)
+	}
+	return groups, rows.Err()
+}
+
+// RecordCapacitySnapshot snapshots the node count and summed capacity of
+// the stored nodes of a cluster, and prunes its snapshots older than keep
+func (r *ClusterRepository) RecordCapacitySnapshot(ctx context.Context, clusterID uuid.UUID, keep time.Duration) error {
+	query := `
+		WITH pruned AS (
+			DELETE FROM cluster_capacity_snapshots
+			WHERE cluster_id = $1 AND observed_at < NOW() - $2::interval
+		)
+		INSERT INTO cluster_capacity_snapshots (
+			cluster_id, nodes, ready_nodes,
+			cpu_capacity_millicores, cpu_allocatable_millicores,
+			memory_capacity_bytes, memory_allocatable_bytes,
+			pods_capacity, pods_allocatable
+		)
+		SELECT $1, COUNT(*), COUNT(*) FILTER (WHERE status = '` + models.NodeStatusReady + `'),
+			COALESCE(SUM(cpu_capacity_millicores), 0), COALESCE(SUM(cpu_allocatable_millicores), 0),
+			COALESCE(SUM(memory_capacity_bytes), 0), COALESCE(SUM(memory_allocatable_bytes), 0),
+			COALESCE(SUM(pods_capacity), 0), COALESCE(SUM(pods_allocatable), 0)
+		FROM cluster_nodes
+		WHERE cluster_id = $1
+	`
+
+	if _, err := r.pool.Exec(ctx, query, clusterID, fmt.Sprintf("%d seconds", int(keep.Seconds()))); err != nil {
+		return fmt.Errorf("failed to record cluster capacity snapshot: %w", err)
+	}
+	return nil
+}
+
+// ListCapacitySnapshots returns the last capacity snapshot of a cluster in
+// each interval ("day" or "week") since a time, oldest first
+func (r *ClusterRepository) ListCapacitySnapshots(ctx context.Context, clusterID uuid.UUID, interval string, since time.Time) ([]models.ClusterCapacitySnapshot, error) {
+	rows, err := r.reader().Query(ctx, `
+		SELECT nodes, ready_nodes, cpu_capacity_millicores, cpu_allocatable_millicores,
+			memory_capacity_bytes, memory_allocatable_bytes, pods_capacity, pods_allocatable, observed_at
+		FROM (
+			SELECT DISTINCT ON (date_trunc($2, observed_at)) *
+			FROM cluster_capacity_snapshots
+			WHERE cluster_id = $1 AND observed_at >= $3
+			ORDER BY date_trunc($2, observed_at), observed_at DESC
+		) s
+		ORDER BY observed_at`,
+		clusterID, interval, since,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query cluster capacity snapshots: %w", err)
+	}
+	defer rows.Close()
+
+	snapshots := make([]models.ClusterCapacitySnapshot, 0)
+	for rows.Next() {
+		var s models.ClusterCapacitySnapshot
+		if err := rows.Scan(
+			&s.Nodes, &s.ReadyNodes, &s.CPUCapacityMillicores, &s.CPUAllocatableMillicores,
+			&s.MemoryCapacityBytes, &s.MemoryAllocatableBytes, &s.PodsCapacity, &s.PodsAllocatable, &s.ObservedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan cluster capacity snapshot: %w", err)
+		}
+		snapshots = append(snapshots, s)
+	}
+	return snapshots, rows.Err()
+}
+
+// ============================================
+// Maintenance windows
+// ============================================
+
+const maintenanceWindowColumns = `
+	id, cluster_id, organization_id, name, recurrence,
+	COALESCE(to_char(start_date, 'YYYY-MM-DD'), ''), weekdays, COALESCE(day_of_month, 0),
+	start_time, duration_minutes, timezone, created_at, updated_at`
+
+func scanMaintenanceWindow(row pgx.Row, w *models.MaintenanceWindow) error {
+	return row.Scan(
+		&w.ID, &w.ClusterID, &w.OrganizationID, &w.Name, &w.Recurrence,
+		&w.StartDate, &w.Weekdays, &w.DayOfMonth,
+		&w.StartTime, &w.DurationMinutes, &w.Timezone, &w.CreatedAt, &w.UpdatedAt,
+	)
+}
+
+// ListMaintenanceWindows returns the maintenance windows of clusters, by
+// cluster and name
+func (r *ClusterRepository) ListMaintenanceWindows(ctx context.Context, clusterIDs []uuid.UUID) ([]models.MaintenanceWindow, error) {
+	rows, err := r.reader().Query(ctx, `
+		SELECT `+maintenanceWindowColumns+`
+		FROM cluster_maintenance_windows
+		WHERE cluster_id = ANY($1)
+		ORDER BY cluster_id, name, id`, clusterIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list maintenance windows: %w", err)
+	}
+	defer rows.Close()
+
+	windows := make([]models.MaintenanceWindow, 0)
+	for rows.Next() {
+		var w models.MaintenanceWindow
+		if err := scanMaintenanceWindow(rows, &w); err != nil {
+			return nil, fmt.Errorf("failed to scan maintenance window: %w", err)
+		}
+		windows = append(windows, w)
+	}
+	return windows, rows.Err()
+}
+
+// GetMaintenanceWindow retrieves a maintenance window of a cluster. Returns
+// nil when there is none.
+func (r *ClusterRepository) GetMaintenanceWindow(ctx context.Context, clusterID, id uuid.UUID) (*models.MaintenanceWindow, error) {
+	w := &models.MaintenanceWindow{}
+	err := scanMaintenanceWindow(r.pool.QueryRow(ctx, `
+		SELECT `+maintenanceWindowColumns+`
+		FROM cluster_maintenance_windows
+		WHERE id = $1 AND cluster_id = $2`, id, clusterID), w)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// CreateMaintenanceWindow creates a maintenance window
+func (r *ClusterRepository) CreateMaintenanceWindow(ctx context.Context, w *models.MaintenanceWindow) error {
+	w.ID = uuid.New()
+	w.CreatedAt = time.Now()
+	w.UpdatedAt = w.CreatedAt
+
+	_, err := r.pool.Exec(ctx, `
+		INSERT INTO cluster_maintenance_windows (
+			id, cluster_id, organization_id, name, recurrence, start_date, weekdays, day_of_month,
+			start_time, duration_minutes, timezone, created_at, updated_at
+		)
+		VALUES ($1, $2, $3, $4, $5, NULLIF($6, '')::date, $7, NULLIF($8, 0), $9, $10, $11, $12, $13)`,
+		w.ID, w.ClusterID, w.OrganizationID, w.Name, w.Recurrence, w.StartDate, w.Weekdays, w.DayOfMonth,
+		w.StartTime, w.DurationMinutes, w.Timezone, w.CreatedAt, w.UpdatedAt,
+	)
+	return err
+}
+
+// UpdateMaintenanceWindow replaces a maintenance window
+func (r *ClusterRepository) UpdateMaintenanceWindow(ctx context.Context, w *models.MaintenanceWindow) error {
+	w.UpdatedAt = time.Now()
+
+	result, err := r.pool.Exec(ctx, `
+		UPDATE cluster_maintenance_windows SET
+			name = $3, recurrence = $4, start_date = NULLIF($5, '')::date, weekdays = $6,
+			day_of_month = NULLIF($7, 0), start_time = $8, duration_minutes = $9, timezone = $10,
+			updated_at = $11
+		WHERE id = $1 AND cluster_id = $2`,
+		w.ID, w.ClusterID, w.Name, w.Recurrence, w.StartDate, w.Weekdays,
+		w.DayOfMonth, w.StartTime, w.DurationMinutes, w.Timezone, w.UpdatedAt,
+	)
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+// DeleteMaintenanceWindow deletes a maintenance window of a cluster.
+// Returns false when there was none.
+func (r *ClusterRepository) DeleteMaintenanceWindow(ctx context.Context, clusterID, id uuid.UUID) (bool, error) {
+	result, err := r.pool.Exec(ctx,
+		`DELETE FROM cluster_maintenance_windows WHERE id = $1 AND cluster_id = $2`, id, clusterID)
+	if err != nil {
+		return false, err
+	}
+	return result.RowsAffected() > 0, nil
+}
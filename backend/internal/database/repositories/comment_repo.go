@@ -0,0 +1,154 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/kubeatlas/kubeatlas/internal/models"
+)
+
+// CommentRepository stores the comments left on namespaces and clusters
+type CommentRepository struct {
+	*BaseRepository
+	pool DBTX
+}
+
+// NewCommentRepository creates a new comment repository
+func NewCommentRepository(pool DBTX) *CommentRepository {
+	return &CommentRepository{
+		BaseRepository: NewBaseRepository(pool),
+		pool:           pool,
+	}
+}
+
+// commentColumns selects a comment as c with its author as u
+const commentColumns = `
+	c.id, c.organization_id, c.namespace_id, c.cluster_id, c.author_id, c.author_email, u.full_name,
+	c.body, c.mentioned_user_ids, c.created_at, c.updated_at
+`
+
+const commentFrom = ` FROM comments c LEFT JOIN users u ON u.id = c.author_id`
+
+func scanComment(row pgx.Row, c *models.Comment) error {
+	return row.Scan(
+		&c.ID, &c.OrganizationID, &c.NamespaceID, &c.ClusterID, &c.AuthorID, &c.AuthorEmail, &c.AuthorName,
+		&c.Body, &c.MentionedUserIDs, &c.CreatedAt, &c.UpdatedAt,
+	)
+}
+
+// Create creates a comment
+func (r *CommentRepository) Create(ctx context.Context, c *models.Comment) error {
+	c.ID = uuid.New()
+	c.CreatedAt = time.Now()
+	c.UpdatedAt = c.CreatedAt
+
+	query := `
+		INSERT INTO comments (
+			id, organization_id, namespace_id, cluster_id, author_id, author_email, body, mentioned_user_ids, created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	`
+
+	_, err := r.pool.Exec(ctx, query,
+		c.ID, c.OrganizationID, c.NamespaceID, c.ClusterID, c.AuthorID, c.AuthorEmail, c.Body, c.MentionedUserIDs, c.CreatedAt, c.UpdatedAt,
+	)
+	return err
+}
+
+// GetByID retrieves a comment of the organization. Returns nil when there
+// is none.
+func (r *CommentRepository) GetByID(ctx context.Context, orgID, id uuid.UUID) (*models.Comment, error) {
+	query := `SELECT ` + commentColumns + commentFrom + ` WHERE c.id = $1 AND c.organization_id = $2`
+
+	c := &models.Comment{}
+	err := scanComment(r.pool.QueryRow(ctx, query, id, orgID), c)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// List returns the comments on a namespace or, with cluster, a cluster,
+// oldest first
+func (r *CommentRepository) List(ctx context.Context, orgID, targetID uuid.UUID, cluster bool, p Pagination) (*PaginatedResult[models.Comment], error) {
+	qb := NewQueryBuilder(`SELECT ` + commentColumns + commentFrom)
+	qb.SortAlias("c")
+
+	qb.Where("c.organization_id = ?", orgID)
+	if cluster {
+		qb.Where("c.cluster_id = ?", targetID)
+	} else {
+		qb.Where("c.namespace_id = ?", targetID)
+	}
+
+	p.Sort = "created_at"
+	p.Order = "asc"
+	qb.Paginate(p)
+	qb.ThenBy("id", "asc")
+
+	countQuery, countArgs := qb.BuildCount()
+	var total int64
+	if err := r.reader().QueryRow(ctx, countQuery, countArgs...).Scan(&total); err != nil {
+		return nil, fmt.Errorf("failed to count comments: %w", err)
+	}
+
+	query, args := qb.Build()
+	rows, err := r.reader().Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query comments: %w", err)
+	}
+	defer rows.Close()
+
+	comments := make([]models.Comment, 0)
+	for rows.Next() {
+		var c models.Comment
+		if err := scanComment(rows, &c); err != nil {
+			return nil, fmt.Errorf("failed to scan comment: %w", err)
+		}
+		comments = append(comments, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	totalPages := int(total) / p.PageSize
+	if int(total)%p.PageSize > 0 {
+		totalPages++
+	}
+
+	return &PaginatedResult[models.Comment]{
+		Items:      comments,
+		Total:      total,
+		Page:       p.Page,
+		PageSize:   p.PageSize,
+		TotalPages: totalPages,
+	}, nil
+}
+
+// Update replaces the body and mentions of a comment
+func (r *CommentRepository) Update(ctx context.Context, c *models.Comment) error {
+	c.UpdatedAt = time.Now()
+
+	result, err := r.pool.Exec(ctx,
+		`UPDATE comments SET body = $2, mentioned_user_ids = $3, updated_at = $4 WHERE id = $1`,
+		c.ID, c.Body, c.MentionedUserIDs, c.UpdatedAt,
+	)
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+// Delete deletes a comment
+func (r *CommentRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	_, err := r.pool.Exec(ctx, `DELETE FROM comments WHERE id = $1`, id)
+	return err
+}
@@ -0,0 +1,193 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/kubeatlas/kubeatlas/internal/models"
+)
+
+// ContactValidationRepository handles the validation of namespace contacts'
+// email addresses
+type ContactValidationRepository struct {
+	*BaseRepository
+	pool DBTX
+}
+
+// NewContactValidationRepository creates a new contact validation repository
+func NewContactValidationRepository(pool DBTX) *ContactValidationRepository {
+	return &ContactValidationRepository{
+		BaseRepository: NewBaseRepository(pool),
+		pool:           pool,
+	}
+}
+
+// ListOrganizationIDs returns the organizations with namespace contacts to
+// validate
+func (r *ContactValidationRepository) ListOrganizationIDs(ctx context.Context) ([]uuid.UUID, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT DISTINCT organization_id
+		FROM namespaces
+		WHERE deleted_at IS NULL`+archivedCondition(false)+`
+			AND (COALESCE(application_manager_email, '') <> ''
+				OR COALESCE(technical_lead_email, '') <> ''
+				OR COALESCE(project_manager_email, '') <> '')`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := make([]uuid.UUID, 0)
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// ListContacts returns the contact email addresses of the organization's
+// namespaces, archived ones left out
+func (r *ContactValidationRepository) ListContacts(ctx context.Context, orgID uuid.UUID) ([]models.NamespaceContact, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT n.id, c.role, trim(c.email)
+		FROM namespaces n
+		CROSS JOIN LATERAL (VALUES
+			($2, n.application_manager_email),
+			($3, n.technical_lead_email),
+			($4, n.project_manager_email)
+		) AS c(role, email)
+		WHERE n.organization_id = $1 AND n.deleted_at IS NULL`+archivedCondition(false)+`
+			AND COALESCE(trim(c.email), '') <> ''
+		ORDER BY n.id, c.role`,
+		orgID, models.ContactRoleApplicationManager, models.ContactRoleTechnicalLead, models.ContactRoleProjectManager,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	contacts := make([]models.NamespaceContact, 0)
+	for rows.Next() {
+		c := models.NamespaceContact{OrganizationID: orgID}
+		if err := rows.Scan(&c.NamespaceID, &c.Role, &c.Email); err != nil {
+			return nil, err
+		}
+		contacts = append(contacts, c)
+	}
+	return contacts, rows.Err()
+}
+
+// ListDeactivatedEmails returns which of the given lowercase email addresses
+// belong only to deactivated or deleted users of the organization
+func (r *ContactValidationRepository) ListDeactivatedEmails(ctx context.Context, orgID uuid.UUID, emails []string) (map[string]bool, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT lower(email)
+		FROM users
+		WHERE organization_id = $1 AND lower(email) = ANY($2)
+		GROUP BY lower(email)
+		HAVING NOT bool_or(deleted_at IS NULL AND is_active = true)`,
+		orgID, emails,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	deactivated := make(map[string]bool)
+	for rows.Next() {
+		var email string
+		if err := rows.Scan(&email); err != nil {
+			return nil, err
+		}
+		deactivated[email] = true
+	}
+	return deactivated, rows.Err()
+}
+
+// ReplaceIssues makes issues the organization's contact issues. Contacts
+// flagged before for the same address and reason keep when they were first
+// detected.
+func (r *ContactValidationRepository) ReplaceIssues(ctx context.Context, orgID uuid.UUID, issues []models.ContactIssue) error {
+	namespaceIDs := make([]uuid.UUID, len(issues))
+	roles := make([]string, len(issues))
+	emails := make([]string, len(issues))
+	reasons := make([]string, len(issues))
+	for i, issue := range issues {
+		namespaceIDs[i] = issue.NamespaceID
+		roles[i] = issue.Role
+		emails[i] = issue.Email
+		reasons[i] = issue.Reason
+	}
+
+	return runInTx(ctx, r.pool, func(tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, `
+			DELETE FROM namespace_contact_issues i
+			WHERE i.organization_id = $1
+				AND NOT EXISTS (
+					SELECT 1 FROM unnest($2::uuid[], $3::text[], $4::text[], $5::text[]) AS f(namespace_id, role, email, reason)
+					WHERE f.namespace_id = i.namespace_id AND f.role = i.role AND f.email = i.email AND f.reason = i.reason
+				)`,
+			orgID, namespaceIDs, roles, emails, reasons,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to delete contact issues: %w", err)
+		}
+		_, err = tx.Exec(ctx, `
+			INSERT INTO namespace_contact_issues (namespace_id, organization_id, role, email, reason)
+			SELECT f.namespace_id, $1, f.role, f.email, f.reason
+			FROM unnest($2::uuid[], $3::text[], $4::text[], $5::text[]) AS f(namespace_id, role, email, reason)
+			ON CONFLICT (namespace_id, role) DO UPDATE SET checked_at = NOW()`,
+			orgID, namespaceIDs, roles, emails, reasons,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to save contact issues: %w", err)
+		}
+		return nil
+	})
+}
+
+// ListIssues returns up to limit contact issues of the organization's
+// namespaces, by namespace name
+func (r *ContactValidationRepository) ListIssues(ctx context.Context, orgID uuid.UUID, limit int) ([]models.ContactIssue, error) {
+	rows, err := r.reader().Query(ctx, `
+		SELECT i.namespace_id, n.name, n.cluster_id, i.role, i.email, i.reason, i.detected_at, i.checked_at
+		FROM namespace_contact_issues i
+		JOIN namespaces n ON n.id = i.namespace_id AND n.deleted_at IS NULL
+		WHERE i.organization_id = $1
+		ORDER BY n.name, i.role
+		LIMIT $2`,
+		orgID, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	issues := make([]models.ContactIssue, 0)
+	for rows.Next() {
+		i := models.ContactIssue{NamespaceContact: models.NamespaceContact{OrganizationID: orgID}}
+		if err := rows.Scan(&i.NamespaceID, &i.NamespaceName, &i.ClusterID, &i.Role, &i.Email, &i.Reason, &i.DetectedAt, &i.CheckedAt); err != nil {
+			return nil, err
+		}
+		issues = append(issues, i)
+	}
+	return issues, rows.Err()
+}
+
+// CountNamespaces returns how many of the organization's namespaces have a
+// contact issue
+func (r *ContactValidationRepository) CountNamespaces(ctx context.Context, orgID uuid.UUID) (int, error) {
+	var count int
+	err := r.reader().QueryRow(ctx, `
+		SELECT COUNT(DISTINCT i.namespace_id)
+		FROM namespace_contact_issues i
+		JOIN namespaces n ON n.id = i.namespace_id AND n.deleted_at IS NULL
+		WHERE i.organization_id = $1`, orgID,
+	).Scan(&count)
+	return count, err
+}
@@ -7,7 +7,6 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
-	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/kubeatlas/kubeatlas/internal/models"
 )
 
@@ -18,11 +17,11 @@ import (
 // InternalDependencyRepository handles internal dependency database operations
 type InternalDependencyRepository struct {
 	*BaseRepository
-	pool *pgxpool.Pool
+	pool DBTX
 }
 
 // NewInternalDependencyRepository creates a new internal dependency repository
-func NewInternalDependencyRepository(pool *pgxpool.Pool) *InternalDependencyRepository {
+func NewInternalDependencyRepository(pool DBTX) *InternalDependencyRepository {
 	return &InternalDependencyRepository{
 		BaseRepository: NewBaseRepository(pool),
 		pool:           pool,
@@ -117,7 +116,7 @@ func (r *InternalDependencyRepository) ListByNamespace(ctx context.Context, name
 		ORDER BY d.is_critical DESC, d.dependency_type ASC
 	`
 
-	rows, err := r.pool.Query(ctx, query, namespaceID)
+	rows, err := r.reader().Query(ctx, query, namespaceID)
 	if err != nil {
 		return nil, err
 	}
@@ -150,7 +149,7 @@ func (r *InternalDependencyRepository) ListByNamespace(ctx context.Context, name
 }
 
 // List retrieves all internal dependencies for an organization
-func (r *InternalDependencyRepository) List(ctx context.Context, orgID uuid.UUID, p Pagination) (*PaginatedResult[models.InternalDependency], error) {
+func (r *InternalDependencyRepository) List(ctx context.Context, orgID uuid.UUID, p Pagination, filters map[string]interface{}) (*PaginatedResult[models.InternalDependency], error) {
 	qb := NewQueryBuilder(`
 		SELECT 
 			id, organization_id,
@@ -165,18 +164,41 @@ func (r *InternalDependencyRepository) List(ctx context.Context, orgID uuid.UUID
 
 	qb.Where("organization_id = ?", orgID)
 	qb.Where("deleted_at IS NULL")
+	if dependencyType, ok := filters["dependency_type"].(string); ok && dependencyType != "" {
+		qb.Where("dependency_type = ?", dependencyType)
+	}
+	if status, ok := filters["status"].(string); ok && status != "" {
+		qb.Where("status = ?", status)
+	}
+	if critical, ok := filters["critical"].(bool); ok {
+		qb.Where("is_critical = ?", critical)
+	}
+	if autoDiscovered, ok := filters["auto_discovered"].(bool); ok {
+		qb.Where("is_auto_discovered = ?", autoDiscovered)
+	}
+	// Dependencies from or to a namespace of that name
+	if namespace, ok := filters["namespace"].(string); ok && namespace != "" {
+		qb.Where(`EXISTS (
+			SELECT 1 FROM namespaces ns
+			WHERE ns.id IN (source_namespace_id, target_namespace_id) AND ns.name = ? AND ns.deleted_at IS NULL
+		)`, namespace)
+	}
+	if search, ok := filters["search"].(string); ok && search != "" {
+		pattern := searchPattern(search)
+		qb.Where("(source_resource_name ILIKE ? OR target_resource_name ILIKE ? OR description ILIKE ?)", pattern, pattern, pattern)
+	}
 	qb.Paginate(p)
 
 	// Count
 	countQuery, countArgs := qb.BuildCount()
 	var total int64
-	if err := r.pool.QueryRow(ctx, countQuery, countArgs...).Scan(&total); err != nil {
+	if err := r.reader().QueryRow(ctx, countQuery, countArgs...).Scan(&total); err != nil {
 		return nil, err
 	}
 
 	// Data
 	query, args := qb.Build()
-	rows, err := r.pool.Query(ctx, query, args...)
+	rows, err := r.reader().Query(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -250,6 +272,60 @@ func (r *InternalDependencyRepository) Delete(ctx context.Context, id uuid.UUID)
 	return r.SoftDelete(ctx, "internal_dependencies", id)
 }
 
+// ListDependents returns the namespaces that depend on a namespace, directly
+// or through up to maxDepth dependencies, nearest first. Cycles are followed
+// only once.
+func (r *InternalDependencyRepository) ListDependents(ctx context.Context, namespaceID uuid.UUID, maxDepth int) ([]models.ImpactedNamespace, error) {
+	query := `
+		WITH RECURSIVE dependents AS (
+			SELECT d.source_namespace_id AS namespace_id, 1 AS depth, d.is_critical AS critical,
+				ARRAY[d.target_namespace_id, d.source_namespace_id] AS path
+			FROM internal_dependencies d
+			WHERE d.target_namespace_id = $1 AND d.deleted_at IS NULL
+			UNION ALL
+			SELECT d.source_namespace_id, dep.depth + 1, dep.critical AND d.is_critical,
+				dep.path || d.source_namespace_id
+			FROM internal_dependencies d
+			JOIN dependents dep ON d.target_namespace_id = dep.namespace_id
+			WHERE d.deleted_at IS NULL AND dep.depth < $2 AND NOT d.source_namespace_id = ANY(dep.path)
+		)
+		SELECT
+			n.id, n.name, c.id, c.name, COALESCE(n.environment, ''), COALESCE(n.criticality, ''),
+			n.infrastructure_owner_team_id, COALESCE(t.name, ''),
+			COALESCE(t.contact_email, ''), COALESCE(t.contact_slack, ''), COALESCE(t.pagerduty_service_id, ''),
+			COALESCE(t.opsgenie_schedule_id, ''),
+			MIN(dep.depth), BOOL_OR(dep.critical)
+		FROM dependents dep
+		JOIN namespaces n ON n.id = dep.namespace_id AND n.deleted_at IS NULL
+		JOIN clusters c ON c.id = n.cluster_id AND c.deleted_at IS NULL
+		LEFT JOIN teams t ON t.id = n.infrastructure_owner_team_id AND t.deleted_at IS NULL
+		WHERE n.id <> $1
+		GROUP BY n.id, c.id, t.id
+		ORDER BY MIN(dep.depth), BOOL_OR(dep.critical) DESC, c.name, n.name
+	`
+
+	rows, err := r.reader().Query(ctx, query, namespaceID, maxDepth)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query dependents: %w", err)
+	}
+	defer rows.Close()
+
+	dependents := make([]models.ImpactedNamespace, 0)
+	for rows.Next() {
+		var d models.ImpactedNamespace
+		if err := rows.Scan(
+			&d.NamespaceID, &d.Namespace, &d.ClusterID, &d.Cluster, &d.Environment, &d.Criticality,
+			&d.OwnerTeamID, &d.OwnerTeam,
+			&d.OwnerContactEmail, &d.OwnerContactSlack, &d.PagerDutyServiceID, &d.OpsgenieScheduleID,
+			&d.Depth, &d.Critical,
+		); err != nil {
+			return nil, err
+		}
+		dependents = append(dependents, d)
+	}
+	return dependents, rows.Err()
+}
+
 // ============================================
 // External Dependency Repository
 // ============================================
@@ -257,11 +333,11 @@ func (r *InternalDependencyRepository) Delete(ctx context.Context, id uuid.UUID)
 // ExternalDependencyRepository handles external dependency database operations
 type ExternalDependencyRepository struct {
 	*BaseRepository
-	pool *pgxpool.Pool
+	pool DBTX
 }
 
 // NewExternalDependencyRepository creates a new external dependency repository
-func NewExternalDependencyRepository(pool *pgxpool.Pool) *ExternalDependencyRepository {
+func NewExternalDependencyRepository(pool DBTX) *ExternalDependencyRepository {
 	return &ExternalDependencyRepository{
 		BaseRepository: NewBaseRepository(pool),
 		pool:           pool,
@@ -346,7 +422,7 @@ func (r *ExternalDependencyRepository) ListByNamespace(ctx context.Context, name
 		ORDER BY is_critical DESC, name ASC
 	`
 
-	rows, err := r.pool.Query(ctx, query, namespaceID)
+	rows, err := r.reader().Query(ctx, query, namespaceID)
 	if err != nil {
 		return nil, err
 	}
@@ -373,16 +449,8 @@ func (r *ExternalDependencyRepository) ListByNamespace(ctx context.Context, name
 }
 
 // List retrieves all external dependencies for an organization with pagination
-func (r *ExternalDependencyRepository) List(ctx context.Context, orgID uuid.UUID, p Pagination) (*PaginatedResult[models.ExternalDependency], error) {
-	// Count total
-	countQuery := `SELECT COUNT(*) FROM external_dependencies WHERE organization_id = $1 AND deleted_at IS NULL`
-	var total int64
-	if err := r.pool.QueryRow(ctx, countQuery, orgID).Scan(&total); err != nil {
-		return nil, err
-	}
-
-	// Get items
-	query := `
+func (r *ExternalDependencyRepository) List(ctx context.Context, orgID uuid.UUID, p Pagination, filters map[string]interface{}) (*PaginatedResult[models.ExternalDependency], error) {
+	qb := NewQueryBuilder(`
 		SELECT 
 			id, organization_id, namespace_id,
 			name, system_type, provider, endpoint, description,
@@ -391,13 +459,47 @@ func (r *ExternalDependencyRepository) List(ctx context.Context, orgID uuid.UUID
 			status, metadata,
 			created_at, updated_at
 		FROM external_dependencies
-		WHERE organization_id = $1 AND deleted_at IS NULL
-		ORDER BY is_critical DESC, name ASC
-		LIMIT $2 OFFSET $3
-	`
+	`)
 
-	offset := (p.Page - 1) * p.PageSize
-	rows, err := r.pool.Query(ctx, query, orgID, p.PageSize, offset)
+	qb.Where("organization_id = ?", orgID)
+	qb.Where("deleted_at IS NULL")
+	if systemType, ok := filters["system_type"].(string); ok && systemType != "" {
+		qb.Where("system_type = ?", systemType)
+	}
+	if provider, ok := filters["provider"].(string); ok && provider != "" {
+		qb.Where("lower(provider) = lower(?)", provider)
+	}
+	if status, ok := filters["status"].(string); ok && status != "" {
+		qb.Where("status = ?", status)
+	}
+	if critical, ok := filters["critical"].(bool); ok {
+		qb.Where("is_critical = ?", critical)
+	}
+	if namespace, ok := filters["namespace"].(string); ok && namespace != "" {
+		qb.Where(`EXISTS (
+			SELECT 1 FROM namespaces ns
+			WHERE ns.id = namespace_id AND ns.name = ? AND ns.deleted_at IS NULL
+		)`, namespace)
+	}
+	if search, ok := filters["search"].(string); ok && search != "" {
+		pattern := searchPattern(search)
+		qb.Where("(name ILIKE ? OR endpoint ILIKE ? OR description ILIKE ?)", pattern, pattern, pattern)
+	}
+	if p.Sort == "" {
+		qb.OrderBy("is_critical", "desc").ThenBy("name", "asc")
+	}
+	qb.Paginate(p)
+
+	// Count total
+	countQuery, countArgs := qb.BuildCount()
+	var total int64
+	if err := r.reader().QueryRow(ctx, countQuery, countArgs...).Scan(&total); err != nil {
+		return nil, err
+	}
+
+	// Get items
+	query, args := qb.Build()
+	rows, err := r.reader().Query(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -465,6 +567,27 @@ func (r *ExternalDependencyRepository) Update(ctx context.Context, dep *models.E
 	return nil
 }
 
+// UpdateStatus sets the status of an external dependency and returns the
+// status it had before
+func (r *ExternalDependencyRepository) UpdateStatus(ctx context.Context, id uuid.UUID, status string) (string, error) {
+	query := `
+		UPDATE external_dependencies d SET status = $2, updated_at = NOW()
+		FROM (
+			SELECT id, status FROM external_dependencies
+			WHERE id = $1 AND deleted_at IS NULL
+			FOR UPDATE
+		) old
+		WHERE d.id = old.id
+		RETURNING old.status
+	`
+
+	var previous models.NullString
+	if err := r.pool.QueryRow(ctx, query, id, status).Scan(&previous); err != nil {
+		return "", err
+	}
+	return previous.ValueOrEmpty(), nil
+}
+
 // Delete soft deletes an external dependency
 func (r *ExternalDependencyRepository) Delete(ctx context.Context, id uuid.UUID) error {
 	return r.SoftDelete(ctx, "external_dependencies", id)
@@ -477,11 +600,11 @@ func (r *ExternalDependencyRepository) Delete(ctx context.Context, id uuid.UUID)
 // DocumentRepository handles document database operations
 type DocumentRepository struct {
 	*BaseRepository
-	pool *pgxpool.Pool
+	pool DBTX
 }
 
 // NewDocumentRepository creates a new document repository
-func NewDocumentRepository(pool *pgxpool.Pool) *DocumentRepository {
+func NewDocumentRepository(pool DBTX) *DocumentRepository {
 	return &DocumentRepository{
 		BaseRepository: NewBaseRepository(pool),
 		pool:           pool,
@@ -497,18 +620,18 @@ func (r *DocumentRepository) Create(ctx context.Context, doc *models.Document) e
 
 	query := `
 		INSERT INTO documents (
-			id, organization_id, namespace_id, cluster_id,
+			id, organization_id, namespace_id, cluster_id, application_id,
 			name, file_name, file_path, file_size, mime_type, checksum,
 			category_id, description, tags,
 			version, previous_version_id,
 			uploaded_by, uploaded_at,
 			status, metadata,
 			created_at, updated_at
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21)
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22)
 	`
 
 	_, err := r.pool.Exec(ctx, query,
-		doc.ID, doc.OrganizationID, doc.NamespaceID, doc.ClusterID,
+		doc.ID, doc.OrganizationID, doc.NamespaceID, doc.ClusterID, doc.ApplicationID,
 		doc.Name, doc.FileName, doc.FilePath, doc.FileSize, doc.MimeType, doc.Checksum,
 		doc.CategoryID, doc.Description, doc.Tags,
 		doc.Version, doc.PreviousVersionID,
@@ -524,7 +647,7 @@ func (r *DocumentRepository) Create(ctx context.Context, doc *models.Document) e
 func (r *DocumentRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Document, error) {
 	query := `
 		SELECT 
-			d.id, d.organization_id, d.namespace_id, d.cluster_id,
+			d.id, d.organization_id, d.namespace_id, d.cluster_id, d.application_id,
 			d.name, d.file_name, d.file_path, d.file_size, d.mime_type, d.checksum,
 			d.category_id, d.description, d.tags,
 			d.version, d.previous_version_id,
@@ -541,7 +664,7 @@ func (r *DocumentRepository) GetByID(ctx context.Context, id uuid.UUID) (*models
 	var categoryName, categorySlug *string
 
 	err := r.pool.QueryRow(ctx, query, id).Scan(
-		&doc.ID, &doc.OrganizationID, &doc.NamespaceID, &doc.ClusterID,
+		&doc.ID, &doc.OrganizationID, &doc.NamespaceID, &doc.ClusterID, &doc.ApplicationID,
 		&doc.Name, &doc.FileName, &doc.FilePath, &doc.FileSize, &doc.MimeType, &doc.Checksum,
 		&doc.CategoryID, &doc.Description, &doc.Tags,
 		&doc.Version, &doc.PreviousVersionID,
@@ -575,7 +698,7 @@ func (r *DocumentRepository) GetByID(ctx context.Context, id uuid.UUID) (*models
 func (r *DocumentRepository) ListByNamespace(ctx context.Context, namespaceID uuid.UUID) ([]models.Document, error) {
 	query := `
 		SELECT 
-			d.id, d.organization_id, d.namespace_id, d.cluster_id,
+			d.id, d.organization_id, d.namespace_id, d.cluster_id, d.application_id,
 			d.name, d.file_name, d.file_path, d.file_size, d.mime_type, d.checksum,
 			d.category_id, d.description, d.tags,
 			d.version, d.previous_version_id,
@@ -591,7 +714,7 @@ func (r *DocumentRepository) ListByNamespace(ctx context.Context, namespaceID uu
 		ORDER BY d.uploaded_at DESC
 	`
 
-	rows, err := r.pool.Query(ctx, query, namespaceID)
+	rows, err := r.reader().Query(ctx, query, namespaceID)
 	if err != nil {
 		return nil, err
 	}
@@ -603,7 +726,7 @@ func (r *DocumentRepository) ListByNamespace(ctx context.Context, namespaceID uu
 		var categoryName, uploaderName *string
 
 		err := rows.Scan(
-			&d.ID, &d.OrganizationID, &d.NamespaceID, &d.ClusterID,
+			&d.ID, &d.OrganizationID, &d.NamespaceID, &d.ClusterID, &d.ApplicationID,
 			&d.Name, &d.FileName, &d.FilePath, &d.FileSize, &d.MimeType, &d.Checksum,
 			&d.CategoryID, &d.Description, &d.Tags,
 			&d.Version, &d.PreviousVersionID,
@@ -635,7 +758,7 @@ func (r *DocumentRepository) ListByNamespace(ctx context.Context, namespaceID uu
 func (r *DocumentRepository) GetRecent(ctx context.Context, orgID uuid.UUID, limit int) ([]models.Document, error) {
 	query := `
 		SELECT 
-			d.id, d.organization_id, d.namespace_id, d.cluster_id,
+			d.id, d.organization_id, d.namespace_id, d.cluster_id, d.application_id,
 			d.name, d.file_name, d.file_size, d.mime_type,
 			d.category_id, d.description, d.tags,
 			d.uploaded_by, d.uploaded_at,
@@ -650,7 +773,7 @@ func (r *DocumentRepository) GetRecent(ctx context.Context, orgID uuid.UUID, lim
 		LIMIT $2
 	`
 
-	rows, err := r.pool.Query(ctx, query, orgID, limit)
+	rows, err := r.reader().Query(ctx, query, orgID, limit)
 	if err != nil {
 		return nil, err
 	}
@@ -662,7 +785,7 @@ func (r *DocumentRepository) GetRecent(ctx context.Context, orgID uuid.UUID, lim
 		var namespaceName, uploaderName *string
 
 		err := rows.Scan(
-			&d.ID, &d.OrganizationID, &d.NamespaceID, &d.ClusterID,
+			&d.ID, &d.OrganizationID, &d.NamespaceID, &d.ClusterID, &d.ApplicationID,
 			&d.Name, &d.FileName, &d.FileSize, &d.MimeType,
 			&d.CategoryID, &d.Description, &d.Tags,
 			&d.UploadedBy, &d.UploadedAt,
@@ -728,7 +851,7 @@ func (r *DocumentRepository) GetCategories(ctx context.Context, orgID *uuid.UUID
 		ORDER BY sort_order ASC
 	`
 
-	rows, err := r.pool.Query(ctx, query, orgID)
+	rows, err := r.reader().Query(ctx, query, orgID)
 	if err != nil {
 		return nil, err
 	}
@@ -762,6 +885,11 @@ func (r *DocumentRepository) List(ctx context.Context, orgID uuid.UUID, p Pagina
 		where += fmt.Sprintf(" AND namespace_id = $%d", argCount)
 		args = append(args, nsID)
 	}
+	if appID, ok := filters["application_id"].(uuid.UUID); ok {
+		argCount++
+		where += fmt.Sprintf(" AND application_id = $%d", argCount)
+		args = append(args, appID)
+	}
 	if catID, ok := filters["category_id"].(uuid.UUID); ok {
 		argCount++
 		where += fmt.Sprintf(" AND category_id = $%d", argCount)
@@ -771,14 +899,14 @@ func (r *DocumentRepository) List(ctx context.Context, orgID uuid.UUID, p Pagina
 	// Count total
 	countQuery := fmt.Sprintf("SELECT COUNT(*) FROM documents WHERE %s", where)
 	var total int64
-	if err := r.pool.QueryRow(ctx, countQuery, args...).Scan(&total); err != nil {
+	if err := r.reader().QueryRow(ctx, countQuery, args...).Scan(&total); err != nil {
 		return nil, err
 	}
 
 	// Get items
 	query := fmt.Sprintf(`
 		SELECT 
-			id, organization_id, namespace_id, cluster_id,
+			id, organization_id, namespace_id, cluster_id, application_id,
 			name, file_name, file_path, file_size, mime_type, checksum,
 			category_id, description, tags,
 			version, previous_version_id,
@@ -793,7 +921,7 @@ func (r *DocumentRepository) List(ctx context.Context, orgID uuid.UUID, p Pagina
 	offset := (p.Page - 1) * p.PageSize
 	args = append(args, p.PageSize, offset)
 
-	rows, err := r.pool.Query(ctx, query, args...)
+	rows, err := r.reader().Query(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -803,7 +931,7 @@ func (r *DocumentRepository) List(ctx context.Context, orgID uuid.UUID, p Pagina
 	for rows.Next() {
 		var d models.Document
 		err := rows.Scan(
-			&d.ID, &d.OrganizationID, &d.NamespaceID, &d.ClusterID,
+			&d.ID, &d.OrganizationID, &d.NamespaceID, &d.ClusterID, &d.ApplicationID,
 			&d.Name, &d.FileName, &d.FilePath, &d.FileSize, &d.MimeType, &d.Checksum,
 			&d.CategoryID, &d.Description, &d.Tags,
 			&d.Version, &d.PreviousVersionID,
@@ -0,0 +1,159 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/kubeatlas/kubeatlas/internal/models"
+)
+
+// SavePages replaces the extracted text of a document, numbering pages from
+// 1 and skipping empty ones, and marks its text as extracted
+func (r *DocumentRepository) SavePages(ctx context.Context, id, orgID uuid.UUID, pages []string) error {
+	return runInTx(ctx, r.pool, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, `DELETE FROM document_pages WHERE document_id = $1`, id); err != nil {
+			return fmt.Errorf("failed to delete document pages: %w", err)
+		}
+		_, err := tx.Exec(ctx, `
+			INSERT INTO document_pages (document_id, organization_id, page, content)
+			SELECT $1, $2, u.page, u.content
+			FROM unnest($3::text[]) WITH ORDINALITY AS u(content, page)
+			WHERE u.content <> ''`,
+			id, orgID, pages,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to save document pages: %w", err)
+		}
+		_, err = tx.Exec(ctx, `UPDATE documents SET text_extracted_at = NOW() WHERE id = $1`, id)
+		return err
+	})
+}
+
+// ListTextPending returns up to limit current documents whose text was not
+// extracted yet, oldest first, with their organization, file and type
+func (r *DocumentRepository) ListTextPending(ctx context.Context, limit int) ([]models.Document, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT id, organization_id, name, file_path, file_size, mime_type
+		FROM documents
+		WHERE text_extracted_at IS NULL AND deleted_at IS NULL
+		ORDER BY uploaded_at
+		LIMIT $1`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	docs := make([]models.Document, 0)
+	for rows.Next() {
+		var d models.Document
+		if err := rows.Scan(&d.ID, &d.OrganizationID, &d.Name, &d.FilePath, &d.FileSize, &d.MimeType); err != nil {
+			return nil, err
+		}
+		docs = append(docs, d)
+	}
+	return docs, rows.Err()
+}
+
+// Headline markers ts_headline puts around matches, replaced by <mark> once
+// the snippet is HTML escaped
+const (
+	headlineStart = "\x01"
+	headlineStop  = "\x02"
+)
+
+// headlineOptions cut content snippets to about the words around the best
+// match
+const headlineOptions = "StartSel=" + headlineStart + ", StopSel=" + headlineStop + ", MinWords=15, MaxWords=35, MaxFragments=1"
+
+var headlineMarks = strings.NewReplacer(headlineStart, "<mark>", headlineStop, "</mark>")
+
+// Search returns up to limit of the organization's documents whose name,
+// file name, description, tags or contents contain term, best matches first.
+// Contents are matched word by word, with web search syntax ("quoted
+// phrases", -excluded words).
+//
+// Scores rank name matches (3) above description matches (1), tag matches
+// (0.5) and content matches (below 0.5, by relevance). Documents with
+// matching contents carry their best page and its snippet, with the words
+// found wrapped in <mark>, under "content".
+func (r *DocumentRepository) Search(ctx context.Context, orgID uuid.UUID, term string, limit int) ([]models.SearchResult, error) {
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+
+	query := `
+		WITH q AS (
+			SELECT websearch_to_tsquery('simple', $2) AS query
+		), matched_pages AS (
+			SELECT DISTINCT ON (p.document_id)
+				p.document_id, p.page, p.content, ts_rank_cd(p.search, q.query, 32) AS rank
+			FROM document_pages p, q
+			WHERE p.organization_id = $1 AND p.search @@ q.query
+			ORDER BY p.document_id, rank DESC, p.page
+		), ranked AS (
+			SELECT
+				d.id, d.organization_id, d.namespace_id, d.cluster_id,
+				d.name, d.file_name, d.file_size, d.mime_type,
+				d.category_id, d.description, d.tags,
+				d.uploaded_by, d.uploaded_at, d.status,
+				mp.page, mp.content,
+				CASE
+					WHEN d.name ILIKE $3 OR d.file_name ILIKE $3 THEN 3
+					WHEN d.description ILIKE $3 THEN 1
+					WHEN EXISTS (SELECT 1 FROM unnest(d.tags) AS tag WHERE tag ILIKE $3) THEN 0.5
+					WHEN mp.page IS NOT NULL THEN 0.5 * mp.rank
+					ELSE 0
+				END AS score
+			FROM documents d
+			LEFT JOIN matched_pages mp ON mp.document_id = d.id
+			WHERE d.organization_id = $1 AND d.deleted_at IS NULL
+		)
+		SELECT
+			id, organization_id, namespace_id, cluster_id,
+			name, file_name, file_size, mime_type,
+			category_id, description, tags,
+			uploaded_by, uploaded_at, status,
+			page,
+			CASE WHEN page IS NULL THEN NULL ELSE ts_headline('simple', content, (SELECT query FROM q), $5) END,
+			score
+		FROM (
+			SELECT * FROM ranked WHERE score > 0 ORDER BY score DESC, name LIMIT $4
+		) top
+		ORDER BY score DESC, name
+	`
+
+	rows, err := r.reader().Query(ctx, query, orgID, term, searchPattern(term), limit, headlineOptions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search documents: %w", err)
+	}
+	defer rows.Close()
+
+	results := make([]models.SearchResult, 0)
+	for rows.Next() {
+		d := &models.Document{}
+		var page *int
+		var headline *string
+		res := models.SearchResult{Type: models.SearchTypeDocument, Document: d}
+		if err := rows.Scan(
+			&d.ID, &d.OrganizationID, &d.NamespaceID, &d.ClusterID,
+			&d.Name, &d.FileName, &d.FileSize, &d.MimeType,
+			&d.CategoryID, &d.Description, &d.Tags,
+			&d.UploadedBy, &d.UploadedAt, &d.Status,
+			&page, &headline,
+			&res.Score,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan document: %w", err)
+		}
+		res.ID, res.Name = d.ID, d.Name
+		if page != nil && headline != nil {
+			res.Page = *page
+			res.Highlights = map[string]string{"content": headlineMarks.Replace(html.EscapeString(*headline))}
+		}
+		results = append(results, res)
+	}
+	return results, rows.Err()
+}
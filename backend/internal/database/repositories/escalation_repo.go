@@ -0,0 +1,207 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/kubeatlas/kubeatlas/internal/models"
+)
+
+// EscalationRepository handles escalating alerts
+type EscalationRepository struct {
+	*BaseRepository
+	pool DBTX
+}
+
+// NewEscalationRepository creates a new escalation repository
+func NewEscalationRepository(pool DBTX) *EscalationRepository {
+	return &EscalationRepository{
+		BaseRepository: NewBaseRepository(pool),
+		pool:           pool,
+	}
+}
+
+const escalationColumns = `
+	id, organization_id, namespace_id, reason, resource_type, resource_id, summary, status, level,
+	next_escalation_at, acknowledged_by, acknowledged_at, resolved_at, created_at, updated_at
+`
+
+func scanEscalation(row pgx.Row, e *models.Escalation) error {
+	return row.Scan(
+		&e.ID, &e.OrganizationID, &e.NamespaceID, &e.Reason, &e.ResourceType, &e.ResourceID, &e.Summary, &e.Status, &e.Level,
+		&e.NextEscalationAt, &e.AcknowledgedBy, &e.AcknowledgedAt, &e.ResolvedAt, &e.CreatedAt, &e.UpdatedAt,
+	)
+}
+
+// Create opens an escalation. It returns false without creating anything
+// when the resource already has an unresolved escalation for the reason.
+func (r *EscalationRepository) Create(ctx context.Context, e *models.Escalation) (bool, error) {
+	e.ID = uuid.New()
+	e.CreatedAt = time.Now()
+	e.UpdatedAt = e.CreatedAt
+	if e.Status == "" {
+		e.Status = models.EscalationStatusOpen
+	}
+
+	query := `
+		INSERT INTO escalations (
+			id, organization_id, namespace_id, reason, resource_type, resource_id, summary, status, level,
+			next_escalation_at, created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		ON CONFLICT (resource_type, resource_id, reason) WHERE status <> 'resolved' DO NOTHING
+	`
+
+	result, err := r.pool.Exec(ctx, query,
+		e.ID, e.OrganizationID, e.NamespaceID, e.Reason, e.ResourceType, e.ResourceID, e.Summary, e.Status, e.Level,
+		e.NextEscalationAt, e.CreatedAt, e.UpdatedAt,
+	)
+	if err != nil {
+		return false, err
+	}
+	return result.RowsAffected() > 0, nil
+}
+
+// GetByID retrieves an escalation by ID. Returns nil when it does not exist.
+func (r *EscalationRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Escalation, error) {
+	query := `SELECT ` + escalationColumns + ` FROM escalations WHERE id = $1`
+
+	e := &models.Escalation{}
+	err := scanEscalation(r.pool.QueryRow(ctx, query, id), e)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// List retrieves the organization's escalations, newest first
+func (r *EscalationRepository) List(ctx context.Context, orgID uuid.UUID, p Pagination, filters map[string]interface{}) (*PaginatedResult[models.Escalation], error) {
+	qb := NewQueryBuilder(`SELECT ` + escalationColumns + ` FROM escalations`)
+
+	qb.Where("organization_id = ?", orgID)
+	if status, ok := filters["status"].(string); ok && status != "" {
+		qb.Where("status = ?", status)
+	}
+	if namespaceID, ok := filters["namespace_id"].(uuid.UUID); ok {
+		qb.Where("namespace_id = ?", namespaceID)
+	}
+
+	p.Sort = "created_at"
+	p.Order = "desc"
+	qb.Paginate(p)
+
+	countQuery, countArgs := qb.BuildCount()
+	var total int64
+	if err := r.reader().QueryRow(ctx, countQuery, countArgs...).Scan(&total); err != nil {
+		return nil, fmt.Errorf("failed to count escalations: %w", err)
+	}
+
+	query, args := qb.Build()
+	rows, err := r.reader().Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query escalations: %w", err)
+	}
+	defer rows.Close()
+
+	escalations := make([]models.Escalation, 0)
+	for rows.Next() {
+		var e models.Escalation
+		if err := scanEscalation(rows, &e); err != nil {
+			return nil, fmt.Errorf("failed to scan escalation: %w", err)
+		}
+		escalations = append(escalations, e)
+	}
+
+	totalPages := int(total) / p.PageSize
+	if int(total)%p.PageSize > 0 {
+		totalPages++
+	}
+
+	return &PaginatedResult[models.Escalation]{
+		Items:      escalations,
+		Total:      total,
+		Page:       p.Page,
+		PageSize:   p.PageSize,
+		TotalPages: totalPages,
+	}, nil
+}
+
+// ClaimDue returns up to limit open escalations whose next level is due and
+// postpones them by staleAfter, so other instances skip them and a crashed
+// run is retried
+func (r *EscalationRepository) ClaimDue(ctx context.Context, limit int, staleAfter time.Duration) ([]models.Escalation, error) {
+	query := `
+		UPDATE escalations SET
+			next_escalation_at = NOW() + $2::interval,
+			updated_at = NOW()
+		WHERE id IN (
+			SELECT id FROM escalations
+			WHERE status = 'open' AND next_escalation_at <= NOW()
+			ORDER BY next_escalation_at
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + escalationColumns
+
+	rows, err := r.pool.Query(ctx, query, limit, fmt.Sprintf("%d seconds", int(staleAfter.Seconds())))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	escalations := make([]models.Escalation, 0)
+	for rows.Next() {
+		var e models.Escalation
+		if err := scanEscalation(rows, &e); err != nil {
+			return nil, err
+		}
+		escalations = append(escalations, e)
+	}
+	return escalations, rows.Err()
+}
+
+// SetLevel records that level levels were notified and when the next one is
+// due. A nil next stops the escalation at this level.
+func (r *EscalationRepository) SetLevel(ctx context.Context, id uuid.UUID, level int, next *time.Time) error {
+	query := `
+		UPDATE escalations SET level = $2, next_escalation_at = $3, updated_at = NOW()
+		WHERE id = $1 AND status = 'open'
+	`
+	_, err := r.pool.Exec(ctx, query, id, level, next)
+	return err
+}
+
+// Acknowledge stops an open escalation. It returns false when the
+// escalation is not open.
+func (r *EscalationRepository) Acknowledge(ctx context.Context, id uuid.UUID, userID *uuid.UUID) (bool, error) {
+	query := `
+		UPDATE escalations SET
+			status = 'acknowledged', acknowledged_by = $2, acknowledged_at = NOW(),
+			next_escalation_at = NULL, updated_at = NOW()
+		WHERE id = $1 AND status = 'open'
+	`
+	result, err := r.pool.Exec(ctx, query, id, userID)
+	if err != nil {
+		return false, err
+	}
+	return result.RowsAffected() > 0, nil
+}
+
+// Resolve resolves the resource's unresolved escalation for reason, if any
+func (r *EscalationRepository) Resolve(ctx context.Context, resourceType string, resourceID uuid.UUID, reason string) (bool, error) {
+	query := `
+		UPDATE escalations SET
+			status = 'resolved', resolved_at = NOW(), next_escalation_at = NULL, updated_at = NOW()
+		WHERE resource_type = $1 AND resource_id = $2 AND reason = $3 AND status <> 'resolved'
+	`
+	result, err := r.pool.Exec(ctx, query, resourceType, resourceID, reason)
+	if err != nil {
+		return false, err
+	}
+	return result.RowsAffected() > 0, nil
+}
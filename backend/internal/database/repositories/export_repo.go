@@ -0,0 +1,334 @@
+package repositories
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/kubeatlas/kubeatlas/internal/models"
+)
+
+// exportEntity selects an organization's rows of table for an export. In
+// where, t is the table and $1 the organization. Secret columns in exclude
+// are left out of every row.
+type exportEntity struct {
+	name    string
+	table   string
+	where   string
+	exclude []string
+}
+
+// Conditions selecting the rows of an organization, given as $1, from
+// tables aliased t
+const (
+	whereOrganization = `t.organization_id = $1`
+	whereOrgNamespace = `t.namespace_id IN (SELECT id FROM namespaces WHERE organization_id = $1)`
+	whereOrgCluster   = `t.cluster_id IN (SELECT id FROM clusters WHERE organization_id = $1)`
+	whereOrgTeam      = `t.team_id IN (SELECT id FROM teams WHERE organization_id = $1)`
+)
+
+// exportEntities lists the exported tables in export order. Integration
+// credentials live in organization settings, which are exported separately,
+// and API tokens are left out entirely.
+var exportEntities = []exportEntity{
+	{name: "users", table: "users", where: whereOrganization, exclude: []string{"password_hash"}},
+	{name: "teams", table: "teams", where: whereOrganization},
+	{name: "team_members", table: "team_members", where: whereOrgTeam},
+	{name: "team_directory_groups", table: "team_directory_groups", where: whereOrganization},
+	{name: "team_membership_changes", table: "team_membership_changes", where: whereOrganization},
+	{name: "team_on_call", table: "team_on_call", where: whereOrganization},
+	{name: "team_notification_bindings", table: "team_notification_bindings", where: whereOrganization,
+		exclude: []string{"teams_webhook_url"}},
+	{name: "business_units", table: "business_units", where: whereOrganization},
+	{name: "clusters", table: "clusters", where: whereOrganization,
+		exclude: []string{"kubeconfig_encrypted", "service_account_token_encrypted", "ca_certificate_encrypted"}},
+	{name: "cluster_sync_errors", table: "cluster_sync_errors", where: whereOrgCluster},
+	{name: "cluster_sync_runs", table: "cluster_sync_runs", where: whereOrgCluster},
+	{name: "cluster_nodes", table: "cluster_nodes", where: whereOrganization},
+	{name: "cluster_capacity_snapshots", table: "cluster_capacity_snapshots", where: whereOrgCluster},
+	{name: "cluster_version_alerts", table: "cluster_version_alerts", where: whereOrgCluster},
+	{name: "cluster_fleets", table: "cluster_fleets", where: whereOrganization},
+	{name: "cluster_fleet_members", table: "cluster_fleet_members", where: whereOrganization},
+	{name: "cluster_maintenance_windows", table: "cluster_maintenance_windows", where: whereOrganization},
+	{name: "namespaces", table: "namespaces", where: whereOrganization},
+	{name: "namespace_role_bindings", table: "namespace_role_bindings", where: whereOrgNamespace},
+	{name: "namespace_service_accounts", table: "namespace_service_accounts", where: whereOrgNamespace},
+	{name: "namespace_flux_resources", table: "namespace_flux_resources", where: whereOrgNamespace},
+	{name: "namespace_tickets", table: "namespace_tickets", where: whereOrganization},
+	{name: "namespace_confluence_pages", table: "namespace_confluence_pages", where: whereOrganization},
+	{name: "namespace_repositories", table: "namespace_repositories", where: whereOrganization},
+	{name: "namespace_monitoring_links", table: "namespace_monitoring_links", where: whereOrganization},
+	{name: "namespace_contact_issues", table: "namespace_contact_issues", where: whereOrganization},
+	{name: "applications", table: "applications", where: whereOrganization},
+	{name: "application_namespaces", table: "application_namespaces", where: whereOrganization},
+	{name: "internal_dependencies", table: "internal_dependencies", where: whereOrganization},
+	{name: "external_dependencies", table: "external_dependencies", where: whereOrganization},
+	{name: "escalations", table: "escalations", where: whereOrganization},
+	{name: "comments", table: "comments", where: whereOrganization},
+	{name: "document_categories", table: "document_categories", where: whereOrganization},
+	{name: "documents", table: "documents", where: whereOrganization, exclude: []string{"file_path"}},
+	{name: "webhook_subscriptions", table: "webhook_subscriptions", where: whereOrganization, exclude: []string{"secret_encrypted"}},
+	{name: "webhook_deliveries", table: "webhook_deliveries", where: whereOrganization},
+	{name: "notification_templates", table: "notification_templates", where: whereOrganization},
+	{name: "notification_deliveries", table: "notification_deliveries", where: whereOrganization},
+	{name: "scheduled_reports", table: "scheduled_reports", where: whereOrganization},
+	{name: "saved_searches", table: "saved_searches", where: whereOrganization},
+	{name: "audit_logs", table: "audit_logs", where: whereOrganization},
+}
+
+// ExportEntities lists the names of the exported entities in export order
+var ExportEntities = func() []string {
+	names := make([]string, len(exportEntities))
+	for i, e := range exportEntities {
+		names[i] = e.name
+	}
+	return names
+}()
+
+func lookupExportEntity(name string) (exportEntity, error) {
+	for _, e := range exportEntities {
+		if e.name == name {
+			return e, nil
+		}
+	}
+	return exportEntity{}, fmt.Errorf("unknown export entity %q", name)
+}
+
+// ExportFile is the stored file of a document in an export
+type ExportFile struct {
+	DocumentID uuid.UUID
+	FileName   string
+	FilePath   string
+}
+
+const orgExportColumns = `
+	id, organization_id, requested_by, requested_by_email, status, progress, step, object_key,
+	size_bytes, error, started_at, completed_at, expires_at, created_at, updated_at
+`
+
+func scanOrgExport(row pgx.Row, e *models.OrgExport) error {
+	return row.Scan(
+		&e.ID, &e.OrganizationID, &e.RequestedBy, &e.RequestedByEmail, &e.Status, &e.Progress, &e.Step, &e.ObjectKey,
+		&e.SizeBytes, &e.Error, &e.StartedAt, &e.CompletedAt, &e.ExpiresAt, &e.CreatedAt, &e.UpdatedAt,
+	)
+}
+
+// ExportRepository stores organization exports and reads the data exported
+// with them
+type ExportRepository struct {
+	*BaseRepository
+	pool DBTX
+}
+
+// NewExportRepository creates a new export repository
+func NewExportRepository(pool DBTX) *ExportRepository {
+	return &ExportRepository{
+		BaseRepository: NewBaseRepository(pool),
+		pool:           pool,
+	}
+}
+
+// Create queues an export, reporting false when the organization already
+// has one pending or running
+func (r *ExportRepository) Create(ctx context.Context, e *models.OrgExport) (bool, error) {
+	query := `
+		INSERT INTO org_exports (organization_id, requested_by, requested_by_email)
+		SELECT $1, $2, $3
+		WHERE NOT EXISTS (
+			SELECT 1 FROM org_exports WHERE organization_id = $1 AND status IN ('pending', 'running')
+		)
+		RETURNING ` + orgExportColumns
+
+	err := scanOrgExport(r.pool.QueryRow(ctx, query, e.OrganizationID, e.RequestedBy, e.RequestedByEmail), e)
+	if err == pgx.ErrNoRows {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// Get retrieves an organization's export, or nil when there is none
+func (r *ExportRepository) Get(ctx context.Context, orgID, id uuid.UUID) (*models.OrgExport, error) {
+	query := `SELECT ` + orgExportColumns + ` FROM org_exports WHERE id = $1 AND organization_id = $2`
+
+	var e models.OrgExport
+	err := scanOrgExport(r.pool.QueryRow(ctx, query, id, orgID), &e)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+// List retrieves an organization's most recent exports
+func (r *ExportRepository) List(ctx context.Context, orgID uuid.UUID, limit int) ([]models.OrgExport, error) {
+	query := `SELECT ` + orgExportColumns + ` FROM org_exports
+		WHERE organization_id = $1
+		ORDER BY created_at DESC
+		LIMIT $2`
+
+	rows, err := r.pool.Query(ctx, query, orgID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	exports := make([]models.OrgExport, 0)
+	for rows.Next() {
+		var e models.OrgExport
+		if err := scanOrgExport(rows, &e); err != nil {
+			return nil, err
+		}
+		exports = append(exports, e)
+	}
+	return exports, rows.Err()
+}
+
+// ClaimNext marks the oldest pending export as running and returns it, or
+// nil when there is none. Rows locked by another instance are skipped, and
+// running exports that made no progress for longer than staleAfter are
+// started over.
+func (r *ExportRepository) ClaimNext(ctx context.Context, staleAfter time.Duration) (*models.OrgExport, error) {
+	query := `
+		UPDATE org_exports SET
+			status = 'running',
+			progress = 0,
+			step = NULL,
+			error = NULL,
+			started_at = NOW(),
+			updated_at = NOW()
+		WHERE id = (
+			SELECT id FROM org_exports
+			WHERE status = 'pending' OR (status = 'running' AND updated_at < NOW() - $1::interval)
+			ORDER BY created_at
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + orgExportColumns
+
+	var e models.OrgExport
+	err := scanOrgExport(r.pool.QueryRow(ctx, query, fmt.Sprintf("%d seconds", int(staleAfter.Seconds()))), &e)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+// UpdateProgress records the step a running export is at
+func (r *ExportRepository) UpdateProgress(ctx context.Context, id uuid.UUID, progress int, step string) error {
+	query := `UPDATE org_exports SET progress = $2, step = $3, updated_at = NOW() WHERE id = $1 AND status = 'running'`
+	_, err := r.pool.Exec(ctx, query, id, progress, step)
+	return err
+}
+
+// Complete records the uploaded archive of an export
+func (r *ExportRepository) Complete(ctx context.Context, id uuid.UUID, objectKey string, size int64, expiresAt time.Time) error {
+	query := `
+		UPDATE org_exports SET
+			status = 'completed', progress = 100, step = NULL, object_key = $2, size_bytes = $3,
+			completed_at = NOW(), expires_at = $4, updated_at = NOW()
+		WHERE id = $1
+	`
+	_, err := r.pool.Exec(ctx, query, id, objectKey, size, expiresAt)
+	return err
+}
+
+// Fail records why an export failed
+func (r *ExportRepository) Fail(ctx context.Context, id uuid.UUID, msg string) error {
+	query := `
+		UPDATE org_exports SET status = 'failed', error = $2, completed_at = NOW(), updated_at = NOW()
+		WHERE id = $1
+	`
+	_, err := r.pool.Exec(ctx, query, id, msg)
+	return err
+}
+
+// ListExpired retrieves completed exports whose archive expired
+func (r *ExportRepository) ListExpired(ctx context.Context) ([]models.OrgExport, error) {
+	query := `SELECT ` + orgExportColumns + ` FROM org_exports
+		WHERE status = 'completed' AND expires_at <= NOW()
+		ORDER BY expires_at`
+
+	rows, err := r.pool.Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	exports := make([]models.OrgExport, 0)
+	for rows.Next() {
+		var e models.OrgExport
+		if err := scanOrgExport(rows, &e); err != nil {
+			return nil, err
+		}
+		exports = append(exports, e)
+	}
+	return exports, rows.Err()
+}
+
+// MarkExpired records that the archive of an export was removed
+func (r *ExportRepository) MarkExpired(ctx context.Context, id uuid.UUID) error {
+	query := `UPDATE org_exports SET status = 'expired', updated_at = NOW() WHERE id = $1`
+	_, err := r.pool.Exec(ctx, query, id)
+	return err
+}
+
+// EachRow calls fn with every row of an exported entity of the organization,
+// encoded as a JSON object without its secret columns
+func (r *ExportRepository) EachRow(ctx context.Context, orgID uuid.UUID, entity string, fn func(json.RawMessage) error) error {
+	e, err := lookupExportEntity(entity)
+	if err != nil {
+		return err
+	}
+
+	exclude := e.exclude
+	if exclude == nil {
+		exclude = []string{}
+	}
+	query := `SELECT to_jsonb(t) - $2::text[] FROM ` + pgx.Identifier{e.table}.Sanitize() + ` t WHERE ` + e.where
+
+	rows, err := r.pool.Query(ctx, query, orgID, exclude)
+	if err != nil {
+		return fmt.Errorf("failed to export %s: %w", entity, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var row []byte
+		if err := rows.Scan(&row); err != nil {
+			return fmt.Errorf("failed to export %s: %w", entity, err)
+		}
+		if err := fn(row); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// DocumentFiles lists the stored files of the organization's documents
+func (r *ExportRepository) DocumentFiles(ctx context.Context, orgID uuid.UUID) ([]ExportFile, error) {
+	query := `SELECT id, file_name, file_path FROM documents WHERE organization_id = $1 ORDER BY created_at`
+
+	rows, err := r.pool.Query(ctx, query, orgID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	files := make([]ExportFile, 0)
+	for rows.Next() {
+		var f ExportFile
+		if err := rows.Scan(&f.DocumentID, &f.FileName, &f.FilePath); err != nil {
+			return nil, err
+		}
+		files = append(files, f)
+	}
+	return files, rows.Err()
+}
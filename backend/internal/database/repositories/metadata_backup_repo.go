@@ -0,0 +1,175 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/kubeatlas/kubeatlas/internal/models"
+)
+
+const metadataBackupColumns = `
+	id, organization_id, trigger, created_by_email, object_key, size_bytes, counts, created_at
+`
+
+func scanMetadataBackup(row pgx.Row, b *models.MetadataBackup) error {
+	return row.Scan(
+		&b.ID, &b.OrganizationID, &b.Trigger, &b.CreatedByEmail, &b.ObjectKey, &b.SizeBytes, &b.Counts, &b.CreatedAt,
+	)
+}
+
+// BackupOwnership is the ownership of a namespace by natural keys: the owner
+// team by slug and the business unit by code, or by name when it has none
+type BackupOwnership struct {
+	Cluster                 string
+	Namespace               string
+	OwnerTeam               string
+	BusinessUnit            string
+	Criticality             string
+	ApplicationManagerName  string
+	ApplicationManagerEmail string
+	ApplicationManagerPhone string
+	TechnicalLeadName       string
+	TechnicalLeadEmail      string
+	ProjectManagerName      string
+	ProjectManagerEmail     string
+	SLAAvailability         string
+	SLARTO                  string
+	SLARPO                  string
+	SupportHours            string
+	EscalationPath          string
+}
+
+// MetadataBackupRepository stores the records of metadata backups and reads
+// the metadata backed up
+type MetadataBackupRepository struct {
+	*BaseRepository
+	pool DBTX
+}
+
+// NewMetadataBackupRepository creates a new metadata backup repository
+func NewMetadataBackupRepository(pool DBTX) *MetadataBackupRepository {
+	return &MetadataBackupRepository{
+		BaseRepository: NewBaseRepository(pool),
+		pool:           pool,
+	}
+}
+
+// Create records a backup stored in object storage
+func (r *MetadataBackupRepository) Create(ctx context.Context, b *models.MetadataBackup) error {
+	query := `
+		INSERT INTO metadata_backups (id, organization_id, trigger, created_by_email, object_key, size_bytes, counts)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING ` + metadataBackupColumns
+
+	return scanMetadataBackup(r.pool.QueryRow(ctx, query,
+		b.ID, b.OrganizationID, b.Trigger, b.CreatedByEmail, b.ObjectKey, b.SizeBytes, b.Counts,
+	), b)
+}
+
+// Get retrieves an organization's backup, or nil when there is none
+func (r *MetadataBackupRepository) Get(ctx context.Context, orgID, id uuid.UUID) (*models.MetadataBackup, error) {
+	query := `SELECT ` + metadataBackupColumns + ` FROM metadata_backups WHERE id = $1 AND organization_id = $2`
+
+	var b models.MetadataBackup
+	err := scanMetadataBackup(r.pool.QueryRow(ctx, query, id, orgID), &b)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
+
+// List retrieves an organization's backups, newest first, skipping offset
+func (r *MetadataBackupRepository) List(ctx context.Context, orgID uuid.UUID, offset int) ([]models.MetadataBackup, error) {
+	query := `SELECT ` + metadataBackupColumns + ` FROM metadata_backups
+		WHERE organization_id = $1
+		ORDER BY created_at DESC
+		OFFSET $2`
+
+	rows, err := r.pool.Query(ctx, query, orgID, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	backups := make([]models.MetadataBackup, 0)
+	for rows.Next() {
+		var b models.MetadataBackup
+		if err := scanMetadataBackup(rows, &b); err != nil {
+			return nil, err
+		}
+		backups = append(backups, b)
+	}
+	return backups, rows.Err()
+}
+
+// LastScheduled retrieves the organization's most recent scheduled backup,
+// or nil when there is none
+func (r *MetadataBackupRepository) LastScheduled(ctx context.Context, orgID uuid.UUID) (*models.MetadataBackup, error) {
+	query := `SELECT ` + metadataBackupColumns + ` FROM metadata_backups
+		WHERE organization_id = $1 AND trigger = $2
+		ORDER BY created_at DESC
+		LIMIT 1`
+
+	var b models.MetadataBackup
+	err := scanMetadataBackup(r.pool.QueryRow(ctx, query, orgID, models.BackupTriggerScheduled), &b)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
+
+// Delete removes the record of a backup
+func (r *MetadataBackupRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	_, err := r.pool.Exec(ctx, `DELETE FROM metadata_backups WHERE id = $1`, id)
+	return err
+}
+
+// ListOwnership retrieves the ownership of the organization's namespaces,
+// ordered by cluster and namespace
+func (r *MetadataBackupRepository) ListOwnership(ctx context.Context, orgID uuid.UUID) ([]BackupOwnership, error) {
+	query := `
+		SELECT
+			c.name, n.name, COALESCE(t.slug, ''), COALESCE(NULLIF(bu.code, ''), bu.name, ''), COALESCE(n.criticality, ''),
+			COALESCE(n.application_manager_name, ''), COALESCE(n.application_manager_email, ''),
+			COALESCE(n.application_manager_phone, ''), COALESCE(n.technical_lead_name, ''),
+			COALESCE(n.technical_lead_email, ''), COALESCE(n.project_manager_name, ''),
+			COALESCE(n.project_manager_email, ''), COALESCE(n.sla_availability, ''), COALESCE(n.sla_rto, ''),
+			COALESCE(n.sla_rpo, ''), COALESCE(n.support_hours, ''), COALESCE(n.escalation_path, '')
+		FROM namespaces n
+		JOIN clusters c ON c.id = n.cluster_id AND c.deleted_at IS NULL
+		LEFT JOIN teams t ON t.id = n.infrastructure_owner_team_id AND t.deleted_at IS NULL
+		LEFT JOIN business_units bu ON bu.id = n.business_unit_id AND bu.deleted_at IS NULL
+		WHERE n.organization_id = $1 AND n.deleted_at IS NULL AND n.merged_into_id IS NULL
+		ORDER BY c.name, n.name
+	`
+
+	rows, err := r.pool.Query(ctx, query, orgID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ownership := make([]BackupOwnership, 0)
+	for rows.Next() {
+		var o BackupOwnership
+		if err := rows.Scan(
+			&o.Cluster, &o.Namespace, &o.OwnerTeam, &o.BusinessUnit, &o.Criticality,
+			&o.ApplicationManagerName, &o.ApplicationManagerEmail,
+			&o.ApplicationManagerPhone, &o.TechnicalLeadName,
+			&o.TechnicalLeadEmail, &o.ProjectManagerName,
+			&o.ProjectManagerEmail, &o.SLAAvailability, &o.SLARTO,
+			&o.SLARPO, &o.SupportHours, &o.EscalationPath,
+		); err != nil {
+			return nil, err
+		}
+		ownership = append(ownership, o)
+	}
+	return ownership, rows.Err()
+}
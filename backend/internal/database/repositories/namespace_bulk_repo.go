@@ -0,0 +1,160 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/kubeatlas/kubeatlas/internal/models"
+)
+
+// bulkAssignmentColumns are the columns scanned by scanBulkAssignment
+const bulkAssignmentColumns = `
+	id, organization_id, requested_by, requested_by_email, changes, cardinality(namespace_ids),
+	status, processed, updated, unchanged, failed, failures, error,
+	started_at, completed_at, created_at, updated_at
+`
+
+func scanBulkAssignment(row pgx.Row, a *models.NamespaceBulkAssignment, extra ...interface{}) error {
+	return row.Scan(append([]interface{}{
+		&a.ID, &a.OrganizationID, &a.RequestedBy, &a.RequestedByEmail, &a.Changes, &a.Total,
+		&a.Status, &a.Processed, &a.Updated, &a.Unchanged, &a.Failed, &a.Failures, &a.Error,
+		&a.StartedAt, &a.CompletedAt, &a.CreatedAt, &a.UpdatedAt,
+	}, extra...)...)
+}
+
+// NamespaceBulkRepository stores bulk namespace assignments
+type NamespaceBulkRepository struct {
+	*BaseRepository
+	pool DBTX
+}
+
+// NewNamespaceBulkRepository creates a new bulk assignment repository
+func NewNamespaceBulkRepository(pool DBTX) *NamespaceBulkRepository {
+	return &NamespaceBulkRepository{
+		BaseRepository: NewBaseRepository(pool),
+		pool:           pool,
+	}
+}
+
+// Create records a bulk assignment of the given namespaces. One created
+// running is run by the caller, and a pending one waits for ClaimNext.
+func (r *NamespaceBulkRepository) Create(ctx context.Context, a *models.NamespaceBulkAssignment) error {
+	query := `
+		INSERT INTO namespace_bulk_assignments (
+			organization_id, requested_by, requested_by_email, changes, namespace_ids, status, started_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING ` + bulkAssignmentColumns
+
+	return scanBulkAssignment(r.pool.QueryRow(ctx, query,
+		a.OrganizationID, a.RequestedBy, a.RequestedByEmail, a.Changes, a.NamespaceIDs, a.Status, a.StartedAt,
+	), a)
+}
+
+// Get retrieves an organization's bulk assignment, or nil when there is none
+func (r *NamespaceBulkRepository) Get(ctx context.Context, orgID, id uuid.UUID) (*models.NamespaceBulkAssignment, error) {
+	query := `SELECT ` + bulkAssignmentColumns + ` FROM namespace_bulk_assignments WHERE id = $1 AND organization_id = $2`
+
+	var a models.NamespaceBulkAssignment
+	err := scanBulkAssignment(r.pool.QueryRow(ctx, query, id, orgID), &a)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+// List retrieves an organization's most recent bulk assignments
+func (r *NamespaceBulkRepository) List(ctx context.Context, orgID uuid.UUID, limit int) ([]models.NamespaceBulkAssignment, error) {
+	query := `SELECT ` + bulkAssignmentColumns + ` FROM namespace_bulk_assignments
+		WHERE organization_id = $1
+		ORDER BY created_at DESC
+		LIMIT $2`
+
+	rows, err := r.pool.Query(ctx, query, orgID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	assignments := make([]models.NamespaceBulkAssignment, 0)
+	for rows.Next() {
+		var a models.NamespaceBulkAssignment
+		if err := scanBulkAssignment(rows, &a); err != nil {
+			return nil, err
+		}
+		assignments = append(assignments, a)
+	}
+	return assignments, rows.Err()
+}
+
+// ClaimNext marks the oldest pending bulk assignment as running and returns
+// it with its namespaces, or nil when there is none. Rows locked by another
+// instance are skipped, and running assignments that made no progress for
+// longer than staleAfter are started over.
+func (r *NamespaceBulkRepository) ClaimNext(ctx context.Context, staleAfter time.Duration) (*models.NamespaceBulkAssignment, error) {
+	query := `
+		UPDATE namespace_bulk_assignments SET
+			status = 'running',
+			processed = 0, updated = 0, unchanged = 0, failed = 0, failures = '[]',
+			error = NULL,
+			started_at = NOW(),
+			updated_at = NOW()
+		WHERE id = (
+			SELECT id FROM namespace_bulk_assignments
+			WHERE status = 'pending' OR (status = 'running' AND updated_at < NOW() - $1::interval)
+			ORDER BY created_at
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + bulkAssignmentColumns + `, namespace_ids`
+
+	var a models.NamespaceBulkAssignment
+	err := scanBulkAssignment(r.pool.QueryRow(ctx, query, fmt.Sprintf("%d seconds", int(staleAfter.Seconds()))), &a, &a.NamespaceIDs)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+// UpdateProgress records the namespaces a running bulk assignment has done
+func (r *NamespaceBulkRepository) UpdateProgress(ctx context.Context, a *models.NamespaceBulkAssignment) error {
+	query := `
+		UPDATE namespace_bulk_assignments SET
+			processed = $2, updated = $3, unchanged = $4, failed = $5, failures = $6, updated_at = NOW()
+		WHERE id = $1 AND status = 'running'
+	`
+	_, err := r.pool.Exec(ctx, query, a.ID, a.Processed, a.Updated, a.Unchanged, a.Failed, a.Failures)
+	return err
+}
+
+// Complete records the summary of a finished bulk assignment
+func (r *NamespaceBulkRepository) Complete(ctx context.Context, a *models.NamespaceBulkAssignment) error {
+	query := `
+		UPDATE namespace_bulk_assignments SET
+			status = 'completed', processed = $2, updated = $3, unchanged = $4, failed = $5, failures = $6,
+			completed_at = NOW(), updated_at = NOW()
+		WHERE id = $1
+		RETURNING completed_at, updated_at
+	`
+	a.Status = models.BulkAssignmentStatusCompleted
+	return r.pool.QueryRow(ctx, query, a.ID, a.Processed, a.Updated, a.Unchanged, a.Failed, a.Failures).
+		Scan(&a.CompletedAt, &a.UpdatedAt)
+}
+
+// Fail records why a bulk assignment stopped
+func (r *NamespaceBulkRepository) Fail(ctx context.Context, id uuid.UUID, msg string) error {
+	query := `
+		UPDATE namespace_bulk_assignments SET status = 'failed', error = $2, completed_at = NOW(), updated_at = NOW()
+		WHERE id = $1
+	`
+	_, err := r.pool.Exec(ctx, query, id, msg)
+	return err
+}
@@ -0,0 +1,160 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/kubeatlas/kubeatlas/internal/models"
+)
+
+// ListDuplicates returns the organization's deleted namespaces that were
+// replaced by a current namespace in another cluster, with the namespace that
+// replaced each. A matching Kubernetes UID wins over a matching name; merged
+// namespaces are left out.
+func (r *NamespaceRepository) ListDuplicates(ctx context.Context, orgID uuid.UUID) ([]models.NamespaceDuplicate, error) {
+	query := `
+		SELECT * FROM (
+			SELECT DISTINCT ON (old.id)
+				old.id, oc.name, old.deleted_at, n.id, c.name, n.name,
+				CASE WHEN old.k8s_uid = n.k8s_uid THEN 'k8s_uid' ELSE 'name' END,
+				(SELECT COUNT(*) FROM documents d WHERE d.namespace_id = old.id AND d.deleted_at IS NULL),
+				(SELECT COUNT(*) FROM internal_dependencies d
+				 WHERE (d.source_namespace_id = old.id OR d.target_namespace_id = old.id) AND d.deleted_at IS NULL)
+				+ (SELECT COUNT(*) FROM external_dependencies d WHERE d.namespace_id = old.id AND d.deleted_at IS NULL)
+			FROM namespaces old
+			JOIN clusters oc ON oc.id = old.cluster_id
+			JOIN namespaces n ON n.organization_id = old.organization_id
+				AND n.cluster_id <> old.cluster_id AND n.deleted_at IS NULL
+			JOIN clusters c ON c.id = n.cluster_id AND c.deleted_at IS NULL
+			WHERE old.organization_id = $1 AND old.deleted_at IS NOT NULL AND old.merged_into_id IS NULL
+				AND (
+					(old.k8s_uid <> '' AND old.k8s_uid = n.k8s_uid)
+					OR (old.name = n.name AND (oc.name = c.name OR oc.api_server_url = c.api_server_url))
+				)
+			ORDER BY old.id, old.k8s_uid = n.k8s_uid DESC, n.created_at DESC
+		) duplicates
+		ORDER BY 5, 6, 3 DESC
+	`
+
+	rows, err := r.pool.Query(ctx, query, orgID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	duplicates := make([]models.NamespaceDuplicate, 0)
+	for rows.Next() {
+		var d models.NamespaceDuplicate
+		if err := rows.Scan(
+			&d.DeletedID, &d.DeletedCluster, &d.DeletedAt, &d.NamespaceID, &d.Cluster, &d.Namespace,
+			&d.MatchedBy, &d.Documents, &d.Dependencies,
+		); err != nil {
+			return nil, err
+		}
+		duplicates = append(duplicates, d)
+	}
+	return duplicates, rows.Err()
+}
+
+// Merge moves the metadata, documents, dependencies and links of the deleted
+// namespace deletedID to the current namespace id of the same organization,
+// and marks the deleted namespace merged. Metadata fills only the fields the
+// current namespace leaves empty; tags and custom fields are combined, the
+// current namespace's values winning. Tickets, Confluence pages, repositories
+// and monitoring links the current namespace already has stay where they
+// are. It returns nil when either namespace is not found, or the deleted one
+// is already merged.
+func (r *NamespaceRepository) Merge(ctx context.Context, orgID, deletedID, id uuid.UUID) (*models.NamespaceMerge, error) {
+	var merge *models.NamespaceMerge
+	err := runInTx(ctx, r.pool, func(tx pgx.Tx) error {
+		var found bool
+		err := tx.QueryRow(ctx,
+			`SELECT EXISTS(SELECT 1 FROM namespaces WHERE id = $1 AND organization_id = $2 AND deleted_at IS NULL)`,
+			id, orgID,
+		).Scan(&found)
+		if err != nil || !found {
+			return err
+		}
+
+		result, err := tx.Exec(ctx, `
+			UPDATE namespaces SET merged_into_id = $3, merged_at = NOW()
+			WHERE id = $1 AND organization_id = $2 AND deleted_at IS NOT NULL AND merged_into_id IS NULL`,
+			deletedID, orgID, id,
+		)
+		if err != nil || result.RowsAffected() == 0 {
+			return err
+		}
+
+		_, err = tx.Exec(ctx, `
+			UPDATE namespaces n SET
+				display_name = COALESCE(NULLIF(n.display_name, ''), old.display_name),
+				description = COALESCE(NULLIF(n.description, ''), old.description),
+				environment = COALESCE(NULLIF(n.environment, ''), old.environment),
+				criticality = COALESCE(NULLIF(n.criticality, ''), old.criticality),
+				infrastructure_owner_team_id = COALESCE(n.infrastructure_owner_team_id, old.infrastructure_owner_team_id),
+				infrastructure_owner_user_id = COALESCE(n.infrastructure_owner_user_id, old.infrastructure_owner_user_id),
+				business_unit_id = COALESCE(n.business_unit_id, old.business_unit_id),
+				application_manager_name = COALESCE(NULLIF(n.application_manager_name, ''), old.application_manager_name),
+				application_manager_email = COALESCE(NULLIF(n.application_manager_email, ''), old.application_manager_email),
+				application_manager_phone = COALESCE(NULLIF(n.application_manager_phone, ''), old.application_manager_phone),
+				technical_lead_name = COALESCE(NULLIF(n.technical_lead_name, ''), old.technical_lead_name),
+				technical_lead_email = COALESCE(NULLIF(n.technical_lead_email, ''), old.technical_lead_email),
+				project_manager_name = COALESCE(NULLIF(n.project_manager_name, ''), old.project_manager_name),
+				project_manager_email = COALESCE(NULLIF(n.project_manager_email, ''), old.project_manager_email),
+				sla_availability = COALESCE(NULLIF(n.sla_availability, ''), old.sla_availability),
+				sla_rto = COALESCE(NULLIF(n.sla_rto, ''), old.sla_rto),
+				sla_rpo = COALESCE(NULLIF(n.sla_rpo, ''), old.sla_rpo),
+				support_hours = COALESCE(NULLIF(n.support_hours, ''), old.support_hours),
+				escalation_path = COALESCE(NULLIF(n.escalation_path, ''), old.escalation_path),
+				lifecycle = CASE WHEN n.lifecycle = 'onboarding' THEN old.lifecycle ELSE n.lifecycle END,
+				lifecycle_changed_at = CASE WHEN n.lifecycle = 'onboarding' THEN old.lifecycle_changed_at ELSE n.lifecycle_changed_at END,
+				decommission_date = COALESCE(n.decommission_date, old.decommission_date),
+				tags = ARRAY(SELECT DISTINCT unnest(COALESCE(n.tags, '{}') || COALESCE(old.tags, '{}'))),
+				custom_fields = COALESCE(old.custom_fields, '{}') || COALESCE(n.custom_fields, '{}'),
+				updated_at = NOW()
+			FROM namespaces old
+			WHERE n.id = $1 AND old.id = $2`,
+			id, deletedID,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to merge metadata: %w", err)
+		}
+
+		m := &models.NamespaceMerge{DeletedID: deletedID, NamespaceID: id}
+		moves := []struct {
+			query string
+			count *int64
+		}{
+			{`UPDATE documents SET namespace_id = $1 WHERE namespace_id = $2`, &m.Documents},
+			// Dependencies between the two namespaces would depend on themselves
+			{`UPDATE internal_dependencies SET source_namespace_id = $1, updated_at = NOW()
+				WHERE source_namespace_id = $2 AND target_namespace_id <> $1`, &m.InternalDependencies},
+			{`UPDATE internal_dependencies SET target_namespace_id = $1, updated_at = NOW()
+				WHERE target_namespace_id = $2 AND source_namespace_id <> $1`, &m.InternalDependencies},
+			{`UPDATE external_dependencies SET namespace_id = $1, updated_at = NOW() WHERE namespace_id = $2`, &m.ExternalDependencies},
+			{`UPDATE namespace_tickets SET namespace_id = $1
+				WHERE namespace_id = $2 AND NOT EXISTS (SELECT 1 FROM namespace_tickets WHERE namespace_id = $1)`, &m.Links},
+			{`UPDATE namespace_confluence_pages SET namespace_id = $1
+				WHERE namespace_id = $2 AND NOT EXISTS (SELECT 1 FROM namespace_confluence_pages WHERE namespace_id = $1)`, &m.Links},
+			{`UPDATE namespace_repositories SET namespace_id = $1
+				WHERE namespace_id = $2 AND url NOT IN (SELECT url FROM namespace_repositories WHERE namespace_id = $1)`, &m.Links},
+			{`UPDATE namespace_monitoring_links SET namespace_id = $1
+				WHERE namespace_id = $2 AND url NOT IN (SELECT url FROM namespace_monitoring_links WHERE namespace_id = $1)`, &m.Links},
+		}
+		for _, move := range moves {
+			result, err := tx.Exec(ctx, move.query, id, deletedID)
+			if err != nil {
+				return fmt.Errorf("failed to move namespace records: %w", err)
+			}
+			*move.count += result.RowsAffected()
+		}
+		merge = m
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to merge namespace: %w", err)
+	}
+	return merge, nil
+}
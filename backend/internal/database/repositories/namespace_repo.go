@@ -4,23 +4,25 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
-	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/kubeatlas/kubeatlas/internal/models"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
 )
 
 // NamespaceRepository handles namespace database operations
 type NamespaceRepository struct {
 	*BaseRepository
-	pool *pgxpool.Pool
+	pool DBTX
 }
 
 // NewNamespaceRepository creates a new namespace repository
-func NewNamespaceRepository(pool *pgxpool.Pool) *NamespaceRepository {
+func NewNamespaceRepository(pool DBTX) *NamespaceRepository {
 	return &NamespaceRepository{
 		BaseRepository: NewBaseRepository(pool),
 		pool:           pool,
@@ -32,6 +34,9 @@ func (r *NamespaceRepository) Create(ctx context.Context, ns *models.Namespace)
 	ns.ID = uuid.New()
 	ns.CreatedAt = time.Now()
 	ns.UpdatedAt = time.Now()
+	if ns.Lifecycle == "" {
+		ns.Lifecycle = models.NamespaceLifecycleOnboarding
+	}
 
 	query := `
 		INSERT INTO namespaces (
@@ -47,7 +52,8 @@ func (r *NamespaceRepository) Create(ctx context.Context, ns *models.Namespace)
 			status, discovered_at, last_sync_at,
 			k8s_uid, k8s_labels, k8s_annotations, k8s_created_at,
 			tags, custom_fields, metadata,
-			created_at, updated_at
+			created_at, updated_at,
+			lifecycle
 		) VALUES (
 			$1, $2, $3,
 			$4, $5, $6,
@@ -61,7 +67,8 @@ func (r *NamespaceRepository) Create(ctx context.Context, ns *models.Namespace)
 			$24, $25, $26,
 			$27, $28, $29, $30,
 			$31, $32, $33,
-			$34, $35
+			$34, $35,
+			$36
 		)
 	`
 
@@ -79,6 +86,7 @@ func (r *NamespaceRepository) Create(ctx context.Context, ns *models.Namespace)
 		ns.K8sUID, ns.K8sLabels, ns.K8sAnnotations, ns.K8sCreatedAt,
 		ns.Tags, ns.CustomFields, ns.Metadata,
 		ns.CreatedAt, ns.UpdatedAt,
+		ns.Lifecycle,
 	)
 
 	return err
@@ -98,10 +106,13 @@ func (r *NamespaceRepository) GetByID(ctx context.Context, id uuid.UUID) (*model
 			n.project_manager_name, n.project_manager_email,
 			n.sla_availability, n.sla_rto, n.sla_rpo, n.support_hours, n.escalation_path,
 			n.status, n.discovered_at, n.last_sync_at,
+			n.lifecycle, n.lifecycle_changed_at, to_char(n.decommission_date, 'YYYY-MM-DD'),
+			n.cpu_usage_millicores, n.cpu_usage_peak_millicores, n.memory_usage_bytes, n.memory_usage_peak_bytes, n.usage_observed_at,
 			n.k8s_uid, n.k8s_labels, n.k8s_annotations, n.k8s_created_at,
 			n.tags, n.custom_fields, n.metadata,
-			n.created_at, n.updated_at
-		FROM namespaces n
+			n.created_at, n.updated_at,
+			cs.completeness_score
+		FROM namespaces n` + namespaceCompletenessJoin + `
 		WHERE n.id = $1 AND n.deleted_at IS NULL
 	`
 
@@ -117,9 +128,12 @@ func (r *NamespaceRepository) GetByID(ctx context.Context, id uuid.UUID) (*model
 		&ns.ProjectManagerName, &ns.ProjectManagerEmail,
 		&ns.SLAAvailability, &ns.SLARTO, &ns.SLARPO, &ns.SupportHours, &ns.EscalationPath,
 		&ns.Status, &ns.DiscoveredAt, &ns.LastSyncAt,
+		&ns.Lifecycle, &ns.LifecycleChangedAt, &ns.DecommissionDate,
+		&ns.CPUUsageMillicores, &ns.CPUUsagePeakMillicores, &ns.MemoryUsageBytes, &ns.MemoryUsagePeakBytes, &ns.UsageObservedAt,
 		&ns.K8sUID, &ns.K8sLabels, &ns.K8sAnnotations, &ns.K8sCreatedAt,
 		&ns.Tags, &ns.CustomFields, &ns.Metadata,
 		&ns.CreatedAt, &ns.UpdatedAt,
+		&ns.CompletenessScore,
 	)
 
 	if err == pgx.ErrNoRows {
@@ -146,6 +160,7 @@ func (r *NamespaceRepository) GetByClusterAndName(ctx context.Context, clusterID
 			project_manager_name, project_manager_email,
 			sla_availability, sla_rto, sla_rpo, support_hours, escalation_path,
 			status, discovered_at, last_sync_at,
+			lifecycle, lifecycle_changed_at, to_char(decommission_date, 'YYYY-MM-DD'),
 			k8s_uid, k8s_labels, k8s_annotations, k8s_created_at,
 			tags, custom_fields, metadata,
 			created_at, updated_at
@@ -165,6 +180,7 @@ func (r *NamespaceRepository) GetByClusterAndName(ctx context.Context, clusterID
 		&ns.ProjectManagerName, &ns.ProjectManagerEmail,
 		&ns.SLAAvailability, &ns.SLARTO, &ns.SLARPO, &ns.SupportHours, &ns.EscalationPath,
 		&ns.Status, &ns.DiscoveredAt, &ns.LastSyncAt,
+		&ns.Lifecycle, &ns.LifecycleChangedAt, &ns.DecommissionDate,
 		&ns.K8sUID, &ns.K8sLabels, &ns.K8sAnnotations, &ns.K8sCreatedAt,
 		&ns.Tags, &ns.CustomFields, &ns.Metadata,
 		&ns.CreatedAt, &ns.UpdatedAt,
@@ -180,9 +196,13 @@ func (r *NamespaceRepository) GetByClusterAndName(ctx context.Context, clusterID
 	return ns, nil
 }
 
-// List retrieves namespaces with pagination and filters
+// List retrieves namespaces with pagination and filters.
+// When filters["include_relations"] is true, the owning cluster, team and
+// business unit are joined in and populated on each namespace.
 func (r *NamespaceRepository) List(ctx context.Context, orgID uuid.UUID, p Pagination, filters map[string]interface{}) (*PaginatedResult[models.Namespace], error) {
-	qb := NewQueryBuilder(`
+	includeRelations, _ := filters["include_relations"].(bool)
+
+	query := `
 		SELECT 
 			n.id, n.organization_id, n.cluster_id,
 			n.name, n.display_name, n.description,
@@ -194,16 +214,130 @@ func (r *NamespaceRepository) List(ctx context.Context, orgID uuid.UUID, p Pagin
 			n.project_manager_name, n.project_manager_email,
 			n.sla_availability, n.sla_rto, n.sla_rpo, n.support_hours, n.escalation_path,
 			n.status, n.discovered_at, n.last_sync_at,
+			n.lifecycle, n.lifecycle_changed_at, to_char(n.decommission_date, 'YYYY-MM-DD'),
+			n.cpu_usage_millicores, n.cpu_usage_peak_millicores, n.memory_usage_bytes, n.memory_usage_peak_bytes, n.usage_observed_at,
 			n.k8s_uid, n.k8s_labels, n.k8s_annotations, n.k8s_created_at,
 			n.tags, n.custom_fields, n.metadata,
-			n.created_at, n.updated_at
-		FROM namespaces n
-	`)
+			n.created_at, n.updated_at,
+			cs.completeness_score`
+	if includeRelations {
+		query += `,
+			c.name, c.display_name, c.environment, c.cluster_type,
+			t.name, t.slug,
+			bu.name, bu.code,
+			nt.issue_key, nt.issue_url, nt.reason, nt.status, nt.status_category, nt.status_checked_at, nt.created_at
+		FROM namespaces n` + namespaceCompletenessJoin + `
+		LEFT JOIN clusters c ON c.id = n.cluster_id AND c.deleted_at IS NULL
+		LEFT JOIN teams t ON t.id = n.infrastructure_owner_team_id AND t.deleted_at IS NULL
+		LEFT JOIN business_units bu ON bu.id = n.business_unit_id AND bu.deleted_at IS NULL
+		LEFT JOIN namespace_tickets nt ON nt.namespace_id = n.id
+	`
+	} else {
+		query += `
+		FROM namespaces n` + namespaceCompletenessJoin + `
+	`
+	}
+
+	// Unmeasured namespaces sort after measured ones either way
+	qb := NewQueryBuilder(query).SortAlias("n").
+		SortColumn("completeness_score", "cs.completeness_score").
+		SortColumn("cpu_usage", "n.cpu_usage_millicores IS NULL, n.cpu_usage_millicores").
+		SortColumn("memory_usage", "n.memory_usage_bytes IS NULL, n.memory_usage_bytes")
 
 	qb.Where("n.organization_id = ?", orgID)
 	qb.Where("n.deleted_at IS NULL")
 
-	// Apply filters
+	whereNamespaceFilters(qb, filters)
+
+	// Default sort
+	if p.Sort == "" {
+		p.Sort = "n.name"
+		p.Order = "asc"
+	}
+
+	qb.Paginate(p)
+	// Many namespaces share a score or are unmeasured, so pages need a
+	// stable order within one
+	if p.Sort == "completeness_score" || p.Sort == "cpu_usage" || p.Sort == "memory_usage" {
+		qb.ThenBy("name", "asc")
+	}
+
+	// Get total count
+	countQuery, countArgs := qb.BuildCount()
+	var total int64
+	if err := r.reader().QueryRow(ctx, countQuery, countArgs...).Scan(&total); err != nil {
+		return nil, fmt.Errorf("failed to count namespaces: %w", err)
+	}
+
+	// Get data
+	query, args := qb.Build()
+	rows, err := r.reader().Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query namespaces: %w", err)
+	}
+	defer rows.Close()
+
+	namespaces := make([]models.Namespace, 0)
+	for rows.Next() {
+		var ns models.Namespace
+		dest := []interface{}{
+			&ns.ID, &ns.OrganizationID, &ns.ClusterID,
+			&ns.Name, &ns.DisplayName, &ns.Description,
+			&ns.Environment, &ns.Criticality,
+			&ns.InfrastructureOwnerTeamID, &ns.InfrastructureOwnerUserID,
+			&ns.BusinessUnitID,
+			&ns.ApplicationManagerName, &ns.ApplicationManagerEmail, &ns.ApplicationManagerPhone,
+			&ns.TechnicalLeadName, &ns.TechnicalLeadEmail,
+			&ns.ProjectManagerName, &ns.ProjectManagerEmail,
+			&ns.SLAAvailability, &ns.SLARTO, &ns.SLARPO, &ns.SupportHours, &ns.EscalationPath,
+			&ns.Status, &ns.DiscoveredAt, &ns.LastSyncAt,
+			&ns.Lifecycle, &ns.LifecycleChangedAt, &ns.DecommissionDate,
+			&ns.CPUUsageMillicores, &ns.CPUUsagePeakMillicores, &ns.MemoryUsageBytes, &ns.MemoryUsagePeakBytes, &ns.UsageObservedAt,
+			&ns.K8sUID, &ns.K8sLabels, &ns.K8sAnnotations, &ns.K8sCreatedAt,
+			&ns.Tags, &ns.CustomFields, &ns.Metadata,
+			&ns.CreatedAt, &ns.UpdatedAt,
+			&ns.CompletenessScore,
+		}
+
+		var rel namespaceRelations
+		if includeRelations {
+			dest = append(dest,
+				&rel.clusterName, &rel.clusterDisplayName, &rel.clusterEnvironment, &rel.clusterType,
+				&rel.teamName, &rel.teamSlug,
+				&rel.businessUnitName, &rel.businessUnitCode,
+				&rel.ticketKey, &rel.ticketURL, &rel.ticketReason, &rel.ticketStatus, &rel.ticketStatusCategory, &rel.ticketCheckedAt, &rel.ticketCreatedAt,
+			)
+		}
+
+		if err := rows.Scan(dest...); err != nil {
+			return nil, fmt.Errorf("failed to scan namespace: %w", err)
+		}
+		if includeRelations {
+			rel.apply(&ns)
+		}
+		namespaces = append(namespaces, ns)
+	}
+
+	totalPages := int(total) / p.PageSize
+	if int(total)%p.PageSize > 0 {
+		totalPages++
+	}
+
+	return &PaginatedResult[models.Namespace]{
+		Items:      namespaces,
+		Total:      total,
+		Page:       p.Page,
+		PageSize:   p.PageSize,
+		TotalPages: totalPages,
+	}, nil
+}
+
+// whereNamespaceFilters adds the conditions of the namespace list filters
+// to a query of the namespaces n joined with namespaceCompletenessJoin
+func whereNamespaceFilters(qb *QueryBuilder, filters map[string]interface{}) {
+	if ids, ok := filters["ids"].([]uuid.UUID); ok {
+		qb.Where("n.id = ANY(?)", ids)
+	}
 	if clusterID, ok := filters["cluster_id"].(uuid.UUID); ok {
 		qb.Where("n.cluster_id = ?", clusterID)
 	}
@@ -216,15 +350,62 @@ func (r *NamespaceRepository) List(ctx context.Context, orgID uuid.UUID, p Pagin
 	if status, ok := filters["status"].(string); ok && status != "" {
 		qb.Where("n.status = ?", status)
 	}
+	if lifecycle, ok := filters["lifecycle"].(string); ok && lifecycle != "" {
+		qb.Where("n.lifecycle = ?", lifecycle)
+	}
+	// Decommissioning namespaces whose decommission date has passed
+	if overdue, ok := filters["decommission_overdue"].(bool); ok && overdue {
+		qb.Where("n.lifecycle = ? AND n.decommission_date < CURRENT_DATE", models.NamespaceLifecycleDecommissioning)
+	}
+	// Archived namespaces only, or none of them
+	if archived, ok := filters["archived"].(bool); ok {
+		if archived {
+			qb.Where("n.status = ?", models.NamespaceStatusArchived)
+		} else {
+			qb.Where("n.status <> ?", models.NamespaceStatusArchived)
+		}
+	}
+	// Pod Security Admission enforce level; "unset" matches namespaces without one
+	if podSecurity, ok := filters["pod_security"].(string); ok && podSecurity != "" {
+		if podSecurity == models.PodSecurityUnset {
+			qb.Where("COALESCE(n.k8s_labels->>'pod-security.kubernetes.io/enforce', '') = ''")
+		} else {
+			qb.Where("n.k8s_labels->>'pod-security.kubernetes.io/enforce' = ?", podSecurity)
+		}
+	}
+	if selector, ok := filters["label_selector"].(labels.Selector); ok {
+		whereLabelSelector(qb, "n.k8s_labels", selector)
+	}
 	if businessUnitID, ok := filters["business_unit_id"].(uuid.UUID); ok {
 		qb.Where("n.business_unit_id = ?", businessUnitID)
 	}
 	if teamID, ok := filters["team_id"].(uuid.UUID); ok {
 		qb.Where("n.infrastructure_owner_team_id = ?", teamID)
 	}
+	if applicationID, ok := filters["application_id"].(uuid.UUID); ok {
+		qb.Where("n.id IN (SELECT namespace_id FROM application_namespaces WHERE application_id = ?)", applicationID)
+	}
+	// Clusters and owner teams by name, as the query language names them
+	if cluster, ok := filters["cluster"].(string); ok && cluster != "" {
+		qb.Where(`n.cluster_id IN (
+			SELECT id FROM clusters WHERE organization_id = n.organization_id AND name = ? AND deleted_at IS NULL
+		)`, cluster)
+	}
+	if team, ok := filters["team"].(string); ok && team != "" {
+		qb.Where(`n.infrastructure_owner_team_id IN (
+			SELECT id FROM teams WHERE organization_id = n.organization_id AND (slug = ? OR lower(name) = lower(?)) AND deleted_at IS NULL
+		)`, team, team)
+	}
+	if tag, ok := filters["tag"].(string); ok && tag != "" {
+		qb.Where("n.tags @> ARRAY[?]::text[]", tag)
+	}
 	if search, ok := filters["search"].(string); ok && search != "" {
-		qb.Where("(n.name ILIKE ? OR n.display_name ILIKE ? OR n.description ILIKE ?)",
-			"%"+search+"%", "%"+search+"%", "%"+search+"%")
+		pattern := searchPattern(search)
+		if threshold, ok := filters["fuzzy_threshold"].(float64); ok && threshold > 0 {
+			qb.Where("("+namespaceSearchCondition+" OR "+namespaceFuzzyCondition+")", pattern, pattern, pattern, search, threshold, search, threshold)
+		} else {
+			qb.Where(namespaceSearchCondition, pattern, pattern, pattern)
+		}
 	}
 
 	// Filter for orphaned (no owner)
@@ -245,65 +426,317 @@ func (r *NamespaceRepository) List(ctx context.Context, orgID uuid.UUID, p Pagin
 		qb.Where("n.business_unit_id IS NULL")
 	}
 
-	// Default sort
-	if p.Sort == "" {
-		p.Sort = "n.name"
-		p.Order = "asc"
+	// Filter for contacts whose email addresses failed validation
+	if invalidContacts, ok := filters["invalid_contacts"].(bool); ok && invalidContacts {
+		qb.Where("EXISTS (SELECT 1 FROM namespace_contact_issues ci WHERE ci.namespace_id = n.id)")
 	}
 
-	qb.Paginate(p)
+	// Completeness score range, both ends included
+	if minScore, ok := filters["min_score"].(int); ok {
+		qb.Where("cs.completeness_score >= ?", minScore)
+	}
+	if maxScore, ok := filters["max_score"].(int); ok {
+		qb.Where("cs.completeness_score <= ?", maxScore)
+	}
+
+	// Average resource usage at most, of measured namespaces only
+	if maxCPU, ok := filters["max_cpu_millicores"].(int64); ok {
+		qb.Where("n.cpu_usage_millicores <= ?", maxCPU)
+	}
+	if maxMemory, ok := filters["max_memory_bytes"].(int64); ok {
+		qb.Where("n.memory_usage_bytes <= ?", maxMemory)
+	}
+}
+
+// ListIDs returns the IDs of up to limit of the organization's namespaces
+// matching the namespace list filters, by name, and how many match in all
+func (r *NamespaceRepository) ListIDs(ctx context.Context, orgID uuid.UUID, filters map[string]interface{}, limit int) ([]uuid.UUID, int64, error) {
+	qb := NewQueryBuilder(`SELECT n.id FROM namespaces n` + namespaceCompletenessJoin).SortAlias("n")
+	qb.Where("n.organization_id = ?", orgID)
+	qb.Where("n.deleted_at IS NULL")
+	whereNamespaceFilters(qb, filters)
 
-	// Get total count
 	countQuery, countArgs := qb.BuildCount()
 	var total int64
-	if err := r.pool.QueryRow(ctx, countQuery, countArgs...).Scan(&total); err != nil {
-		return nil, fmt.Errorf("failed to count namespaces: %w", err)
+	if err := r.reader().QueryRow(ctx, countQuery, countArgs...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count namespaces: %w", err)
 	}
 
-	// Get data
-	query, args := qb.Build()
-	rows, err := r.pool.Query(ctx, query, args...)
+	query, args := qb.OrderBy("name", "asc").Limit(limit).Build()
+	rows, err := r.reader().Query(ctx, query, args...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to query namespaces: %w", err)
+		return nil, 0, fmt.Errorf("failed to query namespaces: %w", err)
 	}
 	defer rows.Close()
 
-	namespaces := make([]models.Namespace, 0)
+	ids := make([]uuid.UUID, 0)
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, 0, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, total, rows.Err()
+}
+
+// namespaceCompletenessJoin scores how much of the inventory metadata of
+// the namespace n is filled in, from 0 to 100, as cs.completeness_score. The
+// checks weigh the same: an owner team, a business unit, a description, an
+// application manager, a technical lead, a document and a dependency.
+const namespaceCompletenessJoin = `
+		CROSS JOIN LATERAL (
+			SELECT ROUND(100.0 * (
+				(n.infrastructure_owner_team_id IS NOT NULL)::int +
+				(n.business_unit_id IS NOT NULL)::int +
+				(COALESCE(n.description, '') <> '')::int +
+				(COALESCE(n.application_manager_email, '') <> '')::int +
+				(COALESCE(n.technical_lead_email, '') <> '')::int +
+				(EXISTS (
+					SELECT 1 FROM documents d
+					WHERE d.namespace_id = n.id AND d.deleted_at IS NULL
+				))::int +
+				(EXISTS (
+					SELECT 1 FROM internal_dependencies dep
+					WHERE dep.source_namespace_id = n.id AND dep.deleted_at IS NULL
+				))::int
+			) / 7)::int AS completeness_score
+		) cs`
+
+// namespaceSearchCondition matches a search pattern against the columns
+// covered by the trigram GIN indexes, so Postgres can use a bitmap OR of them
+const namespaceSearchCondition = "(n.name ILIKE ? OR n.display_name ILIKE ? OR n.description ILIKE ?)"
+
+// namespaceFuzzyCondition matches a search term against names it does not
+// contain, such as "paymets" against "payments-api", when the trigram word
+// similarity of the term reaches a threshold
+const namespaceFuzzyCondition = "(word_similarity(?, n.name) >= ? OR word_similarity(?, COALESCE(n.display_name, '')) >= ?)"
+
+// whereLabelSelector adds the requirements of a Kubernetes label selector
+// as conditions on the JSONB labels column, such as n.k8s_labels. As with
+// kubectl, != and notin also match rows without the label. Equality uses
+// containment so a GIN index on the column can serve it.
+func whereLabelSelector(qb *QueryBuilder, column string, selector labels.Selector) {
+	requirements, _ := selector.Requirements()
+	for _, req := range requirements {
+		key, values := req.Key(), req.Values().List()
+		switch req.Operator() {
+		case selection.Equals, selection.DoubleEquals:
+			qb.Where(column+" @> ?", map[string]string{key: values[0]})
+		case selection.NotEquals:
+			qb.Where(column+"->>? IS DISTINCT FROM ?", key, values[0])
+		case selection.In:
+			qb.Where(column+"->>? = ANY(?)", key, values)
+		case selection.NotIn:
+			qb.Where("NOT COALESCE("+column+"->>? = ANY(?), false)", key, values)
+		case selection.Exists:
+			qb.Where(column+"->>? IS NOT NULL", key)
+		case selection.DoesNotExist:
+			qb.Where(column+"->>? IS NULL", key)
+		case selection.GreaterThan, selection.LessThan:
+			// Labels that are not integers never match, as in Kubernetes. The
+			// pattern avoids ?, which Where would take for a placeholder.
+			op := ">"
+			if req.Operator() == selection.LessThan {
+				op = "<"
+			}
+			qb.Where("CASE WHEN "+column+"->>? ~ '^-{0,1}[0-9]{1,18}$' THEN ("+column+"->>?)::bigint "+op+" ? ELSE false END",
+				key, key, labelSelectorInt(values[0]))
+		}
+	}
+}
+
+// labelSelectorInt parses the operand of a > or < requirement, which the
+// selector parser has already checked is an integer
+func labelSelectorInt(value string) int64 {
+	n, _ := strconv.ParseInt(value, 10, 64)
+	return n
+}
+
+// searchPattern escapes LIKE wildcards in term and wraps it for a substring match
+func searchPattern(term string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+	return "%" + replacer.Replace(term) + "%"
+}
+
+// Search returns namespaces whose name, display name, description or tags
+// contain term, best matches first. A fuzzyThreshold above 0 also returns
+// namespaces whose name or display name is that similar to term, so typos
+// still find them.
+//
+// Scores rank name matches (3 to 4, by trigram similarity) above fuzzy name
+// matches (2 to 3), description matches (1) and tag matches (0.5).
+func (r *NamespaceRepository) Search(ctx context.Context, orgID uuid.UUID, term string, fuzzyThreshold float64, limit int) ([]models.NamespaceSearchResult, error) {
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	pattern := searchPattern(term)
+
+	query := `
+		SELECT * FROM (
+			SELECT 
+				n.id, n.organization_id, n.cluster_id,
+				n.name, n.display_name, n.description,
+				n.environment, n.criticality,
+				n.infrastructure_owner_team_id, n.business_unit_id,
+				n.status, n.tags,
+				n.created_at, n.updated_at,
+				CASE
+					WHEN n.name ILIKE $2 OR n.display_name ILIKE $2
+						THEN 3 + GREATEST(similarity(n.name, $3), similarity(COALESCE(n.display_name, ''), $3))
+					WHEN $5 > 0 AND (word_similarity($3, n.name) >= $5 OR word_similarity($3, COALESCE(n.display_name, '')) >= $5)
+						THEN 2 + GREATEST(word_similarity($3, n.name), word_similarity($3, COALESCE(n.display_name, '')))
+					WHEN n.description ILIKE $2 THEN 1
+					WHEN EXISTS (SELECT 1 FROM unnest(n.tags) AS tag WHERE tag ILIKE $2) THEN 0.5
+					ELSE 0
+				END AS score
+			FROM namespaces n
+			WHERE n.organization_id = $1 AND n.deleted_at IS NULL
+		) ranked
+		WHERE score > 0
+		ORDER BY score DESC, name
+		LIMIT $4
+	`
+
+	rows, err := r.reader().Query(ctx, query, orgID, pattern, term, limit, fuzzyThreshold)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search namespaces: %w", err)
+	}
+	defer rows.Close()
+
+	results := make([]models.NamespaceSearchResult, 0)
+	for rows.Next() {
+		var res models.NamespaceSearchResult
+		ns := &res.Namespace
+		if err := rows.Scan(
+			&ns.ID, &ns.OrganizationID, &ns.ClusterID,
+			&ns.Name, &ns.DisplayName, &ns.Description,
+			&ns.Environment, &ns.Criticality,
+			&ns.InfrastructureOwnerTeamID, &ns.BusinessUnitID,
+			&ns.Status, &ns.Tags,
+			&ns.CreatedAt, &ns.UpdatedAt,
+			&res.Score,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan namespace: %w", err)
+		}
+		results = append(results, res)
+	}
+	return results, rows.Err()
+}
+
+// ListByIDs returns the organization's current namespaces with the given IDs,
+// in no particular order. IDs of other organizations' or deleted namespaces
+// are skipped.
+func (r *NamespaceRepository) ListByIDs(ctx context.Context, orgID uuid.UUID, ids []uuid.UUID) ([]models.Namespace, error) {
+	query := `
+		SELECT
+			n.id, n.organization_id, n.cluster_id,
+			n.name, n.display_name, n.description,
+			n.environment, n.criticality,
+			n.infrastructure_owner_team_id, n.business_unit_id,
+			n.status, n.tags,
+			n.created_at, n.updated_at
+		FROM namespaces n
+		WHERE n.organization_id = $1 AND n.id = ANY($2) AND n.deleted_at IS NULL
+	`
+
+	rows, err := r.reader().Query(ctx, query, orgID, ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list namespaces: %w", err)
+	}
+	defer rows.Close()
+
+	namespaces := make([]models.Namespace, 0, len(ids))
 	for rows.Next() {
 		var ns models.Namespace
-		err := rows.Scan(
+		if err := rows.Scan(
 			&ns.ID, &ns.OrganizationID, &ns.ClusterID,
 			&ns.Name, &ns.DisplayName, &ns.Description,
 			&ns.Environment, &ns.Criticality,
-			&ns.InfrastructureOwnerTeamID, &ns.InfrastructureOwnerUserID,
-			&ns.BusinessUnitID,
-			&ns.ApplicationManagerName, &ns.ApplicationManagerEmail, &ns.ApplicationManagerPhone,
-			&ns.TechnicalLeadName, &ns.TechnicalLeadEmail,
-			&ns.ProjectManagerName, &ns.ProjectManagerEmail,
-			&ns.SLAAvailability, &ns.SLARTO, &ns.SLARPO, &ns.SupportHours, &ns.EscalationPath,
-			&ns.Status, &ns.DiscoveredAt, &ns.LastSyncAt,
-			&ns.K8sUID, &ns.K8sLabels, &ns.K8sAnnotations, &ns.K8sCreatedAt,
-			&ns.Tags, &ns.CustomFields, &ns.Metadata,
+			&ns.InfrastructureOwnerTeamID, &ns.BusinessUnitID,
+			&ns.Status, &ns.Tags,
 			&ns.CreatedAt, &ns.UpdatedAt,
-		)
-		if err != nil {
+		); err != nil {
 			return nil, fmt.Errorf("failed to scan namespace: %w", err)
 		}
 		namespaces = append(namespaces, ns)
 	}
+	return namespaces, rows.Err()
+}
 
-	totalPages := int(total) / p.PageSize
-	if int(total)%p.PageSize > 0 {
-		totalPages++
-	}
+// namespaceRelations holds the joined columns scanned by List when relations are included
+type namespaceRelations struct {
+	clusterName        *string
+	clusterDisplayName models.NullString
+	clusterEnvironment *string
+	clusterType        *string
+	teamName           *string
+	teamSlug           *string
+	businessUnitName   *string
+	businessUnitCode   models.NullString
 
-	return &PaginatedResult[models.Namespace]{
-		Items:      namespaces,
-		Total:      total,
-		Page:       p.Page,
-		PageSize:   p.PageSize,
-		TotalPages: totalPages,
-	}, nil
+	ticketKey            *string
+	ticketURL            *string
+	ticketReason         *string
+	ticketStatus         models.NullString
+	ticketStatusCategory models.NullString
+	ticketCheckedAt      models.NullTime
+	ticketCreatedAt      *time.Time
+}
+
+// apply populates the computed relation fields on a namespace
+func (rel namespaceRelations) apply(ns *models.Namespace) {
+	if rel.clusterName != nil {
+		ns.Cluster = &models.Cluster{
+			BaseModel:      models.BaseModel{ID: ns.ClusterID},
+			OrganizationID: ns.OrganizationID,
+			Name:           *rel.clusterName,
+			DisplayName:    rel.clusterDisplayName,
+		}
+		if rel.clusterEnvironment != nil {
+			ns.Cluster.Environment = *rel.clusterEnvironment
+		}
+		if rel.clusterType != nil {
+			ns.Cluster.ClusterType = *rel.clusterType
+		}
+	}
+	if rel.teamName != nil && ns.InfrastructureOwnerTeamID != nil {
+		ns.InfrastructureOwnerTeam = &models.Team{
+			BaseModel:      models.BaseModel{ID: *ns.InfrastructureOwnerTeamID},
+			OrganizationID: ns.OrganizationID,
+			Name:           *rel.teamName,
+		}
+		if rel.teamSlug != nil {
+			ns.InfrastructureOwnerTeam.Slug = *rel.teamSlug
+		}
+	}
+	if rel.businessUnitName != nil && ns.BusinessUnitID != nil {
+		ns.BusinessUnit = &models.BusinessUnit{
+			BaseModel:      models.BaseModel{ID: *ns.BusinessUnitID},
+			OrganizationID: ns.OrganizationID,
+			Name:           *rel.businessUnitName,
+			Code:           rel.businessUnitCode,
+		}
+	}
+	if rel.ticketKey != nil {
+		ns.Ticket = &models.NamespaceTicket{
+			NamespaceID:     ns.ID,
+			OrganizationID:  ns.OrganizationID,
+			IssueKey:        *rel.ticketKey,
+			Status:          rel.ticketStatus,
+			StatusCategory:  rel.ticketStatusCategory,
+			StatusCheckedAt: rel.ticketCheckedAt,
+		}
+		if rel.ticketURL != nil {
+			ns.Ticket.IssueURL = *rel.ticketURL
+		}
+		if rel.ticketReason != nil {
+			ns.Ticket.Reason = *rel.ticketReason
+		}
+		if rel.ticketCreatedAt != nil {
+			ns.Ticket.CreatedAt = *rel.ticketCreatedAt
+		}
+	}
 }
 
 // Update updates a namespace
@@ -453,88 +886,311 @@ func (r *NamespaceRepository) UpdateFromK8s(ctx context.Context, id uuid.UUID, k
 	return err
 }
 
-// Delete soft deletes a namespace
-func (r *NamespaceRepository) Delete(ctx context.Context, id uuid.UUID) error {
-	return r.SoftDelete(ctx, "namespaces", id)
+// SetStatus sets the lifecycle status of a namespace, reporting false when
+// it is not found or already has the status
+func (r *NamespaceRepository) SetStatus(ctx context.Context, id uuid.UUID, status string) (bool, error) {
+	result, err := r.pool.Exec(ctx,
+		`UPDATE namespaces SET status = $2, updated_at = NOW() WHERE id = $1 AND deleted_at IS NULL AND status <> $2`,
+		id, status,
+	)
+	if err != nil {
+		return false, err
+	}
+	return result.RowsAffected() > 0, nil
 }
 
-// GetStats returns namespace statistics
-func (r *NamespaceRepository) GetStats(ctx context.Context, orgID uuid.UUID) (*models.DashboardStats, error) {
-	query := `
-		SELECT 
-			COUNT(*) as total,
-			COUNT(*) FILTER (WHERE infrastructure_owner_team_id IS NOT NULL) as with_owner,
-			COUNT(*) FILTER (WHERE infrastructure_owner_team_id IS NULL) as orphaned,
-			COUNT(*) FILTER (WHERE business_unit_id IS NULL) as no_business_unit,
-			(
-				SELECT COUNT(DISTINCT namespace_id) 
-				FROM documents 
-				WHERE organization_id = $1 AND deleted_at IS NULL
-			) as documented,
-			(
-				SELECT COUNT(DISTINCT source_namespace_id) 
-				FROM internal_dependencies 
-				WHERE organization_id = $1 AND deleted_at IS NULL
-			) as with_deps
-		FROM namespaces
-		WHERE organization_id = $1 AND deleted_at IS NULL
-	`
-
-	stats := &models.DashboardStats{}
-	var documented, withDeps int
-
-	err := r.pool.QueryRow(ctx, query, orgID).Scan(
-		&stats.TotalNamespaces,
-		&stats.NamespacesWithOwner,
-		&stats.OrphanedNamespaces,
-		&stats.NoBusinessUnit,
-		&documented,
-		&withDeps,
+// SetLifecycle moves a namespace to a lifecycle stage and sets its
+// decommission date, reporting false when it is not found
+func (r *NamespaceRepository) SetLifecycle(ctx context.Context, id uuid.UUID, lifecycle string, decommissionDate *time.Time) (bool, error) {
+	result, err := r.pool.Exec(ctx, `
+		UPDATE namespaces SET
+			lifecycle = $2,
+			lifecycle_changed_at = CASE WHEN lifecycle = $2 THEN lifecycle_changed_at ELSE NOW() END,
+			decommission_date = $3,
+			updated_at = NOW()
+		WHERE id = $1 AND deleted_at IS NULL`,
+		id, lifecycle, decommissionDate,
 	)
 	if err != nil {
-		return nil, err
+		return false, err
 	}
-
-	stats.NamespacesDocumented = documented
-	stats.NamespacesWithDeps = withDeps
-	stats.UndocumentedNamespaces = stats.TotalNamespaces - documented
-	stats.NoDepsNamespaces = stats.TotalNamespaces - withDeps
-
-	return stats, nil
+	return result.RowsAffected() > 0, nil
 }
 
-// GetEnvironmentDistribution returns namespace count by environment
-func (r *NamespaceRepository) GetEnvironmentDistribution(ctx context.Context, orgID uuid.UUID) ([]models.EnvironmentDistribution, error) {
+// ArchiveMissing archives the namespaces of a cluster that are not in
+// present, the names found in the cluster, and returns them
+func (r *NamespaceRepository) ArchiveMissing(ctx context.Context, clusterID uuid.UUID, present []string) ([]models.Namespace, error) {
 	query := `
-		SELECT 
-			COALESCE(environment, 'unknown') as environment,
-			COUNT(*) as count
-		FROM namespaces
-		WHERE organization_id = $1 AND deleted_at IS NULL
-		GROUP BY environment
-		ORDER BY count DESC
+		UPDATE namespaces SET status = $3, updated_at = NOW()
+		WHERE cluster_id = $1 AND deleted_at IS NULL AND status <> $3 AND NOT (name = ANY($2))
+		RETURNING id, organization_id, cluster_id, name
 	`
 
-	rows, err := r.pool.Query(ctx, query, orgID)
+	rows, err := r.pool.Query(ctx, query, clusterID, present, models.NamespaceStatusArchived)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var result []models.EnvironmentDistribution
+	namespaces := make([]models.Namespace, 0)
 	for rows.Next() {
-		var d models.EnvironmentDistribution
-		if err := rows.Scan(&d.Environment, &d.Count); err != nil {
+		var ns models.Namespace
+		if err := rows.Scan(&ns.ID, &ns.OrganizationID, &ns.ClusterID, &ns.Name); err != nil {
 			return nil, err
 		}
-		result = append(result, d)
+		ns.Status = models.NamespaceStatusArchived
+		namespaces = append(namespaces, ns)
 	}
-
-	return result, nil
+	return namespaces, rows.Err()
+}
+
+// Delete soft deletes a namespace
+func (r *NamespaceRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	return r.SoftDelete(ctx, "namespaces", id)
+}
+
+// DeleteByCluster soft deletes all namespaces belonging to a cluster
+func (r *NamespaceRepository) DeleteByCluster(ctx context.Context, clusterID uuid.UUID) (int64, error) {
+	query := `UPDATE namespaces SET deleted_at = NOW() WHERE cluster_id = $1 AND deleted_at IS NULL`
+	result, err := r.pool.Exec(ctx, query, clusterID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+// ReleaseOwnerTeam clears the owner team of every namespace owned by teamID
+// and returns the namespaces that were released
+func (r *NamespaceRepository) ReleaseOwnerTeam(ctx context.Context, teamID uuid.UUID) ([]models.Namespace, error) {
+	query := `
+		UPDATE namespaces SET infrastructure_owner_team_id = NULL, updated_at = NOW()
+		WHERE infrastructure_owner_team_id = $1 AND deleted_at IS NULL
+		RETURNING id, organization_id, cluster_id, name
+	`
+
+	rows, err := r.pool.Query(ctx, query, teamID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	namespaces := make([]models.Namespace, 0)
+	for rows.Next() {
+		var ns models.Namespace
+		if err := rows.Scan(&ns.ID, &ns.OrganizationID, &ns.ClusterID, &ns.Name); err != nil {
+			return nil, err
+		}
+		namespaces = append(namespaces, ns)
+	}
+	return namespaces, rows.Err()
+}
+
+// ListGaps returns the organization's namespaces that have no owner team,
+// no documents or no business unit, ordered by cluster and name. Archived
+// namespaces are left out.
+func (r *NamespaceRepository) ListGaps(ctx context.Context, orgID uuid.UUID) ([]models.NamespaceGap, error) {
+	query := `
+		SELECT * FROM (
+			SELECT
+				n.id, n.name, c.id, c.name, n.infrastructure_owner_team_id,
+				n.infrastructure_owner_team_id IS NULL AS missing_owner,
+				NOT EXISTS (
+					SELECT 1 FROM documents d
+					WHERE d.namespace_id = n.id AND d.deleted_at IS NULL
+				) AS missing_documents,
+				n.business_unit_id IS NULL AS missing_business_unit
+			FROM namespaces n
+			JOIN clusters c ON c.id = n.cluster_id AND c.deleted_at IS NULL
+			WHERE n.organization_id = $1 AND n.deleted_at IS NULL AND n.status <> 'archived'
+		) gaps
+		WHERE missing_owner OR missing_documents OR missing_business_unit
+		ORDER BY 4, 2
+	`
+
+	rows, err := r.reader().Query(ctx, query, orgID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	gaps := make([]models.NamespaceGap, 0)
+	for rows.Next() {
+		var g models.NamespaceGap
+		if err := rows.Scan(
+			&g.NamespaceID, &g.Namespace, &g.ClusterID, &g.Cluster, &g.OwnerTeamID,
+			&g.MissingOwner, &g.MissingDocuments, &g.MissingBusinessUnit,
+		); err != nil {
+			return nil, err
+		}
+		gaps = append(gaps, g)
+	}
+	return gaps, rows.Err()
+}
+
+// ListIDsByName returns the IDs of the namespaces of an organization with a
+// name, one per cluster it exists in
+func (r *NamespaceRepository) ListIDsByName(ctx context.Context, orgID uuid.UUID, name string) ([]uuid.UUID, error) {
+	rows, err := r.reader().Query(ctx,
+		`SELECT id FROM namespaces WHERE organization_id = $1 AND name = $2 AND deleted_at IS NULL ORDER BY created_at`,
+		orgID, name,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// CountDocuments returns the number of documents of a namespace
+func (r *NamespaceRepository) CountDocuments(ctx context.Context, id uuid.UUID) (int, error) {
+	var count int
+	err := r.reader().QueryRow(ctx,
+		`SELECT COUNT(*) FROM documents WHERE namespace_id = $1 AND deleted_at IS NULL`,
+		id,
+	).Scan(&count)
+	return count, err
+}
+
+// ListMetadata returns the unarchived namespaces of an organization with
+// the metadata checked against its requirements, their cluster's name and
+// their number of documents, ordered by cluster and name
+func (r *NamespaceRepository) ListMetadata(ctx context.Context, orgID uuid.UUID) ([]models.Namespace, error) {
+	rows, err := r.reader().Query(ctx, `
+		SELECT
+			n.id, n.organization_id, n.cluster_id, c.name, n.name, n.description,
+			n.environment, n.criticality, n.infrastructure_owner_team_id, n.business_unit_id,
+			n.application_manager_email, n.technical_lead_email, n.project_manager_email,
+			n.sla_availability, n.escalation_path,
+			(SELECT COUNT(*) FROM documents d WHERE d.namespace_id = n.id AND d.deleted_at IS NULL)
+		FROM namespaces n
+		JOIN clusters c ON c.id = n.cluster_id AND c.deleted_at IS NULL
+		WHERE n.organization_id = $1 AND n.deleted_at IS NULL AND n.status <> $2
+		ORDER BY c.name, n.name`,
+		orgID, models.NamespaceStatusArchived,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make([]models.Namespace, 0)
+	for rows.Next() {
+		ns := models.Namespace{Cluster: &models.Cluster{}}
+		if err := rows.Scan(
+			&ns.ID, &ns.OrganizationID, &ns.ClusterID, &ns.Cluster.Name, &ns.Name, &ns.Description,
+			&ns.Environment, &ns.Criticality, &ns.InfrastructureOwnerTeamID, &ns.BusinessUnitID,
+			&ns.ApplicationManagerEmail, &ns.TechnicalLeadEmail, &ns.ProjectManagerEmail,
+			&ns.SLAAvailability, &ns.EscalationPath,
+			&ns.DocumentCount,
+		); err != nil {
+			return nil, err
+		}
+		ns.Cluster.ID = ns.ClusterID
+		result = append(result, ns)
+	}
+	return result, rows.Err()
+}
+
+// archivedCondition limits a namespaces query to namespaces that are not
+// archived, unless includeArchived is set
+func archivedCondition(includeArchived bool) string {
+	if includeArchived {
+		return ""
+	}
+	return " AND status <> '" + models.NamespaceStatusArchived + "'"
+}
+
+// GetStats returns namespace statistics. Archived namespaces are counted
+// only with includeArchived.
+func (r *NamespaceRepository) GetStats(ctx context.Context, orgID uuid.UUID, includeArchived bool) (*models.DashboardStats, error) {
+	query := `
+		WITH ns AS (
+			SELECT id, infrastructure_owner_team_id, business_unit_id
+			FROM namespaces
+			WHERE organization_id = $1 AND deleted_at IS NULL` + archivedCondition(includeArchived) + `
+		)
+		SELECT 
+			COUNT(*) as total,
+			COUNT(*) FILTER (WHERE infrastructure_owner_team_id IS NOT NULL) as with_owner,
+			COUNT(*) FILTER (WHERE infrastructure_owner_team_id IS NULL) as orphaned,
+			COUNT(*) FILTER (WHERE business_unit_id IS NULL) as no_business_unit,
+			(
+				SELECT COUNT(DISTINCT namespace_id) 
+				FROM documents 
+				WHERE organization_id = $1 AND deleted_at IS NULL AND namespace_id IN (SELECT id FROM ns)
+			) as documented,
+			(
+				SELECT COUNT(DISTINCT source_namespace_id) 
+				FROM internal_dependencies 
+				WHERE organization_id = $1 AND deleted_at IS NULL AND source_namespace_id IN (SELECT id FROM ns)
+			) as with_deps
+		FROM ns
+	`
+
+	stats := &models.DashboardStats{}
+	var documented, withDeps int
+
+	err := r.reader().QueryRow(ctx, query, orgID).Scan(
+		&stats.TotalNamespaces,
+		&stats.NamespacesWithOwner,
+		&stats.OrphanedNamespaces,
+		&stats.NoBusinessUnit,
+		&documented,
+		&withDeps,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	stats.NamespacesDocumented = documented
+	stats.NamespacesWithDeps = withDeps
+	stats.UndocumentedNamespaces = stats.TotalNamespaces - documented
+	stats.NoDepsNamespaces = stats.TotalNamespaces - withDeps
+
+	return stats, nil
+}
+
+// GetEnvironmentDistribution returns namespace count by environment
+func (r *NamespaceRepository) GetEnvironmentDistribution(ctx context.Context, orgID uuid.UUID, includeArchived bool) ([]models.EnvironmentDistribution, error) {
+	query := `
+		SELECT 
+			COALESCE(environment, 'unknown') as environment,
+			COUNT(*) as count
+		FROM namespaces
+		WHERE organization_id = $1 AND deleted_at IS NULL` + archivedCondition(includeArchived) + `
+		GROUP BY environment
+		ORDER BY count DESC
+	`
+
+	rows, err := r.reader().Query(ctx, query, orgID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []models.EnvironmentDistribution
+	for rows.Next() {
+		var d models.EnvironmentDistribution
+		if err := rows.Scan(&d.Environment, &d.Count); err != nil {
+			return nil, err
+		}
+		result = append(result, d)
+	}
+
+	return result, nil
 }
 
 // GetBusinessUnitDistribution returns namespace count by business unit
-func (r *NamespaceRepository) GetBusinessUnitDistribution(ctx context.Context, orgID uuid.UUID) ([]models.BusinessUnitDistribution, error) {
+func (r *NamespaceRepository) GetBusinessUnitDistribution(ctx context.Context, orgID uuid.UUID, includeArchived bool) ([]models.BusinessUnitDistribution, error) {
 	query := `
 		SELECT 
 			n.business_unit_id,
@@ -542,12 +1198,12 @@ func (r *NamespaceRepository) GetBusinessUnitDistribution(ctx context.Context, o
 			COUNT(*) as count
 		FROM namespaces n
 		LEFT JOIN business_units bu ON n.business_unit_id = bu.id
-		WHERE n.organization_id = $1 AND n.deleted_at IS NULL
+		WHERE n.organization_id = $1 AND n.deleted_at IS NULL` + archivedCondition(includeArchived) + `
 		GROUP BY n.business_unit_id, bu.name
 		ORDER BY count DESC
 	`
 
-	rows, err := r.pool.Query(ctx, query, orgID)
+	rows, err := r.reader().Query(ctx, query, orgID)
 	if err != nil {
 		return nil, err
 	}
@@ -565,6 +1221,44 @@ func (r *NamespaceRepository) GetBusinessUnitDistribution(ctx context.Context, o
 	return result, nil
 }
 
+// GetTeamCompleteness returns the average completeness score of the
+// namespaces of each owner team, lowest first. Archived namespaces are
+// counted only with includeArchived.
+func (r *NamespaceRepository) GetTeamCompleteness(ctx context.Context, orgID uuid.UUID, includeArchived bool) ([]models.TeamCompleteness, error) {
+	query := `
+		WITH ns AS (
+			SELECT *
+			FROM namespaces
+			WHERE organization_id = $1 AND deleted_at IS NULL` + archivedCondition(includeArchived) + `
+		)
+		SELECT
+			n.infrastructure_owner_team_id,
+			COALESCE(t.name, 'Tanımsız') as team_name,
+			COUNT(*) as namespace_count,
+			ROUND(AVG(cs.completeness_score), 1)::float8 as average_score
+		FROM ns n` + namespaceCompletenessJoin + `
+		LEFT JOIN teams t ON t.id = n.infrastructure_owner_team_id
+		GROUP BY n.infrastructure_owner_team_id, t.name
+		ORDER BY average_score, team_name
+	`
+
+	rows, err := r.reader().Query(ctx, query, orgID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make([]models.TeamCompleteness, 0)
+	for rows.Next() {
+		var tc models.TeamCompleteness
+		if err := rows.Scan(&tc.TeamID, &tc.TeamName, &tc.NamespaceCount, &tc.AverageScore); err != nil {
+			return nil, err
+		}
+		result = append(result, tc)
+	}
+	return result, rows.Err()
+}
+
 // GetRecentlyUpdated returns recently updated namespaces
 func (r *NamespaceRepository) GetRecentlyUpdated(ctx context.Context, orgID uuid.UUID, limit int) ([]models.Namespace, error) {
 	query := `
@@ -579,6 +1273,7 @@ func (r *NamespaceRepository) GetRecentlyUpdated(ctx context.Context, orgID uuid
 			project_manager_name, project_manager_email,
 			sla_availability, sla_rto, sla_rpo, support_hours, escalation_path,
 			status, discovered_at, last_sync_at,
+			lifecycle, lifecycle_changed_at, to_char(decommission_date, 'YYYY-MM-DD'),
 			k8s_uid, k8s_labels, k8s_annotations, k8s_created_at,
 			tags, custom_fields, metadata,
 			created_at, updated_at
@@ -588,7 +1283,7 @@ func (r *NamespaceRepository) GetRecentlyUpdated(ctx context.Context, orgID uuid
 		LIMIT $2
 	`
 
-	rows, err := r.pool.Query(ctx, query, orgID, limit)
+	rows, err := r.reader().Query(ctx, query, orgID, limit)
 	if err != nil {
 		return nil, err
 	}
@@ -608,6 +1303,7 @@ func (r *NamespaceRepository) GetRecentlyUpdated(ctx context.Context, orgID uuid
 			&ns.ProjectManagerName, &ns.ProjectManagerEmail,
 			&ns.SLAAvailability, &ns.SLARTO, &ns.SLARPO, &ns.SupportHours, &ns.EscalationPath,
 			&ns.Status, &ns.DiscoveredAt, &ns.LastSyncAt,
+			&ns.Lifecycle, &ns.LifecycleChangedAt, &ns.DecommissionDate,
 			&ns.K8sUID, &ns.K8sLabels, &ns.K8sAnnotations, &ns.K8sCreatedAt,
 			&ns.Tags, &ns.CustomFields, &ns.Metadata,
 			&ns.CreatedAt, &ns.UpdatedAt,
@@ -632,7 +1328,7 @@ func (r *NamespaceRepository) GetCountsByBusinessUnit(ctx context.Context, orgID
 		GROUP BY business_unit_id
 	`
 	
-	rows, err := r.pool.Query(ctx, query, orgID)
+	rows, err := r.reader().Query(ctx, query, orgID)
 	if err != nil {
 		return nil, err
 	}
@@ -650,3 +1346,714 @@ func (r *NamespaceRepository) GetCountsByBusinessUnit(ctx context.Context, orgID
 	
 	return result, nil
 }
+
+// ReplaceAccess replaces the RBAC inventory of every namespace of a cluster
+// with the role bindings and service accounts given
+func (r *NamespaceRepository) ReplaceAccess(ctx context.Context, clusterID uuid.UUID, bindings []models.NamespaceRoleBinding, accounts []models.NamespaceServiceAccount) error {
+	batch := &pgx.Batch{}
+	batch.Queue(`DELETE FROM namespace_role_bindings WHERE namespace_id IN (SELECT id FROM namespaces WHERE cluster_id = $1)`, clusterID)
+	batch.Queue(`DELETE FROM namespace_service_accounts WHERE namespace_id IN (SELECT id FROM namespaces WHERE cluster_id = $1)`, clusterID)
+	for _, b := range bindings {
+		batch.Queue(`
+			INSERT INTO namespace_role_bindings (
+				namespace_id, binding_kind, binding_name, role_kind, role_name,
+				subject_kind, subject_name, subject_namespace
+			) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+			b.NamespaceID, b.BindingKind, b.BindingName, b.RoleKind, b.RoleName,
+			b.SubjectKind, b.SubjectName, b.SubjectNamespace,
+		)
+	}
+	for _, a := range accounts {
+		batch.Queue(`
+			INSERT INTO namespace_service_accounts (namespace_id, name, k8s_uid, k8s_created_at)
+			VALUES ($1, $2, $3, $4)
+			ON CONFLICT (namespace_id, name) DO NOTHING`,
+			a.NamespaceID, a.Name, a.K8sUID, a.K8sCreatedAt,
+		)
+	}
+
+	return runInTx(ctx, r.pool, func(tx pgx.Tx) error {
+		results := tx.SendBatch(ctx, batch)
+		defer results.Close()
+
+		for i := 0; i < batch.Len(); i++ {
+			if _, err := results.Exec(); err != nil {
+				return fmt.Errorf("failed to replace namespace access: %w", err)
+			}
+		}
+		return results.Close()
+	})
+}
+
+// ListRoleBindings returns the role bindings of a namespace found by the
+// last sync, ordered by subject
+func (r *NamespaceRepository) ListRoleBindings(ctx context.Context, namespaceID uuid.UUID) ([]models.NamespaceRoleBinding, error) {
+	rows, err := r.reader().Query(ctx, `
+		SELECT id, namespace_id, binding_kind, binding_name, role_kind, role_name,
+			subject_kind, subject_name, subject_namespace, synced_at
+		FROM namespace_role_bindings
+		WHERE namespace_id = $1
+		ORDER BY subject_kind, subject_namespace NULLS FIRST, subject_name, binding_kind DESC, binding_name`,
+		namespaceID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	bindings := make([]models.NamespaceRoleBinding, 0)
+	for rows.Next() {
+		var b models.NamespaceRoleBinding
+		if err := rows.Scan(
+			&b.ID, &b.NamespaceID, &b.BindingKind, &b.BindingName, &b.RoleKind, &b.RoleName,
+			&b.SubjectKind, &b.SubjectName, &b.SubjectNamespace, &b.SyncedAt,
+		); err != nil {
+			return nil, err
+		}
+		bindings = append(bindings, b)
+	}
+	return bindings, rows.Err()
+}
+
+// ListServiceAccounts returns the service accounts of a namespace found by
+// the last sync
+func (r *NamespaceRepository) ListServiceAccounts(ctx context.Context, namespaceID uuid.UUID) ([]models.NamespaceServiceAccount, error) {
+	rows, err := r.reader().Query(ctx, `
+		SELECT id, namespace_id, name, k8s_uid, k8s_created_at, synced_at
+		FROM namespace_service_accounts
+		WHERE namespace_id = $1
+		ORDER BY name`,
+		namespaceID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	accounts := make([]models.NamespaceServiceAccount, 0)
+	for rows.Next() {
+		var a models.NamespaceServiceAccount
+		if err := rows.Scan(&a.ID, &a.NamespaceID, &a.Name, &a.K8sUID, &a.K8sCreatedAt, &a.SyncedAt); err != nil {
+			return nil, err
+		}
+		accounts = append(accounts, a)
+	}
+	return accounts, rows.Err()
+}
+
+// ReplaceFluxResources replaces the Flux Kustomizations and HelmReleases of
+// every namespace of a cluster with those given
+func (r *NamespaceRepository) ReplaceFluxResources(ctx context.Context, clusterID uuid.UUID, resources []models.FluxResource) error {
+	batch := &pgx.Batch{}
+	batch.Queue(`DELETE FROM namespace_flux_resources WHERE namespace_id IN (SELECT id FROM namespaces WHERE cluster_id = $1)`, clusterID)
+	for _, f := range resources {
+		batch.Queue(`
+			INSERT INTO namespace_flux_resources (
+				namespace_id, kind, name, source_kind, source_name, source_namespace,
+				path, revision, ready, ready_reason, ready_message, ready_changed_at, suspended
+			) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+			ON CONFLICT (namespace_id, kind, name) DO NOTHING`,
+			f.NamespaceID, f.Kind, f.Name, f.SourceKind, f.SourceName, f.SourceNamespace,
+			f.Path, f.Revision, f.Ready, f.ReadyReason, f.ReadyMessage, f.ReadyChangedAt, f.Suspended,
+		)
+	}
+
+	return runInTx(ctx, r.pool, func(tx pgx.Tx) error {
+		results := tx.SendBatch(ctx, batch)
+		defer results.Close()
+
+		for i := 0; i < batch.Len(); i++ {
+			if _, err := results.Exec(); err != nil {
+				return fmt.Errorf("failed to replace namespace flux resources: %w", err)
+			}
+		}
+		return results.Close()
+	})
+}
+
+// RecordUsage stores usage samples of the namespaces of a cluster, prunes
+// their samples older than keep, and recomputes the average and peak usage
+// of each namespace over the samples left. Namespaces left without samples
+// are unmeasured again.
+func (r *NamespaceRepository) RecordUsage(ctx context.Context, clusterID uuid.UUID, samples []models.NamespaceUsageSample, keep time.Duration) error {
+	batch := &pgx.Batch{}
+	for _, u := range samples {
+		batch.Queue(`
+			INSERT INTO namespace_usage_samples (namespace_id, cpu_millicores, memory_bytes, pods, observed_at)
+			VALUES ($1, $2, $3, $4, $5)`,
+			u.NamespaceID, u.CPUMillicores, u.MemoryBytes, u.Pods, u.ObservedAt,
+		)
+	}
+	batch.Queue(`
+		DELETE FROM namespace_usage_samples
+		WHERE namespace_id IN (SELECT id FROM namespaces WHERE cluster_id = $1)
+			AND observed_at < NOW() - $2::interval`,
+		clusterID, fmt.Sprintf("%d seconds", int(keep.Seconds())),
+	)
+	batch.Queue(`
+		UPDATE namespaces n SET
+			cpu_usage_millicores = u.cpu_avg,
+			cpu_usage_peak_millicores = u.cpu_peak,
+			memory_usage_bytes = u.memory_avg,
+			memory_usage_peak_bytes = u.memory_peak,
+			usage_observed_at = u.observed_at
+		FROM (
+			SELECT s.namespace_id,
+				ROUND(AVG(s.cpu_millicores))::bigint as cpu_avg, MAX(s.cpu_millicores) as cpu_peak,
+				ROUND(AVG(s.memory_bytes))::bigint as memory_avg, MAX(s.memory_bytes) as memory_peak,
+				MAX(s.observed_at) as observed_at
+			FROM namespace_usage_samples s
+			JOIN namespaces ns ON ns.id = s.namespace_id
+			WHERE ns.cluster_id = $1
+			GROUP BY s.namespace_id
+		) u
+		WHERE n.id = u.namespace_id`,
+		clusterID,
+	)
+	batch.Queue(`
+		UPDATE namespaces n SET
+			cpu_usage_millicores = NULL, cpu_usage_peak_millicores = NULL,
+			memory_usage_bytes = NULL, memory_usage_peak_bytes = NULL,
+			usage_observed_at = NULL
+		WHERE n.cluster_id = $1 AND n.usage_observed_at IS NOT NULL
+			AND NOT EXISTS (SELECT 1 FROM namespace_usage_samples s WHERE s.namespace_id = n.id)`,
+		clusterID,
+	)
+
+	return runInTx(ctx, r.pool, func(tx pgx.Tx) error {
+		results := tx.SendBatch(ctx, batch)
+		defer results.Close()
+
+		for i := 0; i < batch.Len(); i++ {
+			if _, err := results.Exec(); err != nil {
+				return fmt.Errorf("failed to record namespace usage: %w", err)
+			}
+		}
+		return results.Close()
+	})
+}
+
+// ListUsageSamples returns the usage samples of a namespace taken since a
+// time, oldest first
+func (r *NamespaceRepository) ListUsageSamples(ctx context.Context, namespaceID uuid.UUID, since time.Time) ([]models.NamespaceUsageSample, error) {
+	rows, err := r.reader().Query(ctx, `
+		SELECT namespace_id, cpu_millicores, memory_bytes, pods, observed_at
+		FROM namespace_usage_samples
+		WHERE namespace_id = $1 AND observed_at >= $2
+		ORDER BY observed_at`,
+		namespaceID, since,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	samples := make([]models.NamespaceUsageSample, 0)
+	for rows.Next() {
+		var u models.NamespaceUsageSample
+		if err := rows.Scan(&u.NamespaceID, &u.CPUMillicores, &u.MemoryBytes, &u.Pods, &u.ObservedAt); err != nil {
+			return nil, err
+		}
+		samples = append(samples, u)
+	}
+	return samples, rows.Err()
+}
+
+// usageGroupColumns are the columns GetUsageByGroup groups namespaces by
+var usageGroupColumns = map[string]string{
+	"team":        "COALESCE(t.name, '')",
+	"environment": "n.environment",
+	"criticality": "n.criticality",
+	"cluster":     "c.name",
+}
+
+// GetUsageByGroup sums the average and peak resource usage of the
+// organization's namespaces by owner team, environment, criticality or
+// cluster, most CPU first. Archived namespaces are left out.
+func (r *NamespaceRepository) GetUsageByGroup(ctx context.Context, orgID uuid.UUID, groupBy string) ([]models.NamespaceUsageGroup, error) {
+	column, ok := usageGroupColumns[groupBy]
+	if !ok {
+		return nil, fmt.Errorf("unknown usage grouping %q", groupBy)
+	}
+	query := `
+		SELECT
+			` + column + ` as grp,
+			COUNT(*) as namespace_count,
+			COUNT(n.usage_observed_at) as measured_count,
+			COALESCE(SUM(n.cpu_usage_millicores), 0)::bigint,
+			COALESCE(SUM(n.cpu_usage_peak_millicores), 0)::bigint,
+			COALESCE(SUM(n.memory_usage_bytes), 0)::bigint,
+			COALESCE(SUM(n.memory_usage_peak_bytes), 0)::bigint
+		FROM namespaces n
+		JOIN clusters c ON c.id = n.cluster_id
+		LEFT JOIN teams t ON t.id = n.infrastructure_owner_team_id
+		WHERE n.organization_id = $1 AND n.deleted_at IS NULL AND n.status <> '` + models.NamespaceStatusArchived + `'
+		GROUP BY grp
+		ORDER BY 4 DESC, grp
+	`
+
+	rows, err := r.reader().Query(ctx, query, orgID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	groups := make([]models.NamespaceUsageGroup, 0)
+	for rows.Next() {
+		var g models.NamespaceUsageGroup
+		if err := rows.Scan(&g.Group, &g.NamespaceCount, &g.MeasuredCount,
+			&g.CPUMillicores, &g.CPUPeakMillicores, &g.MemoryBytes, &g.MemoryPeakBytes); err != nil {
+			return nil, err
+		}
+		groups = append(groups, g)
+	}
+	return groups, rows.Err()
+}
+
+// ListFluxResources returns the Flux Kustomizations and HelmReleases of a
+// namespace found by the last sync, ordered by kind and name
+func (r *NamespaceRepository) ListFluxResources(ctx context.Context, namespaceID uuid.UUID) ([]models.FluxResource, error) {
+	rows, err := r.reader().Query(ctx, `
+		SELECT id, namespace_id, kind, name, source_kind, source_name, source_namespace,
+			path, revision, ready, ready_reason, ready_message, ready_changed_at, suspended, synced_at
+		FROM namespace_flux_resources
+		WHERE namespace_id = $1
+		ORDER BY kind DESC, name`,
+		namespaceID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	resources := make([]models.FluxResource, 0)
+	for rows.Next() {
+		var f models.FluxResource
+		if err := rows.Scan(
+			&f.ID, &f.NamespaceID, &f.Kind, &f.Name, &f.SourceKind, &f.SourceName, &f.SourceNamespace,
+			&f.Path, &f.Revision, &f.Ready, &f.ReadyReason, &f.ReadyMessage, &f.ReadyChangedAt, &f.Suspended, &f.SyncedAt,
+		); err != nil {
+			return nil, err
+		}
+		resources = append(resources, f)
+	}
+	return resources, rows.Err()
+}
+
+// ListPodSecurity returns the Pod Security Admission labels of every
+// namespace of an organization, ordered by cluster and name
+func (r *NamespaceRepository) ListPodSecurity(ctx context.Context, orgID uuid.UUID) ([]models.NamespacePodSecurity, error) {
+	rows, err := r.reader().Query(ctx, `
+		SELECT
+			n.id, n.name, c.id, c.name,
+			COALESCE(n.k8s_labels->>'pod-security.kubernetes.io/enforce', ''),
+			COALESCE(n.k8s_labels->>'pod-security.kubernetes.io/enforce-version', ''),
+			COALESCE(n.k8s_labels->>'pod-security.kubernetes.io/audit', ''),
+			COALESCE(n.k8s_labels->>'pod-security.kubernetes.io/audit-version', ''),
+			COALESCE(n.k8s_labels->>'pod-security.kubernetes.io/warn', ''),
+			COALESCE(n.k8s_labels->>'pod-security.kubernetes.io/warn-version', '')
+		FROM namespaces n
+		JOIN clusters c ON c.id = n.cluster_id AND c.deleted_at IS NULL
+		WHERE n.organization_id = $1 AND n.deleted_at IS NULL
+		ORDER BY c.name, n.name`,
+		orgID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make([]models.NamespacePodSecurity, 0)
+	for rows.Next() {
+		var ps models.NamespacePodSecurity
+		if err := rows.Scan(
+			&ps.NamespaceID, &ps.Namespace, &ps.ClusterID, &ps.Cluster,
+			&ps.Enforce, &ps.EnforceVersion, &ps.Audit, &ps.AuditVersion, &ps.Warn, &ps.WarnVersion,
+		); err != nil {
+			return nil, err
+		}
+		result = append(result, ps)
+	}
+	return result, rows.Err()
+}
+
+// GetTicket returns the remediation ticket of a namespace, or nil if none
+// was opened
+func (r *NamespaceRepository) GetTicket(ctx context.Context, namespaceID uuid.UUID) (*models.NamespaceTicket, error) {
+	var t models.NamespaceTicket
+	err := r.reader().QueryRow(ctx, `
+		SELECT namespace_id, organization_id, issue_key, issue_url, reason,
+			status, status_category, status_checked_at, created_by, created_at
+		FROM namespace_tickets
+		WHERE namespace_id = $1`,
+		namespaceID,
+	).Scan(
+		&t.NamespaceID, &t.OrganizationID, &t.IssueKey, &t.IssueURL, &t.Reason,
+		&t.Status, &t.StatusCategory, &t.StatusCheckedAt, &t.CreatedBy, &t.CreatedAt,
+	)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// SaveTicket records the remediation ticket of a namespace, replacing the
+// one opened before
+func (r *NamespaceRepository) SaveTicket(ctx context.Context, t *models.NamespaceTicket) error {
+	return r.pool.QueryRow(ctx, `
+		INSERT INTO namespace_tickets (
+			namespace_id, organization_id, issue_key, issue_url, reason,
+			status, status_category, status_checked_at, created_by
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		ON CONFLICT (namespace_id) DO UPDATE SET
+			issue_key = EXCLUDED.issue_key,
+			issue_url = EXCLUDED.issue_url,
+			reason = EXCLUDED.reason,
+			status = EXCLUDED.status,
+			status_category = EXCLUDED.status_category,
+			status_checked_at = EXCLUDED.status_checked_at,
+			created_by = EXCLUDED.created_by,
+			created_at = NOW()
+		RETURNING created_at`,
+		t.NamespaceID, t.OrganizationID, t.IssueKey, t.IssueURL, t.Reason,
+		t.Status, t.StatusCategory, t.StatusCheckedAt, t.CreatedBy,
+	).Scan(&t.CreatedAt)
+}
+
+// UpdateTicketStatus records the status Jira reported for a namespace's
+// remediation ticket
+func (r *NamespaceRepository) UpdateTicketStatus(ctx context.Context, namespaceID uuid.UUID, status, category string, checkedAt time.Time) error {
+	_, err := r.pool.Exec(ctx, `
+		UPDATE namespace_tickets
+		SET status = $2, status_category = $3, status_checked_at = $4
+		WHERE namespace_id = $1`,
+		namespaceID, models.NewNullStringFromString(status), models.NewNullStringFromString(category), checkedAt,
+	)
+	return err
+}
+
+// GetConfluencePage returns the Confluence page a namespace is exported to,
+// or nil if it was never exported
+func (r *NamespaceRepository) GetConfluencePage(ctx context.Context, namespaceID uuid.UUID) (*models.NamespaceConfluencePage, error) {
+	var p models.NamespaceConfluencePage
+	err := r.reader().QueryRow(ctx, `
+		SELECT namespace_id, organization_id, page_id, page_url, content_hash, exported_by, exported_at
+		FROM namespace_confluence_pages
+		WHERE namespace_id = $1`,
+		namespaceID,
+	).Scan(&p.NamespaceID, &p.OrganizationID, &p.PageID, &p.PageURL, &p.ContentHash, &p.ExportedBy, &p.ExportedAt)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// ListConfluencePages returns the Confluence pages of every organization's
+// exported namespaces that still exist
+func (r *NamespaceRepository) ListConfluencePages(ctx context.Context) ([]models.NamespaceConfluencePage, error) {
+	rows, err := r.reader().Query(ctx, `
+		SELECT p.namespace_id, p.organization_id, p.page_id, p.page_url, p.content_hash, p.exported_by, p.exported_at
+		FROM namespace_confluence_pages p
+		JOIN namespaces n ON n.id = p.namespace_id AND n.deleted_at IS NULL
+		ORDER BY p.organization_id, p.exported_at`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var pages []models.NamespaceConfluencePage
+	for rows.Next() {
+		var p models.NamespaceConfluencePage
+		if err := rows.Scan(&p.NamespaceID, &p.OrganizationID, &p.PageID, &p.PageURL, &p.ContentHash, &p.ExportedBy, &p.ExportedAt); err != nil {
+			return nil, err
+		}
+		pages = append(pages, p)
+	}
+	return pages, rows.Err()
+}
+
+// SaveConfluencePage records the Confluence page a namespace was exported
+// to, replacing the one recorded before
+func (r *NamespaceRepository) SaveConfluencePage(ctx context.Context, p *models.NamespaceConfluencePage) error {
+	return r.pool.QueryRow(ctx, `
+		INSERT INTO namespace_confluence_pages (
+			namespace_id, organization_id, page_id, page_url, content_hash, exported_by
+		) VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (namespace_id) DO UPDATE SET
+			page_id = EXCLUDED.page_id,
+			page_url = EXCLUDED.page_url,
+			content_hash = EXCLUDED.content_hash,
+			exported_by = EXCLUDED.exported_by,
+			exported_at = NOW()
+		RETURNING exported_at`,
+		p.NamespaceID, p.OrganizationID, p.PageID, p.PageURL, p.ContentHash, p.ExportedBy,
+	).Scan(&p.ExportedAt)
+}
+
+const sourceRepositoryColumns = `id, namespace_id, organization_id, url, provider,
+			default_branch, last_commit_at, checked_at, check_error, created_at`
+
+func scanSourceRepositories(rows pgx.Rows) ([]models.SourceRepository, error) {
+	defer rows.Close()
+
+	repos := make([]models.SourceRepository, 0)
+	for rows.Next() {
+		var s models.SourceRepository
+		if err := rows.Scan(
+			&s.ID, &s.NamespaceID, &s.OrganizationID, &s.URL, &s.Provider,
+			&s.DefaultBranch, &s.LastCommitAt, &s.CheckedAt, &s.CheckError, &s.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		repos = append(repos, s)
+	}
+	return repos, rows.Err()
+}
+
+// ListRepositories returns the source repositories linked to a namespace,
+// in the order they were linked
+func (r *NamespaceRepository) ListRepositories(ctx context.Context, namespaceID uuid.UUID) ([]models.SourceRepository, error) {
+	rows, err := r.reader().Query(ctx, `
+		SELECT `+sourceRepositoryColumns+`
+		FROM namespace_repositories
+		WHERE namespace_id = $1
+		ORDER BY created_at, url`,
+		namespaceID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return scanSourceRepositories(rows)
+}
+
+// SetRepositories links exactly the given repositories to a namespace and
+// returns them. Repositories already linked keep what was read about them.
+func (r *NamespaceRepository) SetRepositories(ctx context.Context, orgID, namespaceID uuid.UUID, repos []models.SourceRepository) ([]models.SourceRepository, error) {
+	urls := make([]string, len(repos))
+	for i, s := range repos {
+		urls[i] = s.URL
+	}
+
+	var linked []models.SourceRepository
+	err := runInTx(ctx, r.pool, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, `
+			DELETE FROM namespace_repositories
+			WHERE namespace_id = $1 AND url <> ALL($2)`,
+			namespaceID, urls,
+		); err != nil {
+			return fmt.Errorf("failed to unlink repositories: %w", err)
+		}
+		for _, s := range repos {
+			if _, err := tx.Exec(ctx, `
+				INSERT INTO namespace_repositories (namespace_id, organization_id, url, provider)
+				VALUES ($1, $2, $3, $4)
+				ON CONFLICT (namespace_id, url) DO NOTHING`,
+				namespaceID, orgID, s.URL, s.Provider,
+			); err != nil {
+				return fmt.Errorf("failed to link repository: %w", err)
+			}
+		}
+
+		rows, err := tx.Query(ctx, `
+			SELECT `+sourceRepositoryColumns+`
+			FROM namespace_repositories
+			WHERE namespace_id = $1
+			ORDER BY created_at, url`,
+			namespaceID,
+		)
+		if err != nil {
+			return err
+		}
+		linked, err = scanSourceRepositories(rows)
+		return err
+	})
+	return linked, err
+}
+
+// ListStaleRepositories returns up to limit GitHub and GitLab repositories
+// of existing namespaces that were not read since checkedBefore, those never
+// read first
+func (r *NamespaceRepository) ListStaleRepositories(ctx context.Context, checkedBefore time.Time, limit int) ([]models.SourceRepository, error) {
+	rows, err := r.reader().Query(ctx, `
+		SELECT `+sourceRepositoryColumns+`
+		FROM namespace_repositories
+		WHERE provider <> 'other'
+			AND (checked_at IS NULL OR checked_at < $1)
+			AND namespace_id IN (SELECT id FROM namespaces WHERE deleted_at IS NULL)
+		ORDER BY checked_at NULLS FIRST
+		LIMIT $2`,
+		checkedBefore, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return scanSourceRepositories(rows)
+}
+
+// UpdateRepositoryCheck records what was read about a source repository, or
+// why reading it failed
+func (r *NamespaceRepository) UpdateRepositoryCheck(ctx context.Context, s *models.SourceRepository) error {
+	_, err := r.pool.Exec(ctx, `
+		UPDATE namespace_repositories
+		SET default_branch = $2, last_commit_at = $3, checked_at = $4, check_error = $5
+		WHERE id = $1`,
+		s.ID, s.DefaultBranch, s.LastCommitAt, s.CheckedAt, s.CheckError,
+	)
+	return err
+}
+
+const monitoringLinkColumns = `id, namespace_id, organization_id, type, url,
+			missing, checked_at, check_error, created_at`
+
+func scanMonitoringLinks(rows pgx.Rows) ([]models.MonitoringLink, error) {
+	defer rows.Close()
+
+	links := make([]models.MonitoringLink, 0)
+	for rows.Next() {
+		var l models.MonitoringLink
+		if err := rows.Scan(
+			&l.ID, &l.NamespaceID, &l.OrganizationID, &l.Type, &l.URL,
+			&l.Missing, &l.CheckedAt, &l.CheckError, &l.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		links = append(links, l)
+	}
+	return links, rows.Err()
+}
+
+// ListMonitoringLinks returns the monitoring links of a namespace, in the
+// order they were added
+func (r *NamespaceRepository) ListMonitoringLinks(ctx context.Context, namespaceID uuid.UUID) ([]models.MonitoringLink, error) {
+	rows, err := r.reader().Query(ctx, `
+		SELECT `+monitoringLinkColumns+`
+		FROM namespace_monitoring_links
+		WHERE namespace_id = $1
+		ORDER BY created_at, url`,
+		namespaceID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return scanMonitoringLinks(rows)
+}
+
+// SetMonitoringLinks sets exactly the given monitoring links on a namespace
+// and returns them. Links already set keep their last check, unless their
+// type changed.
+func (r *NamespaceRepository) SetMonitoringLinks(ctx context.Context, orgID, namespaceID uuid.UUID, links []models.MonitoringLink) ([]models.MonitoringLink, error) {
+	urls := make([]string, len(links))
+	for i, l := range links {
+		urls[i] = l.URL
+	}
+
+	var set []models.MonitoringLink
+	err := runInTx(ctx, r.pool, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, `
+			DELETE FROM namespace_monitoring_links
+			WHERE namespace_id = $1 AND url <> ALL($2)`,
+			namespaceID, urls,
+		); err != nil {
+			return fmt.Errorf("failed to remove monitoring links: %w", err)
+		}
+		for _, l := range links {
+			if _, err := tx.Exec(ctx, `
+				INSERT INTO namespace_monitoring_links (namespace_id, organization_id, type, url)
+				VALUES ($1, $2, $3, $4)
+				ON CONFLICT (namespace_id, url) DO UPDATE
+				SET type = EXCLUDED.type, missing = false, checked_at = NULL, check_error = NULL
+				WHERE namespace_monitoring_links.type <> EXCLUDED.type`,
+				namespaceID, orgID, l.Type, l.URL,
+			); err != nil {
+				return fmt.Errorf("failed to add monitoring link: %w", err)
+			}
+		}
+
+		rows, err := tx.Query(ctx, `
+			SELECT `+monitoringLinkColumns+`
+			FROM namespace_monitoring_links
+			WHERE namespace_id = $1
+			ORDER BY created_at, url`,
+			namespaceID,
+		)
+		if err != nil {
+			return err
+		}
+		set, err = scanMonitoringLinks(rows)
+		return err
+	})
+	return set, err
+}
+
+// ListStaleMonitoringLinks returns up to limit Grafana and Datadog links of
+// existing namespaces that were not checked since checkedBefore, those never
+// checked first
+func (r *NamespaceRepository) ListStaleMonitoringLinks(ctx context.Context, checkedBefore time.Time, limit int) ([]models.MonitoringLink, error) {
+	rows, err := r.reader().Query(ctx, `
+		SELECT `+monitoringLinkColumns+`
+		FROM namespace_monitoring_links
+		WHERE type <> 'other'
+			AND (checked_at IS NULL OR checked_at < $1)
+			AND namespace_id IN (SELECT id FROM namespaces WHERE deleted_at IS NULL)
+		ORDER BY checked_at NULLS FIRST
+		LIMIT $2`,
+		checkedBefore, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return scanMonitoringLinks(rows)
+}
+
+// UpdateMonitoringLinkCheck records whether a link's dashboard was found, or
+// why checking it failed
+func (r *NamespaceRepository) UpdateMonitoringLinkCheck(ctx context.Context, l *models.MonitoringLink) error {
+	_, err := r.pool.Exec(ctx, `
+		UPDATE namespace_monitoring_links
+		SET missing = $2, checked_at = $3, check_error = $4
+		WHERE id = $1`,
+		l.ID, l.Missing, l.CheckedAt, l.CheckError,
+	)
+	return err
+}
+
+// ListMonitoringCoverage counts the monitoring links of every namespace of
+// an organization with the given criticality, ordered by cluster and name
+func (r *NamespaceRepository) ListMonitoringCoverage(ctx context.Context, orgID uuid.UUID, criticality string) ([]models.NamespaceMonitoringCoverage, error) {
+	rows, err := r.reader().Query(ctx, `
+		SELECT
+			n.id, n.name, c.id, c.name, n.environment, COALESCE(t.name, ''),
+			COUNT(l.id), COUNT(l.id) FILTER (WHERE l.missing)
+		FROM namespaces n
+		JOIN clusters c ON c.id = n.cluster_id AND c.deleted_at IS NULL
+		LEFT JOIN teams t ON t.id = n.infrastructure_owner_team_id AND t.deleted_at IS NULL
+		LEFT JOIN namespace_monitoring_links l ON l.namespace_id = n.id
+		WHERE n.organization_id = $1 AND n.criticality = $2 AND n.deleted_at IS NULL
+		GROUP BY n.id, c.id, t.name
+		ORDER BY c.name, n.name`,
+		orgID, criticality,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make([]models.NamespaceMonitoringCoverage, 0)
+	for rows.Next() {
+		var m models.NamespaceMonitoringCoverage
+		if err := rows.Scan(
+			&m.NamespaceID, &m.Namespace, &m.ClusterID, &m.Cluster, &m.Environment, &m.OwnerTeam,
+			&m.Links, &m.MissingLinks,
+		); err != nil {
+			return nil, err
+		}
+		result = append(result, m)
+	}
+	return result, rows.Err()
+}
@@ -0,0 +1,27 @@
+package repositories
+
+import "testing"
+
+func TestSearchPattern(t *testing.T) {
+	tests := []struct {
+		name string
+		term string
+		want string
+	}{
+		{"plain", "payments", `%payments%`},
+		{"empty", "", `%%`},
+		{"percent", "100%", `%100\%%`},
+		{"underscore", "team_a", `%team\_a%`},
+		{"backslash", `C:\logs`, `%C:\\logs%`},
+		{"escaped wildcard", `\%`, `%\\\%%`},
+		{"all together", `a_b%c\d`, `%a\_b\%c\\d%`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := searchPattern(tt.term); got != tt.want {
+				t.Errorf("searchPattern(%q) = %q, want %q", tt.term, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,326 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/kubeatlas/kubeatlas/internal/models"
+)
+
+// NotificationRepository handles notification templates and the delivery log
+type NotificationRepository struct {
+	*BaseRepository
+	pool DBTX
+}
+
+// NewNotificationRepository creates a new notification repository
+func NewNotificationRepository(pool DBTX) *NotificationRepository {
+	return &NotificationRepository{
+		BaseRepository: NewBaseRepository(pool),
+		pool:           pool,
+	}
+}
+
+const notificationDeliveryColumns = `
+	id, organization_id, event_type, channel, recipients, subject, body,
+	status, attempts, last_error, next_attempt_at, sent_at, created_at, updated_at
+`
+
+func scanNotificationDelivery(row pgx.Row, d *models.NotificationDelivery) error {
+	return row.Scan(
+		&d.ID, &d.OrganizationID, &d.EventType, &d.Channel, &d.Recipients, &d.Subject, &d.Body,
+		&d.Status, &d.Attempts, &d.LastError, &d.NextAttemptAt, &d.SentAt, &d.CreatedAt, &d.UpdatedAt,
+	)
+}
+
+// GetTemplate returns the active template for an event, preferring the
+// organization's own over a global one. Returns nil when neither exists.
+func (r *NotificationRepository) GetTemplate(ctx context.Context, orgID uuid.UUID, eventType, channel string) (*models.NotificationTemplate, error) {
+	query := `
+		SELECT id, organization_id, name, event_type, channel, subject_template, body_template, is_active, created_at, updated_at
+		FROM notification_templates
+		WHERE (organization_id = $1 OR organization_id IS NULL)
+		  AND event_type = $2 AND channel = $3 AND is_active = true
+		ORDER BY organization_id NULLS LAST
+		LIMIT 1
+	`
+
+	t := &models.NotificationTemplate{}
+	err := r.pool.QueryRow(ctx, query, orgID, eventType, channel).Scan(
+		&t.ID, &t.OrganizationID, &t.Name, &t.EventType, &t.Channel, &t.SubjectTemplate, &t.BodyTemplate, &t.IsActive, &t.CreatedAt, &t.UpdatedAt,
+	)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// ListTemplates returns the organization's templates
+func (r *NotificationRepository) ListTemplates(ctx context.Context, orgID uuid.UUID) ([]models.NotificationTemplate, error) {
+	query := `
+		SELECT id, organization_id, name, event_type, channel, subject_template, body_template, is_active, created_at, updated_at
+		FROM notification_templates
+		WHERE organization_id = $1
+		ORDER BY event_type, channel
+	`
+
+	rows, err := r.reader().Query(ctx, query, orgID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	templates := make([]models.NotificationTemplate, 0)
+	for rows.Next() {
+		var t models.NotificationTemplate
+		if err := rows.Scan(
+			&t.ID, &t.OrganizationID, &t.Name, &t.EventType, &t.Channel, &t.SubjectTemplate, &t.BodyTemplate, &t.IsActive, &t.CreatedAt, &t.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		templates = append(templates, t)
+	}
+	return templates, rows.Err()
+}
+
+// UpsertTemplate creates or replaces the organization's template for an event and channel
+func (r *NotificationRepository) UpsertTemplate(ctx context.Context, t *models.NotificationTemplate) error {
+	t.ID = uuid.New()
+	t.CreatedAt = time.Now()
+	t.UpdatedAt = time.Now()
+
+	query := `
+		INSERT INTO notification_templates (
+			id, organization_id, name, event_type, channel, subject_template, body_template, is_active, created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		ON CONFLICT (COALESCE(organization_id, '00000000-0000-0000-0000-000000000000'::uuid), event_type, channel)
+		DO UPDATE SET
+			name = EXCLUDED.name,
+			subject_template = EXCLUDED.subject_template,
+			body_template = EXCLUDED.body_template,
+			is_active = EXCLUDED.is_active,
+			updated_at = EXCLUDED.updated_at
+		RETURNING id, created_at
+	`
+
+	return r.pool.QueryRow(ctx, query,
+		t.ID, t.OrganizationID, t.Name, t.EventType, t.Channel, t.SubjectTemplate, t.BodyTemplate, t.IsActive, t.CreatedAt, t.UpdatedAt,
+	).Scan(&t.ID, &t.CreatedAt)
+}
+
+// CreateDelivery queues a rendered notification
+func (r *NotificationRepository) CreateDelivery(ctx context.Context, d *models.NotificationDelivery) error {
+	d.ID = uuid.New()
+	d.CreatedAt = time.Now()
+	d.UpdatedAt = d.CreatedAt
+	if d.Status == "" {
+		d.Status = models.DeliveryStatusPending
+	}
+	if d.NextAttemptAt.IsZero() {
+		d.NextAttemptAt = d.CreatedAt
+	}
+
+	query := `
+		INSERT INTO notification_deliveries (
+			id, organization_id, event_type, channel, recipients, subject, body,
+			status, attempts, next_attempt_at, created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+	`
+
+	_, err := r.pool.Exec(ctx, query,
+		d.ID, d.OrganizationID, d.EventType, d.Channel, d.Recipients, d.Subject, d.Body,
+		d.Status, d.Attempts, d.NextAttemptAt, d.CreatedAt, d.UpdatedAt,
+	)
+	return err
+}
+
+// ClaimDueDeliveries marks up to limit due deliveries as sending and returns
+// them. Rows locked by another instance are skipped, and deliveries stuck in
+// sending for longer than staleAfter are reclaimed.
+func (r *NotificationRepository) ClaimDueDeliveries(ctx context.Context, limit int, staleAfter time.Duration) ([]models.NotificationDelivery, error) {
+	query := `
+		UPDATE notification_deliveries SET
+			status = 'sending',
+			attempts = attempts + 1,
+			next_attempt_at = NOW() + $2::interval,
+			updated_at = NOW()
+		WHERE id IN (
+			SELECT id FROM notification_deliveries
+			WHERE status IN ('pending', 'sending') AND next_attempt_at <= NOW()
+			ORDER BY next_attempt_at
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + notificationDeliveryColumns
+
+	rows, err := r.pool.Query(ctx, query, limit, fmt.Sprintf("%d seconds", int(staleAfter.Seconds())))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	deliveries := make([]models.NotificationDelivery, 0)
+	for rows.Next() {
+		var d models.NotificationDelivery
+		if err := scanNotificationDelivery(rows, &d); err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, d)
+	}
+	return deliveries, rows.Err()
+}
+
+// MarkDeliverySent records a successful delivery
+func (r *NotificationRepository) MarkDeliverySent(ctx context.Context, id uuid.UUID) error {
+	query := `
+		UPDATE notification_deliveries SET
+			status = 'sent', last_error = NULL, sent_at = NOW(), updated_at = NOW()
+		WHERE id = $1
+	`
+	_, err := r.pool.Exec(ctx, query, id)
+	return err
+}
+
+// MarkDeliveryFailed records a failed attempt. With a nil retryAt the
+// delivery is given up on; otherwise it is retried at retryAt.
+func (r *NotificationRepository) MarkDeliveryFailed(ctx context.Context, id uuid.UUID, deliveryErr string, retryAt *time.Time) error {
+	status := models.DeliveryStatusFailed
+	next := time.Now()
+	if retryAt != nil {
+		status = models.DeliveryStatusPending
+		next = *retryAt
+	}
+
+	query := `
+		UPDATE notification_deliveries SET
+			status = $2, last_error = $3, next_attempt_at = $4, updated_at = NOW()
+		WHERE id = $1
+	`
+	_, err := r.pool.Exec(ctx, query, id, status, deliveryErr, next)
+	return err
+}
+
+// ListDeliveries retrieves the delivery log of an organization, newest first
+func (r *NotificationRepository) ListDeliveries(ctx context.Context, orgID uuid.UUID, p Pagination, filters map[string]interface{}) (*PaginatedResult[models.NotificationDelivery], error) {
+	qb := NewQueryBuilder(`SELECT ` + notificationDeliveryColumns + ` FROM notification_deliveries`)
+
+	qb.Where("organization_id = ?", orgID)
+	if status, ok := filters["status"].(string); ok && status != "" {
+		qb.Where("status = ?", status)
+	}
+	if eventType, ok := filters["event_type"].(string); ok && eventType != "" {
+		qb.Where("event_type = ?", eventType)
+	}
+
+	p.Sort = "created_at"
+	p.Order = "desc"
+	qb.Paginate(p)
+
+	countQuery, countArgs := qb.BuildCount()
+	var total int64
+	if err := r.reader().QueryRow(ctx, countQuery, countArgs...).Scan(&total); err != nil {
+		return nil, fmt.Errorf("failed to count notification deliveries: %w", err)
+	}
+
+	query, args := qb.Build()
+	rows, err := r.reader().Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query notification deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	deliveries := make([]models.NotificationDelivery, 0)
+	for rows.Next() {
+		var d models.NotificationDelivery
+		if err := scanNotificationDelivery(rows, &d); err != nil {
+			return nil, fmt.Errorf("failed to scan notification delivery: %w", err)
+		}
+		deliveries = append(deliveries, d)
+	}
+
+	totalPages := int(total) / p.PageSize
+	if int(total)%p.PageSize > 0 {
+		totalPages++
+	}
+
+	return &PaginatedResult[models.NotificationDelivery]{
+		Items:      deliveries,
+		Total:      total,
+		Page:       p.Page,
+		PageSize:   p.PageSize,
+		TotalPages: totalPages,
+	}, nil
+}
+
+// ListEmailEnabledOrganizations returns the organizations with SMTP delivery enabled
+func (r *NotificationRepository) ListEmailEnabledOrganizations(ctx context.Context) ([]uuid.UUID, error) {
+	query := `SELECT id FROM organizations WHERE (settings->'smtp'->>'enabled')::boolean IS TRUE`
+
+	rows, err := r.reader().Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := make([]uuid.UUID, 0)
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// LastDeliveryAt returns when the organization was last sent an event, or nil if never
+func (r *NotificationRepository) LastDeliveryAt(ctx context.Context, orgID uuid.UUID, eventType string) (*time.Time, error) {
+	query := `SELECT MAX(created_at) FROM notification_deliveries WHERE organization_id = $1 AND event_type = $2`
+
+	var last *time.Time
+	if err := r.pool.QueryRow(ctx, query, orgID, eventType).Scan(&last); err != nil {
+		return nil, err
+	}
+	return last, nil
+}
+
+// GetTeamBindings returns the notification bindings of a team with its
+// Teams webhook as stored, or nil when none were set
+func (r *NotificationRepository) GetTeamBindings(ctx context.Context, teamID uuid.UUID) (*models.TeamNotificationBindings, error) {
+	b := &models.TeamNotificationBindings{}
+	err := r.reader().QueryRow(ctx, `
+		SELECT team_id, slack_channel_id, email_alias, teams_webhook_url
+		FROM team_notification_bindings
+		WHERE team_id = $1`, teamID,
+	).Scan(&b.TeamID, &b.SlackChannelID, &b.EmailAlias, &b.TeamsWebhookURL)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	b.TeamsChannel = b.TeamsWebhookURL != ""
+	return b, nil
+}
+
+// SaveTeamBindings stores the notification bindings of a team, with its
+// Teams webhook already sealed
+func (r *NotificationRepository) SaveTeamBindings(ctx context.Context, orgID uuid.UUID, b *models.TeamNotificationBindings) error {
+	_, err := r.pool.Exec(ctx, `
+		INSERT INTO team_notification_bindings (team_id, organization_id, slack_channel_id, email_alias, teams_webhook_url, updated_at)
+		VALUES ($1, $2, $3, $4, $5, NOW())
+		ON CONFLICT (team_id) DO UPDATE SET
+			slack_channel_id = EXCLUDED.slack_channel_id,
+			email_alias = EXCLUDED.email_alias,
+			teams_webhook_url = EXCLUDED.teams_webhook_url,
+			updated_at = NOW()`,
+		b.TeamID, orgID, b.SlackChannelID, b.EmailAlias, b.TeamsWebhookURL,
+	)
+	return err
+}
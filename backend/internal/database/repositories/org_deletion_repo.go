@@ -0,0 +1,327 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/kubeatlas/kubeatlas/internal/models"
+)
+
+// orgDeletionStep deletes an organization's rows of table, or with set,
+// clears references between them first. In where, t is the table and $1 the
+// organization.
+type orgDeletionStep struct {
+	table string
+	where string
+	set   string
+	// files and objects are columns of deleted rows holding stored files and
+	// object storage keys to remove, if any
+	files   string
+	objects string
+}
+
+// orgDeletionSteps lists the deletions in an order that satisfies foreign
+// keys: every table is emptied before the tables it references
+var orgDeletionSteps = []orgDeletionStep{
+	{table: "webhook_deliveries", where: whereOrganization},
+	{table: "webhook_subscriptions", where: whereOrganization},
+	{table: "notification_deliveries", where: whereOrganization},
+	{table: "notification_templates", where: whereOrganization},
+	{table: "scheduled_reports", where: whereOrganization},
+	{table: "api_tokens", where: whereOrganization},
+	{table: "saved_searches", where: whereOrganization},
+	{table: "comments", where: whereOrganization},
+	{table: "escalations", where: whereOrganization},
+	{table: "org_exports", where: whereOrganization, objects: "object_key"},
+	{table: "metadata_backups", where: whereOrganization, objects: "object_key"},
+	{table: "namespace_bulk_assignments", where: whereOrganization},
+	{table: "documents", where: whereOrganization, set: "previous_version_id = NULL"},
+	{table: "documents", where: whereOrganization, files: "file_path"},
+	{table: "document_categories", where: whereOrganization},
+	{table: "application_namespaces", where: whereOrganization},
+	{table: "applications", where: whereOrganization},
+	{table: "internal_dependencies", where: whereOrganization},
+	{table: "external_dependencies", where: whereOrganization},
+	{table: "namespace_role_bindings", where: whereOrgNamespace},
+	{table: "namespace_service_accounts", where: whereOrgNamespace},
+	{table: "namespace_flux_resources", where: whereOrgNamespace},
+	{table: "namespace_usage_samples", where: whereOrgNamespace},
+	{table: "namespace_tickets", where: whereOrganization},
+	{table: "namespace_confluence_pages", where: whereOrganization},
+	{table: "namespace_repositories", where: whereOrganization},
+	{table: "namespace_monitoring_links", where: whereOrganization},
+	{table: "namespace_contact_issues", where: whereOrganization},
+	{table: "namespaces", where: whereOrganization},
+	{table: "cluster_fleet_members", where: whereOrganization},
+	{table: "cluster_fleets", where: whereOrganization},
+	{table: "cluster_maintenance_windows", where: whereOrganization},
+	{table: "cluster_sync_errors", where: whereOrgCluster},
+	{table: "cluster_sync_runs", where: whereOrgCluster},
+	{table: "cluster_capacity_snapshots", where: whereOrgCluster},
+	{table: "cluster_nodes", where: whereOrganization},
+	{table: "cluster_version_alerts", where: whereOrgCluster},
+	{table: "clusters", where: whereOrganization},
+	{table: "team_membership_changes", where: whereOrganization},
+	{table: "team_directory_groups", where: whereOrganization},
+	{table: "directory_groups", where: whereOrganization},
+	{table: "team_on_call", where: whereOrganization},
+	{table: "team_notification_bindings", where: whereOrganization},
+	{table: "team_members", where: whereOrgTeam},
+	{table: "teams", where: whereOrganization, set: "parent_id = NULL"},
+	{table: "teams", where: whereOrganization},
+	{table: "business_units", where: whereOrganization, set: "parent_id = NULL"},
+	{table: "business_units", where: whereOrganization},
+	{table: "audit_log_archives", where: whereOrganization, objects: "object_key"},
+	{table: "audit_logs", where: whereOrganization},
+	{table: "settings", where: whereOrganization},
+	{table: "users", where: whereOrganization},
+}
+
+// OrgDeletionResult lists what is left to remove outside the database once
+// an organization's rows are deleted
+type OrgDeletionResult struct {
+	// Files are the stored files of its documents
+	Files []string
+	// ObjectKeys are its audit log archives, exports and metadata backups in
+	// object storage
+	ObjectKeys []string
+}
+
+const orgDeletionColumns = `
+	id, organization_id, organization_name, organization_slug, requested_by_email, status, counts,
+	step, error, expires_at, confirmed_at, started_at, completed_at, created_at, updated_at
+`
+
+func scanOrgDeletion(row pgx.Row, d *models.OrgDeletion) error {
+	return row.Scan(
+		&d.ID, &d.OrganizationID, &d.OrganizationName, &d.OrganizationSlug, &d.RequestedByEmail, &d.Status, &d.Counts,
+		&d.Step, &d.Error, &d.ExpiresAt, &d.ConfirmedAt, &d.StartedAt, &d.CompletedAt, &d.CreatedAt, &d.UpdatedAt,
+	)
+}
+
+// OrgDeletionRepository stores organization deletions and deletes the data
+// of organizations
+type OrgDeletionRepository struct {
+	*BaseRepository
+	pool DBTX
+}
+
+// NewOrgDeletionRepository creates a new organization deletion repository
+func NewOrgDeletionRepository(pool DBTX) *OrgDeletionRepository {
+	return &OrgDeletionRepository{
+		BaseRepository: NewBaseRepository(pool),
+		pool:           pool,
+	}
+}
+
+// CountRows returns the number of rows per table that deleting the
+// organization removes, leaving out tables without any
+func (r *OrgDeletionRepository) CountRows(ctx context.Context, orgID uuid.UUID) (map[string]int64, error) {
+	counts := make(map[string]int64)
+	for _, step := range orgDeletionSteps {
+		if step.set != "" {
+			continue
+		}
+		var n int64
+		query := `SELECT COUNT(*) FROM ` + pgx.Identifier{step.table}.Sanitize() + ` t WHERE ` + step.where
+		if err := r.pool.QueryRow(ctx, query, orgID).Scan(&n); err != nil {
+			return nil, fmt.Errorf("failed to count %s: %w", step.table, err)
+		}
+		if n > 0 {
+			counts[step.table] = n
+		}
+	}
+	return counts, nil
+}
+
+// CountLedgerEntries returns the number of the organization's entries in the
+// append-only audit log ledger, which cannot be deleted
+func (r *OrgDeletionRepository) CountLedgerEntries(ctx context.Context, orgID uuid.UUID) (int64, error) {
+	var n int64
+	err := r.pool.QueryRow(ctx, `SELECT COUNT(*) FROM audit_log_ledger WHERE organization_id = $1`, orgID).Scan(&n)
+	return n, err
+}
+
+// Create records a deletion preview
+func (r *OrgDeletionRepository) Create(ctx context.Context, d *models.OrgDeletion) error {
+	query := `
+		INSERT INTO org_deletions (organization_id, organization_name, organization_slug, requested_by_email, counts, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING ` + orgDeletionColumns
+
+	return scanOrgDeletion(r.pool.QueryRow(ctx, query,
+		d.OrganizationID, d.OrganizationName, d.OrganizationSlug, d.RequestedByEmail, d.Counts, d.ExpiresAt,
+	), d)
+}
+
+// Get retrieves a deletion of an organization, or nil when there is none
+func (r *OrgDeletionRepository) Get(ctx context.Context, orgID, id uuid.UUID) (*models.OrgDeletion, error) {
+	query := `SELECT ` + orgDeletionColumns + ` FROM org_deletions WHERE id = $1 AND organization_id = $2`
+
+	var d models.OrgDeletion
+	err := scanOrgDeletion(r.pool.QueryRow(ctx, query, id, orgID), &d)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+// Confirm queues the deletion of a preview that has not expired, reporting
+// false when there is no such preview. The organization is marked deleted
+// and its users deactivated at once, so nobody can sign in to it or keep
+// using a token issued before while its data is deleted.
+func (r *OrgDeletionRepository) Confirm(ctx context.Context, orgID, id uuid.UUID) (bool, error) {
+	var confirmed bool
+	err := runInTx(ctx, r.pool, func(tx pgx.Tx) error {
+		result, err := tx.Exec(ctx, `
+			UPDATE org_deletions SET status = 'pending', confirmed_at = NOW(), updated_at = NOW()
+			WHERE id = $1 AND organization_id = $2 AND status = 'previewed' AND expires_at > NOW()
+		`, id, orgID)
+		if err != nil {
+			return err
+		}
+		if result.RowsAffected() == 0 {
+			return nil
+		}
+		confirmed = true
+
+		if _, err := tx.Exec(ctx, `UPDATE organizations SET deleted_at = NOW(), updated_at = NOW() WHERE id = $1 AND deleted_at IS NULL`, orgID); err != nil {
+			return err
+		}
+		_, err = tx.Exec(ctx, `UPDATE users SET is_active = false, updated_at = NOW() WHERE organization_id = $1`, orgID)
+		return err
+	})
+	return confirmed, err
+}
+
+// ClaimNext marks the oldest queued deletion as running and returns it, or
+// nil when there is none. Rows locked by another instance are skipped;
+// running deletions that made no progress for longer than staleAfter and
+// failed ones last tried more than retryAfter ago are run again.
+func (r *OrgDeletionRepository) ClaimNext(ctx context.Context, staleAfter, retryAfter time.Duration) (*models.OrgDeletion, error) {
+	query := `
+		UPDATE org_deletions SET
+			status = 'running',
+			step = NULL,
+			error = NULL,
+			started_at = NOW(),
+			updated_at = NOW()
+		WHERE id = (
+			SELECT id FROM org_deletions
+			WHERE status = 'pending'
+				OR (status = 'running' AND updated_at < NOW() - $1::interval)
+				OR (status = 'failed' AND updated_at < NOW() - $2::interval)
+			ORDER BY confirmed_at
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + orgDeletionColumns
+
+	var d models.OrgDeletion
+	err := scanOrgDeletion(r.pool.QueryRow(ctx, query,
+		fmt.Sprintf("%d seconds", int(staleAfter.Seconds())), fmt.Sprintf("%d seconds", int(retryAfter.Seconds())),
+	), &d)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+// UpdateStep records the table a running deletion is deleting from
+func (r *OrgDeletionRepository) UpdateStep(ctx context.Context, id uuid.UUID, step string) error {
+	query := `UPDATE org_deletions SET step = $2, updated_at = NOW() WHERE id = $1 AND status = 'running'`
+	_, err := r.pool.Exec(ctx, query, id, step)
+	return err
+}
+
+// Complete records a finished deletion
+func (r *OrgDeletionRepository) Complete(ctx context.Context, id uuid.UUID) error {
+	query := `
+		UPDATE org_deletions SET status = 'completed', step = NULL, completed_at = NOW(), updated_at = NOW()
+		WHERE id = $1
+	`
+	_, err := r.pool.Exec(ctx, query, id)
+	return err
+}
+
+// Fail records why a deletion failed
+func (r *OrgDeletionRepository) Fail(ctx context.Context, id uuid.UUID, msg string) error {
+	query := `UPDATE org_deletions SET status = 'failed', error = $2, updated_at = NOW() WHERE id = $1`
+	_, err := r.pool.Exec(ctx, query, id, msg)
+	return err
+}
+
+// DeleteOrganization deletes all rows of an organization, calling step with
+// each table before deleting from it. Every table is deleted from in its own
+// statement, so a deletion that fails part way can be run again. The
+// organization itself is deleted last, together with audit logs written in
+// the meantime.
+func (r *OrgDeletionRepository) DeleteOrganization(ctx context.Context, orgID uuid.UUID, step func(table string) error) (*OrgDeletionResult, error) {
+	result := &OrgDeletionResult{}
+	for _, ds := range orgDeletionSteps {
+		if err := step(ds.table); err != nil {
+			return result, err
+		}
+		if err := r.deleteStep(ctx, orgID, ds, result); err != nil {
+			return result, fmt.Errorf("failed to delete %s: %w", ds.table, err)
+		}
+	}
+
+	if err := step("organizations"); err != nil {
+		return result, err
+	}
+	err := runInTx(ctx, r.pool, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, `DELETE FROM audit_logs WHERE organization_id = $1`, orgID); err != nil {
+			return err
+		}
+		_, err := tx.Exec(ctx, `DELETE FROM organizations WHERE id = $1`, orgID)
+		return err
+	})
+	if err != nil {
+		return result, fmt.Errorf("failed to delete organization: %w", err)
+	}
+	return result, nil
+}
+
+// deleteStep runs one step of an organization deletion, adding the stored
+// files and object keys of deleted rows to result
+func (r *OrgDeletionRepository) deleteStep(ctx context.Context, orgID uuid.UUID, ds orgDeletionStep, result *OrgDeletionResult) error {
+	table := pgx.Identifier{ds.table}.Sanitize()
+	if ds.set != "" {
+		_, err := r.pool.Exec(ctx, `UPDATE `+table+` t SET `+ds.set+` WHERE `+ds.where, orgID)
+		return err
+	}
+
+	column, dest := ds.files, &result.Files
+	if ds.objects != "" {
+		column, dest = ds.objects, &result.ObjectKeys
+	}
+	if column == "" {
+		_, err := r.pool.Exec(ctx, `DELETE FROM `+table+` t WHERE `+ds.where, orgID)
+		return err
+	}
+
+	rows, err := r.pool.Query(ctx, `DELETE FROM `+table+` t WHERE `+ds.where+` RETURNING t.`+column, orgID)
+	if err != nil {
+		return err
+	}
+	values, err := pgx.CollectRows(rows, pgx.RowTo[*string])
+	if err != nil {
+		return err
+	}
+	for _, v := range values {
+		if v != nil && *v != "" {
+			*dest = append(*dest, *v)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,145 @@
+package repositories
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/kubeatlas/kubeatlas/internal/models"
+)
+
+// OrgSettingsRepository handles organization profiles and the settings
+// stored in organizations.settings
+type OrgSettingsRepository struct {
+	*BaseRepository
+	pool DBTX
+}
+
+// NewOrgSettingsRepository creates a new organization settings repository
+func NewOrgSettingsRepository(pool DBTX) *OrgSettingsRepository {
+	return &OrgSettingsRepository{
+		BaseRepository: NewBaseRepository(pool),
+		pool:           pool,
+	}
+}
+
+// GetOrganization retrieves an organization. Returns nil when it does not exist.
+func (r *OrgSettingsRepository) GetOrganization(ctx context.Context, orgID uuid.UUID) (*models.Organization, error) {
+	query := `
+		SELECT id, name, slug, description, logo_url, COALESCE(settings, '{}'::jsonb), created_at, updated_at
+		FROM organizations
+		WHERE id = $1 AND deleted_at IS NULL
+	`
+
+	org := &models.Organization{}
+	err := r.pool.QueryRow(ctx, query, orgID).Scan(
+		&org.ID, &org.Name, &org.Slug, &org.Description, &org.LogoURL, &org.Settings, &org.CreatedAt, &org.UpdatedAt,
+	)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return org, nil
+}
+
+// GetOrganizationBySlug retrieves an organization by slug. Returns nil when
+// it does not exist.
+func (r *OrgSettingsRepository) GetOrganizationBySlug(ctx context.Context, slug string) (*models.Organization, error) {
+	query := `
+		SELECT id, name, slug, description, logo_url, COALESCE(settings, '{}'::jsonb), created_at, updated_at
+		FROM organizations
+		WHERE slug = $1 AND deleted_at IS NULL
+	`
+
+	org := &models.Organization{}
+	err := r.pool.QueryRow(ctx, query, slug).Scan(
+		&org.ID, &org.Name, &org.Slug, &org.Description, &org.LogoURL, &org.Settings, &org.CreatedAt, &org.UpdatedAt,
+	)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return org, nil
+}
+
+// ListOrganizationIDs returns the IDs of all organizations
+func (r *OrgSettingsRepository) ListOrganizationIDs(ctx context.Context) ([]uuid.UUID, error) {
+	rows, err := r.pool.Query(ctx, `SELECT id FROM organizations WHERE deleted_at IS NULL ORDER BY created_at`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := make([]uuid.UUID, 0)
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// UpdateProfile updates the name, description and logo of an organization
+func (r *OrgSettingsRepository) UpdateProfile(ctx context.Context, org *models.Organization) error {
+	query := `
+		UPDATE organizations SET name = $2, description = $3, logo_url = $4, updated_at = NOW()
+		WHERE id = $1 AND deleted_at IS NULL
+	`
+	result, err := r.pool.Exec(ctx, query, org.ID, org.Name, org.Description, org.LogoURL)
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+// Get retrieves all settings of an organization
+func (r *OrgSettingsRepository) Get(ctx context.Context, orgID uuid.UUID) (models.JSONMap, error) {
+	query := `SELECT COALESCE(settings, '{}'::jsonb) FROM organizations WHERE id = $1`
+
+	var settings models.JSONMap
+	err := r.pool.QueryRow(ctx, query, orgID).Scan(&settings)
+	if err == pgx.ErrNoRows {
+		return make(models.JSONMap), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if settings == nil {
+		settings = make(models.JSONMap)
+	}
+	return settings, nil
+}
+
+// Set stores a single setting. Other keys are left alone, so subsystems
+// updating different settings at the same time do not overwrite each other.
+func (r *OrgSettingsRepository) Set(ctx context.Context, orgID uuid.UUID, key string, value interface{}) error {
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		UPDATE organizations SET
+			settings = jsonb_set(COALESCE(settings, '{}'::jsonb), ARRAY[$2::text], $3::jsonb),
+			updated_at = NOW()
+		WHERE id = $1
+	`
+	result, err := r.pool.Exec(ctx, query, orgID, key, string(raw))
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
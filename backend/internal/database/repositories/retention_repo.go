@@ -0,0 +1,382 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// Retention categories
+const (
+	RetentionNotificationDeliveries = "notification_deliveries"
+	RetentionWebhookDeliveries      = "webhook_deliveries"
+	RetentionAuditLogs              = "audit_logs"
+	RetentionInternalDependencies   = "internal_dependencies"
+	RetentionExternalDependencies   = "external_dependencies"
+	RetentionDocuments              = "documents"
+	RetentionDocumentVersions       = "document_versions"
+	RetentionWebhookSubscriptions   = "webhook_subscriptions"
+	RetentionNamespaces             = "namespaces"
+	RetentionClusters               = "clusters"
+	RetentionTeams                  = "teams"
+)
+
+// RetentionCategories lists every retention category in purge order
+var RetentionCategories = []string{
+	RetentionNotificationDeliveries,
+	RetentionWebhookDeliveries,
+	RetentionAuditLogs,
+	RetentionInternalDependencies,
+	RetentionExternalDependencies,
+	RetentionDocuments,
+	RetentionDocumentVersions,
+	RetentionWebhookSubscriptions,
+	RetentionNamespaces,
+	RetentionClusters,
+	RetentionTeams,
+}
+
+// retentionRule selects the rows of table that expire. In where, t is the
+// table, $1 the organization and $2 the cutoff.
+type retentionRule struct {
+	table string
+	where string
+	// returning is a column returned for every purged row, if any
+	returning string
+	// cascade cleans up, in order, the rows that depend on the purged ones
+	cascade []retentionCascade
+}
+
+// retentionCascade deletes the rows of table that depend on purged records,
+// or with set, clears their references instead. In where, $1 is the IDs of
+// the purged records.
+type retentionCascade struct {
+	table     string
+	where     string
+	set       string
+	returning string
+}
+
+// documentsCascade deletes the documents selected by where, unlinking the
+// versions of other documents that were based on them
+func documentsCascade(where string) []retentionCascade {
+	return []retentionCascade{
+		{
+			table: "documents",
+			set:   "previous_version_id = NULL",
+			where: "previous_version_id IN (SELECT id FROM documents WHERE " + where + ") AND NOT COALESCE(" + where + ", false)",
+		},
+		{table: "documents", where: where, returning: "file_path"},
+	}
+}
+
+// retentionRules are the rules of the cutoff-based categories. Purging a
+// namespace, cluster or team cleans up the records that depend on it, deleted
+// or not; a cluster is only purged once its namespaces are.
+var retentionRules = map[string]retentionRule{
+	RetentionNotificationDeliveries: {
+		table: "notification_deliveries",
+		where: "t.status IN ('sent', 'failed') AND t.created_at < $2",
+	},
+	RetentionWebhookDeliveries: {
+		table: "webhook_deliveries",
+		where: "t.status IN ('sent', 'failed') AND t.created_at < $2",
+	},
+	RetentionAuditLogs: {
+		table: "audit_logs",
+		where: "t.created_at < $2",
+	},
+	RetentionInternalDependencies: {
+		table: "internal_dependencies",
+		where: "t.deleted_at < $2",
+	},
+	RetentionExternalDependencies: {
+		table: "external_dependencies",
+		where: "t.deleted_at < $2",
+	},
+	RetentionDocuments: {
+		table: "documents",
+		where: `t.deleted_at < $2
+			AND NOT EXISTS (SELECT 1 FROM documents v WHERE v.previous_version_id = t.id)`,
+		returning: "file_path",
+	},
+	RetentionWebhookSubscriptions: {
+		table: "webhook_subscriptions",
+		where: "t.deleted_at < $2",
+	},
+	RetentionNamespaces: {
+		table: "namespaces",
+		where: "t.deleted_at < $2",
+		cascade: append([]retentionCascade{
+			{table: "internal_dependencies", where: "source_namespace_id = ANY($1) OR target_namespace_id = ANY($1)"},
+			{table: "external_dependencies", where: "namespace_id = ANY($1)"},
+			{table: "escalations", where: "namespace_id = ANY($1)"},
+		}, documentsCascade("namespace_id = ANY($1)")...),
+	},
+	RetentionClusters: {
+		table: "clusters",
+		where: `t.deleted_at < $2
+			AND NOT EXISTS (SELECT 1 FROM namespaces n WHERE n.cluster_id = t.id)`,
+		cascade: documentsCascade("cluster_id = ANY($1)"),
+	},
+	RetentionTeams: {
+		table: "teams",
+		where: "t.deleted_at < $2",
+		cascade: []retentionCascade{
+			{table: "team_members", where: "team_id = ANY($1)"},
+			{table: "teams", set: "parent_id = NULL", where: "parent_id = ANY($1)"},
+			{table: "clusters", set: "owner_team_id = NULL", where: "owner_team_id = ANY($1)"},
+			{table: "namespaces", set: "infrastructure_owner_team_id = NULL", where: "infrastructure_owner_team_id = ANY($1)"},
+		},
+	},
+}
+
+// count counts the rows the cascade deletes
+func (c retentionCascade) count() string {
+	return fmt.Sprintf(`SELECT COUNT(*) FROM %s WHERE %s`, c.table, c.where)
+}
+
+// exec cleans up the dependent rows
+func (c retentionCascade) exec() string {
+	query := fmt.Sprintf(`DELETE FROM %s WHERE %s`, c.table, c.where)
+	if c.set != "" {
+		query = fmt.Sprintf(`UPDATE %s SET %s WHERE %s`, c.table, c.set, c.where)
+	}
+	if c.returning != "" {
+		query += " RETURNING " + c.returning
+	}
+	return query
+}
+
+// documentVersionsQuery numbers the versions of the organization's documents
+// from the newest (1) back along previous_version_id
+const documentVersionsQuery = `
+	WITH RECURSIVE versions AS (
+		SELECT d.id, 1 AS depth
+		FROM documents d
+		WHERE d.organization_id = $1
+		  AND NOT EXISTS (SELECT 1 FROM documents n WHERE n.previous_version_id = d.id)
+		UNION ALL
+		SELECT d.previous_version_id, v.depth + 1
+		FROM versions v
+		JOIN documents d ON d.id = v.id
+		WHERE d.previous_version_id IS NOT NULL
+	)
+`
+
+// RetentionRepository purges records past an organization's retention settings
+type RetentionRepository struct {
+	*BaseRepository
+	pool DBTX
+}
+
+// NewRetentionRepository creates a new retention repository
+func NewRetentionRepository(pool DBTX) *RetentionRepository {
+	return &RetentionRepository{
+		BaseRepository: NewBaseRepository(pool),
+		pool:           pool,
+	}
+}
+
+func lookupRetentionRule(category string) (retentionRule, error) {
+	rule, ok := retentionRules[category]
+	if !ok {
+		return rule, fmt.Errorf("unknown retention category %q", category)
+	}
+	return rule, nil
+}
+
+// RetentionPurge is what purging a category deleted
+type RetentionPurge struct {
+	Count int64
+	// Files are the paths of the files of the deleted documents
+	Files []string
+	// Cascaded has the number of dependent rows deleted, by table
+	Cascaded map[string]int64
+}
+
+// CountExpired counts the organization's records of category older than
+// cutoff and, by table, the dependent rows purging them would delete
+func (r *RetentionRepository) CountExpired(ctx context.Context, orgID uuid.UUID, category string, cutoff time.Time) (int64, map[string]int64, error) {
+	rule, err := lookupRetentionRule(category)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	if len(rule.cascade) == 0 {
+		query := fmt.Sprintf(`SELECT COUNT(*) FROM %s t WHERE t.organization_id = $1 AND %s`, rule.table, rule.where)
+		var count int64
+		if err := r.pool.QueryRow(ctx, query, orgID, cutoff).Scan(&count); err != nil {
+			return 0, nil, fmt.Errorf("failed to count expired %s: %w", category, err)
+		}
+		return count, nil, nil
+	}
+
+	ids, err := expiredIDs(ctx, r.pool, rule, orgID, cutoff, false)
+	if err != nil || len(ids) == 0 {
+		return 0, nil, err
+	}
+	cascaded := make(map[string]int64)
+	for _, c := range rule.cascade {
+		if c.set != "" {
+			continue
+		}
+		var n int64
+		if err := r.pool.QueryRow(ctx, c.count(), ids).Scan(&n); err != nil {
+			return 0, nil, fmt.Errorf("failed to count %s of expired %s: %w", c.table, category, err)
+		}
+		if n > 0 {
+			cascaded[c.table] += n
+		}
+	}
+	return int64(len(ids)), cascaded, nil
+}
+
+// expiredIDs selects the IDs of the organization's records that expire under
+// rule, locking them when lock is set
+func expiredIDs(ctx context.Context, db DBTX, rule retentionRule, orgID uuid.UUID, cutoff time.Time, lock bool) ([]uuid.UUID, error) {
+	query := fmt.Sprintf(`SELECT t.id FROM %s t WHERE t.organization_id = $1 AND %s`, rule.table, rule.where)
+	if lock {
+		query += " FOR UPDATE"
+	}
+	rows, err := db.Query(ctx, query, orgID, cutoff)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, pgx.RowTo[uuid.UUID])
+}
+
+// PurgeExpired deletes the organization's records of category older than
+// cutoff, with the rows that depend on them, in a single transaction
+func (r *RetentionRepository) PurgeExpired(ctx context.Context, orgID uuid.UUID, category string, cutoff time.Time) (*RetentionPurge, error) {
+	rule, err := lookupRetentionRule(category)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(rule.cascade) == 0 {
+		purge, err := r.purge(ctx, rule, orgID, cutoff)
+		if err != nil {
+			return nil, fmt.Errorf("failed to purge expired %s: %w", category, err)
+		}
+		return purge, nil
+	}
+
+	var purge *RetentionPurge
+	err = runInTx(ctx, r.pool, func(tx pgx.Tx) error {
+		purge = &RetentionPurge{Cascaded: make(map[string]int64)}
+		ids, err := expiredIDs(ctx, tx, rule, orgID, cutoff, true)
+		if err != nil || len(ids) == 0 {
+			return err
+		}
+
+		for _, c := range rule.cascade {
+			if c.returning != "" {
+				rows, err := tx.Query(ctx, c.exec(), ids)
+				if err != nil {
+					return err
+				}
+				files, err := pgx.CollectRows(rows, pgx.RowTo[string])
+				if err != nil {
+					return err
+				}
+				purge.Files = append(purge.Files, files...)
+				if len(files) > 0 {
+					purge.Cascaded[c.table] += int64(len(files))
+				}
+				continue
+			}
+			result, err := tx.Exec(ctx, c.exec(), ids)
+			if err != nil {
+				return err
+			}
+			if c.set == "" && result.RowsAffected() > 0 {
+				purge.Cascaded[c.table] += result.RowsAffected()
+			}
+		}
+
+		result, err := tx.Exec(ctx, fmt.Sprintf(`DELETE FROM %s WHERE id = ANY($1)`, rule.table), ids)
+		if err != nil {
+			return err
+		}
+		purge.Count = result.RowsAffected()
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to purge expired %s: %w", category, err)
+	}
+	return purge, nil
+}
+
+// purge deletes the records that expire under a rule without cascade
+func (r *RetentionRepository) purge(ctx context.Context, rule retentionRule, orgID uuid.UUID, cutoff time.Time) (*RetentionPurge, error) {
+	query := fmt.Sprintf(`DELETE FROM %s t WHERE t.organization_id = $1 AND %s`, rule.table, rule.where)
+	if rule.returning == "" {
+		result, err := r.pool.Exec(ctx, query, orgID, cutoff)
+		if err != nil {
+			return nil, err
+		}
+		return &RetentionPurge{Count: result.RowsAffected()}, nil
+	}
+
+	rows, err := r.pool.Query(ctx, query+" RETURNING t."+rule.returning, orgID, cutoff)
+	if err != nil {
+		return nil, err
+	}
+	files, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return nil, err
+	}
+	return &RetentionPurge{Count: int64(len(files)), Files: files}, nil
+}
+
+// CountExcessDocumentVersions counts the organization's document versions
+// beyond the newest keep of each document
+func (r *RetentionRepository) CountExcessDocumentVersions(ctx context.Context, orgID uuid.UUID, keep int) (int64, error) {
+	query := documentVersionsQuery + `SELECT COUNT(*) FROM versions WHERE depth > $2`
+
+	var count int64
+	if err := r.pool.QueryRow(ctx, query, orgID, keep).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count document versions: %w", err)
+	}
+	return count, nil
+}
+
+// PurgeExcessDocumentVersions deletes the organization's document versions
+// beyond the newest keep of each document and returns the paths of their
+// files. The oldest kept version becomes the first version.
+func (r *RetentionRepository) PurgeExcessDocumentVersions(ctx context.Context, orgID uuid.UUID, keep int) ([]string, error) {
+	var paths []string
+	err := runInTx(ctx, r.pool, func(tx pgx.Tx) error {
+		rows, err := tx.Query(ctx, documentVersionsQuery+`SELECT id FROM versions WHERE depth > $2`, orgID, keep)
+		if err != nil {
+			return err
+		}
+		ids, err := pgx.CollectRows(rows, pgx.RowTo[uuid.UUID])
+		if err != nil || len(ids) == 0 {
+			return err
+		}
+
+		unlink := `
+			UPDATE documents SET previous_version_id = NULL, updated_at = NOW()
+			WHERE previous_version_id = ANY($1) AND NOT (id = ANY($1))
+		`
+		if _, err := tx.Exec(ctx, unlink, ids); err != nil {
+			return err
+		}
+
+		rows, err = tx.Query(ctx, `DELETE FROM documents WHERE id = ANY($1) RETURNING file_path`, ids)
+		if err != nil {
+			return err
+		}
+		paths, err = pgx.CollectRows(rows, pgx.RowTo[string])
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to purge document versions: %w", err)
+	}
+	return paths, nil
+}
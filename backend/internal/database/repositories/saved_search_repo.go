@@ -0,0 +1,142 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/kubeatlas/kubeatlas/internal/models"
+)
+
+// SavedSearchRepository stores the saved searches of users
+type SavedSearchRepository struct {
+	*BaseRepository
+	pool DBTX
+}
+
+// NewSavedSearchRepository creates a new saved search repository
+func NewSavedSearchRepository(pool DBTX) *SavedSearchRepository {
+	return &SavedSearchRepository{
+		BaseRepository: NewBaseRepository(pool),
+		pool:           pool,
+	}
+}
+
+const savedSearchColumns = `
+	id, organization_id, user_id, team_id, name, entity, filters, sort, sort_order, created_at, updated_at
+`
+
+func scanSavedSearch(row pgx.Row, s *models.SavedSearch) error {
+	return row.Scan(
+		&s.ID, &s.OrganizationID, &s.UserID, &s.TeamID, &s.Name, &s.Entity, &s.Filters, &s.Sort, &s.Order, &s.CreatedAt, &s.UpdatedAt,
+	)
+}
+
+// Create creates a saved search
+func (r *SavedSearchRepository) Create(ctx context.Context, s *models.SavedSearch) error {
+	s.ID = uuid.New()
+	s.CreatedAt = time.Now()
+	s.UpdatedAt = s.CreatedAt
+
+	query := `
+		INSERT INTO saved_searches (
+			id, organization_id, user_id, team_id, name, entity, filters, sort, sort_order, created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+	`
+
+	_, err := r.pool.Exec(ctx, query,
+		s.ID, s.OrganizationID, s.UserID, s.TeamID, s.Name, s.Entity, s.Filters, s.Sort, s.Order, s.CreatedAt, s.UpdatedAt,
+	)
+	return err
+}
+
+// GetVisible retrieves a saved search the user owns or that is shared with
+// one of their teams. Returns nil when there is none.
+func (r *SavedSearchRepository) GetVisible(ctx context.Context, orgID, userID, id uuid.UUID) (*models.SavedSearch, error) {
+	query := `SELECT ` + savedSearchColumns + ` FROM saved_searches
+		WHERE id = $1 AND organization_id = $2
+		  AND (user_id = $3 OR team_id IN (SELECT team_id FROM team_members WHERE user_id = $3))`
+
+	s := &models.SavedSearch{}
+	err := scanSavedSearch(r.pool.QueryRow(ctx, query, id, orgID, userID), s)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// ExistsByName reports whether the user has another saved search named name
+func (r *SavedSearchRepository) ExistsByName(ctx context.Context, userID uuid.UUID, name string, exceptID uuid.UUID) (bool, error) {
+	var exists bool
+	err := r.pool.QueryRow(ctx,
+		`SELECT EXISTS (SELECT 1 FROM saved_searches WHERE user_id = $1 AND lower(name) = lower($2) AND id <> $3)`,
+		userID, name, exceptID,
+	).Scan(&exists)
+	return exists, err
+}
+
+// ListVisible returns the saved searches the user owns or that are shared
+// with their teams, of entity when it is set, by name
+func (r *SavedSearchRepository) ListVisible(ctx context.Context, orgID, userID uuid.UUID, entity string) ([]models.SavedSearch, error) {
+	query := `SELECT ` + savedSearchColumns + ` FROM saved_searches
+		WHERE organization_id = $1
+		  AND (user_id = $2 OR team_id IN (SELECT team_id FROM team_members WHERE user_id = $2))
+		  AND ($3 = '' OR entity = $3)
+		ORDER BY lower(name), created_at`
+
+	rows, err := r.reader().Query(ctx, query, orgID, userID, entity)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	searches := make([]models.SavedSearch, 0)
+	for rows.Next() {
+		var s models.SavedSearch
+		if err := scanSavedSearch(rows, &s); err != nil {
+			return nil, err
+		}
+		searches = append(searches, s)
+	}
+	return searches, rows.Err()
+}
+
+// Update updates a saved search
+func (r *SavedSearchRepository) Update(ctx context.Context, s *models.SavedSearch) error {
+	s.UpdatedAt = time.Now()
+
+	query := `
+		UPDATE saved_searches SET
+			team_id = $2, name = $3, entity = $4, filters = $5, sort = $6, sort_order = $7, updated_at = $8
+		WHERE id = $1
+	`
+
+	result, err := r.pool.Exec(ctx, query, s.ID, s.TeamID, s.Name, s.Entity, s.Filters, s.Sort, s.Order, s.UpdatedAt)
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+// Delete deletes a saved search
+func (r *SavedSearchRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	_, err := r.pool.Exec(ctx, `DELETE FROM saved_searches WHERE id = $1`, id)
+	return err
+}
+
+// IsTeamMember reports whether the user is a member of the team
+func (r *SavedSearchRepository) IsTeamMember(ctx context.Context, teamID, userID uuid.UUID) (bool, error) {
+	var member bool
+	err := r.pool.QueryRow(ctx,
+		`SELECT EXISTS (SELECT 1 FROM team_members WHERE team_id = $1 AND user_id = $2)`,
+		teamID, userID,
+	).Scan(&member)
+	return member, err
+}
@@ -0,0 +1,86 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/kubeatlas/kubeatlas/internal/models"
+)
+
+// SearchIndexRepository reads the searchable text of catalog records for an
+// external search index
+type SearchIndexRepository struct {
+	*BaseRepository
+	pool DBTX
+}
+
+// NewSearchIndexRepository creates a new search index repository
+func NewSearchIndexRepository(pool DBTX) *SearchIndexRepository {
+	return &SearchIndexRepository{
+		BaseRepository: NewBaseRepository(pool),
+		pool:           pool,
+	}
+}
+
+// searchDocumentQueries select the search documents of each type after the
+// ID $1, in ID order, at most $2
+var searchDocumentQueries = map[string]string{
+	models.SearchTypeNamespace: `
+		SELECT n.id, n.organization_id, n.name, COALESCE(n.display_name, ''),
+			COALESCE(n.description, ''), n.tags, n.updated_at
+		FROM namespaces n
+		WHERE n.deleted_at IS NULL AND n.id > $1
+		ORDER BY n.id
+		LIMIT $2`,
+	models.SearchTypeDocument: `
+		SELECT d.id, d.organization_id, d.name, d.file_name,
+			COALESCE(d.description, ''), d.tags, d.updated_at
+		FROM documents d
+		WHERE d.deleted_at IS NULL AND d.id > $1
+		ORDER BY d.id
+		LIMIT $2`,
+	models.SearchTypeInternalDependency: `
+		SELECT dep.id, dep.organization_id, src.name || ' → ' || tgt.name, '',
+			COALESCE(dep.description, ''), ARRAY[dep.dependency_type], dep.updated_at
+		FROM internal_dependencies dep
+		JOIN namespaces src ON src.id = dep.source_namespace_id
+		JOIN namespaces tgt ON tgt.id = dep.target_namespace_id
+		WHERE dep.deleted_at IS NULL AND dep.id > $1
+		ORDER BY dep.id
+		LIMIT $2`,
+	models.SearchTypeExternalDependency: `
+		SELECT e.id, e.organization_id, e.name, COALESCE(e.provider, ''),
+			COALESCE(e.description, ''), ARRAY[e.system_type], e.updated_at
+		FROM external_dependencies e
+		WHERE e.deleted_at IS NULL AND e.id > $1
+		ORDER BY e.id
+		LIMIT $2`,
+}
+
+// ListDocuments returns up to limit search documents of a type, of all
+// organizations, with IDs after afterID in ID order; uuid.Nil starts from the
+// first. Dependencies are named after what they connect and tagged with
+// their type.
+func (r *SearchIndexRepository) ListDocuments(ctx context.Context, docType string, afterID uuid.UUID, limit int) ([]models.SearchDocument, error) {
+	query, ok := searchDocumentQueries[docType]
+	if !ok {
+		return nil, fmt.Errorf("unknown search document type %q", docType)
+	}
+
+	rows, err := r.reader().Query(ctx, query, afterID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %s search documents: %w", docType, err)
+	}
+	defer rows.Close()
+
+	docs := make([]models.SearchDocument, 0)
+	for rows.Next() {
+		doc := models.SearchDocument{Type: docType}
+		if err := rows.Scan(&doc.ID, &doc.OrganizationID, &doc.Name, &doc.DisplayName, &doc.Description, &doc.Tags, &doc.UpdatedAt); err != nil {
+			return nil, err
+		}
+		docs = append(docs, doc)
+	}
+	return docs, rows.Err()
+}
@@ -0,0 +1,76 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/kubeatlas/kubeatlas/internal/models"
+)
+
+// SuggestionRepository finds names for search autocomplete
+type SuggestionRepository struct {
+	*BaseRepository
+	pool DBTX
+}
+
+// NewSuggestionRepository creates a new suggestion repository
+func NewSuggestionRepository(pool DBTX) *SuggestionRepository {
+	return &SuggestionRepository{
+		BaseRepository: NewBaseRepository(pool),
+		pool:           pool,
+	}
+}
+
+// List returns up to limit names of the organization's namespaces, teams,
+// clusters and tags starting with prefix, ignoring case: an exact match
+// first, then shorter names first. Namespaces and clusters are suggested by
+// their name when their display name matches.
+func (r *SuggestionRepository) List(ctx context.Context, orgID uuid.UUID, prefix string, limit int) ([]models.Suggestion, error) {
+	query := `
+		SELECT type, id, name FROM (
+			SELECT 'namespace' AS type, n.id, n.name
+			FROM namespaces n
+			WHERE n.organization_id = $1 AND n.deleted_at IS NULL
+				AND (n.name ILIKE $2 OR n.display_name ILIKE $2)
+			UNION ALL
+			SELECT 'team', t.id, t.name
+			FROM teams t
+			WHERE t.organization_id = $1 AND t.deleted_at IS NULL AND t.name ILIKE $2
+			UNION ALL
+			SELECT 'cluster', c.id, c.name
+			FROM clusters c
+			WHERE c.organization_id = $1 AND c.deleted_at IS NULL
+				AND (c.name ILIKE $2 OR c.display_name ILIKE $2)
+			UNION ALL
+			SELECT DISTINCT 'tag', NULL::uuid, tag
+			FROM (
+				SELECT unnest(tags) AS tag FROM namespaces WHERE organization_id = $1 AND deleted_at IS NULL
+				UNION ALL
+				SELECT unnest(tags) FROM clusters WHERE organization_id = $1 AND deleted_at IS NULL
+				UNION ALL
+				SELECT unnest(tags) FROM documents WHERE organization_id = $1 AND deleted_at IS NULL
+			) tags
+			WHERE tag ILIKE $2
+		) suggestions
+		ORDER BY lower(name) = lower($3) DESC, length(name), name, type
+		LIMIT $4
+	`
+
+	rows, err := r.reader().Query(ctx, query, orgID, strings.TrimPrefix(searchPattern(prefix), "%"), prefix, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list suggestions: %w", err)
+	}
+	defer rows.Close()
+
+	suggestions := make([]models.Suggestion, 0)
+	for rows.Next() {
+		var s models.Suggestion
+		if err := rows.Scan(&s.Type, &s.ID, &s.Name); err != nil {
+			return nil, err
+		}
+		suggestions = append(suggestions, s)
+	}
+	return suggestions, rows.Err()
+}
@@ -0,0 +1,108 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/kubeatlas/kubeatlas/internal/models"
+)
+
+// TagRepository reads and rewrites the tags of namespaces, clusters and
+// documents
+type TagRepository struct {
+	*BaseRepository
+	pool DBTX
+}
+
+// NewTagRepository creates a new tag repository
+func NewTagRepository(pool DBTX) *TagRepository {
+	return &TagRepository{
+		BaseRepository: NewBaseRepository(pool),
+		pool:           pool,
+	}
+}
+
+// tagTables are the tables with a tags column, in the order of the counts
+// of TagUsage and TagMerge
+var tagTables = []string{"namespaces", "clusters", "documents"}
+
+// List returns the tags of the organization's records with their usage,
+// most used first. When term is set only tags containing it are returned,
+// those starting with it first.
+func (r *TagRepository) List(ctx context.Context, orgID uuid.UUID, term string, limit int) ([]models.TagUsage, error) {
+	selects := make([]string, len(tagTables))
+	for i, table := range tagTables {
+		selects[i] = fmt.Sprintf(`
+			SELECT DISTINCT '%s' AS kind, t.id, tag
+			FROM %s t, unnest(t.tags) AS tag
+			WHERE t.organization_id = $1 AND t.deleted_at IS NULL`, table, table)
+	}
+
+	query := `
+		SELECT tag,
+			COUNT(*) FILTER (WHERE kind = 'namespaces'),
+			COUNT(*) FILTER (WHERE kind = 'clusters'),
+			COUNT(*) FILTER (WHERE kind = 'documents'),
+			COUNT(*)
+		FROM (` + strings.Join(selects, " UNION ALL ") + `) tagged
+		WHERE $2 = '' OR tag ILIKE $3
+		GROUP BY tag
+		ORDER BY ($2 <> '' AND tag ILIKE $4) DESC, COUNT(*) DESC, tag
+		LIMIT $5
+	`
+
+	pattern := searchPattern(term)
+	rows, err := r.reader().Query(ctx, query, orgID, term, pattern, strings.TrimPrefix(pattern, "%"), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tags := make([]models.TagUsage, 0)
+	for rows.Next() {
+		var t models.TagUsage
+		if err := rows.Scan(&t.Tag, &t.Namespaces, &t.Clusters, &t.Documents, &t.Total); err != nil {
+			return nil, err
+		}
+		tags = append(tags, t)
+	}
+	return tags, rows.Err()
+}
+
+// Merge replaces the tags from with into on every record of the organization,
+// deleted ones included so restoring them does not bring the old tags back.
+// A record ends up with into once, where the first of its tags was. Returns
+// the number of records changed per table of tagTables.
+func (r *TagRepository) Merge(ctx context.Context, orgID uuid.UUID, from []string, into string) ([]int64, error) {
+	counts := make([]int64, len(tagTables))
+	err := runInTx(ctx, r.pool, func(tx pgx.Tx) error {
+		for i, table := range tagTables {
+			result, err := tx.Exec(ctx, fmt.Sprintf(`
+				UPDATE %s SET
+					tags = ARRAY(
+						SELECT tag FROM (
+							SELECT CASE WHEN t = ANY($2::text[]) THEN $3::text ELSE t END AS tag, MIN(ord) AS first
+							FROM unnest(tags) WITH ORDINALITY AS u(t, ord)
+							GROUP BY 1
+						) merged
+						ORDER BY first
+					),
+					updated_at = NOW()
+				WHERE organization_id = $1 AND tags && $2::text[]`, table),
+				orgID, from, into,
+			)
+			if err != nil {
+				return fmt.Errorf("failed to merge tags of %s: %w", table, err)
+			}
+			counts[i] = result.RowsAffected()
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return counts, nil
+}
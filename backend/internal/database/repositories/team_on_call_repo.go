@@ -0,0 +1,102 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/kubeatlas/kubeatlas/internal/models"
+)
+
+// TeamOnCallRepository handles the on-call rotations of teams
+type TeamOnCallRepository struct {
+	*BaseRepository
+	pool DBTX
+}
+
+// NewTeamOnCallRepository creates a new team on-call repository
+func NewTeamOnCallRepository(pool DBTX) *TeamOnCallRepository {
+	return &TeamOnCallRepository{
+		BaseRepository: NewBaseRepository(pool),
+		pool:           pool,
+	}
+}
+
+// Get returns the on-call rotation of a team, or nil when none was set up
+// or refreshed yet
+func (r *TeamOnCallRepository) Get(ctx context.Context, teamID uuid.UUID) (*models.TeamOnCall, error) {
+	o := &models.TeamOnCall{}
+	err := r.reader().QueryRow(ctx, `
+		SELECT team_id, rotation_url, escalation_contacts, current_on_call, refreshed_at, refresh_error
+		FROM team_on_call
+		WHERE team_id = $1`, teamID,
+	).Scan(&o.TeamID, &o.RotationURL, &o.EscalationContacts, &o.Current, &o.RefreshedAt, &o.RefreshError)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+// SaveRotation stores the rotation URL and escalation contacts of a team,
+// keeping who is on call
+func (r *TeamOnCallRepository) SaveRotation(ctx context.Context, orgID uuid.UUID, o *models.TeamOnCall) error {
+	if o.EscalationContacts == nil {
+		o.EscalationContacts = []models.EscalationContact{}
+	}
+	_, err := r.pool.Exec(ctx, `
+		INSERT INTO team_on_call (team_id, organization_id, rotation_url, escalation_contacts, updated_at)
+		VALUES ($1, $2, $3, $4, NOW())
+		ON CONFLICT (team_id) DO UPDATE SET
+			rotation_url = EXCLUDED.rotation_url,
+			escalation_contacts = EXCLUDED.escalation_contacts,
+			updated_at = NOW()`,
+		o.TeamID, orgID, o.RotationURL, o.EscalationContacts,
+	)
+	return err
+}
+
+// SaveCurrent stores who is on call for a team as just asked. With a
+// refreshErr, the responders stored before are kept and the error recorded.
+func (r *TeamOnCallRepository) SaveCurrent(ctx context.Context, orgID, teamID uuid.UUID, current []models.OnCallResponder, refreshErr string) error {
+	if current == nil {
+		current = []models.OnCallResponder{}
+	}
+	_, err := r.pool.Exec(ctx, `
+		INSERT INTO team_on_call (team_id, organization_id, current_on_call, refreshed_at, refresh_error)
+		VALUES ($1, $2, $3, NOW(), $4)
+		ON CONFLICT (team_id) DO UPDATE SET
+			current_on_call = CASE WHEN EXCLUDED.refresh_error = '' THEN EXCLUDED.current_on_call ELSE team_on_call.current_on_call END,
+			refreshed_at = CASE WHEN EXCLUDED.refresh_error = '' THEN EXCLUDED.refreshed_at ELSE team_on_call.refreshed_at END,
+			refresh_error = EXCLUDED.refresh_error`,
+		teamID, orgID, current, refreshErr,
+	)
+	return err
+}
+
+// ListLinkedTeams returns the teams of all organizations linked to a
+// PagerDuty service or an Opsgenie schedule, with those links
+func (r *TeamOnCallRepository) ListLinkedTeams(ctx context.Context) ([]models.Team, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT id, organization_id, name, pagerduty_service_id, opsgenie_schedule_id
+		FROM teams
+		WHERE deleted_at IS NULL
+			AND (COALESCE(pagerduty_service_id, '') <> '' OR COALESCE(opsgenie_schedule_id, '') <> '')
+		ORDER BY organization_id, name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	teams := make([]models.Team, 0)
+	for rows.Next() {
+		var t models.Team
+		if err := rows.Scan(&t.ID, &t.OrganizationID, &t.Name, &t.PagerDutyServiceID, &t.OpsgenieScheduleID); err != nil {
+			return nil, err
+		}
+		teams = append(teams, t)
+	}
+	return teams, rows.Err()
+}
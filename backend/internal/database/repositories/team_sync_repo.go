@@ -0,0 +1,266 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/kubeatlas/kubeatlas/internal/models"
+)
+
+// TeamSyncRepository handles the directory groups that make up teams and
+// the membership changes their synchronization makes
+type TeamSyncRepository struct {
+	*BaseRepository
+	pool DBTX
+}
+
+// NewTeamSyncRepository creates a new team sync repository
+func NewTeamSyncRepository(pool DBTX) *TeamSyncRepository {
+	return &TeamSyncRepository{
+		BaseRepository: NewBaseRepository(pool),
+		pool:           pool,
+	}
+}
+
+// TeamDirectoryRoster is the roster of a team according to its directory
+// groups
+type TeamDirectoryRoster struct {
+	TeamID uuid.UUID
+	// Complete reports whether the members of all of the team's groups are
+	// known; incomplete rosters are not reconciled
+	Complete bool
+	// Members are the lowercase email addresses in any of the groups
+	Members []string
+}
+
+// ListGroups returns the directory groups mapped to a team
+func (r *TeamSyncRepository) ListGroups(ctx context.Context, teamID uuid.UUID) ([]models.TeamDirectoryGroup, error) {
+	rows, err := r.reader().Query(ctx, `
+		SELECT team_id, group_name, created_at
+		FROM team_directory_groups
+		WHERE team_id = $1
+		ORDER BY group_name`, teamID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	groups := make([]models.TeamDirectoryGroup, 0)
+	for rows.Next() {
+		var g models.TeamDirectoryGroup
+		if err := rows.Scan(&g.TeamID, &g.GroupName, &g.CreatedAt); err != nil {
+			return nil, err
+		}
+		groups = append(groups, g)
+	}
+	return groups, rows.Err()
+}
+
+// ReplaceGroups maps exactly the given directory groups to a team
+func (r *TeamSyncRepository) ReplaceGroups(ctx context.Context, orgID, teamID uuid.UUID, groups []string) error {
+	return runInTx(ctx, r.pool, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, `DELETE FROM team_directory_groups WHERE team_id = $1`, teamID); err != nil {
+			return fmt.Errorf("failed to delete team directory groups: %w", err)
+		}
+		_, err := tx.Exec(ctx, `
+			INSERT INTO team_directory_groups (team_id, organization_id, group_name)
+			SELECT $1, $2, g FROM unnest($3::text[]) AS g`,
+			teamID, orgID, groups,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to save team directory groups: %w", err)
+		}
+		return nil
+	})
+}
+
+// ListMappedOrganizationIDs returns the organizations with teams made up of
+// directory groups
+func (r *TeamSyncRepository) ListMappedOrganizationIDs(ctx context.Context) ([]uuid.UUID, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT DISTINCT tdg.organization_id
+		FROM team_directory_groups tdg
+		JOIN teams t ON t.id = tdg.team_id AND t.deleted_at IS NULL`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := make([]uuid.UUID, 0)
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// ListMappedGroupNames returns the lowercase names of the directory groups
+// mapped to the organization's teams
+func (r *TeamSyncRepository) ListMappedGroupNames(ctx context.Context, orgID uuid.UUID) ([]string, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT DISTINCT lower(tdg.group_name)
+		FROM team_directory_groups tdg
+		JOIN teams t ON t.id = tdg.team_id AND t.deleted_at IS NULL
+		WHERE tdg.organization_id = $1
+		ORDER BY 1`, orgID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	names := make([]string, 0)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+	}
+	return names, rows.Err()
+}
+
+// SaveGroup stores the members of a directory group, replacing those seen
+// before
+func (r *TeamSyncRepository) SaveGroup(ctx context.Context, g *models.DirectoryGroup) error {
+	g.Name = strings.ToLower(g.Name)
+	g.SyncedAt = time.Now()
+	_, err := r.pool.Exec(ctx, `
+		INSERT INTO directory_groups (organization_id, group_name, members, source, synced_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (organization_id, group_name) DO UPDATE SET
+			members = EXCLUDED.members, source = EXCLUDED.source, synced_at = EXCLUDED.synced_at`,
+		g.OrganizationID, g.Name, g.Members, g.Source, g.SyncedAt,
+	)
+	return err
+}
+
+// ListRosters returns the rosters of the organization's teams made up of
+// directory groups, or with group set only of the teams the group is
+// mapped to
+func (r *TeamSyncRepository) ListRosters(ctx context.Context, orgID uuid.UUID, group string) ([]TeamDirectoryRoster, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT
+			tdg.team_id,
+			bool_and(dg.group_name IS NOT NULL) AS complete,
+			COALESCE(array_agg(DISTINCT m.email) FILTER (WHERE m.email IS NOT NULL), '{}') AS members
+		FROM team_directory_groups tdg
+		JOIN teams t ON t.id = tdg.team_id AND t.deleted_at IS NULL
+		LEFT JOIN directory_groups dg
+			ON dg.organization_id = tdg.organization_id AND dg.group_name = lower(tdg.group_name)
+		LEFT JOIN LATERAL unnest(dg.members) AS m(email) ON true
+		WHERE tdg.organization_id = $1
+		GROUP BY tdg.team_id
+		HAVING $2 = '' OR bool_or(lower(tdg.group_name) = lower($2))`,
+		orgID, group,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rosters := make([]TeamDirectoryRoster, 0)
+	for rows.Next() {
+		var roster TeamDirectoryRoster
+		if err := rows.Scan(&roster.TeamID, &roster.Complete, &roster.Members); err != nil {
+			return nil, err
+		}
+		rosters = append(rosters, roster)
+	}
+	return rosters, rows.Err()
+}
+
+// ListUserIDsByEmail returns the active users of the organization with the
+// given lowercase email addresses, keyed by lowercase email address
+func (r *TeamSyncRepository) ListUserIDsByEmail(ctx context.Context, orgID uuid.UUID, emails []string) (map[string]uuid.UUID, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT lower(email), id
+		FROM users
+		WHERE organization_id = $1 AND lower(email) = ANY($2) AND deleted_at IS NULL AND is_active = true`,
+		orgID, emails,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := make(map[string]uuid.UUID)
+	for rows.Next() {
+		var email string
+		var id uuid.UUID
+		if err := rows.Scan(&email, &id); err != nil {
+			return nil, err
+		}
+		users[email] = id
+	}
+	return users, rows.Err()
+}
+
+// ApplyChanges adds and removes the team members of changes and records the
+// changes in the team's changelog
+func (r *TeamSyncRepository) ApplyChanges(ctx context.Context, changes []models.TeamMembershipChange) error {
+	if len(changes) == 0 {
+		return nil
+	}
+	return runInTx(ctx, r.pool, func(tx pgx.Tx) error {
+		for i := range changes {
+			c := &changes[i]
+			c.ID = uuid.New()
+			c.CreatedAt = time.Now()
+			var err error
+			switch c.Change {
+			case models.TeamMembershipJoined:
+				_, err = tx.Exec(ctx, `
+					INSERT INTO team_members (id, team_id, user_id, role, joined_at)
+					VALUES ($1, $2, $3, 'member', $4)
+					ON CONFLICT (team_id, user_id) DO NOTHING`,
+					uuid.New(), c.TeamID, c.UserID, c.CreatedAt,
+				)
+			case models.TeamMembershipRemoved:
+				_, err = tx.Exec(ctx, `DELETE FROM team_members WHERE team_id = $1 AND user_id = $2`, c.TeamID, c.UserID)
+			}
+			if err != nil {
+				return fmt.Errorf("failed to apply team membership change: %w", err)
+			}
+			_, err = tx.Exec(ctx, `
+				INSERT INTO team_membership_changes (id, organization_id, team_id, user_id, email, change, source, created_at)
+				VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+				c.ID, c.OrganizationID, c.TeamID, c.UserID, c.Email, c.Change, c.Source, c.CreatedAt,
+			)
+			if err != nil {
+				return fmt.Errorf("failed to record team membership change: %w", err)
+			}
+		}
+		return nil
+	})
+}
+
+// ListChanges returns up to limit membership changes of a team, newest first
+func (r *TeamSyncRepository) ListChanges(ctx context.Context, teamID uuid.UUID, limit int) ([]models.TeamMembershipChange, error) {
+	rows, err := r.reader().Query(ctx, `
+		SELECT id, organization_id, team_id, user_id, email, change, source, created_at
+		FROM team_membership_changes
+		WHERE team_id = $1
+		ORDER BY created_at DESC
+		LIMIT $2`, teamID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	changes := make([]models.TeamMembershipChange, 0)
+	for rows.Next() {
+		var c models.TeamMembershipChange
+		if err := rows.Scan(&c.ID, &c.OrganizationID, &c.TeamID, &c.UserID, &c.Email, &c.Change, &c.Source, &c.CreatedAt); err != nil {
+			return nil, err
+		}
+		changes = append(changes, c)
+	}
+	return changes, rows.Err()
+}
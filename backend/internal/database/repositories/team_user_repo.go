@@ -6,7 +6,6 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
-	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/kubeatlas/kubeatlas/internal/models"
 	"golang.org/x/crypto/bcrypt"
 )
@@ -18,11 +17,11 @@ import (
 // TeamRepository handles team database operations
 type TeamRepository struct {
 	*BaseRepository
-	pool *pgxpool.Pool
+	pool DBTX
 }
 
 // NewTeamRepository creates a new team repository
-func NewTeamRepository(pool *pgxpool.Pool) *TeamRepository {
+func NewTeamRepository(pool DBTX) *TeamRepository {
 	return &TeamRepository{
 		BaseRepository: NewBaseRepository(pool),
 		pool:           pool,
@@ -38,14 +37,14 @@ func (r *TeamRepository) Create(ctx context.Context, team *models.Team) error {
 	query := `
 		INSERT INTO teams (
 			id, organization_id, name, slug, description,
-			parent_id, team_type, contact_email, contact_slack, metadata,
+			parent_id, team_type, contact_email, contact_slack, pagerduty_service_id, opsgenie_schedule_id, metadata,
 			created_at, updated_at
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
 	`
 
 	_, err := r.pool.Exec(ctx, query,
 		team.ID, team.OrganizationID, team.Name, team.Slug, team.Description,
-		team.ParentID, team.TeamType, team.ContactEmail, team.ContactSlack, team.Metadata,
+		team.ParentID, team.TeamType, team.ContactEmail, team.ContactSlack, team.PagerDutyServiceID, team.OpsgenieScheduleID, team.Metadata,
 		team.CreatedAt, team.UpdatedAt,
 	)
 
@@ -57,7 +56,7 @@ func (r *TeamRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Tea
 	query := `
 		SELECT 
 			id, organization_id, name, slug, description,
-			parent_id, team_type, contact_email, contact_slack, metadata,
+			parent_id, team_type, contact_email, contact_slack, pagerduty_service_id, opsgenie_schedule_id, metadata,
 			created_at, updated_at
 		FROM teams
 		WHERE id = $1 AND deleted_at IS NULL
@@ -66,7 +65,7 @@ func (r *TeamRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Tea
 	team := &models.Team{}
 	err := r.pool.QueryRow(ctx, query, id).Scan(
 		&team.ID, &team.OrganizationID, &team.Name, &team.Slug, &team.Description,
-		&team.ParentID, &team.TeamType, &team.ContactEmail, &team.ContactSlack, &team.Metadata,
+		&team.ParentID, &team.TeamType, &team.ContactEmail, &team.ContactSlack, &team.PagerDutyServiceID, &team.OpsgenieScheduleID, &team.Metadata,
 		&team.CreatedAt, &team.UpdatedAt,
 	)
 
@@ -84,12 +83,77 @@ func (r *TeamRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Tea
 	return team, nil
 }
 
+// GetByName retrieves a team of an organization by name. Team names are not
+// unique, so the oldest team with the name is returned.
+func (r *TeamRepository) GetByName(ctx context.Context, orgID uuid.UUID, name string) (*models.Team, error) {
+	query := `
+		SELECT 
+			id, organization_id, name, slug, description,
+			parent_id, team_type, contact_email, contact_slack, pagerduty_service_id, opsgenie_schedule_id, metadata,
+			created_at, updated_at
+		FROM teams
+		WHERE organization_id = $1 AND name = $2 AND deleted_at IS NULL
+		ORDER BY created_at ASC
+		LIMIT 1
+	`
+
+	team := &models.Team{}
+	err := r.pool.QueryRow(ctx, query, orgID, name).Scan(
+		&team.ID, &team.OrganizationID, &team.Name, &team.Slug, &team.Description,
+		&team.ParentID, &team.TeamType, &team.ContactEmail, &team.ContactSlack, &team.PagerDutyServiceID, &team.OpsgenieScheduleID, &team.Metadata,
+		&team.CreatedAt, &team.UpdatedAt,
+	)
+
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	countQuery := `SELECT COUNT(*) FROM team_members WHERE team_id = $1`
+	r.pool.QueryRow(ctx, countQuery, team.ID).Scan(&team.MemberCount)
+
+	return team, nil
+}
+
+// GetBySlug retrieves a team of an organization by slug
+func (r *TeamRepository) GetBySlug(ctx context.Context, orgID uuid.UUID, slug string) (*models.Team, error) {
+	query := `
+		SELECT 
+			id, organization_id, name, slug, description,
+			parent_id, team_type, contact_email, contact_slack, pagerduty_service_id, opsgenie_schedule_id, metadata,
+			created_at, updated_at
+		FROM teams
+		WHERE organization_id = $1 AND slug = $2 AND deleted_at IS NULL
+	`
+
+	team := &models.Team{}
+	err := r.pool.QueryRow(ctx, query, orgID, slug).Scan(
+		&team.ID, &team.OrganizationID, &team.Name, &team.Slug, &team.Description,
+		&team.ParentID, &team.TeamType, &team.ContactEmail, &team.ContactSlack, &team.PagerDutyServiceID, &team.OpsgenieScheduleID, &team.Metadata,
+		&team.CreatedAt, &team.UpdatedAt,
+	)
+
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	countQuery := `SELECT COUNT(*) FROM team_members WHERE team_id = $1`
+	r.pool.QueryRow(ctx, countQuery, team.ID).Scan(&team.MemberCount)
+
+	return team, nil
+}
+
 // List retrieves all teams for an organization
 func (r *TeamRepository) List(ctx context.Context, orgID uuid.UUID) ([]models.Team, error) {
 	query := `
 		SELECT 
 			t.id, t.organization_id, t.name, t.slug, t.description,
-			t.parent_id, t.team_type, t.contact_email, t.contact_slack, t.metadata,
+			t.parent_id, t.team_type, t.contact_email, t.contact_slack, t.pagerduty_service_id, t.opsgenie_schedule_id, t.metadata,
 			t.created_at, t.updated_at,
 			(SELECT COUNT(*) FROM team_members tm WHERE tm.team_id = t.id) as member_count
 		FROM teams t
@@ -97,7 +161,7 @@ func (r *TeamRepository) List(ctx context.Context, orgID uuid.UUID) ([]models.Te
 		ORDER BY t.name ASC
 	`
 
-	rows, err := r.pool.Query(ctx, query, orgID)
+	rows, err := r.reader().Query(ctx, query, orgID)
 	if err != nil {
 		return nil, err
 	}
@@ -108,7 +172,7 @@ func (r *TeamRepository) List(ctx context.Context, orgID uuid.UUID) ([]models.Te
 		var t models.Team
 		err := rows.Scan(
 			&t.ID, &t.OrganizationID, &t.Name, &t.Slug, &t.Description,
-			&t.ParentID, &t.TeamType, &t.ContactEmail, &t.ContactSlack, &t.Metadata,
+			&t.ParentID, &t.TeamType, &t.ContactEmail, &t.ContactSlack, &t.PagerDutyServiceID, &t.OpsgenieScheduleID, &t.Metadata,
 			&t.CreatedAt, &t.UpdatedAt, &t.MemberCount,
 		)
 		if err != nil {
@@ -120,6 +184,66 @@ func (r *TeamRepository) List(ctx context.Context, orgID uuid.UUID) ([]models.Te
 	return teams, nil
 }
 
+// NamesByID returns the names of the organization's teams among ids by ID,
+// including deleted teams
+func (r *TeamRepository) NamesByID(ctx context.Context, orgID uuid.UUID, ids []uuid.UUID) (map[uuid.UUID]string, error) {
+	rows, err := r.reader().Query(ctx,
+		`SELECT id, name FROM teams WHERE organization_id = $1 AND id = ANY($2)`, orgID, ids)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	names := make(map[uuid.UUID]string, len(ids))
+	for rows.Next() {
+		var id uuid.UUID
+		var name string
+		if err := rows.Scan(&id, &name); err != nil {
+			return nil, err
+		}
+		names[id] = name
+	}
+	return names, rows.Err()
+}
+
+// Search returns the teams of an organization whose name or slug contains
+// term, best matches first. A fuzzyThreshold above 0 also returns teams
+// whose name is that similar to term, so typos still find them.
+func (r *TeamRepository) Search(ctx context.Context, orgID uuid.UUID, term string, fuzzyThreshold float64) ([]models.Team, error) {
+	query := `
+		SELECT 
+			t.id, t.organization_id, t.name, t.slug, t.description,
+			t.parent_id, t.team_type, t.contact_email, t.contact_slack, t.pagerduty_service_id, t.opsgenie_schedule_id, t.metadata,
+			t.created_at, t.updated_at,
+			(SELECT COUNT(*) FROM team_members tm WHERE tm.team_id = t.id) as member_count
+		FROM teams t
+		WHERE t.organization_id = $1 AND t.deleted_at IS NULL
+			AND (t.name ILIKE $2 OR t.slug ILIKE $2 OR ($4 > 0 AND word_similarity($3, t.name) >= $4))
+		ORDER BY similarity(t.name, $3) DESC, t.name ASC
+	`
+
+	rows, err := r.reader().Query(ctx, query, orgID, searchPattern(term), term, fuzzyThreshold)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	teams := make([]models.Team, 0)
+	for rows.Next() {
+		var t models.Team
+		err := rows.Scan(
+			&t.ID, &t.OrganizationID, &t.Name, &t.Slug, &t.Description,
+			&t.ParentID, &t.TeamType, &t.ContactEmail, &t.ContactSlack, &t.PagerDutyServiceID, &t.OpsgenieScheduleID, &t.Metadata,
+			&t.CreatedAt, &t.UpdatedAt, &t.MemberCount,
+		)
+		if err != nil {
+			return nil, err
+		}
+		teams = append(teams, t)
+	}
+	return teams, rows.Err()
+}
+
 // Update updates a team
 func (r *TeamRepository) Update(ctx context.Context, team *models.Team) error {
 	team.UpdatedAt = time.Now()
@@ -128,15 +252,15 @@ func (r *TeamRepository) Update(ctx context.Context, team *models.Team) error {
 		UPDATE teams SET
 			name = $2, slug = $3, description = $4,
 			parent_id = $5, team_type = $6,
-			contact_email = $7, contact_slack = $8, metadata = $9,
-			updated_at = $10
+			contact_email = $7, contact_slack = $8, pagerduty_service_id = $9, opsgenie_schedule_id = $10, metadata = $11,
+			updated_at = $12
 		WHERE id = $1 AND deleted_at IS NULL
 	`
 
 	result, err := r.pool.Exec(ctx, query,
 		team.ID, team.Name, team.Slug, team.Description,
 		team.ParentID, team.TeamType,
-		team.ContactEmail, team.ContactSlack, team.Metadata,
+		team.ContactEmail, team.ContactSlack, team.PagerDutyServiceID, team.OpsgenieScheduleID, team.Metadata,
 		team.UpdatedAt,
 	)
 
@@ -210,6 +334,64 @@ func (r *TeamRepository) GetMembers(ctx context.Context, teamID uuid.UUID) ([]mo
 	return members, nil
 }
 
+// ListLeadEmails returns the email addresses of the active leads of every
+// team in the organization, keyed by team
+func (r *TeamRepository) ListLeadEmails(ctx context.Context, orgID uuid.UUID) (map[uuid.UUID][]string, error) {
+	query := `
+		SELECT tm.team_id, u.email
+		FROM team_members tm
+		JOIN teams t ON t.id = tm.team_id AND t.deleted_at IS NULL
+		JOIN users u ON u.id = tm.user_id AND u.deleted_at IS NULL AND u.is_active = true
+		WHERE t.organization_id = $1 AND tm.role = 'lead'
+		ORDER BY u.email
+	`
+
+	rows, err := r.reader().Query(ctx, query, orgID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	leads := make(map[uuid.UUID][]string)
+	for rows.Next() {
+		var teamID uuid.UUID
+		var email string
+		if err := rows.Scan(&teamID, &email); err != nil {
+			return nil, err
+		}
+		leads[teamID] = append(leads[teamID], email)
+	}
+	return leads, rows.Err()
+}
+
+// ListMemberIDs returns the users belonging to every team in the
+// organization, keyed by team
+func (r *TeamRepository) ListMemberIDs(ctx context.Context, orgID uuid.UUID) (map[uuid.UUID][]uuid.UUID, error) {
+	query := `
+		SELECT tm.team_id, tm.user_id
+		FROM team_members tm
+		JOIN teams t ON t.id = tm.team_id AND t.deleted_at IS NULL
+		JOIN users u ON u.id = tm.user_id AND u.deleted_at IS NULL
+		WHERE t.organization_id = $1
+	`
+
+	rows, err := r.reader().Query(ctx, query, orgID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	members := make(map[uuid.UUID][]uuid.UUID)
+	for rows.Next() {
+		var teamID, userID uuid.UUID
+		if err := rows.Scan(&teamID, &userID); err != nil {
+			return nil, err
+		}
+		members[teamID] = append(members[teamID], userID)
+	}
+	return members, rows.Err()
+}
+
 // ============================================
 // User Repository
 // ============================================
@@ -217,11 +399,11 @@ func (r *TeamRepository) GetMembers(ctx context.Context, teamID uuid.UUID) ([]mo
 // UserRepository handles user database operations
 type UserRepository struct {
 	*BaseRepository
-	pool *pgxpool.Pool
+	pool DBTX
 }
 
 // NewUserRepository creates a new user repository
-func NewUserRepository(pool *pgxpool.Pool) *UserRepository {
+func NewUserRepository(pool DBTX) *UserRepository {
 	return &UserRepository{
 		BaseRepository: NewBaseRepository(pool),
 		pool:           pool,
@@ -250,7 +432,7 @@ func (r *UserRepository) Create(ctx context.Context, user *models.User, password
 			id, organization_id, email, username, full_name,
 			avatar_url, phone, password_hash, role, is_active,
 			settings, created_at, updated_at
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
 	`
 
 	_, err := r.pool.Exec(ctx, query,
@@ -340,13 +522,13 @@ func (r *UserRepository) List(ctx context.Context, orgID uuid.UUID, p Pagination
 	// Count
 	countQuery, countArgs := qb.BuildCount()
 	var total int64
-	if err := r.pool.QueryRow(ctx, countQuery, countArgs...).Scan(&total); err != nil {
+	if err := r.reader().QueryRow(ctx, countQuery, countArgs...).Scan(&total); err != nil {
 		return nil, err
 	}
 
 	// Data
 	query, args := qb.Build()
-	rows, err := r.pool.Query(ctx, query, args...)
+	rows, err := r.reader().Query(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -427,6 +609,22 @@ func (r *UserRepository) UpdateLastLogin(ctx context.Context, id uuid.UUID) erro
 	return err
 }
 
+// IsActiveMember reports whether the user is active and belongs to the
+// organization, and neither has been deleted
+func (r *UserRepository) IsActiveMember(ctx context.Context, userID, orgID uuid.UUID) (bool, error) {
+	query := `
+		SELECT EXISTS(
+			SELECT 1 FROM users u
+			JOIN organizations o ON o.id = u.organization_id
+			WHERE u.id = $1 AND o.id = $2
+				AND u.is_active AND u.deleted_at IS NULL AND o.deleted_at IS NULL
+		)
+	`
+	var active bool
+	err := r.pool.QueryRow(ctx, query, userID, orgID).Scan(&active)
+	return active, err
+}
+
 // Delete soft deletes a user
 func (r *UserRepository) Delete(ctx context.Context, id uuid.UUID) error {
 	return r.SoftDelete(ctx, "users", id)
@@ -466,6 +664,77 @@ func (r *UserRepository) UpdateOrganizationSettings(ctx context.Context, orgID u
 	return err
 }
 
+// ListEmailsByRole returns the email addresses of active users with the given role
+func (r *UserRepository) ListEmailsByRole(ctx context.Context, orgID uuid.UUID, role string) ([]string, error) {
+	query := `SELECT email FROM users WHERE organization_id = $1 AND role = $2 AND is_active = true ORDER BY email`
+
+	rows, err := r.reader().Query(ctx, query, orgID, role)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	emails := make([]string, 0)
+	for rows.Next() {
+		var email string
+		if err := rows.Scan(&email); err != nil {
+			return nil, err
+		}
+		emails = append(emails, email)
+	}
+	return emails, rows.Err()
+}
+
+// EmailsByID returns the e-mail addresses of the organization's users among
+// ids by ID, including deleted users
+func (r *UserRepository) EmailsByID(ctx context.Context, orgID uuid.UUID, ids []uuid.UUID) (map[uuid.UUID]string, error) {
+	rows, err := r.reader().Query(ctx,
+		`SELECT id, email FROM users WHERE organization_id = $1 AND id = ANY($2)`, orgID, ids)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	emails := make(map[uuid.UUID]string, len(ids))
+	for rows.Next() {
+		var id uuid.UUID
+		var email string
+		if err := rows.Scan(&id, &email); err != nil {
+			return nil, err
+		}
+		emails[id] = email
+	}
+	return emails, rows.Err()
+}
+
+// ListByHandles returns the active users whose username or email address is
+// one of handles, ignoring case
+func (r *UserRepository) ListByHandles(ctx context.Context, orgID uuid.UUID, handles []string) ([]models.User, error) {
+	query := `
+		SELECT id, organization_id, email, username, full_name
+		FROM users
+		WHERE organization_id = $1 AND is_active = true AND deleted_at IS NULL
+		  AND (lower(username) = ANY($2) OR lower(email) = ANY($2))
+		ORDER BY email
+	`
+
+	rows, err := r.reader().Query(ctx, query, orgID, handles)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := make([]models.User, 0)
+	for rows.Next() {
+		var u models.User
+		if err := rows.Scan(&u.ID, &u.OrganizationID, &u.Email, &u.Username, &u.FullName); err != nil {
+			return nil, err
+		}
+		users = append(users, u)
+	}
+	return users, rows.Err()
+}
+
 // ============================================
 // Business Unit Repository
 // ============================================
@@ -473,11 +742,11 @@ func (r *UserRepository) UpdateOrganizationSettings(ctx context.Context, orgID u
 // BusinessUnitRepository handles business unit database operations
 type BusinessUnitRepository struct {
 	*BaseRepository
-	pool *pgxpool.Pool
+	pool DBTX
 }
 
 // NewBusinessUnitRepository creates a new business unit repository
-func NewBusinessUnitRepository(pool *pgxpool.Pool) *BusinessUnitRepository {
+func NewBusinessUnitRepository(pool DBTX) *BusinessUnitRepository {
 	return &BusinessUnitRepository{
 		BaseRepository: NewBaseRepository(pool),
 		pool:           pool,
@@ -535,6 +804,37 @@ func (r *BusinessUnitRepository) GetByID(ctx context.Context, id uuid.UUID) (*mo
 	return bu, nil
 }
 
+// GetByName retrieves a business unit of an organization by name. Business
+// unit names are not unique, so the oldest one with the name is returned.
+func (r *BusinessUnitRepository) GetByName(ctx context.Context, orgID uuid.UUID, name string) (*models.BusinessUnit, error) {
+	query := `
+		SELECT 
+			id, organization_id, name, code, description,
+			director_name, director_email, cost_center,
+			parent_id, metadata, created_at, updated_at
+		FROM business_units
+		WHERE organization_id = $1 AND name = $2 AND deleted_at IS NULL
+		ORDER BY created_at ASC
+		LIMIT 1
+	`
+
+	bu := &models.BusinessUnit{}
+	err := r.pool.QueryRow(ctx, query, orgID, name).Scan(
+		&bu.ID, &bu.OrganizationID, &bu.Name, &bu.Code, &bu.Description,
+		&bu.DirectorName, &bu.DirectorEmail, &bu.CostCenter,
+		&bu.ParentID, &bu.Metadata, &bu.CreatedAt, &bu.UpdatedAt,
+	)
+
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return bu, nil
+}
+
 // List retrieves all business units for an organization
 func (r *BusinessUnitRepository) List(ctx context.Context, orgID uuid.UUID) ([]models.BusinessUnit, error) {
 	query := `
@@ -548,7 +848,7 @@ func (r *BusinessUnitRepository) List(ctx context.Context, orgID uuid.UUID) ([]m
 		ORDER BY bu.name ASC
 	`
 
-	rows, err := r.pool.Query(ctx, query, orgID)
+	rows, err := r.reader().Query(ctx, query, orgID)
 	if err != nil {
 		return nil, err
 	}
@@ -572,6 +872,42 @@ func (r *BusinessUnitRepository) List(ctx context.Context, orgID uuid.UUID) ([]m
 	return units, nil
 }
 
+// ListNamespaceCounts counts the current, unarchived namespaces of every
+// business unit of an organization by criticality, with those having an
+// owner team and those having documents
+func (r *BusinessUnitRepository) ListNamespaceCounts(ctx context.Context, orgID uuid.UUID) ([]models.BusinessUnitNamespaceCounts, error) {
+	query := `
+		SELECT
+			n.business_unit_id,
+			COALESCE(NULLIF(n.criticality, ''), 'unknown') AS criticality,
+			COUNT(*) AS namespaces,
+			COUNT(*) FILTER (WHERE n.infrastructure_owner_team_id IS NOT NULL) AS with_owner,
+			COUNT(*) FILTER (WHERE EXISTS (
+				SELECT 1 FROM documents d WHERE d.namespace_id = n.id AND d.deleted_at IS NULL
+			)) AS documented
+		FROM namespaces n
+		JOIN business_units bu ON bu.id = n.business_unit_id AND bu.deleted_at IS NULL
+		WHERE n.organization_id = $1 AND n.deleted_at IS NULL AND n.status <> $2
+		GROUP BY n.business_unit_id, 2
+	`
+
+	rows, err := r.reader().Query(ctx, query, orgID, models.NamespaceStatusArchived)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make([]models.BusinessUnitNamespaceCounts, 0)
+	for rows.Next() {
+		var c models.BusinessUnitNamespaceCounts
+		if err := rows.Scan(&c.BusinessUnitID, &c.Criticality, &c.Namespaces, &c.WithOwner, &c.Documented); err != nil {
+			return nil, err
+		}
+		counts = append(counts, c)
+	}
+	return counts, rows.Err()
+}
+
 // Update updates a business unit
 func (r *BusinessUnitRepository) Update(ctx context.Context, bu *models.BusinessUnit) error {
 	bu.UpdatedAt = time.Now()
@@ -0,0 +1,218 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/kubeatlas/kubeatlas/internal/models"
+)
+
+// Trash types, named like the resource types of audit logs
+const (
+	TrashCluster   = "cluster"
+	TrashNamespace = "namespace"
+	TrashTeam      = "team"
+	TrashDocument  = "document"
+)
+
+// TrashTypes lists every type of record that can be restored
+var TrashTypes = []string{TrashCluster, TrashNamespace, TrashTeam, TrashDocument}
+
+// trashRule describes the soft-deleted records of a type. In parent and
+// parentDeleted, t is the table and joins may add its parents.
+type trashRule struct {
+	table         string
+	joins         string
+	parent        string
+	parentDeleted string
+}
+
+var trashRules = map[string]trashRule{
+	TrashCluster: {
+		table:         "clusters",
+		parent:        "''",
+		parentDeleted: "false",
+	},
+	TrashNamespace: {
+		table:         "namespaces",
+		joins:         "JOIN clusters c ON c.id = t.cluster_id",
+		parent:        "c.name",
+		parentDeleted: "c.deleted_at IS NOT NULL",
+	},
+	TrashTeam: {
+		table:         "teams",
+		parent:        "''",
+		parentDeleted: "false",
+	},
+	TrashDocument: {
+		table: "documents",
+		joins: `LEFT JOIN namespaces n ON n.id = t.namespace_id
+			LEFT JOIN clusters c ON c.id = COALESCE(n.cluster_id, t.cluster_id)`,
+		parent:        "COALESCE(n.name, c.name, '')",
+		parentDeleted: "(n.deleted_at IS NOT NULL OR c.deleted_at IS NOT NULL)",
+	},
+}
+
+func lookupTrashRule(typ string) (trashRule, error) {
+	rule, ok := trashRules[typ]
+	if !ok {
+		return rule, fmt.Errorf("unknown trash type %q", typ)
+	}
+	return rule, nil
+}
+
+// selectTrashed selects the organization's ($1) soft-deleted records of typ
+func (rule trashRule) selectTrashed(typ string) string {
+	return fmt.Sprintf(`
+		SELECT '%s' AS type, t.id, t.name, %s AS parent, %s AS parent_deleted, t.deleted_at
+		FROM %s t %s
+		WHERE t.organization_id = $1 AND t.deleted_at IS NOT NULL`,
+		typ, rule.parent, rule.parentDeleted, rule.table, rule.joins)
+}
+
+// TrashRepository lists and restores soft-deleted records
+type TrashRepository struct {
+	*BaseRepository
+	pool DBTX
+}
+
+// NewTrashRepository creates a new trash repository
+func NewTrashRepository(pool DBTX) *TrashRepository {
+	return &TrashRepository{
+		BaseRepository: NewBaseRepository(pool),
+		pool:           pool,
+	}
+}
+
+// List retrieves the organization's soft-deleted records of types, the most
+// recently deleted first
+func (r *TrashRepository) List(ctx context.Context, orgID uuid.UUID, types []string, p Pagination) (*PaginatedResult[models.TrashItem], error) {
+	selects := make([]string, 0, len(types))
+	for _, typ := range types {
+		rule, err := lookupTrashRule(typ)
+		if err != nil {
+			return nil, err
+		}
+		selects = append(selects, rule.selectTrashed(typ))
+	}
+	trash := strings.Join(selects, "\nUNION ALL")
+
+	var total int64
+	if err := r.pool.QueryRow(ctx, `SELECT COUNT(*) FROM (`+trash+`) trash`, orgID).Scan(&total); err != nil {
+		return nil, fmt.Errorf("failed to count trash: %w", err)
+	}
+
+	query := `SELECT * FROM (` + trash + `) trash ORDER BY deleted_at DESC, id LIMIT $2 OFFSET $3`
+	offset := (p.Page - 1) * p.PageSize
+	rows, err := r.pool.Query(ctx, query, orgID, p.PageSize, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list trash: %w", err)
+	}
+	items, err := pgx.CollectRows(rows, scanTrashItem)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list trash: %w", err)
+	}
+
+	totalPages := int(total) / p.PageSize
+	if int(total)%p.PageSize > 0 {
+		totalPages++
+	}
+
+	return &PaginatedResult[models.TrashItem]{
+		Items:      items,
+		Total:      total,
+		Page:       p.Page,
+		PageSize:   p.PageSize,
+		TotalPages: totalPages,
+	}, nil
+}
+
+func scanTrashItem(row pgx.CollectableRow) (models.TrashItem, error) {
+	var item models.TrashItem
+	err := row.Scan(&item.Type, &item.ID, &item.Name, &item.Parent, &item.ParentDeleted, &item.DeletedAt)
+	return item, err
+}
+
+// Get retrieves a soft-deleted record of the organization, nil if there is
+// none
+func (r *TrashRepository) Get(ctx context.Context, orgID uuid.UUID, typ string, id uuid.UUID) (*models.TrashItem, error) {
+	rule, err := lookupTrashRule(typ)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.pool.Query(ctx, rule.selectTrashed(typ)+` AND t.id = $2`, orgID, id)
+	if err != nil {
+		return nil, err
+	}
+	item, err := pgx.CollectOneRow(rows, scanTrashItem)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
+
+// clusterRestores undelete the dependencies and documents deleted with
+// cluster $1 at $2, once its namespaces are restored
+var clusterRestores = []string{
+	`UPDATE internal_dependencies SET deleted_at = NULL, updated_at = NOW()
+		WHERE deleted_at = $2 AND (
+			source_namespace_id IN (SELECT id FROM namespaces WHERE cluster_id = $1 AND deleted_at IS NULL)
+			OR target_namespace_id IN (SELECT id FROM namespaces WHERE cluster_id = $1 AND deleted_at IS NULL))`,
+	`UPDATE external_dependencies SET deleted_at = NULL, updated_at = NOW()
+		WHERE deleted_at = $2 AND namespace_id IN (SELECT id FROM namespaces WHERE cluster_id = $1 AND deleted_at IS NULL)`,
+	`UPDATE documents SET deleted_at = NULL, updated_at = NOW()
+		WHERE deleted_at = $2 AND (cluster_id = $1
+			OR namespace_id IN (SELECT id FROM namespaces WHERE cluster_id = $1 AND deleted_at IS NULL))`,
+}
+
+// Restore undeletes a record of typ deleted at deletedAt, reporting false
+// when it is no longer deleted then. The namespaces, dependencies and
+// documents deleted with a cluster, in the same transaction and so at the
+// same time, are restored with it; the number of namespaces is returned.
+func (r *TrashRepository) Restore(ctx context.Context, typ string, id uuid.UUID, deletedAt time.Time) (bool, int64, error) {
+	rule, err := lookupTrashRule(typ)
+	if err != nil {
+		return false, 0, err
+	}
+
+	var restored bool
+	var namespaces int64
+	err = runInTx(ctx, r.pool, func(tx pgx.Tx) error {
+		query := fmt.Sprintf(`UPDATE %s SET deleted_at = NULL, updated_at = NOW() WHERE id = $1 AND deleted_at = $2`, rule.table)
+		result, err := tx.Exec(ctx, query, id, deletedAt)
+		if err != nil {
+			return err
+		}
+		restored = result.RowsAffected() > 0
+		if !restored || typ != TrashCluster {
+			return nil
+		}
+
+		result, err = tx.Exec(ctx,
+			`UPDATE namespaces SET deleted_at = NULL, updated_at = NOW() WHERE cluster_id = $1 AND deleted_at = $2`,
+			id, deletedAt)
+		if err != nil {
+			return err
+		}
+		namespaces = result.RowsAffected()
+
+		for _, query := range clusterRestores {
+			if _, err := tx.Exec(ctx, query, id, deletedAt); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return false, 0, fmt.Errorf("failed to restore %s: %w", typ, err)
+	}
+	return restored, namespaces, nil
+}
@@ -0,0 +1,53 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// UnitOfWork runs a group of repository operations inside a single transaction
+type UnitOfWork struct {
+	db DBTX
+}
+
+// NewUnitOfWork creates a new unit of work
+func NewUnitOfWork(db DBTX) *UnitOfWork {
+	return &UnitOfWork{db: db}
+}
+
+// TxRepositories holds repositories bound to the same transaction
+type TxRepositories struct {
+	Cluster            *ClusterRepository
+	Namespace          *NamespaceRepository
+	Team               *TeamRepository
+	User               *UserRepository
+	BusinessUnit       *BusinessUnitRepository
+	InternalDependency *InternalDependencyRepository
+	ExternalDependency *ExternalDependencyRepository
+	Document           *DocumentRepository
+	Audit              *AuditRepository
+}
+
+// NewTxRepositories creates repositories that execute against the given transaction
+func NewTxRepositories(tx pgx.Tx) *TxRepositories {
+	return &TxRepositories{
+		Cluster:            NewClusterRepository(tx),
+		Namespace:          NewNamespaceRepository(tx),
+		Team:               NewTeamRepository(tx),
+		User:               NewUserRepository(tx),
+		BusinessUnit:       NewBusinessUnitRepository(tx),
+		InternalDependency: NewInternalDependencyRepository(tx),
+		ExternalDependency: NewExternalDependencyRepository(tx),
+		Document:           NewDocumentRepository(tx),
+		Audit:              NewAuditRepository(tx),
+	}
+}
+
+// Do executes fn with transaction-bound repositories. All changes are
+// committed when fn returns nil and rolled back on error or panic.
+func (u *UnitOfWork) Do(ctx context.Context, fn func(repos *TxRepositories) error) error {
+	return runInTx(ctx, u.db, func(tx pgx.Tx) error {
+		return fn(NewTxRepositories(tx))
+	})
+}
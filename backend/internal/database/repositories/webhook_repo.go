@@ -0,0 +1,299 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/kubeatlas/kubeatlas/internal/models"
+)
+
+// WebhookRepository handles webhook subscriptions and their delivery history
+type WebhookRepository struct {
+	*BaseRepository
+	pool DBTX
+}
+
+// NewWebhookRepository creates a new webhook repository
+func NewWebhookRepository(pool DBTX) *WebhookRepository {
+	return &WebhookRepository{
+		BaseRepository: NewBaseRepository(pool),
+		pool:           pool,
+	}
+}
+
+const webhookSubscriptionColumns = `
+	id, organization_id, name, url, secret_encrypted, event_types, is_active, created_by, created_at, updated_at
+`
+
+func scanWebhookSubscription(row pgx.Row, s *models.WebhookSubscription) error {
+	return row.Scan(
+		&s.ID, &s.OrganizationID, &s.Name, &s.URL, &s.SecretEncrypted, &s.EventTypes, &s.IsActive, &s.CreatedBy, &s.CreatedAt, &s.UpdatedAt,
+	)
+}
+
+const webhookDeliveryColumns = `
+	id, subscription_id, organization_id, event_type, payload, status, attempts,
+	response_status, last_error, next_attempt_at, delivered_at, created_at, updated_at
+`
+
+func scanWebhookDelivery(row pgx.Row, d *models.WebhookDelivery) error {
+	return row.Scan(
+		&d.ID, &d.SubscriptionID, &d.OrganizationID, &d.EventType, &d.Payload, &d.Status, &d.Attempts,
+		&d.ResponseStatus, &d.LastError, &d.NextAttemptAt, &d.DeliveredAt, &d.CreatedAt, &d.UpdatedAt,
+	)
+}
+
+// ============================================
+// Subscriptions
+// ============================================
+
+// Create creates a new subscription
+func (r *WebhookRepository) Create(ctx context.Context, s *models.WebhookSubscription) error {
+	s.ID = uuid.New()
+	s.CreatedAt = time.Now()
+	s.UpdatedAt = s.CreatedAt
+
+	query := `
+		INSERT INTO webhook_subscriptions (
+			id, organization_id, name, url, secret_encrypted, event_types, is_active, created_by, created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	`
+
+	_, err := r.pool.Exec(ctx, query,
+		s.ID, s.OrganizationID, s.Name, s.URL, s.SecretEncrypted, s.EventTypes, s.IsActive, s.CreatedBy, s.CreatedAt, s.UpdatedAt,
+	)
+	return err
+}
+
+// GetByID retrieves a subscription by ID. Returns nil when it does not exist.
+func (r *WebhookRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.WebhookSubscription, error) {
+	query := `SELECT ` + webhookSubscriptionColumns + ` FROM webhook_subscriptions WHERE id = $1 AND deleted_at IS NULL`
+
+	s := &models.WebhookSubscription{}
+	err := scanWebhookSubscription(r.pool.QueryRow(ctx, query, id), s)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// List returns the organization's subscriptions
+func (r *WebhookRepository) List(ctx context.Context, orgID uuid.UUID) ([]models.WebhookSubscription, error) {
+	query := `
+		SELECT ` + webhookSubscriptionColumns + `
+		FROM webhook_subscriptions
+		WHERE organization_id = $1 AND deleted_at IS NULL
+		ORDER BY name
+	`
+	return r.querySubscriptions(ctx, r.reader(), query, orgID)
+}
+
+// ListActiveForEvent returns the organization's active subscriptions that
+// selected eventType or all events
+func (r *WebhookRepository) ListActiveForEvent(ctx context.Context, orgID uuid.UUID, eventType string) ([]models.WebhookSubscription, error) {
+	query := `
+		SELECT ` + webhookSubscriptionColumns + `
+		FROM webhook_subscriptions
+		WHERE organization_id = $1 AND deleted_at IS NULL AND is_active = true
+		  AND (event_types @> ARRAY[$2]::text[] OR event_types @> ARRAY['*']::text[])
+	`
+	return r.querySubscriptions(ctx, r.pool, query, orgID, eventType)
+}
+
+func (r *WebhookRepository) querySubscriptions(ctx context.Context, db DBTX, query string, args ...interface{}) ([]models.WebhookSubscription, error) {
+	rows, err := db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	subscriptions := make([]models.WebhookSubscription, 0)
+	for rows.Next() {
+		var s models.WebhookSubscription
+		if err := scanWebhookSubscription(rows, &s); err != nil {
+			return nil, err
+		}
+		subscriptions = append(subscriptions, s)
+	}
+	return subscriptions, rows.Err()
+}
+
+// Update updates a subscription
+func (r *WebhookRepository) Update(ctx context.Context, s *models.WebhookSubscription) error {
+	s.UpdatedAt = time.Now()
+
+	query := `
+		UPDATE webhook_subscriptions SET
+			name = $2, url = $3, secret_encrypted = $4, event_types = $5, is_active = $6, updated_at = $7
+		WHERE id = $1 AND deleted_at IS NULL
+	`
+
+	result, err := r.pool.Exec(ctx, query, s.ID, s.Name, s.URL, s.SecretEncrypted, s.EventTypes, s.IsActive, s.UpdatedAt)
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+// Delete soft deletes a subscription
+func (r *WebhookRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	return r.SoftDelete(ctx, "webhook_subscriptions", id)
+}
+
+// ============================================
+// Deliveries
+// ============================================
+
+// CreateDelivery queues an event for a subscription
+func (r *WebhookRepository) CreateDelivery(ctx context.Context, d *models.WebhookDelivery) error {
+	if d.ID == uuid.Nil {
+		d.ID = uuid.New()
+	}
+	d.CreatedAt = time.Now()
+	d.UpdatedAt = d.CreatedAt
+	if d.Status == "" {
+		d.Status = models.DeliveryStatusPending
+	}
+	if d.NextAttemptAt.IsZero() {
+		d.NextAttemptAt = d.CreatedAt
+	}
+
+	query := `
+		INSERT INTO webhook_deliveries (
+			id, subscription_id, organization_id, event_type, payload, status, attempts, next_attempt_at, created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	`
+
+	_, err := r.pool.Exec(ctx, query,
+		d.ID, d.SubscriptionID, d.OrganizationID, d.EventType, d.Payload, d.Status, d.Attempts, d.NextAttemptAt, d.CreatedAt, d.UpdatedAt,
+	)
+	return err
+}
+
+// ClaimDueDeliveries marks up to limit due deliveries as sending and returns
+// them. Rows locked by another instance are skipped, and deliveries stuck in
+// sending for longer than staleAfter are reclaimed.
+func (r *WebhookRepository) ClaimDueDeliveries(ctx context.Context, limit int, staleAfter time.Duration) ([]models.WebhookDelivery, error) {
+	query := `
+		UPDATE webhook_deliveries SET
+			status = 'sending',
+			attempts = attempts + 1,
+			next_attempt_at = NOW() + $2::interval,
+			updated_at = NOW()
+		WHERE id IN (
+			SELECT id FROM webhook_deliveries
+			WHERE status IN ('pending', 'sending') AND next_attempt_at <= NOW()
+			ORDER BY next_attempt_at
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + webhookDeliveryColumns
+
+	rows, err := r.pool.Query(ctx, query, limit, fmt.Sprintf("%d seconds", int(staleAfter.Seconds())))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	deliveries := make([]models.WebhookDelivery, 0)
+	for rows.Next() {
+		var d models.WebhookDelivery
+		if err := scanWebhookDelivery(rows, &d); err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, d)
+	}
+	return deliveries, rows.Err()
+}
+
+// MarkDelivered records a successful delivery
+func (r *WebhookRepository) MarkDelivered(ctx context.Context, id uuid.UUID, responseStatus int) error {
+	query := `
+		UPDATE webhook_deliveries SET
+			status = 'sent', response_status = $2, last_error = NULL, delivered_at = NOW(), updated_at = NOW()
+		WHERE id = $1
+	`
+	_, err := r.pool.Exec(ctx, query, id, responseStatus)
+	return err
+}
+
+// MarkFailed records a failed attempt and the receiver's status code, if
+// any. With a nil retryAt the delivery is given up on; otherwise it is
+// retried at retryAt.
+func (r *WebhookRepository) MarkFailed(ctx context.Context, id uuid.UUID, responseStatus *int, deliveryErr string, retryAt *time.Time) error {
+	status := models.DeliveryStatusFailed
+	next := time.Now()
+	if retryAt != nil {
+		status = models.DeliveryStatusPending
+		next = *retryAt
+	}
+
+	query := `
+		UPDATE webhook_deliveries SET
+			status = $2, response_status = $3, last_error = $4, next_attempt_at = $5, updated_at = NOW()
+		WHERE id = $1
+	`
+	_, err := r.pool.Exec(ctx, query, id, status, responseStatus, deliveryErr, next)
+	return err
+}
+
+// ListDeliveries retrieves the delivery history of a subscription, newest first
+func (r *WebhookRepository) ListDeliveries(ctx context.Context, subscriptionID uuid.UUID, p Pagination, filters map[string]interface{}) (*PaginatedResult[models.WebhookDelivery], error) {
+	qb := NewQueryBuilder(`SELECT ` + webhookDeliveryColumns + ` FROM webhook_deliveries`)
+
+	qb.Where("subscription_id = ?", subscriptionID)
+	if status, ok := filters["status"].(string); ok && status != "" {
+		qb.Where("status = ?", status)
+	}
+	if eventType, ok := filters["event_type"].(string); ok && eventType != "" {
+		qb.Where("event_type = ?", eventType)
+	}
+
+	p.Sort = "created_at"
+	p.Order = "desc"
+	qb.Paginate(p)
+
+	countQuery, countArgs := qb.BuildCount()
+	var total int64
+	if err := r.reader().QueryRow(ctx, countQuery, countArgs...).Scan(&total); err != nil {
+		return nil, fmt.Errorf("failed to count webhook deliveries: %w", err)
+	}
+
+	query, args := qb.Build()
+	rows, err := r.reader().Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	deliveries := make([]models.WebhookDelivery, 0)
+	for rows.Next() {
+		var d models.WebhookDelivery
+		if err := scanWebhookDelivery(rows, &d); err != nil {
+			return nil, fmt.Errorf("failed to scan webhook delivery: %w", err)
+		}
+		deliveries = append(deliveries, d)
+	}
+
+	totalPages := int(total) / p.PageSize
+	if int(total)%p.PageSize > 0 {
+		totalPages++
+	}
+
+	return &PaginatedResult[models.WebhookDelivery]{
+		Items:      deliveries,
+		Total:      total,
+		Page:       p.Page,
+		PageSize:   p.PageSize,
+		TotalPages: totalPages,
+	}, nil
+}
@@ -0,0 +1,155 @@
+// Package doctext extracts the text of uploaded documents page by page, so
+// their contents can be searched. Plain text formats such as markdown, YAML
+// and JSON are supported, and Word documents.
+package doctext
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ErrUnsupported is returned for file types without extractable text
+var ErrUnsupported = errors.New("text extraction is not supported for this file type")
+
+// MaxTextSize bounds the text kept of a document; the rest is not searched
+const MaxTextSize = 1 << 20
+
+// maxDocxPartSize bounds the decompressed size of the part of a Word
+// document holding its text, so a small upload cannot expand without bound
+const maxDocxPartSize = 64 << 20
+
+// docxType is the MIME type of Word documents
+const docxType = "application/vnd.openxmlformats-officedocument.wordprocessingml.document"
+
+// textTypes are the MIME types outside text/* holding plain text
+var textTypes = map[string]bool{
+	"application/json":   true,
+	"application/xml":    true,
+	"application/yaml":   true,
+	"application/x-yaml": true,
+	"application/toml":   true,
+	"application/x-sh":   true,
+}
+
+// Supported reports whether text can be extracted from files of a MIME type
+func Supported(mimeType string) bool {
+	return strings.HasPrefix(mimeType, "text/") || textTypes[mimeType] || mimeType == docxType
+}
+
+// Extract returns the text of a document of size bytes by page, without
+// empty trailing pages. Plain text is split into pages at form feeds, Word
+// documents at their page breaks.
+func Extract(r io.ReaderAt, size int64, mimeType string) ([]string, error) {
+	switch {
+	case mimeType == docxType:
+		return extractDocx(r, size)
+	case Supported(mimeType):
+		data, err := io.ReadAll(io.NewSectionReader(r, 0, min(size, MaxTextSize)))
+		if err != nil {
+			return nil, err
+		}
+		return pages(strings.Split(string(data), "\f")), nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupported, mimeType)
+	}
+}
+
+// extractDocx reads the paragraphs of a Word document's main part, starting
+// a page at each explicit or rendered page break
+func extractDocx(r io.ReaderAt, size int64) ([]string, error) {
+	archive, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Word document: %w", err)
+	}
+	part, err := archive.Open("word/document.xml")
+	if err != nil {
+		return nil, fmt.Errorf("invalid Word document: %w", err)
+	}
+	defer part.Close()
+	if info, err := part.Stat(); err != nil || info.Size() > maxDocxPartSize {
+		return nil, fmt.Errorf("invalid Word document: its text is larger than %d MB once decompressed", maxDocxPartSize>>20)
+	}
+
+	var result []string
+	var page strings.Builder
+	total := 0
+	breakPage := func() {
+		// Word writes a rendered break after an explicit one; only the
+		// first starts a page
+		if strings.TrimSpace(page.String()) == "" {
+			return
+		}
+		result = append(result, page.String())
+		page.Reset()
+	}
+
+	// The size in the archive may understate what decompresses
+	dec := xml.NewDecoder(io.LimitReader(part, maxDocxPartSize))
+	inText := false
+	for total < MaxTextSize {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid Word document: %w", err)
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "t":
+				inText = true
+			case "tab":
+				page.WriteByte('\t')
+			case "br":
+				if attr(t, "type") == "page" {
+					breakPage()
+				} else {
+					page.WriteByte('\n')
+				}
+			case "lastRenderedPageBreak":
+				breakPage()
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "t":
+				inText = false
+			case "p":
+				page.WriteByte('\n')
+			}
+		case xml.CharData:
+			if inText {
+				page.Write(t)
+				total += len(t)
+			}
+		}
+	}
+	result = append(result, page.String())
+	return pages(result), nil
+}
+
+// attr returns the value of an element's attribute, in any namespace
+func attr(e xml.StartElement, name string) string {
+	for _, a := range e.Attr {
+		if a.Name.Local == name {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+// pages cleans extracted pages for storage and drops empty trailing ones
+func pages(raw []string) []string {
+	for i, p := range raw {
+		p = strings.ToValidUTF8(p, "")
+		raw[i] = strings.TrimSpace(strings.ReplaceAll(p, "\x00", ""))
+	}
+	for len(raw) > 0 && raw[len(raw)-1] == "" {
+		raw = raw[:len(raw)-1]
+	}
+	return raw
+}
@@ -0,0 +1,69 @@
+package doctext
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestExtractText(t *testing.T) {
+	data := []byte("# Runbook\n\nRestart the payments pod.\f\n  Page two \x00\n\f\n\n")
+	got, err := Extract(bytes.NewReader(data), int64(len(data)), "text/markdown")
+	if err != nil {
+		t.Fatalf("Extract() error = %v", err)
+	}
+	want := []string{"# Runbook\n\nRestart the payments pod.", "Page two"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Extract() = %q, want %q", got, want)
+	}
+
+	if got, err := Extract(bytes.NewReader(nil), 0, "application/x-yaml"); err != nil || len(got) != 0 {
+		t.Errorf("Extract() of an empty file = %q, %v, want no pages", got, err)
+	}
+	if _, err := Extract(bytes.NewReader(data), int64(len(data)), "image/png"); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("Extract() of an image error = %v, want ErrUnsupported", err)
+	}
+}
+
+func TestExtractDocx(t *testing.T) {
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	w, _ := archive.Create("word/document.xml")
+	w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?>
+<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"><w:body>
+<w:p><w:r><w:t>Failover</w:t></w:r><w:r><w:tab/><w:t xml:space="preserve">steps &amp; checks</w:t></w:r></w:p>
+<w:p><w:r><w:br w:type="page"/></w:r></w:p>
+<w:p><w:r><w:lastRenderedPageBreak/><w:t>Rollback</w:t></w:r></w:p>
+<w:p><w:r><w:lastRenderedPageBreak/><w:t>Contacts</w:t></w:r></w:p>
+</w:body></w:document>`))
+	archive.Close()
+
+	got, err := Extract(bytes.NewReader(buf.Bytes()), int64(buf.Len()), docxType)
+	if err != nil {
+		t.Fatalf("Extract() error = %v", err)
+	}
+	want := []string{"Failover\tsteps & checks", "Rollback", "Contacts"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Extract() = %q, want %q", got, want)
+	}
+
+	if _, err := Extract(strings.NewReader("not a zip"), 9, docxType); err == nil {
+		t.Error("Extract() of an invalid Word document succeeded")
+	}
+
+	// A few kilobytes decompressing past the limit
+	buf.Reset()
+	archive = zip.NewWriter(&buf)
+	w, _ = archive.Create("word/document.xml")
+	padding := bytes.Repeat([]byte(" "), 1<<20)
+	for i := 0; i <= maxDocxPartSize>>20; i++ {
+		w.Write(padding)
+	}
+	archive.Close()
+	if _, err := Extract(bytes.NewReader(buf.Bytes()), int64(buf.Len()), docxType); err == nil || !strings.Contains(err.Error(), "decompressed") {
+		t.Errorf("Extract() of a Word document decompressing past the limit error = %v", err)
+	}
+}
@@ -0,0 +1,134 @@
+// Package escalation parses the escalation path of a namespace into levels.
+//
+// A path lists one level per line (or separated by "->"). Each level names
+// its contacts, separated by commas, and all levels but the first start with
+// the delay after which an unacknowledged alert reaches them:
+//
+//	oncall@example.com, #payments-oncall
+//	15m: Jane Doe <jane@example.com>
+//	1h: cto@example.com, +90 555 000 0000
+//
+// Contacts are e-mail addresses, Slack channels ("#channel") or free text
+// such as phone numbers, which is kept for display but cannot be notified.
+package escalation
+
+import (
+	"errors"
+	"fmt"
+	"net/mail"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrMissingDelay = errors.New("missing delay")
+	ErrDelayOrder   = errors.New("delay must be longer than the previous level's")
+	ErrNoContacts   = errors.New("no contacts")
+)
+
+// Contact is someone an alert escalates to
+type Contact struct {
+	Name  string `json:"name,omitempty"`
+	Email string `json:"email,omitempty"`
+	Slack string `json:"slack,omitempty"`
+}
+
+// Level is a group of contacts alerted once an alert has been open for Delay
+type Level struct {
+	Delay    time.Duration `json:"delay"`
+	Contacts []Contact     `json:"contacts"`
+}
+
+// Emails returns the e-mail addresses of the level's contacts
+func (l Level) Emails() []string {
+	var emails []string
+	for _, c := range l.Contacts {
+		if c.Email != "" {
+			emails = append(emails, c.Email)
+		}
+	}
+	return emails
+}
+
+// SlackChannels returns the Slack channels of the level's contacts
+func (l Level) SlackChannels() []string {
+	var channels []string
+	for _, c := range l.Contacts {
+		if c.Slack != "" {
+			channels = append(channels, c.Slack)
+		}
+	}
+	return channels
+}
+
+// Parse parses an escalation path. An empty path has no levels.
+func Parse(path string) ([]Level, error) {
+	var levels []Level
+	for _, line := range strings.Split(strings.ReplaceAll(path, "->", "\n"), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		n := len(levels) + 1
+
+		var level Level
+		delay, rest, ok := splitDelay(line)
+		switch {
+		case ok:
+			level.Delay = delay
+			line = rest
+		case n > 1:
+			return nil, fmt.Errorf("level %d: %w", n, ErrMissingDelay)
+		}
+		if n > 1 && level.Delay <= levels[n-2].Delay {
+			return nil, fmt.Errorf("level %d: %w", n, ErrDelayOrder)
+		}
+
+		for _, part := range strings.Split(line, ",") {
+			if part = strings.TrimSpace(part); part != "" {
+				level.Contacts = append(level.Contacts, parseContact(part))
+			}
+		}
+		if len(level.Contacts) == 0 {
+			return nil, fmt.Errorf("level %d: %w", n, ErrNoContacts)
+		}
+		levels = append(levels, level)
+	}
+	return levels, nil
+}
+
+// splitDelay splits "15m: contacts" into its delay and contacts
+func splitDelay(line string) (time.Duration, string, bool) {
+	prefix, rest, found := strings.Cut(line, ":")
+	if !found {
+		return 0, line, false
+	}
+	delay, err := parseDelay(strings.TrimSpace(prefix))
+	if err != nil || delay < 0 {
+		return 0, line, false
+	}
+	return delay, rest, true
+}
+
+// parseDelay is time.ParseDuration that also accepts whole days, e.g. "2d"
+func parseDelay(s string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, err
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	return time.ParseDuration(s)
+}
+
+func parseContact(s string) Contact {
+	if strings.HasPrefix(s, "#") && !strings.ContainsAny(s, " \t") {
+		return Contact{Slack: s}
+	}
+	if addr, err := mail.ParseAddress(s); err == nil {
+		return Contact{Name: addr.Name, Email: addr.Address}
+	}
+	return Contact{Name: s}
+}
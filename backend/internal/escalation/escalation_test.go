@@ -0,0 +1,71 @@
+package escalation
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	levels, err := Parse(`
+		oncall@example.com, #payments-oncall
+		15m: Jane Doe <jane@example.com>
+		2d: cto@example.com, +90 555 000 0000
+	`)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if len(levels) != 3 {
+		t.Fatalf("got %d levels, want 3: %+v", len(levels), levels)
+	}
+
+	if levels[0].Delay != 0 || len(levels[0].Contacts) != 2 {
+		t.Errorf("level 1 = %+v", levels[0])
+	}
+	if got := levels[0].SlackChannels(); len(got) != 1 || got[0] != "#payments-oncall" {
+		t.Errorf("level 1 channels = %v", got)
+	}
+	if c := levels[1].Contacts[0]; levels[1].Delay != 15*time.Minute || c.Name != "Jane Doe" || c.Email != "jane@example.com" {
+		t.Errorf("level 2 = %+v", levels[1])
+	}
+	if levels[2].Delay != 48*time.Hour {
+		t.Errorf("level 3 delay = %v", levels[2].Delay)
+	}
+	if got := levels[2].Emails(); len(got) != 1 || got[0] != "cto@example.com" {
+		t.Errorf("level 3 emails = %v", got)
+	}
+	if c := levels[2].Contacts[1]; c.Name != "+90 555 000 0000" || c.Email != "" {
+		t.Errorf("free text contact = %+v", c)
+	}
+}
+
+func TestParse_Arrows(t *testing.T) {
+	levels, err := Parse("ops@example.com -> 30m: lead@example.com")
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if len(levels) != 2 || levels[1].Delay != 30*time.Minute {
+		t.Errorf("Parse() = %+v", levels)
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	tests := []struct {
+		path string
+		want error
+	}{
+		{"ops@example.com\nlead@example.com", ErrMissingDelay},
+		{"ops@example.com\n1h: a@example.com\n30m: b@example.com", ErrDelayOrder},
+		{"ops@example.com\n0s: lead@example.com", ErrDelayOrder},
+		{"ops@example.com\n15m: ,", ErrNoContacts},
+	}
+	for _, tt := range tests {
+		if _, err := Parse(tt.path); !errors.Is(err, tt.want) {
+			t.Errorf("Parse(%q) error = %v, want %v", tt.path, err, tt.want)
+		}
+	}
+
+	if levels, err := Parse("  \n "); err != nil || len(levels) != 0 {
+		t.Errorf("Parse(blank) = %v, %v", levels, err)
+	}
+}
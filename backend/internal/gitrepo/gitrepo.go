@@ -0,0 +1,242 @@
+// Package gitrepo validates Git repository URLs and reads the default branch
+// and last commit of repositories hosted on GitHub or GitLab.
+package gitrepo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Hosting providers repositories can be read from
+const (
+	ProviderGitHub = "github"
+	ProviderGitLab = "gitlab"
+	// ProviderOther is any other host; its repositories are linked but not read
+	ProviderOther = "other"
+)
+
+var (
+	ErrInvalidURL  = errors.New("invalid repository URL")
+	ErrUnsupported = errors.New("repository host is not GitHub or GitLab")
+)
+
+// Repo is a parsed repository URL
+type Repo struct {
+	// URL is the normalized address: https, without a .git suffix or
+	// trailing slash
+	URL      string
+	Provider string
+	Host     string
+	// Path is the owner and name, or the GitLab group path and name
+	Path string
+}
+
+// Parse validates and normalizes the web or clone address of a repository.
+// Only https addresses are accepted. github.com and hosts named github.* are
+// GitHub; gitlab.com and hosts named gitlab.* are GitLab.
+func Parse(raw string) (Repo, error) {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return Repo{}, fmt.Errorf("%w: %v", ErrInvalidURL, err)
+	}
+	if u.Scheme != "https" || u.Host == "" {
+		return Repo{}, fmt.Errorf("%w: %q must be an https address", ErrInvalidURL, raw)
+	}
+	if u.User != nil || u.RawQuery != "" || u.Fragment != "" {
+		return Repo{}, fmt.Errorf("%w: %q must not have credentials, a query or a fragment", ErrInvalidURL, raw)
+	}
+
+	path := strings.TrimSuffix(strings.Trim(u.Path, "/"), ".git")
+	segments := strings.Split(path, "/")
+	if len(segments) < 2 {
+		return Repo{}, fmt.Errorf("%w: %q must name an owner and a repository", ErrInvalidURL, raw)
+	}
+	for _, s := range segments {
+		if s == "" || s == "." || s == ".." {
+			return Repo{}, fmt.Errorf("%w: %q has an empty path segment", ErrInvalidURL, raw)
+		}
+	}
+
+	host := strings.ToLower(u.Host)
+	provider := ProviderOther
+	switch {
+	case host == "github.com" || strings.HasPrefix(host, "github."):
+		provider = ProviderGitHub
+		// GitHub repositories are owner/name; deeper paths are pages of one
+		if len(segments) > 2 {
+			segments = segments[:2]
+			path = strings.Join(segments, "/")
+		}
+	case host == "gitlab.com" || strings.HasPrefix(host, "gitlab."):
+		provider = ProviderGitLab
+		// GitLab pages of a repository follow a /-/ segment
+		if i := strings.Index(path, "/-/"); i >= 0 {
+			path = path[:i]
+		}
+	}
+
+	return Repo{
+		URL:      "https://" + host + "/" + path,
+		Provider: provider,
+		Host:     host,
+		Path:     path,
+	}, nil
+}
+
+// Config holds the tokens an organization reads private repositories with.
+// Public repositories are read without one, within the providers' lower
+// rate limits for anonymous requests.
+type Config struct {
+	GitHubToken string
+	GitLabToken string
+}
+
+// Info is what a provider reports about a repository
+type Info struct {
+	DefaultBranch string
+	LastCommitAt  time.Time
+}
+
+// Inspector reads repositories
+type Inspector interface {
+	Inspect(ctx context.Context, cfg Config, repo Repo) (*Info, error)
+}
+
+// Client talks to the GitHub and GitLab REST APIs
+type Client struct {
+	httpClient *http.Client
+}
+
+// NewClient creates a client whose requests give up after timeout
+func NewClient(timeout time.Duration) *Client {
+	return &Client{httpClient: &http.Client{Timeout: timeout}}
+}
+
+// Inspect implements Inspector, reading the repository's default branch and
+// the date of the last commit on it
+func (c *Client) Inspect(ctx context.Context, cfg Config, repo Repo) (*Info, error) {
+	switch repo.Provider {
+	case ProviderGitHub:
+		return c.inspectGitHub(ctx, cfg, repo)
+	case ProviderGitLab:
+		return c.inspectGitLab(ctx, cfg, repo)
+	default:
+		return nil, ErrUnsupported
+	}
+}
+
+func (c *Client) inspectGitHub(ctx context.Context, cfg Config, repo Repo) (*Info, error) {
+	// GitHub Enterprise Server serves the API under /api/v3
+	api := "https://" + repo.Host + "/api/v3"
+	if repo.Host == "github.com" {
+		api = "https://api.github.com"
+	}
+	auth := ""
+	if cfg.GitHubToken != "" {
+		auth = "Bearer " + cfg.GitHubToken
+	}
+
+	var project struct {
+		DefaultBranch string `json:"default_branch"`
+	}
+	if err := c.get(ctx, api+"/repos/"+repo.Path, "Authorization", auth, &project); err != nil {
+		return nil, err
+	}
+	info := &Info{DefaultBranch: project.DefaultBranch}
+	if project.DefaultBranch == "" {
+		// An empty repository has no branches yet
+		return info, nil
+	}
+
+	var commit struct {
+		Commit struct {
+			Committer struct {
+				Date time.Time `json:"date"`
+			} `json:"committer"`
+		} `json:"commit"`
+	}
+	if err := c.get(ctx, api+"/repos/"+repo.Path+"/commits/"+url.PathEscape(project.DefaultBranch), "Authorization", auth, &commit); err != nil {
+		return nil, err
+	}
+	info.LastCommitAt = commit.Commit.Committer.Date
+	return info, nil
+}
+
+func (c *Client) inspectGitLab(ctx context.Context, cfg Config, repo Repo) (*Info, error) {
+	project := "https://" + repo.Host + "/api/v4/projects/" + url.PathEscape(repo.Path)
+
+	var p struct {
+		DefaultBranch string `json:"default_branch"`
+	}
+	if err := c.get(ctx, project, "PRIVATE-TOKEN", cfg.GitLabToken, &p); err != nil {
+		return nil, err
+	}
+	info := &Info{DefaultBranch: p.DefaultBranch}
+	if p.DefaultBranch == "" {
+		return info, nil
+	}
+
+	var commit struct {
+		CommittedDate time.Time `json:"committed_date"`
+	}
+	if err := c.get(ctx, project+"/repository/commits/"+url.PathEscape(p.DefaultBranch), "PRIVATE-TOKEN", cfg.GitLabToken, &commit); err != nil {
+		return nil, err
+	}
+	info.LastCommitAt = commit.CommittedDate
+	return info, nil
+}
+
+// get reads a JSON document, sending the token in header when one is set
+func (c *Client) get(ctx context.Context, address, header, token string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, address, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if token != "" {
+		req.Header.Set(header, token)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach %s: %w", req.URL.Host, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s returned %d: %s", req.URL.Host, resp.StatusCode, errorMessage(resp.Body))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode %s response: %w", req.URL.Host, err)
+	}
+	return nil
+}
+
+// errorMessage reads the message of a GitHub or GitLab error response,
+// falling back to the start of the body
+func errorMessage(r io.Reader) string {
+	data, _ := io.ReadAll(io.LimitReader(r, 4096))
+	var result struct {
+		Message interface{} `json:"message"`
+	}
+	// GitLab sends validation messages as objects
+	if err := json.Unmarshal(data, &result); err == nil && result.Message != nil {
+		if s, ok := result.Message.(string); ok {
+			return s
+		}
+		if b, err := json.Marshal(result.Message); err == nil {
+			return string(b)
+		}
+	}
+	if len(data) > 512 {
+		data = data[:512]
+	}
+	return strings.TrimSpace(string(data))
+}
@@ -0,0 +1,113 @@
+package gitrepo
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		raw      string
+		url      string
+		provider string
+		path     string
+	}{
+		{"https://github.com/acme/payments", "https://github.com/acme/payments", ProviderGitHub, "acme/payments"},
+		{"https://GitHub.com/acme/payments.git", "https://github.com/acme/payments", ProviderGitHub, "acme/payments"},
+		{"https://github.com/acme/payments/tree/main/deploy", "https://github.com/acme/payments", ProviderGitHub, "acme/payments"},
+		{"https://github.acme.internal/platform/payments/", "https://github.acme.internal/platform/payments", ProviderGitHub, "platform/payments"},
+		{"https://gitlab.com/acme/fintech/payments", "https://gitlab.com/acme/fintech/payments", ProviderGitLab, "acme/fintech/payments"},
+		{"https://gitlab.com/acme/fintech/payments/-/tree/main", "https://gitlab.com/acme/fintech/payments", ProviderGitLab, "acme/fintech/payments"},
+		{"https://bitbucket.org/acme/payments", "https://bitbucket.org/acme/payments", ProviderOther, "acme/payments"},
+	}
+	for _, tt := range tests {
+		repo, err := Parse(tt.raw)
+		if err != nil {
+			t.Errorf("Parse(%q) error = %v", tt.raw, err)
+			continue
+		}
+		if repo.URL != tt.url || repo.Provider != tt.provider || repo.Path != tt.path {
+			t.Errorf("Parse(%q) = %+v", tt.raw, repo)
+		}
+	}
+
+	for _, raw := range []string{
+		"",
+		"github.com/acme/payments",
+		"http://github.com/acme/payments",
+		"git@github.com:acme/payments.git",
+		"https://github.com/acme",
+		"https://token@github.com/acme/payments",
+		"https://github.com/acme/payments?tab=readme",
+		"https://github.com/acme//payments",
+	} {
+		if _, err := Parse(raw); !errors.Is(err, ErrInvalidURL) {
+			t.Errorf("Parse(%q) error = %v, want ErrInvalidURL", raw, err)
+		}
+	}
+}
+
+func TestClientInspect(t *testing.T) {
+	var gotAuth, gotToken string
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAuth = r.Header.Get("Authorization")
+		gotToken = r.Header.Get("PRIVATE-TOKEN")
+		switch r.URL.EscapedPath() {
+		case "/api/v3/repos/acme/payments":
+			w.Write([]byte(`{"default_branch":"main"}`))
+		case "/api/v3/repos/acme/payments/commits/main":
+			w.Write([]byte(`{"sha":"abc","commit":{"committer":{"date":"2026-10-14T08:30:00Z"}}}`))
+		case "/api/v4/projects/acme%2Ffintech%2Fpayments":
+			w.Write([]byte(`{"id":7,"default_branch":"release/2026"}`))
+		case "/api/v4/projects/acme%2Ffintech%2Fpayments/repository/commits/release%2F2026":
+			w.Write([]byte(`{"id":"abc","committed_date":"2026-10-15T17:00:00.000+02:00"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"message":"Not Found"}`))
+		}
+	}))
+	defer srv.Close()
+
+	c := &Client{httpClient: srv.Client()}
+	ctx := context.Background()
+	host := strings.TrimPrefix(srv.URL, "https://")
+	cfg := Config{GitHubToken: "ghp", GitLabToken: "glpat"}
+
+	info, err := c.Inspect(ctx, cfg, Repo{Provider: ProviderGitHub, Host: host, Path: "acme/payments"})
+	if err != nil {
+		t.Fatalf("Inspect() GitHub error = %v", err)
+	}
+	if info.DefaultBranch != "main" || !info.LastCommitAt.Equal(time.Date(2026, 10, 14, 8, 30, 0, 0, time.UTC)) {
+		t.Errorf("Inspect() GitHub = %+v", info)
+	}
+	if gotAuth != "Bearer ghp" {
+		t.Errorf("Authorization = %q", gotAuth)
+	}
+
+	info, err = c.Inspect(ctx, cfg, Repo{Provider: ProviderGitLab, Host: host, Path: "acme/fintech/payments"})
+	if err != nil {
+		t.Fatalf("Inspect() GitLab error = %v", err)
+	}
+	if info.DefaultBranch != "release/2026" || !info.LastCommitAt.Equal(time.Date(2026, 10, 15, 15, 0, 0, 0, time.UTC)) {
+		t.Errorf("Inspect() GitLab = %+v", info)
+	}
+	if gotToken != "glpat" {
+		t.Errorf("PRIVATE-TOKEN = %q", gotToken)
+	}
+
+	_, err = c.Inspect(ctx, Config{}, Repo{Provider: ProviderGitHub, Host: host, Path: "acme/missing"})
+	if err == nil || !strings.Contains(err.Error(), "404: Not Found") {
+		t.Errorf("Inspect() of a missing repository error = %v", err)
+	}
+	if gotAuth != "" {
+		t.Errorf("Authorization without a token = %q", gotAuth)
+	}
+	if _, err := c.Inspect(ctx, cfg, Repo{Provider: ProviderOther, Host: "bitbucket.org", Path: "acme/payments"}); err != ErrUnsupported {
+		t.Errorf("Inspect() of another host error = %v, want ErrUnsupported", err)
+	}
+}
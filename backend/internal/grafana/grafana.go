@@ -0,0 +1,119 @@
+// Package grafana posts annotations to Grafana through its HTTP API, so
+// catalog changes show up next to the metrics they may explain.
+package grafana
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+var ErrNotConfigured = errors.New("grafana is not configured")
+
+// Config holds the address of a Grafana instance and a service account
+// token allowed to write annotations
+type Config struct {
+	URL      string
+	APIToken string
+}
+
+// Annotation marks a point in time on a dashboard's graphs
+type Annotation struct {
+	// DashboardUID limits the annotation to one dashboard; without one it is
+	// an organization annotation, shown by dashboards that query its tags
+	DashboardUID string
+	Time         time.Time
+	Tags         []string
+	Text         string
+}
+
+// Annotator posts annotations
+type Annotator interface {
+	CreateAnnotation(ctx context.Context, cfg Config, a Annotation) error
+	Check(ctx context.Context, cfg Config) error
+}
+
+// Client talks to the Grafana HTTP API
+type Client struct {
+	httpClient *http.Client
+}
+
+// NewClient creates a client whose requests give up after timeout
+func NewClient(timeout time.Duration) *Client {
+	return &Client{httpClient: &http.Client{Timeout: timeout}}
+}
+
+// CreateAnnotation implements Annotator
+func (c *Client) CreateAnnotation(ctx context.Context, cfg Config, a Annotation) error {
+	body := map[string]interface{}{
+		"time": a.Time.UnixMilli(),
+		"tags": a.Tags,
+		"text": a.Text,
+	}
+	if a.DashboardUID != "" {
+		body["dashboardUID"] = a.DashboardUID
+	}
+	return c.do(ctx, cfg, http.MethodPost, "/api/annotations", body)
+}
+
+// Check implements Annotator, failing unless the token is accepted
+func (c *Client) Check(ctx context.Context, cfg Config) error {
+	return c.do(ctx, cfg, http.MethodGet, "/api/org", nil)
+}
+
+func (c *Client) do(ctx context.Context, cfg Config, method, path string, in interface{}) error {
+	if cfg.URL == "" || cfg.APIToken == "" {
+		return ErrNotConfigured
+	}
+
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, strings.TrimRight(cfg.URL, "/")+path, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+cfg.APIToken)
+	req.Header.Set("Accept", "application/json")
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach grafana: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("grafana returned %d: %s", resp.StatusCode, errorMessage(resp.Body))
+	}
+	return nil
+}
+
+// errorMessage reads the message of a Grafana error response, falling back
+// to the start of the body
+func errorMessage(r io.Reader) string {
+	data, _ := io.ReadAll(io.LimitReader(r, 4096))
+	var result struct {
+		Message string `json:"message"`
+	}
+	if err := json.Unmarshal(data, &result); err == nil && result.Message != "" {
+		return result.Message
+	}
+	if len(data) > 512 {
+		data = data[:512]
+	}
+	return strings.TrimSpace(string(data))
+}
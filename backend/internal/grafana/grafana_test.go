@@ -0,0 +1,56 @@
+package grafana
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestClientCreateAnnotation(t *testing.T) {
+	var gotAuth string
+	var got map[string]interface{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAuth = r.Header.Get("Authorization")
+		if r.URL.Path != "/grafana/api/annotations" {
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"message":"Permission denied"}`))
+			return
+		}
+		got = nil
+		json.NewDecoder(r.Body).Decode(&got)
+		w.Write([]byte(`{"id":1,"message":"Annotation added"}`))
+	}))
+	defer srv.Close()
+
+	c := NewClient(time.Second)
+	ctx := context.Background()
+	cfg := Config{URL: srv.URL + "/grafana/", APIToken: "token"}
+	at := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+
+	err := c.CreateAnnotation(ctx, cfg, Annotation{DashboardUID: "k8s", Time: at, Tags: []string{"kubeatlas"}, Text: "synced"})
+	if err != nil {
+		t.Fatalf("CreateAnnotation() error = %v", err)
+	}
+	if gotAuth != "Bearer token" {
+		t.Errorf("Authorization = %q", gotAuth)
+	}
+	if got["dashboardUID"] != "k8s" || got["time"] != float64(at.UnixMilli()) || got["text"] != "synced" {
+		t.Errorf("annotation body = %v", got)
+	}
+
+	err = c.CreateAnnotation(ctx, cfg, Annotation{Time: at, Text: "synced"})
+	if _, ok := got["dashboardUID"]; err != nil || ok {
+		t.Errorf("organization annotation: error = %v, body = %v", err, got)
+	}
+
+	if err := c.Check(ctx, cfg); err == nil || !strings.Contains(err.Error(), "Permission denied") {
+		t.Errorf("Check() error = %v", err)
+	}
+	if err := c.Check(ctx, Config{URL: srv.URL}); err != ErrNotConfigured {
+		t.Errorf("Check() without token error = %v, want ErrNotConfigured", err)
+	}
+}
@@ -0,0 +1,122 @@
+package health
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/kubeatlas/kubeatlas/internal/config"
+)
+
+// ErrSkipped is returned by checks that are not applicable to the current configuration
+var ErrSkipped = errors.New("check skipped")
+
+// Pinger is implemented by database pools
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// DatabaseCheck verifies a database pool is reachable
+func DatabaseCheck(db Pinger) CheckFunc {
+	return func(ctx context.Context) error {
+		if db == nil {
+			return ErrSkipped
+		}
+		return db.Ping(ctx)
+	}
+}
+
+// StorageCheck verifies document storage is usable. Local storage must be a
+// writable directory; S3/MinIO endpoints must answer HTTP requests.
+func StorageCheck(cfg config.StorageConfig) CheckFunc {
+	return func(ctx context.Context) error {
+		switch cfg.Type {
+		case "", "local":
+			if err := os.MkdirAll(cfg.LocalPath, 0755); err != nil {
+				return fmt.Errorf("storage path not accessible: %w", err)
+			}
+			f, err := os.CreateTemp(cfg.LocalPath, ".ready-*")
+			if err != nil {
+				return fmt.Errorf("storage path not writable: %w", err)
+			}
+			name := f.Name()
+			f.Close()
+			return os.Remove(filepath.Clean(name))
+		case "s3", "minio":
+			endpoint := cfg.S3Endpoint
+			if endpoint == "" {
+				endpoint = fmt.Sprintf("https://s3.%s.amazonaws.com", cfg.S3Region)
+			}
+			req, err := http.NewRequestWithContext(ctx, http.MethodHead, endpoint, nil)
+			if err != nil {
+				return err
+			}
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				return fmt.Errorf("object storage unreachable: %w", err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode >= 500 {
+				return fmt.Errorf("object storage returned %s", resp.Status)
+			}
+			return nil
+		default:
+			return fmt.Errorf("unknown storage type %q", cfg.Type)
+		}
+	}
+}
+
+// RedisCheck sends a PING to Redis when it is enabled
+func RedisCheck(cfg config.RedisConfig) CheckFunc {
+	return func(ctx context.Context) error {
+		if !cfg.Enabled {
+			return ErrSkipped
+		}
+
+		var d net.Dialer
+		conn, err := d.DialContext(ctx, "tcp", net.JoinHostPort(cfg.Host, fmt.Sprint(cfg.Port)))
+		if err != nil {
+			return fmt.Errorf("redis unreachable: %w", err)
+		}
+		defer conn.Close()
+		if deadline, ok := ctx.Deadline(); ok {
+			conn.SetDeadline(deadline)
+		}
+
+		reader := bufio.NewReader(conn)
+		if cfg.Password != "" {
+			if err := redisCommand(conn, reader, "+OK", "AUTH", cfg.Password); err != nil {
+				return err
+			}
+		}
+		return redisCommand(conn, reader, "+PONG", "PING")
+	}
+}
+
+// redisCommand writes a RESP command and checks the first reply line
+func redisCommand(conn net.Conn, reader *bufio.Reader, want string, args ...string) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(&b, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+	if _, err := conn.Write([]byte(b.String())); err != nil {
+		return fmt.Errorf("redis write failed: %w", err)
+	}
+
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return fmt.Errorf("redis read failed: %w", err)
+	}
+	line = strings.TrimSpace(line)
+	if line != want {
+		return fmt.Errorf("unexpected redis reply to %s: %s", args[0], line)
+	}
+	return nil
+}
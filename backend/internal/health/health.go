@@ -0,0 +1,134 @@
+package health
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Overall readiness states
+const (
+	StatusReady    = "ready"
+	StatusDegraded = "degraded"
+	StatusNotReady = "not_ready"
+)
+
+// Per-check states
+const (
+	CheckOK      = "ok"
+	CheckFailed  = "failed"
+	CheckSkipped = "skipped"
+)
+
+// CheckFunc probes a single dependency
+type CheckFunc func(ctx context.Context) error
+
+// Check is a named readiness probe. A failing critical check makes the
+// service not ready; a failing non-critical check only degrades it.
+type Check struct {
+	Name     string
+	Critical bool
+	Run      CheckFunc
+}
+
+// CheckResult is the outcome of a single check
+type CheckResult struct {
+	Status    string `json:"status"`
+	Critical  bool   `json:"critical"`
+	Error     string `json:"error,omitempty"`
+	LatencyMs int64  `json:"latency_ms"`
+}
+
+// Report is the aggregated readiness result
+type Report struct {
+	Status    string                 `json:"status"`
+	Checks    map[string]CheckResult `json:"checks"`
+	Timestamp time.Time              `json:"timestamp"`
+}
+
+// Checker runs a set of readiness checks concurrently
+type Checker struct {
+	checks  []Check
+	timeout time.Duration
+}
+
+// NewChecker creates a new checker. Each check gets at most timeout to complete.
+func NewChecker(timeout time.Duration) *Checker {
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+	return &Checker{timeout: timeout}
+}
+
+// Register adds a check
+func (h *Checker) Register(check Check) {
+	h.checks = append(h.checks, check)
+}
+
+// Run executes all checks and aggregates their status
+func (h *Checker) Run(ctx context.Context) Report {
+	report := Report{
+		Status:    StatusReady,
+		Checks:    make(map[string]CheckResult, len(h.checks)),
+		Timestamp: time.Now().UTC(),
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, check := range h.checks {
+		wg.Add(1)
+		go func(check Check) {
+			defer wg.Done()
+			result := h.runCheck(ctx, check)
+
+			mu.Lock()
+			defer mu.Unlock()
+			report.Checks[check.Name] = result
+		}(check)
+	}
+	wg.Wait()
+
+	for _, result := range report.Checks {
+		if result.Status != CheckFailed {
+			continue
+		}
+		if result.Critical {
+			report.Status = StatusNotReady
+			break
+		}
+		report.Status = StatusDegraded
+	}
+
+	return report
+}
+
+func (h *Checker) runCheck(ctx context.Context, check Check) CheckResult {
+	ctx, cancel := context.WithTimeout(ctx, h.timeout)
+	defer cancel()
+
+	start := time.Now()
+
+	// Checks that ignore ctx must not hold up the whole report
+	done := make(chan error, 1)
+	go func() { done <- check.Run(ctx) }()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	result := CheckResult{
+		Status:    CheckOK,
+		Critical:  check.Critical,
+		LatencyMs: time.Since(start).Milliseconds(),
+	}
+	if err == ErrSkipped {
+		result.Status = CheckSkipped
+	} else if err != nil {
+		result.Status = CheckFailed
+		result.Error = err.Error()
+	}
+	return result
+}
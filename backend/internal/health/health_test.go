@@ -0,0 +1,75 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func ok(context.Context) error { return nil }
+
+func fail(context.Context) error { return errors.New("boom") }
+
+func TestChecker_Run(t *testing.T) {
+	tests := []struct {
+		name   string
+		checks []Check
+		want   string
+	}{
+		{
+			name:   "all checks pass",
+			checks: []Check{{Name: "db", Critical: true, Run: ok}, {Name: "redis", Run: ok}},
+			want:   StatusReady,
+		},
+		{
+			name:   "non-critical failure degrades",
+			checks: []Check{{Name: "db", Critical: true, Run: ok}, {Name: "redis", Run: fail}},
+			want:   StatusDegraded,
+		},
+		{
+			name:   "critical failure is not ready",
+			checks: []Check{{Name: "db", Critical: true, Run: fail}, {Name: "redis", Run: fail}},
+			want:   StatusNotReady,
+		},
+		{
+			name: "skipped checks do not degrade",
+			checks: []Check{{Name: "db", Critical: true, Run: ok}, {Name: "redis", Run: func(context.Context) error {
+				return ErrSkipped
+			}}},
+			want: StatusReady,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checker := NewChecker(time.Second)
+			for _, c := range tt.checks {
+				checker.Register(c)
+			}
+			report := checker.Run(context.Background())
+			if report.Status != tt.want {
+				t.Errorf("Status = %s, want %s", report.Status, tt.want)
+			}
+			if len(report.Checks) != len(tt.checks) {
+				t.Errorf("got %d check results, want %d", len(report.Checks), len(tt.checks))
+			}
+		})
+	}
+}
+
+func TestChecker_Timeout(t *testing.T) {
+	checker := NewChecker(20 * time.Millisecond)
+	checker.Register(Check{Name: "slow", Critical: true, Run: func(context.Context) error {
+		time.Sleep(time.Second)
+		return nil
+	}})
+
+	report := checker.Run(context.Background())
+	if report.Status != StatusNotReady {
+		t.Errorf("Status = %s, want %s", report.Status, StatusNotReady)
+	}
+	if report.Checks["slow"].Error == "" {
+		t.Error("expected timeout error on slow check")
+	}
+}
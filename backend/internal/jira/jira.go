@@ -0,0 +1,213 @@
+// Package jira creates and reads issues through the Jira REST API, version 2,
+// which Jira Cloud, Server and Data Center all serve.
+package jira
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+var ErrNotConfigured = errors.New("jira is not configured")
+
+// Status categories Jira groups every workflow status into
+const (
+	StatusCategoryNew        = "new"
+	StatusCategoryInProgress = "indeterminate"
+	StatusCategoryDone       = "done"
+)
+
+// Config holds the credentials of an organization's Jira integration. With
+// an email, the API token is sent with basic authentication as Jira Cloud
+// expects; without one it is sent as a personal access token, as Jira Server
+// and Data Center expect.
+type Config struct {
+	BaseURL  string
+	Email    string
+	APIToken string
+}
+
+// IssueRequest describes an issue to create
+type IssueRequest struct {
+	ProjectKey  string
+	IssueType   string
+	Summary     string
+	Description string
+	Labels      []string
+}
+
+// Issue is a Jira issue and its current status
+type Issue struct {
+	Key            string
+	URL            string
+	Status         string
+	StatusCategory string
+}
+
+// Tracker creates and reads issues
+type Tracker interface {
+	CreateIssue(ctx context.Context, cfg Config, req IssueRequest) (*Issue, error)
+	GetIssue(ctx context.Context, cfg Config, key string) (*Issue, error)
+	CheckProject(ctx context.Context, cfg Config, projectKey string) error
+}
+
+// Client talks to Jira over HTTPS
+type Client struct {
+	httpClient *http.Client
+}
+
+// NewClient creates a client whose requests give up after timeout
+func NewClient(timeout time.Duration) *Client {
+	return &Client{httpClient: &http.Client{Timeout: timeout}}
+}
+
+type createRequest struct {
+	Fields createFields `json:"fields"`
+}
+
+type createFields struct {
+	Project     keyRef   `json:"project"`
+	IssueType   nameRef  `json:"issuetype"`
+	Summary     string   `json:"summary"`
+	Description string   `json:"description,omitempty"`
+	Labels      []string `json:"labels,omitempty"`
+}
+
+type keyRef struct {
+	Key string `json:"key"`
+}
+
+type nameRef struct {
+	Name string `json:"name"`
+}
+
+type issueResponse struct {
+	Key    string `json:"key"`
+	Fields struct {
+		Status struct {
+			Name           string `json:"name"`
+			StatusCategory struct {
+				Key string `json:"key"`
+			} `json:"statusCategory"`
+		} `json:"status"`
+	} `json:"fields"`
+}
+
+// CreateIssue implements Tracker. Jira does not return the status of a new
+// issue, so Status and StatusCategory are left empty.
+func (c *Client) CreateIssue(ctx context.Context, cfg Config, req IssueRequest) (*Issue, error) {
+	body := createRequest{Fields: createFields{
+		Project:     keyRef{Key: req.ProjectKey},
+		IssueType:   nameRef{Name: req.IssueType},
+		Summary:     req.Summary,
+		Description: req.Description,
+		Labels:      req.Labels,
+	}}
+
+	var created issueResponse
+	if err := c.do(ctx, cfg, http.MethodPost, "/rest/api/2/issue", body, &created); err != nil {
+		return nil, err
+	}
+	return &Issue{Key: created.Key, URL: BrowseURL(cfg.BaseURL, created.Key)}, nil
+}
+
+// GetIssue implements Tracker
+func (c *Client) GetIssue(ctx context.Context, cfg Config, key string) (*Issue, error) {
+	var issue issueResponse
+	if err := c.do(ctx, cfg, http.MethodGet, "/rest/api/2/issue/"+url.PathEscape(key)+"?fields=status", nil, &issue); err != nil {
+		return nil, err
+	}
+	return &Issue{
+		Key:            issue.Key,
+		URL:            BrowseURL(cfg.BaseURL, issue.Key),
+		Status:         issue.Fields.Status.Name,
+		StatusCategory: issue.Fields.Status.StatusCategory.Key,
+	}, nil
+}
+
+// CheckProject implements Tracker, failing unless the credentials can see
+// the project
+func (c *Client) CheckProject(ctx context.Context, cfg Config, projectKey string) error {
+	return c.do(ctx, cfg, http.MethodGet, "/rest/api/2/project/"+url.PathEscape(projectKey), nil, nil)
+}
+
+func (c *Client) do(ctx context.Context, cfg Config, method, path string, in, out interface{}) error {
+	if cfg.BaseURL == "" || cfg.APIToken == "" {
+		return ErrNotConfigured
+	}
+
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, strings.TrimRight(cfg.BaseURL, "/")+path, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if cfg.Email != "" {
+		req.SetBasicAuth(cfg.Email, cfg.APIToken)
+	} else {
+		req.Header.Set("Authorization", "Bearer "+cfg.APIToken)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach jira: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("jira returned %d: %s", resp.StatusCode, errorMessage(resp.Body))
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode jira response: %w", err)
+	}
+	return nil
+}
+
+// errorMessage reads the messages of a Jira error response, falling back to
+// the start of the body
+func errorMessage(r io.Reader) string {
+	data, _ := io.ReadAll(io.LimitReader(r, 4096))
+	var result struct {
+		ErrorMessages []string          `json:"errorMessages"`
+		Errors        map[string]string `json:"errors"`
+	}
+	if err := json.Unmarshal(data, &result); err == nil {
+		messages := append([]string{}, result.ErrorMessages...)
+		for field, msg := range result.Errors {
+			messages = append(messages, field+": "+msg)
+		}
+		if len(messages) > 0 {
+			return strings.Join(messages, "; ")
+		}
+	}
+	if len(data) > 512 {
+		data = data[:512]
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// BrowseURL returns the web address of an issue
+func BrowseURL(baseURL, key string) string {
+	return strings.TrimRight(baseURL, "/") + "/browse/" + key
+}
@@ -0,0 +1,78 @@
+package jira
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestClient(t *testing.T) {
+	var gotUser, gotPass, gotBearer string
+	var created createRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotUser, gotPass, _ = r.BasicAuth()
+		gotBearer = r.Header.Get("Authorization")
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/rest/api/2/issue":
+			json.NewDecoder(r.Body).Decode(&created)
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"id":"10001","key":"OPS-7"}`))
+		case r.URL.Path == "/rest/api/2/issue/OPS-7":
+			w.Write([]byte(`{"key":"OPS-7","fields":{"status":{"name":"In Progress","statusCategory":{"key":"indeterminate"}}}}`))
+		case r.URL.Path == "/rest/api/2/project/OPS":
+			w.Write([]byte(`{"key":"OPS"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"errorMessages":["No project could be found with key 'NOPE'."],"errors":{}}`))
+		}
+	}))
+	defer srv.Close()
+
+	c := NewClient(time.Second)
+	ctx := context.Background()
+	cfg := Config{BaseURL: srv.URL + "/", Email: "bot@example.com", APIToken: "secret"}
+
+	issue, err := c.CreateIssue(ctx, cfg, IssueRequest{
+		ProjectKey: "OPS", IssueType: "Task", Summary: "Assign an owner", Labels: []string{"kubeatlas"},
+	})
+	if err != nil {
+		t.Fatalf("CreateIssue() error = %v", err)
+	}
+	if issue.Key != "OPS-7" || issue.URL != srv.URL+"/browse/OPS-7" {
+		t.Errorf("CreateIssue() = %+v", issue)
+	}
+	if created.Fields.Project.Key != "OPS" || created.Fields.IssueType.Name != "Task" || created.Fields.Summary != "Assign an owner" {
+		t.Errorf("create request = %+v", created)
+	}
+	if gotUser != "bot@example.com" || gotPass != "secret" {
+		t.Errorf("basic auth = %q:%q", gotUser, gotPass)
+	}
+
+	// Without an email the token is a personal access token
+	issue, err = c.GetIssue(ctx, Config{BaseURL: srv.URL, APIToken: "pat"}, "OPS-7")
+	if err != nil {
+		t.Fatalf("GetIssue() error = %v", err)
+	}
+	if issue.Status != "In Progress" || issue.StatusCategory != StatusCategoryInProgress {
+		t.Errorf("GetIssue() = %+v", issue)
+	}
+	if gotBearer != "Bearer pat" {
+		t.Errorf("Authorization = %q", gotBearer)
+	}
+
+	if err := c.CheckProject(ctx, cfg, "OPS"); err != nil {
+		t.Errorf("CheckProject(OPS) error = %v", err)
+	}
+	err = c.CheckProject(ctx, cfg, "NOPE")
+	if err == nil || !strings.Contains(err.Error(), "No project could be found") {
+		t.Errorf("CheckProject(NOPE) error = %v", err)
+	}
+
+	if _, err := c.GetIssue(ctx, Config{}, "OPS-7"); err != ErrNotConfigured {
+		t.Errorf("unconfigured GetIssue() error = %v, want ErrNotConfigured", err)
+	}
+}
@@ -0,0 +1,83 @@
+package jobs
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/kubeatlas/kubeatlas/internal/telemetry"
+	"go.uber.org/zap"
+)
+
+// Job is a unit of background work
+type Job func(ctx context.Context) error
+
+type scheduledJob struct {
+	name     string
+	interval time.Duration
+	job      Job
+}
+
+// Scheduler runs registered jobs periodically until its context is cancelled
+type Scheduler struct {
+	jobs   []scheduledJob
+	logger *zap.SugaredLogger
+	wg     sync.WaitGroup
+}
+
+// NewScheduler creates a new job scheduler
+func NewScheduler(logger *zap.SugaredLogger) *Scheduler {
+	return &Scheduler{logger: logger}
+}
+
+// Every registers a job that runs once at startup and then on every interval
+func (s *Scheduler) Every(name string, interval time.Duration, job Job) {
+	s.jobs = append(s.jobs, scheduledJob{name: name, interval: interval, job: job})
+}
+
+// Start launches all registered jobs in the background
+func (s *Scheduler) Start(ctx context.Context) {
+	for _, j := range s.jobs {
+		s.wg.Add(1)
+		go s.run(ctx, j)
+	}
+}
+
+// Wait blocks until all jobs have stopped
+func (s *Scheduler) Wait() {
+	s.wg.Wait()
+}
+
+func (s *Scheduler) run(ctx context.Context, j scheduledJob) {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	for {
+		s.execute(ctx, j)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Scheduler) execute(ctx context.Context, j scheduledJob) {
+	defer func() {
+		if r := recover(); r != nil {
+			s.logger.Errorw("Background job panicked", "job", j.name, "panic", r)
+			telemetry.CapturePanic(ctx, r)
+		}
+	}()
+
+	start := time.Now()
+	if err := j.job(ctx); err != nil {
+		s.logger.Errorw("Background job failed", "job", j.name, "error", err, "duration", time.Since(start))
+		telemetry.CaptureError(ctx, err)
+		return
+	}
+	s.logger.Debugw("Background job completed", "job", j.name, "duration", time.Since(start))
+}
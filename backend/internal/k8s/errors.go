@@ -0,0 +1,64 @@
+package k8s
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"net"
+	"strings"
+
+	"github.com/kubeatlas/kubeatlas/internal/models"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+// ClassifyError maps an error returned while talking to a cluster onto one of
+// the models.SyncErrorCategory* values so failures can be triaged in bulk.
+func ClassifyError(err error) string {
+	if err == nil {
+		return ""
+	}
+
+	switch {
+	case apierrors.IsUnauthorized(err):
+		return models.SyncErrorCategoryAuth
+	case apierrors.IsForbidden(err):
+		return models.SyncErrorCategoryRBAC
+	case apierrors.IsTimeout(err), apierrors.IsServerTimeout(err), errors.Is(err, context.DeadlineExceeded):
+		return models.SyncErrorCategoryTimeout
+	}
+
+	var (
+		verifyErr    *tls.CertificateVerificationError
+		unknownCA    x509.UnknownAuthorityError
+		invalidCert  x509.CertificateInvalidError
+		hostnameErr  x509.HostnameError
+		recordHdrErr tls.RecordHeaderError
+	)
+	if errors.As(err, &verifyErr) || errors.As(err, &unknownCA) || errors.As(err, &invalidCert) ||
+		errors.As(err, &hostnameErr) || errors.As(err, &recordHdrErr) {
+		return models.SyncErrorCategoryTLS
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return models.SyncErrorCategoryTimeout
+	}
+	var opErr *net.OpError
+	var dnsErr *net.DNSError
+	if errors.As(err, &opErr) || errors.As(err, &dnsErr) {
+		return models.SyncErrorCategoryUnreachable
+	}
+
+	// Client construction errors are wrapped with fmt.Errorf and carry no type
+	msg := err.Error()
+	switch {
+	case strings.Contains(msg, "kubeconfig"), strings.Contains(msg, "service account token"),
+		strings.Contains(msg, "CA certificate"), strings.Contains(msg, "in-cluster config"):
+		return models.SyncErrorCategoryConfig
+	case strings.Contains(msg, "connection refused"), strings.Contains(msg, "no such host"):
+		return models.SyncErrorCategoryUnreachable
+	}
+
+	return models.SyncErrorCategoryUnknown
+}
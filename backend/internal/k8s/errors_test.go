@@ -0,0 +1,41 @@
+package k8s
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+
+	"github.com/kubeatlas/kubeatlas/internal/models"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{"nil", nil, ""},
+		{"unauthorized", apierrors.NewUnauthorized("token expired"), models.SyncErrorCategoryAuth},
+		{"forbidden", fmt.Errorf("failed to list namespaces: %w",
+			apierrors.NewForbidden(schema.GroupResource{Resource: "namespaces"}, "", errors.New("denied"))), models.SyncErrorCategoryRBAC},
+		{"api timeout", apierrors.NewTimeoutError("slow", 1), models.SyncErrorCategoryTimeout},
+		{"context deadline", fmt.Errorf("failed to list nodes: %w", context.DeadlineExceeded), models.SyncErrorCategoryTimeout},
+		{"unknown authority", fmt.Errorf("failed to connect to cluster: %w", x509.UnknownAuthorityError{}), models.SyncErrorCategoryTLS},
+		{"dial refused", &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}, models.SyncErrorCategoryUnreachable},
+		{"missing kubeconfig", errors.New("kubeconfig not provided"), models.SyncErrorCategoryConfig},
+		{"other", errors.New("boom"), models.SyncErrorCategoryUnknown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ClassifyError(tt.err); got != tt.want {
+				t.Errorf("ClassifyError() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
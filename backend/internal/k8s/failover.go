@@ -0,0 +1,126 @@
+package k8s
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/kubeatlas/kubeatlas/internal/metrics"
+	"go.uber.org/zap"
+)
+
+// failoverDialTimeout bounds connecting to an API server endpoint of a
+// cluster with fallbacks, so an unreachable one leaves time to try the next
+// within the request timeout
+const failoverDialTimeout = 5 * time.Second
+
+// apiEndpoints are the API server URLs of a cluster, the primary first, and
+// the one requests currently go to. The transports of a client share them,
+// so a failover found by one call applies to the next.
+type apiEndpoints struct {
+	urls      []*url.URL
+	active    atomic.Int32
+	clusterID string
+	cluster   string // name, as metric label
+	logger    *zap.SugaredLogger
+}
+
+// newAPIEndpoints parses the primary API server URL and its fallbacks,
+// skipping fallbacks that repeat an earlier URL
+func newAPIEndpoints(clusterID, cluster, primary string, fallbacks []string, logger *zap.SugaredLogger) (*apiEndpoints, error) {
+	e := &apiEndpoints{clusterID: clusterID, cluster: cluster, logger: logger}
+	seen := make(map[string]bool)
+	for i, raw := range append([]string{primary}, fallbacks...) {
+		// A kubeconfig server may leave out the scheme
+		if !strings.Contains(raw, "://") {
+			raw = "https://" + raw
+		}
+		u, err := url.Parse(raw)
+		if err != nil || u.Host == "" {
+			if i == 0 {
+				return nil, fmt.Errorf("invalid API server URL %q", raw)
+			}
+			return nil, fmt.Errorf("invalid fallback API server URL %q", raw)
+		}
+		u.Path = strings.TrimSuffix(u.Path, "/")
+		if key := u.String(); !seen[key] {
+			seen[key] = true
+			e.urls = append(e.urls, u)
+		}
+	}
+	return e, nil
+}
+
+// current returns the URL requests go to
+func (e *apiEndpoints) current() *url.URL {
+	return e.urls[e.active.Load()]
+}
+
+// wrap is a rest.Config WrapTransport that fails requests over between the
+// endpoints
+func (e *apiEndpoints) wrap(rt http.RoundTripper) http.RoundTripper {
+	return &failoverTransport{endpoints: e, base: rt}
+}
+
+// failoverTransport sends a request to the current endpoint and, when it
+// cannot be reached, to the others in order until one answers, which becomes
+// the current one. Any response counts as an answer: only transport errors
+// such as refused connections, DNS, TLS or timeouts fail over.
+type failoverTransport struct {
+	endpoints *apiEndpoints
+	base      http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper. Requests are built against the
+// primary URL and moved to the others' scheme, host and path prefix.
+func (t *failoverTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	e := t.endpoints
+	start := int(e.active.Load())
+	var lastErr error
+	for i := range e.urls {
+		n := (start + i) % len(e.urls)
+		attempt := rebaseRequest(req, e.urls[0], e.urls[n])
+		if i > 0 && req.Body != nil && req.Body != http.NoBody {
+			// The body went with the first attempt
+			if req.GetBody == nil {
+				break
+			}
+			body, err := req.GetBody()
+			if err != nil {
+				break
+			}
+			attempt.Body = body
+		}
+
+		resp, err := t.base.RoundTrip(attempt)
+		if err == nil {
+			if n != start && e.active.CompareAndSwap(int32(start), int32(n)) {
+				e.logger.Warnw("Failed over to another API server endpoint", "cluster_id", e.clusterID,
+					"from", e.urls[start].String(), "to", e.urls[n].String(), "error", lastErr)
+				metrics.ObserveK8sFailover(e.cluster)
+			}
+			return resp, nil
+		}
+		lastErr = err
+		if req.Context().Err() != nil {
+			break
+		}
+	}
+	return nil, lastErr
+}
+
+// rebaseRequest returns req moved from the endpoint from to the endpoint to
+func rebaseRequest(req *http.Request, from, to *url.URL) *http.Request {
+	if from == to {
+		return req
+	}
+	r := req.Clone(req.Context())
+	r.URL.Scheme, r.URL.Host = to.Scheme, to.Host
+	r.URL.Path = to.Path + strings.TrimPrefix(req.URL.Path, from.Path)
+	r.URL.RawPath = ""
+	r.Host = ""
+	return r
+}
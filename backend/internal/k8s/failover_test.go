@@ -0,0 +1,69 @@
+package k8s
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+func TestNewAPIEndpoints(t *testing.T) {
+	e, err := newAPIEndpoints("id", "prod", "10.0.0.1:6443", []string{"https://api.example.com/", "https://10.0.0.1:6443"}, zap.NewNop().Sugar())
+	if err != nil {
+		t.Fatalf("newAPIEndpoints() error = %v", err)
+	}
+	var got []string
+	for _, u := range e.urls {
+		got = append(got, u.String())
+	}
+	if len(got) != 2 || got[0] != "https://10.0.0.1:6443" || got[1] != "https://api.example.com" {
+		t.Errorf("urls = %v", got)
+	}
+
+	if _, err := newAPIEndpoints("id", "prod", "https://10.0.0.1:6443", []string{"https://"}, zap.NewNop().Sugar()); err == nil {
+		t.Error("newAPIEndpoints() with a fallback without host: want error")
+	}
+}
+
+func TestFailoverTransport(t *testing.T) {
+	down := httptest.NewServer(http.NotFoundHandler())
+	down.Close()
+
+	var paths []string
+	up := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer up.Close()
+
+	e, err := newAPIEndpoints("id", "prod", down.URL, []string{up.URL + "/proxy/prod"}, zap.NewNop().Sugar())
+	if err != nil {
+		t.Fatalf("newAPIEndpoints() error = %v", err)
+	}
+	client := &http.Client{Transport: e.wrap(http.DefaultTransport)}
+
+	for i := 0; i < 2; i++ {
+		resp, err := client.Get(down.URL + "/api/v1/namespaces")
+		if err != nil {
+			t.Fatalf("Get() error = %v", err)
+		}
+		resp.Body.Close()
+		// Any answer is kept, even an error status
+		if resp.StatusCode != http.StatusServiceUnavailable {
+			t.Errorf("status = %d, want %d", resp.StatusCode, http.StatusServiceUnavailable)
+		}
+	}
+	if len(paths) != 2 || paths[0] != "/proxy/prod/api/v1/namespaces" {
+		t.Errorf("fallback paths = %v", paths)
+	}
+	if got := e.current().String(); got != up.URL+"/proxy/prod" {
+		t.Errorf("current() = %q, want the fallback", got)
+	}
+
+	e.active.Store(0)
+	up.Close()
+	if _, err := client.Get(down.URL + "/api/v1/namespaces"); err == nil {
+		t.Error("Get() with every endpoint down: want error")
+	}
+}
@@ -0,0 +1,151 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// Flux API versions DiscoverFlux reads, newest first. The first one a
+// cluster serves is used.
+var (
+	kustomizationVersions = []schema.GroupVersionResource{
+		{Group: "kustomize.toolkit.fluxcd.io", Version: "v1", Resource: "kustomizations"},
+		{Group: "kustomize.toolkit.fluxcd.io", Version: "v1beta2", Resource: "kustomizations"},
+	}
+	helmReleaseVersions = []schema.GroupVersionResource{
+		{Group: "helm.toolkit.fluxcd.io", Version: "v2", Resource: "helmreleases"},
+		{Group: "helm.toolkit.fluxcd.io", Version: "v2beta2", Resource: "helmreleases"},
+		{Group: "helm.toolkit.fluxcd.io", Version: "v2beta1", Resource: "helmreleases"},
+	}
+)
+
+// DiscoveredFluxResource is a Flux Kustomization or HelmRelease discovered
+// from Kubernetes
+type DiscoveredFluxResource struct {
+	Kind            string
+	Name            string
+	SourceKind      string
+	SourceName      string
+	SourceNamespace string
+	// Path is the path of a Kustomization in its source, or the chart of a
+	// HelmRelease
+	Path     string
+	Revision string
+	// Ready is the status of the Ready condition: True, False or Unknown
+	Ready          string
+	ReadyReason    string
+	ReadyMessage   string
+	ReadyChangedAt time.Time
+	Suspended      bool
+}
+
+// DiscoverFlux discovers the Flux Kustomizations and HelmReleases of every
+// namespace in the cluster, by namespace name. Clusters without Flux have
+// none.
+func (c *Client) DiscoverFlux(ctx context.Context) (map[string][]DiscoveredFluxResource, error) {
+	found := make(map[string][]DiscoveredFluxResource)
+	for _, versions := range [][]schema.GroupVersionResource{kustomizationVersions, helmReleaseVersions} {
+		items, err := c.listServed(ctx, versions)
+		if err != nil {
+			return nil, err
+		}
+		for i := range items {
+			found[items[i].GetNamespace()] = append(found[items[i].GetNamespace()], fluxResource(&items[i]))
+		}
+	}
+	return found, nil
+}
+
+// listServed lists the objects of the first of versions the cluster serves.
+// Served versions are looked up through discovery, which every user may
+// read, so that clusters without Flux are not mistaken for ones the service
+// account lacks permissions on.
+func (c *Client) listServed(ctx context.Context, versions []schema.GroupVersionResource) ([]unstructured.Unstructured, error) {
+	for _, gvr := range versions {
+		_, err := c.clientset.Discovery().ServerResourcesForGroupVersion(gvr.GroupVersion().String())
+		if apierrors.IsNotFound(err) {
+			continue
+		}
+		c.observe(err)
+		if err != nil {
+			return nil, fmt.Errorf("failed to discover %s: %w", gvr.GroupVersion(), err)
+		}
+
+		list, err := c.dynamic.Resource(gvr).Namespace(metav1.NamespaceAll).List(ctx, metav1.ListOptions{})
+		c.observe(err)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list %s: %w", gvr.GroupResource(), err)
+		}
+		return list.Items, nil
+	}
+	return nil, nil
+}
+
+// fluxResource reads the source, revision and readiness of a Kustomization
+// or HelmRelease
+func fluxResource(obj *unstructured.Unstructured) DiscoveredFluxResource {
+	r := DiscoveredFluxResource{
+		Kind:  obj.GetKind(),
+		Name:  obj.GetName(),
+		Ready: string(metav1.ConditionUnknown),
+	}
+
+	o := obj.Object
+	r.Suspended, _, _ = unstructured.NestedBool(o, "spec", "suspend")
+	sourceRef := []string{"spec", "sourceRef"}
+	if r.Kind == "HelmRelease" {
+		// HelmReleases name a chart in a source, or since v2 reference a
+		// HelmChart or OCIRepository directly
+		sourceRef = []string{"spec", "chart", "spec", "sourceRef"}
+		if _, ok, _ := unstructured.NestedMap(o, "spec", "chartRef"); ok {
+			sourceRef = []string{"spec", "chartRef"}
+		}
+		r.Path, _, _ = unstructured.NestedString(o, "spec", "chart", "spec", "chart")
+	} else {
+		r.Path, _, _ = unstructured.NestedString(o, "spec", "path")
+	}
+	r.SourceKind, _, _ = unstructured.NestedString(o, append(sourceRef, "kind")...)
+	r.SourceName, _, _ = unstructured.NestedString(o, append(sourceRef, "name")...)
+	r.SourceNamespace, _, _ = unstructured.NestedString(o, append(sourceRef, "namespace")...)
+	if r.SourceNamespace == "" && r.SourceName != "" {
+		r.SourceNamespace = obj.GetNamespace()
+	}
+
+	r.Revision, _, _ = unstructured.NestedString(o, "status", "lastAppliedRevision")
+	if r.Revision == "" {
+		// HelmRelease v2 keeps the chart version of each release instead
+		if history, _, _ := unstructured.NestedSlice(o, "status", "history"); len(history) > 0 {
+			if latest, ok := history[0].(map[string]interface{}); ok {
+				r.Revision, _, _ = unstructured.NestedString(latest, "chartVersion")
+			}
+		}
+	}
+
+	conditions, _, _ := unstructured.NestedSlice(o, "status", "conditions")
+	for _, c := range conditions {
+		condition, ok := c.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if t, _, _ := unstructured.NestedString(condition, "type"); t != "Ready" {
+			continue
+		}
+		if status, _, _ := unstructured.NestedString(condition, "status"); status != "" {
+			r.Ready = status
+		}
+		r.ReadyReason, _, _ = unstructured.NestedString(condition, "reason")
+		r.ReadyMessage, _, _ = unstructured.NestedString(condition, "message")
+		if changed, _, _ := unstructured.NestedString(condition, "lastTransitionTime"); changed != "" {
+			r.ReadyChangedAt, _ = time.Parse(time.RFC3339, changed)
+		}
+		break
+	}
+
+	return r
+}
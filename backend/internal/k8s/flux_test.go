@@ -0,0 +1,75 @@
+package k8s
+
+import (
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestFluxResource(t *testing.T) {
+	kustomization := &unstructured.Unstructured{Object: map[string]interface{}{
+		"kind":     "Kustomization",
+		"metadata": map[string]interface{}{"name": "payments", "namespace": "payments"},
+		"spec": map[string]interface{}{
+			"path":      "./deploy/production",
+			"sourceRef": map[string]interface{}{"kind": "GitRepository", "name": "payments"},
+		},
+		"status": map[string]interface{}{
+			"lastAppliedRevision": "main@sha1:abc123",
+			"conditions": []interface{}{
+				map[string]interface{}{"type": "Reconciling", "status": "False"},
+				map[string]interface{}{
+					"type": "Ready", "status": "False", "reason": "BuildFailed",
+					"message": "kustomization path not found", "lastTransitionTime": "2026-10-15T08:00:00Z",
+				},
+			},
+		},
+	}}
+	got := fluxResource(kustomization)
+	want := DiscoveredFluxResource{
+		Kind: "Kustomization", Name: "payments",
+		SourceKind: "GitRepository", SourceName: "payments", SourceNamespace: "payments",
+		Path: "./deploy/production", Revision: "main@sha1:abc123",
+		Ready: "False", ReadyReason: "BuildFailed", ReadyMessage: "kustomization path not found",
+		ReadyChangedAt: time.Date(2026, 10, 15, 8, 0, 0, 0, time.UTC),
+	}
+	if got != want {
+		t.Errorf("fluxResource(Kustomization) = %+v, want %+v", got, want)
+	}
+
+	helmRelease := &unstructured.Unstructured{Object: map[string]interface{}{
+		"kind":     "HelmRelease",
+		"metadata": map[string]interface{}{"name": "redis", "namespace": "cache"},
+		"spec": map[string]interface{}{
+			"suspend": true,
+			"chart": map[string]interface{}{"spec": map[string]interface{}{
+				"chart":     "redis",
+				"sourceRef": map[string]interface{}{"kind": "HelmRepository", "name": "bitnami", "namespace": "flux-system"},
+			}},
+		},
+		"status": map[string]interface{}{
+			"history": []interface{}{
+				map[string]interface{}{"chartVersion": "18.1.0"},
+				map[string]interface{}{"chartVersion": "18.0.2"},
+			},
+		},
+	}}
+	got = fluxResource(helmRelease)
+	want = DiscoveredFluxResource{
+		Kind: "HelmRelease", Name: "redis",
+		SourceKind: "HelmRepository", SourceName: "bitnami", SourceNamespace: "flux-system",
+		Path: "redis", Revision: "18.1.0", Ready: "Unknown", Suspended: true,
+	}
+	if got != want {
+		t.Errorf("fluxResource(HelmRelease) = %+v, want %+v", got, want)
+	}
+
+	helmRelease.Object["spec"] = map[string]interface{}{
+		"chartRef": map[string]interface{}{"kind": "OCIRepository", "name": "podinfo"},
+	}
+	got = fluxResource(helmRelease)
+	if got.SourceKind != "OCIRepository" || got.SourceName != "podinfo" || got.SourceNamespace != "cache" || got.Path != "" {
+		t.Errorf("fluxResource(HelmRelease with chartRef) = %+v", got)
+	}
+}
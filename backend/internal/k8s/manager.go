@@ -4,26 +4,49 @@ import (
 	"context"
 	"crypto/tls"
 	"fmt"
+	"net"
 	"net/http"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/kubeatlas/kubeatlas/internal/crypto"
+	"github.com/kubeatlas/kubeatlas/internal/metrics"
 	"github.com/kubeatlas/kubeatlas/internal/models"
+	"github.com/kubeatlas/kubeatlas/internal/telemetry"
 	"go.uber.org/zap"
 	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
 )
 
+// Client cache defaults
+const (
+	defaultClientTTL = 30 * time.Minute
+
+	// maxClientFailures consecutive failed calls evict a cached client
+	maxClientFailures = 3
+)
+
+// Client cache eviction reasons, used as metric labels
+const (
+	evictReasonStale     = "stale"
+	evictReasonExpired   = "expired"
+	evictReasonUnhealthy = "unhealthy"
+	evictReasonRemoved   = "removed"
+)
+
 // Manager manages Kubernetes client connections
 type Manager struct {
 	clients   map[string]*Client
 	mu        sync.RWMutex
 	logger    *zap.SugaredLogger
 	encryptor *crypto.Encryptor
+	clientTTL time.Duration
 }
 
 // ManagerOption is a functional option for Manager
@@ -36,12 +59,29 @@ func WithEncryptor(encryptor *crypto.Encryptor) ManagerOption {
 	}
 }
 
+// WithClientTTL sets how long a cached client is reused before it is rebuilt
+func WithClientTTL(ttl time.Duration) ManagerOption {
+	return func(m *Manager) {
+		if ttl > 0 {
+			m.clientTTL = ttl
+		}
+	}
+}
+
 // Client wraps kubernetes clientset with additional functionality
 type Client struct {
 	clientset *kubernetes.Clientset
+	dynamic   dynamic.Interface // reads custom resources such as Flux's
 	config    *rest.Config
 	cluster   *models.Cluster
 	logger    *zap.SugaredLogger
+	endpoints *apiEndpoints // nil without fallback API server URLs
+
+	// Cache bookkeeping
+	createdAt        time.Time
+	clusterUpdatedAt time.Time // cluster.UpdatedAt the client was built from
+	failures         atomic.Int32
+	invalid          atomic.Bool // set on auth/TLS failures; credentials need reloading
 }
 
 // DiscoveredNamespace represents a namespace discovered from Kubernetes
@@ -68,13 +108,51 @@ type DiscoveredNode struct {
 	Architecture   string
 	Capacity       map[string]string
 	Allocatable    map[string]string
+
+	// CPU in millicores, memory in bytes and pods of Capacity and
+	// Allocatable
+	CPUCapacityMillicores    int64
+	CPUAllocatableMillicores int64
+	MemoryCapacityBytes      int64
+	MemoryAllocatableBytes   int64
+	PodsCapacity             int64
+	PodsAllocatable          int64
+}
+
+// DiscoveredBinding is a subject bound to a role by a RoleBinding or
+// ClusterRoleBinding
+type DiscoveredBinding struct {
+	BindingKind      string
+	BindingName      string
+	RoleKind         string
+	RoleName         string
+	SubjectKind      string
+	SubjectName      string
+	SubjectNamespace string
+}
+
+// DiscoveredServiceAccount represents a service account discovered from
+// Kubernetes
+type DiscoveredServiceAccount struct {
+	Name      string
+	UID       string
+	CreatedAt time.Time
+}
+
+// DiscoveredAccess is the RBAC inventory of a cluster, by namespace name.
+// A namespace's bindings are those of its RoleBindings, and those of
+// ClusterRoleBindings that bind its service accounts.
+type DiscoveredAccess struct {
+	Bindings        map[string][]DiscoveredBinding
+	ServiceAccounts map[string][]DiscoveredServiceAccount
 }
 
 // NewManager creates a new Kubernetes client manager
 func NewManager(logger *zap.SugaredLogger, opts ...ManagerOption) *Manager {
 	m := &Manager{
-		clients: make(map[string]*Client),
-		logger:  logger,
+		clients:   make(map[string]*Client),
+		logger:    logger,
+		clientTTL: defaultClientTTL,
 	}
 
 	for _, opt := range opts {
@@ -84,15 +162,26 @@ func NewManager(logger *zap.SugaredLogger, opts ...ManagerOption) *Manager {
 	return m
 }
 
-// GetClient returns a Kubernetes client for the given cluster
+// GetClient returns a Kubernetes client for the given cluster. A cached client
+// is reused only while it was built from the cluster's current UpdatedAt, is
+// younger than the TTL and has not been marked unhealthy.
 func (m *Manager) GetClient(cluster *models.Cluster) (*Client, error) {
+	key := cluster.ID.String()
+
 	m.mu.RLock()
-	if client, exists := m.clients[cluster.ID.String()]; exists {
-		m.mu.RUnlock()
-		return client, nil
-	}
+	client, exists := m.clients[key]
 	m.mu.RUnlock()
 
+	if exists {
+		reason := m.evictionReason(client, cluster.UpdatedAt, time.Now())
+		if reason == "" {
+			metrics.ObserveK8sClientLookup(true)
+			return client, nil
+		}
+		m.evict(key, client, reason)
+	}
+	metrics.ObserveK8sClientLookup(false)
+
 	// Create new client
 	client, err := m.createClient(cluster)
 	if err != nil {
@@ -101,17 +190,84 @@ func (m *Manager) GetClient(cluster *models.Cluster) (*Client, error) {
 
 	// Cache client
 	m.mu.Lock()
-	m.clients[cluster.ID.String()] = client
+	m.clients[key] = client
+	metrics.SetCachedK8sClients(len(m.clients))
 	m.mu.Unlock()
 
 	return client, nil
 }
 
-// RemoveClient removes a cached client
+// NewClient returns a client for a cluster that is not cached, e.g. to try
+// the credentials of a cluster before it is stored
+func (m *Manager) NewClient(cluster *models.Cluster) (*Client, error) {
+	return m.createClient(cluster)
+}
+
+// RemoveClient removes a cached client, e.g. after its cluster was updated or deleted
 func (m *Manager) RemoveClient(clusterID string) {
+	m.mu.RLock()
+	client, exists := m.clients[clusterID]
+	m.mu.RUnlock()
+
+	if exists {
+		m.evict(clusterID, client, evictReasonRemoved)
+	}
+}
+
+// EvictExpired drops cached clients that are past their TTL or unhealthy.
+// Staleness against UpdatedAt is checked lazily in GetClient.
+func (m *Manager) EvictExpired() int {
+	now := time.Now()
+
+	m.mu.RLock()
+	expired := make(map[string]*Client)
+	reasons := make(map[string]string)
+	for key, client := range m.clients {
+		if reason := m.evictionReason(client, client.clusterUpdatedAt, now); reason != "" {
+			expired[key] = client
+			reasons[key] = reason
+		}
+	}
+	m.mu.RUnlock()
+
+	for key, client := range expired {
+		m.evict(key, client, reasons[key])
+	}
+	return len(expired)
+}
+
+// evictionReason returns why client must not be reused, or "" if it is fine
+func (m *Manager) evictionReason(client *Client, clusterUpdatedAt time.Time, now time.Time) string {
+	switch {
+	case !client.clusterUpdatedAt.Equal(clusterUpdatedAt):
+		return evictReasonStale
+	case client.invalid.Load() || client.failures.Load() >= maxClientFailures:
+		return evictReasonUnhealthy
+	case now.Sub(client.createdAt) > m.clientTTL:
+		return evictReasonExpired
+	}
+	return ""
+}
+
+// evict removes client from the cache unless it was already replaced
+func (m *Manager) evict(key string, client *Client, reason string) {
 	m.mu.Lock()
-	delete(m.clients, clusterID)
-	m.mu.Unlock()
+	defer m.mu.Unlock()
+
+	if current, exists := m.clients[key]; !exists || current != client {
+		return
+	}
+	delete(m.clients, key)
+	metrics.SetCachedK8sClients(len(m.clients))
+	metrics.ObserveK8sClientEviction(reason)
+	m.logger.Debugw("Evicted cached Kubernetes client", "cluster_id", key, "reason", reason)
+}
+
+// CachedClients returns the number of cached clients
+func (m *Manager) CachedClients() int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return len(m.clients)
 }
 
 // createClient creates a new Kubernetes client for the cluster
@@ -194,26 +350,83 @@ func (m *Manager) createClient(cluster *models.Cluster) (*Client, error) {
 		}
 	}
 
+	// Fail over to the fallback API server URLs when the primary, from the
+	// cluster or its kubeconfig, cannot be reached
+	var endpoints *apiEndpoints
+	if len(cluster.FallbackAPIServerURLs) > 0 {
+		endpoints, err = newAPIEndpoints(cluster.ID.String(), cluster.Name, config.Host, cluster.FallbackAPIServerURLs, m.logger)
+		if err != nil {
+			return nil, err
+		}
+		config.Dial = (&net.Dialer{Timeout: failoverDialTimeout, KeepAlive: 30 * time.Second}).DialContext
+	}
+
 	// Set timeouts
 	config.Timeout = 30 * time.Second
 
+	// Trace every call made to the API server, each endpoint tried included
+	config.Wrap(telemetry.WrapTransport)
+	if endpoints != nil {
+		config.Wrap(endpoints.wrap)
+	}
+
 	// Create clientset
 	clientset, err := kubernetes.NewForConfig(config)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create kubernetes clientset: %w", err)
 	}
+	dynamicClient, err := dynamic.NewForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create kubernetes dynamic client: %w", err)
+	}
 
 	return &Client{
-		clientset: clientset,
-		config:    config,
-		cluster:   cluster,
-		logger:    m.logger,
+		clientset:        clientset,
+		dynamic:          dynamicClient,
+		config:           config,
+		cluster:          cluster,
+		logger:           m.logger,
+		endpoints:        endpoints,
+		createdAt:        time.Now(),
+		clusterUpdatedAt: cluster.UpdatedAt,
 	}, nil
 }
 
+// Endpoint returns the API server URL the client's calls go to, which is a
+// fallback one once the primary could not be reached
+func (c *Client) Endpoint() string {
+	if c.endpoints == nil {
+		return c.config.Host
+	}
+	return c.endpoints.current().String()
+}
+
+// FailedOver reports whether the client's calls go to a fallback API server
+// URL because the primary could not be reached
+func (c *Client) FailedOver() bool {
+	return c.endpoints != nil && c.endpoints.active.Load() != 0
+}
+
+// observe tracks call health so failing clients get evicted from the cache.
+// Credential and TLS failures invalidate the client immediately.
+func (c *Client) observe(err error) {
+	if err == nil {
+		c.failures.Store(0)
+		return
+	}
+
+	switch ClassifyError(err) {
+	case models.SyncErrorCategoryAuth, models.SyncErrorCategoryTLS:
+		c.invalid.Store(true)
+	case models.SyncErrorCategoryTimeout, models.SyncErrorCategoryUnreachable:
+		c.failures.Add(1)
+	}
+}
+
 // TestConnection tests the connection to the Kubernetes cluster
 func (c *Client) TestConnection(ctx context.Context) error {
 	_, err := c.clientset.Discovery().ServerVersion()
+	c.observe(err)
 	if err != nil {
 		return fmt.Errorf("failed to connect to cluster: %w", err)
 	}
@@ -223,8 +436,9 @@ func (c *Client) TestConnection(ctx context.Context) error {
 // GetServerVersion returns the Kubernetes server version
 func (c *Client) GetServerVersion(ctx context.Context) (string, error) {
 	version, err := c.clientset.Discovery().ServerVersion()
+	c.observe(err)
 	if err != nil {
-		return "", err
+		return "", fmt.Errorf("failed to get server version: %w", err)
 	}
 	return version.GitVersion, nil
 }
@@ -232,6 +446,7 @@ func (c *Client) GetServerVersion(ctx context.Context) (string, error) {
 // DiscoverNamespaces discovers all namespaces in the cluster
 func (c *Client) DiscoverNamespaces(ctx context.Context) ([]DiscoveredNamespace, error) {
 	namespaces, err := c.clientset.CoreV1().Namespaces().List(ctx, metav1.ListOptions{})
+	c.observe(err)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list namespaces: %w", err)
 	}
@@ -261,18 +476,10 @@ func (c *Client) DiscoverNamespaces(ctx context.Context) ([]DiscoveredNamespace,
 	return result, nil
 }
 
-// GetNodeCount returns the number of nodes in the cluster
-func (c *Client) GetNodeCount(ctx context.Context) (int, error) {
-	nodes, err := c.clientset.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
-	if err != nil {
-		return 0, fmt.Errorf("failed to list nodes: %w", err)
-	}
-	return len(nodes.Items), nil
-}
-
 // DiscoverNodes discovers all nodes in the cluster
 func (c *Client) DiscoverNodes(ctx context.Context) ([]DiscoveredNode, error) {
 	nodes, err := c.clientset.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	c.observe(err)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list nodes: %w", err)
 	}
@@ -342,12 +549,97 @@ func (c *Client) DiscoverNodes(ctx context.Context) ([]DiscoveredNode, error) {
 			Architecture:   node.Status.NodeInfo.Architecture,
 			Capacity:       capacity,
 			Allocatable:    allocatable,
+
+			CPUCapacityMillicores:    node.Status.Capacity.Cpu().MilliValue(),
+			CPUAllocatableMillicores: node.Status.Allocatable.Cpu().MilliValue(),
+			MemoryCapacityBytes:      node.Status.Capacity.Memory().Value(),
+			MemoryAllocatableBytes:   node.Status.Allocatable.Memory().Value(),
+			PodsCapacity:             node.Status.Capacity.Pods().Value(),
+			PodsAllocatable:          node.Status.Allocatable.Pods().Value(),
 		})
 	}
 
 	return result, nil
 }
 
+// DiscoverAccess discovers the role bindings and service accounts of every
+// namespace in the cluster
+func (c *Client) DiscoverAccess(ctx context.Context) (*DiscoveredAccess, error) {
+	roleBindings, err := c.clientset.RbacV1().RoleBindings(metav1.NamespaceAll).List(ctx, metav1.ListOptions{})
+	c.observe(err)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list role bindings: %w", err)
+	}
+	clusterRoleBindings, err := c.clientset.RbacV1().ClusterRoleBindings().List(ctx, metav1.ListOptions{})
+	c.observe(err)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list cluster role bindings: %w", err)
+	}
+	serviceAccounts, err := c.clientset.CoreV1().ServiceAccounts(metav1.NamespaceAll).List(ctx, metav1.ListOptions{})
+	c.observe(err)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list service accounts: %w", err)
+	}
+
+	return groupAccess(roleBindings.Items, clusterRoleBindings.Items, serviceAccounts.Items), nil
+}
+
+// groupAccess sorts role bindings and service accounts by the namespace
+// they grant access to
+func groupAccess(roleBindings []rbacv1.RoleBinding, clusterRoleBindings []rbacv1.ClusterRoleBinding, serviceAccounts []corev1.ServiceAccount) *DiscoveredAccess {
+	access := &DiscoveredAccess{
+		Bindings:        make(map[string][]DiscoveredBinding),
+		ServiceAccounts: make(map[string][]DiscoveredServiceAccount),
+	}
+
+	for _, rb := range roleBindings {
+		for _, subject := range rb.Subjects {
+			subjectNamespace := subject.Namespace
+			if subject.Kind == rbacv1.ServiceAccountKind && subjectNamespace == "" {
+				subjectNamespace = rb.Namespace
+			}
+			access.Bindings[rb.Namespace] = append(access.Bindings[rb.Namespace], DiscoveredBinding{
+				BindingKind:      "RoleBinding",
+				BindingName:      rb.Name,
+				RoleKind:         rb.RoleRef.Kind,
+				RoleName:         rb.RoleRef.Name,
+				SubjectKind:      subject.Kind,
+				SubjectName:      subject.Name,
+				SubjectNamespace: subjectNamespace,
+			})
+		}
+	}
+
+	// Cluster-wide bindings of users and groups would repeat in every
+	// namespace, so only those of service accounts are kept
+	for _, crb := range clusterRoleBindings {
+		for _, subject := range crb.Subjects {
+			if subject.Kind != rbacv1.ServiceAccountKind || subject.Namespace == "" {
+				continue
+			}
+			access.Bindings[subject.Namespace] = append(access.Bindings[subject.Namespace], DiscoveredBinding{
+				BindingKind:      "ClusterRoleBinding",
+				BindingName:      crb.Name,
+				RoleKind:         crb.RoleRef.Kind,
+				RoleName:         crb.RoleRef.Name,
+				SubjectKind:      subject.Kind,
+				SubjectName:      subject.Name,
+				SubjectNamespace: subject.Namespace,
+			})
+		}
+	}
+
+	for _, sa := range serviceAccounts {
+		access.ServiceAccounts[sa.Namespace] = append(access.ServiceAccounts[sa.Namespace], DiscoveredServiceAccount{
+			Name:      sa.Name,
+			UID:       string(sa.UID),
+			CreatedAt: sa.CreationTimestamp.Time,
+		})
+	}
+
+	return access
+}
+
 // GetNamespaceResources returns resources in a namespace
 func (c *Client) GetNamespaceResources(ctx context.Context, namespace string) (map[string]interface{}, error) {
 	resources := make(map[string]interface{})
@@ -0,0 +1,93 @@
+package k8s
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestEvictionReason(t *testing.T) {
+	m := &Manager{clientTTL: time.Hour}
+	now := time.Now()
+	updatedAt := now.Add(-24 * time.Hour)
+
+	tests := []struct {
+		name      string
+		client    func() *Client
+		updatedAt time.Time
+		want      string
+	}{
+		{"fresh", func() *Client {
+			return &Client{createdAt: now.Add(-time.Minute), clusterUpdatedAt: updatedAt}
+		}, updatedAt, ""},
+		{"cluster changed", func() *Client {
+			return &Client{createdAt: now.Add(-time.Minute), clusterUpdatedAt: updatedAt}
+		}, now, evictReasonStale},
+		{"past ttl", func() *Client {
+			return &Client{createdAt: now.Add(-2 * time.Hour), clusterUpdatedAt: updatedAt}
+		}, updatedAt, evictReasonExpired},
+		{"repeated failures", func() *Client {
+			c := &Client{createdAt: now, clusterUpdatedAt: updatedAt}
+			c.failures.Store(maxClientFailures)
+			return c
+		}, updatedAt, evictReasonUnhealthy},
+		{"invalid credentials", func() *Client {
+			c := &Client{createdAt: now, clusterUpdatedAt: updatedAt}
+			c.invalid.Store(true)
+			return c
+		}, updatedAt, evictReasonUnhealthy},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := m.evictionReason(tt.client(), tt.updatedAt, now); got != tt.want {
+				t.Errorf("evictionReason() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGroupAccess(t *testing.T) {
+	roleBindings := []rbacv1.RoleBinding{{
+		ObjectMeta: metav1.ObjectMeta{Name: "deployers", Namespace: "payments"},
+		RoleRef:    rbacv1.RoleRef{Kind: "ClusterRole", Name: "edit"},
+		Subjects: []rbacv1.Subject{
+			{Kind: rbacv1.GroupKind, Name: "payments-devs"},
+			{Kind: rbacv1.ServiceAccountKind, Name: "ci"},
+		},
+	}}
+	clusterRoleBindings := []rbacv1.ClusterRoleBinding{{
+		ObjectMeta: metav1.ObjectMeta{Name: "readers"},
+		RoleRef:    rbacv1.RoleRef{Kind: "ClusterRole", Name: "view"},
+		Subjects: []rbacv1.Subject{
+			{Kind: rbacv1.UserKind, Name: "auditor@example.com"},
+			{Kind: rbacv1.ServiceAccountKind, Name: "scanner", Namespace: "security"},
+		},
+	}}
+	serviceAccounts := []corev1.ServiceAccount{
+		{ObjectMeta: metav1.ObjectMeta{Name: "ci", Namespace: "payments", UID: "uid-1"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "scanner", Namespace: "security", UID: "uid-2"}},
+	}
+
+	access := groupAccess(roleBindings, clusterRoleBindings, serviceAccounts)
+
+	want := map[string][]DiscoveredBinding{
+		"payments": {
+			{BindingKind: "RoleBinding", BindingName: "deployers", RoleKind: "ClusterRole", RoleName: "edit", SubjectKind: "Group", SubjectName: "payments-devs"},
+			{BindingKind: "RoleBinding", BindingName: "deployers", RoleKind: "ClusterRole", RoleName: "edit", SubjectKind: "ServiceAccount", SubjectName: "ci", SubjectNamespace: "payments"},
+		},
+		"security": {
+			{BindingKind: "ClusterRoleBinding", BindingName: "readers", RoleKind: "ClusterRole", RoleName: "view", SubjectKind: "ServiceAccount", SubjectName: "scanner", SubjectNamespace: "security"},
+		},
+	}
+	if !reflect.DeepEqual(access.Bindings, want) {
+		t.Errorf("bindings = %+v, want %+v", access.Bindings, want)
+	}
+	if len(access.ServiceAccounts["payments"]) != 1 || access.ServiceAccounts["payments"][0].UID != "uid-1" {
+		t.Errorf("payments service accounts = %+v", access.ServiceAccounts["payments"])
+	}
+}
@@ -0,0 +1,66 @@
+package k8s
+
+import (
+	"context"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// podMetricsVersions are the metrics-server API versions DiscoverUsage reads
+var podMetricsVersions = []schema.GroupVersionResource{
+	{Group: "metrics.k8s.io", Version: "v1beta1", Resource: "pods"},
+}
+
+// NamespaceUsage is the CPU and memory the pods of a namespace were using,
+// as reported by metrics-server
+type NamespaceUsage struct {
+	CPUMillicores int64
+	MemoryBytes   int64
+	Pods          int
+}
+
+// DiscoverUsage sums the current CPU and memory usage of the pods of every
+// namespace in the cluster, by namespace name. Clusters without
+// metrics-server return nil.
+func (c *Client) DiscoverUsage(ctx context.Context) (map[string]NamespaceUsage, error) {
+	items, err := c.listServed(ctx, podMetricsVersions)
+	if err != nil || items == nil {
+		return nil, err
+	}
+
+	usage := make(map[string]NamespaceUsage)
+	for i := range items {
+		cpu, memory := podUsage(&items[i])
+		u := usage[items[i].GetNamespace()]
+		u.CPUMillicores += cpu
+		u.MemoryBytes += memory
+		u.Pods++
+		usage[items[i].GetNamespace()] = u
+	}
+	return usage, nil
+}
+
+// podUsage sums the CPU, in millicores, and memory, in bytes, of the
+// containers of a PodMetrics. Quantities that do not parse count as zero.
+func podUsage(obj *unstructured.Unstructured) (cpu, memory int64) {
+	containers, _, _ := unstructured.NestedSlice(obj.Object, "containers")
+	for _, c := range containers {
+		container, ok := c.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if v, _, _ := unstructured.NestedString(container, "usage", "cpu"); v != "" {
+			if q, err := resource.ParseQuantity(v); err == nil {
+				cpu += q.MilliValue()
+			}
+		}
+		if v, _, _ := unstructured.NestedString(container, "usage", "memory"); v != "" {
+			if q, err := resource.ParseQuantity(v); err == nil {
+				memory += q.Value()
+			}
+		}
+	}
+	return cpu, memory
+}
@@ -0,0 +1,30 @@
+package k8s
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestPodUsage(t *testing.T) {
+	pod := &unstructured.Unstructured{Object: map[string]interface{}{
+		"kind":     "PodMetrics",
+		"metadata": map[string]interface{}{"name": "payments-7d9f", "namespace": "payments"},
+		"containers": []interface{}{
+			map[string]interface{}{"name": "app", "usage": map[string]interface{}{"cpu": "250m", "memory": "128Mi"}},
+			map[string]interface{}{"name": "proxy", "usage": map[string]interface{}{"cpu": "1500000n", "memory": "16384Ki"}},
+			map[string]interface{}{"name": "broken", "usage": map[string]interface{}{"cpu": "lots"}},
+		},
+	}}
+	cpu, memory := podUsage(pod)
+	if cpu != 252 {
+		t.Errorf("podUsage() cpu = %d, want 252", cpu)
+	}
+	if want := int64(128<<20 + 16<<20); memory != want {
+		t.Errorf("podUsage() memory = %d, want %d", memory, want)
+	}
+
+	if cpu, memory := podUsage(&unstructured.Unstructured{Object: map[string]interface{}{}}); cpu != 0 || memory != 0 {
+		t.Errorf("podUsage() without containers = %d, %d", cpu, memory)
+	}
+}
@@ -0,0 +1,49 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// ServerGroups returns the names of the API groups the cluster serves
+func (c *Client) ServerGroups(ctx context.Context) ([]string, error) {
+	groups, err := c.clientset.Discovery().ServerGroups()
+	c.observe(err)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list API groups: %w", err)
+	}
+
+	names := make([]string, 0, len(groups.Groups))
+	for _, g := range groups.Groups {
+		names = append(names, g.Name)
+	}
+	return names, nil
+}
+
+// DetectClusterType guesses the platform of a cluster from its server
+// version, the API groups it serves and the labels of its nodes. It returns
+// one of the cluster types, kubernetes when nothing gives the platform away.
+func DetectClusterType(gitVersion string, groups []string, nodes []DiscoveredNode) string {
+	for _, g := range groups {
+		if g == "config.openshift.io" {
+			return "openshift"
+		}
+	}
+
+	switch {
+	case strings.Contains(gitVersion, "-eks-"):
+		return "eks"
+	case strings.Contains(gitVersion, "-gke."):
+		return "gke"
+	case strings.Contains(gitVersion, "+rke2"):
+		return "rke2"
+	}
+
+	for _, n := range nodes {
+		if _, ok := n.Labels["kubernetes.azure.com/cluster"]; ok {
+			return "aks"
+		}
+	}
+	return "kubernetes"
+}
@@ -0,0 +1,27 @@
+package k8s
+
+import "testing"
+
+func TestDetectClusterType(t *testing.T) {
+	aksNode := DiscoveredNode{Labels: map[string]interface{}{"kubernetes.azure.com/cluster": "MC_prod_aks"}}
+
+	tests := []struct {
+		version string
+		groups  []string
+		nodes   []DiscoveredNode
+		want    string
+	}{
+		{"v1.29.6", []string{"apps", "config.openshift.io", "route.openshift.io"}, nil, "openshift"},
+		{"v1.30.2-eks-db838b0", []string{"apps"}, nil, "eks"},
+		{"v1.30.3-gke.1639000", []string{"apps"}, nil, "gke"},
+		{"v1.30.4+rke2r1", nil, nil, "rke2"},
+		{"v1.30.4", []string{"apps"}, []DiscoveredNode{{}, aksNode}, "aks"},
+		{"v1.31.0", []string{"apps"}, []DiscoveredNode{{}}, "kubernetes"},
+		{"", nil, nil, "kubernetes"},
+	}
+	for _, tt := range tests {
+		if got := DetectClusterType(tt.version, tt.groups, tt.nodes); got != tt.want {
+			t.Errorf("DetectClusterType(%q, %v) = %q, want %q", tt.version, tt.groups, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,209 @@
+// Package listquery parses the query language of list endpoints into their
+// query parameters.
+//
+// A query is a list of terms joined by AND, which may be left out:
+//
+//	environment:production AND criticality:tier-1 AND owner:none
+//	label:"team in (payments,billing)" checkout
+//
+// A term is a field and a value separated by a colon, or free text. Values
+// and free text containing spaces are quoted. Each list defines its fields
+// and the parameters they set; OR, NOT and parentheses are not supported.
+package listquery
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+var (
+	ErrSyntax       = errors.New("invalid query")
+	ErrUnknownField = errors.New("unknown query field")
+	ErrInvalidValue = errors.New("invalid query value")
+)
+
+// Term is a field and its value. Free text has no field.
+type Term struct {
+	Field string
+	Value string
+}
+
+// Field sets the list parameters of a term's value
+type Field func(value string, params url.Values) error
+
+// Fields are the fields of a list by name. The field named "" receives free
+// text, which the list rejects without it.
+type Fields map[string]Field
+
+// Param sets the parameter name to the value
+func Param(name string) Field {
+	return func(value string, params url.Values) error {
+		params.Set(name, value)
+		return nil
+	}
+}
+
+// Bool sets the parameter name to a value of true or false
+func Bool(name string) Field {
+	return func(value string, params url.Values) error {
+		value = strings.ToLower(value)
+		if value != "true" && value != "false" {
+			return fmt.Errorf("%w: %q is not true or false", ErrInvalidValue, value)
+		}
+		params.Set(name, value)
+		return nil
+	}
+}
+
+// Parse splits a query into its terms
+func Parse(input string) ([]Term, error) {
+	var terms []Term
+	joined := false
+	s := scanner{input: []rune(input)}
+	for {
+		s.skipSpace()
+		if s.done() {
+			break
+		}
+		if r := s.peek(); r == '(' || r == ')' {
+			return nil, fmt.Errorf("%w: parentheses are not supported", ErrSyntax)
+		}
+
+		word, quoted, err := s.word(true)
+		if err != nil {
+			return nil, err
+		}
+		if !quoted {
+			switch strings.ToUpper(word) {
+			case "AND":
+				if len(terms) == 0 || joined {
+					return nil, fmt.Errorf("%w: AND must join two terms", ErrSyntax)
+				}
+				joined = true
+				continue
+			case "OR", "NOT":
+				return nil, fmt.Errorf("%w: %s is not supported, terms are joined by AND", ErrSyntax, strings.ToUpper(word))
+			}
+		}
+
+		term := Term{Value: word}
+		if !quoted && !s.done() && s.peek() == ':' {
+			s.pos++
+			if word == "" {
+				return nil, fmt.Errorf("%w: missing field before ':'", ErrSyntax)
+			}
+			value, _, err := s.word(false)
+			if err != nil {
+				return nil, err
+			}
+			if value == "" {
+				return nil, fmt.Errorf("%w: missing value of %s", ErrSyntax, word)
+			}
+			term = Term{Field: strings.ToLower(word), Value: value}
+		}
+		terms = append(terms, term)
+		joined = false
+	}
+	if joined {
+		return nil, fmt.Errorf("%w: AND must join two terms", ErrSyntax)
+	}
+	return terms, nil
+}
+
+// Expand parses a query and sets the list parameters of its terms, free text
+// joined by spaces
+func Expand(input string, fields Fields, params url.Values) error {
+	terms, err := Parse(input)
+	if err != nil {
+		return err
+	}
+
+	var text []string
+	for _, term := range terms {
+		if term.Field == "" {
+			text = append(text, term.Value)
+			continue
+		}
+		field, ok := fields[term.Field]
+		if !ok {
+			return fmt.Errorf("%w %q, use one of %s", ErrUnknownField, term.Field, strings.Join(fields.names(), ", "))
+		}
+		if err := field(term.Value, params); err != nil {
+			return fmt.Errorf("%s: %w", term.Field, err)
+		}
+	}
+	if len(text) > 0 {
+		field, ok := fields[""]
+		if !ok {
+			return fmt.Errorf("%w: free text is not supported, use one of %s", ErrSyntax, strings.Join(fields.names(), ", "))
+		}
+		if err := field(strings.Join(text, " "), params); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// names returns the named fields in order
+func (f Fields) names() []string {
+	names := make([]string, 0, len(f))
+	for name := range f {
+		if name != "" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+type scanner struct {
+	input []rune
+	pos   int
+}
+
+func (s *scanner) done() bool { return s.pos >= len(s.input) }
+
+func (s *scanner) peek() rune { return s.input[s.pos] }
+
+func (s *scanner) skipSpace() {
+	for !s.done() && unicode.IsSpace(s.peek()) {
+		s.pos++
+	}
+}
+
+// word reads a quoted string, or up to a space or parenthesis and, for
+// field names, a colon
+func (s *scanner) word(field bool) (string, bool, error) {
+	if !s.done() && s.peek() == '"' {
+		s.pos++
+		var b strings.Builder
+		for !s.done() {
+			r := s.peek()
+			s.pos++
+			switch {
+			case r == '\\' && !s.done():
+				b.WriteRune(s.peek())
+				s.pos++
+			case r == '"':
+				return b.String(), true, nil
+			default:
+				b.WriteRune(r)
+			}
+		}
+		return "", false, fmt.Errorf("%w: unterminated quote", ErrSyntax)
+	}
+
+	start := s.pos
+	for !s.done() {
+		r := s.peek()
+		if unicode.IsSpace(r) || (field && r == ':') || r == '(' || r == ')' {
+			break
+		}
+		s.pos++
+	}
+	return string(s.input[start:s.pos]), false, nil
+}
@@ -0,0 +1,87 @@
+package listquery
+
+import (
+	"errors"
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	got, err := Parse(`environment:production AND Criticality:tier-1 and owner:none label:"tier in (1,2)" "payment api" checkout`)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	want := []Term{
+		{Field: "environment", Value: "production"},
+		{Field: "criticality", Value: "tier-1"},
+		{Field: "owner", Value: "none"},
+		{Field: "label", Value: "tier in (1,2)"},
+		{Value: "payment api"},
+		{Value: "checkout"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Parse = %+v, want %+v", got, want)
+	}
+
+	if got, err := Parse(`label:app=web:v2 "AND"`); err != nil || !reflect.DeepEqual(got, []Term{{Field: "label", Value: "app=web:v2"}, {Value: "AND"}}) {
+		t.Errorf("Parse with a colon in a value and a quoted AND = %+v, %v", got, err)
+	}
+	if got, err := Parse("   "); err != nil || len(got) != 0 {
+		t.Errorf("Parse of blanks = %+v, %v, want no terms", got, err)
+	}
+
+	for _, bad := range []string{
+		"AND environment:production",
+		"environment:production AND",
+		"environment:production AND AND owner:none",
+		"environment:production OR environment:staging",
+		"NOT owner:none",
+		"(environment:production)",
+		"environment:",
+		":production",
+		`label:"unterminated`,
+	} {
+		if _, err := Parse(bad); !errors.Is(err, ErrSyntax) {
+			t.Errorf("Parse(%q) error = %v, want ErrSyntax", bad, err)
+		}
+	}
+}
+
+func TestExpand(t *testing.T) {
+	fields := Fields{
+		"":            Param("search"),
+		"environment": Param("environment"),
+		"archived":    Bool("archived"),
+		"owner": func(value string, params url.Values) error {
+			params.Set("orphaned", "true")
+			return nil
+		},
+	}
+
+	params := url.Values{"environment": {"staging"}, "page": {"2"}}
+	if err := Expand("environment:production AND owner:none AND archived:FALSE payments api", fields, params); err != nil {
+		t.Fatalf("Expand: %v", err)
+	}
+	want := url.Values{
+		"environment": {"production"},
+		"orphaned":    {"true"},
+		"archived":    {"false"},
+		"search":      {"payments api"},
+		"page":        {"2"},
+	}
+	if !reflect.DeepEqual(params, want) {
+		t.Errorf("params = %v, want %v", params, want)
+	}
+
+	if err := Expand("team:payments", fields, url.Values{}); !errors.Is(err, ErrUnknownField) {
+		t.Errorf("unknown field error = %v, want ErrUnknownField", err)
+	}
+	if err := Expand("archived:maybe", fields, url.Values{}); !errors.Is(err, ErrInvalidValue) {
+		t.Errorf("invalid bool error = %v, want ErrInvalidValue", err)
+	}
+	delete(fields, "")
+	if err := Expand("payments", fields, url.Values{}); !errors.Is(err, ErrSyntax) {
+		t.Errorf("free text without a search field error = %v, want ErrSyntax", err)
+	}
+}
@@ -0,0 +1,157 @@
+// Package mail sends plain-text email over SMTP.
+package mail
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TLS modes
+const (
+	TLSModeNone     = "none"
+	TLSModeStartTLS = "starttls"
+	TLSModeImplicit = "tls"
+)
+
+var ErrNotConfigured = errors.New("smtp is not configured")
+
+// Config holds SMTP server settings
+type Config struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+	TLSMode  string
+}
+
+// Message is a single email
+type Message struct {
+	To      []string
+	Subject string
+	Body    string
+}
+
+// Sender delivers messages
+type Sender interface {
+	Send(ctx context.Context, cfg Config, msg Message) error
+}
+
+// SMTPSender delivers messages with net/smtp
+type SMTPSender struct {
+	Timeout time.Duration
+}
+
+// NewSMTPSender creates a sender that gives up after timeout
+func NewSMTPSender(timeout time.Duration) *SMTPSender {
+	return &SMTPSender{Timeout: timeout}
+}
+
+// Send implements Sender
+func (s *SMTPSender) Send(ctx context.Context, cfg Config, msg Message) error {
+	if cfg.Host == "" || cfg.From == "" {
+		return ErrNotConfigured
+	}
+	if len(msg.To) == 0 {
+		return errors.New("message has no recipients")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.Timeout)
+	defer cancel()
+
+	addr := net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.Port))
+	tlsConfig := &tls.Config{ServerName: cfg.Host, MinVersion: tls.VersionTLS12}
+
+	var conn net.Conn
+	var err error
+	dialer := &net.Dialer{}
+	if cfg.TLSMode == TLSModeImplicit {
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: tlsConfig}).DialContext(ctx, "tcp", addr)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to connect to smtp server: %w", err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	client, err := smtp.NewClient(conn, cfg.Host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("smtp handshake failed: %w", err)
+	}
+	defer client.Close()
+
+	if cfg.TLSMode == TLSModeStartTLS {
+		if err := client.StartTLS(tlsConfig); err != nil {
+			return fmt.Errorf("smtp starttls failed: %w", err)
+		}
+	}
+
+	if cfg.Username != "" {
+		auth := smtp.PlainAuth("", cfg.Username, cfg.Password, cfg.Host)
+		if err := client.Auth(auth); err != nil {
+			return fmt.Errorf("smtp auth failed: %w", err)
+		}
+	}
+
+	if err := client.Mail(cfg.From); err != nil {
+		return fmt.Errorf("smtp MAIL FROM rejected: %w", err)
+	}
+	for _, to := range msg.To {
+		if err := client.Rcpt(to); err != nil {
+			return fmt.Errorf("smtp RCPT TO %s rejected: %w", to, err)
+		}
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("smtp DATA failed: %w", err)
+	}
+	if _, err := w.Write(Build(cfg.From, msg, time.Now())); err != nil {
+		w.Close()
+		return fmt.Errorf("failed to write message: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("smtp server rejected message: %w", err)
+	}
+
+	return client.Quit()
+}
+
+// Build renders msg as an RFC 5322 message with CRLF line endings
+func Build(from string, msg Message, date time.Time) []byte {
+	var b strings.Builder
+	// Strip line breaks so values cannot inject extra headers
+	headerValue := strings.NewReplacer("\r", "", "\n", "")
+	writeHeader := func(key, value string) {
+		b.WriteString(key + ": " + headerValue.Replace(value) + "\r\n")
+	}
+
+	writeHeader("From", from)
+	writeHeader("To", strings.Join(msg.To, ", "))
+	writeHeader("Subject", mime.QEncoding.Encode("utf-8", msg.Subject))
+	writeHeader("Date", date.Format(time.RFC1123Z))
+	writeHeader("MIME-Version", "1.0")
+	writeHeader("Content-Type", `text/plain; charset="utf-8"`)
+	writeHeader("Content-Transfer-Encoding", "8bit")
+	b.WriteString("\r\n")
+
+	body := strings.ReplaceAll(msg.Body, "\r\n", "\n")
+	b.WriteString(strings.ReplaceAll(body, "\n", "\r\n"))
+	if !strings.HasSuffix(body, "\n") {
+		b.WriteString("\r\n")
+	}
+
+	return []byte(b.String())
+}
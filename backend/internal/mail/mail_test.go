@@ -0,0 +1,32 @@
+package mail
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBuild(t *testing.T) {
+	date := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	msg := Message{
+		To:      []string{"a@example.com", "b@example.com"},
+		Subject: "Sync failed\r\nBcc: evil@example.com",
+		Body:    "line one\nline two",
+	}
+
+	got := string(Build("kubeatlas@example.com", msg, date))
+
+	for _, want := range []string{
+		"From: kubeatlas@example.com\r\n",
+		"To: a@example.com, b@example.com\r\n",
+		"Date: Fri, 01 Mar 2024 12:00:00 +0000\r\n",
+		"\r\n\r\nline one\r\nline two\r\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("message missing %q:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "\r\nBcc:") {
+		t.Errorf("subject injected a header:\n%s", got)
+	}
+}
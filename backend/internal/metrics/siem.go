@@ -0,0 +1,44 @@
+package metrics
+
+import "github.com/prometheus/client_golang/prometheus"
+
+// SIEM event outcomes
+const (
+	SIEMEventSent    = "sent"
+	SIEMEventFailed  = "failed"
+	SIEMEventDropped = "dropped"
+)
+
+var (
+	// Audit events forwarded to the SIEM by outcome
+	siemEventsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "kubeatlas_siem_events_total",
+			Help: "Audit events forwarded to the SIEM by outcome",
+		},
+		[]string{"outcome"},
+	)
+
+	// Audit events waiting to be forwarded
+	siemQueueLength = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "kubeatlas_siem_queue_length",
+			Help: "Number of audit events waiting to be forwarded to the SIEM",
+		},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(siemEventsTotal)
+	prometheus.MustRegister(siemQueueLength)
+}
+
+// ObserveSIEMEvents records n audit events with outcome
+func ObserveSIEMEvents(outcome string, n int) {
+	siemEventsTotal.WithLabelValues(outcome).Add(float64(n))
+}
+
+// SetSIEMQueueLength records the number of queued SIEM events
+func SetSIEMQueueLength(n int) {
+	siemQueueLength.Set(float64(n))
+}
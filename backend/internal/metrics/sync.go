@@ -0,0 +1,128 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// SyncOutcomeSuccess is the outcome label for a clean cluster sync; failures
+// use their models.SyncErrorCategory* value.
+const SyncOutcomeSuccess = "success"
+
+var (
+	// Cluster syncs by outcome
+	clusterSyncTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "kubeatlas_cluster_sync_total",
+			Help: "Total number of cluster syncs by outcome",
+		},
+		[]string{"cluster", "outcome"},
+	)
+
+	// Cluster sync duration
+	clusterSyncDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "kubeatlas_cluster_sync_duration_seconds",
+			Help:    "Cluster sync duration in seconds",
+			Buckets: prometheus.ExponentialBuckets(0.5, 2, 10),
+		},
+		[]string{"cluster"},
+	)
+
+	// Cached Kubernetes clients
+	k8sCachedClients = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "kubeatlas_k8s_cached_clients",
+			Help: "Number of cached Kubernetes clients",
+		},
+	)
+
+	// Kubernetes client cache lookups
+	k8sClientCacheLookups = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "kubeatlas_k8s_client_cache_lookups_total",
+			Help: "Kubernetes client cache lookups by result",
+		},
+		[]string{"result"},
+	)
+
+	// Kubernetes client cache evictions
+	k8sClientCacheEvictions = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "kubeatlas_k8s_client_cache_evictions_total",
+			Help: "Kubernetes client cache evictions by reason",
+		},
+		[]string{"reason"},
+	)
+
+	// Kubernetes API server endpoint failovers
+	k8sEndpointFailovers = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "kubeatlas_k8s_endpoint_failovers_total",
+			Help: "Switches to another API server endpoint of a cluster",
+		},
+		[]string{"cluster"},
+	)
+
+	// Last successful sync per cluster
+	clusterLastSuccess = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "kubeatlas_cluster_last_successful_sync_timestamp_seconds",
+			Help: "Unix time of the last successful cluster sync",
+		},
+		[]string{"cluster"},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(clusterSyncTotal)
+	prometheus.MustRegister(clusterSyncDuration)
+	prometheus.MustRegister(clusterLastSuccess)
+	prometheus.MustRegister(k8sCachedClients)
+	prometheus.MustRegister(k8sClientCacheLookups)
+	prometheus.MustRegister(k8sClientCacheEvictions)
+	prometheus.MustRegister(k8sEndpointFailovers)
+}
+
+// ObserveClusterSync records the outcome and duration of a cluster sync
+func ObserveClusterSync(cluster, outcome string, duration time.Duration) {
+	clusterSyncTotal.WithLabelValues(cluster, outcome).Inc()
+	clusterSyncDuration.WithLabelValues(cluster).Observe(duration.Seconds())
+	if outcome == SyncOutcomeSuccess {
+		clusterLastSuccess.WithLabelValues(cluster).SetToCurrentTime()
+	}
+}
+
+// ForgetCluster drops the series of a deleted cluster
+func ForgetCluster(cluster string) {
+	clusterSyncTotal.DeletePartialMatch(prometheus.Labels{"cluster": cluster})
+	clusterSyncDuration.DeleteLabelValues(cluster)
+	clusterLastSuccess.DeleteLabelValues(cluster)
+	k8sEndpointFailovers.DeleteLabelValues(cluster)
+}
+
+// SetCachedK8sClients records the current size of the Kubernetes client cache
+func SetCachedK8sClients(n int) {
+	k8sCachedClients.Set(float64(n))
+}
+
+// ObserveK8sClientLookup records a client cache hit or miss
+func ObserveK8sClientLookup(hit bool) {
+	result := "miss"
+	if hit {
+		result = "hit"
+	}
+	k8sClientCacheLookups.WithLabelValues(result).Inc()
+}
+
+// ObserveK8sClientEviction records why a cached client was dropped
+func ObserveK8sClientEviction(reason string) {
+	k8sClientCacheEvictions.WithLabelValues(reason).Inc()
+}
+
+// ObserveK8sFailover records a switch to another API server endpoint of a
+// cluster
+func ObserveK8sFailover(cluster string) {
+	k8sEndpointFailovers.WithLabelValues(cluster).Inc()
+}
@@ -4,7 +4,9 @@ import (
 	"database/sql/driver"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"regexp"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -12,6 +14,13 @@ import (
 
 var emailRegex = regexp.MustCompile(`^[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}$`)
 
+// configNameRegex matches the names of organization-defined values, such as
+// environments (production, dr-west) and criticality tiers (tier-1, gold)
+var configNameRegex = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,49}$`)
+
+// namespaceNameRegex matches Kubernetes namespace names, which are DNS labels
+var namespaceNameRegex = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]{0,61}[a-z0-9])?$`)
+
 // ============================================
 // Custom Nullable Types with proper JSON serialization
 // ============================================
@@ -376,11 +385,24 @@ type Team struct {
 	ContactSlack   NullString `json:"contact_slack" db:"contact_slack"`
 	Metadata       JSONMap    `json:"metadata" db:"metadata"`
 
+	// PagerDutyServiceID is the PagerDuty service on call for the team's namespaces
+	PagerDutyServiceID NullString `json:"pagerduty_service_id" db:"pagerduty_service_id"`
+	// OpsgenieScheduleID is the Opsgenie schedule on call for the team's namespaces
+	OpsgenieScheduleID NullString `json:"opsgenie_schedule_id" db:"opsgenie_schedule_id"`
+
 	// Computed fields (not in DB)
 	MemberCount int    `json:"member_count,omitempty" db:"-"`
 	Members     []User `json:"members,omitempty" db:"-"`
 }
 
+// TeamNode is a team in the organization's team tree
+type TeamNode struct {
+	Team Team `json:"team"`
+	// SubtreeMemberCount counts the distinct users of the team and all teams below it
+	SubtreeMemberCount int         `json:"subtree_member_count"`
+	Children           []*TeamNode `json:"children"`
+}
+
 // TeamMember represents a user's membership in a team
 type TeamMember struct {
 	ID       uuid.UUID `json:"id" db:"id"`
@@ -393,6 +415,58 @@ type TeamMember struct {
 	User *User `json:"user,omitempty" db:"-"`
 }
 
+// Sources of directory group memberships
+const (
+	DirectorySourceLDAP = "ldap"
+	DirectorySourceSCIM = "scim"
+)
+
+// Kinds of team membership changes
+const (
+	TeamMembershipJoined  = "joined"
+	TeamMembershipRemoved = "removed"
+)
+
+// TeamDirectoryGroup maps a directory group to the team it makes up
+type TeamDirectoryGroup struct {
+	TeamID    uuid.UUID `json:"team_id"`
+	GroupName string    `json:"group_name"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// DirectoryGroup holds the members of a mapped directory group as last read
+// from LDAP or pushed over SCIM
+type DirectoryGroup struct {
+	OrganizationID uuid.UUID `json:"organization_id"`
+	Name           string    `json:"name"`
+	Members        []string  `json:"members"`
+	Source         string    `json:"source"`
+	SyncedAt       time.Time `json:"synced_at"`
+}
+
+// TeamMembershipChange records a user joining or leaving a team through
+// directory synchronization
+type TeamMembershipChange struct {
+	ID             uuid.UUID  `json:"id"`
+	OrganizationID uuid.UUID  `json:"organization_id"`
+	TeamID         uuid.UUID  `json:"team_id"`
+	UserID         *uuid.UUID `json:"user_id,omitempty"`
+	Email          string     `json:"email"`
+	Change         string     `json:"change"` // joined, removed
+	Source         string     `json:"source"` // ldap, scim
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+// TeamSyncResult summarizes a synchronization of team rosters
+type TeamSyncResult struct {
+	Teams   int `json:"teams"`
+	Joined  int `json:"joined"`
+	Removed int `json:"removed"`
+	// UnknownEmails are group members without a KubeAtlas account, who are
+	// added once they sign in and the groups are synchronized again
+	UnknownEmails []string `json:"unknown_emails"`
+}
+
 // BusinessUnit represents a business department
 type BusinessUnit struct {
 	BaseModel
@@ -410,6 +484,39 @@ type BusinessUnit struct {
 	NamespaceCount int `json:"namespace_count" db:"-"`
 }
 
+// Application is a logical application grouping the namespaces it runs in,
+// such as its production and staging namespaces across clusters, with its
+// own owner and documents
+type Application struct {
+	BaseModel
+	OrganizationID uuid.UUID  `json:"organization_id" db:"organization_id"`
+	Name           string     `json:"name" db:"name"`
+	Slug           string     `json:"slug" db:"slug"`
+	Description    NullString `json:"description" db:"description"`
+	OwnerTeamID    *uuid.UUID `json:"owner_team_id" db:"owner_team_id"`
+	OwnerUserID    *uuid.UUID `json:"owner_user_id" db:"owner_user_id"`
+	Metadata       JSONMap    `json:"metadata" db:"metadata"`
+
+	// Computed fields
+	OwnerTeam      *Team `json:"owner_team,omitempty" db:"-"`
+	NamespaceCount int   `json:"namespace_count" db:"-"`
+}
+
+// ClusterFleet is a group of clusters, such as "EU production fleet", that
+// can be reported on and synced together
+type ClusterFleet struct {
+	BaseModel
+	OrganizationID uuid.UUID  `json:"organization_id" db:"organization_id"`
+	Name           string     `json:"name" db:"name"`
+	Slug           string     `json:"slug" db:"slug"`
+	Description    NullString `json:"description" db:"description"`
+	OwnerTeamID    *uuid.UUID `json:"owner_team_id" db:"owner_team_id"`
+
+	// Computed fields
+	OwnerTeam    *Team `json:"owner_team,omitempty" db:"-"`
+	ClusterCount int   `json:"cluster_count" db:"-"`
+}
+
 // ============================================
 // Kubernetes Resources
 // ============================================
@@ -426,7 +533,7 @@ type Cluster struct {
 	Version        NullString `json:"version" db:"version"`
 	Platform       NullString `json:"platform" db:"platform"`
 	Region         NullString `json:"region" db:"region"`
-	Environment    string     `json:"environment" db:"environment"` // production, staging, development, test
+	Environment    string     `json:"environment" db:"environment"` // one of the organization's environments
 
 	// Connection settings
 	AuthMethod                   string `json:"auth_method" db:"auth_method"`
@@ -434,20 +541,31 @@ type Cluster struct {
 	ServiceAccountTokenEncrypted []byte `json:"-" db:"service_account_token_encrypted"`
 	CACertificateEncrypted       []byte `json:"-" db:"ca_certificate_encrypted"`
 	SkipTLSVerify                bool   `json:"skip_tls_verify" db:"skip_tls_verify"`
+	// FallbackAPIServerURLs are tried in order when APIServerURL cannot be
+	// reached. ActiveAPIServerURL is the one the last successful sync or
+	// connectivity probe used.
+	FallbackAPIServerURLs StringArray `json:"fallback_api_server_urls" db:"fallback_api_server_urls"`
+	ActiveAPIServerURL    NullString  `json:"active_api_server_url" db:"active_api_server_url"`
 
 	// Ownership
 	OwnerTeamID       *uuid.UUID `json:"owner_team_id" db:"owner_team_id"`
 	ResponsibleUserID *uuid.UUID `json:"responsible_user_id" db:"responsible_user_id"`
 
 	// Status
-	Status     string     `json:"status" db:"status"` // active, inactive, error, syncing
-	LastSyncAt NullTime   `json:"last_sync_at" db:"last_sync_at"`
-	SyncError  NullString `json:"sync_error" db:"sync_error"`
+	Status            string     `json:"status" db:"status"` // active, inactive, error, syncing
+	LastSyncAt        NullTime   `json:"last_sync_at" db:"last_sync_at"`
+	SyncError         NullString `json:"sync_error" db:"sync_error"`
+	SyncErrorCategory NullString `json:"sync_error_category" db:"sync_error_category"` // see SyncErrorCategory* constants
+	// ConsecutiveFailures counts failed syncs and connectivity probes since the last success
+	ConsecutiveFailures int      `json:"consecutive_failures" db:"consecutive_failures"`
+	LastAlertedAt       NullTime `json:"last_alerted_at" db:"last_alerted_at"`
 
 	// Metadata
 	NodeCount      int            `json:"node_count" db:"node_count"`
 	NamespaceCount int            `json:"namespace_count" db:"namespace_count"`
 	Tags           StringArray `json:"tags" db:"tags"`
+	// Labels include the region, zone and instance-type a sync reads from
+	// the labels the cluster's nodes share
 	Labels         JSONMap        `json:"labels" db:"labels"`
 	Annotations    JSONMap        `json:"annotations" db:"annotations"`
 	Metadata       JSONMap        `json:"metadata" db:"metadata"`
@@ -455,6 +573,205 @@ type Cluster struct {
 	// Computed fields
 	OwnerTeam       *Team `json:"owner_team,omitempty" db:"-"`
 	ResponsibleUser *User `json:"responsible_user,omitempty" db:"-"`
+	// VersionSupport places Version on the Kubernetes support calendar
+	VersionSupport *VersionSupport `json:"version_support,omitempty" db:"-"`
+	// Maintenance places the cluster on its maintenance windows
+	Maintenance *MaintenanceStatus `json:"maintenance,omitempty" db:"-"`
+}
+
+// Kubernetes version support statuses
+const (
+	VersionSupportSupported      = "supported"
+	VersionSupportApproachingEOL = "approaching_eol"
+	VersionSupportEndOfLife      = "end_of_life"
+	// VersionSupportUnknown is a version missing from the support calendar
+	VersionSupportUnknown = "unknown"
+)
+
+// VersionSupport is where a cluster's Kubernetes minor version stands on
+// the upstream support calendar. EndOfLife and DaysRemaining are nil for
+// versions the calendar has no date for.
+type VersionSupport struct {
+	MinorVersion  string     `json:"minor_version"`
+	Status        string     `json:"status"`
+	EndOfLife     *time.Time `json:"end_of_life"`
+	DaysRemaining *int       `json:"days_remaining"`
+}
+
+// Cluster sync error categories
+const (
+	SyncErrorCategoryAuth        = "auth_error"
+	SyncErrorCategoryRBAC        = "rbac_denied"
+	SyncErrorCategoryTimeout     = "timeout"
+	SyncErrorCategoryUnreachable = "unreachable"
+	SyncErrorCategoryTLS         = "tls_error"
+	SyncErrorCategoryConfig      = "config_error"
+	SyncErrorCategoryPartial     = "partial"
+	SyncErrorCategoryDatabase    = "database_error"
+	SyncErrorCategoryUnknown     = "unknown"
+)
+
+// ClusterSyncError records a failed or partial cluster sync
+type ClusterSyncError struct {
+	ID        uuid.UUID `json:"id" db:"id"`
+	ClusterID uuid.UUID `json:"cluster_id" db:"cluster_id"`
+	Category  string    `json:"category" db:"category"`
+	Message   string    `json:"message" db:"message"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// Cluster sync run statuses
+const (
+	SyncRunStatusSuccess = "success"
+	SyncRunStatusPartial = "partial"
+	SyncRunStatusFailed  = "failed"
+)
+
+// Cluster sync run triggers
+const (
+	SyncTriggerManual = "manual" // started by a user
+	SyncTriggerSystem = "system"
+)
+
+// ClusterSyncRun records a cluster sync and what it changed in the inventory
+type ClusterSyncRun struct {
+	ID          uuid.UUID  `json:"id" db:"id"`
+	ClusterID   uuid.UUID  `json:"cluster_id" db:"cluster_id"`
+	Trigger     string     `json:"trigger" db:"trigger"`
+	TriggeredBy *uuid.UUID `json:"triggered_by,omitempty" db:"triggered_by"`
+	Status      string     `json:"status" db:"status"`
+	StartedAt   time.Time  `json:"started_at" db:"started_at"`
+	FinishedAt  time.Time  `json:"finished_at" db:"finished_at"`
+	DurationMs  int64      `json:"duration_ms" db:"-"`
+
+	NamespaceCount        int         `json:"namespace_count" db:"namespace_count"`
+	NodeCount             int         `json:"node_count" db:"node_count"`
+	NamespacesCreated     int         `json:"namespaces_created" db:"namespaces_created"`
+	NamespacesUpdated     int         `json:"namespaces_updated" db:"namespaces_updated"`
+	NamespacesArchived    int         `json:"namespaces_archived" db:"namespaces_archived"`
+	NamespacesReactivated int         `json:"namespaces_reactivated" db:"namespaces_reactivated"`
+	ArchivedNamespaces    StringArray `json:"archived_namespaces" db:"archived_namespaces"`
+
+	ErrorCategory NullString `json:"error_category" db:"error_category"`
+	Error         NullString `json:"error" db:"error"`
+}
+
+// Maintenance window recurrences
+const (
+	MaintenanceOnce    = "once"
+	MaintenanceDaily   = "daily"
+	MaintenanceWeekly  = "weekly"
+	MaintenanceMonthly = "monthly"
+)
+
+// MaintenanceWindow is a planned maintenance of a cluster, once or
+// recurring, from StartTime (HH:MM) for DurationMinutes in Timezone.
+// StartDate (YYYY-MM-DD) is the day of a once window, Weekdays (mon to sun)
+// those of a weekly one and DayOfMonth that of a monthly one.
+type MaintenanceWindow struct {
+	ID              uuid.UUID   `json:"id" db:"id"`
+	ClusterID       uuid.UUID   `json:"cluster_id" db:"cluster_id"`
+	OrganizationID  uuid.UUID   `json:"organization_id" db:"organization_id"`
+	Name            string      `json:"name" db:"name"`
+	Recurrence      string      `json:"recurrence" db:"recurrence"`
+	StartDate       string      `json:"start_date,omitempty" db:"start_date"`
+	Weekdays        StringArray `json:"weekdays" db:"weekdays"`
+	DayOfMonth      int         `json:"day_of_month,omitempty" db:"day_of_month"`
+	StartTime       string      `json:"start_time" db:"start_time"`
+	DurationMinutes int         `json:"duration_minutes" db:"duration_minutes"`
+	Timezone        string      `json:"timezone" db:"timezone"`
+	CreatedAt       time.Time   `json:"created_at" db:"created_at"`
+	UpdatedAt       time.Time   `json:"updated_at" db:"updated_at"`
+}
+
+// MaintenanceStatus places a cluster on its maintenance windows: the window
+// it is in and when it ends, and when the next one starts
+type MaintenanceStatus struct {
+	Active       bool       `json:"active"`
+	Window       string     `json:"window,omitempty"`
+	EndsAt       *time.Time `json:"ends_at,omitempty"`
+	NextWindow   string     `json:"next_window,omitempty"`
+	NextStartsAt *time.Time `json:"next_starts_at,omitempty"`
+}
+
+// Node statuses, from the node's Ready condition
+const (
+	NodeStatusReady    = "Ready"
+	NodeStatusNotReady = "NotReady"
+	NodeStatusUnknown  = "Unknown"
+)
+
+// ClusterNode is a node of a cluster as found by the cluster's last sync
+type ClusterNode struct {
+	ID             uuid.UUID   `json:"id" db:"id"`
+	OrganizationID uuid.UUID   `json:"organization_id" db:"organization_id"`
+	ClusterID      uuid.UUID   `json:"cluster_id" db:"cluster_id"`
+	Name           string      `json:"name" db:"name"`
+	K8sUID         NullString  `json:"k8s_uid" db:"k8s_uid"`
+	Status         string      `json:"status" db:"status"` // see NodeStatus* constants
+	Roles          StringArray `json:"roles" db:"roles"`
+	KubeletVersion string      `json:"kubelet_version" db:"kubelet_version"`
+	OSImage        string      `json:"os_image" db:"os_image"`
+	Architecture   string      `json:"architecture" db:"architecture"`
+
+	CPUCapacityMillicores    int64 `json:"cpu_capacity_millicores" db:"cpu_capacity_millicores"`
+	CPUAllocatableMillicores int64 `json:"cpu_allocatable_millicores" db:"cpu_allocatable_millicores"`
+	MemoryCapacityBytes      int64 `json:"memory_capacity_bytes" db:"memory_capacity_bytes"`
+	MemoryAllocatableBytes   int64 `json:"memory_allocatable_bytes" db:"memory_allocatable_bytes"`
+	PodsCapacity             int64 `json:"pods_capacity" db:"pods_capacity"`
+	PodsAllocatable          int64 `json:"pods_allocatable" db:"pods_allocatable"`
+	// Capacity and Allocatable are every resource the node reports, such as
+	// ephemeral storage and GPUs, as Kubernetes quantities
+	Capacity    JSONMap `json:"capacity" db:"capacity"`
+	Allocatable JSONMap `json:"allocatable" db:"allocatable"`
+
+	Labels       JSONMap   `json:"labels" db:"labels"`
+	Annotations  JSONMap   `json:"annotations" db:"annotations"`
+	K8sCreatedAt NullTime  `json:"k8s_created_at" db:"k8s_created_at"`
+	FirstSeenAt  time.Time `json:"first_seen_at" db:"first_seen_at"`
+	LastSeenAt   time.Time `json:"last_seen_at" db:"last_seen_at"`
+
+	// Computed fields (not in DB)
+	ClusterName string `json:"cluster_name,omitempty" db:"-"`
+}
+
+// NodeGroup counts the nodes sharing a role, status or kubelet version
+type NodeGroup struct {
+	Value string `json:"value"`
+	Nodes int    `json:"nodes"`
+}
+
+// ClusterCapacity sums the capacity of the nodes of a cluster
+type ClusterCapacity struct {
+	ClusterID  uuid.UUID `json:"cluster_id"`
+	Nodes      int       `json:"nodes"`
+	ReadyNodes int       `json:"ready_nodes"`
+
+	CPUCapacityMillicores    int64 `json:"cpu_capacity_millicores"`
+	CPUAllocatableMillicores int64 `json:"cpu_allocatable_millicores"`
+	MemoryCapacityBytes      int64 `json:"memory_capacity_bytes"`
+	MemoryAllocatableBytes   int64 `json:"memory_allocatable_bytes"`
+	PodsCapacity             int64 `json:"pods_capacity"`
+	PodsAllocatable          int64 `json:"pods_allocatable"`
+
+	Roles           []NodeGroup `json:"roles"`
+	KubeletVersions []NodeGroup `json:"kubelet_versions"`
+}
+
+// ClusterCapacitySnapshot is the node count and summed node capacity of a
+// cluster as found by a sync
+type ClusterCapacitySnapshot struct {
+	Nodes      int `json:"nodes" db:"nodes"`
+	ReadyNodes int `json:"ready_nodes" db:"ready_nodes"`
+
+	CPUCapacityMillicores    int64 `json:"cpu_capacity_millicores" db:"cpu_capacity_millicores"`
+	CPUAllocatableMillicores int64 `json:"cpu_allocatable_millicores" db:"cpu_allocatable_millicores"`
+	MemoryCapacityBytes      int64 `json:"memory_capacity_bytes" db:"memory_capacity_bytes"`
+	MemoryAllocatableBytes   int64 `json:"memory_allocatable_bytes" db:"memory_allocatable_bytes"`
+	PodsCapacity             int64 `json:"pods_capacity" db:"pods_capacity"`
+	PodsAllocatable          int64 `json:"pods_allocatable" db:"pods_allocatable"`
+
+	ObservedAt time.Time `json:"observed_at" db:"observed_at"`
 }
 
 // Namespace represents a Kubernetes namespace
@@ -469,8 +786,8 @@ type Namespace struct {
 	Description NullString `json:"description" db:"description"`
 
 	// Environment & Criticality
-	Environment string `json:"environment" db:"environment"` // production, staging, test, development
-	Criticality string `json:"criticality" db:"criticality"` // tier-1, tier-2, tier-3
+	Environment string `json:"environment" db:"environment"` // one of the organization's environments
+	Criticality string `json:"criticality" db:"criticality"` // one of the organization's criticality tiers
 
 	// Ownership
 	InfrastructureOwnerTeamID *uuid.UUID `json:"infrastructure_owner_team_id" db:"infrastructure_owner_team_id"`
@@ -496,6 +813,19 @@ type Namespace struct {
 	DiscoveredAt NullTime `json:"discovered_at" db:"discovered_at"`
 	LastSyncAt   NullTime `json:"last_sync_at" db:"last_sync_at"`
 
+	// Lifecycle of the application, see NamespaceLifecycleTransitions
+	Lifecycle          string     `json:"lifecycle" db:"lifecycle"`
+	LifecycleChangedAt NullTime   `json:"lifecycle_changed_at" db:"lifecycle_changed_at"`
+	DecommissionDate   NullString `json:"decommission_date" db:"decommission_date"` // YYYY-MM-DD
+
+	// CPU and memory used by the namespace's pods over the samples kept, see
+	// NamespaceUsageSample. Nil until measured.
+	CPUUsageMillicores     *int64   `json:"cpu_usage_millicores" db:"cpu_usage_millicores"`
+	CPUUsagePeakMillicores *int64   `json:"cpu_usage_peak_millicores" db:"cpu_usage_peak_millicores"`
+	MemoryUsageBytes       *int64   `json:"memory_usage_bytes" db:"memory_usage_bytes"`
+	MemoryUsagePeakBytes   *int64   `json:"memory_usage_peak_bytes" db:"memory_usage_peak_bytes"`
+	UsageObservedAt        NullTime `json:"usage_observed_at" db:"usage_observed_at"`
+
 	// Kubernetes metadata
 	K8sUID         NullString `json:"k8s_uid" db:"k8s_uid"`
 	K8sLabels      JSONMap    `json:"k8s_labels" db:"k8s_labels"`
@@ -508,11 +838,316 @@ type Namespace struct {
 	Metadata     JSONMap        `json:"metadata" db:"metadata"`
 
 	// Computed fields (not in DB)
-	Cluster                 *Cluster      `json:"cluster,omitempty" db:"-"`
-	InfrastructureOwnerTeam *Team         `json:"infrastructure_owner_team,omitempty" db:"-"`
-	BusinessUnit            *BusinessUnit `json:"business_unit,omitempty" db:"-"`
-	DocumentCount           int           `json:"document_count,omitempty" db:"-"`
-	DependencyCount         int           `json:"dependency_count,omitempty" db:"-"`
+	Cluster                 *Cluster           `json:"cluster,omitempty" db:"-"`
+	InfrastructureOwnerTeam *Team              `json:"infrastructure_owner_team,omitempty" db:"-"`
+	BusinessUnit            *BusinessUnit      `json:"business_unit,omitempty" db:"-"`
+	DocumentCount           int                `json:"document_count,omitempty" db:"-"`
+	DependencyCount         int                `json:"dependency_count,omitempty" db:"-"`
+	CompletenessScore       int                `json:"completeness_score" db:"-"`
+	PodSecurity             *PodSecurity       `json:"pod_security,omitempty" db:"-"`
+	Ticket                  *NamespaceTicket   `json:"ticket,omitempty" db:"-"`
+	OnCall                  []OnCallResponder  `json:"on_call,omitempty" db:"-"`
+	OnCallRotation          *TeamOnCall        `json:"on_call_rotation,omitempty" db:"-"`
+	Repositories            []SourceRepository `json:"repositories,omitempty" db:"-"`
+	MonitoringLinks         []MonitoringLink   `json:"monitoring_links,omitempty" db:"-"`
+	MetadataCheck           *MetadataCheck     `json:"metadata_check,omitempty" db:"-"`
+}
+
+// Namespace lifecycle statuses. A namespace deleted in its cluster is
+// archived: it is kept for history, searchable and restorable, but left out
+// of coverage statistics unless they include archived namespaces.
+const (
+	NamespaceStatusActive   = "active"
+	NamespaceStatusArchived = "archived"
+)
+
+// Namespace lifecycle stages of the application a namespace runs, apart
+// from whether the namespace itself still exists in its cluster
+const (
+	NamespaceLifecycleOnboarding      = "onboarding"
+	NamespaceLifecycleActive          = "active"
+	NamespaceLifecycleDecommissioning = "decommissioning"
+	NamespaceLifecycleDecommissioned  = "decommissioned"
+)
+
+// NamespaceLifecycleTransitions lists the stages each lifecycle stage can
+// move to. Decommissioned is final.
+var NamespaceLifecycleTransitions = map[string][]string{
+	NamespaceLifecycleOnboarding:      {NamespaceLifecycleActive, NamespaceLifecycleDecommissioning},
+	NamespaceLifecycleActive:          {NamespaceLifecycleDecommissioning},
+	NamespaceLifecycleDecommissioning: {NamespaceLifecycleActive, NamespaceLifecycleDecommissioned},
+	NamespaceLifecycleDecommissioned:  {},
+}
+
+// Pod Security Standards levels, as set by the pod-security.kubernetes.io
+// namespace labels
+const (
+	PodSecurityPrivileged = "privileged"
+	PodSecurityBaseline   = "baseline"
+	PodSecurityRestricted = "restricted"
+
+	// PodSecurityUnset groups namespaces without an enforce level
+	PodSecurityUnset = "unset"
+)
+
+// podSecurityLabelPrefix prefixes the Pod Security Admission labels
+const podSecurityLabelPrefix = "pod-security.kubernetes.io/"
+
+// PodSecurity is the Pod Security Admission configuration of a namespace.
+// An empty level is not set on the namespace, so the cluster's default
+// applies.
+type PodSecurity struct {
+	Enforce        string `json:"enforce"`
+	EnforceVersion string `json:"enforce_version,omitempty"`
+	Audit          string `json:"audit"`
+	AuditVersion   string `json:"audit_version,omitempty"`
+	Warn           string `json:"warn"`
+	WarnVersion    string `json:"warn_version,omitempty"`
+}
+
+// PodSecurityFromLabels reads the Pod Security Admission labels of a
+// namespace
+func PodSecurityFromLabels(labels map[string]interface{}) PodSecurity {
+	label := func(name string) string {
+		v, _ := labels[podSecurityLabelPrefix+name].(string)
+		return v
+	}
+	return PodSecurity{
+		Enforce:        label("enforce"),
+		EnforceVersion: label("enforce-version"),
+		Audit:          label("audit"),
+		AuditVersion:   label("audit-version"),
+		Warn:           label("warn"),
+		WarnVersion:    label("warn-version"),
+	}
+}
+
+// OnCallResponder is a person on call for a namespace, as reported by
+// PagerDuty or Opsgenie for its owner team
+type OnCallResponder struct {
+	EscalationLevel int        `json:"escalation_level"`
+	Name            string     `json:"name"`
+	Email           string     `json:"email,omitempty"`
+	URL             string     `json:"url,omitempty"`
+	Schedule        string     `json:"schedule,omitempty"`
+	Until           *time.Time `json:"until,omitempty"`
+}
+
+// EscalationContact is a person to escalate to for a team beyond its
+// on-call rotation, lowest level first
+type EscalationContact struct {
+	Level int    `json:"level"`
+	Name  string `json:"name"`
+	Email string `json:"email,omitempty"`
+	Phone string `json:"phone,omitempty"`
+}
+
+// TeamOnCall is the on-call rotation of a team: where its schedule lives,
+// who to escalate to, and who was on call when PagerDuty or Opsgenie were
+// last asked. Current keeps the last answer when a refresh fails.
+type TeamOnCall struct {
+	TeamID             uuid.UUID           `json:"team_id"`
+	RotationURL        string              `json:"rotation_url"`
+	EscalationContacts []EscalationContact `json:"escalation_contacts"`
+	Current            []OnCallResponder   `json:"current"`
+	RefreshedAt        *time.Time          `json:"refreshed_at,omitempty"`
+	RefreshError       string              `json:"refresh_error,omitempty"`
+}
+
+// Namespace contacts whose email addresses are validated
+const (
+	ContactRoleApplicationManager = "application_manager"
+	ContactRoleTechnicalLead      = "technical_lead"
+	ContactRoleProjectManager     = "project_manager"
+)
+
+// Reasons a namespace contact's email address fails validation
+const (
+	ContactIssueInvalidFormat = "invalid_format"
+	ContactIssueNoMailServer  = "no_mail_server"
+	ContactIssueDeparted      = "departed"
+)
+
+// NamespaceContact is the email address of one of a namespace's contacts
+type NamespaceContact struct {
+	NamespaceID    uuid.UUID `json:"namespace_id"`
+	OrganizationID uuid.UUID `json:"-"`
+	Role           string    `json:"role"`
+	Email          string    `json:"email"`
+}
+
+// ContactIssue is a namespace contact whose email address failed the last
+// validation, and why
+type ContactIssue struct {
+	NamespaceContact
+	NamespaceName string    `json:"namespace_name"`
+	ClusterID     uuid.UUID `json:"cluster_id"`
+	Reason        string    `json:"reason"`
+	DetectedAt    time.Time `json:"detected_at"`
+	CheckedAt     time.Time `json:"checked_at"`
+}
+
+// ContactValidationResult summarizes a validation of an organization's
+// namespace contacts
+type ContactValidationResult struct {
+	Checked int            `json:"checked"`
+	Flagged int            `json:"flagged"`
+	Reasons map[string]int `json:"reasons"`
+}
+
+// Reasons a remediation ticket is opened for a namespace
+const (
+	TicketReasonOrphaned     = "orphaned"
+	TicketReasonUndocumented = "undocumented"
+)
+
+// NamespaceTicket is the Jira issue last opened to fix a namespace's missing
+// owner or documents. Status is Jira's as of StatusCheckedAt.
+type NamespaceTicket struct {
+	NamespaceID     uuid.UUID  `json:"namespace_id" db:"namespace_id"`
+	OrganizationID  uuid.UUID  `json:"organization_id" db:"organization_id"`
+	IssueKey        string     `json:"issue_key" db:"issue_key"`
+	IssueURL        string     `json:"issue_url" db:"issue_url"`
+	Reason          string     `json:"reason" db:"reason"`
+	Status          NullString `json:"status" db:"status"`
+	StatusCategory  NullString `json:"status_category" db:"status_category"` // new, indeterminate, done
+	StatusCheckedAt NullTime   `json:"status_checked_at" db:"status_checked_at"`
+	CreatedBy       *uuid.UUID `json:"created_by,omitempty" db:"created_by"`
+	CreatedAt       time.Time  `json:"created_at" db:"created_at"`
+}
+
+// Resolved reports whether Jira has moved the issue to a done status
+func (t *NamespaceTicket) Resolved() bool {
+	return t.StatusCategory.ValueOrEmpty() == "done"
+}
+
+// SourceRepository is a Git repository holding the source of a namespace's
+// workloads. DefaultBranch and LastCommitAt are read from the provider;
+// CheckError is set when the last read failed.
+type SourceRepository struct {
+	ID             uuid.UUID  `json:"id" db:"id"`
+	NamespaceID    uuid.UUID  `json:"namespace_id" db:"namespace_id"`
+	OrganizationID uuid.UUID  `json:"-" db:"organization_id"`
+	URL            string     `json:"url" db:"url"`
+	Provider       string     `json:"provider" db:"provider"` // github, gitlab, other
+	DefaultBranch  NullString `json:"default_branch" db:"default_branch"`
+	LastCommitAt   NullTime   `json:"last_commit_at" db:"last_commit_at"`
+	CheckedAt      NullTime   `json:"checked_at" db:"checked_at"`
+	CheckError     NullString `json:"check_error" db:"check_error"`
+	CreatedAt      time.Time  `json:"created_at" db:"created_at"`
+}
+
+// MonitoringLink is a dashboard or other monitoring page of a namespace.
+// Grafana and Datadog dashboards are checked for existence: Missing is set
+// when the last check found the dashboard deleted, CheckError when the check
+// could not tell.
+type MonitoringLink struct {
+	ID             uuid.UUID  `json:"id" db:"id"`
+	NamespaceID    uuid.UUID  `json:"namespace_id" db:"namespace_id"`
+	OrganizationID uuid.UUID  `json:"-" db:"organization_id"`
+	Type           string     `json:"type" db:"type"` // grafana, datadog, other
+	URL            string     `json:"url" db:"url"`
+	Missing        bool       `json:"missing" db:"missing"`
+	CheckedAt      NullTime   `json:"checked_at" db:"checked_at"`
+	CheckError     NullString `json:"check_error" db:"check_error"`
+	CreatedAt      time.Time  `json:"created_at" db:"created_at"`
+}
+
+// NamespaceMonitoringCoverage counts the monitoring links of a namespace,
+// for coverage reports
+type NamespaceMonitoringCoverage struct {
+	NamespaceID uuid.UUID `json:"namespace_id"`
+	Namespace   string    `json:"namespace"`
+	ClusterID   uuid.UUID `json:"cluster_id"`
+	Cluster     string    `json:"cluster"`
+	Environment string    `json:"environment"`
+	OwnerTeam   string    `json:"owner_team,omitempty"`
+	Links       int       `json:"links"`
+	// MissingLinks are links to dashboards found deleted
+	MissingLinks int `json:"missing_links"`
+}
+
+// MetadataCheck evaluates a namespace against the metadata its organization
+// requires of it: the metadata required of every namespace and that of the
+// metadata policies matching its environment and criticality
+type MetadataCheck struct {
+	Complete bool     `json:"complete"`
+	Required []string `json:"required"`
+	Missing  []string `json:"missing"`
+	// Policies are the names of the policies the namespace falls under
+	Policies []string `json:"policies"`
+}
+
+// NamespaceConfluencePage is the Confluence page a namespace's documentation
+// is exported to. ContentHash identifies the body last exported.
+type NamespaceConfluencePage struct {
+	NamespaceID    uuid.UUID  `json:"namespace_id" db:"namespace_id"`
+	OrganizationID uuid.UUID  `json:"organization_id" db:"organization_id"`
+	PageID         string     `json:"page_id" db:"page_id"`
+	PageURL        string     `json:"page_url" db:"page_url"`
+	ContentHash    string     `json:"-" db:"content_hash"`
+	ExportedBy     *uuid.UUID `json:"exported_by,omitempty" db:"exported_by"`
+	ExportedAt     time.Time  `json:"exported_at" db:"exported_at"`
+}
+
+// NamespaceRoleBinding is a subject bound to a role in a namespace, as found
+// by the last cluster sync. ClusterRoleBindings are recorded in the
+// namespaces of the service accounts they bind.
+type NamespaceRoleBinding struct {
+	ID               uuid.UUID  `json:"id" db:"id"`
+	NamespaceID      uuid.UUID  `json:"namespace_id" db:"namespace_id"`
+	BindingKind      string     `json:"binding_kind" db:"binding_kind"` // RoleBinding, ClusterRoleBinding
+	BindingName      string     `json:"binding_name" db:"binding_name"`
+	RoleKind         string     `json:"role_kind" db:"role_kind"` // Role, ClusterRole
+	RoleName         string     `json:"role_name" db:"role_name"`
+	SubjectKind      string     `json:"subject_kind" db:"subject_kind"` // User, Group, ServiceAccount
+	SubjectName      string     `json:"subject_name" db:"subject_name"`
+	SubjectNamespace NullString `json:"subject_namespace" db:"subject_namespace"`
+	SyncedAt         time.Time  `json:"synced_at" db:"synced_at"`
+}
+
+// NamespaceServiceAccount is a service account of a namespace, as found by
+// the last cluster sync
+type NamespaceServiceAccount struct {
+	ID           uuid.UUID  `json:"id" db:"id"`
+	NamespaceID  uuid.UUID  `json:"namespace_id" db:"namespace_id"`
+	Name         string     `json:"name" db:"name"`
+	K8sUID       NullString `json:"k8s_uid" db:"k8s_uid"`
+	K8sCreatedAt NullTime   `json:"k8s_created_at" db:"k8s_created_at"`
+	SyncedAt     time.Time  `json:"synced_at" db:"synced_at"`
+}
+
+// FluxResource is a Flux Kustomization or HelmRelease of a namespace, as
+// found by the last cluster sync
+type FluxResource struct {
+	ID              uuid.UUID  `json:"id" db:"id"`
+	NamespaceID     uuid.UUID  `json:"namespace_id" db:"namespace_id"`
+	Kind            string     `json:"kind" db:"kind"`
+	Name            string     `json:"name" db:"name"`
+	SourceKind      NullString `json:"source_kind" db:"source_kind"`
+	SourceName      NullString `json:"source_name" db:"source_name"`
+	SourceNamespace NullString `json:"source_namespace" db:"source_namespace"`
+	// Path is the path of a Kustomization in its source, or the chart of a
+	// HelmRelease
+	Path     NullString `json:"path" db:"path"`
+	Revision NullString `json:"revision" db:"revision"`
+	// Ready is the status of the Ready condition: True, False or Unknown
+	Ready          string     `json:"ready" db:"ready"`
+	ReadyReason    NullString `json:"ready_reason" db:"ready_reason"`
+	ReadyMessage   NullString `json:"ready_message" db:"ready_message"`
+	ReadyChangedAt NullTime   `json:"ready_changed_at" db:"ready_changed_at"`
+	Suspended      bool       `json:"suspended" db:"suspended"`
+	SyncedAt       time.Time  `json:"synced_at" db:"synced_at"`
+}
+
+// NamespaceUsageSample is the CPU and memory the pods of a namespace were
+// using when a cluster sync asked metrics-server
+type NamespaceUsageSample struct {
+	NamespaceID   uuid.UUID `json:"namespace_id" db:"namespace_id"`
+	CPUMillicores int64     `json:"cpu_millicores" db:"cpu_millicores"`
+	MemoryBytes   int64     `json:"memory_bytes" db:"memory_bytes"`
+	Pods          int       `json:"pods" db:"pods"`
+	ObservedAt    time.Time `json:"observed_at" db:"observed_at"`
 }
 
 // ============================================
@@ -604,6 +1239,7 @@ type Document struct {
 	OrganizationID uuid.UUID  `json:"organization_id" db:"organization_id"`
 	NamespaceID    *uuid.UUID `json:"namespace_id" db:"namespace_id"`
 	ClusterID      *uuid.UUID `json:"cluster_id" db:"cluster_id"`
+	ApplicationID  *uuid.UUID `json:"application_id" db:"application_id"`
 
 	// File info
 	Name     string     `json:"name" db:"name"`
@@ -669,6 +1305,220 @@ type AuditLog struct {
 	User *User `json:"user,omitempty" db:"-"`
 }
 
+// AuditLogArchive is a month of an organization's audit logs archived to
+// object storage as compressed NDJSON
+type AuditLogArchive struct {
+	ID             uuid.UUID `json:"id" db:"id"`
+	OrganizationID uuid.UUID `json:"organization_id" db:"organization_id"`
+	Month          time.Time `json:"month" db:"month"`
+	ObjectKey      string    `json:"object_key" db:"object_key"`
+	EntryCount     int64     `json:"entry_count" db:"entry_count"`
+	SizeBytes      int64     `json:"size_bytes" db:"size_bytes"`
+	CreatedAt      time.Time `json:"created_at" db:"created_at"`
+}
+
+// Authentication events are audited with resource type AuthAuditResource
+// and the ID of the account they concern, or the nil UUID when no account
+// matched
+const (
+	AuthAuditResource       = "auth"
+	AuthActionLoginFailed   = "login_failed"
+	AuthActionRefreshFailed = "token_refresh_failed"
+	AuthActionLockout       = "lockout"
+)
+
+// Kinds of authentication anomalies
+const (
+	AuthAnomalyIP      = "ip"      // many failures from one IP address
+	AuthAnomalyAccount = "account" // many failures for one account
+)
+
+// AuthAnomaly is an IP address or account with suspiciously many
+// authentication failures
+type AuthAnomaly struct {
+	Kind     string `json:"kind"`
+	Value    string `json:"value"` // the IP address or email
+	Failures int64  `json:"failures"`
+	Lockouts int64  `json:"lockouts"`
+	// Distinct is the number of emails tried from the IP address, or of IP
+	// addresses the account was tried from
+	Distinct  int64     `json:"distinct"`
+	FirstSeen time.Time `json:"first_seen"`
+	LastSeen  time.Time `json:"last_seen"`
+}
+
+// ============================================
+// Notifications
+// ============================================
+
+// Notification event types
+const (
+	NotificationEventSyncFailed  = "sync_failed"
+	NotificationEventReportReady = "report_ready"
+	NotificationEventInvitation  = "invitation"
+	NotificationEventDigest      = "namespace_digest"
+	NotificationEventTest        = "test"
+
+	NotificationEventNamespaceOrphaned = "namespace_orphaned"
+	NotificationEventDocumentUploaded  = "document_uploaded"
+	NotificationEventOwnershipChanged  = "ownership_changed"
+	NotificationEventEscalation        = "escalation"
+	NotificationEventMention           = "comment_mention"
+	NotificationEventClusterVersionEOL = "cluster_version_eol"
+)
+
+// Notification delivery statuses
+const (
+	DeliveryStatusPending = "pending"
+	DeliveryStatusSending = "sending"
+	DeliveryStatusSent    = "sent"
+	DeliveryStatusFailed  = "failed"
+)
+
+// NotificationTemplate overrides the built-in message for an event
+type NotificationTemplate struct {
+	ID              uuid.UUID  `json:"id" db:"id"`
+	OrganizationID  *uuid.UUID `json:"organization_id" db:"organization_id"`
+	Name            string     `json:"name" db:"name"`
+	EventType       string     `json:"event_type" db:"event_type"`
+	Channel         string     `json:"channel" db:"channel"` // email, slack, teams, webhook
+	SubjectTemplate NullString `json:"subject_template" db:"subject_template"`
+	BodyTemplate    string     `json:"body_template" db:"body_template"`
+	IsActive        bool       `json:"is_active" db:"is_active"`
+	CreatedAt       time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// TeamNotificationBindings are where notifications about a team's resources
+// go: a Slack channel by ID, an email alias and a Microsoft Teams channel's
+// webhook. Unset bindings fall back to the team's contact_slack and
+// contact_email. The webhook URL is never returned.
+type TeamNotificationBindings struct {
+	TeamID          uuid.UUID `json:"team_id"`
+	SlackChannelID  string    `json:"slack_channel_id"`
+	EmailAlias      string    `json:"email_alias"`
+	TeamsWebhookURL string    `json:"-"`
+	TeamsChannel    bool      `json:"teams_channel"`
+}
+
+// NotificationDelivery is a rendered notification and its delivery state
+type NotificationDelivery struct {
+	ID             uuid.UUID   `json:"id" db:"id"`
+	OrganizationID uuid.UUID   `json:"organization_id" db:"organization_id"`
+	EventType      string      `json:"event_type" db:"event_type"`
+	Channel        string      `json:"channel" db:"channel"`
+	Recipients     StringArray `json:"recipients" db:"recipients"`
+	Subject        string      `json:"subject" db:"subject"`
+	Body           string      `json:"-" db:"body"`
+	Status         string      `json:"status" db:"status"` // pending, sending, sent, failed
+	Attempts       int         `json:"attempts" db:"attempts"`
+	LastError      NullString  `json:"last_error" db:"last_error"`
+	NextAttemptAt  time.Time   `json:"next_attempt_at" db:"next_attempt_at"`
+	SentAt         NullTime    `json:"sent_at" db:"sent_at"`
+	CreatedAt      time.Time   `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time   `json:"updated_at" db:"updated_at"`
+}
+
+// ============================================
+// Webhooks
+// ============================================
+
+// Webhook event types
+const (
+	WebhookEventClusterCreated    = "cluster.created"
+	WebhookEventClusterUpdated    = "cluster.updated"
+	WebhookEventClusterDeleted    = "cluster.deleted"
+	WebhookEventClusterSynced     = "cluster.synced"
+	WebhookEventClusterSyncFailed = "cluster.sync_failed"
+	WebhookEventNamespaceCreated  = "namespace.created"
+	WebhookEventNamespaceUpdated  = "namespace.updated"
+	WebhookEventDocumentUploaded  = "document.uploaded"
+	WebhookEventDocumentDeleted   = "document.deleted"
+	WebhookEventPing              = "ping"
+
+	// WebhookEventAll subscribes to every event
+	WebhookEventAll = "*"
+)
+
+// WebhookEventTypes lists the events a subscription can select
+var WebhookEventTypes = []string{
+	WebhookEventClusterCreated, WebhookEventClusterUpdated, WebhookEventClusterDeleted,
+	WebhookEventClusterSynced, WebhookEventClusterSyncFailed,
+	WebhookEventNamespaceCreated, WebhookEventNamespaceUpdated,
+	WebhookEventDocumentUploaded, WebhookEventDocumentDeleted,
+}
+
+// WebhookSubscription delivers selected events to an external URL
+type WebhookSubscription struct {
+	BaseModel
+	OrganizationID  uuid.UUID   `json:"organization_id" db:"organization_id"`
+	Name            string      `json:"name" db:"name"`
+	URL             string      `json:"url" db:"url"`
+	SecretEncrypted []byte      `json:"-" db:"secret_encrypted"`
+	EventTypes      StringArray `json:"event_types" db:"event_types"`
+	IsActive        bool        `json:"is_active" db:"is_active"`
+	CreatedBy       *uuid.UUID  `json:"created_by" db:"created_by"`
+}
+
+// WebhookDelivery is one attempt history entry of an event sent to a subscription
+type WebhookDelivery struct {
+	ID             uuid.UUID  `json:"id" db:"id"`
+	SubscriptionID uuid.UUID  `json:"subscription_id" db:"subscription_id"`
+	OrganizationID uuid.UUID  `json:"organization_id" db:"organization_id"`
+	EventType      string     `json:"event_type" db:"event_type"`
+	Payload        JSONMap    `json:"payload" db:"payload"`
+	Status         string     `json:"status" db:"status"` // pending, sending, sent, failed
+	Attempts       int        `json:"attempts" db:"attempts"`
+	ResponseStatus *int       `json:"response_status" db:"response_status"`
+	LastError      NullString `json:"last_error" db:"last_error"`
+	NextAttemptAt  time.Time  `json:"next_attempt_at" db:"next_attempt_at"`
+	DeliveredAt    NullTime   `json:"delivered_at" db:"delivered_at"`
+	CreatedAt      time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// ============================================
+// Escalations
+// ============================================
+
+// External dependency statuses
+const (
+	DependencyStatusActive   = "active"
+	DependencyStatusDegraded = "degraded"
+	DependencyStatusDown     = "down"
+)
+
+// Escalation statuses
+const (
+	EscalationStatusOpen         = "open"
+	EscalationStatusAcknowledged = "acknowledged"
+	EscalationStatusResolved     = "resolved"
+)
+
+// EscalationReasonDependencyDown is raised when a critical external
+// dependency goes down
+const EscalationReasonDependencyDown = "dependency_down"
+
+// Escalation is an alert that notifies the levels of a namespace's
+// escalation path one after another until it is acknowledged or resolved
+type Escalation struct {
+	ID               uuid.UUID  `json:"id" db:"id"`
+	OrganizationID   uuid.UUID  `json:"organization_id" db:"organization_id"`
+	NamespaceID      uuid.UUID  `json:"namespace_id" db:"namespace_id"`
+	Reason           string     `json:"reason" db:"reason"`
+	ResourceType     string     `json:"resource_type" db:"resource_type"`
+	ResourceID       uuid.UUID  `json:"resource_id" db:"resource_id"`
+	Summary          string     `json:"summary" db:"summary"`
+	Status           string     `json:"status" db:"status"` // open, acknowledged, resolved
+	Level            int        `json:"level" db:"level"`   // levels notified so far
+	NextEscalationAt NullTime   `json:"next_escalation_at" db:"next_escalation_at"`
+	AcknowledgedBy   *uuid.UUID `json:"acknowledged_by" db:"acknowledged_by"`
+	AcknowledgedAt   NullTime   `json:"acknowledged_at" db:"acknowledged_at"`
+	ResolvedAt       NullTime   `json:"resolved_at" db:"resolved_at"`
+	CreatedAt        time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at" db:"updated_at"`
+}
+
 // ============================================
 // Helper Types
 // ============================================
@@ -725,6 +1575,347 @@ type DashboardStats struct {
 	NoBusinessUnit         int `json:"no_business_unit"`
 }
 
+// NamespaceGap is a namespace missing inventory metadata
+type NamespaceGap struct {
+	NamespaceID         uuid.UUID  `json:"namespace_id"`
+	Namespace           string     `json:"namespace"`
+	ClusterID           uuid.UUID  `json:"cluster_id"`
+	Cluster             string     `json:"cluster"`
+	OwnerTeamID         *uuid.UUID `json:"owner_team_id"`
+	MissingOwner        bool       `json:"missing_owner"`
+	MissingDocuments    bool       `json:"missing_documents"`
+	MissingBusinessUnit bool       `json:"missing_business_unit"`
+}
+
+// How a deleted namespace was matched to the namespace that replaced it
+const (
+	NamespaceMatchK8sUID = "k8s_uid"
+	NamespaceMatchName   = "name"
+)
+
+// NamespaceDuplicate is a deleted namespace and the current namespace that
+// replaced it, matched by Kubernetes UID, or by name in a cluster with the
+// same name or API server. Documents and Dependencies count what a merge
+// would move.
+type NamespaceDuplicate struct {
+	DeletedID      uuid.UUID `json:"deleted_id"`
+	DeletedCluster string    `json:"deleted_cluster"`
+	DeletedAt      time.Time `json:"deleted_at"`
+	NamespaceID    uuid.UUID `json:"namespace_id"`
+	Cluster        string    `json:"cluster"`
+	Namespace      string    `json:"namespace"`
+	MatchedBy      string    `json:"matched_by"`
+	Documents      int       `json:"documents"`
+	Dependencies   int       `json:"dependencies"`
+}
+
+// NamespaceMerge counts what merging a deleted namespace moved to the
+// namespace that replaced it
+type NamespaceMerge struct {
+	DeletedID            uuid.UUID `json:"deleted_id"`
+	NamespaceID          uuid.UUID `json:"namespace_id"`
+	Documents            int64     `json:"documents"`
+	InternalDependencies int64     `json:"internal_dependencies"`
+	ExternalDependencies int64     `json:"external_dependencies"`
+	Links                int64     `json:"links"`
+}
+
+// ImpactedNamespace is a namespace that depends, directly or through other
+// namespaces, on a namespace under analysis. Depth is the length of the
+// shortest dependency chain; Critical is set when every dependency on some
+// chain is critical.
+type ImpactedNamespace struct {
+	NamespaceID        uuid.UUID  `json:"namespace_id"`
+	Namespace          string     `json:"namespace"`
+	ClusterID          uuid.UUID  `json:"cluster_id"`
+	Cluster            string     `json:"cluster"`
+	Environment        string     `json:"environment"`
+	Criticality        string     `json:"criticality"`
+	OwnerTeamID        *uuid.UUID `json:"owner_team_id"`
+	OwnerTeam          string     `json:"owner_team,omitempty"`
+	OwnerContactEmail  string     `json:"owner_contact_email,omitempty"`
+	OwnerContactSlack  string     `json:"owner_contact_slack,omitempty"`
+	PagerDutyServiceID string     `json:"-"`
+	OpsgenieScheduleID string     `json:"-"`
+	Depth              int        `json:"depth"`
+	Critical           bool       `json:"critical"`
+}
+
+// NamespacePodSecurity is the Pod Security Admission configuration of a
+// namespace, for compliance reports
+type NamespacePodSecurity struct {
+	NamespaceID uuid.UUID `json:"namespace_id"`
+	Namespace   string    `json:"namespace"`
+	ClusterID   uuid.UUID `json:"cluster_id"`
+	Cluster     string    `json:"cluster"`
+	PodSecurity
+}
+
+// TrashItem is a soft-deleted record that can be restored. Parent is the
+// cluster of a namespace, or the namespace or cluster of a document;
+// ParentDeleted is set while that parent is deleted too, since the item
+// cannot be restored before it.
+type TrashItem struct {
+	Type          string    `json:"type"`
+	ID            uuid.UUID `json:"id"`
+	Name          string    `json:"name"`
+	Parent        string    `json:"parent,omitempty"`
+	ParentDeleted bool      `json:"parent_deleted"`
+	DeletedAt     time.Time `json:"deleted_at"`
+}
+
+// ClusterDeletion counts what deleting a cluster soft-deletes with it: its
+// namespaces, the internal dependencies from or to them, their external
+// dependencies and the documents of the cluster and its namespaces.
+// DependentNamespaces are the namespaces of other clusters that lose their
+// dependencies on the cluster's namespaces.
+type ClusterDeletion struct {
+	Namespaces           int64 `json:"namespaces"`
+	InternalDependencies int64 `json:"internal_dependencies"`
+	ExternalDependencies int64 `json:"external_dependencies"`
+	Documents            int64 `json:"documents"`
+	DependentNamespaces  int64 `json:"dependent_namespaces"`
+}
+
+// Bulk namespace assignment statuses
+const (
+	BulkAssignmentStatusPending   = "pending"
+	BulkAssignmentStatusRunning   = "running"
+	BulkAssignmentStatusCompleted = "completed"
+	BulkAssignmentStatusFailed    = "failed"
+)
+
+// NamespaceBulkChanges are the fields a bulk assignment sets on every
+// namespace it selects. Empty fields are left unchanged.
+type NamespaceBulkChanges struct {
+	AddTags     []string   `json:"add_tags,omitempty"`
+	RemoveTags  []string   `json:"remove_tags,omitempty"`
+	OwnerTeamID *uuid.UUID `json:"owner_team_id,omitempty"`
+	Environment string     `json:"environment,omitempty"`
+}
+
+// NamespaceBulkFailure is a namespace a bulk assignment could not update
+type NamespaceBulkFailure struct {
+	NamespaceID uuid.UUID `json:"namespace_id"`
+	Namespace   string    `json:"namespace,omitempty"`
+	Error       string    `json:"error"`
+}
+
+// NamespaceBulkAssignment sets tags, an owner team or an environment on many
+// namespaces. Processed counts the namespaces done so far, and Updated,
+// Unchanged and Failed summarize them.
+type NamespaceBulkAssignment struct {
+	ID               uuid.UUID              `json:"id" db:"id"`
+	OrganizationID   uuid.UUID              `json:"organization_id" db:"organization_id"`
+	RequestedBy      *uuid.UUID             `json:"requested_by" db:"requested_by"`
+	RequestedByEmail string                 `json:"requested_by_email" db:"requested_by_email"`
+	Changes          NamespaceBulkChanges   `json:"changes" db:"changes"`
+	NamespaceIDs     []uuid.UUID            `json:"-" db:"namespace_ids"`
+	Total            int                    `json:"total" db:"-"`
+	Status           string                 `json:"status" db:"status"`
+	Processed        int                    `json:"processed" db:"processed"`
+	Updated          int                    `json:"updated" db:"updated"`
+	Unchanged        int                    `json:"unchanged" db:"unchanged"`
+	Failed           int                    `json:"failed" db:"failed"`
+	Failures         []NamespaceBulkFailure `json:"failures" db:"failures"`
+	Error            NullString             `json:"error" db:"error"`
+	StartedAt        NullTime               `json:"started_at" db:"started_at"`
+	CompletedAt      NullTime               `json:"completed_at" db:"completed_at"`
+	CreatedAt        time.Time              `json:"created_at" db:"created_at"`
+	UpdatedAt        time.Time              `json:"updated_at" db:"updated_at"`
+}
+
+// Organization export statuses
+const (
+	ExportStatusPending   = "pending"
+	ExportStatusRunning   = "running"
+	ExportStatusCompleted = "completed"
+	ExportStatusFailed    = "failed"
+	ExportStatusExpired   = "expired"
+)
+
+// OrgExport is an archive of all of an organization's data, generated in
+// the background. Progress is the percentage done and Step the entity being
+// exported while it runs.
+type OrgExport struct {
+	ID               uuid.UUID  `json:"id" db:"id"`
+	OrganizationID   uuid.UUID  `json:"organization_id" db:"organization_id"`
+	RequestedBy      *uuid.UUID `json:"requested_by" db:"requested_by"`
+	RequestedByEmail string     `json:"requested_by_email" db:"requested_by_email"`
+	Status           string     `json:"status" db:"status"`
+	Progress         int        `json:"progress" db:"progress"`
+	Step             NullString `json:"step" db:"step"`
+	ObjectKey        NullString `json:"-" db:"object_key"`
+	SizeBytes        int64      `json:"size_bytes" db:"size_bytes"`
+	Error            NullString `json:"error" db:"error"`
+	StartedAt        NullTime   `json:"started_at" db:"started_at"`
+	CompletedAt      NullTime   `json:"completed_at" db:"completed_at"`
+	ExpiresAt        NullTime   `json:"expires_at" db:"expires_at"`
+	CreatedAt        time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// Metadata backup triggers
+const (
+	BackupTriggerManual    = "manual"
+	BackupTriggerScheduled = "scheduled"
+	BackupTriggerImported  = "imported" // uploaded from another environment
+)
+
+// MetadataBackup is a snapshot of an organization's catalog metadata kept in
+// object storage. Counts are the records in the backup per section.
+type MetadataBackup struct {
+	ID             uuid.UUID        `json:"id" db:"id"`
+	OrganizationID uuid.UUID        `json:"organization_id" db:"organization_id"`
+	Trigger        string           `json:"trigger" db:"trigger"`
+	CreatedByEmail string           `json:"created_by_email" db:"created_by_email"`
+	ObjectKey      string           `json:"-" db:"object_key"`
+	SizeBytes      int64            `json:"size_bytes" db:"size_bytes"`
+	Counts         map[string]int64 `json:"counts" db:"counts"`
+	CreatedAt      time.Time        `json:"created_at" db:"created_at"`
+}
+
+// SavedSearch is a named filter set of a list endpoint, saved by a user and
+// shared with the members of TeamID when it is set. Filters are the query
+// parameters of the entity's list endpoint.
+type SavedSearch struct {
+	ID             uuid.UUID         `json:"id" db:"id"`
+	OrganizationID uuid.UUID         `json:"organization_id" db:"organization_id"`
+	UserID         uuid.UUID         `json:"user_id" db:"user_id"`
+	TeamID         *uuid.UUID        `json:"team_id" db:"team_id"`
+	Name           string            `json:"name" db:"name"`
+	Entity         string            `json:"entity" db:"entity"`
+	Filters        map[string]string `json:"filters" db:"filters"`
+	Sort           NullString        `json:"sort" db:"sort"`
+	Order          NullString        `json:"order" db:"sort_order"`
+	CreatedAt      time.Time         `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time         `json:"updated_at" db:"updated_at"`
+}
+
+// NamespaceSearchResult is a namespace found by search, with its relevance
+// and highlighted snippets of the fields that matched, by field name
+type NamespaceSearchResult struct {
+	Namespace
+	Score      float64           `json:"score"`
+	Highlights map[string]string `json:"highlights,omitempty"`
+}
+
+// Types of the records kept in a search index
+const (
+	SearchTypeNamespace          = "namespace"
+	SearchTypeDocument           = "document"
+	SearchTypeInternalDependency = "internal_dependency"
+	SearchTypeExternalDependency = "external_dependency"
+)
+
+// SearchTypes are the types of records kept in a search index
+var SearchTypes = []string{SearchTypeNamespace, SearchTypeDocument, SearchTypeInternalDependency, SearchTypeExternalDependency}
+
+// SearchDocument is the searchable text of a catalog record, as copied into
+// an external search index
+type SearchDocument struct {
+	ID             uuid.UUID `json:"id"`
+	OrganizationID uuid.UUID `json:"organization_id"`
+	Type           string    `json:"type"`
+	Name           string    `json:"name"`
+	DisplayName    string    `json:"display_name,omitempty"`
+	Description    string    `json:"description,omitempty"`
+	Tags           []string  `json:"tags,omitempty"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// SearchResult is a record found by global search, with its relevance and
+// highlighted snippets of the fields that matched, by field name. A document
+// found by its contents has the snippet of its best matching page under
+// "content", and that page's number.
+type SearchResult struct {
+	Type       string            `json:"type"`
+	ID         uuid.UUID         `json:"id"`
+	Name       string            `json:"name"`
+	Score      float64           `json:"score"`
+	Highlights map[string]string `json:"highlights,omitempty"`
+	Page       int               `json:"page,omitempty"`
+	Namespace  *Namespace        `json:"namespace,omitempty"`
+	Document   *Document         `json:"document,omitempty"`
+}
+
+// Suggestion is a name starting with a search term, for autocomplete, with
+// the type of record it names: namespace, team, cluster or tag
+type Suggestion struct {
+	Type string     `json:"type"`
+	ID   *uuid.UUID `json:"id,omitempty"` // absent for tags
+	Name string     `json:"name"`
+}
+
+// TagUsage is a tag with the number of records using it
+type TagUsage struct {
+	Tag        string `json:"tag"`
+	Namespaces int64  `json:"namespaces"`
+	Clusters   int64  `json:"clusters"`
+	Documents  int64  `json:"documents"`
+	Total      int64  `json:"total"`
+}
+
+// TagMerge is the outcome of renaming or merging tags
+type TagMerge struct {
+	Tag        string   `json:"tag"`
+	Merged     []string `json:"merged"`
+	Namespaces int64    `json:"namespaces"`
+	Clusters   int64    `json:"clusters"`
+	Documents  int64    `json:"documents"`
+}
+
+// Comment is a markdown note left on a namespace or, with ClusterID, a
+// cluster. MentionedUserIDs are the users its body mentions.
+type Comment struct {
+	ID               uuid.UUID   `json:"id" db:"id"`
+	OrganizationID   uuid.UUID   `json:"organization_id" db:"organization_id"`
+	NamespaceID      *uuid.UUID  `json:"namespace_id" db:"namespace_id"`
+	ClusterID        *uuid.UUID  `json:"cluster_id" db:"cluster_id"`
+	AuthorID         *uuid.UUID  `json:"author_id" db:"author_id"`
+	AuthorEmail      string      `json:"author_email" db:"author_email"`
+	AuthorName       NullString  `json:"author_name" db:"-"`
+	Body             string      `json:"body" db:"body"`
+	MentionedUserIDs []uuid.UUID `json:"mentioned_user_ids" db:"mentioned_user_ids"`
+	CreatedAt        time.Time   `json:"created_at" db:"created_at"`
+	UpdatedAt        time.Time   `json:"updated_at" db:"updated_at"`
+}
+
+// Organization deletion statuses
+const (
+	DeletionStatusPreviewed = "previewed"
+	DeletionStatusPending   = "pending"
+	DeletionStatusRunning   = "running"
+	DeletionStatusCompleted = "completed"
+	DeletionStatusFailed    = "failed"
+)
+
+// OrgDeletion is a request to delete an organization with all of its data.
+// Counts are the rows to remove per table, as of the preview; Step is the
+// table being deleted from while it runs.
+
+type OrgDeletion struct {
+	ID               uuid.UUID        `json:"id" db:"id"`
+	OrganizationID   uuid.UUID        `json:"organization_id" db:"organization_id"`
+	OrganizationName string           `json:"organization_name" db:"organization_name"`
+	OrganizationSlug string           `json:"organization_slug" db:"organization_slug"`
+	RequestedByEmail string           `json:"requested_by_email" db:"requested_by_email"`
+	Status           string           `json:"status" db:"status"`
+	Counts           map[string]int64 `json:"counts" db:"counts"`
+	Total            int64            `json:"total" db:"-"`
+	Step             NullString       `json:"step" db:"step"`
+	Error            NullString       `json:"error" db:"error"`
+	ExpiresAt        time.Time        `json:"expires_at" db:"expires_at"`
+	ConfirmedAt      NullTime         `json:"confirmed_at" db:"confirmed_at"`
+	StartedAt        NullTime         `json:"started_at" db:"started_at"`
+	CompletedAt      NullTime         `json:"completed_at" db:"completed_at"`
+	CreatedAt        time.Time        `json:"created_at" db:"created_at"`
+	UpdatedAt        time.Time        `json:"updated_at" db:"updated_at"`
+
+	// Blocked says why the organization cannot be deleted, if it cannot
+	Blocked string `json:"blocked,omitempty" db:"-"`
+}
+
 // EnvironmentDistribution represents namespace distribution by environment
 type EnvironmentDistribution struct {
 	Environment string `json:"environment"`
@@ -738,6 +1929,28 @@ type BusinessUnitDistribution struct {
 	Count            int        `json:"count"`
 }
 
+// TeamCompleteness is the average completeness score of the namespaces a
+// team owns. Namespaces without an owner team are grouped with no team ID.
+type TeamCompleteness struct {
+	TeamID         *uuid.UUID `json:"team_id"`
+	TeamName       string     `json:"team_name"`
+	NamespaceCount int        `json:"namespace_count"`
+	AverageScore   float64    `json:"average_score"`
+}
+
+// NamespaceUsageGroup sums the resource usage of the namespaces of a team,
+// environment, criticality tier or cluster. MeasuredCount namespaces have
+// usage; the sums are theirs.
+type NamespaceUsageGroup struct {
+	Group             string `json:"group"`
+	NamespaceCount    int    `json:"namespace_count"`
+	MeasuredCount     int    `json:"measured_count"`
+	CPUMillicores     int64  `json:"cpu_millicores"`
+	CPUPeakMillicores int64  `json:"cpu_peak_millicores"`
+	MemoryBytes       int64  `json:"memory_bytes"`
+	MemoryPeakBytes   int64  `json:"memory_peak_bytes"`
+}
+
 // ============================================
 // Validation Methods
 // ============================================
@@ -753,22 +1966,30 @@ func (c *Cluster) Validate() error {
 	if !isValidClusterType(c.ClusterType) {
 		return errors.New("invalid cluster_type")
 	}
-	if !isValidEnvironment(c.Environment) {
+	if !IsValidEnvironmentName(c.Environment) {
 		return errors.New("invalid environment")
 	}
 	return nil
 }
 
-// Validate validates the Namespace struct
-func (n *Namespace) Validate() error {
+// Validate validates the Namespace struct against the organization's
+// criticality tiers
+func (n *Namespace) Validate(tiers []CriticalityTier) error {
 	if n.ClusterID == uuid.Nil {
 		return errors.New("cluster_id is required")
 	}
 	if n.Name == "" {
 		return errors.New("name is required")
 	}
-	if n.Criticality != "" && !isValidCriticality(n.Criticality) {
-		return errors.New("invalid criticality")
+	if !namespaceNameRegex.MatchString(n.Name) {
+		return fmt.Errorf("invalid name %q: must be a DNS label", n.Name)
+	}
+	if n.Criticality != "" && FindCriticalityTier(tiers, n.Criticality) == nil {
+		names := make([]string, len(tiers))
+		for i, t := range tiers {
+			names[i] = t.Name
+		}
+		return fmt.Errorf("invalid criticality %q: must be one of %s", n.Criticality, strings.Join(names, ", "))
 	}
 	return nil
 }
@@ -813,20 +2034,44 @@ func isValidClusterType(t string) bool {
 	return false
 }
 
-func isValidEnvironment(e string) bool {
-	switch e {
-	case "production", "staging", "development", "test":
-		return true
-	}
-	return false
+// IsValidEnvironmentName reports whether e is a well-formed environment
+// name. Which environments exist is configured per organization.
+func IsValidEnvironmentName(e string) bool {
+	return configNameRegex.MatchString(e)
 }
 
-func isValidCriticality(c string) bool {
-	switch c {
-	case "tier-1", "tier-2", "tier-3":
-		return true
+// CriticalityTier is one of an organization's criticality tiers and the
+// service levels expected of namespaces in it. Tiers are ordered from most
+// to least critical.
+type CriticalityTier struct {
+	Name            string `json:"name"`
+	Description     string `json:"description,omitempty"`
+	SLOAvailability string `json:"slo_availability,omitempty"` // e.g. 99.95%
+	SLORTO          string `json:"slo_rto,omitempty"`
+	SLORPO          string `json:"slo_rpo,omitempty"`
+	SupportHours    string `json:"support_hours,omitempty"`
+}
+
+// DefaultCriticalityTiers are used until an organization defines its own
+var DefaultCriticalityTiers = []CriticalityTier{
+	{Name: "tier-1"},
+	{Name: "tier-2"},
+	{Name: "tier-3"},
+}
+
+// IsValidCriticalityName reports whether c is a well-formed tier name
+func IsValidCriticalityName(c string) bool {
+	return configNameRegex.MatchString(c)
+}
+
+// FindCriticalityTier returns the tier named name, or nil
+func FindCriticalityTier(tiers []CriticalityTier, name string) *CriticalityTier {
+	for i := range tiers {
+		if tiers[i].Name == name {
+			return &tiers[i]
+		}
 	}
-	return false
+	return nil
 }
 
 func isValidRole(r string) bool {
@@ -917,6 +2162,38 @@ func (b *BusinessUnit) ToResponse() BusinessUnitResponse {
 	}
 }
 
+// BusinessUnitNamespaceCounts counts the current namespaces of a business
+// unit with one criticality
+type BusinessUnitNamespaceCounts struct {
+	BusinessUnitID uuid.UUID
+	Criticality    string
+	Namespaces     int
+	WithOwner      int
+	Documented     int
+}
+
+// BusinessUnitStats summarizes the namespaces of a business unit, or of a
+// business unit and all units below it. Coverages are percentages of the
+// namespaces with an owner team and with documents.
+type BusinessUnitStats struct {
+	NamespaceCount        int            `json:"namespace_count"`
+	Criticality           map[string]int `json:"criticality"`
+	NamespacesWithOwner   int            `json:"namespaces_with_owner"`
+	NamespacesDocumented  int            `json:"namespaces_documented"`
+	OwnershipCoverage     float64        `json:"ownership_coverage"`
+	DocumentationCoverage float64        `json:"documentation_coverage"`
+}
+
+// BusinessUnitTreeNode is a business unit in the organization's business
+// unit tree, with the statistics of its own namespaces and rolled up over
+// its subtree
+type BusinessUnitTreeNode struct {
+	BusinessUnitResponse
+	Stats    BusinessUnitStats       `json:"stats"`
+	Rollup   BusinessUnitStats       `json:"rollup"`
+	Children []*BusinessUnitTreeNode `json:"children"`
+}
+
 // BusinessUnitsToResponse converts a slice of BusinessUnit to BusinessUnitResponse
 func BusinessUnitsToResponse(units []BusinessUnit) []BusinessUnitResponse {
 	result := make([]BusinessUnitResponse, len(units))
@@ -51,13 +51,32 @@ func TestCluster_Validate(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "custom environment",
+			cluster: Cluster{
+				Name:         "test-cluster",
+				APIServerURL: "https://api.cluster.local:6443",
+				ClusterType:  "openshift",
+				Environment:  "dr-west",
+			},
+			wantErr: false,
+		},
 		{
 			name: "invalid environment",
 			cluster: Cluster{
 				Name:         "test-cluster",
 				APIServerURL: "https://api.cluster.local:6443",
 				ClusterType:  "openshift",
-				Environment:  "invalid",
+				Environment:  "Not Valid!",
+			},
+			wantErr: true,
+		},
+		{
+			name: "missing environment",
+			cluster: Cluster{
+				Name:         "test-cluster",
+				APIServerURL: "https://api.cluster.local:6443",
+				ClusterType:  "openshift",
 			},
 			wantErr: true,
 		},
@@ -119,7 +138,7 @@ func TestNamespace_Validate(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			err := tt.namespace.Validate()
+			err := tt.namespace.Validate(DefaultCriticalityTiers)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("Namespace.Validate() error = %v, wantErr %v", err, tt.wantErr)
 			}
@@ -271,7 +290,7 @@ func TestCriticality_IsValid(t *testing.T) {
 			Name:        "test",
 			Criticality: crit,
 		}
-		if err := n.Validate(); err != nil {
+		if err := n.Validate(DefaultCriticalityTiers); err != nil {
 			t.Errorf("Namespace with criticality %s should be valid", crit)
 		}
 	}
@@ -282,7 +301,35 @@ func TestCriticality_IsValid(t *testing.T) {
 		Name:        "test",
 		Criticality: "tier-4",
 	}
-	if err := n.Validate(); err == nil {
+	if err := n.Validate(DefaultCriticalityTiers); err == nil {
 		t.Error("Namespace with criticality 'tier-4' should be invalid")
 	}
+
+	// Organizations define their own tiers
+	custom := []CriticalityTier{{Name: "gold"}, {Name: "silver"}}
+	n.Criticality = "gold"
+	if err := n.Validate(custom); err != nil {
+		t.Errorf("Namespace with custom criticality 'gold' should be valid: %v", err)
+	}
+	n.Criticality = "tier-1"
+	if err := n.Validate(custom); err == nil {
+		t.Error("Namespace with criticality 'tier-1' should be invalid for custom tiers")
+	}
+}
+
+func TestPodSecurityFromLabels(t *testing.T) {
+	ps := PodSecurityFromLabels(JSONMap{
+		"pod-security.kubernetes.io/enforce":         "baseline",
+		"pod-security.kubernetes.io/enforce-version": "v1.29",
+		"pod-security.kubernetes.io/warn":            "restricted",
+		"team":                                       "payments",
+	})
+	want := PodSecurity{Enforce: "baseline", EnforceVersion: "v1.29", Warn: "restricted"}
+	if ps != want {
+		t.Errorf("PodSecurityFromLabels() = %+v, want %+v", ps, want)
+	}
+
+	if ps := PodSecurityFromLabels(nil); ps != (PodSecurity{}) {
+		t.Errorf("PodSecurityFromLabels(nil) = %+v, want no levels", ps)
+	}
 }
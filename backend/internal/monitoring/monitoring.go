@@ -0,0 +1,257 @@
+// Package monitoring validates links to Grafana and Datadog dashboards and
+// checks through their APIs that the dashboards still exist.
+package monitoring
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Kinds of monitoring links
+const (
+	TypeGrafana = "grafana"
+	TypeDatadog = "datadog"
+	// TypeOther is any other monitoring page; it is linked but not checked
+	TypeOther = "other"
+)
+
+var (
+	ErrInvalidURL    = errors.New("invalid monitoring link")
+	ErrUnsupported   = errors.New("only Grafana and Datadog dashboards can be checked")
+	ErrNotConfigured = errors.New("no credentials for the dashboard's Grafana or Datadog site")
+	ErrNotFound      = errors.New("dashboard not found")
+)
+
+// datadogSites are the Datadog sites, regional ones before datadoghq.com
+// since they end with it. The web app is served under the site itself,
+// app.<site> or a custom subdomain, the API under api.<site>.
+var datadogSites = []string{
+	"us3.datadoghq.com",
+	"us5.datadoghq.com",
+	"ap1.datadoghq.com",
+	"datadoghq.com",
+	"datadoghq.eu",
+	"ddog-gov.com",
+}
+
+// IsDatadogSite reports whether site is a Datadog site
+func IsDatadogSite(site string) bool {
+	for _, s := range datadogSites {
+		if site == s {
+			return true
+		}
+	}
+	return false
+}
+
+// Link is a parsed monitoring link
+type Link struct {
+	URL  string
+	Type string
+	// DashboardID is the UID of a Grafana dashboard or the ID of a Datadog
+	// dashboard
+	DashboardID string
+	// Site is the Datadog site of a Datadog dashboard
+	Site string
+}
+
+// Parse validates the address of a monitoring page. Without a type, it is
+// inferred: addresses on a Datadog site are Datadog dashboards, addresses
+// with a /d/<uid> path are Grafana dashboards and anything else is other.
+func Parse(typ, raw string) (Link, error) {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return Link{}, fmt.Errorf("%w: %v", ErrInvalidURL, err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return Link{}, fmt.Errorf("%w: %q must be an http or https address", ErrInvalidURL, raw)
+	}
+	if u.User != nil {
+		return Link{}, fmt.Errorf("%w: %q must not have credentials", ErrInvalidURL, raw)
+	}
+	u.Host = strings.ToLower(u.Host)
+	u.Fragment = ""
+
+	link := Link{URL: u.String(), Type: typ}
+	site, datadogID := datadogDashboard(u)
+	grafanaUID := grafanaDashboard(u)
+	if link.Type == "" {
+		switch {
+		case site != "":
+			link.Type = TypeDatadog
+		case grafanaUID != "":
+			link.Type = TypeGrafana
+		default:
+			link.Type = TypeOther
+		}
+	}
+
+	switch link.Type {
+	case TypeGrafana:
+		if grafanaUID == "" {
+			return Link{}, fmt.Errorf("%w: %q is not a Grafana dashboard address (.../d/<uid>)", ErrInvalidURL, raw)
+		}
+		link.DashboardID = grafanaUID
+	case TypeDatadog:
+		if u.Scheme != "https" || site == "" || datadogID == "" {
+			return Link{}, fmt.Errorf("%w: %q is not a Datadog dashboard address (https://app.datadoghq.com/dashboard/<id>)", ErrInvalidURL, raw)
+		}
+		link.DashboardID = datadogID
+		link.Site = site
+	case TypeOther:
+	default:
+		return Link{}, fmt.Errorf("%w: unknown type %q", ErrInvalidURL, typ)
+	}
+	return link, nil
+}
+
+// grafanaDashboard returns the UID of a Grafana dashboard address. Grafana
+// may be served under a sub path, so /d/ can follow other segments.
+func grafanaDashboard(u *url.URL) string {
+	segments := strings.Split(strings.Trim(u.Path, "/"), "/")
+	for i := 0; i+1 < len(segments); i++ {
+		if segments[i] == "d" && segments[i+1] != "" {
+			return segments[i+1]
+		}
+	}
+	return ""
+}
+
+// datadogDashboard returns the site and ID of a Datadog dashboard address
+func datadogDashboard(u *url.URL) (site, id string) {
+	host := u.Hostname()
+	for _, s := range datadogSites {
+		if host == s || strings.HasSuffix(host, "."+s) {
+			site = s
+			break
+		}
+	}
+	if site == "" {
+		return "", ""
+	}
+	segments := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if len(segments) >= 2 && segments[0] == "dashboard" {
+		id = segments[1]
+	}
+	return site, id
+}
+
+// Config holds the credentials dashboards are checked with. Grafana
+// dashboards are only checked on the configured Grafana, and Datadog
+// dashboards only on the configured site, so keys are never sent elsewhere.
+type Config struct {
+	GrafanaURL    string
+	GrafanaToken  string
+	DatadogSite   string
+	DatadogAPIKey string
+	DatadogAppKey string
+}
+
+// Checker checks that dashboards exist
+type Checker interface {
+	Check(ctx context.Context, cfg Config, link Link) error
+	CheckDatadog(ctx context.Context, cfg Config) error
+}
+
+// Client talks to the Grafana and Datadog HTTP APIs
+type Client struct {
+	httpClient *http.Client
+	// datadogAPI is the API address of a site, as a format string
+	datadogAPI string
+}
+
+// NewClient creates a client whose requests give up after timeout
+func NewClient(timeout time.Duration) *Client {
+	return &Client{httpClient: &http.Client{Timeout: timeout}, datadogAPI: "https://api.%s"}
+}
+
+// Check implements Checker. It returns ErrNotFound when the dashboard was
+// deleted and ErrNotConfigured when there are no credentials for it.
+func (c *Client) Check(ctx context.Context, cfg Config, link Link) error {
+	switch link.Type {
+	case TypeGrafana:
+		base := strings.TrimRight(cfg.GrafanaURL, "/")
+		if base == "" || cfg.GrafanaToken == "" || !strings.HasPrefix(link.URL, base+"/") {
+			return ErrNotConfigured
+		}
+		return c.get(ctx, base+"/api/dashboards/uid/"+url.PathEscape(link.DashboardID), map[string]string{
+			"Authorization": "Bearer " + cfg.GrafanaToken,
+		})
+	case TypeDatadog:
+		if cfg.DatadogAPIKey == "" || cfg.DatadogAppKey == "" || link.Site != cfg.DatadogSite {
+			return ErrNotConfigured
+		}
+		return c.get(ctx, fmt.Sprintf(c.datadogAPI, link.Site)+"/api/v1/dashboard/"+url.PathEscape(link.DashboardID), map[string]string{
+			"DD-API-KEY":         cfg.DatadogAPIKey,
+			"DD-APPLICATION-KEY": cfg.DatadogAppKey,
+		})
+	default:
+		return ErrUnsupported
+	}
+}
+
+// CheckDatadog implements Checker, failing unless Datadog accepts the API
+// key
+func (c *Client) CheckDatadog(ctx context.Context, cfg Config) error {
+	if cfg.DatadogSite == "" || cfg.DatadogAPIKey == "" {
+		return ErrNotConfigured
+	}
+	return c.get(ctx, fmt.Sprintf(c.datadogAPI, cfg.DatadogSite)+"/api/v1/validate", map[string]string{
+		"DD-API-KEY": cfg.DatadogAPIKey,
+	})
+}
+
+func (c *Client) get(ctx context.Context, address string, headers map[string]string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, address, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach %s: %w", req.URL.Host, err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return nil
+	case http.StatusNotFound:
+		return ErrNotFound
+	default:
+		return fmt.Errorf("%s returned %d: %s", req.URL.Host, resp.StatusCode, errorMessage(resp.Body))
+	}
+}
+
+// errorMessage reads the message of a Grafana or Datadog error response,
+// falling back to the start of the body
+func errorMessage(r io.Reader) string {
+	data, _ := io.ReadAll(io.LimitReader(r, 4096))
+	var result struct {
+		Message string   `json:"message"`
+		Errors  []string `json:"errors"`
+	}
+	if err := json.Unmarshal(data, &result); err == nil {
+		if result.Message != "" {
+			return result.Message
+		}
+		if len(result.Errors) > 0 {
+			return strings.Join(result.Errors, "; ")
+		}
+	}
+	if len(data) > 512 {
+		data = data[:512]
+	}
+	return strings.TrimSpace(string(data))
+}
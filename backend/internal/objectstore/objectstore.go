@@ -0,0 +1,167 @@
+// Package objectstore stores blobs such as audit log archives on the local
+// filesystem or in an S3-compatible bucket (AWS S3, MinIO).
+package objectstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/kubeatlas/kubeatlas/internal/config"
+)
+
+var (
+	ErrNotFound     = errors.New("object not found")
+	ErrInvalidKey   = errors.New("invalid object key")
+	ErrObjectExists = errors.New("object already exists")
+)
+
+// Store reads and writes objects by key. Keys are slash-separated paths.
+type Store interface {
+	// Put stores size bytes read from body under key, replacing any object
+	// already stored there
+	Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error
+	// Get opens the object stored under key. It returns ErrNotFound when
+	// there is none.
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete removes the object stored under key. Deleting a missing object
+	// is not an error.
+	Delete(ctx context.Context, key string) error
+}
+
+// LockingStore is a Store that can write objects nobody can overwrite or
+// delete until a retention date, for records that must be immutable
+type LockingStore interface {
+	Store
+	// PutLocked stores body under key, locked until retainUntil
+	PutLocked(ctx context.Context, key string, body []byte, contentType string, retainUntil time.Time) error
+}
+
+// New creates the store configured by cfg
+func New(cfg config.StorageConfig) (Store, error) {
+	switch cfg.Type {
+	case "", "local":
+		return NewLocalStore(cfg.LocalPath), nil
+	case "s3", "minio":
+		if cfg.S3Bucket == "" {
+			return nil, fmt.Errorf("STORAGE_S3_BUCKET is required for %s storage", cfg.Type)
+		}
+		return NewS3Store(S3Config{
+			Endpoint:  cfg.S3Endpoint,
+			Region:    cfg.S3Region,
+			Bucket:    cfg.S3Bucket,
+			AccessKey: cfg.S3AccessKey,
+			SecretKey: cfg.S3SecretKey,
+		}, 5*time.Minute), nil
+	default:
+		return nil, fmt.Errorf("unknown storage type %q", cfg.Type)
+	}
+}
+
+// validateKey rejects keys that could escape the store's root
+func validateKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") {
+		return fmt.Errorf("%w: %q", ErrInvalidKey, key)
+	}
+	for _, part := range strings.Split(key, "/") {
+		if part == "" || part == "." || part == ".." {
+			return fmt.Errorf("%w: %q", ErrInvalidKey, key)
+		}
+	}
+	return nil
+}
+
+// LocalStore keeps objects as files below a directory
+type LocalStore struct {
+	dir string
+}
+
+// NewLocalStore creates a store rooted at dir
+func NewLocalStore(dir string) *LocalStore {
+	return &LocalStore{dir: dir}
+}
+
+// Put implements Store. The object is written to a temporary file first, so
+// readers never see a partial object.
+func (s *LocalStore) Put(_ context.Context, key string, body io.Reader, _ int64, _ string) error {
+	if err := validateKey(key); err != nil {
+		return err
+	}
+	path := filepath.Join(s.dir, filepath.FromSlash(key))
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return fmt.Errorf("failed to create object directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return fmt.Errorf("failed to create object: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, body); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write object: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write object: %w", err)
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// PutLocked implements LockingStore. A filesystem cannot enforce retention,
+// so the object is created read-only and never replaced; it fails with
+// ErrObjectExists when key is taken.
+func (s *LocalStore) PutLocked(_ context.Context, key string, body []byte, _ string, _ time.Time) error {
+	if err := validateKey(key); err != nil {
+		return err
+	}
+	path := filepath.Join(s.dir, filepath.FromSlash(key))
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return fmt.Errorf("failed to create object directory: %w", err)
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o440)
+	if errors.Is(err, os.ErrExist) {
+		return fmt.Errorf("%w: %s", ErrObjectExists, key)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create object: %w", err)
+	}
+	if _, err := f.Write(body); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write object: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write object: %w", err)
+	}
+	return nil
+}
+
+// Get implements Store
+func (s *LocalStore) Get(_ context.Context, key string) (io.ReadCloser, error) {
+	if err := validateKey(key); err != nil {
+		return nil, err
+	}
+	f, err := os.Open(filepath.Join(s.dir, filepath.FromSlash(key)))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, key)
+	}
+	return f, err
+}
+
+// Delete implements Store
+func (s *LocalStore) Delete(_ context.Context, key string) error {
+	if err := validateKey(key); err != nil {
+		return err
+	}
+	err := os.Remove(filepath.Join(s.dir, filepath.FromSlash(key)))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to delete object: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,178 @@
+package objectstore
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValidateKey(t *testing.T) {
+	for _, key := range []string{"audit-logs/org/2024-05.ndjson.gz", "a"} {
+		if err := validateKey(key); err != nil {
+			t.Errorf("validateKey(%q) = %v, want nil", key, err)
+		}
+	}
+	for _, key := range []string{"", "/etc/passwd", "a/../b", "a//b", "./a", `a\b`, "a/"} {
+		if err := validateKey(key); !errors.Is(err, ErrInvalidKey) {
+			t.Errorf("validateKey(%q) = %v, want ErrInvalidKey", key, err)
+		}
+	}
+}
+
+func TestLocalStore(t *testing.T) {
+	ctx := context.Background()
+	store := NewLocalStore(t.TempDir())
+
+	if _, err := store.Get(ctx, "missing/object"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Get of a missing object = %v, want ErrNotFound", err)
+	}
+
+	for _, body := range []string{"first", "second"} {
+		if err := store.Put(ctx, "a/b/c.txt", strings.NewReader(body), int64(len(body)), "text/plain"); err != nil {
+			t.Fatalf("Put failed: %v", err)
+		}
+	}
+
+	rc, err := store.Get(ctx, "a/b/c.txt")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	defer rc.Close()
+	got, _ := io.ReadAll(rc)
+	if string(got) != "second" {
+		t.Errorf("Get = %q, want the replaced object %q", got, "second")
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := store.Delete(ctx, "a/b/c.txt"); err != nil {
+			t.Fatalf("Delete failed: %v", err)
+		}
+	}
+	if _, err := store.Get(ctx, "a/b/c.txt"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get of a deleted object = %v, want ErrNotFound", err)
+	}
+}
+
+func TestS3Store(t *testing.T) {
+	objects := map[string]string{}
+	var gotAuth []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAuth = append(gotAuth, r.Header.Get("Authorization"))
+		switch r.Method {
+		case http.MethodPut:
+			body, _ := io.ReadAll(r.Body)
+			objects[r.URL.EscapedPath()] = string(body)
+		case http.MethodGet:
+			body, ok := objects[r.URL.EscapedPath()]
+			if !ok {
+				http.Error(w, "NoSuchKey", http.StatusNotFound)
+				return
+			}
+			io.WriteString(w, body)
+		case http.MethodDelete:
+			delete(objects, r.URL.EscapedPath())
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer srv.Close()
+
+	store := NewS3Store(S3Config{
+		Endpoint:  srv.URL,
+		Region:    "eu-central-1",
+		Bucket:    "archives",
+		AccessKey: "AKIDEXAMPLE",
+		SecretKey: "secret",
+	}, time.Second)
+	store.now = func() time.Time { return time.Date(2024, 5, 1, 9, 30, 0, 0, time.UTC) }
+
+	ctx := context.Background()
+	if err := store.Put(ctx, "audit logs/2024-05.gz", strings.NewReader("data"), 4, "application/gzip"); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	if _, ok := objects["/archives/audit%20logs/2024-05.gz"]; !ok {
+		t.Fatalf("Put stored %v, want a path-style escaped key", objects)
+	}
+
+	rc, err := store.Get(ctx, "audit logs/2024-05.gz")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	got, _ := io.ReadAll(rc)
+	rc.Close()
+	if string(got) != "data" {
+		t.Errorf("Get = %q, want %q", got, "data")
+	}
+
+	if _, err := store.Get(ctx, "missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get of a missing object = %v, want ErrNotFound", err)
+	}
+
+	if err := store.Delete(ctx, "audit logs/2024-05.gz"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if len(objects) != 0 {
+		t.Errorf("Delete left %v", objects)
+	}
+
+	wantPrefix := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20240501/eu-central-1/s3/aws4_request, SignedHeaders="
+	if !strings.HasPrefix(gotAuth[0], wantPrefix+"content-type;host;x-amz-content-sha256;x-amz-date, Signature=") {
+		t.Errorf("Put authorization = %q", gotAuth[0])
+	}
+	if !strings.HasPrefix(gotAuth[1], wantPrefix+"host;x-amz-content-sha256;x-amz-date, Signature=") {
+		t.Errorf("Get authorization = %q", gotAuth[1])
+	}
+}
+
+func TestLocalStorePutLocked(t *testing.T) {
+	ctx := context.Background()
+	store := NewLocalStore(t.TempDir())
+	until := time.Now().AddDate(1, 0, 0)
+
+	if err := store.PutLocked(ctx, "ledger/1.json", []byte(`{}`), "application/json", until); err != nil {
+		t.Fatalf("PutLocked failed: %v", err)
+	}
+	if err := store.PutLocked(ctx, "ledger/1.json", []byte(`{"x":1}`), "application/json", until); !errors.Is(err, ErrObjectExists) {
+		t.Errorf("second PutLocked = %v, want ErrObjectExists", err)
+	}
+
+	rc, err := store.Get(ctx, "ledger/1.json")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	defer rc.Close()
+	if got, _ := io.ReadAll(rc); string(got) != `{}` {
+		t.Errorf("Get = %q, want the first object", got)
+	}
+}
+
+func TestS3StorePutLocked(t *testing.T) {
+	var got http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+	}))
+	defer srv.Close()
+
+	store := NewS3Store(S3Config{Endpoint: srv.URL, Bucket: "ledger", AccessKey: "AKIDEXAMPLE", SecretKey: "secret"}, time.Second)
+	until := time.Date(2031, 5, 1, 0, 0, 0, 0, time.UTC)
+	if err := store.PutLocked(context.Background(), "a.json", []byte("{}"), "application/json", until); err != nil {
+		t.Fatalf("PutLocked failed: %v", err)
+	}
+
+	if got.Get("X-Amz-Object-Lock-Mode") != "COMPLIANCE" || got.Get("X-Amz-Object-Lock-Retain-Until-Date") != "2031-05-01T00:00:00Z" {
+		t.Errorf("object lock headers = %v", got)
+	}
+	// md5("{}")
+	if got.Get("Content-Md5") != "mZFLkyvTelC5g8XnyQrpOw==" {
+		t.Errorf("Content-MD5 = %q", got.Get("Content-Md5"))
+	}
+	// S3 rejects requests with unsigned x-amz-* headers
+	want := "SignedHeaders=content-md5;content-type;host;x-amz-content-sha256;x-amz-date;x-amz-object-lock-mode;x-amz-object-lock-retain-until-date,"
+	if !strings.Contains(got.Get("Authorization"), want) {
+		t.Errorf("Authorization = %q, want %s", got.Get("Authorization"), want)
+	}
+}
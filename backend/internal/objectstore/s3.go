@@ -0,0 +1,255 @@
+package objectstore
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// unsignedPayload skips hashing request bodies, which S3 accepts over HTTPS
+// and which lets uploads stream instead of being read twice
+const unsignedPayload = "UNSIGNED-PAYLOAD"
+
+// S3Config locates a bucket and the credentials to access it
+type S3Config struct {
+	// Endpoint is the base URL of an S3-compatible service such as MinIO;
+	// empty uses AWS S3 in Region
+	Endpoint  string
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+}
+
+// S3Store keeps objects in an S3 bucket, addressed path-style so it works
+// with MinIO and other S3-compatible services
+type S3Store struct {
+	cfg        S3Config
+	endpoint   string
+	httpClient *http.Client
+	now        func() time.Time
+}
+
+// NewS3Store creates a store whose requests give up after timeout
+func NewS3Store(cfg S3Config, timeout time.Duration) *S3Store {
+	if cfg.Region == "" {
+		cfg.Region = "us-east-1"
+	}
+	endpoint := strings.TrimSuffix(cfg.Endpoint, "/")
+	if endpoint == "" {
+		endpoint = "https://s3." + cfg.Region + ".amazonaws.com"
+	}
+	return &S3Store{
+		cfg:        cfg,
+		endpoint:   endpoint,
+		httpClient: &http.Client{Timeout: timeout},
+		now:        time.Now,
+	}
+}
+
+// Put implements Store
+func (s *S3Store) Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error {
+	return s.put(ctx, key, body, size, contentType, nil)
+}
+
+// PutLocked implements LockingStore using S3 Object Lock in compliance mode,
+// which even the bucket owner cannot lift. The bucket must have Object Lock
+// enabled.
+func (s *S3Store) PutLocked(ctx context.Context, key string, body []byte, contentType string, retainUntil time.Time) error {
+	// S3 requires an integrity check on uploads with a retention period
+	sum := md5.Sum(body)
+	return s.put(ctx, key, bytes.NewReader(body), int64(len(body)), contentType, map[string]string{
+		"Content-MD5":                         base64.StdEncoding.EncodeToString(sum[:]),
+		"X-Amz-Object-Lock-Mode":              "COMPLIANCE",
+		"X-Amz-Object-Lock-Retain-Until-Date": retainUntil.UTC().Format(time.RFC3339),
+	})
+}
+
+func (s *S3Store) put(ctx context.Context, key string, body io.Reader, size int64, contentType string, headers map[string]string) error {
+	req, err := s.newRequest(ctx, http.MethodPut, key, body)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	s.sign(req)
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach object storage: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return s3Error(resp)
+	}
+	return nil
+}
+
+// Get implements Store
+func (s *S3Store) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	req, err := s.newRequest(ctx, http.MethodGet, key, nil)
+	if err != nil {
+		return nil, err
+	}
+	s.sign(req)
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach object storage: %w", err)
+	}
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return resp.Body, nil
+	case http.StatusNotFound:
+		resp.Body.Close()
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, key)
+	default:
+		defer resp.Body.Close()
+		return nil, s3Error(resp)
+	}
+}
+
+// Delete implements Store. S3 reports success for missing objects too.
+func (s *S3Store) Delete(ctx context.Context, key string) error {
+	req, err := s.newRequest(ctx, http.MethodDelete, key, nil)
+	if err != nil {
+		return err
+	}
+	s.sign(req)
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach object storage: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusNoContent, http.StatusNotFound:
+		return nil
+	default:
+		return s3Error(resp)
+	}
+}
+
+func (s *S3Store) newRequest(ctx context.Context, method, key string, body io.Reader) (*http.Request, error) {
+	if err := validateKey(key); err != nil {
+		return nil, err
+	}
+	u, err := url.Parse(s.endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid object storage endpoint: %w", err)
+	}
+	u.Path = strings.TrimSuffix(u.Path, "/") + "/" + s.cfg.Bucket + "/" + key
+	u.RawPath = strings.TrimSuffix(u.RawPath, "/") + "/" + uriEscape(s.cfg.Bucket) + "/" + uriEscape(key)
+	return http.NewRequestWithContext(ctx, method, u.String(), body)
+}
+
+// sign adds an AWS Signature Version 4 authorization header to req
+func (s *S3Store) sign(req *http.Request) {
+	now := s.now().UTC()
+	amzDate := now.Format("20060102T150405Z")
+	day := now.Format("20060102")
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", unsignedPayload)
+
+	// Sign the host, content headers and every x-amz-* header, as S3
+	// requires
+	values := map[string]string{"host": req.URL.Host}
+	for name := range req.Header {
+		lower := strings.ToLower(name)
+		if lower == "content-type" || lower == "content-md5" || strings.HasPrefix(lower, "x-amz-") {
+			values[lower] = req.Header.Get(name)
+		}
+	}
+	headers := make([]string, 0, len(values))
+	for h := range values {
+		headers = append(headers, h)
+	}
+	sort.Strings(headers)
+
+	var canonicalHeaders strings.Builder
+	for _, h := range headers {
+		canonicalHeaders.WriteString(h + ":" + strings.TrimSpace(values[h]) + "\n")
+	}
+	signedHeaders := strings.Join(headers, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		unsignedPayload,
+	}, "\n")
+
+	scope := day + "/" + s.cfg.Region + "/s3/aws4_request"
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		hexSHA256(canonicalRequest),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+s.cfg.SecretKey), day)
+	key = hmacSHA256(key, s.cfg.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf(
+		"AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.cfg.AccessKey, scope, signedHeaders, signature,
+	))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+func hexSHA256(data string) string {
+	sum := sha256.Sum256([]byte(data))
+	return hex.EncodeToString(sum[:])
+}
+
+// uriEscape percent-encodes a path the way SigV4 expects: everything except
+// unreserved characters and slashes
+func uriEscape(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.', c == '~', c == '/':
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+// s3Error turns an error response into an error including S3's message
+func s3Error(resp *http.Response) error {
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	return fmt.Errorf("object storage returned %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+}
@@ -0,0 +1,231 @@
+package operator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/kubeatlas/kubeatlas/internal/apiclient"
+	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+)
+
+// OwnershipAPI sets the ownership of namespaces in KubeAtlas
+type OwnershipAPI interface {
+	ImportOwnership(ctx context.Context, namespaces []apiclient.NamespaceOwnership, dryRun bool) (*apiclient.OwnershipImport, error)
+}
+
+// Controller syncs the NamespaceOwnership resources of a cluster to
+// KubeAtlas. Each resource is synced when its spec changes and again every
+// resync period, so changes made in KubeAtlas are put back. Deleting a
+// resource leaves the ownership in KubeAtlas as it is.
+type Controller struct {
+	api      OwnershipAPI
+	cluster  string
+	logger   *zap.SugaredLogger
+	informer cache.SharedIndexInformer
+	queue    workqueue.RateLimitingInterface
+	now      func() time.Time
+	// updateStatus writes the status of a resource
+	updateStatus func(ctx context.Context, u *unstructured.Unstructured) error
+}
+
+// NewController returns a controller that syncs the NamespaceOwnership
+// resources of client to api, as the KubeAtlas cluster named cluster
+func NewController(client dynamic.Interface, api OwnershipAPI, cluster string, resync time.Duration, logger *zap.SugaredLogger) *Controller {
+	c := &Controller{
+		api:     api,
+		cluster: cluster,
+		logger:  logger,
+		informer: dynamicinformer.NewFilteredDynamicInformer(client, NamespaceOwnershipResource, metav1.NamespaceAll, resync,
+			cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc}, nil).Informer(),
+		queue: workqueue.NewRateLimitingQueueWithConfig(workqueue.DefaultControllerRateLimiter(),
+			workqueue.RateLimitingQueueConfig{Name: "namespaceownership"}),
+		now: time.Now,
+		updateStatus: func(ctx context.Context, u *unstructured.Unstructured) error {
+			_, err := client.Resource(NamespaceOwnershipResource).Namespace(u.GetNamespace()).UpdateStatus(ctx, u, metav1.UpdateOptions{})
+			return err
+		},
+	}
+
+	_, _ = c.informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: c.enqueue,
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			oldU, newU := oldObj.(*unstructured.Unstructured), newObj.(*unstructured.Unstructured)
+			// Status updates keep the generation; resyncs keep the version
+			if oldU.GetGeneration() != newU.GetGeneration() || oldU.GetResourceVersion() == newU.GetResourceVersion() {
+				c.enqueue(newObj)
+			}
+		},
+		// The oldest remaining resource of a namespace takes over
+		DeleteFunc: func(obj interface{}) {
+			if u, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+				obj = u.Obj
+			}
+			if u, ok := obj.(*unstructured.Unstructured); ok {
+				c.enqueueNamespace(u.GetNamespace())
+			}
+		},
+	})
+	return c
+}
+
+func (c *Controller) enqueue(obj interface{}) {
+	key, err := cache.MetaNamespaceKeyFunc(obj)
+	if err != nil {
+		c.logger.Warnw("Failed to get key of NamespaceOwnership", "error", err)
+		return
+	}
+	c.queue.Add(key)
+}
+
+func (c *Controller) enqueueNamespace(namespace string) {
+	objs, err := c.informer.GetIndexer().ByIndex(cache.NamespaceIndex, namespace)
+	if err != nil {
+		return
+	}
+	for _, obj := range objs {
+		c.enqueue(obj)
+	}
+}
+
+// Run syncs resources with workers goroutines until ctx is done
+func (c *Controller) Run(ctx context.Context, workers int) error {
+	defer c.queue.ShutDown()
+
+	go c.informer.Run(ctx.Done())
+	if !cache.WaitForNamedCacheSync("namespaceownership", ctx.Done(), c.informer.HasSynced) {
+		return errors.New("failed to sync NamespaceOwnership cache")
+	}
+	c.logger.Infow("Namespace operator started", "cluster", c.cluster, "workers", workers)
+
+	for i := 0; i < workers; i++ {
+		go wait.UntilWithContext(ctx, c.runWorker, time.Second)
+	}
+	<-ctx.Done()
+	return nil
+}
+
+func (c *Controller) runWorker(ctx context.Context) {
+	for c.processNextItem(ctx) {
+	}
+}
+
+func (c *Controller) processNextItem(ctx context.Context) bool {
+	item, shutdown := c.queue.Get()
+	if shutdown {
+		return false
+	}
+	defer c.queue.Done(item)
+
+	key := item.(string)
+	if err := c.reconcile(ctx, key); err != nil {
+		c.logger.Warnw("Failed to sync NamespaceOwnership, retrying", "key", key, "error", err)
+		c.queue.AddRateLimited(key)
+		return true
+	}
+	c.queue.Forget(key)
+	return true
+}
+
+// reconcile syncs the resource with key to KubeAtlas and reports the outcome
+// on its status. Errors worth retrying soon are returned; rejected ownership
+// is only retried on the next change or resync.
+func (c *Controller) reconcile(ctx context.Context, key string) error {
+	obj, exists, err := c.informer.GetIndexer().GetByKey(key)
+	if err != nil || !exists {
+		return err
+	}
+	u := obj.(*unstructured.Unstructured)
+	var o NamespaceOwnership
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, &o); err != nil {
+		return c.setStatus(ctx, u, &o, metav1.ConditionFalse, ReasonRejected, "Invalid NamespaceOwnership: "+err.Error())
+	}
+
+	if other := c.olderInNamespace(&o); other != "" {
+		return c.setStatus(ctx, u, &o, metav1.ConditionFalse, ReasonDuplicate,
+			fmt.Sprintf("NamespaceOwnership %s already declares the ownership of this namespace", other))
+	}
+
+	result, err := c.api.ImportOwnership(ctx, []apiclient.NamespaceOwnership{o.Ownership(c.cluster)}, false)
+	if err != nil {
+		var apiErr *apiclient.Error
+		if errors.As(err, &apiErr) && apiErr.StatusCode < http.StatusInternalServerError &&
+			apiErr.StatusCode != http.StatusUnauthorized && apiErr.StatusCode != http.StatusTooManyRequests {
+			return c.setStatus(ctx, u, &o, metav1.ConditionFalse, ReasonRejected, apiErr.Error())
+		}
+		if statusErr := c.setStatus(ctx, u, &o, metav1.ConditionFalse, ReasonSyncFailed, err.Error()); statusErr != nil {
+			c.logger.Warnw("Failed to update NamespaceOwnership status", "key", key, "error", statusErr)
+		}
+		return err
+	}
+	if len(result.Results) != 1 {
+		return fmt.Errorf("expected 1 import result, got %d", len(result.Results))
+	}
+
+	r := result.Results[0]
+	o.Status.NamespaceID = r.NamespaceID
+	switch r.Status {
+	case "updated":
+		c.logger.Infow("NamespaceOwnership synced", "key", key, "changes", len(r.Changes))
+		return c.setStatus(ctx, u, &o, metav1.ConditionTrue, ReasonSynced, fmt.Sprintf("Updated %d fields in KubeAtlas", len(r.Changes)))
+	case "unchanged":
+		return c.setStatus(ctx, u, &o, metav1.ConditionTrue, ReasonSynced, "KubeAtlas holds the declared ownership")
+	default:
+		return c.setStatus(ctx, u, &o, metav1.ConditionFalse, ReasonRejected, r.Error)
+	}
+}
+
+// olderInNamespace returns the name of the oldest other NamespaceOwnership
+// of the namespace of o when it precedes o, or ""
+func (c *Controller) olderInNamespace(o *NamespaceOwnership) string {
+	objs, err := c.informer.GetIndexer().ByIndex(cache.NamespaceIndex, o.Namespace)
+	if err != nil {
+		return ""
+	}
+	oldest, oldestTime := o.Name, o.CreationTimestamp
+	for _, obj := range objs {
+		u := obj.(*unstructured.Unstructured)
+		created := u.GetCreationTimestamp()
+		// Resources created in the same second are ordered by name
+		if created.Before(&oldestTime) || (created.Equal(&oldestTime) && u.GetName() < oldest) {
+			oldest, oldestTime = u.GetName(), created
+		}
+	}
+	if oldest == o.Name {
+		return ""
+	}
+	return oldest
+}
+
+// setStatus records the outcome of a sync of o on the status of u
+func (c *Controller) setStatus(ctx context.Context, u *unstructured.Unstructured, o *NamespaceOwnership, status metav1.ConditionStatus, reason, message string) error {
+	now := metav1.NewTime(c.now())
+	o.Status.ObservedGeneration = u.GetGeneration()
+	o.Status.LastSyncTime = &now
+	meta.SetStatusCondition(&o.Status.Conditions, metav1.Condition{
+		Type:               ConditionSynced,
+		Status:             status,
+		ObservedGeneration: u.GetGeneration(),
+		Reason:             reason,
+		Message:            message,
+	})
+
+	values, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&o.Status)
+	if err != nil {
+		return err
+	}
+	u = u.DeepCopy()
+	u.Object["status"] = values
+	return c.updateStatus(ctx, u)
+}
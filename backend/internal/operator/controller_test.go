@@ -0,0 +1,132 @@
+package operator
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/kubeatlas/kubeatlas/internal/apiclient"
+	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+type fakeOwnershipAPI struct {
+	calls  [][]apiclient.NamespaceOwnership
+	result apiclient.OwnershipImportResult
+}
+
+func (f *fakeOwnershipAPI) ImportOwnership(ctx context.Context, namespaces []apiclient.NamespaceOwnership, dryRun bool) (*apiclient.OwnershipImport, error) {
+	f.calls = append(f.calls, namespaces)
+	return &apiclient.OwnershipImport{Results: []apiclient.OwnershipImportResult{f.result}}, nil
+}
+
+func newOwnership(name string, created time.Time, spec NamespaceOwnershipSpec) *unstructured.Unstructured {
+	o := &NamespaceOwnership{
+		TypeMeta: metav1.TypeMeta{APIVersion: "kubeatlas.io/v1alpha1", Kind: "NamespaceOwnership"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              name,
+			Namespace:         "payments",
+			Generation:        2,
+			CreationTimestamp: metav1.NewTime(created),
+		},
+		Spec: spec,
+	}
+	values, err := runtime.DefaultUnstructuredConverter.ToUnstructured(o)
+	if err != nil {
+		panic(err)
+	}
+	return &unstructured.Unstructured{Object: values}
+}
+
+func TestOwnership(t *testing.T) {
+	o := NamespaceOwnership{
+		ObjectMeta: metav1.ObjectMeta{Name: "ownership", Namespace: "payments"},
+		Spec: NamespaceOwnershipSpec{
+			OwnerTeam:          "payments",
+			Criticality:        "tier-1",
+			ApplicationManager: &ApplicationManager{Name: "Ann", Phone: "+1 555 0100"},
+			TechnicalLead:      &Contact{Email: "lead@example.com"},
+			SLA:                &SLA{Availability: "99.9%", SupportHours: "24x7"},
+		},
+	}
+	want := apiclient.NamespaceOwnership{
+		Cluster:                 "prod-eu",
+		Namespace:               "payments",
+		OwnerTeam:               "payments",
+		Criticality:             "tier-1",
+		ApplicationManagerName:  "Ann",
+		ApplicationManagerPhone: "+1 555 0100",
+		TechnicalLeadEmail:      "lead@example.com",
+		SLAAvailability:         "99.9%",
+		SupportHours:            "24x7",
+	}
+	if got := o.Ownership("prod-eu"); got != want {
+		t.Errorf("Ownership = %+v, want %+v", got, want)
+	}
+}
+
+func TestReconcile(t *testing.T) {
+	created := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	first := newOwnership("ownership", created, NamespaceOwnershipSpec{OwnerTeam: "payments"})
+	second := newOwnership("more-ownership", created.Add(time.Hour), NamespaceOwnershipSpec{OwnerTeam: "search"})
+
+	api := &fakeOwnershipAPI{result: apiclient.OwnershipImportResult{
+		NamespaceID: "7d1f4a52-5f0e-4f4c-9a36-1b1f0e2c6a11",
+		Status:      "updated",
+		Changes:     []apiclient.FieldChange{{Field: "owner_team"}},
+	}}
+	c := NewController(nil, api, "prod-eu", time.Hour, zap.NewNop().Sugar())
+	statuses := make(map[string]*unstructured.Unstructured)
+	c.updateStatus = func(ctx context.Context, u *unstructured.Unstructured) error {
+		statuses[u.GetName()] = u
+		return nil
+	}
+	for _, obj := range []*unstructured.Unstructured{first, second} {
+		if err := c.informer.GetIndexer().Add(obj); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		calls  int
+		status metav1.ConditionStatus
+		reason string
+	}{
+		{"ownership", 1, metav1.ConditionTrue, ReasonSynced},
+		// Only the oldest resource of a namespace is synced
+		{"more-ownership", 1, metav1.ConditionFalse, ReasonDuplicate},
+	}
+	for _, tt := range tests {
+		if err := c.reconcile(context.Background(), "payments/"+tt.name); err != nil {
+			t.Fatalf("reconcile %s failed: %v", tt.name, err)
+		}
+		if len(api.calls) != tt.calls {
+			t.Errorf("reconcile %s: API called %d times, want %d", tt.name, len(api.calls), tt.calls)
+		}
+
+		u := statuses[tt.name]
+		if u == nil {
+			t.Fatalf("reconcile %s: no status written", tt.name)
+		}
+		var o NamespaceOwnership
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, &o); err != nil {
+			t.Fatal(err)
+		}
+		cond := meta.FindStatusCondition(o.Status.Conditions, ConditionSynced)
+		if cond == nil || cond.Status != tt.status || cond.Reason != tt.reason || cond.ObservedGeneration != 2 {
+			t.Errorf("reconcile %s: condition = %+v, want %s %s", tt.name, cond, tt.status, tt.reason)
+		}
+		if o.Status.ObservedGeneration != 2 || o.Status.LastSyncTime == nil {
+			t.Errorf("reconcile %s: status = %+v", tt.name, o.Status)
+		}
+	}
+
+	got := api.calls[0]
+	if len(got) != 1 || got[0].Cluster != "prod-eu" || got[0].Namespace != "payments" || got[0].OwnerTeam != "payments" {
+		t.Errorf("imported %+v, want the ownership of payments in prod-eu", got)
+	}
+}
@@ -0,0 +1,80 @@
+package operator
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+
+	"github.com/kubeatlas/kubeatlas/internal/apiclient"
+)
+
+// Session calls the KubeAtlas API as a service user, for the operator and
+// the admission webhook. Access tokens expire, so it logs in with the user's
+// email and password when it has no token and again when the token is
+// rejected.
+type Session struct {
+	client   *apiclient.Client
+	email    string
+	password string
+
+	mu sync.Mutex
+}
+
+// NewSession returns a session for client. With an email, the session logs
+// in as that user; otherwise it uses the token of client as is.
+func NewSession(client *apiclient.Client, email, password string) *Session {
+	return &Session{client: client, email: email, password: password}
+}
+
+// ImportOwnership sets the ownership of namespaces, logging in as needed
+func (s *Session) ImportOwnership(ctx context.Context, namespaces []apiclient.NamespaceOwnership, dryRun bool) (*apiclient.OwnershipImport, error) {
+	var result *apiclient.OwnershipImport
+	err := s.call(ctx, func() (err error) {
+		result, err = s.client.ImportOwnership(ctx, namespaces, dryRun)
+		return err
+	})
+	return result, err
+}
+
+// AdmissionPolicy gets the organization's admission policy, logging in as
+// needed
+func (s *Session) AdmissionPolicy(ctx context.Context) (*apiclient.AdmissionPolicy, error) {
+	var policy *apiclient.AdmissionPolicy
+	err := s.call(ctx, func() (err error) {
+		policy, err = s.client.AdmissionPolicy(ctx)
+		return err
+	})
+	return policy, err
+}
+
+// call runs fn, logging in first without a token and again if fn's token is
+// rejected
+func (s *Session) call(ctx context.Context, fn func() error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.client.Token == "" && s.email != "" {
+		if err := s.login(ctx); err != nil {
+			return err
+		}
+	}
+	err := fn()
+	var apiErr *apiclient.Error
+	if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusUnauthorized && s.email != "" {
+		if err := s.login(ctx); err != nil {
+			return err
+		}
+		return fn()
+	}
+	return err
+}
+
+func (s *Session) login(ctx context.Context) error {
+	resp, err := s.client.Login(ctx, s.email, s.password)
+	if err != nil {
+		return err
+	}
+	s.client.Token = resp.Tokens.AccessToken
+	return nil
+}
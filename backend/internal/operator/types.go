@@ -0,0 +1,121 @@
+// Package operator implements the KubeAtlas namespace operator. It runs in a
+// workload cluster and pushes the ownership that teams declare in
+// NamespaceOwnership resources, kept next to their namespaces, to KubeAtlas.
+package operator
+
+import (
+	"github.com/kubeatlas/kubeatlas/internal/apiclient"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// NamespaceOwnershipResource is the resource of the NamespaceOwnership CRD
+var NamespaceOwnershipResource = schema.GroupVersionResource{
+	Group:    "kubeatlas.io",
+	Version:  "v1alpha1",
+	Resource: "namespaceownerships",
+}
+
+// NamespaceOwnership declares the ownership of the namespace it is created
+// in. Fields left empty are not changed in KubeAtlas.
+type NamespaceOwnership struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   NamespaceOwnershipSpec   `json:"spec,omitempty"`
+	Status NamespaceOwnershipStatus `json:"status,omitempty"`
+}
+
+// NamespaceOwnershipSpec is the declared ownership of a namespace
+type NamespaceOwnershipSpec struct {
+	// OwnerTeam is the slug or name of the owning team
+	OwnerTeam string `json:"ownerTeam,omitempty"`
+	// BusinessUnit is the code or name of the business unit
+	BusinessUnit string `json:"businessUnit,omitempty"`
+	// Criticality is one of the organization's criticality tiers
+	Criticality string `json:"criticality,omitempty"`
+
+	ApplicationManager *ApplicationManager `json:"applicationManager,omitempty"`
+	TechnicalLead      *Contact            `json:"technicalLead,omitempty"`
+	ProjectManager     *Contact            `json:"projectManager,omitempty"`
+
+	SLA            *SLA   `json:"sla,omitempty"`
+	EscalationPath string `json:"escalationPath,omitempty"`
+}
+
+// Contact is a person responsible for a namespace
+type Contact struct {
+	Name  string `json:"name,omitempty"`
+	Email string `json:"email,omitempty"`
+}
+
+// ApplicationManager is the contact who also has a phone number on record
+type ApplicationManager struct {
+	Name  string `json:"name,omitempty"`
+	Email string `json:"email,omitempty"`
+	Phone string `json:"phone,omitempty"`
+}
+
+// SLA is the service level of the workloads in a namespace
+type SLA struct {
+	Availability string `json:"availability,omitempty"`
+	RTO          string `json:"rto,omitempty"`
+	RPO          string `json:"rpo,omitempty"`
+	SupportHours string `json:"supportHours,omitempty"`
+}
+
+// NamespaceOwnershipStatus reports the last sync of a NamespaceOwnership
+type NamespaceOwnershipStatus struct {
+	// ObservedGeneration is the generation of the spec last synced
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// NamespaceID is the ID of the namespace in KubeAtlas
+	NamespaceID  string       `json:"namespaceId,omitempty"`
+	LastSyncTime *metav1.Time `json:"lastSyncTime,omitempty"`
+	// Conditions holds the Synced condition
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// ConditionSynced is true when KubeAtlas holds the declared ownership
+const ConditionSynced = "Synced"
+
+// Reasons of the Synced condition
+const (
+	ReasonSynced     = "Synced"
+	ReasonRejected   = "Rejected"
+	ReasonSyncFailed = "SyncFailed"
+	ReasonDuplicate  = "Duplicate"
+)
+
+// Ownership returns the ownership import of the resource for the namespace
+// it is in, in cluster
+func (o *NamespaceOwnership) Ownership(cluster string) apiclient.NamespaceOwnership {
+	s := o.Spec
+	r := apiclient.NamespaceOwnership{
+		Cluster:        cluster,
+		Namespace:      o.Namespace,
+		OwnerTeam:      s.OwnerTeam,
+		BusinessUnit:   s.BusinessUnit,
+		Criticality:    s.Criticality,
+		EscalationPath: s.EscalationPath,
+	}
+	if m := s.ApplicationManager; m != nil {
+		r.ApplicationManagerName = m.Name
+		r.ApplicationManagerEmail = m.Email
+		r.ApplicationManagerPhone = m.Phone
+	}
+	if l := s.TechnicalLead; l != nil {
+		r.TechnicalLeadName = l.Name
+		r.TechnicalLeadEmail = l.Email
+	}
+	if m := s.ProjectManager; m != nil {
+		r.ProjectManagerName = m.Name
+		r.ProjectManagerEmail = m.Email
+	}
+	if sla := s.SLA; sla != nil {
+		r.SLAAvailability = sla.Availability
+		r.SLARTO = sla.RTO
+		r.SLARPO = sla.RPO
+		r.SupportHours = sla.SupportHours
+	}
+	return r
+}
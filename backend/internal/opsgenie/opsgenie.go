@@ -0,0 +1,215 @@
+// Package opsgenie reads who is on call for an Opsgenie schedule and opens
+// alerts through the Opsgenie REST API.
+package opsgenie
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Opsgenie accounts live in one of two regions with separate API hosts
+const (
+	RegionUS = "us"
+	RegionEU = "eu"
+)
+
+var apiURLs = map[string]string{
+	RegionUS: "https://api.opsgenie.com",
+	RegionEU: "https://api.eu.opsgenie.com",
+}
+
+var ErrNotConfigured = errors.New("opsgenie is not configured")
+
+// Config holds the API key of an organization's Opsgenie account. Region
+// defaults to RegionUS.
+type Config struct {
+	APIKey string
+	Region string
+}
+
+// OnCall is a user on call for a schedule
+type OnCall struct {
+	Name     string
+	Schedule string
+}
+
+// Alert is an alert to open. Alias identifies it, so opening it again while
+// it is open does not page anyone twice and closing it needs only the alias.
+type Alert struct {
+	Alias       string
+	Message     string
+	Description string
+	// Priority is P1 (critical) to P5 (informational)
+	Priority string
+	// ScheduleID, when set, routes the alert to the schedule's on-call user
+	ScheduleID string
+	Tags       []string
+	Details    map[string]string
+}
+
+// Pager looks up on-call users and opens and closes alerts
+type Pager interface {
+	OnCall(ctx context.Context, cfg Config, scheduleID string) ([]OnCall, error)
+	CreateAlert(ctx context.Context, cfg Config, alert Alert) error
+	CloseAlert(ctx context.Context, cfg Config, alias, note string) error
+	Check(ctx context.Context, cfg Config) error
+}
+
+// Client talks to the Opsgenie REST API
+type Client struct {
+	httpClient *http.Client
+	// apiURL overrides the region's API host, for tests
+	apiURL string
+}
+
+// NewClient creates a client whose requests give up after timeout
+func NewClient(timeout time.Duration) *Client {
+	return &Client{httpClient: &http.Client{Timeout: timeout}}
+}
+
+// OnCall implements Pager, returning the users currently on call for the
+// schedule
+func (c *Client) OnCall(ctx context.Context, cfg Config, scheduleID string) ([]OnCall, error) {
+	query := url.Values{"scheduleIdentifierType": {"id"}}
+	var result struct {
+		Data struct {
+			Parent struct {
+				Name string `json:"name"`
+			} `json:"_parent"`
+			OnCallParticipants []struct {
+				Name string `json:"name"`
+				Type string `json:"type"`
+			} `json:"onCallParticipants"`
+		} `json:"data"`
+	}
+	path := "/v2/schedules/" + url.PathEscape(scheduleID) + "/on-calls"
+	if err := c.do(ctx, cfg, http.MethodGet, path, query, nil, &result); err != nil {
+		return nil, err
+	}
+
+	oncalls := make([]OnCall, 0, len(result.Data.OnCallParticipants))
+	for _, p := range result.Data.OnCallParticipants {
+		// Teams and escalations are listed too; only users can be contacted
+		if p.Type != "user" {
+			continue
+		}
+		oncalls = append(oncalls, OnCall{Name: p.Name, Schedule: result.Data.Parent.Name})
+	}
+	return oncalls, nil
+}
+
+// CreateAlert implements Pager
+func (c *Client) CreateAlert(ctx context.Context, cfg Config, alert Alert) error {
+	body := map[string]interface{}{
+		"message":  alert.Message,
+		"alias":    alert.Alias,
+		"priority": alert.Priority,
+		"source":   "KubeAtlas",
+	}
+	if alert.Description != "" {
+		body["description"] = alert.Description
+	}
+	if alert.ScheduleID != "" {
+		body["responders"] = []map[string]string{{"id": alert.ScheduleID, "type": "schedule"}}
+	}
+	if len(alert.Tags) > 0 {
+		body["tags"] = alert.Tags
+	}
+	if len(alert.Details) > 0 {
+		body["details"] = alert.Details
+	}
+	return c.do(ctx, cfg, http.MethodPost, "/v2/alerts", nil, body, nil)
+}
+
+// CloseAlert implements Pager, closing the open alert with the alias
+func (c *Client) CloseAlert(ctx context.Context, cfg Config, alias, note string) error {
+	query := url.Values{"identifierType": {"alias"}}
+	body := map[string]string{"source": "KubeAtlas", "note": note}
+	return c.do(ctx, cfg, http.MethodPost, "/v2/alerts/"+url.PathEscape(alias)+"/close", query, body, nil)
+}
+
+// Check implements Pager, failing unless the API key is accepted
+func (c *Client) Check(ctx context.Context, cfg Config) error {
+	return c.do(ctx, cfg, http.MethodGet, "/v2/account", nil, nil, nil)
+}
+
+func (c *Client) do(ctx context.Context, cfg Config, method, path string, query url.Values, in, out interface{}) error {
+	if cfg.APIKey == "" {
+		return ErrNotConfigured
+	}
+
+	base := c.apiURL
+	if base == "" {
+		region := cfg.Region
+		if region == "" {
+			region = RegionUS
+		}
+		var ok bool
+		if base, ok = apiURLs[region]; !ok {
+			return fmt.Errorf("unknown opsgenie region %q", cfg.Region)
+		}
+	}
+	u := base + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "GenieKey "+cfg.APIKey)
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach opsgenie: %w", err)
+	}
+	defer resp.Body.Close()
+
+	// Alert requests are processed asynchronously and answered with 202
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusAccepted {
+		return fmt.Errorf("opsgenie returned %d: %s", resp.StatusCode, errorMessage(resp.Body))
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode opsgenie response: %w", err)
+	}
+	return nil
+}
+
+// errorMessage reads the message of an Opsgenie error response, falling back
+// to the start of the body
+func errorMessage(r io.Reader) string {
+	data, _ := io.ReadAll(io.LimitReader(r, 4096))
+	var result struct {
+		Message string `json:"message"`
+	}
+	if err := json.Unmarshal(data, &result); err == nil && result.Message != "" {
+		return result.Message
+	}
+	if len(data) > 512 {
+		data = data[:512]
+	}
+	return strings.TrimSpace(string(data))
+}
@@ -0,0 +1,82 @@
+package opsgenie
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestClient(t *testing.T) {
+	var gotAuth, gotQuery string
+	var gotAlert map[string]interface{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAuth = r.Header.Get("Authorization")
+		gotQuery = r.URL.RawQuery
+		switch r.URL.Path {
+		case "/v2/schedules/S1/on-calls":
+			w.Write([]byte(`{"data":{"_parent":{"name":"payments_schedule"},"onCallParticipants":[
+				{"name":"ada@example.com","type":"user"},
+				{"name":"payments_escalation","type":"escalation"}
+			]}}`))
+		case "/v2/alerts":
+			json.NewDecoder(r.Body).Decode(&gotAlert)
+			w.WriteHeader(http.StatusAccepted)
+			w.Write([]byte(`{"result":"Request will be processed","requestId":"r1"}`))
+		case "/v2/alerts/dep-1/close":
+			w.WriteHeader(http.StatusAccepted)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"message":"Schedule not found","took":0.01}`))
+		}
+	}))
+	defer srv.Close()
+
+	c := NewClient(time.Second)
+	c.apiURL = srv.URL
+	ctx := context.Background()
+	cfg := Config{APIKey: "key"}
+
+	oncalls, err := c.OnCall(ctx, cfg, "S1")
+	if err != nil {
+		t.Fatalf("OnCall() error = %v", err)
+	}
+	if gotAuth != "GenieKey key" {
+		t.Errorf("Authorization = %q", gotAuth)
+	}
+	if len(oncalls) != 1 || oncalls[0].Name != "ada@example.com" || oncalls[0].Schedule != "payments_schedule" {
+		t.Errorf("OnCall() = %+v, want only the user", oncalls)
+	}
+
+	_, err = c.OnCall(ctx, cfg, "S2")
+	if err == nil || !strings.Contains(err.Error(), "Schedule not found") {
+		t.Errorf("unknown schedule error = %v", err)
+	}
+
+	err = c.CreateAlert(ctx, cfg, Alert{Alias: "dep-1", Message: "down", Priority: "P1", ScheduleID: "S1"})
+	if err != nil {
+		t.Fatalf("CreateAlert() error = %v", err)
+	}
+	responders, _ := gotAlert["responders"].([]interface{})
+	if gotAlert["alias"] != "dep-1" || len(responders) != 1 {
+		t.Errorf("alert body = %v", gotAlert)
+	}
+
+	if err := c.CloseAlert(ctx, cfg, "dep-1", "recovered"); err != nil {
+		t.Errorf("CloseAlert() error = %v", err)
+	}
+	if gotQuery != "identifierType=alias" {
+		t.Errorf("CloseAlert() query = %q", gotQuery)
+	}
+
+	if err := c.Check(ctx, Config{}); err != ErrNotConfigured {
+		t.Errorf("unconfigured Check() error = %v, want ErrNotConfigured", err)
+	}
+	c.apiURL = ""
+	if err := c.Check(ctx, Config{APIKey: "key", Region: "apac"}); err == nil {
+		t.Error("Check() with an unknown region succeeded")
+	}
+}
@@ -0,0 +1,171 @@
+// Package pagerduty reads who is on call for a PagerDuty service through the
+// PagerDuty REST API.
+package pagerduty
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+const apiURL = "https://api.pagerduty.com"
+
+var ErrNotConfigured = errors.New("pagerduty is not configured")
+
+// Config holds the REST API key of an organization's PagerDuty account. A
+// read-only key is enough.
+type Config struct {
+	APIToken string
+}
+
+// OnCall is a user on call for a service at one escalation level
+type OnCall struct {
+	EscalationLevel int
+	Name            string
+	Email           string
+	URL             string
+	Schedule        string
+	// Until is when the shift ends, or nil for a permanent assignment
+	Until *time.Time
+}
+
+// Directory looks up on-call responders
+type Directory interface {
+	OnCall(ctx context.Context, cfg Config, serviceID string) ([]OnCall, error)
+	Check(ctx context.Context, cfg Config) error
+}
+
+// Client talks to the PagerDuty REST API
+type Client struct {
+	httpClient *http.Client
+	apiURL     string
+}
+
+// NewClient creates a client whose requests give up after timeout
+func NewClient(timeout time.Duration) *Client {
+	return &Client{
+		httpClient: &http.Client{Timeout: timeout},
+		apiURL:     apiURL,
+	}
+}
+
+type reference struct {
+	ID      string `json:"id"`
+	Summary string `json:"summary"`
+	Email   string `json:"email"`
+	HTMLURL string `json:"html_url"`
+}
+
+// OnCall implements Directory, returning the responders of the service's
+// escalation policy ordered by escalation level
+func (c *Client) OnCall(ctx context.Context, cfg Config, serviceID string) ([]OnCall, error) {
+	var service struct {
+		Service struct {
+			EscalationPolicy reference `json:"escalation_policy"`
+		} `json:"service"`
+	}
+	if err := c.get(ctx, cfg, "/services/"+url.PathEscape(serviceID), nil, &service); err != nil {
+		return nil, err
+	}
+
+	query := url.Values{
+		"escalation_policy_ids[]": {service.Service.EscalationPolicy.ID},
+		"include[]":               {"users"},
+		"earliest":                {"true"},
+	}
+	var result struct {
+		OnCalls []struct {
+			EscalationLevel int        `json:"escalation_level"`
+			End             *time.Time `json:"end"`
+			User            reference  `json:"user"`
+			Schedule        *reference `json:"schedule"`
+		} `json:"oncalls"`
+	}
+	if err := c.get(ctx, cfg, "/oncalls", query, &result); err != nil {
+		return nil, err
+	}
+
+	oncalls := make([]OnCall, 0, len(result.OnCalls))
+	for _, o := range result.OnCalls {
+		oncall := OnCall{
+			EscalationLevel: o.EscalationLevel,
+			Name:            o.User.Summary,
+			Email:           o.User.Email,
+			URL:             o.User.HTMLURL,
+			Until:           o.End,
+		}
+		if o.Schedule != nil {
+			oncall.Schedule = o.Schedule.Summary
+		}
+		oncalls = append(oncalls, oncall)
+	}
+	sort.SliceStable(oncalls, func(i, j int) bool {
+		return oncalls[i].EscalationLevel < oncalls[j].EscalationLevel
+	})
+	return oncalls, nil
+}
+
+// Check implements Directory, failing unless the API key is accepted
+func (c *Client) Check(ctx context.Context, cfg Config) error {
+	return c.get(ctx, cfg, "/abilities", nil, nil)
+}
+
+func (c *Client) get(ctx context.Context, cfg Config, path string, query url.Values, out interface{}) error {
+	if cfg.APIToken == "" {
+		return ErrNotConfigured
+	}
+
+	u := c.apiURL + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/vnd.pagerduty+json;version=2")
+	req.Header.Set("Authorization", "Token token="+cfg.APIToken)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach pagerduty: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("pagerduty returned %d: %s", resp.StatusCode, errorMessage(resp.Body))
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode pagerduty response: %w", err)
+	}
+	return nil
+}
+
+// errorMessage reads the message of a PagerDuty error response, falling back
+// to the start of the body
+func errorMessage(r io.Reader) string {
+	data, _ := io.ReadAll(io.LimitReader(r, 4096))
+	var result struct {
+		Error struct {
+			Message string   `json:"message"`
+			Errors  []string `json:"errors"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(data, &result); err == nil && result.Error.Message != "" {
+		return strings.Join(append([]string{result.Error.Message}, result.Error.Errors...), "; ")
+	}
+	if len(data) > 512 {
+		data = data[:512]
+	}
+	return strings.TrimSpace(string(data))
+}
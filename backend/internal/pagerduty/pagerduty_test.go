@@ -0,0 +1,59 @@
+package pagerduty
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestClientOnCall(t *testing.T) {
+	var gotAuth, gotPolicy string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAuth = r.Header.Get("Authorization")
+		switch r.URL.Path {
+		case "/services/PSVC1":
+			w.Write([]byte(`{"service":{"id":"PSVC1","escalation_policy":{"id":"PPOL1"}}}`))
+		case "/oncalls":
+			gotPolicy = r.URL.Query().Get("escalation_policy_ids[]")
+			w.Write([]byte(`{"oncalls":[
+				{"escalation_level":2,"end":null,"user":{"summary":"Lead","email":"lead@example.com"},"schedule":null},
+				{"escalation_level":1,"end":"2026-10-16T18:00:00Z","user":{"summary":"Ada","email":"ada@example.com","html_url":"https://acme.pagerduty.com/users/PU1"},"schedule":{"summary":"Payments primary"}}
+			]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error":{"message":"Not Found","code":2100}}`))
+		}
+	}))
+	defer srv.Close()
+
+	c := NewClient(time.Second)
+	c.apiURL = srv.URL
+	ctx := context.Background()
+	cfg := Config{APIToken: "key"}
+
+	oncalls, err := c.OnCall(ctx, cfg, "PSVC1")
+	if err != nil {
+		t.Fatalf("OnCall() error = %v", err)
+	}
+	if gotAuth != "Token token=key" || gotPolicy != "PPOL1" {
+		t.Errorf("request: auth=%q policy=%q", gotAuth, gotPolicy)
+	}
+	if len(oncalls) != 2 || oncalls[0].Name != "Ada" || oncalls[1].Name != "Lead" {
+		t.Fatalf("OnCall() = %+v, want Ada then Lead", oncalls)
+	}
+	if oncalls[0].Schedule != "Payments primary" || oncalls[0].Until == nil || oncalls[1].Until != nil {
+		t.Errorf("OnCall()[0] = %+v, [1] = %+v", oncalls[0], oncalls[1])
+	}
+
+	_, err = c.OnCall(ctx, cfg, "PNOPE")
+	if err == nil || !strings.Contains(err.Error(), "Not Found") {
+		t.Errorf("unknown service error = %v", err)
+	}
+
+	if err := c.Check(ctx, Config{}); err != ErrNotConfigured {
+		t.Errorf("unconfigured Check() error = %v, want ErrNotConfigured", err)
+	}
+}
@@ -0,0 +1,282 @@
+package searchindex
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kubeatlas/kubeatlas/internal/models"
+)
+
+// OpenSearchConfig locates an index and the credentials to access it
+type OpenSearchConfig struct {
+	URL      string
+	Index    string
+	Username string
+	Password string
+}
+
+// OpenSearch keeps documents in an OpenSearch or Elasticsearch index, which
+// it creates on first use. Documents are addressed by type and ID.
+type OpenSearch struct {
+	cfg        OpenSearchConfig
+	httpClient *http.Client
+
+	mu      sync.Mutex
+	created bool
+}
+
+// NewOpenSearch creates an index whose requests give up after timeout
+func NewOpenSearch(cfg OpenSearchConfig, timeout time.Duration) *OpenSearch {
+	cfg.URL = strings.TrimRight(cfg.URL, "/")
+	if cfg.Index == "" {
+		cfg.Index = "kubeatlas"
+	}
+	return &OpenSearch{cfg: cfg, httpClient: &http.Client{Timeout: timeout}}
+}
+
+// indexMapping maps the fields of indexed documents; indexed_at holds the
+// start of the run that wrote a document, in Unix milliseconds
+var indexMapping = map[string]interface{}{
+	"mappings": map[string]interface{}{
+		"properties": map[string]interface{}{
+			"id":              map[string]string{"type": "keyword"},
+			"organization_id": map[string]string{"type": "keyword"},
+			"type":            map[string]string{"type": "keyword"},
+			"name":            map[string]string{"type": "text"},
+			"display_name":    map[string]string{"type": "text"},
+			"description":     map[string]string{"type": "text"},
+			"tags":            map[string]string{"type": "text"},
+			"updated_at":      map[string]string{"type": "date"},
+			"indexed_at":      map[string]string{"type": "long"},
+		},
+	},
+}
+
+// searchFields are the fields searched, boosted so name matches rank above
+// description matches, which rank above tag matches
+var searchFields = []string{"name^3", "display_name^3", "description^2", "tags"}
+
+// indexedDocument is a search document as stored in the index
+type indexedDocument struct {
+	models.SearchDocument
+	IndexedAt int64 `json:"indexed_at"`
+}
+
+// Index implements Index with the bulk API
+func (o *OpenSearch) Index(ctx context.Context, run time.Time, docs []models.SearchDocument) error {
+	if len(docs) == 0 {
+		return nil
+	}
+	if err := o.ensureIndex(ctx); err != nil {
+		return err
+	}
+
+	var body bytes.Buffer
+	enc := json.NewEncoder(&body)
+	for _, doc := range docs {
+		action := map[string]interface{}{
+			"index": map[string]string{"_index": o.cfg.Index, "_id": doc.Type + ":" + doc.ID.String()},
+		}
+		if err := enc.Encode(action); err != nil {
+			return err
+		}
+		if err := enc.Encode(indexedDocument{SearchDocument: doc, IndexedAt: run.UnixMilli()}); err != nil {
+			return err
+		}
+	}
+
+	var result struct {
+		Errors bool `json:"errors"`
+		Items  []map[string]struct {
+			Status int            `json:"status"`
+			Error  *responseError `json:"error"`
+		} `json:"items"`
+	}
+	if err := o.do(ctx, http.MethodPost, "/_bulk", "application/x-ndjson", body.Bytes(), &result); err != nil {
+		return err
+	}
+	if result.Errors {
+		for _, item := range result.Items {
+			for _, op := range item {
+				if op.Error != nil {
+					op.Error.Status = op.Status
+					return fmt.Errorf("failed to index documents: %w", op.Error)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// Prune implements Index
+func (o *OpenSearch) Prune(ctx context.Context, run time.Time) error {
+	body, err := json.Marshal(map[string]interface{}{
+		"query": map[string]interface{}{
+			"range": map[string]interface{}{"indexed_at": map[string]int64{"lt": run.UnixMilli()}},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	path := "/" + o.cfg.Index + "/_delete_by_query?conflicts=proceed&refresh=true"
+	return o.do(ctx, http.MethodPost, path, "application/json", body, nil)
+}
+
+// Search implements Index. Highlights wrap matches in <mark> and escape the
+// rest of the text; matching tags are joined by commas.
+func (o *OpenSearch) Search(ctx context.Context, q Query) ([]Hit, error) {
+	filters := []interface{}{
+		map[string]interface{}{"term": map[string]string{"organization_id": q.OrganizationID.String()}},
+	}
+	if len(q.Types) > 0 {
+		filters = append(filters, map[string]interface{}{"terms": map[string][]string{"type": q.Types}})
+	}
+	match := map[string]interface{}{
+		"query":    q.Term,
+		"fields":   searchFields,
+		"operator": "and",
+	}
+	if q.Fuzzy {
+		match["fuzziness"] = "AUTO"
+	}
+	body, err := json.Marshal(map[string]interface{}{
+		"size":    q.Limit,
+		"_source": []string{"type", "id"},
+		"query": map[string]interface{}{
+			"bool": map[string]interface{}{
+				"filter": filters,
+				"must":   map[string]interface{}{"multi_match": match},
+			},
+		},
+		"highlight": map[string]interface{}{
+			"pre_tags":  []string{"<mark>"},
+			"post_tags": []string{"</mark>"},
+			"encoder":   "html",
+			"fields": map[string]interface{}{
+				"name":         map[string]int{"number_of_fragments": 0},
+				"display_name": map[string]int{"number_of_fragments": 0},
+				"description":  map[string]int{"fragment_size": 120, "number_of_fragments": 1},
+				"tags":         map[string]int{"number_of_fragments": 0},
+			},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		Hits struct {
+			Hits []struct {
+				Score  float64 `json:"_score"`
+				Source struct {
+					Type string    `json:"type"`
+					ID   uuid.UUID `json:"id"`
+				} `json:"_source"`
+				Highlight map[string][]string `json:"highlight"`
+			} `json:"hits"`
+		} `json:"hits"`
+	}
+	if err := o.do(ctx, http.MethodPost, "/"+o.cfg.Index+"/_search", "application/json", body, &result); err != nil {
+		return nil, err
+	}
+
+	hits := make([]Hit, 0, len(result.Hits.Hits))
+	for _, h := range result.Hits.Hits {
+		hit := Hit{Type: h.Source.Type, ID: h.Source.ID, Score: h.Score}
+		if len(h.Highlight) > 0 {
+			hit.Highlights = make(map[string]string, len(h.Highlight))
+			for field, fragments := range h.Highlight {
+				hit.Highlights[field] = strings.Join(fragments, ", ")
+			}
+		}
+		hits = append(hits, hit)
+	}
+	return hits, nil
+}
+
+// ensureIndex creates the index with its mapping unless it exists
+func (o *OpenSearch) ensureIndex(ctx context.Context) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.created {
+		return nil
+	}
+
+	body, err := json.Marshal(indexMapping)
+	if err != nil {
+		return err
+	}
+	err = o.do(ctx, http.MethodPut, "/"+o.cfg.Index, "application/json", body, nil)
+	var respErr *responseError
+	if err != nil && !(errors.As(err, &respErr) && respErr.Type == "resource_already_exists_exception") {
+		return fmt.Errorf("failed to create search index: %w", err)
+	}
+	o.created = true
+	return nil
+}
+
+// responseError is an error reported by the search engine
+type responseError struct {
+	Status int    `json:"-"`
+	Type   string `json:"type"`
+	Reason string `json:"reason"`
+}
+
+func (e *responseError) Error() string {
+	return fmt.Sprintf("search engine returned %d: %s: %s", e.Status, e.Type, e.Reason)
+}
+
+func (o *OpenSearch) do(ctx context.Context, method, path, contentType string, body []byte, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, method, o.cfg.URL+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("Accept", "application/json")
+	if o.cfg.Username != "" {
+		req.SetBasicAuth(o.cfg.Username, o.cfg.Password)
+	}
+
+	resp, err := o.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach search engine: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return errorResponse(resp)
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode search engine response: %w", err)
+	}
+	return nil
+}
+
+// errorResponse reads the error of a failed request, falling back to the
+// start of the body
+func errorResponse(resp *http.Response) error {
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	var result struct {
+		Error *responseError `json:"error"`
+	}
+	if err := json.Unmarshal(data, &result); err == nil && result.Error != nil {
+		result.Error.Status = resp.StatusCode
+		return result.Error
+	}
+	if len(data) > 512 {
+		data = data[:512]
+	}
+	return fmt.Errorf("search engine returned %d: %s", resp.StatusCode, strings.TrimSpace(string(data)))
+}
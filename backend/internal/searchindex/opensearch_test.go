@@ -0,0 +1,142 @@
+package searchindex
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kubeatlas/kubeatlas/internal/config"
+	"github.com/kubeatlas/kubeatlas/internal/models"
+)
+
+func TestOpenSearchIndexAndPrune(t *testing.T) {
+	var paths []string
+	var lines []map[string]interface{}
+	var prune map[string]interface{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.Method+" "+r.URL.Path)
+		if user, pass, _ := r.BasicAuth(); user != "kubeatlas" || pass != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/catalog":
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":{"type":"resource_already_exists_exception","reason":"index [catalog] already exists"},"status":400}`))
+		case "/_bulk":
+			scanner := bufio.NewScanner(r.Body)
+			for scanner.Scan() {
+				var line map[string]interface{}
+				json.Unmarshal(scanner.Bytes(), &line)
+				lines = append(lines, line)
+			}
+			w.Write([]byte(`{"errors":false,"items":[]}`))
+		case "/catalog/_delete_by_query":
+			json.NewDecoder(r.Body).Decode(&prune)
+			w.Write([]byte(`{"deleted":1}`))
+		}
+	}))
+	defer srv.Close()
+
+	idx := NewOpenSearch(OpenSearchConfig{URL: srv.URL + "/", Index: "catalog", Username: "kubeatlas", Password: "secret"}, time.Second)
+	ctx := context.Background()
+	run := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	doc := models.SearchDocument{ID: uuid.New(), OrganizationID: uuid.New(), Type: models.SearchTypeNamespace, Name: "payments-api", Tags: []string{"pci"}}
+
+	if err := idx.Index(ctx, run, []models.SearchDocument{doc}); err != nil {
+		t.Fatalf("Index() error = %v", err)
+	}
+	if err := idx.Index(ctx, run, []models.SearchDocument{doc}); err != nil {
+		t.Fatalf("second Index() error = %v", err)
+	}
+	if want := []string{"PUT /catalog", "POST /_bulk", "POST /_bulk"}; strings.Join(paths, ",") != strings.Join(want, ",") {
+		t.Errorf("requests = %v, want %v creating the index once", paths, want)
+	}
+	if len(lines) != 4 {
+		t.Fatalf("bulk lines = %v, want an action and a document per document", lines)
+	}
+	action := lines[0]["index"].(map[string]interface{})
+	if action["_index"] != "catalog" || action["_id"] != "namespace:"+doc.ID.String() {
+		t.Errorf("bulk action = %v", action)
+	}
+	if lines[1]["name"] != "payments-api" || lines[1]["indexed_at"] != float64(run.UnixMilli()) {
+		t.Errorf("bulk document = %v", lines[1])
+	}
+
+	if err := idx.Prune(ctx, run); err != nil {
+		t.Fatalf("Prune() error = %v", err)
+	}
+	indexedAt := prune["query"].(map[string]interface{})["range"].(map[string]interface{})["indexed_at"].(map[string]interface{})
+	if indexedAt["lt"] != float64(run.UnixMilli()) {
+		t.Errorf("prune query = %v, want documents indexed before the run", prune)
+	}
+}
+
+func TestOpenSearchSearch(t *testing.T) {
+	orgID, nsID := uuid.New(), uuid.New()
+	var got map[string]interface{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/kubeatlas/_search" {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error":{"type":"index_not_found_exception","reason":"no such index"},"status":404}`))
+			return
+		}
+		json.NewDecoder(r.Body).Decode(&got)
+		w.Write([]byte(`{"hits":{"hits":[{"_score":7.5,"_source":{"type":"namespace","id":"` + nsID.String() + `"},
+			"highlight":{"name":["<mark>payments</mark>-api"],"tags":["<mark>payments</mark>","<mark>payments</mark>-eu"]}}]}}`))
+	}))
+	defer srv.Close()
+
+	idx := NewOpenSearch(OpenSearchConfig{URL: srv.URL}, time.Second)
+	hits, err := idx.Search(context.Background(), Query{OrganizationID: orgID, Term: "payments", Types: []string{models.SearchTypeNamespace}, Fuzzy: true, Limit: 5})
+	if err != nil {
+		t.Fatalf("Search() error = %v", err)
+	}
+	if len(hits) != 1 || hits[0].ID != nsID || hits[0].Type != models.SearchTypeNamespace || hits[0].Score != 7.5 {
+		t.Fatalf("hits = %+v", hits)
+	}
+	if hits[0].Highlights["name"] != "<mark>payments</mark>-api" || hits[0].Highlights["tags"] != "<mark>payments</mark>, <mark>payments</mark>-eu" {
+		t.Errorf("highlights = %v", hits[0].Highlights)
+	}
+
+	query := got["query"].(map[string]interface{})["bool"].(map[string]interface{})
+	match := query["must"].(map[string]interface{})["multi_match"].(map[string]interface{})
+	if match["query"] != "payments" || match["fuzziness"] != "AUTO" {
+		t.Errorf("multi_match = %v", match)
+	}
+	filters, _ := json.Marshal(query["filter"])
+	if !strings.Contains(string(filters), orgID.String()) || !strings.Contains(string(filters), `"type":["namespace"]`) {
+		t.Errorf("filters = %s, want the organization and types", filters)
+	}
+	if got["size"] != float64(5) {
+		t.Errorf("size = %v", got["size"])
+	}
+
+	idx = NewOpenSearch(OpenSearchConfig{URL: srv.URL, Index: "missing"}, time.Second)
+	var respErr *responseError
+	if _, err := idx.Search(context.Background(), Query{OrganizationID: orgID, Term: "payments"}); !errors.As(err, &respErr) || respErr.Status != http.StatusNotFound {
+		t.Errorf("Search() of a missing index error = %v", err)
+	}
+}
+
+func TestNew(t *testing.T) {
+	if idx, err := New(config.SearchConfig{Backend: BackendPostgres}); idx != nil || err != nil {
+		t.Errorf("New(postgres) = %v, %v, want no index", idx, err)
+	}
+	if _, err := New(config.SearchConfig{Backend: BackendOpenSearch}); err == nil {
+		t.Error("New(opensearch) without a URL succeeded")
+	}
+	if idx, err := New(config.SearchConfig{Backend: "elasticsearch", URL: "http://search:9200"}); err != nil || idx == nil {
+		t.Errorf("New(elasticsearch) = %v, %v", idx, err)
+	}
+	if _, err := New(config.SearchConfig{Backend: "solr"}); !errors.Is(err, ErrUnknownBackend) {
+		t.Errorf("New(solr) error = %v, want ErrUnknownBackend", err)
+	}
+}
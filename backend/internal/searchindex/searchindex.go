@@ -0,0 +1,79 @@
+// Package searchindex keeps a copy of the catalog's searchable text in an
+// external search engine, for deployments whose catalogs outgrow Postgres
+// search. Postgres stays the source of truth: a background job copies the
+// records into the index and searches return record IDs to load from it.
+package searchindex
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kubeatlas/kubeatlas/internal/config"
+	"github.com/kubeatlas/kubeatlas/internal/models"
+)
+
+// ErrUnknownBackend is returned for an unsupported search backend
+var ErrUnknownBackend = errors.New("unknown search backend")
+
+// Backends
+const (
+	BackendPostgres   = "postgres"
+	BackendOpenSearch = "opensearch"
+)
+
+// Query searches the records of one organization
+type Query struct {
+	OrganizationID uuid.UUID
+	Term           string
+	// Types limits the search to records of these types; empty searches all
+	Types []string
+	// Fuzzy also matches terms a typo or two away
+	Fuzzy bool
+	Limit int
+}
+
+// Hit is a record matching a query, with its relevance and highlighted
+// snippets of the fields that matched, by field name
+type Hit struct {
+	Type       string
+	ID         uuid.UUID
+	Score      float64
+	Highlights map[string]string
+}
+
+// Index stores search documents and searches them
+type Index interface {
+	// Index adds or replaces documents, marking them as written by the
+	// indexing run started at run
+	Index(ctx context.Context, run time.Time, docs []models.SearchDocument) error
+	// Prune removes the documents no run since run has written, those of
+	// deleted records
+	Prune(ctx context.Context, run time.Time) error
+	// Search returns the best matches first. Name matches rank above
+	// description matches, which rank above tag matches.
+	Search(ctx context.Context, q Query) ([]Hit, error)
+}
+
+// New creates the index configured by cfg, or nil when Postgres answers
+// searches
+func New(cfg config.SearchConfig) (Index, error) {
+	switch cfg.Backend {
+	case "", BackendPostgres:
+		return nil, nil
+	case BackendOpenSearch, "elasticsearch":
+		if cfg.URL == "" {
+			return nil, fmt.Errorf("SEARCH_URL is required for the %s search backend", cfg.Backend)
+		}
+		return NewOpenSearch(OpenSearchConfig{
+			URL:      cfg.URL,
+			Index:    cfg.Index,
+			Username: cfg.Username,
+			Password: cfg.Password,
+		}, time.Duration(cfg.TimeoutSeconds)*time.Second), nil
+	default:
+		return nil, fmt.Errorf("%w %q", ErrUnknownBackend, cfg.Backend)
+	}
+}
@@ -0,0 +1,160 @@
+package services
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"unicode"
+
+	"github.com/google/uuid"
+	"github.com/kubeatlas/kubeatlas/internal/database/repositories"
+	"github.com/kubeatlas/kubeatlas/internal/escalation"
+	"go.uber.org/zap"
+)
+
+// anonymizedDocument is the content that replaces every document file
+const anonymizedDocument = "This document was replaced when the organization's data was anonymized.\n"
+
+// Anonymization is what anonymizing an organization rewrote
+type Anonymization struct {
+	// Rows are the rows rewritten or removed per table
+	Rows map[string]int64
+	// EscalationPaths is the number of escalation paths rewritten
+	EscalationPaths int
+	// Documents is the number of document files replaced
+	Documents int
+	// LedgerEntries are the entries of the append-only audit log ledger,
+	// which cannot be rewritten
+	LedgerEntries int64
+}
+
+// AnonymizeService replaces the personal data of an organization (e-mail
+// addresses, names, phone numbers and document contents) with fakes in
+// place, so a copy of production can be used for testing
+type AnonymizeService struct {
+	repo         *repositories.AnonymizeRepository
+	deletionRepo *repositories.OrgDeletionRepository
+	logger       *zap.SugaredLogger
+}
+
+// NewAnonymizeService creates a new anonymize service
+func NewAnonymizeService(repo *repositories.AnonymizeRepository, deletionRepo *repositories.OrgDeletionRepository, logger *zap.SugaredLogger) *AnonymizeService {
+	return &AnonymizeService{
+		repo:         repo,
+		deletionRepo: deletionRepo,
+		logger:       logger,
+	}
+}
+
+// Anonymize rewrites the organization's personal data. The user with
+// keepEmail, if any, keeps their identity so someone can still sign in.
+// Database rows are rewritten in one transaction; escalation paths and
+// document files are rewritten after it, one at a time, so an interrupted
+// run is completed by running it again.
+func (s *AnonymizeService) Anonymize(ctx context.Context, orgID uuid.UUID, keepEmail string) (*Anonymization, error) {
+	rows, err := s.repo.Anonymize(ctx, orgID, keepEmail)
+	if err != nil {
+		return nil, err
+	}
+	result := &Anonymization{Rows: rows}
+
+	paths, err := s.repo.ListEscalationPaths(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	for id, path := range paths {
+		if err := s.repo.SetEscalationPath(ctx, id, anonymizeEscalationPath(path, keepEmail)); err != nil {
+			return nil, fmt.Errorf("failed to anonymize escalation path of namespace %s: %w", id, err)
+		}
+		result.EscalationPaths++
+	}
+
+	docs, err := s.repo.ListDocuments(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	for _, doc := range docs {
+		if err := os.WriteFile(doc.FilePath, []byte(anonymizedDocument), 0o600); err != nil {
+			if !errors.Is(err, os.ErrNotExist) {
+				return nil, fmt.Errorf("failed to replace file of document %s: %w", doc.ID, err)
+			}
+			s.logger.Warnw("Document file is not stored here, only its record is anonymized", "document_id", doc.ID, "path", doc.FilePath)
+		}
+		fileName := "document-" + doc.ID.String()[:8] + ".txt"
+		if err := s.repo.SetDocumentFile(ctx, doc.ID, fileName, int64(len(anonymizedDocument)), "text/plain"); err != nil {
+			return nil, err
+		}
+		result.Documents++
+	}
+
+	if result.LedgerEntries, err = s.deletionRepo.CountLedgerEntries(ctx, orgID); err != nil {
+		return nil, err
+	}
+
+	s.logger.Infow("Organization anonymized", "organization_id", orgID, "rows", rows,
+		"escalation_paths", result.EscalationPaths, "documents", result.Documents)
+	return result, nil
+}
+
+// md5Hex is the hex MD5 of s, as md5() in Postgres
+func md5Hex(s string) string {
+	sum := md5.Sum([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+// fakeEmail, fakeName and fakePhone compute the same fakes as the
+// anonymization steps of the repository do in SQL
+
+func fakeEmail(email string) string {
+	return "person-" + md5Hex(strings.ToLower(strings.TrimSpace(email)))[:10] + "@example.com"
+}
+
+func fakeName(name string) string {
+	return "Person " + strings.ToUpper(md5Hex(strings.ToLower(strings.TrimSpace(name)))[:6])
+}
+
+func fakePhone(phone string) string {
+	digits := strings.NewReplacer("a", "0", "b", "1", "c", "2", "d", "3", "e", "4", "f", "5").Replace(md5Hex(strings.TrimSpace(phone)))
+	return "+1 555 " + digits[:7]
+}
+
+// anonymizeEscalationPath replaces the people in an escalation path with
+// fakes: e-mail addresses, names, and free text such as phone numbers. Slack
+// channels and delays are kept. A path that does not parse is cleared.
+func anonymizeEscalationPath(path, keepEmail string) string {
+	levels, err := escalation.Parse(path)
+	if err != nil {
+		return ""
+	}
+
+	lines := make([]string, 0, len(levels))
+	for _, level := range levels {
+		contacts := make([]string, 0, len(level.Contacts))
+		for _, c := range level.Contacts {
+			switch {
+			case c.Slack != "":
+				contacts = append(contacts, c.Slack)
+			case c.Email != "" && strings.EqualFold(c.Email, keepEmail):
+				contacts = append(contacts, c.Email)
+			case c.Email != "" && c.Name != "":
+				contacts = append(contacts, fmt.Sprintf("%s <%s>", fakeName(c.Name), fakeEmail(c.Email)))
+			case c.Email != "":
+				contacts = append(contacts, fakeEmail(c.Email))
+			case strings.IndexFunc(c.Name, unicode.IsLetter) >= 0:
+				contacts = append(contacts, fakeName(c.Name))
+			default:
+				contacts = append(contacts, fakePhone(c.Name))
+			}
+		}
+		line := strings.Join(contacts, ", ")
+		if level.Delay > 0 {
+			line = level.Delay.String() + ": " + line
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
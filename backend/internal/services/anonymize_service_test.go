@@ -0,0 +1,59 @@
+package services
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/kubeatlas/kubeatlas/internal/escalation"
+)
+
+func TestFakes(t *testing.T) {
+	// The fakes must match the ones the repository computes in SQL
+	if got := fakeEmail(" Jane@Example.com "); got != "person-"+md5Hex("jane@example.com")[:10]+"@example.com" {
+		t.Errorf("fakeEmail = %q, want it derived from the trimmed, lower-cased address", got)
+	}
+	if fakeEmail("a@example.com") == fakeEmail("b@example.com") {
+		t.Error("different addresses got the same fake")
+	}
+	if got := fakeName("Jane Doe"); !strings.HasPrefix(got, "Person ") || len(got) != len("Person ")+6 || got != fakeName("jane doe") {
+		t.Errorf("fakeName = %q, want Person and 6 hex digits, ignoring case", got)
+	}
+	phone := fakePhone("+90 555 000 0000")
+	if !strings.HasPrefix(phone, "+1 555 ") || strings.IndexFunc(phone[len("+1 555 "):], func(r rune) bool { return r < '0' || r > '9' }) >= 0 {
+		t.Errorf("fakePhone = %q, want a 555 number of digits", phone)
+	}
+}
+
+func TestAnonymizeEscalationPath(t *testing.T) {
+	path := "oncall@example.com, #payments-oncall\n15m: Jane Doe <jane@example.com>\n1h: admin@example.com, +90 555 000 0000, Ops desk"
+
+	got := anonymizeEscalationPath(path, "Admin@example.com")
+	for _, original := range []string{"oncall@example.com", "Jane Doe", "jane@example.com", "+90 555", "Ops desk"} {
+		if strings.Contains(got, original) {
+			t.Errorf("anonymized path %q still contains %q", got, original)
+		}
+	}
+	for _, kept := range []string{"#payments-oncall", "admin@example.com", fakeEmail("oncall@example.com"), fakeName("Ops desk")} {
+		if !strings.Contains(got, kept) {
+			t.Errorf("anonymized path %q is missing %q", got, kept)
+		}
+	}
+
+	levels, err := escalation.Parse(got)
+	if err != nil {
+		t.Fatalf("anonymized path does not parse: %v", err)
+	}
+	original, _ := escalation.Parse(path)
+	if len(levels) != len(original) {
+		t.Fatalf("anonymized path has %d levels, want %d", len(levels), len(original))
+	}
+	for i := range levels {
+		if levels[i].Delay != original[i].Delay || len(levels[i].Contacts) != len(original[i].Contacts) {
+			t.Errorf("level %d = %+v, want the delay and contacts of %+v", i, levels[i], original[i])
+		}
+	}
+
+	if got := anonymizeEscalationPath("15m: oncall@example.com\n5m: cto@example.com", ""); got != "" {
+		t.Errorf("invalid path anonymized to %q, want it cleared", got)
+	}
+}
@@ -0,0 +1,494 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/kubeatlas/kubeatlas/internal/database/repositories"
+	"github.com/kubeatlas/kubeatlas/internal/models"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrApplicationNotFound = errors.New("application not found")
+	ErrApplicationExists   = errors.New("an application with this slug already exists")
+	ErrInvalidApplication  = errors.New("invalid application")
+)
+
+// maxApplicationNamespaces bounds the namespaces of one application, which
+// its dashboard lists in full
+const maxApplicationNamespaces = 200
+
+// ApplicationRequest creates or replaces an application. The slug is
+// derived from the name when empty.
+type ApplicationRequest struct {
+	Name        string     `json:"name" binding:"required"`
+	Slug        string     `json:"slug"`
+	Description string     `json:"description"`
+	OwnerTeamID *uuid.UUID `json:"owner_team_id"`
+	OwnerUserID *uuid.UUID `json:"owner_user_id"`
+}
+
+func (r *ApplicationRequest) validate() error {
+	r.Name = strings.TrimSpace(r.Name)
+	if r.Name == "" || len(r.Name) > 255 {
+		return fmt.Errorf("%w: name must be 1 to 255 characters", ErrInvalidApplication)
+	}
+	if r.Slug == "" {
+		r.Slug = generateSlug(r.Name)
+	}
+	if r.Slug == "" || len(r.Slug) > 100 || r.Slug != generateSlug(r.Slug) {
+		return fmt.Errorf("%w: slug must be 1 to 100 lowercase letters, digits and hyphens", ErrInvalidApplication)
+	}
+	return nil
+}
+
+// ApplicationNamespacesRequest adds namespaces to an application
+type ApplicationNamespacesRequest struct {
+	NamespaceIDs []uuid.UUID `json:"namespace_ids" binding:"required"`
+}
+
+// ApplicationExternalDependency is an external system one or more
+// namespaces of an application depend on
+type ApplicationExternalDependency struct {
+	Name       string   `json:"name"`
+	SystemType string   `json:"system_type"`
+	Provider   string   `json:"provider"`
+	Endpoint   string   `json:"endpoint"`
+	IsCritical bool     `json:"is_critical"`
+	Namespaces []string `json:"namespaces"`
+}
+
+// ApplicationDependencies rolls up the dependencies of the namespaces of an
+// application. Upstream are the namespaces of other applications it depends
+// on, Downstream those that depend on it and Within the dependencies
+// between its own namespaces.
+type ApplicationDependencies struct {
+	ApplicationID uuid.UUID                       `json:"application_id"`
+	Upstream      []models.InternalDependency     `json:"upstream"`
+	Downstream    []models.InternalDependency     `json:"downstream"`
+	Within        []models.InternalDependency     `json:"within"`
+	External      []ApplicationExternalDependency `json:"external"`
+}
+
+// ApplicationEnvironment counts the namespaces of an application in one
+// environment and names the clusters they run in
+type ApplicationEnvironment struct {
+	Environment string   `json:"environment"`
+	Namespaces  int      `json:"namespaces"`
+	Clusters    []string `json:"clusters"`
+}
+
+// ApplicationDependencyCounts counts the rolled-up dependencies of an
+// application. Critical counts the critical upstream and external ones.
+type ApplicationDependencyCounts struct {
+	Upstream   int `json:"upstream"`
+	Downstream int `json:"downstream"`
+	External   int `json:"external"`
+	Critical   int `json:"critical"`
+}
+
+// ApplicationDashboard summarizes an application across its namespaces.
+// Usage sums the average usage of the measured, unarchived namespaces.
+type ApplicationDashboard struct {
+	Application         *models.Application         `json:"application"`
+	Namespaces          []models.Namespace          `json:"namespaces"`
+	Environments        []ApplicationEnvironment    `json:"environments"`
+	Clusters            int                         `json:"clusters"`
+	Archived            int                         `json:"archived"`
+	WithoutOwner        int                         `json:"without_owner"`
+	AverageCompleteness int                         `json:"average_completeness"`
+	CPUUsageMillicores  int64                       `json:"cpu_usage_millicores"`
+	MemoryUsageBytes    int64                       `json:"memory_usage_bytes"`
+	Documents           int                         `json:"documents"`
+	Dependencies        ApplicationDependencyCounts `json:"dependencies"`
+}
+
+// ApplicationService manages applications, the logical applications that
+// group namespaces across clusters
+type ApplicationService struct {
+	repo          *repositories.ApplicationRepository
+	namespaceRepo *repositories.NamespaceRepository
+	teamRepo      *repositories.TeamRepository
+	userRepo      *repositories.UserRepository
+	auditSvc      *AuditService
+	logger        *zap.SugaredLogger
+}
+
+// NewApplicationService creates a new application service
+func NewApplicationService(
+	repo *repositories.ApplicationRepository,
+	namespaceRepo *repositories.NamespaceRepository,
+	teamRepo *repositories.TeamRepository,
+	userRepo *repositories.UserRepository,
+	auditSvc *AuditService,
+	logger *zap.SugaredLogger,
+) *ApplicationService {
+	return &ApplicationService{
+		repo:          repo,
+		namespaceRepo: namespaceRepo,
+		teamRepo:      teamRepo,
+		userRepo:      userRepo,
+		auditSvc:      auditSvc,
+		logger:        logger,
+	}
+}
+
+// List returns the applications of the organization, by name
+func (s *ApplicationService) List(ctx context.Context, orgID uuid.UUID, p repositories.Pagination, filters map[string]interface{}) (*repositories.PaginatedResult[models.Application], error) {
+	return s.repo.List(ctx, orgID, p, filters)
+}
+
+// GetByID returns an application of the organization
+func (s *ApplicationService) GetByID(ctx context.Context, orgID, id uuid.UUID) (*models.Application, error) {
+	app, err := s.repo.GetByID(ctx, orgID, id)
+	if err != nil {
+		return nil, err
+	}
+	if app == nil {
+		return nil, ErrApplicationNotFound
+	}
+	return app, nil
+}
+
+// Create creates an application
+func (s *ApplicationService) Create(ctx context.Context, ac AuditContext, req ApplicationRequest) (*models.Application, error) {
+	app := &models.Application{OrganizationID: ac.OrgID, Metadata: make(models.JSONMap)}
+	if err := s.apply(ctx, app, req); err != nil {
+		return nil, err
+	}
+	if err := s.repo.Create(ctx, app); err != nil {
+		return nil, err
+	}
+	s.auditSvc.LogCreate(ctx, ac, "application", app.ID, app.Name, StructToMap(app))
+	return s.GetByID(ctx, ac.OrgID, app.ID)
+}
+
+// Update replaces the details and owner of an application
+func (s *ApplicationService) Update(ctx context.Context, ac AuditContext, id uuid.UUID, req ApplicationRequest) (*models.Application, error) {
+	app, err := s.GetByID(ctx, ac.OrgID, id)
+	if err != nil {
+		return nil, err
+	}
+	oldValues := StructToMap(app)
+	if err := s.apply(ctx, app, req); err != nil {
+		return nil, err
+	}
+	if err := s.repo.Update(ctx, app); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrApplicationNotFound
+		}
+		return nil, err
+	}
+	s.auditSvc.LogUpdate(ctx, ac, "application", app.ID, app.Name, oldValues, StructToMap(app))
+	return s.GetByID(ctx, ac.OrgID, app.ID)
+}
+
+// Delete deletes an application with its documents. Its namespaces are
+// kept and can join another application.
+func (s *ApplicationService) Delete(ctx context.Context, ac AuditContext, id uuid.UUID) error {
+	app, err := s.GetByID(ctx, ac.OrgID, id)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.Delete(ctx, id); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrApplicationNotFound
+		}
+		return err
+	}
+	s.auditSvc.LogDelete(ctx, ac, "application", id, app.Name)
+	return nil
+}
+
+// apply validates req and sets it on app
+func (s *ApplicationService) apply(ctx context.Context, app *models.Application, req ApplicationRequest) error {
+	if err := req.validate(); err != nil {
+		return err
+	}
+	exists, err := s.repo.ExistsBySlug(ctx, app.OrganizationID, req.Slug, app.ID)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return ErrApplicationExists
+	}
+	if req.OwnerTeamID != nil {
+		team, err := s.teamRepo.GetByID(ctx, *req.OwnerTeamID)
+		if err != nil {
+			return err
+		}
+		if team == nil || team.OrganizationID != app.OrganizationID {
+			return ErrTeamNotFound
+		}
+	}
+	if req.OwnerUserID != nil {
+		user, err := s.userRepo.GetByID(ctx, *req.OwnerUserID)
+		if err != nil {
+			return err
+		}
+		if user == nil || user.OrganizationID != app.OrganizationID {
+			return ErrUserNotFound
+		}
+	}
+
+	app.Name = req.Name
+	app.Slug = req.Slug
+	app.Description = models.NullString{}
+	if req.Description != "" {
+		app.Description = models.NewNullStringFromString(req.Description)
+	}
+	app.OwnerTeamID = req.OwnerTeamID
+	app.OwnerUserID = req.OwnerUserID
+	return nil
+}
+
+// AddNamespaces adds namespaces of the organization to an application. A
+// namespace belongs to one application at most, so namespaces of another
+// application are moved.
+func (s *ApplicationService) AddNamespaces(ctx context.Context, ac AuditContext, id uuid.UUID, req ApplicationNamespacesRequest) (*models.Application, error) {
+	app, err := s.GetByID(ctx, ac.OrgID, id)
+	if err != nil {
+		return nil, err
+	}
+	ids := uniqueIDs(req.NamespaceIDs)
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("%w: namespace_ids must not be empty", ErrInvalidApplication)
+	}
+	if app.NamespaceCount+len(ids) > maxApplicationNamespaces {
+		return nil, fmt.Errorf("%w: an application has at most %d namespaces", ErrInvalidApplication, maxApplicationNamespaces)
+	}
+	namespaces, err := s.namespaceRepo.ListByIDs(ctx, ac.OrgID, ids)
+	if err != nil {
+		return nil, err
+	}
+	if len(namespaces) != len(ids) {
+		return nil, ErrNamespaceNotFound
+	}
+
+	moved, err := s.repo.AddNamespaces(ctx, ac.OrgID, id, ids)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, len(namespaces))
+	byID := make(map[uuid.UUID]string, len(namespaces))
+	for i, ns := range namespaces {
+		names[i] = ns.Name
+		byID[ns.ID] = ns.Name
+	}
+	sort.Strings(names)
+	s.auditSvc.LogAction(ctx, ac, "add_namespaces", "application", id, app.Name,
+		"Added namespaces "+strings.Join(names, ", "))
+	for nsID, from := range moved {
+		s.auditSvc.LogAction(ctx, ac, "remove_namespace", "application", from, "",
+			fmt.Sprintf("Moved namespace %s to application %s", byID[nsID], app.Name))
+	}
+	return s.GetByID(ctx, ac.OrgID, id)
+}
+
+// RemoveNamespace removes a namespace from an application
+func (s *ApplicationService) RemoveNamespace(ctx context.Context, ac AuditContext, id, namespaceID uuid.UUID) error {
+	app, err := s.GetByID(ctx, ac.OrgID, id)
+	if err != nil {
+		return err
+	}
+	removed, err := s.repo.RemoveNamespace(ctx, id, namespaceID)
+	if err != nil {
+		return err
+	}
+	if !removed {
+		return ErrNamespaceNotFound
+	}
+	name := namespaceID.String()
+	if ns, err := s.namespaceRepo.GetByID(ctx, namespaceID); err == nil && ns != nil {
+		name = ns.Name
+	}
+	s.auditSvc.LogAction(ctx, ac, "remove_namespace", "application", id, app.Name, "Removed namespace "+name)
+	return nil
+}
+
+// Dependencies rolls up the dependencies of the namespaces of an
+// application of the organization
+func (s *ApplicationService) Dependencies(ctx context.Context, orgID, id uuid.UUID) (*ApplicationDependencies, error) {
+	if _, err := s.GetByID(ctx, orgID, id); err != nil {
+		return nil, err
+	}
+	namespaces, err := s.repo.ListNamespaces(ctx, id, maxApplicationNamespaces)
+	if err != nil {
+		return nil, err
+	}
+	internal, err := s.repo.ListInternalDependencies(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	external, err := s.repo.ListExternalDependencies(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	deps := rollUpDependencies(namespaceIDSet(namespaces), internal, external)
+	deps.ApplicationID = id
+	return deps, nil
+}
+
+// Dashboard summarizes an application of the organization across its
+// namespaces, environments and clusters
+func (s *ApplicationService) Dashboard(ctx context.Context, orgID, id uuid.UUID) (*ApplicationDashboard, error) {
+	app, err := s.GetByID(ctx, orgID, id)
+	if err != nil {
+		return nil, err
+	}
+	namespaces, err := s.repo.ListNamespaces(ctx, id, maxApplicationNamespaces)
+	if err != nil {
+		return nil, err
+	}
+	deps, err := s.Dependencies(ctx, orgID, id)
+	if err != nil {
+		return nil, err
+	}
+	documents, err := s.repo.CountDocuments(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	dashboard := summarizeApplication(namespaces)
+	dashboard.Application = app
+	dashboard.Documents = documents
+	dashboard.Dependencies = ApplicationDependencyCounts{
+		Upstream:   len(deps.Upstream),
+		Downstream: len(deps.Downstream),
+		External:   len(deps.External),
+	}
+	for _, d := range deps.Upstream {
+		if d.IsCritical {
+			dashboard.Dependencies.Critical++
+		}
+	}
+	for _, d := range deps.External {
+		if d.IsCritical {
+			dashboard.Dependencies.Critical++
+		}
+	}
+	return dashboard, nil
+}
+
+// uniqueIDs returns ids without duplicates, in order
+func uniqueIDs(ids []uuid.UUID) []uuid.UUID {
+	seen := make(map[uuid.UUID]bool, len(ids))
+	unique := make([]uuid.UUID, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	return unique
+}
+
+func namespaceIDSet(namespaces []models.Namespace) map[uuid.UUID]bool {
+	set := make(map[uuid.UUID]bool, len(namespaces))
+	for _, ns := range namespaces {
+		set[ns.ID] = true
+	}
+	return set
+}
+
+// rollUpDependencies splits the dependencies from or to the namespaces of
+// an application by direction and merges the external systems several of
+// its namespaces depend on, by name and endpoint
+func rollUpDependencies(members map[uuid.UUID]bool, internal []models.InternalDependency, external []models.ExternalDependency) *ApplicationDependencies {
+	deps := &ApplicationDependencies{
+		Upstream:   make([]models.InternalDependency, 0),
+		Downstream: make([]models.InternalDependency, 0),
+		Within:     make([]models.InternalDependency, 0),
+		External:   make([]ApplicationExternalDependency, 0),
+	}
+	for _, d := range internal {
+		switch source, target := members[d.SourceNamespaceID], members[d.TargetNamespaceID]; {
+		case source && target:
+			deps.Within = append(deps.Within, d)
+		case source:
+			deps.Upstream = append(deps.Upstream, d)
+		case target:
+			deps.Downstream = append(deps.Downstream, d)
+		}
+	}
+
+	merged := make(map[string]int)
+	for _, d := range external {
+		key := strings.ToLower(d.Name) + "\x00" + strings.ToLower(d.Endpoint.String)
+		i, ok := merged[key]
+		if !ok {
+			i = len(deps.External)
+			merged[key] = i
+			deps.External = append(deps.External, ApplicationExternalDependency{
+				Name:       d.Name,
+				SystemType: d.SystemType,
+				Provider:   d.Provider.String,
+				Endpoint:   d.Endpoint.String,
+				Namespaces: []string{},
+			})
+		}
+		e := &deps.External[i]
+		e.IsCritical = e.IsCritical || d.IsCritical
+		if d.Namespace != nil && !containsString(e.Namespaces, d.Namespace.Name) {
+			e.Namespaces = append(e.Namespaces, d.Namespace.Name)
+		}
+	}
+	return deps
+}
+
+// summarizeApplication counts the namespaces of an application by
+// environment and cluster, and sums their usage and completeness
+func summarizeApplication(namespaces []models.Namespace) *ApplicationDashboard {
+	dashboard := &ApplicationDashboard{Namespaces: namespaces, Environments: make([]ApplicationEnvironment, 0)}
+
+	environments := make(map[string]*ApplicationEnvironment)
+	clusters := make(map[uuid.UUID]bool)
+	completeness := 0
+	for _, ns := range namespaces {
+		completeness += ns.CompletenessScore
+		clusters[ns.ClusterID] = true
+		if ns.InfrastructureOwnerTeamID == nil {
+			dashboard.WithoutOwner++
+		}
+		if ns.Status == models.NamespaceStatusArchived {
+			dashboard.Archived++
+		} else {
+			if ns.CPUUsageMillicores != nil {
+				dashboard.CPUUsageMillicores += *ns.CPUUsageMillicores
+			}
+			if ns.MemoryUsageBytes != nil {
+				dashboard.MemoryUsageBytes += *ns.MemoryUsageBytes
+			}
+		}
+
+		env, ok := environments[ns.Environment]
+		if !ok {
+			env = &ApplicationEnvironment{Environment: ns.Environment, Clusters: []string{}}
+			environments[ns.Environment] = env
+		}
+		env.Namespaces++
+		if ns.Cluster != nil && !containsString(env.Clusters, ns.Cluster.Name) {
+			env.Clusters = append(env.Clusters, ns.Cluster.Name)
+		}
+	}
+
+	for _, env := range environments {
+		sort.Strings(env.Clusters)
+		dashboard.Environments = append(dashboard.Environments, *env)
+	}
+	sort.Slice(dashboard.Environments, func(i, j int) bool {
+		return dashboard.Environments[i].Environment < dashboard.Environments[j].Environment
+	})
+	dashboard.Clusters = len(clusters)
+	if len(namespaces) > 0 {
+		dashboard.AverageCompleteness = completeness / len(namespaces)
+	}
+	return dashboard
+}
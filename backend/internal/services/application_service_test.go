@@ -0,0 +1,86 @@
+package services
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/kubeatlas/kubeatlas/internal/models"
+)
+
+func TestApplicationRequestValidate(t *testing.T) {
+	req := ApplicationRequest{Name: "  Payments API "}
+	if err := req.validate(); err != nil || req.Name != "Payments API" || req.Slug != "payments-api" {
+		t.Errorf("validate() = %v, request %+v", err, req)
+	}
+
+	for _, slug := range []string{"Payments", "payments_api", "-payments"} {
+		req := ApplicationRequest{Name: "Payments", Slug: slug}
+		if err := req.validate(); !errors.Is(err, ErrInvalidApplication) {
+			t.Errorf("validate() with slug %q = %v, want ErrInvalidApplication", slug, err)
+		}
+	}
+}
+
+func TestRollUpDependencies(t *testing.T) {
+	prod, staging, ledger, checkout := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+	members := map[uuid.UUID]bool{prod: true, staging: true}
+	internal := []models.InternalDependency{
+		{SourceNamespaceID: prod, TargetNamespaceID: ledger, IsCritical: true},
+		{SourceNamespaceID: checkout, TargetNamespaceID: prod},
+		{SourceNamespaceID: staging, TargetNamespaceID: prod},
+	}
+	namespace := func(name string) *models.Namespace { return &models.Namespace{Name: name} }
+	external := []models.ExternalDependency{
+		{Name: "Stripe", Endpoint: models.NewNullStringFromString("https://api.stripe.com"), Namespace: namespace("payments-prod"), IsCritical: true},
+		{Name: "stripe", Endpoint: models.NewNullStringFromString("https://api.stripe.com"), Namespace: namespace("payments-staging")},
+		{Name: "Stripe", Endpoint: models.NewNullStringFromString("https://sandbox.stripe.com"), Namespace: namespace("payments-staging")},
+	}
+
+	deps := rollUpDependencies(members, internal, external)
+	if len(deps.Upstream) != 1 || deps.Upstream[0].TargetNamespaceID != ledger {
+		t.Errorf("Upstream = %+v, want the dependency on ledger", deps.Upstream)
+	}
+	if len(deps.Downstream) != 1 || deps.Downstream[0].SourceNamespaceID != checkout {
+		t.Errorf("Downstream = %+v, want the dependency of checkout", deps.Downstream)
+	}
+	if len(deps.Within) != 1 {
+		t.Errorf("Within = %+v, want the dependency of staging on prod", deps.Within)
+	}
+	if len(deps.External) != 2 {
+		t.Fatalf("External = %+v, want 2 systems", deps.External)
+	}
+	if e := deps.External[0]; !e.IsCritical || !reflect.DeepEqual(e.Namespaces, []string{"payments-prod", "payments-staging"}) {
+		t.Errorf("External[0] = %+v, want critical and used by both namespaces", e)
+	}
+}
+
+func TestSummarizeApplication(t *testing.T) {
+	eu, us := uuid.New(), uuid.New()
+	team := uuid.New()
+	int64p := func(v int64) *int64 { return &v }
+	namespaces := []models.Namespace{
+		{ClusterID: eu, Cluster: &models.Cluster{Name: "eu-prod"}, Environment: "production", Status: "active",
+			InfrastructureOwnerTeamID: &team, CompletenessScore: 100, CPUUsageMillicores: int64p(500), MemoryUsageBytes: int64p(1 << 30)},
+		{ClusterID: us, Cluster: &models.Cluster{Name: "us-prod"}, Environment: "production", Status: "active",
+			InfrastructureOwnerTeamID: &team, CompletenessScore: 70, CPUUsageMillicores: int64p(250)},
+		{ClusterID: eu, Cluster: &models.Cluster{Name: "eu-prod"}, Environment: "staging", Status: models.NamespaceStatusArchived,
+			CompletenessScore: 30, CPUUsageMillicores: int64p(1000)},
+	}
+
+	d := summarizeApplication(namespaces)
+	if d.Clusters != 2 || d.Archived != 1 || d.WithoutOwner != 1 || d.AverageCompleteness != 66 {
+		t.Errorf("summarizeApplication() = %+v", d)
+	}
+	if d.CPUUsageMillicores != 750 || d.MemoryUsageBytes != 1<<30 {
+		t.Errorf("usage = %d millicores, %d bytes, want 750 and 1GiB of the unarchived namespaces", d.CPUUsageMillicores, d.MemoryUsageBytes)
+	}
+	want := []ApplicationEnvironment{
+		{Environment: "production", Namespaces: 2, Clusters: []string{"eu-prod", "us-prod"}},
+		{Environment: "staging", Namespaces: 1, Clusters: []string{"eu-prod"}},
+	}
+	if !reflect.DeepEqual(d.Environments, want) {
+		t.Errorf("Environments = %+v, want %+v", d.Environments, want)
+	}
+}
@@ -0,0 +1,248 @@
+package services
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kubeatlas/kubeatlas/internal/models"
+	"github.com/kubeatlas/kubeatlas/internal/objectstore"
+)
+
+var (
+	ErrAuditArchiveDisabled = errors.New("audit log archiving is not enabled")
+	ErrAuditArchiveNotFound = errors.New("no audit log archives in range")
+	ErrInvalidArchiveRange  = errors.New("invalid archive range")
+)
+
+const (
+	// auditRestoreDays is how long logs restored from an archive are kept
+	auditRestoreDays = 30
+	// auditRestoreBatchSize is the number of logs inserted at once on restore
+	auditRestoreBatchSize = 500
+	// auditArchiveMonthLayout is the layout of archive months in keys and
+	// requests
+	auditArchiveMonthLayout = "2006-01"
+)
+
+// SetArchiveStore archives expired audit log partitions to store before they
+// are dropped, and enables restoring them
+func (s *AuditService) SetArchiveStore(store objectstore.Store) {
+	s.archive = store
+}
+
+// auditArchiveKey is the object key of an organization's archived month
+func auditArchiveKey(orgID uuid.UUID, month time.Time) string {
+	return fmt.Sprintf("audit-logs/%s/%s.ndjson.gz", orgID, month.Format(auditArchiveMonthLayout))
+}
+
+// archivePartition archives the logs of every organization in a partition
+func (s *AuditService) archivePartition(ctx context.Context, partition string, month time.Time) error {
+	orgIDs, err := s.repo.PartitionOrganizations(ctx, partition)
+	if err != nil {
+		return fmt.Errorf("failed to archive %s: %w", partition, err)
+	}
+	for _, orgID := range orgIDs {
+		if err := s.archiveMonth(ctx, partition, orgID, month); err != nil {
+			return fmt.Errorf("failed to archive %s for organization %s: %w", partition, orgID, err)
+		}
+	}
+	return nil
+}
+
+// archiveMonth writes an organization's logs of a partition to the archive
+// store as gzipped NDJSON and records the archive. The archive is staged in
+// a temporary file so months of any size are uploaded with a known length.
+func (s *AuditService) archiveMonth(ctx context.Context, partition string, orgID uuid.UUID, month time.Time) error {
+	tmp, err := os.CreateTemp("", "audit-archive-*.ndjson.gz")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	gz := gzip.NewWriter(tmp)
+	enc := json.NewEncoder(gz)
+	var count int64
+	err = s.repo.EachInPartition(ctx, partition, orgID, func(l *models.AuditLog) error {
+		count++
+		return enc.Encode(l)
+	})
+	if err != nil {
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		return err
+	}
+
+	size, err := tmp.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	key := auditArchiveKey(orgID, month)
+	if err := s.archive.Put(ctx, key, tmp, size, "application/gzip"); err != nil {
+		return err
+	}
+
+	archive := &models.AuditLogArchive{
+		OrganizationID: orgID,
+		Month:          month,
+		ObjectKey:      key,
+		EntryCount:     count,
+		SizeBytes:      size,
+	}
+	if err := s.repo.CreateArchive(ctx, archive); err != nil {
+		return err
+	}
+	s.logger.Infow("Archived audit logs", "organization_id", orgID, "month", month.Format(auditArchiveMonthLayout), "entries", count, "key", key)
+	return nil
+}
+
+// parseArchiveMonth parses a YYYY-MM month; an empty value is the zero time
+func parseArchiveMonth(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	month, err := time.Parse(auditArchiveMonthLayout, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: %q is not a YYYY-MM month", ErrInvalidArchiveRange, value)
+	}
+	return month, nil
+}
+
+// ListArchives returns the organization's archived months from from to to,
+// given as YYYY-MM and either of which may be empty
+func (s *AuditService) ListArchives(ctx context.Context, orgID uuid.UUID, from, to string) ([]models.AuditLogArchive, error) {
+	fromMonth, err := parseArchiveMonth(from)
+	if err != nil {
+		return nil, err
+	}
+	toMonth, err := parseArchiveMonth(to)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.ListArchives(ctx, orgID, fromMonth, toMonth)
+}
+
+// RestoreAuditArchivesRequest selects the archived months to restore
+type RestoreAuditArchivesRequest struct {
+	From string `json:"from" binding:"required"` // YYYY-MM
+	To   string `json:"to" binding:"required"`   // YYYY-MM, inclusive
+}
+
+// AuditRestoreResult summarizes a restore
+type AuditRestoreResult struct {
+	Archives       int       `json:"archives"`
+	Restored       int64     `json:"restored"`
+	RestoredMonths []string  `json:"restored_months"`
+	ExpiresAt      time.Time `json:"expires_at"`
+}
+
+// RestoreArchives loads the organization's archived months in range back
+// into the audit log for investigation. Restored logs are marked in their
+// metadata, show up in lists and exports like any other log, and are
+// removed again after auditRestoreDays. Restoring a month twice does not
+// duplicate its logs.
+func (s *AuditService) RestoreArchives(ctx context.Context, ac AuditContext, req RestoreAuditArchivesRequest) (*AuditRestoreResult, error) {
+	if s.archive == nil {
+		return nil, ErrAuditArchiveDisabled
+	}
+	from, err := parseArchiveMonth(req.From)
+	if err != nil {
+		return nil, err
+	}
+	to, err := parseArchiveMonth(req.To)
+	if err != nil {
+		return nil, err
+	}
+	if from.IsZero() || to.IsZero() || to.Before(from) {
+		return nil, fmt.Errorf("%w: from must be a month on or before to", ErrInvalidArchiveRange)
+	}
+
+	archives, err := s.repo.ListArchives(ctx, ac.OrgID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	if len(archives) == 0 {
+		return nil, ErrAuditArchiveNotFound
+	}
+
+	now := time.Now().UTC()
+	result := &AuditRestoreResult{
+		ExpiresAt:      now.AddDate(0, 0, auditRestoreDays),
+		RestoredMonths: make([]string, 0, len(archives)),
+	}
+	for _, archive := range archives {
+		n, err := s.restoreArchive(ctx, ac.OrgID, archive, now)
+		if err != nil {
+			return nil, fmt.Errorf("failed to restore %s: %w", archive.ObjectKey, err)
+		}
+		result.Archives++
+		result.Restored += n
+		result.RestoredMonths = append(result.RestoredMonths, archive.Month.Format(auditArchiveMonthLayout))
+	}
+
+	s.LogAction(ctx, ac, "restore", "audit_log", ac.OrgID, "audit_logs",
+		fmt.Sprintf("Restored %d audit log entries archived from %s to %s", result.Restored, req.From, req.To))
+	return result, nil
+}
+
+// restoreArchive inserts the logs of one archive in batches
+func (s *AuditService) restoreArchive(ctx context.Context, orgID uuid.UUID, archive models.AuditLogArchive, restoredAt time.Time) (int64, error) {
+	rc, err := s.archive.Get(ctx, archive.ObjectKey)
+	if err != nil {
+		return 0, err
+	}
+	defer rc.Close()
+
+	gz, err := gzip.NewReader(rc)
+	if err != nil {
+		return 0, err
+	}
+	defer gz.Close()
+
+	var restored int64
+	batch := make([]models.AuditLog, 0, auditRestoreBatchSize)
+	flush := func() error {
+		n, err := s.repo.Restore(ctx, batch, restoredAt)
+		restored += n
+		batch = batch[:0]
+		return err
+	}
+
+	dec := json.NewDecoder(gz)
+	for {
+		var l models.AuditLog
+		if err := dec.Decode(&l); err == io.EOF {
+			break
+		} else if err != nil {
+			return restored, fmt.Errorf("corrupt archive: %w", err)
+		}
+		// Archives are per organization; anything else is not restored
+		if l.OrganizationID != orgID {
+			return restored, fmt.Errorf("corrupt archive: entry %s belongs to another organization", l.ID)
+		}
+		batch = append(batch, l)
+		if len(batch) == auditRestoreBatchSize {
+			if err := flush(); err != nil {
+				return restored, err
+			}
+		}
+	}
+	if len(batch) > 0 {
+		if err := flush(); err != nil {
+			return restored, err
+		}
+	}
+	return restored, nil
+}
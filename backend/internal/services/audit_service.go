@@ -2,24 +2,52 @@ package services
 
 import (
 	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
 	"reflect"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/kubeatlas/kubeatlas/internal/database/repositories"
 	"github.com/kubeatlas/kubeatlas/internal/models"
+	"github.com/kubeatlas/kubeatlas/internal/objectstore"
+	"github.com/kubeatlas/kubeatlas/internal/siem"
+	"github.com/kubeatlas/kubeatlas/internal/telemetry"
 	"go.uber.org/zap"
 )
 
 type AuditService struct {
-	repo   *repositories.AuditRepository
-	logger *zap.SugaredLogger
+	repo      *repositories.AuditRepository
+	forwarder *siem.Forwarder
+	archive   objectstore.Store
+	locking   objectstore.LockingStore
+	settings  *OrgSettingsService
+	watchers  auditWatchers
+	logger    *zap.SugaredLogger
 }
 
 func NewAuditService(repo *repositories.AuditRepository, logger *zap.SugaredLogger) *AuditService {
 	return &AuditService{repo: repo, logger: logger}
 }
 
+// SetSettings reads the organizations' access audit and audit storage
+// settings from settings. Without settings, reads are never audited and
+// audit logs are only stored in Postgres.
+func (s *AuditService) SetSettings(settings *OrgSettingsService) {
+	s.settings = settings
+}
+
+// SetForwarder forwards every audit log entry to a SIEM as it is recorded
+func (s *AuditService) SetForwarder(f *siem.Forwarder) {
+	s.forwarder = f
+}
+
 // AuditContext holds context for audit logging
 type AuditContext struct {
 	UserID    *uuid.UUID
@@ -84,6 +112,71 @@ func (s *AuditService) LogAction(ctx context.Context, ac AuditContext, action, r
 	s.log(ctx, ac, action, resourceType, resourceID, resourceName, nil, nil, nil, description)
 }
 
+// Actions audited for read access
+const (
+	AuditActionView   = "view"
+	AuditActionExport = "export"
+)
+
+// AccessAuditSettings control auditing of read access, stored in
+// organizations.settings["access_audit"]. Reads far outnumber changes, so
+// they are only audited when enabled and may be sampled.
+type AccessAuditSettings struct {
+	Enabled bool `json:"enabled"`
+	// ViewSampleRate is the fraction of views audited, from 0 to 1
+	ViewSampleRate float64 `json:"view_sample_rate"`
+	// ExportSampleRate is the fraction of exports and downloads audited
+	ExportSampleRate float64 `json:"export_sample_rate"`
+}
+
+func (a *AccessAuditSettings) validate() error {
+	if a.ViewSampleRate < 0 || a.ViewSampleRate > 1 {
+		return errors.New("view_sample_rate must be between 0 and 1")
+	}
+	if a.ExportSampleRate < 0 || a.ExportSampleRate > 1 {
+		return errors.New("export_sample_rate must be between 0 and 1")
+	}
+	return nil
+}
+
+// sampled reports whether an access with action is audited, given r drawn
+// uniformly from [0, 1)
+func (a AccessAuditSettings) sampled(action string, r float64) bool {
+	if !a.Enabled {
+		return false
+	}
+	rate := a.ViewSampleRate
+	if action == AuditActionExport {
+		rate = a.ExportSampleRate
+	}
+	return r < rate
+}
+
+// AccessAuditSetting leaves read access unaudited by default; once enabled,
+// every read is audited until sample rates are lowered
+var AccessAuditSetting = SettingKey[AccessAuditSettings]{
+	Name:     "access_audit",
+	Default:  AccessAuditSettings{ViewSampleRate: 1, ExportSampleRate: 1},
+	Validate: (*AccessAuditSettings).validate,
+}
+
+// LogAccess logs a read of a resource as a view or export action, when the
+// organization audits read access and the access is sampled
+func (s *AuditService) LogAccess(ctx context.Context, ac AuditContext, action, resourceType string, resourceID uuid.UUID, resourceName, description string) {
+	if s.settings == nil {
+		return
+	}
+	cfg, err := GetSetting(ctx, s.settings, ac.OrgID, AccessAuditSetting)
+	if err != nil {
+		s.logger.Warnw("Failed to read access audit settings", "organization_id", ac.OrgID, "error", err)
+		return
+	}
+	if !cfg.sampled(action, rand.Float64()) {
+		return
+	}
+	s.log(ctx, ac, action, resourceType, resourceID, resourceName, nil, nil, nil, description)
+}
+
 func (s *AuditService) log(ctx context.Context, ac AuditContext, action, resourceType string, resourceID uuid.UUID, resourceName string, oldValues, newValues map[string]interface{}, changedFields []string, description string) {
 	log := &models.AuditLog{
 		OrganizationID: ac.OrgID,
@@ -114,7 +207,40 @@ func (s *AuditService) log(ctx context.Context, ac AuditContext, action, resourc
 
 	if err := s.repo.Create(ctx, log); err != nil {
 		s.logger.Errorw("Failed to create audit log", "error", err, "action", action, "resource", resourceType)
+		telemetry.CaptureError(ctx, err)
+	} else {
+		s.watchers.publish(log)
+	}
+	s.appendOnly(ctx, log)
+
+	// Forwarded even when storing failed, so the SIEM still sees the event
+	if s.forwarder != nil {
+		s.forwarder.Enqueue(siemEvent(log))
+	}
+}
+
+// siemEvent converts an audit log entry for SIEM forwarding
+func siemEvent(l *models.AuditLog) siem.Event {
+	e := siem.Event{
+		ID:             l.ID.String(),
+		OrganizationID: l.OrganizationID.String(),
+		Time:           l.CreatedAt,
+		UserEmail:      l.UserEmail.String,
+		UserIP:         l.UserIP.String,
+		UserAgent:      l.UserAgent.String,
+		Action:         l.Action,
+		ResourceType:   l.ResourceType,
+		ResourceID:     l.ResourceID.String(),
+		ResourceName:   l.ResourceName.String,
+		ChangedFields:  l.ChangedFields,
+		OldValues:      l.OldValues,
+		NewValues:      l.NewValues,
+		Description:    l.Description.String,
+	}
+	if l.UserID != nil {
+		e.UserID = l.UserID.String()
 	}
+	return e
 }
 
 func (s *AuditService) getChangedFields(oldValues, newValues map[string]interface{}) []string {
@@ -133,16 +259,330 @@ func (s *AuditService) List(ctx context.Context, orgID uuid.UUID, p repositories
 	return s.repo.List(ctx, orgID, p, filters)
 }
 
+// ErrUnsupportedExportFormat is returned for an unknown audit export format
+var ErrUnsupportedExportFormat = errors.New("unsupported export format")
+
+// Audit log export formats
+const (
+	AuditExportCSV    = "csv"
+	AuditExportNDJSON = "ndjson"
+)
+
+// AuditExportContentType returns the content type of an audit export format
+func AuditExportContentType(format string) (string, error) {
+	switch format {
+	case AuditExportCSV:
+		return "text/csv", nil
+	case AuditExportNDJSON:
+		return "application/x-ndjson", nil
+	default:
+		return "", fmt.Errorf("%w: %q", ErrUnsupportedExportFormat, format)
+	}
+}
+
+// auditExportHeader is the header row of CSV audit exports
+var auditExportHeader = []string{
+	"id", "created_at", "user_id", "user_email", "user_name", "user_ip",
+	"action", "resource_type", "resource_id", "resource_name",
+	"changed_fields", "old_values", "new_values", "description",
+}
+
+// auditLogEncoder writes audit logs in an export format
+type auditLogEncoder interface {
+	Encode(l *models.AuditLog) error
+	Flush() error
+}
+
+func newAuditLogEncoder(w io.Writer, format string) (auditLogEncoder, error) {
+	switch format {
+	case AuditExportCSV:
+		enc := &csvAuditLogEncoder{w: csv.NewWriter(w)}
+		if err := enc.w.Write(auditExportHeader); err != nil {
+			return nil, err
+		}
+		return enc, nil
+	case AuditExportNDJSON:
+		return ndjsonAuditLogEncoder{json.NewEncoder(w)}, nil
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedExportFormat, format)
+	}
+}
+
+type csvAuditLogEncoder struct {
+	w *csv.Writer
+}
+
+func (e *csvAuditLogEncoder) Encode(l *models.AuditLog) error {
+	var userID, userName string
+	if l.UserID != nil {
+		userID = l.UserID.String()
+	}
+	if l.User != nil {
+		userName = l.User.FullName.String
+	}
+	oldValues, err := marshalAuditValues(l.OldValues)
+	if err != nil {
+		return err
+	}
+	newValues, err := marshalAuditValues(l.NewValues)
+	if err != nil {
+		return err
+	}
+
+	return e.w.Write([]string{
+		l.ID.String(),
+		l.CreatedAt.UTC().Format(time.RFC3339Nano),
+		userID,
+		l.UserEmail.String,
+		userName,
+		l.UserIP.String,
+		l.Action,
+		l.ResourceType,
+		l.ResourceID.String(),
+		l.ResourceName.String,
+		strings.Join(l.ChangedFields, ";"),
+		oldValues,
+		newValues,
+		l.Description.String,
+	})
+}
+
+func (e *csvAuditLogEncoder) Flush() error {
+	e.w.Flush()
+	return e.w.Error()
+}
+
+// marshalAuditValues returns values as JSON, or "" when there are none
+func marshalAuditValues(values models.JSONMap) (string, error) {
+	if len(values) == 0 {
+		return "", nil
+	}
+	data, err := json.Marshal(values)
+	return string(data), err
+}
+
+type ndjsonAuditLogEncoder struct {
+	enc *json.Encoder
+}
+
+func (e ndjsonAuditLogEncoder) Encode(l *models.AuditLog) error {
+	return e.enc.Encode(l)
+}
+
+func (e ndjsonAuditLogEncoder) Flush() error {
+	return nil
+}
+
+// Export writes the organization's audit logs matching filters to w in
+// format, oldest first. Logs are streamed from the database rather than
+// loaded at once, so exports of any size use constant memory. The export
+// itself is audited.
+func (s *AuditService) Export(ctx context.Context, ac AuditContext, filters map[string]interface{}, format string, w io.Writer) error {
+	enc, err := newAuditLogEncoder(w, format)
+	if err != nil {
+		return err
+	}
+
+	count := 0
+	err = s.repo.Each(ctx, ac.OrgID, filters, func(l *models.AuditLog) error {
+		count++
+		return enc.Encode(l)
+	})
+	if err == nil {
+		err = enc.Flush()
+	}
+	if err != nil {
+		return fmt.Errorf("failed to export audit logs: %w", err)
+	}
+
+	s.LogAction(ctx, ac, "export", "audit_log", ac.OrgID, "audit_logs", fmt.Sprintf("Exported %d audit log entries as %s", count, format))
+	return nil
+}
+
 // ListByResource retrieves audit logs for a specific resource
 func (s *AuditService) ListByResource(ctx context.Context, resourceType string, resourceID uuid.UUID, limit int) ([]models.AuditLog, error) {
 	return s.repo.ListByResource(ctx, resourceType, resourceID, limit)
 }
 
+// ErrAuditLogNotFound is returned for audit logs missing from the organization
+var ErrAuditLogNotFound = errors.New("audit log not found")
+
+// Kinds of field changes in an audit log diff
+const (
+	FieldAdded   = "added"
+	FieldRemoved = "removed"
+	FieldChanged = "changed"
+)
+
+// FieldChange is the before and after value of one field of an audit log.
+// Nested objects, such as JSONB columns, are diffed per field and named by
+// their dotted path (labels.team); arrays are compared as a whole.
+type FieldChange struct {
+	Field  string      `json:"field"`
+	Change string      `json:"change"`
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+// AuditLogDiff lists the fields an audit log changed
+type AuditLogDiff struct {
+	AuditLogID   uuid.UUID     `json:"audit_log_id"`
+	Action       string        `json:"action"`
+	ResourceType string        `json:"resource_type"`
+	ResourceID   uuid.UUID     `json:"resource_id"`
+	ResourceName string        `json:"resource_name,omitempty"`
+	CreatedAt    time.Time     `json:"created_at"`
+	Changes      []FieldChange `json:"changes"`
+}
+
+// Diff returns the field-level changes recorded by an audit log
+func (s *AuditService) Diff(ctx context.Context, orgID, id uuid.UUID) (*AuditLogDiff, error) {
+	l, err := s.repo.GetByID(ctx, orgID, id)
+	if err != nil {
+		return nil, err
+	}
+	if l == nil {
+		return nil, ErrAuditLogNotFound
+	}
+
+	return &AuditLogDiff{
+		AuditLogID:   l.ID,
+		Action:       l.Action,
+		ResourceType: l.ResourceType,
+		ResourceID:   l.ResourceID,
+		ResourceName: l.ResourceName.String,
+		CreatedAt:    l.CreatedAt,
+		Changes:      diffAuditValues(l.OldValues, l.NewValues),
+	}, nil
+}
+
+// diffAuditValues compares old and new values field by field, sorted by field
+func diffAuditValues(oldValues, newValues map[string]interface{}) []FieldChange {
+	before := make(map[string]interface{})
+	after := make(map[string]interface{})
+	flattenAuditValues("", oldValues, before)
+	flattenAuditValues("", newValues, after)
+
+	fields := make([]string, 0, len(before)+len(after))
+	for field := range before {
+		fields = append(fields, field)
+	}
+	for field := range after {
+		if _, ok := before[field]; !ok {
+			fields = append(fields, field)
+		}
+	}
+	sort.Strings(fields)
+
+	changes := make([]FieldChange, 0, len(fields))
+	for _, field := range fields {
+		b, inBefore := before[field]
+		a, inAfter := after[field]
+		switch {
+		case !inBefore:
+			changes = append(changes, FieldChange{Field: field, Change: FieldAdded, After: a})
+		case !inAfter:
+			changes = append(changes, FieldChange{Field: field, Change: FieldRemoved, Before: b})
+		case !reflect.DeepEqual(b, a):
+			changes = append(changes, FieldChange{Field: field, Change: FieldChanged, Before: b, After: a})
+		}
+	}
+	return changes
+}
+
+// flattenAuditValues adds the leaf values of values to out keyed by their
+// dotted path. Empty objects have no leaves, so they match missing ones.
+func flattenAuditValues(prefix string, values map[string]interface{}, out map[string]interface{}) {
+	for key, value := range values {
+		path := key
+		if prefix != "" {
+			path = prefix + "." + key
+		}
+		switch v := value.(type) {
+		case map[string]interface{}:
+			flattenAuditValues(path, v, out)
+		case models.JSONMap:
+			flattenAuditValues(path, v, out)
+		default:
+			out[path] = value
+		}
+	}
+}
+
+// AuthAnomalies reports the IP addresses and accounts with at least
+// threshold authentication failures within window
+func (s *AuditService) AuthAnomalies(ctx context.Context, orgID uuid.UUID, window time.Duration, threshold int) ([]models.AuthAnomaly, error) {
+	since := time.Now().Add(-window)
+	byIP, err := s.repo.AuthAnomalies(ctx, orgID, models.AuthAnomalyIP, since, threshold)
+	if err != nil {
+		return nil, err
+	}
+	byAccount, err := s.repo.AuthAnomalies(ctx, orgID, models.AuthAnomalyAccount, since, threshold)
+	if err != nil {
+		return nil, err
+	}
+	return append(byIP, byAccount...), nil
+}
+
 // GetRecentActivities retrieves recent activities for dashboard
 func (s *AuditService) GetRecentActivities(ctx context.Context, orgID uuid.UUID, limit int) ([]models.AuditLog, error) {
 	return s.repo.GetRecentActivities(ctx, orgID, limit)
 }
 
+// auditPartitionsAhead is the number of future monthly partitions kept ready
+const auditPartitionsAhead = 3
+
+// MaintainPartitions creates upcoming audit_logs partitions and drops the ones
+// older than the retention period. A retention of 0 keeps all partitions.
+// With an archive store, each partition is archived first and kept when
+// archiving fails. Logs restored from archives are removed again after
+// auditRestoreDays.
+func (s *AuditService) MaintainPartitions(ctx context.Context, retentionMonths int) error {
+	now := time.Now().UTC()
+	if err := s.repo.EnsurePartitions(ctx, now, auditPartitionsAhead); err != nil {
+		return err
+	}
+
+	if n, err := s.repo.DeleteRestoredBefore(ctx, now.AddDate(0, 0, -auditRestoreDays)); err != nil {
+		return fmt.Errorf("failed to delete restored audit logs: %w", err)
+	} else if n > 0 {
+		s.logger.Infow("Deleted restored audit logs", "count", n)
+	}
+
+	if retentionMonths <= 0 {
+		return nil
+	}
+
+	cutoff := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC).AddDate(0, -retentionMonths, 0)
+	partitions, err := s.repo.ListPartitions(ctx)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	dropped := make([]string, 0)
+	for name, month := range partitions {
+		if month.AddDate(0, 1, 0).After(cutoff) {
+			continue
+		}
+		if s.archive != nil {
+			if err := s.archivePartition(ctx, name, month); err != nil {
+				errs = append(errs, err)
+				continue
+			}
+		}
+		if err := s.repo.DropPartition(ctx, name); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		dropped = append(dropped, name)
+	}
+	if len(dropped) > 0 {
+		s.logger.Infow("Dropped expired audit log partitions", "partitions", dropped, "cutoff", cutoff, "archived", s.archive != nil)
+	}
+	return errors.Join(errs...)
+}
+
 // StructToMap converts a struct to map for audit logging
 func StructToMap(obj interface{}) map[string]interface{} {
 	result := make(map[string]interface{})
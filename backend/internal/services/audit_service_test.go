@@ -0,0 +1,182 @@
+package services
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kubeatlas/kubeatlas/internal/models"
+)
+
+func exportTestLog() *models.AuditLog {
+	userID := uuid.MustParse("7d6f3f2e-4a1b-4c2d-9e8f-0a1b2c3d4e5f")
+	l := &models.AuditLog{
+		ID:            uuid.MustParse("0f1e2d3c-4b5a-4978-8695-a4b3c2d1e0f9"),
+		UserID:        &userID,
+		Action:        "update",
+		ResourceType:  "namespace",
+		ResourceID:    uuid.MustParse("11111111-2222-4333-8444-555555555555"),
+		OldValues:     models.JSONMap{"owner": "platform"},
+		NewValues:     models.JSONMap{"owner": "payments, core"},
+		ChangedFields: models.StringArray{"owner", "criticality"},
+		CreatedAt:     time.Date(2024, 5, 1, 9, 30, 0, 0, time.UTC),
+	}
+	l.UserEmail.String, l.UserEmail.Valid = "jane@example.com", true
+	l.ResourceName.String, l.ResourceName.Valid = "payments-prod", true
+	l.User = &models.User{}
+	l.User.FullName.String, l.User.FullName.Valid = "Jane Doe", true
+	return l
+}
+
+func TestAuditLogEncoderCSV(t *testing.T) {
+	var buf bytes.Buffer
+	enc, err := newAuditLogEncoder(&buf, AuditExportCSV)
+	if err != nil {
+		t.Fatalf("newAuditLogEncoder failed: %v", err)
+	}
+	if err := enc.Encode(exportTestLog()); err != nil {
+		t.Fatalf("Encode failed: %v", err)
+	}
+	if err := enc.Flush(); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("export is not valid CSV: %v", err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("got %d records, want header and 1 row", len(records))
+	}
+
+	row := make(map[string]string)
+	for i, column := range records[0] {
+		row[column] = records[1][i]
+	}
+	want := map[string]string{
+		"created_at":     "2024-05-01T09:30:00Z",
+		"user_email":     "jane@example.com",
+		"user_name":      "Jane Doe",
+		"resource_name":  "payments-prod",
+		"changed_fields": "owner;criticality",
+		"new_values":     `{"owner":"payments, core"}`,
+		"description":    "",
+	}
+	for column, value := range want {
+		if row[column] != value {
+			t.Errorf("%s = %q, want %q", column, row[column], value)
+		}
+	}
+}
+
+func TestAuditLogEncoderNDJSON(t *testing.T) {
+	var buf bytes.Buffer
+	enc, err := newAuditLogEncoder(&buf, AuditExportNDJSON)
+	if err != nil {
+		t.Fatalf("newAuditLogEncoder failed: %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		if err := enc.Encode(exportTestLog()); err != nil {
+			t.Fatalf("Encode failed: %v", err)
+		}
+	}
+
+	lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines, want 2", len(lines))
+	}
+	var got map[string]interface{}
+	if err := json.Unmarshal(lines[1], &got); err != nil {
+		t.Fatalf("line is not valid JSON: %v", err)
+	}
+	if got["resource_type"] != "namespace" {
+		t.Errorf("resource_type = %v", got["resource_type"])
+	}
+}
+
+func TestAuditExportFormats(t *testing.T) {
+	if ct, err := AuditExportContentType(AuditExportNDJSON); err != nil || ct != "application/x-ndjson" {
+		t.Errorf("AuditExportContentType(ndjson) = %q, %v", ct, err)
+	}
+	if _, err := AuditExportContentType("xlsx"); !errors.Is(err, ErrUnsupportedExportFormat) {
+		t.Errorf("AuditExportContentType(xlsx) error = %v, want ErrUnsupportedExportFormat", err)
+	}
+	if _, err := newAuditLogEncoder(&bytes.Buffer{}, "xlsx"); !errors.Is(err, ErrUnsupportedExportFormat) {
+		t.Errorf("newAuditLogEncoder(xlsx) error = %v, want ErrUnsupportedExportFormat", err)
+	}
+}
+
+func TestDiffAuditValues(t *testing.T) {
+	// Values as read back from JSONB
+	var oldValues, newValues map[string]interface{}
+	json.Unmarshal([]byte(`{
+		"name": "payments",
+		"owner": "core",
+		"replicas": 2,
+		"labels": {"team": "core", "tier": "1"},
+		"settings": {},
+		"tags": ["a", "b"],
+		"retired": true
+	}`), &oldValues)
+	json.Unmarshal([]byte(`{
+		"name": "payments",
+		"owner": "platform",
+		"replicas": 2,
+		"labels": {"team": "platform", "tier": "1", "cost_center": "42"},
+		"tags": ["a", "c"],
+		"description": null
+	}`), &newValues)
+
+	want := []FieldChange{
+		{Field: "description", Change: FieldAdded},
+		{Field: "labels.cost_center", Change: FieldAdded, After: "42"},
+		{Field: "labels.team", Change: FieldChanged, Before: "core", After: "platform"},
+		{Field: "owner", Change: FieldChanged, Before: "core", After: "platform"},
+		{Field: "retired", Change: FieldRemoved, Before: true},
+		{Field: "tags", Change: FieldChanged, Before: []interface{}{"a", "b"}, After: []interface{}{"a", "c"}},
+	}
+	if got := diffAuditValues(oldValues, newValues); !reflect.DeepEqual(got, want) {
+		t.Errorf("diffAuditValues() =\n%+v\nwant\n%+v", got, want)
+	}
+
+	// A create has no old values, so every field is added
+	created := diffAuditValues(nil, models.JSONMap{"spec": models.JSONMap{"a": 1}})
+	if len(created) != 1 || created[0].Field != "spec.a" || created[0].Change != FieldAdded {
+		t.Errorf("diffAuditValues(nil, ...) = %+v", created)
+	}
+}
+
+func TestAccessAuditSampling(t *testing.T) {
+	cfg := AccessAuditSetting.Default
+	if cfg.sampled(AuditActionView, 0) {
+		t.Errorf("reads are audited by default")
+	}
+
+	cfg.Enabled = true
+	cfg.ViewSampleRate = 0.25
+	tests := []struct {
+		action string
+		r      float64
+		want   bool
+	}{
+		{AuditActionView, 0.1, true},
+		{AuditActionView, 0.25, false},
+		{AuditActionView, 0.9, false},
+		{AuditActionExport, 0.999, true},
+	}
+	for _, tt := range tests {
+		if got := cfg.sampled(tt.action, tt.r); got != tt.want {
+			t.Errorf("sampled(%s, %v) = %v, want %v", tt.action, tt.r, got, tt.want)
+		}
+	}
+
+	cfg.ExportSampleRate = 1.5
+	if err := cfg.validate(); err == nil {
+		t.Errorf("validate accepted export_sample_rate 1.5")
+	}
+}
@@ -0,0 +1,98 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/kubeatlas/kubeatlas/internal/models"
+	"github.com/kubeatlas/kubeatlas/internal/objectstore"
+	"github.com/kubeatlas/kubeatlas/internal/telemetry"
+)
+
+// ErrObjectLockUnavailable is returned when an organization selects object
+// lock storage but no locking store is configured
+var ErrObjectLockUnavailable = errors.New("object lock storage is not configured")
+
+// Append-only audit storage, kept in addition to audit_logs
+const (
+	AuditStorageNone       = ""
+	AuditStorageLedger     = "ledger"      // the append-only audit_log_ledger table
+	AuditStorageObjectLock = "object_lock" // objects locked in object storage
+)
+
+// AuditStorageSettings select append-only storage for the organization's
+// audit log, stored in organizations.settings["audit_storage"]. Postgres
+// keeps the audit log that is listed, exported and pruned by retention; the
+// append-only copy is for compliance regimes that forbid changing it.
+type AuditStorageSettings struct {
+	AppendOnly string `json:"append_only"`
+	// LockDays is how long objects stay locked with object_lock storage
+	LockDays int `json:"lock_days"`
+}
+
+func (a *AuditStorageSettings) validate() error {
+	switch a.AppendOnly {
+	case AuditStorageNone, AuditStorageLedger, AuditStorageObjectLock:
+	default:
+		return errors.New("append_only must be empty, ledger or object_lock")
+	}
+	if a.LockDays < 1 || a.LockDays > 36500 {
+		return errors.New("lock_days must be between 1 and 36500")
+	}
+	return nil
+}
+
+// AuditStorageSetting keeps the audit log in Postgres only by default, and
+// locks objects for seven years once object lock storage is selected
+var AuditStorageSetting = SettingKey[AuditStorageSettings]{
+	Name:     "audit_storage",
+	Default:  AuditStorageSettings{LockDays: 7 * 365},
+	Validate: (*AuditStorageSettings).validate,
+}
+
+// SetLockingStore writes the audit logs of organizations that select object
+// lock storage to store
+func (s *AuditService) SetLockingStore(store objectstore.LockingStore) {
+	s.locking = store
+}
+
+// auditLockedKey is the object key of an audit log in object lock storage
+func auditLockedKey(l *models.AuditLog) string {
+	return fmt.Sprintf("audit-ledger/%s/%s/%s.json", l.OrganizationID, l.CreatedAt.UTC().Format("2006/01/02"), l.ID)
+}
+
+// appendOnly copies an audit log to the organization's append-only storage,
+// if it selected one. Failures are logged, like failures to store the log.
+func (s *AuditService) appendOnly(ctx context.Context, l *models.AuditLog) {
+	if s.settings == nil {
+		return
+	}
+	cfg, err := GetSetting(ctx, s.settings, l.OrganizationID, AuditStorageSetting)
+	if err != nil {
+		s.logger.Errorw("Failed to read audit storage settings", "organization_id", l.OrganizationID, "error", err)
+		return
+	}
+	if cfg.AppendOnly == AuditStorageNone {
+		return
+	}
+
+	entry, err := json.Marshal(l)
+	if err == nil {
+		switch cfg.AppendOnly {
+		case AuditStorageLedger:
+			err = s.repo.AppendLedger(ctx, l, entry)
+		case AuditStorageObjectLock:
+			if s.locking == nil {
+				err = ErrObjectLockUnavailable
+			} else {
+				err = s.locking.PutLocked(ctx, auditLockedKey(l), entry, "application/json", l.CreatedAt.AddDate(0, 0, cfg.LockDays))
+			}
+		}
+	}
+	if err != nil {
+		s.logger.Errorw("Failed to write audit log to append-only storage", "error", err, "storage", cfg.AppendOnly, "audit_log_id", l.ID)
+		telemetry.CaptureError(ctx, err)
+	}
+}
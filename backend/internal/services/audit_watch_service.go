@@ -0,0 +1,101 @@
+package services
+
+import (
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/kubeatlas/kubeatlas/internal/models"
+)
+
+// auditWatchBuffer is the number of audit logs held for a slow watcher
+// before further logs are dropped for it
+const auditWatchBuffer = 16
+
+// auditWatchKey identifies the audit logs of one resource
+type auditWatchKey struct {
+	orgID        uuid.UUID
+	resourceType string
+	resourceID   uuid.UUID
+}
+
+// auditWatchers hands audit logs recorded by this instance to the watchers
+// of their resource
+type auditWatchers struct {
+	mu       sync.Mutex
+	closed   bool
+	watchers map[auditWatchKey]map[chan models.AuditLog]struct{}
+}
+
+func (w *auditWatchers) add(key auditWatchKey) chan models.AuditLog {
+	ch := make(chan models.AuditLog, auditWatchBuffer)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		close(ch)
+		return ch
+	}
+	if w.watchers == nil {
+		w.watchers = make(map[auditWatchKey]map[chan models.AuditLog]struct{})
+	}
+	if w.watchers[key] == nil {
+		w.watchers[key] = make(map[chan models.AuditLog]struct{})
+	}
+	w.watchers[key][ch] = struct{}{}
+	return ch
+}
+
+func (w *auditWatchers) remove(key auditWatchKey, ch chan models.AuditLog) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if _, ok := w.watchers[key][ch]; !ok {
+		return
+	}
+	delete(w.watchers[key], ch)
+	if len(w.watchers[key]) == 0 {
+		delete(w.watchers, key)
+	}
+	close(ch)
+}
+
+// publish sends l to the watchers of its resource without waiting for them
+func (w *auditWatchers) publish(l *models.AuditLog) {
+	key := auditWatchKey{orgID: l.OrganizationID, resourceType: l.ResourceType, resourceID: l.ResourceID}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for ch := range w.watchers[key] {
+		select {
+		case ch <- *l:
+		default:
+		}
+	}
+}
+
+func (w *auditWatchers) close() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.closed = true
+	for _, chans := range w.watchers {
+		for ch := range chans {
+			close(ch)
+		}
+	}
+	w.watchers = nil
+}
+
+// Watch returns a channel receiving the resource's audit logs as they are
+// recorded, and a function that stops watching. Only logs recorded by this
+// instance are received, and a watcher that falls behind misses logs, so
+// watchers should reload the history when they (re)connect. The channel is
+// closed when watching stops.
+func (s *AuditService) Watch(orgID uuid.UUID, resourceType string, resourceID uuid.UUID) (<-chan models.AuditLog, func()) {
+	key := auditWatchKey{orgID: orgID, resourceType: resourceType, resourceID: resourceID}
+	ch := s.watchers.add(key)
+	return ch, func() { s.watchers.remove(key, ch) }
+}
+
+// CloseWatchers stops all watches, so streams end when the server shuts down
+func (s *AuditService) CloseWatchers() {
+	s.watchers.close()
+}
@@ -0,0 +1,51 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/kubeatlas/kubeatlas/internal/models"
+)
+
+func TestAuditWatchers(t *testing.T) {
+	svc := &AuditService{}
+	orgID, nsID := uuid.New(), uuid.New()
+
+	logs, stop := svc.Watch(orgID, "namespace", nsID)
+	other, stopOther := svc.Watch(orgID, "namespace", uuid.New())
+	defer stopOther()
+
+	svc.watchers.publish(&models.AuditLog{OrganizationID: orgID, ResourceType: "namespace", ResourceID: nsID, Action: "update"})
+	// Same ID in another organization
+	svc.watchers.publish(&models.AuditLog{OrganizationID: uuid.New(), ResourceType: "namespace", ResourceID: nsID})
+
+	if l := <-logs; l.Action != "update" {
+		t.Errorf("watcher received %+v", l)
+	}
+	select {
+	case l := <-logs:
+		t.Errorf("watcher received another organization's log %+v", l)
+	case l := <-other:
+		t.Errorf("watcher of another resource received %+v", l)
+	default:
+	}
+
+	// A watcher that falls behind misses logs instead of blocking
+	for i := 0; i < auditWatchBuffer+5; i++ {
+		svc.watchers.publish(&models.AuditLog{OrganizationID: orgID, ResourceType: "namespace", ResourceID: nsID})
+	}
+	if len(logs) != auditWatchBuffer {
+		t.Errorf("watcher holds %d logs, want %d", len(logs), auditWatchBuffer)
+	}
+
+	stop()
+	stop()
+	svc.CloseWatchers()
+	if _, ok := <-other; ok {
+		t.Errorf("CloseWatchers left a watch open")
+	}
+	late, _ := svc.Watch(orgID, "namespace", nsID)
+	if _, ok := <-late; ok {
+		t.Errorf("Watch after CloseWatchers returned an open channel")
+	}
+}
@@ -3,12 +3,14 @@ package services
 import (
 	"context"
 	"errors"
+	"sync"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
 	"github.com/kubeatlas/kubeatlas/internal/database/repositories"
 	"github.com/kubeatlas/kubeatlas/internal/models"
+	"github.com/kubeatlas/kubeatlas/internal/telemetry"
 	"go.uber.org/zap"
 )
 
@@ -19,21 +21,31 @@ var (
 	ErrInvalidToken       = errors.New("invalid or expired token")
 )
 
+// lockoutAuditInterval is how often a lockout of the same IP address is
+// audited while it keeps retrying
+const lockoutAuditInterval = 15 * time.Minute
+
 type AuthService struct {
 	userRepo        *repositories.UserRepository
 	ldapService     *LDAPService
+	auditSvc        *AuditService
 	logger          *zap.SugaredLogger
 	jwtSecret       string
 	expirationHours int
+
+	lockoutMu sync.Mutex
+	lockouts  map[string]time.Time // IP address -> last audited lockout
 }
 
-func NewAuthService(userRepo *repositories.UserRepository, ldapService *LDAPService, logger *zap.SugaredLogger, jwtSecret string, expirationHours int) *AuthService {
+func NewAuthService(userRepo *repositories.UserRepository, ldapService *LDAPService, auditSvc *AuditService, logger *zap.SugaredLogger, jwtSecret string, expirationHours int) *AuthService {
 	return &AuthService{
 		userRepo:        userRepo,
 		ldapService:     ldapService,
+		auditSvc:        auditSvc,
 		logger:          logger,
 		jwtSecret:       jwtSecret,
 		expirationHours: expirationHours,
+		lockouts:        make(map[string]time.Time),
 	}
 }
 
@@ -57,7 +69,10 @@ type LoginRequest struct {
 	Password string `json:"password" binding:"required"`
 }
 
-func (s *AuthService) Login(ctx context.Context, orgID uuid.UUID, req LoginRequest) (*TokenPair, *models.User, error) {
+// Login authenticates a user of ac.OrgID. Failed attempts are audited with
+// the client details of ac.
+func (s *AuthService) Login(ctx context.Context, ac AuditContext, req LoginRequest) (*TokenPair, *models.User, error) {
+	orgID := ac.OrgID
 	var user *models.User
 	var err error
 
@@ -89,6 +104,7 @@ func (s *AuthService) Login(ctx context.Context, orgID uuid.UUID, req LoginReque
 				user, err = s.ldapService.SyncLDAPUser(ctx, orgID, ldapResult)
 				if err != nil {
 					s.logger.Errorw("Failed to sync LDAP user", "error", err, "email", ldapResult.Email)
+					telemetry.CaptureError(ctx, err)
 					return nil, nil, err
 				}
 
@@ -115,12 +131,15 @@ func (s *AuthService) Login(ctx context.Context, orgID uuid.UUID, req LoginReque
 		return nil, nil, err
 	}
 	if user == nil {
+		s.logAuthFailure(ctx, ac, models.AuthActionLoginFailed, nil, req.Email, "Login failed: unknown account")
 		return nil, nil, ErrInvalidCredentials
 	}
 	if !user.IsActive {
+		s.logAuthFailure(ctx, ac, models.AuthActionLoginFailed, user, req.Email, "Login failed: account is inactive")
 		return nil, nil, ErrUserInactive
 	}
 	if !s.userRepo.VerifyPassword(user, req.Password) {
+		s.logAuthFailure(ctx, ac, models.AuthActionLoginFailed, user, req.Email, "Login failed: wrong password")
 		return nil, nil, ErrInvalidCredentials
 	}
 
@@ -214,13 +233,19 @@ func (s *AuthService) ValidateToken(tokenString, jwtSecret string) (*Claims, err
 	return claims, nil
 }
 
-func (s *AuthService) RefreshToken(ctx context.Context, refreshTokenString string) (*TokenPair, error) {
+// RefreshToken issues new tokens for a refresh token. Failures are audited
+// with the client details of ac, in the token's organization once the token
+// is known to be genuine.
+func (s *AuthService) RefreshToken(ctx context.Context, ac AuditContext, refreshTokenString string) (*TokenPair, error) {
 	claims, err := s.ValidateToken(refreshTokenString, s.jwtSecret)
 	if err != nil {
+		s.logAuthFailure(ctx, ac, models.AuthActionRefreshFailed, nil, "", "Token refresh failed: invalid or expired token")
 		return nil, err
 	}
+	ac.OrgID = claims.OrganizationID
 
 	if claims.Issuer != "kubeatlas-refresh" {
+		s.logAuthFailure(ctx, ac, models.AuthActionRefreshFailed, nil, claims.Email, "Token refresh failed: not a refresh token")
 		return nil, ErrInvalidToken
 	}
 
@@ -229,19 +254,65 @@ func (s *AuthService) RefreshToken(ctx context.Context, refreshTokenString strin
 		return nil, err
 	}
 	if user == nil {
+		s.logAuthFailure(ctx, ac, models.AuthActionRefreshFailed, nil, claims.Email, "Token refresh failed: unknown account")
 		return nil, ErrUserNotFound
 	}
 	if !user.IsActive {
+		s.logAuthFailure(ctx, ac, models.AuthActionRefreshFailed, user, claims.Email, "Token refresh failed: account is inactive")
 		return nil, ErrUserInactive
 	}
 
 	return s.GenerateTokens(user, s.jwtSecret, s.expirationHours)
 }
 
+// logAuthFailure audits a failed authentication attempt for email. user is
+// the account it concerns, nil when none matched.
+func (s *AuthService) logAuthFailure(ctx context.Context, ac AuditContext, action string, user *models.User, email, description string) {
+	if s.auditSvc == nil {
+		return
+	}
+	var resourceID uuid.UUID
+	if user != nil {
+		resourceID = user.ID
+		ac.UserID = &user.ID
+	}
+	ac.UserEmail = email
+	s.auditSvc.LogAction(ctx, ac, action, models.AuthAuditResource, resourceID, email, description)
+}
+
+// RecordLockout audits a login rejected because its IP address made too
+// many attempts. An IP address that keeps retrying is audited once per
+// lockoutAuditInterval.
+func (s *AuthService) RecordLockout(ctx context.Context, ac AuditContext) {
+	now := time.Now()
+
+	s.lockoutMu.Lock()
+	if last, ok := s.lockouts[ac.UserIP]; ok && now.Sub(last) < lockoutAuditInterval {
+		s.lockoutMu.Unlock()
+		return
+	}
+	for ip, last := range s.lockouts {
+		if now.Sub(last) >= lockoutAuditInterval {
+			delete(s.lockouts, ip)
+		}
+	}
+	s.lockouts[ac.UserIP] = now
+	s.lockoutMu.Unlock()
+
+	s.logAuthFailure(ctx, ac, models.AuthActionLockout, nil, "", "Login locked out: too many attempts from "+ac.UserIP)
+}
+
 func (s *AuthService) GetUserFromToken(ctx context.Context, claims *Claims) (*models.User, error) {
 	return s.userRepo.GetByID(ctx, claims.UserID)
 }
 
+// SessionActive reports whether a token issued to the user may still be
+// used: the user is active and the organization has not been deleted.
+// Deactivating a user or deleting an organization revokes its tokens.
+func (s *AuthService) SessionActive(ctx context.Context, userID, orgID uuid.UUID) (bool, error) {
+	return s.userRepo.IsActiveMember(ctx, userID, orgID)
+}
+
 // Logout logs out a user (for audit purposes, token invalidation would require a blacklist)
 func (s *AuthService) Logout(ctx context.Context, userID, orgID uuid.UUID, userIP, userAgent string) {
 	s.logger.Infow("User logged out",
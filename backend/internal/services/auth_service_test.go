@@ -85,3 +85,30 @@ func TestAuditService_LogCreate(t *testing.T) {
 	_ = context.Background()
 	_ = AuditContext{OrgID: uuid.New(), UserEmail: "test@example.com"}
 }
+
+func TestAuthService_RecordLockoutOncePerInterval(t *testing.T) {
+	svc := &AuthService{lockouts: make(map[string]time.Time)}
+	ctx := context.Background()
+	ac := AuditContext{UserIP: "10.0.0.1"}
+
+	svc.RecordLockout(ctx, ac)
+	first, ok := svc.lockouts["10.0.0.1"]
+	if !ok {
+		t.Fatal("lockout was not recorded")
+	}
+	svc.RecordLockout(ctx, ac)
+	if !svc.lockouts["10.0.0.1"].Equal(first) {
+		t.Error("repeated lockout within the interval was audited again")
+	}
+
+	// Once the interval passed it is audited again, and stale IPs are pruned
+	svc.lockouts["10.0.0.1"] = first.Add(-lockoutAuditInterval)
+	svc.lockouts["10.0.0.2"] = first.Add(-lockoutAuditInterval)
+	svc.RecordLockout(ctx, ac)
+	if !svc.lockouts["10.0.0.1"].After(first.Add(-lockoutAuditInterval)) {
+		t.Error("lockout after the interval was not audited")
+	}
+	if _, ok := svc.lockouts["10.0.0.2"]; ok {
+		t.Error("stale lockout was not pruned")
+	}
+}
@@ -0,0 +1,621 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/kubeatlas/kubeatlas/internal/backstage"
+	"github.com/kubeatlas/kubeatlas/internal/database/repositories"
+	"github.com/kubeatlas/kubeatlas/internal/models"
+	"go.uber.org/zap"
+)
+
+// MaxBackstageImport is the most catalog entities one import may contain
+const MaxBackstageImport = 5000
+
+var (
+	ErrInvalidBackstageImport = errors.New("invalid backstage import")
+	ErrBackstageConflict      = errors.New("backstage import conflicts with existing data")
+)
+
+// How an import treats catalog entities that disagree with existing data
+const (
+	ConflictSkip      = "skip"      // keep the existing data
+	ConflictOverwrite = "overwrite" // replace it with the catalog's
+	ConflictFail      = "fail"      // import nothing
+)
+
+// Outcomes of importing one catalog entity
+const (
+	BackstageCreated   = "created"
+	BackstageUpdated   = "updated"
+	BackstageUnchanged = "unchanged"
+	BackstageSkipped   = "skipped"
+	BackstageConflict  = "conflict"
+	BackstageFailed    = "failed"
+)
+
+// dependencyTypes are the dependency types a resource's spec.type may map to.
+// Other dependencies are recorded as api dependencies.
+var dependencyTypes = map[string]bool{"database": true, "queue": true, "cache": true, "storage": true}
+
+// BackstageImportOptions controls an import. With DryRun the changes are
+// reported but not made.
+type BackstageImportOptions struct {
+	DryRun   bool
+	Conflict string
+}
+
+// BackstageImportItem is the outcome of importing one catalog entity, or one
+// dependsOn relation of it
+type BackstageImportItem struct {
+	Entity   string        `json:"entity"`
+	Resource string        `json:"resource"`
+	Name     string        `json:"name"`
+	Status   string        `json:"status"`
+	Changes  []FieldChange `json:"changes,omitempty"`
+	Detail   string        `json:"detail,omitempty"`
+}
+
+// BackstageImport is the outcome of an import, teams first, then business
+// units, namespaces and dependencies
+type BackstageImport struct {
+	DryRun    bool                  `json:"dry_run"`
+	Conflict  string                `json:"conflict"`
+	Created   int                   `json:"created"`
+	Updated   int                   `json:"updated"`
+	Unchanged int                   `json:"unchanged"`
+	Skipped   int                   `json:"skipped"`
+	Conflicts int                   `json:"conflicts"`
+	Failed    int                   `json:"failed"`
+	Items     []BackstageImportItem `json:"items"`
+}
+
+// BackstageImportService imports a Backstage software catalog. Groups become
+// teams, systems become business units, and components and resources are
+// matched to the namespaces named by their backstage.io/kubernetes-namespace
+// annotation, whose owner, business unit and dependencies they set.
+type BackstageImportService struct {
+	teamRepo         *repositories.TeamRepository
+	businessUnitRepo *repositories.BusinessUnitRepository
+	clusterRepo      *repositories.ClusterRepository
+	namespaceRepo    *repositories.NamespaceRepository
+	dependencyRepo   *repositories.InternalDependencyRepository
+	teamSvc          *TeamService
+	businessUnitSvc  *BusinessUnitService
+	namespaceSvc     *NamespaceService
+	dependencySvc    *DependencyService
+	logger           *zap.SugaredLogger
+}
+
+func NewBackstageImportService(repos *Repositories, teamSvc *TeamService, businessUnitSvc *BusinessUnitService, namespaceSvc *NamespaceService, dependencySvc *DependencyService, logger *zap.SugaredLogger) *BackstageImportService {
+	return &BackstageImportService{
+		teamRepo:         repos.Team,
+		businessUnitRepo: repos.BusinessUnit,
+		clusterRepo:      repos.Cluster,
+		namespaceRepo:    repos.Namespace,
+		dependencyRepo:   repos.InternalDependency,
+		teamSvc:          teamSvc,
+		businessUnitSvc:  businessUnitSvc,
+		namespaceSvc:     namespaceSvc,
+		dependencySvc:    dependencySvc,
+		logger:           logger,
+	}
+}
+
+// Import imports the catalog entities read from r, YAML documents such as
+// catalog-info.yaml files or JSON. Entities of other kinds are ignored.
+// Entities that fail do not stop the others, except with the fail conflict
+// option, where any conflict stops the whole import before anything is
+// written and ErrBackstageConflict is returned along with the result.
+func (s *BackstageImportService) Import(ctx context.Context, ac AuditContext, r io.Reader, opts BackstageImportOptions) (*BackstageImport, error) {
+	if opts.Conflict == "" {
+		opts.Conflict = ConflictSkip
+	}
+	if opts.Conflict != ConflictSkip && opts.Conflict != ConflictOverwrite && opts.Conflict != ConflictFail {
+		return nil, fmt.Errorf("%w: conflict must be skip, overwrite or fail", ErrInvalidBackstageImport)
+	}
+	entities, err := backstage.Parse(r)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidBackstageImport, err)
+	}
+	if len(entities) == 0 {
+		return nil, fmt.Errorf("%w: no entities given", ErrInvalidBackstageImport)
+	}
+	if len(entities) > MaxBackstageImport {
+		return nil, fmt.Errorf("%w: at most %d entities can be imported at once", ErrInvalidBackstageImport, MaxBackstageImport)
+	}
+
+	im, err := s.newImporter(ctx, ac, opts)
+	if err != nil {
+		return nil, err
+	}
+	for _, kind := range []string{backstage.KindGroup, backstage.KindSystem, backstage.KindComponent, backstage.KindResource} {
+		for i := range entities {
+			e := &entities[i]
+			if e.Ref().Kind != kind {
+				continue
+			}
+			switch kind {
+			case backstage.KindGroup:
+				im.planTeam(e)
+			case backstage.KindSystem:
+				im.planBusinessUnit(e)
+			default:
+				if err := im.planNamespace(ctx, e); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+	for _, w := range im.ordered {
+		if err := im.planDependencies(ctx, w); err != nil {
+			return nil, err
+		}
+	}
+
+	result := &BackstageImport{DryRun: opts.DryRun, Conflict: opts.Conflict, Items: make([]BackstageImportItem, 0, len(im.changes))}
+	conflicted := false
+	for _, c := range im.changes {
+		if c.item.Status == BackstageConflict {
+			conflicted = true
+		}
+	}
+	for _, c := range im.changes {
+		if c.apply != nil && !opts.DryRun && !conflicted {
+			if err := c.apply(ctx); err != nil {
+				s.logger.Warnw("Failed to import backstage entity", "entity", c.item.Entity, "resource", c.item.Resource, "error", err)
+				c.item.Status = BackstageFailed
+				c.item.Detail = err.Error()
+			}
+		}
+		switch c.item.Status {
+		case BackstageCreated:
+			result.Created++
+		case BackstageUpdated:
+			result.Updated++
+		case BackstageUnchanged:
+			result.Unchanged++
+		case BackstageSkipped:
+			result.Skipped++
+		case BackstageConflict:
+			result.Conflicts++
+		default:
+			result.Failed++
+		}
+		result.Items = append(result.Items, c.item)
+	}
+
+	s.logger.Infow("Backstage catalog imported", "organization_id", ac.OrgID, "dry_run", opts.DryRun, "conflict", opts.Conflict,
+		"created", result.Created, "updated", result.Updated, "unchanged", result.Unchanged,
+		"skipped", result.Skipped, "conflicts", result.Conflicts, "failed", result.Failed)
+	if conflicted {
+		return result, ErrBackstageConflict
+	}
+	return result, nil
+}
+
+// backstageChange is a planned change and the function that makes it, nil
+// when there is nothing to do
+type backstageChange struct {
+	item  BackstageImportItem
+	apply func(ctx context.Context) error
+}
+
+// workload is a component or resource matched to a namespace
+type workload struct {
+	entity    *backstage.Entity
+	namespace *models.Namespace
+}
+
+// backstageImporter plans an import. Teams and business units the import
+// creates are known by slug and code before they exist, with a nil ID until
+// they are created.
+type backstageImporter struct {
+	s         *BackstageImportService
+	ac        AuditContext
+	conflict  string
+	teams     map[string]*models.Team         // by lowercase slug
+	units     map[string]*models.BusinessUnit // by lowercase code, then name
+	clusters  map[string]*models.Cluster
+	mapped    map[uuid.UUID]string // entities already mapped to a namespace
+	workloads map[string]*workload // by entity ref
+	ordered   []*workload
+	changes   []*backstageChange
+}
+
+func (s *BackstageImportService) newImporter(ctx context.Context, ac AuditContext, opts BackstageImportOptions) (*backstageImporter, error) {
+	teams, err := s.teamRepo.List(ctx, ac.OrgID)
+	if err != nil {
+		return nil, err
+	}
+	units, err := s.businessUnitRepo.List(ctx, ac.OrgID)
+	if err != nil {
+		return nil, err
+	}
+	im := &backstageImporter{
+		s:         s,
+		ac:        ac,
+		conflict:  opts.Conflict,
+		teams:     make(map[string]*models.Team),
+		units:     make(map[string]*models.BusinessUnit),
+		clusters:  make(map[string]*models.Cluster),
+		mapped:    make(map[uuid.UUID]string),
+		workloads: make(map[string]*workload),
+	}
+	for i := range teams {
+		im.teams[strings.ToLower(teams[i].Slug)] = &teams[i]
+	}
+	// Codes win over names that happen to match them
+	for i := range units {
+		im.units[strings.ToLower(units[i].Name)] = &units[i]
+	}
+	for i := range units {
+		if units[i].Code.Valid && units[i].Code.String != "" {
+			im.units[strings.ToLower(units[i].Code.String)] = &units[i]
+		}
+	}
+	return im, nil
+}
+
+// fieldDiff collects the differences between existing and catalog values
+type fieldDiff struct {
+	before, after map[string]interface{}
+	conflict      bool
+}
+
+func newFieldDiff() *fieldDiff {
+	return &fieldDiff{before: make(map[string]interface{}), after: make(map[string]interface{})}
+}
+
+// set compares one field. Filling in an empty field is a change, replacing a
+// value is a conflict.
+func (d *fieldDiff) set(field, current, desired string) {
+	if desired == "" || current == desired {
+		return
+	}
+	if current != "" {
+		d.before[field] = current
+		d.conflict = true
+	}
+	d.after[field] = desired
+}
+
+// add records a change to something that exists, applying it unless it
+// conflicts and the import keeps existing data
+func (im *backstageImporter) add(item BackstageImportItem, diff *fieldDiff, apply func(ctx context.Context) error) {
+	item.Changes = diffAuditValues(diff.before, diff.after)
+	switch {
+	case len(item.Changes) == 0:
+		item.Status = BackstageUnchanged
+		apply = nil
+	case !diff.conflict || im.conflict == ConflictOverwrite:
+		item.Status = BackstageUpdated
+	case im.conflict == ConflictFail:
+		item.Status = BackstageConflict
+		item.Detail = "existing data differs from the catalog"
+		apply = nil
+	default:
+		item.Status = BackstageSkipped
+		item.Detail = "existing data differs from the catalog"
+		apply = nil
+	}
+	im.changes = append(im.changes, &backstageChange{item: item, apply: apply})
+}
+
+func (im *backstageImporter) fail(item BackstageImportItem, status, detail string) {
+	item.Status, item.Detail = status, detail
+	im.changes = append(im.changes, &backstageChange{item: item})
+}
+
+// planTeam maps a group to the team whose slug is the group's name
+func (im *backstageImporter) planTeam(e *backstage.Entity) {
+	slug := strings.ToLower(e.Metadata.Name)
+	item := BackstageImportItem{Entity: e.Ref().String(), Resource: "team", Name: e.DisplayName()}
+	req := CreateTeamRequest{
+		Name:         e.DisplayName(),
+		Slug:         slug,
+		Description:  e.Metadata.Description,
+		ContactEmail: e.Spec.Profile.Email,
+	}
+
+	team, ok := im.teams[slug]
+	if !ok {
+		team = &models.Team{Name: req.Name, Slug: slug}
+		im.teams[slug] = team
+		item.Status = BackstageCreated
+		im.changes = append(im.changes, &backstageChange{item: item, apply: func(ctx context.Context) error {
+			created, err := im.s.teamSvc.Create(ctx, im.ac, req)
+			if err != nil {
+				return err
+			}
+			*team = *created
+			return nil
+		}})
+		return
+	}
+	if team.ID == uuid.Nil {
+		im.fail(item, BackstageSkipped, "duplicate of another group in the catalog")
+		return
+	}
+
+	diff := newFieldDiff()
+	diff.set("name", team.Name, req.Name)
+	diff.set("description", team.Description.ValueOrEmpty(), req.Description)
+	diff.set("contact_email", team.ContactEmail.ValueOrEmpty(), req.ContactEmail)
+	id := team.ID
+	im.add(item, diff, func(ctx context.Context) error {
+		_, err := im.s.teamSvc.Update(ctx, im.ac, id, req)
+		return err
+	})
+}
+
+// planBusinessUnit maps a system to the business unit whose code, or else
+// name, is the system's name
+func (im *backstageImporter) planBusinessUnit(e *backstage.Entity) {
+	code := strings.ToLower(e.Metadata.Name)
+	item := BackstageImportItem{Entity: e.Ref().String(), Resource: "business_unit", Name: e.DisplayName()}
+	req := CreateBusinessUnitRequest{
+		Name:        e.DisplayName(),
+		Code:        e.Metadata.Name,
+		Description: e.Metadata.Description,
+	}
+
+	unit, ok := im.units[code]
+	if !ok {
+		unit, ok = im.units[strings.ToLower(req.Name)]
+	}
+	if !ok {
+		unit = &models.BusinessUnit{Name: req.Name, Code: models.NewNullStringFromString(req.Code)}
+		im.units[code] = unit
+		item.Status = BackstageCreated
+		im.changes = append(im.changes, &backstageChange{item: item, apply: func(ctx context.Context) error {
+			created, err := im.s.businessUnitSvc.Create(ctx, im.ac, req)
+			if err != nil {
+				return err
+			}
+			*unit = *created
+			return nil
+		}})
+		return
+	}
+	if unit.ID == uuid.Nil {
+		im.fail(item, BackstageSkipped, "duplicate of another system in the catalog")
+		return
+	}
+
+	diff := newFieldDiff()
+	diff.set("name", unit.Name, req.Name)
+	diff.set("code", unit.Code.ValueOrEmpty(), req.Code)
+	diff.set("description", unit.Description.ValueOrEmpty(), req.Description)
+	id := unit.ID
+	im.add(item, diff, func(ctx context.Context) error {
+		_, err := im.s.businessUnitSvc.Update(ctx, im.ac, id, req)
+		return err
+	})
+}
+
+// planNamespace maps a component or resource to its namespace, setting the
+// owner team from spec.owner and the business unit from spec.system.
+// Namespaces are only matched, never created, as they come from cluster
+// syncs.
+func (im *backstageImporter) planNamespace(ctx context.Context, e *backstage.Entity) error {
+	ref := e.Ref().String()
+	name := e.Metadata.Annotations[backstage.AnnotationNamespace]
+	item := BackstageImportItem{Entity: ref, Resource: "namespace", Name: name}
+	if name == "" {
+		im.fail(item, BackstageSkipped, "no "+backstage.AnnotationNamespace+" annotation")
+		return nil
+	}
+
+	ns, detail, err := im.findNamespace(ctx, e.Metadata.Annotations[backstage.AnnotationCluster], name)
+	if err != nil {
+		return err
+	}
+	if ns == nil {
+		im.fail(item, BackstageFailed, detail)
+		return nil
+	}
+	w := &workload{entity: e, namespace: ns}
+	im.workloads[ref] = w
+	im.ordered = append(im.ordered, w)
+	if other, ok := im.mapped[ns.ID]; ok {
+		im.fail(item, BackstageSkipped, "namespace already set by "+other)
+		return nil
+	}
+	im.mapped[ns.ID] = ref
+
+	var owner *models.Team
+	if e.Spec.Owner != "" {
+		r, err := backstage.ParseRef(e.Spec.Owner, backstage.KindGroup)
+		if err != nil || r.Kind != backstage.KindGroup {
+			im.fail(item, BackstageFailed, fmt.Sprintf("owner %q is not a group", e.Spec.Owner))
+			return nil
+		}
+		if owner = im.teams[r.Name]; owner == nil {
+			im.fail(item, BackstageFailed, fmt.Sprintf("owner group %q not found", r.Name))
+			return nil
+		}
+	}
+	var unit *models.BusinessUnit
+	if e.Spec.System != "" {
+		r, err := backstage.ParseRef(e.Spec.System, backstage.KindSystem)
+		if err != nil || r.Kind != backstage.KindSystem {
+			im.fail(item, BackstageFailed, fmt.Sprintf("system %q is not a system", e.Spec.System))
+			return nil
+		}
+		if unit = im.units[r.Name]; unit == nil {
+			im.fail(item, BackstageFailed, fmt.Sprintf("system %q not found", r.Name))
+			return nil
+		}
+	}
+
+	diff := newFieldDiff()
+	if owner != nil && (ns.InfrastructureOwnerTeamID == nil || *ns.InfrastructureOwnerTeamID != owner.ID) {
+		diff.set("owner_team", im.teamName(ns.InfrastructureOwnerTeamID), owner.Name)
+	}
+	if unit != nil && (ns.BusinessUnitID == nil || *ns.BusinessUnitID != unit.ID) {
+		diff.set("business_unit", im.unitName(ns.BusinessUnitID), unit.Name)
+	}
+	diff.set("description", ns.Description.ValueOrEmpty(), e.Metadata.Description)
+	diff.set("display_name", ns.DisplayName.ValueOrEmpty(), e.Metadata.Title)
+
+	id := ns.ID
+	im.add(item, diff, func(ctx context.Context) error {
+		req := UpdateNamespaceRequest{DisplayName: e.Metadata.Title, Description: e.Metadata.Description}
+		// The team or unit may have been created by this import, so their
+		// IDs are read when the change is made
+		if owner != nil {
+			if owner.ID == uuid.Nil {
+				return fmt.Errorf("owner team %q was not created", owner.Slug)
+			}
+			req.InfrastructureOwnerTeamID = &owner.ID
+		}
+		if unit != nil {
+			if unit.ID == uuid.Nil {
+				return fmt.Errorf("business unit %q was not created", unit.Name)
+			}
+			req.BusinessUnitID = &unit.ID
+		}
+		_, err := im.s.namespaceSvc.Update(ctx, im.ac, id, req)
+		return err
+	})
+	return nil
+}
+
+// findNamespace finds a namespace in the named cluster or, without one, the
+// only namespace of the organization with the name. When there is none, the
+// reason is returned instead.
+func (im *backstageImporter) findNamespace(ctx context.Context, clusterName, name string) (*models.Namespace, string, error) {
+	if clusterName != "" {
+		cluster, ok := im.clusters[clusterName]
+		if !ok {
+			var err error
+			if cluster, err = im.s.clusterRepo.GetByName(ctx, im.ac.OrgID, clusterName); err != nil {
+				return nil, "", err
+			}
+			im.clusters[clusterName] = cluster
+		}
+		if cluster == nil {
+			return nil, fmt.Sprintf("cluster %q not found", clusterName), nil
+		}
+		ns, err := im.s.namespaceRepo.GetByClusterAndName(ctx, cluster.ID, name)
+		if err != nil || ns == nil {
+			return nil, fmt.Sprintf("namespace %q not found in cluster %q", name, clusterName), err
+		}
+		return ns, "", nil
+	}
+
+	ids, err := im.s.namespaceRepo.ListIDsByName(ctx, im.ac.OrgID, name)
+	if err != nil {
+		return nil, "", err
+	}
+	switch len(ids) {
+	case 0:
+		return nil, fmt.Sprintf("namespace %q not found", name), nil
+	case 1:
+		ns, err := im.s.namespaceRepo.GetByID(ctx, ids[0])
+		if err != nil || ns == nil {
+			return nil, fmt.Sprintf("namespace %q not found", name), err
+		}
+		return ns, "", nil
+	default:
+		return nil, fmt.Sprintf("namespace %q exists in %d clusters, set the %s annotation", name, len(ids), backstage.AnnotationCluster), nil
+	}
+}
+
+// planDependencies records the dependsOn relations of a component or
+// resource between namespaces as internal dependencies. Dependencies are
+// only ever added, so they cannot conflict.
+func (im *backstageImporter) planDependencies(ctx context.Context, w *workload) error {
+	if len(w.entity.Spec.DependsOn) == 0 {
+		return nil
+	}
+	existing, err := im.s.dependencyRepo.ListByNamespace(ctx, w.namespace.ID)
+	if err != nil {
+		return err
+	}
+	ref := w.entity.Ref().String()
+	for _, dependsOn := range w.entity.Spec.DependsOn {
+		item := BackstageImportItem{Entity: ref, Resource: "dependency", Name: dependsOn}
+		r, err := backstage.ParseRef(dependsOn, backstage.KindComponent)
+		if err != nil {
+			im.fail(item, BackstageFailed, err.Error())
+			continue
+		}
+		target, ok := im.workloads[r.String()]
+		if !ok {
+			im.fail(item, BackstageSkipped, r.String()+" is not in the catalog or has no namespace")
+			continue
+		}
+		if target.namespace.ID == w.namespace.ID {
+			im.fail(item, BackstageSkipped, "both entities are in the same namespace")
+			continue
+		}
+		item.Name = w.namespace.Name + " -> " + target.namespace.Name
+
+		if hasDependency(existing, w.namespace.ID, target.namespace.ID) {
+			item.Status = BackstageUnchanged
+			im.changes = append(im.changes, &backstageChange{item: item})
+			continue
+		}
+		depType := "api"
+		if r.Kind == backstage.KindResource && dependencyTypes[strings.ToLower(target.entity.Spec.Type)] {
+			depType = strings.ToLower(target.entity.Spec.Type)
+		}
+		req := CreateInternalDependencyRequest{
+			SourceNamespaceID: w.namespace.ID,
+			TargetNamespaceID: target.namespace.ID,
+			DependencyType:    depType,
+			Description:       ref + " depends on " + r.String(),
+			DiscoveryMethod:   "backstage",
+		}
+		// Record it so a repeated relation is not created twice
+		existing = append(existing, models.InternalDependency{SourceNamespaceID: req.SourceNamespaceID, TargetNamespaceID: req.TargetNamespaceID})
+		item.Status = BackstageCreated
+		im.changes = append(im.changes, &backstageChange{item: item, apply: func(ctx context.Context) error {
+			_, err := im.s.dependencySvc.CreateInternal(ctx, im.ac, req)
+			return err
+		}})
+	}
+	return nil
+}
+
+func hasDependency(deps []models.InternalDependency, source, target uuid.UUID) bool {
+	for _, d := range deps {
+		if d.SourceNamespaceID == source && d.TargetNamespaceID == target {
+			return true
+		}
+	}
+	return false
+}
+
+// teamName names a team for a change, falling back to its ID for teams of
+// other organizations or deleted ones
+func (im *backstageImporter) teamName(id *uuid.UUID) string {
+	if id == nil {
+		return ""
+	}
+	for _, team := range im.teams {
+		if team.ID == *id {
+			return team.Name
+		}
+	}
+	return id.String()
+}
+
+func (im *backstageImporter) unitName(id *uuid.UUID) string {
+	if id == nil {
+		return ""
+	}
+	for _, unit := range im.units {
+		if unit.ID == *id {
+			return unit.Name
+		}
+	}
+	return id.String()
+}
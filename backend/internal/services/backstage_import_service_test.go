@@ -0,0 +1,59 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/kubeatlas/kubeatlas/internal/backstage"
+	"github.com/kubeatlas/kubeatlas/internal/models"
+)
+
+func TestBackstageImportPlanTeam(t *testing.T) {
+	group := func(name, displayName, email string) *backstage.Entity {
+		return &backstage.Entity{
+			Kind:     "Group",
+			Metadata: backstage.Metadata{Name: name},
+			Spec:     backstage.Spec{Profile: backstage.Profile{DisplayName: displayName, Email: email}},
+		}
+	}
+	tests := []struct {
+		conflict string
+		entity   *backstage.Entity
+		status   string
+		apply    bool
+	}{
+		{ConflictSkip, group("payments", "Payments", ""), BackstageUnchanged, false},
+		{ConflictSkip, group("payments", "Payments", "payments@example.com"), BackstageUpdated, true},
+		{ConflictSkip, group("payments", "Payments Team", ""), BackstageSkipped, false},
+		{ConflictOverwrite, group("payments", "Payments Team", ""), BackstageUpdated, true},
+		{ConflictFail, group("payments", "Payments Team", ""), BackstageConflict, false},
+		{ConflictFail, group("checkout", "Checkout", ""), BackstageCreated, true},
+	}
+	for _, tt := range tests {
+		im := &backstageImporter{
+			conflict: tt.conflict,
+			teams:    map[string]*models.Team{"payments": {Name: "Payments", Slug: "payments"}},
+		}
+		im.teams["payments"].ID = uuid.New()
+
+		im.planTeam(tt.entity)
+		if len(im.changes) != 1 {
+			t.Fatalf("planned %d changes, want 1", len(im.changes))
+		}
+		c := im.changes[0]
+		if c.item.Status != tt.status || (c.apply != nil) != tt.apply {
+			t.Errorf("%s %s/%s: status = %s, apply = %v, want %s, %v", tt.conflict, tt.entity.Metadata.Name,
+				tt.entity.Spec.Profile.DisplayName, c.item.Status, c.apply != nil, tt.status, tt.apply)
+		}
+	}
+}
+
+func TestBackstageImportPlanTeamDuplicate(t *testing.T) {
+	im := &backstageImporter{conflict: ConflictSkip, teams: make(map[string]*models.Team)}
+	e := &backstage.Entity{Kind: "Group", Metadata: backstage.Metadata{Name: "Payments"}}
+	im.planTeam(e)
+	im.planTeam(e)
+	if len(im.changes) != 2 || im.changes[0].item.Status != BackstageCreated || im.changes[1].item.Status != BackstageSkipped {
+		t.Errorf("changes = %+v, %+v, want created then skipped", im.changes[0].item, im.changes[1].item)
+	}
+}
@@ -0,0 +1,98 @@
+package services
+
+import (
+	"github.com/google/uuid"
+	"github.com/kubeatlas/kubeatlas/internal/models"
+)
+
+// buildBusinessUnitTree arranges units, ordered by name, into a tree with
+// the statistics of each unit's namespaces, from counts, and of its subtree.
+// Units whose parent is missing, or which a stored cycle of parents would
+// make unreachable, are placed at the top level.
+func buildBusinessUnitTree(units []models.BusinessUnit, counts []models.BusinessUnitNamespaceCounts) []*models.BusinessUnitTreeNode {
+	nodes := make(map[uuid.UUID]*models.BusinessUnitTreeNode, len(units))
+	for i := range units {
+		nodes[units[i].ID] = &models.BusinessUnitTreeNode{
+			BusinessUnitResponse: units[i].ToResponse(),
+			Stats:                models.BusinessUnitStats{Criticality: map[string]int{}},
+			Children:             []*models.BusinessUnitTreeNode{},
+		}
+	}
+	for _, c := range counts {
+		if node, ok := nodes[c.BusinessUnitID]; ok {
+			addNamespaceCounts(&node.Stats, c)
+		}
+	}
+
+	roots := make([]*models.BusinessUnitTreeNode, 0)
+	for i := range units {
+		node := nodes[units[i].ID]
+		if parentID := units[i].ParentID; parentID != nil {
+			if parent, ok := nodes[*parentID]; ok && !businessUnitAbove(nodes, node, parent) {
+				parent.Children = append(parent.Children, node)
+				continue
+			}
+		}
+		roots = append(roots, node)
+	}
+
+	var rollUp func(node *models.BusinessUnitTreeNode)
+	rollUp = func(node *models.BusinessUnitTreeNode) {
+		node.NamespaceCount = node.Stats.NamespaceCount
+		node.Rollup = node.Stats
+		node.Rollup.Criticality = make(map[string]int, len(node.Stats.Criticality))
+		for tier, n := range node.Stats.Criticality {
+			node.Rollup.Criticality[tier] = n
+		}
+		for _, child := range node.Children {
+			rollUp(child)
+			node.Rollup.NamespaceCount += child.Rollup.NamespaceCount
+			node.Rollup.NamespacesWithOwner += child.Rollup.NamespacesWithOwner
+			node.Rollup.NamespacesDocumented += child.Rollup.NamespacesDocumented
+			for tier, n := range child.Rollup.Criticality {
+				node.Rollup.Criticality[tier] += n
+			}
+		}
+		setCoverage(&node.Stats)
+		setCoverage(&node.Rollup)
+	}
+	for _, root := range roots {
+		rollUp(root)
+	}
+	return roots
+}
+
+// addNamespaceCounts adds the namespaces of one criticality to stats
+func addNamespaceCounts(stats *models.BusinessUnitStats, c models.BusinessUnitNamespaceCounts) {
+	stats.NamespaceCount += c.Namespaces
+	stats.NamespacesWithOwner += c.WithOwner
+	stats.NamespacesDocumented += c.Documented
+	stats.Criticality[c.Criticality] += c.Namespaces
+}
+
+// setCoverage computes the coverage percentages of stats, 0 without
+// namespaces
+func setCoverage(stats *models.BusinessUnitStats) {
+	stats.OwnershipCoverage, stats.DocumentationCoverage = 0, 0
+	if stats.NamespaceCount == 0 {
+		return
+	}
+	total := float64(stats.NamespaceCount)
+	stats.OwnershipCoverage = float64(stats.NamespacesWithOwner) / total * 100
+	stats.DocumentationCoverage = float64(stats.NamespacesDocumented) / total * 100
+}
+
+// businessUnitAbove reports whether node is already an ancestor of other in
+// the partly built tree
+func businessUnitAbove(nodes map[uuid.UUID]*models.BusinessUnitTreeNode, node, other *models.BusinessUnitTreeNode) bool {
+	for steps := 0; other != nil && steps <= len(nodes); steps++ {
+		if other == node {
+			return true
+		}
+		if other.ParentID == nil {
+			return false
+		}
+		other = nodes[*other.ParentID]
+	}
+	return false
+}
@@ -0,0 +1,62 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/kubeatlas/kubeatlas/internal/models"
+)
+
+func TestBuildBusinessUnitTree(t *testing.T) {
+	retail := models.BusinessUnit{Name: "Retail"}
+	retail.ID = uuid.New()
+	payments := models.BusinessUnit{Name: "Payments", ParentID: &retail.ID}
+	payments.ID = uuid.New()
+	stores := models.BusinessUnit{Name: "Stores", ParentID: &retail.ID}
+	stores.ID = uuid.New()
+	cards := models.BusinessUnit{Name: "Cards", ParentID: &payments.ID}
+	cards.ID = uuid.New()
+
+	counts := []models.BusinessUnitNamespaceCounts{
+		{BusinessUnitID: retail.ID, Criticality: "tier-2", Namespaces: 2, WithOwner: 2, Documented: 1},
+		{BusinessUnitID: payments.ID, Criticality: "tier-1", Namespaces: 3, WithOwner: 3, Documented: 3},
+		{BusinessUnitID: payments.ID, Criticality: "tier-2", Namespaces: 1, WithOwner: 0, Documented: 0},
+		{BusinessUnitID: cards.ID, Criticality: "tier-1", Namespaces: 4, WithOwner: 2, Documented: 1},
+		{BusinessUnitID: uuid.New(), Criticality: "tier-1", Namespaces: 9},
+	}
+
+	roots := buildBusinessUnitTree([]models.BusinessUnit{cards, payments, retail, stores}, counts)
+	if len(roots) != 1 || roots[0].Name != "Retail" {
+		t.Fatalf("roots = %+v, want Retail", roots)
+	}
+	root := roots[0]
+	if root.Stats.NamespaceCount != 2 || root.NamespaceCount != 2 || root.Stats.OwnershipCoverage != 100 || root.Stats.DocumentationCoverage != 50 {
+		t.Errorf("Retail stats = %+v, want its own 2 namespaces", root.Stats)
+	}
+	rollup := root.Rollup
+	if rollup.NamespaceCount != 10 || rollup.NamespacesWithOwner != 7 || rollup.NamespacesDocumented != 5 {
+		t.Errorf("Retail rollup = %+v, want 10 namespaces, 7 owned, 5 documented", rollup)
+	}
+	if rollup.Criticality["tier-1"] != 7 || rollup.Criticality["tier-2"] != 3 {
+		t.Errorf("Retail criticality = %v, want 7 tier-1 and 3 tier-2", rollup.Criticality)
+	}
+	if rollup.OwnershipCoverage != 70 || rollup.DocumentationCoverage != 50 {
+		t.Errorf("Retail coverage = %v/%v, want 70/50", rollup.OwnershipCoverage, rollup.DocumentationCoverage)
+	}
+
+	if len(root.Children) != 2 || root.Children[0].Name != "Payments" || root.Children[1].Name != "Stores" {
+		t.Fatalf("Retail children = %+v, want Payments and Stores", root.Children)
+	}
+	pay := root.Children[0]
+	if pay.Stats.NamespaceCount != 4 || pay.Rollup.NamespaceCount != 8 || pay.Rollup.Criticality["tier-1"] != 7 {
+		t.Errorf("Payments = %+v / %+v, want 4 own and 8 rolled up namespaces", pay.Stats, pay.Rollup)
+	}
+	// Rolling up leaves a unit's own distribution alone
+	if pay.Stats.Criticality["tier-1"] != 3 {
+		t.Errorf("Payments own criticality = %v, want 3 tier-1", pay.Stats.Criticality)
+	}
+	empty := root.Children[1]
+	if empty.Rollup.NamespaceCount != 0 || empty.Rollup.OwnershipCoverage != 0 || empty.Rollup.Criticality == nil {
+		t.Errorf("Stores rollup = %+v, want no namespaces", empty.Rollup)
+	}
+}
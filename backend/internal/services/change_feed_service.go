@@ -0,0 +1,282 @@
+package services
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kubeatlas/kubeatlas/internal/models"
+)
+
+// ErrInvalidFeedCursor is returned for a change feed cursor that was not
+// issued by the feed
+var ErrInvalidFeedCursor = errors.New("invalid change feed cursor")
+
+// Change feed page sizes
+const (
+	DefaultChangeFeedLimit = 100
+	MaxChangeFeedLimit     = 1000
+)
+
+// Change feed formats
+const (
+	ChangeFeedNDJSON = "ndjson"
+	ChangeFeedAtom   = "atom"
+)
+
+// changeFeedExcludedActions and changeFeedExcludedResources are audited
+// events that change nothing in the catalog
+var (
+	changeFeedExcludedActions   = []string{AuditActionView, AuditActionExport}
+	changeFeedExcludedResources = []string{models.AuthAuditResource, "audit_log"}
+)
+
+// ChangeEvent is a change to the catalog, derived from its audit log
+type ChangeEvent struct {
+	ID           uuid.UUID     `json:"id"`
+	Time         time.Time     `json:"time"`
+	Action       string        `json:"action"`
+	ResourceType string        `json:"resource_type"`
+	ResourceID   uuid.UUID     `json:"resource_id"`
+	ResourceName string        `json:"resource_name,omitempty"`
+	Actor        string        `json:"actor,omitempty"`
+	Description  string        `json:"description,omitempty"`
+	Changes      []FieldChange `json:"changes"`
+	// Cursor resumes the feed after this event
+	Cursor string `json:"cursor"`
+}
+
+// ChangeFeed is a page of change events, oldest first
+type ChangeFeed struct {
+	Events []ChangeEvent `json:"events"`
+	// NextCursor resumes the feed after the last event. It is the requested
+	// cursor when there are no new events, so consumers can keep polling it.
+	NextCursor string `json:"next_cursor"`
+}
+
+// EncodeFeedCursor returns the opaque cursor of the feed position after the
+// audit log created at t with id
+func EncodeFeedCursor(t time.Time, id uuid.UUID) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(t.UTC().Format(time.RFC3339Nano) + "|" + id.String()))
+}
+
+// DecodeFeedCursor returns the position of a cursor from EncodeFeedCursor
+func DecodeFeedCursor(cursor string) (time.Time, uuid.UUID, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return time.Time{}, uuid.Nil, ErrInvalidFeedCursor
+	}
+	ts, rawID, ok := strings.Cut(string(data), "|")
+	if !ok {
+		return time.Time{}, uuid.Nil, ErrInvalidFeedCursor
+	}
+	t, err := time.Parse(time.RFC3339Nano, ts)
+	if err != nil {
+		return time.Time{}, uuid.Nil, ErrInvalidFeedCursor
+	}
+	id, err := uuid.Parse(rawID)
+	if err != nil {
+		return time.Time{}, uuid.Nil, ErrInvalidFeedCursor
+	}
+	return t, id, nil
+}
+
+// ParseFeedSince returns the cursor that starts a feed at since, which is a
+// cursor from the feed or an RFC 3339 time to read the changes made from
+func ParseFeedSince(since string) (string, error) {
+	if since == "" {
+		return "", nil
+	}
+	if _, _, err := DecodeFeedCursor(since); err == nil {
+		return since, nil
+	}
+	t, err := time.Parse(time.RFC3339Nano, since)
+	if err != nil {
+		return "", ErrInvalidFeedCursor
+	}
+	// No audit log has the nil ID, so this includes the changes made at t
+	return EncodeFeedCursor(t, uuid.Nil), nil
+}
+
+// ChangeFeed returns up to limit catalog changes of the organization made
+// after cursor, oldest first. An empty cursor starts from the oldest change
+// still retained. Reads, exports and authentication events are left out.
+func (s *AuditService) ChangeFeed(ctx context.Context, orgID uuid.UUID, cursor string, limit int) (*ChangeFeed, error) {
+	var after time.Time
+	afterID := uuid.Nil
+	if cursor != "" {
+		var err error
+		if after, afterID, err = DecodeFeedCursor(cursor); err != nil {
+			return nil, err
+		}
+	}
+	if limit <= 0 {
+		limit = DefaultChangeFeedLimit
+	}
+	if limit > MaxChangeFeedLimit {
+		limit = MaxChangeFeedLimit
+	}
+
+	filters := map[string]interface{}{
+		"exclude_actions":        changeFeedExcludedActions,
+		"exclude_resource_types": changeFeedExcludedResources,
+	}
+	logs, err := s.repo.ListAfter(ctx, orgID, filters, after, afterID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list changes: %w", err)
+	}
+
+	feed := &ChangeFeed{Events: make([]ChangeEvent, 0, len(logs)), NextCursor: cursor}
+	for i := range logs {
+		e := changeEvent(&logs[i])
+		feed.Events = append(feed.Events, e)
+		feed.NextCursor = e.Cursor
+	}
+	return feed, nil
+}
+
+// changeEvent converts an audit log to a change event
+func changeEvent(l *models.AuditLog) ChangeEvent {
+	return ChangeEvent{
+		ID:           l.ID,
+		Time:         l.CreatedAt,
+		Action:       l.Action,
+		ResourceType: l.ResourceType,
+		ResourceID:   l.ResourceID,
+		ResourceName: l.ResourceName.String,
+		Actor:        l.UserEmail.String,
+		Description:  l.Description.String,
+		Changes:      diffAuditValues(l.OldValues, l.NewValues),
+		Cursor:       EncodeFeedCursor(l.CreatedAt, l.ID),
+	}
+}
+
+// ChangeFeedContentType returns the content type of a change feed format
+func ChangeFeedContentType(format string) (string, error) {
+	switch format {
+	case ChangeFeedNDJSON:
+		return "application/x-ndjson", nil
+	case ChangeFeedAtom:
+		return "application/atom+xml", nil
+	default:
+		return "", fmt.Errorf("%w: %q", ErrUnsupportedExportFormat, format)
+	}
+}
+
+// WriteNDJSON writes the events of the feed to w, one JSON object per line
+func (f *ChangeFeed) WriteNDJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	for i := range f.Events {
+		if err := enc.Encode(&f.Events[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Atom documents, as defined by RFC 4287
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	ID      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated"`
+	Author  atomAuthor  `xml:"author"`
+	Links   []atomLink  `xml:"link"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Rel  string `xml:"rel,attr"`
+	Href string `xml:"href,attr"`
+}
+
+type atomEntry struct {
+	ID         string         `xml:"id"`
+	Title      string         `xml:"title"`
+	Updated    string         `xml:"updated"`
+	Author     *atomAuthor    `xml:"author,omitempty"`
+	Categories []atomCategory `xml:"category"`
+	Summary    string         `xml:"summary,omitempty"`
+	Content    atomContent    `xml:"content"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+type atomCategory struct {
+	Scheme string `xml:"scheme,attr"`
+	Term   string `xml:"term,attr"`
+}
+
+type atomContent struct {
+	Type string `xml:"type,attr"`
+	Body string `xml:",chardata"`
+}
+
+// WriteAtom writes the feed to w as an Atom document for orgID. selfURL is
+// the URL of this page and nextURL the URL that resumes after it.
+func (f *ChangeFeed) WriteAtom(w io.Writer, orgID uuid.UUID, selfURL, nextURL string) error {
+	// An empty feed is as recent as the request, which found nothing newer
+	updated := time.Now()
+	if len(f.Events) > 0 {
+		updated = f.Events[len(f.Events)-1].Time
+	}
+	doc := atomFeed{
+		ID:      "urn:kubeatlas:" + orgID.String() + ":changes",
+		Title:   "KubeAtlas catalog changes",
+		Updated: updated.UTC().Format(time.RFC3339Nano),
+		// Entries without an actor were made by KubeAtlas itself
+		Author: atomAuthor{Name: "KubeAtlas"},
+		Links: []atomLink{
+			{Rel: "self", Href: selfURL},
+			{Rel: "next", Href: nextURL},
+		},
+		Entries: make([]atomEntry, 0, len(f.Events)),
+	}
+
+	for _, e := range f.Events {
+		// The changes are carried as JSON text, which Atom readers show as is
+		changes, err := json.Marshal(e.Changes)
+		if err != nil {
+			return err
+		}
+		title := e.Action + " " + e.ResourceType
+		if e.ResourceName != "" {
+			title += " " + e.ResourceName
+		}
+		entry := atomEntry{
+			ID:      "urn:uuid:" + e.ID.String(),
+			Title:   title,
+			Updated: e.Time.UTC().Format(time.RFC3339Nano),
+			Categories: []atomCategory{
+				{Scheme: "urn:kubeatlas:action", Term: e.Action},
+				{Scheme: "urn:kubeatlas:resource_type", Term: e.ResourceType},
+				{Scheme: "urn:kubeatlas:resource_id", Term: e.ResourceID.String()},
+			},
+			Summary: e.Description,
+			Content: atomContent{Type: "text", Body: string(changes)},
+		}
+		if e.Actor != "" {
+			entry.Author = &atomAuthor{Name: e.Actor}
+		}
+		doc.Entries = append(doc.Entries, entry)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	return enc.Flush()
+}
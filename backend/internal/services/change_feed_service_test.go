@@ -0,0 +1,117 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestFeedCursor(t *testing.T) {
+	at := time.Date(2024, 3, 1, 12, 30, 0, 123456000, time.FixedZone("CET", 3600))
+	id := uuid.New()
+
+	gotTime, gotID, err := DecodeFeedCursor(EncodeFeedCursor(at, id))
+	if err != nil {
+		t.Fatalf("DecodeFeedCursor failed: %v", err)
+	}
+	if !gotTime.Equal(at) || gotID != id {
+		t.Errorf("cursor decoded to %v, %v, want %v, %v", gotTime, gotID, at, id)
+	}
+
+	for _, cursor := range []string{"not a cursor", "MjAyNA", EncodeFeedCursor(at, id)[:10]} {
+		if _, _, err := DecodeFeedCursor(cursor); !errors.Is(err, ErrInvalidFeedCursor) {
+			t.Errorf("DecodeFeedCursor(%q) = %v, want ErrInvalidFeedCursor", cursor, err)
+		}
+	}
+}
+
+func TestParseFeedSince(t *testing.T) {
+	cursor := EncodeFeedCursor(time.Now(), uuid.New())
+	if got, err := ParseFeedSince(cursor); err != nil || got != cursor {
+		t.Errorf("ParseFeedSince(cursor) = %q, %v, want the cursor", got, err)
+	}
+	if got, err := ParseFeedSince(""); err != nil || got != "" {
+		t.Errorf("ParseFeedSince(\"\") = %q, %v, want no cursor", got, err)
+	}
+
+	got, err := ParseFeedSince("2024-03-01T12:00:00Z")
+	if err != nil {
+		t.Fatalf("ParseFeedSince(time) failed: %v", err)
+	}
+	at, id, _ := DecodeFeedCursor(got)
+	if !at.Equal(time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)) || id != uuid.Nil {
+		t.Errorf("ParseFeedSince(time) = %v, %v, want the time and nil ID", at, id)
+	}
+
+	if _, err := ParseFeedSince("yesterday"); !errors.Is(err, ErrInvalidFeedCursor) {
+		t.Errorf("ParseFeedSince(yesterday) = %v, want ErrInvalidFeedCursor", err)
+	}
+}
+
+func testChangeFeed() *ChangeFeed {
+	at := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	e := ChangeEvent{
+		ID:           uuid.New(),
+		Time:         at,
+		Action:       "update",
+		ResourceType: "namespace",
+		ResourceID:   uuid.New(),
+		ResourceName: "payments",
+		Actor:        "dev@example.com",
+		Changes:      []FieldChange{{Field: "criticality", Change: FieldChanged, Before: "tier-2", After: "tier-1"}},
+	}
+	e.Cursor = EncodeFeedCursor(e.Time, e.ID)
+	return &ChangeFeed{Events: []ChangeEvent{e}, NextCursor: e.Cursor}
+}
+
+func TestChangeFeedWriteNDJSON(t *testing.T) {
+	feed := testChangeFeed()
+	var buf bytes.Buffer
+	if err := feed.WriteNDJSON(&buf); err != nil {
+		t.Fatalf("WriteNDJSON failed: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("wrote %d lines, want 1", len(lines))
+	}
+	var e ChangeEvent
+	if err := json.Unmarshal([]byte(lines[0]), &e); err != nil {
+		t.Fatalf("line is not JSON: %v", err)
+	}
+	if e.ID != feed.Events[0].ID || e.Cursor != feed.NextCursor || len(e.Changes) != 1 {
+		t.Errorf("event = %+v, want %+v", e, feed.Events[0])
+	}
+}
+
+func TestChangeFeedWriteAtom(t *testing.T) {
+	feed := testChangeFeed()
+	var buf bytes.Buffer
+	if err := feed.WriteAtom(&buf, uuid.New(), "https://atlas.example.com/api/v1/feed/changes", "https://atlas.example.com/api/v1/feed/changes?since=x"); err != nil {
+		t.Fatalf("WriteAtom failed: %v", err)
+	}
+
+	var doc atomFeed
+	if err := xml.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("feed is not XML: %v", err)
+	}
+	if doc.Updated != "2024-03-01T12:00:00Z" || len(doc.Links) != 2 || doc.Links[1].Rel != "next" {
+		t.Errorf("feed = %+v", doc)
+	}
+	if len(doc.Entries) != 1 {
+		t.Fatalf("feed has %d entries, want 1", len(doc.Entries))
+	}
+	entry := doc.Entries[0]
+	if entry.Title != "update namespace payments" || entry.Author == nil || entry.Author.Name != "dev@example.com" {
+		t.Errorf("entry = %+v", entry)
+	}
+	if !strings.Contains(entry.Content.Body, `"field":"criticality"`) {
+		t.Errorf("entry content = %q, want the changes", entry.Content.Body)
+	}
+}
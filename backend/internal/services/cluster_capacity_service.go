@@ -0,0 +1,112 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kubeatlas/kubeatlas/internal/models"
+)
+
+// capacitySnapshotKeep is how long the capacity snapshots of a cluster are
+// kept, and so how far back its capacity trend goes
+const capacitySnapshotKeep = 365 * 24 * time.Hour
+
+// ErrInvalidCapacityTrend is returned for capacity trends with an unknown
+// interval or a period outside what is kept
+var ErrInvalidCapacityTrend = errors.New("invalid capacity trend")
+
+// CapacityTrend is the capacity of a cluster over time, one point per
+// interval, with its growth over the period
+type CapacityTrend struct {
+	ClusterID uuid.UUID                        `json:"cluster_id"`
+	Interval  string                           `json:"interval"`
+	Since     time.Time                        `json:"since"`
+	Points    []models.ClusterCapacitySnapshot `json:"points"`
+	Growth    *CapacityGrowth                  `json:"growth"`
+}
+
+// CapacityGrowth is the average daily change of the capacity of a cluster,
+// fitted as a straight line through the points of its trend, to project
+// when it will need more
+type CapacityGrowth struct {
+	NodesPerDay                    float64 `json:"nodes_per_day"`
+	CPUAllocatableMillicoresPerDay float64 `json:"cpu_allocatable_millicores_per_day"`
+	MemoryAllocatableBytesPerDay   float64 `json:"memory_allocatable_bytes_per_day"`
+	PodsAllocatablePerDay          float64 `json:"pods_allocatable_per_day"`
+}
+
+// GetCapacityTrend returns the capacity of a cluster in the organization
+// over the last days, as snapshotted by its syncs: the last snapshot of each
+// day or week
+func (s *ClusterService) GetCapacityTrend(ctx context.Context, orgID, clusterID uuid.UUID, interval string, days int) (*CapacityTrend, error) {
+	if interval == "" {
+		interval = "day"
+	}
+	if interval != "day" && interval != "week" {
+		return nil, fmt.Errorf("%w: interval must be day or week", ErrInvalidCapacityTrend)
+	}
+	if maxDays := int(capacitySnapshotKeep / (24 * time.Hour)); days < 1 || days > maxDays {
+		return nil, fmt.Errorf("%w: days must be between 1 and %d", ErrInvalidCapacityTrend, maxDays)
+	}
+	if err := s.checkCluster(ctx, orgID, clusterID); err != nil {
+		return nil, err
+	}
+
+	since := time.Now().AddDate(0, 0, -days)
+	points, err := s.clusterRepo.ListCapacitySnapshots(ctx, clusterID, interval, since)
+	if err != nil {
+		return nil, err
+	}
+	return &CapacityTrend{
+		ClusterID: clusterID,
+		Interval:  interval,
+		Since:     since,
+		Points:    points,
+		Growth:    capacityGrowth(points),
+	}, nil
+}
+
+// capacityGrowth fits the capacity snapshots by least squares, or returns
+// nil without two snapshots taken at different times
+func capacityGrowth(points []models.ClusterCapacitySnapshot) *CapacityGrowth {
+	if len(points) < 2 {
+		return nil
+	}
+	days := make([]float64, len(points))
+	for i, p := range points {
+		days[i] = p.ObservedAt.Sub(points[0].ObservedAt).Hours() / 24
+	}
+	var mean float64
+	for _, d := range days {
+		mean += d
+	}
+	mean /= float64(len(days))
+	var variance float64
+	for _, d := range days {
+		variance += (d - mean) * (d - mean)
+	}
+	if variance == 0 {
+		return nil
+	}
+
+	slope := func(value func(models.ClusterCapacitySnapshot) float64) float64 {
+		var avg, covariance float64
+		for _, p := range points {
+			avg += value(p)
+		}
+		avg /= float64(len(points))
+		for i, p := range points {
+			covariance += (days[i] - mean) * (value(p) - avg)
+		}
+		return covariance / variance
+	}
+	return &CapacityGrowth{
+		NodesPerDay:                    slope(func(p models.ClusterCapacitySnapshot) float64 { return float64(p.Nodes) }),
+		CPUAllocatableMillicoresPerDay: slope(func(p models.ClusterCapacitySnapshot) float64 { return float64(p.CPUAllocatableMillicores) }),
+		MemoryAllocatableBytesPerDay:   slope(func(p models.ClusterCapacitySnapshot) float64 { return float64(p.MemoryAllocatableBytes) }),
+		PodsAllocatablePerDay:          slope(func(p models.ClusterCapacitySnapshot) float64 { return float64(p.PodsAllocatable) }),
+	}
+}
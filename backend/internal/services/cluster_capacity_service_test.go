@@ -0,0 +1,37 @@
+package services
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/kubeatlas/kubeatlas/internal/models"
+)
+
+func TestCapacityGrowth(t *testing.T) {
+	start := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	snapshot := func(day, nodes int) models.ClusterCapacitySnapshot {
+		return models.ClusterCapacitySnapshot{
+			Nodes: nodes, CPUAllocatableMillicores: int64(nodes) * 3800, MemoryAllocatableBytes: int64(nodes) << 30,
+			PodsAllocatable: int64(nodes) * 110, ObservedAt: start.AddDate(0, 0, day),
+		}
+	}
+
+	// Two nodes a week, one week a node short of the line
+	g := capacityGrowth([]models.ClusterCapacitySnapshot{snapshot(0, 10), snapshot(7, 11), snapshot(14, 14)})
+	if g == nil {
+		t.Fatal("capacityGrowth() = nil")
+	}
+	near := func(got, want float64) bool { return math.Abs(got-want) < 1e-9 }
+	if !near(g.NodesPerDay, 2.0/7) || !near(g.CPUAllocatableMillicoresPerDay, 3800*2.0/7) ||
+		!near(g.MemoryAllocatableBytesPerDay, float64(int64(1)<<30)*2/7) || !near(g.PodsAllocatablePerDay, 110*2.0/7) {
+		t.Errorf("capacityGrowth() = %+v", g)
+	}
+
+	if g := capacityGrowth([]models.ClusterCapacitySnapshot{snapshot(0, 10)}); g != nil {
+		t.Errorf("capacityGrowth() of one snapshot = %+v, want nil", g)
+	}
+	if g := capacityGrowth([]models.ClusterCapacitySnapshot{snapshot(3, 10), snapshot(3, 12)}); g != nil {
+		t.Errorf("capacityGrowth() of simultaneous snapshots = %+v, want nil", g)
+	}
+}
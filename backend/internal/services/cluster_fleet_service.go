@@ -0,0 +1,407 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/kubeatlas/kubeatlas/internal/database/repositories"
+	"github.com/kubeatlas/kubeatlas/internal/models"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrFleetNotFound = errors.New("cluster fleet not found")
+	ErrFleetExists   = errors.New("a cluster fleet with this slug already exists")
+	ErrInvalidFleet  = errors.New("invalid cluster fleet")
+)
+
+const (
+	// maxFleetClusters bounds the clusters of one fleet, which its report
+	// lists in full
+	maxFleetClusters = 500
+	// fleetSyncConcurrency is the number of clusters of a fleet synced at
+	// once
+	fleetSyncConcurrency = 4
+)
+
+// FleetRequest creates or replaces a fleet. The slug is derived from the
+// name when empty.
+type FleetRequest struct {
+	Name        string     `json:"name" binding:"required"`
+	Slug        string     `json:"slug"`
+	Description string     `json:"description"`
+	OwnerTeamID *uuid.UUID `json:"owner_team_id"`
+}
+
+func (r *FleetRequest) validate() error {
+	r.Name = strings.TrimSpace(r.Name)
+	if r.Name == "" || len(r.Name) > 255 {
+		return fmt.Errorf("%w: name must be 1 to 255 characters", ErrInvalidFleet)
+	}
+	if r.Slug == "" {
+		r.Slug = generateSlug(r.Name)
+	}
+	if r.Slug == "" || len(r.Slug) > 100 || r.Slug != generateSlug(r.Slug) {
+		return fmt.Errorf("%w: slug must be 1 to 100 lowercase letters, digits and hyphens", ErrInvalidFleet)
+	}
+	return nil
+}
+
+// FleetClustersRequest adds clusters to a fleet
+type FleetClustersRequest struct {
+	ClusterIDs []uuid.UUID `json:"cluster_ids" binding:"required"`
+}
+
+// FleetSync is the outcome of starting the sync of a fleet. Clusters
+// already syncing are skipped.
+type FleetSync struct {
+	Queued  []string `json:"queued"`
+	Skipped []string `json:"skipped"`
+}
+
+// FleetClusterStatus is a cluster of a fleet report
+type FleetClusterStatus struct {
+	ClusterID         uuid.UUID  `json:"cluster_id"`
+	Cluster           string     `json:"cluster"`
+	Environment       string     `json:"environment"`
+	Status            string     `json:"status"`
+	Version           string     `json:"version"`
+	VersionSupport    string     `json:"version_support"`
+	NodeCount         int        `json:"node_count"`
+	NamespaceCount    int        `json:"namespace_count"`
+	LastSyncAt        *time.Time `json:"last_sync_at"`
+	SyncErrorCategory string     `json:"sync_error_category,omitempty"`
+}
+
+// FleetReport summarizes the clusters of a fleet. Failing counts the
+// clusters whose last sync or connectivity probe failed.
+type FleetReport struct {
+	Fleet            *models.ClusterFleet `json:"fleet"`
+	TotalClusters    int                  `json:"total_clusters"`
+	TotalNodes       int                  `json:"total_nodes"`
+	TotalNamespaces  int                  `json:"total_namespaces"`
+	Failing          int                  `json:"failing"`
+	ByStatus         map[string]int       `json:"by_status"`
+	ByEnvironment    map[string]int       `json:"by_environment"`
+	ByVersionSupport map[string]int       `json:"by_version_support"`
+	Clusters         []FleetClusterStatus `json:"clusters"`
+}
+
+// ClusterFleetService manages cluster fleets, the groups of clusters that
+// are reported on and synced together
+type ClusterFleetService struct {
+	repo     *repositories.ClusterFleetRepository
+	teamRepo *repositories.TeamRepository
+	clusters *ClusterService
+	auditSvc *AuditService
+	logger   *zap.SugaredLogger
+}
+
+// NewClusterFleetService creates a new cluster fleet service
+func NewClusterFleetService(
+	repo *repositories.ClusterFleetRepository,
+	teamRepo *repositories.TeamRepository,
+	clusters *ClusterService,
+	auditSvc *AuditService,
+	logger *zap.SugaredLogger,
+) *ClusterFleetService {
+	return &ClusterFleetService{
+		repo:     repo,
+		teamRepo: teamRepo,
+		clusters: clusters,
+		auditSvc: auditSvc,
+		logger:   logger,
+	}
+}
+
+// List returns the fleets of the organization, by name
+func (s *ClusterFleetService) List(ctx context.Context, orgID uuid.UUID, p repositories.Pagination, filters map[string]interface{}) (*repositories.PaginatedResult[models.ClusterFleet], error) {
+	return s.repo.List(ctx, orgID, p, filters)
+}
+
+// GetByID returns a fleet of the organization
+func (s *ClusterFleetService) GetByID(ctx context.Context, orgID, id uuid.UUID) (*models.ClusterFleet, error) {
+	fleet, err := s.repo.GetByID(ctx, orgID, id)
+	if err != nil {
+		return nil, err
+	}
+	if fleet == nil {
+		return nil, ErrFleetNotFound
+	}
+	return fleet, nil
+}
+
+// Create creates a fleet
+func (s *ClusterFleetService) Create(ctx context.Context, ac AuditContext, req FleetRequest) (*models.ClusterFleet, error) {
+	fleet := &models.ClusterFleet{OrganizationID: ac.OrgID}
+	if err := s.apply(ctx, fleet, req); err != nil {
+		return nil, err
+	}
+	if err := s.repo.Create(ctx, fleet); err != nil {
+		return nil, err
+	}
+	s.auditSvc.LogCreate(ctx, ac, "cluster_fleet", fleet.ID, fleet.Name, StructToMap(fleet))
+	return s.GetByID(ctx, ac.OrgID, fleet.ID)
+}
+
+// Update replaces the details and owner of a fleet
+func (s *ClusterFleetService) Update(ctx context.Context, ac AuditContext, id uuid.UUID, req FleetRequest) (*models.ClusterFleet, error) {
+	fleet, err := s.GetByID(ctx, ac.OrgID, id)
+	if err != nil {
+		return nil, err
+	}
+	oldValues := StructToMap(fleet)
+	if err := s.apply(ctx, fleet, req); err != nil {
+		return nil, err
+	}
+	if err := s.repo.Update(ctx, fleet); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrFleetNotFound
+		}
+		return nil, err
+	}
+	s.auditSvc.LogUpdate(ctx, ac, "cluster_fleet", fleet.ID, fleet.Name, oldValues, StructToMap(fleet))
+	return s.GetByID(ctx, ac.OrgID, fleet.ID)
+}
+
+// Delete deletes a fleet. Its clusters are kept.
+func (s *ClusterFleetService) Delete(ctx context.Context, ac AuditContext, id uuid.UUID) error {
+	fleet, err := s.GetByID(ctx, ac.OrgID, id)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.Delete(ctx, id); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrFleetNotFound
+		}
+		return err
+	}
+	s.auditSvc.LogDelete(ctx, ac, "cluster_fleet", id, fleet.Name)
+	return nil
+}
+
+// apply validates req and sets it on fleet
+func (s *ClusterFleetService) apply(ctx context.Context, fleet *models.ClusterFleet, req FleetRequest) error {
+	if err := req.validate(); err != nil {
+		return err
+	}
+	exists, err := s.repo.ExistsBySlug(ctx, fleet.OrganizationID, req.Slug, fleet.ID)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return ErrFleetExists
+	}
+	if req.OwnerTeamID != nil {
+		team, err := s.teamRepo.GetByID(ctx, *req.OwnerTeamID)
+		if err != nil {
+			return err
+		}
+		if team == nil || team.OrganizationID != fleet.OrganizationID {
+			return ErrTeamNotFound
+		}
+	}
+
+	fleet.Name = req.Name
+	fleet.Slug = req.Slug
+	fleet.Description = models.NullString{}
+	if req.Description != "" {
+		fleet.Description = models.NewNullStringFromString(req.Description)
+	}
+	fleet.OwnerTeamID = req.OwnerTeamID
+	return nil
+}
+
+// AddClusters adds clusters of the organization to a fleet
+func (s *ClusterFleetService) AddClusters(ctx context.Context, ac AuditContext, id uuid.UUID, req FleetClustersRequest) (*models.ClusterFleet, error) {
+	fleet, err := s.GetByID(ctx, ac.OrgID, id)
+	if err != nil {
+		return nil, err
+	}
+	ids := uniqueIDs(req.ClusterIDs)
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("%w: cluster_ids must not be empty", ErrInvalidFleet)
+	}
+	if fleet.ClusterCount+len(ids) > maxFleetClusters {
+		return nil, fmt.Errorf("%w: a fleet has at most %d clusters", ErrInvalidFleet, maxFleetClusters)
+	}
+	names, err := s.repo.ClusterNames(ctx, ac.OrgID, ids)
+	if err != nil {
+		return nil, err
+	}
+	if len(names) != len(ids) {
+		return nil, ErrClusterNotFound
+	}
+
+	if err := s.repo.AddClusters(ctx, ac.OrgID, id, ids); err != nil {
+		return nil, err
+	}
+	added := make([]string, 0, len(names))
+	for _, name := range names {
+		added = append(added, name)
+	}
+	sort.Strings(added)
+	s.auditSvc.LogAction(ctx, ac, "add_clusters", "cluster_fleet", id, fleet.Name,
+		"Added clusters "+strings.Join(added, ", "))
+	return s.GetByID(ctx, ac.OrgID, id)
+}
+
+// RemoveCluster removes a cluster from a fleet
+func (s *ClusterFleetService) RemoveCluster(ctx context.Context, ac AuditContext, id, clusterID uuid.UUID) error {
+	fleet, err := s.GetByID(ctx, ac.OrgID, id)
+	if err != nil {
+		return err
+	}
+	removed, err := s.repo.RemoveCluster(ctx, id, clusterID)
+	if err != nil {
+		return err
+	}
+	if !removed {
+		return ErrClusterNotFound
+	}
+	name := clusterID.String()
+	if names, err := s.repo.ClusterNames(ctx, ac.OrgID, []uuid.UUID{clusterID}); err == nil && names[clusterID] != "" {
+		name = names[clusterID]
+	}
+	s.auditSvc.LogAction(ctx, ac, "remove_cluster", "cluster_fleet", id, fleet.Name, "Removed cluster "+name)
+	return nil
+}
+
+// Report summarizes the clusters of a fleet of the organization
+func (s *ClusterFleetService) Report(ctx context.Context, orgID, id uuid.UUID) (*FleetReport, error) {
+	fleet, err := s.GetByID(ctx, orgID, id)
+	if err != nil {
+		return nil, err
+	}
+	clusters, err := s.repo.ListClusters(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	calendar, err := s.clusters.versionCalendar(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+
+	report := fleetReport(calendar, clusters, time.Now())
+	report.Fleet = fleet
+	return report, nil
+}
+
+// Sync starts the sync of every cluster of a fleet of the organization,
+// fleetSyncConcurrency at a time, and returns without waiting for them.
+// Each sync is recorded in its cluster's sync runs.
+func (s *ClusterFleetService) Sync(ctx context.Context, ac AuditContext, id uuid.UUID) (*FleetSync, error) {
+	fleet, err := s.GetByID(ctx, ac.OrgID, id)
+	if err != nil {
+		return nil, err
+	}
+	clusters, err := s.repo.ListClusters(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &FleetSync{Queued: make([]string, 0, len(clusters)), Skipped: make([]string, 0)}
+	queued := make([]models.Cluster, 0, len(clusters))
+	for _, c := range clusters {
+		if c.Status == "syncing" {
+			result.Skipped = append(result.Skipped, c.Name)
+			continue
+		}
+		result.Queued = append(result.Queued, c.Name)
+		queued = append(queued, c)
+	}
+	s.auditSvc.LogAction(ctx, ac, "sync", "cluster_fleet", id, fleet.Name,
+		fmt.Sprintf("Started the sync of %d clusters", len(queued)))
+
+	// The syncs outlive the request that started them
+	syncCtx := context.WithoutCancel(ctx)
+	go func() {
+		var wg sync.WaitGroup
+		slots := make(chan struct{}, fleetSyncConcurrency)
+		for _, c := range queued {
+			wg.Add(1)
+			slots <- struct{}{}
+			go func(c models.Cluster) {
+				defer wg.Done()
+				defer func() { <-slots }()
+				if err := s.clusters.Sync(syncCtx, ac, c.ID); err != nil {
+					s.logger.Warnw("Fleet cluster sync failed", "fleet_id", id, "cluster_id", c.ID, "error", err)
+				}
+			}(c)
+		}
+		wg.Wait()
+		s.logger.Infow("Fleet synced", "fleet_id", id, "clusters", len(queued))
+	}()
+
+	return result, nil
+}
+
+// fleetReport summarizes clusters with the support of their versions on
+// the calendar
+func fleetReport(calendar versionCalendar, clusters []models.Cluster, now time.Time) *FleetReport {
+	report := &FleetReport{
+		TotalClusters:    len(clusters),
+		ByStatus:         make(map[string]int),
+		ByEnvironment:    make(map[string]int),
+		ByVersionSupport: make(map[string]int),
+		Clusters:         make([]FleetClusterStatus, 0, len(clusters)),
+	}
+	for _, c := range clusters {
+		support := calendar.support(c.Version.String, now)
+		status := FleetClusterStatus{
+			ClusterID:         c.ID,
+			Cluster:           c.Name,
+			Environment:       c.Environment,
+			Status:            c.Status,
+			Version:           c.Version.String,
+			VersionSupport:    support.Status,
+			NodeCount:         c.NodeCount,
+			NamespaceCount:    c.NamespaceCount,
+			SyncErrorCategory: c.SyncErrorCategory.String,
+		}
+		if c.LastSyncAt.Valid {
+			t := c.LastSyncAt.Time
+			status.LastSyncAt = &t
+		}
+		report.Clusters = append(report.Clusters, status)
+
+		report.TotalNodes += c.NodeCount
+		report.TotalNamespaces += c.NamespaceCount
+		report.ByStatus[c.Status]++
+		report.ByEnvironment[c.Environment]++
+		report.ByVersionSupport[support.Status]++
+		if c.Status == "error" || c.ConsecutiveFailures > 0 {
+			report.Failing++
+		}
+	}
+	return report
+}
+
+// CSV renders the clusters of the report as CSV
+func (r *FleetReport) CSV() ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.Write([]string{"Cluster", "Environment", "Status", "Version", "VersionSupport", "Nodes", "Namespaces", "LastSyncAt", "SyncErrorCategory"})
+	for _, c := range r.Clusters {
+		var lastSync string
+		if c.LastSyncAt != nil {
+			lastSync = c.LastSyncAt.UTC().Format(time.RFC3339)
+		}
+		w.Write([]string{
+			c.Cluster, c.Environment, c.Status, c.Version, c.VersionSupport,
+			strconv.Itoa(c.NodeCount), strconv.Itoa(c.NamespaceCount), lastSync, c.SyncErrorCategory,
+		})
+	}
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
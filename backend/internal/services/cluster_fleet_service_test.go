@@ -0,0 +1,77 @@
+package services
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kubeatlas/kubeatlas/internal/models"
+)
+
+func TestFleetRequestValidate(t *testing.T) {
+	req := FleetRequest{Name: " EU production fleet "}
+	if err := req.validate(); err != nil || req.Name != "EU production fleet" || req.Slug != "eu-production-fleet" {
+		t.Errorf("validate() = %v, request %+v", err, req)
+	}
+
+	for _, slug := range []string{"EU", "eu_prod", "eu-"} {
+		req := FleetRequest{Name: "EU", Slug: slug}
+		if err := req.validate(); !errors.Is(err, ErrInvalidFleet) {
+			t.Errorf("validate() with slug %q = %v, want ErrInvalidFleet", slug, err)
+		}
+	}
+}
+
+func TestFleetReport(t *testing.T) {
+	calendar := KubernetesVersionSettings{AlertDays: []int{90, 30, 0}}.calendar()
+	now := time.Date(2026, 10, 16, 15, 0, 0, 0, time.UTC)
+	lastSync := time.Date(2026, 10, 16, 14, 0, 0, 0, time.UTC)
+
+	clusters := []models.Cluster{
+		{
+			Name: "eu-prod-1", Environment: "production", Status: "active",
+			Version: models.NewNullStringFromString("v1.36.1"), LastSyncAt: models.NullTime{Time: lastSync, Valid: true},
+			NodeCount: 12, NamespaceCount: 40,
+		},
+		{
+			Name: "eu-prod-2", Environment: "production", Status: "error",
+			Version:           models.NewNullStringFromString("v1.33.5"),
+			SyncErrorCategory: models.NewNullStringFromString(models.SyncErrorCategoryUnreachable), ConsecutiveFailures: 3,
+			NodeCount: 8, NamespaceCount: 35,
+		},
+		{
+			Name: "eu-staging", Environment: "staging", Status: "active", ConsecutiveFailures: 1,
+			NodeCount: 3, NamespaceCount: 20,
+		},
+	}
+
+	report := fleetReport(calendar, clusters, now)
+	if report.TotalClusters != 3 || report.TotalNodes != 23 || report.TotalNamespaces != 95 || report.Failing != 2 {
+		t.Errorf("fleetReport() totals = %d clusters, %d nodes, %d namespaces, %d failing",
+			report.TotalClusters, report.TotalNodes, report.TotalNamespaces, report.Failing)
+	}
+	if !reflect.DeepEqual(report.ByStatus, map[string]int{"active": 2, "error": 1}) {
+		t.Errorf("ByStatus = %v", report.ByStatus)
+	}
+	if !reflect.DeepEqual(report.ByEnvironment, map[string]int{"production": 2, "staging": 1}) {
+		t.Errorf("ByEnvironment = %v", report.ByEnvironment)
+	}
+	want := map[string]int{models.VersionSupportSupported: 1, models.VersionSupportEndOfLife: 1, models.VersionSupportUnknown: 1}
+	if !reflect.DeepEqual(report.ByVersionSupport, want) {
+		t.Errorf("ByVersionSupport = %v, want %v", report.ByVersionSupport, want)
+	}
+	if c := report.Clusters[0]; c.LastSyncAt == nil || !c.LastSyncAt.Equal(lastSync) || report.Clusters[2].LastSyncAt != nil {
+		t.Errorf("LastSyncAt = %v, %v", c.LastSyncAt, report.Clusters[2].LastSyncAt)
+	}
+
+	data, err := report.CSV()
+	if err != nil {
+		t.Fatalf("CSV() error = %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 4 || lines[2] != "eu-prod-2,production,error,v1.33.5,end_of_life,8,35,,unreachable" {
+		t.Errorf("CSV() = %q", data)
+	}
+}
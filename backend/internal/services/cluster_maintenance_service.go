@@ -0,0 +1,290 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	// Maintenance window time zones resolve in images without zoneinfo
+	_ "time/tzdata"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/kubeatlas/kubeatlas/internal/models"
+)
+
+var (
+	ErrMaintenanceWindowNotFound = errors.New("maintenance window not found")
+	ErrInvalidMaintenanceWindow  = errors.New("invalid maintenance window")
+)
+
+const (
+	// maxMaintenanceWindows bounds the maintenance windows of a cluster
+	maxMaintenanceWindows = 20
+	// maxMaintenanceMinutes bounds the duration of a maintenance window to
+	// a week
+	maxMaintenanceMinutes = 7 * 24 * 60
+	// maintenanceHorizonDays is how far ahead the next maintenance window
+	// is looked for, enough for every monthly window
+	maintenanceHorizonDays = 62
+)
+
+// maintenanceWeekdays are the weekdays of weekly maintenance windows, in
+// the order of time.Weekday
+var maintenanceWeekdays = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+
+// MaintenanceWindowRequest creates or replaces a maintenance window.
+// Timezone is an IANA time zone such as Europe/Istanbul, UTC when empty.
+type MaintenanceWindowRequest struct {
+	Name            string   `json:"name" binding:"required"`
+	Recurrence      string   `json:"recurrence" binding:"required"`
+	StartDate       string   `json:"start_date"`
+	Weekdays        []string `json:"weekdays"`
+	DayOfMonth      int      `json:"day_of_month"`
+	StartTime       string   `json:"start_time" binding:"required"`
+	DurationMinutes int      `json:"duration_minutes" binding:"required"`
+	Timezone        string   `json:"timezone"`
+}
+
+func (r *MaintenanceWindowRequest) validate() error {
+	r.Name = strings.TrimSpace(r.Name)
+	if r.Name == "" || len(r.Name) > 255 {
+		return fmt.Errorf("%w: name must be 1 to 255 characters", ErrInvalidMaintenanceWindow)
+	}
+	if _, err := time.Parse("15:04", r.StartTime); err != nil {
+		return fmt.Errorf("%w: start_time must be HH:MM", ErrInvalidMaintenanceWindow)
+	}
+	if r.DurationMinutes < 1 || r.DurationMinutes > maxMaintenanceMinutes {
+		return fmt.Errorf("%w: duration_minutes must be 1 to %d", ErrInvalidMaintenanceWindow, maxMaintenanceMinutes)
+	}
+	if r.Timezone == "" {
+		r.Timezone = "UTC"
+	}
+	if _, err := time.LoadLocation(r.Timezone); err != nil {
+		return fmt.Errorf("%w: unknown timezone %q", ErrInvalidMaintenanceWindow, r.Timezone)
+	}
+
+	switch r.Recurrence {
+	case models.MaintenanceOnce:
+		if _, err := time.Parse(releaseDateLayout, r.StartDate); err != nil {
+			return fmt.Errorf("%w: start_date must be YYYY-MM-DD", ErrInvalidMaintenanceWindow)
+		}
+		r.Weekdays, r.DayOfMonth = nil, 0
+	case models.MaintenanceDaily:
+		r.StartDate, r.Weekdays, r.DayOfMonth = "", nil, 0
+	case models.MaintenanceWeekly:
+		days := make(map[string]bool, len(r.Weekdays))
+		for _, day := range r.Weekdays {
+			day = strings.ToLower(strings.TrimSpace(day))
+			if !containsString(maintenanceWeekdays, day) {
+				return fmt.Errorf("%w: weekdays must be mon, tue, wed, thu, fri, sat or sun", ErrInvalidMaintenanceWindow)
+			}
+			days[day] = true
+		}
+		if len(days) == 0 {
+			return fmt.Errorf("%w: a weekly window needs weekdays", ErrInvalidMaintenanceWindow)
+		}
+		// Monday first
+		r.Weekdays = make([]string, 0, len(days))
+		for i := 1; i <= len(maintenanceWeekdays); i++ {
+			if day := maintenanceWeekdays[i%len(maintenanceWeekdays)]; days[day] {
+				r.Weekdays = append(r.Weekdays, day)
+			}
+		}
+		r.StartDate, r.DayOfMonth = "", 0
+	case models.MaintenanceMonthly:
+		if r.DayOfMonth < 1 || r.DayOfMonth > 31 {
+			return fmt.Errorf("%w: day_of_month must be 1 to 31", ErrInvalidMaintenanceWindow)
+		}
+		r.StartDate, r.Weekdays = "", nil
+	default:
+		return fmt.Errorf("%w: recurrence must be once, daily, weekly or monthly", ErrInvalidMaintenanceWindow)
+	}
+	return nil
+}
+
+// ListMaintenanceWindows returns the maintenance windows of a cluster in
+// the organization, by name
+func (s *ClusterService) ListMaintenanceWindows(ctx context.Context, orgID, clusterID uuid.UUID) ([]models.MaintenanceWindow, error) {
+	if err := s.checkCluster(ctx, orgID, clusterID); err != nil {
+		return nil, err
+	}
+	return s.clusterRepo.ListMaintenanceWindows(ctx, []uuid.UUID{clusterID})
+}
+
+// CreateMaintenanceWindow adds a maintenance window to a cluster
+func (s *ClusterService) CreateMaintenanceWindow(ctx context.Context, ac AuditContext, clusterID uuid.UUID, req MaintenanceWindowRequest) (*models.MaintenanceWindow, error) {
+	cluster, err := s.orgCluster(ctx, ac.OrgID, clusterID)
+	if err != nil {
+		return nil, err
+	}
+	if err := req.validate(); err != nil {
+		return nil, err
+	}
+	windows, err := s.clusterRepo.ListMaintenanceWindows(ctx, []uuid.UUID{clusterID})
+	if err != nil {
+		return nil, err
+	}
+	if len(windows) >= maxMaintenanceWindows {
+		return nil, fmt.Errorf("%w: a cluster has at most %d maintenance windows", ErrInvalidMaintenanceWindow, maxMaintenanceWindows)
+	}
+
+	window := &models.MaintenanceWindow{ClusterID: clusterID, OrganizationID: ac.OrgID}
+	applyMaintenanceWindow(window, req)
+	if err := s.clusterRepo.CreateMaintenanceWindow(ctx, window); err != nil {
+		return nil, err
+	}
+	s.auditSvc.LogAction(ctx, ac, "add_maintenance_window", "cluster", clusterID, cluster.Name,
+		"Added maintenance window "+window.Name)
+	return window, nil
+}
+
+// UpdateMaintenanceWindow replaces a maintenance window of a cluster
+func (s *ClusterService) UpdateMaintenanceWindow(ctx context.Context, ac AuditContext, clusterID, id uuid.UUID, req MaintenanceWindowRequest) (*models.MaintenanceWindow, error) {
+	cluster, err := s.orgCluster(ctx, ac.OrgID, clusterID)
+	if err != nil {
+		return nil, err
+	}
+	window, err := s.clusterRepo.GetMaintenanceWindow(ctx, clusterID, id)
+	if err != nil {
+		return nil, err
+	}
+	if window == nil {
+		return nil, ErrMaintenanceWindowNotFound
+	}
+	if err := req.validate(); err != nil {
+		return nil, err
+	}
+
+	applyMaintenanceWindow(window, req)
+	if err := s.clusterRepo.UpdateMaintenanceWindow(ctx, window); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrMaintenanceWindowNotFound
+		}
+		return nil, err
+	}
+	s.auditSvc.LogAction(ctx, ac, "update_maintenance_window", "cluster", clusterID, cluster.Name,
+		"Updated maintenance window "+window.Name)
+	return window, nil
+}
+
+// DeleteMaintenanceWindow deletes a maintenance window of a cluster
+func (s *ClusterService) DeleteMaintenanceWindow(ctx context.Context, ac AuditContext, clusterID, id uuid.UUID) error {
+	cluster, err := s.orgCluster(ctx, ac.OrgID, clusterID)
+	if err != nil {
+		return err
+	}
+	window, err := s.clusterRepo.GetMaintenanceWindow(ctx, clusterID, id)
+	if err != nil {
+		return err
+	}
+	if window == nil {
+		return ErrMaintenanceWindowNotFound
+	}
+	if _, err := s.clusterRepo.DeleteMaintenanceWindow(ctx, clusterID, id); err != nil {
+		return err
+	}
+	s.auditSvc.LogAction(ctx, ac, "delete_maintenance_window", "cluster", clusterID, cluster.Name,
+		"Deleted maintenance window "+window.Name)
+	return nil
+}
+
+// clusterMaintenance places a cluster on its maintenance windows as of now.
+// Failing to load them leaves the cluster out of maintenance.
+func (s *ClusterService) clusterMaintenance(ctx context.Context, clusterID uuid.UUID, now time.Time) *models.MaintenanceStatus {
+	windows, err := s.clusterRepo.ListMaintenanceWindows(ctx, []uuid.UUID{clusterID})
+	if err != nil {
+		s.logger.Warnw("Failed to load maintenance windows", "cluster_id", clusterID, "error", err)
+		return nil
+	}
+	return maintenanceStatus(windows, now)
+}
+
+func applyMaintenanceWindow(w *models.MaintenanceWindow, req MaintenanceWindowRequest) {
+	w.Name = req.Name
+	w.Recurrence = req.Recurrence
+	w.StartDate = req.StartDate
+	w.Weekdays = req.Weekdays
+	if w.Weekdays == nil {
+		w.Weekdays = []string{}
+	}
+	w.DayOfMonth = req.DayOfMonth
+	w.StartTime = req.StartTime
+	w.DurationMinutes = req.DurationMinutes
+	w.Timezone = req.Timezone
+}
+
+// maintenanceStatus places windows as of now: the window now falls in,
+// ending last if several do, and the next window to start. Returns nil
+// without windows.
+func maintenanceStatus(windows []models.MaintenanceWindow, now time.Time) *models.MaintenanceStatus {
+	if len(windows) == 0 {
+		return nil
+	}
+	status := &models.MaintenanceStatus{}
+	for _, w := range windows {
+		for _, start := range maintenanceStarts(w, now) {
+			start, end := start, start.Add(time.Duration(w.DurationMinutes)*time.Minute)
+			switch {
+			case !start.After(now) && now.Before(end):
+				if !status.Active || end.After(*status.EndsAt) {
+					status.Active, status.Window, status.EndsAt = true, w.Name, &end
+				}
+			case start.After(now):
+				if status.NextStartsAt == nil || start.Before(*status.NextStartsAt) {
+					status.NextWindow, status.NextStartsAt = w.Name, &start
+				}
+			}
+		}
+	}
+	return status
+}
+
+// maintenanceStarts returns the starts of a window that may span now: those
+// from as long before now as the window lasts up to maintenanceHorizonDays
+// after it. Windows whose start time or time zone do not parse have none.
+func maintenanceStarts(w models.MaintenanceWindow, now time.Time) []time.Time {
+	loc, err := time.LoadLocation(w.Timezone)
+	if err != nil {
+		return nil
+	}
+	at, err := time.Parse("15:04", w.StartTime)
+	if err != nil {
+		return nil
+	}
+	startOn := func(day time.Time) time.Time {
+		return time.Date(day.Year(), day.Month(), day.Day(), at.Hour(), at.Minute(), 0, 0, loc)
+	}
+
+	if w.Recurrence == models.MaintenanceOnce {
+		day, err := time.ParseInLocation(releaseDateLayout, w.StartDate, loc)
+		if err != nil {
+			return nil
+		}
+		return []time.Time{startOn(day)}
+	}
+
+	local := now.In(loc)
+	lookback := w.DurationMinutes/(24*60) + 1
+	first := time.Date(local.Year(), local.Month(), local.Day()-lookback, 0, 0, 0, 0, loc)
+	var starts []time.Time
+	for i := 0; i <= lookback+maintenanceHorizonDays; i++ {
+		day := first.AddDate(0, 0, i)
+		switch w.Recurrence {
+		case models.MaintenanceDaily:
+		case models.MaintenanceWeekly:
+			if !containsString(w.Weekdays, maintenanceWeekdays[day.Weekday()]) {
+				continue
+			}
+		case models.MaintenanceMonthly:
+			if day.Day() != w.DayOfMonth {
+				continue
+			}
+		default:
+			continue
+		}
+		starts = append(starts, startOn(day))
+	}
+	return starts
+}
@@ -0,0 +1,88 @@
+package services
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/kubeatlas/kubeatlas/internal/models"
+)
+
+func TestMaintenanceWindowRequestValidate(t *testing.T) {
+	req := MaintenanceWindowRequest{
+		Name: " Patch night ", Recurrence: models.MaintenanceWeekly, Weekdays: []string{"Sun", "tue", "sun"},
+		StartTime: "22:00", DurationMinutes: 120, DayOfMonth: 3,
+	}
+	if err := req.validate(); err != nil {
+		t.Fatalf("validate() = %v", err)
+	}
+	if req.Name != "Patch night" || req.Timezone != "UTC" || req.DayOfMonth != 0 || !reflect.DeepEqual(req.Weekdays, []string{"tue", "sun"}) {
+		t.Errorf("validate() request = %+v", req)
+	}
+
+	invalid := []MaintenanceWindowRequest{
+		{Name: "w", Recurrence: "yearly", StartTime: "22:00", DurationMinutes: 60},
+		{Name: "w", Recurrence: models.MaintenanceOnce, StartTime: "22:00", DurationMinutes: 60},
+		{Name: "w", Recurrence: models.MaintenanceWeekly, StartTime: "22:00", DurationMinutes: 60},
+		{Name: "w", Recurrence: models.MaintenanceWeekly, Weekdays: []string{"monday"}, StartTime: "22:00", DurationMinutes: 60},
+		{Name: "w", Recurrence: models.MaintenanceMonthly, DayOfMonth: 32, StartTime: "22:00", DurationMinutes: 60},
+		{Name: "w", Recurrence: models.MaintenanceDaily, StartTime: "25:00", DurationMinutes: 60},
+		{Name: "w", Recurrence: models.MaintenanceDaily, StartTime: "22:00", DurationMinutes: 0},
+		{Name: "w", Recurrence: models.MaintenanceDaily, StartTime: "22:00", DurationMinutes: 60, Timezone: "Europe/Nowhere"},
+	}
+	for _, req := range invalid {
+		if err := req.validate(); !errors.Is(err, ErrInvalidMaintenanceWindow) {
+			t.Errorf("validate(%+v) = %v, want ErrInvalidMaintenanceWindow", req, err)
+		}
+	}
+}
+
+func TestMaintenanceStatus(t *testing.T) {
+	// Friday 16 October 2026, 23:30 in Istanbul (UTC+3)
+	now := time.Date(2026, 10, 16, 20, 30, 0, 0, time.UTC)
+	at := func(day, hour, min int) time.Time { return time.Date(2026, 10, day, hour, min, 0, 0, time.UTC) }
+
+	weekly := models.MaintenanceWindow{
+		Name: "Patch night", Recurrence: models.MaintenanceWeekly, Weekdays: []string{"fri"},
+		StartTime: "23:00", DurationMinutes: 120, Timezone: "Europe/Istanbul",
+	}
+	daily := models.MaintenanceWindow{
+		Name: "Backups", Recurrence: models.MaintenanceDaily, StartTime: "02:00", DurationMinutes: 30, Timezone: "UTC",
+	}
+	once := models.MaintenanceWindow{
+		Name: "Upgrade to 1.35", Recurrence: models.MaintenanceOnce, StartDate: "2026-10-14",
+		StartTime: "20:00", DurationMinutes: 3 * 24 * 60, Timezone: "UTC",
+	}
+	monthly := models.MaintenanceWindow{
+		Name: "Certificates", Recurrence: models.MaintenanceMonthly, DayOfMonth: 31,
+		StartTime: "00:00", DurationMinutes: 60, Timezone: "UTC",
+	}
+
+	tests := []struct {
+		name    string
+		windows []models.MaintenanceWindow
+		window  string
+		endsAt  time.Time
+		next    string
+		startAt time.Time
+	}{
+		{"weekly in its time zone", []models.MaintenanceWindow{weekly}, "Patch night", at(16, 22, 0), "Patch night", at(23, 20, 0)},
+		{"daily ahead", []models.MaintenanceWindow{daily}, "", time.Time{}, "Backups", at(17, 2, 0)},
+		{"once spanning days", []models.MaintenanceWindow{once, daily}, "Upgrade to 1.35", at(17, 20, 0), "Backups", at(17, 2, 0)},
+		{"monthly", []models.MaintenanceWindow{monthly}, "", time.Time{}, "Certificates", at(31, 0, 0)},
+	}
+	for _, tt := range tests {
+		s := maintenanceStatus(tt.windows, now)
+		if s.Active != (tt.window != "") || s.Window != tt.window || (s.Active && !s.EndsAt.Equal(tt.endsAt)) {
+			t.Errorf("%s: active %v in %q until %v, want %q until %v", tt.name, s.Active, s.Window, s.EndsAt, tt.window, tt.endsAt)
+		}
+		if s.NextWindow != tt.next || s.NextStartsAt == nil || !s.NextStartsAt.Equal(tt.startAt) {
+			t.Errorf("%s: next %q at %v, want %q at %v", tt.name, s.NextWindow, s.NextStartsAt, tt.next, tt.startAt)
+		}
+	}
+
+	if s := maintenanceStatus(nil, now); s != nil {
+		t.Errorf("maintenanceStatus(nil) = %+v, want nil", s)
+	}
+}
@@ -0,0 +1,167 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sort"
+
+	"github.com/google/uuid"
+	"github.com/kubeatlas/kubeatlas/internal/database/repositories"
+	"github.com/kubeatlas/kubeatlas/internal/k8s"
+	"github.com/kubeatlas/kubeatlas/internal/models"
+)
+
+// ErrNodeNotFound is returned for nodes that are not in the organization's
+// clusters
+var ErrNodeNotFound = errors.New("node not found")
+
+// ListNodes returns the nodes of a cluster in the organization as found by
+// its last sync, filtered by role, status and kubelet version
+func (s *ClusterService) ListNodes(ctx context.Context, orgID, clusterID uuid.UUID, p repositories.Pagination, filters map[string]interface{}) (*repositories.PaginatedResult[models.ClusterNode], error) {
+	if err := s.checkCluster(ctx, orgID, clusterID); err != nil {
+		return nil, err
+	}
+	return s.clusterRepo.ListNodes(ctx, clusterID, p, filters)
+}
+
+// GetNode returns a node of a cluster in the organization
+func (s *ClusterService) GetNode(ctx context.Context, orgID, id uuid.UUID) (*models.ClusterNode, error) {
+	node, err := s.clusterRepo.GetNode(ctx, orgID, id)
+	if err != nil {
+		return nil, err
+	}
+	if node == nil {
+		return nil, ErrNodeNotFound
+	}
+	return node, nil
+}
+
+// GetCapacity sums the capacity of the nodes of a cluster in the
+// organization, with the node list filters
+func (s *ClusterService) GetCapacity(ctx context.Context, orgID, clusterID uuid.UUID, filters map[string]interface{}) (*models.ClusterCapacity, error) {
+	if err := s.checkCluster(ctx, orgID, clusterID); err != nil {
+		return nil, err
+	}
+	return s.clusterRepo.GetCapacity(ctx, clusterID, filters)
+}
+
+// checkCluster returns ErrClusterNotFound unless the cluster is one of the
+// organization's
+func (s *ClusterService) checkCluster(ctx context.Context, orgID, clusterID uuid.UUID) error {
+	_, err := s.orgCluster(ctx, orgID, clusterID)
+	return err
+}
+
+// orgCluster returns a cluster of the organization
+func (s *ClusterService) orgCluster(ctx context.Context, orgID, clusterID uuid.UUID) (*models.Cluster, error) {
+	cluster, err := s.clusterRepo.GetByID(ctx, clusterID)
+	if err != nil {
+		return nil, err
+	}
+	if cluster == nil || cluster.OrganizationID != orgID {
+		return nil, ErrClusterNotFound
+	}
+	return cluster, nil
+}
+
+// clusterNodes converts the nodes found in a cluster to be stored
+func clusterNodes(discovered []k8s.DiscoveredNode) []models.ClusterNode {
+	nodes := make([]models.ClusterNode, 0, len(discovered))
+	for _, d := range discovered {
+		roles := append([]string{}, d.Roles...)
+		sort.Strings(roles)
+		node := models.ClusterNode{
+			Name:                     d.Name,
+			K8sUID:                   models.NewNullStringFromString(d.UID),
+			Status:                   d.Status,
+			Roles:                    roles,
+			KubeletVersion:           d.KubeletVersion,
+			OSImage:                  d.OSImage,
+			Architecture:             d.Architecture,
+			CPUCapacityMillicores:    d.CPUCapacityMillicores,
+			CPUAllocatableMillicores: d.CPUAllocatableMillicores,
+			MemoryCapacityBytes:      d.MemoryCapacityBytes,
+			MemoryAllocatableBytes:   d.MemoryAllocatableBytes,
+			PodsCapacity:             d.PodsCapacity,
+			PodsAllocatable:          d.PodsAllocatable,
+			Capacity:                 quantities(d.Capacity),
+			Allocatable:              quantities(d.Allocatable),
+			Labels:                   models.JSONMap(d.Labels),
+			Annotations:              models.JSONMap(d.Annotations),
+		}
+		if node.Labels == nil {
+			node.Labels = make(models.JSONMap)
+		}
+		if node.Annotations == nil {
+			node.Annotations = make(models.JSONMap)
+		}
+		if !d.CreatedAt.IsZero() {
+			node.K8sCreatedAt = models.NullTime{Time: d.CreatedAt, Valid: true}
+		}
+		nodes = append(nodes, node)
+	}
+	return nodes
+}
+
+// syncLabels are the cluster labels a sync sets from the cloud metadata of
+// its nodes, with the node labels they are read from, current one first
+var syncLabels = []struct {
+	key        string
+	nodeLabels []string
+}{
+	{"region", []string{"topology.kubernetes.io/region", "failure-domain.beta.kubernetes.io/region"}},
+	{"zone", []string{"topology.kubernetes.io/zone", "failure-domain.beta.kubernetes.io/zone"}},
+	{"instance-type", []string{"node.kubernetes.io/instance-type", "beta.kubernetes.io/instance-type"}},
+}
+
+// syncLabelKeys returns the keys of the cluster labels a sync sets
+func syncLabelKeys() []string {
+	keys := make([]string, len(syncLabels))
+	for i, l := range syncLabels {
+		keys[i] = l.key
+	}
+	return keys
+}
+
+// clusterSyncLabels returns the cloud metadata the nodes of a cluster share
+// as cluster labels. A label is set when every node carrying it agrees on
+// its value, so a cluster spread over several zones has no zone.
+func clusterSyncLabels(nodes []k8s.DiscoveredNode) map[string]string {
+	found := make(map[string]string, len(syncLabels))
+	for _, l := range syncLabels {
+		value, agreed := "", true
+		for _, n := range nodes {
+			v := nodeLabel(n.Labels, l.nodeLabels)
+			if v == "" {
+				continue
+			}
+			if value != "" && v != value {
+				agreed = false
+				break
+			}
+			value = v
+		}
+		if agreed && value != "" {
+			found[l.key] = value
+		}
+	}
+	return found
+}
+
+// nodeLabel returns the value of the first of keys a node is labelled with
+func nodeLabel(labels map[string]interface{}, keys []string) string {
+	for _, key := range keys {
+		if v, _ := labels[key].(string); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+func quantities(resources map[string]string) models.JSONMap {
+	m := make(models.JSONMap, len(resources))
+	for name, q := range resources {
+		m[name] = q
+	}
+	return m
+}
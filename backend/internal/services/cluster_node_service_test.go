@@ -0,0 +1,67 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/kubeatlas/kubeatlas/internal/k8s"
+	"github.com/kubeatlas/kubeatlas/internal/models"
+)
+
+func TestClusterNodes(t *testing.T) {
+	created := time.Date(2026, 10, 1, 9, 0, 0, 0, time.UTC)
+	nodes := clusterNodes([]k8s.DiscoveredNode{
+		{
+			Name: "worker-1", UID: "0b1c", CreatedAt: created, Status: models.NodeStatusReady,
+			Roles: []string{"worker", "infra"}, KubeletVersion: "v1.30.2",
+			Labels:                map[string]interface{}{"topology.kubernetes.io/zone": "eu-west-1a"},
+			Capacity:              map[string]string{"cpu": "4", "memory": "16Gi", "nvidia.com/gpu": "1"},
+			CPUCapacityMillicores: 4000, MemoryCapacityBytes: 16 << 30, PodsCapacity: 110,
+		},
+		{Name: "worker-2", Status: models.NodeStatusNotReady},
+	})
+
+	if len(nodes) != 2 {
+		t.Fatalf("clusterNodes() = %d nodes, want 2", len(nodes))
+	}
+	n := nodes[0]
+	if !reflect.DeepEqual([]string(n.Roles), []string{"infra", "worker"}) {
+		t.Errorf("roles = %v, want them sorted", n.Roles)
+	}
+	if n.K8sUID.String != "0b1c" || !n.K8sCreatedAt.Time.Equal(created) || n.CPUCapacityMillicores != 4000 || n.PodsCapacity != 110 {
+		t.Errorf("node = %+v", n)
+	}
+	if n.Capacity["nvidia.com/gpu"] != "1" || n.Labels["topology.kubernetes.io/zone"] != "eu-west-1a" {
+		t.Errorf("capacity = %v, labels = %v", n.Capacity, n.Labels)
+	}
+	if n := nodes[1]; n.K8sUID.Valid || n.K8sCreatedAt.Valid || n.Labels == nil || n.Annotations == nil || len(n.Capacity) != 0 {
+		t.Errorf("node without details = %+v, want empty maps and null UID and creation", n)
+	}
+}
+
+func TestClusterSyncLabels(t *testing.T) {
+	node := func(labels map[string]interface{}) k8s.DiscoveredNode { return k8s.DiscoveredNode{Labels: labels} }
+	got := clusterSyncLabels([]k8s.DiscoveredNode{
+		node(map[string]interface{}{
+			"topology.kubernetes.io/region":    "eu-west-1",
+			"topology.kubernetes.io/zone":      "eu-west-1a",
+			"node.kubernetes.io/instance-type": "m5.large",
+		}),
+		node(map[string]interface{}{
+			"failure-domain.beta.kubernetes.io/region": "eu-west-1",
+			"topology.kubernetes.io/zone":              "eu-west-1b",
+			"node.kubernetes.io/instance-type":         "m5.large",
+		}),
+		// Control plane nodes of self-managed clusters are often unlabelled
+		node(nil),
+	})
+	want := map[string]string{"region": "eu-west-1", "instance-type": "m5.large"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("clusterSyncLabels() = %v, want %v", got, want)
+	}
+
+	if got := clusterSyncLabels(nil); len(got) != 0 {
+		t.Errorf("clusterSyncLabels(nil) = %v, want none", got)
+	}
+}
@@ -0,0 +1,127 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kubeatlas/kubeatlas/internal/k8s"
+	"github.com/kubeatlas/kubeatlas/internal/models"
+)
+
+// ErrClusterConnectionFailed is returned when a cluster previewed before it
+// is created cannot be reached with the credentials given
+var ErrClusterConnectionFailed = errors.New("cluster connection failed")
+
+// previewNamespaceSample is the number of namespace names a preview shows
+const previewNamespaceSample = 20
+
+// ClusterPreview is what the credentials of a create request show of a
+// cluster, for users to check they are registering the right one before it
+// is saved
+type ClusterPreview struct {
+	ServerVersion  string                 `json:"server_version"`
+	VersionSupport *models.VersionSupport `json:"version_support,omitempty"`
+	// DetectedType is the cluster type guessed from the cluster itself
+	DetectedType   string `json:"detected_cluster_type"`
+	NodeCount      int    `json:"node_count"`
+	ReadyNodes     int    `json:"ready_nodes"`
+	NamespaceCount int    `json:"namespace_count"`
+	// Namespaces is a sample of the namespace names, sorted
+	Namespaces []string `json:"namespaces"`
+	Warnings   []string `json:"warnings"`
+}
+
+// Preview connects to the cluster of a create request and returns what it
+// finds, without saving the cluster. The request is validated as Create does.
+func (s *ClusterService) Preview(ctx context.Context, orgID uuid.UUID, req CreateClusterRequest) (*ClusterPreview, error) {
+	if err := s.validateCreate(ctx, orgID, req); err != nil {
+		return nil, err
+	}
+	cluster, err := s.newCluster(ctx, orgID, req)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := s.k8sManager.NewClient(cluster)
+	if err != nil {
+		return nil, fmt.Errorf("%w (%s): %v", ErrClusterConnectionFailed, models.SyncErrorCategoryConfig, err)
+	}
+	version, err := client.GetServerVersion(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%w (%s): %v", ErrClusterConnectionFailed, k8s.ClassifyError(err), err)
+	}
+	namespaces, err := client.DiscoverNamespaces(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%w (%s): %v", ErrClusterConnectionFailed, k8s.ClassifyError(err), err)
+	}
+
+	var warnings []string
+	if client.FailedOver() {
+		warnings = append(warnings, fmt.Sprintf("API server URL %s could not be reached; the fallback %s answered", req.APIServerURL, client.Endpoint()))
+	}
+
+	// Nodes and API groups only help detect the platform; without them the
+	// preview is still worth showing
+	nodes, err := client.DiscoverNodes(ctx)
+	if err != nil {
+		warnings = append(warnings, fmt.Sprintf("Nodes could not be listed: %v", err))
+	}
+	groups, err := client.ServerGroups(ctx)
+	if err != nil {
+		s.logger.Debugw("Failed to list API groups for cluster preview", "error", err)
+	}
+
+	registered, err := s.clusterRepo.ListNamesByAPIServerURL(ctx, orgID, req.APIServerURL)
+	if err != nil {
+		return nil, err
+	}
+	calendar, err := s.versionCalendar(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+
+	preview := clusterPreview(req.ClusterType, version, namespaces, nodes, k8s.DetectClusterType(version, groups, nodes), registered)
+	preview.VersionSupport = calendar.support(version, time.Now())
+	preview.Warnings = append(warnings, preview.Warnings...)
+	return preview, nil
+}
+
+// clusterPreview builds the preview of a cluster from what was discovered
+// of it. registered are the clusters of the organization with the same API
+// server URL.
+func clusterPreview(clusterType, version string, namespaces []k8s.DiscoveredNamespace, nodes []k8s.DiscoveredNode, detected string, registered []string) *ClusterPreview {
+	p := &ClusterPreview{
+		ServerVersion:  version,
+		DetectedType:   detected,
+		NodeCount:      len(nodes),
+		NamespaceCount: len(namespaces),
+		Namespaces:     make([]string, 0, len(namespaces)),
+		Warnings:       make([]string, 0),
+	}
+	for _, n := range nodes {
+		if n.Status == "Ready" {
+			p.ReadyNodes++
+		}
+	}
+	for _, ns := range namespaces {
+		p.Namespaces = append(p.Namespaces, ns.Name)
+	}
+	sort.Strings(p.Namespaces)
+	if len(p.Namespaces) > previewNamespaceSample {
+		p.Namespaces = p.Namespaces[:previewNamespaceSample]
+	}
+
+	// A plain kubernetes detection only means nothing gave the platform away
+	if detected != "kubernetes" && detected != clusterType {
+		p.Warnings = append(p.Warnings, fmt.Sprintf("The cluster looks like %s, not %s", detected, clusterType))
+	}
+	if len(registered) > 0 {
+		p.Warnings = append(p.Warnings, fmt.Sprintf("The API server is already registered as %s", strings.Join(registered, ", ")))
+	}
+	return p
+}
@@ -0,0 +1,33 @@
+package services
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/kubeatlas/kubeatlas/internal/k8s"
+)
+
+func TestClusterPreview(t *testing.T) {
+	var namespaces []k8s.DiscoveredNamespace
+	for i := 25; i > 0; i-- {
+		namespaces = append(namespaces, k8s.DiscoveredNamespace{Name: fmt.Sprintf("ns-%02d", i)})
+	}
+	nodes := []k8s.DiscoveredNode{{Status: "Ready"}, {Status: "NotReady"}, {Status: "Ready"}}
+
+	p := clusterPreview("kubernetes", "v1.30.2-eks-db838b0", namespaces, nodes, "eks", []string{"prod-eu"})
+	if p.NodeCount != 3 || p.ReadyNodes != 2 || p.NamespaceCount != 25 {
+		t.Errorf("clusterPreview() counts = %d nodes, %d ready, %d namespaces", p.NodeCount, p.ReadyNodes, p.NamespaceCount)
+	}
+	if len(p.Namespaces) != previewNamespaceSample || p.Namespaces[0] != "ns-01" {
+		t.Errorf("Namespaces = %v, want the first %d sorted", p.Namespaces, previewNamespaceSample)
+	}
+	want := []string{"The cluster looks like eks, not kubernetes", "The API server is already registered as prod-eu"}
+	if !reflect.DeepEqual(p.Warnings, want) {
+		t.Errorf("Warnings = %q, want %q", p.Warnings, want)
+	}
+
+	if p := clusterPreview("eks", "v1.31.0", nil, nil, "kubernetes", nil); len(p.Warnings) != 0 || p.Namespaces == nil {
+		t.Errorf("clusterPreview() of a matching cluster = %+v, want no warnings", p)
+	}
+}
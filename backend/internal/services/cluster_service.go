@@ -6,12 +6,16 @@ import (
 	"errors"
 	"net/url"
 	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/kubeatlas/kubeatlas/internal/crypto"
 	"github.com/kubeatlas/kubeatlas/internal/database/repositories"
 	"github.com/kubeatlas/kubeatlas/internal/k8s"
+	"github.com/kubeatlas/kubeatlas/internal/metrics"
 	"github.com/kubeatlas/kubeatlas/internal/models"
+	"github.com/kubeatlas/kubeatlas/internal/telemetry"
+	"go.opentelemetry.io/otel/attribute"
 	"go.uber.org/zap"
 )
 
@@ -22,48 +26,71 @@ var (
 	ErrEncryptionFailed    = errors.New("failed to encrypt sensitive data")
 	ErrInvalidClusterName  = errors.New("invalid cluster name: must be 1-63 characters, alphanumeric with dashes")
 	ErrInvalidAPIServerURL = errors.New("invalid API server URL: must be a valid https URL")
-	ErrInvalidEnvironment  = errors.New("invalid environment: must be production, staging, development, or test")
+	ErrInvalidFallbackURLs = errors.New("invalid fallback API server URLs: at most 5 valid https URLs")
+	ErrInvalidEnvironment  = errors.New("invalid environment")
 	ErrInvalidClusterType  = errors.New("invalid cluster type")
 )
 
-// Cluster name validation constants
+// Cluster validation constants
 const (
 	maxClusterNameLength = 63
+	// maxFallbackURLs bounds the API server URLs tried after the primary one
+	maxFallbackURLs = 5
 )
 
 type ClusterService struct {
 	clusterRepo   *repositories.ClusterRepository
 	namespaceRepo *repositories.NamespaceRepository
+	uow           *repositories.UnitOfWork
 	k8sManager    *k8s.Manager
 	encryptor     *crypto.Encryptor
+	settings      *OrgSettingsService
 	auditSvc      *AuditService
+	notifications *NotificationService
+	webhooks      *WebhookService
+	annotations   *GrafanaService
 	logger        *zap.SugaredLogger
 }
 
 func NewClusterService(
 	clusterRepo *repositories.ClusterRepository,
 	namespaceRepo *repositories.NamespaceRepository,
+	uow *repositories.UnitOfWork,
 	k8sManager *k8s.Manager,
 	encryptor *crypto.Encryptor,
+	settings *OrgSettingsService,
 	auditSvc *AuditService,
+	notifications *NotificationService,
+	webhooks *WebhookService,
 	logger *zap.SugaredLogger,
 ) *ClusterService {
 	return &ClusterService{
 		clusterRepo:   clusterRepo,
 		namespaceRepo: namespaceRepo,
+		uow:           uow,
 		k8sManager:    k8sManager,
 		encryptor:     encryptor,
+		settings:      settings,
 		auditSvc:      auditSvc,
+		notifications: notifications,
+		webhooks:      webhooks,
 		logger:        logger,
 	}
 }
 
+// SetAnnotations annotates cluster syncs and the namespaces removed with a
+// cluster in Grafana
+func (s *ClusterService) SetAnnotations(annotations *GrafanaService) {
+	s.annotations = annotations
+}
+
 // CreateClusterRequest represents cluster creation data
 type CreateClusterRequest struct {
 	Name                string     `json:"name" binding:"required"`
 	DisplayName         string     `json:"display_name"`
 	Description         string     `json:"description"`
 	APIServerURL        string     `json:"api_server_url" binding:"required,url"`
+	FallbackURLs        []string   `json:"fallback_api_server_urls"` // Tried in order when APIServerURL cannot be reached
 	ClusterType         string     `json:"cluster_type" binding:"required"`
 	Environment         string     `json:"environment" binding:"required"`
 	Platform            string     `json:"platform"`
@@ -80,37 +107,85 @@ type CreateClusterRequest struct {
 
 // Create creates a new cluster
 func (s *ClusterService) Create(ctx context.Context, ac AuditContext, req CreateClusterRequest) (*models.Cluster, error) {
+	if err := s.validateCreate(ctx, ac.OrgID, req); err != nil {
+		return nil, err
+	}
+	cluster, err := s.newCluster(ctx, ac.OrgID, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.clusterRepo.Create(ctx, cluster); err != nil {
+		return nil, err
+	}
+
+	// Test connection and update status
+	go func() {
+		testCtx := context.Background()
+		client, err := s.k8sManager.GetClient(cluster)
+		if err != nil {
+			s.clusterRepo.UpdateSyncStatus(testCtx, cluster.ID, "error", err.Error(), 0, 0)
+			s.recordSyncError(testCtx, cluster, models.SyncErrorCategoryConfig, err)
+			return
+		}
+
+		if err := client.TestConnection(testCtx); err != nil {
+			s.clusterRepo.UpdateSyncStatus(testCtx, cluster.ID, "error", err.Error(), 0, 0)
+			s.recordSyncError(testCtx, cluster, k8s.ClassifyError(err), err)
+			return
+		}
+
+		s.clusterRepo.UpdateSyncStatus(testCtx, cluster.ID, "active", "", 0, 0)
+	}()
+
+	s.auditSvc.LogCreate(ctx, ac, "cluster", cluster.ID, cluster.Name, StructToMap(cluster))
+	s.logger.Infow("Cluster created", "cluster_id", cluster.ID, "name", cluster.Name)
+	s.webhooks.Publish(ctx, cluster.OrganizationID, models.WebhookEventClusterCreated, cluster)
+
+	return cluster, nil
+}
+
+// validateCreate checks a cluster can be created in the organization as requested
+func (s *ClusterService) validateCreate(ctx context.Context, orgID uuid.UUID, req CreateClusterRequest) error {
 	// Validate cluster name (Kubernetes naming convention)
 	if !isValidKubernetesName(req.Name) {
-		return nil, ErrInvalidClusterName
+		return ErrInvalidClusterName
 	}
 
 	// Validate API Server URL
 	if !isValidAPIServerURL(req.APIServerURL) {
-		return nil, ErrInvalidAPIServerURL
+		return ErrInvalidAPIServerURL
+	}
+	if !validFallbackURLs(req.FallbackURLs) {
+		return ErrInvalidFallbackURLs
 	}
 
 	// Validate environment
-	validEnvs := map[string]bool{"production": true, "staging": true, "development": true, "test": true}
-	if !validEnvs[req.Environment] {
-		return nil, ErrInvalidEnvironment
+	if err := s.settings.ValidateEnvironment(ctx, orgID, req.Environment); err != nil {
+		return err
 	}
 
 	// Validate cluster type
 	validTypes := map[string]bool{"kubernetes": true, "openshift": true, "rke2": true, "eks": true, "aks": true, "gke": true}
 	if !validTypes[req.ClusterType] {
-		return nil, ErrInvalidClusterType
+		return ErrInvalidClusterType
 	}
 
 	// Check if name already exists
-	existing, err := s.clusterRepo.GetByName(ctx, ac.OrgID, req.Name)
+	existing, err := s.clusterRepo.GetByName(ctx, orgID, req.Name)
 	if err != nil {
-		return nil, err
+		return err
 	}
 	if existing != nil {
-		return nil, ErrClusterNameExists
+		return ErrClusterNameExists
 	}
 
+	return nil
+}
+
+// newCluster builds the cluster of a create request with its credentials
+// encrypted, without storing it
+func (s *ClusterService) newCluster(ctx context.Context, orgID uuid.UUID, req CreateClusterRequest) (*models.Cluster, error) {
 	// Ensure tags is not nil (StringArray requires non-nil for proper encoding)
 	tags := req.Tags
 	if tags == nil {
@@ -118,7 +193,7 @@ func (s *ClusterService) Create(ctx context.Context, ac AuditContext, req Create
 	}
 
 	cluster := &models.Cluster{
-		OrganizationID:    ac.OrgID,
+		OrganizationID:    orgID,
 		Name:              req.Name,
 		APIServerURL:      req.APIServerURL,
 		ClusterType:       req.ClusterType,
@@ -134,6 +209,9 @@ func (s *ClusterService) Create(ctx context.Context, ac AuditContext, req Create
 		Metadata:          make(models.JSONMap),
 	}
 
+	if req.FallbackURLs != nil {
+		cluster.FallbackAPIServerURLs = req.FallbackURLs
+	}
 	if req.DisplayName != "" {
 		cluster.DisplayName = models.NewNullStringFromString(req.DisplayName)
 	}
@@ -159,6 +237,7 @@ func (s *ClusterService) Create(ctx context.Context, ac AuditContext, req Create
 		encrypted, err := s.encryptor.Encrypt(kubeconfigBytes)
 		if err != nil {
 			s.logger.Errorw("Failed to encrypt kubeconfig", "error", err)
+			telemetry.CaptureError(ctx, err)
 			return nil, ErrEncryptionFailed
 		}
 		cluster.KubeconfigEncrypted = encrypted
@@ -169,6 +248,7 @@ func (s *ClusterService) Create(ctx context.Context, ac AuditContext, req Create
 		encrypted, err := s.encryptor.EncryptToken(req.ServiceAccountToken)
 		if err != nil {
 			s.logger.Errorw("Failed to encrypt service account token", "error", err)
+			telemetry.CaptureError(ctx, err)
 			return nil, ErrEncryptionFailed
 		}
 		cluster.ServiceAccountTokenEncrypted = encrypted
@@ -186,39 +266,49 @@ func (s *ClusterService) Create(ctx context.Context, ac AuditContext, req Create
 		encrypted, err := s.encryptor.Encrypt(caCertBytes)
 		if err != nil {
 			s.logger.Errorw("Failed to encrypt CA certificate", "error", err)
+			telemetry.CaptureError(ctx, err)
 			return nil, ErrEncryptionFailed
 		}
 		cluster.CACertificateEncrypted = encrypted
 	}
 
-	if err := s.clusterRepo.Create(ctx, cluster); err != nil {
-		return nil, err
+	return cluster, nil
+}
+
+// CheckConnectivity verifies the API server of a cluster is reachable and
+// records the URL it was reached at. Failures count towards the cluster's
+// sync failure alerts.
+func (s *ClusterService) CheckConnectivity(ctx context.Context, id uuid.UUID) error {
+	cluster, err := s.clusterRepo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if cluster == nil {
+		return ErrClusterNotFound
 	}
 
-	// Test connection and update status
-	go func() {
-		testCtx := context.Background()
-		client, err := s.k8sManager.GetClient(cluster)
-		if err != nil {
-			s.clusterRepo.UpdateSyncStatus(testCtx, cluster.ID, "error", err.Error(), 0, 0)
-			return
-		}
+	client, err := s.k8sManager.GetClient(cluster)
+	if err == nil {
+		err = client.TestConnection(ctx)
+	}
+	if err != nil {
+		s.alertSyncFailure(ctx, cluster, k8s.ClassifyError(err), err)
+		return err
+	}
 
-		if err := client.TestConnection(testCtx); err != nil {
-			s.clusterRepo.UpdateSyncStatus(testCtx, cluster.ID, "error", err.Error(), 0, 0)
-			return
+	if err := s.clusterRepo.SetActiveAPIServerURL(ctx, id, client.Endpoint()); err != nil {
+		s.logger.Warnw("Failed to record cluster API server URL", "cluster_id", id, "error", err)
+	}
+	if cluster.ConsecutiveFailures > 0 {
+		if err := s.clusterRepo.ResetProbeFailures(ctx, id); err != nil {
+			s.logger.Warnw("Failed to reset cluster probe failures", "cluster_id", id, "error", err)
 		}
-
-		s.clusterRepo.UpdateSyncStatus(testCtx, cluster.ID, "active", "", 0, 0)
-	}()
-
-	s.auditSvc.LogCreate(ctx, ac, "cluster", cluster.ID, cluster.Name, StructToMap(cluster))
-	s.logger.Infow("Cluster created", "cluster_id", cluster.ID, "name", cluster.Name)
-
-	return cluster, nil
+	}
+	return nil
 }
 
-// GetByID retrieves a cluster by ID
+// GetByID retrieves a cluster by ID, with its version support and
+// maintenance status
 func (s *ClusterService) GetByID(ctx context.Context, id uuid.UUID) (*models.Cluster, error) {
 	cluster, err := s.clusterRepo.GetByID(ctx, id)
 	if err != nil {
@@ -227,12 +317,80 @@ func (s *ClusterService) GetByID(ctx context.Context, id uuid.UUID) (*models.Clu
 	if cluster == nil {
 		return nil, ErrClusterNotFound
 	}
+	clusters := []models.Cluster{*cluster}
+	if err := s.annotateVersionSupport(ctx, cluster.OrganizationID, clusters); err != nil {
+		return nil, err
+	}
+	clusters[0].Maintenance = s.clusterMaintenance(ctx, id, time.Now())
+	return &clusters[0], nil
+}
+
+// GetByName retrieves a cluster of an organization by name
+func (s *ClusterService) GetByName(ctx context.Context, orgID uuid.UUID, name string) (*models.Cluster, error) {
+	cluster, err := s.clusterRepo.GetByName(ctx, orgID, name)
+	if err != nil {
+		return nil, err
+	}
+	if cluster == nil {
+		return nil, ErrClusterNotFound
+	}
 	return cluster, nil
 }
 
-// List retrieves clusters with pagination
+// UpsertByName creates the cluster named name, or updates it if it exists,
+// so that clusters can be managed declaratively by name. Updates leave empty
+// fields unchanged, and credentials are only set when the cluster is
+// created. created reports whether the cluster was created.
+func (s *ClusterService) UpsertByName(ctx context.Context, ac AuditContext, name string, req CreateClusterRequest) (cluster *models.Cluster, created bool, err error) {
+	req.Name = name
+	existing, err := s.clusterRepo.GetByName(ctx, ac.OrgID, name)
+	if err != nil {
+		return nil, false, err
+	}
+	if existing == nil {
+		cluster, err = s.Create(ctx, ac, req)
+		return cluster, err == nil, err
+	}
+
+	if !isValidAPIServerURL(req.APIServerURL) {
+		return nil, false, ErrInvalidAPIServerURL
+	}
+	validTypes := map[string]bool{"kubernetes": true, "openshift": true, "rke2": true, "eks": true, "aks": true, "gke": true}
+	if !validTypes[req.ClusterType] {
+		return nil, false, ErrInvalidClusterType
+	}
+
+	cluster, err = s.Update(ctx, ac, existing.ID, UpdateClusterRequest{
+		DisplayName:       req.DisplayName,
+		Description:       req.Description,
+		APIServerURL:      req.APIServerURL,
+		FallbackURLs:      req.FallbackURLs,
+		ClusterType:       req.ClusterType,
+		Environment:       req.Environment,
+		Platform:          req.Platform,
+		Region:            req.Region,
+		SkipTLSVerify:     &req.SkipTLSVerify,
+		OwnerTeamID:       req.OwnerTeamID,
+		ResponsibleUserID: req.ResponsibleUserID,
+		Tags:              req.Tags,
+	})
+	return cluster, false, err
+}
+
+// List retrieves clusters with pagination and their version support. The
+// version_support filter takes a models.VersionSupport* status.
 func (s *ClusterService) List(ctx context.Context, orgID uuid.UUID, p repositories.Pagination, filters map[string]interface{}) (*repositories.PaginatedResult[models.Cluster], error) {
-	return s.clusterRepo.List(ctx, orgID, p, filters)
+	if err := s.versionFilters(ctx, orgID, filters); err != nil {
+		return nil, err
+	}
+	result, err := s.clusterRepo.List(ctx, orgID, p, filters)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.annotateVersionSupport(ctx, orgID, result.Items); err != nil {
+		return nil, err
+	}
+	return result, nil
 }
 
 // UpdateClusterRequest represents cluster update data
@@ -240,6 +398,7 @@ type UpdateClusterRequest struct {
 	DisplayName       string     `json:"display_name"`
 	Description       string     `json:"description"`
 	APIServerURL      string     `json:"api_server_url"`
+	FallbackURLs      []string   `json:"fallback_api_server_urls"` // Replaced unless nil; empty removes them
 	ClusterType       string     `json:"cluster_type"`
 	Environment       string     `json:"environment"`
 	Platform          string     `json:"platform"`
@@ -261,6 +420,16 @@ func (s *ClusterService) Update(ctx context.Context, ac AuditContext, id uuid.UU
 		return nil, ErrClusterNotFound
 	}
 
+	// Environments that are no longer configured are kept until changed
+	if req.Environment != "" && req.Environment != cluster.Environment {
+		if err := s.settings.ValidateEnvironment(ctx, ac.OrgID, req.Environment); err != nil {
+			return nil, err
+		}
+	}
+	if req.FallbackURLs != nil && !validFallbackURLs(req.FallbackURLs) {
+		return nil, ErrInvalidFallbackURLs
+	}
+
 	oldValues := StructToMap(cluster)
 
 	// Apply updates
@@ -273,6 +442,9 @@ func (s *ClusterService) Update(ctx context.Context, ac AuditContext, id uuid.UU
 	if req.APIServerURL != "" {
 		cluster.APIServerURL = req.APIServerURL
 	}
+	if req.FallbackURLs != nil {
+		cluster.FallbackAPIServerURLs = req.FallbackURLs
+	}
 	if req.ClusterType != "" {
 		cluster.ClusterType = req.ClusterType
 	}
@@ -305,13 +477,20 @@ func (s *ClusterService) Update(ctx context.Context, ac AuditContext, id uuid.UU
 		return nil, err
 	}
 
-	s.auditSvc.LogUpdate(ctx, ac, "cluster", cluster.ID, cluster.Name, oldValues, StructToMap(cluster))
+	// Drop the cached client so connection changes apply immediately
+	s.k8sManager.RemoveClient(cluster.ID.String())
+
+	newValues := StructToMap(cluster)
+	s.auditSvc.LogUpdate(ctx, ac, "cluster", cluster.ID, cluster.Name, oldValues, newValues)
+	s.notifications.NotifyClusterOwnershipChanged(ctx, cluster, oldValues, newValues, ac.UserEmail)
 	s.logger.Infow("Cluster updated", "cluster_id", cluster.ID)
+	s.webhooks.Publish(ctx, cluster.OrganizationID, models.WebhookEventClusterUpdated, cluster)
 
 	return cluster, nil
 }
 
-// Delete deletes a cluster
+// Delete soft-deletes a cluster with its namespaces, the dependencies from,
+// to and of them, and the documents of the cluster and its namespaces
 func (s *ClusterService) Delete(ctx context.Context, ac AuditContext, id uuid.UUID) error {
 	cluster, err := s.clusterRepo.GetByID(ctx, id)
 	if err != nil {
@@ -321,18 +500,51 @@ func (s *ClusterService) Delete(ctx context.Context, ac AuditContext, id uuid.UU
 		return ErrClusterNotFound
 	}
 
-	if err := s.clusterRepo.Delete(ctx, id); err != nil {
+	// Remove the cluster, its namespaces and what is attached to them
+	// atomically, so the trash restores them together
+	var deleted *models.ClusterDeletion
+	err = s.uow.Do(ctx, func(tx *repositories.TxRepositories) error {
+		d, err := tx.Cluster.DeleteAttached(ctx, id)
+		if err != nil {
+			return err
+		}
+		if d.Namespaces, err = tx.Namespace.DeleteByCluster(ctx, id); err != nil {
+			return err
+		}
+		deleted = d
+		return tx.Cluster.Delete(ctx, id)
+	})
+	if err != nil {
 		return err
 	}
 
+	s.k8sManager.RemoveClient(id.String())
+	metrics.ForgetCluster(cluster.Name)
+
 	s.auditSvc.LogDelete(ctx, ac, "cluster", id, cluster.Name)
-	s.logger.Infow("Cluster deleted", "cluster_id", id)
+	s.logger.Infow("Cluster deleted", "cluster_id", id, "namespaces", deleted.Namespaces,
+		"internal_dependencies", deleted.InternalDependencies, "external_dependencies", deleted.ExternalDependencies,
+		"documents", deleted.Documents)
+	s.webhooks.Publish(ctx, cluster.OrganizationID, models.WebhookEventClusterDeleted, cluster)
+	s.annotations.NamespacesDecommissioned(ctx, cluster, deleted.Namespaces)
 
 	return nil
 }
 
+// DeletionPreview counts what deleting a cluster of the organization would
+// soft-delete with it, for users to confirm before deleting it
+func (s *ClusterService) DeletionPreview(ctx context.Context, orgID, id uuid.UUID) (*models.ClusterDeletion, error) {
+	if err := s.checkCluster(ctx, orgID, id); err != nil {
+		return nil, err
+	}
+	return s.clusterRepo.DeletionPreview(ctx, id)
+}
+
 // Sync syncs cluster resources from Kubernetes
-func (s *ClusterService) Sync(ctx context.Context, ac AuditContext, id uuid.UUID) error {
+func (s *ClusterService) Sync(ctx context.Context, ac AuditContext, id uuid.UUID) (err error) {
+	ctx, span := telemetry.StartSpan(ctx, "ClusterService.Sync", attribute.String("cluster_id", id.String()))
+	defer func() { telemetry.EndSpan(span, err) }()
+
 	cluster, err := s.clusterRepo.GetByID(ctx, id)
 	if err != nil {
 		return err
@@ -341,73 +553,456 @@ func (s *ClusterService) Sync(ctx context.Context, ac AuditContext, id uuid.UUID
 		return ErrClusterNotFound
 	}
 
+	start := time.Now()
+
+	// The run is recorded however the sync ends
+	run := newSyncRun(cluster.ID, ac, start)
+	defer s.recordSyncRun(ctx, run)
+
 	// Update status to syncing
 	s.clusterRepo.UpdateSyncStatus(ctx, id, "syncing", "", cluster.NodeCount, cluster.NamespaceCount)
 
 	// Get Kubernetes client
 	client, err := s.k8sManager.GetClient(cluster)
 	if err != nil {
-		s.clusterRepo.UpdateSyncStatus(ctx, id, "error", err.Error(), cluster.NodeCount, cluster.NamespaceCount)
+		category := k8s.ClassifyError(err)
+		if category == models.SyncErrorCategoryUnknown {
+			category = models.SyncErrorCategoryConfig
+		}
+		s.failSync(ctx, cluster, run, category, err)
 		return ErrClusterSyncFailed
 	}
 
 	// Discover namespaces
 	namespaces, err := client.DiscoverNamespaces(ctx)
 	if err != nil {
-		s.clusterRepo.UpdateSyncStatus(ctx, id, "error", err.Error(), cluster.NodeCount, cluster.NamespaceCount)
+		s.failSync(ctx, cluster, run, k8s.ClassifyError(err), err)
 		return ErrClusterSyncFailed
 	}
 
-	// Get node count
-	nodeCount, err := client.GetNodeCount(ctx)
+	// Nodes are informational; without them the sync is only partial and
+	// the last ones found are kept
+	discoveredNodes, nodeErr := client.DiscoverNodes(ctx)
+	nodeCount := cluster.NodeCount
+	if nodeErr == nil {
+		nodeCount = len(discoveredNodes)
+	}
+
+	// So is the server version; without it the last one found is kept
+	version, versionErr := client.GetServerVersion(ctx)
+	// And the RBAC inventory
+	access, accessErr := client.DiscoverAccess(ctx)
+	// And the Flux Kustomizations and HelmReleases
+	flux, fluxErr := client.DiscoverFlux(ctx)
+	// And the resource usage reported by metrics-server
+	usage, usageErr := client.DiscoverUsage(ctx)
+	usageAt := time.Now()
+	partialErr := errors.Join(nodeErr, versionErr, accessErr, fluxErr, usageErr)
+
+	// Discovered namespaces start in the least critical tier
+	tiers, err := s.settings.CriticalityTiers(ctx, cluster.OrganizationID)
+	if err != nil {
+		s.failSync(ctx, cluster, run, models.SyncErrorCategoryDatabase, err)
+		return ErrClusterSyncFailed
+	}
+	defaultCriticality := tiers[len(tiers)-1].Name
+	mappings, err := GetSetting(ctx, s.settings, cluster.OrganizationID, MetadataMappingsSetting)
+	if err != nil {
+		s.failSync(ctx, cluster, run, models.SyncErrorCategoryDatabase, err)
+		return ErrClusterSyncFailed
+	}
+	environments, err := GetSetting(ctx, s.settings, cluster.OrganizationID, EnvironmentsSetting)
 	if err != nil {
-		nodeCount = 0
+		s.failSync(ctx, cluster, run, models.SyncErrorCategoryDatabase, err)
+		return ErrClusterSyncFailed
 	}
 
-	// Sync namespaces to database
-	for _, ns := range namespaces {
-		existing, err := s.namespaceRepo.GetByClusterAndName(ctx, cluster.ID, ns.Name)
-		if err != nil {
-			continue
+	// Sync namespaces to database in a single transaction so a failure
+	// part-way through does not leave the inventory half updated
+	var created, archived, reactivated []*models.Namespace
+	var mapped []mappedNamespace
+	var unresolved []error
+	err = s.uow.Do(ctx, func(tx *repositories.TxRepositories) error {
+		created = created[:0]
+		archived = archived[:0]
+		reactivated = reactivated[:0]
+		mapped = mapped[:0]
+		unresolved = unresolved[:0]
+
+		ids := make(map[string]uuid.UUID, len(namespaces))
+		var mapper *namespaceMapper
+		if len(mappings.Rules) > 0 {
+			teams, err := tx.Team.List(ctx, cluster.OrganizationID)
+			if err != nil {
+				return err
+			}
+			units, err := tx.BusinessUnit.List(ctx, cluster.OrganizationID)
+			if err != nil {
+				return err
+			}
+			mapper = newNamespaceMapper(mappings.Rules, teams, units, tiers, environments)
+		}
+
+		for _, ns := range namespaces {
+			existing, err := tx.Namespace.GetByClusterAndName(ctx, cluster.ID, ns.Name)
+			if err != nil {
+				return err
+			}
+
+			if existing == nil {
+				// Create new namespace
+				newNs := &models.Namespace{
+					OrganizationID: cluster.OrganizationID,
+					ClusterID:      cluster.ID,
+					Name:           ns.Name,
+					Status:         models.NamespaceStatusActive,
+					Environment:    "unknown",
+					Criticality:    defaultCriticality,
+					K8sLabels:      ns.Labels,
+					K8sAnnotations: ns.Annotations,
+					Tags:           []string{},
+					CustomFields:   make(models.JSONMap),
+					Metadata:       make(models.JSONMap),
+				}
+				if ns.UID != "" {
+					newNs.K8sUID = models.NewNullStringFromString(ns.UID)
+				}
+				if mapper != nil {
+					_, errs := mapper.apply(newNs, ns.Labels, ns.Annotations, true)
+					unresolved = append(unresolved, errs...)
+				}
+				if err := tx.Namespace.Create(ctx, newNs); err != nil {
+					return err
+				}
+				created = append(created, newNs)
+				ids[newNs.Name] = newNs.ID
+			} else {
+				// Update existing namespace K8s metadata
+				if err := tx.Namespace.UpdateFromK8s(ctx, existing.ID, ns.UID, ns.Labels, ns.Annotations, ns.CreatedAt); err != nil {
+					return err
+				}
+				ids[existing.Name] = existing.ID
+				// A namespace created again in the cluster is active again
+				if existing.Status == models.NamespaceStatusArchived {
+					if _, err := tx.Namespace.SetStatus(ctx, existing.ID, models.NamespaceStatusActive); err != nil {
+						return err
+					}
+					existing.Status = models.NamespaceStatusActive
+					reactivated = append(reactivated, existing)
+				}
+				if mapper == nil {
+					continue
+				}
+				before := StructToMap(existing)
+				fields, errs := mapper.apply(existing, ns.Labels, ns.Annotations, false)
+				unresolved = append(unresolved, errs...)
+				if len(fields) == 0 {
+					continue
+				}
+				if err := tx.Namespace.Update(ctx, existing); err != nil {
+					return err
+				}
+				mapped = append(mapped, mappedNamespace{ns: existing, before: before})
+			}
 		}
 
-		if existing == nil {
-			// Create new namespace
-			newNs := &models.Namespace{
-				OrganizationID: cluster.OrganizationID,
-				ClusterID:      cluster.ID,
-				Name:           ns.Name,
-				Status:         "active",
-				Environment:    "unknown",
-				Criticality:    "tier-3",
-				K8sLabels:      ns.Labels,
-				K8sAnnotations: ns.Annotations,
-				Tags:           []string{},
-				CustomFields:   make(models.JSONMap),
-				Metadata:       make(models.JSONMap),
+		// Namespaces deleted in the cluster are archived. A cluster listing no
+		// namespaces at all is more likely misreported than emptied.
+		if len(namespaces) > 0 {
+			present := make([]string, 0, len(ids))
+			for name := range ids {
+				present = append(present, name)
 			}
-			if ns.UID != "" {
-				newNs.K8sUID = models.NewNullStringFromString(ns.UID)
+			gone, err := tx.Namespace.ArchiveMissing(ctx, cluster.ID, present)
+			if err != nil {
+				return err
+			}
+			for i := range gone {
+				archived = append(archived, &gone[i])
+			}
+		}
+
+		if access != nil {
+			if err := replaceAccess(ctx, tx, cluster.ID, ids, access); err != nil {
+				return err
 			}
-			s.namespaceRepo.Create(ctx, newNs)
-		} else {
-			// Update existing namespace K8s metadata
-			s.namespaceRepo.UpdateFromK8s(ctx, existing.ID, ns.UID, ns.Labels, ns.Annotations, ns.CreatedAt)
 		}
+		if flux != nil {
+			if err := replaceFluxResources(ctx, tx, cluster.ID, ids, flux); err != nil {
+				return err
+			}
+		}
+		if nodeErr == nil {
+			if err := tx.Cluster.ReplaceNodes(ctx, cluster.OrganizationID, cluster.ID, clusterNodes(discoveredNodes)); err != nil {
+				return err
+			}
+			if err := tx.Cluster.RecordCapacitySnapshot(ctx, cluster.ID, capacitySnapshotKeep); err != nil {
+				return err
+			}
+			if err := tx.Cluster.SetSyncLabels(ctx, cluster.ID, syncLabelKeys(), clusterSyncLabels(discoveredNodes)); err != nil {
+				return err
+			}
+		}
+		if versionErr == nil {
+			if err := tx.Cluster.SetVersion(ctx, cluster.ID, version); err != nil {
+				return err
+			}
+		}
+		if err := tx.Cluster.SetActiveAPIServerURL(ctx, cluster.ID, client.Endpoint()); err != nil {
+			return err
+		}
+		if usage != nil {
+			if err := tx.Namespace.RecordUsage(ctx, cluster.ID, usageSamples(ids, usage, usageAt), namespaceUsageKeep); err != nil {
+				return err
+			}
+		}
+
+		// Update sync status
+		syncError := ""
+		if partialErr != nil {
+			syncError = partialErr.Error()
+		}
+		return tx.Cluster.UpdateSyncStatus(ctx, id, "active", syncError, nodeCount, len(namespaces))
+	})
+	if err != nil {
+		s.logger.Errorw("Cluster sync rolled back", "cluster_id", id, "error", err)
+		telemetry.CaptureError(ctx, err)
+		s.failSync(ctx, cluster, run, models.SyncErrorCategoryDatabase, err)
+		return ErrClusterSyncFailed
 	}
 
-	// Update sync status
-	s.clusterRepo.UpdateSyncStatus(ctx, id, "active", "", nodeCount, len(namespaces))
+	completeSyncRun(run, len(namespaces), nodeCount, created, archived, reactivated, len(mapped), partialErr)
+	if partialErr != nil {
+		s.recordSyncError(ctx, cluster, models.SyncErrorCategoryPartial, partialErr)
+		metrics.ObserveClusterSync(cluster.Name, models.SyncErrorCategoryPartial, time.Since(start))
+	} else {
+		metrics.ObserveClusterSync(cluster.Name, metrics.SyncOutcomeSuccess, time.Since(start))
+	}
 
 	s.auditSvc.LogAction(ctx, ac, "sync", "cluster", id, cluster.Name, "Cluster synced successfully")
-	s.logger.Infow("Cluster synced", "cluster_id", id, "namespaces", len(namespaces), "nodes", nodeCount)
+	s.logger.Infow("Cluster synced", "cluster_id", id, "namespaces", len(namespaces), "nodes", nodeCount, "mapped", len(mapped), "archived", len(archived), "reactivated", len(reactivated))
+	if len(unresolved) > 0 {
+		s.logger.Warnw("Metadata mapping values not found", "cluster_id", id, "count", len(unresolved), "first", unresolved[0].Error())
+	}
+
+	// Webhooks are published only once the transaction has committed
+	for _, ns := range created {
+		s.webhooks.Publish(ctx, ns.OrganizationID, models.WebhookEventNamespaceCreated, ns)
+	}
+	for _, ns := range archived {
+		s.auditSvc.LogAction(ctx, ac, "archive", "namespace", ns.ID, ns.Name, "Namespace archived: deleted in cluster "+cluster.Name)
+		s.webhooks.Publish(ctx, ns.OrganizationID, models.WebhookEventNamespaceUpdated, ns)
+	}
+	for _, ns := range reactivated {
+		s.auditSvc.LogAction(ctx, ac, "unarchive", "namespace", ns.ID, ns.Name, "Namespace unarchived: found again in cluster "+cluster.Name)
+		s.webhooks.Publish(ctx, ns.OrganizationID, models.WebhookEventNamespaceUpdated, ns)
+	}
+	for _, m := range mapped {
+		s.auditSvc.LogUpdate(ctx, ac, "namespace", m.ns.ID, m.ns.Name, m.before, StructToMap(m.ns))
+		s.webhooks.Publish(ctx, m.ns.OrganizationID, models.WebhookEventNamespaceUpdated, m.ns)
+	}
+	s.webhooks.Publish(ctx, cluster.OrganizationID, models.WebhookEventClusterSynced, map[string]interface{}{
+		"cluster_id":          cluster.ID,
+		"cluster_name":        cluster.Name,
+		"namespace_count":     len(namespaces),
+		"namespaces_created":  len(created),
+		"namespaces_archived": len(archived),
+		"node_count":          nodeCount,
+		"duration_ms":         time.Since(start).Milliseconds(),
+	})
+	s.annotations.ClusterSynced(ctx, cluster, len(namespaces), len(created))
 
 	return nil
 }
 
-// GetStats returns cluster statistics
+// replaceAccess stores the RBAC inventory found for the namespaces of a
+// cluster. ids are the IDs of the cluster's namespaces by name.
+func replaceAccess(ctx context.Context, tx *repositories.TxRepositories, clusterID uuid.UUID, ids map[string]uuid.UUID, access *k8s.DiscoveredAccess) error {
+	var bindings []models.NamespaceRoleBinding
+	var accounts []models.NamespaceServiceAccount
+	for name, found := range access.Bindings {
+		id, ok := ids[name]
+		if !ok {
+			continue
+		}
+		for _, b := range found {
+			binding := models.NamespaceRoleBinding{
+				NamespaceID: id,
+				BindingKind: b.BindingKind,
+				BindingName: b.BindingName,
+				RoleKind:    b.RoleKind,
+				RoleName:    b.RoleName,
+				SubjectKind: b.SubjectKind,
+				SubjectName: b.SubjectName,
+			}
+			if b.SubjectNamespace != "" {
+				binding.SubjectNamespace = models.NewNullStringFromString(b.SubjectNamespace)
+			}
+			bindings = append(bindings, binding)
+		}
+	}
+	for name, found := range access.ServiceAccounts {
+		id, ok := ids[name]
+		if !ok {
+			continue
+		}
+		for _, sa := range found {
+			account := models.NamespaceServiceAccount{NamespaceID: id, Name: sa.Name}
+			if sa.UID != "" {
+				account.K8sUID = models.NewNullStringFromString(sa.UID)
+			}
+			if !sa.CreatedAt.IsZero() {
+				account.K8sCreatedAt = models.NullTime{Time: sa.CreatedAt, Valid: true}
+			}
+			accounts = append(accounts, account)
+		}
+	}
+	return tx.Namespace.ReplaceAccess(ctx, clusterID, bindings, accounts)
+}
+
+// replaceFluxResources stores the Flux Kustomizations and HelmReleases found
+// for the namespaces of a cluster. ids are the IDs of the cluster's
+// namespaces by name.
+func replaceFluxResources(ctx context.Context, tx *repositories.TxRepositories, clusterID uuid.UUID, ids map[string]uuid.UUID, flux map[string][]k8s.DiscoveredFluxResource) error {
+	var resources []models.FluxResource
+	for name, found := range flux {
+		id, ok := ids[name]
+		if !ok {
+			continue
+		}
+		for _, f := range found {
+			resource := models.FluxResource{
+				NamespaceID:     id,
+				Kind:            f.Kind,
+				Name:            f.Name,
+				SourceKind:      models.NewNullStringFromString(f.SourceKind),
+				SourceName:      models.NewNullStringFromString(f.SourceName),
+				SourceNamespace: models.NewNullStringFromString(f.SourceNamespace),
+				Path:            models.NewNullStringFromString(f.Path),
+				Revision:        models.NewNullStringFromString(f.Revision),
+				Ready:           f.Ready,
+				ReadyReason:     models.NewNullStringFromString(f.ReadyReason),
+				ReadyMessage:    models.NewNullStringFromString(f.ReadyMessage),
+				Suspended:       f.Suspended,
+			}
+			if !f.ReadyChangedAt.IsZero() {
+				resource.ReadyChangedAt = models.NullTime{Time: f.ReadyChangedAt, Valid: true}
+			}
+			resources = append(resources, resource)
+		}
+	}
+	return tx.Namespace.ReplaceFluxResources(ctx, clusterID, resources)
+}
+
+// mappedNamespace is a namespace whose metadata mapping rules changed it
+type mappedNamespace struct {
+	ns     *models.Namespace
+	before map[string]interface{}
+}
+
+// failSync marks the cluster and its sync run as errored, records the
+// categorized failure and alerts the cluster's owners
+func (s *ClusterService) failSync(ctx context.Context, cluster *models.Cluster, run *models.ClusterSyncRun, category string, cause error) {
+	s.clusterRepo.UpdateSyncStatus(ctx, cluster.ID, "error", cause.Error(), cluster.NodeCount, cluster.NamespaceCount)
+	failSyncRun(run, category, cause)
+	s.recordSyncError(ctx, cluster, category, cause)
+	metrics.ObserveClusterSync(cluster.Name, category, time.Since(run.StartedAt))
+	s.alertSyncFailure(ctx, cluster, category, cause)
+	s.webhooks.Publish(ctx, cluster.OrganizationID, models.WebhookEventClusterSyncFailed, map[string]interface{}{
+		"cluster_id":   cluster.ID,
+		"cluster_name": cluster.Name,
+		"category":     category,
+		"error":        cause.Error(),
+	})
+}
+
+// alertSyncFailure counts a failed sync or probe and notifies the cluster's
+// owners once the organization's failure threshold is reached. Further
+// alerts in the same failure streak are sent or suppressed according to the
+// organization's sync alert settings. No alert is sent while the cluster is
+// in one of its maintenance windows.
+func (s *ClusterService) alertSyncFailure(ctx context.Context, cluster *models.Cluster, category string, cause error) {
+	failures, err := s.clusterRepo.IncrementFailures(ctx, cluster.ID)
+	if err != nil {
+		s.logger.Errorw("Failed to count cluster failure", "cluster_id", cluster.ID, "error", err)
+		return
+	}
+	if s.notifications == nil {
+		return
+	}
+	if m := s.clusterMaintenance(ctx, cluster.ID, time.Now()); m != nil && m.Active {
+		s.logger.Infow("Sync alert suppressed during maintenance", "cluster_id", cluster.ID, "window", m.Window, "failures", failures)
+		return
+	}
+
+	settings, err := s.notifications.GetSyncAlertSettings(ctx, cluster.OrganizationID)
+	if err != nil {
+		s.logger.Errorw("Failed to load sync alert settings", "organization_id", cluster.OrganizationID, "error", err)
+		return
+	}
+	if failures < settings.FailureThreshold {
+		return
+	}
+
+	alert, err := s.clusterRepo.ClaimSyncAlert(ctx, cluster.ID, settings.SuppressRepeats, settings.RepeatInterval())
+	if err != nil {
+		s.logger.Errorw("Failed to claim sync alert", "cluster_id", cluster.ID, "error", err)
+		return
+	}
+	if !alert {
+		s.logger.Debugw("Sync alert suppressed", "cluster_id", cluster.ID, "failures", failures)
+		return
+	}
+
+	s.notifications.NotifySyncFailed(ctx, cluster, category, cause, failures)
+}
+
+// recordSyncError stores a sync error in the cluster's history
+func (s *ClusterService) recordSyncError(ctx context.Context, cluster *models.Cluster, category string, cause error) {
+	syncErr := &models.ClusterSyncError{
+		ClusterID: cluster.ID,
+		Category:  category,
+		Message:   cause.Error(),
+	}
+	if err := s.clusterRepo.RecordSyncError(ctx, syncErr); err != nil {
+		s.logger.Errorw("Failed to record cluster sync error", "cluster_id", cluster.ID, "error", err)
+		telemetry.CaptureError(ctx, err)
+	}
+	s.logger.Warnw("Cluster sync failed", "cluster_id", cluster.ID, "category", category, "error", cause)
+}
+
+// ListSyncErrors returns the sync error history of a cluster in the organization
+func (s *ClusterService) ListSyncErrors(ctx context.Context, orgID, clusterID uuid.UUID, p repositories.Pagination, filters map[string]interface{}) (*repositories.PaginatedResult[models.ClusterSyncError], error) {
+	cluster, err := s.clusterRepo.GetByID(ctx, clusterID)
+	if err != nil {
+		return nil, err
+	}
+	if cluster == nil || cluster.OrganizationID != orgID {
+		return nil, ErrClusterNotFound
+	}
+
+	return s.clusterRepo.ListSyncErrors(ctx, clusterID, p, filters)
+}
+
+// GetSyncErrorSummary returns how many clusters are currently failing in each category
+func (s *ClusterService) GetSyncErrorSummary(ctx context.Context, orgID uuid.UUID) (map[string]int64, error) {
+	return s.clusterRepo.CountSyncErrorsByCategory(ctx, orgID)
+}
+
+// GetStats returns cluster statistics, including failing clusters by sync error category
 func (s *ClusterService) GetStats(ctx context.Context, orgID uuid.UUID) (map[string]interface{}, error) {
-	return s.clusterRepo.GetStats(ctx, orgID)
+	stats, err := s.clusterRepo.GetStats(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+
+	syncErrors, err := s.GetSyncErrorSummary(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	stats["sync_errors"] = syncErrors
+
+	return stats, nil
 }
 
 // GetNamespaces returns namespaces for a cluster
@@ -453,6 +1048,19 @@ func isValidKubernetesName(name string) bool {
 	return true
 }
 
+// validFallbackURLs reports whether urls are valid fallback API server URLs
+func validFallbackURLs(urls []string) bool {
+	if len(urls) > maxFallbackURLs {
+		return false
+	}
+	for _, u := range urls {
+		if !isValidAPIServerURL(u) {
+			return false
+		}
+	}
+	return true
+}
+
 // isValidAPIServerURL validates Kubernetes API server URL
 func isValidAPIServerURL(rawURL string) bool {
 	if rawURL == "" {
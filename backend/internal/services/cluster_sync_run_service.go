@@ -0,0 +1,84 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kubeatlas/kubeatlas/internal/database/repositories"
+	"github.com/kubeatlas/kubeatlas/internal/models"
+	"github.com/kubeatlas/kubeatlas/internal/telemetry"
+)
+
+// syncRunKeep is how long the sync runs of a cluster are kept
+const syncRunKeep = 90 * 24 * time.Hour
+
+// newSyncRun starts the run of a cluster sync. Syncs started by a user are
+// manual, the others are the system's.
+func newSyncRun(clusterID uuid.UUID, ac AuditContext, start time.Time) *models.ClusterSyncRun {
+	run := &models.ClusterSyncRun{
+		ClusterID: clusterID,
+		Trigger:   models.SyncTriggerSystem,
+		StartedAt: start,
+		// A sync that ends before it completes has failed
+		Status: models.SyncRunStatusFailed,
+	}
+	if ac.UserID != nil {
+		run.Trigger = models.SyncTriggerManual
+		run.TriggeredBy = ac.UserID
+	}
+	return run
+}
+
+// completeSyncRun records what a sync changed in the inventory. partialErr
+// is what the sync could not discover, if anything.
+func completeSyncRun(run *models.ClusterSyncRun, namespaceCount, nodeCount int, created, archived, reactivated []*models.Namespace, updated int, partialErr error) {
+	run.Status = models.SyncRunStatusSuccess
+	run.NamespaceCount = namespaceCount
+	run.NodeCount = nodeCount
+	run.NamespacesCreated = len(created)
+	run.NamespacesUpdated = updated
+	run.NamespacesArchived = len(archived)
+	run.NamespacesReactivated = len(reactivated)
+	run.ArchivedNamespaces = make(models.StringArray, 0, len(archived))
+	for _, ns := range archived {
+		run.ArchivedNamespaces = append(run.ArchivedNamespaces, ns.Name)
+	}
+	if partialErr != nil {
+		run.Status = models.SyncRunStatusPartial
+		run.ErrorCategory = models.NewNullStringFromString(models.SyncErrorCategoryPartial)
+		run.Error = models.NewNullStringFromString(partialErr.Error())
+	}
+}
+
+// failSyncRun records why a sync failed
+func failSyncRun(run *models.ClusterSyncRun, category string, cause error) {
+	run.Status = models.SyncRunStatusFailed
+	run.ErrorCategory = models.NewNullStringFromString(category)
+	run.Error = models.NewNullStringFromString(cause.Error())
+}
+
+// recordSyncRun stores a finished sync run. Failing to store it does not
+// fail the sync.
+func (s *ClusterService) recordSyncRun(ctx context.Context, run *models.ClusterSyncRun) {
+	run.FinishedAt = time.Now()
+	run.DurationMs = run.FinishedAt.Sub(run.StartedAt).Milliseconds()
+	if err := s.clusterRepo.RecordSyncRun(ctx, run, syncRunKeep); err != nil {
+		s.logger.Errorw("Failed to record cluster sync run", "cluster_id", run.ClusterID, "error", err)
+		telemetry.CaptureError(ctx, err)
+	}
+}
+
+// ListSyncRuns returns the sync runs of a cluster in the organization, newest
+// first
+func (s *ClusterService) ListSyncRuns(ctx context.Context, orgID, clusterID uuid.UUID, p repositories.Pagination, filters map[string]interface{}) (*repositories.PaginatedResult[models.ClusterSyncRun], error) {
+	cluster, err := s.clusterRepo.GetByID(ctx, clusterID)
+	if err != nil {
+		return nil, err
+	}
+	if cluster == nil || cluster.OrganizationID != orgID {
+		return nil, ErrClusterNotFound
+	}
+
+	return s.clusterRepo.ListSyncRuns(ctx, clusterID, p, filters)
+}
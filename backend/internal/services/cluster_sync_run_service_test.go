@@ -0,0 +1,44 @@
+package services
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kubeatlas/kubeatlas/internal/models"
+)
+
+func TestSyncRun(t *testing.T) {
+	clusterID, userID := uuid.New(), uuid.New()
+	start := time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)
+
+	run := newSyncRun(clusterID, AuditContext{UserID: &userID}, start)
+	if run.Trigger != models.SyncTriggerManual || run.TriggeredBy == nil || *run.TriggeredBy != userID || run.Status != models.SyncRunStatusFailed {
+		t.Errorf("newSyncRun() by a user = %+v", run)
+	}
+	if run := newSyncRun(clusterID, AuditContext{}, start); run.Trigger != models.SyncTriggerSystem || run.TriggeredBy != nil {
+		t.Errorf("newSyncRun() by the system = %+v", run)
+	}
+
+	archived := []*models.Namespace{{Name: "payments-old"}, {Name: "legacy"}}
+	completeSyncRun(run, 40, 6, []*models.Namespace{{Name: "new"}}, archived, nil, 3, nil)
+	if run.Status != models.SyncRunStatusSuccess || run.NamespaceCount != 40 || run.NodeCount != 6 ||
+		run.NamespacesCreated != 1 || run.NamespacesUpdated != 3 || run.NamespacesArchived != 2 || run.NamespacesReactivated != 0 {
+		t.Errorf("completeSyncRun() = %+v", run)
+	}
+	if !reflect.DeepEqual([]string(run.ArchivedNamespaces), []string{"payments-old", "legacy"}) {
+		t.Errorf("ArchivedNamespaces = %v", run.ArchivedNamespaces)
+	}
+
+	completeSyncRun(run, 40, 6, nil, nil, nil, 0, errors.New("failed to list nodes"))
+	if run.Status != models.SyncRunStatusPartial || run.ErrorCategory.String != models.SyncErrorCategoryPartial || run.Error.String != "failed to list nodes" {
+		t.Errorf("completeSyncRun() with a partial error = %+v", run)
+	}
+
+	failSyncRun(run, models.SyncErrorCategoryAuth, errors.New("Unauthorized"))
+	if run.Status != models.SyncRunStatusFailed || run.ErrorCategory.String != models.SyncErrorCategoryAuth {
+		t.Errorf("failSyncRun() = %+v", run)
+	}
+}
@@ -0,0 +1,490 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kubeatlas/kubeatlas/internal/models"
+)
+
+// ============================================
+// Kubernetes Version Support
+// ============================================
+
+// KubernetesRelease is the end-of-life date, as YYYY-MM-DD, of a Kubernetes
+// minor version such as 1.30
+type KubernetesRelease struct {
+	Version   string `json:"version"`
+	EndOfLife string `json:"end_of_life"`
+}
+
+// kubernetesReleases is the upstream end of maintenance of every minor
+// version, see https://kubernetes.io/releases/patch-releases/. Versions
+// still in support are on the projected date of the release cycle.
+var kubernetesReleases = []KubernetesRelease{
+	{Version: "1.19", EndOfLife: "2021-10-28"},
+	{Version: "1.20", EndOfLife: "2022-02-28"},
+	{Version: "1.21", EndOfLife: "2022-06-28"},
+	{Version: "1.22", EndOfLife: "2022-10-28"},
+	{Version: "1.23", EndOfLife: "2023-02-28"},
+	{Version: "1.24", EndOfLife: "2023-07-28"},
+	{Version: "1.25", EndOfLife: "2023-10-28"},
+	{Version: "1.26", EndOfLife: "2024-02-28"},
+	{Version: "1.27", EndOfLife: "2024-06-28"},
+	{Version: "1.28", EndOfLife: "2024-10-28"},
+	{Version: "1.29", EndOfLife: "2025-02-28"},
+	{Version: "1.30", EndOfLife: "2025-06-28"},
+	{Version: "1.31", EndOfLife: "2025-10-28"},
+	{Version: "1.32", EndOfLife: "2026-02-28"},
+	{Version: "1.33", EndOfLife: "2026-06-28"},
+	{Version: "1.34", EndOfLife: "2026-10-27"},
+	{Version: "1.35", EndOfLife: "2027-02-28"},
+	{Version: "1.36", EndOfLife: "2027-06-28"},
+}
+
+const (
+	releaseDateLayout = "2006-01-02"
+	// maxVersionAlertDays caps the alert thresholds of an organization
+	maxVersionAlertDays = 10
+	// maxKubernetesReleases caps the releases an organization adds to the calendar
+	maxKubernetesReleases = 100
+)
+
+// ErrInvalidVersionSupport is returned for unknown version support statuses
+var ErrInvalidVersionSupport = errors.New("invalid version support: must be supported, approaching_eol, end_of_life or unknown")
+
+var (
+	// minorVersionRegex matches the minor version of versions such as
+	// v1.30.2, 1.30 and v1.30.2+k3s1
+	minorVersionRegex   = regexp.MustCompile(`^v*(\d+)\.(\d+)`)
+	releaseVersionRegex = regexp.MustCompile(`^\d+\.\d+$`)
+)
+
+// KubernetesVersionSettings control the end-of-life tracking of cluster
+// versions, stored in organizations.settings["kubernetes_versions"]
+type KubernetesVersionSettings struct {
+	// AlertDays are the days before end of life at which a cluster's owners
+	// are alerted, 0 being the day itself. The largest one also flags
+	// clusters as approaching end of life.
+	AlertDays []int `json:"alert_days"`
+	// Releases add to or replace dates of the upstream calendar, e.g. for
+	// the extended support of a managed Kubernetes offering
+	Releases []KubernetesRelease `json:"releases"`
+}
+
+func (k *KubernetesVersionSettings) validate() error {
+	if len(k.AlertDays) > maxVersionAlertDays {
+		return fmt.Errorf("at most %d alert_days", maxVersionAlertDays)
+	}
+	seen := make(map[int]bool, len(k.AlertDays))
+	for _, days := range k.AlertDays {
+		if days < 0 || days > 730 {
+			return errors.New("alert_days must be between 0 and 730")
+		}
+		if seen[days] {
+			return fmt.Errorf("duplicate alert day %d", days)
+		}
+		seen[days] = true
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(k.AlertDays)))
+
+	if len(k.Releases) > maxKubernetesReleases {
+		return fmt.Errorf("at most %d releases", maxKubernetesReleases)
+	}
+	for i := range k.Releases {
+		r := &k.Releases[i]
+		r.Version = strings.TrimPrefix(strings.TrimSpace(r.Version), "v")
+		if !releaseVersionRegex.MatchString(r.Version) {
+			return fmt.Errorf("invalid release version %q: must be a minor version such as 1.30", r.Version)
+		}
+		if _, err := time.Parse(releaseDateLayout, r.EndOfLife); err != nil {
+			return fmt.Errorf("invalid end_of_life %q of release %s: must be a date such as 2025-06-28", r.EndOfLife, r.Version)
+		}
+	}
+	return nil
+}
+
+// KubernetesVersionsSetting alerts 90 and 30 days before end of life and
+// on the day itself by default
+var KubernetesVersionsSetting = SettingKey[KubernetesVersionSettings]{
+	Name:     "kubernetes_versions",
+	Default:  KubernetesVersionSettings{AlertDays: []int{90, 30, 0}},
+	Validate: (*KubernetesVersionSettings).validate,
+}
+
+// versionCalendar is the support calendar of an organization
+type versionCalendar struct {
+	endOfLife map[string]time.Time
+	// oldest is the oldest minor version of every major version on the
+	// calendar; older ones are past end of life
+	oldest map[int]int
+	// warningDays is how long before end of life a version is approaching it
+	warningDays int
+	alertDays   []int
+}
+
+// calendar merges the organization's releases into the upstream calendar
+func (k KubernetesVersionSettings) calendar() versionCalendar {
+	c := versionCalendar{
+		endOfLife: make(map[string]time.Time, len(kubernetesReleases)+len(k.Releases)),
+		oldest:    make(map[int]int),
+		alertDays: k.AlertDays,
+	}
+	for _, releases := range [][]KubernetesRelease{kubernetesReleases, k.Releases} {
+		for _, r := range releases {
+			major, minor, ok := parseMinorVersion(r.Version)
+			date, err := time.Parse(releaseDateLayout, r.EndOfLife)
+			if !ok || err != nil {
+				continue
+			}
+			c.endOfLife[r.Version] = date
+			if oldest, seen := c.oldest[major]; !seen || minor < oldest {
+				c.oldest[major] = minor
+			}
+		}
+	}
+	for _, days := range k.AlertDays {
+		if days > c.warningDays {
+			c.warningDays = days
+		}
+	}
+	return c
+}
+
+// parseMinorVersion returns the major and minor version of a version
+func parseMinorVersion(version string) (major, minor int, ok bool) {
+	m := minorVersionRegex.FindStringSubmatch(strings.TrimSpace(version))
+	if m == nil {
+		return 0, 0, false
+	}
+	major, errMajor := strconv.Atoi(m[1])
+	minor, errMinor := strconv.Atoi(m[2])
+	return major, minor, errMajor == nil && errMinor == nil
+}
+
+// support places a version on the calendar as of now. Versions that do not
+// parse, including empty ones, are unknown.
+func (c versionCalendar) support(version string, now time.Time) *models.VersionSupport {
+	major, minor, ok := parseMinorVersion(version)
+	if !ok {
+		return &models.VersionSupport{Status: models.VersionSupportUnknown}
+	}
+	s := &models.VersionSupport{MinorVersion: fmt.Sprintf("%d.%d", major, minor)}
+
+	eol, ok := c.endOfLife[s.MinorVersion]
+	if !ok {
+		if oldest, seen := c.oldest[major]; seen && minor < oldest {
+			s.Status = models.VersionSupportEndOfLife
+		} else {
+			s.Status = models.VersionSupportUnknown
+		}
+		return s
+	}
+
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	days := int(eol.Sub(today).Hours() / 24)
+	s.EndOfLife = &eol
+	s.DaysRemaining = &days
+	switch {
+	case days <= 0:
+		s.Status = models.VersionSupportEndOfLife
+	case days <= c.warningDays:
+		s.Status = models.VersionSupportApproachingEOL
+	default:
+		s.Status = models.VersionSupportSupported
+	}
+	return s
+}
+
+// minorVersions returns the minor versions with a status as of now, for
+// filtering clusters by it. Unknown versions are those not returned for
+// any other status, so exclude is set for them.
+func (c versionCalendar) minorVersions(status string, now time.Time) (minors []string, exclude bool) {
+	minors = make([]string, 0)
+	for version := range c.endOfLife {
+		if status == models.VersionSupportUnknown || c.support(version, now).Status == status {
+			minors = append(minors, version)
+		}
+	}
+	if status == models.VersionSupportEndOfLife || status == models.VersionSupportUnknown {
+		for major, oldest := range c.oldest {
+			for minor := 0; minor < oldest; minor++ {
+				minors = append(minors, fmt.Sprintf("%d.%d", major, minor))
+			}
+		}
+	}
+	sort.Strings(minors)
+	return minors, status == models.VersionSupportUnknown
+}
+
+// alertThreshold returns the alert threshold a version has reached: the
+// smallest of the alert days that are not before its remaining days.
+// Versions past end of life without a date reach the smallest one.
+func (c versionCalendar) alertThreshold(s *models.VersionSupport) (int, bool) {
+	if len(c.alertDays) == 0 {
+		return 0, false
+	}
+	switch s.Status {
+	case models.VersionSupportApproachingEOL, models.VersionSupportEndOfLife:
+	default:
+		return 0, false
+	}
+	if s.DaysRemaining == nil {
+		return c.alertDays[len(c.alertDays)-1], true
+	}
+
+	threshold, reached := 0, false
+	for _, days := range c.alertDays { // largest first
+		if *s.DaysRemaining <= days {
+			threshold, reached = days, true
+		}
+	}
+	return threshold, reached
+}
+
+// isVersionSupportStatus reports whether status is a version support status
+func isVersionSupportStatus(status string) bool {
+	switch status {
+	case models.VersionSupportSupported, models.VersionSupportApproachingEOL,
+		models.VersionSupportEndOfLife, models.VersionSupportUnknown:
+		return true
+	}
+	return false
+}
+
+// versionCalendar returns the organization's support calendar
+func (s *ClusterService) versionCalendar(ctx context.Context, orgID uuid.UUID) (versionCalendar, error) {
+	settings, err := GetSetting(ctx, s.settings, orgID, KubernetesVersionsSetting)
+	if err != nil {
+		return versionCalendar{}, err
+	}
+	return settings.calendar(), nil
+}
+
+// versionFilters replaces a version_support filter with the minor versions
+// having that status
+func (s *ClusterService) versionFilters(ctx context.Context, orgID uuid.UUID, filters map[string]interface{}) error {
+	status, ok := filters["version_support"].(string)
+	if !ok || status == "" {
+		return nil
+	}
+	if !isVersionSupportStatus(status) {
+		return ErrInvalidVersionSupport
+	}
+	calendar, err := s.versionCalendar(ctx, orgID)
+	if err != nil {
+		return err
+	}
+
+	minors, exclude := calendar.minorVersions(status, time.Now())
+	if exclude {
+		filters["exclude_minor_versions"] = minors
+	} else {
+		filters["minor_versions"] = minors
+	}
+	return nil
+}
+
+// annotateVersionSupport sets the version support of clusters of the organization
+func (s *ClusterService) annotateVersionSupport(ctx context.Context, orgID uuid.UUID, clusters []models.Cluster) error {
+	calendar, err := s.versionCalendar(ctx, orgID)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	for i := range clusters {
+		clusters[i].VersionSupport = calendar.support(clusters[i].Version.String, now)
+	}
+	return nil
+}
+
+// CheckVersionSupport alerts the owners of every cluster whose Kubernetes
+// version reached one of its organization's end-of-life thresholds, once
+// per threshold and minor version
+func (s *ClusterService) CheckVersionSupport(ctx context.Context) error {
+	orgIDs, err := s.clusterRepo.ListOrganizationIDs(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list organizations: %w", err)
+	}
+
+	var errs []error
+	for _, orgID := range orgIDs {
+		if err := s.checkVersionSupport(ctx, orgID); err != nil {
+			errs = append(errs, fmt.Errorf("organization %s: %w", orgID, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (s *ClusterService) checkVersionSupport(ctx context.Context, orgID uuid.UUID) error {
+	calendar, err := s.versionCalendar(ctx, orgID)
+	if err != nil {
+		return err
+	}
+	clusters, err := s.clusterRepo.ListVersions(ctx, orgID)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for i := range clusters {
+		cluster := &clusters[i]
+		if !cluster.Version.Valid || cluster.Version.String == "" {
+			continue
+		}
+		support := calendar.support(cluster.Version.String, now)
+		threshold, ok := calendar.alertThreshold(support)
+		if !ok {
+			continue
+		}
+
+		claimed, err := s.clusterRepo.ClaimVersionAlert(ctx, cluster.ID, support.MinorVersion, threshold)
+		if err != nil {
+			return err
+		}
+		if claimed {
+			s.notifications.NotifyClusterVersionEOL(ctx, cluster, support)
+		}
+	}
+	return nil
+}
+
+// ============================================
+// Kubernetes Version Report
+// ============================================
+
+// KubernetesVersionCount counts the clusters running a minor version
+type KubernetesVersionCount struct {
+	models.VersionSupport
+	Clusters int `json:"clusters"`
+}
+
+// ClusterVersionStatus is a cluster on the support calendar
+type ClusterVersionStatus struct {
+	ClusterID   uuid.UUID `json:"cluster_id"`
+	Cluster     string    `json:"cluster"`
+	Environment string    `json:"environment"`
+	Version     string    `json:"version"`
+	models.VersionSupport
+}
+
+// KubernetesVersionReport places the clusters of an organization on the
+// Kubernetes support calendar
+type KubernetesVersionReport struct {
+	TotalClusters  int `json:"total_clusters"`
+	Supported      int `json:"supported"`
+	ApproachingEOL int `json:"approaching_eol"`
+	EndOfLife      int `json:"end_of_life"`
+	Unknown        int `json:"unknown"`
+	// Versions are the minor versions running, newest first
+	Versions []KubernetesVersionCount `json:"versions"`
+	// Clusters are those approaching or past end of life, soonest first
+	Clusters []ClusterVersionStatus `json:"clusters"`
+}
+
+// GetKubernetesVersionReport returns the Kubernetes version report
+func (s *DashboardService) GetKubernetesVersionReport(ctx context.Context, orgID uuid.UUID) (*KubernetesVersionReport, error) {
+	settings, err := GetSetting(ctx, s.settings, orgID, KubernetesVersionsSetting)
+	if err != nil {
+		return nil, err
+	}
+	clusters, err := s.repos.Cluster.ListVersions(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	return kubernetesVersionReport(settings.calendar(), clusters, time.Now()), nil
+}
+
+func kubernetesVersionReport(calendar versionCalendar, clusters []models.Cluster, now time.Time) *KubernetesVersionReport {
+	report := &KubernetesVersionReport{
+		TotalClusters: len(clusters),
+		Versions:      []KubernetesVersionCount{},
+		Clusters:      []ClusterVersionStatus{},
+	}
+
+	byVersion := make(map[string]int)
+	for _, c := range clusters {
+		support := calendar.support(c.Version.String, now)
+		switch support.Status {
+		case models.VersionSupportSupported:
+			report.Supported++
+		case models.VersionSupportApproachingEOL:
+			report.ApproachingEOL++
+		case models.VersionSupportEndOfLife:
+			report.EndOfLife++
+		default:
+			report.Unknown++
+		}
+
+		if i, ok := byVersion[support.MinorVersion]; ok {
+			report.Versions[i].Clusters++
+		} else {
+			byVersion[support.MinorVersion] = len(report.Versions)
+			report.Versions = append(report.Versions, KubernetesVersionCount{VersionSupport: *support, Clusters: 1})
+		}
+
+		if support.Status == models.VersionSupportApproachingEOL || support.Status == models.VersionSupportEndOfLife {
+			report.Clusters = append(report.Clusters, ClusterVersionStatus{
+				ClusterID:      c.ID,
+				Cluster:        c.Name,
+				Environment:    c.Environment,
+				Version:        c.Version.String,
+				VersionSupport: *support,
+			})
+		}
+	}
+
+	sort.Slice(report.Versions, func(i, j int) bool {
+		return newerMinorVersion(report.Versions[i].MinorVersion, report.Versions[j].MinorVersion)
+	})
+	// Versions without a date are older than the calendar, so they come first
+	sort.SliceStable(report.Clusters, func(i, j int) bool {
+		a, b := report.Clusters[i].EndOfLife, report.Clusters[j].EndOfLife
+		if a == nil || b == nil {
+			return a == nil && b != nil
+		}
+		return a.Before(*b)
+	})
+	return report
+}
+
+// newerMinorVersion orders minor versions newest first, unknown ones last
+func newerMinorVersion(a, b string) bool {
+	aMajor, aMinor, aOK := parseMinorVersion(a)
+	bMajor, bMinor, bOK := parseMinorVersion(b)
+	if !aOK || !bOK {
+		return aOK
+	}
+	if aMajor != bMajor {
+		return aMajor > bMajor
+	}
+	return aMinor > bMinor
+}
+
+// CSV renders the clusters approaching or past end of life as CSV
+func (r *KubernetesVersionReport) CSV() ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.Write([]string{"Cluster", "Environment", "Version", "Status", "EndOfLife", "DaysRemaining"})
+	for _, c := range r.Clusters {
+		var eol, days string
+		if c.EndOfLife != nil {
+			eol = c.EndOfLife.Format(releaseDateLayout)
+		}
+		if c.DaysRemaining != nil {
+			days = strconv.Itoa(*c.DaysRemaining)
+		}
+		w.Write([]string{c.Cluster, c.Environment, c.Version, c.Status, eol, days})
+	}
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
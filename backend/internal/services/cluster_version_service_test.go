@@ -0,0 +1,146 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/kubeatlas/kubeatlas/internal/models"
+)
+
+func TestVersionCalendarSupport(t *testing.T) {
+	settings := KubernetesVersionSettings{
+		AlertDays: []int{90, 30, 0},
+		Releases:  []KubernetesRelease{{Version: "1.30", EndOfLife: "2027-01-31"}},
+	}
+	calendar := settings.calendar()
+	now := time.Date(2026, 10, 16, 15, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		version string
+		minor   string
+		status  string
+		days    int
+	}{
+		{"v1.36.1", "1.36", models.VersionSupportSupported, 255},
+		{"v1.35.0-eks-1a2b3c", "1.35", models.VersionSupportSupported, 135},
+		{"v1.34.2+k3s1", "1.34", models.VersionSupportApproachingEOL, 11},
+		{"v1.33.5", "1.33", models.VersionSupportEndOfLife, -110},
+		{"1.30", "1.30", models.VersionSupportSupported, 107}, // extended by the organization
+		{"v1.12.3", "1.12", models.VersionSupportEndOfLife, 0},
+		{"v1.40.0", "1.40", models.VersionSupportUnknown, 0},
+		{"", "", models.VersionSupportUnknown, 0},
+	}
+	for _, tt := range tests {
+		s := calendar.support(tt.version, now)
+		if s.MinorVersion != tt.minor || s.Status != tt.status {
+			t.Errorf("support(%q) = %s %s, want %s %s", tt.version, s.MinorVersion, s.Status, tt.minor, tt.status)
+			continue
+		}
+		if s.DaysRemaining != nil && *s.DaysRemaining != tt.days {
+			t.Errorf("support(%q) days remaining = %d, want %d", tt.version, *s.DaysRemaining, tt.days)
+		}
+	}
+}
+
+func TestVersionCalendarAlertThreshold(t *testing.T) {
+	calendar := KubernetesVersionSettings{AlertDays: []int{90, 30, 0}}.calendar()
+	days := func(d int) *int { return &d }
+
+	tests := []struct {
+		support   models.VersionSupport
+		threshold int
+		ok        bool
+	}{
+		{models.VersionSupport{Status: models.VersionSupportSupported, DaysRemaining: days(120)}, 0, false},
+		{models.VersionSupport{Status: models.VersionSupportApproachingEOL, DaysRemaining: days(90)}, 90, true},
+		{models.VersionSupport{Status: models.VersionSupportApproachingEOL, DaysRemaining: days(12)}, 30, true},
+		{models.VersionSupport{Status: models.VersionSupportEndOfLife, DaysRemaining: days(-200)}, 0, true},
+		{models.VersionSupport{Status: models.VersionSupportEndOfLife}, 0, true},
+		{models.VersionSupport{Status: models.VersionSupportUnknown}, 0, false},
+	}
+	for _, tt := range tests {
+		threshold, ok := calendar.alertThreshold(&tt.support)
+		if threshold != tt.threshold || ok != tt.ok {
+			t.Errorf("alertThreshold(%+v) = %d, %v, want %d, %v", tt.support, threshold, ok, tt.threshold, tt.ok)
+		}
+	}
+
+	if _, ok := (KubernetesVersionSettings{}).calendar().alertThreshold(&tests[3].support); ok {
+		t.Error("alertThreshold() without alert days reached a threshold")
+	}
+}
+
+func TestVersionCalendarMinorVersions(t *testing.T) {
+	calendar := KubernetesVersionSettings{AlertDays: []int{30}}.calendar()
+	now := time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC)
+
+	if minors, exclude := calendar.minorVersions(models.VersionSupportApproachingEOL, now); exclude || !reflect.DeepEqual(minors, []string{"1.34"}) {
+		t.Errorf("minorVersions(approaching_eol) = %v, %v", minors, exclude)
+	}
+	minors, exclude := calendar.minorVersions(models.VersionSupportEndOfLife, now)
+	if exclude || len(minors) != 34 || minors[0] != "1.0" {
+		t.Errorf("minorVersions(end_of_life) = %v, %v, want 1.0 to 1.33", minors, exclude)
+	}
+	if minors, exclude := calendar.minorVersions(models.VersionSupportUnknown, now); !exclude || len(minors) != 37 {
+		t.Errorf("minorVersions(unknown) = %d versions, %v, want every known one excluded", len(minors), exclude)
+	}
+}
+
+func TestKubernetesVersionSettingsValidate(t *testing.T) {
+	s := KubernetesVersionSettings{
+		AlertDays: []int{0, 90, 30},
+		Releases:  []KubernetesRelease{{Version: " v1.30 ", EndOfLife: "2027-01-31"}},
+	}
+	if err := s.validate(); err != nil || !reflect.DeepEqual(s.AlertDays, []int{90, 30, 0}) || s.Releases[0].Version != "1.30" {
+		t.Errorf("validate() = %v, settings %+v", err, s)
+	}
+
+	for _, invalid := range []KubernetesVersionSettings{
+		{AlertDays: []int{30, 30}},
+		{AlertDays: []int{-1}},
+		{Releases: []KubernetesRelease{{Version: "1.30.2", EndOfLife: "2027-01-31"}}},
+		{Releases: []KubernetesRelease{{Version: "1.30", EndOfLife: "31/01/2027"}}},
+	} {
+		if err := invalid.validate(); err == nil {
+			t.Errorf("validate(%+v) = nil, want an error", invalid)
+		}
+	}
+}
+
+func TestKubernetesVersionReport(t *testing.T) {
+	calendar := KubernetesVersionSettings{AlertDays: []int{90}}.calendar()
+	now := time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC)
+	cluster := func(name, version string) models.Cluster {
+		return models.Cluster{Name: name, Environment: "production", Version: models.NewNullStringFromString(version)}
+	}
+	clusters := []models.Cluster{
+		cluster("eu-prod", "v1.34.2"),
+		cluster("us-prod", "v1.36.0"),
+		cluster("legacy", "v1.16.4"),
+		cluster("staging", "v1.32.9"),
+		cluster("new", ""),
+		cluster("us-staging", "v1.34.1"),
+	}
+
+	r := kubernetesVersionReport(calendar, clusters, now)
+	if r.TotalClusters != 6 || r.Supported != 1 || r.ApproachingEOL != 2 || r.EndOfLife != 2 || r.Unknown != 1 {
+		t.Errorf("kubernetesVersionReport() = %+v", r)
+	}
+
+	var versions []string
+	for _, v := range r.Versions {
+		versions = append(versions, v.MinorVersion)
+	}
+	if !reflect.DeepEqual(versions, []string{"1.36", "1.34", "1.32", "1.16", ""}) || r.Versions[1].Clusters != 2 {
+		t.Errorf("Versions = %+v", r.Versions)
+	}
+
+	var names []string
+	for _, c := range r.Clusters {
+		names = append(names, c.Cluster)
+	}
+	if !reflect.DeepEqual(names, []string{"legacy", "staging", "eu-prod", "us-staging"}) {
+		t.Errorf("Clusters = %v, want those approaching or past end of life, soonest first", names)
+	}
+}
@@ -0,0 +1,310 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/kubeatlas/kubeatlas/internal/database/repositories"
+	"github.com/kubeatlas/kubeatlas/internal/models"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrCommentNotFound  = errors.New("comment not found")
+	ErrCommentNotAuthor = errors.New("only the author of a comment can change it")
+	ErrInvalidComment   = errors.New("invalid comment")
+)
+
+const (
+	// maxCommentLength bounds the markdown body of a comment, in bytes
+	maxCommentLength = 20000
+	// maxCommentMentions bounds the users one comment notifies
+	maxCommentMentions = 20
+)
+
+// mentionPattern matches @username and @email mentions that do not follow
+// a word, so that e-mail addresses in the text are not read as mentions
+var mentionPattern = regexp.MustCompile(`(?:^|[^\w@.])@([\w][\w.+-]*(?:@[\w-]+(?:\.[\w-]+)+)?)`)
+
+// markdownCodePattern matches fenced code blocks and inline code spans,
+// which are left out of mentions
+var markdownCodePattern = regexp.MustCompile("(?s)```.*?```|`[^`\n]*`")
+
+// CommentRequest creates or replaces the body of a comment
+type CommentRequest struct {
+	Body string `json:"body" binding:"required"`
+}
+
+func (r *CommentRequest) validate() error {
+	r.Body = strings.TrimSpace(r.Body)
+	if r.Body == "" || len(r.Body) > maxCommentLength {
+		return fmt.Errorf("%w: body must be 1 to %d characters", ErrInvalidComment, maxCommentLength)
+	}
+	return nil
+}
+
+// CommentService manages the comments left on namespaces and clusters and
+// notifies the users they mention
+type CommentService struct {
+	repo            *repositories.CommentRepository
+	userRepo        *repositories.UserRepository
+	namespaceRepo   *repositories.NamespaceRepository
+	clusterRepo     *repositories.ClusterRepository
+	notificationSvc *NotificationService
+	logger          *zap.SugaredLogger
+}
+
+// NewCommentService creates a new comment service
+func NewCommentService(
+	repo *repositories.CommentRepository,
+	userRepo *repositories.UserRepository,
+	namespaceRepo *repositories.NamespaceRepository,
+	clusterRepo *repositories.ClusterRepository,
+	notificationSvc *NotificationService,
+	logger *zap.SugaredLogger,
+) *CommentService {
+	return &CommentService{
+		repo:            repo,
+		userRepo:        userRepo,
+		namespaceRepo:   namespaceRepo,
+		clusterRepo:     clusterRepo,
+		notificationSvc: notificationSvc,
+		logger:          logger,
+	}
+}
+
+// ListForNamespace returns the comments on a namespace, oldest first
+func (s *CommentService) ListForNamespace(ctx context.Context, orgID, namespaceID uuid.UUID, p repositories.Pagination) (*repositories.PaginatedResult[models.Comment], error) {
+	if _, err := s.namespace(ctx, orgID, namespaceID); err != nil {
+		return nil, err
+	}
+	return s.repo.List(ctx, orgID, namespaceID, false, p)
+}
+
+// ListForCluster returns the comments on a cluster, oldest first
+func (s *CommentService) ListForCluster(ctx context.Context, orgID, clusterID uuid.UUID, p repositories.Pagination) (*repositories.PaginatedResult[models.Comment], error) {
+	if _, err := s.cluster(ctx, orgID, clusterID); err != nil {
+		return nil, err
+	}
+	return s.repo.List(ctx, orgID, clusterID, true, p)
+}
+
+// CreateForNamespace leaves a comment on a namespace and notifies the users
+// it mentions
+func (s *CommentService) CreateForNamespace(ctx context.Context, ac AuditContext, namespaceID uuid.UUID, req CommentRequest) (*models.Comment, error) {
+	ns, err := s.namespace(ctx, ac.OrgID, namespaceID)
+	if err != nil {
+		return nil, err
+	}
+	return s.create(ctx, ac, &models.Comment{NamespaceID: &ns.ID}, "namespace "+ns.Name, req)
+}
+
+// CreateForCluster leaves a comment on a cluster and notifies the users it
+// mentions
+func (s *CommentService) CreateForCluster(ctx context.Context, ac AuditContext, clusterID uuid.UUID, req CommentRequest) (*models.Comment, error) {
+	cluster, err := s.cluster(ctx, ac.OrgID, clusterID)
+	if err != nil {
+		return nil, err
+	}
+	return s.create(ctx, ac, &models.Comment{ClusterID: &cluster.ID}, "cluster "+cluster.Name, req)
+}
+
+func (s *CommentService) create(ctx context.Context, ac AuditContext, comment *models.Comment, target string, req CommentRequest) (*models.Comment, error) {
+	if ac.UserID == nil {
+		return nil, ErrCommentNotAuthor
+	}
+	if err := req.validate(); err != nil {
+		return nil, err
+	}
+	mentioned, err := s.mentionedUsers(ctx, ac.OrgID, req.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	comment.OrganizationID = ac.OrgID
+	comment.AuthorID = ac.UserID
+	comment.AuthorEmail = ac.UserEmail
+	comment.Body = req.Body
+	comment.MentionedUserIDs = userIDs(mentioned)
+	if err := s.repo.Create(ctx, comment); err != nil {
+		return nil, err
+	}
+	s.logger.Infow("Comment created", "comment_id", comment.ID, "target", target, "mentions", len(mentioned))
+
+	s.notificationSvc.NotifyMentioned(ctx, comment, newMentions(mentioned, nil, *ac.UserID), target)
+	// Re-read for the author's name
+	if created, err := s.repo.GetByID(ctx, ac.OrgID, comment.ID); err == nil && created != nil {
+		return created, nil
+	}
+	return comment, nil
+}
+
+// Update replaces the body of a comment of the caller. Only users the
+// comment did not mention before are notified.
+func (s *CommentService) Update(ctx context.Context, ac AuditContext, id uuid.UUID, req CommentRequest) (*models.Comment, error) {
+	comment, err := s.get(ctx, ac.OrgID, id)
+	if err != nil {
+		return nil, err
+	}
+	if ac.UserID == nil || comment.AuthorID == nil || *comment.AuthorID != *ac.UserID {
+		return nil, ErrCommentNotAuthor
+	}
+	if err := req.validate(); err != nil {
+		return nil, err
+	}
+	mentioned, err := s.mentionedUsers(ctx, ac.OrgID, req.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	notify := newMentions(mentioned, comment.MentionedUserIDs, *ac.UserID)
+	comment.Body = req.Body
+	comment.MentionedUserIDs = userIDs(mentioned)
+	if err := s.repo.Update(ctx, comment); err != nil {
+		return nil, err
+	}
+
+	if len(notify) > 0 {
+		s.notificationSvc.NotifyMentioned(ctx, comment, notify, s.targetName(ctx, comment))
+	}
+	return comment, nil
+}
+
+// Delete deletes a comment. Authors delete their own comments; admins any.
+func (s *CommentService) Delete(ctx context.Context, ac AuditContext, id uuid.UUID) error {
+	comment, err := s.get(ctx, ac.OrgID, id)
+	if err != nil {
+		return err
+	}
+	if ac.UserID == nil {
+		return ErrCommentNotAuthor
+	}
+	if comment.AuthorID == nil || *comment.AuthorID != *ac.UserID {
+		user, err := s.userRepo.GetByID(ctx, *ac.UserID)
+		if err != nil {
+			return err
+		}
+		if user == nil || user.Role != "admin" {
+			return ErrCommentNotAuthor
+		}
+	}
+	if err := s.repo.Delete(ctx, id); err != nil {
+		return err
+	}
+	s.logger.Infow("Comment deleted", "comment_id", id, "deleted_by", ac.UserEmail)
+	return nil
+}
+
+func (s *CommentService) get(ctx context.Context, orgID, id uuid.UUID) (*models.Comment, error) {
+	comment, err := s.repo.GetByID(ctx, orgID, id)
+	if err != nil {
+		return nil, err
+	}
+	if comment == nil {
+		return nil, ErrCommentNotFound
+	}
+	return comment, nil
+}
+
+func (s *CommentService) namespace(ctx context.Context, orgID, id uuid.UUID) (*models.Namespace, error) {
+	ns, err := s.namespaceRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if ns == nil || ns.OrganizationID != orgID {
+		return nil, ErrNamespaceNotFound
+	}
+	return ns, nil
+}
+
+func (s *CommentService) cluster(ctx context.Context, orgID, id uuid.UUID) (*models.Cluster, error) {
+	cluster, err := s.clusterRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if cluster == nil || cluster.OrganizationID != orgID {
+		return nil, ErrClusterNotFound
+	}
+	return cluster, nil
+}
+
+// targetName names what a comment was left on, for notifications
+func (s *CommentService) targetName(ctx context.Context, comment *models.Comment) string {
+	if comment.NamespaceID != nil {
+		if ns, err := s.namespaceRepo.GetByID(ctx, *comment.NamespaceID); err == nil && ns != nil {
+			return "namespace " + ns.Name
+		}
+	} else if comment.ClusterID != nil {
+		if cluster, err := s.clusterRepo.GetByID(ctx, *comment.ClusterID); err == nil && cluster != nil {
+			return "cluster " + cluster.Name
+		}
+	}
+	return ""
+}
+
+// mentionedUsers returns the active users of the organization a comment
+// body mentions
+func (s *CommentService) mentionedUsers(ctx context.Context, orgID uuid.UUID, body string) ([]models.User, error) {
+	handles := parseMentions(body)
+	if len(handles) == 0 {
+		return nil, nil
+	}
+	users, err := s.userRepo.ListByHandles(ctx, orgID, handles)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve mentions: %w", err)
+	}
+	return users, nil
+}
+
+// parseMentions returns the lowercased usernames and e-mail addresses a
+// markdown body mentions with @, in order and at most maxCommentMentions.
+// Mentions in code are ignored.
+func parseMentions(body string) []string {
+	body = markdownCodePattern.ReplaceAllString(body, " ")
+
+	seen := make(map[string]bool)
+	var handles []string
+	for _, m := range mentionPattern.FindAllStringSubmatch(body, -1) {
+		// Sentence punctuation is not part of the handle
+		handle := strings.ToLower(strings.TrimRight(m[1], ".-+"))
+		if handle == "" || seen[handle] {
+			continue
+		}
+		seen[handle] = true
+		handles = append(handles, handle)
+		if len(handles) == maxCommentMentions {
+			break
+		}
+	}
+	return handles
+}
+
+// newMentions returns the mentioned users not in before, leaving out the
+// author
+func newMentions(mentioned []models.User, before []uuid.UUID, author uuid.UUID) []models.User {
+	known := make(map[uuid.UUID]bool, len(before)+1)
+	for _, id := range before {
+		known[id] = true
+	}
+	known[author] = true
+
+	var users []models.User
+	for _, u := range mentioned {
+		if !known[u.ID] {
+			users = append(users, u)
+		}
+	}
+	return users
+}
+
+func userIDs(users []models.User) []uuid.UUID {
+	ids := make([]uuid.UUID, len(users))
+	for i, u := range users {
+		ids[i] = u.ID
+	}
+	return ids
+}
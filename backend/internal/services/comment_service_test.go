@@ -0,0 +1,40 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/kubeatlas/kubeatlas/internal/models"
+)
+
+func TestParseMentions(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want []string
+	}{
+		{"username", "Ask @Ada about the failover.", []string{"ada"}},
+		{"email", "cc @grace.hopper@acme.com, @ada and @ada", []string{"grace.hopper@acme.com", "ada"}},
+		{"plain email address", "Write to ops@acme.com", nil},
+		{"code", "Run `kubectl get @pods` or\n```\n@ada\n```\nthen ping @linus", []string{"linus"}},
+		{"none", "No mentions here", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseMentions(tt.body); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseMentions(%q) = %v, want %v", tt.body, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewMentions(t *testing.T) {
+	author, ada, grace := uuid.New(), uuid.New(), uuid.New()
+	mentioned := []models.User{{BaseModel: models.BaseModel{ID: author}}, {BaseModel: models.BaseModel{ID: ada}}, {BaseModel: models.BaseModel{ID: grace}}}
+
+	got := newMentions(mentioned, []uuid.UUID{ada}, author)
+	if len(got) != 1 || got[0].ID != grace {
+		t.Errorf("newMentions() = %+v, want only grace", got)
+	}
+}
@@ -0,0 +1,536 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"html"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/kubeatlas/kubeatlas/internal/confluence"
+	"github.com/kubeatlas/kubeatlas/internal/crypto"
+	"github.com/kubeatlas/kubeatlas/internal/models"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrInvalidConfluenceSettings = errors.New("invalid Confluence settings: a base URL, API token and space key are required")
+	ErrConfluenceNotEnabled      = errors.New("confluence integration is not enabled")
+	ErrConfluenceRequestFailed   = errors.New("confluence request failed")
+)
+
+// ConfluenceService exports the documentation of namespaces to Confluence
+// pages and keeps the exported pages current
+type ConfluenceService struct {
+	repos     *Repositories
+	publisher confluence.Publisher
+	encryptor *crypto.Encryptor
+	auditSvc  *AuditService
+	logger    *zap.SugaredLogger
+}
+
+func NewConfluenceService(repos *Repositories, publisher confluence.Publisher, encryptor *crypto.Encryptor, auditSvc *AuditService, logger *zap.SugaredLogger) *ConfluenceService {
+	return &ConfluenceService{
+		repos:     repos,
+		publisher: publisher,
+		encryptor: encryptor,
+		auditSvc:  auditSvc,
+		logger:    logger,
+	}
+}
+
+// ============================================
+// Confluence Settings
+// ============================================
+
+// ConfluenceSettings are the organization's Confluence settings, stored in
+// organizations.settings["confluence"]. Pages are created in SpaceKey, under
+// ParentPageID when one is set.
+type ConfluenceSettings struct {
+	Enabled      bool   `json:"enabled"`
+	BaseURL      string `json:"base_url"`
+	Email        string `json:"email"`
+	APIToken     string `json:"api_token,omitempty"`
+	SpaceKey     string `json:"space_key"`
+	ParentPageID string `json:"parent_page_id"`
+}
+
+func (s *ConfluenceSettings) confluenceConfig() confluence.Config {
+	return confluence.Config{BaseURL: s.BaseURL, Email: s.Email, APIToken: s.APIToken}
+}
+
+func (s *ConfluenceSettings) validate() error {
+	if s.BaseURL == "" || s.APIToken == "" || s.SpaceKey == "" {
+		return ErrInvalidConfluenceSettings
+	}
+	if !strings.HasPrefix(s.BaseURL, "https://") {
+		return fmt.Errorf("%w: base URL must use https", ErrInvalidConfluenceSettings)
+	}
+	for _, r := range s.ParentPageID {
+		if r < '0' || r > '9' {
+			return fmt.Errorf("%w: parent page ID must be numeric", ErrInvalidConfluenceSettings)
+		}
+	}
+	return nil
+}
+
+// GetSettings returns the organization's Confluence settings, including
+// credentials
+func (s *ConfluenceService) GetSettings(ctx context.Context, orgID uuid.UUID) (*ConfluenceSettings, error) {
+	settings, err := s.repos.User.GetOrganizationSettings(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := &ConfluenceSettings{}
+	cSettings, ok := settings["confluence"].(map[string]interface{})
+	if !ok {
+		return cfg, nil
+	}
+	if v, ok := cSettings["enabled"].(bool); ok {
+		cfg.Enabled = v
+	}
+	if v, ok := cSettings["base_url"].(string); ok {
+		cfg.BaseURL = v
+	}
+	if v, ok := cSettings["email"].(string); ok {
+		cfg.Email = v
+	}
+	if v, ok := cSettings["api_token"].(string); ok {
+		if cfg.APIToken, err = openCredential(s.encryptor, v); err != nil {
+			return nil, err
+		}
+	}
+	if v, ok := cSettings["space_key"].(string); ok {
+		cfg.SpaceKey = v
+	}
+	if v, ok := cSettings["parent_page_id"].(string); ok {
+		cfg.ParentPageID = v
+	}
+	return cfg, nil
+}
+
+// UpdateSettings replaces the organization's Confluence settings. An empty
+// API token keeps the stored one. The returned settings omit the token.
+func (s *ConfluenceService) UpdateSettings(ctx context.Context, ac AuditContext, req ConfluenceSettings) (*ConfluenceSettings, error) {
+	settings, err := s.repos.User.GetOrganizationSettings(ctx, ac.OrgID)
+	if err != nil {
+		return nil, err
+	}
+
+	if existing, ok := settings["confluence"].(map[string]interface{}); ok {
+		if v, ok := existing["api_token"].(string); ok && req.APIToken == "" {
+			if req.APIToken, err = openCredential(s.encryptor, v); err != nil {
+				return nil, err
+			}
+		}
+	}
+	req.BaseURL = strings.TrimRight(req.BaseURL, "/")
+	if req.Enabled {
+		if err := req.validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	apiToken, err := sealCredential(s.encryptor, req.APIToken)
+	if err != nil {
+		return nil, err
+	}
+	settings["confluence"] = map[string]interface{}{
+		"enabled":        req.Enabled,
+		"base_url":       req.BaseURL,
+		"email":          req.Email,
+		"api_token":      apiToken,
+		"space_key":      req.SpaceKey,
+		"parent_page_id": req.ParentPageID,
+	}
+	if err := s.repos.User.UpdateOrganizationSettings(ctx, ac.OrgID, settings); err != nil {
+		return nil, err
+	}
+
+	s.auditSvc.LogUpdate(ctx, ac, "confluence_settings", ac.OrgID, "confluence", nil, map[string]interface{}{
+		"enabled":        req.Enabled,
+		"base_url":       req.BaseURL,
+		"email":          req.Email,
+		"space_key":      req.SpaceKey,
+		"parent_page_id": req.ParentPageID,
+	})
+
+	req.APIToken = ""
+	return &req, nil
+}
+
+// TestSettings checks that the given settings can see the space. An empty
+// API token falls back to the stored one.
+func (s *ConfluenceService) TestSettings(ctx context.Context, orgID uuid.UUID, req ConfluenceSettings) error {
+	if req.APIToken == "" {
+		stored, err := s.GetSettings(ctx, orgID)
+		if err != nil {
+			return err
+		}
+		req.APIToken = stored.APIToken
+	}
+	req.BaseURL = strings.TrimRight(req.BaseURL, "/")
+	if err := req.validate(); err != nil {
+		return err
+	}
+	return s.publisher.CheckSpace(ctx, req.confluenceConfig(), req.SpaceKey)
+}
+
+// ============================================
+// Namespace Pages
+// ============================================
+
+// ExportNamespace renders a namespace's documentation and creates or updates
+// its Confluence page. Once exported, the page is kept current by
+// RefreshPages.
+func (s *ConfluenceService) ExportNamespace(ctx context.Context, ac AuditContext, namespaceID uuid.UUID) (*models.NamespaceConfluencePage, error) {
+	cfg, err := s.GetSettings(ctx, ac.OrgID)
+	if err != nil {
+		return nil, err
+	}
+	if !cfg.Enabled {
+		return nil, ErrConfluenceNotEnabled
+	}
+
+	ns, err := s.repos.Namespace.GetByID(ctx, namespaceID)
+	if err != nil {
+		return nil, err
+	}
+	if ns == nil || ns.OrganizationID != ac.OrgID {
+		return nil, ErrNamespaceNotFound
+	}
+	existing, err := s.repos.Namespace.GetConfluencePage(ctx, ns.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := s.pageContent(ctx, ns)
+	if err != nil {
+		return nil, err
+	}
+	page, err := s.publish(ctx, cfg, existing, content)
+	if err != nil {
+		return nil, err
+	}
+
+	record := &models.NamespaceConfluencePage{
+		NamespaceID:    ns.ID,
+		OrganizationID: ns.OrganizationID,
+		PageID:         page.ID,
+		PageURL:        page.URL,
+		ContentHash:    content.hash(),
+		ExportedBy:     ac.UserID,
+	}
+	if err := s.repos.Namespace.SaveConfluencePage(ctx, record); err != nil {
+		return nil, fmt.Errorf("exported page %s but failed to store it: %w", page.ID, err)
+	}
+
+	s.auditSvc.LogUpdate(ctx, ac, "namespace", ns.ID, ns.Name, nil, map[string]interface{}{
+		"confluence_page": page.URL,
+	})
+	return record, nil
+}
+
+// RefreshPages re-renders every exported namespace page and updates those
+// whose content changed since the last export, so wiki runbook indexes stay
+// current. Pages of organizations that disabled the integration are left
+// alone. Failures are logged and the remaining pages refreshed.
+func (s *ConfluenceService) RefreshPages(ctx context.Context) error {
+	pages, err := s.repos.Namespace.ListConfluencePages(ctx)
+	if err != nil {
+		return err
+	}
+
+	settings := make(map[uuid.UUID]*ConfluenceSettings)
+	for i := range pages {
+		p := &pages[i]
+		cfg, ok := settings[p.OrganizationID]
+		if !ok {
+			cfg, err = s.GetSettings(ctx, p.OrganizationID)
+			if err != nil {
+				s.logger.Errorw("Failed to load Confluence settings", "organization_id", p.OrganizationID, "error", err)
+			}
+			settings[p.OrganizationID] = cfg
+		}
+		if cfg == nil || !cfg.Enabled {
+			continue
+		}
+		if err := s.refresh(ctx, cfg, p); err != nil {
+			s.logger.Warnw("Failed to refresh Confluence page", "namespace_id", p.NamespaceID, "page_id", p.PageID, "error", err)
+		}
+	}
+	return nil
+}
+
+// refresh updates the page of an exported namespace if its content changed
+func (s *ConfluenceService) refresh(ctx context.Context, cfg *ConfluenceSettings, p *models.NamespaceConfluencePage) error {
+	ns, err := s.repos.Namespace.GetByID(ctx, p.NamespaceID)
+	if err != nil || ns == nil {
+		return err
+	}
+	content, err := s.pageContent(ctx, ns)
+	if err != nil {
+		return err
+	}
+	if content.hash() == p.ContentHash {
+		return nil
+	}
+
+	page, err := s.publish(ctx, cfg, p, content)
+	if err != nil {
+		return err
+	}
+	p.PageID = page.ID
+	p.PageURL = page.URL
+	p.ContentHash = content.hash()
+	return s.repos.Namespace.SaveConfluencePage(ctx, p)
+}
+
+// publish writes content to the namespace's page. The recorded page is
+// updated if it still exists; otherwise a page with the same title is
+// adopted, as Confluence titles are unique within a space, or a new one is
+// created.
+func (s *ConfluenceService) publish(ctx context.Context, cfg *ConfluenceSettings, existing *models.NamespaceConfluencePage, content *namespacePage) (*confluence.Page, error) {
+	conf := cfg.confluenceConfig()
+
+	var page *confluence.Page
+	var err error
+	if existing != nil {
+		if page, err = s.publisher.GetPage(ctx, conf, existing.PageID); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrConfluenceRequestFailed, err)
+		}
+	}
+	if page == nil {
+		if page, err = s.publisher.FindPage(ctx, conf, cfg.SpaceKey, content.title); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrConfluenceRequestFailed, err)
+		}
+	}
+
+	if page == nil {
+		page, err = s.publisher.CreatePage(ctx, conf, cfg.SpaceKey, cfg.ParentPageID, content.title, content.body)
+	} else {
+		page, err = s.publisher.UpdatePage(ctx, conf, page, content.title, content.body)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrConfluenceRequestFailed, err)
+	}
+	return page, nil
+}
+
+// pageContent loads what a namespace's page shows and renders it
+func (s *ConfluenceService) pageContent(ctx context.Context, ns *models.Namespace) (*namespacePage, error) {
+	doc := namespaceDocumentation{Namespace: ns}
+
+	cluster, err := s.repos.Cluster.GetByID(ctx, ns.ClusterID)
+	if err != nil {
+		return nil, err
+	}
+	if cluster != nil {
+		doc.Cluster = cluster.Name
+	}
+	if ns.InfrastructureOwnerTeamID != nil {
+		team, err := s.repos.Team.GetByID(ctx, *ns.InfrastructureOwnerTeamID)
+		if err != nil {
+			return nil, err
+		}
+		if team != nil {
+			doc.OwnerTeam = team.Name
+		}
+	}
+	if ns.BusinessUnitID != nil {
+		bu, err := s.repos.BusinessUnit.GetByID(ctx, *ns.BusinessUnitID)
+		if err != nil {
+			return nil, err
+		}
+		if bu != nil {
+			doc.BusinessUnit = bu.Name
+		}
+	}
+
+	if doc.Dependencies, err = s.repos.InternalDependency.ListByNamespace(ctx, ns.ID); err != nil {
+		return nil, err
+	}
+	if doc.External, err = s.repos.ExternalDependency.ListByNamespace(ctx, ns.ID); err != nil {
+		return nil, err
+	}
+	if doc.Documents, err = s.repos.Document.ListByNamespace(ctx, ns.ID); err != nil {
+		return nil, err
+	}
+	return renderNamespacePage(doc), nil
+}
+
+// namespaceDocumentation is what a namespace's Confluence page shows
+type namespaceDocumentation struct {
+	Namespace    *models.Namespace
+	Cluster      string
+	OwnerTeam    string
+	BusinessUnit string
+	Dependencies []models.InternalDependency
+	External     []models.ExternalDependency
+	Documents    []models.Document
+}
+
+// namespacePage is a rendered Confluence page
+type namespacePage struct {
+	title string
+	body  string
+}
+
+// hash identifies the page's content, so unchanged pages are not updated
+func (p *namespacePage) hash() string {
+	sum := sha256.Sum256([]byte(p.title + "\n" + p.body))
+	return hex.EncodeToString(sum[:])
+}
+
+// renderNamespacePage renders a namespace's documentation in the Confluence
+// storage format. The page has no timestamps, so rendering unchanged data
+// gives the same body.
+func renderNamespacePage(doc namespaceDocumentation) *namespacePage {
+	ns := doc.Namespace
+	title := ns.Name
+	// Namespace names repeat across clusters, but titles must be unique in a
+	// space
+	if doc.Cluster != "" {
+		title = fmt.Sprintf("%s (%s)", ns.Name, doc.Cluster)
+	}
+
+	var b strings.Builder
+	b.WriteString(`<ac:structured-macro ac:name="info"><ac:rich-text-body><p>`)
+	b.WriteString("This page is generated from KubeAtlas. Changes made here are overwritten by the next export.")
+	b.WriteString(`</p></ac:rich-text-body></ac:structured-macro>`)
+	if ns.Description.Valid && ns.Description.String != "" {
+		fmt.Fprintf(&b, "<p>%s</p>", html.EscapeString(ns.Description.String))
+	}
+
+	writeFields(&b, "Overview", [][2]string{
+		{"Namespace", ns.Name},
+		{"Display name", ns.DisplayName.ValueOrEmpty()},
+		{"Cluster", doc.Cluster},
+		{"Environment", ns.Environment},
+		{"Criticality", ns.Criticality},
+		{"Status", ns.Status},
+		{"Tags", strings.Join(ns.Tags, ", ")},
+	})
+	writeFields(&b, "Ownership", [][2]string{
+		{"Owner team", doc.OwnerTeam},
+		{"Business unit", doc.BusinessUnit},
+		{"Application manager", contact(ns.ApplicationManagerName, ns.ApplicationManagerEmail)},
+		{"Technical lead", contact(ns.TechnicalLeadName, ns.TechnicalLeadEmail)},
+		{"Project manager", contact(ns.ProjectManagerName, ns.ProjectManagerEmail)},
+	})
+	writeFields(&b, "Service Level", [][2]string{
+		{"Availability", ns.SLAAvailability.ValueOrEmpty()},
+		{"RTO", ns.SLARTO.ValueOrEmpty()},
+		{"RPO", ns.SLARPO.ValueOrEmpty()},
+		{"Support hours", ns.SupportHours.ValueOrEmpty()},
+		{"Escalation path", ns.EscalationPath.ValueOrEmpty()},
+	})
+
+	b.WriteString("<h2>Dependencies</h2>")
+	var dependsOn, usedBy [][]string
+	for _, d := range doc.Dependencies {
+		if d.SourceNamespaceID == ns.ID && d.TargetNamespace != nil {
+			dependsOn = append(dependsOn, dependencyRow(d.TargetNamespace.Name, d, d.TargetResourceName))
+		}
+		if d.TargetNamespaceID == ns.ID && d.SourceNamespace != nil {
+			usedBy = append(usedBy, dependencyRow(d.SourceNamespace.Name, d, d.SourceResourceName))
+		}
+	}
+	header := []string{"Namespace", "Type", "Resource", "Critical", "Status"}
+	b.WriteString("<h3>Depends on</h3>")
+	writeTable(&b, header, dependsOn, "No internal dependencies.")
+	b.WriteString("<h3>Used by</h3>")
+	writeTable(&b, header, usedBy, "No namespaces depend on this namespace.")
+
+	b.WriteString("<h3>External systems</h3>")
+	var external [][]string
+	for _, d := range doc.External {
+		external = append(external, []string{
+			d.Name, d.SystemType, d.Provider.ValueOrEmpty(), d.Endpoint.ValueOrEmpty(), yesNo(d.IsCritical), d.Status,
+		})
+	}
+	writeTable(&b, []string{"Name", "Type", "Provider", "Endpoint", "Critical", "Status"}, external, "No external dependencies.")
+
+	b.WriteString("<h2>Documents</h2>")
+	var documents [][]string
+	for _, d := range doc.Documents {
+		category := ""
+		if d.Category != nil {
+			category = d.Category.Name
+		}
+		documents = append(documents, []string{
+			d.Name, category, fmt.Sprintf("%d", d.Version), d.UploadedAt.UTC().Format("2006-01-02"), d.Description.ValueOrEmpty(),
+		})
+	}
+	writeTable(&b, []string{"Name", "Category", "Version", "Uploaded", "Description"}, documents, "No documents.")
+
+	return &namespacePage{title: title, body: b.String()}
+}
+
+// writeFields writes a section of label and value rows, leaving out empty
+// values and the whole section when all are empty
+func writeFields(b *strings.Builder, heading string, fields [][2]string) {
+	var rows [][2]string
+	for _, f := range fields {
+		if f[1] != "" {
+			rows = append(rows, f)
+		}
+	}
+	if len(rows) == 0 {
+		return
+	}
+	fmt.Fprintf(b, "<h2>%s</h2><table><tbody>", html.EscapeString(heading))
+	for _, r := range rows {
+		fmt.Fprintf(b, "<tr><th>%s</th><td>%s</td></tr>", html.EscapeString(r[0]), html.EscapeString(r[1]))
+	}
+	b.WriteString("</tbody></table>")
+}
+
+// writeTable writes a table, or empty as a paragraph when there are no rows
+func writeTable(b *strings.Builder, header []string, rows [][]string, empty string) {
+	if len(rows) == 0 {
+		fmt.Fprintf(b, "<p><em>%s</em></p>", html.EscapeString(empty))
+		return
+	}
+	b.WriteString("<table><tbody><tr>")
+	for _, h := range header {
+		fmt.Fprintf(b, "<th>%s</th>", html.EscapeString(h))
+	}
+	b.WriteString("</tr>")
+	for _, row := range rows {
+		b.WriteString("<tr>")
+		for _, cell := range row {
+			fmt.Fprintf(b, "<td>%s</td>", html.EscapeString(cell))
+		}
+		b.WriteString("</tr>")
+	}
+	b.WriteString("</tbody></table>")
+}
+
+func dependencyRow(namespace string, d models.InternalDependency, resource models.NullString) []string {
+	return []string{namespace, d.DependencyType, resource.ValueOrEmpty(), yesNo(d.IsCritical), d.Status}
+}
+
+// contact formats a person as "name <email>", or whichever is known
+func contact(name, email models.NullString) string {
+	n, e := name.ValueOrEmpty(), email.ValueOrEmpty()
+	switch {
+	case n != "" && e != "":
+		return fmt.Sprintf("%s <%s>", n, e)
+	case n != "":
+		return n
+	default:
+		return e
+	}
+}
+
+func yesNo(v bool) string {
+	if v {
+		return "Yes"
+	}
+	return "No"
+}
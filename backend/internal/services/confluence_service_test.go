@@ -0,0 +1,91 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kubeatlas/kubeatlas/internal/models"
+)
+
+func TestRenderNamespacePage(t *testing.T) {
+	ns := &models.Namespace{
+		BaseModel:               models.BaseModel{ID: uuid.New()},
+		Name:                    "payments",
+		Description:             models.NewNullStringFromString("Card <payments> & refunds"),
+		Environment:             "production",
+		Criticality:             "tier-1",
+		ApplicationManagerName:  models.NewNullStringFromString("Ada"),
+		ApplicationManagerEmail: models.NewNullStringFromString("ada@example.com"),
+		TechnicalLeadEmail:      models.NewNullStringFromString("lead@example.com"),
+	}
+	orders := uuid.New()
+	doc := namespaceDocumentation{
+		Namespace: ns,
+		Cluster:   "prod-eu",
+		OwnerTeam: "Payments",
+		Dependencies: []models.InternalDependency{{
+			SourceNamespaceID: ns.ID, TargetNamespaceID: orders, DependencyType: "api", IsCritical: true, Status: "active",
+			SourceNamespace: &models.Namespace{Name: "payments"}, TargetNamespace: &models.Namespace{Name: "orders"},
+		}},
+		External: []models.ExternalDependency{{Name: "Stripe", SystemType: "payment-gateway", Status: "active"}},
+		Documents: []models.Document{{
+			Name: "Runbook", Version: 2, UploadedAt: time.Date(2026, 10, 1, 9, 0, 0, 0, time.UTC),
+			Category: &models.DocumentCategory{Name: "Runbooks"},
+		}},
+	}
+
+	page := renderNamespacePage(doc)
+	if page.title != "payments (prod-eu)" {
+		t.Errorf("title = %q", page.title)
+	}
+	for _, want := range []string{
+		"<p>Card &lt;payments&gt; &amp; refunds</p>",
+		"<th>Owner team</th><td>Payments</td>",
+		"<th>Application manager</th><td>Ada &lt;ada@example.com&gt;</td>",
+		"<th>Technical lead</th><td>lead@example.com</td>",
+		"<h3>Depends on</h3><table><tbody><tr><th>Namespace</th>",
+		"<td>orders</td><td>api</td><td></td><td>Yes</td><td>active</td>",
+		"No namespaces depend on this namespace.",
+		"<td>Stripe</td><td>payment-gateway</td>",
+		"<td>Runbook</td><td>Runbooks</td><td>2</td><td>2026-10-01</td>",
+	} {
+		if !strings.Contains(page.body, want) {
+			t.Errorf("body is missing %q", want)
+		}
+	}
+	// Sections without values are left out
+	if strings.Contains(page.body, "Service Level") || strings.Contains(page.body, "Business unit") {
+		t.Errorf("body has empty fields: %s", page.body)
+	}
+
+	if again := renderNamespacePage(doc); again.hash() != page.hash() {
+		t.Error("rendering the same documentation gave a different hash")
+	}
+	doc.Documents = nil
+	if changed := renderNamespacePage(doc); changed.hash() == page.hash() || !strings.Contains(changed.body, "No documents.") {
+		t.Error("removing the documents did not change the page")
+	}
+}
+
+func TestConfluenceSettingsValidate(t *testing.T) {
+	valid := ConfluenceSettings{BaseURL: "https://acme.atlassian.net/wiki", APIToken: "token", SpaceKey: "OPS", ParentPageID: "12345"}
+	if err := valid.validate(); err != nil {
+		t.Errorf("validate() error = %v", err)
+	}
+
+	for name, mutate := range map[string]func(*ConfluenceSettings){
+		"no token":    func(s *ConfluenceSettings) { s.APIToken = "" },
+		"no space":    func(s *ConfluenceSettings) { s.SpaceKey = "" },
+		"plain http":  func(s *ConfluenceSettings) { s.BaseURL = "http://wiki.internal" },
+		"parent page": func(s *ConfluenceSettings) { s.ParentPageID = "Runbooks" },
+	} {
+		s := valid
+		mutate(&s)
+		if err := s.validate(); !errors.Is(err, ErrInvalidConfluenceSettings) {
+			t.Errorf("%s: validate() error = %v, want ErrInvalidConfluenceSettings", name, err)
+		}
+	}
+}
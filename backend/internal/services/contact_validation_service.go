@@ -0,0 +1,188 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/mail"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/kubeatlas/kubeatlas/internal/database/repositories"
+	"github.com/kubeatlas/kubeatlas/internal/models"
+	"go.uber.org/zap"
+)
+
+// mailResolver looks up where a domain receives mail; *net.Resolver
+// implements it
+type mailResolver interface {
+	LookupMX(ctx context.Context, name string) ([]*net.MX, error)
+	LookupHost(ctx context.Context, host string) ([]string, error)
+}
+
+// ContactValidationService flags namespaces whose application manager,
+// technical lead or project manager email addresses went stale: malformed,
+// at a domain that receives no mail, or of someone who left. Whether someone
+// left is known from deactivated KubeAtlas users and, when LDAP is
+// configured, from the directory.
+type ContactValidationService struct {
+	repo     *repositories.ContactValidationRepository
+	ldap     *LDAPService
+	resolver mailResolver
+	logger   *zap.SugaredLogger
+}
+
+// NewContactValidationService creates a new contact validation service
+func NewContactValidationService(repo *repositories.ContactValidationRepository, ldap *LDAPService, logger *zap.SugaredLogger) *ContactValidationService {
+	return &ContactValidationService{repo: repo, ldap: ldap, resolver: net.DefaultResolver, logger: logger}
+}
+
+// ValidateAll validates the namespace contacts of every organization. A
+// failing organization does not stop the others.
+func (s *ContactValidationService) ValidateAll(ctx context.Context) error {
+	orgIDs, err := s.repo.ListOrganizationIDs(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list organizations: %w", err)
+	}
+
+	var errs []error
+	for _, orgID := range orgIDs {
+		result, err := s.Validate(ctx, orgID)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("organization %s: %w", orgID, err))
+			continue
+		}
+		if result.Flagged > 0 {
+			s.logger.Infow("Validated namespace contacts", "organization_id", orgID, "checked", result.Checked, "flagged", result.Flagged)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Validate validates the organization's namespace contacts and replaces its
+// contact issues with those found
+func (s *ContactValidationService) Validate(ctx context.Context, orgID uuid.UUID) (*models.ContactValidationResult, error) {
+	contacts, err := s.repo.ListContacts(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	emails := make([]string, 0, len(contacts))
+	for _, c := range contacts {
+		if email := strings.ToLower(c.Email); !seen[email] {
+			seen[email] = true
+			emails = append(emails, email)
+		}
+	}
+	deactivated, err := s.repo.ListDeactivatedEmails(ctx, orgID, emails)
+	if err != nil {
+		return nil, err
+	}
+	leftDirectory := func(string) bool { return false }
+	directory, err := s.ldap.ExistingEmails(ctx, orgID, emails)
+	switch {
+	case err == nil:
+		leftDirectory = missingFromDirectory(directory)
+	case !errors.Is(err, ErrLDAPNotConfigured):
+		s.logger.Warnw("Failed to look up namespace contacts in LDAP", "organization_id", orgID, "error", err)
+	}
+	departed := func(email string) bool { return deactivated[email] || leftDirectory(email) }
+
+	mailable := make(map[string]bool)
+	receivesMail := func(domain string) bool {
+		ok, checked := mailable[domain]
+		if !checked {
+			ok = s.receivesMail(ctx, domain)
+			mailable[domain] = ok
+		}
+		return ok
+	}
+
+	result := &models.ContactValidationResult{Checked: len(contacts), Reasons: map[string]int{}}
+	issues := make([]models.ContactIssue, 0)
+	for _, c := range contacts {
+		reason := contactIssue(c.Email, receivesMail, departed)
+		if reason == "" {
+			continue
+		}
+		issues = append(issues, models.ContactIssue{NamespaceContact: c, Reason: reason})
+		result.Flagged++
+		result.Reasons[reason]++
+	}
+	if err := s.repo.ReplaceIssues(ctx, orgID, issues); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// Issues returns up to limit contact issues of the organization
+func (s *ContactValidationService) Issues(ctx context.Context, orgID uuid.UUID, limit int) ([]models.ContactIssue, error) {
+	if limit <= 0 || limit > 500 {
+		limit = 100
+	}
+	return s.repo.ListIssues(ctx, orgID, limit)
+}
+
+// receivesMail reports whether a domain has a mail server, from its MX
+// records or, without any, its address records. A lookup that fails for any
+// other reason than the name not existing counts as receiving mail, so an
+// unreachable DNS server flags no one.
+func (s *ContactValidationService) receivesMail(ctx context.Context, domain string) bool {
+	mx, err := s.resolver.LookupMX(ctx, domain)
+	if err == nil && len(mx) > 0 {
+		// A single "." record is a null MX: the domain accepts no mail
+		return !(len(mx) == 1 && (mx[0].Host == "." || mx[0].Host == ""))
+	}
+	if err != nil && !isDNSNotFound(err) {
+		s.logger.Warnw("Failed to look up mail servers", "domain", domain, "error", err)
+		return true
+	}
+	if _, err := s.resolver.LookupHost(ctx, domain); err != nil {
+		return !isDNSNotFound(err)
+	}
+	return true
+}
+
+// isDNSNotFound reports whether a lookup failed because the name does not
+// exist or has no records of the type asked for
+func isDNSNotFound(err error) bool {
+	var dnsErr *net.DNSError
+	return errors.As(err, &dnsErr) && dnsErr.IsNotFound
+}
+
+// contactIssue returns why a contact's email address fails validation, or
+// "" when it passes. receivesMail tells whether a domain has a mail server,
+// and departed whether a lowercase address is of someone who left.
+func contactIssue(email string, receivesMail func(domain string) bool, departed func(email string) bool) string {
+	addr, err := mail.ParseAddress(email)
+	if err != nil || addr.Address != email {
+		return models.ContactIssueInvalidFormat
+	}
+	domain := strings.ToLower(email[strings.LastIndex(email, "@")+1:])
+	if !strings.Contains(domain, ".") || strings.HasPrefix(domain, "[") {
+		return models.ContactIssueInvalidFormat
+	}
+	if !receivesMail(domain) {
+		return models.ContactIssueNoMailServer
+	}
+	if departed(strings.ToLower(email)) {
+		return models.ContactIssueDeparted
+	}
+	return ""
+}
+
+// missingFromDirectory tells whether a lowercase address is unknown to the
+// directory, given the addresses it knows. Only addresses at domains the
+// directory has users of are judged, so external contacts are not taken
+// for people who left.
+func missingFromDirectory(known map[string]bool) func(email string) bool {
+	domains := make(map[string]bool)
+	for email := range known {
+		domains[email[strings.LastIndex(email, "@")+1:]] = true
+	}
+	return func(email string) bool {
+		return domains[email[strings.LastIndex(email, "@")+1:]] && !known[email]
+	}
+}
@@ -0,0 +1,94 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/kubeatlas/kubeatlas/internal/models"
+	"go.uber.org/zap"
+)
+
+type fakeMailResolver struct {
+	mx    map[string][]*net.MX
+	hosts map[string][]string
+	err   error
+}
+
+func (f fakeMailResolver) LookupMX(_ context.Context, name string) ([]*net.MX, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	if mx, ok := f.mx[name]; ok {
+		return mx, nil
+	}
+	return nil, &net.DNSError{Err: "no such host", Name: name, IsNotFound: true}
+}
+
+func (f fakeMailResolver) LookupHost(_ context.Context, host string) ([]string, error) {
+	if hosts, ok := f.hosts[host]; ok {
+		return hosts, nil
+	}
+	return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+}
+
+func TestContactIssue(t *testing.T) {
+	receivesMail := func(domain string) bool { return domain != "gone.example" }
+	departed := func(email string) bool { return email == "left@acme.com" }
+
+	tests := []struct {
+		email string
+		want  string
+	}{
+		{"ada@acme.com", ""},
+		{"Ada Lovelace <ada@acme.com>", models.ContactIssueInvalidFormat},
+		{"ada@", models.ContactIssueInvalidFormat},
+		{"ada@localhost", models.ContactIssueInvalidFormat},
+		{"ada@gone.example", models.ContactIssueNoMailServer},
+		{"Left@acme.com", models.ContactIssueDeparted},
+	}
+	for _, tt := range tests {
+		if got := contactIssue(tt.email, receivesMail, departed); got != tt.want {
+			t.Errorf("contactIssue(%q) = %q, want %q", tt.email, got, tt.want)
+		}
+	}
+}
+
+func TestMissingFromDirectory(t *testing.T) {
+	missing := missingFromDirectory(map[string]bool{"ada@acme.com": true})
+	if missing("ada@acme.com") {
+		t.Error("missingFromDirectory() of a known address = true")
+	}
+	if !missing("alan@acme.com") {
+		t.Error("missingFromDirectory() of an unknown address at a directory domain = false")
+	}
+	if missing("vendor@partner.example") {
+		t.Error("missingFromDirectory() of an external address = true")
+	}
+}
+
+func TestReceivesMail(t *testing.T) {
+	s := &ContactValidationService{
+		resolver: fakeMailResolver{
+			mx: map[string][]*net.MX{
+				"acme.com":    {{Host: "mx.acme.com.", Pref: 10}},
+				"nomail.test": {{Host: ".", Pref: 0}},
+			},
+			hosts: map[string][]string{"small.test": {"192.0.2.1"}},
+		},
+		logger: zap.NewNop().Sugar(),
+	}
+	ctx := context.Background()
+	for domain, want := range map[string]bool{"acme.com": true, "small.test": true, "nomail.test": false, "gone.test": false} {
+		if got := s.receivesMail(ctx, domain); got != want {
+			t.Errorf("receivesMail(%q) = %v, want %v", domain, got, want)
+		}
+	}
+
+	// A DNS server that cannot be reached flags no one
+	s.resolver = fakeMailResolver{err: errors.New("i/o timeout")}
+	if !s.receivesMail(ctx, "gone.test") {
+		t.Error("receivesMail() with DNS unreachable = false, want true")
+	}
+}
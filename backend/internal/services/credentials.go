@@ -0,0 +1,39 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/kubeatlas/kubeatlas/internal/crypto"
+)
+
+// Integration credentials (API tokens, passwords, webhook URLs) are stored in
+// organizations.settings encrypted with the server's encryption key, marked
+// by credentialPrefix. Values saved before credentials were encrypted are read
+// as plain text and encrypted the next time the integration is saved.
+const credentialPrefix = "enc:"
+
+// sealCredential encrypts a credential for storage in organization settings
+func sealCredential(encryptor *crypto.Encryptor, value string) (string, error) {
+	if value == "" {
+		return "", nil
+	}
+	encrypted, err := encryptor.EncryptString(value)
+	if err != nil {
+		return "", ErrEncryptionFailed
+	}
+	return credentialPrefix + encrypted, nil
+}
+
+// openCredential decrypts a credential stored in organization settings
+func openCredential(encryptor *crypto.Encryptor, stored string) (string, error) {
+	encrypted, ok := strings.CutPrefix(stored, credentialPrefix)
+	if !ok {
+		return stored, nil
+	}
+	value, err := encryptor.DecryptString(encrypted)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt stored credential: %w", err)
+	}
+	return value, nil
+}
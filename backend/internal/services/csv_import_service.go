@@ -0,0 +1,456 @@
+package services
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/mail"
+	"strings"
+
+	"github.com/kubeatlas/kubeatlas/internal/database/repositories"
+	"github.com/kubeatlas/kubeatlas/internal/models"
+	"go.uber.org/zap"
+)
+
+// MaxCSVImportRows is the most rows one CSV import may contain
+const MaxCSVImportRows = 5000
+
+var (
+	ErrInvalidCSVImport = errors.New("invalid csv import")
+	ErrCSVRowsInvalid   = errors.New("csv import has invalid rows")
+)
+
+// Resources a CSV import can create
+const (
+	CSVImportUsers         = "users"
+	CSVImportTeams         = "teams"
+	CSVImportBusinessUnits = "business_units"
+)
+
+// Outcomes of importing one CSV row
+const (
+	CSVRowCreated   = "created"
+	CSVRowUpdated   = "updated"
+	CSVRowUnchanged = "unchanged"
+	CSVRowInvalid   = "invalid"
+	CSVRowFailed    = "failed"
+)
+
+// csvFields are the fields each resource imports. The first one identifies
+// the row and is required.
+var csvFields = map[string][]string{
+	CSVImportUsers:         {"email", "username", "full_name", "phone", "role"},
+	CSVImportTeams:         {"name", "slug", "description", "team_type", "contact_email", "contact_slack"},
+	CSVImportBusinessUnits: {"name", "code", "description", "director_name", "director_email", "cost_center"},
+}
+
+// CSVImportOptions controls a CSV import. Mapping names the column of each
+// field, by header; fields it leaves out are read from the column whose
+// header is the field name, ignoring case, spaces and dashes. With DryRun
+// the rows are validated and the changes reported but not made.
+type CSVImportOptions struct {
+	DryRun    bool
+	Mapping   map[string]string
+	Delimiter rune
+}
+
+// CSVImportRow is the outcome of importing one row
+type CSVImportRow struct {
+	Row     int           `json:"row"`
+	Key     string        `json:"key"`
+	Status  string        `json:"status"`
+	Changes []FieldChange `json:"changes,omitempty"`
+	Errors  []string      `json:"errors,omitempty"`
+}
+
+// CSVImport is the outcome of a CSV import, in the order of the file
+type CSVImport struct {
+	Resource  string         `json:"resource"`
+	DryRun    bool           `json:"dry_run"`
+	Created   int            `json:"created"`
+	Updated   int            `json:"updated"`
+	Unchanged int            `json:"unchanged"`
+	Invalid   int            `json:"invalid"`
+	Failed    int            `json:"failed"`
+	Rows      []CSVImportRow `json:"rows"`
+}
+
+// CSVImportService seeds users, teams and business units from CSV exports
+// of HR systems and CMDBs. Rows update the user with their email, the team
+// with their slug or the business unit with their code or name when there
+// is one, and create it otherwise.
+type CSVImportService struct {
+	userRepo         *repositories.UserRepository
+	teamRepo         *repositories.TeamRepository
+	businessUnitRepo *repositories.BusinessUnitRepository
+	userSvc          *UserService
+	teamSvc          *TeamService
+	businessUnitSvc  *BusinessUnitService
+	logger           *zap.SugaredLogger
+}
+
+func NewCSVImportService(repos *Repositories, userSvc *UserService, teamSvc *TeamService, businessUnitSvc *BusinessUnitService, logger *zap.SugaredLogger) *CSVImportService {
+	return &CSVImportService{
+		userRepo:         repos.User,
+		teamRepo:         repos.Team,
+		businessUnitRepo: repos.BusinessUnit,
+		userSvc:          userSvc,
+		teamSvc:          teamSvc,
+		businessUnitSvc:  businessUnitSvc,
+		logger:           logger,
+	}
+}
+
+// csvChange is a planned row and the function that imports it, nil when
+// there is nothing to do
+type csvChange struct {
+	row   CSVImportRow
+	apply func(ctx context.Context) error
+}
+
+// Import imports the users, teams or business units of a CSV file with a
+// header row. Every row is validated first: when any is invalid nothing is
+// imported and ErrCSVRowsInvalid is returned along with the result.
+// Created users are sent an invitation.
+func (s *CSVImportService) Import(ctx context.Context, ac AuditContext, resource string, r io.Reader, opts CSVImportOptions) (*CSVImport, error) {
+	fields, ok := csvFields[resource]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown resource %q", ErrInvalidCSVImport, resource)
+	}
+	reader := csv.NewReader(r)
+	if opts.Delimiter != 0 {
+		reader.Comma = opts.Delimiter
+	}
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("%w: the file is empty", ErrInvalidCSVImport)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidCSVImport, err)
+	}
+	columns, err := csvColumns(header, fields, opts.Mapping)
+	if err != nil {
+		return nil, err
+	}
+
+	var plan func(ctx context.Context, values map[string]string) (*csvChange, error)
+	switch resource {
+	case CSVImportUsers:
+		plan = func(ctx context.Context, values map[string]string) (*csvChange, error) {
+			return s.planUser(ctx, ac, values)
+		}
+	case CSVImportTeams:
+		teams, err := s.teamRepo.List(ctx, ac.OrgID)
+		if err != nil {
+			return nil, err
+		}
+		bySlug := make(map[string]*models.Team, len(teams))
+		for i := range teams {
+			bySlug[teams[i].Slug] = &teams[i]
+		}
+		plan = func(_ context.Context, values map[string]string) (*csvChange, error) {
+			return s.planTeam(ac, bySlug, values), nil
+		}
+	default:
+		units, err := s.businessUnitRepo.List(ctx, ac.OrgID)
+		if err != nil {
+			return nil, err
+		}
+		// Codes win over names that happen to match them
+		byKey := make(map[string]*models.BusinessUnit, 2*len(units))
+		for i := range units {
+			byKey["name:"+strings.ToLower(units[i].Name)] = &units[i]
+			if units[i].Code.Valid && units[i].Code.String != "" {
+				byKey["code:"+strings.ToLower(units[i].Code.String)] = &units[i]
+			}
+		}
+		plan = func(_ context.Context, values map[string]string) (*csvChange, error) {
+			return s.planBusinessUnit(ac, byKey, values), nil
+		}
+	}
+
+	var changes []*csvChange
+	seen := make(map[string]int)
+	invalid := false
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidCSVImport, err)
+		}
+		if len(changes) == MaxCSVImportRows {
+			return nil, fmt.Errorf("%w: at most %d rows can be imported at once", ErrInvalidCSVImport, MaxCSVImportRows)
+		}
+		line, _ := reader.FieldPos(0)
+
+		values := make(map[string]string, len(columns))
+		for field, i := range columns {
+			values[field] = strings.TrimSpace(record[i])
+		}
+		c, err := plan(ctx, values)
+		if err != nil {
+			return nil, err
+		}
+		c.row.Row = line
+		if first, ok := seen[c.row.Key]; ok && c.row.Key != "" {
+			c.row.Errors = append(c.row.Errors, fmt.Sprintf("duplicate of row %d", first))
+		} else {
+			seen[c.row.Key] = line
+		}
+		if len(c.row.Errors) > 0 {
+			c.row.Status, c.row.Changes, c.apply = CSVRowInvalid, nil, nil
+			invalid = true
+		}
+		changes = append(changes, c)
+	}
+	if len(changes) == 0 {
+		return nil, fmt.Errorf("%w: the file has no rows", ErrInvalidCSVImport)
+	}
+
+	result := &CSVImport{Resource: resource, DryRun: opts.DryRun, Rows: make([]CSVImportRow, 0, len(changes))}
+	for _, c := range changes {
+		if c.apply != nil && !opts.DryRun && !invalid {
+			if err := c.apply(ctx); err != nil {
+				s.logger.Warnw("Failed to import CSV row", "resource", resource, "row", c.row.Row, "error", err)
+				c.row.Status = CSVRowFailed
+				c.row.Errors = []string{err.Error()}
+			}
+		}
+		switch c.row.Status {
+		case CSVRowCreated:
+			result.Created++
+		case CSVRowUpdated:
+			result.Updated++
+		case CSVRowUnchanged:
+			result.Unchanged++
+		case CSVRowInvalid:
+			result.Invalid++
+		default:
+			result.Failed++
+		}
+		result.Rows = append(result.Rows, c.row)
+	}
+
+	s.logger.Infow("CSV imported", "organization_id", ac.OrgID, "resource", resource, "dry_run", opts.DryRun,
+		"created", result.Created, "updated", result.Updated, "unchanged", result.Unchanged,
+		"invalid", result.Invalid, "failed", result.Failed)
+	if invalid {
+		return result, ErrCSVRowsInvalid
+	}
+	return result, nil
+}
+
+// csvColumns returns the column index of each field found in the header
+func csvColumns(header, fields []string, mapping map[string]string) (map[string]int, error) {
+	byHeader := make(map[string]int, len(header))
+	for i, h := range header {
+		if i == 0 {
+			h = strings.TrimPrefix(h, "\ufeff") // byte order mark
+		}
+		byHeader[normalizeCSVHeader(h)] = i
+	}
+
+	columns := make(map[string]int, len(fields))
+	for field, column := range mapping {
+		if !containsString(fields, field) {
+			return nil, fmt.Errorf("%w: unknown field %q, must be one of %s", ErrInvalidCSVImport, field, strings.Join(fields, ", "))
+		}
+		i, ok := byHeader[normalizeCSVHeader(column)]
+		if !ok {
+			return nil, fmt.Errorf("%w: column %q of field %s is not in the header", ErrInvalidCSVImport, column, field)
+		}
+		columns[field] = i
+	}
+	for _, field := range fields {
+		if _, ok := columns[field]; ok {
+			continue
+		}
+		if i, ok := byHeader[normalizeCSVHeader(field)]; ok {
+			columns[field] = i
+		}
+	}
+	if _, ok := columns[fields[0]]; !ok {
+		return nil, fmt.Errorf("%w: no %s column", ErrInvalidCSVImport, fields[0])
+	}
+	return columns, nil
+}
+
+// normalizeCSVHeader lets "Full Name" and "full-name" match full_name
+func normalizeCSVHeader(h string) string {
+	h = strings.ToLower(strings.TrimSpace(h))
+	return strings.NewReplacer(" ", "_", "-", "_").Replace(h)
+}
+
+func containsString(values []string, v string) bool {
+	for _, value := range values {
+		if value == v {
+			return true
+		}
+	}
+	return false
+}
+
+// validateCSVEmail adds an error when a non-empty email is malformed
+func validateCSVEmail(row *CSVImportRow, field, email string) {
+	if email == "" {
+		return
+	}
+	if addr, err := mail.ParseAddress(email); err != nil || addr.Address != email {
+		row.Errors = append(row.Errors, fmt.Sprintf("%s: invalid email %q", field, email))
+	}
+}
+
+// planUpsert completes the row of an existing resource from its changes
+func planUpsert(c *csvChange, diff *fieldDiff) *csvChange {
+	c.row.Changes = diffAuditValues(diff.before, diff.after)
+	c.row.Status = CSVRowUpdated
+	if len(c.row.Changes) == 0 {
+		c.row.Status, c.apply = CSVRowUnchanged, nil
+	}
+	return c
+}
+
+func (s *CSVImportService) planUser(ctx context.Context, ac AuditContext, values map[string]string) (*csvChange, error) {
+	req := CreateUserRequest{
+		Email:    strings.ToLower(values["email"]),
+		Username: values["username"],
+		FullName: values["full_name"],
+		Phone:    values["phone"],
+		Role:     strings.ToLower(values["role"]),
+	}
+	c := &csvChange{row: CSVImportRow{Key: req.Email}}
+	user := &models.User{OrganizationID: ac.OrgID, Email: req.Email, Role: req.Role}
+	if err := user.Validate(); err != nil {
+		c.row.Errors = append(c.row.Errors, err.Error())
+		return c, nil
+	}
+
+	existing, err := s.userRepo.GetByEmail(ctx, ac.OrgID, req.Email)
+	if err != nil {
+		return nil, err
+	}
+	if existing == nil {
+		c.row.Status = CSVRowCreated
+		c.apply = func(ctx context.Context) error {
+			_, err := s.userSvc.Create(ctx, ac, req)
+			return err
+		}
+		return c, nil
+	}
+
+	diff := newFieldDiff()
+	diff.set("username", existing.Username.ValueOrEmpty(), req.Username)
+	diff.set("full_name", existing.FullName.ValueOrEmpty(), req.FullName)
+	diff.set("phone", existing.Phone.ValueOrEmpty(), req.Phone)
+	diff.set("role", existing.Role, req.Role)
+	c.apply = func(ctx context.Context) error {
+		_, err := s.userSvc.Update(ctx, ac, existing.ID, req)
+		return err
+	}
+	return planUpsert(c, diff), nil
+}
+
+func (s *CSVImportService) planTeam(ac AuditContext, bySlug map[string]*models.Team, values map[string]string) *csvChange {
+	req := CreateTeamRequest{
+		Name:         values["name"],
+		Slug:         values["slug"],
+		Description:  values["description"],
+		TeamType:     values["team_type"],
+		ContactEmail: values["contact_email"],
+		ContactSlack: values["contact_slack"],
+	}
+	if req.Slug == "" {
+		req.Slug = generateSlug(req.Name)
+	}
+	c := &csvChange{row: CSVImportRow{Key: req.Slug}}
+	team := &models.Team{OrganizationID: ac.OrgID, Name: req.Name, Slug: req.Slug}
+	if err := team.Validate(); err != nil {
+		c.row.Errors = append(c.row.Errors, err.Error())
+	}
+	validateCSVEmail(&c.row, "contact_email", req.ContactEmail)
+	if len(c.row.Errors) > 0 {
+		return c
+	}
+
+	existing := bySlug[req.Slug]
+	if existing == nil {
+		c.row.Status = CSVRowCreated
+		c.apply = func(ctx context.Context) error {
+			_, err := s.teamSvc.Create(ctx, ac, req)
+			return err
+		}
+		return c
+	}
+
+	diff := newFieldDiff()
+	diff.set("name", existing.Name, req.Name)
+	diff.set("description", existing.Description.ValueOrEmpty(), req.Description)
+	diff.set("team_type", existing.TeamType, req.TeamType)
+	diff.set("contact_email", existing.ContactEmail.ValueOrEmpty(), req.ContactEmail)
+	diff.set("contact_slack", existing.ContactSlack.ValueOrEmpty(), req.ContactSlack)
+	id := existing.ID
+	c.apply = func(ctx context.Context) error {
+		_, err := s.teamSvc.Update(ctx, ac, id, req)
+		return err
+	}
+	return planUpsert(c, diff)
+}
+
+func (s *CSVImportService) planBusinessUnit(ac AuditContext, byKey map[string]*models.BusinessUnit, values map[string]string) *csvChange {
+	req := CreateBusinessUnitRequest{
+		Name:          values["name"],
+		Code:          values["code"],
+		Description:   values["description"],
+		DirectorName:  values["director_name"],
+		DirectorEmail: values["director_email"],
+		CostCenter:    values["cost_center"],
+	}
+	c := &csvChange{row: CSVImportRow{Key: req.Name}}
+	key := "name:" + strings.ToLower(req.Name)
+	if req.Code != "" {
+		c.row.Key, key = req.Code, "code:"+strings.ToLower(req.Code)
+	}
+	if req.Name == "" {
+		c.row.Errors = append(c.row.Errors, "name is required")
+	}
+	validateCSVEmail(&c.row, "director_email", req.DirectorEmail)
+	if len(c.row.Errors) > 0 {
+		return c
+	}
+
+	existing := byKey[key]
+	if existing == nil && req.Code != "" {
+		// A unit without a code is matched by name, and given the code
+		if unit := byKey["name:"+strings.ToLower(req.Name)]; unit != nil && !unit.Code.Valid {
+			existing = unit
+		}
+	}
+	if existing == nil {
+		c.row.Status = CSVRowCreated
+		c.apply = func(ctx context.Context) error {
+			_, err := s.businessUnitSvc.Create(ctx, ac, req)
+			return err
+		}
+		return c
+	}
+
+	diff := newFieldDiff()
+	diff.set("name", existing.Name, req.Name)
+	diff.set("code", existing.Code.ValueOrEmpty(), req.Code)
+	diff.set("description", existing.Description.ValueOrEmpty(), req.Description)
+	diff.set("director_name", existing.DirectorName.ValueOrEmpty(), req.DirectorName)
+	diff.set("director_email", existing.DirectorEmail.ValueOrEmpty(), req.DirectorEmail)
+	diff.set("cost_center", existing.CostCenter.ValueOrEmpty(), req.CostCenter)
+	id := existing.ID
+	c.apply = func(ctx context.Context) error {
+		_, err := s.businessUnitSvc.Update(ctx, ac, id, req)
+		return err
+	}
+	return planUpsert(c, diff)
+}
@@ -0,0 +1,81 @@
+package services
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/kubeatlas/kubeatlas/internal/models"
+)
+
+func TestCSVColumns(t *testing.T) {
+	fields := csvFields[CSVImportUsers]
+	header := []string{"\ufeffE-Mail", "Full Name", "Department", "ROLE"}
+
+	got, err := csvColumns(header, fields, map[string]string{"email": "e-mail"})
+	if err != nil {
+		t.Fatalf("csvColumns failed: %v", err)
+	}
+	if want := map[string]int{"email": 0, "full_name": 1, "role": 3}; !reflect.DeepEqual(got, want) {
+		t.Errorf("columns = %v, want %v", got, want)
+	}
+
+	for _, mapping := range []map[string]string{
+		nil,                                // no email column
+		{"email": "Mail"},                  // column not in the header
+		{"email": "E-Mail", "team": "Dep"}, // unknown field
+	} {
+		if _, err := csvColumns(header, fields, mapping); !errors.Is(err, ErrInvalidCSVImport) {
+			t.Errorf("csvColumns with mapping %v = %v, want ErrInvalidCSVImport", mapping, err)
+		}
+	}
+}
+
+func TestCSVImportPlanTeam(t *testing.T) {
+	s := &CSVImportService{}
+	ac := AuditContext{OrgID: uuid.New()}
+	existing := &models.Team{Name: "Payments", Slug: "payments", TeamType: "team"}
+	existing.ID = uuid.New()
+	bySlug := map[string]*models.Team{"payments": existing}
+
+	tests := []struct {
+		values map[string]string
+		key    string
+		status string
+		errors int
+	}{
+		{map[string]string{"name": "Payments"}, "payments", CSVRowUnchanged, 0},
+		{map[string]string{"name": "Payments", "contact_email": "pay@example.com"}, "payments", CSVRowUpdated, 0},
+		{map[string]string{"name": "Checkout Team"}, "checkout-team", CSVRowCreated, 0},
+		{map[string]string{"name": "Search", "contact_email": "not an email"}, "search", "", 1},
+		{map[string]string{"name": ""}, "", "", 1},
+	}
+	for _, tt := range tests {
+		c := s.planTeam(ac, bySlug, tt.values)
+		if c.row.Key != tt.key || c.row.Status != tt.status || len(c.row.Errors) != tt.errors {
+			t.Errorf("planTeam(%v) = %+v, want key %q, status %q and %d errors", tt.values, c.row, tt.key, tt.status, tt.errors)
+		}
+		if (c.apply != nil) != (tt.status == CSVRowCreated || tt.status == CSVRowUpdated) {
+			t.Errorf("planTeam(%v) apply = %v", tt.values, c.apply != nil)
+		}
+	}
+}
+
+func TestCSVImportPlanBusinessUnit(t *testing.T) {
+	s := &CSVImportService{}
+	ac := AuditContext{OrgID: uuid.New()}
+	retail := &models.BusinessUnit{Name: "Retail"}
+	retail.ID = uuid.New()
+	byKey := map[string]*models.BusinessUnit{"name:retail": retail}
+
+	// A unit without a code is matched by name and given the code
+	c := s.planBusinessUnit(ac, byKey, map[string]string{"name": "Retail", "code": "RTL"})
+	if c.row.Key != "RTL" || c.row.Status != CSVRowUpdated || len(c.row.Changes) != 1 || c.row.Changes[0].Field != "code" {
+		t.Errorf("planBusinessUnit = %+v, want the code added", c.row)
+	}
+	c = s.planBusinessUnit(ac, byKey, map[string]string{"name": "Wholesale", "code": "WHL"})
+	if c.row.Status != CSVRowCreated {
+		t.Errorf("planBusinessUnit of a new unit = %+v, want created", c.row)
+	}
+}
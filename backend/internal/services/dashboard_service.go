@@ -2,40 +2,60 @@ package services
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 
 	"github.com/google/uuid"
 	"github.com/kubeatlas/kubeatlas/internal/database/repositories"
+	"github.com/kubeatlas/kubeatlas/internal/models"
+	"github.com/kubeatlas/kubeatlas/internal/telemetry"
+	"go.opentelemetry.io/otel/attribute"
 	"go.uber.org/zap"
 )
 
 type DashboardService struct {
-	repos  *Repositories
-	logger *zap.SugaredLogger
+	repos    *Repositories
+	settings *OrgSettingsService
+	logger   *zap.SugaredLogger
 }
 
-func NewDashboardService(repos *Repositories, logger *zap.SugaredLogger) *DashboardService {
-	return &DashboardService{repos: repos, logger: logger}
+func NewDashboardService(repos *Repositories, settings *OrgSettingsService, logger *zap.SugaredLogger) *DashboardService {
+	return &DashboardService{repos: repos, settings: settings, logger: logger}
+}
+
+// environmentDistribution returns namespace counts for every environment of
+// the organization
+func (s *DashboardService) environmentDistribution(ctx context.Context, orgID uuid.UUID, includeArchived bool) ([]models.EnvironmentDistribution, error) {
+	counts, err := s.repos.Namespace.GetEnvironmentDistribution(ctx, orgID, includeArchived)
+	if err != nil {
+		return nil, err
+	}
+	return s.settings.EnvironmentDistribution(ctx, orgID, counts)
 }
 
 // DashboardData represents all dashboard data
 type DashboardData struct {
-	Stats                    map[string]interface{}   `json:"stats"`
-	ClusterStats             map[string]interface{}   `json:"cluster_stats"`
-	EnvironmentDistribution  []map[string]interface{} `json:"environment_distribution"`
-	BusinessUnitDistribution []map[string]interface{} `json:"business_unit_distribution"`
-	RecentNamespaces         []map[string]interface{} `json:"recent_namespaces"`
-	RecentDocuments          []map[string]interface{} `json:"recent_documents"`
-	RecentActivities         []map[string]interface{} `json:"recent_activities"`
-	MissingInfo              map[string]interface{}   `json:"missing_info"`
+	Stats                    map[string]interface{}    `json:"stats"`
+	ClusterStats             map[string]interface{}    `json:"cluster_stats"`
+	EnvironmentDistribution  []map[string]interface{}  `json:"environment_distribution"`
+	BusinessUnitDistribution []map[string]interface{}  `json:"business_unit_distribution"`
+	TeamCompleteness         []models.TeamCompleteness `json:"team_completeness"`
+	RecentNamespaces         []map[string]interface{}  `json:"recent_namespaces"`
+	RecentDocuments          []map[string]interface{}  `json:"recent_documents"`
+	RecentActivities         []map[string]interface{}  `json:"recent_activities"`
+	MissingInfo              map[string]interface{}    `json:"missing_info"`
 }
 
-// GetDashboardData returns all dashboard data
-func (s *DashboardService) GetDashboardData(ctx context.Context, orgID uuid.UUID) (*DashboardData, error) {
+// GetDashboardData returns all dashboard data. Archived namespaces are
+// counted only with includeArchived.
+func (s *DashboardService) GetDashboardData(ctx context.Context, orgID uuid.UUID, includeArchived bool) (*DashboardData, error) {
+	ctx, span := telemetry.StartSpan(ctx, "DashboardService.GetDashboardData", attribute.String("organization_id", orgID.String()))
+	defer span.End()
+
 	data := &DashboardData{}
 
 	// Namespace stats
-	nsStats, err := s.repos.Namespace.GetStats(ctx, orgID)
+	nsStats, err := s.repos.Namespace.GetStats(ctx, orgID, includeArchived)
 	if err == nil && nsStats != nil {
 		data.Stats = map[string]interface{}{
 			"total_namespaces":        nsStats.TotalNamespaces,
@@ -54,6 +74,9 @@ func (s *DashboardService) GetDashboardData(ctx context.Context, orgID uuid.UUID
 			"no_deps":      nsStats.NoDepsNamespaces,
 			"no_bu":        nsStats.NoBusinessUnit,
 		}
+		if invalid, err := s.repos.ContactValidation.CountNamespaces(ctx, orgID); err == nil {
+			data.MissingInfo["invalid_contacts"] = invalid
+		}
 	}
 
 	// Cluster stats
@@ -63,7 +86,7 @@ func (s *DashboardService) GetDashboardData(ctx context.Context, orgID uuid.UUID
 	}
 
 	// Environment distribution
-	envDist, err := s.repos.Namespace.GetEnvironmentDistribution(ctx, orgID)
+	envDist, err := s.environmentDistribution(ctx, orgID, includeArchived)
 	if err == nil {
 		data.EnvironmentDistribution = make([]map[string]interface{}, len(envDist))
 		for i, d := range envDist {
@@ -75,7 +98,7 @@ func (s *DashboardService) GetDashboardData(ctx context.Context, orgID uuid.UUID
 	}
 
 	// Business unit distribution
-	buDist, err := s.repos.Namespace.GetBusinessUnitDistribution(ctx, orgID)
+	buDist, err := s.repos.Namespace.GetBusinessUnitDistribution(ctx, orgID, includeArchived)
 	if err == nil {
 		data.BusinessUnitDistribution = make([]map[string]interface{}, len(buDist))
 		for i, d := range buDist {
@@ -87,6 +110,12 @@ func (s *DashboardService) GetDashboardData(ctx context.Context, orgID uuid.UUID
 		}
 	}
 
+	// Average completeness score per owner team
+	teamCompleteness, err := s.repos.Namespace.GetTeamCompleteness(ctx, orgID, includeArchived)
+	if err == nil {
+		data.TeamCompleteness = teamCompleteness
+	}
+
 	// Recent namespaces
 	recentNs, err := s.repos.Namespace.GetRecentlyUpdated(ctx, orgID, 10)
 	if err == nil {
@@ -143,12 +172,16 @@ func (s *DashboardService) GetDashboardData(ctx context.Context, orgID uuid.UUID
 	return data, nil
 }
 
-// GetStats returns summary statistics in format expected by frontend
-func (s *DashboardService) GetStats(ctx context.Context, orgID uuid.UUID) (map[string]interface{}, error) {
+// GetStats returns summary statistics in format expected by frontend.
+// Archived namespaces are counted only with includeArchived.
+func (s *DashboardService) GetStats(ctx context.Context, orgID uuid.UUID, includeArchived bool) (map[string]interface{}, error) {
+	ctx, span := telemetry.StartSpan(ctx, "DashboardService.GetStats", attribute.String("organization_id", orgID.String()))
+	defer span.End()
+
 	stats := make(map[string]interface{})
 
 	// Namespace stats
-	nsStats, err := s.repos.Namespace.GetStats(ctx, orgID)
+	nsStats, err := s.repos.Namespace.GetStats(ctx, orgID, includeArchived)
 	if err == nil && nsStats != nil {
 		stats["total_namespaces"] = nsStats.TotalNamespaces
 		stats["namespaces_with_owner"] = nsStats.NamespacesWithOwner
@@ -176,7 +209,7 @@ func (s *DashboardService) GetStats(ctx context.Context, orgID uuid.UUID) (map[s
 	}
 
 	// Environment distribution for chart
-	envDist, err := s.repos.Namespace.GetEnvironmentDistribution(ctx, orgID)
+	envDist, err := s.environmentDistribution(ctx, orgID, includeArchived)
 	if err == nil {
 		envData := make([]map[string]interface{}, len(envDist))
 		for i, d := range envDist {
@@ -188,11 +221,20 @@ func (s *DashboardService) GetStats(ctx context.Context, orgID uuid.UUID) (map[s
 		stats["environment_distribution"] = envData
 	}
 
+	// Average completeness score per owner team
+	teamCompleteness, err := s.repos.Namespace.GetTeamCompleteness(ctx, orgID, includeArchived)
+	if err == nil {
+		stats["team_completeness"] = teamCompleteness
+	}
+
 	return stats, nil
 }
 
 // GetMissingInfo returns namespaces with missing information
 func (s *DashboardService) GetMissingInfo(ctx context.Context, orgID uuid.UUID) (map[string]interface{}, error) {
+	ctx, span := telemetry.StartSpan(ctx, "DashboardService.GetMissingInfo", attribute.String("organization_id", orgID.String()))
+	defer span.End()
+
 	result := make(map[string]interface{})
 	
 	// Get all clusters for lookup
@@ -207,9 +249,9 @@ func (s *DashboardService) GetMissingInfo(ctx context.Context, orgID uuid.UUID)
 			}
 		}
 	}
-	
+
 	// Get orphaned namespaces (no owner)
-	orphanedResult, err := s.repos.Namespace.List(ctx, orgID, repositories.Pagination{Page: 1, PageSize: 50}, map[string]interface{}{"orphaned": true})
+	orphanedResult, err := s.repos.Namespace.List(ctx, orgID, repositories.Pagination{Page: 1, PageSize: 50}, map[string]interface{}{"orphaned": true, "archived": false})
 	if err != nil {
 		s.logger.Errorf("GetMissingInfo: failed to get orphaned namespaces: %v", err)
 	}
@@ -231,9 +273,9 @@ func (s *DashboardService) GetMissingInfo(ctx context.Context, orgID uuid.UUID)
 	} else {
 		result["orphaned"] = []map[string]interface{}{}
 	}
-	
+
 	// Get undocumented namespaces
-	undocResult, err := s.repos.Namespace.List(ctx, orgID, repositories.Pagination{Page: 1, PageSize: 50}, map[string]interface{}{"undocumented": true})
+	undocResult, err := s.repos.Namespace.List(ctx, orgID, repositories.Pagination{Page: 1, PageSize: 50}, map[string]interface{}{"undocumented": true, "archived": false})
 	if err != nil {
 		s.logger.Errorf("GetMissingInfo: failed to get undocumented namespaces: %v", err)
 	}
@@ -255,9 +297,9 @@ func (s *DashboardService) GetMissingInfo(ctx context.Context, orgID uuid.UUID)
 	} else {
 		result["undocumented"] = []map[string]interface{}{}
 	}
-	
+
 	// Get namespaces without business unit
-	noBuResult, err := s.repos.Namespace.List(ctx, orgID, repositories.Pagination{Page: 1, PageSize: 50}, map[string]interface{}{"no_business_unit": true})
+	noBuResult, err := s.repos.Namespace.List(ctx, orgID, repositories.Pagination{Page: 1, PageSize: 50}, map[string]interface{}{"no_business_unit": true, "archived": false})
 	if err == nil && noBuResult != nil {
 		noBuList := make([]map[string]interface{}, len(noBuResult.Items))
 		for i, ns := range noBuResult.Items {
@@ -271,7 +313,29 @@ func (s *DashboardService) GetMissingInfo(ctx context.Context, orgID uuid.UUID)
 	} else {
 		result["no_business_unit"] = []map[string]interface{}{}
 	}
-	
+
+	// Get namespaces whose contacts failed validation
+	issues, err := s.repos.ContactValidation.ListIssues(ctx, orgID, 50)
+	if err != nil {
+		s.logger.Errorf("GetMissingInfo: failed to get contact issues: %v", err)
+	}
+	invalidList := make([]map[string]interface{}, len(issues))
+	for i, issue := range issues {
+		item := map[string]interface{}{
+			"id":         issue.NamespaceID,
+			"name":       issue.NamespaceName,
+			"cluster_id": issue.ClusterID,
+			"role":       issue.Role,
+			"email":      issue.Email,
+			"reason":     issue.Reason,
+		}
+		if cluster, ok := clusterMap[issue.ClusterID]; ok {
+			item["cluster"] = cluster
+		}
+		invalidList[i] = item
+	}
+	result["invalid_contacts"] = invalidList
+
 	return result, nil
 }
 
@@ -307,8 +371,8 @@ func (s *DashboardService) GetRecentActivities(ctx context.Context, orgID uuid.U
 }
 
 // GetOwnershipCoverage returns ownership coverage report
-func (s *DashboardService) GetOwnershipCoverage(ctx context.Context, orgID uuid.UUID) (map[string]interface{}, error) {
-	nsStats, err := s.repos.Namespace.GetStats(ctx, orgID)
+func (s *DashboardService) GetOwnershipCoverage(ctx context.Context, orgID uuid.UUID, includeArchived bool) (map[string]interface{}, error) {
+	nsStats, err := s.repos.Namespace.GetStats(ctx, orgID, includeArchived)
 	if err != nil {
 		return nil, err
 	}
@@ -327,8 +391,8 @@ func (s *DashboardService) GetOwnershipCoverage(ctx context.Context, orgID uuid.
 }
 
 // GetOrphanedResources returns orphaned resources report
-func (s *DashboardService) GetOrphanedResources(ctx context.Context, orgID uuid.UUID) (map[string]interface{}, error) {
-	nsStats, err := s.repos.Namespace.GetStats(ctx, orgID)
+func (s *DashboardService) GetOrphanedResources(ctx context.Context, orgID uuid.UUID, includeArchived bool) (map[string]interface{}, error) {
+	nsStats, err := s.repos.Namespace.GetStats(ctx, orgID, includeArchived)
 	if err != nil {
 		return nil, err
 	}
@@ -365,7 +429,7 @@ func (s *DashboardService) ExportReport(ctx context.Context, orgID uuid.UUID, re
 
 	switch reportType {
 	case "ownership":
-		report, err := s.GetOwnershipCoverage(ctx, orgID)
+		report, err := s.GetOwnershipCoverage(ctx, orgID, false)
 		if err != nil {
 			return nil, "", "", err
 		}
@@ -378,7 +442,7 @@ func (s *DashboardService) ExportReport(ctx context.Context, orgID uuid.UUID, re
 				stringify(report["coverage_percentage"]) + "\n")
 		}
 	case "orphaned":
-		report, err := s.GetOrphanedResources(ctx, orgID)
+		report, err := s.GetOrphanedResources(ctx, orgID, false)
 		if err != nil {
 			return nil, "", "", err
 		}
@@ -391,6 +455,45 @@ func (s *DashboardService) ExportReport(ctx context.Context, orgID uuid.UUID, re
 				stringify(report["no_deps_namespaces"]) + "," +
 				stringify(report["no_business_unit"]) + "\n")
 		}
+	case "pod_security":
+		report, err := s.GetPodSecurityReport(ctx, orgID)
+		if err != nil {
+			return nil, "", "", err
+		}
+		if format == "json" {
+			data, err = json.Marshal(map[string]interface{}{"report": "pod_security", "data": report})
+		} else {
+			data, err = report.CSV()
+		}
+		if err != nil {
+			return nil, "", "", err
+		}
+	case "kubernetes_versions":
+		report, err := s.GetKubernetesVersionReport(ctx, orgID)
+		if err != nil {
+			return nil, "", "", err
+		}
+		if format == "json" {
+			data, err = json.Marshal(map[string]interface{}{"report": "kubernetes_versions", "data": report})
+		} else {
+			data, err = report.CSV()
+		}
+		if err != nil {
+			return nil, "", "", err
+		}
+	case "metadata_policy":
+		report, err := s.GetMetadataPolicyReport(ctx, orgID)
+		if err != nil {
+			return nil, "", "", err
+		}
+		if format == "json" {
+			data, err = json.Marshal(map[string]interface{}{"report": "metadata_policy", "data": report})
+		} else {
+			data, err = report.CSV()
+		}
+		if err != nil {
+			return nil, "", "", err
+		}
 	default:
 		data = []byte("Report not implemented")
 	}
@@ -3,6 +3,7 @@ package services
 import (
 	"context"
 	"errors"
+	"fmt"
 
 	"github.com/google/uuid"
 	"github.com/kubeatlas/kubeatlas/internal/database/repositories"
@@ -10,17 +11,22 @@ import (
 	"go.uber.org/zap"
 )
 
-var ErrDependencyNotFound = errors.New("dependency not found")
+var (
+	ErrDependencyNotFound      = errors.New("dependency not found")
+	ErrInvalidDependencyStatus = errors.New("status must be active, degraded or down")
+)
 
 type DependencyService struct {
 	internalRepo *repositories.InternalDependencyRepository
 	externalRepo *repositories.ExternalDependencyRepository
 	auditSvc     *AuditService
+	escalations  *EscalationService
+	alerts       *OpsgenieService
 	logger       *zap.SugaredLogger
 }
 
-func NewDependencyService(internalRepo *repositories.InternalDependencyRepository, externalRepo *repositories.ExternalDependencyRepository, auditSvc *AuditService, logger *zap.SugaredLogger) *DependencyService {
-	return &DependencyService{internalRepo: internalRepo, externalRepo: externalRepo, auditSvc: auditSvc, logger: logger}
+func NewDependencyService(internalRepo *repositories.InternalDependencyRepository, externalRepo *repositories.ExternalDependencyRepository, auditSvc *AuditService, escalations *EscalationService, logger *zap.SugaredLogger) *DependencyService {
+	return &DependencyService{internalRepo: internalRepo, externalRepo: externalRepo, auditSvc: auditSvc, escalations: escalations, logger: logger}
 }
 
 // Internal Dependency
@@ -30,6 +36,10 @@ type CreateInternalDependencyRequest struct {
 	DependencyType    string    `json:"dependency_type" binding:"required"`
 	Description       string    `json:"description"`
 	IsCritical        bool      `json:"is_critical"`
+
+	// DiscoveryMethod records how the dependency was found, for
+	// dependencies created by imports rather than by users
+	DiscoveryMethod string `json:"-"`
 }
 
 func (s *DependencyService) CreateInternal(ctx context.Context, ac AuditContext, req CreateInternalDependencyRequest) (*models.InternalDependency, error) {
@@ -45,6 +55,9 @@ func (s *DependencyService) CreateInternal(ctx context.Context, ac AuditContext,
 	if req.Description != "" {
 		dep.Description = models.NewNullStringFromString(req.Description)
 	}
+	if req.DiscoveryMethod != "" {
+		dep.DiscoveryMethod = models.NewNullStringFromString(req.DiscoveryMethod)
+	}
 
 	if err := s.internalRepo.Create(ctx, dep); err != nil {
 		return nil, err
@@ -175,6 +188,58 @@ func (s *DependencyService) UpdateExternal(ctx context.Context, ac AuditContext,
 	return dep, nil
 }
 
+// SetExternalStatus records the status of an external dependency. A critical
+// dependency going down escalates through its namespace's escalation path
+// until acknowledged and, when enabled, opens an Opsgenie alert; coming back
+// resolves the escalation and closes the alert.
+func (s *DependencyService) SetExternalStatus(ctx context.Context, ac AuditContext, id uuid.UUID, status string) (*models.ExternalDependency, error) {
+	switch status {
+	case models.DependencyStatusActive, models.DependencyStatusDegraded, models.DependencyStatusDown:
+	default:
+		return nil, ErrInvalidDependencyStatus
+	}
+
+	dep, err := s.externalRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if dep == nil || dep.OrganizationID != ac.OrgID {
+		return nil, ErrDependencyNotFound
+	}
+
+	previous, err := s.externalRepo.UpdateStatus(ctx, id, status)
+	if err != nil {
+		return nil, err
+	}
+	dep.Status = status
+	if previous != status {
+		s.auditSvc.LogUpdate(ctx, ac, "external_dependency", dep.ID, dep.Name,
+			map[string]interface{}{"status": previous}, map[string]interface{}{"status": status})
+	}
+
+	if status == models.DependencyStatusDown {
+		if dep.IsCritical {
+			s.escalations.Raise(ctx, dep.NamespaceID, models.EscalationReasonDependencyDown, "external_dependency", dep.ID,
+				fmt.Sprintf("Critical external dependency %s is down", dep.Name))
+			if previous != status {
+				s.alerts.DependencyDown(ctx, dep)
+			}
+		}
+	} else {
+		s.escalations.Resolve(ctx, "external_dependency", dep.ID, models.EscalationReasonDependencyDown)
+		if previous == models.DependencyStatusDown && dep.IsCritical {
+			s.alerts.DependencyRecovered(ctx, dep)
+		}
+	}
+	return dep, nil
+}
+
+// SetAlerts opens Opsgenie alerts for critical external dependencies that go
+// down
+func (s *DependencyService) SetAlerts(alerts *OpsgenieService) {
+	s.alerts = alerts
+}
+
 func (s *DependencyService) GetAllByNamespace(ctx context.Context, namespaceID uuid.UUID) (map[string]interface{}, error) {
 	internal, _ := s.ListInternalByNamespace(ctx, namespaceID)
 	external, _ := s.ListExternalByNamespace(ctx, namespaceID)
@@ -182,13 +247,13 @@ func (s *DependencyService) GetAllByNamespace(ctx context.Context, namespaceID u
 }
 
 // ListInternal returns all internal dependencies for an organization with pagination
-func (s *DependencyService) ListInternal(ctx context.Context, orgID uuid.UUID, p repositories.Pagination) (*repositories.PaginatedResult[models.InternalDependency], error) {
-	return s.internalRepo.List(ctx, orgID, p)
+func (s *DependencyService) ListInternal(ctx context.Context, orgID uuid.UUID, p repositories.Pagination, filters map[string]interface{}) (*repositories.PaginatedResult[models.InternalDependency], error) {
+	return s.internalRepo.List(ctx, orgID, p, filters)
 }
 
 // ListExternal returns all external dependencies for an organization with pagination
-func (s *DependencyService) ListExternal(ctx context.Context, orgID uuid.UUID, p repositories.Pagination) (*repositories.PaginatedResult[models.ExternalDependency], error) {
-	return s.externalRepo.List(ctx, orgID, p)
+func (s *DependencyService) ListExternal(ctx context.Context, orgID uuid.UUID, p repositories.Pagination, filters map[string]interface{}) (*repositories.PaginatedResult[models.ExternalDependency], error) {
+	return s.externalRepo.List(ctx, orgID, p, filters)
 }
 
 // GetGraph returns dependency graph for a namespace
@@ -248,7 +313,7 @@ func (s *DependencyService) GetGraph(ctx context.Context, namespaceID uuid.UUID)
 // GetDependencyMatrix returns a dependency matrix for the organization
 func (s *DependencyService) GetDependencyMatrix(ctx context.Context, orgID uuid.UUID) (map[string]interface{}, error) {
 	// Get all internal dependencies
-	result, err := s.internalRepo.List(ctx, orgID, repositories.Pagination{Page: 1, PageSize: 1000})
+	result, err := s.internalRepo.List(ctx, orgID, repositories.Pagination{Page: 1, PageSize: 1000}, nil)
 	if err != nil {
 		return nil, err
 	}
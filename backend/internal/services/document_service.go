@@ -3,58 +3,199 @@ package services
 import (
 	"context"
 	"errors"
+	"fmt"
 	"io"
+	"mime"
 	"mime/multipart"
+	"net/http"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/google/uuid"
 	"github.com/kubeatlas/kubeatlas/internal/database/repositories"
+	"github.com/kubeatlas/kubeatlas/internal/doctext"
 	"github.com/kubeatlas/kubeatlas/internal/models"
 	"go.uber.org/zap"
 )
 
-var ErrDocumentNotFound = errors.New("document not found")
+var (
+	ErrDocumentNotFound   = errors.New("document not found")
+	ErrFileTooLarge       = errors.New("file exceeds the upload size limit")
+	ErrFileTypeNotAllowed = errors.New("file type is not allowed")
+)
+
+// MaxUploadSizeMB is the largest upload limit an organization can configure
+const MaxUploadSizeMB = 1024
+
+// UploadSettings limit document uploads, stored in
+// organizations.settings["uploads"]
+type UploadSettings struct {
+	MaxSizeMB int `json:"max_size_mb"`
+	// AllowedMIMETypes such as "application/pdf" or "image/*"; empty allows
+	// every type
+	AllowedMIMETypes []string `json:"allowed_mime_types"`
+	// BlockedExtensions such as ".exe" are rejected whatever their type
+	BlockedExtensions []string `json:"blocked_extensions"`
+}
+
+func (u *UploadSettings) validate() error {
+	if u.MaxSizeMB < 1 || u.MaxSizeMB > MaxUploadSizeMB {
+		return fmt.Errorf("max_size_mb must be between 1 and %d", MaxUploadSizeMB)
+	}
+	for i, t := range u.AllowedMIMETypes {
+		t = strings.ToLower(strings.TrimSpace(t))
+		major, minor, ok := strings.Cut(t, "/")
+		if !ok || major == "" || major == "*" || minor == "" || strings.ContainsAny(t, " ;") {
+			return fmt.Errorf("allowed_mime_types: invalid MIME type %q", t)
+		}
+		u.AllowedMIMETypes[i] = t
+	}
+	for i, ext := range u.BlockedExtensions {
+		ext = strings.ToLower(strings.TrimSpace(ext))
+		if ext == "" || ext == "." {
+			return errors.New("blocked_extensions cannot contain empty extensions")
+		}
+		if !strings.HasPrefix(ext, ".") {
+			ext = "." + ext
+		}
+		u.BlockedExtensions[i] = ext
+	}
+	return nil
+}
+
+// allows reports whether a file of the given name, size and MIME type may be
+// uploaded
+func (u *UploadSettings) allows(filename string, size int64, mimeType string) error {
+	if size > int64(u.MaxSizeMB)<<20 {
+		return fmt.Errorf("%w of %d MB", ErrFileTooLarge, u.MaxSizeMB)
+	}
+
+	ext := strings.ToLower(filepath.Ext(filename))
+	for _, blocked := range u.BlockedExtensions {
+		if ext == blocked {
+			return fmt.Errorf("%w: %s files are blocked", ErrFileTypeNotAllowed, ext)
+		}
+	}
+
+	if len(u.AllowedMIMETypes) == 0 {
+		return nil
+	}
+	for _, allowed := range u.AllowedMIMETypes {
+		if allowed == mimeType {
+			return nil
+		}
+		if major, ok := strings.CutSuffix(allowed, "/*"); ok && strings.HasPrefix(mimeType, major+"/") {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s is not an allowed MIME type", ErrFileTypeNotAllowed, mimeType)
+}
+
+// UploadsSetting allows files of up to 50 MB of any type except executables
+// and scripts by default
+var UploadsSetting = SettingKey[UploadSettings]{
+	Name: "uploads",
+	Default: UploadSettings{
+		MaxSizeMB:         50,
+		BlockedExtensions: []string{".exe", ".dll", ".msi", ".bat", ".cmd", ".com", ".scr", ".ps1", ".vbs", ".jar"},
+	},
+	Validate: (*UploadSettings).validate,
+}
+
+// detectMIMEType returns the media type of an upload, sniffed from its first
+// bytes. Generic results such as zip archives or plain text are refined by
+// the file extension, so that office documents and markdown keep their type.
+func detectMIMEType(filename string, head []byte) string {
+	sniffed, _, _ := mime.ParseMediaType(http.DetectContentType(head))
+	switch sniffed {
+	case "application/octet-stream", "application/zip", "text/plain":
+		if byExt := mime.TypeByExtension(filepath.Ext(filename)); byExt != "" {
+			if mediaType, _, err := mime.ParseMediaType(byExt); err == nil {
+				return mediaType
+			}
+		}
+	}
+	return sniffed
+}
 
 type DocumentService struct {
-	repo       *repositories.DocumentRepository
-	auditSvc   *AuditService
-	logger     *zap.SugaredLogger
-	uploadPath string
+	repo          *repositories.DocumentRepository
+	settings      *OrgSettingsService
+	auditSvc      *AuditService
+	notifications *NotificationService
+	webhooks      *WebhookService
+	logger        *zap.SugaredLogger
+	uploadPath    string
 }
 
-func NewDocumentService(repo *repositories.DocumentRepository, auditSvc *AuditService, logger *zap.SugaredLogger) *DocumentService {
+func NewDocumentService(repo *repositories.DocumentRepository, settings *OrgSettingsService, auditSvc *AuditService, notifications *NotificationService, webhooks *WebhookService, logger *zap.SugaredLogger) *DocumentService {
 	uploadPath := os.Getenv("STORAGE_LOCAL_PATH")
 	if uploadPath == "" {
 		uploadPath = "./data/uploads"
 	}
 	os.MkdirAll(uploadPath, 0755)
 
-	return &DocumentService{repo: repo, auditSvc: auditSvc, logger: logger, uploadPath: uploadPath}
+	return &DocumentService{repo: repo, settings: settings, auditSvc: auditSvc, notifications: notifications, webhooks: webhooks, logger: logger, uploadPath: uploadPath}
 }
 
 type UploadDocumentRequest struct {
-	NamespaceID *uuid.UUID `form:"namespace_id"`
-	ClusterID   *uuid.UUID `form:"cluster_id"`
-	Name        string     `form:"name" binding:"required"`
-	Description string     `form:"description"`
-	CategoryID  *uuid.UUID `form:"category_id"`
-	Tags        []string   `form:"tags"`
+	NamespaceID   *uuid.UUID `form:"namespace_id"`
+	ClusterID     *uuid.UUID `form:"cluster_id"`
+	ApplicationID *uuid.UUID `form:"application_id"`
+	Name          string     `form:"name" binding:"required"`
+	Description   string     `form:"description"`
+	CategoryID    *uuid.UUID `form:"category_id"`
+	Tags          []string   `form:"tags"`
+}
+
+// uploadOverhead allows for the multipart boundaries and form fields sent
+// alongside the file
+const uploadOverhead = 1 << 20
+
+// MaxRequestSize returns the largest upload request body the organization
+// accepts: its file size limit plus room for the rest of the multipart form
+func (s *DocumentService) MaxRequestSize(ctx context.Context, orgID uuid.UUID) (int64, error) {
+	limits, err := GetSetting(ctx, s.settings, orgID, UploadsSetting)
+	if err != nil {
+		return 0, err
+	}
+	return int64(limits.MaxSizeMB)<<20 + uploadOverhead, nil
 }
 
 func (s *DocumentService) Upload(ctx context.Context, ac AuditContext, req UploadDocumentRequest, file *multipart.FileHeader) (*models.Document, error) {
-	// Generate unique filename
-	ext := filepath.Ext(file.Filename)
-	uniqueName := uuid.New().String() + ext
-	filePath := filepath.Join(s.uploadPath, uniqueName)
+	limits, err := GetSetting(ctx, s.settings, ac.OrgID, UploadsSetting)
+	if err != nil {
+		return nil, err
+	}
 
-	// Save file
 	src, err := file.Open()
 	if err != nil {
 		return nil, err
 	}
 	defer src.Close()
 
+	// Detect MIME type from the content rather than the client's Content-Type
+	head := make([]byte, 512)
+	n, err := io.ReadFull(src, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return nil, err
+	}
+	mimeType := detectMIMEType(file.Filename, head[:n])
+
+	if err := limits.allows(file.Filename, file.Size, mimeType); err != nil {
+		return nil, err
+	}
+	if _, err := src.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	// Generate unique filename
+	ext := filepath.Ext(file.Filename)
+	uniqueName := uuid.New().String() + ext
+	filePath := filepath.Join(s.uploadPath, uniqueName)
+
+	// Save file
 	dst, err := os.Create(filePath)
 	if err != nil {
 		return nil, err
@@ -66,17 +207,12 @@ func (s *DocumentService) Upload(ctx context.Context, ac AuditContext, req Uploa
 		return nil, err
 	}
 
-	// Detect MIME type
-	mimeType := file.Header.Get("Content-Type")
-	if mimeType == "" {
-		mimeType = "application/octet-stream"
-	}
-
 	// Create document record
 	doc := &models.Document{
 		OrganizationID: ac.OrgID,
 		NamespaceID:    req.NamespaceID,
 		ClusterID:      req.ClusterID,
+		ApplicationID:  req.ApplicationID,
 		Name:           req.Name,
 		FileName:       file.Filename,
 		FilePath:       filePath,
@@ -101,10 +237,61 @@ func (s *DocumentService) Upload(ctx context.Context, ac AuditContext, req Uploa
 
 	s.auditSvc.LogCreate(ctx, ac, "document", doc.ID, doc.Name, nil)
 	s.logger.Infow("Document uploaded", "id", doc.ID, "name", doc.Name, "size", doc.FileSize)
+	if err := s.extractText(ctx, doc); err != nil {
+		// The text extraction job retries
+		s.logger.Warnw("Failed to save document text", "id", doc.ID, "error", err)
+	}
+	s.notifications.NotifyDocumentUploaded(ctx, doc, ac.UserEmail)
+	s.webhooks.Publish(ctx, doc.OrganizationID, models.WebhookEventDocumentUploaded, doc)
 
 	return doc, nil
 }
 
+// textExtractionBatchSize is the number of documents a text extraction run
+// reads at most
+const textExtractionBatchSize = 100
+
+// ExtractPendingText saves the text of documents uploaded before text
+// extraction, or whose text could not be saved on upload. Run as a
+// background job.
+func (s *DocumentService) ExtractPendingText(ctx context.Context) error {
+	docs, err := s.repo.ListTextPending(ctx, textExtractionBatchSize)
+	if err != nil {
+		return err
+	}
+	for i := range docs {
+		if err := s.extractText(ctx, &docs[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// extractText saves the text of a document's file, page by page, for global
+// search. Files without extractable text, missing or unreadable ones are
+// saved without pages so they are not tried again.
+func (s *DocumentService) extractText(ctx context.Context, doc *models.Document) error {
+	var pages []string
+	if doctext.Supported(doc.MimeType) {
+		var err error
+		pages, err = readText(doc)
+		if err != nil {
+			s.logger.Warnw("Failed to extract document text", "id", doc.ID, "name", doc.Name, "error", err)
+		}
+	}
+	return s.repo.SavePages(ctx, doc.ID, doc.OrganizationID, pages)
+}
+
+// readText extracts the text of a document's file
+func readText(doc *models.Document) ([]string, error) {
+	f, err := os.Open(doc.FilePath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return doctext.Extract(f, doc.FileSize, doc.MimeType)
+}
+
 func (s *DocumentService) GetByID(ctx context.Context, id uuid.UUID) (*models.Document, error) {
 	doc, err := s.repo.GetByID(ctx, id)
 	if err != nil {
@@ -141,16 +328,14 @@ func (s *DocumentService) Delete(ctx context.Context, ac AuditContext, id uuid.U
 		return ErrDocumentNotFound
 	}
 
-	// Delete file
-	if doc.FilePath != "" {
-		os.Remove(doc.FilePath)
-	}
-
+	// The file is kept so the document can be restored; data retention
+	// removes it when the document is purged
 	if err := s.repo.Delete(ctx, id); err != nil {
 		return err
 	}
 
 	s.auditSvc.LogDelete(ctx, ac, "document", id, doc.Name)
+	s.webhooks.Publish(ctx, doc.OrganizationID, models.WebhookEventDocumentDeleted, doc)
 	return nil
 }
 
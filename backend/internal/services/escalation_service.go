@@ -0,0 +1,213 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kubeatlas/kubeatlas/internal/database/repositories"
+	"github.com/kubeatlas/kubeatlas/internal/escalation"
+	"github.com/kubeatlas/kubeatlas/internal/models"
+	"github.com/kubeatlas/kubeatlas/internal/telemetry"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrEscalationNotFound = errors.New("escalation not found")
+	ErrEscalationNotOpen  = errors.New("escalation is not open")
+)
+
+const (
+	// escalationBatchSize is the number of due escalations handled per run
+	escalationBatchSize = 50
+	// escalationClaimTimeout is how long a claimed escalation is left alone
+	// before another run picks it up again
+	escalationClaimTimeout = 5 * time.Minute
+)
+
+// EscalationService raises alerts that walk a namespace's escalation path,
+// notifying one level after another until someone acknowledges the alert or
+// its cause is resolved
+type EscalationService struct {
+	repo          *repositories.EscalationRepository
+	namespaceRepo *repositories.NamespaceRepository
+	notifications *NotificationService
+	auditSvc      *AuditService
+	logger        *zap.SugaredLogger
+}
+
+// NewEscalationService creates a new escalation service
+func NewEscalationService(
+	repo *repositories.EscalationRepository,
+	namespaceRepo *repositories.NamespaceRepository,
+	notifications *NotificationService,
+	auditSvc *AuditService,
+	logger *zap.SugaredLogger,
+) *EscalationService {
+	return &EscalationService{
+		repo:          repo,
+		namespaceRepo: namespaceRepo,
+		notifications: notifications,
+		auditSvc:      auditSvc,
+		logger:        logger,
+	}
+}
+
+// Raise opens an escalation about a resource of a namespace and notifies the
+// first level of the namespace's escalation path. It does nothing while the
+// resource has an unresolved escalation for the same reason.
+func (s *EscalationService) Raise(ctx context.Context, namespaceID uuid.UUID, reason, resourceType string, resourceID uuid.UUID, summary string) {
+	if s == nil {
+		return
+	}
+
+	ns, err := s.namespaceRepo.GetByID(ctx, namespaceID)
+	if err != nil || ns == nil {
+		s.logger.Errorw("Failed to load namespace for escalation", "namespace_id", namespaceID, "error", err)
+		return
+	}
+	levels := s.levels(ns)
+
+	e := &models.Escalation{
+		OrganizationID: ns.OrganizationID,
+		NamespaceID:    ns.ID,
+		Reason:         reason,
+		ResourceType:   resourceType,
+		ResourceID:     resourceID,
+		Summary:        summary,
+		Level:          1,
+	}
+	if len(levels) > 1 {
+		e.NextEscalationAt = models.NullTime{Time: time.Now().Add(levels[1].Delay), Valid: true}
+	}
+
+	created, err := s.repo.Create(ctx, e)
+	if err != nil {
+		s.logger.Errorw("Failed to open escalation", "resource_type", resourceType, "resource_id", resourceID, "error", err)
+		telemetry.CaptureError(ctx, err)
+		return
+	}
+	if !created {
+		return
+	}
+
+	s.logger.Infow("Escalation opened", "escalation_id", e.ID, "reason", reason, "namespace_id", ns.ID)
+	s.notifications.NotifyEscalation(ctx, e, ns, levelAt(levels, 0), 1, len(levels))
+}
+
+// Resolve closes the resource's unresolved escalation for reason, if any
+func (s *EscalationService) Resolve(ctx context.Context, resourceType string, resourceID uuid.UUID, reason string) {
+	if s == nil {
+		return
+	}
+
+	resolved, err := s.repo.Resolve(ctx, resourceType, resourceID, reason)
+	if err != nil {
+		s.logger.Errorw("Failed to resolve escalation", "resource_type", resourceType, "resource_id", resourceID, "error", err)
+		return
+	}
+	if resolved {
+		s.logger.Infow("Escalation resolved", "resource_type", resourceType, "resource_id", resourceID, "reason", reason)
+	}
+}
+
+// ProcessDue notifies the next level of open escalations that were not
+// acknowledged in time
+func (s *EscalationService) ProcessDue(ctx context.Context) error {
+	due, err := s.repo.ClaimDue(ctx, escalationBatchSize, escalationClaimTimeout)
+	if err != nil {
+		return err
+	}
+
+	for i := range due {
+		e := &due[i]
+
+		ns, err := s.namespaceRepo.GetByID(ctx, e.NamespaceID)
+		if err != nil {
+			s.logger.Errorw("Failed to load namespace for escalation", "escalation_id", e.ID, "error", err)
+			continue
+		}
+
+		// The path may have been shortened since the escalation was opened
+		var levels []escalation.Level
+		if ns != nil {
+			levels = s.levels(ns)
+		}
+		if e.Level >= len(levels) {
+			if err := s.repo.SetLevel(ctx, e.ID, e.Level, nil); err != nil {
+				s.logger.Errorw("Failed to stop escalation", "escalation_id", e.ID, "error", err)
+			}
+			continue
+		}
+
+		s.notifications.NotifyEscalation(ctx, e, ns, levels[e.Level], e.Level+1, len(levels))
+
+		e.Level++
+		var next *time.Time
+		if e.Level < len(levels) {
+			t := e.CreatedAt.Add(levels[e.Level].Delay)
+			next = &t
+		}
+		if err := s.repo.SetLevel(ctx, e.ID, e.Level, next); err != nil {
+			s.logger.Errorw("Failed to record escalation level", "escalation_id", e.ID, "error", err)
+		}
+	}
+	return nil
+}
+
+// Acknowledge stops an open escalation from reaching further levels
+func (s *EscalationService) Acknowledge(ctx context.Context, ac AuditContext, id uuid.UUID) (*models.Escalation, error) {
+	e, err := s.GetByID(ctx, ac.OrgID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	acknowledged, err := s.repo.Acknowledge(ctx, id, ac.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if !acknowledged {
+		return nil, ErrEscalationNotOpen
+	}
+
+	s.auditSvc.LogAction(ctx, ac, "acknowledge", "escalation", e.ID, e.Summary, fmt.Sprintf("Acknowledged at level %d", e.Level))
+	return s.repo.GetByID(ctx, id)
+}
+
+// GetByID returns one of the organization's escalations
+func (s *EscalationService) GetByID(ctx context.Context, orgID, id uuid.UUID) (*models.Escalation, error) {
+	e, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if e == nil || e.OrganizationID != orgID {
+		return nil, ErrEscalationNotFound
+	}
+	return e, nil
+}
+
+// List returns the organization's escalations, newest first
+func (s *EscalationService) List(ctx context.Context, orgID uuid.UUID, p repositories.Pagination, filters map[string]interface{}) (*repositories.PaginatedResult[models.Escalation], error) {
+	return s.repo.List(ctx, orgID, p, filters)
+}
+
+// levels parses the namespace's escalation path. A path that does not parse
+// is treated as empty so alerts still reach the owner team.
+func (s *EscalationService) levels(ns *models.Namespace) []escalation.Level {
+	levels, err := escalation.Parse(ns.EscalationPath.ValueOrEmpty())
+	if err != nil {
+		s.logger.Warnw("Invalid escalation path", "namespace_id", ns.ID, "error", err)
+		return nil
+	}
+	return levels
+}
+
+// levelAt returns level i, or an empty level when the path is shorter
+func levelAt(levels []escalation.Level, i int) escalation.Level {
+	if i < len(levels) {
+		return levels[i]
+	}
+	return escalation.Level{}
+}
@@ -0,0 +1,356 @@
+package services
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kubeatlas/kubeatlas/internal/database/repositories"
+	"github.com/kubeatlas/kubeatlas/internal/models"
+	"github.com/kubeatlas/kubeatlas/internal/objectstore"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrExportUnavailable = errors.New("organization exports are not available")
+	ErrExportInProgress  = errors.New("an export of the organization is already in progress")
+	ErrExportNotFound    = errors.New("export not found")
+	ErrExportNotReady    = errors.New("export is not ready for download")
+)
+
+const (
+	// exportRetention is how long a completed export can be downloaded
+	exportRetention = 7 * 24 * time.Hour
+	// exportStaleAfter is how long a running export may go without progress
+	// before another instance starts it over
+	exportStaleAfter = 15 * time.Minute
+	// exportListLimit is the number of recent exports listed
+	exportListLimit = 20
+)
+
+// ExportService generates archives of all of an organization's data: one
+// JSON file per entity, the organization's settings and the files of its
+// documents. Secrets are never exported.
+type ExportService struct {
+	repo     *repositories.ExportRepository
+	settings *OrgSettingsService
+	auditSvc *AuditService
+	logger   *zap.SugaredLogger
+	store    objectstore.Store
+}
+
+// NewExportService creates a new export service
+func NewExportService(repo *repositories.ExportRepository, settings *OrgSettingsService, auditSvc *AuditService, logger *zap.SugaredLogger) *ExportService {
+	return &ExportService{
+		repo:     repo,
+		settings: settings,
+		auditSvc: auditSvc,
+		logger:   logger,
+	}
+}
+
+// SetStore keeps generated archives in store, which enables exports
+func (s *ExportService) SetStore(store objectstore.Store) {
+	s.store = store
+}
+
+// exportKey is the object key of an export's archive
+func exportKey(orgID, id uuid.UUID) string {
+	return fmt.Sprintf("exports/%s/%s.zip", orgID, id)
+}
+
+// exportFileName is the archive path of a document's file. Only the base
+// of the uploaded name is kept, so entries stay in the document's directory.
+func exportFileName(documentID uuid.UUID, fileName string) string {
+	name := path.Base(strings.ReplaceAll(fileName, "\\", "/"))
+	if name == "." || name == "/" || name == ".." {
+		name = "file"
+	}
+	return fmt.Sprintf("documents/%s/%s", documentID, name)
+}
+
+// ExportManifest describes the contents of an export archive
+type ExportManifest struct {
+	ExportID       uuid.UUID        `json:"export_id"`
+	OrganizationID uuid.UUID        `json:"organization_id"`
+	GeneratedAt    time.Time        `json:"generated_at"`
+	Entities       map[string]int64 `json:"entities"`
+	Documents      int              `json:"documents"`
+	// MissingFiles are documents whose file was no longer stored
+	MissingFiles []uuid.UUID `json:"missing_files"`
+}
+
+// Request queues an export of the caller's organization. Only one export
+// per organization runs at a time.
+func (s *ExportService) Request(ctx context.Context, ac AuditContext) (*models.OrgExport, error) {
+	if s.store == nil {
+		return nil, ErrExportUnavailable
+	}
+
+	export := &models.OrgExport{
+		OrganizationID:   ac.OrgID,
+		RequestedBy:      ac.UserID,
+		RequestedByEmail: ac.UserEmail,
+	}
+	created, err := s.repo.Create(ctx, export)
+	if err != nil {
+		return nil, err
+	}
+	if !created {
+		return nil, ErrExportInProgress
+	}
+
+	s.auditSvc.LogAction(ctx, ac, "export_requested", "organization", ac.OrgID, "organization", "Requested a full organization export")
+	return export, nil
+}
+
+// List retrieves the organization's most recent exports
+func (s *ExportService) List(ctx context.Context, orgID uuid.UUID) ([]models.OrgExport, error) {
+	return s.repo.List(ctx, orgID, exportListLimit)
+}
+
+// Get retrieves an export with its progress
+func (s *ExportService) Get(ctx context.Context, orgID, id uuid.UUID) (*models.OrgExport, error) {
+	export, err := s.repo.Get(ctx, orgID, id)
+	if err != nil {
+		return nil, err
+	}
+	if export == nil {
+		return nil, ErrExportNotFound
+	}
+	return export, nil
+}
+
+// Open opens the archive of a completed export for download
+func (s *ExportService) Open(ctx context.Context, ac AuditContext, id uuid.UUID) (io.ReadCloser, *models.OrgExport, error) {
+	if s.store == nil {
+		return nil, nil, ErrExportUnavailable
+	}
+	export, err := s.Get(ctx, ac.OrgID, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if export.Status != models.ExportStatusCompleted || !export.ObjectKey.Valid {
+		return nil, nil, fmt.Errorf("%w: the export is %s", ErrExportNotReady, export.Status)
+	}
+
+	rc, err := s.store.Get(ctx, export.ObjectKey.String)
+	if err != nil {
+		return nil, nil, err
+	}
+	s.auditSvc.LogAction(ctx, ac, "export_downloaded", "organization", ac.OrgID, "organization", "Downloaded organization export "+id.String())
+	return rc, export, nil
+}
+
+// ProcessPending removes expired archives and runs the next queued export,
+// if any. Failed exports are recorded with their error.
+func (s *ExportService) ProcessPending(ctx context.Context) error {
+	if s.store == nil {
+		return nil
+	}
+	if err := s.removeExpired(ctx); err != nil {
+		return err
+	}
+
+	export, err := s.repo.ClaimNext(ctx, exportStaleAfter)
+	if err != nil || export == nil {
+		return err
+	}
+
+	if err := s.run(ctx, export); err != nil {
+		s.logger.Warnw("Organization export failed", "id", export.ID, "organization_id", export.OrganizationID, "error", err)
+		return s.repo.Fail(ctx, export.ID, err.Error())
+	}
+	return nil
+}
+
+// removeExpired deletes the archives of exports past their expiry
+func (s *ExportService) removeExpired(ctx context.Context) error {
+	expired, err := s.repo.ListExpired(ctx)
+	if err != nil {
+		return err
+	}
+	for _, export := range expired {
+		if err := s.store.Delete(ctx, export.ObjectKey.String); err != nil {
+			return fmt.Errorf("failed to remove export %s: %w", export.ID, err)
+		}
+		if err := s.repo.MarkExpired(ctx, export.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// run writes the archive of an export and uploads it. The archive is staged
+// in a temporary file so exports of any size are uploaded with a known
+// length.
+func (s *ExportService) run(ctx context.Context, export *models.OrgExport) error {
+	tmp, err := os.CreateTemp("", "org-export-*.zip")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	manifest := ExportManifest{
+		ExportID:       export.ID,
+		OrganizationID: export.OrganizationID,
+		GeneratedAt:    time.Now().UTC(),
+		Entities:       make(map[string]int64, len(repositories.ExportEntities)),
+		MissingFiles:   make([]uuid.UUID, 0),
+	}
+
+	// Steps are the organization, every entity and the document files
+	steps := len(repositories.ExportEntities) + 2
+	done := 0
+	progress := func(step string) error {
+		err := s.repo.UpdateProgress(ctx, export.ID, done*100/steps, step)
+		done++
+		return err
+	}
+
+	zw := zip.NewWriter(tmp)
+
+	if err := progress("organization"); err != nil {
+		return err
+	}
+	org, err := s.settings.Get(ctx, export.OrganizationID)
+	if err != nil {
+		return err
+	}
+	if err := writeZipJSON(zw, "organization.json", org); err != nil {
+		return err
+	}
+
+	for _, entity := range repositories.ExportEntities {
+		if err := progress(entity); err != nil {
+			return err
+		}
+		n, err := s.writeEntity(ctx, zw, export.OrganizationID, entity)
+		if err != nil {
+			return err
+		}
+		manifest.Entities[entity] = n
+	}
+
+	if err := progress("document files"); err != nil {
+		return err
+	}
+	files, err := s.repo.DocumentFiles(ctx, export.OrganizationID)
+	if err != nil {
+		return err
+	}
+	for _, f := range files {
+		stored, err := addZipFile(zw, exportFileName(f.DocumentID, f.FileName), f.FilePath)
+		if err != nil {
+			return fmt.Errorf("failed to export the file of document %s: %w", f.DocumentID, err)
+		}
+		if stored {
+			manifest.Documents++
+		} else {
+			manifest.MissingFiles = append(manifest.MissingFiles, f.DocumentID)
+		}
+	}
+
+	if err := writeZipJSON(zw, "manifest.json", manifest); err != nil {
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+
+	size, err := tmp.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	key := exportKey(export.OrganizationID, export.ID)
+	if err := s.store.Put(ctx, key, tmp, size, "application/zip"); err != nil {
+		return err
+	}
+	if err := s.repo.Complete(ctx, export.ID, key, size, time.Now().Add(exportRetention)); err != nil {
+		return err
+	}
+
+	var records int64
+	for _, n := range manifest.Entities {
+		records += n
+	}
+	description := fmt.Sprintf("Exported %d records and %d document files, requested by %s", records, manifest.Documents, export.RequestedByEmail)
+	s.auditSvc.LogAction(ctx, AuditContext{OrgID: export.OrganizationID, UserEmail: "system"}, "export", "organization", export.OrganizationID, "organization", description)
+	s.logger.Infow("Exported organization", "id", export.ID, "organization_id", export.OrganizationID, "records", records, "documents", manifest.Documents, "size", size)
+	return nil
+}
+
+// writeEntity writes the organization's rows of an entity as a JSON array
+func (s *ExportService) writeEntity(ctx context.Context, zw *zip.Writer, orgID uuid.UUID, entity string) (int64, error) {
+	w, err := zw.Create(entity + ".json")
+	if err != nil {
+		return 0, err
+	}
+	if _, err := io.WriteString(w, "["); err != nil {
+		return 0, err
+	}
+	var n int64
+	err = s.repo.EachRow(ctx, orgID, entity, func(row json.RawMessage) error {
+		sep := ",\n"
+		if n == 0 {
+			sep = "\n"
+		}
+		n++
+		if _, err := io.WriteString(w, sep); err != nil {
+			return err
+		}
+		_, err := w.Write(row)
+		return err
+	})
+	if err != nil {
+		return n, err
+	}
+	_, err = io.WriteString(w, "\n]\n")
+	return n, err
+}
+
+// writeZipJSON adds v to the archive as an indented JSON file
+func writeZipJSON(zw *zip.Writer, name string, v interface{}) error {
+	w, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// addZipFile copies a stored file into the archive, reporting false when
+// the file no longer exists
+func addZipFile(zw *zip.Writer, name, filePath string) (bool, error) {
+	f, err := os.Open(filePath)
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+
+	w, err := zw.Create(name)
+	if err != nil {
+		return false, err
+	}
+	if _, err := io.Copy(w, f); err != nil {
+		return false, err
+	}
+	return true, nil
+}
@@ -0,0 +1,55 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestExportFileName(t *testing.T) {
+	id := uuid.MustParse("6f1c2a3b-0000-4000-8000-000000000001")
+	tests := map[string]string{
+		"runbook.pdf":       "runbook.pdf",
+		"../../etc/passwd":  "passwd",
+		`C:\docs\design.md`: "design.md",
+		"..":                "file",
+		"":                  "file",
+	}
+	for fileName, want := range tests {
+		if got := exportFileName(id, fileName); got != "documents/"+id.String()+"/"+want {
+			t.Errorf("exportFileName(%q) = %q, want documents/<id>/%s", fileName, got, want)
+		}
+	}
+}
+
+func TestAddZipFile(t *testing.T) {
+	dir := t.TempDir()
+	stored := filepath.Join(dir, "stored")
+	if err := os.WriteFile(stored, []byte("content"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	if ok, err := addZipFile(zw, "documents/a/runbook.pdf", stored); !ok || err != nil {
+		t.Fatalf("addZipFile(stored) = %v, %v, want true", ok, err)
+	}
+	if ok, err := addZipFile(zw, "documents/b/missing.pdf", filepath.Join(dir, "missing")); ok || err != nil {
+		t.Fatalf("addZipFile(missing) = %v, %v, want false without error", ok, err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(zr.File) != 1 || zr.File[0].Name != "documents/a/runbook.pdf" {
+		t.Errorf("archive holds %v, want only the stored file", zr.File)
+	}
+}
@@ -0,0 +1,257 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kubeatlas/kubeatlas/internal/crypto"
+	"github.com/kubeatlas/kubeatlas/internal/database/repositories"
+	"github.com/kubeatlas/kubeatlas/internal/gitrepo"
+	"github.com/kubeatlas/kubeatlas/internal/models"
+	"go.uber.org/zap"
+)
+
+// maxNamespaceRepositories bounds the source repositories linked to one
+// namespace
+const maxNamespaceRepositories = 20
+
+// repositoryCheckInterval is how long what was read about a repository is
+// shown before GitHub or GitLab is asked again
+const repositoryCheckInterval = 6 * time.Hour
+
+// repositoryCheckBatch bounds the repositories one refresh reads, to stay
+// within the providers' rate limits
+const repositoryCheckBatch = 100
+
+// GitService reads the default branch and last commit of the source
+// repositories linked to namespaces from GitHub and GitLab
+type GitService struct {
+	namespaceRepo *repositories.NamespaceRepository
+	userRepo      *repositories.UserRepository
+	inspector     gitrepo.Inspector
+	encryptor     *crypto.Encryptor
+	auditSvc      *AuditService
+	logger        *zap.SugaredLogger
+}
+
+func NewGitService(namespaceRepo *repositories.NamespaceRepository, userRepo *repositories.UserRepository, inspector gitrepo.Inspector, encryptor *crypto.Encryptor, auditSvc *AuditService, logger *zap.SugaredLogger) *GitService {
+	return &GitService{
+		namespaceRepo: namespaceRepo,
+		userRepo:      userRepo,
+		inspector:     inspector,
+		encryptor:     encryptor,
+		auditSvc:      auditSvc,
+		logger:        logger,
+	}
+}
+
+// ============================================
+// Git Settings
+// ============================================
+
+// GitSettings are the tokens the organization reads private repositories
+// with, stored in organizations.settings["git"]. Public repositories are read
+// without them.
+type GitSettings struct {
+	GitHubToken string `json:"github_token,omitempty"`
+	GitLabToken string `json:"gitlab_token,omitempty"`
+}
+
+func (s *GitSettings) gitConfig() gitrepo.Config {
+	return gitrepo.Config{GitHubToken: s.GitHubToken, GitLabToken: s.GitLabToken}
+}
+
+// GetSettings returns the organization's Git settings, including the tokens
+func (s *GitService) GetSettings(ctx context.Context, orgID uuid.UUID) (*GitSettings, error) {
+	settings, err := s.userRepo.GetOrganizationSettings(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := &GitSettings{}
+	gitSettings, ok := settings["git"].(map[string]interface{})
+	if !ok {
+		return cfg, nil
+	}
+	if v, ok := gitSettings["github_token"].(string); ok {
+		if cfg.GitHubToken, err = openCredential(s.encryptor, v); err != nil {
+			return nil, err
+		}
+	}
+	if v, ok := gitSettings["gitlab_token"].(string); ok {
+		if cfg.GitLabToken, err = openCredential(s.encryptor, v); err != nil {
+			return nil, err
+		}
+	}
+	return cfg, nil
+}
+
+// UpdateSettings replaces the organization's Git settings. An empty token
+// keeps the stored one. The returned settings omit the tokens.
+func (s *GitService) UpdateSettings(ctx context.Context, ac AuditContext, req GitSettings) (*GitSettings, error) {
+	settings, err := s.userRepo.GetOrganizationSettings(ctx, ac.OrgID)
+	if err != nil {
+		return nil, err
+	}
+
+	if existing, ok := settings["git"].(map[string]interface{}); ok {
+		if v, ok := existing["github_token"].(string); ok && req.GitHubToken == "" {
+			if req.GitHubToken, err = openCredential(s.encryptor, v); err != nil {
+				return nil, err
+			}
+		}
+		if v, ok := existing["gitlab_token"].(string); ok && req.GitLabToken == "" {
+			if req.GitLabToken, err = openCredential(s.encryptor, v); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	gitHubToken, err := sealCredential(s.encryptor, req.GitHubToken)
+	if err != nil {
+		return nil, err
+	}
+	gitLabToken, err := sealCredential(s.encryptor, req.GitLabToken)
+	if err != nil {
+		return nil, err
+	}
+	settings["git"] = map[string]interface{}{
+		"github_token": gitHubToken,
+		"gitlab_token": gitLabToken,
+	}
+	if err := s.userRepo.UpdateOrganizationSettings(ctx, ac.OrgID, settings); err != nil {
+		return nil, err
+	}
+
+	s.auditSvc.LogUpdate(ctx, ac, "git_settings", ac.OrgID, "git", nil, map[string]interface{}{
+		"github_token_set": req.GitHubToken != "",
+		"gitlab_token_set": req.GitLabToken != "",
+	})
+
+	return &GitSettings{}, nil
+}
+
+// ============================================
+// Source Repositories
+// ============================================
+
+// parseRepositoryURLs validates and normalizes the repository URLs of a
+// namespace, dropping duplicates
+func parseRepositoryURLs(urls []string) ([]models.SourceRepository, error) {
+	repos := make([]models.SourceRepository, 0, len(urls))
+	seen := make(map[string]bool, len(urls))
+	for _, raw := range urls {
+		repo, err := gitrepo.Parse(raw)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidNamespace, err)
+		}
+		if seen[repo.URL] {
+			continue
+		}
+		seen[repo.URL] = true
+		repos = append(repos, models.SourceRepository{URL: repo.URL, Provider: repo.Provider})
+	}
+	if len(repos) > maxNamespaceRepositories {
+		return nil, fmt.Errorf("%w: at most %d repositories can be linked", ErrInvalidNamespace, maxNamespaceRepositories)
+	}
+	return repos, nil
+}
+
+// repositoryURLs lists the URLs of repositories, for audit logs
+func repositoryURLs(repos []models.SourceRepository) []string {
+	urls := make([]string, len(repos))
+	for i, r := range repos {
+		urls[i] = r.URL
+	}
+	return urls
+}
+
+// CheckNew reads the repositories of a namespace that were never read, in
+// the background so linking does not wait for GitHub or GitLab
+func (s *GitService) CheckNew(ctx context.Context, orgID uuid.UUID, repos []models.SourceRepository) {
+	if s == nil {
+		return
+	}
+	var pending []models.SourceRepository
+	for _, r := range repos {
+		if !r.CheckedAt.Valid && r.Provider != gitrepo.ProviderOther {
+			pending = append(pending, r)
+		}
+	}
+	if len(pending) == 0 {
+		return
+	}
+
+	cfg, err := s.GetSettings(ctx, orgID)
+	if err != nil {
+		s.logger.Errorw("Failed to load Git settings", "organization_id", orgID, "error", err)
+		return
+	}
+	ctx = context.WithoutCancel(ctx)
+	go func() {
+		for i := range pending {
+			s.check(ctx, cfg, &pending[i])
+		}
+	}()
+}
+
+// RefreshRepositories reads the GitHub and GitLab repositories not read
+// within repositoryCheckInterval, those never read first. Failures are
+// recorded on the repository.
+func (s *GitService) RefreshRepositories(ctx context.Context) error {
+	stale, err := s.namespaceRepo.ListStaleRepositories(ctx, time.Now().Add(-repositoryCheckInterval), repositoryCheckBatch)
+	if err != nil {
+		return err
+	}
+
+	settings := make(map[uuid.UUID]*GitSettings)
+	for i := range stale {
+		r := &stale[i]
+		cfg, ok := settings[r.OrganizationID]
+		if !ok {
+			cfg, err = s.GetSettings(ctx, r.OrganizationID)
+			if err != nil {
+				s.logger.Errorw("Failed to load Git settings", "organization_id", r.OrganizationID, "error", err)
+			}
+			settings[r.OrganizationID] = cfg
+		}
+		if cfg == nil {
+			continue
+		}
+		s.check(ctx, cfg, r)
+	}
+	return nil
+}
+
+// check reads a repository and stores the result
+func (s *GitService) check(ctx context.Context, cfg *GitSettings, r *models.SourceRepository) {
+	repo, err := gitrepo.Parse(r.URL)
+	var info *gitrepo.Info
+	if err == nil {
+		info, err = s.inspector.Inspect(ctx, cfg.gitConfig(), repo)
+	}
+	if err != nil {
+		s.logger.Warnw("Failed to read repository", "repository", r.URL, "error", err)
+	}
+
+	applyRepositoryCheck(r, info, err, time.Now())
+	if err := s.namespaceRepo.UpdateRepositoryCheck(ctx, r); err != nil {
+		s.logger.Errorw("Failed to store repository check", "repository", r.URL, "error", err)
+	}
+}
+
+// applyRepositoryCheck records what was read about a repository at
+// checkedAt. When reading failed, what was read before is kept next to the
+// error.
+func applyRepositoryCheck(r *models.SourceRepository, info *gitrepo.Info, err error, checkedAt time.Time) {
+	r.CheckedAt = models.NullTime{Time: checkedAt, Valid: true}
+	if err != nil {
+		r.CheckError = models.NewNullStringFromString(err.Error())
+		return
+	}
+	r.CheckError = models.NullString{}
+	r.DefaultBranch = models.NewNullStringFromString(info.DefaultBranch)
+	r.LastCommitAt = models.NullTime{Time: info.LastCommitAt, Valid: !info.LastCommitAt.IsZero()}
+}
@@ -0,0 +1,72 @@
+package services
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/kubeatlas/kubeatlas/internal/gitrepo"
+	"github.com/kubeatlas/kubeatlas/internal/models"
+)
+
+func TestParseRepositoryURLs(t *testing.T) {
+	repos, err := parseRepositoryURLs([]string{
+		"https://github.com/acme/payments.git",
+		"https://github.com/acme/payments",
+		"https://gitlab.com/acme/fintech/ledger",
+		"https://bitbucket.org/acme/legacy",
+	})
+	if err != nil {
+		t.Fatalf("parseRepositoryURLs() error = %v", err)
+	}
+	want := []models.SourceRepository{
+		{URL: "https://github.com/acme/payments", Provider: gitrepo.ProviderGitHub},
+		{URL: "https://gitlab.com/acme/fintech/ledger", Provider: gitrepo.ProviderGitLab},
+		{URL: "https://bitbucket.org/acme/legacy", Provider: gitrepo.ProviderOther},
+	}
+	if !reflect.DeepEqual(repos, want) {
+		t.Errorf("parseRepositoryURLs() = %+v, want %+v", repos, want)
+	}
+
+	if repos, err := parseRepositoryURLs([]string{}); err != nil || len(repos) != 0 {
+		t.Errorf("parseRepositoryURLs() of no URLs = %v, %v", repos, err)
+	}
+	if _, err := parseRepositoryURLs([]string{"http://github.com/acme/payments"}); !errors.Is(err, ErrInvalidNamespace) {
+		t.Errorf("parseRepositoryURLs() of an http URL error = %v, want ErrInvalidNamespace", err)
+	}
+
+	many := make([]string, maxNamespaceRepositories+1)
+	for i := range many {
+		many[i] = "https://github.com/acme/service-" + string(rune('a'+i))
+	}
+	if _, err := parseRepositoryURLs(many); !errors.Is(err, ErrInvalidNamespace) {
+		t.Errorf("parseRepositoryURLs() of %d URLs error = %v, want ErrInvalidNamespace", len(many), err)
+	}
+}
+
+func TestApplyRepositoryCheck(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	committed := now.Add(-48 * time.Hour)
+
+	var r models.SourceRepository
+	applyRepositoryCheck(&r, &gitrepo.Info{DefaultBranch: "main", LastCommitAt: committed}, nil, now)
+	if r.DefaultBranch.ValueOrEmpty() != "main" || !r.LastCommitAt.Valid || !r.LastCommitAt.Time.Equal(committed) || r.CheckError.Valid {
+		t.Errorf("after a successful check = %+v", r)
+	}
+
+	// A failed check keeps what was read before
+	applyRepositoryCheck(&r, nil, errors.New("api.github.com returned 404: Not Found"), now.Add(time.Hour))
+	if r.DefaultBranch.ValueOrEmpty() != "main" || !r.LastCommitAt.Time.Equal(committed) || r.CheckError.ValueOrEmpty() == "" {
+		t.Errorf("after a failed check = %+v", r)
+	}
+	if !r.CheckedAt.Time.Equal(now.Add(time.Hour)) {
+		t.Errorf("CheckedAt = %v", r.CheckedAt.Time)
+	}
+
+	// An empty repository has no commits
+	applyRepositoryCheck(&r, &gitrepo.Info{}, nil, now)
+	if r.LastCommitAt.Valid || r.CheckError.Valid {
+		t.Errorf("after checking an empty repository = %+v", r)
+	}
+}
@@ -0,0 +1,314 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kubeatlas/kubeatlas/internal/crypto"
+	"github.com/kubeatlas/kubeatlas/internal/database/repositories"
+	"github.com/kubeatlas/kubeatlas/internal/grafana"
+	"github.com/kubeatlas/kubeatlas/internal/models"
+	"go.uber.org/zap"
+)
+
+var ErrInvalidGrafanaSettings = errors.New("invalid Grafana settings")
+
+// Catalog events annotated in Grafana
+const (
+	GrafanaEventClusterSynced           = "cluster_synced"
+	GrafanaEventOwnershipChanged        = "ownership_changed"
+	GrafanaEventNamespaceDecommissioned = "namespace_decommissioned"
+)
+
+var grafanaEvents = []string{
+	GrafanaEventClusterSynced, GrafanaEventOwnershipChanged, GrafanaEventNamespaceDecommissioned,
+}
+
+// GrafanaService posts annotations for catalog changes to the organization's
+// Grafana dashboards
+type GrafanaService struct {
+	userRepo    *repositories.UserRepository
+	clusterRepo *repositories.ClusterRepository
+	annotator   grafana.Annotator
+	encryptor   *crypto.Encryptor
+	auditSvc    *AuditService
+	logger      *zap.SugaredLogger
+}
+
+func NewGrafanaService(userRepo *repositories.UserRepository, clusterRepo *repositories.ClusterRepository, annotator grafana.Annotator, encryptor *crypto.Encryptor, auditSvc *AuditService, logger *zap.SugaredLogger) *GrafanaService {
+	return &GrafanaService{
+		userRepo:    userRepo,
+		clusterRepo: clusterRepo,
+		annotator:   annotator,
+		encryptor:   encryptor,
+		auditSvc:    auditSvc,
+		logger:      logger,
+	}
+}
+
+// ============================================
+// Grafana Settings
+// ============================================
+
+// GrafanaSettings are the organization's Grafana settings, stored in
+// organizations.settings["grafana"]
+type GrafanaSettings struct {
+	Enabled  bool   `json:"enabled"`
+	URL      string `json:"url"`
+	APIToken string `json:"api_token,omitempty"`
+	// DashboardUIDs are the dashboards annotated. Without any, organization
+	// annotations are posted instead.
+	DashboardUIDs []string `json:"dashboard_uids"`
+	// Events are the events annotated; empty means all of them
+	Events []string `json:"events"`
+}
+
+func (s *GrafanaSettings) validate() error {
+	if !s.Enabled {
+		return nil
+	}
+	u, err := url.Parse(s.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%w: url must be an http or https address", ErrInvalidGrafanaSettings)
+	}
+	if s.APIToken == "" {
+		return fmt.Errorf("%w: a service account token is required", ErrInvalidGrafanaSettings)
+	}
+	for _, uid := range s.DashboardUIDs {
+		if strings.TrimSpace(uid) == "" {
+			return fmt.Errorf("%w: dashboard UIDs cannot be empty", ErrInvalidGrafanaSettings)
+		}
+	}
+	for _, e := range s.Events {
+		known := false
+		for _, k := range grafanaEvents {
+			known = known || e == k
+		}
+		if !known {
+			return fmt.Errorf("%w: unknown event %q", ErrInvalidGrafanaSettings, e)
+		}
+	}
+	return nil
+}
+
+// annotates reports whether the settings annotate event
+func (s *GrafanaSettings) annotates(event string) bool {
+	if !s.Enabled {
+		return false
+	}
+	if len(s.Events) == 0 {
+		return true
+	}
+	for _, e := range s.Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *GrafanaSettings) grafanaConfig() grafana.Config {
+	return grafana.Config{URL: s.URL, APIToken: s.APIToken}
+}
+
+// GetSettings returns the organization's Grafana settings, including the token
+func (s *GrafanaService) GetSettings(ctx context.Context, orgID uuid.UUID) (*GrafanaSettings, error) {
+	settings, err := s.userRepo.GetOrganizationSettings(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := &GrafanaSettings{DashboardUIDs: []string{}, Events: []string{}}
+	gSettings, ok := settings["grafana"].(map[string]interface{})
+	if !ok {
+		return cfg, nil
+	}
+	if v, ok := gSettings["enabled"].(bool); ok {
+		cfg.Enabled = v
+	}
+	if v, ok := gSettings["url"].(string); ok {
+		cfg.URL = v
+	}
+	if v, ok := gSettings["api_token"].(string); ok {
+		if cfg.APIToken, err = openCredential(s.encryptor, v); err != nil {
+			return nil, err
+		}
+	}
+	cfg.DashboardUIDs = stringList(gSettings["dashboard_uids"])
+	cfg.Events = stringList(gSettings["events"])
+	return cfg, nil
+}
+
+// stringList reads a list of strings stored in a JSON settings document
+func stringList(v interface{}) []string {
+	list := []string{}
+	items, _ := v.([]interface{})
+	for _, item := range items {
+		if s, ok := item.(string); ok {
+			list = append(list, s)
+		}
+	}
+	return list
+}
+
+// UpdateSettings replaces the organization's Grafana settings. An empty
+// token keeps the stored one. The returned settings omit the token.
+func (s *GrafanaService) UpdateSettings(ctx context.Context, ac AuditContext, req GrafanaSettings) (*GrafanaSettings, error) {
+	settings, err := s.userRepo.GetOrganizationSettings(ctx, ac.OrgID)
+	if err != nil {
+		return nil, err
+	}
+
+	if existing, ok := settings["grafana"].(map[string]interface{}); ok {
+		if v, ok := existing["api_token"].(string); ok && req.APIToken == "" {
+			if req.APIToken, err = openCredential(s.encryptor, v); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if req.DashboardUIDs == nil {
+		req.DashboardUIDs = []string{}
+	}
+	if req.Events == nil {
+		req.Events = []string{}
+	}
+	if err := req.validate(); err != nil {
+		return nil, err
+	}
+
+	apiToken, err := sealCredential(s.encryptor, req.APIToken)
+	if err != nil {
+		return nil, err
+	}
+	settings["grafana"] = map[string]interface{}{
+		"enabled":        req.Enabled,
+		"url":            req.URL,
+		"api_token":      apiToken,
+		"dashboard_uids": req.DashboardUIDs,
+		"events":         req.Events,
+	}
+	if err := s.userRepo.UpdateOrganizationSettings(ctx, ac.OrgID, settings); err != nil {
+		return nil, err
+	}
+
+	s.auditSvc.LogUpdate(ctx, ac, "grafana_settings", ac.OrgID, "grafana", nil, map[string]interface{}{
+		"enabled":        req.Enabled,
+		"url":            req.URL,
+		"dashboard_uids": req.DashboardUIDs,
+		"events":         req.Events,
+	})
+
+	req.APIToken = ""
+	return &req, nil
+}
+
+// TestSettings checks that Grafana accepts the token. An empty token falls
+// back to the stored one.
+func (s *GrafanaService) TestSettings(ctx context.Context, orgID uuid.UUID, req GrafanaSettings) error {
+	if req.APIToken == "" {
+		stored, err := s.GetSettings(ctx, orgID)
+		if err != nil {
+			return err
+		}
+		req.APIToken = stored.APIToken
+	}
+	req.Enabled = true
+	if err := req.validate(); err != nil {
+		return err
+	}
+	return s.annotator.Check(ctx, req.grafanaConfig())
+}
+
+// ============================================
+// Annotations
+// ============================================
+
+// ClusterSynced annotates a successful sync of a cluster
+func (s *GrafanaService) ClusterSynced(ctx context.Context, cluster *models.Cluster, namespaces, created int) {
+	if s == nil {
+		return
+	}
+	text := fmt.Sprintf("Cluster %s synced: %d namespaces", cluster.Name, namespaces)
+	if created > 0 {
+		text += fmt.Sprintf(", %d new", created)
+	}
+	s.annotate(ctx, cluster.OrganizationID, GrafanaEventClusterSynced, text, "cluster:"+cluster.Name)
+}
+
+// NamespaceOwnershipChanged annotates a change of a namespace's owners or
+// contacts. It does nothing when no ownership field changed.
+func (s *GrafanaService) NamespaceOwnershipChanged(ctx context.Context, ns *models.Namespace, oldValues, newValues map[string]interface{}) {
+	if s == nil {
+		return
+	}
+	changes := ownershipChanges(namespaceOwnershipFields, oldValues, newValues)
+	if len(changes) == 0 {
+		return
+	}
+
+	labels := make([]string, len(changes))
+	for i, c := range changes {
+		labels[i] = c.field.Label
+	}
+	text := fmt.Sprintf("Ownership of namespace %s changed: %s", ns.Name, strings.Join(labels, ", "))
+	tags := []string{"namespace:" + ns.Name}
+	if cluster, err := s.clusterRepo.GetByID(ctx, ns.ClusterID); err == nil && cluster != nil {
+		tags = append(tags, "cluster:"+cluster.Name)
+	}
+	s.annotate(ctx, ns.OrganizationID, GrafanaEventOwnershipChanged, text, tags...)
+}
+
+// NamespacesDecommissioned annotates the removal of namespaces from the
+// catalog together with their cluster
+func (s *GrafanaService) NamespacesDecommissioned(ctx context.Context, cluster *models.Cluster, count int64) {
+	if s == nil || count == 0 {
+		return
+	}
+	text := fmt.Sprintf("%d namespaces of cluster %s decommissioned with the cluster", count, cluster.Name)
+	s.annotate(ctx, cluster.OrganizationID, GrafanaEventNamespaceDecommissioned, text, "cluster:"+cluster.Name)
+}
+
+// annotate posts an annotation for event in the background if the
+// organization annotates it. Failures are logged.
+func (s *GrafanaService) annotate(ctx context.Context, orgID uuid.UUID, event, text string, tags ...string) {
+	cfg, err := s.GetSettings(ctx, orgID)
+	if err != nil {
+		s.logger.Errorw("Failed to load Grafana settings", "organization_id", orgID, "error", err)
+		return
+	}
+	if !cfg.annotates(event) {
+		return
+	}
+
+	annotations := grafanaAnnotations(cfg.DashboardUIDs, event, text, tags, time.Now())
+	// Grafana is not waited for, so a slow instance does not hold up syncs
+	// and updates
+	ctx = context.WithoutCancel(ctx)
+	go func() {
+		for _, a := range annotations {
+			if err := s.annotator.CreateAnnotation(ctx, cfg.grafanaConfig(), a); err != nil {
+				s.logger.Warnw("Failed to post Grafana annotation", "event", event, "dashboard", a.DashboardUID, "error", err)
+			}
+		}
+	}()
+}
+
+// grafanaAnnotations builds the annotation of an event for each dashboard,
+// or a single organization annotation without dashboards. Every annotation
+// is tagged kubeatlas and with the event, so dashboards can query them.
+func grafanaAnnotations(dashboardUIDs []string, event, text string, tags []string, at time.Time) []grafana.Annotation {
+	tags = append([]string{"kubeatlas", event}, tags...)
+	if len(dashboardUIDs) == 0 {
+		return []grafana.Annotation{{Time: at, Tags: tags, Text: text}}
+	}
+	annotations := make([]grafana.Annotation, len(dashboardUIDs))
+	for i, uid := range dashboardUIDs {
+		annotations[i] = grafana.Annotation{DashboardUID: uid, Time: at, Tags: tags, Text: text}
+	}
+	return annotations
+}
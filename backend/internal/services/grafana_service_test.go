@@ -0,0 +1,57 @@
+package services
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestGrafanaSettingsValidate(t *testing.T) {
+	valid := GrafanaSettings{Enabled: true, URL: "http://grafana.monitoring:3000", APIToken: "token", DashboardUIDs: []string{"k8s"}, Events: []string{GrafanaEventClusterSynced}}
+	if err := valid.validate(); err != nil {
+		t.Errorf("validate() error = %v", err)
+	}
+	if err := (&GrafanaSettings{URL: "not a url"}).validate(); err != nil {
+		t.Errorf("disabled validate() error = %v", err)
+	}
+
+	for name, mutate := range map[string]func(*GrafanaSettings){
+		"no token":      func(s *GrafanaSettings) { s.APIToken = "" },
+		"no scheme":     func(s *GrafanaSettings) { s.URL = "grafana.monitoring:3000" },
+		"empty UID":     func(s *GrafanaSettings) { s.DashboardUIDs = []string{" "} },
+		"unknown event": func(s *GrafanaSettings) { s.Events = []string{"cluster_deleted"} },
+	} {
+		s := valid
+		mutate(&s)
+		if err := s.validate(); !errors.Is(err, ErrInvalidGrafanaSettings) {
+			t.Errorf("%s: validate() error = %v, want ErrInvalidGrafanaSettings", name, err)
+		}
+	}
+}
+
+func TestGrafanaSettingsAnnotates(t *testing.T) {
+	all := GrafanaSettings{Enabled: true}
+	some := GrafanaSettings{Enabled: true, Events: []string{GrafanaEventOwnershipChanged}}
+	if !all.annotates(GrafanaEventClusterSynced) || some.annotates(GrafanaEventClusterSynced) || !some.annotates(GrafanaEventOwnershipChanged) {
+		t.Error("annotates() ignores the selected events")
+	}
+	if (&GrafanaSettings{}).annotates(GrafanaEventClusterSynced) {
+		t.Error("disabled settings annotate")
+	}
+}
+
+func TestGrafanaAnnotations(t *testing.T) {
+	at := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	wantTags := []string{"kubeatlas", GrafanaEventClusterSynced, "cluster:prod-eu"}
+
+	got := grafanaAnnotations(nil, GrafanaEventClusterSynced, "synced", []string{"cluster:prod-eu"}, at)
+	if len(got) != 1 || got[0].DashboardUID != "" || !reflect.DeepEqual(got[0].Tags, wantTags) || !got[0].Time.Equal(at) {
+		t.Errorf("organization annotation = %+v", got)
+	}
+
+	got = grafanaAnnotations([]string{"k8s", "slo"}, GrafanaEventClusterSynced, "synced", []string{"cluster:prod-eu"}, at)
+	if len(got) != 2 || got[0].DashboardUID != "k8s" || got[1].DashboardUID != "slo" || got[1].Text != "synced" {
+		t.Errorf("dashboard annotations = %+v", got)
+	}
+}
@@ -0,0 +1,168 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kubeatlas/kubeatlas/internal/models"
+	"go.uber.org/zap"
+)
+
+// maxImpactDepth bounds how many dependency hops an impact analysis follows
+const maxImpactDepth = 5
+
+// OnCallSource looks up who is on call for a team. Implementations return nil
+// for teams they are not linked to and log lookup failures.
+type OnCallSource interface {
+	TeamOnCall(ctx context.Context, team *models.Team) []models.OnCallResponder
+}
+
+// teamOnCall returns the responders of the first source the team is linked to
+func teamOnCall(ctx context.Context, sources []OnCallSource, team *models.Team) []models.OnCallResponder {
+	for _, source := range sources {
+		if responders := source.TeamOnCall(ctx, team); len(responders) > 0 {
+			return responders
+		}
+	}
+	return nil
+}
+
+// ImpactAnalysis lists the namespaces affected when a namespace fails, with
+// who to contact for each. Maintenance places the namespace's cluster on
+// its maintenance windows, during which a failure may be planned.
+type ImpactAnalysis struct {
+	NamespaceID    uuid.UUID                 `json:"namespace_id"`
+	Namespace      string                    `json:"namespace"`
+	OnCall         []models.OnCallResponder  `json:"on_call"`
+	OnCallRotation *models.TeamOnCall        `json:"on_call_rotation,omitempty"`
+	Maintenance    *models.MaintenanceStatus `json:"maintenance,omitempty"`
+	Affected       []AffectedNamespace       `json:"affected"`
+}
+
+// AffectedNamespace is an impacted namespace with its owner team's on-call
+// responders. InMaintenance is set while its cluster is in one of its
+// maintenance windows.
+type AffectedNamespace struct {
+	models.ImpactedNamespace
+	OnCall        []models.OnCallResponder `json:"on_call"`
+	InMaintenance bool                     `json:"in_maintenance"`
+}
+
+// ImpactService analyzes which namespaces depend on a namespace
+type ImpactService struct {
+	repos  *Repositories
+	oncall []OnCallSource
+	logger *zap.SugaredLogger
+}
+
+func NewImpactService(repos *Repositories, logger *zap.SugaredLogger, oncall ...OnCallSource) *ImpactService {
+	return &ImpactService{repos: repos, oncall: oncall, logger: logger}
+}
+
+// Analyze returns the namespaces that depend on a namespace, nearest first,
+// with the on-call responders of their owner teams
+func (s *ImpactService) Analyze(ctx context.Context, orgID, namespaceID uuid.UUID) (*ImpactAnalysis, error) {
+	ns, err := s.repos.Namespace.GetByID(ctx, namespaceID)
+	if err != nil {
+		return nil, err
+	}
+	if ns == nil || ns.OrganizationID != orgID {
+		return nil, ErrNamespaceNotFound
+	}
+
+	dependents, err := s.repos.InternalDependency.ListDependents(ctx, namespaceID, maxImpactDepth)
+	if err != nil {
+		return nil, err
+	}
+
+	lookup := func(d models.ImpactedNamespace) []models.OnCallResponder {
+		return teamOnCall(ctx, s.oncall, &models.Team{
+			BaseModel:          models.BaseModel{ID: *d.OwnerTeamID},
+			OrganizationID:     orgID,
+			PagerDutyServiceID: models.NewNullStringFromString(d.PagerDutyServiceID),
+			OpsgenieScheduleID: models.NewNullStringFromString(d.OpsgenieScheduleID),
+		})
+	}
+
+	analysis := &ImpactAnalysis{
+		NamespaceID: ns.ID,
+		Namespace:   ns.Name,
+		Affected:    attachOnCall(dependents, lookup),
+	}
+
+	clusterIDs := []uuid.UUID{ns.ClusterID}
+	for _, d := range dependents {
+		clusterIDs = append(clusterIDs, d.ClusterID)
+	}
+	windows, err := s.repos.Cluster.ListMaintenanceWindows(ctx, uniqueIDs(clusterIDs))
+	if err != nil {
+		return nil, err
+	}
+	analysis.Maintenance = attachMaintenance(analysis.Affected, ns.ClusterID, windows, time.Now())
+
+	if ns.InfrastructureOwnerTeamID != nil {
+		team, err := s.repos.Team.GetByID(ctx, *ns.InfrastructureOwnerTeamID)
+		if err != nil {
+			return nil, err
+		}
+		if team != nil {
+			analysis.OnCall = teamOnCall(ctx, s.oncall, team)
+		}
+		rotation, err := s.repos.TeamOnCall.Get(ctx, *ns.InfrastructureOwnerTeamID)
+		if err != nil {
+			return nil, err
+		}
+		if rotation != nil {
+			rotation.Current = activeResponders(rotation.Current, time.Now())
+			analysis.OnCallRotation = rotation
+		}
+	}
+	return analysis, nil
+}
+
+// attachOnCall pairs each namespace with the responders of its owner team,
+// looking each team up once
+func attachOnCall(dependents []models.ImpactedNamespace, lookup func(d models.ImpactedNamespace) []models.OnCallResponder) []AffectedNamespace {
+	responders := make(map[uuid.UUID][]models.OnCallResponder)
+	affected := make([]AffectedNamespace, len(dependents))
+	for i, d := range dependents {
+		affected[i].ImpactedNamespace = d
+		if d.OwnerTeamID == nil || (d.PagerDutyServiceID == "" && d.OpsgenieScheduleID == "") {
+			continue
+		}
+		r, ok := responders[*d.OwnerTeamID]
+		if !ok {
+			r = lookup(d)
+			responders[*d.OwnerTeamID] = r
+		}
+		affected[i].OnCall = r
+	}
+	return affected
+}
+
+// attachMaintenance marks the namespaces whose cluster is in one of its
+// maintenance windows as of now and returns the maintenance status of
+// clusterID, placing each cluster once
+func attachMaintenance(affected []AffectedNamespace, clusterID uuid.UUID, windows []models.MaintenanceWindow, now time.Time) *models.MaintenanceStatus {
+	byCluster := make(map[uuid.UUID][]models.MaintenanceWindow)
+	for _, w := range windows {
+		byCluster[w.ClusterID] = append(byCluster[w.ClusterID], w)
+	}
+	statuses := make(map[uuid.UUID]*models.MaintenanceStatus, len(byCluster))
+	status := func(id uuid.UUID) *models.MaintenanceStatus {
+		st, ok := statuses[id]
+		if !ok {
+			st = maintenanceStatus(byCluster[id], now)
+			statuses[id] = st
+		}
+		return st
+	}
+
+	for i := range affected {
+		if st := status(affected[i].ClusterID); st != nil && st.Active {
+			affected[i].InMaintenance = true
+		}
+	}
+	return status(clusterID)
+}
@@ -0,0 +1,88 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kubeatlas/kubeatlas/internal/models"
+)
+
+func TestAttachOnCall(t *testing.T) {
+	payments, search, platform := uuid.New(), uuid.New(), uuid.New()
+	dependents := []models.ImpactedNamespace{
+		{Namespace: "checkout", OwnerTeamID: &payments, PagerDutyServiceID: "PPAY"},
+		{Namespace: "legacy"},
+		{Namespace: "refunds", OwnerTeamID: &payments, PagerDutyServiceID: "PPAY"},
+		{Namespace: "search", OwnerTeamID: &search, OpsgenieScheduleID: "S1"},
+		{Namespace: "ingress", OwnerTeamID: &platform},
+	}
+	var lookups []string
+	lookup := func(d models.ImpactedNamespace) []models.OnCallResponder {
+		lookups = append(lookups, d.Namespace)
+		return []models.OnCallResponder{{EscalationLevel: 1, Name: "Ada"}}
+	}
+
+	affected := attachOnCall(dependents, lookup)
+	if !reflect.DeepEqual(lookups, []string{"checkout", "search"}) {
+		t.Errorf("looked up %v, want each linked team once", lookups)
+	}
+	if len(affected) != 5 || affected[0].Namespace != "checkout" || affected[2].OnCall[0].Name != "Ada" {
+		t.Fatalf("attachOnCall() = %+v", affected)
+	}
+	if affected[1].OnCall != nil || affected[4].OnCall != nil {
+		t.Errorf("namespaces without a linked team got %+v, %+v", affected[1].OnCall, affected[4].OnCall)
+	}
+}
+
+func TestOnCallCache(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	c := newOnCallCache(time.Minute)
+	c.now = func() time.Time { return now }
+	org := uuid.New()
+	responders := []models.OnCallResponder{{Name: "Ada"}}
+
+	c.put(org, "PPAY", responders)
+	if got, ok := c.get(org, "PPAY"); !ok || got[0].Name != "Ada" {
+		t.Errorf("get() = %v, %v", got, ok)
+	}
+	if _, ok := c.get(uuid.New(), "PPAY"); ok {
+		t.Error("get() for another organization hit the cache")
+	}
+
+	now = now.Add(time.Minute)
+	if _, ok := c.get(org, "PPAY"); ok {
+		t.Error("get() returned an expired entry")
+	}
+
+	c.put(org, "PPAY", responders)
+	c.clear(org)
+	if _, ok := c.get(org, "PPAY"); ok {
+		t.Error("get() hit after clear()")
+	}
+}
+
+func TestAttachMaintenance(t *testing.T) {
+	now := time.Date(2026, 10, 16, 2, 15, 0, 0, time.UTC)
+	source, inWindow, outside := uuid.New(), uuid.New(), uuid.New()
+	windows := []models.MaintenanceWindow{
+		{ClusterID: inWindow, Name: "Backups", Recurrence: models.MaintenanceDaily, StartTime: "02:00", DurationMinutes: 30, Timezone: "UTC"},
+		{ClusterID: outside, Name: "Patch night", Recurrence: models.MaintenanceDaily, StartTime: "22:00", DurationMinutes: 60, Timezone: "UTC"},
+	}
+	affected := []AffectedNamespace{
+		{ImpactedNamespace: models.ImpactedNamespace{Namespace: "checkout", ClusterID: inWindow}},
+		{ImpactedNamespace: models.ImpactedNamespace{Namespace: "search", ClusterID: outside}},
+		{ImpactedNamespace: models.ImpactedNamespace{Namespace: "ledger", ClusterID: source}},
+	}
+
+	if status := attachMaintenance(affected, source, windows, now); status != nil {
+		t.Errorf("status of a cluster without windows = %+v, want nil", status)
+	}
+	if !affected[0].InMaintenance || affected[1].InMaintenance || affected[2].InMaintenance {
+		t.Errorf("InMaintenance = %v, %v, %v, want only checkout", affected[0].InMaintenance, affected[1].InMaintenance, affected[2].InMaintenance)
+	}
+	if status := attachMaintenance(nil, inWindow, windows, now); status == nil || !status.Active || status.Window != "Backups" {
+		t.Errorf("status = %+v, want in Backups", status)
+	}
+}
@@ -0,0 +1,529 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kubeatlas/kubeatlas/internal/crypto"
+	"github.com/kubeatlas/kubeatlas/internal/database/repositories"
+	"github.com/kubeatlas/kubeatlas/internal/jira"
+	"github.com/kubeatlas/kubeatlas/internal/models"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrInvalidJiraSettings = errors.New("invalid Jira settings: a base URL, API token and project key are required")
+	ErrJiraNotEnabled      = errors.New("jira integration is not enabled")
+	ErrTicketOpen          = errors.New("namespace already has an open ticket")
+	ErrNothingToRemediate  = errors.New("namespace has an owner and documents")
+	ErrInvalidTicketReason = errors.New("invalid ticket reason: must be orphaned or undocumented")
+	ErrJiraRequestFailed   = errors.New("jira request failed")
+	ErrTooManyTickets      = fmt.Errorf("at most %d tickets can be created at once", maxBulkTickets)
+)
+
+// maxBulkTickets bounds the Jira issues one bulk request creates
+const maxBulkTickets = 100
+
+// ticketStatusTTL is how long a ticket's status is shown before it is read
+// from Jira again
+const ticketStatusTTL = 5 * time.Minute
+
+// JiraService opens Jira issues to fix orphaned and undocumented namespaces
+// and keeps their status up to date
+type JiraService struct {
+	namespaceRepo *repositories.NamespaceRepository
+	clusterRepo   *repositories.ClusterRepository
+	userRepo      *repositories.UserRepository
+	tracker       jira.Tracker
+	encryptor     *crypto.Encryptor
+	auditSvc      *AuditService
+	logger        *zap.SugaredLogger
+}
+
+func NewJiraService(
+	namespaceRepo *repositories.NamespaceRepository,
+	clusterRepo *repositories.ClusterRepository,
+	userRepo *repositories.UserRepository,
+	tracker jira.Tracker,
+	encryptor *crypto.Encryptor,
+	auditSvc *AuditService,
+	logger *zap.SugaredLogger,
+) *JiraService {
+	return &JiraService{
+		namespaceRepo: namespaceRepo,
+		clusterRepo:   clusterRepo,
+		userRepo:      userRepo,
+		tracker:       tracker,
+		encryptor:     encryptor,
+		auditSvc:      auditSvc,
+		logger:        logger,
+	}
+}
+
+// ============================================
+// Jira Settings
+// ============================================
+
+// JiraSettings are the organization's Jira settings, stored in
+// organizations.settings["jira"]. Issues are created in ProjectKey with
+// IssueType and Labels.
+type JiraSettings struct {
+	Enabled    bool     `json:"enabled"`
+	BaseURL    string   `json:"base_url"`
+	Email      string   `json:"email"`
+	APIToken   string   `json:"api_token,omitempty"`
+	ProjectKey string   `json:"project_key"`
+	IssueType  string   `json:"issue_type"`
+	Labels     []string `json:"labels"`
+}
+
+func (s *JiraSettings) jiraConfig() jira.Config {
+	return jira.Config{BaseURL: s.BaseURL, Email: s.Email, APIToken: s.APIToken}
+}
+
+func (s *JiraSettings) validate() error {
+	if s.BaseURL == "" || s.APIToken == "" || s.ProjectKey == "" {
+		return ErrInvalidJiraSettings
+	}
+	if !strings.HasPrefix(s.BaseURL, "https://") {
+		return fmt.Errorf("%w: base URL must use https", ErrInvalidJiraSettings)
+	}
+	for _, label := range s.Labels {
+		// Jira rejects labels with spaces
+		if label == "" || strings.ContainsAny(label, " \t") {
+			return fmt.Errorf("%w: invalid label %q", ErrInvalidJiraSettings, label)
+		}
+	}
+	return nil
+}
+
+// GetSettings returns the organization's Jira settings, including credentials
+func (s *JiraService) GetSettings(ctx context.Context, orgID uuid.UUID) (*JiraSettings, error) {
+	settings, err := s.userRepo.GetOrganizationSettings(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := &JiraSettings{IssueType: "Task", Labels: []string{}}
+
+	jiraSettings, ok := settings["jira"].(map[string]interface{})
+	if !ok {
+		return cfg, nil
+	}
+
+	if v, ok := jiraSettings["enabled"].(bool); ok {
+		cfg.Enabled = v
+	}
+	if v, ok := jiraSettings["base_url"].(string); ok {
+		cfg.BaseURL = v
+	}
+	if v, ok := jiraSettings["email"].(string); ok {
+		cfg.Email = v
+	}
+	if v, ok := jiraSettings["api_token"].(string); ok {
+		if cfg.APIToken, err = openCredential(s.encryptor, v); err != nil {
+			return nil, err
+		}
+	}
+	if v, ok := jiraSettings["project_key"].(string); ok {
+		cfg.ProjectKey = v
+	}
+	if v, ok := jiraSettings["issue_type"].(string); ok && v != "" {
+		cfg.IssueType = v
+	}
+	if labels, ok := jiraSettings["labels"].([]interface{}); ok {
+		for _, label := range labels {
+			if v, ok := label.(string); ok {
+				cfg.Labels = append(cfg.Labels, v)
+			}
+		}
+	}
+
+	return cfg, nil
+}
+
+// UpdateSettings replaces the organization's Jira settings. An empty API
+// token keeps the stored one. The returned settings omit the token.
+func (s *JiraService) UpdateSettings(ctx context.Context, ac AuditContext, req JiraSettings) (*JiraSettings, error) {
+	settings, err := s.userRepo.GetOrganizationSettings(ctx, ac.OrgID)
+	if err != nil {
+		return nil, err
+	}
+
+	if existing, ok := settings["jira"].(map[string]interface{}); ok {
+		if v, ok := existing["api_token"].(string); ok && req.APIToken == "" {
+			if req.APIToken, err = openCredential(s.encryptor, v); err != nil {
+				return nil, err
+			}
+		}
+	}
+	req.BaseURL = strings.TrimRight(req.BaseURL, "/")
+	if req.IssueType == "" {
+		req.IssueType = "Task"
+	}
+	if req.Labels == nil {
+		req.Labels = []string{}
+	}
+	if req.Enabled {
+		if err := req.validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	labels := make([]interface{}, len(req.Labels))
+	for i, label := range req.Labels {
+		labels[i] = label
+	}
+	apiToken, err := sealCredential(s.encryptor, req.APIToken)
+	if err != nil {
+		return nil, err
+	}
+	settings["jira"] = map[string]interface{}{
+		"enabled":     req.Enabled,
+		"base_url":    req.BaseURL,
+		"email":       req.Email,
+		"api_token":   apiToken,
+		"project_key": req.ProjectKey,
+		"issue_type":  req.IssueType,
+		"labels":      labels,
+	}
+
+	if err := s.userRepo.UpdateOrganizationSettings(ctx, ac.OrgID, settings); err != nil {
+		return nil, err
+	}
+
+	s.auditSvc.LogUpdate(ctx, ac, "jira_settings", ac.OrgID, "jira", nil, map[string]interface{}{
+		"enabled":     req.Enabled,
+		"base_url":    req.BaseURL,
+		"email":       req.Email,
+		"project_key": req.ProjectKey,
+		"issue_type":  req.IssueType,
+		"labels":      labels,
+	})
+
+	req.APIToken = ""
+	return &req, nil
+}
+
+// TestSettings checks that the given settings can see the project. An empty
+// API token falls back to the stored one.
+func (s *JiraService) TestSettings(ctx context.Context, orgID uuid.UUID, req JiraSettings) error {
+	if req.APIToken == "" {
+		stored, err := s.GetSettings(ctx, orgID)
+		if err != nil {
+			return err
+		}
+		req.APIToken = stored.APIToken
+	}
+	req.BaseURL = strings.TrimRight(req.BaseURL, "/")
+	if err := req.validate(); err != nil {
+		return err
+	}
+	return s.tracker.CheckProject(ctx, req.jiraConfig(), req.ProjectKey)
+}
+
+// enabledSettings returns the organization's Jira settings, failing unless
+// the integration is enabled
+func (s *JiraService) enabledSettings(ctx context.Context, orgID uuid.UUID) (*JiraSettings, error) {
+	cfg, err := s.GetSettings(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	if !cfg.Enabled {
+		return nil, ErrJiraNotEnabled
+	}
+	return cfg, nil
+}
+
+// ============================================
+// Remediation Tickets
+// ============================================
+
+// CreateTicketRequest opens a ticket for a namespace. Without a reason, the
+// ticket asks for an owner if the namespace has none and for documents
+// otherwise.
+type CreateTicketRequest struct {
+	Reason string `json:"reason"`
+}
+
+// CreateTicket opens a Jira issue to fix a namespace's missing owner or
+// documents and stores its key on the namespace. It fails with ErrTicketOpen
+// while the namespace's last ticket is unresolved.
+func (s *JiraService) CreateTicket(ctx context.Context, ac AuditContext, namespaceID uuid.UUID, req CreateTicketRequest) (*models.NamespaceTicket, error) {
+	cfg, err := s.enabledSettings(ctx, ac.OrgID)
+	if err != nil {
+		return nil, err
+	}
+
+	ns, err := s.namespaceRepo.GetByID(ctx, namespaceID)
+	if err != nil {
+		return nil, err
+	}
+	if ns == nil || ns.OrganizationID != ac.OrgID {
+		return nil, ErrNamespaceNotFound
+	}
+
+	existing, err := s.Ticket(ctx, ns)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil && !existing.Resolved() {
+		return nil, ErrTicketOpen
+	}
+
+	documents, err := s.namespaceRepo.CountDocuments(ctx, ns.ID)
+	if err != nil {
+		return nil, err
+	}
+	reason, err := ticketReason(req.Reason, ns.InfrastructureOwnerTeamID == nil, documents == 0)
+	if err != nil {
+		return nil, err
+	}
+
+	cluster, environment := "", ns.Environment
+	if c, err := s.clusterRepo.GetByID(ctx, ns.ClusterID); err == nil && c != nil {
+		cluster = c.Name
+	}
+	return s.open(ctx, ac, cfg, ns.ID, ns.Name, cluster, environment, reason)
+}
+
+// BulkTicketRequest opens tickets for many namespaces at once, either those
+// listed or, without NamespaceIDs, every namespace with the Reason's gap
+type BulkTicketRequest struct {
+	Reason       string      `json:"reason" binding:"required"`
+	NamespaceIDs []uuid.UUID `json:"namespace_ids"`
+}
+
+// SkippedTicket is a namespace a bulk request opened no ticket for
+type SkippedTicket struct {
+	NamespaceID uuid.UUID `json:"namespace_id"`
+	Namespace   string    `json:"namespace"`
+	Cluster     string    `json:"cluster"`
+	Error       string    `json:"error"`
+}
+
+// BulkTicketResult lists the tickets a bulk request opened and the
+// namespaces it skipped
+type BulkTicketResult struct {
+	Created []models.NamespaceTicket `json:"created"`
+	Skipped []SkippedTicket          `json:"skipped"`
+}
+
+// CreateTickets opens a Jira issue for each orphaned or undocumented
+// namespace of a report. Namespaces without the gap or with an unresolved
+// ticket are skipped, as are those Jira rejects.
+func (s *JiraService) CreateTickets(ctx context.Context, ac AuditContext, req BulkTicketRequest) (*BulkTicketResult, error) {
+	if req.Reason != models.TicketReasonOrphaned && req.Reason != models.TicketReasonUndocumented {
+		return nil, ErrInvalidTicketReason
+	}
+	cfg, err := s.enabledSettings(ctx, ac.OrgID)
+	if err != nil {
+		return nil, err
+	}
+
+	gaps, err := s.namespaceRepo.ListGaps(ctx, ac.OrgID)
+	if err != nil {
+		return nil, err
+	}
+	selected := selectGaps(gaps, req.Reason, req.NamespaceIDs)
+
+	result := &BulkTicketResult{Created: []models.NamespaceTicket{}, Skipped: []SkippedTicket{}}
+	var pending []models.NamespaceGap
+	for _, g := range selected.gaps {
+		ticket, err := s.namespaceRepo.GetTicket(ctx, g.NamespaceID)
+		if err != nil {
+			return nil, err
+		}
+		if ticket != nil && !ticket.Resolved() {
+			result.Skipped = append(result.Skipped, SkippedTicket{
+				NamespaceID: g.NamespaceID, Namespace: g.Namespace, Cluster: g.Cluster,
+				Error: fmt.Sprintf("%s (%s)", ErrTicketOpen, ticket.IssueKey),
+			})
+			continue
+		}
+		pending = append(pending, g)
+	}
+	if len(pending) > maxBulkTickets {
+		return nil, ErrTooManyTickets
+	}
+	for _, id := range selected.missing {
+		result.Skipped = append(result.Skipped, SkippedTicket{
+			NamespaceID: id,
+			Error:       fmt.Sprintf("namespace is not %s", req.Reason),
+		})
+	}
+
+	for _, g := range pending {
+		// The gap list has no environment; it is only used in the description
+		ticket, err := s.open(ctx, ac, cfg, g.NamespaceID, g.Namespace, g.Cluster, "", req.Reason)
+		if err != nil {
+			s.logger.Warnw("Failed to create Jira issue", "namespace_id", g.NamespaceID, "error", err)
+			result.Skipped = append(result.Skipped, SkippedTicket{
+				NamespaceID: g.NamespaceID, Namespace: g.Namespace, Cluster: g.Cluster, Error: err.Error(),
+			})
+			continue
+		}
+		result.Created = append(result.Created, *ticket)
+	}
+
+	return result, nil
+}
+
+// open creates the Jira issue of a namespace and stores it
+func (s *JiraService) open(ctx context.Context, ac AuditContext, cfg *JiraSettings, namespaceID uuid.UUID, namespace, cluster, environment, reason string) (*models.NamespaceTicket, error) {
+	issue, err := s.tracker.CreateIssue(ctx, cfg.jiraConfig(), ticketIssue(cfg, namespace, cluster, environment, reason))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrJiraRequestFailed, err)
+	}
+
+	ticket := &models.NamespaceTicket{
+		NamespaceID:    namespaceID,
+		OrganizationID: ac.OrgID,
+		IssueKey:       issue.Key,
+		IssueURL:       issue.URL,
+		Reason:         reason,
+		CreatedBy:      ac.UserID,
+	}
+	if err := s.namespaceRepo.SaveTicket(ctx, ticket); err != nil {
+		return nil, fmt.Errorf("created %s but failed to store it: %w", issue.Key, err)
+	}
+
+	s.auditSvc.LogUpdate(ctx, ac, "namespace", namespaceID, namespace, nil, map[string]interface{}{
+		"jira_issue": issue.Key,
+		"reason":     reason,
+	})
+	return ticket, nil
+}
+
+// Ticket returns a namespace's remediation ticket, or nil if none was
+// opened. A status older than ticketStatusTTL is read from Jira again; if
+// Jira cannot be reached the stored status is returned.
+func (s *JiraService) Ticket(ctx context.Context, ns *models.Namespace) (*models.NamespaceTicket, error) {
+	ticket, err := s.namespaceRepo.GetTicket(ctx, ns.ID)
+	if err != nil || ticket == nil {
+		return nil, err
+	}
+	if ticket.StatusCheckedAt.Valid && time.Since(ticket.StatusCheckedAt.Time) < ticketStatusTTL {
+		return ticket, nil
+	}
+
+	cfg, err := s.GetSettings(ctx, ns.OrganizationID)
+	if err != nil {
+		s.logger.Warnw("Failed to load Jira settings", "organization_id", ns.OrganizationID, "error", err)
+		return ticket, nil
+	}
+	if !cfg.Enabled {
+		return ticket, nil
+	}
+	issue, err := s.tracker.GetIssue(ctx, cfg.jiraConfig(), ticket.IssueKey)
+	if err != nil {
+		s.logger.Warnw("Failed to read Jira issue status", "issue", ticket.IssueKey, "error", err)
+		return ticket, nil
+	}
+
+	now := time.Now()
+	if err := s.namespaceRepo.UpdateTicketStatus(ctx, ns.ID, issue.Status, issue.StatusCategory, now); err != nil {
+		s.logger.Warnw("Failed to store Jira issue status", "issue", ticket.IssueKey, "error", err)
+	}
+	ticket.Status = models.NewNullStringFromString(issue.Status)
+	ticket.StatusCategory = models.NewNullStringFromString(issue.StatusCategory)
+	ticket.StatusCheckedAt = models.NullTime{Time: now, Valid: true}
+	return ticket, nil
+}
+
+// ticketReason picks the gap a ticket asks to fix, checking that the
+// namespace has it
+func ticketReason(requested string, orphaned, undocumented bool) (string, error) {
+	switch requested {
+	case "":
+		if orphaned {
+			return models.TicketReasonOrphaned, nil
+		}
+		if undocumented {
+			return models.TicketReasonUndocumented, nil
+		}
+		return "", ErrNothingToRemediate
+	case models.TicketReasonOrphaned:
+		if !orphaned {
+			return "", fmt.Errorf("%w: namespace has an owner", ErrNothingToRemediate)
+		}
+	case models.TicketReasonUndocumented:
+		if !undocumented {
+			return "", fmt.Errorf("%w: namespace has documents", ErrNothingToRemediate)
+		}
+	default:
+		return "", ErrInvalidTicketReason
+	}
+	return requested, nil
+}
+
+// selectedGaps are the namespaces a bulk request opens tickets for, and the
+// requested IDs that do not have the gap
+type selectedGaps struct {
+	gaps    []models.NamespaceGap
+	missing []uuid.UUID
+}
+
+// selectGaps picks the gaps with reason, limited to ids when any are given
+func selectGaps(gaps []models.NamespaceGap, reason string, ids []uuid.UUID) selectedGaps {
+	var selected selectedGaps
+	wanted := make(map[uuid.UUID]bool, len(ids))
+	for _, id := range ids {
+		wanted[id] = true
+	}
+	found := make(map[uuid.UUID]bool)
+	for _, g := range gaps {
+		has := (reason == models.TicketReasonOrphaned && g.MissingOwner) ||
+			(reason == models.TicketReasonUndocumented && g.MissingDocuments)
+		if !has || (len(ids) > 0 && !wanted[g.NamespaceID]) {
+			continue
+		}
+		selected.gaps = append(selected.gaps, g)
+		found[g.NamespaceID] = true
+	}
+	for _, id := range ids {
+		if !found[id] {
+			selected.missing = append(selected.missing, id)
+			found[id] = true
+		}
+	}
+	return selected
+}
+
+// ticketIssue describes the Jira issue opened for a namespace's gap
+func ticketIssue(cfg *JiraSettings, namespace, cluster, environment, reason string) jira.IssueRequest {
+	var summary, task string
+	switch reason {
+	case models.TicketReasonOrphaned:
+		summary = fmt.Sprintf("Assign an owner to namespace %s", namespace)
+		task = "The namespace has no owner team in KubeAtlas. Set its owner team, or delete the namespace if it is no longer used."
+	default:
+		summary = fmt.Sprintf("Document namespace %s", namespace)
+		task = "The namespace has no documents in KubeAtlas. Add its runbook, architecture or other documents."
+	}
+	if cluster != "" {
+		summary += " on " + cluster
+	}
+
+	var b strings.Builder
+	b.WriteString(task + "\n\n")
+	fmt.Fprintf(&b, "Namespace: %s\n", namespace)
+	if cluster != "" {
+		fmt.Fprintf(&b, "Cluster: %s\n", cluster)
+	}
+	if environment != "" {
+		fmt.Fprintf(&b, "Environment: %s\n", environment)
+	}
+
+	return jira.IssueRequest{
+		ProjectKey:  cfg.ProjectKey,
+		IssueType:   cfg.IssueType,
+		Summary:     summary,
+		Description: b.String(),
+		Labels:      cfg.Labels,
+	}
+}
@@ -0,0 +1,94 @@
+package services
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/kubeatlas/kubeatlas/internal/models"
+)
+
+func TestTicketReason(t *testing.T) {
+	tests := []struct {
+		requested              string
+		orphaned, undocumented bool
+		want                   string
+		wantErr                error
+	}{
+		{"", true, true, models.TicketReasonOrphaned, nil},
+		{"", false, true, models.TicketReasonUndocumented, nil},
+		{"", false, false, "", ErrNothingToRemediate},
+		{models.TicketReasonUndocumented, true, true, models.TicketReasonUndocumented, nil},
+		{models.TicketReasonOrphaned, false, true, "", ErrNothingToRemediate},
+		{"stale", true, true, "", ErrInvalidTicketReason},
+	}
+	for _, tt := range tests {
+		got, err := ticketReason(tt.requested, tt.orphaned, tt.undocumented)
+		if got != tt.want || !errors.Is(err, tt.wantErr) {
+			t.Errorf("ticketReason(%q, %v, %v) = %q, %v; want %q, %v",
+				tt.requested, tt.orphaned, tt.undocumented, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestSelectGaps(t *testing.T) {
+	orphan := models.NamespaceGap{NamespaceID: uuid.New(), Namespace: "legacy", MissingOwner: true, MissingDocuments: true}
+	undocumented := models.NamespaceGap{NamespaceID: uuid.New(), Namespace: "payments", MissingDocuments: true}
+	noBU := models.NamespaceGap{NamespaceID: uuid.New(), Namespace: "search", MissingBusinessUnit: true}
+	gaps := []models.NamespaceGap{orphan, undocumented, noBU}
+
+	got := selectGaps(gaps, models.TicketReasonUndocumented, nil)
+	if want := []models.NamespaceGap{orphan, undocumented}; !reflect.DeepEqual(got.gaps, want) || got.missing != nil {
+		t.Errorf("selectGaps(undocumented) = %+v", got)
+	}
+
+	// Requested namespaces without the gap are reported, once
+	got = selectGaps(gaps, models.TicketReasonOrphaned, []uuid.UUID{orphan.NamespaceID, undocumented.NamespaceID, undocumented.NamespaceID})
+	if !reflect.DeepEqual(got.gaps, []models.NamespaceGap{orphan}) || !reflect.DeepEqual(got.missing, []uuid.UUID{undocumented.NamespaceID}) {
+		t.Errorf("selectGaps(orphaned, ids) = %+v", got)
+	}
+}
+
+func TestTicketIssue(t *testing.T) {
+	cfg := &JiraSettings{ProjectKey: "OPS", IssueType: "Task", Labels: []string{"kubeatlas"}}
+
+	issue := ticketIssue(cfg, "legacy", "prod-eu", "production", models.TicketReasonOrphaned)
+	if issue.Summary != "Assign an owner to namespace legacy on prod-eu" {
+		t.Errorf("Summary = %q", issue.Summary)
+	}
+	for _, line := range []string{"Namespace: legacy\n", "Cluster: prod-eu\n", "Environment: production\n"} {
+		if !strings.Contains(issue.Description, line) {
+			t.Errorf("Description %q lacks %q", issue.Description, line)
+		}
+	}
+	if issue.ProjectKey != "OPS" || issue.IssueType != "Task" || !reflect.DeepEqual(issue.Labels, cfg.Labels) {
+		t.Errorf("ticketIssue() = %+v", issue)
+	}
+
+	issue = ticketIssue(cfg, "payments", "", "", models.TicketReasonUndocumented)
+	if issue.Summary != "Document namespace payments" || strings.Contains(issue.Description, "Cluster:") {
+		t.Errorf("ticketIssue() without cluster = %+v", issue)
+	}
+}
+
+func TestJiraSettingsValidate(t *testing.T) {
+	valid := JiraSettings{BaseURL: "https://acme.atlassian.net", APIToken: "token", ProjectKey: "OPS", Labels: []string{"kubeatlas"}}
+	if err := valid.validate(); err != nil {
+		t.Errorf("validate() error = %v", err)
+	}
+
+	for name, mutate := range map[string]func(*JiraSettings){
+		"no token":    func(s *JiraSettings) { s.APIToken = "" },
+		"no project":  func(s *JiraSettings) { s.ProjectKey = "" },
+		"plain http":  func(s *JiraSettings) { s.BaseURL = "http://jira.internal" },
+		"label space": func(s *JiraSettings) { s.Labels = []string{"needs owner"} },
+	} {
+		s := valid
+		mutate(&s)
+		if err := s.validate(); !errors.Is(err, ErrInvalidJiraSettings) {
+			t.Errorf("%s: validate() error = %v, want ErrInvalidJiraSettings", name, err)
+		}
+	}
+}
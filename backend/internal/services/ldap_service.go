@@ -3,6 +3,7 @@ package services
 import (
 	"context"
 	"crypto/tls"
+	"errors"
 	"fmt"
 	"strings"
 	"time"
@@ -14,6 +15,10 @@ import (
 	"go.uber.org/zap"
 )
 
+// ErrLDAPNotConfigured is returned for directory lookups of organizations
+// without LDAP
+var ErrLDAPNotConfigured = errors.New("LDAP is not enabled for the organization")
+
 // LDAPConfig holds LDAP connection settings
 type LDAPConfig struct {
 	Enabled           bool   `json:"enabled"`
@@ -267,6 +272,143 @@ func (s *LDAPService) TestConnection(config *LDAPConfig) error {
 	return nil
 }
 
+// GroupMembers returns the lowercase email addresses of the members of
+// groups, keyed by lowercase group name. Groups are looked up by common name
+// under the group search base, and their members by memberOf under the
+// search base. Groups that are not found are left out.
+func (s *LDAPService) GroupMembers(ctx context.Context, orgID uuid.UUID, groups []string) (map[string][]string, error) {
+	config, err := s.GetConfig(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	if !config.Enabled || config.ServerURL == "" {
+		return nil, ErrLDAPNotConfigured
+	}
+
+	conn, err := s.connect(config.ServerURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to LDAP server: %w", err)
+	}
+	defer conn.Close()
+
+	if config.BindDN != "" && config.BindPassword != "" {
+		if err := conn.Bind(config.BindDN, config.BindPassword); err != nil {
+			return nil, fmt.Errorf("failed to bind with service account: %w", err)
+		}
+	}
+
+	groupBase := config.GroupSearchBase
+	if groupBase == "" {
+		groupBase = config.SearchBase
+	}
+	members := make(map[string][]string, len(groups))
+	for _, group := range groups {
+		sr, err := conn.Search(ldap.NewSearchRequest(
+			groupBase,
+			ldap.ScopeWholeSubtree,
+			ldap.NeverDerefAliases,
+			1,
+			30,
+			false,
+			"(cn="+ldap.EscapeFilter(group)+")",
+			[]string{"dn"},
+			nil,
+		))
+		if err != nil && !ldap.IsErrorWithCode(err, ldap.LDAPResultSizeLimitExceeded) {
+			return nil, fmt.Errorf("failed to search group %s: %w", group, err)
+		}
+		if sr == nil || len(sr.Entries) == 0 {
+			s.logger.Warnw("LDAP group not found", "group", group, "organization_id", orgID)
+			continue
+		}
+
+		users, err := conn.SearchWithPaging(ldap.NewSearchRequest(
+			config.SearchBase,
+			ldap.ScopeWholeSubtree,
+			ldap.NeverDerefAliases,
+			0,
+			60,
+			false,
+			"(memberOf="+ldap.EscapeFilter(sr.Entries[0].DN)+")",
+			[]string{config.EmailAttribute},
+			nil,
+		), 500)
+		if err != nil {
+			return nil, fmt.Errorf("failed to search members of group %s: %w", group, err)
+		}
+		emails := make([]string, 0, len(users.Entries))
+		for _, entry := range users.Entries {
+			if email := strings.ToLower(entry.GetAttributeValue(config.EmailAttribute)); email != "" {
+				emails = append(emails, email)
+			}
+		}
+		members[strings.ToLower(group)] = emails
+	}
+	return members, nil
+}
+
+// ldapEmailBatch bounds the email addresses looked up in one LDAP search
+const ldapEmailBatch = 50
+
+// ExistingEmails returns which of the given lowercase email addresses belong
+// to a user under the search base
+func (s *LDAPService) ExistingEmails(ctx context.Context, orgID uuid.UUID, emails []string) (map[string]bool, error) {
+	config, err := s.GetConfig(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	if !config.Enabled || config.ServerURL == "" {
+		return nil, ErrLDAPNotConfigured
+	}
+
+	conn, err := s.connect(config.ServerURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to LDAP server: %w", err)
+	}
+	defer conn.Close()
+
+	if config.BindDN != "" && config.BindPassword != "" {
+		if err := conn.Bind(config.BindDN, config.BindPassword); err != nil {
+			return nil, fmt.Errorf("failed to bind with service account: %w", err)
+		}
+	}
+
+	existing := make(map[string]bool, len(emails))
+	for start := 0; start < len(emails); start += ldapEmailBatch {
+		end := start + ldapEmailBatch
+		if end > len(emails) {
+			end = len(emails)
+		}
+		var filter strings.Builder
+		filter.WriteString("(|")
+		for _, email := range emails[start:end] {
+			filter.WriteString("(" + config.EmailAttribute + "=" + ldap.EscapeFilter(email) + ")")
+		}
+		filter.WriteString(")")
+
+		sr, err := conn.Search(ldap.NewSearchRequest(
+			config.SearchBase,
+			ldap.ScopeWholeSubtree,
+			ldap.NeverDerefAliases,
+			0,
+			60,
+			false,
+			filter.String(),
+			[]string{config.EmailAttribute},
+			nil,
+		))
+		if err != nil {
+			return nil, fmt.Errorf("failed to search users by email: %w", err)
+		}
+		for _, entry := range sr.Entries {
+			if email := strings.ToLower(entry.GetAttributeValue(config.EmailAttribute)); email != "" {
+				existing[email] = true
+			}
+		}
+	}
+	return existing, nil
+}
+
 // connect establishes connection to LDAP server
 func (s *LDAPService) connect(serverURL string) (*ldap.Conn, error) {
 	var conn *ldap.Conn
@@ -0,0 +1,516 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kubeatlas/kubeatlas/internal/database/repositories"
+	"github.com/kubeatlas/kubeatlas/internal/models"
+	"github.com/kubeatlas/kubeatlas/internal/objectstore"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrBackupUnavailable = errors.New("metadata backups are not available")
+	ErrBackupNotFound    = errors.New("backup not found")
+	ErrInvalidBackup     = errors.New("invalid backup")
+)
+
+// Sections of a metadata backup, in the order they are restored: business
+// units and teams before the namespace ownership that refers to them
+const (
+	BackupSectionBusinessUnits = "business_units"
+	BackupSectionTeams         = "teams"
+	BackupSectionOwnership     = "ownership"
+)
+
+// MetadataBackupSections lists the sections of a backup in restore order
+var MetadataBackupSections = []string{BackupSectionBusinessUnits, BackupSectionTeams, BackupSectionOwnership}
+
+const (
+	// metadataSnapshotVersion is the format of the backups written
+	metadataSnapshotVersion = 1
+	// maxMetadataSnapshotSize bounds the backups read and imported
+	maxMetadataSnapshotSize = 64 << 20
+)
+
+// MetadataBackupSettings schedule backups of the organization's catalog
+// metadata, stored in organizations.settings["metadata_backups"]
+type MetadataBackupSettings struct {
+	// IntervalHours is the time between scheduled backups; 0 disables them
+	IntervalHours int `json:"interval_hours"`
+	// Keep is the number of most recent backups kept
+	Keep int `json:"keep"`
+}
+
+func (m *MetadataBackupSettings) validate() error {
+	if m.IntervalHours < 0 || m.IntervalHours > 720 {
+		return errors.New("interval_hours must be between 0 and 720")
+	}
+	if m.Keep < 1 || m.Keep > 1000 {
+		return errors.New("keep must be between 1 and 1000")
+	}
+	return nil
+}
+
+// MetadataBackupSetting takes no scheduled backups by default and keeps the
+// 30 most recent backups
+var MetadataBackupSetting = SettingKey[MetadataBackupSettings]{
+	Name:     "metadata_backups",
+	Default:  MetadataBackupSettings{Keep: 30},
+	Validate: (*MetadataBackupSettings).validate,
+}
+
+// MetadataSnapshot is the document stored by a metadata backup. Records are
+// identified by natural keys (team slugs, business unit names, cluster and
+// namespace names) so a backup restores into another environment. Credentials
+// are never included.
+type MetadataSnapshot struct {
+	Version        int                         `json:"version"`
+	OrganizationID uuid.UUID                   `json:"organization_id"`
+	CreatedAt      time.Time                   `json:"created_at"`
+	BusinessUnits  []CreateBusinessUnitRequest `json:"business_units"`
+	Teams          []CreateTeamRequest         `json:"teams"`
+	Ownership      []NamespaceOwnership        `json:"ownership"`
+}
+
+// counts returns the number of records per section
+func (s *MetadataSnapshot) counts() map[string]int64 {
+	return map[string]int64{
+		BackupSectionBusinessUnits: int64(len(s.BusinessUnits)),
+		BackupSectionTeams:         int64(len(s.Teams)),
+		BackupSectionOwnership:     int64(len(s.Ownership)),
+	}
+}
+
+// RestoreMetadataRequest selects the sections of a backup to restore; all
+// of them when none are given
+type RestoreMetadataRequest struct {
+	Sections []string `json:"sections"`
+}
+
+// MetadataRestoreResult is the outcome of restoring one section
+type MetadataRestoreResult struct {
+	Created   int      `json:"created"`
+	Updated   int      `json:"updated"`
+	Unchanged int      `json:"unchanged"`
+	Failed    int      `json:"failed"`
+	Errors    []string `json:"errors,omitempty"`
+}
+
+func (r *MetadataRestoreResult) fail(key string, err error) {
+	r.Failed++
+	r.Errors = append(r.Errors, fmt.Sprintf("%s: %v", key, err))
+}
+
+// MetadataRestore is the outcome of a restore, by section
+type MetadataRestore struct {
+	BackupID uuid.UUID                         `json:"backup_id"`
+	Sections map[string]*MetadataRestoreResult `json:"sections"`
+}
+
+// MetadataBackupService snapshots an organization's catalog metadata to
+// object storage and restores it selectively, for disaster recovery and for
+// cloning an environment
+type MetadataBackupService struct {
+	repo          *repositories.MetadataBackupRepository
+	orgRepo       *repositories.OrgSettingsRepository
+	teams         *TeamService
+	businessUnits *BusinessUnitService
+	namespaces    *NamespaceService
+	settings      *OrgSettingsService
+	auditSvc      *AuditService
+	logger        *zap.SugaredLogger
+	store         objectstore.Store
+}
+
+// NewMetadataBackupService creates a new metadata backup service
+func NewMetadataBackupService(
+	repo *repositories.MetadataBackupRepository,
+	orgRepo *repositories.OrgSettingsRepository,
+	teams *TeamService,
+	businessUnits *BusinessUnitService,
+	namespaces *NamespaceService,
+	settings *OrgSettingsService,
+	auditSvc *AuditService,
+	logger *zap.SugaredLogger,
+) *MetadataBackupService {
+	return &MetadataBackupService{
+		repo:          repo,
+		orgRepo:       orgRepo,
+		teams:         teams,
+		businessUnits: businessUnits,
+		namespaces:    namespaces,
+		settings:      settings,
+		auditSvc:      auditSvc,
+		logger:        logger,
+	}
+}
+
+// SetStore keeps backups in store, which enables them
+func (s *MetadataBackupService) SetStore(store objectstore.Store) {
+	s.store = store
+}
+
+// metadataBackupKey is the object key of a backup
+func metadataBackupKey(orgID, id uuid.UUID) string {
+	return fmt.Sprintf("backups/%s/%s.json", orgID, id)
+}
+
+// Backup snapshots the caller's organization now
+func (s *MetadataBackupService) Backup(ctx context.Context, ac AuditContext) (*models.MetadataBackup, error) {
+	if s.store == nil {
+		return nil, ErrBackupUnavailable
+	}
+	snapshot, err := s.snapshot(ctx, ac.OrgID)
+	if err != nil {
+		return nil, err
+	}
+	return s.save(ctx, ac, snapshot, models.BackupTriggerManual)
+}
+
+// Import records a backup downloaded from another environment, so its
+// sections can be restored here
+func (s *MetadataBackupService) Import(ctx context.Context, ac AuditContext, r io.Reader) (*models.MetadataBackup, error) {
+	if s.store == nil {
+		return nil, ErrBackupUnavailable
+	}
+	snapshot, err := decodeMetadataSnapshot(r)
+	if err != nil {
+		return nil, err
+	}
+	return s.save(ctx, ac, snapshot, models.BackupTriggerImported)
+}
+
+// List retrieves the organization's backups, newest first
+func (s *MetadataBackupService) List(ctx context.Context, orgID uuid.UUID) ([]models.MetadataBackup, error) {
+	return s.repo.List(ctx, orgID, 0)
+}
+
+// Open opens the document of a backup for download
+func (s *MetadataBackupService) Open(ctx context.Context, ac AuditContext, id uuid.UUID) (io.ReadCloser, *models.MetadataBackup, error) {
+	if s.store == nil {
+		return nil, nil, ErrBackupUnavailable
+	}
+	backup, err := s.get(ctx, ac.OrgID, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	rc, err := s.store.Get(ctx, backup.ObjectKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	s.auditSvc.LogAction(ctx, ac, "backup_downloaded", "metadata_backup", id, "metadata", "Downloaded metadata backup "+id.String())
+	return rc, backup, nil
+}
+
+// Restore upserts the selected sections of a backup into the caller's
+// organization. Records are matched by natural key and updated with the
+// values backed up; records not in the backup, and fields the backup leaves
+// empty, are kept. A record that fails does not stop the others.
+func (s *MetadataBackupService) Restore(ctx context.Context, ac AuditContext, id uuid.UUID, req RestoreMetadataRequest) (*MetadataRestore, error) {
+	if s.store == nil {
+		return nil, ErrBackupUnavailable
+	}
+	sections, err := restoreSections(req.Sections)
+	if err != nil {
+		return nil, err
+	}
+	backup, err := s.get(ctx, ac.OrgID, id)
+	if err != nil {
+		return nil, err
+	}
+	rc, err := s.store.Get(ctx, backup.ObjectKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read backup: %w", err)
+	}
+	snapshot, err := decodeMetadataSnapshot(rc)
+	rc.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	restore := &MetadataRestore{BackupID: id, Sections: make(map[string]*MetadataRestoreResult)}
+	for _, section := range sections {
+		var result *MetadataRestoreResult
+		switch section {
+		case BackupSectionBusinessUnits:
+			result = s.restoreBusinessUnits(ctx, ac, snapshot.BusinessUnits)
+		case BackupSectionTeams:
+			result = s.restoreTeams(ctx, ac, snapshot.Teams)
+		case BackupSectionOwnership:
+			if result, err = s.restoreOwnership(ctx, ac, snapshot.Ownership); err != nil {
+				return nil, err
+			}
+		}
+		restore.Sections[section] = result
+	}
+
+	s.auditSvc.LogAction(ctx, ac, "restore", "metadata_backup", id, "metadata",
+		fmt.Sprintf("Restored %s from metadata backup %s", strings.Join(sections, ", "), id))
+	s.logger.Infow("Metadata backup restored", "organization_id", ac.OrgID, "backup_id", id, "sections", sections)
+	return restore, nil
+}
+
+// ProcessScheduled backs up every organization whose last scheduled backup
+// is older than its interval. A failing organization does not stop the
+// others.
+func (s *MetadataBackupService) ProcessScheduled(ctx context.Context) error {
+	if s.store == nil {
+		return nil
+	}
+	orgIDs, err := s.orgRepo.ListOrganizationIDs(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list organizations: %w", err)
+	}
+
+	var errs []error
+	for _, orgID := range orgIDs {
+		if err := s.backupIfDue(ctx, orgID); err != nil {
+			errs = append(errs, fmt.Errorf("organization %s: %w", orgID, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (s *MetadataBackupService) backupIfDue(ctx context.Context, orgID uuid.UUID) error {
+	cfg, err := GetSetting(ctx, s.settings, orgID, MetadataBackupSetting)
+	if err != nil || cfg.IntervalHours == 0 {
+		return err
+	}
+	last, err := s.repo.LastScheduled(ctx, orgID)
+	if err != nil {
+		return err
+	}
+	if last != nil && time.Since(last.CreatedAt) < time.Duration(cfg.IntervalHours)*time.Hour {
+		return nil
+	}
+
+	snapshot, err := s.snapshot(ctx, orgID)
+	if err != nil {
+		return err
+	}
+	_, err = s.save(ctx, AuditContext{OrgID: orgID, UserEmail: "system"}, snapshot, models.BackupTriggerScheduled)
+	return err
+}
+
+// snapshot reads the organization's metadata by natural keys
+func (s *MetadataBackupService) snapshot(ctx context.Context, orgID uuid.UUID) (*MetadataSnapshot, error) {
+	units, err := s.businessUnits.List(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	teams, err := s.teams.List(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	ownership, err := s.repo.ListOwnership(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot := &MetadataSnapshot{
+		Version:        metadataSnapshotVersion,
+		OrganizationID: orgID,
+		CreatedAt:      time.Now().UTC(),
+		BusinessUnits:  make([]CreateBusinessUnitRequest, 0, len(units)),
+		Teams:          make([]CreateTeamRequest, 0, len(teams)),
+		Ownership:      make([]NamespaceOwnership, 0, len(ownership)),
+	}
+	for _, bu := range units {
+		snapshot.BusinessUnits = append(snapshot.BusinessUnits, CreateBusinessUnitRequest{
+			Name:          bu.Name,
+			Code:          bu.Code.String,
+			Description:   bu.Description.String,
+			DirectorName:  bu.DirectorName.String,
+			DirectorEmail: bu.DirectorEmail.String,
+			CostCenter:    bu.CostCenter.String,
+		})
+	}
+	for _, t := range teams {
+		snapshot.Teams = append(snapshot.Teams, CreateTeamRequest{
+			Name:               t.Name,
+			Slug:               t.Slug,
+			Description:        t.Description.String,
+			TeamType:           t.TeamType,
+			ContactEmail:       t.ContactEmail.String,
+			ContactSlack:       t.ContactSlack.String,
+			PagerDutyServiceID: t.PagerDutyServiceID.String,
+			OpsgenieScheduleID: t.OpsgenieScheduleID.String,
+		})
+	}
+	for _, o := range ownership {
+		snapshot.Ownership = append(snapshot.Ownership, NamespaceOwnership(o))
+	}
+	return snapshot, nil
+}
+
+// save stores a snapshot, records it and removes the backups past the
+// organization's limit
+func (s *MetadataBackupService) save(ctx context.Context, ac AuditContext, snapshot *MetadataSnapshot, trigger string) (*models.MetadataBackup, error) {
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return nil, err
+	}
+	backup := &models.MetadataBackup{
+		ID:             uuid.New(),
+		OrganizationID: ac.OrgID,
+		Trigger:        trigger,
+		CreatedByEmail: ac.UserEmail,
+		SizeBytes:      int64(len(data)),
+		Counts:         snapshot.counts(),
+	}
+	backup.ObjectKey = metadataBackupKey(ac.OrgID, backup.ID)
+	if err := s.store.Put(ctx, backup.ObjectKey, bytes.NewReader(data), backup.SizeBytes, "application/json"); err != nil {
+		return nil, fmt.Errorf("failed to store backup: %w", err)
+	}
+	if err := s.repo.Create(ctx, backup); err != nil {
+		return nil, err
+	}
+
+	description := fmt.Sprintf("Backed up %d business units, %d teams and the ownership of %d namespaces",
+		len(snapshot.BusinessUnits), len(snapshot.Teams), len(snapshot.Ownership))
+	if trigger == models.BackupTriggerImported {
+		description = fmt.Sprintf("Imported a metadata backup of %d business units, %d teams and the ownership of %d namespaces",
+			len(snapshot.BusinessUnits), len(snapshot.Teams), len(snapshot.Ownership))
+	}
+	s.auditSvc.LogAction(ctx, ac, "backup", "metadata_backup", backup.ID, "metadata", description)
+	s.logger.Infow("Metadata backup stored", "organization_id", ac.OrgID, "backup_id", backup.ID, "trigger", trigger, "size_bytes", backup.SizeBytes)
+
+	if err := s.prune(ctx, ac.OrgID); err != nil {
+		s.logger.Warnw("Failed to remove old metadata backups", "organization_id", ac.OrgID, "error", err)
+	}
+	return backup, nil
+}
+
+// prune removes the organization's backups past the number it keeps
+func (s *MetadataBackupService) prune(ctx context.Context, orgID uuid.UUID) error {
+	cfg, err := GetSetting(ctx, s.settings, orgID, MetadataBackupSetting)
+	if err != nil {
+		return err
+	}
+	old, err := s.repo.List(ctx, orgID, cfg.Keep)
+	if err != nil {
+		return err
+	}
+	for _, backup := range old {
+		if err := s.store.Delete(ctx, backup.ObjectKey); err != nil {
+			return fmt.Errorf("failed to remove backup %s: %w", backup.ID, err)
+		}
+		if err := s.repo.Delete(ctx, backup.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *MetadataBackupService) get(ctx context.Context, orgID, id uuid.UUID) (*models.MetadataBackup, error) {
+	backup, err := s.repo.Get(ctx, orgID, id)
+	if err != nil {
+		return nil, err
+	}
+	if backup == nil {
+		return nil, ErrBackupNotFound
+	}
+	return backup, nil
+}
+
+func (s *MetadataBackupService) restoreBusinessUnits(ctx context.Context, ac AuditContext, units []CreateBusinessUnitRequest) *MetadataRestoreResult {
+	result := &MetadataRestoreResult{}
+	for _, req := range units {
+		_, created, err := s.businessUnits.UpsertByName(ctx, ac, req.Name, req)
+		switch {
+		case err != nil:
+			result.fail(req.Name, err)
+		case created:
+			result.Created++
+		default:
+			result.Updated++
+		}
+	}
+	return result
+}
+
+func (s *MetadataBackupService) restoreTeams(ctx context.Context, ac AuditContext, teams []CreateTeamRequest) *MetadataRestoreResult {
+	result := &MetadataRestoreResult{}
+	for _, req := range teams {
+		_, upsert, err := s.teams.UpsertBySlug(ctx, ac, req)
+		if err != nil {
+			result.fail(req.Slug, err)
+			continue
+		}
+		switch upsert.Action {
+		case UpsertCreated:
+			result.Created++
+		case UpsertUpdated:
+			result.Updated++
+		default:
+			result.Unchanged++
+		}
+	}
+	return result
+}
+
+// restoreOwnership imports the ownership in batches of the most one import
+// may update
+func (s *MetadataBackupService) restoreOwnership(ctx context.Context, ac AuditContext, ownership []NamespaceOwnership) (*MetadataRestoreResult, error) {
+	result := &MetadataRestoreResult{}
+	for start := 0; start < len(ownership); start += MaxOwnershipImport {
+		end := min(start+MaxOwnershipImport, len(ownership))
+		imported, err := s.namespaces.ImportOwnership(ctx, ac, ac.OrgID, ImportOwnershipRequest{Namespaces: ownership[start:end]})
+		if err != nil {
+			return nil, err
+		}
+		result.Updated += imported.Updated
+		result.Unchanged += imported.Unchanged
+		for _, r := range imported.Results {
+			if r.Status == OwnershipFailed {
+				result.fail(r.Cluster+"/"+r.Namespace, errors.New(r.Error))
+			}
+		}
+	}
+	return result, nil
+}
+
+// restoreSections validates the sections of a restore and puts them in
+// restore order
+func restoreSections(requested []string) ([]string, error) {
+	if len(requested) == 0 {
+		return MetadataBackupSections, nil
+	}
+	wanted := make(map[string]bool, len(requested))
+	for _, section := range requested {
+		wanted[section] = true
+	}
+	sections := make([]string, 0, len(wanted))
+	for _, section := range MetadataBackupSections {
+		if wanted[section] {
+			sections = append(sections, section)
+			delete(wanted, section)
+		}
+	}
+	for section := range wanted {
+		return nil, fmt.Errorf("%w: unknown section %q, use %s", ErrInvalidBackup, section, strings.Join(MetadataBackupSections, ", "))
+	}
+	return sections, nil
+}
+
+// decodeMetadataSnapshot reads a backup document
+func decodeMetadataSnapshot(r io.Reader) (*MetadataSnapshot, error) {
+	var snapshot MetadataSnapshot
+	dec := json.NewDecoder(io.LimitReader(r, maxMetadataSnapshotSize))
+	if err := dec.Decode(&snapshot); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidBackup, err)
+	}
+	if snapshot.Version != metadataSnapshotVersion {
+		return nil, fmt.Errorf("%w: unsupported version %d", ErrInvalidBackup, snapshot.Version)
+	}
+	return &snapshot, nil
+}
@@ -0,0 +1,64 @@
+package services
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRestoreSections(t *testing.T) {
+	sections, err := restoreSections(nil)
+	if err != nil || !reflect.DeepEqual(sections, MetadataBackupSections) {
+		t.Errorf("restoreSections(nil) = %v, %v, want every section", sections, err)
+	}
+
+	sections, err = restoreSections([]string{"ownership", "teams", "teams"})
+	if want := []string{"teams", "ownership"}; err != nil || !reflect.DeepEqual(sections, want) {
+		t.Errorf("restoreSections(ownership, teams) = %v, %v, want %v", sections, err, want)
+	}
+
+	if _, err := restoreSections([]string{"teams", "credentials"}); !errors.Is(err, ErrInvalidBackup) {
+		t.Errorf("restoreSections(credentials) error = %v, want ErrInvalidBackup", err)
+	}
+}
+
+func TestDecodeMetadataSnapshot(t *testing.T) {
+	snapshot, err := decodeMetadataSnapshot(strings.NewReader(`{
+		"version": 1,
+		"teams": [{"name": "Platform", "slug": "platform"}],
+		"ownership": [{"cluster": "prod", "namespace": "payments", "owner_team": "platform"}]
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	counts := snapshot.counts()
+	if counts[BackupSectionTeams] != 1 || counts[BackupSectionOwnership] != 1 || counts[BackupSectionBusinessUnits] != 0 {
+		t.Errorf("counts = %v, want 1 team and 1 namespace", counts)
+	}
+
+	for name, doc := range map[string]string{
+		"not json":        `teams`,
+		"unknown version": `{"version": 2}`,
+		"no version":      `{"teams": []}`,
+	} {
+		if _, err := decodeMetadataSnapshot(strings.NewReader(doc)); !errors.Is(err, ErrInvalidBackup) {
+			t.Errorf("%s: error = %v, want ErrInvalidBackup", name, err)
+		}
+	}
+}
+
+func TestMetadataBackupSettings(t *testing.T) {
+	if err := MetadataBackupSetting.Default.validate(); err != nil {
+		t.Errorf("default settings are invalid: %v", err)
+	}
+	for _, cfg := range []MetadataBackupSettings{
+		{IntervalHours: -1, Keep: 30},
+		{IntervalHours: 24, Keep: 0},
+		{IntervalHours: 721, Keep: 30},
+	} {
+		if err := cfg.validate(); err == nil {
+			t.Errorf("%+v validated, want an error", cfg)
+		}
+	}
+}
@@ -0,0 +1,261 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/kubeatlas/kubeatlas/internal/models"
+)
+
+// Namespace metadata that mapping rules read
+const (
+	MappingSourceLabel      = "label"
+	MappingSourceAnnotation = "annotation"
+)
+
+// maxMetadataMappingRules caps the rules evaluated for every namespace of a
+// sync
+const maxMetadataMappingRules = 100
+
+// mappableNamespaceFields are the namespace fields mapping rules can set
+var mappableNamespaceFields = []string{
+	"owner_team", "business_unit", "criticality", "environment",
+	"display_name", "description",
+	"application_manager_name", "application_manager_email", "application_manager_phone",
+	"technical_lead_name", "technical_lead_email",
+	"project_manager_name", "project_manager_email",
+	"sla_availability", "sla_rto", "sla_rpo", "support_hours",
+}
+
+// MetadataMappingRule sets a namespace field from a label or annotation of
+// the namespace. With Value, the rule only matches that value; Set is the
+// value given to the field, and defaults to the label or annotation's own.
+// Teams are matched by slug or name and business units by code or name.
+type MetadataMappingRule struct {
+	Source string `json:"source"`
+	Key    string `json:"key"`
+	Value  string `json:"value,omitempty"`
+	Field  string `json:"field"`
+	Set    string `json:"set,omitempty"`
+	// Overwrite replaces values entered by hand. Otherwise the rule only
+	// fills empty fields, and the criticality of namespaces new to the sync.
+	Overwrite bool `json:"overwrite"`
+}
+
+func (r *MetadataMappingRule) validate() error {
+	if r.Source != MappingSourceLabel && r.Source != MappingSourceAnnotation {
+		return fmt.Errorf("unknown source %q, want label or annotation", r.Source)
+	}
+	r.Key = strings.TrimSpace(r.Key)
+	if r.Key == "" {
+		return errors.New("a key is required")
+	}
+	if !containsString(mappableNamespaceFields, r.Field) {
+		return fmt.Errorf("unknown field %q", r.Field)
+	}
+	return nil
+}
+
+// MetadataMappings are the rules that populate namespace metadata from the
+// labels and annotations found by cluster syncs, stored in
+// organizations.settings["metadata_mappings"]. For each field, the first
+// matching rule wins.
+type MetadataMappings struct {
+	Rules []MetadataMappingRule `json:"rules"`
+}
+
+func (m *MetadataMappings) validate() error {
+	if len(m.Rules) > maxMetadataMappingRules {
+		return fmt.Errorf("at most %d rules are allowed", maxMetadataMappingRules)
+	}
+	for i := range m.Rules {
+		if err := m.Rules[i].validate(); err != nil {
+			return fmt.Errorf("rule %d: %w", i+1, err)
+		}
+	}
+	return nil
+}
+
+// MetadataMappingsSetting has no rules by default
+var MetadataMappingsSetting = SettingKey[MetadataMappings]{
+	Name:     "metadata_mappings",
+	Default:  MetadataMappings{Rules: []MetadataMappingRule{}},
+	Validate: (*MetadataMappings).validate,
+}
+
+// namespaceMapper applies an organization's mapping rules during a sync
+type namespaceMapper struct {
+	rules        []MetadataMappingRule
+	teams        map[string]*models.Team
+	units        map[string]*models.BusinessUnit
+	tiers        []models.CriticalityTier
+	environments EnvironmentSettings
+}
+
+func newNamespaceMapper(rules []MetadataMappingRule, teams []models.Team, units []models.BusinessUnit, tiers []models.CriticalityTier, environments EnvironmentSettings) *namespaceMapper {
+	m := &namespaceMapper{
+		rules:        rules,
+		teams:        make(map[string]*models.Team),
+		units:        make(map[string]*models.BusinessUnit),
+		tiers:        tiers,
+		environments: environments,
+	}
+	// Slugs and codes win over names that happen to match them
+	for i := range teams {
+		m.teams[strings.ToLower(teams[i].Name)] = &teams[i]
+	}
+	for i := range teams {
+		m.teams[strings.ToLower(teams[i].Slug)] = &teams[i]
+	}
+	for i := range units {
+		m.units[strings.ToLower(units[i].Name)] = &units[i]
+	}
+	for i := range units {
+		if units[i].Code.Valid && units[i].Code.String != "" {
+			m.units[strings.ToLower(units[i].Code.String)] = &units[i]
+		}
+	}
+	return m
+}
+
+// apply sets the fields of ns that the rules map from its labels and
+// annotations. discovered is true for namespaces the sync is creating. It
+// returns the fields changed and the mapped values that name no team,
+// business unit, tier or environment of the organization.
+func (m *namespaceMapper) apply(ns *models.Namespace, labels, annotations map[string]interface{}, discovered bool) (changed []string, unresolved []error) {
+	done := make(map[string]bool)
+	for _, r := range m.rules {
+		if done[r.Field] {
+			continue
+		}
+		source := labels
+		if r.Source == MappingSourceAnnotation {
+			source = annotations
+		}
+		found, ok := source[r.Key].(string)
+		if !ok || (r.Value != "" && found != r.Value) {
+			continue
+		}
+		value := strings.TrimSpace(found)
+		if r.Set != "" {
+			value = r.Set
+		}
+		if value == "" {
+			continue
+		}
+		done[r.Field] = true
+
+		if !r.Overwrite && !mappedFieldEmpty(ns, r.Field, discovered) {
+			continue
+		}
+		set, err := m.set(ns, r.Field, value)
+		if err != nil {
+			unresolved = append(unresolved, fmt.Errorf("%s %s: %w", r.Source, r.Key, err))
+			continue
+		}
+		if set {
+			changed = append(changed, r.Field)
+		}
+	}
+	return changed, unresolved
+}
+
+// mappedFieldEmpty reports whether a field of ns has not been filled in.
+// Criticality always has a tier, which is only the sync's default for
+// namespaces it discovers.
+func mappedFieldEmpty(ns *models.Namespace, field string, discovered bool) bool {
+	switch field {
+	case "owner_team":
+		return ns.InfrastructureOwnerTeamID == nil
+	case "business_unit":
+		return ns.BusinessUnitID == nil
+	case "criticality":
+		return discovered
+	case "environment":
+		return ns.Environment == "" || ns.Environment == "unknown"
+	default:
+		return mappedString(ns, field).ValueOrEmpty() == ""
+	}
+}
+
+// set sets field of ns to value and reports whether that changed it
+func (m *namespaceMapper) set(ns *models.Namespace, field, value string) (bool, error) {
+	switch field {
+	case "owner_team":
+		team := m.teams[strings.ToLower(value)]
+		if team == nil {
+			return false, fmt.Errorf("team %q not found", value)
+		}
+		if ns.InfrastructureOwnerTeamID != nil && *ns.InfrastructureOwnerTeamID == team.ID {
+			return false, nil
+		}
+		ns.InfrastructureOwnerTeamID = &team.ID
+	case "business_unit":
+		unit := m.units[strings.ToLower(value)]
+		if unit == nil {
+			return false, fmt.Errorf("business unit %q not found", value)
+		}
+		if ns.BusinessUnitID != nil && *ns.BusinessUnitID == unit.ID {
+			return false, nil
+		}
+		ns.BusinessUnitID = &unit.ID
+	case "criticality":
+		value = strings.ToLower(value)
+		if models.FindCriticalityTier(m.tiers, value) == nil {
+			return false, fmt.Errorf("criticality tier %q not found", value)
+		}
+		if ns.Criticality == value {
+			return false, nil
+		}
+		ns.Criticality = value
+	case "environment":
+		value = strings.ToLower(value)
+		if !m.environments.Allows(value) {
+			return false, fmt.Errorf("environment %q not found", value)
+		}
+		if ns.Environment == value {
+			return false, nil
+		}
+		ns.Environment = value
+	default:
+		s := mappedString(ns, field)
+		if s.Valid && s.String == value {
+			return false, nil
+		}
+		*s = models.NewNullStringFromString(value)
+	}
+	return true, nil
+}
+
+// mappedString returns the text field of ns named field
+func mappedString(ns *models.Namespace, field string) *models.NullString {
+	switch field {
+	case "display_name":
+		return &ns.DisplayName
+	case "description":
+		return &ns.Description
+	case "application_manager_name":
+		return &ns.ApplicationManagerName
+	case "application_manager_email":
+		return &ns.ApplicationManagerEmail
+	case "application_manager_phone":
+		return &ns.ApplicationManagerPhone
+	case "technical_lead_name":
+		return &ns.TechnicalLeadName
+	case "technical_lead_email":
+		return &ns.TechnicalLeadEmail
+	case "project_manager_name":
+		return &ns.ProjectManagerName
+	case "project_manager_email":
+		return &ns.ProjectManagerEmail
+	case "sla_availability":
+		return &ns.SLAAvailability
+	case "sla_rto":
+		return &ns.SLARTO
+	case "sla_rpo":
+		return &ns.SLARPO
+	default:
+		return &ns.SupportHours
+	}
+}
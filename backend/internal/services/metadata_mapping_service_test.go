@@ -0,0 +1,123 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/kubeatlas/kubeatlas/internal/models"
+)
+
+func TestMetadataMappingsValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		rule    MetadataMappingRule
+		wantErr bool
+	}{
+		{"label", MetadataMappingRule{Source: MappingSourceLabel, Key: " team ", Field: "owner_team"}, false},
+		{"annotation", MetadataMappingRule{Source: MappingSourceAnnotation, Key: "kubeatlas.io/criticality", Field: "criticality"}, false},
+		{"unknown source", MetadataMappingRule{Source: "env", Key: "team", Field: "owner_team"}, true},
+		{"no key", MetadataMappingRule{Source: MappingSourceLabel, Key: " ", Field: "owner_team"}, true},
+		{"unknown field", MetadataMappingRule{Source: MappingSourceLabel, Key: "team", Field: "cluster"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := MetadataMappings{Rules: []MetadataMappingRule{tt.rule}}
+			if err := m.validate(); (err != nil) != tt.wantErr {
+				t.Errorf("validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	m := MetadataMappings{Rules: []MetadataMappingRule{{Source: MappingSourceLabel, Key: " team ", Field: "owner_team"}}}
+	if err := m.validate(); err != nil || m.Rules[0].Key != "team" {
+		t.Errorf("validate() = %v with key %q, want the key trimmed", err, m.Rules[0].Key)
+	}
+}
+
+func TestNamespaceMapperApply(t *testing.T) {
+	payments := models.Team{BaseModel: models.BaseModel{ID: uuid.New()}, Name: "Payments", Slug: "payments"}
+	retail := models.BusinessUnit{BaseModel: models.BaseModel{ID: uuid.New()}, Name: "Retail Banking", Code: models.NewNullStringFromString("RETAIL")}
+	environments := EnvironmentSettings{Values: []string{"production", "staging"}}
+
+	rules := []MetadataMappingRule{
+		{Source: MappingSourceLabel, Key: "team", Field: "owner_team"},
+		{Source: MappingSourceLabel, Key: "unit", Field: "business_unit"},
+		{Source: MappingSourceAnnotation, Key: "kubeatlas.io/criticality", Field: "criticality"},
+		{Source: MappingSourceLabel, Key: "tier", Value: "gold", Field: "criticality", Set: "tier-1"},
+		{Source: MappingSourceLabel, Key: "env", Field: "environment", Overwrite: true},
+		{Source: MappingSourceAnnotation, Key: "contact", Field: "technical_lead_email"},
+	}
+	mapper := newNamespaceMapper(rules, []models.Team{payments}, []models.BusinessUnit{retail}, models.DefaultCriticalityTiers, environments)
+
+	t.Run("discovered", func(t *testing.T) {
+		ns := &models.Namespace{Criticality: "tier-3", Environment: "unknown"}
+		labels := map[string]interface{}{"team": "Payments", "unit": "retail", "tier": "gold", "env": "Production"}
+		annotations := map[string]interface{}{"contact": "jane@example.com"}
+
+		changed, unresolved := mapper.apply(ns, labels, annotations, true)
+		if len(unresolved) != 0 {
+			t.Fatalf("unresolved = %v", unresolved)
+		}
+		want := []string{"owner_team", "business_unit", "criticality", "environment", "technical_lead_email"}
+		if !reflect.DeepEqual(changed, want) {
+			t.Errorf("changed = %v, want %v", changed, want)
+		}
+		if ns.InfrastructureOwnerTeamID == nil || *ns.InfrastructureOwnerTeamID != payments.ID {
+			t.Errorf("owner team = %v, want %s", ns.InfrastructureOwnerTeamID, payments.ID)
+		}
+		if ns.BusinessUnitID == nil || *ns.BusinessUnitID != retail.ID {
+			t.Errorf("business unit = %v, want %s", ns.BusinessUnitID, retail.ID)
+		}
+		if ns.Criticality != "tier-1" || ns.Environment != "production" {
+			t.Errorf("criticality, environment = %s, %s, want tier-1, production", ns.Criticality, ns.Environment)
+		}
+		if ns.TechnicalLeadEmail.ValueOrEmpty() != "jane@example.com" {
+			t.Errorf("technical lead email = %q", ns.TechnicalLeadEmail.ValueOrEmpty())
+		}
+	})
+
+	t.Run("first matching rule wins", func(t *testing.T) {
+		ns := &models.Namespace{Criticality: "tier-3"}
+		labels := map[string]interface{}{"tier": "gold"}
+		annotations := map[string]interface{}{"kubeatlas.io/criticality": "tier-2"}
+
+		mapper.apply(ns, labels, annotations, true)
+		if ns.Criticality != "tier-2" {
+			t.Errorf("criticality = %s, want tier-2 from the annotation rule", ns.Criticality)
+		}
+	})
+
+	t.Run("existing values are kept", func(t *testing.T) {
+		other := uuid.New()
+		ns := &models.Namespace{
+			Criticality:               "tier-3",
+			Environment:               "staging",
+			InfrastructureOwnerTeamID: &other,
+		}
+		labels := map[string]interface{}{"team": "payments", "tier": "gold", "env": "production"}
+
+		changed, _ := mapper.apply(ns, labels, nil, false)
+		if !reflect.DeepEqual(changed, []string{"environment"}) {
+			t.Errorf("changed = %v, want only the overwriting environment rule", changed)
+		}
+		if *ns.InfrastructureOwnerTeamID != other || ns.Criticality != "tier-3" {
+			t.Errorf("owner team, criticality = %s, %s, want them unchanged", ns.InfrastructureOwnerTeamID, ns.Criticality)
+		}
+	})
+
+	t.Run("unresolved values", func(t *testing.T) {
+		ns := &models.Namespace{Criticality: "tier-3", Environment: "unknown"}
+		labels := map[string]interface{}{"team": "checkout", "env": "qa"}
+		annotations := map[string]interface{}{"kubeatlas.io/criticality": "tier-9"}
+
+		changed, unresolved := mapper.apply(ns, labels, annotations, true)
+		if len(changed) != 0 || len(unresolved) != 3 {
+			t.Errorf("changed = %v, unresolved = %v, want nothing changed and 3 unresolved", changed, unresolved)
+		}
+		if ns.InfrastructureOwnerTeamID != nil || ns.Criticality != "tier-3" || ns.Environment != "unknown" {
+			t.Errorf("namespace changed by unresolved values: %+v", ns)
+		}
+	})
+}
@@ -0,0 +1,34 @@
+package services
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/kubeatlas/kubeatlas/internal/database"
+	"go.uber.org/zap"
+)
+
+// MigrationService reports the schema migrations applied to the database.
+// Migrations are applied on start and reverted with the migrate command;
+// the API only lists them.
+type MigrationService struct {
+	pool   *pgxpool.Pool
+	logger *zap.SugaredLogger
+}
+
+// NewMigrationService creates a new migration service
+func NewMigrationService(pool *pgxpool.Pool, logger *zap.SugaredLogger) *MigrationService {
+	return &MigrationService{
+		pool:   pool,
+		logger: logger,
+	}
+}
+
+// Status lists every migration and whether it is applied, oldest first
+func (s *MigrationService) Status(ctx context.Context) ([]database.MigrationStatus, error) {
+	migrator, err := database.NewMigrator(s.pool)
+	if err != nil {
+		return nil, err
+	}
+	return migrator.Status(ctx)
+}
@@ -0,0 +1,388 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kubeatlas/kubeatlas/internal/crypto"
+	"github.com/kubeatlas/kubeatlas/internal/database/repositories"
+	"github.com/kubeatlas/kubeatlas/internal/models"
+	"github.com/kubeatlas/kubeatlas/internal/monitoring"
+	"go.uber.org/zap"
+)
+
+var ErrInvalidDatadogSettings = errors.New("invalid Datadog settings")
+
+// maxMonitoringLinks bounds the monitoring links of one namespace
+const maxMonitoringLinks = 20
+
+// monitoringCheckInterval is how long a dashboard is taken to exist before
+// Grafana or Datadog is asked again
+const monitoringCheckInterval = 6 * time.Hour
+
+// monitoringCheckBatch bounds the links one refresh checks
+const monitoringCheckBatch = 100
+
+// MonitoringService checks that the Grafana and Datadog dashboards linked to
+// namespaces exist, and reports critical namespaces without any
+type MonitoringService struct {
+	namespaceRepo *repositories.NamespaceRepository
+	userRepo      *repositories.UserRepository
+	grafana       *GrafanaService
+	settings      *OrgSettingsService
+	checker       monitoring.Checker
+	encryptor     *crypto.Encryptor
+	auditSvc      *AuditService
+	logger        *zap.SugaredLogger
+}
+
+func NewMonitoringService(namespaceRepo *repositories.NamespaceRepository, userRepo *repositories.UserRepository, grafana *GrafanaService, settings *OrgSettingsService, checker monitoring.Checker, encryptor *crypto.Encryptor, auditSvc *AuditService, logger *zap.SugaredLogger) *MonitoringService {
+	return &MonitoringService{
+		namespaceRepo: namespaceRepo,
+		userRepo:      userRepo,
+		grafana:       grafana,
+		settings:      settings,
+		checker:       checker,
+		encryptor:     encryptor,
+		auditSvc:      auditSvc,
+		logger:        logger,
+	}
+}
+
+// ============================================
+// Datadog Settings
+// ============================================
+
+// DatadogSettings are the keys Datadog dashboards are checked with, stored
+// in organizations.settings["datadog"]. Grafana dashboards are checked with
+// the Grafana settings.
+type DatadogSettings struct {
+	// Site is the organization's Datadog site, such as datadoghq.com or
+	// datadoghq.eu
+	Site   string `json:"site"`
+	APIKey string `json:"api_key,omitempty"`
+	AppKey string `json:"app_key,omitempty"`
+}
+
+func (s *DatadogSettings) monitoringConfig() monitoring.Config {
+	return monitoring.Config{DatadogSite: s.Site, DatadogAPIKey: s.APIKey, DatadogAppKey: s.AppKey}
+}
+
+func (s *DatadogSettings) validate() error {
+	if s.Site == "" && s.APIKey == "" && s.AppKey == "" {
+		return nil
+	}
+	if !monitoring.IsDatadogSite(s.Site) {
+		return fmt.Errorf("%w: unknown site %q", ErrInvalidDatadogSettings, s.Site)
+	}
+	if s.APIKey == "" || s.AppKey == "" {
+		return fmt.Errorf("%w: an API key and an application key are required", ErrInvalidDatadogSettings)
+	}
+	return nil
+}
+
+// GetDatadogSettings returns the organization's Datadog settings, including
+// the keys
+func (s *MonitoringService) GetDatadogSettings(ctx context.Context, orgID uuid.UUID) (*DatadogSettings, error) {
+	settings, err := s.userRepo.GetOrganizationSettings(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := &DatadogSettings{}
+	ddSettings, ok := settings["datadog"].(map[string]interface{})
+	if !ok {
+		return cfg, nil
+	}
+	if v, ok := ddSettings["site"].(string); ok {
+		cfg.Site = v
+	}
+	if v, ok := ddSettings["api_key"].(string); ok {
+		if cfg.APIKey, err = openCredential(s.encryptor, v); err != nil {
+			return nil, err
+		}
+	}
+	if v, ok := ddSettings["app_key"].(string); ok {
+		if cfg.AppKey, err = openCredential(s.encryptor, v); err != nil {
+			return nil, err
+		}
+	}
+	return cfg, nil
+}
+
+// UpdateDatadogSettings replaces the organization's Datadog settings. An
+// empty key keeps the stored one. The returned settings omit the keys.
+func (s *MonitoringService) UpdateDatadogSettings(ctx context.Context, ac AuditContext, req DatadogSettings) (*DatadogSettings, error) {
+	settings, err := s.userRepo.GetOrganizationSettings(ctx, ac.OrgID)
+	if err != nil {
+		return nil, err
+	}
+
+	if existing, ok := settings["datadog"].(map[string]interface{}); ok {
+		if v, ok := existing["api_key"].(string); ok && req.APIKey == "" {
+			if req.APIKey, err = openCredential(s.encryptor, v); err != nil {
+				return nil, err
+			}
+		}
+		if v, ok := existing["app_key"].(string); ok && req.AppKey == "" {
+			if req.AppKey, err = openCredential(s.encryptor, v); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := req.validate(); err != nil {
+		return nil, err
+	}
+
+	apiKey, err := sealCredential(s.encryptor, req.APIKey)
+	if err != nil {
+		return nil, err
+	}
+	appKey, err := sealCredential(s.encryptor, req.AppKey)
+	if err != nil {
+		return nil, err
+	}
+	settings["datadog"] = map[string]interface{}{
+		"site":    req.Site,
+		"api_key": apiKey,
+		"app_key": appKey,
+	}
+	if err := s.userRepo.UpdateOrganizationSettings(ctx, ac.OrgID, settings); err != nil {
+		return nil, err
+	}
+
+	s.auditSvc.LogUpdate(ctx, ac, "datadog_settings", ac.OrgID, "datadog", nil, map[string]interface{}{
+		"site":        req.Site,
+		"api_key_set": req.APIKey != "",
+		"app_key_set": req.AppKey != "",
+	})
+
+	req.APIKey = ""
+	req.AppKey = ""
+	return &req, nil
+}
+
+// TestDatadogSettings checks that Datadog accepts the given API key. Empty
+// keys are taken from the stored settings.
+func (s *MonitoringService) TestDatadogSettings(ctx context.Context, orgID uuid.UUID, req DatadogSettings) error {
+	if req.APIKey == "" || req.AppKey == "" {
+		stored, err := s.GetDatadogSettings(ctx, orgID)
+		if err != nil {
+			return err
+		}
+		if req.APIKey == "" {
+			req.APIKey = stored.APIKey
+		}
+		if req.AppKey == "" {
+			req.AppKey = stored.AppKey
+		}
+	}
+	if err := req.validate(); err != nil {
+		return err
+	}
+	return s.checker.CheckDatadog(ctx, req.monitoringConfig())
+}
+
+// config returns the credentials an organization's dashboards are checked
+// with
+func (s *MonitoringService) config(ctx context.Context, orgID uuid.UUID) (*monitoring.Config, error) {
+	dd, err := s.GetDatadogSettings(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	cfg := dd.monitoringConfig()
+
+	gf, err := s.grafana.GetSettings(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	cfg.GrafanaURL = gf.URL
+	cfg.GrafanaToken = gf.APIToken
+	return &cfg, nil
+}
+
+// ============================================
+// Monitoring Links
+// ============================================
+
+// MonitoringLinkRequest is a monitoring link of a namespace. Without a type,
+// it is inferred from the URL.
+type MonitoringLinkRequest struct {
+	Type string `json:"type"`
+	URL  string `json:"url"`
+}
+
+// parseMonitoringLinks validates and normalizes the monitoring links of a
+// namespace, dropping duplicates
+func parseMonitoringLinks(reqs []MonitoringLinkRequest) ([]models.MonitoringLink, error) {
+	links := make([]models.MonitoringLink, 0, len(reqs))
+	seen := make(map[string]bool, len(reqs))
+	for _, req := range reqs {
+		link, err := monitoring.Parse(req.Type, req.URL)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidNamespace, err)
+		}
+		if seen[link.URL] {
+			continue
+		}
+		seen[link.URL] = true
+		links = append(links, models.MonitoringLink{Type: link.Type, URL: link.URL})
+	}
+	if len(links) > maxMonitoringLinks {
+		return nil, fmt.Errorf("%w: at most %d monitoring links can be set", ErrInvalidNamespace, maxMonitoringLinks)
+	}
+	return links, nil
+}
+
+// monitoringLinkURLs lists the URLs of links, for audit logs
+func monitoringLinkURLs(links []models.MonitoringLink) []string {
+	urls := make([]string, len(links))
+	for i, l := range links {
+		urls[i] = l.URL
+	}
+	return urls
+}
+
+// CheckNew checks the links of a namespace that were never checked, in the
+// background so saving does not wait for Grafana or Datadog
+func (s *MonitoringService) CheckNew(ctx context.Context, orgID uuid.UUID, links []models.MonitoringLink) {
+	if s == nil {
+		return
+	}
+	var pending []models.MonitoringLink
+	for _, l := range links {
+		if !l.CheckedAt.Valid && l.Type != monitoring.TypeOther {
+			pending = append(pending, l)
+		}
+	}
+	if len(pending) == 0 {
+		return
+	}
+
+	cfg, err := s.config(ctx, orgID)
+	if err != nil {
+		s.logger.Errorw("Failed to load monitoring settings", "organization_id", orgID, "error", err)
+		return
+	}
+	ctx = context.WithoutCancel(ctx)
+	go func() {
+		for i := range pending {
+			s.check(ctx, cfg, &pending[i])
+		}
+	}()
+}
+
+// RefreshLinks checks the Grafana and Datadog links not checked within
+// monitoringCheckInterval, those never checked first
+func (s *MonitoringService) RefreshLinks(ctx context.Context) error {
+	stale, err := s.namespaceRepo.ListStaleMonitoringLinks(ctx, time.Now().Add(-monitoringCheckInterval), monitoringCheckBatch)
+	if err != nil {
+		return err
+	}
+
+	configs := make(map[uuid.UUID]*monitoring.Config)
+	for i := range stale {
+		l := &stale[i]
+		cfg, ok := configs[l.OrganizationID]
+		if !ok {
+			cfg, err = s.config(ctx, l.OrganizationID)
+			if err != nil {
+				s.logger.Errorw("Failed to load monitoring settings", "organization_id", l.OrganizationID, "error", err)
+			}
+			configs[l.OrganizationID] = cfg
+		}
+		if cfg == nil {
+			continue
+		}
+		s.check(ctx, cfg, l)
+	}
+	return nil
+}
+
+// check checks that a link's dashboard exists and stores the result
+func (s *MonitoringService) check(ctx context.Context, cfg *monitoring.Config, l *models.MonitoringLink) {
+	link, err := monitoring.Parse(l.Type, l.URL)
+	if err == nil {
+		err = s.checker.Check(ctx, *cfg, link)
+	}
+	if err != nil && err != monitoring.ErrNotFound && err != monitoring.ErrNotConfigured {
+		s.logger.Warnw("Failed to check monitoring link", "url", l.URL, "error", err)
+	}
+
+	applyMonitoringCheck(l, err, time.Now())
+	if err := s.namespaceRepo.UpdateMonitoringLinkCheck(ctx, l); err != nil {
+		s.logger.Errorw("Failed to store monitoring link check", "url", l.URL, "error", err)
+	}
+}
+
+// applyMonitoringCheck records the result of checking a link at checkedAt.
+// When the check could not tell, whether the dashboard was missing before is
+// kept next to the error.
+func applyMonitoringCheck(l *models.MonitoringLink, err error, checkedAt time.Time) {
+	l.CheckedAt = models.NullTime{Time: checkedAt, Valid: true}
+	switch {
+	case err == nil:
+		l.Missing = false
+		l.CheckError = models.NullString{}
+	case errors.Is(err, monitoring.ErrNotFound):
+		l.Missing = true
+		l.CheckError = models.NullString{}
+	default:
+		l.CheckError = models.NewNullStringFromString(err.Error())
+	}
+}
+
+// ============================================
+// Coverage Report
+// ============================================
+
+// MonitoringCoverageReport lists the namespaces of a criticality tier that
+// have no monitoring, meaning no links or only links to deleted dashboards
+type MonitoringCoverageReport struct {
+	Criticality     string                               `json:"criticality"`
+	TotalNamespaces int                                  `json:"total_namespaces"`
+	Monitored       int                                  `json:"monitored"`
+	Percentage      float64                              `json:"percentage"`
+	Unmonitored     []models.NamespaceMonitoringCoverage `json:"unmonitored"`
+}
+
+// GetCoverageReport reports the namespaces of a criticality tier without
+// monitoring. Without a criticality, the organization's most critical tier
+// is reported.
+func (s *MonitoringService) GetCoverageReport(ctx context.Context, orgID uuid.UUID, criticality string) (*MonitoringCoverageReport, error) {
+	if criticality == "" {
+		tiers, err := s.settings.CriticalityTiers(ctx, orgID)
+		if err != nil {
+			return nil, err
+		}
+		criticality = tiers[0].Name
+	}
+
+	namespaces, err := s.namespaceRepo.ListMonitoringCoverage(ctx, orgID, criticality)
+	if err != nil {
+		return nil, err
+	}
+	return monitoringCoverageReport(criticality, namespaces), nil
+}
+
+func monitoringCoverageReport(criticality string, namespaces []models.NamespaceMonitoringCoverage) *MonitoringCoverageReport {
+	report := &MonitoringCoverageReport{
+		Criticality:     criticality,
+		TotalNamespaces: len(namespaces),
+		Unmonitored:     []models.NamespaceMonitoringCoverage{},
+	}
+	for _, ns := range namespaces {
+		if ns.Links > ns.MissingLinks {
+			report.Monitored++
+			continue
+		}
+		report.Unmonitored = append(report.Unmonitored, ns)
+	}
+	if report.TotalNamespaces > 0 {
+		report.Percentage = float64(report.Monitored) / float64(report.TotalNamespaces) * 100
+	}
+	return report
+}
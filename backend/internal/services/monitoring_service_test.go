@@ -0,0 +1,102 @@
+package services
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/kubeatlas/kubeatlas/internal/models"
+	"github.com/kubeatlas/kubeatlas/internal/monitoring"
+)
+
+func TestParseMonitoringLinks(t *testing.T) {
+	links, err := parseMonitoringLinks([]MonitoringLinkRequest{
+		{URL: "https://grafana.acme.io/d/abc123/payments"},
+		{URL: "https://grafana.acme.io/d/abc123/payments#panel-2"},
+		{Type: monitoring.TypeDatadog, URL: "https://app.datadoghq.eu/dashboard/abc-def-ghi"},
+		{URL: "https://status.acme.io/payments"},
+	})
+	if err != nil {
+		t.Fatalf("parseMonitoringLinks() error = %v", err)
+	}
+	want := []models.MonitoringLink{
+		{Type: monitoring.TypeGrafana, URL: "https://grafana.acme.io/d/abc123/payments"},
+		{Type: monitoring.TypeDatadog, URL: "https://app.datadoghq.eu/dashboard/abc-def-ghi"},
+		{Type: monitoring.TypeOther, URL: "https://status.acme.io/payments"},
+	}
+	if !reflect.DeepEqual(links, want) {
+		t.Errorf("parseMonitoringLinks() = %+v, want %+v", links, want)
+	}
+
+	if _, err := parseMonitoringLinks([]MonitoringLinkRequest{{Type: monitoring.TypeGrafana, URL: "https://grafana.acme.io/explore"}}); !errors.Is(err, ErrInvalidNamespace) {
+		t.Errorf("parseMonitoringLinks() of a page that is not a dashboard error = %v, want ErrInvalidNamespace", err)
+	}
+
+	many := make([]MonitoringLinkRequest, maxMonitoringLinks+1)
+	for i := range many {
+		many[i] = MonitoringLinkRequest{URL: "https://grafana.acme.io/d/" + string(rune('a'+i))}
+	}
+	if _, err := parseMonitoringLinks(many); !errors.Is(err, ErrInvalidNamespace) {
+		t.Errorf("parseMonitoringLinks() of %d links error = %v, want ErrInvalidNamespace", len(many), err)
+	}
+}
+
+func TestApplyMonitoringCheck(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+
+	var l models.MonitoringLink
+	applyMonitoringCheck(&l, monitoring.ErrNotFound, now)
+	if !l.Missing || l.CheckError.Valid || !l.CheckedAt.Time.Equal(now) {
+		t.Errorf("after finding the dashboard deleted = %+v", l)
+	}
+
+	// A check that cannot tell keeps whether the dashboard was missing
+	applyMonitoringCheck(&l, errors.New("grafana.acme.io returned 403: Forbidden"), now)
+	if !l.Missing || l.CheckError.ValueOrEmpty() == "" {
+		t.Errorf("after a failed check = %+v", l)
+	}
+
+	applyMonitoringCheck(&l, nil, now)
+	if l.Missing || l.CheckError.Valid {
+		t.Errorf("after finding the dashboard = %+v", l)
+	}
+}
+
+func TestMonitoringCoverageReport(t *testing.T) {
+	report := monitoringCoverageReport("tier-1", []models.NamespaceMonitoringCoverage{
+		{Namespace: "payments", Links: 2, MissingLinks: 1},
+		{Namespace: "orders"},
+		{Namespace: "ledger", Links: 1, MissingLinks: 1},
+		{Namespace: "auth", Links: 1},
+	})
+	if report.Criticality != "tier-1" || report.TotalNamespaces != 4 || report.Monitored != 2 || report.Percentage != 50 {
+		t.Errorf("report = %+v", report)
+	}
+	if len(report.Unmonitored) != 2 || report.Unmonitored[0].Namespace != "orders" || report.Unmonitored[1].Namespace != "ledger" {
+		t.Errorf("Unmonitored = %+v", report.Unmonitored)
+	}
+
+	if empty := monitoringCoverageReport("tier-1", nil); empty.Percentage != 0 || empty.Unmonitored == nil {
+		t.Errorf("report of no namespaces = %+v", empty)
+	}
+}
+
+func TestDatadogSettingsValidate(t *testing.T) {
+	for _, s := range []DatadogSettings{
+		{},
+		{Site: "datadoghq.eu", APIKey: "api", AppKey: "app"},
+	} {
+		if err := s.validate(); err != nil {
+			t.Errorf("validate(%+v) error = %v", s, err)
+		}
+	}
+	for _, s := range []DatadogSettings{
+		{Site: "datadog.acme.io", APIKey: "api", AppKey: "app"},
+		{Site: "datadoghq.com", APIKey: "api"},
+	} {
+		if err := s.validate(); !errors.Is(err, ErrInvalidDatadogSettings) {
+			t.Errorf("validate(%+v) error = %v, want ErrInvalidDatadogSettings", s, err)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kubeatlas/kubeatlas/internal/models"
+)
+
+// AccessRole is a role a subject has in a namespace, with the binding that
+// grants it
+type AccessRole struct {
+	BindingKind string `json:"binding_kind"`
+	BindingName string `json:"binding_name"`
+	RoleKind    string `json:"role_kind"`
+	RoleName    string `json:"role_name"`
+}
+
+// AccessSubject is a user, group or service account with roles in a
+// namespace. Namespace is only set for service accounts.
+type AccessSubject struct {
+	Kind      string       `json:"kind"`
+	Name      string       `json:"name"`
+	Namespace string       `json:"namespace,omitempty"`
+	Roles     []AccessRole `json:"roles"`
+}
+
+// NamespaceAccess is the RBAC inventory of a namespace found by the last
+// cluster sync, for access reviews
+type NamespaceAccess struct {
+	NamespaceID     uuid.UUID                        `json:"namespace_id"`
+	Namespace       string                           `json:"namespace"`
+	Subjects        []AccessSubject                  `json:"subjects"`
+	ServiceAccounts []models.NamespaceServiceAccount `json:"service_accounts"`
+	// SyncedAt is when the inventory was found, or nil if it is empty
+	SyncedAt *time.Time `json:"synced_at"`
+}
+
+// GetAccess returns which subjects have which roles in a namespace
+func (s *NamespaceService) GetAccess(ctx context.Context, id uuid.UUID) (*NamespaceAccess, error) {
+	ns, err := s.namespaceRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if ns == nil {
+		return nil, ErrNamespaceNotFound
+	}
+
+	bindings, err := s.namespaceRepo.ListRoleBindings(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	accounts, err := s.namespaceRepo.ListServiceAccounts(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	access := &NamespaceAccess{
+		NamespaceID:     ns.ID,
+		Namespace:       ns.Name,
+		Subjects:        accessSubjects(bindings),
+		ServiceAccounts: accounts,
+	}
+	for _, b := range bindings {
+		if access.SyncedAt == nil || b.SyncedAt.After(*access.SyncedAt) {
+			t := b.SyncedAt
+			access.SyncedAt = &t
+		}
+	}
+	for _, a := range accounts {
+		if access.SyncedAt == nil || a.SyncedAt.After(*access.SyncedAt) {
+			t := a.SyncedAt
+			access.SyncedAt = &t
+		}
+	}
+	return access, nil
+}
+
+// accessSubjects groups role bindings by subject, in the order the subjects
+// first appear
+func accessSubjects(bindings []models.NamespaceRoleBinding) []AccessSubject {
+	subjects := make([]AccessSubject, 0)
+	index := make(map[[3]string]int)
+	for _, b := range bindings {
+		key := [3]string{b.SubjectKind, b.SubjectNamespace.ValueOrEmpty(), b.SubjectName}
+		i, ok := index[key]
+		if !ok {
+			i = len(subjects)
+			index[key] = i
+			subjects = append(subjects, AccessSubject{
+				Kind:      b.SubjectKind,
+				Name:      b.SubjectName,
+				Namespace: b.SubjectNamespace.ValueOrEmpty(),
+				Roles:     []AccessRole{},
+			})
+		}
+		subjects[i].Roles = append(subjects[i].Roles, AccessRole{
+			BindingKind: b.BindingKind,
+			BindingName: b.BindingName,
+			RoleKind:    b.RoleKind,
+			RoleName:    b.RoleName,
+		})
+	}
+	return subjects
+}
@@ -0,0 +1,34 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/kubeatlas/kubeatlas/internal/models"
+)
+
+func TestAccessSubjects(t *testing.T) {
+	payments := models.NewNullStringFromString("payments")
+	bindings := []models.NamespaceRoleBinding{
+		{BindingKind: "RoleBinding", BindingName: "devs", RoleKind: "ClusterRole", RoleName: "edit", SubjectKind: "Group", SubjectName: "payments-devs"},
+		{BindingKind: "RoleBinding", BindingName: "ci", RoleKind: "Role", RoleName: "deployer", SubjectKind: "ServiceAccount", SubjectName: "ci", SubjectNamespace: payments},
+		{BindingKind: "ClusterRoleBinding", BindingName: "ci-view", RoleKind: "ClusterRole", RoleName: "view", SubjectKind: "ServiceAccount", SubjectName: "ci", SubjectNamespace: payments},
+	}
+
+	want := []AccessSubject{
+		{Kind: "Group", Name: "payments-devs", Roles: []AccessRole{
+			{BindingKind: "RoleBinding", BindingName: "devs", RoleKind: "ClusterRole", RoleName: "edit"},
+		}},
+		{Kind: "ServiceAccount", Name: "ci", Namespace: "payments", Roles: []AccessRole{
+			{BindingKind: "RoleBinding", BindingName: "ci", RoleKind: "Role", RoleName: "deployer"},
+			{BindingKind: "ClusterRoleBinding", BindingName: "ci-view", RoleKind: "ClusterRole", RoleName: "view"},
+		}},
+	}
+	if got := accessSubjects(bindings); !reflect.DeepEqual(got, want) {
+		t.Errorf("accessSubjects() = %+v, want %+v", got, want)
+	}
+
+	if got := accessSubjects(nil); got == nil || len(got) != 0 {
+		t.Errorf("accessSubjects(nil) = %#v, want an empty list", got)
+	}
+}
@@ -0,0 +1,335 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kubeatlas/kubeatlas/internal/database/repositories"
+	"github.com/kubeatlas/kubeatlas/internal/models"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrInvalidBulkAssignment  = errors.New("invalid bulk assignment")
+	ErrBulkAssignmentNotFound = errors.New("bulk assignment not found")
+)
+
+const (
+	// bulkAssignSyncLimit is the largest selection assigned within the
+	// request; larger ones run in the background
+	bulkAssignSyncLimit = 100
+	// bulkAssignMaxNamespaces bounds the selection of one bulk assignment
+	bulkAssignMaxNamespaces = 10000
+	// bulkAssignStaleAfter is how long a running assignment may go without
+	// progress before another instance starts it over
+	bulkAssignStaleAfter = 15 * time.Minute
+	// bulkAssignProgressEvery is how many namespaces are done between
+	// progress updates
+	bulkAssignProgressEvery = 50
+	// bulkAssignMaxFailures bounds the failures listed in a summary; all of
+	// them are counted
+	bulkAssignMaxFailures = 100
+	// bulkAssignListLimit is the number of recent assignments listed
+	bulkAssignListLimit = 20
+)
+
+// BulkAssignRequest selects namespaces, by ID or with the namespace list
+// filters, and the fields to set on them
+type BulkAssignRequest struct {
+	NamespaceIDs []uuid.UUID       `json:"namespace_ids"`
+	Filter       map[string]string `json:"filter"`
+	models.NamespaceBulkChanges
+}
+
+// NamespaceBulkService sets tags, owner teams and environments on many
+// namespaces at once. Each namespace is updated as by NamespaceService.Update,
+// so it is audited and notified about the same way.
+type NamespaceBulkService struct {
+	repo          *repositories.NamespaceBulkRepository
+	namespaceRepo *repositories.NamespaceRepository
+	teamRepo      *repositories.TeamRepository
+	namespaces    *NamespaceService
+	settings      *OrgSettingsService
+	auditSvc      *AuditService
+	logger        *zap.SugaredLogger
+}
+
+// NewNamespaceBulkService creates a new bulk assignment service
+func NewNamespaceBulkService(
+	repo *repositories.NamespaceBulkRepository,
+	namespaceRepo *repositories.NamespaceRepository,
+	teamRepo *repositories.TeamRepository,
+	namespaces *NamespaceService,
+	settings *OrgSettingsService,
+	auditSvc *AuditService,
+	logger *zap.SugaredLogger,
+) *NamespaceBulkService {
+	return &NamespaceBulkService{
+		repo:          repo,
+		namespaceRepo: namespaceRepo,
+		teamRepo:      teamRepo,
+		namespaces:    namespaces,
+		settings:      settings,
+		auditSvc:      auditSvc,
+		logger:        logger,
+	}
+}
+
+// Request assigns the changes of req to the namespaces it selects. filters
+// are req.Filter parsed as namespace list filters. Selections of up to
+// bulkAssignSyncLimit namespaces are assigned before it returns; larger
+// ones are queued, and the assignment is returned pending.
+func (s *NamespaceBulkService) Request(ctx context.Context, ac AuditContext, req BulkAssignRequest, filters map[string]interface{}) (*models.NamespaceBulkAssignment, error) {
+	changes, err := normalizeBulkChanges(req.NamespaceBulkChanges)
+	if err != nil {
+		return nil, err
+	}
+	if changes.OwnerTeamID != nil {
+		team, err := s.teamRepo.GetByID(ctx, *changes.OwnerTeamID)
+		if err != nil {
+			return nil, err
+		}
+		if team == nil || team.OrganizationID != ac.OrgID {
+			return nil, fmt.Errorf("%w: owner team not found", ErrInvalidBulkAssignment)
+		}
+	}
+	if changes.Environment != "" {
+		if err := s.settings.ValidateEnvironment(ctx, ac.OrgID, changes.Environment); err != nil {
+			return nil, err
+		}
+	}
+
+	switch {
+	case len(req.NamespaceIDs) > 0 && len(req.Filter) > 0:
+		return nil, fmt.Errorf("%w: give either namespace_ids or a filter", ErrInvalidBulkAssignment)
+	case len(req.NamespaceIDs) > 0:
+		filters = map[string]interface{}{"ids": req.NamespaceIDs}
+	case len(req.Filter) == 0:
+		return nil, fmt.Errorf("%w: select namespaces with namespace_ids or a filter", ErrInvalidBulkAssignment)
+	}
+	ids, total, err := s.namespaceRepo.ListIDs(ctx, ac.OrgID, filters, bulkAssignMaxNamespaces)
+	if err != nil {
+		return nil, err
+	}
+	if total > bulkAssignMaxNamespaces {
+		return nil, fmt.Errorf("%w: %d namespaces selected, at most %d can be assigned at once", ErrInvalidBulkAssignment, total, bulkAssignMaxNamespaces)
+	}
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("%w: no namespaces selected", ErrInvalidBulkAssignment)
+	}
+
+	a := &models.NamespaceBulkAssignment{
+		OrganizationID:   ac.OrgID,
+		RequestedBy:      ac.UserID,
+		RequestedByEmail: ac.UserEmail,
+		Changes:          changes,
+		NamespaceIDs:     ids,
+		Status:           models.BulkAssignmentStatusPending,
+	}
+	if len(ids) <= bulkAssignSyncLimit {
+		a.Status = models.BulkAssignmentStatusRunning
+		a.StartedAt = models.NullTime{Time: time.Now(), Valid: true}
+	}
+	if err := s.repo.Create(ctx, a); err != nil {
+		return nil, err
+	}
+
+	s.auditSvc.LogAction(ctx, ac, "namespace_bulk_assign", "organization", ac.OrgID, "organization",
+		fmt.Sprintf("Requested a bulk assignment of %s to %d namespaces", describeBulkChanges(changes), len(ids)))
+
+	if a.Status == models.BulkAssignmentStatusRunning {
+		if err := s.run(ctx, a); err != nil {
+			if failErr := s.repo.Fail(ctx, a.ID, err.Error()); failErr != nil {
+				s.logger.Errorw("Failed to record bulk assignment failure", "id", a.ID, "error", failErr)
+			}
+			return nil, err
+		}
+	}
+	return a, nil
+}
+
+// List retrieves the organization's most recent bulk assignments
+func (s *NamespaceBulkService) List(ctx context.Context, orgID uuid.UUID) ([]models.NamespaceBulkAssignment, error) {
+	return s.repo.List(ctx, orgID, bulkAssignListLimit)
+}
+
+// Get retrieves a bulk assignment with its progress or summary
+func (s *NamespaceBulkService) Get(ctx context.Context, orgID, id uuid.UUID) (*models.NamespaceBulkAssignment, error) {
+	a, err := s.repo.Get(ctx, orgID, id)
+	if err != nil {
+		return nil, err
+	}
+	if a == nil {
+		return nil, ErrBulkAssignmentNotFound
+	}
+	return a, nil
+}
+
+// ProcessPending runs the next queued bulk assignment, if any. Failed
+// assignments are recorded with their error.
+func (s *NamespaceBulkService) ProcessPending(ctx context.Context) error {
+	a, err := s.repo.ClaimNext(ctx, bulkAssignStaleAfter)
+	if err != nil || a == nil {
+		return err
+	}
+
+	if err := s.run(ctx, a); err != nil {
+		s.logger.Warnw("Bulk namespace assignment failed", "id", a.ID, "organization_id", a.OrganizationID, "error", err)
+		return s.repo.Fail(ctx, a.ID, err.Error())
+	}
+	return nil
+}
+
+// run assigns the changes of a running assignment to each of its namespaces
+// as its requester, and records the summary
+func (s *NamespaceBulkService) run(ctx context.Context, a *models.NamespaceBulkAssignment) error {
+	ac := AuditContext{OrgID: a.OrganizationID, UserID: a.RequestedBy, UserEmail: a.RequestedByEmail}
+	a.Failures = make([]models.NamespaceBulkFailure, 0)
+
+	for _, id := range a.NamespaceIDs {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		name, changed, err := s.assign(ctx, ac, id, a.Changes)
+		switch {
+		case err != nil:
+			a.Failed++
+			if len(a.Failures) < bulkAssignMaxFailures {
+				a.Failures = append(a.Failures, models.NamespaceBulkFailure{NamespaceID: id, Namespace: name, Error: err.Error()})
+			}
+		case changed:
+			a.Updated++
+		default:
+			a.Unchanged++
+		}
+		a.Processed++
+
+		if a.Processed%bulkAssignProgressEvery == 0 && a.Processed < len(a.NamespaceIDs) {
+			if err := s.repo.UpdateProgress(ctx, a); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := s.repo.Complete(ctx, a); err != nil {
+		return err
+	}
+	s.logger.Infow("Bulk namespace assignment completed", "id", a.ID, "organization_id", a.OrganizationID,
+		"updated", a.Updated, "unchanged", a.Unchanged, "failed", a.Failed)
+	return nil
+}
+
+// assign applies the changes to a namespace, reporting its name and whether
+// it changed
+func (s *NamespaceBulkService) assign(ctx context.Context, ac AuditContext, id uuid.UUID, changes models.NamespaceBulkChanges) (string, bool, error) {
+	ns, err := s.namespaceRepo.GetByID(ctx, id)
+	if err != nil {
+		return "", false, err
+	}
+	if ns == nil || ns.OrganizationID != ac.OrgID {
+		return "", false, ErrNamespaceNotFound
+	}
+
+	req, changed := bulkUpdateRequest(ns, changes)
+	if !changed {
+		return ns.Name, false, nil
+	}
+	if _, err := s.namespaces.Update(ctx, ac, id, req); err != nil {
+		return ns.Name, false, err
+	}
+	return ns.Name, true, nil
+}
+
+// normalizeBulkChanges trims and deduplicates the tags of changes, and
+// checks that they change something
+func normalizeBulkChanges(changes models.NamespaceBulkChanges) (models.NamespaceBulkChanges, error) {
+	var err error
+	if changes.AddTags, err = normalizeBulkTags(changes.AddTags); err != nil {
+		return changes, err
+	}
+	if changes.RemoveTags, err = normalizeBulkTags(changes.RemoveTags); err != nil {
+		return changes, err
+	}
+	for _, tag := range changes.AddTags {
+		if slices.Contains(changes.RemoveTags, tag) {
+			return changes, fmt.Errorf("%w: tag %q is both added and removed", ErrInvalidBulkAssignment, tag)
+		}
+	}
+	changes.Environment = strings.TrimSpace(changes.Environment)
+	if len(changes.AddTags) == 0 && len(changes.RemoveTags) == 0 && changes.OwnerTeamID == nil && changes.Environment == "" {
+		return changes, fmt.Errorf("%w: set add_tags, remove_tags, owner_team_id or environment", ErrInvalidBulkAssignment)
+	}
+	return changes, nil
+}
+
+// normalizeBulkTags trims tags and drops empty and repeated ones
+func normalizeBulkTags(tags []string) ([]string, error) {
+	var out []string
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if tag == "" || slices.Contains(out, tag) {
+			continue
+		}
+		if len(tag) > maxTagLength {
+			return nil, fmt.Errorf("%w: tags must be at most %d characters", ErrInvalidBulkAssignment, maxTagLength)
+		}
+		out = append(out, tag)
+	}
+	return out, nil
+}
+
+// bulkUpdateRequest returns the update applying changes to a namespace, and
+// whether it changes anything
+func bulkUpdateRequest(ns *models.Namespace, changes models.NamespaceBulkChanges) (UpdateNamespaceRequest, bool) {
+	var req UpdateNamespaceRequest
+	changed := false
+
+	tags := make([]string, 0, len(ns.Tags)+len(changes.AddTags))
+	for _, tag := range ns.Tags {
+		if !slices.Contains(changes.RemoveTags, tag) {
+			tags = append(tags, tag)
+		}
+	}
+	for _, tag := range changes.AddTags {
+		if !slices.Contains(tags, tag) {
+			tags = append(tags, tag)
+		}
+	}
+	if !slices.Equal(tags, ns.Tags) {
+		req.Tags = tags
+		changed = true
+	}
+
+	if id := changes.OwnerTeamID; id != nil && (ns.InfrastructureOwnerTeamID == nil || *ns.InfrastructureOwnerTeamID != *id) {
+		req.InfrastructureOwnerTeamID = id
+		changed = true
+	}
+	if changes.Environment != "" && changes.Environment != ns.Environment {
+		req.Environment = changes.Environment
+		changed = true
+	}
+	return req, changed
+}
+
+// describeBulkChanges summarizes changes for the audit log
+func describeBulkChanges(changes models.NamespaceBulkChanges) string {
+	var parts []string
+	if len(changes.AddTags) > 0 {
+		parts = append(parts, "tags +"+strings.Join(changes.AddTags, ", +"))
+	}
+	if len(changes.RemoveTags) > 0 {
+		parts = append(parts, "tags -"+strings.Join(changes.RemoveTags, ", -"))
+	}
+	if changes.OwnerTeamID != nil {
+		parts = append(parts, "owner team "+changes.OwnerTeamID.String())
+	}
+	if changes.Environment != "" {
+		parts = append(parts, "environment "+changes.Environment)
+	}
+	return strings.Join(parts, "; ")
+}
@@ -0,0 +1,74 @@
+package services
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/kubeatlas/kubeatlas/internal/models"
+)
+
+func TestNormalizeBulkChanges(t *testing.T) {
+	got, err := normalizeBulkChanges(models.NamespaceBulkChanges{
+		AddTags:     []string{" pci ", "", "pci", "gold"},
+		RemoveTags:  []string{"legacy "},
+		Environment: " production ",
+	})
+	if err != nil {
+		t.Fatalf("normalizeBulkChanges() error = %v", err)
+	}
+	want := models.NamespaceBulkChanges{AddTags: []string{"pci", "gold"}, RemoveTags: []string{"legacy"}, Environment: "production"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("normalizeBulkChanges() = %+v, want %+v", got, want)
+	}
+
+	invalid := []models.NamespaceBulkChanges{
+		{},
+		{AddTags: []string{" "}},
+		{AddTags: []string{"pci"}, RemoveTags: []string{"pci"}},
+	}
+	for _, changes := range invalid {
+		if _, err := normalizeBulkChanges(changes); !errors.Is(err, ErrInvalidBulkAssignment) {
+			t.Errorf("normalizeBulkChanges(%+v) error = %v, want ErrInvalidBulkAssignment", changes, err)
+		}
+	}
+}
+
+func TestBulkUpdateRequest(t *testing.T) {
+	team := uuid.New()
+	ns := &models.Namespace{Tags: []string{"legacy", "pci"}, Environment: "staging", InfrastructureOwnerTeamID: &team}
+
+	req, changed := bulkUpdateRequest(ns, models.NamespaceBulkChanges{AddTags: []string{"gold", "pci"}, RemoveTags: []string{"legacy"}})
+	if !changed || !reflect.DeepEqual(req.Tags, []string{"pci", "gold"}) {
+		t.Errorf("bulkUpdateRequest(tags) = %+v, %v, want tags [pci gold]", req, changed)
+	}
+	if req.InfrastructureOwnerTeamID != nil || req.Environment != "" {
+		t.Errorf("bulkUpdateRequest(tags) = %+v, want only tags set", req)
+	}
+
+	other := uuid.New()
+	req, changed = bulkUpdateRequest(ns, models.NamespaceBulkChanges{OwnerTeamID: &other, Environment: "production"})
+	if !changed || req.Tags != nil || *req.InfrastructureOwnerTeamID != other || req.Environment != "production" {
+		t.Errorf("bulkUpdateRequest(owner, environment) = %+v, %v", req, changed)
+	}
+
+	unchanged := []models.NamespaceBulkChanges{
+		{AddTags: []string{"pci"}},
+		{RemoveTags: []string{"gold"}},
+		{OwnerTeamID: &team, Environment: "staging"},
+	}
+	for _, changes := range unchanged {
+		if req, changed := bulkUpdateRequest(ns, changes); changed {
+			t.Errorf("bulkUpdateRequest(%+v) = %+v, want unchanged", changes, req)
+		}
+	}
+}
+
+func TestDescribeBulkChanges(t *testing.T) {
+	changes := models.NamespaceBulkChanges{AddTags: []string{"pci", "gold"}, RemoveTags: []string{"legacy"}, Environment: "production"}
+	want := "tags +pci, +gold; tags -legacy; environment production"
+	if got := describeBulkChanges(changes); got != want {
+		t.Errorf("describeBulkChanges() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,444 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/kubeatlas/kubeatlas/internal/models"
+)
+
+// Namespace metadata an organization can require
+const (
+	RequireOwner        = "owner"           // an owner team
+	RequireCriticality  = "criticality"     // one of the organization's criticality tiers
+	RequireDocuments    = "documents"       // at least one document
+	RequireBusinessUnit = "business_unit"   // a business unit
+	RequireContacts     = "contacts"        // an application manager or technical lead email
+	RequireTwoContacts  = "two_contacts"    // two different contact emails
+	RequireSLA          = "sla"             // an availability SLA
+	RequireEscalation   = "escalation_path" // an escalation path
+	RequireDescription  = "description"     // a description
+)
+
+var metadataRequirements = []string{
+	RequireOwner, RequireCriticality, RequireDocuments, RequireBusinessUnit, RequireContacts, RequireTwoContacts,
+	RequireSLA, RequireEscalation, RequireDescription,
+}
+
+// maxMetadataPolicies caps the policies evaluated for every namespace
+const maxMetadataPolicies = 50
+
+// MetadataRequirements are the metadata the namespaces of the organization
+// must have, stored in organizations.settings["metadata_requirements"].
+// Required applies to every namespace; policies require more of the
+// namespaces of some environments and criticality tiers. They are enforced
+// by namespace checks, which pipelines run before deploys.
+type MetadataRequirements struct {
+	Required []string         `json:"required"`
+	Policies []MetadataPolicy `json:"policies"`
+}
+
+// MetadataPolicy requires metadata of the namespaces of its environments
+// and criticality tiers, such as an SLA and an escalation path of tier-1
+// production namespaces. A policy without environments or criticalities
+// applies to all of them.
+type MetadataPolicy struct {
+	Name          string   `json:"name"`
+	Environments  []string `json:"environments"`
+	Criticalities []string `json:"criticalities"`
+	Required      []string `json:"required"`
+}
+
+// Matches reports whether the policy applies to ns
+func (p *MetadataPolicy) Matches(ns *models.Namespace) bool {
+	return (len(p.Environments) == 0 || containsString(p.Environments, ns.Environment)) &&
+		(len(p.Criticalities) == 0 || containsString(p.Criticalities, ns.Criticality))
+}
+
+func (m *MetadataRequirements) validate() error {
+	if err := validateRequired(m.Required); err != nil {
+		return err
+	}
+	if len(m.Policies) > maxMetadataPolicies {
+		return fmt.Errorf("at most %d policies are allowed", maxMetadataPolicies)
+	}
+	names := make(map[string]bool)
+	for i := range m.Policies {
+		p := &m.Policies[i]
+		p.Name = strings.TrimSpace(p.Name)
+		if p.Name == "" {
+			return fmt.Errorf("policy %d has no name", i+1)
+		}
+		if names[p.Name] {
+			return fmt.Errorf("duplicate policy %q", p.Name)
+		}
+		names[p.Name] = true
+		if len(p.Required) == 0 {
+			return fmt.Errorf("policy %q requires nothing", p.Name)
+		}
+		if err := validateRequired(p.Required); err != nil {
+			return fmt.Errorf("policy %q: %w", p.Name, err)
+		}
+		for j, env := range p.Environments {
+			p.Environments[j] = strings.ToLower(strings.TrimSpace(env))
+			if !models.IsValidEnvironmentName(p.Environments[j]) {
+				return fmt.Errorf("policy %q: invalid environment name %q", p.Name, env)
+			}
+		}
+		for j, tier := range p.Criticalities {
+			if p.Criticalities[j] = strings.TrimSpace(tier); p.Criticalities[j] == "" {
+				return fmt.Errorf("policy %q: empty criticality", p.Name)
+			}
+		}
+	}
+	return nil
+}
+
+func validateRequired(required []string) error {
+	seen := make(map[string]bool)
+	for _, r := range required {
+		known := false
+		for _, k := range metadataRequirements {
+			known = known || r == k
+		}
+		if !known {
+			return fmt.Errorf("unknown requirement %q", r)
+		}
+		if seen[r] {
+			return fmt.Errorf("duplicate requirement %q", r)
+		}
+		seen[r] = true
+	}
+	return nil
+}
+
+// For returns the metadata required of ns, those of every namespace first,
+// and the names of the policies that apply to it
+func (m *MetadataRequirements) For(ns *models.Namespace) (required, policies []string) {
+	required = append(make([]string, 0, len(m.Required)), m.Required...)
+	policies = make([]string, 0)
+	for i := range m.Policies {
+		p := &m.Policies[i]
+		if !p.Matches(ns) {
+			continue
+		}
+		policies = append(policies, p.Name)
+		for _, r := range p.Required {
+			if !containsString(required, r) {
+				required = append(required, r)
+			}
+		}
+	}
+	return required, policies
+}
+
+// MetadataRequirementsSetting requires an owner, a criticality tier and
+// documentation by default
+var MetadataRequirementsSetting = SettingKey[MetadataRequirements]{
+	Name: "metadata_requirements",
+	Default: MetadataRequirements{
+		Required: []string{RequireOwner, RequireCriticality, RequireDocuments},
+		Policies: []MetadataPolicy{},
+	},
+	Validate: (*MetadataRequirements).validate,
+}
+
+// NamespaceCheck reports whether a namespace has the metadata its
+// organization requires
+type NamespaceCheck struct {
+	NamespaceID uuid.UUID `json:"namespace_id"`
+	Cluster     string    `json:"cluster"`
+	Namespace   string    `json:"namespace"`
+	models.MetadataCheck
+}
+
+// Check checks the namespace named name in the named cluster against the
+// organization's metadata requirements
+func (s *NamespaceService) Check(ctx context.Context, orgID uuid.UUID, clusterName, name string) (*NamespaceCheck, error) {
+	cluster, err := s.clusterRepo.GetByName(ctx, orgID, clusterName)
+	if err != nil {
+		return nil, err
+	}
+	if cluster == nil {
+		return nil, ErrClusterNotFound
+	}
+	ns, err := s.namespaceRepo.GetByClusterAndName(ctx, cluster.ID, name)
+	if err != nil {
+		return nil, err
+	}
+	if ns == nil {
+		return nil, ErrNamespaceNotFound
+	}
+
+	check, err := s.MetadataCheck(ctx, ns)
+	if err != nil {
+		return nil, err
+	}
+	return &NamespaceCheck{
+		NamespaceID:   ns.ID,
+		Cluster:       cluster.Name,
+		Namespace:     ns.Name,
+		MetadataCheck: *check,
+	}, nil
+}
+
+// MetadataCheck evaluates ns against the metadata requirements and
+// policies of its organization
+func (s *NamespaceService) MetadataCheck(ctx context.Context, ns *models.Namespace) (*models.MetadataCheck, error) {
+	reqs, err := GetSetting(ctx, s.settings, ns.OrganizationID, MetadataRequirementsSetting)
+	if err != nil {
+		return nil, err
+	}
+	tiers, err := s.settings.CriticalityTiers(ctx, ns.OrganizationID)
+	if err != nil {
+		return nil, err
+	}
+	required, policies := reqs.For(ns)
+	documents := 0
+	if containsString(required, RequireDocuments) {
+		if documents, err = s.namespaceRepo.CountDocuments(ctx, ns.ID); err != nil {
+			return nil, err
+		}
+	}
+	return metadataCheck(ns, tiers, documents, required, policies), nil
+}
+
+func metadataCheck(ns *models.Namespace, tiers []models.CriticalityTier, documents int, required, policies []string) *models.MetadataCheck {
+	missing := missingMetadata(ns, tiers, documents, required)
+	return &models.MetadataCheck{
+		Complete: len(missing) == 0,
+		Required: required,
+		Missing:  missing,
+		Policies: policies,
+	}
+}
+
+// missingMetadata returns the required metadata ns lacks, in the order of
+// required
+func missingMetadata(ns *models.Namespace, tiers []models.CriticalityTier, documents int, required []string) []string {
+	missing := make([]string, 0)
+	for _, r := range required {
+		var ok bool
+		switch r {
+		case RequireOwner:
+			ok = ns.InfrastructureOwnerTeamID != nil
+		case RequireCriticality:
+			ok = ns.Criticality != "" && models.FindCriticalityTier(tiers, ns.Criticality) != nil
+		case RequireDocuments:
+			ok = documents > 0
+		case RequireBusinessUnit:
+			ok = ns.BusinessUnitID != nil
+		case RequireContacts:
+			ok = ns.ApplicationManagerEmail.ValueOrEmpty() != "" || ns.TechnicalLeadEmail.ValueOrEmpty() != ""
+		case RequireTwoContacts:
+			ok = len(contactEmails(ns)) >= 2
+		case RequireSLA:
+			ok = ns.SLAAvailability.ValueOrEmpty() != ""
+		case RequireEscalation:
+			ok = strings.TrimSpace(ns.EscalationPath.ValueOrEmpty()) != ""
+		case RequireDescription:
+			ok = strings.TrimSpace(ns.Description.ValueOrEmpty()) != ""
+		}
+		if !ok {
+			missing = append(missing, r)
+		}
+	}
+	return missing
+}
+
+// contactEmails returns the different contact emails of ns, ignoring case
+func contactEmails(ns *models.Namespace) []string {
+	emails := make([]string, 0, 3)
+	for _, email := range []models.NullString{ns.ApplicationManagerEmail, ns.TechnicalLeadEmail, ns.ProjectManagerEmail} {
+		e := strings.ToLower(strings.TrimSpace(email.ValueOrEmpty()))
+		if e != "" && !containsString(emails, e) {
+			emails = append(emails, e)
+		}
+	}
+	return emails
+}
+
+// requirementFields are the mappable namespace fields that meet each
+// requirement; any one of them is enough
+var requirementFields = map[string][]string{
+	RequireOwner:        {"owner_team"},
+	RequireCriticality:  {"criticality"},
+	RequireBusinessUnit: {"business_unit"},
+	RequireContacts:     {"application_manager_email", "technical_lead_email"},
+	RequireSLA:          {"sla_availability"},
+	RequireDescription:  {"description"},
+}
+
+// AdmissionRequirement is a required piece of metadata, with the mapping
+// rules that set it from a namespace's labels and annotations
+type AdmissionRequirement struct {
+	Requirement string                `json:"requirement"`
+	Rules       []MetadataMappingRule `json:"rules"`
+}
+
+// AdmissionPolicy is what admission webhooks require of new namespaces:
+// a label or annotation matching one of the rules of each requirement
+type AdmissionPolicy struct {
+	Requirements []AdmissionRequirement `json:"requirements"`
+	// Unenforced are required metadata no mapping rule sets, such as
+	// documents, which can only be checked once the namespace exists
+	Unenforced []string `json:"unenforced"`
+}
+
+// AdmissionPolicy returns the organization's metadata requirements that
+// its mapping rules let namespaces meet with labels and annotations
+func (s *NamespaceService) AdmissionPolicy(ctx context.Context, orgID uuid.UUID) (*AdmissionPolicy, error) {
+	reqs, err := GetSetting(ctx, s.settings, orgID, MetadataRequirementsSetting)
+	if err != nil {
+		return nil, err
+	}
+	mappings, err := GetSetting(ctx, s.settings, orgID, MetadataMappingsSetting)
+	if err != nil {
+		return nil, err
+	}
+	return admissionPolicy(reqs.Required, mappings.Rules), nil
+}
+
+func admissionPolicy(required []string, rules []MetadataMappingRule) *AdmissionPolicy {
+	policy := &AdmissionPolicy{
+		Requirements: make([]AdmissionRequirement, 0),
+		Unenforced:   make([]string, 0),
+	}
+	for _, r := range required {
+		var matching []MetadataMappingRule
+		for _, rule := range rules {
+			if containsString(requirementFields[r], rule.Field) {
+				matching = append(matching, rule)
+			}
+		}
+		if len(matching) == 0 {
+			policy.Unenforced = append(policy.Unenforced, r)
+			continue
+		}
+		policy.Requirements = append(policy.Requirements, AdmissionRequirement{Requirement: r, Rules: matching})
+	}
+	return policy
+}
+
+// ============================================
+// Metadata Policy Report
+// ============================================
+
+// MetadataPolicyCompliance counts the namespaces a metadata policy applies
+// to and those of them with all the metadata it requires
+type MetadataPolicyCompliance struct {
+	Policy     string  `json:"policy"`
+	Namespaces int     `json:"namespaces"`
+	Compliant  int     `json:"compliant"`
+	Percentage float64 `json:"percentage"`
+}
+
+// MetadataViolation is a namespace lacking metadata its organization
+// requires of it
+type MetadataViolation struct {
+	NamespaceID uuid.UUID `json:"namespace_id"`
+	Namespace   string    `json:"namespace"`
+	ClusterID   uuid.UUID `json:"cluster_id"`
+	Cluster     string    `json:"cluster"`
+	Environment string    `json:"environment"`
+	Criticality string    `json:"criticality"`
+	Policies    []string  `json:"policies"`
+	Missing     []string  `json:"missing"`
+}
+
+// MetadataPolicyReport evaluates the unarchived namespaces of an
+// organization against its metadata requirements and policies
+type MetadataPolicyReport struct {
+	TotalNamespaces int                        `json:"total_namespaces"`
+	Compliant       int                        `json:"compliant"`
+	Percentage      float64                    `json:"percentage"`
+	Policies        []MetadataPolicyCompliance `json:"policies"`
+	Violations      []MetadataViolation        `json:"violations"`
+}
+
+// GetMetadataPolicyReport returns the metadata policy compliance report
+func (s *DashboardService) GetMetadataPolicyReport(ctx context.Context, orgID uuid.UUID) (*MetadataPolicyReport, error) {
+	reqs, err := GetSetting(ctx, s.settings, orgID, MetadataRequirementsSetting)
+	if err != nil {
+		return nil, err
+	}
+	tiers, err := s.settings.CriticalityTiers(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	namespaces, err := s.repos.Namespace.ListMetadata(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	return metadataPolicyReport(reqs, tiers, namespaces), nil
+}
+
+func metadataPolicyReport(reqs MetadataRequirements, tiers []models.CriticalityTier, namespaces []models.Namespace) *MetadataPolicyReport {
+	report := &MetadataPolicyReport{
+		TotalNamespaces: len(namespaces),
+		Policies:        make([]MetadataPolicyCompliance, len(reqs.Policies)),
+		Violations:      []MetadataViolation{},
+	}
+	for i, p := range reqs.Policies {
+		report.Policies[i].Policy = p.Name
+	}
+
+	for i := range namespaces {
+		ns := &namespaces[i]
+		for j := range reqs.Policies {
+			if !reqs.Policies[j].Matches(ns) {
+				continue
+			}
+			report.Policies[j].Namespaces++
+			if len(missingMetadata(ns, tiers, ns.DocumentCount, reqs.Policies[j].Required)) == 0 {
+				report.Policies[j].Compliant++
+			}
+		}
+
+		required, policies := reqs.For(ns)
+		missing := missingMetadata(ns, tiers, ns.DocumentCount, required)
+		if len(missing) == 0 {
+			report.Compliant++
+			continue
+		}
+		v := MetadataViolation{
+			NamespaceID: ns.ID,
+			Namespace:   ns.Name,
+			ClusterID:   ns.ClusterID,
+			Environment: ns.Environment,
+			Criticality: ns.Criticality,
+			Policies:    policies,
+			Missing:     missing,
+		}
+		if ns.Cluster != nil {
+			v.Cluster = ns.Cluster.Name
+		}
+		report.Violations = append(report.Violations, v)
+	}
+
+	if report.TotalNamespaces > 0 {
+		report.Percentage = float64(report.Compliant) / float64(report.TotalNamespaces) * 100
+	}
+	for i := range report.Policies {
+		if p := &report.Policies[i]; p.Namespaces > 0 {
+			p.Percentage = float64(p.Compliant) / float64(p.Namespaces) * 100
+		}
+	}
+	return report
+}
+
+// CSV renders the report with one row per namespace lacking metadata
+func (r *MetadataPolicyReport) CSV() ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.Write([]string{"Cluster", "Namespace", "Environment", "Criticality", "Policies", "Missing"})
+	for _, v := range r.Violations {
+		w.Write([]string{v.Cluster, v.Namespace, v.Environment, v.Criticality, strings.Join(v.Policies, "; "), strings.Join(v.Missing, "; ")})
+	}
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
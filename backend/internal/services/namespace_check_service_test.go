@@ -0,0 +1,170 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/kubeatlas/kubeatlas/internal/models"
+)
+
+func TestMissingMetadata(t *testing.T) {
+	team := uuid.New()
+	all := []string{RequireSLA, RequireOwner, RequireCriticality, RequireDocuments, RequireBusinessUnit, RequireContacts}
+
+	ns := &models.Namespace{Criticality: "tier-9"}
+	got := missingMetadata(ns, models.DefaultCriticalityTiers, 0, all)
+	if !reflect.DeepEqual(got, all) {
+		t.Errorf("missing = %v, want all requirements in order", got)
+	}
+
+	ns = &models.Namespace{
+		Criticality:               "tier-1",
+		InfrastructureOwnerTeamID: &team,
+		TechnicalLeadEmail:        models.NewNullStringFromString("lead@example.com"),
+	}
+	got = missingMetadata(ns, models.DefaultCriticalityTiers, 2, all)
+	if want := []string{RequireSLA, RequireBusinessUnit}; !reflect.DeepEqual(got, want) {
+		t.Errorf("missing = %v, want %v", got, want)
+	}
+
+	if got := missingMetadata(&models.Namespace{}, nil, 0, nil); len(got) != 0 {
+		t.Errorf("missing without requirements = %v", got)
+	}
+
+	policy := []string{RequireTwoContacts, RequireEscalation, RequireDescription}
+	ns = &models.Namespace{
+		ApplicationManagerEmail: models.NewNullStringFromString("Lead@example.com"),
+		TechnicalLeadEmail:      models.NewNullStringFromString("lead@example.com"),
+		Description:             models.NewNullStringFromString("  "),
+	}
+	if got := missingMetadata(ns, nil, 0, policy); !reflect.DeepEqual(got, policy) {
+		t.Errorf("missing = %v, want %v with one contact twice and a blank description", got, policy)
+	}
+	ns.ProjectManagerEmail = models.NewNullStringFromString("pm@example.com")
+	ns.EscalationPath = models.NewNullStringFromString("on-call, then the CTO")
+	if got := missingMetadata(ns, nil, 0, policy); !reflect.DeepEqual(got, []string{RequireDescription}) {
+		t.Errorf("missing = %v, want only the description", got)
+	}
+}
+
+func TestMetadataRequirementsFor(t *testing.T) {
+	reqs := MetadataRequirements{
+		Required: []string{RequireOwner},
+		Policies: []MetadataPolicy{
+			{Name: "tier-1 production", Environments: []string{"production"}, Criticalities: []string{"tier-1"},
+				Required: []string{RequireSLA, RequireEscalation, RequireTwoContacts}},
+			{Name: "production", Environments: []string{"production"}, Required: []string{RequireOwner, RequireDocuments}},
+			{Name: "tier-1", Criticalities: []string{"tier-1"}, Required: []string{RequireSLA}},
+		},
+	}
+
+	required, policies := reqs.For(&models.Namespace{Environment: "production", Criticality: "tier-1"})
+	if want := []string{RequireOwner, RequireSLA, RequireEscalation, RequireTwoContacts, RequireDocuments}; !reflect.DeepEqual(required, want) {
+		t.Errorf("required = %v, want %v", required, want)
+	}
+	if want := []string{"tier-1 production", "production", "tier-1"}; !reflect.DeepEqual(policies, want) {
+		t.Errorf("policies = %v, want %v", policies, want)
+	}
+
+	required, policies = reqs.For(&models.Namespace{Environment: "staging", Criticality: "tier-2"})
+	if !reflect.DeepEqual(required, []string{RequireOwner}) || len(policies) != 0 {
+		t.Errorf("For(staging) = %v, %v, want only the owner", required, policies)
+	}
+}
+
+func TestMetadataRequirementsValidate(t *testing.T) {
+	if err := (&MetadataRequirements{Required: []string{RequireOwner, RequireSLA}}).validate(); err != nil {
+		t.Errorf("valid requirements: %v", err)
+	}
+	for _, required := range [][]string{{"owner", "owner"}, {"labels"}} {
+		if err := (&MetadataRequirements{Required: required}).validate(); err == nil {
+			t.Errorf("validate(%v) = nil, want an error", required)
+		}
+	}
+
+	reqs := &MetadataRequirements{Policies: []MetadataPolicy{
+		{Name: " Production ", Environments: []string{" Production"}, Criticalities: []string{"tier-1 "}, Required: []string{RequireSLA}},
+	}}
+	if err := reqs.validate(); err != nil {
+		t.Fatalf("valid policy: %v", err)
+	}
+	if p := reqs.Policies[0]; p.Name != "Production" || p.Environments[0] != "production" || p.Criticalities[0] != "tier-1" {
+		t.Errorf("policy = %+v, want trimmed and the environment lowercased", p)
+	}
+
+	for _, p := range []MetadataPolicy{
+		{Required: []string{RequireSLA}},
+		{Name: "empty"},
+		{Name: "unknown", Required: []string{"labels"}},
+		{Name: "environment", Environments: []string{"prod env"}, Required: []string{RequireSLA}},
+	} {
+		if err := (&MetadataRequirements{Policies: []MetadataPolicy{p}}).validate(); err == nil {
+			t.Errorf("validate(%+v) = nil, want an error", p)
+		}
+	}
+	twice := MetadataPolicy{Name: "prod", Required: []string{RequireSLA}}
+	if err := (&MetadataRequirements{Policies: []MetadataPolicy{twice, twice}}).validate(); err == nil {
+		t.Error("validate() with duplicate policies = nil, want an error")
+	}
+}
+
+func TestMetadataPolicyReport(t *testing.T) {
+	team := uuid.New()
+	reqs := MetadataRequirements{
+		Required: []string{RequireOwner},
+		Policies: []MetadataPolicy{
+			{Name: "production", Environments: []string{"production"}, Required: []string{RequireSLA, RequireDocuments}},
+			{Name: "staging", Environments: []string{"staging"}, Required: []string{RequireSLA}},
+		},
+	}
+	cluster := &models.Cluster{Name: "eu-prod"}
+	namespaces := []models.Namespace{
+		{Name: "payments", Cluster: cluster, Environment: "production", InfrastructureOwnerTeamID: &team,
+			SLAAvailability: models.NewNullStringFromString("99.9"), DocumentCount: 1},
+		{Name: "ledger", Cluster: cluster, Environment: "production", SLAAvailability: models.NewNullStringFromString("99.9"), DocumentCount: 2},
+		{Name: "checkout", Cluster: cluster, Environment: "production", InfrastructureOwnerTeamID: &team},
+		{Name: "search", Cluster: cluster, Environment: "production", InfrastructureOwnerTeamID: &team,
+			SLAAvailability: models.NewNullStringFromString("99.5"), DocumentCount: 3},
+	}
+
+	report := metadataPolicyReport(reqs, nil, namespaces)
+	if report.TotalNamespaces != 4 || report.Compliant != 2 || report.Percentage != 50 {
+		t.Errorf("report = %d of %d compliant (%.0f%%), want 2 of 4", report.Compliant, report.TotalNamespaces, report.Percentage)
+	}
+	want := []MetadataPolicyCompliance{
+		{Policy: "production", Namespaces: 4, Compliant: 3, Percentage: 75},
+		{Policy: "staging"},
+	}
+	if !reflect.DeepEqual(report.Policies, want) {
+		t.Errorf("policies = %+v, want %+v", report.Policies, want)
+	}
+	if len(report.Violations) != 2 {
+		t.Fatalf("violations = %+v, want ledger and checkout", report.Violations)
+	}
+	if v := report.Violations[0]; v.Namespace != "ledger" || v.Cluster != "eu-prod" || !reflect.DeepEqual(v.Missing, []string{RequireOwner}) {
+		t.Errorf("violation = %+v, want ledger missing an owner", v)
+	}
+	if v := report.Violations[1]; !reflect.DeepEqual(v.Missing, []string{RequireSLA, RequireDocuments}) || !reflect.DeepEqual(v.Policies, []string{"production"}) {
+		t.Errorf("violation = %+v, want checkout missing the SLA and documents of the production policy", v)
+	}
+}
+
+func TestAdmissionPolicy(t *testing.T) {
+	owner := MetadataMappingRule{Source: MappingSourceLabel, Key: "team", Field: "owner_team"}
+	lead := MetadataMappingRule{Source: MappingSourceAnnotation, Key: "lead", Field: "technical_lead_email"}
+	manager := MetadataMappingRule{Source: MappingSourceAnnotation, Key: "manager", Field: "application_manager_email"}
+	rules := []MetadataMappingRule{owner, lead, {Source: MappingSourceLabel, Key: "name", Field: "display_name"}, manager}
+
+	policy := admissionPolicy([]string{RequireOwner, RequireDocuments, RequireContacts, RequireCriticality}, rules)
+	want := []AdmissionRequirement{
+		{Requirement: RequireOwner, Rules: []MetadataMappingRule{owner}},
+		{Requirement: RequireContacts, Rules: []MetadataMappingRule{lead, manager}},
+	}
+	if !reflect.DeepEqual(policy.Requirements, want) {
+		t.Errorf("requirements = %+v, want %+v", policy.Requirements, want)
+	}
+	if !reflect.DeepEqual(policy.Unenforced, []string{RequireDocuments, RequireCriticality}) {
+		t.Errorf("unenforced = %v, want documents and criticality", policy.Unenforced)
+	}
+}
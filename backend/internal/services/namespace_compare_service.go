@@ -0,0 +1,382 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/google/uuid"
+	"github.com/kubeatlas/kubeatlas/internal/models"
+	"go.uber.org/zap"
+)
+
+// ComparedNamespace identifies one side of a namespace comparison
+type ComparedNamespace struct {
+	ID          uuid.UUID `json:"id"`
+	Name        string    `json:"name"`
+	Cluster     string    `json:"cluster"`
+	Environment string    `json:"environment"`
+}
+
+// FieldDifference is a field whose value differs between two namespaces.
+// Gap names the namespace without a value, "a" or "b", when only one has it.
+type FieldDifference struct {
+	Field string `json:"field"`
+	A     string `json:"a"`
+	B     string `json:"b"`
+	Gap   string `json:"gap,omitempty"`
+}
+
+// SetDifference splits the items of two namespaces into those only one of
+// them has and those both have
+type SetDifference struct {
+	OnlyA  []string `json:"only_a"`
+	OnlyB  []string `json:"only_b"`
+	Common []string `json:"common"`
+}
+
+// ComparisonGaps lists what each namespace lacks that the other has
+type ComparisonGaps struct {
+	A []string `json:"a"`
+	B []string `json:"b"`
+}
+
+// NamespaceComparison is the difference between two namespaces, such as the
+// staging and production namespaces of an application. Discovered
+// resources are those found by the last sync of each cluster.
+type NamespaceComparison struct {
+	A                    ComparedNamespace `json:"a"`
+	B                    ComparedNamespace `json:"b"`
+	Metadata             []FieldDifference `json:"metadata"`
+	Labels               []FieldDifference `json:"labels"`
+	Tags                 SetDifference     `json:"tags"`
+	InternalDependencies SetDifference     `json:"internal_dependencies"`
+	ExternalDependencies SetDifference     `json:"external_dependencies"`
+	Documents            SetDifference     `json:"documents"`
+	FluxResources        SetDifference     `json:"flux_resources"`
+	ServiceAccounts      SetDifference     `json:"service_accounts"`
+	RoleBindings         SetDifference     `json:"role_bindings"`
+	Gaps                 ComparisonGaps    `json:"gaps"`
+}
+
+// comparedField is a field of a namespace with its value as compared
+type comparedField struct {
+	name  string
+	value string
+}
+
+// namespaceSide is everything compared of one namespace
+type namespaceSide struct {
+	ns                   *models.Namespace
+	cluster              string
+	fields               []comparedField
+	internalDependencies []string
+	externalDependencies []string
+	documents            []string
+	fluxResources        []string
+	serviceAccounts      []string
+	roleBindings         []string
+}
+
+// NamespaceCompareService compares the metadata, dependencies, documents and
+// discovered resources of two namespaces
+type NamespaceCompareService struct {
+	repos  *Repositories
+	logger *zap.SugaredLogger
+}
+
+func NewNamespaceCompareService(repos *Repositories, logger *zap.SugaredLogger) *NamespaceCompareService {
+	return &NamespaceCompareService{repos: repos, logger: logger}
+}
+
+// Compare returns the difference between two of an organization's namespaces
+func (s *NamespaceCompareService) Compare(ctx context.Context, orgID, aID, bID uuid.UUID) (*NamespaceComparison, error) {
+	a, err := s.load(ctx, orgID, aID)
+	if err != nil {
+		return nil, err
+	}
+	b, err := s.load(ctx, orgID, bID)
+	if err != nil {
+		return nil, err
+	}
+	return compareNamespaces(a, b), nil
+}
+
+// load gathers what is compared of a namespace
+func (s *NamespaceCompareService) load(ctx context.Context, orgID, id uuid.UUID) (*namespaceSide, error) {
+	ns, err := s.repos.Namespace.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if ns == nil || ns.OrganizationID != orgID {
+		return nil, ErrNamespaceNotFound
+	}
+	side := &namespaceSide{ns: ns}
+
+	if cluster, err := s.repos.Cluster.GetByID(ctx, ns.ClusterID); err != nil {
+		return nil, err
+	} else if cluster != nil {
+		side.cluster = cluster.Name
+	}
+
+	// Owners are compared by name, so that the sides read alike
+	var ownerTeam, ownerUser, businessUnit string
+	if ns.InfrastructureOwnerTeamID != nil {
+		team, err := s.repos.Team.GetByID(ctx, *ns.InfrastructureOwnerTeamID)
+		if err != nil {
+			return nil, err
+		}
+		if team != nil {
+			ownerTeam = team.Name
+		}
+	}
+	if ns.InfrastructureOwnerUserID != nil {
+		user, err := s.repos.User.GetByID(ctx, *ns.InfrastructureOwnerUserID)
+		if err != nil {
+			return nil, err
+		}
+		if user != nil {
+			ownerUser = user.Email
+		}
+	}
+	if ns.BusinessUnitID != nil {
+		bu, err := s.repos.BusinessUnit.GetByID(ctx, *ns.BusinessUnitID)
+		if err != nil {
+			return nil, err
+		}
+		if bu != nil {
+			businessUnit = bu.Name
+		}
+	}
+	side.fields = namespaceComparedFields(ns, ownerTeam, ownerUser, businessUnit)
+
+	internal, err := s.repos.InternalDependency.ListByNamespace(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	for _, d := range internal {
+		// Namespaces depending on this one are compared by their own side
+		if d.SourceNamespaceID != id {
+			continue
+		}
+		side.internalDependencies = append(side.internalDependencies, fmt.Sprintf("%s (%s)", d.TargetNamespace.Name, d.DependencyType))
+	}
+	external, err := s.repos.ExternalDependency.ListByNamespace(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	for _, d := range external {
+		side.externalDependencies = append(side.externalDependencies, fmt.Sprintf("%s (%s)", d.Name, d.SystemType))
+	}
+
+	docs, err := s.repos.Document.ListByNamespace(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	for _, d := range docs {
+		side.documents = append(side.documents, documentComparisonKey(d))
+	}
+
+	flux, err := s.repos.Namespace.ListFluxResources(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range flux {
+		side.fluxResources = append(side.fluxResources, r.Kind+"/"+r.Name)
+	}
+	accounts, err := s.repos.Namespace.ListServiceAccounts(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	for _, a := range accounts {
+		side.serviceAccounts = append(side.serviceAccounts, a.Name)
+	}
+	bindings, err := s.repos.Namespace.ListRoleBindings(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	for _, b := range bindings {
+		side.roleBindings = append(side.roleBindings, fmt.Sprintf("%s/%s (%s/%s)", b.BindingKind, b.BindingName, b.RoleKind, b.RoleName))
+	}
+	return side, nil
+}
+
+// compareNamespaces compares two loaded namespaces
+func compareNamespaces(a, b *namespaceSide) *NamespaceComparison {
+	c := &NamespaceComparison{
+		A:                    ComparedNamespace{ID: a.ns.ID, Name: a.ns.Name, Cluster: a.cluster, Environment: a.ns.Environment},
+		B:                    ComparedNamespace{ID: b.ns.ID, Name: b.ns.Name, Cluster: b.cluster, Environment: b.ns.Environment},
+		Metadata:             compareFields(a.fields, b.fields),
+		Labels:               compareFields(labelComparedFields(a.ns.K8sLabels), labelComparedFields(b.ns.K8sLabels)),
+		Tags:                 compareSets(a.ns.Tags, b.ns.Tags),
+		InternalDependencies: compareSets(a.internalDependencies, b.internalDependencies),
+		ExternalDependencies: compareSets(a.externalDependencies, b.externalDependencies),
+		Documents:            compareSets(a.documents, b.documents),
+		FluxResources:        compareSets(a.fluxResources, b.fluxResources),
+		ServiceAccounts:      compareSets(a.serviceAccounts, b.serviceAccounts),
+		RoleBindings:         compareSets(a.roleBindings, b.roleBindings),
+		Gaps:                 ComparisonGaps{A: []string{}, B: []string{}},
+	}
+
+	for _, d := range c.Metadata {
+		switch d.Gap {
+		case "a":
+			c.Gaps.A = append(c.Gaps.A, d.Field)
+		case "b":
+			c.Gaps.B = append(c.Gaps.B, d.Field)
+		}
+	}
+	for _, section := range []struct {
+		name string
+		diff SetDifference
+	}{
+		{"internal dependency", c.InternalDependencies},
+		{"external dependency", c.ExternalDependencies},
+		{"document", c.Documents},
+		{"flux resource", c.FluxResources},
+	} {
+		for _, item := range section.diff.OnlyB {
+			c.Gaps.A = append(c.Gaps.A, section.name+" "+item)
+		}
+		for _, item := range section.diff.OnlyA {
+			c.Gaps.B = append(c.Gaps.B, section.name+" "+item)
+		}
+	}
+	return c
+}
+
+// namespaceComparedFields returns the metadata fields of a namespace that
+// are compared, in the order they are shown
+func namespaceComparedFields(ns *models.Namespace, ownerTeam, ownerUser, businessUnit string) []comparedField {
+	fields := []comparedField{
+		{"display_name", ns.DisplayName.ValueOrEmpty()},
+		{"description", ns.Description.ValueOrEmpty()},
+		{"environment", ns.Environment},
+		{"criticality", ns.Criticality},
+		{"lifecycle", ns.Lifecycle},
+		{"infrastructure_owner_team", ownerTeam},
+		{"infrastructure_owner_user", ownerUser},
+		{"business_unit", businessUnit},
+		{"application_manager_name", ns.ApplicationManagerName.ValueOrEmpty()},
+		{"application_manager_email", ns.ApplicationManagerEmail.ValueOrEmpty()},
+		{"application_manager_phone", ns.ApplicationManagerPhone.ValueOrEmpty()},
+		{"technical_lead_name", ns.TechnicalLeadName.ValueOrEmpty()},
+		{"technical_lead_email", ns.TechnicalLeadEmail.ValueOrEmpty()},
+		{"project_manager_name", ns.ProjectManagerName.ValueOrEmpty()},
+		{"project_manager_email", ns.ProjectManagerEmail.ValueOrEmpty()},
+		{"sla_availability", ns.SLAAvailability.ValueOrEmpty()},
+		{"sla_rto", ns.SLARTO.ValueOrEmpty()},
+		{"sla_rpo", ns.SLARPO.ValueOrEmpty()},
+		{"support_hours", ns.SupportHours.ValueOrEmpty()},
+		{"escalation_path", ns.EscalationPath.ValueOrEmpty()},
+	}
+	keys := make([]string, 0, len(ns.CustomFields))
+	for key := range ns.CustomFields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		fields = append(fields, comparedField{"custom_fields." + key, comparedValue(ns.CustomFields[key])})
+	}
+	return fields
+}
+
+// labelComparedFields returns the Kubernetes labels of a namespace as
+// compared fields, by key
+func labelComparedFields(labels models.JSONMap) []comparedField {
+	keys := make([]string, 0, len(labels))
+	for key := range labels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	fields := make([]comparedField, 0, len(keys))
+	for _, key := range keys {
+		fields = append(fields, comparedField{key, comparedValue(labels[key])})
+	}
+	return fields
+}
+
+// comparedValue formats a custom field or label value for comparison
+func comparedValue(v interface{}) string {
+	if v == nil {
+		return ""
+	}
+	if s, ok := v.(string); ok {
+		return s
+	}
+	return fmt.Sprint(v)
+}
+
+// documentComparisonKey is what a document is compared by: its category,
+// so that the runbooks of two namespaces match whatever they are named, or
+// its name when it has none
+func documentComparisonKey(d models.Document) string {
+	if d.Category != nil {
+		return "category " + d.Category.Name
+	}
+	return d.Name
+}
+
+// compareFields returns the fields whose values differ, in the order of a
+// followed by the fields only b has
+func compareFields(a, b []comparedField) []FieldDifference {
+	bValues := make(map[string]string, len(b))
+	for _, f := range b {
+		bValues[f.name] = f.value
+	}
+	seen := make(map[string]bool, len(a))
+	diffs := make([]FieldDifference, 0)
+	add := func(name, av, bv string) {
+		if av == bv {
+			return
+		}
+		d := FieldDifference{Field: name, A: av, B: bv}
+		switch {
+		case av == "":
+			d.Gap = "a"
+		case bv == "":
+			d.Gap = "b"
+		}
+		diffs = append(diffs, d)
+	}
+	for _, f := range a {
+		seen[f.name] = true
+		add(f.name, f.value, bValues[f.name])
+	}
+	for _, f := range b {
+		if !seen[f.name] {
+			add(f.name, "", f.value)
+		}
+	}
+	return diffs
+}
+
+// compareSets splits the items of a and b, sorted and without repeats
+func compareSets(a, b []string) SetDifference {
+	inA := make(map[string]bool, len(a))
+	for _, item := range a {
+		inA[item] = true
+	}
+	inB := make(map[string]bool, len(b))
+	for _, item := range b {
+		inB[item] = true
+	}
+
+	d := SetDifference{OnlyA: []string{}, OnlyB: []string{}, Common: []string{}}
+	for item := range inA {
+		if inB[item] {
+			d.Common = append(d.Common, item)
+		} else {
+			d.OnlyA = append(d.OnlyA, item)
+		}
+	}
+	for item := range inB {
+		if !inA[item] {
+			d.OnlyB = append(d.OnlyB, item)
+		}
+	}
+	sort.Strings(d.OnlyA)
+	sort.Strings(d.OnlyB)
+	sort.Strings(d.Common)
+	return d
+}
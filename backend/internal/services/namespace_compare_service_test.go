@@ -0,0 +1,68 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/kubeatlas/kubeatlas/internal/models"
+)
+
+func TestCompareFields(t *testing.T) {
+	a := []comparedField{{"environment", "staging"}, {"sla_rto", ""}, {"support_hours", "24x7"}, {"description", "Payments"}}
+	b := []comparedField{{"environment", "production"}, {"sla_rto", "4h"}, {"support_hours", ""}, {"description", "Payments"}, {"custom_fields.cost_center", "42"}}
+
+	want := []FieldDifference{
+		{Field: "environment", A: "staging", B: "production"},
+		{Field: "sla_rto", A: "", B: "4h", Gap: "a"},
+		{Field: "support_hours", A: "24x7", B: "", Gap: "b"},
+		{Field: "custom_fields.cost_center", A: "", B: "42", Gap: "a"},
+	}
+	if got := compareFields(a, b); !reflect.DeepEqual(got, want) {
+		t.Errorf("compareFields() = %+v, want %+v", got, want)
+	}
+}
+
+func TestCompareSets(t *testing.T) {
+	got := compareSets([]string{"runbook", "api", "api"}, []string{"dr-plan", "api"})
+	want := SetDifference{OnlyA: []string{"runbook"}, OnlyB: []string{"dr-plan"}, Common: []string{"api"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("compareSets() = %+v, want %+v", got, want)
+	}
+
+	if got := compareSets(nil, nil); got.OnlyA == nil || got.OnlyB == nil || got.Common == nil {
+		t.Errorf("compareSets(nil, nil) = %+v, want empty lists", got)
+	}
+}
+
+func TestCompareNamespaces(t *testing.T) {
+	a := &namespaceSide{
+		ns:                   &models.Namespace{BaseModel: models.BaseModel{ID: uuid.New()}, Name: "payments", Environment: "staging", K8sLabels: models.JSONMap{"team": "payments"}},
+		cluster:              "staging-1",
+		fields:               []comparedField{{"technical_lead_email", ""}},
+		internalDependencies: []string{"postgres (database)"},
+		documents:            []string{"category Runbook"},
+	}
+	b := &namespaceSide{
+		ns:                   &models.Namespace{BaseModel: models.BaseModel{ID: uuid.New()}, Name: "payments", Environment: "production", K8sLabels: models.JSONMap{"team": "payments", "tier": "1"}},
+		cluster:              "prod-1",
+		fields:               []comparedField{{"technical_lead_email", "lead@acme.com"}},
+		internalDependencies: []string{"postgres (database)", "redis (cache)"},
+		fluxResources:        []string{"HelmRelease/payments"},
+	}
+
+	c := compareNamespaces(a, b)
+	if c.A.Cluster != "staging-1" || c.B.Environment != "production" {
+		t.Errorf("compareNamespaces() sides = %+v, %+v", c.A, c.B)
+	}
+	if want := []FieldDifference{{Field: "tier", A: "", B: "1", Gap: "a"}}; !reflect.DeepEqual(c.Labels, want) {
+		t.Errorf("compareNamespaces() labels = %+v, want %+v", c.Labels, want)
+	}
+	wantGaps := ComparisonGaps{
+		A: []string{"technical_lead_email", "internal dependency redis (cache)", "flux resource HelmRelease/payments"},
+		B: []string{"document category Runbook"},
+	}
+	if !reflect.DeepEqual(c.Gaps, wantGaps) {
+		t.Errorf("compareNamespaces() gaps = %+v, want %+v", c.Gaps, wantGaps)
+	}
+}
@@ -0,0 +1,54 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kubeatlas/kubeatlas/internal/models"
+)
+
+// NamespaceFlux is the Flux Kustomizations and HelmReleases of a namespace
+// found by the last cluster sync
+type NamespaceFlux struct {
+	NamespaceID uuid.UUID             `json:"namespace_id"`
+	Namespace   string                `json:"namespace"`
+	Resources   []models.FluxResource `json:"resources"`
+	// NotReady counts the resources whose Ready condition is not True
+	NotReady int `json:"not_ready"`
+	// SyncedAt is when the resources were found, or nil if there are none
+	SyncedAt *time.Time `json:"synced_at"`
+}
+
+// GetFlux returns the Flux resources of a namespace with their sources and
+// readiness
+func (s *NamespaceService) GetFlux(ctx context.Context, id uuid.UUID) (*NamespaceFlux, error) {
+	ns, err := s.namespaceRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if ns == nil {
+		return nil, ErrNamespaceNotFound
+	}
+
+	resources, err := s.namespaceRepo.ListFluxResources(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	flux := &NamespaceFlux{
+		NamespaceID: ns.ID,
+		Namespace:   ns.Name,
+		Resources:   resources,
+	}
+	for _, r := range resources {
+		if r.Ready != "True" {
+			flux.NotReady++
+		}
+		if flux.SyncedAt == nil || r.SyncedAt.After(*flux.SyncedAt) {
+			t := r.SyncedAt
+			flux.SyncedAt = &t
+		}
+	}
+	return flux, nil
+}
@@ -0,0 +1,114 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kubeatlas/kubeatlas/internal/models"
+)
+
+var (
+	ErrInvalidLifecycleTransition = errors.New("invalid lifecycle transition")
+	ErrLifecycleFieldsMissing     = errors.New("namespace is missing fields required by the lifecycle stage")
+)
+
+// SetLifecycleRequest moves a namespace to a lifecycle stage. An empty
+// DecommissionDate keeps the stored one.
+type SetLifecycleRequest struct {
+	Lifecycle        string `json:"lifecycle" binding:"required"`
+	DecommissionDate string `json:"decommission_date"` // YYYY-MM-DD
+}
+
+// SetLifecycle moves a namespace to a lifecycle stage the stage it is in
+// allows, once the namespace has the fields the stage requires. Setting the
+// stage it is already in updates its decommission date.
+func (s *NamespaceService) SetLifecycle(ctx context.Context, ac AuditContext, id uuid.UUID, req SetLifecycleRequest) (*models.Namespace, error) {
+	ns, err := s.namespaceRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if ns == nil || ns.OrganizationID != ac.OrgID {
+		return nil, ErrNamespaceNotFound
+	}
+
+	if err := checkLifecycleTransition(ns.Lifecycle, req.Lifecycle); err != nil {
+		return nil, err
+	}
+	decommissionDate := ns.DecommissionDate
+	if req.DecommissionDate != "" {
+		if _, err := time.Parse(time.DateOnly, req.DecommissionDate); err != nil {
+			return nil, fmt.Errorf("%w: decommission_date must be YYYY-MM-DD", ErrInvalidNamespace)
+		}
+		decommissionDate = models.NewNullStringFromString(req.DecommissionDate)
+	}
+	// Applications back in service are no longer due to be retired
+	if req.Lifecycle == models.NamespaceLifecycleActive {
+		decommissionDate = models.NullString{}
+	}
+
+	before := *ns
+	ns.Lifecycle = req.Lifecycle
+	ns.DecommissionDate = decommissionDate
+	if missing := lifecycleMissingFields(ns); len(missing) > 0 {
+		return nil, fmt.Errorf("%w: %s needs %s", ErrLifecycleFieldsMissing, ns.Lifecycle, strings.Join(missing, ", "))
+	}
+
+	var date *time.Time
+	if decommissionDate.Valid {
+		d, _ := time.Parse(time.DateOnly, decommissionDate.String)
+		date = &d
+	}
+	if _, err := s.namespaceRepo.SetLifecycle(ctx, id, ns.Lifecycle, date); err != nil {
+		return nil, err
+	}
+	if before.Lifecycle != ns.Lifecycle {
+		ns.LifecycleChangedAt = models.NullTime{Time: time.Now(), Valid: true}
+	}
+
+	s.auditSvc.LogUpdate(ctx, ac, "namespace", ns.ID, ns.Name,
+		map[string]interface{}{"lifecycle": before.Lifecycle, "decommission_date": before.DecommissionDate.ValueOrEmpty()},
+		map[string]interface{}{"lifecycle": ns.Lifecycle, "decommission_date": ns.DecommissionDate.ValueOrEmpty()})
+	s.webhooks.Publish(ctx, ns.OrganizationID, models.WebhookEventNamespaceUpdated, ns)
+	return ns, nil
+}
+
+// checkLifecycleTransition reports whether a namespace can move from one
+// lifecycle stage to another
+func checkLifecycleTransition(from, to string) error {
+	if _, ok := models.NamespaceLifecycleTransitions[to]; !ok {
+		return fmt.Errorf("%w: unknown lifecycle %q", ErrInvalidLifecycleTransition, to)
+	}
+	if from == to || slices.Contains(models.NamespaceLifecycleTransitions[from], to) {
+		return nil
+	}
+	return fmt.Errorf("%w: %s cannot move to %s", ErrInvalidLifecycleTransition, from, to)
+}
+
+// lifecycleMissingFields returns the fields the lifecycle stage of a
+// namespace requires that it does not have
+func lifecycleMissingFields(ns *models.Namespace) []string {
+	var missing []string
+	need := func(field string, ok bool) {
+		if !ok {
+			missing = append(missing, field)
+		}
+	}
+	switch ns.Lifecycle {
+	case models.NamespaceLifecycleActive:
+		need("infrastructure_owner_team_id", ns.InfrastructureOwnerTeamID != nil)
+		need("business_unit_id", ns.BusinessUnitID != nil)
+		need("application_manager_email", ns.ApplicationManagerEmail.ValueOrEmpty() != "")
+		need("technical_lead_email", ns.TechnicalLeadEmail.ValueOrEmpty() != "")
+	case models.NamespaceLifecycleDecommissioning:
+		need("infrastructure_owner_team_id", ns.InfrastructureOwnerTeamID != nil)
+		need("decommission_date", ns.DecommissionDate.Valid)
+	case models.NamespaceLifecycleDecommissioned:
+		need("decommission_date", ns.DecommissionDate.Valid)
+	}
+	return missing
+}
@@ -0,0 +1,62 @@
+package services
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/kubeatlas/kubeatlas/internal/models"
+)
+
+func TestCheckLifecycleTransition(t *testing.T) {
+	tests := []struct {
+		from, to string
+		ok       bool
+	}{
+		{models.NamespaceLifecycleOnboarding, models.NamespaceLifecycleActive, true},
+		{models.NamespaceLifecycleActive, models.NamespaceLifecycleDecommissioning, true},
+		{models.NamespaceLifecycleDecommissioning, models.NamespaceLifecycleActive, true},
+		{models.NamespaceLifecycleDecommissioning, models.NamespaceLifecycleDecommissioned, true},
+		{models.NamespaceLifecycleDecommissioning, models.NamespaceLifecycleDecommissioning, true},
+		{models.NamespaceLifecycleActive, models.NamespaceLifecycleDecommissioned, false},
+		{models.NamespaceLifecycleActive, models.NamespaceLifecycleOnboarding, false},
+		{models.NamespaceLifecycleDecommissioned, models.NamespaceLifecycleActive, false},
+		{models.NamespaceLifecycleActive, "retired", false},
+	}
+	for _, tt := range tests {
+		err := checkLifecycleTransition(tt.from, tt.to)
+		if tt.ok && err != nil {
+			t.Errorf("checkLifecycleTransition(%s, %s) error = %v", tt.from, tt.to, err)
+		}
+		if !tt.ok && !errors.Is(err, ErrInvalidLifecycleTransition) {
+			t.Errorf("checkLifecycleTransition(%s, %s) error = %v, want ErrInvalidLifecycleTransition", tt.from, tt.to, err)
+		}
+	}
+}
+
+func TestLifecycleMissingFields(t *testing.T) {
+	team := uuid.New()
+	ns := &models.Namespace{
+		Lifecycle:                 models.NamespaceLifecycleActive,
+		InfrastructureOwnerTeamID: &team,
+		ApplicationManagerEmail:   models.NewNullStringFromString("ada@acme.com"),
+	}
+	want := []string{"business_unit_id", "technical_lead_email"}
+	if got := lifecycleMissingFields(ns); !reflect.DeepEqual(got, want) {
+		t.Errorf("lifecycleMissingFields(active) = %v, want %v", got, want)
+	}
+
+	ns.Lifecycle = models.NamespaceLifecycleDecommissioning
+	if got := lifecycleMissingFields(ns); !reflect.DeepEqual(got, []string{"decommission_date"}) {
+		t.Errorf("lifecycleMissingFields(decommissioning) = %v, want the decommission date", got)
+	}
+	ns.DecommissionDate = models.NewNullStringFromString("2026-12-31")
+	if got := lifecycleMissingFields(ns); len(got) != 0 {
+		t.Errorf("lifecycleMissingFields(decommissioning) = %v, want none", got)
+	}
+
+	if got := lifecycleMissingFields(&models.Namespace{Lifecycle: models.NamespaceLifecycleOnboarding}); len(got) != 0 {
+		t.Errorf("lifecycleMissingFields(onboarding) = %v, want none", got)
+	}
+}
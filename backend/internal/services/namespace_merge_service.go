@@ -0,0 +1,52 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/kubeatlas/kubeatlas/internal/models"
+)
+
+var ErrDuplicateNotFound = errors.New("deleted namespace not found or already merged")
+
+// ListDuplicates lists the organization's deleted namespaces that were
+// replaced by a current namespace, typically because their cluster was
+// removed and added again
+func (s *NamespaceService) ListDuplicates(ctx context.Context, orgID uuid.UUID) ([]models.NamespaceDuplicate, error) {
+	return s.namespaceRepo.ListDuplicates(ctx, orgID)
+}
+
+// Merge moves the ownership, documents and dependencies of the deleted
+// namespace deletedID to the current namespace id, which keeps the values it
+// already has. The deleted namespace stays in the trash, marked merged.
+func (s *NamespaceService) Merge(ctx context.Context, ac AuditContext, deletedID, id uuid.UUID) (*models.NamespaceMerge, error) {
+	if deletedID == id {
+		return nil, fmt.Errorf("%w: a namespace cannot be merged into itself", ErrInvalidNamespace)
+	}
+	ns, err := s.namespaceRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if ns == nil || ns.OrganizationID != ac.OrgID {
+		return nil, ErrNamespaceNotFound
+	}
+
+	merge, err := s.namespaceRepo.Merge(ctx, ac.OrgID, deletedID, id)
+	if err != nil {
+		return nil, err
+	}
+	if merge == nil {
+		return nil, ErrDuplicateNotFound
+	}
+
+	s.auditSvc.LogAction(ctx, ac, "merge", "namespace", id, ns.Name, fmt.Sprintf(
+		"Merged deleted namespace %s: moved %d documents, %d internal and %d external dependencies",
+		deletedID, merge.Documents, merge.InternalDependencies, merge.ExternalDependencies,
+	))
+	s.logger.Infow("Namespace merged", "namespace_id", id, "deleted_id", deletedID,
+		"documents", merge.Documents, "internal_dependencies", merge.InternalDependencies,
+		"external_dependencies", merge.ExternalDependencies, "links", merge.Links)
+	return merge, nil
+}
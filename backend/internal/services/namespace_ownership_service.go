@@ -0,0 +1,291 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/kubeatlas/kubeatlas/internal/escalation"
+	"github.com/kubeatlas/kubeatlas/internal/models"
+)
+
+// MaxOwnershipImport is the most namespaces one ownership import may update
+const MaxOwnershipImport = 1000
+
+var ErrInvalidOwnershipImport = errors.New("invalid ownership import")
+
+// Outcomes of importing the ownership of one namespace
+const (
+	OwnershipUpdated   = "updated"
+	OwnershipUnchanged = "unchanged"
+	OwnershipFailed    = "failed"
+)
+
+// NamespaceOwnership is the ownership of one namespace in an import, keyed by
+// cluster and namespace name. Fields left empty are not changed.
+type NamespaceOwnership struct {
+	Cluster   string `json:"cluster"`
+	Namespace string `json:"namespace"`
+
+	OwnerTeam    string `json:"owner_team"`    // team slug or name
+	BusinessUnit string `json:"business_unit"` // business unit code or name
+	Criticality  string `json:"criticality"`
+
+	ApplicationManagerName  string `json:"application_manager_name"`
+	ApplicationManagerEmail string `json:"application_manager_email"`
+	ApplicationManagerPhone string `json:"application_manager_phone"`
+	TechnicalLeadName       string `json:"technical_lead_name"`
+	TechnicalLeadEmail      string `json:"technical_lead_email"`
+	ProjectManagerName      string `json:"project_manager_name"`
+	ProjectManagerEmail     string `json:"project_manager_email"`
+
+	SLAAvailability string `json:"sla_availability"`
+	SLARTO          string `json:"sla_rto"`
+	SLARPO          string `json:"sla_rpo"`
+	SupportHours    string `json:"support_hours"`
+	EscalationPath  string `json:"escalation_path"`
+}
+
+// ImportOwnershipRequest sets the ownership of many namespaces. With DryRun
+// the changes are reported but not made.
+type ImportOwnershipRequest struct {
+	DryRun     bool                 `json:"dry_run"`
+	Namespaces []NamespaceOwnership `json:"namespaces"`
+}
+
+// OwnershipImportResult is the outcome for one namespace of an import
+type OwnershipImportResult struct {
+	Cluster     string        `json:"cluster"`
+	Namespace   string        `json:"namespace"`
+	NamespaceID *uuid.UUID    `json:"namespace_id,omitempty"`
+	Status      string        `json:"status"`
+	Changes     []FieldChange `json:"changes,omitempty"`
+	Error       string        `json:"error,omitempty"`
+}
+
+// OwnershipImport is the outcome of an import, in the order of the request
+type OwnershipImport struct {
+	DryRun    bool                    `json:"dry_run"`
+	Updated   int                     `json:"updated"`
+	Unchanged int                     `json:"unchanged"`
+	Failed    int                     `json:"failed"`
+	Results   []OwnershipImportResult `json:"results"`
+}
+
+// ImportOwnership upserts the owner team, business unit, contacts and SLA of
+// namespaces of the organization. Each namespace is updated on its own, so
+// one that fails does not stop the others.
+func (s *NamespaceService) ImportOwnership(ctx context.Context, ac AuditContext, orgID uuid.UUID, req ImportOwnershipRequest) (*OwnershipImport, error) {
+	if len(req.Namespaces) == 0 {
+		return nil, fmt.Errorf("%w: no namespaces given", ErrInvalidOwnershipImport)
+	}
+	if len(req.Namespaces) > MaxOwnershipImport {
+		return nil, fmt.Errorf("%w: at most %d namespaces can be imported at once", ErrInvalidOwnershipImport, MaxOwnershipImport)
+	}
+
+	teams, err := s.teamRepo.List(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	units, err := s.businessUnitRepo.List(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	im := &ownershipImporter{
+		s:        s,
+		orgID:    orgID,
+		clusters: make(map[string]*models.Cluster),
+		teams:    make(map[string]*models.Team),
+		units:    make(map[string]*models.BusinessUnit),
+	}
+	// Slugs and codes win over names that happen to match them
+	for i := range teams {
+		im.teams[strings.ToLower(teams[i].Name)] = &teams[i]
+	}
+	for i := range teams {
+		im.teams[strings.ToLower(teams[i].Slug)] = &teams[i]
+	}
+	for i := range units {
+		im.units[strings.ToLower(units[i].Name)] = &units[i]
+	}
+	for i := range units {
+		if units[i].Code.Valid && units[i].Code.String != "" {
+			im.units[strings.ToLower(units[i].Code.String)] = &units[i]
+		}
+	}
+
+	result := &OwnershipImport{DryRun: req.DryRun, Results: make([]OwnershipImportResult, 0, len(req.Namespaces))}
+	for _, o := range req.Namespaces {
+		r := im.apply(ctx, ac, o, req.DryRun)
+		switch r.Status {
+		case OwnershipUpdated:
+			result.Updated++
+		case OwnershipUnchanged:
+			result.Unchanged++
+		default:
+			result.Failed++
+		}
+		result.Results = append(result.Results, r)
+	}
+
+	s.logger.Infow("Namespace ownership imported", "organization_id", orgID, "dry_run", req.DryRun,
+		"updated", result.Updated, "unchanged", result.Unchanged, "failed", result.Failed)
+	return result, nil
+}
+
+// ownershipImporter resolves the names of an import within an organization
+type ownershipImporter struct {
+	s        *NamespaceService
+	orgID    uuid.UUID
+	clusters map[string]*models.Cluster
+	teams    map[string]*models.Team
+	units    map[string]*models.BusinessUnit
+}
+
+func (im *ownershipImporter) apply(ctx context.Context, ac AuditContext, o NamespaceOwnership, dryRun bool) OwnershipImportResult {
+	r := OwnershipImportResult{Cluster: o.Cluster, Namespace: o.Namespace, Status: OwnershipFailed}
+
+	ns, req, changes, err := im.plan(ctx, o)
+	if ns != nil {
+		r.NamespaceID = &ns.ID
+	}
+	if err != nil {
+		r.Error = err.Error()
+		return r
+	}
+	r.Changes = changes
+	if len(changes) == 0 {
+		r.Status = OwnershipUnchanged
+		return r
+	}
+	if !dryRun {
+		if _, err := im.s.Update(ctx, ac, ns.ID, req); err != nil {
+			im.s.logger.Warnw("Failed to import namespace ownership", "namespace_id", ns.ID, "error", err)
+			r.Error = err.Error()
+			return r
+		}
+	}
+	r.Status = OwnershipUpdated
+	return r
+}
+
+// plan finds the namespace of o and the update and changes that set its
+// ownership
+func (im *ownershipImporter) plan(ctx context.Context, o NamespaceOwnership) (*models.Namespace, UpdateNamespaceRequest, []FieldChange, error) {
+	var req UpdateNamespaceRequest
+	if o.Cluster == "" || o.Namespace == "" {
+		return nil, req, nil, errors.New("cluster and namespace are required")
+	}
+
+	cluster, ok := im.clusters[o.Cluster]
+	if !ok {
+		var err error
+		if cluster, err = im.s.clusterRepo.GetByName(ctx, im.orgID, o.Cluster); err != nil {
+			return nil, req, nil, err
+		}
+		im.clusters[o.Cluster] = cluster
+	}
+	if cluster == nil {
+		return nil, req, nil, fmt.Errorf("cluster %q not found", o.Cluster)
+	}
+	ns, err := im.s.namespaceRepo.GetByClusterAndName(ctx, cluster.ID, o.Namespace)
+	if err != nil {
+		return nil, req, nil, err
+	}
+	if ns == nil {
+		return nil, req, nil, fmt.Errorf("namespace %q not found in cluster %q", o.Namespace, o.Cluster)
+	}
+	if _, err := escalation.Parse(o.EscalationPath); err != nil {
+		return ns, req, nil, fmt.Errorf("%w: %v", ErrInvalidEscalationPath, err)
+	}
+
+	// Only the fields set by the import are compared
+	before := make(map[string]interface{})
+	after := make(map[string]interface{})
+	set := func(field, current, desired string) {
+		if desired == "" {
+			return
+		}
+		if current != "" {
+			before[field] = current
+		}
+		after[field] = desired
+	}
+
+	if o.OwnerTeam != "" {
+		team := im.teams[strings.ToLower(o.OwnerTeam)]
+		if team == nil {
+			return ns, req, nil, fmt.Errorf("team %q not found", o.OwnerTeam)
+		}
+		req.InfrastructureOwnerTeamID = &team.ID
+		current := ""
+		if ns.InfrastructureOwnerTeamID != nil {
+			current = im.teamName(*ns.InfrastructureOwnerTeamID)
+		}
+		set("owner_team", current, team.Name)
+	}
+	if o.BusinessUnit != "" {
+		unit := im.units[strings.ToLower(o.BusinessUnit)]
+		if unit == nil {
+			return ns, req, nil, fmt.Errorf("business unit %q not found", o.BusinessUnit)
+		}
+		req.BusinessUnitID = &unit.ID
+		current := ""
+		if ns.BusinessUnitID != nil {
+			current = im.unitName(*ns.BusinessUnitID)
+		}
+		set("business_unit", current, unit.Name)
+	}
+
+	req.Criticality = o.Criticality
+	req.ApplicationManagerName = o.ApplicationManagerName
+	req.ApplicationManagerEmail = o.ApplicationManagerEmail
+	req.ApplicationManagerPhone = o.ApplicationManagerPhone
+	req.TechnicalLeadName = o.TechnicalLeadName
+	req.TechnicalLeadEmail = o.TechnicalLeadEmail
+	req.ProjectManagerName = o.ProjectManagerName
+	req.ProjectManagerEmail = o.ProjectManagerEmail
+	req.SLAAvailability = o.SLAAvailability
+	req.SLARTO = o.SLARTO
+	req.SLARPO = o.SLARPO
+	req.SupportHours = o.SupportHours
+	req.EscalationPath = o.EscalationPath
+
+	set("criticality", ns.Criticality, o.Criticality)
+	set("application_manager_name", ns.ApplicationManagerName.ValueOrEmpty(), o.ApplicationManagerName)
+	set("application_manager_email", ns.ApplicationManagerEmail.ValueOrEmpty(), o.ApplicationManagerEmail)
+	set("application_manager_phone", ns.ApplicationManagerPhone.ValueOrEmpty(), o.ApplicationManagerPhone)
+	set("technical_lead_name", ns.TechnicalLeadName.ValueOrEmpty(), o.TechnicalLeadName)
+	set("technical_lead_email", ns.TechnicalLeadEmail.ValueOrEmpty(), o.TechnicalLeadEmail)
+	set("project_manager_name", ns.ProjectManagerName.ValueOrEmpty(), o.ProjectManagerName)
+	set("project_manager_email", ns.ProjectManagerEmail.ValueOrEmpty(), o.ProjectManagerEmail)
+	set("sla_availability", ns.SLAAvailability.ValueOrEmpty(), o.SLAAvailability)
+	set("sla_rto", ns.SLARTO.ValueOrEmpty(), o.SLARTO)
+	set("sla_rpo", ns.SLARPO.ValueOrEmpty(), o.SLARPO)
+	set("support_hours", ns.SupportHours.ValueOrEmpty(), o.SupportHours)
+	set("escalation_path", ns.EscalationPath.ValueOrEmpty(), o.EscalationPath)
+
+	return ns, req, diffAuditValues(before, after), nil
+}
+
+// teamName names a team for a change, falling back to its ID for teams of
+// other organizations or deleted ones
+func (im *ownershipImporter) teamName(id uuid.UUID) string {
+	for _, team := range im.teams {
+		if team.ID == id {
+			return team.Name
+		}
+	}
+	return id.String()
+}
+
+func (im *ownershipImporter) unitName(id uuid.UUID) string {
+	for _, unit := range im.units {
+		if unit.ID == id {
+			return unit.Name
+		}
+	}
+	return id.String()
+}
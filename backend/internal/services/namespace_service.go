@@ -3,16 +3,23 @@ package services
 import (
 	"context"
 	"errors"
+	"fmt"
 	"strings"
 
 	"github.com/google/uuid"
 	"github.com/kubeatlas/kubeatlas/internal/database/repositories"
+	"github.com/kubeatlas/kubeatlas/internal/escalation"
 	"github.com/kubeatlas/kubeatlas/internal/models"
+	"github.com/kubeatlas/kubeatlas/internal/searchindex"
+	"github.com/kubeatlas/kubeatlas/internal/telemetry"
+	"go.opentelemetry.io/otel/attribute"
 	"go.uber.org/zap"
 )
 
 var (
-	ErrNamespaceNotFound = errors.New("namespace not found")
+	ErrNamespaceNotFound     = errors.New("namespace not found")
+	ErrInvalidEscalationPath = errors.New("invalid escalation path")
+	ErrInvalidNamespace      = errors.New("invalid namespace")
 )
 
 type NamespaceService struct {
@@ -20,7 +27,17 @@ type NamespaceService struct {
 	clusterRepo      *repositories.ClusterRepository
 	teamRepo         *repositories.TeamRepository
 	businessUnitRepo *repositories.BusinessUnitRepository
+	settings         *OrgSettingsService
 	auditSvc         *AuditService
+	notifications    *NotificationService
+	webhooks         *WebhookService
+	tickets          *JiraService
+	oncall           []OnCallSource
+	rotations        *TeamOnCallService
+	annotations      *GrafanaService
+	git              *GitService
+	monitoring       *MonitoringService
+	searchIndex      *SearchIndexService
 	logger           *zap.SugaredLogger
 }
 
@@ -29,7 +46,10 @@ func NewNamespaceService(
 	clusterRepo *repositories.ClusterRepository,
 	teamRepo *repositories.TeamRepository,
 	businessUnitRepo *repositories.BusinessUnitRepository,
+	settings *OrgSettingsService,
 	auditSvc *AuditService,
+	notifications *NotificationService,
+	webhooks *WebhookService,
 	logger *zap.SugaredLogger,
 ) *NamespaceService {
 	return &NamespaceService{
@@ -37,11 +57,54 @@ func NewNamespaceService(
 		clusterRepo:      clusterRepo,
 		teamRepo:         teamRepo,
 		businessUnitRepo: businessUnitRepo,
-		auditSvc:      auditSvc,
-		logger:        logger,
+		settings:         settings,
+		auditSvc:         auditSvc,
+		notifications:    notifications,
+		webhooks:         webhooks,
+		logger:           logger,
 	}
 }
 
+// SetTickets shows each namespace's remediation ticket, with its current
+// Jira status, on the namespace
+func (s *NamespaceService) SetTickets(tickets *JiraService) {
+	s.tickets = tickets
+}
+
+// SetOnCall shows who is on call for the namespace's owner team, from the
+// first source the team is linked to
+func (s *NamespaceService) SetOnCall(sources ...OnCallSource) {
+	s.oncall = sources
+}
+
+// SetOnCallRotations shows the on-call rotation of the namespace's owner
+// team, with its escalation contacts
+func (s *NamespaceService) SetOnCallRotations(rotations *TeamOnCallService) {
+	s.rotations = rotations
+}
+
+// SetAnnotations annotates ownership changes in Grafana
+func (s *NamespaceService) SetAnnotations(annotations *GrafanaService) {
+	s.annotations = annotations
+}
+
+// SetGit reads the default branch and last commit of newly linked source
+// repositories
+func (s *NamespaceService) SetGit(git *GitService) {
+	s.git = git
+}
+
+// SetMonitoring checks that newly linked dashboards exist
+func (s *NamespaceService) SetMonitoring(monitoring *MonitoringService) {
+	s.monitoring = monitoring
+}
+
+// SetSearchIndex answers searches from the external search index when one
+// is configured
+func (s *NamespaceService) SetSearchIndex(searchIndex *SearchIndexService) {
+	s.searchIndex = searchIndex
+}
+
 // GetByID retrieves a namespace by ID
 func (s *NamespaceService) GetByID(ctx context.Context, id uuid.UUID) (*models.Namespace, error) {
 	ns, err := s.namespaceRepo.GetByID(ctx, id)
@@ -51,7 +114,7 @@ func (s *NamespaceService) GetByID(ctx context.Context, id uuid.UUID) (*models.N
 	if ns == nil {
 		return nil, ErrNamespaceNotFound
 	}
-	
+
 	// Populate related data
 	if ns.ClusterID != uuid.Nil {
 		cluster, err := s.clusterRepo.GetByID(ctx, ns.ClusterID)
@@ -59,81 +122,153 @@ func (s *NamespaceService) GetByID(ctx context.Context, id uuid.UUID) (*models.N
 			ns.Cluster = cluster
 		}
 	}
-	
+
 	if ns.InfrastructureOwnerTeamID != nil {
 		team, err := s.teamRepo.GetByID(ctx, *ns.InfrastructureOwnerTeamID)
 		if err == nil && team != nil {
 			ns.InfrastructureOwnerTeam = team
 		}
 	}
-	
+
 	if ns.BusinessUnitID != nil {
 		bu, err := s.businessUnitRepo.GetByID(ctx, *ns.BusinessUnitID)
 		if err == nil && bu != nil {
 			ns.BusinessUnit = bu
 		}
 	}
-	
+
+	podSecurity := models.PodSecurityFromLabels(ns.K8sLabels)
+	ns.PodSecurity = &podSecurity
+
+	if s.tickets != nil {
+		ticket, err := s.tickets.Ticket(ctx, ns)
+		if err != nil {
+			s.logger.Warnw("Failed to load namespace ticket", "namespace_id", ns.ID, "error", err)
+		}
+		ns.Ticket = ticket
+	}
+
+	if ns.InfrastructureOwnerTeam != nil {
+		ns.OnCall = teamOnCall(ctx, s.oncall, ns.InfrastructureOwnerTeam)
+		if s.rotations != nil {
+			ns.OnCallRotation = s.rotations.Rotation(ctx, ns.InfrastructureOwnerTeam.ID)
+		}
+	}
+
+	repos, err := s.namespaceRepo.ListRepositories(ctx, ns.ID)
+	if err != nil {
+		s.logger.Warnw("Failed to load namespace repositories", "namespace_id", ns.ID, "error", err)
+	}
+	ns.Repositories = repos
+
+	links, err := s.namespaceRepo.ListMonitoringLinks(ctx, ns.ID)
+	if err != nil {
+		s.logger.Warnw("Failed to load namespace monitoring links", "namespace_id", ns.ID, "error", err)
+	}
+	ns.MonitoringLinks = links
+
+	check, err := s.MetadataCheck(ctx, ns)
+	if err != nil {
+		s.logger.Warnw("Failed to check namespace metadata", "namespace_id", ns.ID, "error", err)
+	}
+	ns.MetadataCheck = check
+
 	return ns, nil
 }
 
 // List retrieves namespaces with pagination
 func (s *NamespaceService) List(ctx context.Context, orgID uuid.UUID, p repositories.Pagination, filters map[string]interface{}) (*repositories.PaginatedResult[models.Namespace], error) {
-	result, err := s.namespaceRepo.List(ctx, orgID, p, filters)
+	ctx, span := telemetry.StartSpan(ctx, "NamespaceService.List", attribute.String("organization_id", orgID.String()))
+	defer span.End()
+
+	if filters == nil {
+		filters = make(map[string]interface{})
+	}
+	// Populate cluster, team, and business unit information in the same query
+	if _, ok := filters["include_relations"]; !ok {
+		filters["include_relations"] = true
+	}
+	if _, ok := filters["search"]; ok {
+		threshold, err := s.settings.FuzzyThreshold(ctx, orgID)
+		if err != nil {
+			return nil, err
+		}
+		filters["fuzzy_threshold"] = threshold
+	}
+
+	return s.namespaceRepo.List(ctx, orgID, p, filters)
+}
+
+// Search finds namespaces matching a free-text term, best matches first, with
+// the fields containing it highlighted. Names within the organization's fuzzy
+// threshold of the term match too. The external search index answers when
+// configured, Postgres when not or while the index is unavailable.
+func (s *NamespaceService) Search(ctx context.Context, orgID uuid.UUID, term string, limit int) ([]models.NamespaceSearchResult, error) {
+	ctx, span := telemetry.StartSpan(ctx, "NamespaceService.Search", attribute.String("organization_id", orgID.String()))
+	defer span.End()
+
+	threshold, err := s.settings.FuzzyThreshold(ctx, orgID)
 	if err != nil {
 		return nil, err
 	}
-
-	// Populate cluster, team, and business unit information for each namespace
-	clusterCache := make(map[uuid.UUID]*models.Cluster)
-	teamCache := make(map[uuid.UUID]*models.Team)
-	buCache := make(map[uuid.UUID]*models.BusinessUnit)
-	
-	for i := range result.Items {
-		// Populate cluster
-		clusterID := result.Items[i].ClusterID
-		if clusterID != uuid.Nil {
-			if cluster, ok := clusterCache[clusterID]; ok {
-				result.Items[i].Cluster = cluster
-			} else {
-				cluster, err := s.clusterRepo.GetByID(ctx, clusterID)
-				if err == nil && cluster != nil {
-					clusterCache[clusterID] = cluster
-					result.Items[i].Cluster = cluster
-				}
-			}
-		}
-		
-		// Populate team
-		if result.Items[i].InfrastructureOwnerTeamID != nil {
-			teamID := *result.Items[i].InfrastructureOwnerTeamID
-			if team, ok := teamCache[teamID]; ok {
-				result.Items[i].InfrastructureOwnerTeam = team
-			} else {
-				team, err := s.teamRepo.GetByID(ctx, teamID)
-				if err == nil && team != nil {
-					teamCache[teamID] = team
-					result.Items[i].InfrastructureOwnerTeam = team
-				}
-			}
-		}
-		
-		// Populate business unit
-		if result.Items[i].BusinessUnitID != nil {
-			buID := *result.Items[i].BusinessUnitID
-			if bu, ok := buCache[buID]; ok {
-				result.Items[i].BusinessUnit = bu
-			} else {
-				bu, err := s.businessUnitRepo.GetByID(ctx, buID)
-				if err == nil && bu != nil {
-					buCache[buID] = bu
-					result.Items[i].BusinessUnit = bu
-				}
-			}
+	term = strings.TrimSpace(term)
+	if s.searchIndex != nil && s.searchIndex.Enabled() {
+		results, err := s.searchIndexed(ctx, orgID, term, threshold > 0, limit)
+		if err == nil {
+			return results, nil
 		}
+		s.logger.Warnw("Search index unavailable, searching Postgres", "organization_id", orgID, "error", err)
+	}
+
+	results, err := s.namespaceRepo.Search(ctx, orgID, term, threshold, limit)
+	if err != nil {
+		return nil, err
+	}
+	for i := range results {
+		results[i].Highlights = namespaceHighlights(&results[i].Namespace, term)
+	}
+	return results, nil
+}
+
+// searchIndexed searches namespaces in the external search index and loads
+// the hits, skipping any deleted since they were indexed
+func (s *NamespaceService) searchIndexed(ctx context.Context, orgID uuid.UUID, term string, fuzzy bool, limit int) ([]models.NamespaceSearchResult, error) {
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	hits, err := s.searchIndex.Search(ctx, searchindex.Query{
+		OrganizationID: orgID,
+		Term:           term,
+		Types:          []string{models.SearchTypeNamespace},
+		Fuzzy:          fuzzy,
+		Limit:          limit,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]uuid.UUID, len(hits))
+	for i, hit := range hits {
+		ids[i] = hit.ID
+	}
+	namespaces, err := s.namespaceRepo.ListByIDs(ctx, orgID, ids)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[uuid.UUID]*models.Namespace, len(namespaces))
+	for i := range namespaces {
+		byID[namespaces[i].ID] = &namespaces[i]
 	}
 
-	return result, nil
+	results := make([]models.NamespaceSearchResult, 0, len(hits))
+	for _, hit := range hits {
+		ns, ok := byID[hit.ID]
+		if !ok {
+			continue
+		}
+		results = append(results, models.NamespaceSearchResult{Namespace: *ns, Score: hit.Score, Highlights: hit.Highlights})
+	}
+	return results, nil
 }
 
 // UpdateNamespaceRequest represents namespace update data
@@ -170,10 +305,22 @@ type UpdateNamespaceRequest struct {
 
 	// Tags
 	Tags []string `json:"tags"`
+
+	// RepoURLs are the Git repositories holding the namespace's source. Nil
+	// leaves the linked repositories unchanged; an empty list unlinks them.
+	RepoURLs []string `json:"repo_urls"`
+
+	// MonitoringLinks are the namespace's dashboards. Nil leaves them
+	// unchanged; an empty list removes them.
+	MonitoringLinks []MonitoringLinkRequest `json:"monitoring_links"`
 }
 
 // Update updates a namespace
 func (s *NamespaceService) Update(ctx context.Context, ac AuditContext, id uuid.UUID, req UpdateNamespaceRequest) (*models.Namespace, error) {
+	if _, err := escalation.Parse(req.EscalationPath); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidEscalationPath, err)
+	}
+
 	ns, err := s.namespaceRepo.GetByID(ctx, id)
 	if err != nil {
 		return nil, err
@@ -182,6 +329,28 @@ func (s *NamespaceService) Update(ctx context.Context, ac AuditContext, id uuid.
 		return nil, ErrNamespaceNotFound
 	}
 
+	// Environments and criticality tiers that are no longer configured are
+	// kept until changed
+	if req.Environment != "" && req.Environment != ns.Environment {
+		if err := s.settings.ValidateEnvironment(ctx, ns.OrganizationID, req.Environment); err != nil {
+			return nil, err
+		}
+	}
+	criticalityChanged := req.Criticality != "" && req.Criticality != ns.Criticality
+
+	var repos []models.SourceRepository
+	if req.RepoURLs != nil {
+		if repos, err = parseRepositoryURLs(req.RepoURLs); err != nil {
+			return nil, err
+		}
+	}
+	var links []models.MonitoringLink
+	if req.MonitoringLinks != nil {
+		if links, err = parseMonitoringLinks(req.MonitoringLinks); err != nil {
+			return nil, err
+		}
+	}
+
 	// Ensure Tags, CustomFields, Metadata are not nil (pgx requires non-nil for array/json types)
 	if ns.Tags == nil {
 		ns.Tags = models.StringArray{}
@@ -242,7 +411,89 @@ func (s *NamespaceService) Update(ctx context.Context, ac AuditContext, id uuid.
 	ns.CustomFields = sanitizeJSONMap(ns.CustomFields)
 	ns.Metadata = sanitizeJSONMap(ns.Metadata)
 
-	// Apply updates
+	oldValues := StructToMap(ns)
+
+	applyNamespaceUpdate(ns, req)
+
+	// Ensure tags is never nil (StringArray requires non-nil for proper encoding)
+	if ns.Tags == nil {
+		ns.Tags = []string{}
+	}
+
+	// Ensure CustomFields and Metadata are never nil
+	if ns.CustomFields == nil {
+		ns.CustomFields = make(models.JSONMap)
+	}
+	if ns.Metadata == nil {
+		ns.Metadata = make(models.JSONMap)
+	}
+
+	if criticalityChanged {
+		tiers, err := s.settings.CriticalityTiers(ctx, ns.OrganizationID)
+		if err != nil {
+			return nil, err
+		}
+		if err := ns.Validate(tiers); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidNamespace, err)
+		}
+	}
+
+	if err := s.namespaceRepo.Update(ctx, ns); err != nil {
+		return nil, err
+	}
+	newValues := StructToMap(ns)
+
+	if req.RepoURLs != nil {
+		previous, err := s.namespaceRepo.ListRepositories(ctx, ns.ID)
+		if err != nil {
+			return nil, err
+		}
+		linked, err := s.namespaceRepo.SetRepositories(ctx, ns.OrganizationID, ns.ID, repos)
+		if err != nil {
+			return nil, err
+		}
+		oldValues["repo_urls"] = repositoryURLs(previous)
+		newValues["repo_urls"] = repositoryURLs(linked)
+		ns.Repositories = linked
+		s.git.CheckNew(ctx, ns.OrganizationID, linked)
+	}
+
+	if req.MonitoringLinks != nil {
+		previous, err := s.namespaceRepo.ListMonitoringLinks(ctx, ns.ID)
+		if err != nil {
+			return nil, err
+		}
+		set, err := s.namespaceRepo.SetMonitoringLinks(ctx, ns.OrganizationID, ns.ID, links)
+		if err != nil {
+			return nil, err
+		}
+		oldValues["monitoring_links"] = monitoringLinkURLs(previous)
+		newValues["monitoring_links"] = monitoringLinkURLs(set)
+		ns.MonitoringLinks = set
+		s.monitoring.CheckNew(ctx, ns.OrganizationID, set)
+	}
+
+	// Audit log - don't fail the update if audit fails
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				s.logger.Warnw("Audit log panic recovered", "error", r)
+			}
+		}()
+		s.auditSvc.LogUpdate(context.Background(), ac, "namespace", ns.ID, ns.Name, oldValues, newValues)
+	}()
+
+	s.logger.Infow("Namespace updated", "namespace_id", ns.ID, "name", ns.Name)
+	s.notifications.NotifyNamespaceOwnershipChanged(ctx, ns, oldValues, newValues, ac.UserEmail)
+	s.annotations.NamespaceOwnershipChanged(ctx, ns, oldValues, newValues)
+	s.webhooks.Publish(ctx, ns.OrganizationID, models.WebhookEventNamespaceUpdated, ns)
+
+	return ns, nil
+}
+
+// applyNamespaceUpdate sets the fields of ns given by req. Empty fields are
+// left unchanged.
+func applyNamespaceUpdate(ns *models.Namespace, req UpdateNamespaceRequest) {
 	if req.DisplayName != "" {
 		ns.DisplayName = models.NewNullStringFromString(req.DisplayName)
 	}
@@ -315,37 +566,23 @@ func (s *NamespaceService) Update(ctx context.Context, ac AuditContext, id uuid.
 	if req.Tags != nil {
 		ns.Tags = req.Tags
 	}
+}
 
-	// Ensure tags is never nil (StringArray requires non-nil for proper encoding)
-	if ns.Tags == nil {
-		ns.Tags = []string{}
+// EscalationLevels returns the namespace's escalation path parsed into levels
+func (s *NamespaceService) EscalationLevels(ctx context.Context, id uuid.UUID) ([]escalation.Level, error) {
+	ns, err := s.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
 	}
 
-	// Ensure CustomFields and Metadata are never nil
-	if ns.CustomFields == nil {
-		ns.CustomFields = make(models.JSONMap)
-	}
-	if ns.Metadata == nil {
-		ns.Metadata = make(models.JSONMap)
+	levels, err := escalation.Parse(ns.EscalationPath.ValueOrEmpty())
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidEscalationPath, err)
 	}
-
-	if err := s.namespaceRepo.Update(ctx, ns); err != nil {
-		return nil, err
+	if levels == nil {
+		levels = []escalation.Level{}
 	}
-
-	// Audit log - don't fail the update if audit fails
-	go func() {
-		defer func() {
-			if r := recover(); r != nil {
-				s.logger.Warnw("Audit log panic recovered", "error", r)
-			}
-		}()
-		s.auditSvc.LogUpdate(context.Background(), ac, "namespace", ns.ID, ns.Name, nil, nil)
-	}()
-	
-	s.logger.Infow("Namespace updated", "namespace_id", ns.ID, "name", ns.Name)
-
-	return ns, nil
+	return levels, nil
 }
 
 // AddTag adds a tag to namespace
@@ -375,6 +612,7 @@ func (s *NamespaceService) AddTag(ctx context.Context, ac AuditContext, id uuid.
 	s.auditSvc.LogUpdate(ctx, ac, "namespace", ns.ID, ns.Name,
 		map[string]interface{}{"tags": oldTags},
 		map[string]interface{}{"tags": ns.Tags})
+	s.webhooks.Publish(ctx, ns.OrganizationID, models.WebhookEventNamespaceUpdated, ns)
 
 	return nil
 }
@@ -405,23 +643,29 @@ func (s *NamespaceService) RemoveTag(ctx context.Context, ac AuditContext, id uu
 	s.auditSvc.LogUpdate(ctx, ac, "namespace", ns.ID, ns.Name,
 		map[string]interface{}{"tags": oldTags},
 		map[string]interface{}{"tags": ns.Tags})
+	s.webhooks.Publish(ctx, ns.OrganizationID, models.WebhookEventNamespaceUpdated, ns)
 
 	return nil
 }
 
-// GetStats returns namespace statistics
-func (s *NamespaceService) GetStats(ctx context.Context, orgID uuid.UUID) (*models.DashboardStats, error) {
-	return s.namespaceRepo.GetStats(ctx, orgID)
+// GetStats returns namespace statistics, counting archived namespaces only
+// with includeArchived
+func (s *NamespaceService) GetStats(ctx context.Context, orgID uuid.UUID, includeArchived bool) (*models.DashboardStats, error) {
+	return s.namespaceRepo.GetStats(ctx, orgID, includeArchived)
 }
 
 // GetEnvironmentDistribution returns namespace distribution by environment
-func (s *NamespaceService) GetEnvironmentDistribution(ctx context.Context, orgID uuid.UUID) ([]models.EnvironmentDistribution, error) {
-	return s.namespaceRepo.GetEnvironmentDistribution(ctx, orgID)
+func (s *NamespaceService) GetEnvironmentDistribution(ctx context.Context, orgID uuid.UUID, includeArchived bool) ([]models.EnvironmentDistribution, error) {
+	counts, err := s.namespaceRepo.GetEnvironmentDistribution(ctx, orgID, includeArchived)
+	if err != nil {
+		return nil, err
+	}
+	return s.settings.EnvironmentDistribution(ctx, orgID, counts)
 }
 
 // GetBusinessUnitDistribution returns namespace distribution by business unit
-func (s *NamespaceService) GetBusinessUnitDistribution(ctx context.Context, orgID uuid.UUID) ([]models.BusinessUnitDistribution, error) {
-	return s.namespaceRepo.GetBusinessUnitDistribution(ctx, orgID)
+func (s *NamespaceService) GetBusinessUnitDistribution(ctx context.Context, orgID uuid.UUID, includeArchived bool) ([]models.BusinessUnitDistribution, error) {
+	return s.namespaceRepo.GetBusinessUnitDistribution(ctx, orgID, includeArchived)
 }
 
 // GetRecentlyUpdated returns recently updated namespaces
@@ -431,16 +675,52 @@ func (s *NamespaceService) GetRecentlyUpdated(ctx context.Context, orgID uuid.UU
 
 // GetOrphaned returns namespaces without owner
 func (s *NamespaceService) GetOrphaned(ctx context.Context, orgID uuid.UUID, p repositories.Pagination) (*repositories.PaginatedResult[models.Namespace], error) {
-	filters := map[string]interface{}{"orphaned": true}
+	filters := map[string]interface{}{"orphaned": true, "archived": false}
 	return s.namespaceRepo.List(ctx, orgID, p, filters)
 }
 
 // GetUndocumented returns namespaces without documentation
 func (s *NamespaceService) GetUndocumented(ctx context.Context, orgID uuid.UUID, p repositories.Pagination) (*repositories.PaginatedResult[models.Namespace], error) {
-	filters := map[string]interface{}{"undocumented": true}
+	filters := map[string]interface{}{"undocumented": true, "archived": false}
 	return s.namespaceRepo.List(ctx, orgID, p, filters)
 }
 
+// Archive archives a namespace, keeping it for history but leaving it out of
+// coverage statistics. Syncs archive namespaces deleted in their cluster.
+func (s *NamespaceService) Archive(ctx context.Context, ac AuditContext, id uuid.UUID) (*models.Namespace, error) {
+	return s.setStatus(ctx, ac, id, models.NamespaceStatusArchived, "archive", "Namespace archived")
+}
+
+// Unarchive makes an archived namespace active again. Syncs unarchive
+// namespaces created again in their cluster, and archive again those still
+// missing from it.
+func (s *NamespaceService) Unarchive(ctx context.Context, ac AuditContext, id uuid.UUID) (*models.Namespace, error) {
+	return s.setStatus(ctx, ac, id, models.NamespaceStatusActive, "unarchive", "Namespace unarchived")
+}
+
+func (s *NamespaceService) setStatus(ctx context.Context, ac AuditContext, id uuid.UUID, status, action, description string) (*models.Namespace, error) {
+	ns, err := s.namespaceRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if ns == nil || ns.OrganizationID != ac.OrgID {
+		return nil, ErrNamespaceNotFound
+	}
+
+	changed, err := s.namespaceRepo.SetStatus(ctx, id, status)
+	if err != nil {
+		return nil, err
+	}
+	ns.Status = status
+	if !changed {
+		return ns, nil
+	}
+
+	s.auditSvc.LogAction(ctx, ac, action, "namespace", id, ns.Name, description)
+	s.webhooks.Publish(ctx, ns.OrganizationID, models.WebhookEventNamespaceUpdated, ns)
+	return ns, nil
+}
+
 // GetHistory returns namespace audit history
 func (s *NamespaceService) GetHistory(ctx context.Context, namespaceID uuid.UUID, limit int) ([]models.AuditLog, error) {
 	return s.auditSvc.ListByResource(ctx, "namespace", namespaceID, limit)
@@ -0,0 +1,107 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kubeatlas/kubeatlas/internal/k8s"
+	"github.com/kubeatlas/kubeatlas/internal/models"
+)
+
+// namespaceUsageKeep is how long usage samples are kept. The usage of a
+// namespace is averaged over them.
+const namespaceUsageKeep = 7 * 24 * time.Hour
+
+// ErrInvalidUsageGrouping is returned for capacity reports grouped by
+// something other than team, environment, criticality or cluster
+var ErrInvalidUsageGrouping = errors.New("invalid usage grouping")
+
+// NamespaceUsage is the resource usage of a namespace with the samples it
+// is computed from
+type NamespaceUsage struct {
+	NamespaceID            uuid.UUID                     `json:"namespace_id"`
+	Namespace              string                        `json:"namespace"`
+	CPUUsageMillicores     *int64                        `json:"cpu_usage_millicores"`
+	CPUUsagePeakMillicores *int64                        `json:"cpu_usage_peak_millicores"`
+	MemoryUsageBytes       *int64                        `json:"memory_usage_bytes"`
+	MemoryUsagePeakBytes   *int64                        `json:"memory_usage_peak_bytes"`
+	ObservedAt             *time.Time                    `json:"observed_at"`
+	Samples                []models.NamespaceUsageSample `json:"samples"`
+}
+
+// CapacityReport sums the resource usage of namespaces by a grouping
+type CapacityReport struct {
+	GroupBy string                       `json:"group_by"`
+	Groups  []models.NamespaceUsageGroup `json:"groups"`
+}
+
+// GetUsage returns the CPU and memory usage of a namespace over the last
+// seven days, as sampled by cluster syncs
+func (s *NamespaceService) GetUsage(ctx context.Context, id uuid.UUID) (*NamespaceUsage, error) {
+	ns, err := s.namespaceRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if ns == nil {
+		return nil, ErrNamespaceNotFound
+	}
+
+	samples, err := s.namespaceRepo.ListUsageSamples(ctx, id, time.Now().Add(-namespaceUsageKeep))
+	if err != nil {
+		return nil, err
+	}
+
+	usage := &NamespaceUsage{
+		NamespaceID:            ns.ID,
+		Namespace:              ns.Name,
+		CPUUsageMillicores:     ns.CPUUsageMillicores,
+		CPUUsagePeakMillicores: ns.CPUUsagePeakMillicores,
+		MemoryUsageBytes:       ns.MemoryUsageBytes,
+		MemoryUsagePeakBytes:   ns.MemoryUsagePeakBytes,
+		Samples:                samples,
+	}
+	if ns.UsageObservedAt.Valid {
+		usage.ObservedAt = &ns.UsageObservedAt.Time
+	}
+	return usage, nil
+}
+
+// GetCapacityReport sums the average and peak resource usage of the
+// organization's namespaces by team, environment, criticality or cluster
+func (s *NamespaceService) GetCapacityReport(ctx context.Context, orgID uuid.UUID, groupBy string) (*CapacityReport, error) {
+	if groupBy == "" {
+		groupBy = "team"
+	}
+	switch groupBy {
+	case "team", "environment", "criticality", "cluster":
+	default:
+		return nil, fmt.Errorf("%w: group_by must be team, environment, criticality or cluster", ErrInvalidUsageGrouping)
+	}
+
+	groups, err := s.namespaceRepo.GetUsageByGroup(ctx, orgID, groupBy)
+	if err != nil {
+		return nil, err
+	}
+	return &CapacityReport{GroupBy: groupBy, Groups: groups}, nil
+}
+
+// usageSamples returns a usage sample of each namespace of a cluster. ids
+// are the IDs of the cluster's namespaces by name; those metrics-server
+// reports no pods for used nothing.
+func usageSamples(ids map[string]uuid.UUID, usage map[string]k8s.NamespaceUsage, at time.Time) []models.NamespaceUsageSample {
+	samples := make([]models.NamespaceUsageSample, 0, len(ids))
+	for name, id := range ids {
+		u := usage[name]
+		samples = append(samples, models.NamespaceUsageSample{
+			NamespaceID:   id,
+			CPUMillicores: u.CPUMillicores,
+			MemoryBytes:   u.MemoryBytes,
+			Pods:          u.Pods,
+			ObservedAt:    at,
+		})
+	}
+	return samples
+}
@@ -0,0 +1,31 @@
+package services
+
+import (
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kubeatlas/kubeatlas/internal/k8s"
+	"github.com/kubeatlas/kubeatlas/internal/models"
+)
+
+func TestUsageSamples(t *testing.T) {
+	payments, idle := uuid.New(), uuid.New()
+	ids := map[string]uuid.UUID{"payments": payments, "idle": idle}
+	usage := map[string]k8s.NamespaceUsage{
+		"payments":    {CPUMillicores: 250, MemoryBytes: 512 << 20, Pods: 3},
+		"kube-system": {CPUMillicores: 100, MemoryBytes: 64 << 20, Pods: 5},
+	}
+	at := time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)
+
+	got := usageSamples(ids, usage, at)
+	sort.Slice(got, func(i, j int) bool { return got[i].Pods > got[j].Pods })
+	want := []models.NamespaceUsageSample{
+		{NamespaceID: payments, CPUMillicores: 250, MemoryBytes: 512 << 20, Pods: 3, ObservedAt: at},
+		{NamespaceID: idle, ObservedAt: at},
+	}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("usageSamples() = %+v, want %+v", got, want)
+	}
+}
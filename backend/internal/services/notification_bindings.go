@@ -0,0 +1,190 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/mail"
+	"regexp"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/kubeatlas/kubeatlas/internal/models"
+)
+
+var ErrInvalidTeamBindings = errors.New("invalid team notification bindings")
+
+// slackChannelIDRegex matches the IDs of public and private Slack channels
+var slackChannelIDRegex = regexp.MustCompile(`^[CG][A-Z0-9]{8,}$`)
+
+// teamRecipientPrefix marks a Microsoft Teams delivery for a team's own
+// channel. The delivery names the team, and its webhook is resolved when it
+// is sent so the log never holds webhook URLs.
+const teamRecipientPrefix = "team:"
+
+// UpdateTeamBindingsRequest sets where notifications about a team's
+// resources go. A nil TeamsWebhookURL keeps the stored webhook, and an empty
+// one removes it.
+type UpdateTeamBindingsRequest struct {
+	SlackChannelID  string  `json:"slack_channel_id"`
+	EmailAlias      string  `json:"email_alias"`
+	TeamsWebhookURL *string `json:"teams_webhook_url"`
+}
+
+// GetTeamBindings returns the notification bindings of a team of the
+// organization
+func (s *NotificationService) GetTeamBindings(ctx context.Context, orgID, teamID uuid.UUID) (*models.TeamNotificationBindings, error) {
+	if _, err := s.orgTeam(ctx, orgID, teamID); err != nil {
+		return nil, err
+	}
+	b, err := s.repo.GetTeamBindings(ctx, teamID)
+	if err != nil {
+		return nil, err
+	}
+	if b == nil {
+		b = &models.TeamNotificationBindings{TeamID: teamID}
+	}
+	return b, nil
+}
+
+// UpdateTeamBindings replaces the notification bindings of a team
+func (s *NotificationService) UpdateTeamBindings(ctx context.Context, ac AuditContext, teamID uuid.UUID, req UpdateTeamBindingsRequest) (*models.TeamNotificationBindings, error) {
+	team, err := s.orgTeam(ctx, ac.OrgID, teamID)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateTeamBindings(&req); err != nil {
+		return nil, err
+	}
+	before, err := s.GetTeamBindings(ctx, ac.OrgID, teamID)
+	if err != nil {
+		return nil, err
+	}
+
+	after := &models.TeamNotificationBindings{
+		TeamID:          teamID,
+		SlackChannelID:  req.SlackChannelID,
+		EmailAlias:      req.EmailAlias,
+		TeamsWebhookURL: before.TeamsWebhookURL,
+	}
+	if req.TeamsWebhookURL != nil {
+		if after.TeamsWebhookURL, err = sealCredential(s.encryptor, *req.TeamsWebhookURL); err != nil {
+			return nil, err
+		}
+	}
+	after.TeamsChannel = after.TeamsWebhookURL != ""
+	if err := s.repo.SaveTeamBindings(ctx, ac.OrgID, after); err != nil {
+		return nil, err
+	}
+
+	s.auditSvc.LogUpdate(ctx, ac, "team", team.ID, team.Name,
+		map[string]interface{}{"slack_channel_id": before.SlackChannelID, "email_alias": before.EmailAlias, "teams_channel": before.TeamsChannel},
+		map[string]interface{}{"slack_channel_id": after.SlackChannelID, "email_alias": after.EmailAlias, "teams_channel": after.TeamsChannel})
+	return after, nil
+}
+
+// orgTeam returns a team of the organization
+func (s *NotificationService) orgTeam(ctx context.Context, orgID, teamID uuid.UUID) (*models.Team, error) {
+	team, err := s.teamRepo.GetByID(ctx, teamID)
+	if err != nil {
+		return nil, err
+	}
+	if team == nil || team.OrganizationID != orgID {
+		return nil, ErrTeamNotFound
+	}
+	return team, nil
+}
+
+// teamBindings returns the notification bindings of a team, or nil when it
+// has none or they cannot be loaded
+func (s *NotificationService) teamBindings(ctx context.Context, team *models.Team) *models.TeamNotificationBindings {
+	if team == nil {
+		return nil
+	}
+	b, err := s.repo.GetTeamBindings(ctx, team.ID)
+	if err != nil {
+		s.logger.Errorw("Failed to load team notification bindings", "team_id", team.ID, "error", err)
+		return nil
+	}
+	return b
+}
+
+// teamEmail returns the address reaching a team: its email alias, or its
+// contact email
+func (s *NotificationService) teamEmail(ctx context.Context, team *models.Team) string {
+	return teamEmailAddress(team, s.teamBindings(ctx, team))
+}
+
+// teamWebhook returns the Microsoft Teams webhook of the team a delivery was
+// addressed to, or "" when it was not or the team has none
+func (s *NotificationService) teamWebhook(ctx context.Context, recipients []string) (string, error) {
+	teamID, ok := teamRecipient(recipients)
+	if !ok {
+		return "", nil
+	}
+	b, err := s.repo.GetTeamBindings(ctx, teamID)
+	if err != nil {
+		return "", fmt.Errorf("failed to load team notification bindings: %w", err)
+	}
+	if b == nil || b.TeamsWebhookURL == "" {
+		return "", nil
+	}
+	return openCredential(s.encryptor, b.TeamsWebhookURL)
+}
+
+// teamEmailAddress returns a team's email alias, or its contact email
+func teamEmailAddress(team *models.Team, b *models.TeamNotificationBindings) string {
+	if b != nil && b.EmailAlias != "" {
+		return b.EmailAlias
+	}
+	if team != nil && team.ContactEmail.Valid {
+		return team.ContactEmail.String
+	}
+	return ""
+}
+
+// teamSlackChannel returns a team's Slack channel ID, or its Slack contact
+func teamSlackChannel(team *models.Team, b *models.TeamNotificationBindings) string {
+	if b != nil && b.SlackChannelID != "" {
+		return b.SlackChannelID
+	}
+	if team != nil && team.ContactSlack.Valid {
+		return team.ContactSlack.String
+	}
+	return ""
+}
+
+// teamRecipient returns the team a Microsoft Teams delivery was addressed to
+func teamRecipient(recipients []string) (uuid.UUID, bool) {
+	if len(recipients) == 0 {
+		return uuid.Nil, false
+	}
+	raw, ok := strings.CutPrefix(recipients[0], teamRecipientPrefix)
+	if !ok {
+		return uuid.Nil, false
+	}
+	id, err := uuid.Parse(raw)
+	return id, err == nil
+}
+
+// validateTeamBindings trims the bindings of a request and checks them
+func validateTeamBindings(req *UpdateTeamBindingsRequest) error {
+	req.SlackChannelID = strings.TrimSpace(req.SlackChannelID)
+	req.EmailAlias = strings.TrimSpace(req.EmailAlias)
+	if req.SlackChannelID != "" && !slackChannelIDRegex.MatchString(req.SlackChannelID) {
+		return fmt.Errorf("%w: slack_channel_id must be a channel ID such as C0123456789", ErrInvalidTeamBindings)
+	}
+	if req.EmailAlias != "" {
+		if addr, err := mail.ParseAddress(req.EmailAlias); err != nil || addr.Address != req.EmailAlias {
+			return fmt.Errorf("%w: invalid email_alias %q", ErrInvalidTeamBindings, req.EmailAlias)
+		}
+	}
+	if req.TeamsWebhookURL != nil {
+		url := strings.TrimSpace(*req.TeamsWebhookURL)
+		if url != "" && !strings.HasPrefix(url, "https://") {
+			return fmt.Errorf("%w: teams_webhook_url must use https", ErrInvalidTeamBindings)
+		}
+		req.TeamsWebhookURL = &url
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/kubeatlas/kubeatlas/internal/models"
+)
+
+func TestValidateTeamBindings(t *testing.T) {
+	webhook := " https://acme.webhook.office.com/webhookb2/payments "
+	req := UpdateTeamBindingsRequest{SlackChannelID: " C0123456789 ", EmailAlias: "payments@acme.com", TeamsWebhookURL: &webhook}
+	if err := validateTeamBindings(&req); err != nil {
+		t.Fatalf("validateTeamBindings() error = %v", err)
+	}
+	if req.SlackChannelID != "C0123456789" || *req.TeamsWebhookURL != "https://acme.webhook.office.com/webhookb2/payments" {
+		t.Errorf("validateTeamBindings() = %+v, want trimmed bindings", req)
+	}
+
+	plain := "http://acme.example/hook"
+	invalid := []UpdateTeamBindingsRequest{
+		{SlackChannelID: "#payments"},
+		{EmailAlias: "payments"},
+		{TeamsWebhookURL: &plain},
+	}
+	for _, req := range invalid {
+		if err := validateTeamBindings(&req); !errors.Is(err, ErrInvalidTeamBindings) {
+			t.Errorf("validateTeamBindings(%+v) error = %v, want ErrInvalidTeamBindings", req, err)
+		}
+	}
+}
+
+func TestTeamBindingFallbacks(t *testing.T) {
+	team := &models.Team{
+		ContactEmail: models.NewNullStringFromString("team@acme.com"),
+		ContactSlack: models.NewNullStringFromString("#payments"),
+	}
+	if got := teamSlackChannel(team, nil); got != "#payments" {
+		t.Errorf("teamSlackChannel() without bindings = %q, want the Slack contact", got)
+	}
+	if got := teamEmailAddress(team, &models.TeamNotificationBindings{SlackChannelID: "C0123456789"}); got != "team@acme.com" {
+		t.Errorf("teamEmailAddress() without an alias = %q, want the contact email", got)
+	}
+
+	b := &models.TeamNotificationBindings{SlackChannelID: "C0123456789", EmailAlias: "payments-oncall@acme.com"}
+	if got := teamSlackChannel(team, b); got != "C0123456789" {
+		t.Errorf("teamSlackChannel() = %q, want the bound channel", got)
+	}
+	if got := teamEmailAddress(team, b); got != "payments-oncall@acme.com" {
+		t.Errorf("teamEmailAddress() = %q, want the alias", got)
+	}
+	if got := teamSlackChannel(nil, nil); got != "" {
+		t.Errorf("teamSlackChannel() of no team = %q", got)
+	}
+}
+
+func TestTeamRecipient(t *testing.T) {
+	id := uuid.New()
+	if got, ok := teamRecipient([]string{teamRecipientPrefix + id.String()}); !ok || got != id {
+		t.Errorf("teamRecipient() = %v, %v, want %v", got, ok, id)
+	}
+	for _, recipients := range [][]string{nil, {"C0123456789"}, {teamRecipientPrefix + "nope"}} {
+		if _, ok := teamRecipient(recipients); ok {
+			t.Errorf("teamRecipient(%q) ok, want not a team", recipients)
+		}
+	}
+}
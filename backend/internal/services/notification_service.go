@@ -0,0 +1,1915 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kubeatlas/kubeatlas/internal/crypto"
+	"github.com/kubeatlas/kubeatlas/internal/database/repositories"
+	"github.com/kubeatlas/kubeatlas/internal/escalation"
+	"github.com/kubeatlas/kubeatlas/internal/mail"
+	"github.com/kubeatlas/kubeatlas/internal/models"
+	"github.com/kubeatlas/kubeatlas/internal/slack"
+	"github.com/kubeatlas/kubeatlas/internal/teams"
+	"github.com/kubeatlas/kubeatlas/internal/telemetry"
+	"go.uber.org/zap"
+)
+
+const (
+	// Delivery channels
+	notificationChannelEmail = "email"
+	notificationChannelSlack = "slack"
+	notificationChannelTeams = "teams"
+
+	// maxDeliveryAttempts is how often a delivery is tried before it is marked failed
+	maxDeliveryAttempts = 5
+	// deliveryBatchSize caps the deliveries sent per ProcessDeliveries run
+	deliveryBatchSize = 50
+	// deliveryClaimTimeout is how long a claimed delivery stays with one
+	// instance before another may pick it up again
+	deliveryClaimTimeout = 5 * time.Minute
+	// digestInterval is the minimum time between two digests to an organization
+	digestInterval = 7 * 24 * time.Hour
+	// digestNamespacesPerCluster caps the namespaces listed per cluster in a digest
+	digestNamespacesPerCluster = 25
+)
+
+var (
+	ErrUnknownNotificationEvent = errors.New("unknown notification event")
+	ErrInvalidTemplate          = errors.New("invalid notification template")
+	ErrInvalidSMTPSettings      = errors.New("invalid SMTP settings: host, port and from address are required")
+	ErrNoRecipients             = errors.New("notification has no recipients")
+	ErrEmailDisabled            = errors.New("email delivery is not enabled for this organization")
+	ErrInvalidSlackSettings     = errors.New("invalid Slack settings: a webhook URL or bot token is required")
+	ErrInvalidTeamsSettings     = errors.New("invalid Microsoft Teams settings: a webhook URL is required")
+	ErrUnknownChannel           = errors.New("unknown notification channel")
+	ErrInvalidSyncAlertSettings = errors.New("invalid sync alert settings: failure_threshold must be at least 1 and repeat_interval_hours cannot be negative")
+)
+
+// SMTPSettings are the organization's mail server settings, stored in
+// organizations.settings["smtp"]
+type SMTPSettings struct {
+	Enabled  bool   `json:"enabled"`
+	Host     string `json:"host"`
+	Port     int    `json:"port"`
+	Username string `json:"username"`
+	Password string `json:"password,omitempty"`
+	From     string `json:"from"`
+	TLSMode  string `json:"tls_mode"` // none, starttls, tls
+}
+
+func (s *SMTPSettings) mailConfig() mail.Config {
+	return mail.Config{
+		Host:     s.Host,
+		Port:     s.Port,
+		Username: s.Username,
+		Password: s.Password,
+		From:     s.From,
+		TLSMode:  s.TLSMode,
+	}
+}
+
+func (s *SMTPSettings) validate() error {
+	if s.Host == "" || s.Port <= 0 || s.Port > 65535 || s.From == "" {
+		return ErrInvalidSMTPSettings
+	}
+	switch s.TLSMode {
+	case mail.TLSModeNone, mail.TLSModeStartTLS, mail.TLSModeImplicit:
+		return nil
+	default:
+		return fmt.Errorf("invalid SMTP settings: unknown tls_mode %q", s.TLSMode)
+	}
+}
+
+// notificationTemplate is the built-in subject and body of an event
+type notificationTemplate struct {
+	Subject string
+	Body    string
+}
+
+// defaultEmailTemplates are used when the organization has no email
+// template of its own for an event
+var defaultEmailTemplates = map[string]notificationTemplate{
+	models.NotificationEventSyncFailed: {
+		Subject: "[KubeAtlas] Sync failed for cluster {{.Cluster}}",
+		Body: `Synchronizing cluster {{.Cluster}} failed at {{.Time}}.
+
+Category: {{.Category}}
+Error: {{.Error}}
+{{- if .Failures}}
+Consecutive failures: {{.Failures}}
+{{- end}}
+
+The cluster keeps its last known inventory until the next successful sync.
+`,
+	},
+	models.NotificationEventReportReady: {
+		Subject: "[KubeAtlas] {{.Report}} report",
+		Body: `The {{.Report}} report you requested is below ({{.Filename}}).
+
+{{.Content}}`,
+	},
+	models.NotificationEventInvitation: {
+		Subject: "You have been invited to KubeAtlas",
+		Body: `Hello {{.Name}},
+
+{{if .InvitedBy}}{{.InvitedBy}} has given you{{else}}You have been given{{end}} access to KubeAtlas as {{.Role}}.
+Sign in with {{.Email}}.
+`,
+	},
+	models.NotificationEventDigest: {
+		Subject: "[KubeAtlas] Weekly digest: {{.Total}} namespaces need attention{{if .Team}} ({{.Team}}){{end}}",
+		Body: `{{if .Team}}Namespaces owned by {{.Team}}{{else}}Namespaces{{end}} missing inventory information:
+
+Without an owner:         {{.Orphaned}}
+Without documentation:    {{.Undocumented}}
+Without a business unit:  {{.NoBusinessUnit}}
+{{range .Clusters}}
+Cluster {{.Name}}:
+{{- range .Namespaces}}
+  - {{.Name}} (missing {{.Missing}})
+{{- end}}
+{{- if .More}}
+  ...and {{.More}} more
+{{- end}}
+{{end}}
+Open the KubeAtlas dashboard to review them.
+`,
+	},
+	models.NotificationEventOwnershipChanged: {
+		Subject: "[KubeAtlas] Ownership of {{.Resource}} {{.Name}} changed",
+		Body: `{{if .ChangedBy}}{{.ChangedBy}} changed the ownership of{{else}}The ownership changed for{{end}} {{.Resource}} {{.Name}}{{if .Cluster}} on cluster {{.Cluster}}{{end}}:
+{{range .Changes}}
+  {{.Field}}: {{or .Old "(none)"}} -> {{or .New "(none)"}}
+{{- end}}
+
+You receive this because your team {{if .Team}}({{.Team}}) {{end}}owned or now owns it.
+`,
+	},
+	models.NotificationEventEscalation: {
+		Subject: "[KubeAtlas] {{.Summary}} (level {{.Level}} of {{.Levels}})",
+		Body: `{{.Summary}}.
+
+Namespace: {{.Namespace}}{{if .Cluster}}
+Cluster:   {{.Cluster}}{{end}}
+Opened:    {{.Time}}
+
+You are contacted at level {{.Level}} of {{.Levels}} of the namespace's escalation path.
+{{- if not .Final}} Unless the alert is acknowledged, it escalates to the next level.{{end}}
+Acknowledge it in KubeAtlas (escalation {{.EscalationID}}).
+`,
+	},
+	models.NotificationEventMention: {
+		Subject: "[KubeAtlas] {{.Author}} mentioned you on {{.Target}}",
+		Body: `{{.Author}} mentioned you in a comment on {{.Target}}:
+
+{{.Comment}}
+
+Reply on the {{.Target}} page in KubeAtlas.
+`,
+	},
+	models.NotificationEventClusterVersionEOL: {
+		Subject: "[KubeAtlas] Kubernetes {{.MinorVersion}} on cluster {{.Cluster}} {{if .Past}}is past end of life{{else}}reaches end of life in {{.Days}} days{{end}}",
+		Body: `Cluster {{.Cluster}} runs Kubernetes {{.Version}}.
+{{if .EndOfLife}}
+Kubernetes {{.MinorVersion}} {{if .Past}}reached{{else}}reaches{{end}} end of life on {{.EndOfLife}}.
+{{- else}}
+Kubernetes {{.MinorVersion}} is past end of life.
+{{- end}}
+{{- if .Past}} It no longer receives security fixes.{{end}}
+
+Plan an upgrade to a supported minor version.
+`,
+	},
+	models.NotificationEventTest: {
+		Subject: "[KubeAtlas] Test email",
+		Body:    "This is a test email from KubeAtlas. Your SMTP settings work.\n",
+	},
+}
+
+// defaultSlackTemplates are the built-in Slack messages. Slack has no
+// subject, so only the body is sent.
+var defaultSlackTemplates = map[string]notificationTemplate{
+	models.NotificationEventSyncFailed: {
+		Body: ":red_circle: Sync failed for cluster *{{.Cluster}}* ({{.Category}}{{if .Failures}}, {{.Failures}} failures in a row{{end}}): {{.Error}}",
+	},
+	models.NotificationEventNamespaceOrphaned: {
+		Body: ":warning: Namespace *{{.Namespace}}* on cluster *{{.Cluster}}* no longer has an owner team{{if .Team}} ({{.Team}} was removed){{end}}.",
+	},
+	models.NotificationEventDocumentUploaded: {
+		Body: ":page_facing_up: {{if .UploadedBy}}{{.UploadedBy}} uploaded{{else}}New document{{end}} *{{.Document}}*{{if .Target}} for {{.Target}}{{end}}.",
+	},
+	models.NotificationEventOwnershipChanged: {
+		Body: ":busts_in_silhouette: Ownership of {{.Resource}} *{{.Name}}*{{if .Cluster}} on cluster *{{.Cluster}}*{{end}} changed{{if .ChangedBy}} by {{.ChangedBy}}{{end}}:{{range .Changes}}\n• {{.Field}}: {{or .Old \"(none)\"}} → {{or .New \"(none)\"}}{{end}}",
+	},
+	models.NotificationEventClusterVersionEOL: {
+		Body: ":hourglass: Cluster *{{.Cluster}}* runs Kubernetes {{.Version}}, which {{if .Past}}is past end of life{{else}}reaches end of life in {{.Days}} days{{end}}{{if .EndOfLife}} ({{.EndOfLife}}){{end}}.",
+	},
+	models.NotificationEventEscalation: {
+		Body: ":rotating_light: *{{.Summary}}* in namespace *{{.Namespace}}*{{if .Cluster}} on cluster *{{.Cluster}}*{{end}} (escalation level {{.Level}} of {{.Levels}}). Acknowledge escalation `{{.EscalationID}}` in KubeAtlas to stop further escalation.",
+	},
+	models.NotificationEventTest: {
+		Body: "This is a test message from KubeAtlas. Your Slack settings work.",
+	},
+}
+
+// defaultTeamsTemplates are the built-in Microsoft Teams cards; the subject
+// is the card title and the body its text
+var defaultTeamsTemplates = map[string]notificationTemplate{
+	models.NotificationEventSyncFailed: {
+		Subject: "Sync failed for cluster {{.Cluster}}",
+		Body:    "**Category:** {{.Category}}\n\n**Error:** {{.Error}}{{if .Failures}}\n\n**Consecutive failures:** {{.Failures}}{{end}}",
+	},
+	models.NotificationEventNamespaceOrphaned: {
+		Subject: "Namespace {{.Namespace}} has no owner team",
+		Body:    "Namespace **{{.Namespace}}** on cluster **{{.Cluster}}** no longer has an owner team{{if .Team}} because {{.Team}} was removed{{end}}.",
+	},
+	models.NotificationEventDocumentUploaded: {
+		Subject: "New document: {{.Document}}",
+		Body:    "{{if .UploadedBy}}{{.UploadedBy}} uploaded{{else}}Uploaded{{end}} **{{.FileName}}**{{if .Target}} for {{.Target}}{{end}}.",
+	},
+	models.NotificationEventOwnershipChanged: {
+		Subject: "Ownership of {{.Resource}} {{.Name}} changed",
+		Body:    "{{if .ChangedBy}}Changed by {{.ChangedBy}}{{if .Cluster}} on cluster **{{.Cluster}}**{{end}}.\n\n{{else if .Cluster}}On cluster **{{.Cluster}}**.\n\n{{end}}{{range .Changes}}- **{{.Field}}:** {{or .Old \"(none)\"}} → {{or .New \"(none)\"}}\n{{end}}",
+	},
+	models.NotificationEventClusterVersionEOL: {
+		Subject: "Kubernetes {{.MinorVersion}} on cluster {{.Cluster}} {{if .Past}}is past end of life{{else}}reaches end of life in {{.Days}} days{{end}}",
+		Body:    "Cluster **{{.Cluster}}** runs Kubernetes {{.Version}}.{{if .EndOfLife}}\n\n**End of life:** {{.EndOfLife}}{{end}}\n\nPlan an upgrade to a supported minor version.",
+	},
+	models.NotificationEventEscalation: {
+		Subject: "{{.Summary}}",
+		Body:    "Namespace **{{.Namespace}}**{{if .Cluster}} on cluster **{{.Cluster}}**{{end}}, escalation level {{.Level}} of {{.Levels}}.\n\nAcknowledge escalation `{{.EscalationID}}` in KubeAtlas to stop further escalation.",
+	},
+	models.NotificationEventTest: {
+		Subject: "KubeAtlas test message",
+		Body:    "Your Microsoft Teams settings work.",
+	},
+}
+
+// teamsCardStyles colours the card title of an event
+var teamsCardStyles = map[string]string{
+	models.NotificationEventSyncFailed:        "attention",
+	models.NotificationEventEscalation:        "attention",
+	models.NotificationEventNamespaceOrphaned: "warning",
+	models.NotificationEventClusterVersionEOL: "warning",
+	models.NotificationEventTest:              "good",
+}
+
+// defaultTemplate returns the built-in template of an event on a channel
+func defaultTemplate(channel, eventType string) (notificationTemplate, error) {
+	var templates map[string]notificationTemplate
+	switch channel {
+	case notificationChannelEmail:
+		templates = defaultEmailTemplates
+	case notificationChannelSlack:
+		templates = defaultSlackTemplates
+	case notificationChannelTeams:
+		templates = defaultTeamsTemplates
+	default:
+		return notificationTemplate{}, ErrUnknownChannel
+	}
+
+	tmpl, ok := templates[eventType]
+	if !ok {
+		return notificationTemplate{}, ErrUnknownNotificationEvent
+	}
+	return tmpl, nil
+}
+
+// UpsertTemplateRequest is the payload for overriding an event's template
+type UpsertTemplateRequest struct {
+	Name            string `json:"name"`
+	Channel         string `json:"channel"` // email (default), slack or teams
+	SubjectTemplate string `json:"subject_template"`
+	BodyTemplate    string `json:"body_template" binding:"required"`
+	IsActive        *bool  `json:"is_active"`
+}
+
+// NotificationService renders notifications and delivers them by email, Slack
+// and Microsoft Teams
+type NotificationService struct {
+	repo          *repositories.NotificationRepository
+	userRepo      *repositories.UserRepository
+	teamRepo      *repositories.TeamRepository
+	clusterRepo   *repositories.ClusterRepository
+	namespaceRepo *repositories.NamespaceRepository
+	settings      *OrgSettingsService
+	sender        mail.Sender
+	slack         slack.Poster
+	teams         teams.Poster
+	encryptor     *crypto.Encryptor
+	auditSvc      *AuditService
+	logger        *zap.SugaredLogger
+}
+
+// NewNotificationService creates a new notification service
+func NewNotificationService(
+	repo *repositories.NotificationRepository,
+	userRepo *repositories.UserRepository,
+	teamRepo *repositories.TeamRepository,
+	clusterRepo *repositories.ClusterRepository,
+	namespaceRepo *repositories.NamespaceRepository,
+	settings *OrgSettingsService,
+	sender mail.Sender,
+	slackPoster slack.Poster,
+	teamsPoster teams.Poster,
+	encryptor *crypto.Encryptor,
+	auditSvc *AuditService,
+	logger *zap.SugaredLogger,
+) *NotificationService {
+	return &NotificationService{
+		repo:          repo,
+		userRepo:      userRepo,
+		teamRepo:      teamRepo,
+		clusterRepo:   clusterRepo,
+		namespaceRepo: namespaceRepo,
+		settings:      settings,
+		sender:        sender,
+		slack:         slackPoster,
+		teams:         teamsPoster,
+		encryptor:     encryptor,
+		auditSvc:      auditSvc,
+		logger:        logger,
+	}
+}
+
+// ============================================
+// SMTP Settings
+// ============================================
+
+// GetSMTPSettings returns the organization's SMTP settings, including the password
+func (s *NotificationService) GetSMTPSettings(ctx context.Context, orgID uuid.UUID) (*SMTPSettings, error) {
+	settings, err := s.userRepo.GetOrganizationSettings(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+
+	smtp := &SMTPSettings{
+		Port:    587,
+		TLSMode: mail.TLSModeStartTLS,
+	}
+
+	smtpSettings, ok := settings["smtp"].(map[string]interface{})
+	if !ok {
+		return smtp, nil
+	}
+
+	if v, ok := smtpSettings["enabled"].(bool); ok {
+		smtp.Enabled = v
+	}
+	if v, ok := smtpSettings["host"].(string); ok {
+		smtp.Host = v
+	}
+	// JSON numbers decode as float64
+	if v, ok := smtpSettings["port"].(float64); ok {
+		smtp.Port = int(v)
+	}
+	if v, ok := smtpSettings["username"].(string); ok {
+		smtp.Username = v
+	}
+	if v, ok := smtpSettings["password"].(string); ok {
+		if smtp.Password, err = openCredential(s.encryptor, v); err != nil {
+			return nil, err
+		}
+	}
+	if v, ok := smtpSettings["from"].(string); ok {
+		smtp.From = v
+	}
+	if v, ok := smtpSettings["tls_mode"].(string); ok && v != "" {
+		smtp.TLSMode = v
+	}
+
+	return smtp, nil
+}
+
+// UpdateSMTPSettings replaces the organization's SMTP settings. An empty
+// password keeps the stored one. The returned settings omit the password.
+func (s *NotificationService) UpdateSMTPSettings(ctx context.Context, ac AuditContext, req SMTPSettings) (*SMTPSettings, error) {
+	if req.TLSMode == "" {
+		req.TLSMode = mail.TLSModeStartTLS
+	}
+	if req.Enabled {
+		if err := req.validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	settings, err := s.userRepo.GetOrganizationSettings(ctx, ac.OrgID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Password == "" {
+		if existing, ok := settings["smtp"].(map[string]interface{}); ok {
+			if v, ok := existing["password"].(string); ok {
+				if req.Password, err = openCredential(s.encryptor, v); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	password, err := sealCredential(s.encryptor, req.Password)
+	if err != nil {
+		return nil, err
+	}
+	settings["smtp"] = map[string]interface{}{
+		"enabled":  req.Enabled,
+		"host":     req.Host,
+		"port":     req.Port,
+		"username": req.Username,
+		"password": password,
+		"from":     req.From,
+		"tls_mode": req.TLSMode,
+	}
+
+	if err := s.userRepo.UpdateOrganizationSettings(ctx, ac.OrgID, settings); err != nil {
+		return nil, err
+	}
+
+	s.auditSvc.LogUpdate(ctx, ac, "smtp_settings", ac.OrgID, "smtp", nil, map[string]interface{}{
+		"enabled":  req.Enabled,
+		"host":     req.Host,
+		"port":     req.Port,
+		"from":     req.From,
+		"tls_mode": req.TLSMode,
+	})
+
+	req.Password = ""
+	return &req, nil
+}
+
+// TestSMTPSettings sends a test email synchronously with the given settings.
+// An empty password falls back to the stored one.
+func (s *NotificationService) TestSMTPSettings(ctx context.Context, orgID uuid.UUID, req SMTPSettings, to string) error {
+	if req.TLSMode == "" {
+		req.TLSMode = mail.TLSModeStartTLS
+	}
+	if err := req.validate(); err != nil {
+		return err
+	}
+	if req.Password == "" {
+		stored, err := s.GetSMTPSettings(ctx, orgID)
+		if err != nil {
+			return err
+		}
+		req.Password = stored.Password
+	}
+
+	subject, body, err := s.render(ctx, orgID, notificationChannelEmail, models.NotificationEventTest, nil)
+	if err != nil {
+		return err
+	}
+
+	return s.sender.Send(ctx, req.mailConfig(), mail.Message{
+		To:      []string{to},
+		Subject: subject,
+		Body:    body,
+	})
+}
+
+// ============================================
+// Slack Settings
+// ============================================
+
+// SlackSettings are the organization's Slack integration settings, stored in
+// organizations.settings["slack"]. Routes selects the events posted to Slack
+// and maps each to a channel; an empty channel means DefaultChannel.
+type SlackSettings struct {
+	Enabled        bool              `json:"enabled"`
+	WebhookURL     string            `json:"webhook_url,omitempty"`
+	BotToken       string            `json:"bot_token,omitempty"`
+	DefaultChannel string            `json:"default_channel"`
+	Routes         map[string]string `json:"routes"`
+}
+
+func (s *SlackSettings) slackConfig() slack.Config {
+	return slack.Config{WebhookURL: s.WebhookURL, BotToken: s.BotToken}
+}
+
+func (s *SlackSettings) validate() error {
+	if s.WebhookURL == "" && s.BotToken == "" {
+		return ErrInvalidSlackSettings
+	}
+	if s.WebhookURL != "" && !strings.HasPrefix(s.WebhookURL, "https://") {
+		return fmt.Errorf("%w: webhook URL must use https", ErrInvalidSlackSettings)
+	}
+	for event := range s.Routes {
+		if _, ok := defaultSlackTemplates[event]; !ok || event == models.NotificationEventTest {
+			return fmt.Errorf("%w: unknown event %q", ErrInvalidSlackSettings, event)
+		}
+	}
+	return nil
+}
+
+// GetSlackSettings returns the organization's Slack settings, including credentials
+func (s *NotificationService) GetSlackSettings(ctx context.Context, orgID uuid.UUID) (*SlackSettings, error) {
+	settings, err := s.userRepo.GetOrganizationSettings(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := &SlackSettings{Routes: make(map[string]string)}
+
+	slackSettings, ok := settings["slack"].(map[string]interface{})
+	if !ok {
+		return cfg, nil
+	}
+
+	if v, ok := slackSettings["enabled"].(bool); ok {
+		cfg.Enabled = v
+	}
+	if v, ok := slackSettings["webhook_url"].(string); ok {
+		if cfg.WebhookURL, err = openCredential(s.encryptor, v); err != nil {
+			return nil, err
+		}
+	}
+	if v, ok := slackSettings["bot_token"].(string); ok {
+		if cfg.BotToken, err = openCredential(s.encryptor, v); err != nil {
+			return nil, err
+		}
+	}
+	if v, ok := slackSettings["default_channel"].(string); ok {
+		cfg.DefaultChannel = v
+	}
+	if routes, ok := slackSettings["routes"].(map[string]interface{}); ok {
+		for event, channel := range routes {
+			if v, ok := channel.(string); ok {
+				cfg.Routes[event] = v
+			}
+		}
+	}
+
+	return cfg, nil
+}
+
+// UpdateSlackSettings replaces the organization's Slack settings. Empty
+// credentials keep the stored ones. The returned settings omit credentials.
+func (s *NotificationService) UpdateSlackSettings(ctx context.Context, ac AuditContext, req SlackSettings) (*SlackSettings, error) {
+	settings, err := s.userRepo.GetOrganizationSettings(ctx, ac.OrgID)
+	if err != nil {
+		return nil, err
+	}
+
+	if existing, ok := settings["slack"].(map[string]interface{}); ok {
+		if v, ok := existing["webhook_url"].(string); ok && req.WebhookURL == "" {
+			if req.WebhookURL, err = openCredential(s.encryptor, v); err != nil {
+				return nil, err
+			}
+		}
+		if v, ok := existing["bot_token"].(string); ok && req.BotToken == "" {
+			if req.BotToken, err = openCredential(s.encryptor, v); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if req.Routes == nil {
+		req.Routes = make(map[string]string)
+	}
+	if req.Enabled {
+		if err := req.validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	routes := make(map[string]interface{}, len(req.Routes))
+	for event, channel := range req.Routes {
+		routes[event] = channel
+	}
+	webhookURL, err := sealCredential(s.encryptor, req.WebhookURL)
+	if err != nil {
+		return nil, err
+	}
+	botToken, err := sealCredential(s.encryptor, req.BotToken)
+	if err != nil {
+		return nil, err
+	}
+	settings["slack"] = map[string]interface{}{
+		"enabled":         req.Enabled,
+		"webhook_url":     webhookURL,
+		"bot_token":       botToken,
+		"default_channel": req.DefaultChannel,
+		"routes":          routes,
+	}
+
+	if err := s.userRepo.UpdateOrganizationSettings(ctx, ac.OrgID, settings); err != nil {
+		return nil, err
+	}
+
+	s.auditSvc.LogUpdate(ctx, ac, "slack_settings", ac.OrgID, "slack", nil, map[string]interface{}{
+		"enabled":         req.Enabled,
+		"default_channel": req.DefaultChannel,
+		"routes":          routes,
+	})
+
+	req.WebhookURL = ""
+	req.BotToken = ""
+	return &req, nil
+}
+
+// TestSlackSettings posts a test message synchronously with the given
+// settings. Empty credentials fall back to the stored ones.
+func (s *NotificationService) TestSlackSettings(ctx context.Context, orgID uuid.UUID, req SlackSettings, channel string) error {
+	if req.WebhookURL == "" || req.BotToken == "" {
+		stored, err := s.GetSlackSettings(ctx, orgID)
+		if err != nil {
+			return err
+		}
+		if req.WebhookURL == "" {
+			req.WebhookURL = stored.WebhookURL
+		}
+		if req.BotToken == "" {
+			req.BotToken = stored.BotToken
+		}
+	}
+	req.Routes = nil
+	if err := req.validate(); err != nil {
+		return err
+	}
+	if channel == "" {
+		channel = req.DefaultChannel
+	}
+
+	_, body, err := s.render(ctx, orgID, notificationChannelSlack, models.NotificationEventTest, nil)
+	if err != nil {
+		return err
+	}
+
+	return s.slack.Post(ctx, req.slackConfig(), slack.Message{Channel: channel, Text: body})
+}
+
+// ============================================
+// Microsoft Teams Settings
+// ============================================
+
+// TeamsSettings are the organization's Microsoft Teams settings, stored in
+// organizations.settings["teams"]. Events lists the subscribed events; each
+// posts to its EventWebhooks entry or, without one, to WebhookURL.
+type TeamsSettings struct {
+	Enabled       bool              `json:"enabled"`
+	WebhookURL    string            `json:"webhook_url,omitempty"`
+	Events        []string          `json:"events"`
+	EventWebhooks map[string]string `json:"event_webhooks,omitempty"`
+}
+
+func (s *TeamsSettings) subscribed(eventType string) bool {
+	for _, e := range s.Events {
+		if e == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *TeamsSettings) webhookFor(eventType string) string {
+	if url := s.EventWebhooks[eventType]; url != "" {
+		return url
+	}
+	return s.WebhookURL
+}
+
+func (s *TeamsSettings) validate() error {
+	if s.WebhookURL != "" && !strings.HasPrefix(s.WebhookURL, "https://") {
+		return fmt.Errorf("%w: webhook URL must use https", ErrInvalidTeamsSettings)
+	}
+	for event, url := range s.EventWebhooks {
+		if !strings.HasPrefix(url, "https://") {
+			return fmt.Errorf("%w: webhook URL for %s must use https", ErrInvalidTeamsSettings, event)
+		}
+	}
+	for _, event := range s.Events {
+		if _, ok := defaultTeamsTemplates[event]; !ok || event == models.NotificationEventTest {
+			return fmt.Errorf("%w: unknown event %q", ErrInvalidTeamsSettings, event)
+		}
+		if s.webhookFor(event) == "" {
+			return ErrInvalidTeamsSettings
+		}
+	}
+	return nil
+}
+
+// GetTeamsSettings returns the organization's Microsoft Teams settings, including webhook URLs
+func (s *NotificationService) GetTeamsSettings(ctx context.Context, orgID uuid.UUID) (*TeamsSettings, error) {
+	settings, err := s.userRepo.GetOrganizationSettings(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := &TeamsSettings{Events: []string{}, EventWebhooks: make(map[string]string)}
+
+	teamsSettings, ok := settings["teams"].(map[string]interface{})
+	if !ok {
+		return cfg, nil
+	}
+
+	if v, ok := teamsSettings["enabled"].(bool); ok {
+		cfg.Enabled = v
+	}
+	if v, ok := teamsSettings["webhook_url"].(string); ok {
+		if cfg.WebhookURL, err = openCredential(s.encryptor, v); err != nil {
+			return nil, err
+		}
+	}
+	if events, ok := teamsSettings["events"].([]interface{}); ok {
+		for _, e := range events {
+			if v, ok := e.(string); ok {
+				cfg.Events = append(cfg.Events, v)
+			}
+		}
+	}
+	if webhooks, ok := teamsSettings["event_webhooks"].(map[string]interface{}); ok {
+		for event, url := range webhooks {
+			if v, ok := url.(string); ok {
+				if cfg.EventWebhooks[event], err = openCredential(s.encryptor, v); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	return cfg, nil
+}
+
+// UpdateTeamsSettings replaces the organization's Microsoft Teams settings.
+// An empty webhook URL or nil EventWebhooks keeps the stored ones. The
+// returned settings omit webhook URLs.
+func (s *NotificationService) UpdateTeamsSettings(ctx context.Context, ac AuditContext, req TeamsSettings) (*TeamsSettings, error) {
+	stored, err := s.GetTeamsSettings(ctx, ac.OrgID)
+	if err != nil {
+		return nil, err
+	}
+	if req.WebhookURL == "" {
+		req.WebhookURL = stored.WebhookURL
+	}
+	if req.EventWebhooks == nil {
+		req.EventWebhooks = stored.EventWebhooks
+	}
+	if req.Events == nil {
+		req.Events = []string{}
+	}
+	if req.Enabled {
+		if err := req.validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	settings, err := s.userRepo.GetOrganizationSettings(ctx, ac.OrgID)
+	if err != nil {
+		return nil, err
+	}
+
+	webhookURL, err := sealCredential(s.encryptor, req.WebhookURL)
+	if err != nil {
+		return nil, err
+	}
+	webhooks := make(map[string]interface{}, len(req.EventWebhooks))
+	for event, url := range req.EventWebhooks {
+		if webhooks[event], err = sealCredential(s.encryptor, url); err != nil {
+			return nil, err
+		}
+	}
+	settings["teams"] = map[string]interface{}{
+		"enabled":        req.Enabled,
+		"webhook_url":    webhookURL,
+		"events":         req.Events,
+		"event_webhooks": webhooks,
+	}
+
+	if err := s.userRepo.UpdateOrganizationSettings(ctx, ac.OrgID, settings); err != nil {
+		return nil, err
+	}
+
+	s.auditSvc.LogUpdate(ctx, ac, "teams_settings", ac.OrgID, "teams", nil, map[string]interface{}{
+		"enabled": req.Enabled,
+		"events":  req.Events,
+	})
+
+	req.WebhookURL = ""
+	req.EventWebhooks = nil
+	return &req, nil
+}
+
+// TestTeamsSettings posts a test card synchronously to the given webhook,
+// falling back to the stored default webhook
+func (s *NotificationService) TestTeamsSettings(ctx context.Context, orgID uuid.UUID, webhookURL string) error {
+	if webhookURL == "" {
+		stored, err := s.GetTeamsSettings(ctx, orgID)
+		if err != nil {
+			return err
+		}
+		webhookURL = stored.WebhookURL
+	}
+	if webhookURL == "" {
+		return ErrInvalidTeamsSettings
+	}
+	if !strings.HasPrefix(webhookURL, "https://") {
+		return fmt.Errorf("%w: webhook URL must use https", ErrInvalidTeamsSettings)
+	}
+
+	title, text, err := s.render(ctx, orgID, notificationChannelTeams, models.NotificationEventTest, nil)
+	if err != nil {
+		return err
+	}
+
+	return s.teams.Post(ctx, webhookURL, teams.Card{
+		Title: title,
+		Text:  text,
+		Style: teamsCardStyles[models.NotificationEventTest],
+	})
+}
+
+// ============================================
+// Sync Alert Settings
+// ============================================
+
+// SyncAlertSettings control when cluster owners are alerted about failing
+// syncs and connectivity probes, stored in organizations.settings["sync_alerts"]
+type SyncAlertSettings struct {
+	// FailureThreshold is the number of consecutive failures before the first alert
+	FailureThreshold int `json:"failure_threshold"`
+	// SuppressRepeats alerts once per failure streak instead of on every failure
+	SuppressRepeats bool `json:"suppress_repeats"`
+	// RepeatIntervalHours re-sends a suppressed alert while the cluster keeps
+	// failing; 0 never does
+	RepeatIntervalHours int `json:"repeat_interval_hours"`
+}
+
+// RepeatInterval is RepeatIntervalHours as a duration
+func (s *SyncAlertSettings) RepeatInterval() time.Duration {
+	return time.Duration(s.RepeatIntervalHours) * time.Hour
+}
+
+func (s *SyncAlertSettings) validate() error {
+	if s.FailureThreshold < 1 || s.RepeatIntervalHours < 0 {
+		return ErrInvalidSyncAlertSettings
+	}
+	return nil
+}
+
+// SyncAlertsSetting alerts on the first failure of a streak only by default
+var SyncAlertsSetting = SettingKey[SyncAlertSettings]{
+	Name:     "sync_alerts",
+	Default:  SyncAlertSettings{FailureThreshold: 1, SuppressRepeats: true},
+	Validate: (*SyncAlertSettings).validate,
+}
+
+// GetSyncAlertSettings returns the organization's sync alert settings
+func (s *NotificationService) GetSyncAlertSettings(ctx context.Context, orgID uuid.UUID) (*SyncAlertSettings, error) {
+	cfg, err := GetSetting(ctx, s.settings, orgID, SyncAlertsSetting)
+	if err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+// UpdateSyncAlertSettings replaces the organization's sync alert settings
+func (s *NotificationService) UpdateSyncAlertSettings(ctx context.Context, ac AuditContext, req SyncAlertSettings) (*SyncAlertSettings, error) {
+	cfg, err := UpdateSetting(ctx, s.settings, ac, SyncAlertsSetting, req)
+	if err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+// ============================================
+// Digest Settings
+// ============================================
+
+// DigestSettings control the weekly namespace digest, stored in
+// organizations.settings["digest"]
+type DigestSettings struct {
+	Enabled bool `json:"enabled"`
+	// IncludeTeamLeads also sends team leads the namespaces their team owns
+	IncludeTeamLeads bool `json:"include_team_leads"`
+}
+
+// DigestSetting turns the digest on by default for organizations with
+// email enabled
+var DigestSetting = SettingKey[DigestSettings]{
+	Name:    "digest",
+	Default: DigestSettings{Enabled: true},
+}
+
+// GetDigestSettings returns the organization's digest settings
+func (s *NotificationService) GetDigestSettings(ctx context.Context, orgID uuid.UUID) (*DigestSettings, error) {
+	cfg, err := GetSetting(ctx, s.settings, orgID, DigestSetting)
+	if err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+// UpdateDigestSettings replaces the organization's digest settings
+func (s *NotificationService) UpdateDigestSettings(ctx context.Context, ac AuditContext, req DigestSettings) (*DigestSettings, error) {
+	cfg, err := UpdateSetting(ctx, s.settings, ac, DigestSetting, req)
+	if err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+// ============================================
+// Templates
+// ============================================
+
+// ListTemplates returns the organization's template overrides
+func (s *NotificationService) ListTemplates(ctx context.Context, orgID uuid.UUID) ([]models.NotificationTemplate, error) {
+	return s.repo.ListTemplates(ctx, orgID)
+}
+
+// UpsertTemplate overrides the template of an event on a channel for the organization
+func (s *NotificationService) UpsertTemplate(ctx context.Context, ac AuditContext, eventType string, req UpsertTemplateRequest) (*models.NotificationTemplate, error) {
+	if req.Channel == "" {
+		req.Channel = notificationChannelEmail
+	}
+	if _, err := defaultTemplate(req.Channel, eventType); err != nil {
+		return nil, err
+	}
+	if req.Channel != notificationChannelSlack && req.SubjectTemplate == "" {
+		return nil, fmt.Errorf("%w: subject is required for %s", ErrInvalidTemplate, req.Channel)
+	}
+	if _, err := template.New("subject").Parse(req.SubjectTemplate); err != nil {
+		return nil, fmt.Errorf("%w: subject: %v", ErrInvalidTemplate, err)
+	}
+	if _, err := template.New("body").Parse(req.BodyTemplate); err != nil {
+		return nil, fmt.Errorf("%w: body: %v", ErrInvalidTemplate, err)
+	}
+
+	orgID := ac.OrgID
+	tmpl := &models.NotificationTemplate{
+		OrganizationID:  &orgID,
+		Name:            req.Name,
+		EventType:       eventType,
+		Channel:         req.Channel,
+		SubjectTemplate: models.NewNullStringFromString(req.SubjectTemplate),
+		BodyTemplate:    req.BodyTemplate,
+		IsActive:        true,
+	}
+	if tmpl.Name == "" {
+		tmpl.Name = eventType
+	}
+	if req.IsActive != nil {
+		tmpl.IsActive = *req.IsActive
+	}
+
+	if err := s.repo.UpsertTemplate(ctx, tmpl); err != nil {
+		return nil, err
+	}
+	s.auditSvc.LogUpdate(ctx, ac, "notification_template", tmpl.ID, eventType, nil, map[string]interface{}{
+		"channel":          req.Channel,
+		"subject_template": req.SubjectTemplate,
+		"is_active":        tmpl.IsActive,
+	})
+	return tmpl, nil
+}
+
+// render executes the organization's template for the event on a channel,
+// falling back to the built-in one
+func (s *NotificationService) render(ctx context.Context, orgID uuid.UUID, channel, eventType string, data map[string]interface{}) (string, string, error) {
+	tmpl, err := defaultTemplate(channel, eventType)
+	if err != nil {
+		return "", "", err
+	}
+
+	custom, err := s.repo.GetTemplate(ctx, orgID, eventType, channel)
+	if err != nil {
+		return "", "", err
+	}
+	if custom != nil {
+		tmpl.Body = custom.BodyTemplate
+		if custom.SubjectTemplate.Valid {
+			tmpl.Subject = custom.SubjectTemplate.String
+		}
+	}
+
+	return renderNotification(tmpl, data)
+}
+
+func renderNotification(tmpl notificationTemplate, data map[string]interface{}) (string, string, error) {
+	subject, err := executeTemplate("subject", tmpl.Subject, data)
+	if err != nil {
+		return "", "", err
+	}
+	body, err := executeTemplate("body", tmpl.Body, data)
+	if err != nil {
+		return "", "", err
+	}
+	// Subjects are a single header line
+	subject = strings.Join(strings.Fields(subject), " ")
+	return subject, body, nil
+}
+
+func executeTemplate(name, text string, data map[string]interface{}) (string, error) {
+	t, err := template.New(name).Option("missingkey=zero").Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("%w: %s: %v", ErrInvalidTemplate, name, err)
+	}
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("%w: %s: %v", ErrInvalidTemplate, name, err)
+	}
+	return buf.String(), nil
+}
+
+// ============================================
+// Delivery
+// ============================================
+
+// Notify renders an event for the recipients and queues it for delivery.
+// It does nothing when the organization has not enabled email.
+func (s *NotificationService) Notify(ctx context.Context, orgID uuid.UUID, eventType string, recipients []string, data map[string]interface{}) error {
+	smtp, err := s.GetSMTPSettings(ctx, orgID)
+	if err != nil {
+		return err
+	}
+	if !smtp.Enabled {
+		return nil
+	}
+
+	recipients = uniqueRecipients(recipients)
+	if len(recipients) == 0 {
+		return ErrNoRecipients
+	}
+
+	subject, body, err := s.render(ctx, orgID, notificationChannelEmail, eventType, data)
+	if err != nil {
+		return err
+	}
+
+	delivery := &models.NotificationDelivery{
+		OrganizationID: orgID,
+		EventType:      eventType,
+		Channel:        notificationChannelEmail,
+		Recipients:     recipients,
+		Subject:        subject,
+		Body:           body,
+	}
+	if err := s.repo.CreateDelivery(ctx, delivery); err != nil {
+		return fmt.Errorf("failed to queue notification: %w", err)
+	}
+	return nil
+}
+
+// notifySlack queues an event for Slack when the organization routes it
+// there. Messages about a team go to the team's bound Slack channel, or its
+// Slack contact, when it has one. Webhooks ignore the channel, so routing to
+// several channels needs a bot token.
+func (s *NotificationService) notifySlack(ctx context.Context, orgID uuid.UUID, eventType string, team *models.Team, data map[string]interface{}) {
+	if s == nil {
+		return
+	}
+
+	s.notifySlackChannel(ctx, orgID, eventType, teamSlackChannel(team, s.teamBindings(ctx, team)), data)
+}
+
+// notifySlackChannel is notifySlack for an explicit channel. An empty
+// channel uses the event's route or the default channel.
+func (s *NotificationService) notifySlackChannel(ctx context.Context, orgID uuid.UUID, eventType, channel string, data map[string]interface{}) {
+	cfg, err := s.GetSlackSettings(ctx, orgID)
+	if err != nil {
+		s.logger.Errorw("Failed to load Slack settings", "organization_id", orgID, "error", err)
+		return
+	}
+	route, routed := cfg.Routes[eventType]
+	if !cfg.Enabled || !routed {
+		return
+	}
+	if channel == "" {
+		channel = route
+	}
+	if channel == "" {
+		channel = cfg.DefaultChannel
+	}
+
+	_, body, err := s.render(ctx, orgID, notificationChannelSlack, eventType, escapeSlackData(data))
+	if err != nil {
+		s.logger.Errorw("Failed to render Slack notification", "event", eventType, "organization_id", orgID, "error", err)
+		return
+	}
+
+	delivery := &models.NotificationDelivery{
+		OrganizationID: orgID,
+		EventType:      eventType,
+		Channel:        notificationChannelSlack,
+		Recipients:     models.StringArray{},
+		Body:           body,
+	}
+	if channel != "" {
+		delivery.Recipients = append(delivery.Recipients, channel)
+	}
+	if err := s.repo.CreateDelivery(ctx, delivery); err != nil {
+		s.logger.Errorw("Failed to queue Slack notification", "event", eventType, "organization_id", orgID, "error", err)
+		telemetry.CaptureError(ctx, err)
+	}
+}
+
+// notifyTeams queues an event for Microsoft Teams when the organization
+// subscribed to it. Messages about a team with a Teams channel of its own go
+// there. The webhook is resolved at delivery time so the log never holds
+// webhook URLs.
+func (s *NotificationService) notifyTeams(ctx context.Context, orgID uuid.UUID, eventType string, team *models.Team, data map[string]interface{}) {
+	if s == nil {
+		return
+	}
+
+	cfg, err := s.GetTeamsSettings(ctx, orgID)
+	if err != nil {
+		s.logger.Errorw("Failed to load Microsoft Teams settings", "organization_id", orgID, "error", err)
+		return
+	}
+	if !cfg.Enabled || !cfg.subscribed(eventType) {
+		return
+	}
+
+	title, text, err := s.render(ctx, orgID, notificationChannelTeams, eventType, data)
+	if err != nil {
+		s.logger.Errorw("Failed to render Microsoft Teams notification", "event", eventType, "organization_id", orgID, "error", err)
+		return
+	}
+
+	delivery := &models.NotificationDelivery{
+		OrganizationID: orgID,
+		EventType:      eventType,
+		Channel:        notificationChannelTeams,
+		Recipients:     models.StringArray{},
+		Subject:        title,
+		Body:           text,
+	}
+	if b := s.teamBindings(ctx, team); b != nil && b.TeamsChannel {
+		delivery.Recipients = append(delivery.Recipients, teamRecipientPrefix+team.ID.String())
+	}
+	if err := s.repo.CreateDelivery(ctx, delivery); err != nil {
+		s.logger.Errorw("Failed to queue Microsoft Teams notification", "event", eventType, "organization_id", orgID, "error", err)
+		telemetry.CaptureError(ctx, err)
+	}
+}
+
+// notifyChat queues an event for every chat integration of the organization
+func (s *NotificationService) notifyChat(ctx context.Context, orgID uuid.UUID, eventType string, team *models.Team, data map[string]interface{}) {
+	s.notifySlack(ctx, orgID, eventType, team, data)
+	s.notifyTeams(ctx, orgID, eventType, team, data)
+}
+
+// escapeSlackData escapes string values so they cannot inject Slack mentions or links
+func escapeSlackData(data map[string]interface{}) map[string]interface{} {
+	escaped := make(map[string]interface{}, len(data))
+	for k, v := range data {
+		switch t := v.(type) {
+		case string:
+			v = slack.Escape(t)
+		case []OwnershipChange:
+			changes := make([]OwnershipChange, len(t))
+			for i, c := range t {
+				changes[i] = OwnershipChange{Field: slack.Escape(c.Field), Old: slack.Escape(c.Old), New: slack.Escape(c.New)}
+			}
+			v = changes
+		}
+		escaped[k] = v
+	}
+	return escaped
+}
+
+// notify is Notify for callers that must not fail because of a notification
+func (s *NotificationService) notify(ctx context.Context, orgID uuid.UUID, eventType string, recipients []string, data map[string]interface{}) {
+	if s == nil {
+		return
+	}
+	if err := s.Notify(ctx, orgID, eventType, recipients, data); err != nil && !errors.Is(err, ErrNoRecipients) {
+		s.logger.Errorw("Failed to queue notification", "event", eventType, "organization_id", orgID, "error", err)
+		telemetry.CaptureError(ctx, err)
+	}
+}
+
+// ProcessDeliveries sends due deliveries and schedules retries for failed ones
+func (s *NotificationService) ProcessDeliveries(ctx context.Context) error {
+	deliveries, err := s.repo.ClaimDueDeliveries(ctx, deliveryBatchSize, deliveryClaimTimeout)
+	if err != nil {
+		return fmt.Errorf("failed to claim notification deliveries: %w", err)
+	}
+
+	smtpByOrg := make(map[uuid.UUID]*SMTPSettings)
+	slackByOrg := make(map[uuid.UUID]*SlackSettings)
+	teamsByOrg := make(map[uuid.UUID]*TeamsSettings)
+	for _, d := range deliveries {
+		sendErr := s.deliver(ctx, d, smtpByOrg, slackByOrg, teamsByOrg)
+
+		if sendErr == nil {
+			if err := s.repo.MarkDeliverySent(ctx, d.ID); err != nil {
+				s.logger.Errorw("Failed to mark notification sent", "delivery_id", d.ID, "error", err)
+			}
+			continue
+		}
+
+		var retryAt *time.Time
+		if d.Attempts < maxDeliveryAttempts && !isPermanentDeliveryError(sendErr) {
+			next := time.Now().Add(deliveryBackoff(d.Attempts))
+			retryAt = &next
+		}
+		s.logger.Warnw("Notification delivery failed",
+			"delivery_id", d.ID, "event", d.EventType, "attempt", d.Attempts, "retry", retryAt != nil, "error", sendErr)
+		if err := s.repo.MarkDeliveryFailed(ctx, d.ID, sendErr.Error(), retryAt); err != nil {
+			s.logger.Errorw("Failed to record notification failure", "delivery_id", d.ID, "error", err)
+		}
+	}
+
+	return nil
+}
+
+// deliver sends a delivery over its channel, caching each organization's
+// settings for the rest of the batch
+func (s *NotificationService) deliver(ctx context.Context, d models.NotificationDelivery, smtpByOrg map[uuid.UUID]*SMTPSettings, slackByOrg map[uuid.UUID]*SlackSettings, teamsByOrg map[uuid.UUID]*TeamsSettings) error {
+	switch d.Channel {
+	case notificationChannelEmail:
+		smtp, ok := smtpByOrg[d.OrganizationID]
+		if !ok {
+			var err error
+			if smtp, err = s.GetSMTPSettings(ctx, d.OrganizationID); err != nil {
+				return fmt.Errorf("failed to load SMTP settings: %w", err)
+			}
+			smtpByOrg[d.OrganizationID] = smtp
+		}
+		if !smtp.Enabled {
+			return mail.ErrNotConfigured
+		}
+		return s.sender.Send(ctx, smtp.mailConfig(), mail.Message{
+			To:      d.Recipients,
+			Subject: d.Subject,
+			Body:    d.Body,
+		})
+
+	case notificationChannelSlack:
+		cfg, ok := slackByOrg[d.OrganizationID]
+		if !ok {
+			var err error
+			if cfg, err = s.GetSlackSettings(ctx, d.OrganizationID); err != nil {
+				return fmt.Errorf("failed to load Slack settings: %w", err)
+			}
+			slackByOrg[d.OrganizationID] = cfg
+		}
+		if !cfg.Enabled {
+			return slack.ErrNotConfigured
+		}
+		msg := slack.Message{Text: d.Body}
+		if len(d.Recipients) > 0 {
+			msg.Channel = d.Recipients[0]
+		}
+		return s.slack.Post(ctx, cfg.slackConfig(), msg)
+
+	case notificationChannelTeams:
+		cfg, ok := teamsByOrg[d.OrganizationID]
+		if !ok {
+			var err error
+			if cfg, err = s.GetTeamsSettings(ctx, d.OrganizationID); err != nil {
+				return fmt.Errorf("failed to load Microsoft Teams settings: %w", err)
+			}
+			teamsByOrg[d.OrganizationID] = cfg
+		}
+		if !cfg.Enabled {
+			return teams.ErrNotConfigured
+		}
+		webhook, err := s.teamWebhook(ctx, d.Recipients)
+		if err != nil {
+			return err
+		}
+		if webhook == "" {
+			webhook = cfg.webhookFor(d.EventType)
+		}
+		return s.teams.Post(ctx, webhook, teams.Card{
+			Title: d.Subject,
+			Text:  d.Body,
+			Style: teamsCardStyles[d.EventType],
+		})
+
+	default:
+		return ErrUnknownChannel
+	}
+}
+
+// isPermanentDeliveryError reports whether retrying cannot help
+func isPermanentDeliveryError(err error) bool {
+	return errors.Is(err, mail.ErrNotConfigured) || errors.Is(err, slack.ErrNotConfigured) ||
+		errors.Is(err, teams.ErrNotConfigured) || errors.Is(err, ErrUnknownChannel)
+}
+
+// deliveryBackoff is the wait before retrying after the given attempt:
+// 1, 2, 4, 8 minutes and so on, capped at one hour
+func deliveryBackoff(attempt int) time.Duration {
+	if attempt < 1 {
+		attempt = 1
+	}
+	if attempt > 7 {
+		return time.Hour
+	}
+	if d := time.Minute << (attempt - 1); d < time.Hour {
+		return d
+	}
+	return time.Hour
+}
+
+// ListDeliveries returns the organization's delivery log
+func (s *NotificationService) ListDeliveries(ctx context.Context, orgID uuid.UUID, p repositories.Pagination, filters map[string]interface{}) (*repositories.PaginatedResult[models.NotificationDelivery], error) {
+	return s.repo.ListDeliveries(ctx, orgID, p, filters)
+}
+
+// ============================================
+// Events
+// ============================================
+
+// NotifySyncFailed tells the cluster's owner team and responsible user, or
+// the organization's admins when it has neither, that a sync failed for the
+// given number of consecutive times, and posts it to the chat integrations
+// subscribed to it
+func (s *NotificationService) NotifySyncFailed(ctx context.Context, cluster *models.Cluster, category string, cause error, failures int) {
+	if s == nil {
+		return
+	}
+
+	team := s.team(ctx, cluster.OwnerTeamID)
+	recipients := s.clusterOwnerEmails(ctx, cluster, team)
+
+	data := map[string]interface{}{
+		"Cluster":  cluster.Name,
+		"Category": category,
+		"Error":    cause.Error(),
+		"Time":     time.Now().UTC().Format(time.RFC1123),
+	}
+	if failures > 1 {
+		data["Failures"] = failures
+	}
+	s.notify(ctx, cluster.OrganizationID, models.NotificationEventSyncFailed, recipients, data)
+	s.notifyChat(ctx, cluster.OrganizationID, models.NotificationEventSyncFailed, team, data)
+}
+
+// NotifyClusterVersionEOL tells the owners of a cluster, like
+// NotifySyncFailed, that its Kubernetes version approaches or is past end of
+// life, and posts it to the chat integrations subscribed to it
+func (s *NotificationService) NotifyClusterVersionEOL(ctx context.Context, cluster *models.Cluster, support *models.VersionSupport) {
+	if s == nil {
+		return
+	}
+
+	team := s.team(ctx, cluster.OwnerTeamID)
+	data := map[string]interface{}{
+		"Cluster":      cluster.Name,
+		"Version":      cluster.Version.String,
+		"MinorVersion": support.MinorVersion,
+		"Past":         support.Status == models.VersionSupportEndOfLife,
+	}
+	if support.EndOfLife != nil {
+		data["EndOfLife"] = support.EndOfLife.Format(releaseDateLayout)
+	}
+	if support.DaysRemaining != nil {
+		data["Days"] = *support.DaysRemaining
+	}
+	s.notify(ctx, cluster.OrganizationID, models.NotificationEventClusterVersionEOL, s.clusterOwnerEmails(ctx, cluster, team), data)
+	s.notifyChat(ctx, cluster.OrganizationID, models.NotificationEventClusterVersionEOL, team, data)
+}
+
+// clusterOwnerEmails returns the email of the cluster's owner team and
+// responsible user, or those of the organization's admins when it has neither
+func (s *NotificationService) clusterOwnerEmails(ctx context.Context, cluster *models.Cluster, team *models.Team) []string {
+	var recipients []string
+	if email := s.teamEmail(ctx, team); email != "" {
+		recipients = append(recipients, email)
+	}
+	if cluster.ResponsibleUserID != nil {
+		if user, err := s.userRepo.GetByID(ctx, *cluster.ResponsibleUserID); err == nil && user != nil && user.IsActive {
+			recipients = append(recipients, user.Email)
+		}
+	}
+	if len(recipients) == 0 {
+		recipients = s.adminEmails(ctx, cluster.OrganizationID)
+	}
+	return recipients
+}
+
+// NotifyNamespacesOrphaned posts to chat that namespaces lost their owner
+// team, e.g. because formerTeam was deleted
+func (s *NotificationService) NotifyNamespacesOrphaned(ctx context.Context, namespaces []models.Namespace, formerTeam *models.Team) {
+	if s == nil {
+		return
+	}
+
+	clusterNames := make(map[uuid.UUID]string)
+	for _, ns := range namespaces {
+		clusterName, ok := clusterNames[ns.ClusterID]
+		if !ok {
+			if cluster, err := s.clusterRepo.GetByID(ctx, ns.ClusterID); err == nil && cluster != nil {
+				clusterName = cluster.Name
+			}
+			clusterNames[ns.ClusterID] = clusterName
+		}
+
+		data := map[string]interface{}{
+			"Namespace": ns.Name,
+			"Cluster":   clusterName,
+		}
+		if formerTeam != nil {
+			data["Team"] = formerTeam.Name
+		}
+		s.notifyChat(ctx, ns.OrganizationID, models.NotificationEventNamespaceOrphaned, nil, data)
+	}
+}
+
+// NotifyDocumentUploaded posts a new document to chat, addressed in Slack to
+// the team owning its namespace or cluster
+func (s *NotificationService) NotifyDocumentUploaded(ctx context.Context, doc *models.Document, uploadedBy string) {
+	if s == nil {
+		return
+	}
+
+	var team *models.Team
+	target := ""
+	if doc.NamespaceID != nil {
+		if ns, err := s.namespaceRepo.GetByID(ctx, *doc.NamespaceID); err == nil && ns != nil {
+			target = "namespace " + ns.Name
+			team = s.team(ctx, ns.InfrastructureOwnerTeamID)
+		}
+	} else if doc.ClusterID != nil {
+		if cluster, err := s.clusterRepo.GetByID(ctx, *doc.ClusterID); err == nil && cluster != nil {
+			target = "cluster " + cluster.Name
+			team = s.team(ctx, cluster.OwnerTeamID)
+		}
+	}
+
+	s.notifyChat(ctx, doc.OrganizationID, models.NotificationEventDocumentUploaded, team, map[string]interface{}{
+		"Document":   doc.Name,
+		"FileName":   doc.FileName,
+		"Target":     target,
+		"UploadedBy": uploadedBy,
+	})
+}
+
+// OwnershipChange is one changed ownership field of a namespace or cluster
+type OwnershipChange struct {
+	Field string
+	Old   string
+	New   string
+}
+
+// ownershipField is an audit log key that is part of a resource's ownership
+type ownershipField struct {
+	Key   string
+	Label string
+	// Ref is "team" or "user" when the value is the ID of one
+	Ref string
+}
+
+var namespaceOwnershipFields = []ownershipField{
+	{Key: "infrastructure_owner_team_id", Label: "Owner team", Ref: "team"},
+	{Key: "infrastructure_owner_user_id", Label: "Owner user", Ref: "user"},
+	{Key: "application_manager_name", Label: "Application manager"},
+	{Key: "application_manager_email", Label: "Application manager email"},
+	{Key: "technical_lead_name", Label: "Technical lead"},
+	{Key: "technical_lead_email", Label: "Technical lead email"},
+	{Key: "project_manager_name", Label: "Project manager"},
+	{Key: "project_manager_email", Label: "Project manager email"},
+}
+
+var clusterOwnershipFields = []ownershipField{
+	{Key: "owner_team_id", Label: "Owner team", Ref: "team"},
+	{Key: "responsible_user_id", Label: "Responsible user", Ref: "user"},
+}
+
+// ownershipChanges compares the old and new audit log values of a resource
+// and returns the ownership fields that changed, with raw values
+func ownershipChanges(fields []ownershipField, oldValues, newValues map[string]interface{}) []ownershipFieldChange {
+	var changes []ownershipFieldChange
+	for _, f := range fields {
+		oldValue, newValue := auditValueString(oldValues[f.Key]), auditValueString(newValues[f.Key])
+		if oldValue != newValue {
+			changes = append(changes, ownershipFieldChange{field: f, old: oldValue, new: newValue})
+		}
+	}
+	return changes
+}
+
+type ownershipFieldChange struct {
+	field    ownershipField
+	old, new string
+}
+
+// auditValueString flattens a value recorded by StructToMap
+func auditValueString(v interface{}) string {
+	switch t := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return t
+	case models.NullString:
+		if !t.Valid {
+			return ""
+		}
+		return t.String
+	case *uuid.UUID:
+		if t == nil {
+			return ""
+		}
+		return t.String()
+	case uuid.UUID:
+		return t.String()
+	default:
+		return fmt.Sprint(t)
+	}
+}
+
+// NotifyNamespaceOwnershipChanged tells the old and new owning teams of a
+// namespace which ownership fields changed between the old and new values
+// recorded in the audit log. It does nothing when none did.
+func (s *NotificationService) NotifyNamespaceOwnershipChanged(ctx context.Context, ns *models.Namespace, oldValues, newValues map[string]interface{}, changedBy string) {
+	if s == nil {
+		return
+	}
+	changes := ownershipChanges(namespaceOwnershipFields, oldValues, newValues)
+	if len(changes) == 0 {
+		return
+	}
+
+	clusterName := ""
+	if cluster, err := s.clusterRepo.GetByID(ctx, ns.ClusterID); err == nil && cluster != nil {
+		clusterName = cluster.Name
+	}
+	s.notifyOwnershipChanged(ctx, ns.OrganizationID, "namespace", ns.Name, clusterName, changes,
+		auditTeamID(oldValues["infrastructure_owner_team_id"]), ns.InfrastructureOwnerTeamID, changedBy)
+}
+
+// NotifyClusterOwnershipChanged is NotifyNamespaceOwnershipChanged for clusters
+func (s *NotificationService) NotifyClusterOwnershipChanged(ctx context.Context, cluster *models.Cluster, oldValues, newValues map[string]interface{}, changedBy string) {
+	if s == nil {
+		return
+	}
+	changes := ownershipChanges(clusterOwnershipFields, oldValues, newValues)
+	if len(changes) == 0 {
+		return
+	}
+
+	s.notifyOwnershipChanged(ctx, cluster.OrganizationID, "cluster", cluster.Name, "", changes,
+		auditTeamID(oldValues["owner_team_id"]), cluster.OwnerTeamID, changedBy)
+}
+
+func (s *NotificationService) notifyOwnershipChanged(ctx context.Context, orgID uuid.UUID, resource, name, clusterName string, fieldChanges []ownershipFieldChange, oldTeamID, newTeamID *uuid.UUID, changedBy string) {
+	changes := make([]OwnershipChange, len(fieldChanges))
+	for i, c := range fieldChanges {
+		changes[i] = OwnershipChange{
+			Field: c.field.Label,
+			Old:   s.ownershipDisplayValue(ctx, c.field.Ref, c.old),
+			New:   s.ownershipDisplayValue(ctx, c.field.Ref, c.new),
+		}
+	}
+
+	oldTeam, newTeam := s.team(ctx, oldTeamID), s.team(ctx, newTeamID)
+	teams := []*models.Team{newTeam}
+	if oldTeam != nil && (newTeam == nil || oldTeam.ID != newTeam.ID) {
+		teams = append(teams, oldTeam)
+	}
+
+	var leads map[uuid.UUID][]string
+	for i, team := range teams {
+		data := map[string]interface{}{
+			"Resource":  resource,
+			"Name":      name,
+			"Cluster":   clusterName,
+			"Changes":   changes,
+			"ChangedBy": changedBy,
+		}
+		if team == nil {
+			// Nobody owns it now; only chat hears about it
+			s.notifyChat(ctx, orgID, models.NotificationEventOwnershipChanged, nil, data)
+			continue
+		}
+		data["Team"] = team.Name
+		bindings := s.teamBindings(ctx, team)
+
+		// Teams without a contact address are reached through their leads
+		var recipients []string
+		if email := teamEmailAddress(team, bindings); email != "" {
+			recipients = []string{email}
+		} else {
+			if leads == nil {
+				var err error
+				if leads, err = s.teamRepo.ListLeadEmails(ctx, orgID); err != nil {
+					s.logger.Errorw("Failed to list team leads", "organization_id", orgID, "error", err)
+				}
+			}
+			recipients = leads[team.ID]
+		}
+		s.notify(ctx, orgID, models.NotificationEventOwnershipChanged, recipients, data)
+
+		// One chat message per change, addressed to the old team as well only
+		// when it has a channel of its own
+		if i == 0 || teamSlackChannel(team, bindings) != "" {
+			s.notifySlack(ctx, orgID, models.NotificationEventOwnershipChanged, team, data)
+		}
+		if i == 0 || (bindings != nil && bindings.TeamsChannel) {
+			s.notifyTeams(ctx, orgID, models.NotificationEventOwnershipChanged, team, data)
+		}
+	}
+}
+
+// ownershipDisplayValue resolves team and user IDs to names for messages
+func (s *NotificationService) ownershipDisplayValue(ctx context.Context, ref, value string) string {
+	if value == "" || ref == "" {
+		return value
+	}
+	id, err := uuid.Parse(value)
+	if err != nil {
+		return value
+	}
+	switch ref {
+	case "team":
+		if team := s.team(ctx, &id); team != nil {
+			return team.Name
+		}
+	case "user":
+		if user, err := s.userRepo.GetByID(ctx, id); err == nil && user != nil {
+			return user.Email
+		}
+	}
+	return value
+}
+
+// auditTeamID returns the team ID recorded in an audit log value
+func auditTeamID(v interface{}) *uuid.UUID {
+	id, err := uuid.Parse(auditValueString(v))
+	if err != nil {
+		return nil
+	}
+	return &id
+}
+
+// NotifyEscalation sends one level of an escalation to the level's contacts.
+// A level without contacts that can be notified, e.g. because the namespace
+// has no escalation path, falls back to the namespace's owner team and then
+// to the organization's admins. Teams hears about the first level only.
+func (s *NotificationService) NotifyEscalation(ctx context.Context, e *models.Escalation, ns *models.Namespace, level escalation.Level, number, total int) {
+	if s == nil {
+		return
+	}
+
+	team := s.team(ctx, ns.InfrastructureOwnerTeamID)
+	recipients, channels := level.Emails(), level.SlackChannels()
+	if len(recipients) == 0 && len(channels) == 0 {
+		if email := s.teamEmail(ctx, team); email != "" {
+			recipients = []string{email}
+		} else {
+			recipients = s.adminEmails(ctx, ns.OrganizationID)
+		}
+	}
+
+	if total < number {
+		total = number
+	}
+	clusterName := ""
+	if cluster, err := s.clusterRepo.GetByID(ctx, ns.ClusterID); err == nil && cluster != nil {
+		clusterName = cluster.Name
+	}
+	data := map[string]interface{}{
+		"Summary":      e.Summary,
+		"Namespace":    ns.Name,
+		"Cluster":      clusterName,
+		"Level":        number,
+		"Levels":       total,
+		"Final":        number >= total,
+		"EscalationID": e.ID.String(),
+		"Time":         e.CreatedAt.UTC().Format(time.RFC1123),
+	}
+
+	s.notify(ctx, ns.OrganizationID, models.NotificationEventEscalation, recipients, data)
+	if len(channels) == 0 {
+		s.notifySlack(ctx, ns.OrganizationID, models.NotificationEventEscalation, team, data)
+	}
+	for _, channel := range channels {
+		s.notifySlackChannel(ctx, ns.OrganizationID, models.NotificationEventEscalation, channel, data)
+	}
+	if number == 1 {
+		s.notifyTeams(ctx, ns.OrganizationID, models.NotificationEventEscalation, team, data)
+	}
+}
+
+// NotifyMentioned emails the users a comment on target, e.g. "namespace
+// payments", mentions
+func (s *NotificationService) NotifyMentioned(ctx context.Context, comment *models.Comment, users []models.User, target string) {
+	if s == nil || len(users) == 0 {
+		return
+	}
+
+	recipients := make([]string, len(users))
+	for i, u := range users {
+		recipients[i] = u.Email
+	}
+	s.notify(ctx, comment.OrganizationID, models.NotificationEventMention, recipients, map[string]interface{}{
+		"Author":    comment.AuthorEmail,
+		"Target":    target,
+		"Comment":   comment.Body,
+		"CommentID": comment.ID.String(),
+	})
+}
+
+// NotifyInvitation tells a newly created user they have access
+func (s *NotificationService) NotifyInvitation(ctx context.Context, user *models.User, invitedBy string) {
+	if s == nil {
+		return
+	}
+
+	name := user.Email
+	if user.FullName.Valid && user.FullName.String != "" {
+		name = user.FullName.String
+	}
+
+	s.notify(ctx, user.OrganizationID, models.NotificationEventInvitation, []string{user.Email}, map[string]interface{}{
+		"Name":      name,
+		"Email":     user.Email,
+		"Role":      user.Role,
+		"InvitedBy": invitedBy,
+	})
+}
+
+// SendReport emails a rendered report to the recipients
+func (s *NotificationService) SendReport(ctx context.Context, ac AuditContext, reportType, filename string, content []byte, recipients []string) error {
+	smtp, err := s.GetSMTPSettings(ctx, ac.OrgID)
+	if err != nil {
+		return err
+	}
+	if !smtp.Enabled {
+		return ErrEmailDisabled
+	}
+
+	err = s.Notify(ctx, ac.OrgID, models.NotificationEventReportReady, recipients, map[string]interface{}{
+		"Report":   reportType,
+		"Filename": filename,
+		"Content":  string(content),
+	})
+	if err != nil {
+		return err
+	}
+
+	s.auditSvc.LogAction(ctx, ac, "email", "report", ac.OrgID, reportType, "Report emailed to "+strings.Join(recipients, ", "))
+	return nil
+}
+
+// SendDigests emails every organization with email enabled a digest of the
+// namespaces missing an owner, documents or a business unit, grouped by
+// cluster, at most once per digestInterval. The admins get every namespace;
+// with IncludeTeamLeads, team leads also get those their team owns.
+func (s *NotificationService) SendDigests(ctx context.Context) error {
+	orgIDs, err := s.repo.ListEmailEnabledOrganizations(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, orgID := range orgIDs {
+		if err := s.sendDigest(ctx, orgID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *NotificationService) sendDigest(ctx context.Context, orgID uuid.UUID) error {
+	settings, err := s.GetDigestSettings(ctx, orgID)
+	if err != nil {
+		return err
+	}
+	if !settings.Enabled {
+		return nil
+	}
+
+	last, err := s.repo.LastDeliveryAt(ctx, orgID, models.NotificationEventDigest)
+	if err != nil {
+		return err
+	}
+	if last != nil && time.Since(*last) < digestInterval {
+		return nil
+	}
+
+	gaps, err := s.namespaceRepo.ListGaps(ctx, orgID)
+	if err != nil {
+		return err
+	}
+	if len(gaps) == 0 {
+		return nil
+	}
+
+	s.notify(ctx, orgID, models.NotificationEventDigest, s.adminEmails(ctx, orgID), digestData(gaps))
+
+	if !settings.IncludeTeamLeads {
+		return nil
+	}
+
+	leads, err := s.teamRepo.ListLeadEmails(ctx, orgID)
+	if err != nil {
+		return err
+	}
+	byTeam := make(map[uuid.UUID][]models.NamespaceGap)
+	for _, g := range gaps {
+		if g.OwnerTeamID != nil && len(leads[*g.OwnerTeamID]) > 0 {
+			byTeam[*g.OwnerTeamID] = append(byTeam[*g.OwnerTeamID], g)
+		}
+	}
+	for teamID, teamGaps := range byTeam {
+		data := digestData(teamGaps)
+		if team := s.team(ctx, &teamID); team != nil {
+			data["Team"] = team.Name
+		}
+		s.notify(ctx, orgID, models.NotificationEventDigest, leads[teamID], data)
+	}
+	return nil
+}
+
+// digestCluster is the part of a digest about one cluster
+type digestCluster struct {
+	Name       string
+	Namespaces []digestNamespace
+	// More counts the namespaces left out beyond digestNamespacesPerCluster
+	More int
+}
+
+type digestNamespace struct {
+	Name    string
+	Missing string
+}
+
+// digestData builds the template data of a digest from gaps ordered by cluster
+func digestData(gaps []models.NamespaceGap) map[string]interface{} {
+	var orphaned, undocumented, noBusinessUnit int
+	clusters := make([]*digestCluster, 0)
+	byCluster := make(map[uuid.UUID]*digestCluster)
+
+	for _, g := range gaps {
+		var missing []string
+		if g.MissingOwner {
+			orphaned++
+			missing = append(missing, "owner")
+		}
+		if g.MissingDocuments {
+			undocumented++
+			missing = append(missing, "documents")
+		}
+		if g.MissingBusinessUnit {
+			noBusinessUnit++
+			missing = append(missing, "business unit")
+		}
+
+		c, ok := byCluster[g.ClusterID]
+		if !ok {
+			c = &digestCluster{Name: g.Cluster}
+			byCluster[g.ClusterID] = c
+			clusters = append(clusters, c)
+		}
+		if len(c.Namespaces) < digestNamespacesPerCluster {
+			c.Namespaces = append(c.Namespaces, digestNamespace{Name: g.Namespace, Missing: strings.Join(missing, ", ")})
+		} else {
+			c.More++
+		}
+	}
+
+	return map[string]interface{}{
+		"Total":          len(gaps),
+		"Orphaned":       orphaned,
+		"Undocumented":   undocumented,
+		"NoBusinessUnit": noBusinessUnit,
+		"Clusters":       clusters,
+	}
+}
+
+// team loads a team by optional ID, returning nil when it is unset or missing
+func (s *NotificationService) team(ctx context.Context, id *uuid.UUID) *models.Team {
+	if id == nil {
+		return nil
+	}
+	team, err := s.teamRepo.GetByID(ctx, *id)
+	if err != nil {
+		return nil
+	}
+	return team
+}
+
+func (s *NotificationService) adminEmails(ctx context.Context, orgID uuid.UUID) []string {
+	emails, err := s.userRepo.ListEmailsByRole(ctx, orgID, "admin")
+	if err != nil {
+		s.logger.Errorw("Failed to list admin emails", "organization_id", orgID, "error", err)
+		return nil
+	}
+	return emails
+}
+
+// uniqueRecipients trims, lowercases and de-duplicates addresses
+func uniqueRecipients(recipients []string) []string {
+	seen := make(map[string]bool, len(recipients))
+	result := make([]string, 0, len(recipients))
+	for _, r := range recipients {
+		r = strings.ToLower(strings.TrimSpace(r))
+		if r == "" || seen[r] {
+			continue
+		}
+		seen[r] = true
+		result = append(result, r)
+	}
+	return result
+}
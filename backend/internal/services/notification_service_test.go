@@ -0,0 +1,216 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kubeatlas/kubeatlas/internal/models"
+)
+
+func TestRenderNotification_Defaults(t *testing.T) {
+	subject, body, err := renderNotification(defaultEmailTemplates[models.NotificationEventSyncFailed], map[string]interface{}{
+		"Cluster":  "prod-eu",
+		"Category": models.SyncErrorCategoryAuth,
+		"Error":    "unauthorized",
+	})
+	if err != nil {
+		t.Fatalf("renderNotification failed: %v", err)
+	}
+	if subject != "[KubeAtlas] Sync failed for cluster prod-eu" {
+		t.Errorf("subject = %q", subject)
+	}
+	if !strings.Contains(body, "Category: auth_error") || !strings.Contains(body, "Error: unauthorized") {
+		t.Errorf("body missing sync details: %q", body)
+	}
+
+	// Every built-in template renders without data
+	for _, templates := range []map[string]notificationTemplate{defaultEmailTemplates, defaultSlackTemplates, defaultTeamsTemplates} {
+		for event, tmpl := range templates {
+			if _, _, err := renderNotification(tmpl, nil); err != nil {
+				t.Errorf("%s: %v", event, err)
+			}
+		}
+	}
+}
+
+func TestRenderNotification_SyncFailureCount(t *testing.T) {
+	data := map[string]interface{}{"Cluster": "prod-eu", "Category": models.SyncErrorCategoryTimeout, "Error": "i/o timeout"}
+
+	_, body, err := renderNotification(defaultEmailTemplates[models.NotificationEventSyncFailed], data)
+	if err != nil {
+		t.Fatalf("renderNotification failed: %v", err)
+	}
+	if strings.Contains(body, "Consecutive failures") || !strings.Contains(body, "Error: i/o timeout\n\n") {
+		t.Errorf("body without a failure count = %q", body)
+	}
+
+	data["Failures"] = 3
+	_, body, err = renderNotification(defaultEmailTemplates[models.NotificationEventSyncFailed], data)
+	if err != nil {
+		t.Fatalf("renderNotification failed: %v", err)
+	}
+	if !strings.Contains(body, "Error: i/o timeout\nConsecutive failures: 3\n\n") {
+		t.Errorf("body with a failure count = %q", body)
+	}
+}
+
+func TestRenderNotification_SubjectIsOneLine(t *testing.T) {
+	subject, _, err := renderNotification(notificationTemplate{Subject: "a\n{{.X}}\r\nb", Body: ""}, map[string]interface{}{"X": "x"})
+	if err != nil {
+		t.Fatalf("renderNotification failed: %v", err)
+	}
+	if subject != "a x b" {
+		t.Errorf("subject = %q, want %q", subject, "a x b")
+	}
+}
+
+func TestDeliveryBackoff(t *testing.T) {
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{0, time.Minute},
+		{1, time.Minute},
+		{2, 2 * time.Minute},
+		{4, 8 * time.Minute},
+		{6, 32 * time.Minute},
+		{7, time.Hour},
+		{20, time.Hour},
+	}
+	for _, tt := range tests {
+		if got := deliveryBackoff(tt.attempt); got != tt.want {
+			t.Errorf("deliveryBackoff(%d) = %v, want %v", tt.attempt, got, tt.want)
+		}
+	}
+}
+
+func TestDigestData(t *testing.T) {
+	prod, dev := uuid.New(), uuid.New()
+	gaps := []models.NamespaceGap{
+		{ClusterID: dev, Cluster: "dev", Namespace: "sandbox", MissingOwner: true, MissingDocuments: true},
+		{ClusterID: prod, Cluster: "prod", Namespace: "billing", MissingBusinessUnit: true},
+		{ClusterID: prod, Cluster: "prod", Namespace: "payments", MissingDocuments: true},
+	}
+
+	data := digestData(gaps)
+	if data["Total"] != 3 || data["Orphaned"] != 1 || data["Undocumented"] != 2 || data["NoBusinessUnit"] != 1 {
+		t.Errorf("unexpected counts: %v", data)
+	}
+
+	_, body, err := renderNotification(defaultEmailTemplates[models.NotificationEventDigest], data)
+	if err != nil {
+		t.Fatalf("renderNotification failed: %v", err)
+	}
+	want := "Cluster dev:\n  - sandbox (missing owner, documents)\n\nCluster prod:\n  - billing (missing business unit)\n  - payments (missing documents)\n"
+	if !strings.Contains(body, want) {
+		t.Errorf("body = %q, want it to contain %q", body, want)
+	}
+}
+
+func TestDigestData_CapsNamespacesPerCluster(t *testing.T) {
+	cluster := uuid.New()
+	gaps := make([]models.NamespaceGap, digestNamespacesPerCluster+3)
+	for i := range gaps {
+		gaps[i] = models.NamespaceGap{ClusterID: cluster, Cluster: "prod", Namespace: "ns", MissingDocuments: true}
+	}
+
+	clusters := digestData(gaps)["Clusters"].([]*digestCluster)
+	if len(clusters) != 1 || len(clusters[0].Namespaces) != digestNamespacesPerCluster || clusters[0].More != 3 {
+		t.Errorf("unexpected clusters: %+v", clusters[0])
+	}
+}
+
+func TestUniqueRecipients(t *testing.T) {
+	got := uniqueRecipients([]string{" Ops@Example.com", "", "ops@example.com", "dev@example.com"})
+	if len(got) != 2 || got[0] != "ops@example.com" || got[1] != "dev@example.com" {
+		t.Errorf("uniqueRecipients() = %v", got)
+	}
+}
+
+func TestOwnershipChanges(t *testing.T) {
+	oldTeam, newTeam := uuid.New(), uuid.New()
+	oldValues := StructToMap(&models.Namespace{
+		InfrastructureOwnerTeamID: &oldTeam,
+		TechnicalLeadName:         models.NewNullStringFromString("Ada"),
+		Description:               models.NewNullStringFromString("before"),
+	})
+	newValues := StructToMap(&models.Namespace{
+		InfrastructureOwnerTeamID: &newTeam,
+		TechnicalLeadName:         models.NewNullStringFromString("Ada"),
+		ProjectManagerEmail:       models.NewNullStringFromString("pm@example.com"),
+		Description:               models.NewNullStringFromString("after"),
+	})
+
+	changes := ownershipChanges(namespaceOwnershipFields, oldValues, newValues)
+	if len(changes) != 2 {
+		t.Fatalf("ownershipChanges() = %+v, want owner team and project manager email", changes)
+	}
+	if c := changes[0]; c.field.Ref != "team" || c.old != oldTeam.String() || c.new != newTeam.String() {
+		t.Errorf("team change = %+v", c)
+	}
+	if c := changes[1]; c.field.Key != "project_manager_email" || c.old != "" || c.new != "pm@example.com" {
+		t.Errorf("contact change = %+v", c)
+	}
+
+	if changes := ownershipChanges(namespaceOwnershipFields, oldValues, oldValues); len(changes) != 0 {
+		t.Errorf("unchanged values reported as %+v", changes)
+	}
+}
+
+func TestRenderNotification_OwnershipChanged(t *testing.T) {
+	data := map[string]interface{}{
+		"Resource":  "namespace",
+		"Name":      "payments",
+		"Cluster":   "prod-eu",
+		"ChangedBy": "admin@example.com",
+		"Team":      "platform",
+		"Changes": []OwnershipChange{
+			{Field: "Owner team", Old: "platform", New: "payments-team"},
+			{Field: "Technical lead", New: "Ada"},
+		},
+	}
+
+	subject, body, err := renderNotification(defaultEmailTemplates[models.NotificationEventOwnershipChanged], data)
+	if err != nil {
+		t.Fatalf("renderNotification failed: %v", err)
+	}
+	if subject != "[KubeAtlas] Ownership of namespace payments changed" {
+		t.Errorf("subject = %q", subject)
+	}
+	for _, want := range []string{"admin@example.com changed", "Owner team: platform -> payments-team", "Technical lead: (none) -> Ada"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("body missing %q:\n%s", want, body)
+		}
+	}
+}
+
+func TestRenderNotification_Escalation(t *testing.T) {
+	data := map[string]interface{}{
+		"Summary":      "Critical external dependency card-gateway is down",
+		"Namespace":    "payments",
+		"Cluster":      "prod-eu",
+		"Level":        1,
+		"Levels":       2,
+		"Final":        false,
+		"EscalationID": "e-1",
+		"Time":         "Mon, 02 Jan 2006 15:04:05 UTC",
+	}
+
+	subject, body, err := renderNotification(defaultEmailTemplates[models.NotificationEventEscalation], data)
+	if err != nil {
+		t.Fatalf("renderNotification failed: %v", err)
+	}
+	if subject != "[KubeAtlas] Critical external dependency card-gateway is down (level 1 of 2)" {
+		t.Errorf("subject = %q", subject)
+	}
+	if !strings.Contains(body, "escalates to the next level") {
+		t.Errorf("body does not mention the next level:\n%s", body)
+	}
+
+	data["Level"], data["Final"] = 2, true
+	if _, body, _ := renderNotification(defaultEmailTemplates[models.NotificationEventEscalation], data); strings.Contains(body, "next level") {
+		t.Errorf("last level mentions a next level:\n%s", body)
+	}
+}
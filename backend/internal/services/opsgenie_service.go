@@ -0,0 +1,346 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/kubeatlas/kubeatlas/internal/crypto"
+	"github.com/kubeatlas/kubeatlas/internal/database/repositories"
+	"github.com/kubeatlas/kubeatlas/internal/models"
+	"github.com/kubeatlas/kubeatlas/internal/opsgenie"
+	"go.uber.org/zap"
+)
+
+var ErrInvalidOpsgenieSettings = errors.New("invalid Opsgenie settings")
+
+// defaultAlertPriority is used when the settings do not set a priority
+const defaultAlertPriority = "P1"
+
+// OpsgenieService shows who is on call for teams linked to an Opsgenie
+// schedule and, when enabled, opens Opsgenie alerts for critical external
+// dependencies that go down
+type OpsgenieService struct {
+	userRepo      *repositories.UserRepository
+	namespaceRepo *repositories.NamespaceRepository
+	teamRepo      *repositories.TeamRepository
+	pager         opsgenie.Pager
+	encryptor     *crypto.Encryptor
+	auditSvc      *AuditService
+	logger        *zap.SugaredLogger
+	cache         *onCallCache
+}
+
+func NewOpsgenieService(
+	userRepo *repositories.UserRepository,
+	namespaceRepo *repositories.NamespaceRepository,
+	teamRepo *repositories.TeamRepository,
+	pager opsgenie.Pager,
+	encryptor *crypto.Encryptor,
+	auditSvc *AuditService,
+	logger *zap.SugaredLogger,
+) *OpsgenieService {
+	return &OpsgenieService{
+		userRepo:      userRepo,
+		namespaceRepo: namespaceRepo,
+		teamRepo:      teamRepo,
+		pager:         pager,
+		encryptor:     encryptor,
+		auditSvc:      auditSvc,
+		logger:        logger,
+		cache:         newOnCallCache(onCallCacheTTL),
+	}
+}
+
+// ============================================
+// Opsgenie Settings
+// ============================================
+
+// OpsgenieSettings are the organization's Opsgenie settings, stored in
+// organizations.settings["opsgenie"]
+type OpsgenieSettings struct {
+	Enabled bool   `json:"enabled"`
+	APIKey  string `json:"api_key,omitempty"`
+	// Region is "us" (default) or "eu"
+	Region string `json:"region"`
+	// Alerts opens an alert when a critical external dependency goes down
+	Alerts bool `json:"alerts"`
+	// AlertPriority is the priority of those alerts, P1 to P5 (default P1)
+	AlertPriority string `json:"alert_priority"`
+}
+
+func (s *OpsgenieSettings) validate() error {
+	if s.Enabled && s.APIKey == "" {
+		return fmt.Errorf("%w: an API key is required", ErrInvalidOpsgenieSettings)
+	}
+	switch s.Region {
+	case "", opsgenie.RegionUS, opsgenie.RegionEU:
+	default:
+		return fmt.Errorf("%w: region must be us or eu", ErrInvalidOpsgenieSettings)
+	}
+	switch s.AlertPriority {
+	case "", "P1", "P2", "P3", "P4", "P5":
+	default:
+		return fmt.Errorf("%w: alert_priority must be P1 to P5", ErrInvalidOpsgenieSettings)
+	}
+	return nil
+}
+
+func (s *OpsgenieSettings) opsgenieConfig() opsgenie.Config {
+	return opsgenie.Config{APIKey: s.APIKey, Region: s.Region}
+}
+
+// GetSettings returns the organization's Opsgenie settings, including the API key
+func (s *OpsgenieService) GetSettings(ctx context.Context, orgID uuid.UUID) (*OpsgenieSettings, error) {
+	settings, err := s.userRepo.GetOrganizationSettings(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := &OpsgenieSettings{}
+	ogSettings, ok := settings["opsgenie"].(map[string]interface{})
+	if !ok {
+		return cfg, nil
+	}
+	if v, ok := ogSettings["enabled"].(bool); ok {
+		cfg.Enabled = v
+	}
+	if v, ok := ogSettings["api_key"].(string); ok {
+		if cfg.APIKey, err = openCredential(s.encryptor, v); err != nil {
+			return nil, err
+		}
+	}
+	if v, ok := ogSettings["region"].(string); ok {
+		cfg.Region = v
+	}
+	if v, ok := ogSettings["alerts"].(bool); ok {
+		cfg.Alerts = v
+	}
+	if v, ok := ogSettings["alert_priority"].(string); ok {
+		cfg.AlertPriority = v
+	}
+	return cfg, nil
+}
+
+// UpdateSettings replaces the organization's Opsgenie settings. An empty API
+// key keeps the stored one. The returned settings omit the key.
+func (s *OpsgenieService) UpdateSettings(ctx context.Context, ac AuditContext, req OpsgenieSettings) (*OpsgenieSettings, error) {
+	settings, err := s.userRepo.GetOrganizationSettings(ctx, ac.OrgID)
+	if err != nil {
+		return nil, err
+	}
+
+	if existing, ok := settings["opsgenie"].(map[string]interface{}); ok {
+		if v, ok := existing["api_key"].(string); ok && req.APIKey == "" {
+			if req.APIKey, err = openCredential(s.encryptor, v); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := req.validate(); err != nil {
+		return nil, err
+	}
+
+	apiKey, err := sealCredential(s.encryptor, req.APIKey)
+	if err != nil {
+		return nil, err
+	}
+	settings["opsgenie"] = map[string]interface{}{
+		"enabled":        req.Enabled,
+		"api_key":        apiKey,
+		"region":         req.Region,
+		"alerts":         req.Alerts,
+		"alert_priority": req.AlertPriority,
+	}
+	if err := s.userRepo.UpdateOrganizationSettings(ctx, ac.OrgID, settings); err != nil {
+		return nil, err
+	}
+	s.cache.clear(ac.OrgID)
+
+	s.auditSvc.LogUpdate(ctx, ac, "opsgenie_settings", ac.OrgID, "opsgenie", nil, map[string]interface{}{
+		"enabled":        req.Enabled,
+		"region":         req.Region,
+		"alerts":         req.Alerts,
+		"alert_priority": req.AlertPriority,
+	})
+
+	req.APIKey = ""
+	return &req, nil
+}
+
+// TestSettings checks that Opsgenie accepts the API key. An empty key falls
+// back to the stored one.
+func (s *OpsgenieService) TestSettings(ctx context.Context, orgID uuid.UUID, req OpsgenieSettings) error {
+	if req.APIKey == "" {
+		stored, err := s.GetSettings(ctx, orgID)
+		if err != nil {
+			return err
+		}
+		req.APIKey = stored.APIKey
+	}
+	if req.APIKey == "" {
+		return fmt.Errorf("%w: an API key is required", ErrInvalidOpsgenieSettings)
+	}
+	if err := req.validate(); err != nil {
+		return err
+	}
+	return s.pager.Check(ctx, req.opsgenieConfig())
+}
+
+// ============================================
+// On-call Users
+// ============================================
+
+// OnCall returns who is on call for an Opsgenie schedule. It returns nil
+// when the integration is disabled.
+func (s *OpsgenieService) OnCall(ctx context.Context, orgID uuid.UUID, scheduleID string) ([]models.OnCallResponder, error) {
+	if scheduleID == "" {
+		return nil, nil
+	}
+	if responders, ok := s.cache.get(orgID, scheduleID); ok {
+		return responders, nil
+	}
+
+	cfg, err := s.GetSettings(ctx, orgID)
+	if err != nil || !cfg.Enabled {
+		return nil, err
+	}
+	oncalls, err := s.pager.OnCall(ctx, cfg.opsgenieConfig(), scheduleID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Opsgenie has no escalation levels on schedules; everyone on call is
+	// contacted first
+	responders := make([]models.OnCallResponder, len(oncalls))
+	for i, o := range oncalls {
+		responders[i] = models.OnCallResponder{
+			EscalationLevel: 1,
+			Name:            o.Name,
+			Email:           o.Name,
+			Schedule:        o.Schedule,
+		}
+	}
+	s.cache.put(orgID, scheduleID, responders)
+	return responders, nil
+}
+
+// TeamOnCall implements OnCallSource for teams linked to an Opsgenie schedule
+func (s *OpsgenieService) TeamOnCall(ctx context.Context, team *models.Team) []models.OnCallResponder {
+	if team == nil || !team.OpsgenieScheduleID.Valid {
+		return nil
+	}
+	responders, err := s.OnCall(ctx, team.OrganizationID, team.OpsgenieScheduleID.String)
+	if err != nil {
+		s.logger.Warnw("Failed to get on-call users", "team_id", team.ID, "schedule", team.OpsgenieScheduleID.String, "error", err)
+		return nil
+	}
+	return responders
+}
+
+// ============================================
+// Alerts
+// ============================================
+
+// DependencyDown opens an alert for a critical external dependency that went
+// down, routed to the schedule of its namespace's owner team. It does
+// nothing unless alerts are enabled; failures are logged.
+func (s *OpsgenieService) DependencyDown(ctx context.Context, dep *models.ExternalDependency) {
+	if s == nil {
+		return
+	}
+	cfg, ok := s.alertSettings(ctx, dep.OrganizationID)
+	if !ok {
+		return
+	}
+
+	ns, err := s.namespaceRepo.GetByID(ctx, dep.NamespaceID)
+	if err != nil || ns == nil {
+		s.logger.Errorw("Failed to load namespace for Opsgenie alert", "namespace_id", dep.NamespaceID, "error", err)
+		return
+	}
+	var scheduleID string
+	if ns.InfrastructureOwnerTeamID != nil {
+		team, err := s.teamRepo.GetByID(ctx, *ns.InfrastructureOwnerTeamID)
+		if err != nil {
+			s.logger.Errorw("Failed to load team for Opsgenie alert", "team_id", *ns.InfrastructureOwnerTeamID, "error", err)
+		} else if team != nil {
+			scheduleID = team.OpsgenieScheduleID.ValueOrEmpty()
+		}
+	}
+
+	alert := dependencyAlert(dep, ns.Name, scheduleID, cfg.AlertPriority)
+	if err := s.pager.CreateAlert(ctx, cfg.opsgenieConfig(), alert); err != nil {
+		s.logger.Errorw("Failed to open Opsgenie alert", "dependency_id", dep.ID, "error", err)
+		return
+	}
+	s.logger.Infow("Opsgenie alert opened", "dependency_id", dep.ID, "alias", alert.Alias)
+}
+
+// DependencyRecovered closes the alert of an external dependency that is no
+// longer down. Closing an alert that was never opened is harmless.
+func (s *OpsgenieService) DependencyRecovered(ctx context.Context, dep *models.ExternalDependency) {
+	if s == nil {
+		return
+	}
+	cfg, ok := s.alertSettings(ctx, dep.OrganizationID)
+	if !ok {
+		return
+	}
+
+	note := fmt.Sprintf("%s is %s again", dep.Name, dep.Status)
+	if err := s.pager.CloseAlert(ctx, cfg.opsgenieConfig(), dependencyAlertAlias(dep.ID), note); err != nil {
+		s.logger.Warnw("Failed to close Opsgenie alert", "dependency_id", dep.ID, "error", err)
+	}
+}
+
+// alertSettings returns the organization's settings if alerts are enabled
+func (s *OpsgenieService) alertSettings(ctx context.Context, orgID uuid.UUID) (*OpsgenieSettings, bool) {
+	cfg, err := s.GetSettings(ctx, orgID)
+	if err != nil {
+		s.logger.Errorw("Failed to load Opsgenie settings", "organization_id", orgID, "error", err)
+		return nil, false
+	}
+	return cfg, cfg.Enabled && cfg.Alerts
+}
+
+func dependencyAlertAlias(id uuid.UUID) string {
+	return "kubeatlas-external-dependency-" + id.String()
+}
+
+// dependencyAlert builds the alert for a critical external dependency that
+// is down
+func dependencyAlert(dep *models.ExternalDependency, namespace, scheduleID, priority string) opsgenie.Alert {
+	if priority == "" {
+		priority = defaultAlertPriority
+	}
+	details := map[string]string{
+		"namespace":   namespace,
+		"dependency":  dep.Name,
+		"system_type": dep.SystemType,
+	}
+	if dep.Endpoint.Valid {
+		details["endpoint"] = dep.Endpoint.String
+	}
+	if dep.Provider.Valid {
+		details["provider"] = dep.Provider.String
+	}
+
+	description := fmt.Sprintf("The critical external dependency %s of namespace %s was reported down.", dep.Name, namespace)
+	if dep.ContactName.Valid || dep.ContactEmail.Valid {
+		contact := strings.TrimSpace(dep.ContactName.ValueOrEmpty() + " " + dep.ContactEmail.ValueOrEmpty())
+		description += "\nProvider contact: " + contact
+	}
+
+	return opsgenie.Alert{
+		Alias:       dependencyAlertAlias(dep.ID),
+		Message:     fmt.Sprintf("Critical dependency %s of %s is down", dep.Name, namespace),
+		Description: description,
+		Priority:    priority,
+		ScheduleID:  scheduleID,
+		Tags:        []string{"kubeatlas", "external-dependency"},
+		Details:     details,
+	}
+}
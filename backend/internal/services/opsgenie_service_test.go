@@ -0,0 +1,63 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/kubeatlas/kubeatlas/internal/models"
+)
+
+func TestDependencyAlert(t *testing.T) {
+	dep := &models.ExternalDependency{
+		Name:         "stripe",
+		SystemType:   "payment-gateway",
+		Endpoint:     models.NewNullStringFromString("https://api.stripe.com"),
+		ContactEmail: models.NewNullStringFromString("support@stripe.com"),
+	}
+	dep.ID = uuid.New()
+
+	alert := dependencyAlert(dep, "checkout", "S1", "")
+	if alert.Alias != "kubeatlas-external-dependency-"+dep.ID.String() || alert.Priority != "P1" || alert.ScheduleID != "S1" {
+		t.Errorf("dependencyAlert() = %+v", alert)
+	}
+	if alert.Message != "Critical dependency stripe of checkout is down" {
+		t.Errorf("Message = %q", alert.Message)
+	}
+	if !strings.HasSuffix(alert.Description, "\nProvider contact: support@stripe.com") {
+		t.Errorf("Description = %q", alert.Description)
+	}
+	if alert.Details["endpoint"] != "https://api.stripe.com" || alert.Details["namespace"] != "checkout" {
+		t.Errorf("Details = %v", alert.Details)
+	}
+	if _, ok := alert.Details["provider"]; ok {
+		t.Errorf("Details has an unset provider: %v", alert.Details)
+	}
+
+	if got := dependencyAlert(dep, "checkout", "", "P3").Priority; got != "P3" {
+		t.Errorf("Priority = %q, want P3", got)
+	}
+}
+
+func TestOpsgenieSettingsValidate(t *testing.T) {
+	valid := OpsgenieSettings{Enabled: true, APIKey: "key", Region: "eu", Alerts: true, AlertPriority: "P2"}
+	if err := valid.validate(); err != nil {
+		t.Errorf("validate() error = %v", err)
+	}
+	if err := (&OpsgenieSettings{}).validate(); err != nil {
+		t.Errorf("disabled validate() error = %v", err)
+	}
+
+	for name, mutate := range map[string]func(*OpsgenieSettings){
+		"no key":   func(s *OpsgenieSettings) { s.APIKey = "" },
+		"region":   func(s *OpsgenieSettings) { s.Region = "apac" },
+		"priority": func(s *OpsgenieSettings) { s.AlertPriority = "high" },
+	} {
+		s := valid
+		mutate(&s)
+		if err := s.validate(); !errors.Is(err, ErrInvalidOpsgenieSettings) {
+			t.Errorf("%s: validate() error = %v, want ErrInvalidOpsgenieSettings", name, err)
+		}
+	}
+}
@@ -0,0 +1,226 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kubeatlas/kubeatlas/internal/database/repositories"
+	"github.com/kubeatlas/kubeatlas/internal/models"
+	"github.com/kubeatlas/kubeatlas/internal/objectstore"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrOrgDeletionNotFound     = errors.New("organization deletion not found")
+	ErrOrgDeletionBlocked      = errors.New("organization cannot be deleted")
+	ErrOrgDeletionExpired      = errors.New("deletion preview expired or was already confirmed; request a new preview")
+	ErrOrgDeletionConfirmation = errors.New("confirm must be the organization's slug")
+)
+
+const (
+	// orgDeletionPreviewTTL is how long a deletion preview can be confirmed
+	orgDeletionPreviewTTL = 30 * time.Minute
+	// orgDeletionStaleAfter is how long a running deletion may go without
+	// progress before another instance runs it again
+	orgDeletionStaleAfter = 15 * time.Minute
+	// orgDeletionRetryAfter is how long a failed deletion waits before it is
+	// run again
+	orgDeletionRetryAfter = time.Hour
+)
+
+// OrgDeletionService deletes organizations with all of their data. A
+// deletion must be previewed first, which counts the rows it would remove;
+// confirming the preview deletes them in the background.
+type OrgDeletionService struct {
+	repo     *repositories.OrgDeletionRepository
+	orgRepo  *repositories.OrgSettingsRepository
+	auditSvc *AuditService
+	logger   *zap.SugaredLogger
+	store    objectstore.Store
+}
+
+// NewOrgDeletionService creates a new organization deletion service
+func NewOrgDeletionService(repo *repositories.OrgDeletionRepository, orgRepo *repositories.OrgSettingsRepository, auditSvc *AuditService, logger *zap.SugaredLogger) *OrgDeletionService {
+	return &OrgDeletionService{
+		repo:     repo,
+		orgRepo:  orgRepo,
+		auditSvc: auditSvc,
+		logger:   logger,
+	}
+}
+
+// SetStore removes the organization's audit log archives, exports and
+// metadata backups from store when it is deleted
+func (s *OrgDeletionService) SetStore(store objectstore.Store) {
+	s.store = store
+}
+
+// ConfirmOrgDeletionRequest confirms a deletion preview. Confirm must repeat
+// the organization's slug.
+type ConfirmOrgDeletionRequest struct {
+	PreviewID uuid.UUID `json:"preview_id" binding:"required"`
+	Confirm   string    `json:"confirm" binding:"required"`
+}
+
+// Preview counts the rows that deleting the caller's organization would
+// remove, and records the preview so it can be confirmed
+func (s *OrgDeletionService) Preview(ctx context.Context, ac AuditContext) (*models.OrgDeletion, error) {
+	org, err := s.orgRepo.GetOrganization(ctx, ac.OrgID)
+	if err != nil {
+		return nil, err
+	}
+	if org == nil {
+		return nil, ErrOrganizationNotFound
+	}
+
+	counts, err := s.repo.CountRows(ctx, org.ID)
+	if err != nil {
+		return nil, err
+	}
+	blocked, err := s.blocked(ctx, org.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	deletion := &models.OrgDeletion{
+		OrganizationID:   org.ID,
+		OrganizationName: org.Name,
+		OrganizationSlug: org.Slug,
+		RequestedByEmail: ac.UserEmail,
+		Counts:           counts,
+		ExpiresAt:        time.Now().Add(orgDeletionPreviewTTL),
+	}
+	if err := s.repo.Create(ctx, deletion); err != nil {
+		return nil, err
+	}
+	deletion.Total = countTotal(deletion.Counts)
+	deletion.Blocked = blocked
+
+	s.auditSvc.LogAction(ctx, ac, "delete_preview", "organization", org.ID, org.Name,
+		fmt.Sprintf("Previewed deleting the organization: %d rows would be removed", deletion.Total))
+	return deletion, nil
+}
+
+// Confirm queues the deletion of a preview. The organization is marked
+// deleted and its users deactivated at once; its data is deleted in the
+// background.
+func (s *OrgDeletionService) Confirm(ctx context.Context, ac AuditContext, req ConfirmOrgDeletionRequest) (*models.OrgDeletion, error) {
+	org, err := s.orgRepo.GetOrganization(ctx, ac.OrgID)
+	if err != nil {
+		return nil, err
+	}
+	if org == nil {
+		return nil, ErrOrganizationNotFound
+	}
+	if req.Confirm != org.Slug {
+		return nil, ErrOrgDeletionConfirmation
+	}
+
+	blocked, err := s.blocked(ctx, org.ID)
+	if err != nil {
+		return nil, err
+	}
+	if blocked != "" {
+		return nil, fmt.Errorf("%w: %s", ErrOrgDeletionBlocked, blocked)
+	}
+
+	deletion, err := s.Get(ctx, org.ID, req.PreviewID)
+	if err != nil {
+		return nil, err
+	}
+	confirmed, err := s.repo.Confirm(ctx, org.ID, deletion.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !confirmed {
+		return nil, ErrOrgDeletionExpired
+	}
+
+	// The entry is deleted with the organization, but reaches any SIEM
+	// forwarder first
+	s.auditSvc.LogAction(ctx, ac, "delete", "organization", org.ID, org.Name,
+		fmt.Sprintf("Confirmed deleting the organization: %d rows will be removed", deletion.Total))
+	s.logger.Infow("Organization deletion confirmed", "id", deletion.ID, "organization_id", org.ID, "by", ac.UserEmail)
+	return s.Get(ctx, org.ID, deletion.ID)
+}
+
+// Get retrieves a deletion of the organization with its status
+func (s *OrgDeletionService) Get(ctx context.Context, orgID, id uuid.UUID) (*models.OrgDeletion, error) {
+	deletion, err := s.repo.Get(ctx, orgID, id)
+	if err != nil {
+		return nil, err
+	}
+	if deletion == nil {
+		return nil, ErrOrgDeletionNotFound
+	}
+	deletion.Total = countTotal(deletion.Counts)
+	return deletion, nil
+}
+
+// blocked says why an organization cannot be deleted, or returns "" when it
+// can. Entries of the append-only audit ledger can never be deleted.
+func (s *OrgDeletionService) blocked(ctx context.Context, orgID uuid.UUID) (string, error) {
+	n, err := s.repo.CountLedgerEntries(ctx, orgID)
+	if err != nil || n == 0 {
+		return "", err
+	}
+	return fmt.Sprintf("it has %d entries in the append-only audit log ledger", n), nil
+}
+
+// ProcessPending runs the next confirmed deletion, if any. A deletion that
+// fails is recorded with its error and retried after orgDeletionRetryAfter.
+func (s *OrgDeletionService) ProcessPending(ctx context.Context) error {
+	deletion, err := s.repo.ClaimNext(ctx, orgDeletionStaleAfter, orgDeletionRetryAfter)
+	if err != nil || deletion == nil {
+		return err
+	}
+
+	result, err := s.repo.DeleteOrganization(ctx, deletion.OrganizationID, func(table string) error {
+		return s.repo.UpdateStep(ctx, deletion.ID, table)
+	})
+	if result != nil {
+		s.removeStored(ctx, result)
+	}
+	if err != nil {
+		s.logger.Warnw("Organization deletion failed", "id", deletion.ID, "organization_id", deletion.OrganizationID, "error", err)
+		return s.repo.Fail(ctx, deletion.ID, err.Error())
+	}
+
+	if err := s.repo.Complete(ctx, deletion.ID); err != nil {
+		return err
+	}
+	s.logger.Infow("Organization deleted", "id", deletion.ID, "organization_id", deletion.OrganizationID,
+		"organization", deletion.OrganizationSlug, "files", len(result.Files), "objects", len(result.ObjectKeys))
+	return nil
+}
+
+// removeStored removes the document files and stored objects of deleted
+// rows. Failures are only logged, since the rows are gone already.
+func (s *OrgDeletionService) removeStored(ctx context.Context, result *repositories.OrgDeletionResult) {
+	for _, path := range result.Files {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			s.logger.Warnw("Failed to remove document file of deleted organization", "path", path, "error", err)
+		}
+	}
+	if s.store == nil {
+		return
+	}
+	for _, key := range result.ObjectKeys {
+		if err := s.store.Delete(ctx, key); err != nil {
+			s.logger.Warnw("Failed to remove stored object of deleted organization", "key", key, "error", err)
+		}
+	}
+}
+
+// countTotal sums counts per table
+func countTotal(counts map[string]int64) int64 {
+	var total int64
+	for _, n := range counts {
+		total += n
+	}
+	return total
+}
@@ -15,6 +15,8 @@ import (
 var (
 	ErrTeamNotFound         = errors.New("team not found")
 	ErrBusinessUnitNotFound = errors.New("business unit not found")
+	ErrTeamParentNotFound   = errors.New("parent team not found")
+	ErrTeamCycle            = errors.New("a team cannot be placed under itself or one of its descendants")
 )
 
 // generateSlug creates a URL-friendly slug from a name
@@ -37,13 +39,16 @@ func generateSlug(name string) string {
 // ============================================
 
 type TeamService struct {
-	repo     *repositories.TeamRepository
-	auditSvc *AuditService
-	logger   *zap.SugaredLogger
+	repo          *repositories.TeamRepository
+	namespaceRepo *repositories.NamespaceRepository
+	auditSvc      *AuditService
+	notifications *NotificationService
+	settings      *OrgSettingsService
+	logger        *zap.SugaredLogger
 }
 
-func NewTeamService(repo *repositories.TeamRepository, auditSvc *AuditService, logger *zap.SugaredLogger) *TeamService {
-	return &TeamService{repo: repo, auditSvc: auditSvc, logger: logger}
+func NewTeamService(repo *repositories.TeamRepository, namespaceRepo *repositories.NamespaceRepository, settings *OrgSettingsService, auditSvc *AuditService, notifications *NotificationService, logger *zap.SugaredLogger) *TeamService {
+	return &TeamService{repo: repo, namespaceRepo: namespaceRepo, settings: settings, auditSvc: auditSvc, notifications: notifications, logger: logger}
 }
 
 type CreateTeamRequest struct {
@@ -53,6 +58,13 @@ type CreateTeamRequest struct {
 	TeamType     string `json:"team_type"`
 	ContactEmail string `json:"contact_email"`
 	ContactSlack string `json:"contact_slack"`
+	// PagerDutyServiceID links the team to a PagerDuty service, such as PX1Y2Z3
+	PagerDutyServiceID string `json:"pagerduty_service_id"`
+	// OpsgenieScheduleID links the team to an Opsgenie schedule by its ID
+	OpsgenieScheduleID string `json:"opsgenie_schedule_id"`
+	// ParentID places the team under another team; the nil UUID moves it to
+	// the top level
+	ParentID *uuid.UUID `json:"parent_id"`
 }
 
 func (s *TeamService) Create(ctx context.Context, ac AuditContext, req CreateTeamRequest) (*models.Team, error) {
@@ -76,9 +88,25 @@ func (s *TeamService) Create(ctx context.Context, ac AuditContext, req CreateTea
 	if req.ContactSlack != "" {
 		team.ContactSlack = models.NewNullStringFromString(req.ContactSlack)
 	}
+	if req.PagerDutyServiceID != "" {
+		team.PagerDutyServiceID = models.NewNullStringFromString(req.PagerDutyServiceID)
+	}
+	if req.OpsgenieScheduleID != "" {
+		team.OpsgenieScheduleID = models.NewNullStringFromString(req.OpsgenieScheduleID)
+	}
 	if team.TeamType == "" {
 		team.TeamType = "team"
 	}
+	if req.ParentID != nil && *req.ParentID != uuid.Nil {
+		teams, err := s.repo.List(ctx, ac.OrgID)
+		if err != nil {
+			return nil, err
+		}
+		if err := checkTeamParent(teams, uuid.Nil, *req.ParentID); err != nil {
+			return nil, err
+		}
+		team.ParentID = req.ParentID
+	}
 
 	if err := s.repo.Create(ctx, team); err != nil {
 		return nil, err
@@ -102,6 +130,45 @@ func (s *TeamService) List(ctx context.Context, orgID uuid.UUID) ([]models.Team,
 	return s.repo.List(ctx, orgID)
 }
 
+// Search finds teams by name or slug, best matches first. Names within the
+// organization's fuzzy threshold of the term match too.
+func (s *TeamService) Search(ctx context.Context, orgID uuid.UUID, term string) ([]models.Team, error) {
+	threshold, err := s.settings.FuzzyThreshold(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.Search(ctx, orgID, strings.TrimSpace(term), threshold)
+}
+
+// GetByName retrieves a team of an organization by name
+func (s *TeamService) GetByName(ctx context.Context, orgID uuid.UUID, name string) (*models.Team, error) {
+	team, err := s.repo.GetByName(ctx, orgID, name)
+	if err != nil {
+		return nil, err
+	}
+	if team == nil {
+		return nil, ErrTeamNotFound
+	}
+	return team, nil
+}
+
+// UpsertByName creates the team named name, or updates it if it exists, so
+// that teams can be managed declaratively by name. created reports whether
+// the team was created.
+func (s *TeamService) UpsertByName(ctx context.Context, ac AuditContext, name string, req CreateTeamRequest) (team *models.Team, created bool, err error) {
+	req.Name = name
+	existing, err := s.repo.GetByName(ctx, ac.OrgID, name)
+	if err != nil {
+		return nil, false, err
+	}
+	if existing == nil {
+		team, err = s.Create(ctx, ac, req)
+		return team, err == nil, err
+	}
+	team, err = s.Update(ctx, ac, existing.ID, req)
+	return team, false, err
+}
+
 func (s *TeamService) Update(ctx context.Context, ac AuditContext, id uuid.UUID, req CreateTeamRequest) (*models.Team, error) {
 	team, err := s.repo.GetByID(ctx, id)
 	if err != nil {
@@ -111,6 +178,27 @@ func (s *TeamService) Update(ctx context.Context, ac AuditContext, id uuid.UUID,
 		return nil, ErrTeamNotFound
 	}
 
+	if req.ParentID != nil && *req.ParentID != uuid.Nil {
+		teams, err := s.repo.List(ctx, team.OrganizationID)
+		if err != nil {
+			return nil, err
+		}
+		if err := checkTeamParent(teams, team.ID, *req.ParentID); err != nil {
+			return nil, err
+		}
+	}
+	applyTeamUpdate(team, req)
+
+	if err := s.repo.Update(ctx, team); err != nil {
+		return nil, err
+	}
+	s.auditSvc.LogUpdate(ctx, ac, "team", team.ID, team.Name, nil, nil)
+	return team, nil
+}
+
+// applyTeamUpdate sets the fields of team given by req. The name is always
+// set; other empty fields are left unchanged.
+func applyTeamUpdate(team *models.Team, req CreateTeamRequest) {
 	team.Name = req.Name
 	if req.Slug != "" {
 		team.Slug = req.Slug
@@ -121,25 +209,54 @@ func (s *TeamService) Update(ctx context.Context, ac AuditContext, id uuid.UUID,
 	if req.TeamType != "" {
 		team.TeamType = req.TeamType
 	}
+	if req.ParentID != nil {
+		if *req.ParentID == uuid.Nil {
+			team.ParentID = nil
+		} else {
+			parentID := *req.ParentID
+			team.ParentID = &parentID
+		}
+	}
 	if req.ContactEmail != "" {
 		team.ContactEmail = models.NewNullStringFromString(req.ContactEmail)
 	}
 	if req.ContactSlack != "" {
 		team.ContactSlack = models.NewNullStringFromString(req.ContactSlack)
 	}
-
-	if err := s.repo.Update(ctx, team); err != nil {
-		return nil, err
+	if req.PagerDutyServiceID != "" {
+		team.PagerDutyServiceID = models.NewNullStringFromString(req.PagerDutyServiceID)
+	}
+	if req.OpsgenieScheduleID != "" {
+		team.OpsgenieScheduleID = models.NewNullStringFromString(req.OpsgenieScheduleID)
 	}
-	s.auditSvc.LogUpdate(ctx, ac, "team", team.ID, team.Name, nil, nil)
-	return team, nil
 }
 
+// Delete removes a team and releases the namespaces it owned, which become orphaned
 func (s *TeamService) Delete(ctx context.Context, ac AuditContext, id uuid.UUID) error {
+	team, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
 	if err := s.repo.Delete(ctx, id); err != nil {
 		return err
 	}
 	s.auditSvc.LogDelete(ctx, ac, "team", id, "")
+
+	orphaned, err := s.namespaceRepo.ReleaseOwnerTeam(ctx, id)
+	if err != nil {
+		s.logger.Errorw("Failed to release namespaces of deleted team", "team_id", id, "error", err)
+		return nil
+	}
+	// The release is recorded on each namespace so it shows in its history
+	for _, ns := range orphaned {
+		s.auditSvc.LogUpdate(ctx, ac, "namespace", ns.ID, ns.Name,
+			map[string]interface{}{"infrastructure_owner_team_id": id.String()},
+			map[string]interface{}{"infrastructure_owner_team_id": nil})
+	}
+	if len(orphaned) > 0 {
+		s.notifications.NotifyNamespacesOrphaned(ctx, orphaned, team)
+	}
 	return nil
 }
 
@@ -163,18 +280,48 @@ func (s *TeamService) GetMembers(ctx context.Context, teamID uuid.UUID) ([]model
 	return s.repo.GetMembers(ctx, teamID)
 }
 
+// Tree returns the organization's teams as a tree, top-level teams first,
+// with the distinct members of every subtree counted for org charts
+func (s *TeamService) Tree(ctx context.Context, orgID uuid.UUID) ([]*models.TeamNode, error) {
+	teams, err := s.repo.List(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	members, err := s.repo.ListMemberIDs(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	return buildTeamTree(teams, members), nil
+}
+
+// Descendants returns the teams below a team of the organization, level by
+// level
+func (s *TeamService) Descendants(ctx context.Context, orgID, id uuid.UUID) ([]models.Team, error) {
+	teams, err := s.repo.List(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range teams {
+		if t.ID == id {
+			return teamDescendants(teams, id), nil
+		}
+	}
+	return nil, ErrTeamNotFound
+}
+
 // ============================================
 // User Service
 // ============================================
 
 type UserService struct {
-	repo     *repositories.UserRepository
-	auditSvc *AuditService
-	logger   *zap.SugaredLogger
+	repo          *repositories.UserRepository
+	auditSvc      *AuditService
+	notifications *NotificationService
+	logger        *zap.SugaredLogger
 }
 
-func NewUserService(repo *repositories.UserRepository, auditSvc *AuditService, logger *zap.SugaredLogger) *UserService {
-	return &UserService{repo: repo, auditSvc: auditSvc, logger: logger}
+func NewUserService(repo *repositories.UserRepository, auditSvc *AuditService, notifications *NotificationService, logger *zap.SugaredLogger) *UserService {
+	return &UserService{repo: repo, auditSvc: auditSvc, notifications: notifications, logger: logger}
 }
 
 type CreateUserRequest struct {
@@ -211,6 +358,7 @@ func (s *UserService) Create(ctx context.Context, ac AuditContext, req CreateUse
 		return nil, err
 	}
 	s.auditSvc.LogCreate(ctx, ac, "user", user.ID, user.Email, nil)
+	s.notifications.NotifyInvitation(ctx, user, ac.UserEmail)
 	return user, nil
 }
 
@@ -394,6 +542,49 @@ func (s *BusinessUnitService) List(ctx context.Context, orgID uuid.UUID) ([]mode
 	return s.repo.List(ctx, orgID)
 }
 
+// Tree returns the organization's business units as a tree, top-level units
+// first, with statistics of each unit's namespaces and of its whole subtree
+func (s *BusinessUnitService) Tree(ctx context.Context, orgID uuid.UUID) ([]*models.BusinessUnitTreeNode, error) {
+	units, err := s.repo.List(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	counts, err := s.repo.ListNamespaceCounts(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	return buildBusinessUnitTree(units, counts), nil
+}
+
+// GetByName retrieves a business unit of an organization by name
+func (s *BusinessUnitService) GetByName(ctx context.Context, orgID uuid.UUID, name string) (*models.BusinessUnit, error) {
+	bu, err := s.repo.GetByName(ctx, orgID, name)
+	if err != nil {
+		return nil, err
+	}
+	if bu == nil {
+		return nil, ErrBusinessUnitNotFound
+	}
+	return bu, nil
+}
+
+// UpsertByName creates the business unit named name, or updates it if it
+// exists, so that business units can be managed declaratively by name.
+// created reports whether the business unit was created.
+func (s *BusinessUnitService) UpsertByName(ctx context.Context, ac AuditContext, name string, req CreateBusinessUnitRequest) (bu *models.BusinessUnit, created bool, err error) {
+	req.Name = name
+	existing, err := s.repo.GetByName(ctx, ac.OrgID, name)
+	if err != nil {
+		return nil, false, err
+	}
+	if existing == nil {
+		bu, err = s.Create(ctx, ac, req)
+		return bu, err == nil, err
+	}
+	bu, err = s.Update(ctx, ac, existing.ID, req)
+	return bu, false, err
+}
+
 func (s *BusinessUnitService) Update(ctx context.Context, ac AuditContext, id uuid.UUID, req CreateBusinessUnitRequest) (*models.BusinessUnit, error) {
 	bu, err := s.repo.GetByID(ctx, id)
 	if err != nil {
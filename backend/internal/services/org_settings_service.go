@@ -0,0 +1,652 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/kubeatlas/kubeatlas/internal/database/repositories"
+	"github.com/kubeatlas/kubeatlas/internal/models"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrOrganizationNotFound = errors.New("organization not found")
+	ErrUnknownSetting       = errors.New("unknown setting")
+	ErrInvalidSetting       = errors.New("invalid setting")
+)
+
+// ============================================
+// Setting Keys
+// ============================================
+
+// SettingKey is a typed organization setting stored as JSON under Name in
+// organizations.settings. Stored fields are decoded over Default, so fields
+// added later keep their default until they are set.
+type SettingKey[T any] struct {
+	Name     string
+	Default  T
+	Validate func(*T) error
+}
+
+// value decodes the key from stored settings. Values that no longer decode
+// or validate fall back to the default, which is returned with the error.
+func (k SettingKey[T]) value(settings models.JSONMap) (T, error) {
+	raw, ok := settings[k.Name]
+	if !ok || raw == nil {
+		return k.Default, nil
+	}
+	encoded, err := json.Marshal(raw)
+	if err != nil {
+		return k.Default, err
+	}
+	v, err := k.decode(k.Default, encoded, false)
+	if err != nil {
+		return k.Default, err
+	}
+	return v, nil
+}
+
+// merge decodes a partial update over the stored value of the key
+func (k SettingKey[T]) merge(settings models.JSONMap, patch json.RawMessage) (interface{}, error) {
+	current, _ := k.value(settings)
+	return k.decode(current, patch, true)
+}
+
+// decode decodes encoded over base and validates the result. Strict
+// decoding rejects unknown fields.
+func (k SettingKey[T]) decode(base T, encoded []byte, strict bool) (T, error) {
+	// Decode into a copy of base, since decoding reuses the backing arrays
+	// of slices and would otherwise write into the default
+	var v T
+	if copied, err := json.Marshal(base); err == nil {
+		_ = json.Unmarshal(copied, &v)
+	}
+	dec := json.NewDecoder(bytes.NewReader(encoded))
+	if strict {
+		dec.DisallowUnknownFields()
+	}
+	if err := dec.Decode(&v); err != nil {
+		return base, fmt.Errorf("%w %s: %v", ErrInvalidSetting, k.Name, err)
+	}
+	if err := k.validate(&v); err != nil {
+		return base, err
+	}
+	return v, nil
+}
+
+func (k SettingKey[T]) validate(v *T) error {
+	if k.Validate == nil {
+		return nil
+	}
+	if err := k.Validate(v); err != nil {
+		return fmt.Errorf("%w %s: %w", ErrInvalidSetting, k.Name, err)
+	}
+	return nil
+}
+
+func (k SettingKey[T]) settingName() string {
+	return k.Name
+}
+
+func (k SettingKey[T]) settingValue(settings models.JSONMap) interface{} {
+	v, _ := k.value(settings)
+	return v
+}
+
+// settingDefinition is a SettingKey of any type
+type settingDefinition interface {
+	settingName() string
+	settingValue(settings models.JSONMap) interface{}
+	merge(settings models.JSONMap, patch json.RawMessage) (interface{}, error)
+}
+
+// organizationSettings are the settings managed through the generic
+// settings API. Settings holding credentials (smtp, slack, teams, ldap) have
+// their own endpoints and are never returned here.
+var organizationSettings = []settingDefinition{
+	SyncAlertsSetting,
+	DigestSetting,
+	UploadsSetting,
+	RetentionSetting,
+	EnvironmentsSetting,
+	CriticalitySetting,
+	BrandingSetting,
+	AccessAuditSetting,
+	AuditStorageSetting,
+	MetadataRequirementsSetting,
+	MetadataMappingsSetting,
+	MetadataBackupSetting,
+	SearchSetting,
+	KubernetesVersionsSetting,
+}
+
+func lookupSetting(name string) settingDefinition {
+	for _, def := range organizationSettings {
+		if def.settingName() == name {
+			return def
+		}
+	}
+	return nil
+}
+
+// RetentionSettings control how long history is kept, stored in
+// organizations.settings["retention"]. A value of 0 keeps records forever.
+type RetentionSettings struct {
+	// DeliveryHistoryDays keeps finished notification and webhook deliveries
+	DeliveryHistoryDays int `json:"delivery_history_days"`
+	// AuditLogDays keeps audit log entries
+	AuditLogDays int `json:"audit_log_days"`
+	// DeletedRecordDays keeps soft-deleted records before they are purged
+	DeletedRecordDays int `json:"deleted_record_days"`
+	// DocumentVersions is the number of versions kept of each document
+	DocumentVersions int `json:"document_versions"`
+	// DryRun only reports in the audit log what would be purged
+	DryRun bool `json:"dry_run"`
+}
+
+func (r *RetentionSettings) validate() error {
+	if r.DeliveryHistoryDays < 0 || r.DeliveryHistoryDays > 3650 {
+		return errors.New("delivery_history_days must be between 0 and 3650")
+	}
+	if r.AuditLogDays < 0 || r.AuditLogDays > 3650 {
+		return errors.New("audit_log_days must be between 0 and 3650")
+	}
+	if r.DeletedRecordDays < 0 || r.DeletedRecordDays > 3650 {
+		return errors.New("deleted_record_days must be between 0 and 3650")
+	}
+	if r.DocumentVersions < 0 || r.DocumentVersions > 1000 {
+		return errors.New("document_versions must be between 0 and 1000")
+	}
+	return nil
+}
+
+// cutoffs returns the cutoff of every cutoff-based retention category that is
+// enforced, relative to now
+func (r RetentionSettings) cutoffs(now time.Time) map[string]time.Time {
+	cutoffs := make(map[string]time.Time)
+	set := func(days int, categories ...string) {
+		if days <= 0 {
+			return
+		}
+		for _, category := range categories {
+			cutoffs[category] = now.AddDate(0, 0, -days)
+		}
+	}
+
+	set(r.DeliveryHistoryDays, repositories.RetentionNotificationDeliveries, repositories.RetentionWebhookDeliveries)
+	set(r.AuditLogDays, repositories.RetentionAuditLogs)
+	set(r.DeletedRecordDays,
+		repositories.RetentionInternalDependencies,
+		repositories.RetentionExternalDependencies,
+		repositories.RetentionDocuments,
+		repositories.RetentionWebhookSubscriptions,
+		repositories.RetentionNamespaces,
+		repositories.RetentionClusters,
+		repositories.RetentionTeams,
+	)
+	return cutoffs
+}
+
+// RetentionSetting keeps delivery history for 30 days and everything else
+// forever by default
+var RetentionSetting = SettingKey[RetentionSettings]{
+	Name:     "retention",
+	Default:  RetentionSettings{DeliveryHistoryDays: 30},
+	Validate: (*RetentionSettings).validate,
+}
+
+// EnvironmentSettings list the environments clusters and namespaces can be
+// assigned to, stored in organizations.settings["environments"]
+type EnvironmentSettings struct {
+	Values []string `json:"values"`
+}
+
+func (e *EnvironmentSettings) validate() error {
+	if len(e.Values) == 0 {
+		return errors.New("at least one environment is required")
+	}
+	seen := make(map[string]bool, len(e.Values))
+	for i, v := range e.Values {
+		v = strings.ToLower(strings.TrimSpace(v))
+		if !models.IsValidEnvironmentName(v) {
+			return fmt.Errorf("invalid environment name %q", v)
+		}
+		if seen[v] {
+			return fmt.Errorf("duplicate environment %q", v)
+		}
+		seen[v] = true
+		e.Values[i] = v
+	}
+	return nil
+}
+
+// Allows reports whether env is one of the environments
+func (e *EnvironmentSettings) Allows(env string) bool {
+	for _, v := range e.Values {
+		if v == env {
+			return true
+		}
+	}
+	return false
+}
+
+// distribution orders namespace counts by environment as configured,
+// including environments without namespaces. Environments that are no
+// longer configured follow in their original order.
+func (e *EnvironmentSettings) distribution(counts []models.EnvironmentDistribution) []models.EnvironmentDistribution {
+	byEnv := make(map[string]int, len(counts))
+	for _, d := range counts {
+		byEnv[d.Environment] = d.Count
+	}
+
+	result := make([]models.EnvironmentDistribution, 0, len(e.Values)+len(counts))
+	for _, v := range e.Values {
+		result = append(result, models.EnvironmentDistribution{Environment: v, Count: byEnv[v]})
+	}
+	for _, d := range counts {
+		if !e.Allows(d.Environment) {
+			result = append(result, d)
+		}
+	}
+	return result
+}
+
+// EnvironmentsSetting defaults to the environments KubeAtlas always had
+var EnvironmentsSetting = SettingKey[EnvironmentSettings]{
+	Name:     "environments",
+	Default:  EnvironmentSettings{Values: []string{"production", "staging", "development", "test"}},
+	Validate: (*EnvironmentSettings).validate,
+}
+
+// CriticalitySettings define the criticality tiers namespaces can be
+// assigned to, most critical first, stored in
+// organizations.settings["criticality"]
+type CriticalitySettings struct {
+	Tiers []models.CriticalityTier `json:"tiers"`
+}
+
+func (c *CriticalitySettings) validate() error {
+	if len(c.Tiers) == 0 {
+		return errors.New("at least one criticality tier is required")
+	}
+	seen := make(map[string]bool, len(c.Tiers))
+	for i := range c.Tiers {
+		t := &c.Tiers[i]
+		t.Name = strings.ToLower(strings.TrimSpace(t.Name))
+		if !models.IsValidCriticalityName(t.Name) {
+			return fmt.Errorf("invalid criticality tier name %q", t.Name)
+		}
+		if seen[t.Name] {
+			return fmt.Errorf("duplicate criticality tier %q", t.Name)
+		}
+		seen[t.Name] = true
+	}
+	return nil
+}
+
+// CriticalitySetting defaults to the tiers KubeAtlas always had
+var CriticalitySetting = SettingKey[CriticalitySettings]{
+	Name:     "criticality",
+	Default:  CriticalitySettings{Tiers: models.DefaultCriticalityTiers},
+	Validate: (*CriticalitySettings).validate,
+}
+
+// hexColorRegex matches colors such as #1d4ed8
+var hexColorRegex = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+// BrandingSettings white-label the UI, stored in
+// organizations.settings["branding"]. Empty colors keep the UI's own theme.
+type BrandingSettings struct {
+	ProductName  string `json:"product_name"`
+	LogoURL      string `json:"logo_url"`
+	PrimaryColor string `json:"primary_color"`
+	AccentColor  string `json:"accent_color"`
+	// ColorScheme is light, dark or system
+	ColorScheme string `json:"color_scheme"`
+}
+
+func (b *BrandingSettings) validate() error {
+	b.ProductName = strings.TrimSpace(b.ProductName)
+	if b.ProductName == "" || len(b.ProductName) > 64 {
+		return errors.New("product_name must be 1-64 characters")
+	}
+	if b.LogoURL != "" && !strings.HasPrefix(b.LogoURL, "/") {
+		u, err := url.Parse(b.LogoURL)
+		if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+			return errors.New("logo_url must be an http(s) URL or an absolute path")
+		}
+	}
+	for name, color := range map[string]string{"primary_color": b.PrimaryColor, "accent_color": b.AccentColor} {
+		if color != "" && !hexColorRegex.MatchString(color) {
+			return fmt.Errorf("%s must be a hex color such as #1d4ed8", name)
+		}
+	}
+	switch b.ColorScheme {
+	case "light", "dark", "system":
+	default:
+		return errors.New("color_scheme must be light, dark or system")
+	}
+	return nil
+}
+
+// BrandingSetting shows KubeAtlas in the user's color scheme by default
+var BrandingSetting = SettingKey[BrandingSettings]{
+	Name:     "branding",
+	Default:  BrandingSettings{ProductName: "KubeAtlas", ColorScheme: "system"},
+	Validate: (*BrandingSettings).validate,
+}
+
+// SearchSettings tune free-text search of namespaces and teams, stored in
+// organizations.settings["search"]
+type SearchSettings struct {
+	// FuzzyThreshold is the trigram word similarity, from 0 to 1, a search
+	// term needs to match a name it is not part of, so typos still find it.
+	// 0 turns fuzzy matching off.
+	FuzzyThreshold float64 `json:"fuzzy_threshold"`
+}
+
+func (s *SearchSettings) validate() error {
+	if s.FuzzyThreshold < 0 || s.FuzzyThreshold > 1 {
+		return errors.New("fuzzy_threshold must be between 0 and 1")
+	}
+	return nil
+}
+
+// SearchSetting uses the default threshold of pg_trgm's <% operator, which
+// lets "paymets" find "payments-api"
+var SearchSetting = SettingKey[SearchSettings]{
+	Name:     "search",
+	Default:  SearchSettings{FuzzyThreshold: 0.6},
+	Validate: (*SearchSettings).validate,
+}
+
+// ============================================
+// Organization Settings Service
+// ============================================
+
+// OrgSettingsService reads and writes organization profiles and settings
+type OrgSettingsService struct {
+	repo     *repositories.OrgSettingsRepository
+	auditSvc *AuditService
+	logger   *zap.SugaredLogger
+}
+
+// NewOrgSettingsService creates a new organization settings service
+func NewOrgSettingsService(repo *repositories.OrgSettingsRepository, auditSvc *AuditService, logger *zap.SugaredLogger) *OrgSettingsService {
+	return &OrgSettingsService{repo: repo, auditSvc: auditSvc, logger: logger}
+}
+
+// GetSetting returns the organization's value of key, or its default when
+// it is not set or the stored value is invalid
+func GetSetting[T any](ctx context.Context, s *OrgSettingsService, orgID uuid.UUID, key SettingKey[T]) (T, error) {
+	settings, err := s.repo.Get(ctx, orgID)
+	if err != nil {
+		return key.Default, err
+	}
+	v, err := key.value(settings)
+	if err != nil {
+		s.logger.Warnw("Ignoring invalid organization setting", "organization_id", orgID, "key", key.Name, "error", err)
+	}
+	return v, nil
+}
+
+// UpdateSetting validates and stores the organization's value of key
+func UpdateSetting[T any](ctx context.Context, s *OrgSettingsService, ac AuditContext, key SettingKey[T], value T) (T, error) {
+	if err := key.validate(&value); err != nil {
+		return value, err
+	}
+
+	settings, err := s.repo.Get(ctx, ac.OrgID)
+	if err != nil {
+		return value, err
+	}
+	old, _ := key.value(settings)
+
+	if err := s.set(ctx, ac, key.Name, old, value); err != nil {
+		return value, err
+	}
+	return value, nil
+}
+
+// Environments returns the organization's environments
+func (s *OrgSettingsService) Environments(ctx context.Context, orgID uuid.UUID) ([]string, error) {
+	cfg, err := GetSetting(ctx, s, orgID, EnvironmentsSetting)
+	if err != nil {
+		return nil, err
+	}
+	return cfg.Values, nil
+}
+
+// ValidateEnvironment returns ErrInvalidEnvironment unless env is one of the
+// organization's environments
+func (s *OrgSettingsService) ValidateEnvironment(ctx context.Context, orgID uuid.UUID, env string) error {
+	cfg, err := GetSetting(ctx, s, orgID, EnvironmentsSetting)
+	if err != nil {
+		return err
+	}
+	if !cfg.Allows(env) {
+		return fmt.Errorf("%w: must be one of %s", ErrInvalidEnvironment, strings.Join(cfg.Values, ", "))
+	}
+	return nil
+}
+
+// Branding returns the branding of the organization with the given slug, or
+// of orgID when slug is empty. Organizations without a branding logo show
+// their profile logo.
+func (s *OrgSettingsService) Branding(ctx context.Context, orgID uuid.UUID, slug string) (*BrandingSettings, error) {
+	var org *models.Organization
+	var err error
+	if slug != "" {
+		org, err = s.repo.GetOrganizationBySlug(ctx, slug)
+	} else {
+		org, err = s.repo.GetOrganization(ctx, orgID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if org == nil {
+		return nil, ErrOrganizationNotFound
+	}
+
+	branding, err := BrandingSetting.value(org.Settings)
+	if err != nil {
+		s.logger.Warnw("Ignoring invalid organization setting", "organization_id", org.ID, "key", BrandingSetting.Name, "error", err)
+	}
+	if branding.LogoURL == "" {
+		branding.LogoURL = org.LogoURL.ValueOrEmpty()
+	}
+	return &branding, nil
+}
+
+// CriticalityTiers returns the organization's criticality tiers, most
+// critical first
+func (s *OrgSettingsService) CriticalityTiers(ctx context.Context, orgID uuid.UUID) ([]models.CriticalityTier, error) {
+	cfg, err := GetSetting(ctx, s, orgID, CriticalitySetting)
+	if err != nil {
+		return nil, err
+	}
+	return cfg.Tiers, nil
+}
+
+// FuzzyThreshold returns the similarity a name needs to fuzzily match a
+// search term, 0 when fuzzy matching is off
+func (s *OrgSettingsService) FuzzyThreshold(ctx context.Context, orgID uuid.UUID) (float64, error) {
+	cfg, err := GetSetting(ctx, s, orgID, SearchSetting)
+	if err != nil {
+		return 0, err
+	}
+	return cfg.FuzzyThreshold, nil
+}
+
+// EnvironmentDistribution orders namespace counts by the organization's
+// environments, including those without namespaces
+func (s *OrgSettingsService) EnvironmentDistribution(ctx context.Context, orgID uuid.UUID, counts []models.EnvironmentDistribution) ([]models.EnvironmentDistribution, error) {
+	cfg, err := GetSetting(ctx, s, orgID, EnvironmentsSetting)
+	if err != nil {
+		return nil, err
+	}
+	return cfg.distribution(counts), nil
+}
+
+// OrganizationSettings is an organization's profile and its settings
+type OrganizationSettings struct {
+	ID          uuid.UUID              `json:"id"`
+	Name        string                 `json:"name"`
+	Slug        string                 `json:"slug"`
+	Description string                 `json:"description"`
+	LogoURL     string                 `json:"logo_url"`
+	Settings    map[string]interface{} `json:"settings"`
+}
+
+// UpdateOrganizationSettingsRequest updates an organization's profile and
+// settings. Empty profile fields are left alone; each setting is merged into
+// its stored value.
+type UpdateOrganizationSettingsRequest struct {
+	Name        string                     `json:"name"`
+	Description string                     `json:"description"`
+	LogoURL     string                     `json:"logo_url"`
+	Settings    map[string]json.RawMessage `json:"settings"`
+}
+
+// Get returns the organization's profile and every known setting, with
+// defaults for those that are not set
+func (s *OrgSettingsService) Get(ctx context.Context, orgID uuid.UUID) (*OrganizationSettings, error) {
+	org, err := s.repo.GetOrganization(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	if org == nil {
+		return nil, ErrOrganizationNotFound
+	}
+
+	result := &OrganizationSettings{
+		ID:          org.ID,
+		Name:        org.Name,
+		Slug:        org.Slug,
+		Description: org.Description.ValueOrEmpty(),
+		LogoURL:     org.LogoURL.ValueOrEmpty(),
+		Settings:    make(map[string]interface{}, len(organizationSettings)),
+	}
+	for _, def := range organizationSettings {
+		result.Settings[def.settingName()] = def.settingValue(org.Settings)
+	}
+	return result, nil
+}
+
+// Update applies a settings update. Every setting is validated before any
+// is stored.
+func (s *OrgSettingsService) Update(ctx context.Context, ac AuditContext, req UpdateOrganizationSettingsRequest) (*OrganizationSettings, error) {
+	org, err := s.repo.GetOrganization(ctx, ac.OrgID)
+	if err != nil {
+		return nil, err
+	}
+	if org == nil {
+		return nil, ErrOrganizationNotFound
+	}
+
+	names := make([]string, 0, len(req.Settings))
+	for name := range req.Settings {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	updates := make(map[string]interface{}, len(names))
+	for _, name := range names {
+		def := lookupSetting(name)
+		if def == nil {
+			return nil, fmt.Errorf("%w: %q", ErrUnknownSetting, name)
+		}
+		v, err := def.merge(org.Settings, req.Settings[name])
+		if err != nil {
+			return nil, err
+		}
+		updates[name] = v
+	}
+
+	// The profile and every setting are stored in one transaction, so a
+	// failed write leaves the organization as it was
+	oldProfile := profileAuditValues(org)
+	profileChanged := applyProfile(org, req)
+	err = s.repo.RunInTx(ctx, func(tx pgx.Tx) error {
+		txRepo := repositories.NewOrgSettingsRepository(tx)
+		if profileChanged {
+			if err := txRepo.UpdateProfile(ctx, org); err != nil {
+				return err
+			}
+		}
+		for _, name := range names {
+			if err := txRepo.Set(ctx, ac.OrgID, name, updates[name]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if profileChanged {
+		s.auditSvc.LogUpdate(ctx, ac, "organization", org.ID, org.Name, oldProfile, profileAuditValues(org))
+	}
+	for _, name := range names {
+		oldValue := settingAuditValues(lookupSetting(name).settingValue(org.Settings))
+		s.auditSvc.LogUpdate(ctx, ac, "organization_settings", ac.OrgID, name, oldValue, settingAuditValues(updates[name]))
+	}
+
+	return s.Get(ctx, ac.OrgID)
+}
+
+// applyProfile copies the profile fields set in req onto org and reports
+// whether any was set
+func applyProfile(org *models.Organization, req UpdateOrganizationSettingsRequest) bool {
+	if req.Name == "" && req.Description == "" && req.LogoURL == "" {
+		return false
+	}
+	if name := strings.TrimSpace(req.Name); name != "" {
+		org.Name = name
+	}
+	if req.Description != "" {
+		org.Description = models.NewNullStringFromString(req.Description)
+	}
+	if req.LogoURL != "" {
+		org.LogoURL = models.NewNullStringFromString(req.LogoURL)
+	}
+	return true
+}
+
+func profileAuditValues(org *models.Organization) map[string]interface{} {
+	return map[string]interface{}{
+		"name":        org.Name,
+		"description": org.Description.ValueOrEmpty(),
+		"logo_url":    org.LogoURL.ValueOrEmpty(),
+	}
+}
+
+// set stores one setting and records the change in the audit log
+func (s *OrgSettingsService) set(ctx context.Context, ac AuditContext, name string, oldValue, newValue interface{}) error {
+	if err := s.repo.Set(ctx, ac.OrgID, name, newValue); err != nil {
+		return err
+	}
+	s.auditSvc.LogUpdate(ctx, ac, "organization_settings", ac.OrgID, name, settingAuditValues(oldValue), settingAuditValues(newValue))
+	return nil
+}
+
+// settingAuditValues flattens a setting into the map the audit log stores
+func settingAuditValues(v interface{}) map[string]interface{} {
+	values := make(map[string]interface{})
+	encoded, err := json.Marshal(v)
+	if err == nil {
+		_ = json.Unmarshal(encoded, &values)
+	}
+	return values
+}
@@ -0,0 +1,359 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/kubeatlas/kubeatlas/internal/database/repositories"
+	"github.com/kubeatlas/kubeatlas/internal/models"
+)
+
+func TestSettingKeyValue(t *testing.T) {
+	got, err := SyncAlertsSetting.value(models.JSONMap{})
+	if err != nil || got != SyncAlertsSetting.Default {
+		t.Errorf("unset value = %+v, %v, want default", got, err)
+	}
+
+	// Stored fields are decoded over the default
+	got, err = SyncAlertsSetting.value(models.JSONMap{
+		"sync_alerts": map[string]interface{}{"failure_threshold": float64(3)},
+	})
+	if err != nil {
+		t.Fatalf("value failed: %v", err)
+	}
+	if got.FailureThreshold != 3 || !got.SuppressRepeats {
+		t.Errorf("partial value = %+v", got)
+	}
+
+	// Invalid stored values fall back to the default
+	got, err = SyncAlertsSetting.value(models.JSONMap{
+		"sync_alerts": map[string]interface{}{"failure_threshold": float64(0)},
+	})
+	if !errors.Is(err, ErrInvalidSetting) || got != SyncAlertsSetting.Default {
+		t.Errorf("invalid value = %+v, %v, want default and ErrInvalidSetting", got, err)
+	}
+}
+
+func TestSettingKeyMerge(t *testing.T) {
+	stored := models.JSONMap{
+		"retention": map[string]interface{}{"delivery_history_days": float64(90)},
+	}
+
+	v, err := RetentionSetting.merge(stored, json.RawMessage(`{}`))
+	if err != nil {
+		t.Fatalf("merge failed: %v", err)
+	}
+	if got := v.(RetentionSettings); got.DeliveryHistoryDays != 90 {
+		t.Errorf("empty patch = %+v, want stored value", got)
+	}
+
+	v, err = RetentionSetting.merge(stored, json.RawMessage(`{"delivery_history_days": 7}`))
+	if err != nil {
+		t.Fatalf("merge failed: %v", err)
+	}
+	if got := v.(RetentionSettings); got.DeliveryHistoryDays != 7 {
+		t.Errorf("patched value = %+v", got)
+	}
+
+	tests := []struct {
+		name  string
+		patch string
+	}{
+		{"unknown field", `{"days": 7}`},
+		{"wrong type", `{"delivery_history_days": "7"}`},
+		{"out of range", `{"delivery_history_days": -1}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := RetentionSetting.merge(stored, json.RawMessage(tt.patch)); !errors.Is(err, ErrInvalidSetting) {
+				t.Errorf("merge(%s) error = %v, want ErrInvalidSetting", tt.patch, err)
+			}
+		})
+	}
+}
+
+func TestSettingKeyValidateWrapsCause(t *testing.T) {
+	cfg := SyncAlertSettings{FailureThreshold: 0}
+	err := SyncAlertsSetting.validate(&cfg)
+	if !errors.Is(err, ErrInvalidSetting) || !errors.Is(err, ErrInvalidSyncAlertSettings) {
+		t.Errorf("validate error = %v, want ErrInvalidSetting and ErrInvalidSyncAlertSettings", err)
+	}
+}
+
+func TestRetentionSettingsCutoffs(t *testing.T) {
+	now := time.Date(2024, 3, 31, 2, 0, 0, 0, time.UTC)
+
+	r := RetentionSettings{DeliveryHistoryDays: 30, AuditLogDays: 365}
+	got := r.cutoffs(now)
+	if want := now.AddDate(0, 0, -30); !got[repositories.RetentionWebhookDeliveries].Equal(want) {
+		t.Errorf("webhook_deliveries cutoff = %v, want %v", got[repositories.RetentionWebhookDeliveries], want)
+	}
+	if want := now.AddDate(0, 0, -365); !got[repositories.RetentionAuditLogs].Equal(want) {
+		t.Errorf("audit_logs cutoff = %v, want %v", got[repositories.RetentionAuditLogs], want)
+	}
+	// Soft-deleted records are kept forever
+	if _, ok := got[repositories.RetentionNamespaces]; ok {
+		t.Errorf("namespaces have a cutoff with deleted_record_days 0")
+	}
+
+	r.DeletedRecordDays = 90
+	got = r.cutoffs(now)
+	for _, category := range []string{repositories.RetentionDocuments, repositories.RetentionClusters, repositories.RetentionTeams} {
+		if want := now.AddDate(0, 0, -90); !got[category].Equal(want) {
+			t.Errorf("%s cutoff = %v, want %v", category, got[category], want)
+		}
+	}
+	// Document versions are not cutoff-based
+	if _, ok := got[repositories.RetentionDocumentVersions]; ok {
+		t.Errorf("document_versions has a cutoff")
+	}
+
+	for _, bad := range []RetentionSettings{
+		{AuditLogDays: -1},
+		{DeletedRecordDays: 3651},
+		{DocumentVersions: 1001},
+	} {
+		if err := bad.validate(); err == nil {
+			t.Errorf("validate(%+v) succeeded", bad)
+		}
+	}
+}
+
+func TestLookupSetting(t *testing.T) {
+	for _, name := range []string{"sync_alerts", "digest", "uploads", "retention"} {
+		if lookupSetting(name) == nil {
+			t.Errorf("lookupSetting(%q) = nil", name)
+		}
+	}
+	// Credentials are only managed through their own endpoints
+	for _, name := range []string{"smtp", "slack", "teams", "ldap"} {
+		if lookupSetting(name) != nil {
+			t.Errorf("lookupSetting(%q) found a setting", name)
+		}
+	}
+}
+
+func TestUploadSettings(t *testing.T) {
+	u := UploadSettings{
+		MaxSizeMB:         1,
+		AllowedMIMETypes:  []string{"application/pdf", " Image/* "},
+		BlockedExtensions: []string{"EXE", " .sh "},
+	}
+	if err := u.validate(); err != nil {
+		t.Fatalf("validate failed: %v", err)
+	}
+	if u.AllowedMIMETypes[1] != "image/*" || u.BlockedExtensions[0] != ".exe" || u.BlockedExtensions[1] != ".sh" {
+		t.Errorf("normalized settings = %+v", u)
+	}
+
+	tests := []struct {
+		name     string
+		filename string
+		size     int64
+		mimeType string
+		want     error
+	}{
+		{"allowed type", "runbook.pdf", 1 << 20, "application/pdf", nil},
+		{"wildcard type", "diagram.png", 10, "image/png", nil},
+		{"too large", "runbook.pdf", 1<<20 + 1, "application/pdf", ErrFileTooLarge},
+		{"blocked extension", "setup.SH", 10, "text/x-shellscript", ErrFileTypeNotAllowed},
+		{"type not allowed", "notes.txt", 10, "text/plain", ErrFileTypeNotAllowed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := u.allows(tt.filename, tt.size, tt.mimeType)
+			if !errors.Is(err, tt.want) {
+				t.Errorf("allows(%q, %d, %q) = %v, want %v", tt.filename, tt.size, tt.mimeType, err, tt.want)
+			}
+		})
+	}
+
+	unrestricted := UploadSettings{MaxSizeMB: 32}
+	if err := unrestricted.allows("setup.sh", 10, "text/plain"); err != nil {
+		t.Errorf("no restrictions: allows(setup.sh) = %v", err)
+	}
+
+	for _, bad := range []UploadSettings{
+		{MaxSizeMB: 0},
+		{MaxSizeMB: MaxUploadSizeMB + 1},
+		{MaxSizeMB: 1, AllowedMIMETypes: []string{"pdf"}},
+		{MaxSizeMB: 1, AllowedMIMETypes: []string{"*/*"}},
+		{MaxSizeMB: 1, BlockedExtensions: []string{"."}},
+	} {
+		if err := bad.validate(); err == nil {
+			t.Errorf("validate(%+v) succeeded", bad)
+		}
+	}
+}
+
+func TestUploadsSettingMergeKeepsDefault(t *testing.T) {
+	want := append([]string(nil), UploadsSetting.Default.BlockedExtensions...)
+
+	v, err := UploadsSetting.merge(models.JSONMap{}, json.RawMessage(`{"blocked_extensions": ["iso"]}`))
+	if err != nil {
+		t.Fatalf("merge failed: %v", err)
+	}
+	if got := v.(UploadSettings).BlockedExtensions; len(got) != 1 || got[0] != ".iso" {
+		t.Errorf("merged blocked_extensions = %v", got)
+	}
+	if got := UploadsSetting.Default.BlockedExtensions; len(got) != len(want) || got[0] != want[0] {
+		t.Errorf("default blocked_extensions changed to %v", got)
+	}
+}
+
+func TestDetectMIMEType(t *testing.T) {
+	tests := []struct {
+		filename string
+		head     string
+		want     string
+	}{
+		{"runbook.pdf", "%PDF-1.7", "application/pdf"},
+		// Sniffed content wins over a misleading extension
+		{"diagram.pdf", "\x89PNG\r\n\x1a\n", "image/png"},
+		{"notes.txt", "plain notes", "text/plain"},
+		{"archive.bin", "\x00\x01\x02", "application/octet-stream"},
+	}
+	for _, tt := range tests {
+		if got := detectMIMEType(tt.filename, []byte(tt.head)); got != tt.want {
+			t.Errorf("detectMIMEType(%q) = %q, want %q", tt.filename, got, tt.want)
+		}
+	}
+}
+
+func TestEnvironmentSettings(t *testing.T) {
+	e := EnvironmentSettings{Values: []string{" Production ", "dr", "uat"}}
+	if err := e.validate(); err != nil {
+		t.Fatalf("validate failed: %v", err)
+	}
+	if e.Values[0] != "production" {
+		t.Errorf("normalized values = %v", e.Values)
+	}
+	if !e.Allows("dr") || e.Allows("staging") {
+		t.Errorf("Allows() disagrees with values %v", e.Values)
+	}
+
+	for _, bad := range [][]string{
+		nil,
+		{"prod", "prod"},
+		{"has space"},
+		{"-leading-dash"},
+	} {
+		e := EnvironmentSettings{Values: bad}
+		if err := e.validate(); err == nil {
+			t.Errorf("validate(%v) succeeded", bad)
+		}
+	}
+}
+
+func TestEnvironmentDistribution(t *testing.T) {
+	e := EnvironmentSettings{Values: []string{"production", "dr", "sandbox"}}
+	got := e.distribution([]models.EnvironmentDistribution{
+		{Environment: "staging", Count: 7},
+		{Environment: "production", Count: 5},
+		{Environment: "unknown", Count: 1},
+	})
+
+	want := []models.EnvironmentDistribution{
+		{Environment: "production", Count: 5},
+		{Environment: "dr", Count: 0},
+		{Environment: "sandbox", Count: 0},
+		{Environment: "staging", Count: 7},
+		{Environment: "unknown", Count: 1},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("distribution() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("distribution()[%d] = %v, want %v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestCriticalitySettings(t *testing.T) {
+	c := CriticalitySettings{Tiers: []models.CriticalityTier{
+		{Name: " Gold ", SLOAvailability: "99.95%", SLORTO: "1h"},
+		{Name: "silver"},
+	}}
+	if err := c.validate(); err != nil {
+		t.Fatalf("validate failed: %v", err)
+	}
+	if c.Tiers[0].Name != "gold" || c.Tiers[0].SLOAvailability != "99.95%" {
+		t.Errorf("normalized tiers = %+v", c.Tiers)
+	}
+
+	for _, bad := range [][]models.CriticalityTier{
+		nil,
+		{{Name: "gold"}, {Name: "GOLD"}},
+		{{Name: ""}},
+		{{Name: "tier 1"}},
+	} {
+		c := CriticalitySettings{Tiers: bad}
+		if err := c.validate(); err == nil {
+			t.Errorf("validate(%+v) succeeded", bad)
+		}
+	}
+
+	// Stored tiers replace the default tiers rather than merging with them
+	v, err := CriticalitySetting.merge(models.JSONMap{}, json.RawMessage(`{"tiers": [{"name": "gold"}]}`))
+	if err != nil {
+		t.Fatalf("merge failed: %v", err)
+	}
+	if got := v.(CriticalitySettings).Tiers; len(got) != 1 || got[0].Name != "gold" {
+		t.Errorf("merged tiers = %+v", got)
+	}
+	if got := CriticalitySetting.Default.Tiers; len(got) != 3 || got[0].Name != "tier-1" {
+		t.Errorf("default tiers changed to %+v", got)
+	}
+}
+
+func TestBrandingSettings(t *testing.T) {
+	b := BrandingSettings{ProductName: " Acme Atlas ", LogoURL: "https://cdn.acme.test/logo.svg", PrimaryColor: "#1D4ED8", ColorScheme: "dark"}
+	if err := b.validate(); err != nil {
+		t.Fatalf("validate failed: %v", err)
+	}
+	if b.ProductName != "Acme Atlas" {
+		t.Errorf("ProductName = %q", b.ProductName)
+	}
+
+	def := BrandingSetting.Default
+	if err := def.validate(); err != nil {
+		t.Errorf("default branding is invalid: %v", err)
+	}
+
+	for _, bad := range []BrandingSettings{
+		{ProductName: "", ColorScheme: "system"},
+		{ProductName: "Acme", ColorScheme: "sepia"},
+		{ProductName: "Acme", ColorScheme: "light", PrimaryColor: "blue"},
+		{ProductName: "Acme", ColorScheme: "light", AccentColor: "#fff"},
+		{ProductName: "Acme", ColorScheme: "light", LogoURL: "javascript:alert(1)"},
+		{ProductName: "Acme", ColorScheme: "light", LogoURL: "logo.png"},
+	} {
+		if err := bad.validate(); err == nil {
+			t.Errorf("validate(%+v) succeeded", bad)
+		}
+	}
+
+	relative := BrandingSettings{ProductName: "Acme", ColorScheme: "light", LogoURL: "/static/logo.png"}
+	if err := relative.validate(); err != nil {
+		t.Errorf("relative logo_url: %v", err)
+	}
+}
+
+func TestSearchSettings(t *testing.T) {
+	def := SearchSetting.Default
+	if err := def.validate(); err != nil {
+		t.Errorf("default search settings are invalid: %v", err)
+	}
+	off := SearchSettings{FuzzyThreshold: 0}
+	if err := off.validate(); err != nil {
+		t.Errorf("fuzzy_threshold 0: %v", err)
+	}
+	for _, bad := range []SearchSettings{{FuzzyThreshold: -0.1}, {FuzzyThreshold: 1.5}} {
+		if err := bad.validate(); err == nil {
+			t.Errorf("validate(%+v) succeeded", bad)
+		}
+	}
+}
@@ -0,0 +1,188 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kubeatlas/kubeatlas/internal/models"
+	"go.uber.org/zap"
+)
+
+// maxOwnershipHistoryLogs bounds the audit logs of a namespace, newest
+// first, an ownership history is derived from
+const maxOwnershipHistoryLogs = 1000
+
+// OwnershipPeriod is a span of time during which the ownership of a
+// namespace did not change. From is null when the period began before the
+// audit logs kept, To is null for the current period.
+type OwnershipPeriod struct {
+	From *time.Time `json:"from"`
+	To   *time.Time `json:"to"`
+
+	InfrastructureOwnerTeamID *uuid.UUID `json:"infrastructure_owner_team_id"`
+	InfrastructureOwnerTeam   string     `json:"infrastructure_owner_team"`
+	InfrastructureOwnerUserID *uuid.UUID `json:"infrastructure_owner_user_id"`
+	InfrastructureOwnerUser   string     `json:"infrastructure_owner_user"`
+	ApplicationManagerName    string     `json:"application_manager_name"`
+	ApplicationManagerEmail   string     `json:"application_manager_email"`
+	TechnicalLeadName         string     `json:"technical_lead_name"`
+	TechnicalLeadEmail        string     `json:"technical_lead_email"`
+	ProjectManagerName        string     `json:"project_manager_name"`
+	ProjectManagerEmail       string     `json:"project_manager_email"`
+
+	// Changed are the fields that changed when the period began, ChangedBy
+	// who changed them and AuditLogID the audit log that recorded it. A
+	// period that began with the creation of the namespace changed nothing.
+	Changed    []string   `json:"changed"`
+	ChangedBy  string     `json:"changed_by"`
+	AuditLogID *uuid.UUID `json:"audit_log_id"`
+}
+
+// OwnershipHistory is the ownership timeline of a namespace
+type OwnershipHistory struct {
+	NamespaceID uuid.UUID         `json:"namespace_id"`
+	Namespace   string            `json:"namespace"`
+	Periods     []OwnershipPeriod `json:"periods"` // newest first
+}
+
+// OwnershipHistoryService derives ownership timelines from the audit log
+type OwnershipHistoryService struct {
+	repos  *Repositories
+	logger *zap.SugaredLogger
+}
+
+func NewOwnershipHistoryService(repos *Repositories, logger *zap.SugaredLogger) *OwnershipHistoryService {
+	return &OwnershipHistoryService{repos: repos, logger: logger}
+}
+
+// NamespaceHistory returns who owned a namespace of the organization and
+// when, newest first
+func (s *OwnershipHistoryService) NamespaceHistory(ctx context.Context, orgID, id uuid.UUID) (*OwnershipHistory, error) {
+	ns, err := s.repos.Namespace.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if ns == nil || ns.OrganizationID != orgID {
+		return nil, ErrNamespaceNotFound
+	}
+	logs, err := s.repos.Audit.ListByResource(ctx, "namespace", id, maxOwnershipHistoryLogs)
+	if err != nil {
+		return nil, err
+	}
+
+	spans := ownershipTimeline(namespaceOwnershipState(ns), logs)
+
+	var teamIDs, userIDs []uuid.UUID
+	for _, span := range spans {
+		if id := ownerID(span.state["infrastructure_owner_team_id"]); id != nil {
+			teamIDs = append(teamIDs, *id)
+		}
+		if id := ownerID(span.state["infrastructure_owner_user_id"]); id != nil {
+			userIDs = append(userIDs, *id)
+		}
+	}
+	teams, err := s.repos.Team.NamesByID(ctx, orgID, teamIDs)
+	if err != nil {
+		return nil, err
+	}
+	users, err := s.repos.User.EmailsByID(ctx, orgID, userIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	history := &OwnershipHistory{NamespaceID: ns.ID, Namespace: ns.Name, Periods: make([]OwnershipPeriod, len(spans))}
+	for i, span := range spans {
+		p := OwnershipPeriod{
+			From:                    span.from,
+			To:                      span.to,
+			ApplicationManagerName:  span.state["application_manager_name"],
+			ApplicationManagerEmail: span.state["application_manager_email"],
+			TechnicalLeadName:       span.state["technical_lead_name"],
+			TechnicalLeadEmail:      span.state["technical_lead_email"],
+			ProjectManagerName:      span.state["project_manager_name"],
+			ProjectManagerEmail:     span.state["project_manager_email"],
+			Changed:                 span.changed,
+		}
+		if id := ownerID(span.state["infrastructure_owner_team_id"]); id != nil {
+			p.InfrastructureOwnerTeamID, p.InfrastructureOwnerTeam = id, teams[*id]
+		}
+		if id := ownerID(span.state["infrastructure_owner_user_id"]); id != nil {
+			p.InfrastructureOwnerUserID, p.InfrastructureOwnerUser = id, users[*id]
+		}
+		if span.log != nil {
+			p.ChangedBy = span.log.UserEmail.String
+			p.AuditLogID = &span.log.ID
+		}
+		history.Periods[i] = p
+	}
+	return history, nil
+}
+
+// ownershipState is the value of each of namespaceOwnershipFields, by
+// audit log key
+type ownershipState map[string]string
+
+func namespaceOwnershipState(ns *models.Namespace) ownershipState {
+	values := StructToMap(ns)
+	state := make(ownershipState, len(namespaceOwnershipFields))
+	for _, f := range namespaceOwnershipFields {
+		state[f.Key] = auditValueString(values[f.Key])
+	}
+	return state
+}
+
+// ownerID parses the team or user ID of an ownership state
+func ownerID(value string) *uuid.UUID {
+	id, err := uuid.Parse(value)
+	if err != nil {
+		return nil
+	}
+	return &id
+}
+
+// ownershipSpan is an OwnershipPeriod before its IDs are resolved. log is
+// the audit log that began it, if any.
+type ownershipSpan struct {
+	state    ownershipState
+	from, to *time.Time
+	changed  []string
+	log      *models.AuditLog
+}
+
+// ownershipTimeline walks the audit logs of a namespace, newest first,
+// back from its current ownership and returns the spans of time its
+// ownership did not change, newest first. Logs that change no ownership
+// field are skipped; a create log ends the walk.
+func ownershipTimeline(current ownershipState, logs []models.AuditLog) []ownershipSpan {
+	state := make(ownershipState, len(current))
+	for k, v := range current {
+		state[k] = v
+	}
+
+	var spans []ownershipSpan
+	var to *time.Time
+	for i := range logs {
+		l := &logs[i]
+		switch l.Action {
+		case "create":
+			return append(spans, ownershipSpan{state: state, from: &l.CreatedAt, to: to, changed: []string{}, log: l})
+		case "update":
+			changes := ownershipChanges(namespaceOwnershipFields, l.OldValues, l.NewValues)
+			if len(changes) == 0 {
+				continue
+			}
+			span := ownershipSpan{state: make(ownershipState, len(state)), from: &l.CreatedAt, to: to, log: l}
+			for k, v := range state {
+				span.state[k] = v
+			}
+			for _, c := range changes {
+				span.changed = append(span.changed, c.field.Key)
+				state[c.field.Key] = c.old
+			}
+			spans = append(spans, span)
+			to = &l.CreatedAt
+		}
+	}
+	return append(spans, ownershipSpan{state: state, to: to, changed: []string{}})
+}
@@ -0,0 +1,50 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/kubeatlas/kubeatlas/internal/models"
+)
+
+func TestOwnershipTimeline(t *testing.T) {
+	at := func(day int) time.Time { return time.Date(2026, 10, day, 9, 0, 0, 0, time.UTC) }
+	current := ownershipState{"infrastructure_owner_team_id": "team-b", "technical_lead_name": "Ada"}
+	logs := []models.AuditLog{ // newest first
+		{Action: "update", CreatedAt: at(9), OldValues: models.JSONMap{"tags": []interface{}{}}, NewValues: models.JSONMap{"tags": []interface{}{"pci"}}},
+		{Action: "update", CreatedAt: at(8), UserEmail: models.NewNullStringFromString("admin@acme.com"),
+			OldValues: models.JSONMap{"infrastructure_owner_team_id": "team-a", "technical_lead_name": nil},
+			NewValues: models.JSONMap{"infrastructure_owner_team_id": "team-b", "technical_lead_name": "Ada"}},
+		{Action: "update", CreatedAt: at(5),
+			OldValues: models.JSONMap{"infrastructure_owner_team_id": nil},
+			NewValues: models.JSONMap{"infrastructure_owner_team_id": "team-a"}},
+	}
+
+	spans := ownershipTimeline(current, logs)
+	if len(spans) != 3 {
+		t.Fatalf("ownershipTimeline() = %d spans, want 3", len(spans))
+	}
+
+	if s := spans[0]; s.to != nil || !s.from.Equal(at(8)) || s.state["infrastructure_owner_team_id"] != "team-b" ||
+		!reflect.DeepEqual(s.changed, []string{"infrastructure_owner_team_id", "technical_lead_name"}) || s.log.UserEmail.String != "admin@acme.com" {
+		t.Errorf("current span = %+v", s)
+	}
+	if s := spans[1]; !s.from.Equal(at(5)) || !s.to.Equal(at(8)) || s.state["infrastructure_owner_team_id"] != "team-a" || s.state["technical_lead_name"] != "" {
+		t.Errorf("middle span = %+v", s)
+	}
+	if s := spans[2]; s.from != nil || !s.to.Equal(at(5)) || s.state["infrastructure_owner_team_id"] != "" || s.log != nil {
+		t.Errorf("first span = %+v", s)
+	}
+}
+
+func TestOwnershipTimeline_Created(t *testing.T) {
+	created := time.Date(2026, 10, 1, 9, 0, 0, 0, time.UTC)
+	spans := ownershipTimeline(ownershipState{"infrastructure_owner_team_id": "team-a"}, []models.AuditLog{
+		{Action: "archive", CreatedAt: created.Add(time.Hour)},
+		{Action: "create", CreatedAt: created},
+	})
+	if len(spans) != 1 || spans[0].to != nil || !spans[0].from.Equal(created) || spans[0].log == nil {
+		t.Errorf("ownershipTimeline() = %+v, want one span since creation", spans)
+	}
+}
@@ -0,0 +1,247 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kubeatlas/kubeatlas/internal/crypto"
+	"github.com/kubeatlas/kubeatlas/internal/database/repositories"
+	"github.com/kubeatlas/kubeatlas/internal/models"
+	"github.com/kubeatlas/kubeatlas/internal/pagerduty"
+	"go.uber.org/zap"
+)
+
+var ErrInvalidPagerDutySettings = errors.New("invalid PagerDuty settings: an API key is required")
+
+// onCallCacheTTL is how long the responders of a service are reused before
+// PagerDuty is asked again. Impact analyses look up many teams at once.
+const onCallCacheTTL = time.Minute
+
+// PagerDutyService shows who is on call for teams linked to a PagerDuty
+// service
+type PagerDutyService struct {
+	userRepo  *repositories.UserRepository
+	directory pagerduty.Directory
+	encryptor *crypto.Encryptor
+	auditSvc  *AuditService
+	logger    *zap.SugaredLogger
+	cache     *onCallCache
+}
+
+func NewPagerDutyService(userRepo *repositories.UserRepository, directory pagerduty.Directory, encryptor *crypto.Encryptor, auditSvc *AuditService, logger *zap.SugaredLogger) *PagerDutyService {
+	return &PagerDutyService{
+		userRepo:  userRepo,
+		directory: directory,
+		encryptor: encryptor,
+		auditSvc:  auditSvc,
+		logger:    logger,
+		cache:     newOnCallCache(onCallCacheTTL),
+	}
+}
+
+// ============================================
+// PagerDuty Settings
+// ============================================
+
+// PagerDutySettings are the organization's PagerDuty settings, stored in
+// organizations.settings["pagerduty"]
+type PagerDutySettings struct {
+	Enabled  bool   `json:"enabled"`
+	APIToken string `json:"api_token,omitempty"`
+}
+
+func (s *PagerDutySettings) pagerDutyConfig() pagerduty.Config {
+	return pagerduty.Config{APIToken: s.APIToken}
+}
+
+// GetSettings returns the organization's PagerDuty settings, including the API key
+func (s *PagerDutyService) GetSettings(ctx context.Context, orgID uuid.UUID) (*PagerDutySettings, error) {
+	settings, err := s.userRepo.GetOrganizationSettings(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := &PagerDutySettings{}
+	pdSettings, ok := settings["pagerduty"].(map[string]interface{})
+	if !ok {
+		return cfg, nil
+	}
+	if v, ok := pdSettings["enabled"].(bool); ok {
+		cfg.Enabled = v
+	}
+	if v, ok := pdSettings["api_token"].(string); ok {
+		if cfg.APIToken, err = openCredential(s.encryptor, v); err != nil {
+			return nil, err
+		}
+	}
+	return cfg, nil
+}
+
+// UpdateSettings replaces the organization's PagerDuty settings. An empty
+// API key keeps the stored one. The returned settings omit the key.
+func (s *PagerDutyService) UpdateSettings(ctx context.Context, ac AuditContext, req PagerDutySettings) (*PagerDutySettings, error) {
+	settings, err := s.userRepo.GetOrganizationSettings(ctx, ac.OrgID)
+	if err != nil {
+		return nil, err
+	}
+
+	if existing, ok := settings["pagerduty"].(map[string]interface{}); ok {
+		if v, ok := existing["api_token"].(string); ok && req.APIToken == "" {
+			if req.APIToken, err = openCredential(s.encryptor, v); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if req.Enabled && req.APIToken == "" {
+		return nil, ErrInvalidPagerDutySettings
+	}
+
+	apiToken, err := sealCredential(s.encryptor, req.APIToken)
+	if err != nil {
+		return nil, err
+	}
+	settings["pagerduty"] = map[string]interface{}{
+		"enabled":   req.Enabled,
+		"api_token": apiToken,
+	}
+	if err := s.userRepo.UpdateOrganizationSettings(ctx, ac.OrgID, settings); err != nil {
+		return nil, err
+	}
+	s.cache.clear(ac.OrgID)
+
+	s.auditSvc.LogUpdate(ctx, ac, "pagerduty_settings", ac.OrgID, "pagerduty", nil, map[string]interface{}{
+		"enabled": req.Enabled,
+	})
+
+	req.APIToken = ""
+	return &req, nil
+}
+
+// TestSettings checks that PagerDuty accepts the API key. An empty key falls
+// back to the stored one.
+func (s *PagerDutyService) TestSettings(ctx context.Context, orgID uuid.UUID, req PagerDutySettings) error {
+	if req.APIToken == "" {
+		stored, err := s.GetSettings(ctx, orgID)
+		if err != nil {
+			return err
+		}
+		req.APIToken = stored.APIToken
+	}
+	if req.APIToken == "" {
+		return ErrInvalidPagerDutySettings
+	}
+	return s.directory.Check(ctx, req.pagerDutyConfig())
+}
+
+// ============================================
+// On-call Responders
+// ============================================
+
+// OnCall returns who is on call for a PagerDuty service, first escalation
+// level first. It returns nil when the integration is disabled.
+func (s *PagerDutyService) OnCall(ctx context.Context, orgID uuid.UUID, serviceID string) ([]models.OnCallResponder, error) {
+	if serviceID == "" {
+		return nil, nil
+	}
+	if responders, ok := s.cache.get(orgID, serviceID); ok {
+		return responders, nil
+	}
+
+	cfg, err := s.GetSettings(ctx, orgID)
+	if err != nil || !cfg.Enabled {
+		return nil, err
+	}
+	oncalls, err := s.directory.OnCall(ctx, cfg.pagerDutyConfig(), serviceID)
+	if err != nil {
+		return nil, err
+	}
+
+	responders := make([]models.OnCallResponder, len(oncalls))
+	for i, o := range oncalls {
+		responders[i] = models.OnCallResponder{
+			EscalationLevel: o.EscalationLevel,
+			Name:            o.Name,
+			Email:           o.Email,
+			URL:             o.URL,
+			Schedule:        o.Schedule,
+			Until:           o.Until,
+		}
+	}
+	s.cache.put(orgID, serviceID, responders)
+	return responders, nil
+}
+
+// TeamOnCall implements OnCallSource for teams linked to a PagerDuty service.
+// Failures are logged, not returned, so views still load while PagerDuty is
+// unreachable.
+func (s *PagerDutyService) TeamOnCall(ctx context.Context, team *models.Team) []models.OnCallResponder {
+	if team == nil || !team.PagerDutyServiceID.Valid {
+		return nil
+	}
+	responders, err := s.OnCall(ctx, team.OrganizationID, team.PagerDutyServiceID.String)
+	if err != nil {
+		s.logger.Warnw("Failed to get on-call responders", "team_id", team.ID, "service", team.PagerDutyServiceID.String, "error", err)
+		return nil
+	}
+	return responders
+}
+
+// onCallCache holds the responders of PagerDuty services or Opsgenie
+// schedules for ttl
+type onCallCache struct {
+	ttl     time.Duration
+	now     func() time.Time
+	mu      sync.Mutex
+	entries map[onCallKey]onCallEntry
+}
+
+type onCallKey struct {
+	orgID uuid.UUID
+	ref   string
+}
+
+type onCallEntry struct {
+	responders []models.OnCallResponder
+	expires    time.Time
+}
+
+func newOnCallCache(ttl time.Duration) *onCallCache {
+	return &onCallCache{ttl: ttl, now: time.Now, entries: make(map[onCallKey]onCallEntry)}
+}
+
+func (c *onCallCache) get(orgID uuid.UUID, ref string) ([]models.OnCallResponder, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[onCallKey{orgID, ref}]
+	if !ok || !c.now().Before(entry.expires) {
+		return nil, false
+	}
+	return entry.responders, true
+}
+
+func (c *onCallCache) put(orgID uuid.UUID, ref string, responders []models.OnCallResponder) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.now()
+	// Drop expired entries so references no longer looked up do not pile up
+	for key, entry := range c.entries {
+		if !now.Before(entry.expires) {
+			delete(c.entries, key)
+		}
+	}
+	c.entries[onCallKey{orgID, ref}] = onCallEntry{responders: responders, expires: now.Add(c.ttl)}
+}
+
+// clear drops an organization's entries after its settings change
+func (c *onCallCache) clear(orgID uuid.UUID) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key := range c.entries {
+		if key.orgID == orgID {
+			delete(c.entries, key)
+		}
+	}
+}
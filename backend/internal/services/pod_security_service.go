@@ -0,0 +1,94 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+
+	"github.com/google/uuid"
+	"github.com/kubeatlas/kubeatlas/internal/models"
+)
+
+// podSecurityLevels are the report's groups, least restrictive first so
+// the namespaces that most need review lead
+var podSecurityLevels = []string{
+	models.PodSecurityUnset,
+	models.PodSecurityPrivileged,
+	models.PodSecurityBaseline,
+	models.PodSecurityRestricted,
+}
+
+// PodSecurityLevelGroup is the namespaces that enforce one Pod Security
+// Standards level
+type PodSecurityLevelGroup struct {
+	Level      string                        `json:"level"`
+	Count      int                           `json:"count"`
+	Percentage float64                       `json:"percentage"`
+	Namespaces []models.NamespacePodSecurity `json:"namespaces"`
+}
+
+// PodSecurityReport groups an organization's namespaces by the Pod Security
+// Admission level they enforce. Namespaces with a level Kubernetes does not
+// know get a group of their own after the standard ones.
+type PodSecurityReport struct {
+	TotalNamespaces int                     `json:"total_namespaces"`
+	Levels          []PodSecurityLevelGroup `json:"levels"`
+}
+
+// GetPodSecurityReport returns the Pod Security Admission compliance report
+func (s *DashboardService) GetPodSecurityReport(ctx context.Context, orgID uuid.UUID) (*PodSecurityReport, error) {
+	namespaces, err := s.repos.Namespace.ListPodSecurity(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	return podSecurityReport(namespaces), nil
+}
+
+func podSecurityReport(namespaces []models.NamespacePodSecurity) *PodSecurityReport {
+	report := &PodSecurityReport{TotalNamespaces: len(namespaces)}
+	index := make(map[string]int)
+	for _, level := range podSecurityLevels {
+		index[level] = len(report.Levels)
+		report.Levels = append(report.Levels, PodSecurityLevelGroup{
+			Level:      level,
+			Namespaces: []models.NamespacePodSecurity{},
+		})
+	}
+
+	for _, ns := range namespaces {
+		level := ns.Enforce
+		if level == "" {
+			level = models.PodSecurityUnset
+		}
+		i, ok := index[level]
+		if !ok {
+			i = len(report.Levels)
+			index[level] = i
+			report.Levels = append(report.Levels, PodSecurityLevelGroup{Level: level})
+		}
+		report.Levels[i].Namespaces = append(report.Levels[i].Namespaces, ns)
+	}
+
+	for i := range report.Levels {
+		group := &report.Levels[i]
+		group.Count = len(group.Namespaces)
+		if report.TotalNamespaces > 0 {
+			group.Percentage = float64(group.Count) / float64(report.TotalNamespaces) * 100
+		}
+	}
+	return report
+}
+
+// CSV renders the report with one row per namespace
+func (r *PodSecurityReport) CSV() ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.Write([]string{"Cluster", "Namespace", "Level", "EnforceVersion", "Audit", "Warn"})
+	for _, group := range r.Levels {
+		for _, ns := range group.Namespaces {
+			w.Write([]string{ns.Cluster, ns.Namespace, group.Level, ns.EnforceVersion, ns.Audit, ns.Warn})
+		}
+	}
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
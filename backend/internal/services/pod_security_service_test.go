@@ -0,0 +1,66 @@
+package services
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/kubeatlas/kubeatlas/internal/models"
+)
+
+func TestPodSecurityReport(t *testing.T) {
+	ns := func(name, enforce string) models.NamespacePodSecurity {
+		return models.NamespacePodSecurity{Namespace: name, Cluster: "prod", PodSecurity: models.PodSecurity{Enforce: enforce}}
+	}
+	report := podSecurityReport([]models.NamespacePodSecurity{
+		ns("payments", models.PodSecurityRestricted),
+		ns("legacy", ""),
+		ns("monitoring", models.PodSecurityPrivileged),
+		ns("checkout", models.PodSecurityRestricted),
+		ns("typo", "restricetd"),
+	})
+
+	if report.TotalNamespaces != 5 {
+		t.Errorf("total = %d, want 5", report.TotalNamespaces)
+	}
+	want := []struct {
+		level string
+		count int
+	}{
+		{models.PodSecurityUnset, 1},
+		{models.PodSecurityPrivileged, 1},
+		{models.PodSecurityBaseline, 0},
+		{models.PodSecurityRestricted, 2},
+		{"restricetd", 1},
+	}
+	if len(report.Levels) != len(want) {
+		t.Fatalf("levels = %+v, want %d groups", report.Levels, len(want))
+	}
+	for i, w := range want {
+		got := report.Levels[i]
+		if got.Level != w.level || got.Count != w.count || len(got.Namespaces) != w.count {
+			t.Errorf("group %d = %s with %d, want %s with %d", i, got.Level, got.Count, w.level, w.count)
+		}
+	}
+	if report.Levels[3].Percentage != 40 {
+		t.Errorf("restricted percentage = %v, want 40", report.Levels[3].Percentage)
+	}
+	if report.Levels[2].Namespaces == nil {
+		t.Error("empty group has a null namespace list")
+	}
+
+	csv, err := report.CSV()
+	if err != nil {
+		t.Fatalf("CSV failed: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(csv)), "\n")
+	if len(lines) != 6 || lines[1] != "prod,legacy,unset,,," {
+		t.Errorf("CSV = %q", csv)
+	}
+}
+
+func TestPodSecurityReportEmpty(t *testing.T) {
+	report := podSecurityReport(nil)
+	if report.TotalNamespaces != 0 || len(report.Levels) != len(podSecurityLevels) {
+		t.Errorf("report = %+v, want the standard levels and no namespaces", report)
+	}
+}
@@ -0,0 +1,186 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kubeatlas/kubeatlas/internal/database/repositories"
+	"go.uber.org/zap"
+)
+
+// RetentionService purges records past each organization's retention settings
+type RetentionService struct {
+	repo     *repositories.RetentionRepository
+	orgRepo  *repositories.OrgSettingsRepository
+	settings *OrgSettingsService
+	auditSvc *AuditService
+	logger   *zap.SugaredLogger
+}
+
+// NewRetentionService creates a new retention service
+func NewRetentionService(
+	repo *repositories.RetentionRepository,
+	orgRepo *repositories.OrgSettingsRepository,
+	settings *OrgSettingsService,
+	auditSvc *AuditService,
+	logger *zap.SugaredLogger,
+) *RetentionService {
+	return &RetentionService{
+		repo:     repo,
+		orgRepo:  orgRepo,
+		settings: settings,
+		auditSvc: auditSvc,
+		logger:   logger,
+	}
+}
+
+// RetentionPreview is what the next retention run would purge
+type RetentionPreview struct {
+	Settings RetentionSettings `json:"settings"`
+	// Counts has the number of records to purge per category
+	Counts map[string]int64 `json:"counts"`
+	Total  int64            `json:"total"`
+	// Cascaded has the number of rows that depend on the purged records and
+	// would be deleted with them, by table
+	Cascaded map[string]int64 `json:"cascaded"`
+}
+
+// Preview counts the organization's records the next retention run would purge
+func (s *RetentionService) Preview(ctx context.Context, orgID uuid.UUID) (*RetentionPreview, error) {
+	cfg, err := GetSetting(ctx, s.settings, orgID, RetentionSetting)
+	if err != nil {
+		return nil, err
+	}
+	return s.preview(ctx, orgID, cfg)
+}
+
+func (s *RetentionService) preview(ctx context.Context, orgID uuid.UUID, cfg RetentionSettings) (*RetentionPreview, error) {
+	var err error
+	preview := &RetentionPreview{Settings: cfg, Counts: make(map[string]int64), Cascaded: make(map[string]int64)}
+	cutoffs := cfg.cutoffs(time.Now())
+	for _, category := range repositories.RetentionCategories {
+		var n int64
+		var cascaded map[string]int64
+		switch cutoff, ok := cutoffs[category]; {
+		case ok:
+			n, cascaded, err = s.repo.CountExpired(ctx, orgID, category, cutoff)
+		case category == repositories.RetentionDocumentVersions && cfg.DocumentVersions > 0:
+			n, err = s.repo.CountExcessDocumentVersions(ctx, orgID, cfg.DocumentVersions)
+		}
+		if err != nil {
+			return nil, err
+		}
+		preview.Counts[category] = n
+		preview.Total += n
+		for table, c := range cascaded {
+			preview.Cascaded[table] += c
+		}
+	}
+	return preview, nil
+}
+
+// Enforce purges the records past every organization's retention settings.
+// A failing organization does not stop the others.
+func (s *RetentionService) Enforce(ctx context.Context) error {
+	orgIDs, err := s.orgRepo.ListOrganizationIDs(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list organizations: %w", err)
+	}
+
+	var errs []error
+	for _, orgID := range orgIDs {
+		if err := s.enforce(ctx, orgID); err != nil {
+			errs = append(errs, fmt.Errorf("organization %s: %w", orgID, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (s *RetentionService) enforce(ctx context.Context, orgID uuid.UUID) error {
+	cfg, err := GetSetting(ctx, s.settings, orgID, RetentionSetting)
+	if err != nil {
+		return err
+	}
+
+	// A dry run only reports what would be purged
+	if cfg.DryRun {
+		preview, err := s.preview(ctx, orgID, cfg)
+		if err != nil || preview.Total == 0 {
+			return err
+		}
+		description := "Would purge " + retentionDescription(preview.Counts, preview.Cascaded)
+		s.logger.Infow("Data retention dry run", "organization_id", orgID, "purged", preview.Counts, "cascaded", preview.Cascaded)
+		s.auditSvc.LogAction(ctx, AuditContext{OrgID: orgID, UserEmail: "system"}, "purge_dry_run", "retention", orgID, "retention", description)
+		return nil
+	}
+
+	purged := make(map[string]int64)
+	cascaded := make(map[string]int64)
+	cutoffs := cfg.cutoffs(time.Now())
+	for _, category := range repositories.RetentionCategories {
+		purge := &repositories.RetentionPurge{}
+		switch cutoff, ok := cutoffs[category]; {
+		case ok:
+			purge, err = s.repo.PurgeExpired(ctx, orgID, category, cutoff)
+		case category == repositories.RetentionDocumentVersions && cfg.DocumentVersions > 0:
+			purge.Files, err = s.repo.PurgeExcessDocumentVersions(ctx, orgID, cfg.DocumentVersions)
+			purge.Count = int64(len(purge.Files))
+		}
+		if err != nil {
+			return err
+		}
+
+		for _, path := range purge.Files {
+			if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+				s.logger.Warnw("Failed to remove purged document file", "path", path, "error", err)
+			}
+		}
+		if purge.Count > 0 {
+			purged[category] = purge.Count
+		}
+		for table, n := range purge.Cascaded {
+			cascaded[table] += n
+		}
+	}
+	if len(purged) == 0 {
+		return nil
+	}
+
+	description := "Purged " + retentionDescription(purged, cascaded)
+
+	s.logger.Infow("Enforced data retention", "organization_id", orgID, "purged", purged, "cascaded", cascaded)
+	s.auditSvc.LogAction(ctx, AuditContext{OrgID: orgID, UserEmail: "system"}, "purge", "retention", orgID, "retention", description)
+	return nil
+}
+
+// retentionDescription describes the records purged per category, in purge
+// order, and the dependent rows deleted with them, by table
+func retentionDescription(purged, cascaded map[string]int64) string {
+	parts := make([]string, 0, len(purged))
+	for _, category := range repositories.RetentionCategories {
+		if n := purged[category]; n > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", n, category))
+		}
+	}
+	description := strings.Join(parts, ", ")
+	if len(cascaded) == 0 {
+		return description
+	}
+
+	tables := make([]string, 0, len(cascaded))
+	for table := range cascaded {
+		tables = append(tables, table)
+	}
+	sort.Strings(tables)
+	parts = parts[:0]
+	for _, table := range tables {
+		parts = append(parts, fmt.Sprintf("%d %s", cascaded[table], table))
+	}
+	return description + " with dependent " + strings.Join(parts, ", ")
+}
@@ -0,0 +1,24 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/kubeatlas/kubeatlas/internal/database/repositories"
+)
+
+func TestRetentionDescription(t *testing.T) {
+	purged := map[string]int64{
+		repositories.RetentionClusters:   1,
+		repositories.RetentionAuditLogs:  120,
+		repositories.RetentionNamespaces: 4,
+	}
+	got := retentionDescription(purged, nil)
+	if want := "120 audit_logs, 4 namespaces, 1 clusters"; got != want {
+		t.Errorf("retentionDescription() = %q, want %q", got, want)
+	}
+
+	got = retentionDescription(purged, map[string]int64{"internal_dependencies": 3, "documents": 2})
+	if want := "120 audit_logs, 4 namespaces, 1 clusters with dependent 2 documents, 3 internal_dependencies"; got != want {
+		t.Errorf("retentionDescription() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,199 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/kubeatlas/kubeatlas/internal/database/repositories"
+	"github.com/kubeatlas/kubeatlas/internal/listquery"
+	"github.com/kubeatlas/kubeatlas/internal/models"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrSavedSearchNotFound = errors.New("saved search not found")
+	ErrSavedSearchExists   = errors.New("a saved search with this name already exists")
+	ErrSavedSearchNotOwner = errors.New("only the owner of a saved search can change it")
+	ErrInvalidSavedSearch  = errors.New("invalid saved search")
+)
+
+// SavedSearchEntities are the list endpoints a search can be saved for
+var SavedSearchEntities = []string{"namespaces", "clusters", "teams", "documents", "audit_logs", "internal_dependencies", "external_dependencies"}
+
+// maxSavedSearchFilters bounds the filters of a saved search
+const maxSavedSearchFilters = 50
+
+// savedSearchReserved are query parameters a saved search cannot hold:
+// paging is chosen when the search is applied and sorting has its own fields
+var savedSearchReserved = map[string]bool{"page": true, "page_size": true, "sort": true, "order": true}
+
+// SavedSearchRequest creates or replaces a saved search. TeamID shares it
+// with a team the user is a member of.
+type SavedSearchRequest struct {
+	Name    string            `json:"name" binding:"required"`
+	Entity  string            `json:"entity" binding:"required"`
+	Filters map[string]string `json:"filters"`
+	Sort    string            `json:"sort"`
+	Order   string            `json:"order"`
+	TeamID  *uuid.UUID        `json:"team_id"`
+}
+
+func (r *SavedSearchRequest) validate() error {
+	r.Name = strings.TrimSpace(r.Name)
+	if r.Name == "" || len(r.Name) > 255 {
+		return fmt.Errorf("%w: name must be 1 to 255 characters", ErrInvalidSavedSearch)
+	}
+	known := false
+	for _, entity := range SavedSearchEntities {
+		known = known || entity == r.Entity
+	}
+	if !known {
+		return fmt.Errorf("%w: entity must be one of %s", ErrInvalidSavedSearch, strings.Join(SavedSearchEntities, ", "))
+	}
+	if len(r.Filters) > maxSavedSearchFilters {
+		return fmt.Errorf("%w: at most %d filters", ErrInvalidSavedSearch, maxSavedSearchFilters)
+	}
+	for key := range r.Filters {
+		if key == "" || savedSearchReserved[key] {
+			return fmt.Errorf("%w: %q cannot be a filter", ErrInvalidSavedSearch, key)
+		}
+	}
+	// Fields are checked when the search is applied, by its entity's list
+	if q, ok := r.Filters["q"]; ok {
+		if _, err := listquery.Parse(q); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidSavedSearch, err)
+		}
+	}
+	switch r.Order {
+	case "", "asc", "desc":
+	default:
+		return fmt.Errorf("%w: order must be asc or desc", ErrInvalidSavedSearch)
+	}
+	return nil
+}
+
+// SavedSearchService manages the saved searches of users
+type SavedSearchService struct {
+	repo   *repositories.SavedSearchRepository
+	logger *zap.SugaredLogger
+}
+
+// NewSavedSearchService creates a new saved search service
+func NewSavedSearchService(repo *repositories.SavedSearchRepository, logger *zap.SugaredLogger) *SavedSearchService {
+	return &SavedSearchService{
+		repo:   repo,
+		logger: logger,
+	}
+}
+
+// Create saves a search for the caller
+func (s *SavedSearchService) Create(ctx context.Context, ac AuditContext, req SavedSearchRequest) (*models.SavedSearch, error) {
+	if ac.UserID == nil {
+		return nil, ErrSavedSearchNotOwner
+	}
+	search := &models.SavedSearch{OrganizationID: ac.OrgID, UserID: *ac.UserID}
+	if err := s.apply(ctx, search, req); err != nil {
+		return nil, err
+	}
+	if err := s.repo.Create(ctx, search); err != nil {
+		return nil, err
+	}
+	s.logger.Infow("Saved search created", "saved_search_id", search.ID, "user_id", search.UserID, "entity", search.Entity)
+	return search, nil
+}
+
+// List returns the caller's saved searches and those shared with their
+// teams, of entity when it is set
+func (s *SavedSearchService) List(ctx context.Context, ac AuditContext, entity string) ([]models.SavedSearch, error) {
+	if ac.UserID == nil {
+		return []models.SavedSearch{}, nil
+	}
+	return s.repo.ListVisible(ctx, ac.OrgID, *ac.UserID, entity)
+}
+
+// Get retrieves a saved search visible to the caller
+func (s *SavedSearchService) Get(ctx context.Context, ac AuditContext, id uuid.UUID) (*models.SavedSearch, error) {
+	if ac.UserID == nil {
+		return nil, ErrSavedSearchNotFound
+	}
+	search, err := s.repo.GetVisible(ctx, ac.OrgID, *ac.UserID, id)
+	if err != nil {
+		return nil, err
+	}
+	if search == nil {
+		return nil, ErrSavedSearchNotFound
+	}
+	return search, nil
+}
+
+// Update replaces a saved search of the caller
+func (s *SavedSearchService) Update(ctx context.Context, ac AuditContext, id uuid.UUID, req SavedSearchRequest) (*models.SavedSearch, error) {
+	search, err := s.owned(ctx, ac, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.apply(ctx, search, req); err != nil {
+		return nil, err
+	}
+	if err := s.repo.Update(ctx, search); err != nil {
+		return nil, err
+	}
+	return search, nil
+}
+
+// Delete deletes a saved search of the caller
+func (s *SavedSearchService) Delete(ctx context.Context, ac AuditContext, id uuid.UUID) error {
+	if _, err := s.owned(ctx, ac, id); err != nil {
+		return err
+	}
+	return s.repo.Delete(ctx, id)
+}
+
+// owned retrieves a saved search the caller can change
+func (s *SavedSearchService) owned(ctx context.Context, ac AuditContext, id uuid.UUID) (*models.SavedSearch, error) {
+	search, err := s.Get(ctx, ac, id)
+	if err != nil {
+		return nil, err
+	}
+	if search.UserID != *ac.UserID {
+		return nil, ErrSavedSearchNotOwner
+	}
+	return search, nil
+}
+
+// apply validates req and sets it on search
+func (s *SavedSearchService) apply(ctx context.Context, search *models.SavedSearch, req SavedSearchRequest) error {
+	if err := req.validate(); err != nil {
+		return err
+	}
+	exists, err := s.repo.ExistsByName(ctx, search.UserID, req.Name, search.ID)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return ErrSavedSearchExists
+	}
+	if req.TeamID != nil {
+		member, err := s.repo.IsTeamMember(ctx, *req.TeamID, search.UserID)
+		if err != nil {
+			return err
+		}
+		if !member {
+			return fmt.Errorf("%w: searches can only be shared with your own teams", ErrInvalidSavedSearch)
+		}
+	}
+
+	search.Name = req.Name
+	search.Entity = req.Entity
+	search.Filters = req.Filters
+	if search.Filters == nil {
+		search.Filters = map[string]string{}
+	}
+	search.Sort = models.NewNullStringFromString(req.Sort)
+	search.Order = models.NewNullStringFromString(req.Order)
+	search.TeamID = req.TeamID
+	return nil
+}
@@ -0,0 +1,37 @@
+package services
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestSavedSearchRequestValidate(t *testing.T) {
+	valid := SavedSearchRequest{
+		Name:    "  Tier-1 prod without owner ",
+		Entity:  "namespaces",
+		Filters: map[string]string{"q": "criticality:tier-1 AND environment:production AND owner:none"},
+		Sort:    "name",
+		Order:   "desc",
+	}
+	if err := valid.validate(); err != nil {
+		t.Fatalf("valid request: %v", err)
+	}
+	if valid.Name != "Tier-1 prod without owner" {
+		t.Errorf("name = %q, want it trimmed", valid.Name)
+	}
+
+	tests := map[string]SavedSearchRequest{
+		"blank name":     {Name: " ", Entity: "namespaces"},
+		"unknown entity": {Name: "x", Entity: "secrets"},
+		"paging filter":  {Name: "x", Entity: "clusters", Filters: map[string]string{"page": "2"}},
+		"sort filter":    {Name: "x", Entity: "clusters", Filters: map[string]string{"sort": "name"}},
+		"empty filter":   {Name: "x", Entity: "clusters", Filters: map[string]string{"": "a"}},
+		"invalid order":  {Name: "x", Entity: "teams", Order: "up"},
+		"invalid query":  {Name: "x", Entity: "namespaces", Filters: map[string]string{"q": "owner:none OR tag:pci"}},
+	}
+	for name, req := range tests {
+		if err := req.validate(); !errors.Is(err, ErrInvalidSavedSearch) {
+			t.Errorf("%s: error = %v, want ErrInvalidSavedSearch", name, err)
+		}
+	}
+}
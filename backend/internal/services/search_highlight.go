@@ -0,0 +1,115 @@
+package services
+
+import (
+	"html"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/kubeatlas/kubeatlas/internal/models"
+)
+
+// snippetContext is the number of bytes kept on each side of the first match
+// when a highlighted field is cut to a snippet
+const snippetContext = 60
+
+// highlightMatch returns text with every occurrence of term, ignoring case,
+// wrapped in <mark>, and false when term does not occur. The rest of the
+// text is HTML escaped; long text is cut to the context of the first match.
+func highlightMatch(text, term string) (string, bool) {
+	if term == "" {
+		return "", false
+	}
+	matches := regexp.MustCompile("(?i)"+regexp.QuoteMeta(term)).FindAllStringIndex(text, -1)
+	if matches == nil {
+		return "", false
+	}
+
+	start, end := 0, len(text)
+	if first := matches[0]; first[0] > snippetContext {
+		start = first[0] - snippetContext
+		for !utf8.RuneStart(text[start]) {
+			start++
+		}
+	}
+	if first := matches[0]; len(text)-first[1] > snippetContext {
+		end = first[1] + snippetContext
+		for end < len(text) && !utf8.RuneStart(text[end]) {
+			end++
+		}
+	}
+
+	var b strings.Builder
+	if start > 0 {
+		b.WriteString("…")
+	}
+	pos := start
+	for _, m := range matches {
+		if m[1] > end {
+			break
+		}
+		b.WriteString(html.EscapeString(text[pos:m[0]]))
+		b.WriteString("<mark>" + html.EscapeString(text[m[0]:m[1]]) + "</mark>")
+		pos = m[1]
+	}
+	b.WriteString(html.EscapeString(text[pos:end]))
+	if end < len(text) {
+		b.WriteString("…")
+	}
+	return b.String(), true
+}
+
+// namespaceHighlights highlights term in the fields of a namespace that
+// contain it, by field name. Returns nil when no field contains term, as
+// with fuzzy matches.
+func namespaceHighlights(ns *models.Namespace, term string) map[string]string {
+	return fieldHighlights(map[string]string{
+		"name":         ns.Name,
+		"display_name": ns.DisplayName.ValueOrEmpty(),
+		"description":  ns.Description.ValueOrEmpty(),
+	}, ns.Tags, term)
+}
+
+// documentHighlights highlights term in the fields of a document that contain
+// it, by field name, next to highlights found already such as its contents
+func documentHighlights(doc *models.Document, term string, found map[string]string) map[string]string {
+	highlights := fieldHighlights(map[string]string{
+		"name":        doc.Name,
+		"file_name":   doc.FileName,
+		"description": doc.Description.ValueOrEmpty(),
+	}, doc.Tags, term)
+	for field, snippet := range found {
+		if highlights == nil {
+			highlights = make(map[string]string, len(found))
+		}
+		highlights[field] = snippet
+	}
+	return highlights
+}
+
+// fieldHighlights highlights term in the fields that contain it, by field
+// name, and in matching tags, joined by commas under "tags". Returns nil when
+// none contains term.
+func fieldHighlights(fields map[string]string, tags []string, term string) map[string]string {
+	highlights := make(map[string]string)
+	for field, text := range fields {
+		if snippet, ok := highlightMatch(text, term); ok {
+			highlights[field] = snippet
+		}
+	}
+
+	var matched []string
+	for _, tag := range tags {
+		if snippet, ok := highlightMatch(tag, term); ok {
+			matched = append(matched, snippet)
+		}
+	}
+	if len(matched) > 0 {
+		highlights["tags"] = strings.Join(matched, ", ")
+	}
+
+	if len(highlights) == 0 {
+		return nil
+	}
+	return highlights
+}
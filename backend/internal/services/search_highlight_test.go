@@ -0,0 +1,83 @@
+package services
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/kubeatlas/kubeatlas/internal/models"
+)
+
+func TestHighlightMatch(t *testing.T) {
+	if got, ok := highlightMatch("Payments API for <checkout>", "payments"); !ok || got != "<mark>Payments</mark> API for &lt;checkout&gt;" {
+		t.Errorf("highlightMatch = %q, %v", got, ok)
+	}
+	if got, _ := highlightMatch("pay, repay", "PAY"); got != "<mark>pay</mark>, re<mark>pay</mark>" {
+		t.Errorf("every match = %q", got)
+	}
+	if _, ok := highlightMatch("billing", "payments"); ok {
+		t.Error("highlightMatch matched text without the term")
+	}
+
+	long := strings.Repeat("é", 100) + " payments " + strings.Repeat("ü", 100) + " payments"
+	got, ok := highlightMatch(long, "payments")
+	if !ok || !strings.HasPrefix(got, "…") || !strings.HasSuffix(got, "…") || !strings.Contains(got, "<mark>payments</mark>") {
+		t.Errorf("snippet = %q, want the context of the first match between ellipses", got)
+	}
+	if strings.Count(got, "<mark>") != 1 {
+		t.Errorf("snippet = %q, want only the match within it highlighted", got)
+	}
+	if !strings.HasPrefix(got, "…é") {
+		t.Errorf("snippet = %q, want it cut on a rune boundary", got)
+	}
+}
+
+func TestNamespaceHighlights(t *testing.T) {
+	ns := &models.Namespace{
+		Name:        "payments-api",
+		Description: models.NewNullStringFromString("Card payments"),
+		Tags:        models.StringArray{"payments", "pci"},
+	}
+	got := namespaceHighlights(ns, "payments")
+	want := map[string]string{
+		"name":        "<mark>payments</mark>-api",
+		"description": "Card <mark>payments</mark>",
+		"tags":        "<mark>payments</mark>",
+	}
+	if len(got) != len(want) {
+		t.Fatalf("highlights = %v, want %v", got, want)
+	}
+	for field, snippet := range want {
+		if got[field] != snippet {
+			t.Errorf("highlights[%s] = %q, want %q", field, got[field], snippet)
+		}
+	}
+
+	if got := namespaceHighlights(ns, "paymets"); got != nil {
+		t.Errorf("fuzzy match highlights = %v, want none", got)
+	}
+}
+
+func TestDocumentHighlights(t *testing.T) {
+	doc := &models.Document{Name: "Payments runbook", FileName: "runbook.md"}
+	got := documentHighlights(doc, "runbook", map[string]string{"content": "restart the <mark>runbook</mark> job"})
+	want := map[string]string{
+		"name":      "Payments <mark>runbook</mark>",
+		"file_name": "<mark>runbook</mark>.md",
+		"content":   "restart the <mark>runbook</mark> job",
+	}
+	if len(got) != len(want) {
+		t.Fatalf("highlights = %v, want %v", got, want)
+	}
+	for field, snippet := range want {
+		if got[field] != snippet {
+			t.Errorf("highlights[%s] = %q, want %q", field, got[field], snippet)
+		}
+	}
+
+	if got := documentHighlights(doc, "failover", nil); got != nil {
+		t.Errorf("highlights without a match = %v, want none", got)
+	}
+	if got := documentHighlights(doc, "failover", map[string]string{"content": "<mark>failover</mark>"}); len(got) != 1 {
+		t.Errorf("content only highlights = %v", got)
+	}
+}
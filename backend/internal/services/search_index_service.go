@@ -0,0 +1,84 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kubeatlas/kubeatlas/internal/database/repositories"
+	"github.com/kubeatlas/kubeatlas/internal/models"
+	"github.com/kubeatlas/kubeatlas/internal/searchindex"
+	"go.uber.org/zap"
+)
+
+// searchIndexBatchSize is the number of records read and indexed at a time
+const searchIndexBatchSize = 500
+
+// SearchIndexService copies namespaces, documents and dependencies into an
+// optional external search index and answers searches from it. Without an
+// index searches are left to Postgres.
+type SearchIndexService struct {
+	repo   *repositories.SearchIndexRepository
+	index  searchindex.Index
+	logger *zap.SugaredLogger
+	now    func() time.Time
+}
+
+// NewSearchIndexService creates a new search index service
+func NewSearchIndexService(repo *repositories.SearchIndexRepository, logger *zap.SugaredLogger) *SearchIndexService {
+	return &SearchIndexService{repo: repo, logger: logger, now: time.Now}
+}
+
+// SetIndex answers searches from index, which Reindex keeps up to date
+func (s *SearchIndexService) SetIndex(index searchindex.Index) {
+	s.index = index
+}
+
+// Enabled reports whether an external index answers searches
+func (s *SearchIndexService) Enabled() bool {
+	return s.index != nil
+}
+
+// Reindex copies every current record into the index and then removes the
+// records deleted since the last run. A failed run removes nothing, so the
+// index never loses records it could not rewrite. Run as a background job.
+func (s *SearchIndexService) Reindex(ctx context.Context) error {
+	if s.index == nil {
+		return nil
+	}
+
+	run := s.now()
+	total := 0
+	for _, docType := range models.SearchTypes {
+		after := uuid.Nil
+		for {
+			docs, err := s.repo.ListDocuments(ctx, docType, after, searchIndexBatchSize)
+			if err != nil {
+				return err
+			}
+			if len(docs) == 0 {
+				break
+			}
+			if err := s.index.Index(ctx, run, docs); err != nil {
+				return fmt.Errorf("failed to index %s records: %w", docType, err)
+			}
+			total += len(docs)
+			after = docs[len(docs)-1].ID
+		}
+	}
+
+	if err := s.index.Prune(ctx, run); err != nil {
+		return fmt.Errorf("failed to remove deleted records from the search index: %w", err)
+	}
+	s.logger.Debugw("Search index updated", "records", total, "duration", time.Since(run))
+	return nil
+}
+
+// Search searches the index
+func (s *SearchIndexService) Search(ctx context.Context, q searchindex.Query) ([]searchindex.Hit, error) {
+	if s.index == nil {
+		return nil, fmt.Errorf("no search index is configured")
+	}
+	return s.index.Search(ctx, q)
+}
@@ -0,0 +1,217 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kubeatlas/kubeatlas/internal/database/repositories"
+	"github.com/kubeatlas/kubeatlas/internal/models"
+	"go.uber.org/zap"
+)
+
+var ErrInvalidSearchType = errors.New("invalid search type")
+
+// GlobalSearchTypes are the types of records global search finds
+var GlobalSearchTypes = []string{models.SearchTypeNamespace, models.SearchTypeDocument}
+
+// suggestionCacheTTL is how long the suggestions for a prefix are reused;
+// names added meanwhile are suggested once it passed
+const suggestionCacheTTL = 30 * time.Second
+
+// maxSuggestionCacheEntries bounds the prefixes whose suggestions are cached
+const maxSuggestionCacheEntries = 10000
+
+// SearchService searches the organization's records of every type at once
+// and suggests names as search terms are typed
+type SearchService struct {
+	namespaces     *NamespaceService
+	documentRepo   *repositories.DocumentRepository
+	suggestionRepo *repositories.SuggestionRepository
+	suggestions    *suggestionCache
+	logger         *zap.SugaredLogger
+}
+
+// NewSearchService creates a new search service
+func NewSearchService(namespaces *NamespaceService, documentRepo *repositories.DocumentRepository, suggestionRepo *repositories.SuggestionRepository, logger *zap.SugaredLogger) *SearchService {
+	return &SearchService{
+		namespaces:     namespaces,
+		documentRepo:   documentRepo,
+		suggestionRepo: suggestionRepo,
+		suggestions:    newSuggestionCache(suggestionCacheTTL, maxSuggestionCacheEntries),
+		logger:         logger,
+	}
+}
+
+// Search returns up to limit records of the given types matching term, all
+// of GlobalSearchTypes when types is empty, best matches first. Documents
+// are found by their contents too.
+func (s *SearchService) Search(ctx context.Context, orgID uuid.UUID, term string, types []string, limit int) ([]models.SearchResult, error) {
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	types, err := searchTypes(types)
+	if err != nil {
+		return nil, err
+	}
+	term = strings.TrimSpace(term)
+
+	var found [][]models.SearchResult
+	for _, t := range types {
+		switch t {
+		case models.SearchTypeNamespace:
+			namespaces, err := s.namespaces.Search(ctx, orgID, term, limit)
+			if err != nil {
+				return nil, err
+			}
+			results := make([]models.SearchResult, len(namespaces))
+			for i := range namespaces {
+				ns := &namespaces[i]
+				results[i] = models.SearchResult{
+					Type:       models.SearchTypeNamespace,
+					ID:         ns.ID,
+					Name:       ns.Name,
+					Score:      ns.Score,
+					Highlights: ns.Highlights,
+					Namespace:  &ns.Namespace,
+				}
+			}
+			found = append(found, results)
+		case models.SearchTypeDocument:
+			results, err := s.documentRepo.Search(ctx, orgID, term, limit)
+			if err != nil {
+				return nil, err
+			}
+			for i := range results {
+				results[i].Highlights = documentHighlights(results[i].Document, term, results[i].Highlights)
+			}
+			found = append(found, results)
+		}
+	}
+	return mergeResults(limit, found...), nil
+}
+
+// Suggest returns up to limit names of namespaces, teams, clusters and tags
+// starting with prefix, for autocomplete. Suggestions are cached per prefix
+// for suggestionCacheTTL.
+func (s *SearchService) Suggest(ctx context.Context, orgID uuid.UUID, prefix string, limit int) ([]models.Suggestion, error) {
+	if limit <= 0 || limit > 50 {
+		limit = 10
+	}
+	prefix = strings.ToLower(strings.TrimSpace(prefix))
+	if prefix == "" {
+		return []models.Suggestion{}, nil
+	}
+
+	key := suggestionKey{orgID: orgID, prefix: prefix, limit: limit}
+	if suggestions, ok := s.suggestions.get(key); ok {
+		return suggestions, nil
+	}
+	suggestions, err := s.suggestionRepo.List(ctx, orgID, prefix, limit)
+	if err != nil {
+		return nil, err
+	}
+	s.suggestions.put(key, suggestions)
+	return suggestions, nil
+}
+
+// searchTypes validates the requested types of records, defaulting to all
+func searchTypes(types []string) ([]string, error) {
+	if len(types) == 0 {
+		return GlobalSearchTypes, nil
+	}
+	seen := make(map[string]bool, len(types))
+	var valid []string
+	for _, t := range types {
+		t = strings.ToLower(strings.TrimSpace(t))
+		known := false
+		for _, st := range GlobalSearchTypes {
+			known = known || st == t
+		}
+		if !known {
+			return nil, fmt.Errorf("%w %q, use %s", ErrInvalidSearchType, t, strings.Join(GlobalSearchTypes, " or "))
+		}
+		if !seen[t] {
+			seen[t] = true
+			valid = append(valid, t)
+		}
+	}
+	return valid, nil
+}
+
+// mergeResults orders the results of several types by score, then name, and
+// keeps the first limit
+func mergeResults(limit int, lists ...[]models.SearchResult) []models.SearchResult {
+	merged := make([]models.SearchResult, 0)
+	for _, list := range lists {
+		merged = append(merged, list...)
+	}
+	sort.SliceStable(merged, func(i, j int) bool {
+		if merged[i].Score != merged[j].Score {
+			return merged[i].Score > merged[j].Score
+		}
+		return merged[i].Name < merged[j].Name
+	})
+	if len(merged) > limit {
+		merged = merged[:limit]
+	}
+	return merged
+}
+
+// suggestionCache holds the suggestions for prefixes for ttl, for at most
+// maxEntries prefixes
+type suggestionCache struct {
+	ttl        time.Duration
+	maxEntries int
+	now        func() time.Time
+	mu         sync.Mutex
+	entries    map[suggestionKey]suggestionEntry
+}
+
+type suggestionKey struct {
+	orgID  uuid.UUID
+	prefix string
+	limit  int
+}
+
+type suggestionEntry struct {
+	suggestions []models.Suggestion
+	expires     time.Time
+}
+
+func newSuggestionCache(ttl time.Duration, maxEntries int) *suggestionCache {
+	return &suggestionCache{ttl: ttl, maxEntries: maxEntries, now: time.Now, entries: make(map[suggestionKey]suggestionEntry)}
+}
+
+func (c *suggestionCache) get(key suggestionKey) ([]models.Suggestion, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[key]
+	if !ok || !c.now().Before(entry.expires) {
+		return nil, false
+	}
+	return entry.suggestions, true
+}
+
+func (c *suggestionCache) put(key suggestionKey, suggestions []models.Suggestion) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.now()
+	if len(c.entries) >= c.maxEntries {
+		for k, entry := range c.entries {
+			if !now.Before(entry.expires) {
+				delete(c.entries, k)
+			}
+		}
+		// Everything is fresh: start over rather than grow
+		if len(c.entries) >= c.maxEntries {
+			c.entries = make(map[suggestionKey]suggestionEntry)
+		}
+	}
+	c.entries[key] = suggestionEntry{suggestions: suggestions, expires: now.Add(c.ttl)}
+}
@@ -0,0 +1,79 @@
+package services
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kubeatlas/kubeatlas/internal/models"
+)
+
+func TestSearchTypes(t *testing.T) {
+	if got, err := searchTypes(nil); err != nil || !reflect.DeepEqual(got, GlobalSearchTypes) {
+		t.Errorf("searchTypes(nil) = %v, %v, want all types", got, err)
+	}
+	if got, err := searchTypes([]string{" Document", "document"}); err != nil || !reflect.DeepEqual(got, []string{models.SearchTypeDocument}) {
+		t.Errorf("searchTypes(document twice) = %v, %v", got, err)
+	}
+	if _, err := searchTypes([]string{"namespace", "cluster"}); !errors.Is(err, ErrInvalidSearchType) {
+		t.Errorf("searchTypes(cluster) error = %v, want ErrInvalidSearchType", err)
+	}
+}
+
+func TestMergeResults(t *testing.T) {
+	namespaces := []models.SearchResult{
+		{Type: models.SearchTypeNamespace, Name: "payments", Score: 3.5},
+		{Type: models.SearchTypeNamespace, Name: "billing", Score: 0.5},
+	}
+	documents := []models.SearchResult{
+		{Type: models.SearchTypeDocument, Name: "Payments runbook", Score: 3},
+		{Type: models.SearchTypeDocument, Name: "Architecture", Score: 0.5},
+		{Type: models.SearchTypeDocument, Name: "Oncall", Score: 0.1},
+	}
+
+	got := mergeResults(4, namespaces, documents)
+	var names []string
+	for _, r := range got {
+		names = append(names, r.Name)
+	}
+	if want := []string{"payments", "Payments runbook", "Architecture", "billing"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("mergeResults = %v, want %v", names, want)
+	}
+	if got := mergeResults(10); len(got) != 0 || got == nil {
+		t.Errorf("mergeResults of nothing = %#v, want an empty list", got)
+	}
+}
+
+func TestSuggestionCache(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	c := newSuggestionCache(30*time.Second, 2)
+	c.now = func() time.Time { return now }
+
+	orgID := uuid.New()
+	pay := suggestionKey{orgID: orgID, prefix: "pay", limit: 10}
+	suggestions := []models.Suggestion{{Type: "namespace", Name: "payments"}}
+	c.put(pay, suggestions)
+	if got, ok := c.get(pay); !ok || !reflect.DeepEqual(got, suggestions) {
+		t.Errorf("get = %v, %v, want the cached suggestions", got, ok)
+	}
+	if _, ok := c.get(suggestionKey{orgID: uuid.New(), prefix: "pay", limit: 10}); ok {
+		t.Error("get returned another organization's suggestions")
+	}
+
+	now = now.Add(30 * time.Second)
+	if _, ok := c.get(pay); ok {
+		t.Error("get returned expired suggestions")
+	}
+
+	c.put(suggestionKey{orgID: orgID, prefix: "bil", limit: 10}, nil)
+	c.put(suggestionKey{orgID: orgID, prefix: "che", limit: 10}, nil)
+	if len(c.entries) != 2 {
+		t.Errorf("entries = %d, want the expired one dropped", len(c.entries))
+	}
+	c.put(pay, suggestions)
+	if len(c.entries) != 1 {
+		t.Errorf("entries = %d, want the full cache emptied", len(c.entries))
+	}
+}
@@ -1,10 +1,23 @@
 package services
 
 import (
+	"time"
+
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/kubeatlas/kubeatlas/internal/confluence"
 	"github.com/kubeatlas/kubeatlas/internal/crypto"
 	"github.com/kubeatlas/kubeatlas/internal/database/repositories"
+	"github.com/kubeatlas/kubeatlas/internal/gitrepo"
+	"github.com/kubeatlas/kubeatlas/internal/grafana"
+	"github.com/kubeatlas/kubeatlas/internal/jira"
 	"github.com/kubeatlas/kubeatlas/internal/k8s"
+	"github.com/kubeatlas/kubeatlas/internal/mail"
+	"github.com/kubeatlas/kubeatlas/internal/monitoring"
+	"github.com/kubeatlas/kubeatlas/internal/opsgenie"
+	"github.com/kubeatlas/kubeatlas/internal/pagerduty"
+	"github.com/kubeatlas/kubeatlas/internal/slack"
+	"github.com/kubeatlas/kubeatlas/internal/teams"
+	"github.com/kubeatlas/kubeatlas/internal/webhook"
 	"go.uber.org/zap"
 )
 
@@ -21,6 +34,39 @@ type Services struct {
 	BusinessUnit *BusinessUnitService
 	Dashboard    *DashboardService
 	Audit        *AuditService
+	Notification *NotificationService
+	Webhook      *WebhookService
+	Escalation   *EscalationService
+	OrgSettings  *OrgSettingsService
+	Retention    *RetentionService
+	Trash        *TrashService
+	Export       *ExportService
+	OrgDeletion  *OrgDeletionService
+	Backstage    *BackstageImportService
+	CSVImport    *CSVImportService
+	Jira         *JiraService
+	PagerDuty    *PagerDutyService
+	Opsgenie     *OpsgenieService
+	Grafana      *GrafanaService
+	Confluence   *ConfluenceService
+	Git          *GitService
+	Monitoring   *MonitoringService
+	Impact       *ImpactService
+	Compare      *NamespaceCompareService
+	Ownership    *OwnershipHistoryService
+	Migration    *MigrationService
+	Backup       *MetadataBackupService
+	SavedSearch  *SavedSearchService
+	Tag          *TagService
+	SearchIndex  *SearchIndexService
+	Search       *SearchService
+	TeamSync     *TeamSyncService
+	TeamOnCall   *TeamOnCallService
+	Contacts     *ContactValidationService
+	BulkAssign   *NamespaceBulkService
+	Comment      *CommentService
+	Application  *ApplicationService
+	Fleet        *ClusterFleetService
 
 	Repos *Repositories
 }
@@ -36,10 +82,32 @@ type Repositories struct {
 	ExternalDependency *repositories.ExternalDependencyRepository
 	Document           *repositories.DocumentRepository
 	Audit              *repositories.AuditRepository
+	Notification       *repositories.NotificationRepository
+	Webhook            *repositories.WebhookRepository
+	Escalation         *repositories.EscalationRepository
+	OrgSettings        *repositories.OrgSettingsRepository
+	Retention          *repositories.RetentionRepository
+	Trash              *repositories.TrashRepository
+	Export             *repositories.ExportRepository
+	OrgDeletion        *repositories.OrgDeletionRepository
+	MetadataBackup     *repositories.MetadataBackupRepository
+	SavedSearch        *repositories.SavedSearchRepository
+	Tag                *repositories.TagRepository
+	SearchIndex        *repositories.SearchIndexRepository
+	Suggestion         *repositories.SuggestionRepository
+	TeamSync           *repositories.TeamSyncRepository
+	TeamOnCall         *repositories.TeamOnCallRepository
+	ContactValidation  *repositories.ContactValidationRepository
+	NamespaceBulk      *repositories.NamespaceBulkRepository
+	Comment            *repositories.CommentRepository
+	Application        *repositories.ApplicationRepository
+	ClusterFleet       *repositories.ClusterFleetRepository
+	UnitOfWork         *repositories.UnitOfWork
 }
 
-// New creates a new Services instance
-func New(pool *pgxpool.Pool, k8sManager *k8s.Manager, encryptor *crypto.Encryptor, logger *zap.SugaredLogger, jwtSecret string, jwtExpirationHours int) *Services {
+// New creates a new Services instance. readPool may be nil or the same pool
+// as pool when no read replica is configured.
+func New(pool, readPool *pgxpool.Pool, k8sManager *k8s.Manager, encryptor *crypto.Encryptor, logger *zap.SugaredLogger, jwtSecret string, jwtExpirationHours int) *Services {
 	repos := &Repositories{
 		Cluster:            repositories.NewClusterRepository(pool),
 		Namespace:          repositories.NewNamespaceRepository(pool),
@@ -50,23 +118,134 @@ func New(pool *pgxpool.Pool, k8sManager *k8s.Manager, encryptor *crypto.Encrypto
 		ExternalDependency: repositories.NewExternalDependencyRepository(pool),
 		Document:           repositories.NewDocumentRepository(pool),
 		Audit:              repositories.NewAuditRepository(pool),
+		Notification:       repositories.NewNotificationRepository(pool),
+		Webhook:            repositories.NewWebhookRepository(pool),
+		Escalation:         repositories.NewEscalationRepository(pool),
+		OrgSettings:        repositories.NewOrgSettingsRepository(pool),
+		Retention:          repositories.NewRetentionRepository(pool),
+		Trash:              repositories.NewTrashRepository(pool),
+		Export:             repositories.NewExportRepository(pool),
+		OrgDeletion:        repositories.NewOrgDeletionRepository(pool),
+		MetadataBackup:     repositories.NewMetadataBackupRepository(pool),
+		SavedSearch:        repositories.NewSavedSearchRepository(pool),
+		Tag:                repositories.NewTagRepository(pool),
+		SearchIndex:        repositories.NewSearchIndexRepository(pool),
+		Suggestion:         repositories.NewSuggestionRepository(pool),
+		TeamSync:           repositories.NewTeamSyncRepository(pool),
+		TeamOnCall:         repositories.NewTeamOnCallRepository(pool),
+		ContactValidation:  repositories.NewContactValidationRepository(pool),
+		NamespaceBulk:      repositories.NewNamespaceBulkRepository(pool),
+		Comment:            repositories.NewCommentRepository(pool),
+		Application:        repositories.NewApplicationRepository(pool),
+		ClusterFleet:       repositories.NewClusterFleetRepository(pool),
+		UnitOfWork:         repositories.NewUnitOfWork(pool),
+	}
+	if readPool != nil && readPool != pool {
+		repos.useReadReplica(readPool)
 	}
 
 	auditSvc := NewAuditService(repos.Audit, logger)
 	ldapSvc := NewLDAPService(repos.User, logger)
+	orgSettingsSvc := NewOrgSettingsService(repos.OrgSettings, auditSvc, logger)
+	auditSvc.SetSettings(orgSettingsSvc)
+	notificationSvc := NewNotificationService(repos.Notification, repos.User, repos.Team, repos.Cluster, repos.Namespace, orgSettingsSvc, mail.NewSMTPSender(30*time.Second), slack.NewClient(10*time.Second), teams.NewClient(10*time.Second), encryptor, auditSvc, logger)
+	webhookSvc := NewWebhookService(repos.Webhook, encryptor, webhook.NewClient(10*time.Second), auditSvc, logger)
+	escalationSvc := NewEscalationService(repos.Escalation, repos.Namespace, notificationSvc, auditSvc, logger)
+	userSvc := NewUserService(repos.User, auditSvc, notificationSvc, logger)
+	teamSvc := NewTeamService(repos.Team, repos.Namespace, orgSettingsSvc, auditSvc, notificationSvc, logger)
+	businessUnitSvc := NewBusinessUnitService(repos.BusinessUnit, auditSvc, logger)
+	namespaceSvc := NewNamespaceService(repos.Namespace, repos.Cluster, repos.Team, repos.BusinessUnit, orgSettingsSvc, auditSvc, notificationSvc, webhookSvc, logger)
+	jiraSvc := NewJiraService(repos.Namespace, repos.Cluster, repos.User, jira.NewClient(10*time.Second), encryptor, auditSvc, logger)
+	namespaceSvc.SetTickets(jiraSvc)
+	pagerDutySvc := NewPagerDutyService(repos.User, pagerduty.NewClient(10*time.Second), encryptor, auditSvc, logger)
+	opsgenieSvc := NewOpsgenieService(repos.User, repos.Namespace, repos.Team, opsgenie.NewClient(10*time.Second), encryptor, auditSvc, logger)
+	teamOnCallSvc := NewTeamOnCallService(repos.TeamOnCall, repos.Team, pagerDutySvc, opsgenieSvc, auditSvc, logger)
+	namespaceSvc.SetOnCall(pagerDutySvc, opsgenieSvc, teamOnCallSvc)
+	namespaceSvc.SetOnCallRotations(teamOnCallSvc)
+	dependencySvc := NewDependencyService(repos.InternalDependency, repos.ExternalDependency, auditSvc, escalationSvc, logger)
+	dependencySvc.SetAlerts(opsgenieSvc)
+	grafanaSvc := NewGrafanaService(repos.User, repos.Cluster, grafana.NewClient(10*time.Second), encryptor, auditSvc, logger)
+	namespaceSvc.SetAnnotations(grafanaSvc)
+	gitSvc := NewGitService(repos.Namespace, repos.User, gitrepo.NewClient(10*time.Second), encryptor, auditSvc, logger)
+	namespaceSvc.SetGit(gitSvc)
+	monitoringSvc := NewMonitoringService(repos.Namespace, repos.User, grafanaSvc, orgSettingsSvc, monitoring.NewClient(10*time.Second), encryptor, auditSvc, logger)
+	namespaceSvc.SetMonitoring(monitoringSvc)
+	searchIndexSvc := NewSearchIndexService(repos.SearchIndex, logger)
+	namespaceSvc.SetSearchIndex(searchIndexSvc)
+	clusterSvc := NewClusterService(repos.Cluster, repos.Namespace, repos.UnitOfWork, k8sManager, encryptor, orgSettingsSvc, auditSvc, notificationSvc, webhookSvc, logger)
+	clusterSvc.SetAnnotations(grafanaSvc)
 
 	return &Services{
 		Repos:        repos,
 		Audit:        auditSvc,
 		LDAP:         ldapSvc,
-		Auth:         NewAuthService(repos.User, ldapSvc, logger, jwtSecret, jwtExpirationHours),
-		Team:         NewTeamService(repos.Team, auditSvc, logger),
-		User:         NewUserService(repos.User, auditSvc, logger),
-		BusinessUnit: NewBusinessUnitService(repos.BusinessUnit, auditSvc, logger),
-		Cluster:      NewClusterService(repos.Cluster, repos.Namespace, k8sManager, encryptor, auditSvc, logger),
-		Namespace:    NewNamespaceService(repos.Namespace, repos.Cluster, repos.Team, repos.BusinessUnit, auditSvc, logger),
-		Dependency:   NewDependencyService(repos.InternalDependency, repos.ExternalDependency, auditSvc, logger),
-		Document:     NewDocumentService(repos.Document, auditSvc, logger),
-		Dashboard:    NewDashboardService(repos, logger),
+		Notification: notificationSvc,
+		Webhook:      webhookSvc,
+		Escalation:   escalationSvc,
+		OrgSettings:  orgSettingsSvc,
+		Retention:    NewRetentionService(repos.Retention, repos.OrgSettings, orgSettingsSvc, auditSvc, logger),
+		Trash:        NewTrashService(repos.Trash, auditSvc, logger),
+		Export:       NewExportService(repos.Export, orgSettingsSvc, auditSvc, logger),
+		OrgDeletion:  NewOrgDeletionService(repos.OrgDeletion, repos.OrgSettings, auditSvc, logger),
+		Auth:         NewAuthService(repos.User, ldapSvc, auditSvc, logger, jwtSecret, jwtExpirationHours),
+		Team:         teamSvc,
+		User:         userSvc,
+		BusinessUnit: businessUnitSvc,
+		Cluster:      clusterSvc,
+		Namespace:    namespaceSvc,
+		Dependency:   dependencySvc,
+		Document:     NewDocumentService(repos.Document, orgSettingsSvc, auditSvc, notificationSvc, webhookSvc, logger),
+		Dashboard:    NewDashboardService(repos, orgSettingsSvc, logger),
+		Backstage:    NewBackstageImportService(repos, teamSvc, businessUnitSvc, namespaceSvc, dependencySvc, logger),
+		CSVImport:    NewCSVImportService(repos, userSvc, teamSvc, businessUnitSvc, logger),
+		Jira:         jiraSvc,
+		PagerDuty:    pagerDutySvc,
+		Opsgenie:     opsgenieSvc,
+		Grafana:      grafanaSvc,
+		Confluence:   NewConfluenceService(repos, confluence.NewClient(10*time.Second), encryptor, auditSvc, logger),
+		Git:          gitSvc,
+		Monitoring:   monitoringSvc,
+		Impact:       NewImpactService(repos, logger, pagerDutySvc, opsgenieSvc, teamOnCallSvc),
+		Compare:      NewNamespaceCompareService(repos, logger),
+		Ownership:    NewOwnershipHistoryService(repos, logger),
+		Migration:    NewMigrationService(pool, logger),
+		SavedSearch:  NewSavedSearchService(repos.SavedSearch, logger),
+		Tag:          NewTagService(repos.Tag, auditSvc, logger),
+		SearchIndex:  searchIndexSvc,
+		Search:       NewSearchService(namespaceSvc, repos.Document, repos.Suggestion, logger),
+		TeamSync:     NewTeamSyncService(repos.TeamSync, repos.Team, ldapSvc, auditSvc, logger),
+		TeamOnCall:   teamOnCallSvc,
+		Contacts:     NewContactValidationService(repos.ContactValidation, ldapSvc, logger),
+		BulkAssign:   NewNamespaceBulkService(repos.NamespaceBulk, repos.Namespace, repos.Team, namespaceSvc, orgSettingsSvc, auditSvc, logger),
+		Comment:      NewCommentService(repos.Comment, repos.User, repos.Namespace, repos.Cluster, notificationSvc, logger),
+		Application:  NewApplicationService(repos.Application, repos.Namespace, repos.Team, repos.User, auditSvc, logger),
+		Fleet:        NewClusterFleetService(repos.ClusterFleet, repos.Team, clusterSvc, auditSvc, logger),
+		Backup:       NewMetadataBackupService(repos.MetadataBackup, repos.OrgSettings, teamSvc, businessUnitSvc, namespaceSvc, orgSettingsSvc, auditSvc, logger),
 	}
 }
+
+// useReadReplica routes read-heavy repository queries to the replica pool
+func (r *Repositories) useReadReplica(readPool *pgxpool.Pool) {
+	r.Cluster.SetReadReplica(readPool)
+	r.Namespace.SetReadReplica(readPool)
+	r.Team.SetReadReplica(readPool)
+	r.User.SetReadReplica(readPool)
+	r.BusinessUnit.SetReadReplica(readPool)
+	r.InternalDependency.SetReadReplica(readPool)
+	r.ExternalDependency.SetReadReplica(readPool)
+	r.Document.SetReadReplica(readPool)
+	r.Audit.SetReadReplica(readPool)
+	r.Notification.SetReadReplica(readPool)
+	r.Webhook.SetReadReplica(readPool)
+	r.Escalation.SetReadReplica(readPool)
+	r.SavedSearch.SetReadReplica(readPool)
+	r.Tag.SetReadReplica(readPool)
+	r.SearchIndex.SetReadReplica(readPool)
+	r.Suggestion.SetReadReplica(readPool)
+	r.TeamSync.SetReadReplica(readPool)
+	r.TeamOnCall.SetReadReplica(readPool)
+	r.ContactValidation.SetReadReplica(readPool)
+	r.Comment.SetReadReplica(readPool)
+	r.Application.SetReadReplica(readPool)
+	r.ClusterFleet.SetReadReplica(readPool)
+}
@@ -0,0 +1,98 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/kubeatlas/kubeatlas/internal/database/repositories"
+	"github.com/kubeatlas/kubeatlas/internal/models"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrTagNotFound = errors.New("no records use these tags")
+	ErrInvalidTag  = errors.New("invalid tag")
+)
+
+// maxTagLength bounds a tag a rename or merge writes
+const maxTagLength = 100
+
+// TagService lists the tags in use and keeps them consistent by renaming
+// and merging them
+type TagService struct {
+	repo     *repositories.TagRepository
+	auditSvc *AuditService
+	logger   *zap.SugaredLogger
+}
+
+// NewTagService creates a new tag service
+func NewTagService(repo *repositories.TagRepository, auditSvc *AuditService, logger *zap.SugaredLogger) *TagService {
+	return &TagService{
+		repo:     repo,
+		auditSvc: auditSvc,
+		logger:   logger,
+	}
+}
+
+// List returns the organization's tags with their usage, most used first.
+// A term narrows them to tags containing it, for autocomplete.
+func (s *TagService) List(ctx context.Context, orgID uuid.UUID, term string, limit int) ([]models.TagUsage, error) {
+	if limit <= 0 || limit > 500 {
+		limit = 500
+	}
+	return s.repo.List(ctx, orgID, strings.TrimSpace(term), limit)
+}
+
+// Rename renames a tag on every record using it
+func (s *TagService) Rename(ctx context.Context, ac AuditContext, from, to string) (*models.TagMerge, error) {
+	return s.Merge(ctx, ac, []string{from}, to)
+}
+
+// Merge replaces tags with into on every record using any of them
+func (s *TagService) Merge(ctx context.Context, ac AuditContext, tags []string, into string) (*models.TagMerge, error) {
+	into, from, err := mergeTags(tags, into)
+	if err != nil {
+		return nil, err
+	}
+
+	counts, err := s.repo.Merge(ctx, ac.OrgID, from, into)
+	if err != nil {
+		return nil, err
+	}
+	merge := &models.TagMerge{Tag: into, Merged: from, Namespaces: counts[0], Clusters: counts[1], Documents: counts[2]}
+	if merge.Namespaces+merge.Clusters+merge.Documents == 0 {
+		return nil, ErrTagNotFound
+	}
+
+	s.auditSvc.LogAction(ctx, ac, "tag_merged", "tag", ac.OrgID, into,
+		fmt.Sprintf("Merged tags %s into %s on %d namespaces, %d clusters and %d documents",
+			strings.Join(from, ", "), into, merge.Namespaces, merge.Clusters, merge.Documents))
+	s.logger.Infow("Tags merged", "organization_id", ac.OrgID, "tag", into, "merged", from)
+	return merge, nil
+}
+
+// mergeTags validates a merge of tags into into, returning the trimmed target
+// and the distinct tags to replace with it
+func mergeTags(tags []string, into string) (string, []string, error) {
+	into = strings.TrimSpace(into)
+	if into == "" || len(into) > maxTagLength {
+		return "", nil, fmt.Errorf("%w: the new tag must be 1 to %d characters", ErrInvalidTag, maxTagLength)
+	}
+
+	from := make([]string, 0, len(tags))
+	seen := map[string]bool{into: true}
+	for _, tag := range tags {
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		from = append(from, tag)
+	}
+	if len(from) == 0 {
+		return "", nil, fmt.Errorf("%w: name at least one tag other than %s", ErrInvalidTag, into)
+	}
+	return into, from, nil
+}
@@ -0,0 +1,35 @@
+package services
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestMergeTags(t *testing.T) {
+	into, from, err := mergeTags([]string{"payment", "Payments", "payment", "payments", ""}, " payments ")
+	if err != nil {
+		t.Fatalf("mergeTags: %v", err)
+	}
+	if into != "payments" {
+		t.Errorf("into = %q, want it trimmed", into)
+	}
+	if want := []string{"payment", "Payments"}; !reflect.DeepEqual(from, want) {
+		t.Errorf("from = %v, want %v without duplicates, blanks or the target", from, want)
+	}
+
+	tests := map[string]struct {
+		tags []string
+		into string
+	}{
+		"blank target": {[]string{"a"}, "  "},
+		"long target":  {[]string{"a"}, string(make([]byte, maxTagLength+1))},
+		"only target":  {[]string{"a", "a"}, "a"},
+		"no tags":      {nil, "a"},
+	}
+	for name, tt := range tests {
+		if _, _, err := mergeTags(tt.tags, tt.into); !errors.Is(err, ErrInvalidTag) {
+			t.Errorf("%s: error = %v, want ErrInvalidTag", name, err)
+		}
+	}
+}
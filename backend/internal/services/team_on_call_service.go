@@ -0,0 +1,248 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/mail"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kubeatlas/kubeatlas/internal/database/repositories"
+	"github.com/kubeatlas/kubeatlas/internal/models"
+	"go.uber.org/zap"
+)
+
+var ErrInvalidOnCallRotation = errors.New("invalid on-call rotation")
+
+// maxEscalationContacts bounds the escalation contacts of a team
+const maxEscalationContacts = 20
+
+// UpdateTeamOnCallRequest sets where a team's on-call schedule lives and who
+// to escalate to
+type UpdateTeamOnCallRequest struct {
+	RotationURL        string                     `json:"rotation_url"`
+	EscalationContacts []models.EscalationContact `json:"escalation_contacts"`
+}
+
+// TeamOnCallService keeps the on-call rotation of teams: the rotation URL
+// and escalation contacts set by hand, and who is on call, refreshed from
+// PagerDuty or Opsgenie on a schedule. The stored responders answer when the
+// integrations cannot.
+type TeamOnCallService struct {
+	repo      *repositories.TeamOnCallRepository
+	teamRepo  *repositories.TeamRepository
+	pagerDuty *PagerDutyService
+	opsgenie  *OpsgenieService
+	auditSvc  *AuditService
+	logger    *zap.SugaredLogger
+}
+
+// NewTeamOnCallService creates a new team on-call service
+func NewTeamOnCallService(repo *repositories.TeamOnCallRepository, teamRepo *repositories.TeamRepository, pagerDuty *PagerDutyService, opsgenie *OpsgenieService, auditSvc *AuditService, logger *zap.SugaredLogger) *TeamOnCallService {
+	return &TeamOnCallService{repo: repo, teamRepo: teamRepo, pagerDuty: pagerDuty, opsgenie: opsgenie, auditSvc: auditSvc, logger: logger}
+}
+
+// Get returns the on-call rotation of a team of the organization
+func (s *TeamOnCallService) Get(ctx context.Context, orgID, teamID uuid.UUID) (*models.TeamOnCall, error) {
+	if _, err := s.team(ctx, orgID, teamID); err != nil {
+		return nil, err
+	}
+	return s.rotation(ctx, teamID)
+}
+
+// Update sets the rotation URL and escalation contacts of a team
+func (s *TeamOnCallService) Update(ctx context.Context, ac AuditContext, teamID uuid.UUID, req UpdateTeamOnCallRequest) (*models.TeamOnCall, error) {
+	team, err := s.team(ctx, ac.OrgID, teamID)
+	if err != nil {
+		return nil, err
+	}
+	rotationURL, err := validateRotationURL(req.RotationURL)
+	if err != nil {
+		return nil, err
+	}
+	contacts, err := validateEscalationContacts(req.EscalationContacts)
+	if err != nil {
+		return nil, err
+	}
+
+	before, err := s.rotation(ctx, teamID)
+	if err != nil {
+		return nil, err
+	}
+	after := *before
+	after.RotationURL = rotationURL
+	after.EscalationContacts = contacts
+	if err := s.repo.SaveRotation(ctx, ac.OrgID, &after); err != nil {
+		return nil, err
+	}
+
+	s.auditSvc.LogUpdate(ctx, ac, "team", team.ID, team.Name,
+		map[string]interface{}{"rotation_url": before.RotationURL, "escalation_contacts": before.EscalationContacts},
+		map[string]interface{}{"rotation_url": after.RotationURL, "escalation_contacts": after.EscalationContacts})
+	return &after, nil
+}
+
+// Refresh asks PagerDuty, then Opsgenie, who is on call for every team
+// linked to them and stores the answer. A failing team does not stop the
+// others; its last responders are kept and the error recorded.
+func (s *TeamOnCallService) Refresh(ctx context.Context) error {
+	teams, err := s.repo.ListLinkedTeams(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list teams: %w", err)
+	}
+
+	var errs []error
+	for i := range teams {
+		team := &teams[i]
+		current, err := s.ask(ctx, team)
+		refreshErr := ""
+		if err != nil {
+			refreshErr = err.Error()
+			s.logger.Warnw("Failed to refresh on-call responders", "team_id", team.ID, "error", err)
+		}
+		if err := s.repo.SaveCurrent(ctx, team.OrganizationID, team.ID, current, refreshErr); err != nil {
+			errs = append(errs, fmt.Errorf("team %s: %w", team.ID, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// TeamOnCall implements OnCallSource from the responders stored at the last
+// refresh, leaving out those whose shift has ended
+func (s *TeamOnCallService) TeamOnCall(ctx context.Context, team *models.Team) []models.OnCallResponder {
+	if team == nil {
+		return nil
+	}
+	o, err := s.repo.Get(ctx, team.ID)
+	if err != nil {
+		s.logger.Warnw("Failed to load team on-call rotation", "team_id", team.ID, "error", err)
+		return nil
+	}
+	if o == nil {
+		return nil
+	}
+	return activeResponders(o.Current, time.Now())
+}
+
+// Rotation returns the on-call rotation shown with a namespace owned by the
+// team, or nil when the team has none
+func (s *TeamOnCallService) Rotation(ctx context.Context, teamID uuid.UUID) *models.TeamOnCall {
+	o, err := s.repo.Get(ctx, teamID)
+	if err != nil {
+		s.logger.Warnw("Failed to load team on-call rotation", "team_id", teamID, "error", err)
+		return nil
+	}
+	if o == nil {
+		return nil
+	}
+	o.Current = activeResponders(o.Current, time.Now())
+	return o
+}
+
+// ask returns who is on call for a team, from PagerDuty first and Opsgenie
+// when PagerDuty has no one
+func (s *TeamOnCallService) ask(ctx context.Context, team *models.Team) ([]models.OnCallResponder, error) {
+	var responders []models.OnCallResponder
+	var err error
+	if team.PagerDutyServiceID.Valid && team.PagerDutyServiceID.String != "" {
+		responders, err = s.pagerDuty.OnCall(ctx, team.OrganizationID, team.PagerDutyServiceID.String)
+		if err != nil {
+			err = fmt.Errorf("pagerduty: %w", err)
+		}
+	}
+	if len(responders) == 0 && team.OpsgenieScheduleID.Valid && team.OpsgenieScheduleID.String != "" {
+		og, ogErr := s.opsgenie.OnCall(ctx, team.OrganizationID, team.OpsgenieScheduleID.String)
+		if ogErr != nil {
+			return nil, errors.Join(err, fmt.Errorf("opsgenie: %w", ogErr))
+		}
+		return og, nil
+	}
+	return responders, err
+}
+
+// rotation returns the stored rotation of a team, empty when it has none
+func (s *TeamOnCallService) rotation(ctx context.Context, teamID uuid.UUID) (*models.TeamOnCall, error) {
+	o, err := s.repo.Get(ctx, teamID)
+	if err != nil {
+		return nil, err
+	}
+	if o == nil {
+		o = &models.TeamOnCall{TeamID: teamID}
+	}
+	if o.EscalationContacts == nil {
+		o.EscalationContacts = []models.EscalationContact{}
+	}
+	if o.Current == nil {
+		o.Current = []models.OnCallResponder{}
+	}
+	return o, nil
+}
+
+// team returns a team of the organization
+func (s *TeamOnCallService) team(ctx context.Context, orgID, teamID uuid.UUID) (*models.Team, error) {
+	team, err := s.teamRepo.GetByID(ctx, teamID)
+	if err != nil {
+		return nil, err
+	}
+	if team == nil || team.OrganizationID != orgID {
+		return nil, ErrTeamNotFound
+	}
+	return team, nil
+}
+
+// validateRotationURL trims a rotation URL, which must be empty or http(s)
+func validateRotationURL(raw string) (string, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return "", nil
+	}
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return "", fmt.Errorf("%w: rotation_url must be an http(s) URL", ErrInvalidOnCallRotation)
+	}
+	return raw, nil
+}
+
+// validateEscalationContacts trims escalation contacts and orders them by
+// level, keeping the given order within a level. Each needs a name or an
+// email address and a level of at least 1.
+func validateEscalationContacts(contacts []models.EscalationContact) ([]models.EscalationContact, error) {
+	if len(contacts) > maxEscalationContacts {
+		return nil, fmt.Errorf("%w: at most %d escalation contacts are allowed", ErrInvalidOnCallRotation, maxEscalationContacts)
+	}
+	valid := make([]models.EscalationContact, len(contacts))
+	for i, c := range contacts {
+		c.Name = strings.TrimSpace(c.Name)
+		c.Email = strings.TrimSpace(c.Email)
+		c.Phone = strings.TrimSpace(c.Phone)
+		if c.Name == "" && c.Email == "" {
+			return nil, fmt.Errorf("%w: escalation contact %d needs a name or an email", ErrInvalidOnCallRotation, i+1)
+		}
+		if c.Level < 1 {
+			return nil, fmt.Errorf("%w: escalation contact %d needs a level of at least 1", ErrInvalidOnCallRotation, i+1)
+		}
+		if c.Email != "" {
+			if addr, err := mail.ParseAddress(c.Email); err != nil || addr.Address != c.Email {
+				return nil, fmt.Errorf("%w: invalid email %q", ErrInvalidOnCallRotation, c.Email)
+			}
+		}
+		valid[i] = c
+	}
+	sort.SliceStable(valid, func(i, j int) bool { return valid[i].Level < valid[j].Level })
+	return valid, nil
+}
+
+// activeResponders returns the responders still on call at now
+func activeResponders(responders []models.OnCallResponder, now time.Time) []models.OnCallResponder {
+	active := make([]models.OnCallResponder, 0, len(responders))
+	for _, r := range responders {
+		if r.Until == nil || r.Until.After(now) {
+			active = append(active, r)
+		}
+	}
+	return active
+}
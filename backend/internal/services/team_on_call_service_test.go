@@ -0,0 +1,64 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/kubeatlas/kubeatlas/internal/models"
+)
+
+func TestValidateRotationURL(t *testing.T) {
+	if got, err := validateRotationURL(" https://acme.pagerduty.com/schedules/P1 "); err != nil || got != "https://acme.pagerduty.com/schedules/P1" {
+		t.Errorf("validateRotationURL() = %q, %v", got, err)
+	}
+	if got, err := validateRotationURL(""); err != nil || got != "" {
+		t.Errorf("validateRotationURL() of nothing = %q, %v", got, err)
+	}
+	for _, raw := range []string{"ftp://acme.com/rotation", "acme.com/rotation", "https://"} {
+		if _, err := validateRotationURL(raw); !errors.Is(err, ErrInvalidOnCallRotation) {
+			t.Errorf("validateRotationURL(%q) error = %v, want ErrInvalidOnCallRotation", raw, err)
+		}
+	}
+}
+
+func TestValidateEscalationContacts(t *testing.T) {
+	got, err := validateEscalationContacts([]models.EscalationContact{
+		{Level: 2, Name: " Grace ", Email: "grace@acme.com"},
+		{Level: 1, Name: "Ada"},
+		{Level: 2, Email: "sre-leads@acme.com"},
+	})
+	if err != nil {
+		t.Fatalf("validateEscalationContacts() error = %v", err)
+	}
+	want := []string{"Ada", "Grace", "sre-leads@acme.com"}
+	for i, c := range got {
+		if c.Name != want[i] && c.Email != want[i] {
+			t.Errorf("contact %d = %+v, want %s", i, c, want[i])
+		}
+	}
+
+	invalid := [][]models.EscalationContact{
+		{{Level: 1}},
+		{{Level: 0, Name: "Ada"}},
+		{{Level: 1, Email: "not an email"}},
+	}
+	for _, contacts := range invalid {
+		if _, err := validateEscalationContacts(contacts); !errors.Is(err, ErrInvalidOnCallRotation) {
+			t.Errorf("validateEscalationContacts(%+v) error = %v, want ErrInvalidOnCallRotation", contacts, err)
+		}
+	}
+}
+
+func TestActiveResponders(t *testing.T) {
+	now := time.Now()
+	ended, later := now.Add(-time.Hour), now.Add(time.Hour)
+	got := activeResponders([]models.OnCallResponder{
+		{Name: "ended", Until: &ended},
+		{Name: "on call", Until: &later},
+		{Name: "always"},
+	}, now)
+	if len(got) != 2 || got[0].Name != "on call" || got[1].Name != "always" {
+		t.Errorf("activeResponders() = %+v, want the two still on call", got)
+	}
+}
@@ -0,0 +1,306 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/kubeatlas/kubeatlas/internal/database/repositories"
+	"github.com/kubeatlas/kubeatlas/internal/models"
+	"go.uber.org/zap"
+)
+
+var ErrInvalidDirectoryGroup = errors.New("invalid directory group")
+
+// maxDirectoryGroupName bounds the length of directory group names
+const maxDirectoryGroupName = 255
+
+// TeamSyncService keeps the rosters of teams made up of directory groups in
+// line with the directory. Group members are read from LDAP on a schedule or
+// pushed by the identity provider over SCIM; every user joining or leaving a
+// team is recorded in its changelog.
+type TeamSyncService struct {
+	repo     *repositories.TeamSyncRepository
+	teamRepo *repositories.TeamRepository
+	ldap     *LDAPService
+	auditSvc *AuditService
+	logger   *zap.SugaredLogger
+}
+
+// NewTeamSyncService creates a new team sync service
+func NewTeamSyncService(repo *repositories.TeamSyncRepository, teamRepo *repositories.TeamRepository, ldap *LDAPService, auditSvc *AuditService, logger *zap.SugaredLogger) *TeamSyncService {
+	return &TeamSyncService{repo: repo, teamRepo: teamRepo, ldap: ldap, auditSvc: auditSvc, logger: logger}
+}
+
+// ListGroups returns the directory groups making up a team of the
+// organization
+func (s *TeamSyncService) ListGroups(ctx context.Context, orgID, teamID uuid.UUID) ([]models.TeamDirectoryGroup, error) {
+	if _, err := s.team(ctx, orgID, teamID); err != nil {
+		return nil, err
+	}
+	return s.repo.ListGroups(ctx, teamID)
+}
+
+// SetGroups makes up a team of exactly the given directory groups. Without
+// groups, the team's roster is managed by hand again.
+func (s *TeamSyncService) SetGroups(ctx context.Context, ac AuditContext, teamID uuid.UUID, groups []string) ([]models.TeamDirectoryGroup, error) {
+	team, err := s.team(ctx, ac.OrgID, teamID)
+	if err != nil {
+		return nil, err
+	}
+	groups, err = directoryGroupNames(groups)
+	if err != nil {
+		return nil, err
+	}
+	before, err := s.repo.ListGroups(ctx, teamID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.ReplaceGroups(ctx, ac.OrgID, teamID, groups); err != nil {
+		return nil, err
+	}
+
+	previous := make([]string, len(before))
+	for i, g := range before {
+		previous[i] = g.GroupName
+	}
+	s.auditSvc.LogUpdate(ctx, ac, "team", team.ID, team.Name,
+		map[string]interface{}{"directory_groups": previous},
+		map[string]interface{}{"directory_groups": groups})
+	return s.repo.ListGroups(ctx, teamID)
+}
+
+// Changes returns up to limit membership changes of a team of the
+// organization, newest first
+func (s *TeamSyncService) Changes(ctx context.Context, orgID, teamID uuid.UUID, limit int) ([]models.TeamMembershipChange, error) {
+	if limit <= 0 || limit > 500 {
+		limit = 100
+	}
+	if _, err := s.team(ctx, orgID, teamID); err != nil {
+		return nil, err
+	}
+	return s.repo.ListChanges(ctx, teamID, limit)
+}
+
+// SyncAll reads the members of every organization's mapped groups from LDAP
+// and reconciles the teams made up of them. Organizations without LDAP are
+// skipped, and a failing organization does not stop the others.
+func (s *TeamSyncService) SyncAll(ctx context.Context) error {
+	orgIDs, err := s.repo.ListMappedOrganizationIDs(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list organizations: %w", err)
+	}
+
+	var errs []error
+	for _, orgID := range orgIDs {
+		result, err := s.SyncLDAP(ctx, AuditContext{OrgID: orgID, UserEmail: "system"})
+		switch {
+		case errors.Is(err, ErrLDAPNotConfigured):
+		case err != nil:
+			errs = append(errs, fmt.Errorf("organization %s: %w", orgID, err))
+		case result.Joined > 0 || result.Removed > 0:
+			s.logger.Infow("Synchronized teams from LDAP", "organization_id", orgID, "joined", result.Joined, "removed", result.Removed)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// SyncLDAP reads the members of the organization's mapped groups from LDAP
+// and reconciles the teams made up of them
+func (s *TeamSyncService) SyncLDAP(ctx context.Context, ac AuditContext) (*models.TeamSyncResult, error) {
+	names, err := s.repo.ListMappedGroupNames(ctx, ac.OrgID)
+	if err != nil {
+		return nil, err
+	}
+	if len(names) == 0 {
+		return &models.TeamSyncResult{UnknownEmails: []string{}}, nil
+	}
+	members, err := s.ldap.GroupMembers(ctx, ac.OrgID, names)
+	if err != nil {
+		return nil, err
+	}
+	for name, emails := range members {
+		group := &models.DirectoryGroup{OrganizationID: ac.OrgID, Name: name, Members: emails, Source: models.DirectorySourceLDAP}
+		if err := s.repo.SaveGroup(ctx, group); err != nil {
+			return nil, err
+		}
+	}
+	return s.reconcile(ctx, ac, "", models.DirectorySourceLDAP)
+}
+
+// PushGroup stores the members of a directory group pushed by the identity
+// provider, given by email address, and reconciles the teams it makes up
+func (s *TeamSyncService) PushGroup(ctx context.Context, ac AuditContext, name string, emails []string) (*models.TeamSyncResult, error) {
+	names, err := directoryGroupNames([]string{name})
+	if err != nil {
+		return nil, err
+	}
+	if len(names) == 0 {
+		return nil, fmt.Errorf("%w: the group name is required", ErrInvalidDirectoryGroup)
+	}
+
+	seen := make(map[string]bool, len(emails))
+	members := make([]string, 0, len(emails))
+	for _, email := range emails {
+		email = strings.ToLower(strings.TrimSpace(email))
+		if email != "" && !seen[email] {
+			seen[email] = true
+			members = append(members, email)
+		}
+	}
+	group := &models.DirectoryGroup{OrganizationID: ac.OrgID, Name: names[0], Members: members, Source: models.DirectorySourceSCIM}
+	if err := s.repo.SaveGroup(ctx, group); err != nil {
+		return nil, err
+	}
+	return s.reconcile(ctx, ac, names[0], models.DirectorySourceSCIM)
+}
+
+// reconcile brings the rosters of the organization's teams made up of
+// directory groups, or with group set of those the group is part of, in line
+// with the members last seen of their groups
+func (s *TeamSyncService) reconcile(ctx context.Context, ac AuditContext, group, source string) (*models.TeamSyncResult, error) {
+	rosters, err := s.repo.ListRosters(ctx, ac.OrgID, group)
+	if err != nil {
+		return nil, err
+	}
+
+	var emails []string
+	for _, roster := range rosters {
+		if roster.Complete {
+			emails = append(emails, roster.Members...)
+		}
+	}
+	users, err := s.repo.ListUserIDsByEmail(ctx, ac.OrgID, emails)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &models.TeamSyncResult{UnknownEmails: []string{}}
+	unknown := make(map[string]bool)
+	for _, roster := range rosters {
+		if !roster.Complete {
+			continue
+		}
+		current, err := s.teamRepo.GetMembers(ctx, roster.TeamID)
+		if err != nil {
+			return nil, err
+		}
+		changes, missing := rosterChanges(ac.OrgID, roster.TeamID, source, current, roster.Members, users)
+		for _, email := range missing {
+			unknown[email] = true
+		}
+		result.Teams++
+		if len(changes) == 0 {
+			continue
+		}
+		if err := s.repo.ApplyChanges(ctx, changes); err != nil {
+			return nil, err
+		}
+
+		joined, removed := 0, 0
+		for _, c := range changes {
+			if c.Change == models.TeamMembershipJoined {
+				joined++
+			} else {
+				removed++
+			}
+		}
+		result.Joined += joined
+		result.Removed += removed
+		s.auditSvc.LogAction(ctx, ac, "sync_members", "team", roster.TeamID, "",
+			fmt.Sprintf("Synchronized members from %s: %d joined, %d removed", source, joined, removed))
+	}
+
+	for email := range unknown {
+		result.UnknownEmails = append(result.UnknownEmails, email)
+	}
+	sort.Strings(result.UnknownEmails)
+	return result, nil
+}
+
+// team returns a team of the organization
+func (s *TeamSyncService) team(ctx context.Context, orgID, teamID uuid.UUID) (*models.Team, error) {
+	team, err := s.teamRepo.GetByID(ctx, teamID)
+	if err != nil {
+		return nil, err
+	}
+	if team == nil || team.OrganizationID != orgID {
+		return nil, ErrTeamNotFound
+	}
+	return team, nil
+}
+
+// directoryGroupNames trims group names, dropping empty and repeated ones,
+// ignoring case
+func directoryGroupNames(groups []string) ([]string, error) {
+	seen := make(map[string]bool, len(groups))
+	names := make([]string, 0, len(groups))
+	for _, g := range groups {
+		g = strings.TrimSpace(g)
+		if g == "" || seen[strings.ToLower(g)] {
+			continue
+		}
+		if len(g) > maxDirectoryGroupName {
+			return nil, fmt.Errorf("%w: %q is longer than %d characters", ErrInvalidDirectoryGroup, g, maxDirectoryGroupName)
+		}
+		seen[strings.ToLower(g)] = true
+		names = append(names, g)
+	}
+	return names, nil
+}
+
+// rosterChanges returns the changes bringing a team's current members in
+// line with the lowercase email addresses of its directory groups, users
+// joining first, each by email address. users resolves email addresses to
+// the organization's users; members without an account are returned as
+// unknown.
+func rosterChanges(orgID, teamID uuid.UUID, source string, current []models.TeamMember, members []string, users map[string]uuid.UUID) (changes []models.TeamMembershipChange, unknown []string) {
+	desired := make(map[uuid.UUID]string, len(members))
+	for _, email := range members {
+		if id, ok := users[email]; ok {
+			desired[id] = email
+		} else {
+			unknown = append(unknown, email)
+		}
+	}
+	have := make(map[uuid.UUID]bool, len(current))
+	for _, m := range current {
+		have[m.UserID] = true
+	}
+
+	change := func(userID uuid.UUID, email, kind string) models.TeamMembershipChange {
+		return models.TeamMembershipChange{
+			OrganizationID: orgID,
+			TeamID:         teamID,
+			UserID:         &userID,
+			Email:          email,
+			Change:         kind,
+			Source:         source,
+		}
+	}
+	var joined, removed []models.TeamMembershipChange
+	for id, email := range desired {
+		if !have[id] {
+			joined = append(joined, change(id, email, models.TeamMembershipJoined))
+		}
+	}
+	for _, m := range current {
+		if _, ok := desired[m.UserID]; !ok {
+			email := ""
+			if m.User != nil {
+				email = strings.ToLower(m.User.Email)
+			}
+			removed = append(removed, change(m.UserID, email, models.TeamMembershipRemoved))
+		}
+	}
+	byEmail := func(list []models.TeamMembershipChange) {
+		sort.Slice(list, func(i, j int) bool { return list[i].Email < list[j].Email })
+	}
+	byEmail(joined)
+	byEmail(removed)
+	sort.Strings(unknown)
+	return append(joined, removed...), unknown
+}
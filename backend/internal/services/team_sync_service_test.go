@@ -0,0 +1,68 @@
+package services
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/kubeatlas/kubeatlas/internal/models"
+)
+
+func TestDirectoryGroupNames(t *testing.T) {
+	got, err := directoryGroupNames([]string{" platform-team ", "", "Platform-Team", "sre"})
+	if err != nil {
+		t.Fatalf("directoryGroupNames() error = %v", err)
+	}
+	if want := []string{"platform-team", "sre"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("directoryGroupNames() = %q, want %q", got, want)
+	}
+
+	if _, err := directoryGroupNames([]string{strings.Repeat("g", 256)}); !errors.Is(err, ErrInvalidDirectoryGroup) {
+		t.Errorf("directoryGroupNames() of a long name error = %v, want ErrInvalidDirectoryGroup", err)
+	}
+}
+
+func TestRosterChanges(t *testing.T) {
+	orgID, teamID := uuid.New(), uuid.New()
+	alice, bob, carol, dave := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+	users := map[string]uuid.UUID{
+		"alice@example.com": alice,
+		"bob@example.com":   bob,
+		"carol@example.com": carol,
+	}
+	current := []models.TeamMember{
+		{UserID: alice, User: &models.User{Email: "Alice@example.com"}},
+		{UserID: dave, User: &models.User{Email: "dave@example.com"}},
+	}
+	members := []string{"carol@example.com", "alice@example.com", "bob@example.com", "erin@example.com"}
+
+	changes, unknown := rosterChanges(orgID, teamID, models.DirectorySourceLDAP, current, members, users)
+
+	var got []string
+	for _, c := range changes {
+		if c.OrganizationID != orgID || c.TeamID != teamID || c.Source != models.DirectorySourceLDAP || c.UserID == nil {
+			t.Errorf("change = %+v, want the team, source and user set", c)
+		}
+		got = append(got, c.Change+" "+c.Email)
+	}
+	want := []string{"joined bob@example.com", "joined carol@example.com", "removed dave@example.com"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("changes = %q, want %q", got, want)
+	}
+	if !reflect.DeepEqual(unknown, []string{"erin@example.com"}) {
+		t.Errorf("unknown = %q, want erin@example.com", unknown)
+	}
+
+	// An empty group empties the team
+	changes, _ = rosterChanges(orgID, teamID, models.DirectorySourceSCIM, current, nil, users)
+	if len(changes) != 2 || changes[0].Change != models.TeamMembershipRemoved || changes[1].Change != models.TeamMembershipRemoved {
+		t.Errorf("changes for an empty group = %+v, want both members removed", changes)
+	}
+
+	// A roster in line with its groups changes nothing
+	if changes, _ := rosterChanges(orgID, teamID, models.DirectorySourceLDAP, current[:1], []string{"alice@example.com"}, users); len(changes) != 0 {
+		t.Errorf("changes = %+v, want none", changes)
+	}
+}
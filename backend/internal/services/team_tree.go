@@ -0,0 +1,115 @@
+package services
+
+import (
+	"github.com/google/uuid"
+	"github.com/kubeatlas/kubeatlas/internal/models"
+)
+
+// checkTeamParent verifies that the team id can be placed under parentID:
+// the parent must be one of teams, and neither the team itself nor one of
+// its descendants. id is uuid.Nil for a team being created.
+func checkTeamParent(teams []models.Team, id, parentID uuid.UUID) error {
+	parents := make(map[uuid.UUID]*uuid.UUID, len(teams))
+	for _, t := range teams {
+		parents[t.ID] = t.ParentID
+	}
+	if _, ok := parents[parentID]; !ok {
+		return ErrTeamParentNotFound
+	}
+	// Walk up from the new parent; reaching the team means a cycle. Stop
+	// after visiting every team in case the stored tree already has one.
+	current := &parentID
+	for steps := 0; current != nil && steps <= len(teams); steps++ {
+		if *current == id {
+			return ErrTeamCycle
+		}
+		current = parents[*current]
+	}
+	return nil
+}
+
+// buildTeamTree arranges teams, ordered by name, into a tree. Teams whose
+// parent is missing are placed at the top level. members lists the users of
+// each team, counted once per subtree however many of its teams they are in.
+func buildTeamTree(teams []models.Team, members map[uuid.UUID][]uuid.UUID) []*models.TeamNode {
+	nodes := make(map[uuid.UUID]*models.TeamNode, len(teams))
+	for _, t := range teams {
+		nodes[t.ID] = &models.TeamNode{Team: t, Children: []*models.TeamNode{}}
+	}
+
+	roots := make([]*models.TeamNode, 0)
+	for _, t := range teams {
+		node := nodes[t.ID]
+		if t.ParentID != nil {
+			if parent, ok := nodes[*t.ParentID]; ok && !teamAbove(nodes, node, parent) {
+				parent.Children = append(parent.Children, node)
+				continue
+			}
+		}
+		roots = append(roots, node)
+	}
+
+	var countMembers func(node *models.TeamNode) map[uuid.UUID]bool
+	countMembers = func(node *models.TeamNode) map[uuid.UUID]bool {
+		users := make(map[uuid.UUID]bool)
+		for _, u := range members[node.Team.ID] {
+			users[u] = true
+		}
+		for _, child := range node.Children {
+			for u := range countMembers(child) {
+				users[u] = true
+			}
+		}
+		node.SubtreeMemberCount = len(users)
+		return users
+	}
+	for _, root := range roots {
+		countMembers(root)
+	}
+	return roots
+}
+
+// teamAbove reports whether node is already an ancestor of other in the
+// partly built tree, so a corrupt cycle of parents is broken instead of
+// leaving the teams unreachable
+func teamAbove(nodes map[uuid.UUID]*models.TeamNode, node, other *models.TeamNode) bool {
+	for steps := 0; other != nil && steps <= len(nodes); steps++ {
+		if other == node {
+			return true
+		}
+		if other.Team.ParentID == nil {
+			return false
+		}
+		other = nodes[*other.Team.ParentID]
+	}
+	return false
+}
+
+// teamDescendants returns the teams below the team id, level by level
+func teamDescendants(teams []models.Team, id uuid.UUID) []models.Team {
+	children := make(map[uuid.UUID][]models.Team)
+	for _, t := range teams {
+		if t.ParentID != nil {
+			children[*t.ParentID] = append(children[*t.ParentID], t)
+		}
+	}
+
+	descendants := make([]models.Team, 0)
+	seen := map[uuid.UUID]bool{id: true}
+	level := []uuid.UUID{id}
+	for len(level) > 0 {
+		var next []uuid.UUID
+		for _, parentID := range level {
+			for _, t := range children[parentID] {
+				if seen[t.ID] {
+					continue
+				}
+				seen[t.ID] = true
+				descendants = append(descendants, t)
+				next = append(next, t.ID)
+			}
+		}
+		level = next
+	}
+	return descendants
+}
@@ -0,0 +1,108 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/kubeatlas/kubeatlas/internal/models"
+)
+
+func TestCheckTeamParent(t *testing.T) {
+	platform := models.Team{Name: "Platform"}
+	platform.ID = uuid.New()
+	compute := models.Team{Name: "Compute", ParentID: &platform.ID}
+	compute.ID = uuid.New()
+	nodes := models.Team{Name: "Nodes", ParentID: &compute.ID}
+	nodes.ID = uuid.New()
+	teams := []models.Team{platform, compute, nodes}
+
+	if err := checkTeamParent(teams, nodes.ID, platform.ID); err != nil {
+		t.Errorf("moving a team up: %v", err)
+	}
+	if err := checkTeamParent(teams, uuid.Nil, nodes.ID); err != nil {
+		t.Errorf("creating a team under a leaf: %v", err)
+	}
+	if err := checkTeamParent(teams, platform.ID, platform.ID); !errors.Is(err, ErrTeamCycle) {
+		t.Errorf("placing a team under itself: %v, want ErrTeamCycle", err)
+	}
+	if err := checkTeamParent(teams, platform.ID, nodes.ID); !errors.Is(err, ErrTeamCycle) {
+		t.Errorf("placing a team under its descendant: %v, want ErrTeamCycle", err)
+	}
+	if err := checkTeamParent(teams, nodes.ID, uuid.New()); !errors.Is(err, ErrTeamParentNotFound) {
+		t.Errorf("placing a team under an unknown team: %v, want ErrTeamParentNotFound", err)
+	}
+}
+
+func TestBuildTeamTree(t *testing.T) {
+	platform := models.Team{Name: "Platform"}
+	platform.ID = uuid.New()
+	compute := models.Team{Name: "Compute", ParentID: &platform.ID}
+	compute.ID = uuid.New()
+	network := models.Team{Name: "Network", ParentID: &platform.ID}
+	network.ID = uuid.New()
+	nodes := models.Team{Name: "Nodes", ParentID: &compute.ID}
+	nodes.ID = uuid.New()
+	missing := uuid.New()
+	orphan := models.Team{Name: "Orphan", ParentID: &missing}
+	orphan.ID = uuid.New()
+
+	alice, bob, carol := uuid.New(), uuid.New(), uuid.New()
+	members := map[uuid.UUID][]uuid.UUID{
+		platform.ID: {alice},
+		compute.ID:  {bob},
+		network.ID:  {bob, carol},
+		nodes.ID:    {alice, carol},
+	}
+
+	roots := buildTeamTree([]models.Team{compute, network, nodes, orphan, platform}, members)
+	if len(roots) != 2 || roots[0].Team.Name != "Orphan" || roots[1].Team.Name != "Platform" {
+		t.Fatalf("roots = %+v, want Orphan and Platform", roots)
+	}
+	root := roots[1]
+	if root.SubtreeMemberCount != 3 {
+		t.Errorf("Platform subtree members = %d, want 3", root.SubtreeMemberCount)
+	}
+	if len(root.Children) != 2 || root.Children[0].Team.Name != "Compute" || root.Children[1].Team.Name != "Network" {
+		t.Fatalf("Platform children = %+v, want Compute and Network", root.Children)
+	}
+	if got := root.Children[0].SubtreeMemberCount; got != 3 {
+		t.Errorf("Compute subtree members = %d, want 3", got)
+	}
+	if got := root.Children[0].Children[0].SubtreeMemberCount; got != 2 {
+		t.Errorf("Nodes subtree members = %d, want 2", got)
+	}
+	if roots[0].SubtreeMemberCount != 0 || roots[0].Children == nil {
+		t.Errorf("Orphan = %+v, want no members and empty children", roots[0])
+	}
+
+	// A stored cycle of parents still lists every team
+	a := models.Team{Name: "A"}
+	a.ID = uuid.New()
+	b := models.Team{Name: "B", ParentID: &a.ID}
+	b.ID = uuid.New()
+	a.ParentID = &b.ID
+	if roots := buildTeamTree([]models.Team{a, b}, nil); len(roots) == 0 {
+		t.Error("teams in a cycle are missing from the tree")
+	}
+}
+
+func TestTeamDescendants(t *testing.T) {
+	platform := models.Team{Name: "Platform"}
+	platform.ID = uuid.New()
+	compute := models.Team{Name: "Compute", ParentID: &platform.ID}
+	compute.ID = uuid.New()
+	nodes := models.Team{Name: "Nodes", ParentID: &compute.ID}
+	nodes.ID = uuid.New()
+	network := models.Team{Name: "Network", ParentID: &platform.ID}
+	network.ID = uuid.New()
+	teams := []models.Team{compute, network, nodes, platform}
+
+	got := teamDescendants(teams, platform.ID)
+	if len(got) != 3 || got[0].Name != "Compute" || got[1].Name != "Network" || got[2].Name != "Nodes" {
+		t.Errorf("descendants of Platform = %+v, want Compute, Network, Nodes", got)
+	}
+	if got := teamDescendants(teams, nodes.ID); len(got) != 0 {
+		t.Errorf("descendants of Nodes = %+v, want none", got)
+	}
+}
@@ -0,0 +1,90 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/kubeatlas/kubeatlas/internal/database/repositories"
+	"github.com/kubeatlas/kubeatlas/internal/models"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrInvalidTrashType  = errors.New("invalid trash type")
+	ErrTrashItemNotFound = errors.New("deleted record not found")
+	ErrRestoreBlocked    = errors.New("record cannot be restored while its parent is deleted")
+)
+
+// TrashService lists and restores soft-deleted clusters, namespaces, teams
+// and documents. Records stay in the trash until data retention purges them.
+type TrashService struct {
+	repo     *repositories.TrashRepository
+	auditSvc *AuditService
+	logger   *zap.SugaredLogger
+}
+
+// NewTrashService creates a new trash service
+func NewTrashService(repo *repositories.TrashRepository, auditSvc *AuditService, logger *zap.SugaredLogger) *TrashService {
+	return &TrashService{
+		repo:     repo,
+		auditSvc: auditSvc,
+		logger:   logger,
+	}
+}
+
+// trashTypes returns the types to list: typ, or every type when it is empty
+func trashTypes(typ string) ([]string, error) {
+	if typ == "" {
+		return repositories.TrashTypes, nil
+	}
+	for _, t := range repositories.TrashTypes {
+		if t == typ {
+			return []string{typ}, nil
+		}
+	}
+	return nil, fmt.Errorf("%w %q: must be one of %v", ErrInvalidTrashType, typ, repositories.TrashTypes)
+}
+
+// List retrieves the organization's deleted records of typ, or of every type
+// when it is empty, the most recently deleted first
+func (s *TrashService) List(ctx context.Context, orgID uuid.UUID, typ string, p repositories.Pagination) (*repositories.PaginatedResult[models.TrashItem], error) {
+	types, err := trashTypes(typ)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.List(ctx, orgID, types, p)
+}
+
+// Restore undeletes a record of typ. A cluster is restored with the
+// namespaces deleted with it; a namespace or document whose cluster or
+// namespace is still deleted cannot be restored.
+func (s *TrashService) Restore(ctx context.Context, ac AuditContext, typ string, id uuid.UUID) (*models.TrashItem, error) {
+	item, err := s.repo.Get(ctx, ac.OrgID, typ, id)
+	if err != nil {
+		return nil, err
+	}
+	if item == nil {
+		return nil, ErrTrashItemNotFound
+	}
+	if item.ParentDeleted {
+		return nil, fmt.Errorf("%w: restore %s first", ErrRestoreBlocked, item.Parent)
+	}
+
+	restored, namespaces, err := s.repo.Restore(ctx, typ, id, item.DeletedAt)
+	if err != nil {
+		return nil, err
+	}
+	if !restored {
+		return nil, ErrTrashItemNotFound
+	}
+
+	description := fmt.Sprintf("Restored %s %s", typ, item.Name)
+	if namespaces > 0 {
+		description += fmt.Sprintf(" with %d namespaces", namespaces)
+	}
+	s.auditSvc.LogAction(ctx, ac, "restore", typ, id, item.Name, description)
+	s.logger.Infow("Record restored", "type", typ, "id", id, "namespaces", namespaces)
+	return item, nil
+}
@@ -0,0 +1,23 @@
+package services
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/kubeatlas/kubeatlas/internal/database/repositories"
+)
+
+func TestTrashTypes(t *testing.T) {
+	types, err := trashTypes("")
+	if err != nil || !reflect.DeepEqual(types, repositories.TrashTypes) {
+		t.Errorf("trashTypes(\"\") = %v, %v, want every type", types, err)
+	}
+	types, err = trashTypes(repositories.TrashNamespace)
+	if err != nil || !reflect.DeepEqual(types, []string{repositories.TrashNamespace}) {
+		t.Errorf("trashTypes(namespace) = %v, %v", types, err)
+	}
+	if _, err := trashTypes("user"); !errors.Is(err, ErrInvalidTrashType) {
+		t.Errorf("trashTypes(user) error = %v, want ErrInvalidTrashType", err)
+	}
+}
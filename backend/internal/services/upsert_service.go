@@ -0,0 +1,174 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/kubeatlas/kubeatlas/internal/escalation"
+	"github.com/kubeatlas/kubeatlas/internal/models"
+)
+
+// Outcomes of an upsert
+const (
+	UpsertCreated   = "created"
+	UpsertUpdated   = "updated"
+	UpsertUnchanged = "unchanged"
+)
+
+// UpsertResult reports what an upsert did. Upserts that would change nothing
+// write nothing, so automation can repeat them freely.
+type UpsertResult struct {
+	Action  string        `json:"action"`
+	Changes []FieldChange `json:"changes"`
+}
+
+// upsertChanges lists the fields that differ between two versions of a
+// resource, compared as they are serialized
+func upsertChanges(before, after interface{}) ([]FieldChange, error) {
+	b, err := jsonValues(before)
+	if err != nil {
+		return nil, err
+	}
+	a, err := jsonValues(after)
+	if err != nil {
+		return nil, err
+	}
+	return diffAuditValues(b, a), nil
+}
+
+func jsonValues(v interface{}) (map[string]interface{}, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var values map[string]interface{}
+	err = json.Unmarshal(data, &values)
+	return values, err
+}
+
+// UpsertNamespaceRequest creates or updates the namespace named Name in the
+// cluster named Cluster. Empty fields are left unchanged.
+type UpsertNamespaceRequest struct {
+	Cluster string `json:"cluster" binding:"required"`
+	Name    string `json:"name" binding:"required"`
+	UpdateNamespaceRequest
+}
+
+// Upsert creates or updates a namespace keyed by cluster and namespace name.
+// Namespaces created ahead of a cluster sync are picked up by it.
+func (s *NamespaceService) Upsert(ctx context.Context, ac AuditContext, req UpsertNamespaceRequest) (*models.Namespace, *UpsertResult, error) {
+	if _, err := escalation.Parse(req.EscalationPath); err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrInvalidEscalationPath, err)
+	}
+	cluster, err := s.clusterRepo.GetByName(ctx, ac.OrgID, req.Cluster)
+	if err != nil {
+		return nil, nil, err
+	}
+	if cluster == nil {
+		return nil, nil, ErrClusterNotFound
+	}
+	existing, err := s.namespaceRepo.GetByClusterAndName(ctx, cluster.ID, req.Name)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if existing == nil {
+		ns, err := s.create(ctx, ac, cluster, req)
+		if err != nil {
+			return nil, nil, err
+		}
+		return ns, &UpsertResult{Action: UpsertCreated, Changes: []FieldChange{}}, nil
+	}
+
+	desired := *existing
+	applyNamespaceUpdate(&desired, req.UpdateNamespaceRequest)
+	changes, err := upsertChanges(existing, &desired)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(changes) == 0 {
+		return existing, &UpsertResult{Action: UpsertUnchanged, Changes: changes}, nil
+	}
+	ns, err := s.Update(ctx, ac, existing.ID, req.UpdateNamespaceRequest)
+	if err != nil {
+		return nil, nil, err
+	}
+	return ns, &UpsertResult{Action: UpsertUpdated, Changes: changes}, nil
+}
+
+// create registers a namespace of a cluster before a sync discovers it
+func (s *NamespaceService) create(ctx context.Context, ac AuditContext, cluster *models.Cluster, req UpsertNamespaceRequest) (*models.Namespace, error) {
+	if req.Environment != "" {
+		if err := s.settings.ValidateEnvironment(ctx, ac.OrgID, req.Environment); err != nil {
+			return nil, err
+		}
+	}
+	tiers, err := s.settings.CriticalityTiers(ctx, ac.OrgID)
+	if err != nil {
+		return nil, err
+	}
+
+	ns := &models.Namespace{
+		OrganizationID: ac.OrgID,
+		ClusterID:      cluster.ID,
+		Name:           req.Name,
+		Status:         "active",
+		Environment:    "unknown",
+		Criticality:    tiers[len(tiers)-1].Name,
+		K8sLabels:      make(models.JSONMap),
+		K8sAnnotations: make(models.JSONMap),
+		Tags:           []string{},
+		CustomFields:   make(models.JSONMap),
+		Metadata:       make(models.JSONMap),
+	}
+	applyNamespaceUpdate(ns, req.UpdateNamespaceRequest)
+	if ns.Tags == nil {
+		ns.Tags = []string{}
+	}
+	if err := ns.Validate(tiers); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidNamespace, err)
+	}
+
+	if err := s.namespaceRepo.Create(ctx, ns); err != nil {
+		return nil, err
+	}
+	s.auditSvc.LogCreate(ctx, ac, "namespace", ns.ID, ns.Name, StructToMap(ns))
+	s.logger.Infow("Namespace created", "namespace_id", ns.ID, "cluster_id", cluster.ID, "name", ns.Name)
+	s.webhooks.Publish(ctx, ns.OrganizationID, models.WebhookEventNamespaceCreated, ns)
+	return ns, nil
+}
+
+// UpsertBySlug creates or updates the team with the slug of req, or the slug
+// generated from its name when it has none
+func (s *TeamService) UpsertBySlug(ctx context.Context, ac AuditContext, req CreateTeamRequest) (*models.Team, *UpsertResult, error) {
+	if req.Slug == "" {
+		req.Slug = generateSlug(req.Name)
+	}
+	existing, err := s.repo.GetBySlug(ctx, ac.OrgID, req.Slug)
+	if err != nil {
+		return nil, nil, err
+	}
+	if existing == nil {
+		team, err := s.Create(ctx, ac, req)
+		if err != nil {
+			return nil, nil, err
+		}
+		return team, &UpsertResult{Action: UpsertCreated, Changes: []FieldChange{}}, nil
+	}
+
+	desired := *existing
+	applyTeamUpdate(&desired, req)
+	changes, err := upsertChanges(existing, &desired)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(changes) == 0 {
+		return existing, &UpsertResult{Action: UpsertUnchanged, Changes: changes}, nil
+	}
+	team, err := s.Update(ctx, ac, existing.ID, req)
+	if err != nil {
+		return nil, nil, err
+	}
+	return team, &UpsertResult{Action: UpsertUpdated, Changes: changes}, nil
+}
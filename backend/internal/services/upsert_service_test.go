@@ -0,0 +1,66 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/kubeatlas/kubeatlas/internal/models"
+)
+
+func TestUpsertChangesNamespace(t *testing.T) {
+	team := uuid.New()
+	existing := &models.Namespace{
+		Name:                      "payments",
+		Environment:               "production",
+		Criticality:               "tier-1",
+		InfrastructureOwnerTeamID: &team,
+		TechnicalLeadEmail:        models.NewNullStringFromString("lead@example.com"),
+		Tags:                      models.StringArray{"pci"},
+	}
+	sameTeam := team
+
+	// Repeating the current values changes nothing
+	desired := *existing
+	applyNamespaceUpdate(&desired, UpdateNamespaceRequest{
+		Environment:               "production",
+		InfrastructureOwnerTeamID: &sameTeam,
+		TechnicalLeadEmail:        "lead@example.com",
+		Tags:                      []string{"pci"},
+	})
+	changes, err := upsertChanges(existing, &desired)
+	if err != nil {
+		t.Fatalf("upsertChanges failed: %v", err)
+	}
+	if len(changes) != 0 {
+		t.Errorf("changes = %+v, want none", changes)
+	}
+
+	desired = *existing
+	applyNamespaceUpdate(&desired, UpdateNamespaceRequest{Criticality: "tier-2", SLAAvailability: "99.9%"})
+	changes, err = upsertChanges(existing, &desired)
+	if err != nil {
+		t.Fatalf("upsertChanges failed: %v", err)
+	}
+	if len(changes) != 2 ||
+		changes[0].Field != "criticality" || changes[0].Change != FieldChanged || changes[0].After != "tier-2" ||
+		changes[1].Field != "sla_availability" || changes[1].After != "99.9%" {
+		t.Errorf("changes = %+v, want criticality and sla_availability", changes)
+	}
+}
+
+func TestUpsertChangesTeam(t *testing.T) {
+	existing := &models.Team{Name: "Payments", Slug: "payments", TeamType: "team"}
+
+	desired := *existing
+	applyTeamUpdate(&desired, CreateTeamRequest{Name: "Payments", Slug: "payments"})
+	if changes, err := upsertChanges(existing, &desired); err != nil || len(changes) != 0 {
+		t.Errorf("changes = %+v, %v, want none", changes, err)
+	}
+
+	desired = *existing
+	applyTeamUpdate(&desired, CreateTeamRequest{Name: "Payments Platform", ContactSlack: "#payments"})
+	changes, err := upsertChanges(existing, &desired)
+	if err != nil || len(changes) != 2 || changes[0].Field != "contact_slack" || changes[1].Field != "name" {
+		t.Errorf("changes = %+v, %v, want contact_slack and name", changes, err)
+	}
+}
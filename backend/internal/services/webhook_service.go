@@ -0,0 +1,448 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kubeatlas/kubeatlas/internal/crypto"
+	"github.com/kubeatlas/kubeatlas/internal/database/repositories"
+	"github.com/kubeatlas/kubeatlas/internal/models"
+	"github.com/kubeatlas/kubeatlas/internal/webhook"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrWebhookNotFound     = errors.New("webhook not found")
+	ErrWebhookDisabled     = errors.New("webhook is disabled")
+	ErrInvalidWebhookURL   = errors.New("invalid webhook URL: must be an http or https URL")
+	ErrInvalidWebhookEvent = errors.New("invalid webhook event type")
+	ErrNoWebhookEvents     = errors.New("webhook must subscribe to at least one event type")
+)
+
+// WebhookService manages outgoing webhook subscriptions and delivers
+// domain events to them
+type WebhookService struct {
+	repo      *repositories.WebhookRepository
+	encryptor *crypto.Encryptor
+	sender    webhook.Sender
+	auditSvc  *AuditService
+	logger    *zap.SugaredLogger
+}
+
+func NewWebhookService(repo *repositories.WebhookRepository, encryptor *crypto.Encryptor, sender webhook.Sender, auditSvc *AuditService, logger *zap.SugaredLogger) *WebhookService {
+	return &WebhookService{
+		repo:      repo,
+		encryptor: encryptor,
+		sender:    sender,
+		auditSvc:  auditSvc,
+		logger:    logger,
+	}
+}
+
+// WebhookEvent is the JSON body of every webhook request
+type WebhookEvent struct {
+	ID             uuid.UUID   `json:"id"`
+	Type           string      `json:"type"`
+	CreatedAt      time.Time   `json:"created_at"`
+	OrganizationID uuid.UUID   `json:"organization_id"`
+	Data           interface{} `json:"data"`
+}
+
+// CreateWebhookRequest represents webhook subscription creation data
+type CreateWebhookRequest struct {
+	Name       string   `json:"name" binding:"required"`
+	URL        string   `json:"url" binding:"required"`
+	Secret     string   `json:"secret"` // Generated when empty
+	EventTypes []string `json:"event_types" binding:"required"`
+	IsActive   *bool    `json:"is_active"`
+}
+
+// UpdateWebhookRequest represents webhook subscription update data
+type UpdateWebhookRequest struct {
+	Name       string   `json:"name"`
+	URL        string   `json:"url"`
+	Secret     string   `json:"secret"` // Rotates the secret when set
+	EventTypes []string `json:"event_types"`
+	IsActive   *bool    `json:"is_active"`
+}
+
+// WebhookWithSecret is a subscription together with its signing secret,
+// returned only when the secret is set so it can be copied once
+type WebhookWithSecret struct {
+	*models.WebhookSubscription
+	Secret string `json:"secret,omitempty"`
+}
+
+// Create creates a subscription
+func (s *WebhookService) Create(ctx context.Context, ac AuditContext, req CreateWebhookRequest) (*WebhookWithSecret, error) {
+	if err := validateWebhookURL(req.URL); err != nil {
+		return nil, err
+	}
+	events, err := normalizeWebhookEvents(req.EventTypes)
+	if err != nil {
+		return nil, err
+	}
+
+	secret := req.Secret
+	if secret == "" {
+		if secret, err = generateWebhookSecret(); err != nil {
+			return nil, err
+		}
+	}
+	encrypted, err := s.encryptor.Encrypt([]byte(secret))
+	if err != nil {
+		s.logger.Errorw("Failed to encrypt webhook secret", "error", err)
+		return nil, ErrEncryptionFailed
+	}
+
+	sub := &models.WebhookSubscription{
+		OrganizationID:  ac.OrgID,
+		Name:            req.Name,
+		URL:             req.URL,
+		SecretEncrypted: encrypted,
+		EventTypes:      events,
+		IsActive:        true,
+		CreatedBy:       ac.UserID,
+	}
+	if req.IsActive != nil {
+		sub.IsActive = *req.IsActive
+	}
+
+	if err := s.repo.Create(ctx, sub); err != nil {
+		return nil, err
+	}
+
+	s.auditSvc.LogCreate(ctx, ac, "webhook", sub.ID, sub.Name, webhookAuditValues(sub))
+	s.logger.Infow("Webhook created", "webhook_id", sub.ID, "events", events)
+
+	return &WebhookWithSecret{WebhookSubscription: sub, Secret: secret}, nil
+}
+
+// GetByID retrieves a subscription of the organization
+func (s *WebhookService) GetByID(ctx context.Context, orgID, id uuid.UUID) (*models.WebhookSubscription, error) {
+	sub, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if sub == nil || sub.OrganizationID != orgID {
+		return nil, ErrWebhookNotFound
+	}
+	return sub, nil
+}
+
+// List returns the organization's subscriptions
+func (s *WebhookService) List(ctx context.Context, orgID uuid.UUID) ([]models.WebhookSubscription, error) {
+	return s.repo.List(ctx, orgID)
+}
+
+// Update updates a subscription. The new secret is returned when it was rotated.
+func (s *WebhookService) Update(ctx context.Context, ac AuditContext, id uuid.UUID, req UpdateWebhookRequest) (*WebhookWithSecret, error) {
+	sub, err := s.GetByID(ctx, ac.OrgID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	oldValues := webhookAuditValues(sub)
+
+	if req.Name != "" {
+		sub.Name = req.Name
+	}
+	if req.URL != "" {
+		if err := validateWebhookURL(req.URL); err != nil {
+			return nil, err
+		}
+		sub.URL = req.URL
+	}
+	if req.EventTypes != nil {
+		events, err := normalizeWebhookEvents(req.EventTypes)
+		if err != nil {
+			return nil, err
+		}
+		sub.EventTypes = events
+	}
+	if req.IsActive != nil {
+		sub.IsActive = *req.IsActive
+	}
+	if req.Secret != "" {
+		encrypted, err := s.encryptor.Encrypt([]byte(req.Secret))
+		if err != nil {
+			s.logger.Errorw("Failed to encrypt webhook secret", "error", err)
+			return nil, ErrEncryptionFailed
+		}
+		sub.SecretEncrypted = encrypted
+	}
+
+	if err := s.repo.Update(ctx, sub); err != nil {
+		return nil, err
+	}
+
+	newValues := webhookAuditValues(sub)
+	if req.Secret != "" {
+		newValues["secret_rotated"] = true
+	}
+	s.auditSvc.LogUpdate(ctx, ac, "webhook", sub.ID, sub.Name, oldValues, newValues)
+
+	return &WebhookWithSecret{WebhookSubscription: sub, Secret: req.Secret}, nil
+}
+
+// Delete deletes a subscription. Deliveries still queued for it fail
+// without being sent.
+func (s *WebhookService) Delete(ctx context.Context, ac AuditContext, id uuid.UUID) error {
+	sub, err := s.GetByID(ctx, ac.OrgID, id)
+	if err != nil {
+		return err
+	}
+
+	if err := s.repo.Delete(ctx, id); err != nil {
+		return err
+	}
+
+	s.auditSvc.LogDelete(ctx, ac, "webhook", id, sub.Name)
+	return nil
+}
+
+// ListDeliveries returns the delivery history of a subscription
+func (s *WebhookService) ListDeliveries(ctx context.Context, orgID, id uuid.UUID, p repositories.Pagination, filters map[string]interface{}) (*repositories.PaginatedResult[models.WebhookDelivery], error) {
+	if _, err := s.GetByID(ctx, orgID, id); err != nil {
+		return nil, err
+	}
+	return s.repo.ListDeliveries(ctx, id, p, filters)
+}
+
+// Ping sends a ping event to a subscription synchronously and records the
+// attempt in its delivery history. It is not retried.
+func (s *WebhookService) Ping(ctx context.Context, ac AuditContext, id uuid.UUID) (*models.WebhookDelivery, error) {
+	sub, err := s.GetByID(ctx, ac.OrgID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	d, err := newWebhookDelivery(sub, models.WebhookEventPing, map[string]interface{}{"webhook_id": sub.ID})
+	if err != nil {
+		return nil, err
+	}
+	// Keep the delivery away from the background worker while it is sent here
+	d.Status = models.DeliveryStatusSending
+	d.Attempts = 1
+	d.NextAttemptAt = time.Now().Add(deliveryClaimTimeout)
+	if err := s.repo.CreateDelivery(ctx, d); err != nil {
+		return nil, err
+	}
+
+	code, sendErr := s.send(ctx, sub, d)
+	if code != 0 {
+		d.ResponseStatus = &code
+	}
+	if sendErr != nil {
+		d.Status = models.DeliveryStatusFailed
+		d.LastError = models.NewNullStringFromString(sendErr.Error())
+		if err := s.repo.MarkFailed(ctx, d.ID, d.ResponseStatus, sendErr.Error(), nil); err != nil {
+			return nil, err
+		}
+	} else {
+		d.Status = models.DeliveryStatusSent
+		d.DeliveredAt = models.NullTime{Time: time.Now(), Valid: true}
+		if err := s.repo.MarkDelivered(ctx, d.ID, code); err != nil {
+			return nil, err
+		}
+	}
+
+	s.auditSvc.LogAction(ctx, ac, "ping", "webhook", sub.ID, sub.Name, "Webhook ping sent")
+	return d, nil
+}
+
+// Publish queues an event for every active subscription of the
+// organization that selected it. data is sent as its JSON encoding.
+// Failures are logged rather than returned so that webhook problems never
+// fail the change that raised the event.
+func (s *WebhookService) Publish(ctx context.Context, orgID uuid.UUID, eventType string, data interface{}) {
+	if s == nil {
+		return
+	}
+
+	subs, err := s.repo.ListActiveForEvent(ctx, orgID, eventType)
+	if err != nil {
+		s.logger.Errorw("Failed to load webhook subscriptions", "event", eventType, "error", err)
+		return
+	}
+	if len(subs) == 0 {
+		return
+	}
+
+	for i := range subs {
+		d, err := newWebhookDelivery(&subs[i], eventType, data)
+		if err == nil {
+			err = s.repo.CreateDelivery(ctx, d)
+		}
+		if err != nil {
+			s.logger.Errorw("Failed to queue webhook delivery", "webhook_id", subs[i].ID, "event", eventType, "error", err)
+		}
+	}
+}
+
+// ProcessDeliveries sends due deliveries and schedules retries for failed ones
+func (s *WebhookService) ProcessDeliveries(ctx context.Context) error {
+	deliveries, err := s.repo.ClaimDueDeliveries(ctx, deliveryBatchSize, deliveryClaimTimeout)
+	if err != nil {
+		return fmt.Errorf("failed to claim webhook deliveries: %w", err)
+	}
+
+	subs := make(map[uuid.UUID]*models.WebhookSubscription)
+	for i := range deliveries {
+		d := &deliveries[i]
+
+		sub, ok := subs[d.SubscriptionID]
+		if !ok {
+			if sub, err = s.repo.GetByID(ctx, d.SubscriptionID); err != nil {
+				s.logger.Errorw("Failed to load webhook subscription", "webhook_id", d.SubscriptionID, "error", err)
+				continue
+			}
+			subs[d.SubscriptionID] = sub
+		}
+
+		var code int
+		var sendErr error
+		switch {
+		case sub == nil:
+			sendErr = ErrWebhookNotFound
+		case !sub.IsActive:
+			sendErr = ErrWebhookDisabled
+		default:
+			code, sendErr = s.send(ctx, sub, d)
+		}
+
+		if sendErr == nil {
+			if err := s.repo.MarkDelivered(ctx, d.ID, code); err != nil {
+				s.logger.Errorw("Failed to mark webhook delivered", "delivery_id", d.ID, "error", err)
+			}
+			continue
+		}
+
+		var responseStatus *int
+		if code != 0 {
+			responseStatus = &code
+		}
+		var retryAt *time.Time
+		if d.Attempts < maxDeliveryAttempts && sub != nil && sub.IsActive {
+			next := time.Now().Add(deliveryBackoff(d.Attempts))
+			retryAt = &next
+		}
+		s.logger.Warnw("Webhook delivery failed",
+			"delivery_id", d.ID, "webhook_id", d.SubscriptionID, "event", d.EventType, "attempt", d.Attempts, "retry", retryAt != nil, "error", sendErr)
+		if err := s.repo.MarkFailed(ctx, d.ID, responseStatus, sendErr.Error(), retryAt); err != nil {
+			s.logger.Errorw("Failed to record webhook failure", "delivery_id", d.ID, "error", err)
+		}
+	}
+
+	return nil
+}
+
+// send signs and posts a delivery's payload to its subscription
+func (s *WebhookService) send(ctx context.Context, sub *models.WebhookSubscription, d *models.WebhookDelivery) (int, error) {
+	secret, err := s.encryptor.Decrypt(sub.SecretEncrypted)
+	if err != nil {
+		return 0, fmt.Errorf("failed to decrypt webhook secret: %w", err)
+	}
+	body, err := json.Marshal(d.Payload)
+	if err != nil {
+		return 0, err
+	}
+
+	return s.sender.Send(ctx, webhook.Request{
+		URL:        sub.URL,
+		Secret:     string(secret),
+		Event:      d.EventType,
+		DeliveryID: d.ID.String(),
+		Body:       body,
+	})
+}
+
+// newWebhookDelivery builds the delivery of an event to a subscription. The
+// payload is stored in its JSON form so later changes to data do not leak
+// into retries.
+func newWebhookDelivery(sub *models.WebhookSubscription, eventType string, data interface{}) (*models.WebhookDelivery, error) {
+	id := uuid.New()
+	raw, err := json.Marshal(WebhookEvent{
+		ID:             id,
+		Type:           eventType,
+		CreatedAt:      time.Now().UTC(),
+		OrganizationID: sub.OrganizationID,
+		Data:           data,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode webhook payload: %w", err)
+	}
+
+	payload := make(models.JSONMap)
+	if err := json.Unmarshal(raw, &payload); err != nil {
+		return nil, fmt.Errorf("failed to encode webhook payload: %w", err)
+	}
+
+	return &models.WebhookDelivery{
+		ID:             id,
+		SubscriptionID: sub.ID,
+		OrganizationID: sub.OrganizationID,
+		EventType:      eventType,
+		Payload:        payload,
+	}, nil
+}
+
+// validateWebhookURL accepts absolute http and https URLs
+func validateWebhookURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+		return ErrInvalidWebhookURL
+	}
+	return nil
+}
+
+// normalizeWebhookEvents validates and de-duplicates the selected event types
+func normalizeWebhookEvents(events []string) (models.StringArray, error) {
+	known := map[string]bool{models.WebhookEventAll: true}
+	for _, e := range models.WebhookEventTypes {
+		known[e] = true
+	}
+
+	seen := make(map[string]bool, len(events))
+	result := make(models.StringArray, 0, len(events))
+	for _, e := range events {
+		e = strings.TrimSpace(e)
+		if e == "" || seen[e] {
+			continue
+		}
+		if !known[e] {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidWebhookEvent, e)
+		}
+		seen[e] = true
+		result = append(result, e)
+	}
+	if len(result) == 0 {
+		return nil, ErrNoWebhookEvents
+	}
+	return result, nil
+}
+
+func generateWebhookSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate webhook secret: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func webhookAuditValues(sub *models.WebhookSubscription) map[string]interface{} {
+	return map[string]interface{}{
+		"name":        sub.Name,
+		"url":         sub.URL,
+		"event_types": []string(sub.EventTypes),
+		"is_active":   sub.IsActive,
+	}
+}
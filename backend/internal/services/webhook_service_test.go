@@ -0,0 +1,71 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/kubeatlas/kubeatlas/internal/models"
+)
+
+func TestNormalizeWebhookEvents(t *testing.T) {
+	got, err := normalizeWebhookEvents([]string{" cluster.created", "cluster.created", "", models.WebhookEventAll})
+	if err != nil {
+		t.Fatalf("normalizeWebhookEvents failed: %v", err)
+	}
+	if len(got) != 2 || got[0] != "cluster.created" || got[1] != "*" {
+		t.Errorf("normalizeWebhookEvents() = %v", got)
+	}
+
+	if _, err := normalizeWebhookEvents([]string{"cluster.exploded"}); !errors.Is(err, ErrInvalidWebhookEvent) {
+		t.Errorf("unknown event error = %v, want ErrInvalidWebhookEvent", err)
+	}
+	if _, err := normalizeWebhookEvents([]string{" "}); err != ErrNoWebhookEvents {
+		t.Errorf("empty events error = %v, want ErrNoWebhookEvents", err)
+	}
+}
+
+func TestValidateWebhookURL(t *testing.T) {
+	tests := []struct {
+		url   string
+		valid bool
+	}{
+		{"https://hooks.example.com/kubeatlas", true},
+		{"http://10.0.0.5:8080/events", true},
+		{"ftp://example.com/x", false},
+		{"/relative/path", false},
+		{"https://", false},
+	}
+	for _, tt := range tests {
+		if err := validateWebhookURL(tt.url); (err == nil) != tt.valid {
+			t.Errorf("validateWebhookURL(%q) = %v, want valid=%v", tt.url, err, tt.valid)
+		}
+	}
+}
+
+func TestNewWebhookDelivery(t *testing.T) {
+	sub := &models.WebhookSubscription{OrganizationID: uuid.New()}
+	sub.ID = uuid.New()
+	ns := &models.Namespace{Name: "payments"}
+
+	d, err := newWebhookDelivery(sub, models.WebhookEventNamespaceCreated, ns)
+	if err != nil {
+		t.Fatalf("newWebhookDelivery failed: %v", err)
+	}
+	if d.SubscriptionID != sub.ID || d.OrganizationID != sub.OrganizationID {
+		t.Errorf("delivery not bound to subscription: %+v", d)
+	}
+	if d.Payload["id"] != d.ID.String() || d.Payload["type"] != models.WebhookEventNamespaceCreated {
+		t.Errorf("unexpected envelope: %v", d.Payload)
+	}
+	data, _ := d.Payload["data"].(map[string]interface{})
+	if data["name"] != "payments" {
+		t.Errorf("payload data = %v", d.Payload["data"])
+	}
+
+	// Later changes must not leak into queued payloads
+	ns.Name = "changed"
+	if data["name"] != "payments" {
+		t.Error("payload shares state with the event data")
+	}
+}
@@ -0,0 +1,212 @@
+package siem
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/kubeatlas/kubeatlas/internal/config"
+	"github.com/kubeatlas/kubeatlas/internal/metrics"
+	"go.uber.org/zap"
+)
+
+// Options tune the forwarder's queue and batching
+type Options struct {
+	// QueueSize is the number of events held while the SIEM catches up;
+	// events arriving on a full queue are dropped
+	QueueSize int
+	// BatchSize is the largest number of events sent at once
+	BatchSize int
+	// FlushInterval is the longest an event waits for its batch to fill
+	FlushInterval time.Duration
+	// MaxRetries is the number of times a failed batch is retried before
+	// it is dropped
+	MaxRetries int
+}
+
+// Forwarder queues events and sends them to a sink in the background
+type Forwarder struct {
+	sink   Sink
+	opts   Options
+	logger *zap.SugaredLogger
+
+	mu     sync.RWMutex
+	closed bool
+	queue  chan Event
+
+	dropped atomic.Int64
+	ctx     context.Context
+	cancel  context.CancelFunc
+	done    chan struct{}
+}
+
+// New creates a forwarder for the configured sink. It returns nil when no
+// sink is configured.
+func New(cfg config.SIEMConfig, logger *zap.SugaredLogger) (*Forwarder, error) {
+	timeout := time.Duration(cfg.TimeoutSeconds) * time.Second
+
+	var sink Sink
+	switch cfg.Sink {
+	case "":
+		return nil, nil
+	case SinkSyslog:
+		if cfg.Address == "" {
+			return nil, fmt.Errorf("SIEM_SYSLOG_ADDRESS is required for the syslog sink")
+		}
+		s, err := NewSyslogSink(cfg.Network, cfg.Address, cfg.Tag)
+		if err != nil {
+			return nil, err
+		}
+		sink = s
+	case SinkSplunk:
+		if cfg.URL == "" || cfg.Token == "" {
+			return nil, fmt.Errorf("SIEM_URL and SIEM_TOKEN are required for the splunk sink")
+		}
+		sink = NewSplunkSink(cfg.URL, cfg.Token, timeout)
+	case SinkHTTPS:
+		if cfg.URL == "" {
+			return nil, fmt.Errorf("SIEM_URL is required for the https sink")
+		}
+		sink = NewHTTPSSink(cfg.URL, cfg.Token, timeout)
+	default:
+		return nil, fmt.Errorf("%w %q", ErrUnknownSink, cfg.Sink)
+	}
+
+	return NewForwarder(sink, Options{
+		QueueSize:     cfg.QueueSize,
+		BatchSize:     cfg.BatchSize,
+		FlushInterval: time.Duration(cfg.FlushIntervalSeconds) * time.Second,
+		MaxRetries:    cfg.MaxRetries,
+	}, logger), nil
+}
+
+// NewForwarder starts a forwarder sending to sink
+func NewForwarder(sink Sink, opts Options, logger *zap.SugaredLogger) *Forwarder {
+	if opts.QueueSize <= 0 {
+		opts.QueueSize = 10000
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 100
+	}
+	if opts.FlushInterval <= 0 {
+		opts.FlushInterval = 2 * time.Second
+	}
+	if opts.MaxRetries < 0 {
+		opts.MaxRetries = 0
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	f := &Forwarder{
+		sink:   sink,
+		opts:   opts,
+		logger: logger,
+		queue:  make(chan Event, opts.QueueSize),
+		ctx:    ctx,
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+	go f.run()
+	return f
+}
+
+// Enqueue queues an event without waiting. It reports false when the event
+// was dropped because the queue is full or the forwarder is closed.
+func (f *Forwarder) Enqueue(e Event) bool {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	if f.closed {
+		return false
+	}
+	select {
+	case f.queue <- e:
+		return true
+	default:
+		f.dropped.Add(1)
+		metrics.ObserveSIEMEvents(metrics.SIEMEventDropped, 1)
+		return false
+	}
+}
+
+// Close stops accepting events and sends the queued ones. When ctx ends
+// first, the remaining events are dropped.
+func (f *Forwarder) Close(ctx context.Context) error {
+	f.mu.Lock()
+	if !f.closed {
+		f.closed = true
+		close(f.queue)
+	}
+	f.mu.Unlock()
+
+	select {
+	case <-f.done:
+	case <-ctx.Done():
+		f.cancel()
+		<-f.done
+	}
+	f.cancel()
+	return f.sink.Close()
+}
+
+func (f *Forwarder) run() {
+	defer close(f.done)
+
+	ticker := time.NewTicker(f.opts.FlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]Event, 0, f.opts.BatchSize)
+	flush := func() {
+		if n := f.dropped.Swap(0); n > 0 {
+			f.logger.Warnw("Dropped SIEM events because the queue is full", "count", n)
+		}
+		if len(batch) > 0 {
+			f.send(batch)
+			batch = batch[:0]
+		}
+		metrics.SetSIEMQueueLength(len(f.queue))
+	}
+
+	for {
+		select {
+		case e, ok := <-f.queue:
+			if !ok {
+				flush()
+				return
+			}
+			batch = append(batch, e)
+			if len(batch) >= f.opts.BatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		}
+	}
+}
+
+// send delivers a batch, retrying with exponential backoff. While it retries
+// the queue keeps filling, so a SIEM outage only ever costs QueueSize events
+// of memory.
+func (f *Forwarder) send(batch []Event) {
+	var err error
+	for attempt := 0; attempt <= f.opts.MaxRetries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-time.After(time.Second << (attempt - 1)):
+			case <-f.ctx.Done():
+			}
+		}
+		if f.ctx.Err() != nil {
+			err = f.ctx.Err()
+			break
+		}
+		if err = f.sink.Send(f.ctx, batch); err == nil {
+			metrics.ObserveSIEMEvents(metrics.SIEMEventSent, len(batch))
+			return
+		}
+	}
+
+	metrics.ObserveSIEMEvents(metrics.SIEMEventFailed, len(batch))
+	f.logger.Errorw("Failed to forward audit events to SIEM", "count", len(batch), "error", err)
+}
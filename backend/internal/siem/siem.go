@@ -0,0 +1,259 @@
+// Package siem forwards audit events to a security information and event
+// management system: CEF over syslog, the Splunk HTTP Event Collector or a
+// generic HTTPS endpoint receiving JSON.
+//
+// Events are queued in memory and sent in batches by a background worker, so
+// recording an audit event never waits for the SIEM. When the SIEM is slow or
+// down the queue fills up and further events are dropped and counted rather
+// than growing memory or blocking requests; the audit log in the database
+// stays complete either way.
+package siem
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/syslog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrUnknownSink is returned for an unsupported sink type
+var ErrUnknownSink = errors.New("unknown SIEM sink")
+
+// Sink types
+const (
+	SinkSyslog = "syslog"
+	SinkSplunk = "splunk"
+	SinkHTTPS  = "https"
+)
+
+// Event is a single audit log entry
+type Event struct {
+	ID             string                 `json:"id"`
+	OrganizationID string                 `json:"organization_id"`
+	Time           time.Time              `json:"time"`
+	UserID         string                 `json:"user_id,omitempty"`
+	UserEmail      string                 `json:"user_email,omitempty"`
+	UserIP         string                 `json:"user_ip,omitempty"`
+	UserAgent      string                 `json:"user_agent,omitempty"`
+	Action         string                 `json:"action"`
+	ResourceType   string                 `json:"resource_type"`
+	ResourceID     string                 `json:"resource_id"`
+	ResourceName   string                 `json:"resource_name,omitempty"`
+	ChangedFields  []string               `json:"changed_fields,omitempty"`
+	OldValues      map[string]interface{} `json:"old_values,omitempty"`
+	NewValues      map[string]interface{} `json:"new_values,omitempty"`
+	Description    string                 `json:"description,omitempty"`
+}
+
+// Sink delivers batches of events to a SIEM
+type Sink interface {
+	Send(ctx context.Context, events []Event) error
+	Close() error
+}
+
+// ============================================
+// Syslog (CEF)
+// ============================================
+
+// SyslogSink writes one CEF message per event to a syslog server
+type SyslogSink struct {
+	w *syslog.Writer
+}
+
+// NewSyslogSink connects to the syslog server at address over network, "udp"
+// or "tcp". The connection is re-established when writing fails.
+func NewSyslogSink(network, address, tag string) (*SyslogSink, error) {
+	w, err := syslog.Dial(network, address, syslog.LOG_INFO|syslog.LOG_AUTH, tag)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to syslog: %w", err)
+	}
+	return &SyslogSink{w: w}, nil
+}
+
+// Send implements Sink
+func (s *SyslogSink) Send(_ context.Context, events []Event) error {
+	for _, e := range events {
+		if err := s.w.Info(FormatCEF(e)); err != nil {
+			return fmt.Errorf("failed to write to syslog: %w", err)
+		}
+	}
+	return nil
+}
+
+// Close implements Sink
+func (s *SyslogSink) Close() error {
+	return s.w.Close()
+}
+
+// cefSeverity rates actions on the CEF 0-10 scale
+func cefSeverity(action string) int {
+	switch action {
+	case "delete", "purge":
+		return 7
+	case "create", "update", "export":
+		return 5
+	default:
+		return 3
+	}
+}
+
+var (
+	cefHeaderEscaper    = strings.NewReplacer(`\`, `\\`, `|`, `\|`, "\r", " ", "\n", " ")
+	cefExtensionEscaper = strings.NewReplacer(`\`, `\\`, `=`, `\=`, "\r", `\r`, "\n", `\n`)
+)
+
+// FormatCEF formats an event as an ArcSight Common Event Format message
+func FormatCEF(e Event) string {
+	name := e.Description
+	if name == "" {
+		name = e.Action + " " + e.ResourceType
+	}
+
+	ext := []struct{ key, value string }{
+		{"rt", strconv.FormatInt(e.Time.UnixMilli(), 10)},
+		{"externalId", e.ID},
+		{"act", e.Action},
+		{"suid", e.UserID},
+		{"suser", e.UserEmail},
+		{"src", e.UserIP},
+		{"requestClientApplication", e.UserAgent},
+		{"cs1Label", "organizationId"},
+		{"cs1", e.OrganizationID},
+		{"cs2Label", "resourceType"},
+		{"cs2", e.ResourceType},
+		{"cs3Label", "resourceId"},
+		{"cs3", e.ResourceID},
+		{"cs4Label", "resourceName"},
+		{"cs4", e.ResourceName},
+		{"cs5Label", "changedFields"},
+		{"cs5", strings.Join(e.ChangedFields, ",")},
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "CEF:0|KubeAtlas|KubeAtlas|1.0|%s|%s|%d|",
+		cefHeaderEscaper.Replace(e.ResourceType+":"+e.Action),
+		cefHeaderEscaper.Replace(name),
+		cefSeverity(e.Action),
+	)
+	first := true
+	for _, kv := range ext {
+		if kv.value == "" {
+			continue
+		}
+		if !first {
+			b.WriteByte(' ')
+		}
+		first = false
+		b.WriteString(kv.key)
+		b.WriteByte('=')
+		b.WriteString(cefExtensionEscaper.Replace(kv.value))
+	}
+	return b.String()
+}
+
+// ============================================
+// HTTP sinks
+// ============================================
+
+// httpSink posts batches of events with an authorization header
+type httpSink struct {
+	url           string
+	authorization string
+	encode        func(events []Event) ([]byte, error)
+	httpClient    *http.Client
+}
+
+// NewSplunkSink sends events to the Splunk HTTP Event Collector at url, for
+// example https://splunk.example.com:8088, authenticating with token
+func NewSplunkSink(url, token string, timeout time.Duration) Sink {
+	return &httpSink{
+		url:           strings.TrimSuffix(url, "/") + "/services/collector/event",
+		authorization: "Splunk " + token,
+		encode:        encodeHEC,
+		httpClient:    &http.Client{Timeout: timeout},
+	}
+}
+
+// NewHTTPSSink posts each batch as a JSON array to url, with token as a
+// bearer token when it is set
+func NewHTTPSSink(url, token string, timeout time.Duration) Sink {
+	s := &httpSink{
+		url:        url,
+		encode:     func(events []Event) ([]byte, error) { return json.Marshal(events) },
+		httpClient: &http.Client{Timeout: timeout},
+	}
+	if token != "" {
+		s.authorization = "Bearer " + token
+	}
+	return s
+}
+
+// hecEvent is the Splunk HTTP Event Collector envelope of an event
+type hecEvent struct {
+	Time       float64 `json:"time"`
+	Source     string  `json:"source"`
+	Sourcetype string  `json:"sourcetype"`
+	Event      Event   `json:"event"`
+}
+
+// encodeHEC encodes events as concatenated HEC envelopes, which the
+// collector accepts as a batch
+func encodeHEC(events []Event) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, e := range events {
+		env := hecEvent{
+			Time:       float64(e.Time.UnixMilli()) / 1000,
+			Source:     "kubeatlas",
+			Sourcetype: "kubeatlas:audit",
+			Event:      e,
+		}
+		if err := enc.Encode(env); err != nil {
+			return nil, err
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// Send implements Sink. Any non-2xx response is an error.
+func (s *httpSink) Send(ctx context.Context, events []Event) error {
+	body, err := s.encode(events)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "KubeAtlas-SIEM/1.0")
+	if s.authorization != "" {
+		req.Header.Set("Authorization", s.authorization)
+	}
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach SIEM: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("SIEM returned %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+// Close implements Sink
+func (s *httpSink) Close() error {
+	s.httpClient.CloseIdleConnections()
+	return nil
+}
@@ -0,0 +1,208 @@
+package siem
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+func testEvent() Event {
+	return Event{
+		ID:             "0f1e2d3c-4b5a-4978-8695-a4b3c2d1e0f9",
+		OrganizationID: "00000000-0000-0000-0000-000000000001",
+		Time:           time.Date(2024, 5, 1, 9, 30, 0, 0, time.UTC),
+		UserEmail:      "jane@example.com",
+		UserIP:         "10.0.0.7",
+		Action:         "update",
+		ResourceType:   "namespace",
+		ResourceID:     "11111111-2222-4333-8444-555555555555",
+		ResourceName:   "payments=prod",
+		ChangedFields:  []string{"owner", "criticality"},
+		Description:    "Updated namespace|owner",
+	}
+}
+
+func TestFormatCEF(t *testing.T) {
+	got := FormatCEF(testEvent())
+
+	wantPrefix := `CEF:0|KubeAtlas|KubeAtlas|1.0|namespace:update|Updated namespace\|owner|5|`
+	if !strings.HasPrefix(got, wantPrefix) {
+		t.Fatalf("FormatCEF() = %q, want prefix %q", got, wantPrefix)
+	}
+	for _, want := range []string{
+		"rt=1714555800000",
+		"suser=jane@example.com",
+		"src=10.0.0.7",
+		`cs4=payments\=prod`,
+		"cs5=owner,criticality",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("FormatCEF() = %q, missing %q", got, want)
+		}
+	}
+	// Empty fields are left out
+	if strings.Contains(got, "suid=") {
+		t.Errorf("FormatCEF() = %q, has empty suid", got)
+	}
+}
+
+func TestSplunkSink(t *testing.T) {
+	var gotAuth, gotPath string
+	var gotBody []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAuth = r.Header.Get("Authorization")
+		gotPath = r.URL.Path
+		gotBody, _ = io.ReadAll(r.Body)
+	}))
+	defer srv.Close()
+
+	sink := NewSplunkSink(srv.URL+"/", "hec-token", time.Second)
+	if err := sink.Send(context.Background(), []Event{testEvent(), testEvent()}); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+
+	if gotAuth != "Splunk hec-token" || gotPath != "/services/collector/event" {
+		t.Errorf("request auth = %q, path = %q", gotAuth, gotPath)
+	}
+	dec := json.NewDecoder(strings.NewReader(string(gotBody)))
+	n := 0
+	for dec.More() {
+		var env hecEvent
+		if err := dec.Decode(&env); err != nil {
+			t.Fatalf("invalid HEC body: %v", err)
+		}
+		if env.Sourcetype != "kubeatlas:audit" || env.Time != 1714555800 || env.Event.Action != "update" {
+			t.Errorf("HEC envelope = %+v", env)
+		}
+		n++
+	}
+	if n != 2 {
+		t.Errorf("HEC body has %d events, want 2", n)
+	}
+}
+
+func TestHTTPSSinkError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "overloaded", http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	err := NewHTTPSSink(srv.URL, "", time.Second).Send(context.Background(), []Event{testEvent()})
+	if err == nil || !strings.Contains(err.Error(), "503") {
+		t.Errorf("Send error = %v, want 503", err)
+	}
+}
+
+// recordingSink records the batches it receives; it fails while failing is
+// set and blocks while block is open
+type recordingSink struct {
+	mu      sync.Mutex
+	batches [][]Event
+	failing bool
+	block   chan struct{}
+}
+
+func (s *recordingSink) Send(ctx context.Context, events []Event) error {
+	if s.block != nil {
+		select {
+		case <-s.block:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.failing {
+		return errors.New("unavailable")
+	}
+	s.batches = append(s.batches, append([]Event(nil), events...))
+	return nil
+}
+
+func (s *recordingSink) Close() error { return nil }
+
+func (s *recordingSink) sent() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := 0
+	for _, b := range s.batches {
+		n += len(b)
+	}
+	return n
+}
+
+func TestForwarderBatchesAndDrains(t *testing.T) {
+	sink := &recordingSink{}
+	f := NewForwarder(sink, Options{QueueSize: 10, BatchSize: 3, FlushInterval: time.Hour}, zap.NewNop().Sugar())
+
+	for i := 0; i < 7; i++ {
+		if !f.Enqueue(testEvent()) {
+			t.Fatalf("Enqueue(%d) dropped the event", i)
+		}
+	}
+	if err := f.Close(context.Background()); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	if got := sink.sent(); got != 7 {
+		t.Errorf("sent %d events, want 7", got)
+	}
+	for _, b := range sink.batches {
+		if len(b) > 3 {
+			t.Errorf("batch of %d events exceeds BatchSize", len(b))
+		}
+	}
+	if f.Enqueue(testEvent()) {
+		t.Errorf("Enqueue after Close accepted the event")
+	}
+}
+
+func TestForwarderDropsWhenFull(t *testing.T) {
+	sink := &recordingSink{block: make(chan struct{})}
+	f := NewForwarder(sink, Options{QueueSize: 2, BatchSize: 1, FlushInterval: time.Hour}, zap.NewNop().Sugar())
+
+	// The worker holds one event while the sink blocks, the queue two more
+	accepted := 0
+	for i := 0; i < 10; i++ {
+		if f.Enqueue(testEvent()) {
+			accepted++
+		}
+	}
+	if accepted < 2 || accepted > 3 {
+		t.Errorf("accepted %d events, want the queue size plus at most one in flight", accepted)
+	}
+
+	close(sink.block)
+	if err := f.Close(context.Background()); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if got := sink.sent(); got != accepted {
+		t.Errorf("sent %d events, want %d", got, accepted)
+	}
+}
+
+func TestForwarderCloseGivesUpOnDeadline(t *testing.T) {
+	sink := &recordingSink{failing: true}
+	f := NewForwarder(sink, Options{BatchSize: 1, MaxRetries: 5}, zap.NewNop().Sugar())
+	f.Enqueue(testEvent())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	if err := f.Close(ctx); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Close took %v, want it to stop retrying at the deadline", elapsed)
+	}
+}
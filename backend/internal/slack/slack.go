@@ -0,0 +1,138 @@
+// Package slack posts messages to Slack through an incoming webhook or the
+// chat.postMessage Web API method.
+package slack
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const postMessageURL = "https://slack.com/api/chat.postMessage"
+
+var ErrNotConfigured = errors.New("slack is not configured")
+
+// Config holds the credentials of an organization's Slack integration. A bot
+// token can post to any channel the app is in; an incoming webhook always
+// posts to the channel it was created for.
+type Config struct {
+	WebhookURL string
+	BotToken   string
+}
+
+// Message is a single Slack message
+type Message struct {
+	Channel string
+	Text    string
+}
+
+// Poster delivers messages
+type Poster interface {
+	Post(ctx context.Context, cfg Config, msg Message) error
+}
+
+// Client delivers messages over HTTPS
+type Client struct {
+	httpClient *http.Client
+	apiURL     string
+}
+
+// NewClient creates a client whose requests give up after timeout
+func NewClient(timeout time.Duration) *Client {
+	return &Client{
+		httpClient: &http.Client{Timeout: timeout},
+		apiURL:     postMessageURL,
+	}
+}
+
+type payload struct {
+	Channel string `json:"channel,omitempty"`
+	Text    string `json:"text"`
+}
+
+// Post implements Poster, preferring the bot token when both are set
+func (c *Client) Post(ctx context.Context, cfg Config, msg Message) error {
+	switch {
+	case cfg.BotToken != "":
+		if msg.Channel == "" {
+			return errors.New("slack channel is required with a bot token")
+		}
+		return c.postAPI(ctx, cfg.BotToken, msg)
+	case cfg.WebhookURL != "":
+		return c.postWebhook(ctx, cfg.WebhookURL, msg)
+	default:
+		return ErrNotConfigured
+	}
+}
+
+func (c *Client) postWebhook(ctx context.Context, url string, msg Message) error {
+	resp, err := c.send(ctx, url, "", payload{Channel: msg.Channel, Text: msg.Text})
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("slack webhook returned %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
+func (c *Client) postAPI(ctx context.Context, token string, msg Message) error {
+	resp, err := c.send(ctx, c.apiURL, token, payload{Channel: msg.Channel, Text: msg.Text})
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("slack API returned %d", resp.StatusCode)
+	}
+
+	// The Web API reports failures in the body of a 200 response
+	var result struct {
+		OK    bool   `json:"ok"`
+		Error string `json:"error"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return fmt.Errorf("failed to decode slack response: %w", err)
+	}
+	if !result.OK {
+		return fmt.Errorf("slack API error: %s", result.Error)
+	}
+	return nil
+}
+
+func (c *Client) send(ctx context.Context, url, token string, p payload) (*http.Response, error) {
+	body, err := json.Marshal(p)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach slack: %w", err)
+	}
+	return resp, nil
+}
+
+// Escape encodes the characters Slack treats as control sequences in message text
+func Escape(s string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(s)
+}
@@ -0,0 +1,67 @@
+package slack
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestClientPost(t *testing.T) {
+	var gotAuth string
+	var got payload
+	apiOK := true
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAuth = r.Header.Get("Authorization")
+		json.NewDecoder(r.Body).Decode(&got)
+		if r.URL.Path == "/api" {
+			if apiOK {
+				w.Write([]byte(`{"ok":true}`))
+			} else {
+				w.Write([]byte(`{"ok":false,"error":"channel_not_found"}`))
+			}
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	c := NewClient(time.Second)
+	c.apiURL = srv.URL + "/api"
+	ctx := context.Background()
+	msg := Message{Channel: "#ops", Text: "hello"}
+
+	if err := c.Post(ctx, Config{WebhookURL: srv.URL + "/hook"}, msg); err != nil {
+		t.Fatalf("webhook Post() error = %v", err)
+	}
+	if gotAuth != "" || got.Text != "hello" {
+		t.Errorf("webhook request: auth=%q payload=%+v", gotAuth, got)
+	}
+
+	// The bot token wins over the webhook
+	if err := c.Post(ctx, Config{WebhookURL: srv.URL + "/hook", BotToken: "xoxb-1"}, msg); err != nil {
+		t.Fatalf("API Post() error = %v", err)
+	}
+	if gotAuth != "Bearer xoxb-1" || got.Channel != "#ops" {
+		t.Errorf("API request: auth=%q payload=%+v", gotAuth, got)
+	}
+
+	apiOK = false
+	err := c.Post(ctx, Config{BotToken: "xoxb-1"}, msg)
+	if err == nil || !strings.Contains(err.Error(), "channel_not_found") {
+		t.Errorf("API error not surfaced: %v", err)
+	}
+
+	if err := c.Post(ctx, Config{}, msg); err != ErrNotConfigured {
+		t.Errorf("unconfigured Post() error = %v, want ErrNotConfigured", err)
+	}
+}
+
+func TestEscape(t *testing.T) {
+	if got := Escape("a <b> & c"); got != "a &lt;b&gt; &amp; c" {
+		t.Errorf("Escape() = %q", got)
+	}
+}
@@ -0,0 +1,110 @@
+// Package teams posts adaptive cards to Microsoft Teams incoming webhooks.
+package teams
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+var ErrNotConfigured = errors.New("microsoft teams is not configured")
+
+// Card is the content of a single adaptive card
+type Card struct {
+	Title string
+	Text  string
+	// Style colours the title: "default", "good", "warning" or "attention"
+	Style string
+}
+
+// Poster delivers cards
+type Poster interface {
+	Post(ctx context.Context, webhookURL string, card Card) error
+}
+
+// Client delivers cards over HTTPS
+type Client struct {
+	httpClient *http.Client
+}
+
+// NewClient creates a client whose requests give up after timeout
+func NewClient(timeout time.Duration) *Client {
+	return &Client{httpClient: &http.Client{Timeout: timeout}}
+}
+
+// Post implements Poster
+func (c *Client) Post(ctx context.Context, webhookURL string, card Card) error {
+	if webhookURL == "" {
+		return ErrNotConfigured
+	}
+
+	body, err := json.Marshal(Payload(card))
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhookURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach microsoft teams: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("microsoft teams webhook returned %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+// Payload wraps card in the message envelope Teams webhooks accept
+func Payload(card Card) map[string]interface{} {
+	color := card.Style
+	if color == "" {
+		color = "default"
+	}
+
+	body := []map[string]interface{}{
+		{
+			"type":   "TextBlock",
+			"text":   card.Title,
+			"weight": "Bolder",
+			"size":   "Medium",
+			"color":  color,
+			"wrap":   true,
+		},
+	}
+	if card.Text != "" {
+		body = append(body, map[string]interface{}{
+			"type": "TextBlock",
+			"text": card.Text,
+			"wrap": true,
+		})
+	}
+
+	return map[string]interface{}{
+		"type": "message",
+		"attachments": []map[string]interface{}{
+			{
+				"contentType": "application/vnd.microsoft.card.adaptive",
+				"content": map[string]interface{}{
+					"$schema": "http://adaptivecards.io/schemas/adaptive-card.json",
+					"type":    "AdaptiveCard",
+					"version": "1.4",
+					"body":    body,
+				},
+			},
+		},
+	}
+}
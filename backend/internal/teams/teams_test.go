@@ -0,0 +1,47 @@
+package teams
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestClientPost(t *testing.T) {
+	var got map[string]interface{}
+	status := http.StatusOK
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&got)
+		w.WriteHeader(status)
+		w.Write([]byte("1"))
+	}))
+	defer srv.Close()
+
+	c := NewClient(time.Second)
+	ctx := context.Background()
+	card := Card{Title: "Sync failed", Text: "cluster prod", Style: "attention"}
+
+	if err := c.Post(ctx, srv.URL, card); err != nil {
+		t.Fatalf("Post() error = %v", err)
+	}
+	attachments, _ := got["attachments"].([]interface{})
+	if got["type"] != "message" || len(attachments) != 1 {
+		t.Fatalf("unexpected envelope: %v", got)
+	}
+	content := attachments[0].(map[string]interface{})["content"].(map[string]interface{})
+	if content["type"] != "AdaptiveCard" || len(content["body"].([]interface{})) != 2 {
+		t.Errorf("unexpected card: %v", content)
+	}
+
+	status = http.StatusTooManyRequests
+	if err := c.Post(ctx, srv.URL, card); err == nil || !strings.Contains(err.Error(), "429") {
+		t.Errorf("Post() error = %v, want status 429", err)
+	}
+
+	if err := c.Post(ctx, "", card); err != ErrNotConfigured {
+		t.Errorf("Post() without URL error = %v, want ErrNotConfigured", err)
+	}
+}
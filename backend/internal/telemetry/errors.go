@@ -0,0 +1,79 @@
+package telemetry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/kubeatlas/kubeatlas/internal/config"
+)
+
+// SetupErrorReporting initializes the Sentry client. With an empty DSN the
+// SDK stays disabled and every capture below is a no-op.
+func SetupErrorReporting(cfg config.SentryConfig, version string) (func(), error) {
+	if cfg.DSN == "" {
+		return func() {}, nil
+	}
+
+	err := sentry.Init(sentry.ClientOptions{
+		Dsn:              cfg.DSN,
+		Environment:      cfg.Environment,
+		Release:          version,
+		SampleRate:       cfg.SampleRate,
+		AttachStacktrace: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return func() { sentry.Flush(5 * time.Second) }, nil
+}
+
+// WithErrorHub attaches a per-request Sentry hub to ctx so events captured
+// further down carry the request's tags.
+func WithErrorHub(ctx context.Context, r *http.Request, requestID string) context.Context {
+	hub := sentry.CurrentHub().Clone()
+	hub.Scope().SetRequest(r)
+	if requestID != "" {
+		hub.Scope().SetTag("request_id", requestID)
+	}
+	return sentry.SetHubOnContext(ctx, hub)
+}
+
+// SetErrorUser tags the request's hub with the authenticated user and organization
+func SetErrorUser(ctx context.Context, userID, email, orgID string) {
+	hub := sentry.GetHubFromContext(ctx)
+	if hub == nil {
+		return
+	}
+	hub.Scope().SetUser(sentry.User{ID: userID, Email: email})
+	hub.Scope().SetTag("organization_id", orgID)
+}
+
+// CaptureError reports err using the hub attached to ctx, if any
+func CaptureError(ctx context.Context, err error) {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return
+	}
+	errorHub(ctx).CaptureException(err)
+}
+
+// CapturePanic reports a recovered panic value
+func CapturePanic(ctx context.Context, recovered interface{}) {
+	errorHub(ctx).RecoverWithContext(ctx, recovered)
+}
+
+// CaptureMessage reports a message, e.g. an HTTP 5xx without an attached error
+func CaptureMessage(ctx context.Context, format string, args ...interface{}) {
+	errorHub(ctx).CaptureMessage(fmt.Sprintf(format, args...))
+}
+
+func errorHub(ctx context.Context) *sentry.Hub {
+	if hub := sentry.GetHubFromContext(ctx); hub != nil {
+		return hub
+	}
+	return sentry.CurrentHub()
+}
@@ -0,0 +1,108 @@
+// Package webhook signs and sends outgoing webhook requests.
+//
+// Every request carries the event type, a delivery ID, a Unix timestamp and
+// an HMAC-SHA256 signature over "<timestamp>.<body>" keyed with the
+// subscription secret, so receivers can verify the sender and reject replays.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Request headers
+const (
+	HeaderSignature = "X-KubeAtlas-Signature"
+	HeaderTimestamp = "X-KubeAtlas-Timestamp"
+	HeaderEvent     = "X-KubeAtlas-Event"
+	HeaderDelivery  = "X-KubeAtlas-Delivery"
+)
+
+const signaturePrefix = "sha256="
+
+// Request is a single webhook delivery
+type Request struct {
+	URL        string
+	Secret     string
+	Event      string
+	DeliveryID string
+	Body       []byte
+}
+
+// Sender delivers webhook requests and reports the receiver's status code,
+// which is zero when no response was received
+type Sender interface {
+	Send(ctx context.Context, req Request) (int, error)
+}
+
+// Client delivers webhook requests over HTTP
+type Client struct {
+	httpClient *http.Client
+	now        func() time.Time
+}
+
+// NewClient creates a client whose requests give up after timeout
+func NewClient(timeout time.Duration) *Client {
+	return &Client{
+		httpClient: &http.Client{
+			Timeout: timeout,
+			// Receivers must answer directly; following redirects would
+			// re-send the signed payload to a host nobody subscribed
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		now: time.Now,
+	}
+}
+
+// Send implements Sender. Any non-2xx response is an error.
+func (c *Client) Send(ctx context.Context, r Request) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.URL, bytes.NewReader(r.Body))
+	if err != nil {
+		return 0, err
+	}
+
+	timestamp := strconv.FormatInt(c.now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "KubeAtlas-Webhook/1.0")
+	req.Header.Set(HeaderEvent, r.Event)
+	req.Header.Set(HeaderDelivery, r.DeliveryID)
+	req.Header.Set(HeaderTimestamp, timestamp)
+	req.Header.Set(HeaderSignature, Sign(r.Secret, timestamp, r.Body))
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("failed to reach webhook receiver: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return resp.StatusCode, fmt.Errorf("webhook receiver returned %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	return resp.StatusCode, nil
+}
+
+// Sign returns the signature header value for body sent at timestamp
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify reports whether signature matches body sent at timestamp
+func Verify(secret, timestamp string, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, timestamp, body)), []byte(signature))
+}
@@ -0,0 +1,66 @@
+package webhook
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestClientSend(t *testing.T) {
+	var gotHeader http.Header
+	var gotBody []byte
+	status := http.StatusNoContent
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotHeader = r.Header
+		gotBody, _ = io.ReadAll(r.Body)
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+
+	c := NewClient(time.Second)
+	c.now = func() time.Time { return time.Unix(1700000000, 0) }
+	ctx := context.Background()
+	req := Request{URL: srv.URL, Secret: "s3cret", Event: "cluster.created", DeliveryID: "d-1", Body: []byte(`{"a":1}`)}
+
+	code, err := c.Send(ctx, req)
+	if err != nil || code != http.StatusNoContent {
+		t.Fatalf("Send() = %d, %v", code, err)
+	}
+	if string(gotBody) != `{"a":1}` {
+		t.Errorf("body = %q", gotBody)
+	}
+	if gotHeader.Get(HeaderEvent) != "cluster.created" || gotHeader.Get(HeaderDelivery) != "d-1" {
+		t.Errorf("unexpected headers: %v", gotHeader)
+	}
+	if ts := gotHeader.Get(HeaderTimestamp); ts != "1700000000" {
+		t.Errorf("timestamp = %q", ts)
+	}
+	if !Verify("s3cret", "1700000000", gotBody, gotHeader.Get(HeaderSignature)) {
+		t.Errorf("signature %q does not verify", gotHeader.Get(HeaderSignature))
+	}
+
+	status = http.StatusInternalServerError
+	code, err = c.Send(ctx, req)
+	if err == nil || code != http.StatusInternalServerError || !strings.Contains(err.Error(), "500") {
+		t.Errorf("Send() = %d, %v; want 500 error", code, err)
+	}
+}
+
+func TestSign(t *testing.T) {
+	// echo -n '1700000000.{}' | openssl dgst -sha256 -hmac key
+	want := "sha256=9d713ed406bb7076d4123f0dc2c39d2df5c654ed4b0cd56b52c8b4c940bd63ae"
+	got := Sign("key", "1700000000", []byte("{}"))
+	if got != want {
+		t.Fatalf("Sign() = %q, want %q", got, want)
+	}
+	if Verify("other", "1700000000", []byte("{}"), got) {
+		t.Error("signature verified with the wrong secret")
+	}
+	if Verify("key", "1700000001", []byte("{}"), got) {
+		t.Error("signature verified with a different timestamp")
+	}
+}
@@ -0,0 +1,50 @@
+-- ============================================
+-- Namespace search benchmark
+-- ============================================
+-- Seeds 50k namespaces inside a transaction, runs EXPLAIN ANALYZE for the
+-- list search filter and the ranked search, then rolls everything back.
+-- Target: both plans use the *_trgm indexes and execute in under 50ms.
+--
+-- Usage: psql "$DATABASE_URL" -f database/benchmarks/namespace_search.sql
+
+BEGIN;
+
+INSERT INTO organizations (id, name, slug)
+VALUES ('00000000-0000-0000-0000-00000000be01', 'Search Benchmark', 'search-benchmark');
+
+INSERT INTO clusters (id, organization_id, name, api_server_url)
+VALUES ('00000000-0000-0000-0000-00000000be02', '00000000-0000-0000-0000-00000000be01',
+        'bench-cluster', 'https://bench.example.com:6443');
+
+INSERT INTO namespaces (organization_id, cluster_id, name, display_name, description)
+SELECT
+    '00000000-0000-0000-0000-00000000be01',
+    '00000000-0000-0000-0000-00000000be02',
+    'ns-' || md5(g::text),
+    'Namespace ' || g,
+    'Benchmark namespace ' || md5((g * 7)::text)
+FROM generate_series(1, 50000) AS g;
+
+ANALYZE namespaces;
+
+-- List filter (NamespaceRepository.List with filters["search"])
+EXPLAIN (ANALYZE, BUFFERS)
+SELECT n.id, n.name
+FROM namespaces n
+WHERE n.organization_id = '00000000-0000-0000-0000-00000000be01'
+  AND n.deleted_at IS NULL
+  AND (n.name ILIKE '%a1b2%' OR n.display_name ILIKE '%a1b2%' OR n.description ILIKE '%a1b2%')
+ORDER BY n.name ASC
+LIMIT 20;
+
+-- Ranked search (NamespaceRepository.Search)
+EXPLAIN (ANALYZE, BUFFERS)
+SELECT n.id, n.name
+FROM namespaces n
+WHERE n.organization_id = '00000000-0000-0000-0000-00000000be01'
+  AND n.deleted_at IS NULL
+  AND (n.name ILIKE '%a1b2%' OR n.display_name ILIKE '%a1b2%' OR n.description ILIKE '%a1b2%')
+ORDER BY GREATEST(similarity(n.name, 'a1b2'), similarity(COALESCE(n.display_name, ''), 'a1b2')) DESC, n.name
+LIMIT 20;
+
+ROLLBACK;
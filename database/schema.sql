@@ -7,6 +7,7 @@
 -- Enable extensions
 CREATE EXTENSION IF NOT EXISTS "uuid-ossp";
 CREATE EXTENSION IF NOT EXISTS "pgcrypto";
+CREATE EXTENSION IF NOT EXISTS "pg_trgm";
 
 -- ============================================
 -- ORGANIZATION & USERS
@@ -56,6 +57,8 @@ CREATE TABLE teams (
     team_type VARCHAR(50) DEFAULT 'team', -- organization, department, team
     contact_email VARCHAR(255),
     contact_slack VARCHAR(255),
+    pagerduty_service_id VARCHAR(50), -- PagerDuty service on call for the team's namespaces
+    opsgenie_schedule_id VARCHAR(64), -- Opsgenie schedule on call for the team's namespaces
     metadata JSONB DEFAULT '{}',
     created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
     updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
@@ -107,7 +110,7 @@ CREATE TABLE clusters (
     version VARCHAR(50),
     platform VARCHAR(100), -- e.g., "VMware vSphere", "AWS", "Azure"
     region VARCHAR(100),
-    environment VARCHAR(50) DEFAULT 'production', -- production, staging, development, test
+    environment VARCHAR(50) DEFAULT 'production', -- one of organizations.settings->'environments'
     
     -- Connection settings
     auth_method VARCHAR(50) DEFAULT 'serviceaccount', -- serviceaccount, kubeconfig, oidc
@@ -124,6 +127,9 @@ CREATE TABLE clusters (
     status VARCHAR(50) DEFAULT 'active', -- active, inactive, error, syncing
     last_sync_at TIMESTAMP WITH TIME ZONE,
     sync_error TEXT,
+    sync_error_category VARCHAR(50), -- auth_error, rbac_denied, timeout, unreachable, tls_error, config_error, partial, database_error, unknown
+    consecutive_failures INTEGER NOT NULL DEFAULT 0, -- failed syncs and probes since the last success
+    last_alerted_at TIMESTAMP WITH TIME ZONE,
     
     -- Metadata
     node_count INTEGER DEFAULT 0,
@@ -139,6 +145,15 @@ CREATE TABLE clusters (
     UNIQUE(organization_id, name)
 );
 
+-- Cluster sync error history
+CREATE TABLE cluster_sync_errors (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    cluster_id UUID NOT NULL REFERENCES clusters(id) ON DELETE CASCADE,
+    category VARCHAR(50) NOT NULL,
+    message TEXT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
 -- Namespaces
 CREATE TABLE namespaces (
     id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
@@ -151,8 +166,8 @@ CREATE TABLE namespaces (
     description TEXT,
     
     -- Environment & Criticality
-    environment VARCHAR(50), -- production, staging, test, development
-    criticality VARCHAR(50) DEFAULT 'tier-3', -- tier-1, tier-2, tier-3
+    environment VARCHAR(50), -- one of organizations.settings->'environments'
+    criticality VARCHAR(50) DEFAULT 'tier-3', -- one of organizations.settings->'criticality'->'tiers'
     
     -- Ownership (multiple ownership types)
     infrastructure_owner_team_id UUID REFERENCES teams(id),
@@ -195,6 +210,106 @@ CREATE TABLE namespaces (
     UNIQUE(cluster_id, name)
 );
 
+-- RBAC inventory of namespaces, replaced by every cluster sync. One row per
+-- subject of a RoleBinding in the namespace, or of a ClusterRoleBinding
+-- that binds a service account of the namespace.
+CREATE TABLE namespace_role_bindings (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    namespace_id UUID NOT NULL REFERENCES namespaces(id) ON DELETE CASCADE,
+    binding_kind VARCHAR(50) NOT NULL, -- RoleBinding, ClusterRoleBinding
+    binding_name VARCHAR(255) NOT NULL,
+    role_kind VARCHAR(50) NOT NULL, -- Role, ClusterRole
+    role_name VARCHAR(255) NOT NULL,
+    subject_kind VARCHAR(50) NOT NULL, -- User, Group, ServiceAccount
+    subject_name VARCHAR(255) NOT NULL,
+    subject_namespace VARCHAR(255),
+    synced_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE TABLE namespace_service_accounts (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    namespace_id UUID NOT NULL REFERENCES namespaces(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    k8s_uid VARCHAR(255),
+    k8s_created_at TIMESTAMP WITH TIME ZONE,
+    synced_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    UNIQUE(namespace_id, name)
+);
+
+-- Jira issue last opened to fix a namespace's missing owner or documents
+CREATE TABLE namespace_tickets (
+    namespace_id UUID PRIMARY KEY REFERENCES namespaces(id) ON DELETE CASCADE,
+    organization_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    issue_key VARCHAR(100) NOT NULL,
+    issue_url TEXT NOT NULL,
+    reason VARCHAR(50) NOT NULL, -- orphaned, undocumented
+    status VARCHAR(100),
+    status_category VARCHAR(50), -- new, indeterminate, done
+    status_checked_at TIMESTAMP WITH TIME ZONE,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Confluence page a namespace's documentation is exported to
+CREATE TABLE namespace_confluence_pages (
+    namespace_id UUID PRIMARY KEY REFERENCES namespaces(id) ON DELETE CASCADE,
+    organization_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    page_id VARCHAR(64) NOT NULL,
+    page_url TEXT NOT NULL,
+    content_hash VARCHAR(64) NOT NULL, -- sha256 of the last exported body
+    exported_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    exported_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Git repositories holding the source of a namespace's workloads
+CREATE TABLE namespace_repositories (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    namespace_id UUID NOT NULL REFERENCES namespaces(id) ON DELETE CASCADE,
+    organization_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    url TEXT NOT NULL,
+    provider VARCHAR(20) NOT NULL, -- github, gitlab, other
+    default_branch VARCHAR(255),
+    last_commit_at TIMESTAMP WITH TIME ZONE,
+    checked_at TIMESTAMP WITH TIME ZONE, -- last read from the provider
+    check_error TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    UNIQUE(namespace_id, url)
+);
+
+-- Flux Kustomizations and HelmReleases of a namespace, replaced by every sync
+CREATE TABLE namespace_flux_resources (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    namespace_id UUID NOT NULL REFERENCES namespaces(id) ON DELETE CASCADE,
+    kind VARCHAR(50) NOT NULL, -- Kustomization, HelmRelease
+    name VARCHAR(255) NOT NULL,
+    source_kind VARCHAR(50),
+    source_name VARCHAR(255),
+    source_namespace VARCHAR(255),
+    path TEXT, -- Kustomization path or HelmRelease chart
+    revision VARCHAR(255),
+    ready VARCHAR(20) NOT NULL, -- True, False, Unknown
+    ready_reason VARCHAR(255),
+    ready_message TEXT,
+    ready_changed_at TIMESTAMP WITH TIME ZONE,
+    suspended BOOLEAN NOT NULL DEFAULT false,
+    synced_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    UNIQUE(namespace_id, kind, name)
+);
+
+-- Dashboards and other monitoring pages of a namespace
+CREATE TABLE namespace_monitoring_links (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    namespace_id UUID NOT NULL REFERENCES namespaces(id) ON DELETE CASCADE,
+    organization_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    type VARCHAR(20) NOT NULL, -- grafana, datadog, other
+    url TEXT NOT NULL,
+    missing BOOLEAN NOT NULL DEFAULT false, -- dashboard found deleted by the last check
+    checked_at TIMESTAMP WITH TIME ZONE,
+    check_error TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    UNIQUE(namespace_id, url)
+);
+
 -- ============================================
 -- DEPENDENCIES
 -- ============================================
@@ -326,8 +441,9 @@ CREATE TABLE documents (
 -- ============================================
 
 -- Audit log for all changes
+-- Partitioned by month on created_at, see create_audit_log_partition()
 CREATE TABLE audit_logs (
-    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    id UUID NOT NULL DEFAULT uuid_generate_v4(),
     organization_id UUID REFERENCES organizations(id) NOT NULL,
     
     -- Who
@@ -351,9 +467,58 @@ CREATE TABLE audit_logs (
     description TEXT,
     metadata JSONB DEFAULT '{}',
     
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (id, created_at)
+) PARTITION BY RANGE (created_at);
+
+CREATE TABLE audit_logs_default PARTITION OF audit_logs DEFAULT;
+
+-- Creates the monthly partition of audit_logs that contains the given date
+CREATE OR REPLACE FUNCTION create_audit_log_partition(p_month DATE)
+RETURNS TEXT AS $$
+DECLARE
+    start_date DATE := date_trunc('month', p_month)::DATE;
+    end_date DATE := (date_trunc('month', p_month) + INTERVAL '1 month')::DATE;
+    partition_name TEXT := 'audit_logs_' || to_char(start_date, '"y"YYYY"m"MM');
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_class WHERE relname = partition_name) THEN
+        EXECUTE format(
+            'CREATE TABLE %I PARTITION OF audit_logs FOR VALUES FROM (%L) TO (%L)',
+            partition_name, start_date, end_date
+        );
+    END IF;
+    RETURN partition_name;
+END;
+$$ LANGUAGE plpgsql;
+
+SELECT create_audit_log_partition((date_trunc('month', NOW()) + (n || ' months')::INTERVAL)::DATE)
+FROM generate_series(0, 3) AS n;
+
+-- Months of audit logs archived to object storage before their partition was
+-- dropped, one compressed NDJSON object per organization and month
+CREATE TABLE audit_log_archives (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    organization_id UUID REFERENCES organizations(id) NOT NULL,
+    month DATE NOT NULL, -- first day of the archived month
+    object_key TEXT NOT NULL,
+    entry_count BIGINT NOT NULL,
+    size_bytes BIGINT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    UNIQUE (organization_id, month)
+);
+
+-- Copy of the audit log of organizations that require immutable audit
+-- storage. Rows can only be inserted; see the audit_log_ledger triggers.
+CREATE TABLE audit_log_ledger (
+    seq BIGSERIAL PRIMARY KEY,
+    organization_id UUID REFERENCES organizations(id) NOT NULL,
+    audit_log_id UUID NOT NULL,
+    entry JSONB NOT NULL,
     created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
 );
 
+CREATE INDEX idx_audit_log_ledger_org ON audit_log_ledger(organization_id, seq);
+
 -- ============================================
 -- SETTINGS & CONFIGURATIONS
 -- ============================================
@@ -403,6 +568,75 @@ CREATE TABLE notification_templates (
     updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
 );
 
+-- Notification delivery log
+CREATE TABLE notification_deliveries (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    organization_id UUID REFERENCES organizations(id) NOT NULL,
+    event_type VARCHAR(100) NOT NULL,
+    channel VARCHAR(50) NOT NULL DEFAULT 'email',
+    recipients TEXT[] NOT NULL,
+    subject TEXT NOT NULL,
+    body TEXT NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending', -- pending, sending, sent, failed
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT,
+    next_attempt_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    sent_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Outgoing webhook subscriptions
+CREATE TABLE webhook_subscriptions (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    organization_id UUID REFERENCES organizations(id) NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    url TEXT NOT NULL,
+    secret_encrypted BYTEA NOT NULL,
+    event_types TEXT[] NOT NULL DEFAULT '{}', -- cluster.created, namespace.updated, ... or '*'
+    is_active BOOLEAN DEFAULT true,
+    created_by UUID REFERENCES users(id),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    deleted_at TIMESTAMP WITH TIME ZONE
+);
+
+-- Outgoing webhook delivery history
+CREATE TABLE webhook_deliveries (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    subscription_id UUID NOT NULL REFERENCES webhook_subscriptions(id) ON DELETE CASCADE,
+    organization_id UUID REFERENCES organizations(id) NOT NULL,
+    event_type VARCHAR(100) NOT NULL,
+    payload JSONB NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending', -- pending, sending, sent, failed
+    attempts INTEGER NOT NULL DEFAULT 0,
+    response_status INTEGER,
+    last_error TEXT,
+    next_attempt_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    delivered_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Alerts that walk a namespace's escalation path until acknowledged
+CREATE TABLE escalations (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    organization_id UUID REFERENCES organizations(id) NOT NULL,
+    namespace_id UUID REFERENCES namespaces(id) NOT NULL,
+    reason VARCHAR(100) NOT NULL, -- dependency_down
+    resource_type VARCHAR(100) NOT NULL,
+    resource_id UUID NOT NULL,
+    summary TEXT NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'open', -- open, acknowledged, resolved
+    level INTEGER NOT NULL DEFAULT 0, -- escalation levels notified so far
+    next_escalation_at TIMESTAMP WITH TIME ZONE,
+    acknowledged_by UUID REFERENCES users(id),
+    acknowledged_at TIMESTAMP WITH TIME ZONE,
+    resolved_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
 -- Scheduled reports
 CREATE TABLE scheduled_reports (
     id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
@@ -420,6 +654,45 @@ CREATE TABLE scheduled_reports (
     updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
 );
 
+-- Archives of all of an organization's data, generated in the background
+CREATE TABLE org_exports (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    organization_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    requested_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    requested_by_email VARCHAR(255) NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending', -- pending, running, completed, failed, expired
+    progress INTEGER NOT NULL DEFAULT 0, -- percent
+    step VARCHAR(100),
+    object_key TEXT,
+    size_bytes BIGINT NOT NULL DEFAULT 0,
+    error TEXT,
+    started_at TIMESTAMP WITH TIME ZONE,
+    completed_at TIMESTAMP WITH TIME ZONE,
+    expires_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Requests to delete an organization with all of its data. Rows have no
+-- foreign keys so they remain as the record of deleted organizations.
+CREATE TABLE org_deletions (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    organization_id UUID NOT NULL,
+    organization_name VARCHAR(255) NOT NULL,
+    organization_slug VARCHAR(100) NOT NULL,
+    requested_by_email VARCHAR(255) NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'previewed', -- previewed, pending, running, completed, failed
+    counts JSONB NOT NULL DEFAULT '{}', -- rows to remove per table
+    step VARCHAR(100),
+    error TEXT,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL, -- until when a preview can be confirmed
+    confirmed_at TIMESTAMP WITH TIME ZONE,
+    started_at TIMESTAMP WITH TIME ZONE,
+    completed_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
 -- ============================================
 -- INDEXES
 -- ============================================
@@ -435,6 +708,32 @@ CREATE INDEX idx_teams_parent ON teams(parent_id);
 -- Clusters
 CREATE INDEX idx_clusters_organization ON clusters(organization_id);
 CREATE INDEX idx_clusters_status ON clusters(status);
+CREATE INDEX idx_clusters_sync_error_category ON clusters(organization_id, sync_error_category) WHERE deleted_at IS NULL AND sync_error_category IS NOT NULL;
+CREATE INDEX idx_cluster_sync_errors_cluster ON cluster_sync_errors(cluster_id, created_at DESC);
+
+-- Notifications
+CREATE INDEX idx_notification_deliveries_due ON notification_deliveries(next_attempt_at) WHERE status IN ('pending', 'sending');
+CREATE INDEX idx_notification_deliveries_org ON notification_deliveries(organization_id, created_at DESC);
+CREATE UNIQUE INDEX idx_notification_templates_event ON notification_templates(COALESCE(organization_id, '00000000-0000-0000-0000-000000000000'::uuid), event_type, channel);
+
+-- Webhooks
+CREATE INDEX idx_webhook_subscriptions_org ON webhook_subscriptions(organization_id) WHERE deleted_at IS NULL;
+CREATE INDEX idx_webhook_deliveries_due ON webhook_deliveries(next_attempt_at) WHERE status IN ('pending', 'sending');
+CREATE INDEX idx_webhook_deliveries_subscription ON webhook_deliveries(subscription_id, created_at DESC);
+
+-- Organization exports
+CREATE INDEX idx_org_exports_org ON org_exports(organization_id, created_at DESC);
+CREATE UNIQUE INDEX idx_org_exports_active ON org_exports(organization_id) WHERE status IN ('pending', 'running');
+CREATE INDEX idx_org_exports_expiry ON org_exports(expires_at) WHERE status = 'completed';
+
+-- Organization deletions
+CREATE INDEX idx_org_deletions_org ON org_deletions(organization_id, created_at DESC);
+CREATE INDEX idx_org_deletions_queued ON org_deletions(updated_at) WHERE status IN ('pending', 'running', 'failed');
+
+-- Escalations
+CREATE UNIQUE INDEX idx_escalations_unresolved ON escalations(resource_type, resource_id, reason) WHERE status <> 'resolved';
+CREATE INDEX idx_escalations_due ON escalations(next_escalation_at) WHERE status = 'open';
+CREATE INDEX idx_escalations_org ON escalations(organization_id, created_at DESC);
 
 -- Namespaces
 CREATE INDEX idx_namespaces_cluster ON namespaces(cluster_id);
@@ -444,6 +743,15 @@ CREATE INDEX idx_namespaces_criticality ON namespaces(criticality);
 CREATE INDEX idx_namespaces_infrastructure_owner ON namespaces(infrastructure_owner_team_id);
 CREATE INDEX idx_namespaces_business_unit ON namespaces(business_unit_id);
 CREATE INDEX idx_namespaces_tags ON namespaces USING GIN(tags);
+CREATE INDEX idx_namespaces_name_trgm ON namespaces USING GIN (name gin_trgm_ops) WHERE deleted_at IS NULL;
+CREATE INDEX idx_namespaces_display_name_trgm ON namespaces USING GIN (display_name gin_trgm_ops) WHERE deleted_at IS NULL;
+CREATE INDEX idx_namespaces_description_trgm ON namespaces USING GIN (description gin_trgm_ops) WHERE deleted_at IS NULL;
+CREATE INDEX idx_namespaces_pod_security ON namespaces(organization_id, (k8s_labels->>'pod-security.kubernetes.io/enforce')) WHERE deleted_at IS NULL;
+CREATE INDEX idx_namespace_role_bindings_namespace ON namespace_role_bindings(namespace_id);
+CREATE INDEX idx_namespace_tickets_organization ON namespace_tickets(organization_id);
+CREATE INDEX idx_namespace_confluence_pages_organization ON namespace_confluence_pages(organization_id);
+CREATE INDEX idx_namespace_repositories_checked ON namespace_repositories(checked_at NULLS FIRST);
+CREATE INDEX idx_namespace_monitoring_links_checked ON namespace_monitoring_links(checked_at NULLS FIRST);
 
 -- Dependencies
 CREATE INDEX idx_internal_deps_source ON internal_dependencies(source_namespace_id);
@@ -484,6 +792,19 @@ CREATE TRIGGER update_namespaces_updated_at BEFORE UPDATE ON namespaces FOR EACH
 CREATE TRIGGER update_internal_dependencies_updated_at BEFORE UPDATE ON internal_dependencies FOR EACH ROW EXECUTE FUNCTION update_updated_at();
 CREATE TRIGGER update_external_dependencies_updated_at BEFORE UPDATE ON external_dependencies FOR EACH ROW EXECUTE FUNCTION update_updated_at();
 CREATE TRIGGER update_documents_updated_at BEFORE UPDATE ON documents FOR EACH ROW EXECUTE FUNCTION update_updated_at();
+CREATE TRIGGER update_webhook_subscriptions_updated_at BEFORE UPDATE ON webhook_subscriptions FOR EACH ROW EXECUTE FUNCTION update_updated_at();
+CREATE TRIGGER update_escalations_updated_at BEFORE UPDATE ON escalations FOR EACH ROW EXECUTE FUNCTION update_updated_at();
+
+-- Keep the audit log ledger append-only
+CREATE OR REPLACE FUNCTION reject_audit_log_ledger_change()
+RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_log_ledger is append-only: % is not allowed', TG_OP;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER audit_log_ledger_no_update BEFORE UPDATE OR DELETE ON audit_log_ledger FOR EACH ROW EXECUTE FUNCTION reject_audit_log_ledger_change();
+CREATE TRIGGER audit_log_ledger_no_truncate BEFORE TRUNCATE ON audit_log_ledger FOR EACH STATEMENT EXECUTE FUNCTION reject_audit_log_ledger_change();
 
 -- ============================================
 -- SEED DATA
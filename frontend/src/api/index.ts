@@ -7,7 +7,11 @@ import type {
   User,
   Cluster,
   CreateClusterRequest,
+  ClusterPreview,
+  ClusterDeletion,
   Namespace,
+  NamespaceTicket,
+  NamespaceConfluencePage,
   UpdateNamespaceRequest,
   Team,
   TeamMember,
@@ -100,6 +104,11 @@ export const clustersApi = {
     const response = await apiClient.post<ApiResponse<Cluster>>('/clusters', data)
     return response.data.data
   },
+
+  preview: async (data: CreateClusterRequest): Promise<ClusterPreview> => {
+    const response = await apiClient.post<ApiResponse<ClusterPreview>>('/clusters/preview', data)
+    return response.data.data
+  },
   
   update: async (id: string, data: Partial<CreateClusterRequest>): Promise<Cluster> => {
     const response = await apiClient.put<ApiResponse<Cluster>>(`/clusters/${id}`, data)
@@ -109,6 +118,11 @@ export const clustersApi = {
   delete: async (id: string): Promise<void> => {
     await apiClient.delete(`/clusters/${id}`)
   },
+
+  deletionPreview: async (id: string): Promise<ClusterDeletion> => {
+    const response = await apiClient.get<ApiResponse<ClusterDeletion>>(`/clusters/${id}/deletion-preview`)
+    return response.data.data
+  },
   
   sync: async (id: string): Promise<void> => {
     await apiClient.post(`/clusters/${id}/sync`)
@@ -175,6 +189,16 @@ export const namespacesApi = {
     return response.data.data
   },
   
+  createTicket: async (id: string, reason?: 'orphaned' | 'undocumented'): Promise<NamespaceTicket> => {
+    const response = await apiClient.post<ApiResponse<NamespaceTicket>>(`/namespaces/${id}/ticket`, { reason })
+    return response.data.data
+  },
+  
+  exportConfluence: async (id: string): Promise<NamespaceConfluencePage> => {
+    const response = await apiClient.post<ApiResponse<NamespaceConfluencePage>>(`/namespaces/${id}/confluence`)
+    return response.data.data
+  },
+  
   getHistory: async (id: string, limit = 50): Promise<AuditLog[]> => {
     const response = await apiClient.get<ApiResponse<AuditLog[]>>(`/namespaces/${id}/history`, {
       params: { limit },
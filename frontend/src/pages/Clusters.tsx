@@ -66,6 +66,30 @@ export default function Clusters() {
     },
   })
 
+  // Deleting a cluster also deletes its namespaces and what is attached to
+  // them, so what goes with it is confirmed first
+  const confirmDelete = async (cluster: Cluster) => {
+    const lines = [`Delete cluster ${cluster.name}?`]
+    try {
+      const preview = await clustersApi.deletionPreview(cluster.id)
+      lines.push(
+        '',
+        `This also deletes ${preview.namespaces} namespaces, ` +
+          `${preview.internal_dependencies + preview.external_dependencies} dependencies ` +
+          `and ${preview.documents} documents.`
+      )
+      if (preview.dependent_namespaces > 0) {
+        lines.push(`${preview.dependent_namespaces} namespaces of other clusters lose their dependencies on it.`)
+      }
+      lines.push('Everything can be restored from the trash.')
+    } catch {
+      // Deleting still works without the preview
+    }
+    if (confirm(lines.join('\n'))) {
+      deleteMutation.mutate(cluster.id)
+    }
+  }
+
   // Defensive: ensure clusters is always an array
   const clusters: Cluster[] = Array.isArray(data?.items) ? data.items : []
 
@@ -183,7 +207,7 @@ export default function Clusters() {
               key={cluster.id}
               cluster={cluster}
               onSync={() => syncMutation.mutate(cluster.id)}
-              onDelete={() => deleteMutation.mutate(cluster.id)}
+              onDelete={() => confirmDelete(cluster)}
               onClick={() => navigate(`/clusters/${cluster.id}`)}
             />
           ))}
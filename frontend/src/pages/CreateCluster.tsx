@@ -1,7 +1,7 @@
-import { useState } from 'react'
+import { useEffect, useState } from 'react'
 import { useNavigate, Link } from 'react-router-dom'
 import { useMutation, useQuery } from '@tanstack/react-query'
-import { ArrowLeft, Server, Shield, AlertTriangle, Upload, Eye, EyeOff } from 'lucide-react'
+import { ArrowLeft, Server, Shield, AlertTriangle, Upload, Eye, EyeOff, CheckCircle } from 'lucide-react'
 import { Button } from '@/components/ui/button'
 import { Input } from '@/components/ui/input'
 import { Label } from '@/components/ui/label'
@@ -15,7 +15,7 @@ import {
   SelectValue,
 } from '@/components/ui/select'
 import { clustersApi, teamsApi } from '@/api'
-import type { CreateClusterRequest, Team } from '@/types'
+import type { ClusterPreview, CreateClusterRequest, Team } from '@/types'
 
 export default function CreateCluster() {
   const navigate = useNavigate()
@@ -38,12 +38,28 @@ export default function CreateCluster() {
     tags: [],
   })
   const [error, setError] = useState<string | null>(null)
+  const [preview, setPreview] = useState<ClusterPreview | null>(null)
+
+  // A preview only confirms the details it was made from
+  useEffect(() => {
+    setPreview(null)
+  }, [formData, authMethod])
 
   const { data: teamsResponse } = useQuery({
     queryKey: ['teams'],
     queryFn: teamsApi.list,
   })
 
+  const previewMutation = useMutation({
+    mutationFn: (data: CreateClusterRequest) => clustersApi.preview(data),
+    onSuccess: (data) => {
+      setPreview(data)
+    },
+    onError: (err: Error) => {
+      setError(err.message || 'Failed to connect to cluster')
+    },
+  })
+
   const createMutation = useMutation({
     mutationFn: (data: CreateClusterRequest) => clustersApi.create(data),
     onSuccess: () => {
@@ -76,10 +92,13 @@ export default function CreateCluster() {
       return
     }
 
-    createMutation.mutate({
-      ...formData,
-      auth_method: authMethod,
-    })
+    // Check the cluster first, it is only created once the preview is confirmed
+    const request = { ...formData, auth_method: authMethod }
+    if (preview) {
+      createMutation.mutate(request)
+    } else {
+      previewMutation.mutate(request)
+    }
   }
 
   const handleFileUpload = (field: 'kubeconfig' | 'ca_certificate') => (e: React.ChangeEvent<HTMLInputElement>) => {
@@ -389,14 +408,80 @@ export default function CreateCluster() {
           </CardContent>
         </Card>
 
+        {/* Preview */}
+        {preview && (
+          <Card>
+            <CardHeader>
+              <CardTitle className="flex items-center gap-2">
+                <CheckCircle className="h-5 w-5 text-green-500" />
+                Cluster Preview
+              </CardTitle>
+              <CardDescription>Check this is the cluster you want to register before creating it</CardDescription>
+            </CardHeader>
+            <CardContent className="space-y-4">
+              <div className="grid gap-4 md:grid-cols-4">
+                <div>
+                  <p className="text-sm text-muted-foreground">Version</p>
+                  <p className="font-medium">{preview.server_version}</p>
+                  {preview.version_support && preview.version_support.status !== 'supported' && (
+                    <p className="text-xs text-yellow-600">{preview.version_support.status.replace(/_/g, ' ')}</p>
+                  )}
+                </div>
+                <div>
+                  <p className="text-sm text-muted-foreground">Detected Type</p>
+                  <p className="font-medium">{preview.detected_cluster_type}</p>
+                </div>
+                <div>
+                  <p className="text-sm text-muted-foreground">Nodes</p>
+                  <p className="font-medium">{preview.ready_nodes} / {preview.node_count} ready</p>
+                </div>
+                <div>
+                  <p className="text-sm text-muted-foreground">Namespaces</p>
+                  <p className="font-medium">{preview.namespace_count}</p>
+                </div>
+              </div>
+
+              {preview.namespaces.length > 0 && (
+                <div className="flex flex-wrap gap-2">
+                  {preview.namespaces.map((ns) => (
+                    <span key={ns} className="px-2 py-1 rounded bg-muted text-xs font-mono">{ns}</span>
+                  ))}
+                  {preview.namespace_count > preview.namespaces.length && (
+                    <span className="px-2 py-1 text-xs text-muted-foreground">
+                      +{preview.namespace_count - preview.namespaces.length} more
+                    </span>
+                  )}
+                </div>
+              )}
+
+              {preview.warnings.map((warning) => (
+                <div key={warning} className="p-3 bg-yellow-500/10 border border-yellow-500/20 rounded-lg flex items-start gap-2">
+                  <AlertTriangle className="h-5 w-5 text-yellow-500 mt-0.5" />
+                  <p className="text-sm text-yellow-600">{warning}</p>
+                </div>
+              ))}
+            </CardContent>
+          </Card>
+        )}
+
         {/* Actions */}
         <div className="flex justify-end gap-4">
-          <Link to="/clusters">
-            <Button type="button" variant="outline">Cancel</Button>
-          </Link>
-          <Button type="submit" disabled={createMutation.isPending}>
-            {createMutation.isPending ? 'Creating...' : 'Create Cluster'}
-          </Button>
+          {preview ? (
+            <Button type="button" variant="outline" onClick={() => setPreview(null)}>Back</Button>
+          ) : (
+            <Link to="/clusters">
+              <Button type="button" variant="outline">Cancel</Button>
+            </Link>
+          )}
+          {preview ? (
+            <Button type="submit" disabled={createMutation.isPending}>
+              {createMutation.isPending ? 'Creating...' : 'Create Cluster'}
+            </Button>
+          ) : (
+            <Button type="submit" disabled={previewMutation.isPending}>
+              {previewMutation.isPending ? 'Connecting...' : 'Preview Cluster'}
+            </Button>
+          )}
         </div>
       </form>
     </div>
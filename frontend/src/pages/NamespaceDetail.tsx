@@ -16,6 +16,9 @@ import {
   Plus,
   Upload,
   Eye,
+  Ticket,
+  BookOpen,
+  Activity,
 } from 'lucide-react'
 import { Button } from '@/components/ui/button'
 import { Card, CardContent, CardHeader, CardTitle } from '@/components/ui/card'
@@ -70,6 +73,8 @@ export default function NamespaceDetail() {
     sla_rpo: '',
     support_hours: '',
     escalation_path: '',
+    repo_urls: '',
+    monitoring_urls: '',
   })
 
   const { data: namespace, isLoading } = useQuery({
@@ -120,6 +125,21 @@ export default function NamespaceDetail() {
     },
   })
 
+  const ticketMutation = useMutation({
+    mutationFn: () => namespacesApi.createTicket(id!),
+    onSuccess: () => {
+      queryClient.invalidateQueries({ queryKey: ['namespace', id] })
+      queryClient.invalidateQueries({ queryKey: ['namespaces'] })
+    },
+  })
+
+  const confluenceMutation = useMutation({
+    mutationFn: () => namespacesApi.exportConfluence(id!),
+    onSuccess: (page) => {
+      window.open(page.page_url, '_blank', 'noopener,noreferrer')
+    },
+  })
+
   const teams = Array.isArray(teamsData) ? teamsData : []
   const businessUnits: any[] = Array.isArray(businessUnitsData) ? businessUnitsData : (businessUnitsData as any)?.items || []
 
@@ -144,6 +164,8 @@ export default function NamespaceDetail() {
         sla_rpo: namespace.sla_rpo || '',
         support_hours: namespace.support_hours || '',
         escalation_path: namespace.escalation_path || '',
+        repo_urls: (namespace.repositories || []).map((r) => r.url).join('\n'),
+        monitoring_urls: (namespace.monitoring_links || []).map((l) => l.url).join('\n'),
       })
       setIsEditOpen(true)
     }
@@ -167,6 +189,14 @@ export default function NamespaceDetail() {
       sla_rpo: editForm.sla_rpo || undefined,
       support_hours: editForm.support_hours || undefined,
       escalation_path: editForm.escalation_path || undefined,
+      // One repository per line; an empty list unlinks them all
+      repo_urls: editForm.repo_urls.split('\n').map((u) => u.trim()).filter(Boolean),
+      // One dashboard per line; the type is inferred from the URL
+      monitoring_links: editForm.monitoring_urls
+        .split('\n')
+        .map((u) => u.trim())
+        .filter(Boolean)
+        .map((url) => ({ url })),
     }
     
     // Only include UUID fields if they have valid values
@@ -199,6 +229,11 @@ export default function NamespaceDetail() {
     )
   }
 
+  // Orphaned or undocumented, without an unresolved ticket
+  const needsTicket =
+    (!namespace.infrastructure_owner_team_id || documents?.length === 0) &&
+    (!namespace.ticket || namespace.ticket.status_category === 'done')
+
   return (
     <div className="space-y-6">
       {/* Header */}
@@ -226,7 +261,35 @@ export default function NamespaceDetail() {
           <Badge className={getCriticalityColor(namespace.criticality)}>
             {namespace.criticality}
           </Badge>
+          {namespace.pod_security && (
+            <Badge
+              variant="outline"
+              title={`Pod Security Admission: audit ${namespace.pod_security.audit || 'unset'}, warn ${namespace.pod_security.warn || 'unset'}`}
+            >
+              PSA: {namespace.pod_security.enforce || 'unset'}
+            </Badge>
+          )}
+          {namespace.ticket && (
+            <a href={namespace.ticket.issue_url} target="_blank" rel="noopener noreferrer">
+              <Badge
+                variant={namespace.ticket.status_category === 'done' ? 'secondary' : 'outline'}
+                title={`Opened ${formatRelativeTime(namespace.ticket.created_at)} for ${namespace.ticket.reason} namespace`}
+              >
+                {namespace.ticket.issue_key}: {namespace.ticket.status || 'Open'}
+              </Badge>
+            </a>
+          )}
         </div>
+        {needsTicket && (
+          <Button variant="outline" onClick={() => ticketMutation.mutate()} disabled={ticketMutation.isPending}>
+            <Ticket className="mr-2 h-4 w-4" />
+            Create Jira Issue
+          </Button>
+        )}
+        <Button variant="outline" onClick={() => confluenceMutation.mutate()} disabled={confluenceMutation.isPending}>
+          <BookOpen className="mr-2 h-4 w-4" />
+          Export to Confluence
+        </Button>
         <Button onClick={handleEditClick}>
           <Edit className="mr-2 h-4 w-4" />
           Edit
@@ -265,6 +328,18 @@ export default function NamespaceDetail() {
                    teamsData?.find((t: any) => t.id === namespace.infrastructure_owner_team_id)?.name || 
                    'Atanmamış'}
                 </p>
+                {namespace.on_call?.[0] && (
+                  <p className="text-xs text-muted-foreground" title={namespace.on_call[0].schedule}>
+                    On call:{' '}
+                    {namespace.on_call[0].url ? (
+                      <a href={namespace.on_call[0].url} target="_blank" rel="noopener noreferrer" className="hover:underline">
+                        {namespace.on_call[0].name}
+                      </a>
+                    ) : (
+                      namespace.on_call[0].name
+                    )}
+                  </p>
+                )}
               </div>
             </div>
           </CardContent>
@@ -330,6 +405,72 @@ export default function NamespaceDetail() {
             </CardContent>
           </Card>
 
+          {namespace.repositories && namespace.repositories.length > 0 && (
+            <Card>
+              <CardHeader>
+                <CardTitle>Source Repositories</CardTitle>
+              </CardHeader>
+              <CardContent className="space-y-3">
+                {namespace.repositories.map((repo) => (
+                  <div key={repo.id} className="flex items-center justify-between gap-4">
+                    <a
+                      href={repo.url}
+                      target="_blank"
+                      rel="noopener noreferrer"
+                      className="flex items-center gap-2 font-medium hover:underline"
+                    >
+                      <GitBranch className="h-4 w-4" />
+                      {repo.url.replace('https://', '')}
+                    </a>
+                    <div className="flex items-center gap-2 text-sm text-muted-foreground">
+                      {repo.default_branch && <Badge variant="outline">{repo.default_branch}</Badge>}
+                      {repo.last_commit_at && <span>Last commit {formatRelativeTime(repo.last_commit_at)}</span>}
+                      {repo.check_error && (
+                        <Badge variant="destructive" title={repo.check_error}>
+                          Unreachable
+                        </Badge>
+                      )}
+                    </div>
+                  </div>
+                ))}
+              </CardContent>
+            </Card>
+          )}
+
+          <Card>
+            <CardHeader>
+              <CardTitle>Monitoring</CardTitle>
+            </CardHeader>
+            <CardContent className="space-y-3">
+              {namespace.monitoring_links && namespace.monitoring_links.length > 0 ? (
+                namespace.monitoring_links.map((link) => (
+                  <div key={link.id} className="flex items-center justify-between gap-4">
+                    <a
+                      href={link.url}
+                      target="_blank"
+                      rel="noopener noreferrer"
+                      className="flex items-center gap-2 font-medium hover:underline truncate"
+                    >
+                      <Activity className="h-4 w-4 shrink-0" />
+                      <span className="truncate">{link.url.replace(/^https?:\/\//, '')}</span>
+                    </a>
+                    <div className="flex items-center gap-2 text-sm text-muted-foreground">
+                      <Badge variant="outline" className="capitalize">{link.type}</Badge>
+                      {link.missing && <Badge variant="destructive">Dashboard deleted</Badge>}
+                      {link.check_error && (
+                        <Badge variant="secondary" title={link.check_error}>
+                          Not verified
+                        </Badge>
+                      )}
+                    </div>
+                  </div>
+                ))
+              ) : (
+                <p className="text-sm text-muted-foreground">No dashboards linked</p>
+              )}
+            </CardContent>
+          </Card>
+
           {namespace.k8s_labels && Object.keys(namespace.k8s_labels).length > 0 && (
             <Card>
               <CardHeader>
@@ -657,6 +798,24 @@ export default function NamespaceDetail() {
                     rows={3}
                   />
                 </div>
+                <div className="space-y-2 md:col-span-2">
+                  <Label>Source Repositories</Label>
+                  <Textarea
+                    value={editForm.repo_urls}
+                    onChange={(e) => setEditForm({ ...editForm, repo_urls: e.target.value })}
+                    placeholder="https://github.com/acme/payments (one per line)"
+                    rows={2}
+                  />
+                </div>
+                <div className="space-y-2 md:col-span-2">
+                  <Label>Monitoring Dashboards</Label>
+                  <Textarea
+                    value={editForm.monitoring_urls}
+                    onChange={(e) => setEditForm({ ...editForm, monitoring_urls: e.target.value })}
+                    placeholder="https://grafana.example.com/d/abc123 or https://app.datadoghq.com/dashboard/abc-def-ghi (one per line)"
+                    rows={2}
+                  />
+                </div>
               </div>
             </div>
 
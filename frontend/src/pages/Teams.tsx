@@ -47,6 +47,8 @@ export default function Teams() {
     description: '',
     contact_email: '',
     contact_slack: '',
+    pagerduty_service_id: '',
+    opsgenie_schedule_id: '',
   })
 
   const { data, isLoading, isError, refetch } = useQuery({
@@ -138,7 +140,7 @@ export default function Teams() {
   })
 
   const resetForm = () => {
-    setFormData({ name: '', description: '', contact_email: '', contact_slack: '' })
+    setFormData({ name: '', description: '', contact_email: '', contact_slack: '', pagerduty_service_id: '', opsgenie_schedule_id: '' })
     setError(null)
   }
 
@@ -155,6 +157,8 @@ export default function Teams() {
       description: team.description || '',
       contact_email: team.contact_email || '',
       contact_slack: team.contact_slack || '',
+      pagerduty_service_id: team.pagerduty_service_id || '',
+      opsgenie_schedule_id: team.opsgenie_schedule_id || '',
     })
     setError(null)
     setIsEditDialogOpen(true)
@@ -411,6 +415,24 @@ export default function Teams() {
                 onChange={(e) => setFormData({ ...formData, contact_slack: e.target.value })}
               />
             </div>
+            <div className="space-y-2">
+              <Label htmlFor="pagerduty">PagerDuty Service ID</Label>
+              <Input
+                id="pagerduty"
+                placeholder="PXXXXXX"
+                value={formData.pagerduty_service_id}
+                onChange={(e) => setFormData({ ...formData, pagerduty_service_id: e.target.value })}
+              />
+            </div>
+            <div className="space-y-2">
+              <Label htmlFor="opsgenie">Opsgenie Schedule ID</Label>
+              <Input
+                id="opsgenie"
+                placeholder="00000000-0000-0000-0000-000000000000"
+                value={formData.opsgenie_schedule_id}
+                onChange={(e) => setFormData({ ...formData, opsgenie_schedule_id: e.target.value })}
+              />
+            </div>
           </div>
           <DialogFooter>
             <Button variant="outline" onClick={() => setIsCreateDialogOpen(false)}>
@@ -468,6 +490,24 @@ export default function Teams() {
                 onChange={(e) => setFormData({ ...formData, contact_slack: e.target.value })}
               />
             </div>
+            <div className="space-y-2">
+              <Label htmlFor="edit-pagerduty">PagerDuty Service ID</Label>
+              <Input
+                id="edit-pagerduty"
+                placeholder="PXXXXXX"
+                value={formData.pagerduty_service_id}
+                onChange={(e) => setFormData({ ...formData, pagerduty_service_id: e.target.value })}
+              />
+            </div>
+            <div className="space-y-2">
+              <Label htmlFor="edit-opsgenie">Opsgenie Schedule ID</Label>
+              <Input
+                id="edit-opsgenie"
+                placeholder="00000000-0000-0000-0000-000000000000"
+                value={formData.opsgenie_schedule_id}
+                onChange={(e) => setFormData({ ...formData, opsgenie_schedule_id: e.target.value })}
+              />
+            </div>
           </div>
           <DialogFooter>
             <Button variant="outline" onClick={() => setIsEditDialogOpen(false)}>
@@ -69,6 +69,8 @@ export interface Team {
   team_type: 'organization' | 'department' | 'team'
   contact_email?: string
   contact_slack?: string
+  pagerduty_service_id?: string
+  opsgenie_schedule_id?: string
   member_count?: number
   created_at: string
   updated_at: string
@@ -151,6 +153,34 @@ export interface CreateClusterRequest {
   tags?: string[]
 }
 
+export interface VersionSupport {
+  minor_version: string
+  status: 'supported' | 'approaching_eol' | 'end_of_life' | 'unknown'
+  end_of_life?: string
+  days_remaining?: number
+}
+
+// What a cluster's credentials show of it before it is created
+export interface ClusterPreview {
+  server_version: string
+  version_support?: VersionSupport
+  detected_cluster_type: string
+  node_count: number
+  ready_nodes: number
+  namespace_count: number
+  namespaces: string[]
+  warnings: string[]
+}
+
+// What deleting a cluster also deletes
+export interface ClusterDeletion {
+  namespaces: number
+  internal_dependencies: number
+  external_dependencies: number
+  documents: number
+  dependent_namespaces: number
+}
+
 // ============================================
 // Namespace Types
 // ============================================
@@ -197,6 +227,84 @@ export interface Namespace {
   business_unit?: BusinessUnit
   document_count?: number
   dependency_count?: number
+  pod_security?: PodSecurity
+  ticket?: NamespaceTicket
+  repositories?: SourceRepository[]
+  monitoring_links?: MonitoringLink[]
+  on_call?: OnCallResponder[]
+}
+
+// Pod Security Admission levels from the pod-security.kubernetes.io labels;
+// empty when the cluster default applies
+export interface PodSecurity {
+  enforce: string
+  enforce_version?: string
+  audit: string
+  audit_version?: string
+  warn: string
+  warn_version?: string
+}
+
+// Jira issue opened to fix a namespace's missing owner or documents
+export interface NamespaceTicket {
+  namespace_id: string
+  issue_key: string
+  issue_url: string
+  reason: 'orphaned' | 'undocumented'
+  status?: string
+  status_category?: 'new' | 'indeterminate' | 'done'
+  status_checked_at?: string
+  created_at: string
+}
+
+export type MonitoringLinkType = 'grafana' | 'datadog' | 'other'
+
+// A dashboard or other monitoring page of a namespace. Grafana and Datadog
+// dashboards are checked for existence; missing is set when the last check
+// found the dashboard deleted.
+export interface MonitoringLink {
+  id: string
+  namespace_id: string
+  type: MonitoringLinkType
+  url: string
+  missing: boolean
+  checked_at?: string
+  check_error?: string
+  created_at: string
+}
+
+// Git repository holding a namespace's source; branch and last commit are
+// read from GitHub or GitLab
+export interface SourceRepository {
+  id: string
+  namespace_id: string
+  url: string
+  provider: 'github' | 'gitlab' | 'other'
+  default_branch?: string
+  last_commit_at?: string
+  checked_at?: string
+  check_error?: string
+  created_at: string
+}
+
+// Confluence page a namespace's documentation is exported to
+export interface NamespaceConfluencePage {
+  namespace_id: string
+  page_id: string
+  page_url: string
+  exported_by?: string
+  exported_at: string
+}
+
+// Who is on call for a team's PagerDuty service or Opsgenie schedule, by
+// escalation level
+export interface OnCallResponder {
+  escalation_level: number
+  name: string
+  email?: string
+  url?: string
+  schedule?: string
+  until?: string
 }
 
 export interface UpdateNamespaceRequest {
@@ -221,6 +329,8 @@ export interface UpdateNamespaceRequest {
   escalation_path?: string
   tags?: string[]
   custom_fields?: Record<string, unknown>
+  repo_urls?: string[]
+  monitoring_links?: { type?: MonitoringLinkType; url: string }[]
 }
 
 // ============================================